@@ -1,11 +1,18 @@
 package bfv
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -68,9 +75,14 @@ func TestBFV(t *testing.T) {
 		for _, testSet := range []func(testctx *testContext, t *testing.T){
 			testParameters,
 			testEncoder,
+			testBytesEncoder,
 			testEvaluator,
 			testEvaluatorKeySwitch,
 			testEvaluatorRotate,
+			testVector,
+			testMasking,
+			testBGVConverter,
+			testStreamEncoder,
 			testMarshaller,
 		} {
 			testSet(testctx, t)
@@ -190,7 +202,7 @@ func testEncoder(testctx *testContext, t *testing.T) {
 	t.Run(testString("Encoder/Encode&Decode/RingT/Int", testctx.params), func(t *testing.T) {
 
 		T := testctx.params.T()
-		THalf := T >> 1
+		THalf := (T + 1) >> 1
 		coeffs := testctx.uSampler.ReadNew()
 		coeffsInt := make([]int64, len(coeffs.Coeffs[0]))
 		for i, c := range coeffs.Coeffs[0] {
@@ -216,7 +228,7 @@ func testEncoder(testctx *testContext, t *testing.T) {
 	t.Run(testString("Encoder/Encode&Decode/RingQ/Int", testctx.params), func(t *testing.T) {
 
 		T := testctx.params.T()
-		THalf := T >> 1
+		THalf := (T + 1) >> 1
 		coeffs := testctx.uSampler.ReadNew()
 		coeffsInt := make([]int64, len(coeffs.Coeffs[0]))
 		for i, c := range coeffs.Coeffs[0] {
@@ -238,6 +250,107 @@ func testEncoder(testctx *testContext, t *testing.T) {
 		values, plaintext := newTestVectorsMul(testctx, t)
 		verifyTestVectors(testctx, nil, values, plaintext, t)
 	})
+
+	t.Run(testString("Encoder/Encode&Decode/RingQ/Int/Reduction", testctx.params), func(t *testing.T) {
+
+		T := int64(testctx.params.T())
+		coeffs := testctx.uSampler.ReadNew()
+		coeffsInt := make([]int64, len(coeffs.Coeffs[0]))
+		coeffsWant := make([]int64, len(coeffs.Coeffs[0]))
+		for i, c := range coeffs.Coeffs[0] {
+			// values outside of the centered interval must wrap around modulo T
+			coeffsInt[i] = int64(c) - T*int64(i-len(coeffsInt)/2)
+			coeffsWant[i] = int64(c)
+			if coeffsWant[i] >= (T+1)>>1 {
+				coeffsWant[i] -= T
+			}
+		}
+		plaintext := NewPlaintext(testctx.params)
+		testctx.encoder.EncodeInt(coeffsInt, plaintext)
+		coeffsTest := testctx.encoder.DecodeIntNew(plaintext)
+
+		require.True(t, utils.EqualSliceInt64(coeffsWant, coeffsTest))
+	})
+
+	t.Run(testString("Encoder/Encode&Decode/BigInt", testctx.params), func(t *testing.T) {
+
+		T := new(big.Int).SetUint64(testctx.params.T())
+		coeffs := testctx.uSampler.ReadNew()
+		coeffsBig := make([]*big.Int, len(coeffs.Coeffs[0]))
+		for i, c := range coeffs.Coeffs[0] {
+			// c + (i - N/2) * T * 2^64, which is congruent to c modulo T
+			coeffsBig[i] = new(big.Int).Lsh(T, 64)
+			coeffsBig[i].Mul(coeffsBig[i], big.NewInt(int64(i-len(coeffsBig)/2)))
+			coeffsBig[i].Add(coeffsBig[i], new(big.Int).SetUint64(c))
+		}
+
+		for _, plaintext := range []interface{}{NewPlaintextRingT(testctx.params), NewPlaintext(testctx.params), NewPlaintextMul(testctx.params)} {
+			switch pt := plaintext.(type) {
+			case *PlaintextRingT:
+				testctx.encoder.EncodeBigIntRingT(coeffsBig, pt)
+			case *Plaintext:
+				testctx.encoder.EncodeBigInt(coeffsBig, pt)
+			case *PlaintextMul:
+				testctx.encoder.EncodeBigIntMul(coeffsBig, pt)
+			}
+
+			coeffsTest := testctx.encoder.DecodeBigIntNew(plaintext)
+			for i := range coeffsTest {
+				require.Equal(t, coeffs.Coeffs[0][i], coeffsTest[i].Uint64())
+			}
+		}
+	})
+}
+
+func testBytesEncoder(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("BytesEncoder/InvalidBytesPerSlot", testctx.params), func(t *testing.T) {
+		_, err := NewBytesEncoder(testctx.params, 0)
+		require.Error(t, err)
+		_, err = NewBytesEncoder(testctx.params, 3)
+		require.Error(t, err)
+	})
+
+	for _, bytesPerSlot := range []int{1, 2} {
+
+		ecd, err := NewBytesEncoder(testctx.params, bytesPerSlot)
+		require.NoError(t, err)
+
+		t.Run(testString(fmt.Sprintf("BytesEncoder/BytesPerSlot=%d/Encode&Decode", bytesPerSlot), testctx.params), func(t *testing.T) {
+			for _, n := range []int{0, 1, 3, 255, ecd.MaxLen()} {
+				data := make([]byte, n)
+				testctx.prng.Clock(data)
+
+				for _, pt := range []interface{}{NewPlaintextRingT(testctx.params), NewPlaintext(testctx.params), NewPlaintextMul(testctx.params)} {
+					require.NoError(t, ecd.EncodeBytes(data, pt))
+					have, err := ecd.DecodeBytes(pt)
+					require.NoError(t, err)
+					require.True(t, utils.EqualSliceUint8(data, have))
+				}
+			}
+
+			require.Error(t, ecd.EncodeBytes(make([]byte, ecd.MaxLen()+1), NewPlaintext(testctx.params)))
+		})
+
+		t.Run(testString(fmt.Sprintf("BytesEncoder/BytesPerSlot=%d/Encrypt&Decrypt", bytesPerSlot), testctx.params), func(t *testing.T) {
+			data := []byte("lattigo: lattice-based multiparty homomorphic encryption library in Go")
+			pt, err := ecd.EncodeBytesNew(data)
+			require.NoError(t, err)
+			ct := testctx.encryptorPk.EncryptNew(pt)
+			have, err := ecd.DecodeBytes(testctx.decryptor.DecryptNew(ct))
+			require.NoError(t, err)
+			require.Equal(t, string(data), string(have))
+		})
+
+		t.Run(testString(fmt.Sprintf("BytesEncoder/BytesPerSlot=%d/InvalidFraming", bytesPerSlot), testctx.params), func(t *testing.T) {
+			slots := make([]uint64, testctx.params.N())
+			for i := 0; i < ecd.HeaderSlots(); i++ {
+				slots[i] = 1<<(8*bytesPerSlot) - 1
+			}
+			_, err := ecd.FromSlots(slots)
+			require.Error(t, err)
+		})
+	}
 }
 
 func testEvaluator(testctx *testContext, t *testing.T) {
@@ -447,6 +560,60 @@ func testEvaluator(testctx *testContext, t *testing.T) {
 		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
 	})
 
+	t.Run(testString("Evaluator/DivScalar", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		// Multiplying then dividing by the same scalar gives back the input
+		testctx.evaluator.MulScalar(ciphertext1, 37, ciphertext1)
+		ciphertext2 := testctx.evaluator.DivScalarNew(ciphertext1, 37)
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext2, t)
+
+		// Average of n values whose sum is a multiple of n
+		n := uint64(5)
+		values := make([]uint64, testctx.params.N())
+		for i := range values {
+			values[i] = uint64(i)
+		}
+		pt := NewPlaintext(testctx.params)
+		testctx.encoder.EncodeUint(values, pt)
+		ciphertext3 := testctx.encryptorPk.EncryptNew(pt)
+		testctx.evaluator.MulScalar(ciphertext3, n, ciphertext3)
+		testctx.evaluator.DivScalar(ciphertext3, n, ciphertext3)
+		require.Equal(t, values, testctx.encoder.DecodeUintNew(testctx.decryptor.DecryptNew(ciphertext3)))
+
+		require.Panics(t, func() { testctx.evaluator.DivScalar(ciphertext1, testctx.params.T(), ciphertext1) })
+	})
+
+	t.Run(testString("Evaluator/EncodeUintInverseMul", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		divisors := make([]uint64, testctx.params.N())
+		for i := range divisors {
+			divisors[i] = uint64(i%7) + 1
+		}
+
+		scaled := make([]uint64, testctx.params.N())
+		T := testctx.params.T()
+		for i := range scaled {
+			scaled[i] = ring.BRedAdd(values1.Coeffs[0][i]*divisors[i], T, ring.BRedParams(T))
+		}
+
+		pt := NewPlaintext(testctx.params)
+		testctx.encoder.EncodeUint(scaled, pt)
+		ciphertext1 = testctx.encryptorPk.EncryptNew(pt)
+
+		ptInv := NewPlaintextMul(testctx.params)
+		testctx.encoder.EncodeUintInverseMul(divisors, ptInv)
+		testctx.evaluator.Mul(ciphertext1, ptInv, ciphertext1)
+
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+
+		divisors[3] = 0
+		require.Panics(t, func() { testctx.encoder.EncodeUintInverseMul(divisors, ptInv) })
+	})
+
 	t.Run(testString("Evaluator/MulScalarNew", testctx.params), func(t *testing.T) {
 
 		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
@@ -457,6 +624,45 @@ func testEvaluator(testctx *testContext, t *testing.T) {
 		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
 	})
 
+	t.Run(testString("Evaluator/MulScalarThenAdd", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, _, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		testctx.evaluator.MulScalarThenAdd(ciphertext1, 37, ciphertext2)
+		testctx.ringT.MulScalarAndAdd(values1, 37, values2)
+
+		verifyTestVectors(testctx, testctx.decryptor, values2, ciphertext2, t)
+	})
+
+	t.Run(testString("Evaluator/LinearCombination", testctx.params), func(t *testing.T) {
+
+		n := 5
+		consts := []uint64{1, 7, 37, testctx.params.T() - 1, 0}
+		cts := make([]*Ciphertext, n)
+		values := testctx.ringT.NewPoly()
+
+		for i := range cts {
+			var valuesi *ring.Poly
+			valuesi, _, cts[i] = newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+			testctx.ringT.MulScalarAndAdd(valuesi, consts[i], values)
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, values, testctx.evaluator.LinearCombinationNew(cts, consts), t)
+
+		// The receiver is one of the inputs
+		// The receiver of a larger degree is resized
+		ctOut := testctx.evaluator.MulNew(cts[0], cts[1])
+		testctx.evaluator.LinearCombination(cts, consts, ctOut)
+		require.Equal(t, 1, ctOut.Degree())
+		verifyTestVectors(testctx, testctx.decryptor, values, ctOut, t)
+
+		testctx.evaluator.LinearCombination(cts, consts, cts[2])
+		verifyTestVectors(testctx, testctx.decryptor, values, cts[2], t)
+
+		require.Panics(t, func() { testctx.evaluator.LinearCombination(cts, consts[1:], cts[0]) })
+	})
+
 	t.Run(testString("Evaluator/Mul/op1=Ciphertext/op2=Ciphertext", testctx.params), func(t *testing.T) {
 
 		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
@@ -534,6 +740,109 @@ func testEvaluator(testctx *testContext, t *testing.T) {
 		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
 	})
 
+	t.Run(testString("Evaluator/MulPlainThenAdd/op2=PlaintextMul", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, plaintext2 := newTestVectorsMul(testctx, t)
+		values3, _, ciphertext3 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		testctx.evaluator.MulPlainThenAdd(ciphertext1, plaintext2, ciphertext3)
+		testctx.ringT.MulCoeffsAndAdd(values1, values2, values3)
+
+		verifyTestVectors(testctx, testctx.decryptor, values3, ciphertext3, t)
+	})
+
+	t.Run(testString("Evaluator/MulPlainThenAdd/op2=PlaintextRingT", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, plaintext2 := newTestVectorsRingT(testctx, t)
+
+		testctx.evaluator.MulPlainThenAdd(ciphertext1, plaintext2, ciphertext1)
+		testctx.ringT.MulCoeffsAndAdd(values1, values2, values1)
+
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
+	t.Run(testString("Evaluator/Mul/PlaintextCache", testctx.params), func(t *testing.T) {
+
+		cache := NewPlaintextCache(testctx.params)
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, plaintext2, _ := newTestVectorsRingQ(testctx, nil, t)
+		values3, plaintext3 := newTestVectorsRingT(testctx, t)
+
+		ptMul2 := cache.Get(plaintext2)
+		require.True(t, ptMul2 == cache.Get(plaintext2))
+		ptMul3 := cache.Get(plaintext3)
+		require.Equal(t, 2, cache.Len())
+
+		ciphertext2 := testctx.evaluator.MulNew(ciphertext1, cache.Get(plaintext2))
+		testctx.evaluator.MulPlainThenAdd(ciphertext1, ptMul3, ciphertext2)
+		testctx.ringT.MulCoeffs(values2, values1, values2)
+		testctx.ringT.MulCoeffsAndAdd(values3, values1, values2)
+
+		verifyTestVectors(testctx, testctx.decryptor, values2, ciphertext2, t)
+
+		cache.Delete(plaintext2)
+		require.Equal(t, 1, cache.Len())
+		require.False(t, ptMul2 == cache.Get(plaintext2))
+
+		cache.Reset()
+		require.Equal(t, 0, cache.Len())
+	})
+
+	t.Run(testString("Evaluator/Tracing", testctx.params), func(t *testing.T) {
+
+		var events []OperationEvent
+		eval := NewTracingEvaluator(testctx.evaluator, func(event OperationEvent) {
+			events = append(events, event)
+		})
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, plaintext2 := newTestVectorsRingT(testctx, t)
+
+		receiver := eval.MulNew(ciphertext1, ciphertext1)
+		eval.Add(receiver, plaintext2, receiver)
+		testctx.ringT.MulCoeffs(values1, values1, values1)
+		testctx.ringT.Add(values1, values2, values1)
+
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+
+		require.Len(t, events, 2)
+
+		require.Equal(t, "MulNew", events[0].Operation)
+		require.Equal(t, []int{1, 1}, events[0].InputDegrees)
+		require.Equal(t, 2, events[0].OutputDegree)
+		require.True(t, events[0].Output == receiver)
+
+		require.Equal(t, "Add", events[1].Operation)
+		require.Equal(t, []int{2, 0}, events[1].InputDegrees)
+		require.True(t, events[1].Inputs[0] == events[0].Output)
+
+		eval.ShallowCopy().Neg(ciphertext1, ciphertext1)
+		require.Len(t, events, 3)
+	})
+
+	t.Run(testString("Evaluator/Metrics", testctx.params), func(t *testing.T) {
+
+		metrics := new(testMetrics)
+		eval := NewMetricsEvaluator(testctx.evaluator, metrics, func(ct *Ciphertext) float64 {
+			return NoiseBudget(testctx.params, testctx.encoder, testctx.decryptor, ct)
+		})
+
+		_, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		fresh := NoiseBudget(testctx.params, testctx.encoder, testctx.decryptor, ciphertext1)
+		require.Greater(t, fresh, 0.0)
+
+		receiver := eval.MulNew(ciphertext1, ciphertext1)
+		eval.Add(receiver, ciphertext1, receiver)
+
+		require.Equal(t, []string{"bfv.Evaluator/MulNew", "bfv.Evaluator/Add"}, metrics.operations)
+		require.Len(t, metrics.budgets, 2)
+		require.Less(t, metrics.budgets[0], fresh)
+		require.Greater(t, metrics.budgets[1], 0.0)
+	})
+
 	t.Run(testString("Evaluator/Mul/Relinearize", testctx.params), func(t *testing.T) {
 
 		if testctx.params.PCount() == 0 {
@@ -553,6 +862,88 @@ func testEvaluator(testctx *testContext, t *testing.T) {
 		testctx.evaluator.Relinearize(receiver, receiver)
 		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
 	})
+
+	t.Run(testString("Evaluator/Mul/Relinearize/Parallel", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		eval := testctx.evaluator.WithParallelism(3)
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, _, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		// Degree one tensoring
+		want := testctx.evaluator.MulNew(ciphertext1, ciphertext2)
+		have := eval.MulNew(ciphertext1, ciphertext2)
+		require.Equal(t, want.Value, have.Value)
+
+		// Square
+		require.Equal(t, testctx.evaluator.MulNew(ciphertext1, ciphertext1).Value, eval.MulNew(ciphertext1, ciphertext1).Value)
+
+		// Larger degree tensoring
+		want3 := testctx.evaluator.MulNew(want, ciphertext1)
+		have3 := eval.ShallowCopy().MulNew(have, ciphertext1)
+		require.Equal(t, want3.Value, have3.Value)
+
+		// Relinearization
+		want = testctx.evaluator.RelinearizeNew(want)
+		eval.Relinearize(have, have)
+		require.Equal(t, want.Value, have.Value)
+
+		testctx.ringT.MulCoeffs(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, have, t)
+
+		// Disabled parallelization
+		require.Equal(t, want.Value, eval.WithParallelism(1).RelinearizeNew(eval.MulNew(ciphertext1, ciphertext2)).Value)
+	})
+}
+
+func testBGVConverter(testctx *testContext, t *testing.T) {
+
+	conv, err := NewBGVConverter(testctx.params)
+	require.NoError(t, err)
+
+	verifyBGV := func(values *ring.Poly, ct *BGVCiphertext) {
+		ptRt := NewPlaintextRingT(testctx.params)
+		conv.DecodeBGV(testctx.decryptor.DecryptNew(&Ciphertext{ct.Ciphertext}), ct.Scale, ptRt)
+		verifyTestVectors(testctx, nil, values, ptRt, t)
+	}
+
+	t.Run(testString("BGVConverter/BFVToBGV", testctx.params), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		ctBGV := conv.BFVToBGVNew(ciphertext)
+		require.Equal(t, conv.Scale(), ctBGV.Scale)
+
+		verifyBGV(values, ctBGV)
+	})
+
+	t.Run(testString("BGVConverter/BGVToBFV", testctx.params), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		ctBGV := conv.BFVToBGVNew(ciphertext)
+
+		// Multiplying a BGV ciphertext by a constant multiplies its scale
+		ctBGV.Scale = ctBGV.Scale * 3 % testctx.params.T()
+		for i := range ctBGV.Value {
+			testctx.ringQ.MulScalar(ctBGV.Value[i], 3, ctBGV.Value[i])
+		}
+
+		verifyBGV(values, ctBGV)
+
+		verifyTestVectors(testctx, testctx.decryptor, values, conv.BGVToBFVNew(ctBGV), t)
+
+		// The converted ciphertext supports the BFV operations
+		ctOut := NewCiphertext(testctx.params, 1)
+		conv.BGVToBFV(ctBGV, ctOut)
+		testctx.evaluator.Add(ctOut, ciphertext, ctOut)
+		testctx.ringT.Add(values, values, values)
+		verifyTestVectors(testctx, testctx.decryptor, values, ctOut, t)
+	})
 }
 
 func testEvaluatorKeySwitch(testctx *testContext, t *testing.T) {
@@ -629,6 +1020,19 @@ func testEvaluatorRotate(testctx *testContext, t *testing.T) {
 		}
 	})
 
+	t.Run(testString("Evaluator/RotateColumnsHoisted", testctx.params), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		receivers := evaluator.RotateColumnsHoistedNew(ciphertext, append(rots, 0))
+		require.Len(t, receivers, len(rots)+1)
+
+		for n, receiver := range receivers {
+			valuesWant := utils.RotateUint64Slots(values.Coeffs[0], n)
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, receiver, t)
+		}
+	})
+
 	rotkey = testctx.kgen.GenRotationKeysForInnerSum(testctx.sk)
 	evaluator = evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
 
@@ -649,6 +1053,283 @@ func testEvaluatorRotate(testctx *testContext, t *testing.T) {
 		}
 		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
 	})
+
+	rotkey = testctx.kgen.GenRotationKeys(testctx.params.GaloisElementsForDotProduct(), testctx.sk)
+	evaluator = evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
+
+	dotProduct := func(values0, values1 *ring.Poly) *ring.Poly {
+		T := testctx.params.T()
+		var sum uint64
+		for i := range values0.Coeffs[0] {
+			sum = (sum + ring.BRed(values0.Coeffs[0][i], values1.Coeffs[0][i], T, testctx.ringT.BredParams[0])) % T
+		}
+		res := testctx.ringT.NewPoly()
+		for i := range res.Coeffs[0] {
+			res.Coeffs[0][i] = sum
+		}
+		return res
+	}
+
+	t.Run(testString("Evaluator/DotProduct/op1=Ciphertext/op2=PlaintextMul", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, plaintext2 := newTestVectorsMul(testctx, t)
+
+		ciphertext := evaluator.DotProductNew(ciphertext1, plaintext2)
+
+		verifyTestVectors(testctx, testctx.decryptor, dotProduct(values1, values2), ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/DotProduct/op1=Ciphertext/op2=Ciphertext", testctx.params), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		values2, _, ciphertext2 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		evaluator.DotProduct(ciphertext1, ciphertext2, ciphertext1)
+
+		verifyTestVectors(testctx, testctx.decryptor, dotProduct(values1, values2), ciphertext1, t)
+	})
+}
+
+func testVector(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Vector", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		params := testctx.params
+		slots := params.N()
+		T := params.T()
+
+		// A full vector of two ciphertexts and a vector ending in the second row of its last ciphertext
+		for _, length := range []int{2 * slots, slots + slots/2 + 3} {
+
+			values0 := make([]uint64, length)
+			values1 := make([]uint64, length)
+			for i := range values0 {
+				values0[i] = utils.RandUint64() % T
+				values1[i] = utils.RandUint64() % T
+			}
+
+			v0 := EncryptVectorNew(params, testctx.encoder, testctx.encryptorSk, values0)
+			v1 := EncryptVectorNew(params, testctx.encoder, testctx.encryptorSk, values1)
+			require.Len(t, v0.Value, (length+slots-1)/slots)
+			require.Equal(t, values0, DecryptVector(params, testctx.encoder, testctx.decryptor, v0))
+
+			// verifyVector checks the values and the zero padding of each ciphertext of the Vector
+			verifyVector := func(want []uint64, v *Vector) {
+				require.Equal(t, len(want), v.Len)
+				for i, ct := range v.Value {
+					chunk := make([]uint64, slots)
+					copy(chunk, want[i*slots:utils.MinInt((i+1)*slots, len(want))])
+					verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{chunk}}, ct, t)
+				}
+			}
+
+			ks := []int{1, -5, slots >> 1, slots, slots + 7}
+
+			rotKey := testctx.kgen.GenRotationKeys(append(params.GaloisElementsForVectorRotate(length, ks), params.GaloisElementsForVectorInnerSum()...), testctx.sk)
+			ve := NewVectorEvaluator(params, testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotKey}), testctx.encoder)
+
+			want := make([]uint64, length)
+
+			for i := range want {
+				want[i] = (values0[i] + values1[i]) % T
+			}
+			verifyVector(want, ve.AddNew(v0, v1))
+
+			for i := range want {
+				want[i] = (values0[i] + T - values1[i]) % T
+			}
+			verifyVector(want, ve.SubNew(v0, v1))
+
+			for i := range want {
+				want[i] = ring.BRed(values0[i], values1[i], T, testctx.ringT.BredParams[0])
+			}
+			verifyVector(want, ve.RelinearizeNew(ve.MulNew(v0, v1)))
+
+			for _, k := range ks {
+				for i := range want {
+					want[i] = values0[((i+k)%length+length)%length]
+				}
+				verifyVector(want, ve.RotateNew(v0, k))
+			}
+
+			var sum uint64
+			for i := range values0 {
+				sum = (sum + values0[i]) % T
+			}
+
+			sums := make([]uint64, slots)
+			for i := range sums {
+				sums[i] = sum
+			}
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{sums}}, ve.InnerSumNew(v0), t)
+
+			// The in-place rotation can alias its input
+			ve.Rotate(v1, 1, v1)
+			for i := range want {
+				want[i] = values1[(i+1)%length]
+			}
+			verifyVector(want, v1)
+		}
+	})
+}
+
+func testMasking(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Masking", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		params := testctx.params
+		slots := params.N()
+
+		values0, _, ciphertext0 := newTestVectorsRingQ(testctx, testctx.encryptorSk, t)
+		values1, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorSk, t)
+
+		rotKey := testctx.kgen.GenRotationKeys(params.GaloisElementsForDuplicateSlot(), testctx.sk)
+		masker := NewMasker(params, testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotKey}), testctx.encoder)
+
+		// The range spans both rows
+		start, end := slots/4, slots/2+3
+
+		want := make([]uint64, slots)
+		copy(want[start:end], values0.Coeffs[0][start:end])
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, masker.MaskRangeNew(ciphertext0, start, end), t)
+
+		index := slots/2 + 1
+		for i := range want {
+			want[i] = values0.Coeffs[0][index]
+		}
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, masker.DuplicateSlotNew(ciphertext0, index), t)
+
+		copy(want, values0.Coeffs[0])
+		copy(want[start:], values1.Coeffs[0][start:])
+		ctOut := masker.MergeNew([]*Ciphertext{ciphertext0, ciphertext1}, [][]uint64{RangeMask(slots, 0, start), RangeMask(slots, start, slots)})
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, ctOut, t)
+	})
+}
+
+func testStreamEncoder(testctx *testContext, t *testing.T) {
+
+	params := testctx.params
+	slots := params.N()
+	fields := 3
+
+	for _, layout := range []SlotLayout{
+		{Order: RowMajor, Fields: fields},
+		{Order: RowMajor, Fields: fields, Stride: 4},
+		{Order: ColumnMajor, Fields: fields},
+		{Order: ColumnMajor, Fields: fields, Stride: 5},
+	} {
+
+		t.Run(testString(fmt.Sprintf("StreamEncoder/Order=%d/Stride=%d", layout.Order, layout.Stride), params), func(t *testing.T) {
+
+			se, err := NewStreamEncoder(params, testctx.encoder, layout)
+			require.NoError(t, err)
+
+			// Two full batches and a partial one, written on a reader
+			records := make([][]uint64, 2*se.RecordsPerBatch()+5)
+			buf := new(bytes.Buffer)
+			for i := range records {
+				records[i] = make([]uint64, fields)
+				for j := range records[i] {
+					records[i][j] = utils.RandUint64() % params.T()
+					binary.Write(buf, binary.LittleEndian, records[i][j])
+				}
+			}
+
+			var batch int
+			err = se.Encrypt(NewReaderSource(buf, fields), testctx.encryptorSk, func(ct *Ciphertext, n int) error {
+
+				first := batch * se.RecordsPerBatch()
+				require.Equal(t, utils.MinInt(se.RecordsPerBatch(), len(records)-first), n)
+
+				want := make([]uint64, slots)
+				for r := 0; r < n; r++ {
+					for f, v := range records[first+r] {
+						want[layout.Slot(r, f, slots)] = v
+					}
+				}
+
+				require.Equal(t, want, testctx.encoder.DecodeUintNew(testctx.decryptor.DecryptNew(ct)))
+				batch++
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 3, batch)
+		})
+	}
+
+	t.Run(testString("StreamEncoder/EncryptTo", params), func(t *testing.T) {
+
+		se, err := NewStreamEncoder(params, testctx.encoder, SlotLayout{Order: ColumnMajor, Fields: fields})
+		require.NoError(t, err)
+
+		values := new(bytes.Buffer)
+		for i := 0; i < fields*(se.RecordsPerBatch()+5); i++ {
+			binary.Write(values, binary.LittleEndian, utils.RandUint64()%params.T())
+		}
+		want := append([]byte{}, values.Bytes()...)
+
+		cts := new(bytes.Buffer)
+		records, err := se.EncryptTo(cts, NewReaderSource(values, fields), testctx.encryptorPk)
+		require.NoError(t, err)
+		require.Equal(t, se.RecordsPerBatch()+5, records)
+		stream := append([]byte{}, cts.Bytes()...)
+
+		have := new(bytes.Buffer)
+		records, err = se.DecryptFrom(have, cts, testctx.decryptor)
+		require.NoError(t, err)
+		require.Equal(t, se.RecordsPerBatch()+5, records)
+		require.Equal(t, want, have.Bytes())
+
+		// Truncated stream
+		_, err = se.DecryptFrom(new(bytes.Buffer), bytes.NewReader(stream[:len(stream)-1]), testctx.decryptor)
+		require.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+		// Ciphertext larger than a ciphertext at the maximum level, or whose size overflows an int64
+		for _, size := range []uint64{uint64(len(stream)), 1 << 63} {
+			invalid := append([]byte{}, stream...)
+			binary.BigEndian.PutUint64(invalid[8:], size)
+			_, err = se.DecryptFrom(new(bytes.Buffer), bytes.NewReader(invalid), testctx.decryptor)
+			require.Error(t, err)
+			require.False(t, errors.Is(err, io.ErrUnexpectedEOF))
+		}
+
+		// Invalid number of records
+		binary.BigEndian.PutUint64(stream, uint64(se.RecordsPerBatch()+1))
+		_, err = se.DecryptFrom(new(bytes.Buffer), bytes.NewReader(stream), testctx.decryptor)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("StreamEncoder/Errors", params), func(t *testing.T) {
+
+		_, err := NewStreamEncoder(params, testctx.encoder, SlotLayout{Order: RowMajor, Fields: fields, Stride: 2})
+		require.Error(t, err)
+
+		_, err = NewStreamEncoder(params, testctx.encoder, SlotLayout{Order: ColumnMajor, Fields: fields, Stride: slots})
+		require.Error(t, err)
+
+		se, err := NewStreamEncoder(params, testctx.encoder, SlotLayout{Order: RowMajor, Fields: fields})
+		require.NoError(t, err)
+
+		// Truncated record
+		_, _, err = se.Next(NewReaderSource(bytes.NewReader(make([]byte, 8*fields+1)), fields))
+		require.Equal(t, io.ErrUnexpectedEOF, err)
+
+		// Record of invalid length
+		ch := make(chan []uint64, 1)
+		ch <- make([]uint64, fields+1)
+		close(ch)
+		_, _, err = se.Next(NewChanSource(ch))
+		require.Error(t, err)
+	})
 }
 
 func testMarshaller(testctx *testContext, t *testing.T) {
@@ -660,6 +1341,13 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 		err = p.UnmarshalBinary(bytes)
 		assert.Nil(t, err)
 		assert.Equal(t, testctx.params, p)
+
+		// The decoder decompresses transparently
+		compressed, err := rlwe.Compress(bytes, rlwe.CompressionDeflate)
+		assert.Nil(t, err)
+		var pCompressed Parameters
+		assert.Nil(t, pCompressed.UnmarshalBinary(compressed))
+		assert.True(t, testctx.params.Equals(pCompressed))
 	})
 
 	t.Run(testString("Marshaller/Parameters/JSON", testctx.params), func(t *testing.T) {
@@ -706,4 +1394,210 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 			require.True(t, testctx.ringQ.Equal(ciphertextWant.Value[i], ciphertextTest.Value[i]))
 		}
 	})
+
+	t.Run(testString("Marshaller/CBOR", testctx.params), func(t *testing.T) {
+
+		data, err := testctx.params.MarshalCBOR()
+		require.NoError(t, err)
+		var paramsTest Parameters
+		require.NoError(t, paramsTest.UnmarshalCBOR(data))
+		require.True(t, testctx.params.Equals(paramsTest))
+
+		ciphertextWant := NewCiphertextRandom(testctx.prng, testctx.params, 2)
+		data, err = ciphertextWant.MarshalCBOR()
+		require.NoError(t, err)
+		ciphertextTest := new(Ciphertext)
+		require.NoError(t, ciphertextTest.UnmarshalCBOR(data))
+
+		for i := range ciphertextWant.Value {
+			require.True(t, testctx.ringQ.Equal(ciphertextWant.Value[i], ciphertextTest.Value[i]))
+		}
+	})
+
+	t.Run(testString("Marshaller/Ciphertext/Compressed", testctx.params), func(t *testing.T) {
+
+		compressor := NewCiphertextCompressor(testctx.params)
+
+		require.Equal(t, testctx.params.MaxLevel(), compressor.MinLevel(float64(testctx.params.LogQ())))
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		// Fresh ciphertexts have a noise far below 2^30
+		logNoise := 30.0
+		level := compressor.MinLevel(logNoise)
+
+		for l := level; l < testctx.params.MaxLevel()+1; l++ {
+			verifyTestVectors(testctx, testctx.decryptor, values, compressor.ModSwitchNew(ciphertext, l), t)
+		}
+
+		marshalledCiphertext, err := compressor.MarshalBinary(ciphertext, logNoise)
+		require.NoError(t, err)
+
+		if level < testctx.params.MaxLevel() {
+			data, err := ciphertext.MarshalBinary()
+			require.NoError(t, err)
+			require.Less(t, len(marshalledCiphertext), len(data))
+		}
+
+		ciphertextTest := new(Ciphertext)
+		require.NoError(t, ciphertextTest.UnmarshalBinary(marshalledCiphertext))
+		require.Equal(t, level, ciphertextTest.Level())
+
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertextTest, t)
+	})
+}
+
+func TestGenParams(t *testing.T) {
+
+	t.Run("GenParams/Depth=2", func(t *testing.T) {
+
+		pl, err := GenParams(2, 65537, rlwe.Classic128)
+		require.NoError(t, err)
+
+		params, err := NewParametersFromLiteral(pl)
+		require.NoError(t, err)
+
+		maxLogQP, err := rlwe.MaxLogQP(params.LogN(), rlwe.Classic128)
+		require.NoError(t, err)
+		require.LessOrEqual(t, params.LogQP(), maxLogQP)
+
+		testctx, err := genTestParams(params)
+		require.NoError(t, err)
+
+		coeffs, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		for i := 0; i < 2; i++ {
+			receiver := NewCiphertext(testctx.params, 2)
+			testctx.evaluator.Mul(ciphertext, ciphertext, receiver)
+			testctx.evaluator.Relinearize(receiver, ciphertext)
+			testctx.ringT.MulCoeffs(coeffs, coeffs, coeffs)
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, coeffs, ciphertext, t)
+	})
+
+	t.Run("GenParams/Errors", func(t *testing.T) {
+
+		_, err := GenParams(-1, 65537, rlwe.Classic128)
+		require.Error(t, err)
+
+		// No supported ring degree is large enough
+		_, err = GenParams(40, 65537, rlwe.Classic128)
+		require.Error(t, err)
+
+		// 40961 = 5 * 2^13 + 1 does not enable the batching for N=2^13
+		_, err = GenParams(3, 40961, rlwe.Classic128)
+		require.Error(t, err)
+	})
+}
+
+func TestPermutation(t *testing.T) {
+
+	t.Run("Permutation/BenesRoute", func(t *testing.T) {
+
+		logN := 12
+		N := 1 << logN
+
+		perm := rand.Perm(N)
+
+		src := make([]int, N)
+		dst := make([]int, N)
+		for i := range perm {
+			src[i], dst[i] = perm[i], i
+		}
+
+		layers := make([][]int, 2*logN-1)
+		for i := range layers {
+			layers[i] = make([]int, N)
+		}
+
+		benesRoute(src, dst, 0, logN, layers)
+
+		for i, layer := range layers {
+			bit := i
+			if i >= logN {
+				bit = 2*(logN-1) - i
+			}
+			for x, y := range layer {
+				require.Zero(t, (x^y)&^(1<<bit), "layer %d moves slot %d to %d", i, x, y)
+			}
+		}
+
+		for x := range perm {
+			y := x
+			for _, layer := range layers {
+				y = layer[y]
+			}
+			require.Equal(t, perm[y], x)
+		}
+	})
+
+	// Small parameters supporting the depth of the full network
+	params, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:  5,
+		LogQ:  []int{60, 60, 60, 60, 60},
+		LogP:  []int{61},
+		Sigma: rlwe.DefaultSigma,
+		T:     65537,
+	})
+	require.NoError(t, err)
+
+	testctx, err := genTestParams(params)
+	require.NoError(t, err)
+
+	perm := rand.Perm(params.N())
+
+	for _, depth := range []int{1, 3, 2*params.LogN() - 1} {
+
+		t.Run(testString(fmt.Sprintf("Permutation/Depth=%d", depth), params), func(t *testing.T) {
+
+			plan, err := NewPermutationPlan(params, perm, depth)
+			require.NoError(t, err)
+			require.LessOrEqual(t, plan.Depth(), depth)
+
+			rtks := testctx.kgen.GenRotationKeys(plan.GaloisElements(), testctx.sk)
+			eval := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rtks})
+
+			coeffs, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+			want := testctx.ringT.NewPoly()
+			for i := range perm {
+				want.Coeffs[0][i] = coeffs.Coeffs[0][perm[i]]
+			}
+
+			verifyTestVectors(testctx, testctx.decryptor, want, eval.PermuteNew(ciphertext, plan), t)
+
+			eval.Permute(ciphertext, plan, ciphertext)
+			verifyTestVectors(testctx, testctx.decryptor, want, ciphertext, t)
+		})
+	}
+
+	t.Run("Permutation/Errors", func(t *testing.T) {
+
+		_, err := NewPermutationPlan(params, perm[1:], 1)
+		require.Error(t, err)
+
+		invalid := make([]int, params.N())
+		_, err = NewPermutationPlan(params, invalid, 1)
+		require.Error(t, err)
+
+		_, err = NewPermutationPlan(params, perm, 2*params.LogN())
+		require.Error(t, err)
+	})
+}
+
+// testMetrics is an rlwe.Metrics recording the operations and noise budgets, for sequential use.
+type testMetrics struct {
+	operations []string
+	budgets    []float64
+}
+
+func (m *testMetrics) ObserveOperation(component, operation string, duration time.Duration) {
+	m.operations = append(m.operations, component+"/"+operation)
+}
+
+func (m *testMetrics) ObserveKeySize(component, kind string, size int) {}
+
+func (m *testMetrics) ObserveNoiseBudget(component, operation string, bits float64) {
+	m.budgets = append(m.budgets, bits)
 }
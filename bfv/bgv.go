@@ -0,0 +1,163 @@
+package bfv
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// BGVCiphertext is a ciphertext in the layout of the BGV scheme, at the parameters of the BFV scheme.
+// For a message m of R_t, the phase c0 + c1*s + ... of a BFV Ciphertext is Q/t * m + e mod Q, whereas the phase
+// of a BGVCiphertext is Scale * m + t * e mod Q, where Scale is a plaintext scaling factor of Z_t tracked along
+// the ciphertext, as in the usual implementations of BGV. The messages of both layouts are encoded identically,
+// so that a converted ciphertext encrypts the same slots.
+type BGVCiphertext struct {
+	*rlwe.Ciphertext
+	Scale uint64
+}
+
+// NewBGVCiphertext creates a new BGVCiphertext of the given degree and plaintext scaling factor.
+func NewBGVCiphertext(params Parameters, degree int, scale uint64) *BGVCiphertext {
+	return &BGVCiphertext{Ciphertext: rlwe.NewCiphertext(params.Parameters, degree, params.MaxLevel()), Scale: scale}
+}
+
+// CopyNew creates a deep copy of the receiver BGVCiphertext and returns it.
+func (ct *BGVCiphertext) CopyNew() *BGVCiphertext {
+	return &BGVCiphertext{Ciphertext: ct.Ciphertext.CopyNew(), Scale: ct.Scale}
+}
+
+// BGVConverter converts ciphertexts between the BFV and the BGV layouts at the same parameters.
+// Both conversions are a multiplication by a constant and do not require any key:
+//
+// - BFV to BGV multiplies the ciphertext by t. The phase t * (Q/t * m + e) = -[Q]_t * m + t * e mod Q
+// is a BGV encryption of m with the scale -Q mod t, and a noise multiplied by t.
+//
+// - BGV to BFV multiplies the ciphertext by t^{-1} * c mod Q, where c = -Q * Scale^{-1} mod t. The phase is then
+// Q/t * m + c * (Scale * m + t * e) / t mod Q, a BFV encryption of m whose noise is at most t/2 times the one of
+// the BGV ciphertext divided by t. The conversion requires the scale to be invertible modulo t.
+type BGVConverter struct {
+	params Parameters
+
+	tInvModQ *big.Int
+	qModT    uint64
+
+	coeffsBigint []*big.Int
+}
+
+// NewBGVConverter creates a new BGVConverter for the parameters params.
+// It returns an error if the plaintext modulus t is not invertible modulo Q.
+func NewBGVConverter(params Parameters) (*BGVConverter, error) {
+
+	Q := params.RingQ().ModulusBigint
+	T := new(big.Int).SetUint64(params.T())
+
+	tInvModQ := new(big.Int).ModInverse(T, Q)
+	if tInvModQ == nil {
+		return nil, fmt.Errorf("cannot NewBGVConverter: t=%d is not invertible modulo Q", params.T())
+	}
+
+	coeffsBigint := make([]*big.Int, params.N())
+	for i := range coeffsBigint {
+		coeffsBigint[i] = new(big.Int)
+	}
+
+	return &BGVConverter{
+		params:       params,
+		tInvModQ:     tInvModQ,
+		qModT:        new(big.Int).Mod(Q, T).Uint64(),
+		coeffsBigint: coeffsBigint,
+	}, nil
+}
+
+// Scale returns the plaintext scaling factor -Q mod t of the BGV ciphertexts returned by BFVToBGV.
+func (conv *BGVConverter) Scale() uint64 {
+	return (conv.params.T() - conv.qModT) % conv.params.T()
+}
+
+// BFVToBGV converts the BFV ciphertext ctIn to the BGV layout and returns the result in ctOut.
+// The scale of ctOut is set to conv.Scale().
+// The method panics if ctIn and ctOut are not of the same degree.
+func (conv *BGVConverter) BFVToBGV(ctIn *Ciphertext, ctOut *BGVCiphertext) {
+
+	if ctIn.Degree() != ctOut.Degree() {
+		panic("cannot BFVToBGV: input and output ciphertexts must be of the same degree")
+	}
+
+	ringQ := conv.params.RingQ()
+	for i := range ctIn.Value {
+		ringQ.MulScalar(ctIn.Value[i], conv.params.T(), ctOut.Value[i])
+	}
+
+	ctOut.Scale = conv.Scale()
+}
+
+// BFVToBGVNew converts the BFV ciphertext ctIn to the BGV layout and returns the result in a new BGVCiphertext.
+func (conv *BGVConverter) BFVToBGVNew(ctIn *Ciphertext) (ctOut *BGVCiphertext) {
+	ctOut = NewBGVCiphertext(conv.params, ctIn.Degree(), 0)
+	conv.BFVToBGV(ctIn, ctOut)
+	return
+}
+
+// BGVToBFV converts the BGV ciphertext ctIn to the BFV layout and returns the result in ctOut.
+// The method panics if ctIn and ctOut are not of the same degree or if the scale of ctIn is not invertible modulo t.
+func (conv *BGVConverter) BGVToBFV(ctIn *BGVCiphertext, ctOut *Ciphertext) {
+
+	if ctIn.Degree() != ctOut.Degree() {
+		panic("cannot BGVToBFV: input and output ciphertexts must be of the same degree")
+	}
+
+	T := conv.params.T()
+
+	scaleInv, err := conv.params.InverseModT(ctIn.Scale % T)
+	if err != nil {
+		panic(fmt.Errorf("cannot BGVToBFV: %w", err))
+	}
+
+	// c = -Q * Scale^{-1} mod t, in the centered representation to minimize the noise
+	c := new(big.Int).SetUint64(T - conv.qModT)
+	c.Mul(c, new(big.Int).SetUint64(scaleInv))
+	c.Mod(c, new(big.Int).SetUint64(T))
+	if c.Uint64() > T>>1 {
+		c.Sub(c, new(big.Int).SetUint64(T))
+	}
+
+	c.Mul(c, conv.tInvModQ)
+
+	ringQ := conv.params.RingQ()
+	for i := range ctIn.Value {
+		ringQ.MulScalarBigint(ctIn.Value[i], c, ctOut.Value[i])
+	}
+}
+
+// BGVToBFVNew converts the BGV ciphertext ctIn to the BFV layout and returns the result in a new Ciphertext.
+func (conv *BGVConverter) BGVToBFVNew(ctIn *BGVCiphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(conv.params, ctIn.Degree())
+	conv.BGVToBFV(ctIn, ctOut)
+	return
+}
+
+// DecodeBGV decodes the phase pt of a BGV ciphertext of scale scale, i.e. the result of its decryption,
+// and returns the message on ptRt. The method panics if the scale is not invertible modulo t.
+func (conv *BGVConverter) DecodeBGV(pt *Plaintext, scale uint64, ptRt *PlaintextRingT) {
+
+	T := conv.params.T()
+
+	scaleInv, err := conv.params.InverseModT(scale % T)
+	if err != nil {
+		panic(fmt.Errorf("cannot DecodeBGV: %w", err))
+	}
+
+	ringQ := conv.params.RingQ()
+	ringQ.PolyToBigintCenteredLvl(pt.Level(), pt.Value, 1, conv.coeffsBigint)
+
+	TBig := new(big.Int).SetUint64(T)
+	scaleInvBig := new(big.Int).SetUint64(scaleInv)
+
+	coeffs := ptRt.Value.Coeffs[0]
+	for i := range coeffs {
+		conv.coeffsBigint[i].Mod(conv.coeffsBigint[i], TBig)
+		conv.coeffsBigint[i].Mul(conv.coeffsBigint[i], scaleInvBig)
+		coeffs[i] = conv.coeffsBigint[i].Mod(conv.coeffsBigint[i], TBig).Uint64()
+	}
+}
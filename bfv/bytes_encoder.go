@@ -0,0 +1,176 @@
+package bfv
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// BytesEncoder packs byte strings into the slots of BFV plaintexts. Each slot stores BytesPerSlot()
+// bytes in little-endian order and the length of the byte string is framed in the first HeaderSlots() slots,
+// so that the string can be recovered exactly regardless of zero padding. The slots that are not used
+// by the header or the data are set to zero.
+//
+// The plaintext modulus t must be at least 2^(8*bytesPerSlot) for the packing to be injective.
+type BytesEncoder struct {
+	Encoder
+	params       Parameters
+	bytesPerSlot int
+	headerSlots  int
+}
+
+// NewBytesEncoder creates a new BytesEncoder storing bytesPerSlot bytes in each slot.
+// It returns an error if bytesPerSlot is smaller than one or if 2^(8*bytesPerSlot) is larger than the plaintext modulus.
+func NewBytesEncoder(params Parameters, bytesPerSlot int) (*BytesEncoder, error) {
+
+	if bytesPerSlot < 1 {
+		return nil, fmt.Errorf("invalid bytesPerSlot: must be at least 1 but is %d", bytesPerSlot)
+	}
+
+	if 8*bytesPerSlot > bits.Len64(params.T())-1 {
+		return nil, fmt.Errorf("invalid bytesPerSlot: %d bytes do not fit in a slot modulo t=%d", bytesPerSlot, params.T())
+	}
+
+	// The header must be able to store any length in [0, N * bytesPerSlot]
+	bitsPerSlot := 8 * bytesPerSlot
+	headerSlots := (bits.Len64(uint64(params.N()*bytesPerSlot)) + bitsPerSlot - 1) / bitsPerSlot
+
+	return &BytesEncoder{
+		Encoder:      NewEncoder(params),
+		params:       params,
+		bytesPerSlot: bytesPerSlot,
+		headerSlots:  headerSlots,
+	}, nil
+}
+
+// BytesPerSlot returns the number of bytes packed in each slot.
+func (ecd *BytesEncoder) BytesPerSlot() int {
+	return ecd.bytesPerSlot
+}
+
+// HeaderSlots returns the number of slots used to store the length of the byte string.
+func (ecd *BytesEncoder) HeaderSlots() int {
+	return ecd.headerSlots
+}
+
+// MaxLen returns the maximum length in bytes of a string that can be encoded on a single plaintext.
+func (ecd *BytesEncoder) MaxLen() int {
+	return (ecd.params.N() - ecd.headerSlots) * ecd.bytesPerSlot
+}
+
+// SlotsLen returns the number of slots (header included) occupied by a byte string of length n.
+func (ecd *BytesEncoder) SlotsLen(n int) int {
+	return ecd.headerSlots + (n+ecd.bytesPerSlot-1)/ecd.bytesPerSlot
+}
+
+// ToSlots packs data, prefixed by its length, into a new slice of N slot values.
+// It returns an error if len(data) is larger than MaxLen().
+func (ecd *BytesEncoder) ToSlots(data []byte) (slots []uint64, err error) {
+
+	if len(data) > ecd.MaxLen() {
+		return nil, fmt.Errorf("cannot encode: data of %d bytes exceeds the maximum of %d bytes", len(data), ecd.MaxLen())
+	}
+
+	slots = make([]uint64, ecd.params.N())
+
+	mask := uint64(1)<<(8*ecd.bytesPerSlot) - 1
+
+	length := uint64(len(data))
+	for i := 0; i < ecd.headerSlots; i++ {
+		slots[i] = length & mask
+		length >>= 8 * ecd.bytesPerSlot
+	}
+
+	for i, b := range data {
+		slots[ecd.headerSlots+i/ecd.bytesPerSlot] |= uint64(b) << (8 * (i % ecd.bytesPerSlot))
+	}
+
+	return
+}
+
+// FromSlots recovers the byte string framed in slots by ToSlots.
+// It returns an error if the header is inconsistent with the number of slots or if
+// a slot value does not fit in BytesPerSlot() bytes.
+func (ecd *BytesEncoder) FromSlots(slots []uint64) (data []byte, err error) {
+
+	if len(slots) < ecd.headerSlots {
+		return nil, fmt.Errorf("cannot decode: %d slots is too few to contain the %d header slots", len(slots), ecd.headerSlots)
+	}
+
+	mask := uint64(1)<<(8*ecd.bytesPerSlot) - 1
+
+	var length uint64
+	for i := ecd.headerSlots - 1; i >= 0; i-- {
+		if slots[i] > mask {
+			return nil, fmt.Errorf("cannot decode: header slot %d has invalid value %d", i, slots[i])
+		}
+		length = length<<(8*ecd.bytesPerSlot) | slots[i]
+	}
+
+	if length > uint64((len(slots)-ecd.headerSlots)*ecd.bytesPerSlot) {
+		return nil, fmt.Errorf("cannot decode: framed length %d exceeds the slot capacity", length)
+	}
+
+	data = make([]byte, length)
+
+	for i := range data {
+		slot := slots[ecd.headerSlots+i/ecd.bytesPerSlot]
+		if slot > mask {
+			return nil, fmt.Errorf("cannot decode: slot %d has invalid value %d", ecd.headerSlots+i/ecd.bytesPerSlot, slot)
+		}
+		data[i] = byte(slot >> (8 * (i % ecd.bytesPerSlot)))
+	}
+
+	return
+}
+
+// EncodeBytes encodes data on pt, which can be a *Plaintext, *PlaintextRingT or *PlaintextMul.
+// It returns an error if len(data) is larger than MaxLen().
+func (ecd *BytesEncoder) EncodeBytes(data []byte, pt interface{}) (err error) {
+
+	var slots []uint64
+	if slots, err = ecd.ToSlots(data); err != nil {
+		return
+	}
+
+	switch p := pt.(type) {
+	case *Plaintext:
+		ecd.EncodeUint(slots, p)
+	case *PlaintextRingT:
+		ecd.EncodeUintRingT(slots, p)
+	case *PlaintextMul:
+		ecd.EncodeUintMul(slots, p)
+	default:
+		return fmt.Errorf("unsupported plaintext type (%T)", p)
+	}
+
+	return
+}
+
+// EncodeBytesNew encodes data on a new Plaintext.
+// It returns an error if len(data) is larger than MaxLen().
+func (ecd *BytesEncoder) EncodeBytesNew(data []byte) (pt *Plaintext, err error) {
+	pt = NewPlaintext(ecd.params)
+	if err = ecd.EncodeBytes(data, pt); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// DecodeBytes decodes a byte string from any plaintext type.
+// It returns an error if the plaintext does not contain a valid framing.
+// It panics if pt is not PlaintextRingT, Plaintext or PlaintextMul.
+func (ecd *BytesEncoder) DecodeBytes(pt interface{}) (data []byte, err error) {
+	return ecd.FromSlots(ecd.DecodeUintNew(pt))
+}
+
+// ShallowCopy creates a shallow copy of BytesEncoder in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// BytesEncoder can be used concurrently.
+func (ecd *BytesEncoder) ShallowCopy() *BytesEncoder {
+	return &BytesEncoder{
+		Encoder:      ecd.Encoder.ShallowCopy(),
+		params:       ecd.params,
+		bytesPerSlot: ecd.bytesPerSlot,
+		headerSlots:  ecd.headerSlots,
+	}
+}
@@ -0,0 +1,84 @@
+package bfv
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils/cbor"
+)
+
+// CBORValue returns the value encoding the parameters in the CBOR serialization: an object of type "bfv.Parameters"
+// with the keys of rlwe.Parameters and the plaintext modulus "t".
+func (p Parameters) CBORValue() (interface{}, error) {
+	v, err := p.Parameters.CBORValue()
+	if err != nil {
+		return nil, err
+	}
+	m := cbor.Object(v.(map[string]interface{})).Retype("bfv.Parameters", rlwe.CBORVersion)
+	m["t"] = p.T()
+	return m, nil
+}
+
+// SetCBORValue sets the parameters to the decoded CBOR value v generated by CBORValue.
+func (p *Parameters) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "bfv.Parameters", rlwe.CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	t, err := o.Uint("t")
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	var rlweParams rlwe.Parameters
+	if err = rlweParams.SetCBORValue(o.Retype("rlwe.Parameters", rlwe.CBORVersion)); err != nil {
+		return err
+	}
+
+	if *p, err = NewParameters(rlweParams, t); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the parameters in the CBOR serialization.
+func (p Parameters) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(p)
+}
+
+// UnmarshalCBOR decodes the parameters encoded by MarshalCBOR.
+func (p *Parameters) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return p.SetCBORValue(v)
+}
+
+// CBORValue returns the value encoding the ciphertext in the CBOR serialization, which is the one of rlwe.Ciphertext.
+func (ct *Ciphertext) CBORValue() (interface{}, error) {
+	return ct.Ciphertext.CBORValue()
+}
+
+// SetCBORValue sets the ciphertext to the decoded CBOR value v generated by CBORValue.
+func (ct *Ciphertext) SetCBORValue(v interface{}) error {
+	ct.Ciphertext = new(rlwe.Ciphertext)
+	return ct.Ciphertext.SetCBORValue(v)
+}
+
+// MarshalCBOR encodes the ciphertext in the CBOR serialization.
+func (ct *Ciphertext) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(ct)
+}
+
+// UnmarshalCBOR decodes the ciphertext encoded by MarshalCBOR.
+func (ct *Ciphertext) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return ct.SetCBORValue(v)
+}
@@ -0,0 +1,114 @@
+package bfv
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// CiphertextCompressor reduces the size of ciphertexts before their serialization by switching
+// their modulus down to the smallest level that is consistent with their remaining noise budget.
+// The switched ciphertexts can be unmarshalled and decrypted as usual, but they must not be used
+// as operands of the Evaluator, which expects ciphertexts at the maximum level.
+type CiphertextCompressor struct {
+	params Parameters
+	ringQ  *ring.Ring
+	pool   *ring.Poly
+}
+
+// NewCiphertextCompressor creates a new CiphertextCompressor.
+func NewCiphertextCompressor(params Parameters) *CiphertextCompressor {
+	return &CiphertextCompressor{
+		params: params,
+		ringQ:  params.RingQ(),
+		pool:   params.RingQ().NewPoly(),
+	}
+}
+
+// MinLevel returns the smallest level to which a ciphertext of degree one, whose noise has an infinity
+// norm of at most 2^logNoise, can be switched while still decrypting correctly. The estimate accounts
+// for the rounding error of the modulus switching, assuming a secret with coefficients in [-1, 1].
+// It returns the maximum level if the noise leaves no budget for the switching.
+func (cc *CiphertextCompressor) MinLevel(logNoise float64) int {
+
+	t := float64(cc.params.T())
+
+	logQ := 0.0
+	for i := 0; i < cc.params.QCount(); i++ {
+		logQ += math.Log2(cc.params.QiFloat64(i))
+	}
+
+	// Remaining fraction of the decryption bound once the current noise is scaled down
+	budget := 1/(2*t) - math.Exp2(logNoise-logQ)
+
+	if budget <= 0 {
+		return cc.params.MaxLevel()
+	}
+
+	// High probability bound on the rounding error c0' + c1' * s of the modulus switching,
+	// plus the error due to the rounding of Q/t
+	switchingNoise := 6*math.Sqrt(float64(cc.params.N()+1)/12) + t
+
+	logQl := 0.0
+	for level := 0; level < cc.params.MaxLevel(); level++ {
+		logQl += math.Log2(cc.params.QiFloat64(level))
+		if math.Log2(switchingNoise) < logQl+math.Log2(budget) {
+			return level
+		}
+	}
+
+	return cc.params.MaxLevel()
+}
+
+// ModSwitch switches the modulus of ct0 from Q_{ct0.Level()} to Q_{level} by dividing and rounding
+// it by the last moduli, and returns the result in ctOut. The level of ctOut is set to level.
+// The method panics if level is negative or larger than the level of ct0.
+func (cc *CiphertextCompressor) ModSwitch(ct0 *Ciphertext, level int, ctOut *Ciphertext) {
+
+	if level < 0 || level > ct0.Level() {
+		panic(fmt.Errorf("cannot ModSwitch: invalid target level %d for a ciphertext at level %d", level, ct0.Level()))
+	}
+
+	if ctOut.Degree() < ct0.Degree() {
+		panic("cannot ModSwitch: receiver operand degree is too small")
+	}
+
+	if ctOut.Level() < level {
+		panic("cannot ModSwitch: receiver operand level is too small")
+	}
+
+	levelIn := ct0.Level()
+
+	for i := range ct0.Value {
+		// DivRoundByLastModulusMany modifies its input, so it operates on a copy of ct0
+		ring.CopyValuesLvl(levelIn, ct0.Value[i], cc.pool)
+		cc.ringQ.DivRoundByLastModulusManyLvl(levelIn, levelIn-level, cc.pool, cc.pool, ctOut.Value[i])
+		ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:level+1]
+	}
+}
+
+// ModSwitchNew switches the modulus of ct0 from Q_{ct0.Level()} to Q_{level} and returns the result in a new ciphertext.
+func (cc *CiphertextCompressor) ModSwitchNew(ct0 *Ciphertext, level int) (ctOut *Ciphertext) {
+	ctOut = &Ciphertext{rlwe.NewCiphertext(cc.params.Parameters, ct0.Degree(), level)}
+	cc.ModSwitch(ct0, level, ctOut)
+	return
+}
+
+// MarshalBinary switches the modulus of a copy of ct to the smallest level given by MinLevel(logNoise)
+// and encodes it in a byte slice. The input ciphertext is not modified.
+func (cc *CiphertextCompressor) MarshalBinary(ct *Ciphertext, logNoise float64) (data []byte, err error) {
+	level := cc.MinLevel(logNoise)
+	if level >= ct.Level() {
+		return ct.MarshalBinary()
+	}
+	return cc.ModSwitchNew(ct, level).MarshalBinary()
+}
+
+// ShallowCopy creates a shallow copy of CiphertextCompressor in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// CiphertextCompressor can be used concurrently.
+func (cc *CiphertextCompressor) ShallowCopy() *CiphertextCompressor {
+	return NewCiphertextCompressor(cc.params)
+}
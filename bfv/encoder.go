@@ -14,13 +14,14 @@ import (
 // The j-th ring automorphism takes the root zeta to zeta^(5j).
 const GaloisGen uint64 = 5
 
-// Encoder is an interface for plaintext encoding and decoding operations. It provides methods to embed []uint64 and []int64 types into
+// Encoder is an interface for plaintext encoding and decoding operations. It provides methods to embed []uint64, []int64 and []*big.Int types into
 // the various plaintext types and the inverse operations. It also provides methodes to convert between the different plaintext types.
 // The different plaintext types represent different embeddings of the message in the polynomial space. This relation is illustrated in
 // The figure below:
 //
-// []uint64 --- Encoder.EncodeUintRingT(.) -┬-> PlaintextRingT -┬-> Encoder.ScaleUp(.) -----> Plaintext
-// []uint64 --- Encoder.EncodeIntRingT(.) --┘                   └-> Encoder.RingTToMul(.) ---> PlaintextMul
+// []uint64   --- Encoder.EncodeUintRingT(.) ---┬-> PlaintextRingT -┬-> Encoder.ScaleUp(.) -----> Plaintext
+// []int64    --- Encoder.EncodeIntRingT(.) ----┤                   └-> Encoder.RingTToMul(.) ---> PlaintextMul
+// []*big.Int --- Encoder.EncodeBigIntRingT(.) -┘
 //
 //
 // The different plaintext types have different efficiency-related caracteristics that we summarize in the Table below. For more information
@@ -40,9 +41,13 @@ type Encoder interface {
 	EncodeUint(coeffs []uint64, pt *Plaintext)
 	EncodeUintRingT(coeffs []uint64, pt *PlaintextRingT)
 	EncodeUintMul(coeffs []uint64, pt *PlaintextMul)
+	EncodeUintInverseMul(coeffs []uint64, pt *PlaintextMul)
 	EncodeInt(coeffs []int64, pt *Plaintext)
 	EncodeIntRingT(coeffs []int64, pt *PlaintextRingT)
 	EncodeIntMul(coeffs []int64, pt *PlaintextMul)
+	EncodeBigInt(coeffs []*big.Int, pt *Plaintext)
+	EncodeBigIntRingT(coeffs []*big.Int, pt *PlaintextRingT)
+	EncodeBigIntMul(coeffs []*big.Int, pt *PlaintextMul)
 
 	ScaleUp(*PlaintextRingT, *Plaintext)
 	ScaleDown(pt *Plaintext, ptRt *PlaintextRingT)
@@ -54,6 +59,8 @@ type Encoder interface {
 	DecodeInt(pt interface{}, coeffs []int64)
 	DecodeUintNew(pt interface{}) (coeffs []uint64)
 	DecodeIntNew(pt interface{}) (coeffs []int64)
+	DecodeBigInt(pt interface{}, coeffs []*big.Int)
+	DecodeBigIntNew(pt interface{}) (coeffs []*big.Int)
 
 	ShallowCopy() Encoder
 }
@@ -160,8 +167,28 @@ func (ecd *encoder) EncodeUintMul(coeffs []uint64, p *PlaintextMul) {
 	ecd.RingTToMul(ptRt, p)
 }
 
+// EncodeUintInverseMul encodes the inverses modulo t of an uint64 slice of size at most N on a PlaintextMul,
+// so that the multiplication of a ciphertext by the plaintext divides each slot by the corresponding value.
+// The slots that are multiples of their divisor are divided exactly, the other ones are multiplied by the modular inverse,
+// and the slots beyond the length of coeffs are set to zero.
+// It panics if a value is not invertible modulo t.
+func (ecd *encoder) EncodeUintInverseMul(coeffs []uint64, p *PlaintextMul) {
+
+	inverses := make([]uint64, len(coeffs))
+
+	var err error
+	for i := range coeffs {
+		if inverses[i], err = ecd.params.InverseModT(coeffs[i]); err != nil {
+			panic(fmt.Errorf("cannot EncodeUintInverseMul: slot %d: %w", i, err))
+		}
+	}
+
+	ecd.EncodeUintMul(inverses, p)
+}
+
 // EncodeIntRingT encodes an int64 slice of size at most N on a plaintext. It also encodes the sign of the given integer (as its inverse modulo the plaintext modulus).
-// The sign will correctly decode as long as the absolute value of the coefficient does not exceed half of the plaintext modulus.
+// Each coefficient is reduced modulo the plaintext modulus, hence the value (and its sign) will correctly decode as long as it lies in the
+// centered interval [-floor(t/2), ceil(t/2)-1].
 func (ecd *encoder) EncodeIntRingT(coeffs []int64, p *PlaintextRingT) {
 
 	if len(coeffs) > len(ecd.indexMatrix) {
@@ -172,13 +199,18 @@ func (ecd *encoder) EncodeIntRingT(coeffs []int64, p *PlaintextRingT) {
 		panic("invalid plaintext to receive encoding: number of coefficients does not match the ring degree")
 	}
 
+	modulus := int64(ecd.params.T())
+
+	var value int64
 	for i := 0; i < len(coeffs); i++ {
 
-		if coeffs[i] < 0 {
-			p.Value.Coeffs[0][ecd.indexMatrix[i]] = uint64(int64(ecd.params.T()) + coeffs[i])
-		} else {
-			p.Value.Coeffs[0][ecd.indexMatrix[i]] = uint64(coeffs[i])
+		value = coeffs[i] % modulus
+
+		if value < 0 {
+			value += modulus
 		}
+
+		p.Value.Coeffs[0][ecd.indexMatrix[i]] = uint64(value)
 	}
 
 	for i := len(coeffs); i < len(ecd.indexMatrix); i++ {
@@ -210,12 +242,62 @@ func (ecd *encoder) EncodeIntMul(coeffs []int64, p *PlaintextMul) {
 	ecd.RingTToMul(ptRt, p)
 }
 
+// EncodeBigInt encodes a slice of *big.Int of size at most N on a plaintext.
+func (ecd *encoder) EncodeBigInt(coeffs []*big.Int, p *Plaintext) {
+	ptRt := &PlaintextRingT{p.Plaintext}
+
+	// Encodes the values in RingT
+	ecd.EncodeBigIntRingT(coeffs, ptRt)
+
+	// Scales by Q/t
+	ecd.ScaleUp(ptRt, p)
+}
+
+// EncodeBigIntRingT encodes a slice of *big.Int of size at most N on a PlaintextRingT (R_t).
+// Each coefficient is reduced modulo the plaintext modulus t; negative values are mapped to their
+// representative in [0, t).
+func (ecd *encoder) EncodeBigIntRingT(coeffs []*big.Int, p *PlaintextRingT) {
+
+	if len(coeffs) > len(ecd.indexMatrix) {
+		panic("invalid input to encode: number of coefficients must be smaller or equal to the ring degree")
+	}
+
+	if len(p.Value.Coeffs[0]) != len(ecd.indexMatrix) {
+		panic("invalid plaintext to receive encoding: number of coefficients does not match the ring degree")
+	}
+
+	modulus := ecd.params.RingT().ModulusBigint
+
+	tmp := new(big.Int)
+	for i := 0; i < len(coeffs); i++ {
+		p.Value.Coeffs[0][ecd.indexMatrix[i]] = tmp.Mod(coeffs[i], modulus).Uint64()
+	}
+
+	for i := len(coeffs); i < len(ecd.indexMatrix); i++ {
+		p.Value.Coeffs[0][ecd.indexMatrix[i]] = 0
+	}
+
+	ecd.params.RingT().InvNTT(p.Value, p.Value)
+}
+
+// EncodeBigIntMul encodes a slice of *big.Int of size at most N on a PlaintextMul optimized for ciphertext-plaintext multiplication.
+func (ecd *encoder) EncodeBigIntMul(coeffs []*big.Int, p *PlaintextMul) {
+	ptRt := &PlaintextRingT{p.Plaintext}
+
+	// Encodes the values in RingT
+	ecd.EncodeBigIntRingT(coeffs, ptRt)
+
+	// Puts in NTT+Montgomery domains of ringQ
+	ecd.RingTToMul(ptRt, p)
+}
+
 // ScaleUp transforms a PlaintextRingT (R_t) into a Plaintext (R_q) by scaling up the coefficient by Q/t.
 func (ecd *encoder) ScaleUp(ptRt *PlaintextRingT, pt *Plaintext) {
 	ecd.scaleUp(ecd.params.RingQ(), ecd.params.RingT(), ecd.tmpPoly.Coeffs[0], ptRt.Value, pt.Value)
 }
 
 // ScaleDown transforms a Plaintext (R_q) into a PlaintextRingT (R_t) by scaling down the coefficient by t/Q and rounding.
+// Q is the product of the moduli up to the level of the plaintext.
 func (ecd *encoder) ScaleDown(pt *Plaintext, ptRt *PlaintextRingT) {
 	ecd.scaler.DivByQOverTRounded(pt.Value, ptRt.Value)
 }
@@ -281,7 +363,8 @@ func (ecd *encoder) DecodeUintNew(p interface{}) (coeffs []uint64) {
 }
 
 // DecodeInt decodes a any plaintext type and write the coefficients in coeffs. It also decodes the sign
-// modulus (by centering the values around the plaintext). It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+// modulus (by centering the values around the plaintext modulus, i.e. in [-floor(t/2), ceil(t/2)-1]).
+// It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
 func (ecd *encoder) DecodeInt(p interface{}, coeffs []int64) {
 
 	ecd.DecodeRingT(p, ecd.tmpPtRt)
@@ -289,7 +372,7 @@ func (ecd *encoder) DecodeInt(p interface{}, coeffs []int64) {
 	ecd.params.RingT().NTT(ecd.tmpPtRt.Value, ecd.tmpPoly)
 
 	modulus := int64(ecd.params.T())
-	modulusHalf := modulus >> 1
+	modulusHalf := (modulus + 1) >> 1
 	var value int64
 	for i := 0; i < ecd.params.RingQ().N; i++ {
 
@@ -309,6 +392,30 @@ func (ecd *encoder) DecodeIntNew(p interface{}) (coeffs []int64) {
 	return
 }
 
+// DecodeBigInt decodes any plaintext type and writes the coefficients, reduced modulo t, in coeffs.
+// It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+func (ecd *encoder) DecodeBigInt(p interface{}, coeffs []*big.Int) {
+
+	ecd.DecodeRingT(p, ecd.tmpPtRt)
+
+	ecd.params.RingT().NTT(ecd.tmpPtRt.Value, ecd.tmpPoly)
+
+	for i := 0; i < ecd.params.RingQ().N; i++ {
+		if coeffs[i] == nil {
+			coeffs[i] = new(big.Int)
+		}
+		coeffs[i].SetUint64(ecd.tmpPoly.Coeffs[0][ecd.indexMatrix[i]])
+	}
+}
+
+// DecodeBigIntNew decodes any plaintext type and returns the coefficients, reduced modulo t, in a new []*big.Int.
+// It panics if p is not PlaintextRingT, Plaintext or PlaintextMul.
+func (ecd *encoder) DecodeBigIntNew(p interface{}) (coeffs []*big.Int) {
+	coeffs = make([]*big.Int, ecd.params.RingQ().N)
+	ecd.DecodeBigInt(p, coeffs)
+	return
+}
+
 // ShallowCopy creates a shallow copy of Encoder in which all the read-only data-structures are
 // shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
 // Encoder can be used concurrently.
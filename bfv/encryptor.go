@@ -3,6 +3,7 @@ package bfv
 import (
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
 )
 
 // Encryptor an encryption interface for the BFV scheme.
@@ -26,6 +27,12 @@ func NewEncryptor(params Parameters, key interface{}) Encryptor {
 	return &encryptor{rlwe.NewEncryptor(params.Parameters, key), params}
 }
 
+// NewEncryptorWithPRNG instantiates a new Encryptor for the BFV scheme which samples the randomness
+// of the encryptions from the given PRNG (see rlwe.NewEncryptorWithPRNG).
+func NewEncryptorWithPRNG(params Parameters, key interface{}, prng utils.PRNG) Encryptor {
+	return &encryptor{rlwe.NewEncryptorWithPRNG(params.Parameters, key, prng), params}
+}
+
 // Encrypt encrypts the input plaintext and write the result on ctOut.
 func (enc *encryptor) Encrypt(plaintext *Plaintext, ctOut *Ciphertext) {
 	enc.Encryptor.Encrypt(&rlwe.Plaintext{Value: plaintext.Value}, &rlwe.Ciphertext{Value: ctOut.Value})
@@ -33,19 +33,32 @@ type Evaluator interface {
 	ReduceNew(op Operand) (ctOut *Ciphertext)
 	MulScalar(op Operand, scalar uint64, ctOut *Ciphertext)
 	MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext)
+	MulScalarThenAdd(op Operand, scalar uint64, ctOut *Ciphertext)
+	DivScalar(op Operand, scalar uint64, ctOut *Ciphertext)
+	DivScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext)
+	LinearCombination(cts []*Ciphertext, consts []uint64, ctOut *Ciphertext)
+	LinearCombinationNew(cts []*Ciphertext, consts []uint64) (ctOut *Ciphertext)
 	Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext)
 	MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext)
+	MulPlainThenAdd(ct0 *Ciphertext, pt Operand, ctOut *Ciphertext)
 	Relinearize(ct0 *Ciphertext, ctOut *Ciphertext)
 	RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext)
 	SwitchKeys(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey, ctOut *Ciphertext)
 	SwitchKeysNew(ct0 *Ciphertext, switchkey *rlwe.SwitchingKey) (ctOut *Ciphertext)
 	RotateColumnsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext)
 	RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext)
+	RotateColumnsHoistedNew(ct0 *Ciphertext, rotations []int) (ctOut map[int]*Ciphertext)
+	RotateColumnsHoisted(ct0 *Ciphertext, rotations []int, ctOut map[int]*Ciphertext)
 	RotateRows(ct0 *Ciphertext, ctOut *Ciphertext)
 	RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext)
 	InnerSum(ct0 *Ciphertext, ctOut *Ciphertext)
+	DotProduct(ct0 *Ciphertext, op1 Operand, ctOut *Ciphertext)
+	DotProductNew(ct0 *Ciphertext, op1 Operand) (ctOut *Ciphertext)
+	Permute(ct0 *Ciphertext, plan *PermutationPlan, ctOut *Ciphertext)
+	PermuteNew(ct0 *Ciphertext, plan *PermutationPlan) (ctOut *Ciphertext)
 	ShallowCopy() Evaluator
 	WithKey(rlwe.EvaluationKey) Evaluator
+	WithParallelism(goroutines int) Evaluator
 }
 
 // evaluator is a struct that holds the necessary elements to perform the homomorphic operations between ciphertexts and/or plaintexts.
@@ -61,6 +74,8 @@ type evaluator struct {
 	rtks *rlwe.RotationKeySet
 
 	basisExtenderQ1toQ2 *ring.BasisExtender
+
+	parallel *parallelizer
 }
 
 type evaluatorBase struct {
@@ -257,9 +272,113 @@ func (eval *evaluator) MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertex
 	return
 }
 
+// DivScalar multiplies op by the inverse of a uint64 scalar modulo t and returns the result in ctOut.
+// The slots that are multiples of scalar are divided exactly, e.g. a sum of n values divided by n gives
+// their average only if the sum is a multiple of n. The other slots are multiplied by the modular inverse,
+// which is not the rounded quotient. The method panics if scalar is not invertible modulo t.
+func (eval *evaluator) DivScalar(op Operand, scalar uint64, ctOut *Ciphertext) {
+
+	inv, err := eval.params.InverseModT(scalar)
+	if err != nil {
+		panic(fmt.Errorf("cannot DivScalar: %w", err))
+	}
+
+	eval.MulScalar(op, inv, ctOut)
+}
+
+// DivScalarNew multiplies op by the inverse of a uint64 scalar modulo t and creates a new element ctOut to store the result.
+func (eval *evaluator) DivScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op.Degree())
+	eval.DivScalar(op, scalar, ctOut)
+	return
+}
+
+// MulScalarThenAdd multiplies op by a uint64 scalar and adds the result on ctOut, i.e. ctOut = ctOut + op * scalar.
+// This removes the need of storing the intermediate value op * scalar.
+func (eval *evaluator) MulScalarThenAdd(op Operand, scalar uint64, ctOut *Ciphertext) {
+	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
+	fun := func(el, elOut *ring.Poly) { eval.ringQ.MulScalarAndAdd(el, scalar, elOut) }
+	evaluateInPlaceUnary(el0, elOut, fun)
+}
+
+// LinearCombination evaluates the weighted sum consts[0] * cts[0] + ... + consts[n-1] * cts[n-1] and returns the result in ctOut.
+// The terms are accumulated with lazy modular reduction, without intermediate ciphertexts.
+// ctOut can be one of the inputs. The degree of ctOut must be at least the largest degree of the inputs, and ctOut is
+// resized to it.
+// The method panics if len(cts) != len(consts) or if cts is empty.
+func (eval *evaluator) LinearCombination(cts []*Ciphertext, consts []uint64, ctOut *Ciphertext) {
+
+	if len(cts) != len(consts) {
+		panic(fmt.Errorf("cannot LinearCombination: number of ciphertexts (%d) and constants (%d) do not match", len(cts), len(consts)))
+	}
+
+	if len(cts) == 0 {
+		panic("cannot LinearCombination: no input ciphertext")
+	}
+
+	maxDegree := 0
+	for _, ct := range cts {
+		eval.getElemAndCheckUnary(ct, ctOut, ct.Degree())
+		maxDegree = utils.MaxInt(maxDegree, ct.Degree())
+	}
+
+	ringQ := eval.ringQ
+
+	// Each term is in [0, Qi-1], so QiOverflowMargin-1 terms can be accumulated on top of a reduced value
+	QiOverF := eval.params.QiOverflowMargin(eval.params.MaxLevel()) - 1
+
+	// Accumulates on a buffer so that ctOut can be one of the inputs
+	acc := eval.poolQ[1][0]
+
+	for u := 0; u < maxDegree+1; u++ {
+
+		reduce := 0
+
+		for i, ct := range cts {
+
+			if ct.Degree() < u {
+				continue
+			}
+
+			if reduce == 0 {
+				ringQ.MulScalar(ct.Value[u], consts[i], acc)
+			} else {
+				ringQ.MulScalarAndAddNoMod(ct.Value[u], consts[i], acc)
+			}
+
+			if reduce%QiOverF == QiOverF-1 {
+				ringQ.Reduce(acc, acc)
+			}
+
+			reduce++
+		}
+
+		ringQ.Reduce(acc, ctOut.Value[u])
+	}
+
+	// Removes the components of ctOut above the degree of the result
+	ctOut.El().Resize(eval.params.Parameters, maxDegree)
+}
+
+// LinearCombinationNew evaluates the weighted sum consts[0] * cts[0] + ... + consts[n-1] * cts[n-1] and creates a new element ctOut to store the result.
+func (eval *evaluator) LinearCombinationNew(cts []*Ciphertext, consts []uint64) (ctOut *Ciphertext) {
+	maxDegree := 0
+	for _, ct := range cts {
+		maxDegree = utils.MaxInt(maxDegree, ct.Degree())
+	}
+	ctOut = NewCiphertext(eval.params, maxDegree)
+	eval.LinearCombination(cts, consts, ctOut)
+	return
+}
+
 // tensorAndRescale computes (ct0 x ct1) * (t/Q) and stores the result in ctOut.
 func (eval *evaluator) tensorAndRescale(ct0, ct1, ctOut *rlwe.Ciphertext) {
 
+	if eval.parallel != nil {
+		eval.tensorAndRescaleParallel(ct0, ct1, ctOut)
+		return
+	}
+
 	c0Q1 := eval.poolQ[0]
 	c0Q2 := eval.poolQmul[0]
 
@@ -299,115 +418,87 @@ func (eval *evaluator) modUpAndNTT(ct *rlwe.Ciphertext, cQ, cQMul []*ring.Poly)
 }
 
 func (eval *evaluator) tensoreLowDeg(ct0, ct1 *rlwe.Ciphertext) {
+	tensorLowDeg(eval.ringQ, eval.poolQ[0], eval.poolQ[1], eval.poolQ[2], eval.poolQ[3], ct0 == ct1)
+	tensorLowDeg(eval.ringQMul, eval.poolQmul[0], eval.poolQmul[1], eval.poolQmul[2], eval.poolQmul[3], ct0 == ct1)
+}
 
-	c0Q1 := eval.poolQ[0]
-	c0Q2 := eval.poolQmul[0]
-
-	c1Q1 := eval.poolQ[1]
-	c1Q2 := eval.poolQmul[1]
-
-	c2Q1 := eval.poolQ[2]
-	c2Q2 := eval.poolQmul[2]
-
-	c00Q := eval.poolQ[3][0]
-	c00Q2 := eval.poolQmul[3][0]
-	c01Q := eval.poolQ[3][1]
-	c01P := eval.poolQmul[3][1]
+// tensorLowDeg computes the tensor product of the degree one elements c0 and c1, in the NTT domain of r,
+// and returns the result in c2 using tmp[0] and tmp[1] as buffers. If square is true, c0 is squared and c1 is ignored.
+func tensorLowDeg(r *ring.Ring, c0, c1, c2, tmp []*ring.Poly, square bool) {
 
-	eval.ringQ.MForm(c0Q1[0], c00Q)
-	eval.ringQMul.MForm(c0Q2[0], c00Q2)
+	c00 := tmp[0]
+	c01 := tmp[1]
 
-	eval.ringQ.MForm(c0Q1[1], c01Q)
-	eval.ringQMul.MForm(c0Q2[1], c01P)
+	r.MForm(c0[0], c00)
+	r.MForm(c0[1], c01)
 
 	// Squaring case
-	if ct0 == ct1 {
+	if square {
 
 		// c0 = c0[0]*c0[0]
-		eval.ringQ.MulCoeffsMontgomery(c00Q, c0Q1[0], c2Q1[0])
-		eval.ringQMul.MulCoeffsMontgomery(c00Q2, c0Q2[0], c2Q2[0])
+		r.MulCoeffsMontgomery(c00, c0[0], c2[0])
 
 		// c1 = 2*c0[0]*c0[1]
-		eval.ringQ.MulCoeffsMontgomery(c00Q, c0Q1[1], c2Q1[1])
-		eval.ringQMul.MulCoeffsMontgomery(c00Q2, c0Q2[1], c2Q2[1])
-
-		eval.ringQ.AddNoMod(c2Q1[1], c2Q1[1], c2Q1[1])
-		eval.ringQMul.AddNoMod(c2Q2[1], c2Q2[1], c2Q2[1])
+		r.MulCoeffsMontgomery(c00, c0[1], c2[1])
+		r.AddNoMod(c2[1], c2[1], c2[1])
 
 		// c2 = c0[1]*c0[1]
-		eval.ringQ.MulCoeffsMontgomery(c01Q, c0Q1[1], c2Q1[2])
-		eval.ringQMul.MulCoeffsMontgomery(c01P, c0Q2[1], c2Q2[2])
+		r.MulCoeffsMontgomery(c01, c0[1], c2[2])
 
 		// Normal case
 	} else {
 
 		// c0 = c0[0]*c1[0]
-		eval.ringQ.MulCoeffsMontgomery(c00Q, c1Q1[0], c2Q1[0])
-		eval.ringQMul.MulCoeffsMontgomery(c00Q2, c1Q2[0], c2Q2[0])
+		r.MulCoeffsMontgomery(c00, c1[0], c2[0])
 
 		// c1 = c0[0]*c1[1] + c0[1]*c1[0]
-		eval.ringQ.MulCoeffsMontgomery(c00Q, c1Q1[1], c2Q1[1])
-		eval.ringQMul.MulCoeffsMontgomery(c00Q2, c1Q2[1], c2Q2[1])
-
-		eval.ringQ.MulCoeffsMontgomeryAndAddNoMod(c01Q, c1Q1[0], c2Q1[1])
-		eval.ringQMul.MulCoeffsMontgomeryAndAddNoMod(c01P, c1Q2[0], c2Q2[1])
+		r.MulCoeffsMontgomery(c00, c1[1], c2[1])
+		r.MulCoeffsMontgomeryAndAddNoMod(c01, c1[0], c2[1])
 
 		// c2 = c0[1]*c1[1]
-		eval.ringQ.MulCoeffsMontgomery(c01Q, c1Q1[1], c2Q1[2])
-		eval.ringQMul.MulCoeffsMontgomery(c01P, c1Q2[1], c2Q2[2])
+		r.MulCoeffsMontgomery(c01, c1[1], c2[2])
 	}
 }
 
 func (eval *evaluator) tensortLargeDeg(ct0, ct1 *rlwe.Ciphertext) {
+	tensorLargeDeg(eval.ringQ, eval.poolQ[0], eval.poolQ[1], eval.poolQ[2], eval.poolQ[3], ct0.Degree(), ct1.Degree(), ct0 == ct1)
+	tensorLargeDeg(eval.ringQMul, eval.poolQmul[0], eval.poolQmul[1], eval.poolQmul[2], eval.poolQmul[3], ct0.Degree(), ct1.Degree(), ct0 == ct1)
+}
 
-	c0Q1 := eval.poolQ[0]
-	c0Q2 := eval.poolQmul[0]
-
-	c1Q1 := eval.poolQ[1]
-	c1Q2 := eval.poolQmul[1]
-
-	c2Q1 := eval.poolQ[2]
-	c2Q2 := eval.poolQmul[2]
+// tensorLargeDeg computes the tensor product of the elements c0 and c1 of degree deg0 and deg1, in the NTT domain of r,
+// and returns the result in c2 using tmp as buffer. If square is true, c0 is squared and c1 is ignored.
+func tensorLargeDeg(r *ring.Ring, c0, c1, c2, tmp []*ring.Poly, deg0, deg1 int, square bool) {
 
-	for i := 0; i < ct0.Degree()+ct1.Degree()+1; i++ {
-		c2Q1[i].Zero()
-		c2Q2[i].Zero()
+	for i := 0; i < deg0+deg1+1; i++ {
+		c2[i].Zero()
 	}
 
 	// Squaring case
-	if ct0 == ct1 {
+	if square {
 
-		c00Q1 := eval.poolQ[3]
-		c00Q2 := eval.poolQmul[3]
+		c00 := tmp
 
-		for i := range ct0.Value {
-			eval.ringQ.MForm(c0Q1[i], c00Q1[i])
-			eval.ringQMul.MForm(c0Q2[i], c00Q2[i])
+		for i := 0; i < deg0+1; i++ {
+			r.MForm(c0[i], c00[i])
 		}
 
-		for i := 0; i < ct0.Degree()+1; i++ {
-			for j := i + 1; j < ct0.Degree()+1; j++ {
-				eval.ringQ.MulCoeffsMontgomery(c00Q1[i], c0Q1[j], c2Q1[i+j])
-				eval.ringQMul.MulCoeffsMontgomery(c00Q2[i], c0Q2[j], c2Q2[i+j])
-
-				eval.ringQ.Add(c2Q1[i+j], c2Q1[i+j], c2Q1[i+j])
-				eval.ringQMul.Add(c2Q2[i+j], c2Q2[i+j], c2Q2[i+j])
+		for i := 0; i < deg0+1; i++ {
+			for j := i + 1; j < deg0+1; j++ {
+				r.MulCoeffsMontgomery(c00[i], c0[j], c2[i+j])
+				r.Add(c2[i+j], c2[i+j], c2[i+j])
 			}
 		}
 
-		for i := 0; i < ct0.Degree()+1; i++ {
-			eval.ringQ.MulCoeffsMontgomeryAndAdd(c00Q1[i], c0Q1[i], c2Q1[i<<1])
-			eval.ringQMul.MulCoeffsMontgomeryAndAdd(c00Q2[i], c0Q2[i], c2Q2[i<<1])
+		for i := 0; i < deg0+1; i++ {
+			r.MulCoeffsMontgomeryAndAdd(c00[i], c0[i], c2[i<<1])
 		}
 
 		// Normal case
 	} else {
-		for i := range ct0.Value {
-			eval.ringQ.MForm(c0Q1[i], c0Q1[i])
-			eval.ringQMul.MForm(c0Q2[i], c0Q2[i])
-			for j := range ct1.Value {
-				eval.ringQ.MulCoeffsMontgomeryAndAdd(c0Q1[i], c1Q1[j], c2Q1[i+j])
-				eval.ringQMul.MulCoeffsMontgomeryAndAdd(c0Q2[i], c1Q2[j], c2Q2[i+j])
+		for i := 0; i < deg0+1; i++ {
+			r.MForm(c0[i], c0[i])
+			for j := 0; j < deg1+1; j++ {
+				r.MulCoeffsMontgomeryAndAdd(c0[i], c1[j], c2[i+j])
 			}
 		}
 	}
@@ -519,6 +610,33 @@ func (eval *evaluator) MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext)
 	return
 }
 
+// MulPlainThenAdd multiplies ct0 by the plaintext pt and adds the result on ctOut, i.e. ctOut = ctOut + ct0 * pt.
+// This removes the need of storing the intermediate value ct0 * pt.
+// pt must be a *PlaintextMul or a *PlaintextRingT. The degree of ctOut must be at least the degree of ct0.
+func (eval *evaluator) MulPlainThenAdd(ct0 *Ciphertext, pt Operand, ctOut *Ciphertext) {
+
+	el0, elOut := eval.getElemAndCheckUnary(ct0, ctOut, ct0.Degree())
+
+	if ct0.Degree() >= len(eval.poolQ[1]) {
+		panic("cannot MulPlainThenAdd: input degree is too large")
+	}
+
+	tmp := &Ciphertext{&rlwe.Ciphertext{Value: eval.poolQ[1][:ct0.Degree()+1]}}
+
+	switch pt := pt.(type) {
+	case *PlaintextMul:
+		eval.mulPlaintextMul(ct0, pt, tmp)
+	case *PlaintextRingT:
+		eval.mulPlaintextRingT(ct0, pt, tmp)
+	default:
+		panic(fmt.Errorf("invalid operand type for MulPlainThenAdd: %T", pt))
+	}
+
+	for i := range el0.Value {
+		eval.ringQ.Add(elOut.Value[i], tmp.Value[i], elOut.Value[i])
+	}
+}
+
 // relinearize is a method common to Relinearize and RelinearizeNew. It switches ct0 to the NTT domain, applies the keyswitch, and returns the result out of the NTT domain.
 func (eval *evaluator) relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 
@@ -528,7 +646,11 @@ func (eval *evaluator) relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 	}
 
 	for deg := uint64(ct0.Degree()); deg > 1; deg-- {
-		eval.SwitchKeysInPlace(ct0.Value[deg].Level(), ct0.Value[deg], eval.rlk.Keys[deg-2], eval.Pool[1].Q, eval.Pool[2].Q)
+		if eval.parallel != nil {
+			eval.switchKeysInPlaceParallel(ct0.Value[deg], eval.rlk.Keys[deg-2], eval.Pool[1].Q, eval.Pool[2].Q)
+		} else {
+			eval.SwitchKeysInPlace(ct0.Value[deg].Level(), ct0.Value[deg], eval.rlk.Keys[deg-2], eval.Pool[1].Q, eval.Pool[2].Q)
+		}
 		eval.ringQ.Add(ctOut.Value[0], eval.Pool[1].Q, ctOut.Value[0])
 		eval.ringQ.Add(ctOut.Value[1], eval.Pool[2].Q, ctOut.Value[1])
 	}
@@ -636,6 +758,70 @@ func (eval *evaluator) RotateColumnsNew(ct0 *Ciphertext, k int) (ctOut *Cipherte
 	return
 }
 
+// RotateColumnsHoistedNew rotates the columns of ct0 by each of the rotations and returns the results in a map of new
+// Ciphertexts indexed by the rotations. See RotateColumnsHoisted.
+func (eval *evaluator) RotateColumnsHoistedNew(ct0 *Ciphertext, rotations []int) (ctOut map[int]*Ciphertext) {
+	ctOut = make(map[int]*Ciphertext)
+	for _, k := range rotations {
+		ctOut[k] = NewCiphertext(eval.params, 1)
+	}
+	eval.RotateColumnsHoisted(ct0, rotations, ctOut)
+	return
+}
+
+// RotateColumnsHoisted rotates the columns of ct0 by each of the rotations and returns the results in the map of
+// pre-allocated Ciphertexts ctOut, indexed by the rotations, which must not contain ct0.
+// The decomposition of ct0 is computed once and shared by all the rotations, which makes it much faster than
+// sequential calls to RotateColumns. It requires the rotation key of each of the rotations.
+func (eval *evaluator) RotateColumnsHoisted(ct0 *Ciphertext, rotations []int, ctOut map[int]*Ciphertext) {
+
+	if ct0.Degree() != 1 {
+		panic("cannot RotateColumnsHoisted: input must be of degree 1")
+	}
+
+	for _, k := range rotations {
+		if ctOut[k] == nil || ctOut[k].Degree() != 1 {
+			panic(fmt.Sprintf("cannot RotateColumnsHoisted: output for rotation by %d must be of degree 1", k))
+		}
+		if k != 0 {
+			if _, inSet := eval.rtks.GetRotationKey(eval.params.GaloisElementForColumnRotationBy(k)); !inSet {
+				panic(fmt.Errorf("evaluator has no rotation key for rotation by %d", k))
+			}
+		}
+	}
+
+	levelQ := ct0.Level()
+	levelP := eval.params.PCount() - 1
+
+	eval.DecomposeNTT(levelQ, levelP, levelP+1, ct0.Value[1], eval.PoolDecompQP)
+
+	for _, k := range rotations {
+
+		if k == 0 {
+			ctOut[k].Copy(ct0.El())
+			continue
+		}
+
+		galEl := eval.params.GaloisElementForColumnRotationBy(k)
+		swk, _ := eval.rtks.GetRotationKey(galEl)
+
+		eval.KeyswitchHoistedNoModDown(levelQ, eval.PoolDecompQP, swk, eval.Pool[1].Q, eval.Pool[2].Q, eval.Pool[1].P, eval.Pool[2].P)
+
+		eval.ringQ.InvNTTLazyLvl(levelQ, eval.Pool[1].Q, eval.Pool[1].Q)
+		eval.ringQ.InvNTTLazyLvl(levelQ, eval.Pool[2].Q, eval.Pool[2].Q)
+		eval.ringP.InvNTTLazyLvl(levelP, eval.Pool[1].P, eval.Pool[1].P)
+		eval.ringP.InvNTTLazyLvl(levelP, eval.Pool[2].P, eval.Pool[2].P)
+
+		eval.BasisExtender.ModDownQPtoQ(levelQ, levelP, eval.Pool[1].Q, eval.Pool[1].P, eval.Pool[1].Q)
+		eval.BasisExtender.ModDownQPtoQ(levelQ, levelP, eval.Pool[2].Q, eval.Pool[2].P, eval.Pool[2].Q)
+
+		eval.ringQ.Add(eval.Pool[1].Q, ct0.Value[0], eval.Pool[1].Q)
+
+		eval.ringQ.Permute(eval.Pool[1].Q, galEl, ctOut[k].Value[0])
+		eval.ringQ.Permute(eval.Pool[2].Q, galEl, ctOut[k].Value[1])
+	}
+}
+
 // RotateRows rotates the rows of ct0 and returns the result in ctOut.
 func (eval *evaluator) RotateRows(ct0 *Ciphertext, ctOut *Ciphertext) {
 
@@ -680,10 +866,38 @@ func (eval *evaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
 	eval.Add(ctOut, cTmp, ctOut)
 }
 
+// DotProduct computes the dot product of the slots of ct0 and op1, i.e. the sum of their slot-wise products,
+// and returns the result in all the slots of ctOut, which must be of degree 1.
+// If op1 is a Ciphertext, the product is relinearized and the evaluator requires a relinearization key.
+// The evaluator also requires the rotation keys of the Galois elements given by Parameters.GaloisElementsForDotProduct().
+func (eval *evaluator) DotProduct(ct0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot DotProduct: input and output must be of degree 1")
+	}
+
+	tmp := NewCiphertext(eval.params, ct0.Degree()+op1.Degree())
+
+	eval.Mul(ct0, op1, tmp)
+
+	if tmp.Degree() > 1 {
+		eval.Relinearize(tmp, tmp)
+	}
+
+	eval.InnerSum(tmp, ctOut)
+}
+
+// DotProductNew computes the dot product of the slots of ct0 and op1 and returns the result in all the slots of a new Ciphertext.
+func (eval *evaluator) DotProductNew(ct0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.DotProduct(ct0, op1, ctOut)
+	return
+}
+
 // ShallowCopy creates a shallow copy of this evaluator in which the read-only data-structures are
 // shared with the receiver.
 func (eval *evaluator) ShallowCopy() Evaluator {
-	return &evaluator{
+	evalCopy := &evaluator{
 		evaluatorBase:       eval.evaluatorBase,
 		KeySwitcher:         eval.KeySwitcher.ShallowCopy(),
 		evaluatorBuffers:    newEvaluatorBuffer(eval.evaluatorBase),
@@ -691,6 +905,12 @@ func (eval *evaluator) ShallowCopy() Evaluator {
 		rlk:                 eval.rlk,
 		rtks:                eval.rtks,
 	}
+
+	if eval.parallel != nil {
+		evalCopy.parallel = newParallelizer(eval.params, eval.parallel.goroutines)
+	}
+
+	return evalCopy
 }
 
 // WithKey creates a shallow copy of this evaluator in which the read-only data-structures are
@@ -703,6 +923,7 @@ func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
 		basisExtenderQ1toQ2: eval.basisExtenderQ1toQ2,
 		rlk:                 evaluationKey.Rlk,
 		rtks:                evaluationKey.Rtks,
+		parallel:            eval.parallel,
 	}
 }
 
@@ -0,0 +1,57 @@
+package bfv
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// NewMetricsEvaluator creates an Evaluator that performs its operations with eval and records each of them, along with
+// its duration, in metrics as an operation of the component "bfv.Evaluator". If noiseBudget is not nil, the noise
+// budget of the output ciphertext of each operation, e.g. computed by NoiseBudget, is recorded as well. The operations
+// are those reported by NewTracingEvaluator, and the metrics are recorded from the goroutine performing them.
+func NewMetricsEvaluator(eval Evaluator, metrics rlwe.Metrics, noiseBudget func(ct *Ciphertext) float64) Evaluator {
+	return NewTracingEvaluator(eval, func(event OperationEvent) {
+		metrics.ObserveOperation("bfv.Evaluator", event.Operation, event.Duration)
+		if noiseBudget != nil && event.Output != nil {
+			metrics.ObserveNoiseBudget("bfv.Evaluator", event.Operation, noiseBudget(event.Output))
+		}
+	})
+}
+
+// NoiseBudget returns the noise budget in bits of the ciphertext, i.e. log2(Q/(2t)) - log2(||e||), where e is the
+// noise of its decryption with the decryptor: the ciphertext decrypts correctly as long as its noise budget is positive.
+// As it requires the secret key, it is intended for the development and the monitoring of circuits on test data.
+func NoiseBudget(params Parameters, encoder Encoder, decryptor Decryptor, ct *Ciphertext) float64 {
+
+	ringQ := params.RingQ()
+	level := ct.Level()
+
+	// The noise is the difference between the decryption and the encoding of the decrypted message
+	pt := decryptor.DecryptNew(ct)
+	ptMsg := NewPlaintext(params)
+	encoder.EncodeUint(encoder.DecodeUintNew(pt), ptMsg)
+	ringQ.SubLvl(level, pt.Value, ptMsg.Value, pt.Value)
+
+	coeffs := make([]*big.Int, params.N())
+	for i := range coeffs {
+		coeffs[i] = new(big.Int)
+	}
+	ringQ.PolyToBigintCenteredLvl(level, pt.Value, 1, coeffs)
+
+	norm := big.NewInt(1)
+	for i := range coeffs {
+		if coeffs[i].CmpAbs(norm) > 0 {
+			norm.Abs(coeffs[i])
+		}
+	}
+	normFloat, _ := new(big.Float).SetInt(norm).Float64()
+
+	logQ := 0.0
+	for _, qi := range ringQ.Modulus[:level+1] {
+		logQ += math.Log2(float64(qi))
+	}
+
+	return logQ - math.Log2(float64(params.T())) - 1 - math.Log2(normFloat)
+}
@@ -0,0 +1,297 @@
+package bfv
+
+import (
+	"math"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// limbChunk is a range of consecutive RNS limbs of a ring, along with the ring of the corresponding moduli.
+type limbChunk struct {
+	ring       *ring.Ring
+	start, end int
+}
+
+// view returns a polynomial sharing the limbs of the chunk with p.
+func (c limbChunk) view(p *ring.Poly) *ring.Poly {
+	return &ring.Poly{Coeffs: p.Coeffs[c.start:c.end], IsNTT: p.IsNTT, IsMForm: p.IsMForm}
+}
+
+// views returns the views of the chunk of a slice of polynomials.
+func (c limbChunk) views(ps []*ring.Poly) (vs []*ring.Poly) {
+	vs = make([]*ring.Poly, len(ps))
+	for i := range ps {
+		vs[i] = c.view(ps[i])
+	}
+	return
+}
+
+// splitLimbs partitions the limbs of r into at most n chunks of consecutive limbs of balanced sizes.
+func splitLimbs(r *ring.Ring, n int) (chunks []limbChunk) {
+
+	limbs := len(r.Modulus)
+	if n > limbs {
+		n = limbs
+	}
+
+	chunks = make([]limbChunk, n)
+	for i := range chunks {
+		start, end := i*limbs/n, (i+1)*limbs/n
+		chunks[i] = limbChunk{ring: r.SubRing(start, end), start: start, end: end}
+	}
+
+	return
+}
+
+// parallelizer distributes the operations of the multiplication and of the relinearization on a bounded number
+// of goroutines. The operations independent for each modulus (NTT, tensoring, inner products with the keys) are
+// split over chunks of RNS limbs, and the basis extensions are split over the components of the ciphertexts.
+type parallelizer struct {
+	goroutines int
+
+	chunksQ    []limbChunk
+	chunksQMul []limbChunk
+	chunksP    []limbChunk
+
+	// One per goroutine for the quantization of the tensor product
+	basisExtendersQ1toQ2 []*ring.BasisExtender
+
+	// One per output polynomial of the key-switching
+	basisExtendersQP [2]*ring.BasisExtender
+}
+
+func newParallelizer(params Parameters, goroutines int) (p *parallelizer) {
+
+	p = &parallelizer{goroutines: goroutines}
+
+	p.chunksQ = splitLimbs(params.RingQ(), goroutines)
+	p.chunksQMul = splitLimbs(params.RingQMul(), goroutines)
+
+	p.basisExtendersQ1toQ2 = make([]*ring.BasisExtender, goroutines)
+	for i := range p.basisExtendersQ1toQ2 {
+		p.basisExtendersQ1toQ2[i] = ring.NewBasisExtender(params.RingQ(), params.RingQMul())
+	}
+
+	if params.PCount() != 0 {
+		p.chunksP = splitLimbs(params.RingP(), goroutines)
+		for i := range p.basisExtendersQP {
+			p.basisExtendersQP[i] = ring.NewBasisExtender(params.RingQ(), params.RingP())
+		}
+	}
+
+	return
+}
+
+// run calls f(i, worker) for 0 <= i < n on at most p.goroutines goroutines, where worker identifies the goroutine.
+func (p *parallelizer) run(n int, f func(i, worker int)) {
+
+	workers := p.goroutines
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < n; i += workers {
+				f(i, w)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// runOnLimbs calls fA on each chunk of chunksA and fB on each chunk of chunksB, concurrently.
+func (p *parallelizer) runOnLimbs(chunksA []limbChunk, fA func(c limbChunk), chunksB []limbChunk, fB func(c limbChunk)) {
+	p.run(len(chunksA)+len(chunksB), func(i, _ int) {
+		if i < len(chunksA) {
+			fA(chunksA[i])
+		} else {
+			fB(chunksB[i-len(chunksA)])
+		}
+	})
+}
+
+// WithParallelism creates a shallow copy of this evaluator in which the multiplications of ciphertexts and
+// the relinearizations use at most goroutines goroutines, by splitting their operations over the RNS limbs
+// and the components of the ciphertexts. The results are identical to the ones of the sequential evaluation.
+// A value of goroutines smaller than 2 disables the parallelization.
+func (eval *evaluator) WithParallelism(goroutines int) Evaluator {
+
+	evalCopy := eval.ShallowCopy().(*evaluator)
+
+	if goroutines > 1 {
+		evalCopy.parallel = newParallelizer(eval.params, goroutines)
+	} else {
+		evalCopy.parallel = nil
+	}
+
+	return evalCopy
+}
+
+// tensorAndRescaleParallel is the parallel counterpart of tensorAndRescale.
+func (eval *evaluator) tensorAndRescaleParallel(ct0, ct1, ctOut *rlwe.Ciphertext) {
+
+	p := eval.parallel
+
+	levelQ := len(eval.ringQ.Modulus) - 1
+	levelQMul := len(eval.ringQMul.Modulus) - 1
+
+	square := ct0 == ct1
+
+	// Extends the basis of each component from Q to QMul
+	inputs := append([]*ring.Poly{}, ct0.Value...)
+	outQ := append([]*ring.Poly{}, eval.poolQ[0][:ct0.Degree()+1]...)
+	outQMul := append([]*ring.Poly{}, eval.poolQmul[0][:ct0.Degree()+1]...)
+	if !square {
+		inputs = append(inputs, ct1.Value...)
+		outQ = append(outQ, eval.poolQ[1][:ct1.Degree()+1]...)
+		outQMul = append(outQMul, eval.poolQmul[1][:ct1.Degree()+1]...)
+	}
+
+	p.run(len(inputs), func(i, worker int) {
+		p.basisExtendersQ1toQ2[worker].ModUpQtoP(levelQ, levelQMul, inputs[i], outQMul[i])
+	})
+
+	p.runOnLimbs(
+		p.chunksQ, func(c limbChunk) {
+			for i := range inputs {
+				c.ring.NTTLazy(c.view(inputs[i]), c.view(outQ[i]))
+			}
+		},
+		p.chunksQMul, func(c limbChunk) {
+			for i := range outQMul {
+				c.ring.NTTLazy(c.view(outQMul[i]), c.view(outQMul[i]))
+			}
+		})
+
+	// Tensoring on each chunk of limbs
+	tensor := func(c limbChunk, pool [][]*ring.Poly) {
+		if ct0.Degree() == 1 && ct1.Degree() == 1 {
+			tensorLowDeg(c.ring, c.views(pool[0]), c.views(pool[1]), c.views(pool[2]), c.views(pool[3]), square)
+		} else {
+			tensorLargeDeg(c.ring, c.views(pool[0]), c.views(pool[1]), c.views(pool[2]), c.views(pool[3]), ct0.Degree(), ct1.Degree(), square)
+		}
+	}
+
+	p.runOnLimbs(
+		p.chunksQ, func(c limbChunk) { tensor(c, eval.poolQ) },
+		p.chunksQMul, func(c limbChunk) { tensor(c, eval.poolQmul) })
+
+	// Quantization
+	c2Q1 := eval.poolQ[2][:len(ctOut.Value)]
+	c2Q2 := eval.poolQmul[2][:len(ctOut.Value)]
+
+	p.runOnLimbs(
+		p.chunksQ, func(c limbChunk) {
+			for i := range c2Q1 {
+				c.ring.InvNTTLazy(c.view(c2Q1[i]), c.view(c2Q1[i]))
+			}
+		},
+		p.chunksQMul, func(c limbChunk) {
+			for i := range c2Q2 {
+				c.ring.InvNTTLazy(c.view(c2Q2[i]), c.view(c2Q2[i]))
+			}
+		})
+
+	p.run(len(ctOut.Value), func(i, worker int) {
+		basisExtender := p.basisExtendersQ1toQ2[worker]
+		basisExtender.ModDownQPtoP(levelQ, levelQMul, c2Q1[i], c2Q2[i], c2Q2[i])
+		eval.ringQMul.AddScalarBigint(c2Q2[i], eval.pHalf, c2Q2[i])
+		basisExtender.ModUpPtoQ(levelQMul, levelQ, c2Q2[i], ctOut.Value[i])
+		eval.ringQ.SubScalarBigint(ctOut.Value[i], eval.pHalf, ctOut.Value[i])
+		eval.ringQ.MulScalar(ctOut.Value[i], eval.t, ctOut.Value[i])
+	})
+}
+
+// switchKeysInPlaceParallel is the parallel counterpart of SwitchKeysInPlace for a polynomial cx outside of the NTT domain.
+func (eval *evaluator) switchKeysInPlaceParallel(cx *ring.Poly, evakey *rlwe.SwitchingKey, p0, p1 *ring.Poly) {
+
+	p := eval.parallel
+
+	levelQ := len(eval.ringQ.Modulus) - 1
+	levelP := len(evakey.Value[0][0].P.Coeffs) - 1
+	alpha := levelP + 1
+	beta := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
+
+	cxNTT := eval.PoolInvNTT
+	c0P := eval.Pool[1].P
+	c1P := eval.Pool[2].P
+	decomp := eval.PoolDecompQP
+
+	p.run(len(p.chunksQ), func(i, _ int) {
+		c := p.chunksQ[i]
+		c.ring.NTT(c.view(cx), c.view(cxNTT))
+	})
+
+	// Decomposes cx, one digit per task
+	p.run(beta, func(i, _ int) {
+		eval.DecomposeSingleNTT(levelQ, levelP, alpha, i, cxNTT, cx, decomp[i].Q, decomp[i].P)
+	})
+
+	// Inner products of the digits with the key on each chunk of limbs
+	innerProduct := func(c limbChunk, key func(i, j int) *ring.Poly, digit func(i int) *ring.Poly, out0, out1 *ring.Poly, overflowMargin int) {
+
+		r := c.ring
+		out0, out1 = c.view(out0), c.view(out1)
+
+		for i := 0; i < beta; i++ {
+
+			if i == 0 {
+				r.MulCoeffsMontgomeryConstant(c.view(key(i, 0)), c.view(digit(i)), out0)
+				r.MulCoeffsMontgomeryConstant(c.view(key(i, 1)), c.view(digit(i)), out1)
+			} else {
+				r.MulCoeffsMontgomeryConstantAndAddNoMod(c.view(key(i, 0)), c.view(digit(i)), out0)
+				r.MulCoeffsMontgomeryConstantAndAddNoMod(c.view(key(i, 1)), c.view(digit(i)), out1)
+			}
+
+			if i%overflowMargin == overflowMargin-1 {
+				r.Reduce(out0, out0)
+				r.Reduce(out1, out1)
+			}
+		}
+
+		if beta%overflowMargin != 0 {
+			r.Reduce(out0, out0)
+			r.Reduce(out1, out1)
+		}
+	}
+
+	QiOverF := eval.params.QiOverflowMargin(levelQ) >> 1
+	PiOverF := eval.params.PiOverflowMargin(levelP) >> 1
+
+	p.runOnLimbs(
+		p.chunksQ, func(c limbChunk) {
+			innerProduct(c,
+				func(i, j int) *ring.Poly { return evakey.Value[i][j].Q },
+				func(i int) *ring.Poly { return decomp[i].Q },
+				p0, p1, QiOverF)
+		},
+		p.chunksP, func(c limbChunk) {
+			innerProduct(c,
+				func(i, j int) *ring.Poly { return evakey.Value[i][j].P },
+				func(i int) *ring.Poly { return decomp[i].P },
+				c0P, c1P, PiOverF)
+		})
+
+	// Divides by P
+	p.runOnLimbs(
+		p.chunksQ, func(c limbChunk) {
+			c.ring.InvNTTLazy(c.view(p0), c.view(p0))
+			c.ring.InvNTTLazy(c.view(p1), c.view(p1))
+		},
+		p.chunksP, func(c limbChunk) {
+			c.ring.InvNTTLazy(c.view(c0P), c.view(c0P))
+			c.ring.InvNTTLazy(c.view(c1P), c.view(c1P))
+		})
+
+	outQ := [2]*ring.Poly{p0, p1}
+	outP := [2]*ring.Poly{c0P, c1P}
+	p.run(2, func(i, _ int) {
+		p.basisExtendersQP[i].ModDownQPtoQ(levelQ, levelP, outQ[i], outP[i], outQ[i])
+	})
+}
@@ -0,0 +1,318 @@
+package bfv
+
+import (
+	"time"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// OperationEvent describes an operation performed by an Evaluator created with NewTracingEvaluator.
+// The operands are referenced for identification only, e.g. to extract the circuit being evaluated,
+// and must not be modified by the hook.
+type OperationEvent struct {
+	Operation    string        // Name of the Evaluator method
+	Inputs       []Operand     // Input operands, in the order of the method arguments
+	InputDegrees []int         // Degrees of the input operands before the operation
+	Output       *Ciphertext   // Output ciphertext
+	OutputDegree int           // Degree of the output ciphertext
+	Duration     time.Duration // Duration of the operation
+}
+
+// TraceHook is a function called after each operation of an Evaluator created with NewTracingEvaluator.
+// A channel can be used as a hook with a function sending the events on it.
+type TraceHook func(event OperationEvent)
+
+// tracingEvaluator is an Evaluator that reports the operations of the underlying Evaluator to a TraceHook.
+type tracingEvaluator struct {
+	Evaluator
+	hook TraceHook
+}
+
+// NewTracingEvaluator creates an Evaluator that performs its operations with eval and reports each of them,
+// along with its inputs, output and duration, to hook. Operations are reported at the granularity of the
+// calls made on the returned Evaluator: the operations performed internally by a method are not reported.
+// The methods with several output ciphertexts (e.g. RotateColumnsHoisted) are not reported.
+// The hook is called from the goroutine performing the operation, so it must be safe for concurrent use
+// if shallow copies of the returned Evaluator are used concurrently.
+func NewTracingEvaluator(eval Evaluator, hook TraceHook) Evaluator {
+	return &tracingEvaluator{Evaluator: eval, hook: hook}
+}
+
+// trace records the inputs of an operation and returns the function reporting its output to the hook.
+func (eval *tracingEvaluator) trace(operation string, inputs ...Operand) func(ctOut *Ciphertext) {
+
+	degrees := make([]int, len(inputs))
+	for i := range inputs {
+		degrees[i] = inputs[i].Degree()
+	}
+
+	start := time.Now()
+
+	return func(ctOut *Ciphertext) {
+
+		duration := time.Since(start)
+
+		outDegree := -1
+		if ctOut != nil {
+			outDegree = ctOut.Degree()
+		}
+
+		eval.hook(OperationEvent{
+			Operation:    operation,
+			Inputs:       inputs,
+			InputDegrees: degrees,
+			Output:       ctOut,
+			OutputDegree: outDegree,
+			Duration:     duration,
+		})
+	}
+}
+
+func ciphertextsToOperands(cts []*Ciphertext) (ops []Operand) {
+	ops = make([]Operand, len(cts))
+	for i := range cts {
+		ops[i] = cts[i]
+	}
+	return
+}
+
+func (eval *tracingEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("Add", op0, op1)
+	eval.Evaluator.Add(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("AddNew", op0, op1)
+	ctOut = eval.Evaluator.AddNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("AddNoMod", op0, op1)
+	eval.Evaluator.AddNoMod(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("AddNoModNew", op0, op1)
+	ctOut = eval.Evaluator.AddNoModNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("Sub", op0, op1)
+	eval.Evaluator.Sub(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("SubNew", op0, op1)
+	ctOut = eval.Evaluator.SubNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("SubNoMod", op0, op1)
+	eval.Evaluator.SubNoMod(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("SubNoModNew", op0, op1)
+	ctOut = eval.Evaluator.SubNoModNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Neg(op Operand, ctOut *Ciphertext) {
+	done := eval.trace("Neg", op)
+	eval.Evaluator.Neg(op, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) NegNew(op Operand) (ctOut *Ciphertext) {
+	done := eval.trace("NegNew", op)
+	ctOut = eval.Evaluator.NegNew(op)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Reduce(op Operand, ctOut *Ciphertext) {
+	done := eval.trace("Reduce", op)
+	eval.Evaluator.Reduce(op, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) ReduceNew(op Operand) (ctOut *Ciphertext) {
+	done := eval.trace("ReduceNew", op)
+	ctOut = eval.Evaluator.ReduceNew(op)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) MulScalar(op Operand, scalar uint64, ctOut *Ciphertext) {
+	done := eval.trace("MulScalar", op)
+	eval.Evaluator.MulScalar(op, scalar, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext) {
+	done := eval.trace("MulScalarNew", op)
+	ctOut = eval.Evaluator.MulScalarNew(op, scalar)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) DivScalar(op Operand, scalar uint64, ctOut *Ciphertext) {
+	done := eval.trace("DivScalar", op)
+	eval.Evaluator.DivScalar(op, scalar, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) DivScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext) {
+	done := eval.trace("DivScalarNew", op)
+	ctOut = eval.Evaluator.DivScalarNew(op, scalar)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) MulScalarThenAdd(op Operand, scalar uint64, ctOut *Ciphertext) {
+	done := eval.trace("MulScalarThenAdd", op)
+	eval.Evaluator.MulScalarThenAdd(op, scalar, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) LinearCombination(cts []*Ciphertext, consts []uint64, ctOut *Ciphertext) {
+	done := eval.trace("LinearCombination", ciphertextsToOperands(cts)...)
+	eval.Evaluator.LinearCombination(cts, consts, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) LinearCombinationNew(cts []*Ciphertext, consts []uint64) (ctOut *Ciphertext) {
+	done := eval.trace("LinearCombinationNew", ciphertextsToOperands(cts)...)
+	ctOut = eval.Evaluator.LinearCombinationNew(cts, consts)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("Mul", op0, op1)
+	eval.Evaluator.Mul(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("MulNew", op0, op1)
+	ctOut = eval.Evaluator.MulNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) MulPlainThenAdd(ct0 *Ciphertext, pt Operand, ctOut *Ciphertext) {
+	done := eval.trace("MulPlainThenAdd", ct0, pt)
+	eval.Evaluator.MulPlainThenAdd(ct0, pt, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) Relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("Relinearize", ct0)
+	eval.Evaluator.Relinearize(ct0, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("RelinearizeNew", ct0)
+	ctOut = eval.Evaluator.RelinearizeNew(ct0)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) SwitchKeys(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey, ctOut *Ciphertext) {
+	done := eval.trace("SwitchKeys", ct0)
+	eval.Evaluator.SwitchKeys(ct0, switchKey, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) SwitchKeysNew(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey) (ctOut *Ciphertext) {
+	done := eval.trace("SwitchKeysNew", ct0)
+	ctOut = eval.Evaluator.SwitchKeysNew(ct0, switchKey)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
+	done := eval.trace("RotateColumns", ct0)
+	eval.Evaluator.RotateColumns(ct0, k, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) RotateColumnsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
+	done := eval.trace("RotateColumnsNew", ct0)
+	ctOut = eval.Evaluator.RotateColumnsNew(ct0, k)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) RotateRows(ct0 *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("RotateRows", ct0)
+	eval.Evaluator.RotateRows(ct0, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("RotateRowsNew", ct0)
+	ctOut = eval.Evaluator.RotateRowsNew(ct0)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("InnerSum", ct0)
+	eval.Evaluator.InnerSum(ct0, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) Permute(ct0 *Ciphertext, plan *PermutationPlan, ctOut *Ciphertext) {
+	done := eval.trace("Permute", ct0)
+	eval.Evaluator.Permute(ct0, plan, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) PermuteNew(ct0 *Ciphertext, plan *PermutationPlan) (ctOut *Ciphertext) {
+	done := eval.trace("PermuteNew", ct0)
+	ctOut = eval.Evaluator.PermuteNew(ct0, plan)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) DotProduct(ct0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("DotProduct", ct0, op1)
+	eval.Evaluator.DotProduct(ct0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) DotProductNew(ct0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("DotProductNew", ct0, op1)
+	ctOut = eval.Evaluator.DotProductNew(ct0, op1)
+	done(ctOut)
+	return
+}
+
+// ShallowCopy creates a shallow copy of the underlying Evaluator and returns it wrapped with the same hook.
+func (eval *tracingEvaluator) ShallowCopy() Evaluator {
+	return NewTracingEvaluator(eval.Evaluator.ShallowCopy(), eval.hook)
+}
+
+// WithKey creates a shallow copy of the underlying Evaluator with the new key and returns it wrapped with the same hook.
+func (eval *tracingEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return NewTracingEvaluator(eval.Evaluator.WithKey(evaluationKey), eval.hook)
+}
+
+// WithParallelism creates a shallow copy of the underlying Evaluator with the given goroutine budget and returns it wrapped with the same hook.
+func (eval *tracingEvaluator) WithParallelism(goroutines int) Evaluator {
+	return NewTracingEvaluator(eval.Evaluator.WithParallelism(goroutines), eval.hook)
+}
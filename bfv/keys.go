@@ -1,12 +1,21 @@
 package bfv
 
-import "github.com/ldsec/lattigo/v2/rlwe"
+import (
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
 
 // NewKeyGenerator creates a rlwe.KeyGenerator instance from the BFV parameters.
 func NewKeyGenerator(params Parameters) rlwe.KeyGenerator {
 	return rlwe.NewKeyGenerator(params.Parameters)
 }
 
+// NewKeyGeneratorWithPRNG creates a new KeyGenerator which samples the keys from the given PRNG
+// (see rlwe.NewKeyGeneratorWithPRNG).
+func NewKeyGeneratorWithPRNG(params Parameters, prng utils.PRNG) rlwe.KeyGenerator {
+	return rlwe.NewKeyGeneratorWithPRNG(params.Parameters, prng)
+}
+
 // NewSecretKey returns an allocated BFV secret key with zero values.
 func NewSecretKey(params Parameters) (sk *rlwe.SecretKey) {
 	return rlwe.NewSecretKey(params.Parameters)
@@ -0,0 +1,95 @@
+package bfv
+
+import (
+	"fmt"
+)
+
+// RangeMask returns the mask of slots values selecting the slots of indexes in [start, end).
+func RangeMask(slots, start, end int) (mask []uint64) {
+
+	if start < 0 || end > slots || start > end {
+		panic(fmt.Sprintf("cannot RangeMask: invalid range [%d, %d) for %d slots", start, end, slots))
+	}
+
+	mask = make([]uint64, slots)
+	for i := start; i < end; i++ {
+		mask[i] = 1
+	}
+
+	return
+}
+
+// OneHotMask returns the mask of slots values selecting the slot of the given index.
+func OneHotMask(slots, index int) (mask []uint64) {
+	return RangeMask(slots, index, index+1)
+}
+
+// GaloisElementsForDuplicateSlot returns the Galois elements of the rotation keys required by Masker.DuplicateSlotNew.
+func (p Parameters) GaloisElementsForDuplicateSlot() []uint64 {
+	return p.GaloisElementsForRowInnerSum()
+}
+
+// Masker multiplies ciphertexts by masks, i.e. vectors of N values modulo t (usually zeros and ones) selecting their
+// slots. The index of a slot is its position in the vector given to the Encoder, i.e. the slots of the first row
+// followed by the slots of the second row.
+type Masker struct {
+	params  Parameters
+	eval    Evaluator
+	encoder Encoder
+}
+
+// NewMasker creates a new Masker from an Evaluator and an Encoder.
+func NewMasker(params Parameters, eval Evaluator, encoder Encoder) *Masker {
+	return &Masker{params: params, eval: eval, encoder: encoder}
+}
+
+// EncodeMaskNew encodes the mask on a new PlaintextMul, which can be multiplied with many ciphertexts.
+func (m *Masker) EncodeMaskNew(mask []uint64) (pt *PlaintextMul) {
+	pt = NewPlaintextMul(m.params)
+	m.encoder.EncodeUintMul(mask, pt)
+	return
+}
+
+// MaskNew returns ctIn multiplied by the mask on a new ciphertext.
+func (m *Masker) MaskNew(ctIn *Ciphertext, mask []uint64) (ctOut *Ciphertext) {
+	return m.eval.MulNew(ctIn, m.EncodeMaskNew(mask))
+}
+
+// MaskRangeNew returns a new ciphertext in which the slots of ctIn of indexes in [start, end) are kept and the others
+// are set to zero.
+func (m *Masker) MaskRangeNew(ctIn *Ciphertext, start, end int) (ctOut *Ciphertext) {
+	return m.MaskNew(ctIn, RangeMask(m.params.N(), start, end))
+}
+
+// DuplicateSlotNew returns a new ciphertext whose slots are all equal to the slot of the given index of ctIn.
+// ctIn must be of degree 1. It requires the rotation keys of Parameters.GaloisElementsForDuplicateSlot.
+func (m *Masker) DuplicateSlotNew(ctIn *Ciphertext, index int) (ctOut *Ciphertext) {
+	// The sum of all the slots of a vector with a single non-zero slot copies this slot everywhere.
+	ctOut = m.MaskNew(ctIn, OneHotMask(m.params.N(), index))
+	m.eval.InnerSum(ctOut, ctOut)
+	return
+}
+
+// MergeNew returns the sum of the ciphertexts of cts, each multiplied by the mask of same index of masks, on a new
+// ciphertext. With disjoint masks, this merges the selected slots of each ciphertext in a single ciphertext.
+func (m *Masker) MergeNew(cts []*Ciphertext, masks [][]uint64) (ctOut *Ciphertext) {
+
+	if len(cts) == 0 || len(cts) != len(masks) {
+		panic("cannot MergeNew: the number of ciphertexts and masks must be equal and non-zero")
+	}
+
+	degree := 0
+	for _, ct := range cts {
+		if ct.Degree() > degree {
+			degree = ct.Degree()
+		}
+	}
+
+	ctOut = NewCiphertext(m.params, degree)
+
+	for i := range cts {
+		m.eval.MulPlainThenAdd(cts[i], m.EncodeMaskNew(masks[i]), ctOut)
+	}
+
+	return
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
@@ -161,6 +162,21 @@ func (p Parameters) T() uint64 {
 	return p.ringT.Modulus[0]
 }
 
+// InverseModT returns the inverse of x modulo the plaintext modulus t.
+// It returns an error if x is not invertible modulo t.
+func (p Parameters) InverseModT(x uint64) (uint64, error) {
+	inv := new(big.Int).ModInverse(new(big.Int).SetUint64(x), new(big.Int).SetUint64(p.T()))
+	if inv == nil {
+		return 0, fmt.Errorf("%d is not invertible modulo t=%d", x, p.T())
+	}
+	return inv.Uint64(), nil
+}
+
+// GaloisElementsForDotProduct returns the Galois elements of the rotation keys required by Evaluator.DotProduct.
+func (p Parameters) GaloisElementsForDotProduct() []uint64 {
+	return p.GaloisElementsForRowInnerSum()
+}
+
 // RingT returns a pointer to the plaintext ring
 func (p Parameters) RingT() *ring.Ring {
 	return p.ringT
@@ -200,20 +216,21 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary decodes a []byte into a parameter set struct.
 func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
-	if err := p.Parameters.UnmarshalBinary(data); err != nil {
+	var rlweParams rlwe.Parameters
+	if err := rlweParams.UnmarshalBinary(data); err != nil {
 		return err
 	}
-	dataBfv := data[len(data)-8:]
 
-	nbQiMul := int(math.Ceil(float64(p.RingQ().ModulusBigint.BitLen()+p.LogN()) / 61.0))
-	if p.ringQMul, err = ring.NewRing(p.N(), ring.GenerateNTTPrimesP(61, 2*p.N(), nbQiMul)); err != nil {
+	// The plaintext modulus follows the RLWE parameters in the decompressed encoding
+	if _, data, err = rlwe.DecodeFormat(data); err != nil {
 		return err
 	}
-
-	if p.ringT, err = ring.NewRing(p.N(), []uint64{binary.BigEndian.Uint64(dataBfv)}); err != nil {
-		return err
+	if len(data) != rlweParams.MarshalBinarySize()-rlwe.FormatHeaderLen+8 {
+		return fmt.Errorf("invalid bfv.Parameters serialization")
 	}
-	return nil
+
+	*p, err = NewParameters(rlweParams, binary.BigEndian.Uint64(data[len(data)-8:]))
+	return err
 }
 
 // MarshalBinarySize returns the length of the []byte encoding of the reciever.
@@ -0,0 +1,66 @@
+package bfv
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// GenParams generates a ParametersLiteral supporting circuits of the given multiplicative depth,
+// with relinearization after each multiplication, for the plaintext modulus t and the target security level.
+// It selects the smallest ring degree whose security bound admits the modulus chain given by a heuristic
+// estimate of the noise growth, and returns a literal specifying the bit-sizes of the moduli, whose primes
+// are generated when the literal is instantiated with NewParametersFromLiteral.
+// It returns an error if no supported ring degree fits the modulus chain or if t is not congruent to 1 modulo 2N.
+func GenParams(depth int, t uint64, security rlwe.SecurityLevel) (pl ParametersLiteral, err error) {
+
+	if depth < 0 {
+		return ParametersLiteral{}, fmt.Errorf("invalid depth: must be non-negative but is %d", depth)
+	}
+
+	if t < 2 {
+		return ParametersLiteral{}, fmt.Errorf("invalid plaintext modulus t=%d", t)
+	}
+
+	logT := bits.Len64(t)
+
+	for logN := rlwe.MinLogN; logN < rlwe.MaxLogN+1; logN++ {
+
+		var maxLogQP int
+		if maxLogQP, err = rlwe.MaxLogQP(logN, security); err != nil {
+			continue
+		}
+
+		// Decryption bound Q/(2t), fresh noise and safety margin, plus the noise growth
+		// of each multiplication followed by a relinearization, which is roughly t * N.
+		logQ := logT + 1 + logN/2 + 4 + 8 + depth*(logT+logN+6)
+
+		logQi := rlwe.ModuliLogSizes(logQ, rlwe.MaxModuliSize)
+
+		// A single special prime larger than all the Qi keeps the key-switching noise negligible
+		logP := []int{logQi[0] + 1}
+
+		if logQ+logP[0] > maxLogQP {
+			continue
+		}
+
+		if len(logQi) > rlwe.MaxModuliCount {
+			break
+		}
+
+		if t%(2<<logN) != 1 {
+			return ParametersLiteral{}, fmt.Errorf("t=%d is not congruent to 1 modulo 2N=%d, which is required for the batching", t, 2<<logN)
+		}
+
+		return ParametersLiteral{
+			LogN:  logN,
+			LogQ:  logQi,
+			LogP:  logP,
+			Sigma: rlwe.DefaultSigma,
+			T:     t,
+		}, nil
+	}
+
+	return ParametersLiteral{}, fmt.Errorf("no supported ring degree ensures %s for depth=%d and t=%d", security, depth, t)
+}
@@ -0,0 +1,285 @@
+package bfv
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PermutationPlan is a precomputed homomorphic evaluation of an arbitrary permutation of the plaintext slots.
+//
+// The slots are seen as the 2 x N/2 matrix of the batching, and the permutation is decomposed with a Beneš
+// network over the bits of the slot indexes. Each of the 2*log(N)-1 layers of the network exchanges pairs of
+// slots whose indexes differ by a single bit, i.e. slots of the same row at a distance that is a power of two
+// (column rotations) or slots of the same column (row rotation).
+// The layers are merged into consecutive groups, and each group is evaluated as a sum of rotations of the
+// ciphertext multiplied by 0/1 masks. Each group consumes one ciphertext-plaintext multiplication: fewer groups
+// reduce the noise growth at the cost of more rotations and rotation keys.
+type PermutationPlan struct {
+	params Parameters
+	layers [][]permutationTerm
+}
+
+// permutationTerm is a rotation of the input of a group followed by the multiplication by a mask.
+type permutationTerm struct {
+	rowRotation bool
+	k           int
+	mask        *PlaintextMul
+}
+
+// NewPermutationPlan creates a new PermutationPlan for the slot permutation given by permutation,
+// i.e. such that slot i of the output is slot permutation[i] of the input, evaluated with at most
+// depth ciphertext-plaintext multiplications.
+// The depth must be between 1, where the permutation is evaluated with a single sum of masked rotations,
+// and 2*log(N)-1, where each layer of the network is evaluated separately with at most three rotations.
+// It returns an error if permutation is not a permutation of the N slots or if the depth is invalid.
+func NewPermutationPlan(params Parameters, permutation []int, depth int) (pp *PermutationPlan, err error) {
+
+	N := params.N()
+	logN := params.LogN()
+
+	if len(permutation) != N {
+		return nil, fmt.Errorf("invalid permutation: length must be %d but is %d", N, len(permutation))
+	}
+
+	src := make([]int, N)
+	dst := make([]int, N)
+	seen := make([]bool, N)
+	for i, j := range permutation {
+		if j < 0 || j >= N || seen[j] {
+			return nil, fmt.Errorf("invalid permutation: index %d is out of range or repeated", j)
+		}
+		seen[j] = true
+		src[i], dst[i] = j, i
+	}
+
+	nbLayers := 2*logN - 1
+
+	if depth < 1 || depth > nbLayers {
+		return nil, fmt.Errorf("invalid depth: must be between 1 and %d but is %d", nbLayers, depth)
+	}
+
+	layers := make([][]int, nbLayers)
+	for i := range layers {
+		layers[i] = make([]int, N)
+	}
+
+	benesRoute(src, dst, 0, logN, layers)
+
+	encoder := NewEncoder(params)
+
+	pp = &PermutationPlan{params: params}
+
+	for g := 0; g < depth; g++ {
+
+		// Composes the layers of the group
+		group := make([]int, N)
+		identity := true
+		for x := range group {
+			y := x
+			for _, layer := range layers[g*nbLayers/depth : (g+1)*nbLayers/depth] {
+				y = layer[y]
+			}
+			group[x] = y
+			identity = identity && x == y
+		}
+
+		if !identity {
+			pp.layers = append(pp.layers, genPermutationTerms(params, encoder, group))
+		}
+	}
+
+	return pp, nil
+}
+
+// benesRoute fills the layers of a Beneš network routing the element at position src[e] to the position dst[e],
+// where the positions only differ on the bits [bit, logN). The sub-network on these bits uses the layers
+// bit and 2*(logN-1)-bit, and each layer maps a position to the position of its element after the layer.
+func benesRoute(src, dst []int, bit, logN int, layers [][]int) {
+
+	if bit == logN-1 {
+		for e := range src {
+			layers[bit][src[e]] = dst[e]
+		}
+		return
+	}
+
+	mask := 1 << bit
+
+	inAt := make(map[int]int, len(src))
+	outAt := make(map[int]int, len(dst))
+	for e := range src {
+		inAt[src[e]] = e
+		outAt[dst[e]] = e
+	}
+
+	// Two-colors the elements such that the elements sharing an input switch or an output switch go to
+	// different sub-networks, by following the cycles alternating between input and output switches.
+	color := make([]int, len(src))
+	for e := range color {
+		color[e] = -1
+	}
+
+	for e0 := range src {
+		for e := e0; color[e] == -1; {
+			color[e] = 0
+			f := inAt[src[e]^mask]
+			color[f] = 1
+			e = outAt[dst[f]^mask]
+		}
+	}
+
+	var subSrc, subDst [2][]int
+	for e := range src {
+		c := color[e]
+		x := src[e]&^mask | c*mask
+		y := dst[e]&^mask | c*mask
+		layers[bit][src[e]] = x
+		layers[2*(logN-1)-bit][y] = dst[e]
+		subSrc[c] = append(subSrc[c], x)
+		subDst[c] = append(subDst[c], y)
+	}
+
+	for c := 0; c < 2; c++ {
+		benesRoute(subSrc[c], subDst[c], bit+1, logN, layers)
+	}
+}
+
+// genPermutationTerms decomposes the permutation moving the slot x to the slot group[x] into masked rotations.
+func genPermutationTerms(params Parameters, encoder Encoder, group []int) (terms []permutationTerm) {
+
+	N := params.N()
+	m := N >> 1
+
+	type rotation struct {
+		rowRotation bool
+		k           int
+	}
+
+	masks := make(map[rotation][]uint64)
+
+	for x, y := range group {
+		// Slot y of the rotated ciphertext is slot x of the input
+		rot := rotation{rowRotation: x/m != y/m, k: ((x % m) - (y % m) + m) % m}
+		if _, ok := masks[rot]; !ok {
+			masks[rot] = make([]uint64, N)
+		}
+		masks[rot][y] = 1
+	}
+
+	for rot, mask := range masks {
+		pt := NewPlaintextMul(params)
+		encoder.EncodeUintMul(mask, pt)
+		terms = append(terms, permutationTerm{rowRotation: rot.rowRotation, k: rot.k, mask: pt})
+	}
+
+	// Deterministic order, with the terms sharing a row rotation evaluated consecutively
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].rowRotation != terms[j].rowRotation {
+			return !terms[i].rowRotation
+		}
+		return terms[i].k < terms[j].k
+	})
+
+	return
+}
+
+// Depth returns the number of ciphertext-plaintext multiplications of the evaluation of the permutation.
+func (pp *PermutationPlan) Depth() int {
+	return len(pp.layers)
+}
+
+// Rotations returns the number of column and row rotations of the evaluation of the permutation.
+func (pp *PermutationPlan) Rotations() (rotations int) {
+	for _, layer := range pp.layers {
+		rowRotation := false
+		for _, term := range layer {
+			if term.k != 0 {
+				rotations++
+			}
+			if term.rowRotation && !rowRotation {
+				rotations++
+				rowRotation = true
+			}
+		}
+	}
+	return
+}
+
+// GaloisElements returns the Galois elements of the rotation keys required to evaluate the permutation.
+func (pp *PermutationPlan) GaloisElements() (galEls []uint64) {
+
+	set := make(map[uint64]bool)
+	for _, layer := range pp.layers {
+		for _, term := range layer {
+			if term.k != 0 {
+				set[pp.params.GaloisElementForColumnRotationBy(term.k)] = true
+			}
+			if term.rowRotation {
+				set[pp.params.GaloisElementForRowRotation()] = true
+			}
+		}
+	}
+
+	galEls = make([]uint64, 0, len(set))
+	for galEl := range set {
+		galEls = append(galEls, galEl)
+	}
+
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	return
+}
+
+// Permute applies the slot permutation of plan to ct0 and returns the result in ctOut.
+// The evaluator must have been created with the rotation keys of the Galois elements given by plan.GaloisElements().
+// The method panics if ct0 or ctOut is not of degree one.
+func (eval *evaluator) Permute(ct0 *Ciphertext, plan *PermutationPlan, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot Permute: input and output Ciphertext must be of degree 1")
+	}
+
+	ctIn := ct0.CopyNew()
+	acc := NewCiphertext(eval.params, 1)
+	rowRotated := NewCiphertext(eval.params, 1)
+	rotated := NewCiphertext(eval.params, 1)
+
+	for _, layer := range plan.layers {
+
+		acc.Value[0].Zero()
+		acc.Value[1].Zero()
+
+		rowRotation := false
+
+		for _, term := range layer {
+
+			src := ctIn
+
+			if term.rowRotation {
+				if !rowRotation {
+					eval.RotateRows(ctIn, rowRotated)
+					rowRotation = true
+				}
+				src = rowRotated
+			}
+
+			if term.k != 0 {
+				eval.RotateColumns(src, term.k, rotated)
+				src = rotated
+			}
+
+			eval.MulPlainThenAdd(src, term.mask, acc)
+		}
+
+		ctIn, acc = acc, ctIn
+	}
+
+	ctOut.Copy(ctIn.Ciphertext)
+}
+
+// PermuteNew applies the slot permutation of plan to ct0 and returns the result in a new Ciphertext.
+func (eval *evaluator) PermuteNew(ct0 *Ciphertext, plan *PermutationPlan) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.Permute(ct0, plan, ctOut)
+	return
+}
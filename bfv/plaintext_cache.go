@@ -0,0 +1,77 @@
+package bfv
+
+import (
+	"fmt"
+)
+
+// PlaintextCache stores the PlaintextMul operands of plaintexts that are multiplied with many
+// ciphertexts, e.g. the weights of a model evaluated on many encrypted inputs, so that the scaling
+// and NTT preparation of a plaintext is done once instead of at each multiplication.
+// The operands are given to Evaluator.Mul or Evaluator.MulPlainThenAdd as any other PlaintextMul.
+//
+// The cache is keyed on the plaintext pointer: a plaintext must not be modified after its operand
+// has been computed unless it is removed from the cache with Delete. The operands must not be modified.
+// A PlaintextCache is not safe for concurrent use, but the operands it returns can be shared by
+// Evaluators running concurrently.
+type PlaintextCache struct {
+	params  Parameters
+	encoder Encoder
+	ptRt    *PlaintextRingT
+	cache   map[Operand]*PlaintextMul
+}
+
+// NewPlaintextCache creates a new empty PlaintextCache.
+func NewPlaintextCache(params Parameters) *PlaintextCache {
+	return &PlaintextCache{
+		params:  params,
+		encoder: NewEncoder(params),
+		ptRt:    NewPlaintextRingT(params),
+		cache:   make(map[Operand]*PlaintextMul),
+	}
+}
+
+// Get returns the PlaintextMul operand of pt, computing and storing it on the first call.
+// pt can be a *Plaintext, which is scaled down to R_t, or a *PlaintextRingT. A *PlaintextMul is returned as is.
+// The method panics if pt is of any other type.
+func (pc *PlaintextCache) Get(pt Operand) (ptMul *PlaintextMul) {
+
+	if ptMul, ok := pt.(*PlaintextMul); ok {
+		return ptMul
+	}
+
+	var ok bool
+	if ptMul, ok = pc.cache[pt]; ok {
+		return
+	}
+
+	ptMul = NewPlaintextMul(pc.params)
+
+	switch pt := pt.(type) {
+	case *Plaintext:
+		pc.encoder.ScaleDown(pt, pc.ptRt)
+		pc.encoder.RingTToMul(pc.ptRt, ptMul)
+	case *PlaintextRingT:
+		pc.encoder.RingTToMul(pt, ptMul)
+	default:
+		panic(fmt.Errorf("invalid operand type for PlaintextCache: %T", pt))
+	}
+
+	pc.cache[pt] = ptMul
+
+	return
+}
+
+// Delete removes the operand of pt from the cache.
+func (pc *PlaintextCache) Delete(pt Operand) {
+	delete(pc.cache, pt)
+}
+
+// Len returns the number of operands stored in the cache.
+func (pc *PlaintextCache) Len() int {
+	return len(pc.cache)
+}
+
+// Reset removes all the operands from the cache.
+func (pc *PlaintextCache) Reset() {
+	pc.cache = make(map[Operand]*PlaintextMul)
+}
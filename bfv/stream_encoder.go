@@ -0,0 +1,211 @@
+package bfv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// SlotOrder is the order in which the values of the records of a batch are placed in the slots of a plaintext.
+type SlotOrder int
+
+const (
+	// RowMajor places the records one after the other: the field f of the record r is in the slot r*Stride + f.
+	RowMajor SlotOrder = iota
+	// ColumnMajor places the fields one after the other: the field f of the record r is in the slot f*Stride + r.
+	ColumnMajor
+)
+
+// SlotLayout describes how a StreamEncoder packs records of Fields values in the slots of a plaintext.
+// Stride is the distance in slots between two consecutive records (RowMajor) or fields (ColumnMajor).
+// A Stride of zero gives the dense layout: Fields for RowMajor and slots/Fields for ColumnMajor.
+// A larger Stride leaves empty slots between the records or fields, e.g. to pad them to a power of two for
+// Evaluator.InnerSumLog. The index of a slot is its position in the vector given to the Encoder.
+type SlotLayout struct {
+	Order  SlotOrder
+	Fields int
+	Stride int
+}
+
+// stride returns the stride of the layout for plaintexts of slots slots.
+func (l SlotLayout) stride(slots int) int {
+	if l.Stride != 0 {
+		return l.Stride
+	}
+	if l.Order == RowMajor {
+		return l.Fields
+	}
+	return slots / l.Fields
+}
+
+// RecordsPerBatch returns the number of records packed in a plaintext of slots slots.
+func (l SlotLayout) RecordsPerBatch(slots int) int {
+	if l.Order == RowMajor {
+		return (slots-l.Fields)/l.stride(slots) + 1
+	}
+	return l.stride(slots)
+}
+
+// Slot returns the slot of the field of index field of the record of index record of a batch.
+func (l SlotLayout) Slot(record, field, slots int) int {
+	if l.Order == RowMajor {
+		return record*l.stride(slots) + field
+	}
+	return field*l.stride(slots) + record
+}
+
+func (l SlotLayout) check(slots int) error {
+	switch {
+	case l.Order != RowMajor && l.Order != ColumnMajor:
+		return fmt.Errorf("invalid slot order %d", l.Order)
+	case l.Fields < 1 || l.Fields > slots:
+		return fmt.Errorf("invalid number of fields %d for %d slots", l.Fields, slots)
+	case l.Order == RowMajor && l.stride(slots) < l.Fields:
+		return fmt.Errorf("stride %d is smaller than the number of fields %d", l.Stride, l.Fields)
+	case l.Order == ColumnMajor && (l.stride(slots) < 1 || l.Fields*l.stride(slots) > slots):
+		return fmt.Errorf("invalid stride %d for %d fields and %d slots", l.Stride, l.Fields, slots)
+	}
+	return nil
+}
+
+// RecordSource is a source of records of uint64 values read by a StreamEncoder.
+// Next returns io.EOF when there are no more records.
+type RecordSource interface {
+	Next() (record []uint64, err error)
+}
+
+type readerSource struct {
+	r      io.Reader
+	buf    []byte
+	record []uint64
+}
+
+// NewReaderSource returns a RecordSource reading records of fields uint64 values on r, each value encoded on
+// 8 bytes in little-endian order. The returned records are overwritten by the
+// next call to Next.
+func NewReaderSource(r io.Reader, fields int) RecordSource {
+	return &readerSource{r: r, buf: make([]byte, 8*fields), record: make([]uint64, fields)}
+}
+
+func (src *readerSource) Next() (record []uint64, err error) {
+	if _, err = io.ReadFull(src.r, src.buf); err != nil {
+		// io.ReadFull returns io.EOF only if no byte was read, i.e. at the boundary of a record
+		return nil, err
+	}
+	for i := range src.record {
+		src.record[i] = binary.LittleEndian.Uint64(src.buf[8*i:])
+	}
+	return src.record, nil
+}
+
+type chanSource <-chan []uint64
+
+// NewChanSource returns a RecordSource receiving the records on ch, until ch is closed.
+func NewChanSource(ch <-chan []uint64) RecordSource {
+	return chanSource(ch)
+}
+
+func (src chanSource) Next() (record []uint64, err error) {
+	var ok bool
+	if record, ok = <-src; !ok {
+		return nil, io.EOF
+	}
+	return
+}
+
+// StreamEncoder packs a stream of records in a stream of plaintexts of N slots, according to a SlotLayout.
+// It holds in memory a single batch of records at a time, hence it can encode datasets far larger than the memory.
+type StreamEncoder struct {
+	params  Parameters
+	encoder Encoder
+	layout  SlotLayout
+
+	values []uint64
+}
+
+// NewStreamEncoder creates a new StreamEncoder. It returns an error if the layout is invalid for N slots.
+func NewStreamEncoder(params Parameters, encoder Encoder, layout SlotLayout) (*StreamEncoder, error) {
+
+	if err := layout.check(params.N()); err != nil {
+		return nil, fmt.Errorf("cannot NewStreamEncoder: %w", err)
+	}
+
+	return &StreamEncoder{
+		params:  params,
+		encoder: encoder,
+		layout:  layout,
+		values:  make([]uint64, params.N()),
+	}, nil
+}
+
+// RecordsPerBatch returns the number of records packed in each plaintext.
+func (se *StreamEncoder) RecordsPerBatch() int {
+	return se.layout.RecordsPerBatch(se.params.N())
+}
+
+// Next reads the next batch of records on src and returns them encoded on a new plaintext, along with the number of
+// records it contains, which is smaller than RecordsPerBatch only for the last batch. The empty slots are set to zero.
+// Next returns io.EOF when src has no more records.
+func (se *StreamEncoder) Next(src RecordSource) (pt *Plaintext, records int, err error) {
+
+	slots := se.params.N()
+
+	for i := range se.values {
+		se.values[i] = 0
+	}
+
+	for records < se.RecordsPerBatch() {
+
+		var record []uint64
+		if record, err = src.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, records, err
+		}
+
+		if len(record) != se.layout.Fields {
+			return nil, records, fmt.Errorf("invalid record: %d values instead of %d", len(record), se.layout.Fields)
+		}
+
+		for f, v := range record {
+			se.values[se.layout.Slot(records, f, slots)] = v
+		}
+
+		records++
+	}
+
+	if records == 0 {
+		return nil, 0, io.EOF
+	}
+
+	pt = NewPlaintext(se.params)
+	se.encoder.EncodeUint(se.values, pt)
+
+	return pt, records, nil
+}
+
+// Encode encodes all the records of src and calls emit on each plaintext, along with the number of records it
+// contains. It stops at the first error returned by src or emit and returns it.
+func (se *StreamEncoder) Encode(src RecordSource, emit func(pt *Plaintext, records int) error) (err error) {
+	for {
+		var pt *Plaintext
+		var records int
+		if pt, records, err = se.Next(src); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+
+		if err = emit(pt, records); err != nil {
+			return
+		}
+	}
+}
+
+// Encrypt encodes and encrypts all the records of src and calls emit on each ciphertext, along with the number of
+// records it contains. It stops at the first error returned by src or emit and returns it.
+func (se *StreamEncoder) Encrypt(src RecordSource, encryptor Encryptor, emit func(ct *Ciphertext, records int) error) (err error) {
+	return se.Encode(src, func(pt *Plaintext, records int) error {
+		return emit(encryptor.EncryptNew(pt), records)
+	})
+}
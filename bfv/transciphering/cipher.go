@@ -0,0 +1,206 @@
+package transciphering
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// newRoundConstantsSampler returns a sampler of the affine layers of the cipher for a given nonce and block counter,
+// derived from a XOF keyed with the nonce and the block counter. The elements are read in the following order:
+// for each of the Rounds+1 affine layers and for each half of the state, the StateSize x StateSize matrix in
+// row-major order followed by the StateSize elements of the round constant.
+func newRoundConstantsSampler(params Parameters, nonce []byte, counter uint64) *uniformSampler {
+
+	key := make([]byte, len(nonce)+8)
+	copy(key, nonce)
+	binary.BigEndian.PutUint64(key[len(nonce):], counter)
+
+	prng, err := utils.NewKeyedPRNG(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return newUniformSampler(prng, params.T)
+}
+
+// uniformSampler samples uniform elements of Z_t from a PRNG by rejection sampling.
+type uniformSampler struct {
+	prng utils.PRNG
+	t    uint64
+	mask uint64
+	buff []byte
+	ptr  int
+}
+
+func newUniformSampler(prng utils.PRNG, t uint64) *uniformSampler {
+	buff := make([]byte, 1024)
+	prng.Clock(buff)
+	return &uniformSampler{prng: prng, t: t, mask: (1 << uint64(bits.Len64(t))) - 1, buff: buff}
+}
+
+func (s *uniformSampler) read() (v uint64) {
+	for {
+		if s.ptr == len(s.buff) {
+			s.prng.Clock(s.buff)
+			s.ptr = 0
+		}
+
+		v = binary.BigEndian.Uint64(s.buff[s.ptr:s.ptr+8]) & s.mask
+		s.ptr += 8
+
+		if v < s.t {
+			return
+		}
+	}
+}
+
+// GenKey generates a new uniformly random symmetric key of 2*StateSize elements of Z_t.
+func GenKey(params Parameters) (key []uint64) {
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	sampler := newUniformSampler(prng, params.T)
+
+	key = make([]uint64, 2*params.StateSize)
+	for i := range key {
+		key[i] = sampler.read()
+	}
+
+	return
+}
+
+// Cipher is the client-side implementation of the symmetric cipher.
+type Cipher struct {
+	params     Parameters
+	key        []uint64
+	bredParams []uint64
+}
+
+// NewCipher creates a new Cipher from the parameters and the symmetric key.
+// It returns an error if the parameters are invalid or if the key is not
+// a vector of 2*StateSize elements of Z_t.
+func NewCipher(params Parameters, key []uint64) (*Cipher, error) {
+
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(key) != 2*params.StateSize {
+		return nil, fmt.Errorf("invalid key: length must be %d but is %d", 2*params.StateSize, len(key))
+	}
+
+	for _, k := range key {
+		if k >= params.T {
+			return nil, fmt.Errorf("invalid key: elements must be smaller than %d", params.T)
+		}
+	}
+
+	return &Cipher{params: params, key: key, bredParams: ring.BRedParams(params.T)}, nil
+}
+
+// Parameters returns the parameters of the cipher.
+func (c *Cipher) Parameters() Parameters {
+	return c.params
+}
+
+// Keystream returns the block of StateSize keystream elements for the given nonce and block counter.
+func (c *Cipher) Keystream(nonce []byte, counter uint64) (ks []uint64) {
+
+	params := c.params
+	t := params.T
+	u := c.bredParams
+
+	sampler := newRoundConstantsSampler(params, nonce, counter)
+
+	state := [2][]uint64{make([]uint64, params.StateSize), make([]uint64, params.StateSize)}
+	copy(state[0], c.key[:params.StateSize])
+	copy(state[1], c.key[params.StateSize:])
+
+	tmp := make([]uint64, params.StateSize)
+
+	for r := 0; r < params.Rounds+1; r++ {
+
+		// Affine layer
+		for h := 0; h < 2; h++ {
+			for i := range tmp {
+				tmp[i] = 0
+				for _, x := range state[h] {
+					tmp[i] = ring.CRed(tmp[i]+ring.BRed(sampler.read(), x, t, u), t)
+				}
+			}
+
+			for i := range tmp {
+				state[h][i] = ring.CRed(tmp[i]+sampler.read(), t)
+			}
+		}
+
+		// Mixing layer: (L, R) -> (2L + R, L + 2R)
+		for i := range tmp {
+			sum := ring.CRed(state[0][i]+state[1][i], t)
+			state[0][i] = ring.CRed(state[0][i]+sum, t)
+			state[1][i] = ring.CRed(state[1][i]+sum, t)
+		}
+
+		if r == params.Rounds {
+			break
+		}
+
+		// S-box layer
+		for h := 0; h < 2; h++ {
+			if r == params.Rounds-1 {
+				for i, x := range state[h] {
+					state[h][i] = ring.BRed(ring.BRed(x, x, t, u), x, t, u)
+				}
+			} else {
+				for i := len(state[h]) - 1; i > 0; i-- {
+					x := state[h][i-1]
+					state[h][i] = ring.CRed(state[h][i]+ring.BRed(x, x, t, u), t)
+				}
+			}
+		}
+	}
+
+	return state[0]
+}
+
+// Encrypt encrypts a block of at most StateSize elements of Z_t and returns the symmetric ciphertext.
+// The same pair (nonce, counter) must never be used to encrypt two different blocks.
+func (c *Cipher) Encrypt(nonce []byte, counter uint64, block []uint64) (ct []uint64) {
+
+	if len(block) > c.params.StateSize {
+		panic(fmt.Errorf("cannot Encrypt: block length must be at most %d but is %d", c.params.StateSize, len(block)))
+	}
+
+	ks := c.Keystream(nonce, counter)
+
+	ct = make([]uint64, len(block))
+	for i := range block {
+		ct[i] = ring.CRed(ring.BRedAdd(block[i], c.params.T, c.bredParams)+ks[i], c.params.T)
+	}
+
+	return
+}
+
+// Decrypt decrypts a symmetric ciphertext of at most StateSize elements of Z_t.
+func (c *Cipher) Decrypt(nonce []byte, counter uint64, ct []uint64) (block []uint64) {
+
+	if len(ct) > c.params.StateSize {
+		panic(fmt.Errorf("cannot Decrypt: ciphertext length must be at most %d but is %d", c.params.StateSize, len(ct)))
+	}
+
+	ks := c.Keystream(nonce, counter)
+
+	block = make([]uint64, len(ct))
+	for i := range ct {
+		block[i] = ring.CRed(ct[i]+c.params.T-ks[i], c.params.T)
+	}
+
+	return
+}
@@ -0,0 +1,57 @@
+// Package transciphering implements the server-side homomorphic evaluation of the decryption circuit of a
+// PASTA-style FHE-friendly symmetric cipher over Z_t, which converts symmetric ciphertexts produced by
+// lightweight clients into BFV ciphertexts, as well as the client-side cipher and the helpers to
+// encapsulate the symmetric key under BFV.
+//
+// The cipher follows the structure of PASTA (Dobraunig et al., https://eprint.iacr.org/2021/731):
+// the key is a state of 2*StateSize elements of Z_t, split into two halves, on which Rounds+1 affine layers
+// (a matrix multiplication followed by the addition of a round constant, applied independently to each half)
+// and mixing layers are interleaved with Rounds S-box layers (a Feistel S-box x_i <- x_i + x_{i-1}^2 for all rounds
+// but the last, and a cube S-box x_i <- x_i^3 for the last round). The left half of the final state is the keystream.
+// The matrices and round constants are sampled from a XOF keyed with the nonce and the block counter.
+//
+// This implementation is not bit-compatible with the reference implementation of PASTA.
+package transciphering
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Parameters is a struct storing the parameters of the symmetric cipher.
+type Parameters struct {
+	StateSize int    // Number of elements of each half of the state (and of each block)
+	Rounds    int    // Number of S-box layers
+	T         uint64 // Modulus of the elements, which must match the BFV plaintext modulus
+}
+
+var (
+	// Pasta3 is a set of parameters with 3 rounds and blocks of 128 elements modulo 65537.
+	Pasta3 = Parameters{StateSize: 128, Rounds: 3, T: 65537}
+
+	// Pasta4 is a set of parameters with 4 rounds and blocks of 32 elements modulo 65537.
+	Pasta4 = Parameters{StateSize: 32, Rounds: 4, T: 65537}
+)
+
+// Depth returns the multiplicative depth of the decryption circuit.
+func (p Parameters) Depth() int {
+	// Rounds-1 Feistel S-boxes of depth one and a cube S-box of depth two.
+	return p.Rounds + 1
+}
+
+// Validate returns an error if the parameters are invalid.
+func (p Parameters) Validate() error {
+	if p.StateSize < 1 {
+		return fmt.Errorf("invalid StateSize: must be at least 1 but is %d", p.StateSize)
+	}
+
+	if p.Rounds < 1 {
+		return fmt.Errorf("invalid Rounds: must be at least 1 but is %d", p.Rounds)
+	}
+
+	if p.T < 3 || bits.Len64(p.T) > 62 {
+		return fmt.Errorf("invalid T: must be in [3, 2^62) but is %d", p.T)
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package transciphering
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// EncryptKey encapsulates the symmetric key under BFV. It returns 2*StateSize ciphertexts,
+// the i-th ciphertext encrypting the i-th element of the key replicated in all the slots.
+// The result is the input expected by the key-dependent methods of Transcipher.
+func EncryptKey(params Parameters, bfvParams bfv.Parameters, encryptor bfv.Encryptor, key []uint64) (encKey []*bfv.Ciphertext, err error) {
+
+	if err = checkParameters(params, bfvParams); err != nil {
+		return nil, err
+	}
+
+	if len(key) != 2*params.StateSize {
+		return nil, fmt.Errorf("invalid key: length must be %d but is %d", 2*params.StateSize, len(key))
+	}
+
+	encoder := bfv.NewEncoder(bfvParams)
+	pt := bfv.NewPlaintext(bfvParams)
+	values := make([]uint64, bfvParams.N())
+
+	encKey = make([]*bfv.Ciphertext, len(key))
+	for i, k := range key {
+		for j := range values {
+			values[j] = k
+		}
+		encoder.EncodeUint(values, pt)
+		encKey[i] = encryptor.EncryptNew(pt)
+	}
+
+	return
+}
+
+// Transcipher is a struct storing the necessary elements to homomorphically evaluate the
+// decryption circuit of the symmetric cipher on BFV ciphertexts. The evaluation is batched
+// over the slots: the j-th slot processes the block of the j-th counter.
+type Transcipher struct {
+	params    Parameters
+	bfvParams bfv.Parameters
+	encoder   bfv.Encoder
+	evaluator bfv.Evaluator
+
+	ptMul  *bfv.PlaintextMul
+	ptRt   *bfv.PlaintextRingT
+	tmpCt  *bfv.Ciphertext
+	tmpCt2 *bfv.Ciphertext
+	values []uint64
+}
+
+// NewTranscipher creates a new Transcipher. The relinearization key must be
+// associated to the secret key under which the symmetric key was encapsulated.
+// It returns an error if the plaintext modulus of the BFV parameters does not match the modulus of the cipher.
+func NewTranscipher(params Parameters, bfvParams bfv.Parameters, rlk *rlwe.RelinearizationKey) (*Transcipher, error) {
+
+	if err := checkParameters(params, bfvParams); err != nil {
+		return nil, err
+	}
+
+	return &Transcipher{
+		params:    params,
+		bfvParams: bfvParams,
+		encoder:   bfv.NewEncoder(bfvParams),
+		evaluator: bfv.NewEvaluator(bfvParams, rlwe.EvaluationKey{Rlk: rlk}),
+		ptMul:     bfv.NewPlaintextMul(bfvParams),
+		ptRt:      bfv.NewPlaintextRingT(bfvParams),
+		tmpCt:     bfv.NewCiphertext(bfvParams, 2),
+		tmpCt2:    bfv.NewCiphertext(bfvParams, 1),
+		values:    make([]uint64, bfvParams.N()),
+	}, nil
+}
+
+func checkParameters(params Parameters, bfvParams bfv.Parameters) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	if params.T != bfvParams.T() {
+		return fmt.Errorf("cipher modulus %d does not match the BFV plaintext modulus %d", params.T, bfvParams.T())
+	}
+
+	return nil
+}
+
+// ShallowCopy creates a shallow copy of Transcipher in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Transcipher can be used concurrently.
+func (tc *Transcipher) ShallowCopy() *Transcipher {
+	return &Transcipher{
+		params:    tc.params,
+		bfvParams: tc.bfvParams,
+		encoder:   tc.encoder.ShallowCopy(),
+		evaluator: tc.evaluator.ShallowCopy(),
+		ptMul:     bfv.NewPlaintextMul(tc.bfvParams),
+		ptRt:      bfv.NewPlaintextRingT(tc.bfvParams),
+		tmpCt:     bfv.NewCiphertext(tc.bfvParams, 2),
+		tmpCt2:    bfv.NewCiphertext(tc.bfvParams, 1),
+		values:    make([]uint64, tc.bfvParams.N()),
+	}
+}
+
+// KeystreamNew homomorphically evaluates the keystream of the blocks associated to the given counters
+// under the encapsulated key. It returns StateSize ciphertexts, the slot j of the i-th ciphertext
+// encrypting the i-th element of the keystream block of counters[j].
+func (tc *Transcipher) KeystreamNew(encKey []*bfv.Ciphertext, nonce []byte, counters []uint64) (ks []*bfv.Ciphertext) {
+
+	params := tc.params
+	eval := tc.evaluator
+
+	if len(encKey) != 2*params.StateSize {
+		panic(fmt.Errorf("invalid encapsulated key: length must be %d but is %d", 2*params.StateSize, len(encKey)))
+	}
+
+	if len(counters) > tc.bfvParams.N() {
+		panic(fmt.Errorf("number of counters (%d) exceeds the number of slots (%d)", len(counters), tc.bfvParams.N()))
+	}
+
+	samplers := make([]*uniformSampler, len(counters))
+	for j := range counters {
+		samplers[j] = newRoundConstantsSampler(params, nonce, counters[j])
+	}
+
+	state := [2][]*bfv.Ciphertext{make([]*bfv.Ciphertext, params.StateSize), make([]*bfv.Ciphertext, params.StateSize)}
+	for i := 0; i < params.StateSize; i++ {
+		state[0][i] = encKey[i].CopyNew()
+		state[1][i] = encKey[params.StateSize+i].CopyNew()
+	}
+
+	tmp := make([]*bfv.Ciphertext, params.StateSize)
+	for i := range tmp {
+		tmp[i] = bfv.NewCiphertext(tc.bfvParams, 1)
+	}
+
+	for r := 0; r < params.Rounds+1; r++ {
+
+		// Affine layer
+		for h := 0; h < 2; h++ {
+
+			for i := range tmp {
+				for j := range state[h] {
+					tc.readRoundConstants(samplers)
+					tc.encoder.EncodeUintMul(tc.values, tc.ptMul)
+					if j == 0 {
+						eval.Mul(state[h][j], tc.ptMul, tmp[i])
+					} else {
+						eval.Mul(state[h][j], tc.ptMul, tc.tmpCt2)
+						eval.Add(tmp[i], tc.tmpCt2, tmp[i])
+					}
+				}
+			}
+
+			for i := range tmp {
+				tc.readRoundConstants(samplers)
+				tc.encoder.EncodeUintRingT(tc.values, tc.ptRt)
+				eval.Add(tmp[i], tc.ptRt, state[h][i])
+			}
+		}
+
+		// Mixing layer: (L, R) -> (2L + R, L + 2R)
+		for i := range tmp {
+			eval.Add(state[0][i], state[1][i], tc.tmpCt2)
+			eval.Add(state[0][i], tc.tmpCt2, state[0][i])
+			eval.Add(state[1][i], tc.tmpCt2, state[1][i])
+		}
+
+		if r == params.Rounds {
+			break
+		}
+
+		// S-box layer
+		for h := 0; h < 2; h++ {
+			if r == params.Rounds-1 {
+				for i := range state[h] {
+					eval.Mul(state[h][i], state[h][i], tc.tmpCt)
+					eval.Relinearize(tc.tmpCt, tc.tmpCt2)
+					eval.Mul(tc.tmpCt2, state[h][i], tc.tmpCt)
+					eval.Relinearize(tc.tmpCt, state[h][i])
+				}
+			} else {
+				for i := len(state[h]) - 1; i > 0; i-- {
+					eval.Mul(state[h][i-1], state[h][i-1], tc.tmpCt)
+					eval.Relinearize(tc.tmpCt, tc.tmpCt2)
+					eval.Add(state[h][i], tc.tmpCt2, state[h][i])
+				}
+			}
+		}
+	}
+
+	return state[0]
+}
+
+// DecryptNew homomorphically decrypts the symmetric ciphertexts cts, where cts[j] is the encryption
+// of a block of at most StateSize elements under the pair (nonce, counters[j]). It returns one ciphertext
+// per element of the longest block, the slot j of the i-th ciphertext encrypting the i-th element of the j-th block.
+// Missing elements of shorter blocks decrypt to arbitrary values.
+func (tc *Transcipher) DecryptNew(encKey []*bfv.Ciphertext, nonce []byte, counters []uint64, cts [][]uint64) (res []*bfv.Ciphertext) {
+
+	if len(cts) != len(counters) {
+		panic(fmt.Errorf("number of symmetric ciphertexts (%d) does not match the number of counters (%d)", len(cts), len(counters)))
+	}
+
+	var blockLen int
+	for _, ct := range cts {
+		if len(ct) > tc.params.StateSize {
+			panic(fmt.Errorf("invalid symmetric ciphertext: length must be at most %d but is %d", tc.params.StateSize, len(ct)))
+		}
+		if len(ct) > blockLen {
+			blockLen = len(ct)
+		}
+	}
+
+	ks := tc.KeystreamNew(encKey, nonce, counters)
+
+	res = ks[:blockLen]
+
+	for i := range res {
+		for j := range tc.values {
+			tc.values[j] = 0
+			if j < len(cts) && i < len(cts[j]) {
+				tc.values[j] = cts[j][i]
+			}
+		}
+		tc.encoder.EncodeUintRingT(tc.values, tc.ptRt)
+		tc.evaluator.Sub(tc.ptRt, res[i], res[i])
+	}
+
+	return
+}
+
+// readRoundConstants reads the next element of each sampler into the slots buffer.
+func (tc *Transcipher) readRoundConstants(samplers []*uniformSampler) {
+	for j := range samplers {
+		tc.values[j] = samplers[j].read()
+	}
+	for j := len(samplers); j < len(tc.values); j++ {
+		tc.values[j] = 0
+	}
+}
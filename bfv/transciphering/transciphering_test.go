@@ -0,0 +1,84 @@
+package transciphering
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+func testString(opname string, params Parameters, bfvParams bfv.Parameters) string {
+	return fmt.Sprintf("%s/StateSize=%d/Rounds=%d/LogN=%d/logQ=%d", opname, params.StateSize, params.Rounds, bfvParams.LogN(), bfvParams.LogQP())
+}
+
+func TestTransciphering(t *testing.T) {
+
+	bfvParams, err := bfv.NewParametersFromLiteral(bfv.PN14QP438)
+	require.NoError(t, err)
+
+	params := Parameters{StateSize: 4, Rounds: 3, T: bfvParams.T()}
+
+	nonce := []byte("lattigo-transciphering")
+
+	key := GenKey(params)
+	cipher, err := NewCipher(params, key)
+	require.NoError(t, err)
+
+	t.Run(testString("Cipher/InvalidParameters", params, bfvParams), func(t *testing.T) {
+		_, err := NewCipher(Parameters{StateSize: 0, Rounds: 3, T: 65537}, key)
+		require.Error(t, err)
+		_, err = NewCipher(params, key[1:])
+		require.Error(t, err)
+		_, err = NewTranscipher(Parameters{StateSize: 4, Rounds: 3, T: 17}, bfvParams, nil)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("Cipher/Encrypt&Decrypt", params, bfvParams), func(t *testing.T) {
+		block := []uint64{0, 1, 2, params.T - 1}
+		ct := cipher.Encrypt(nonce, 7, block)
+		require.False(t, utils.EqualSliceUint64(block, ct))
+		require.True(t, utils.EqualSliceUint64(block, cipher.Decrypt(nonce, 7, ct)))
+		require.False(t, utils.EqualSliceUint64(cipher.Keystream(nonce, 7), cipher.Keystream(nonce, 8)))
+	})
+
+	t.Run(testString("Transcipher/Decrypt", params, bfvParams), func(t *testing.T) {
+
+		kgen := bfv.NewKeyGenerator(bfvParams)
+		sk, pk := kgen.GenKeyPair()
+		rlk := kgen.GenRelinearizationKey(sk, 1)
+		encoder := bfv.NewEncoder(bfvParams)
+		decryptor := bfv.NewDecryptor(bfvParams, sk)
+
+		encKey, err := EncryptKey(params, bfvParams, bfv.NewEncryptor(bfvParams, pk), key)
+		require.NoError(t, err)
+
+		tc, err := NewTranscipher(params, bfvParams, rlk)
+		require.NoError(t, err)
+
+		nbBlocks := 5
+		counters := make([]uint64, nbBlocks)
+		blocks := make([][]uint64, nbBlocks)
+		cts := make([][]uint64, nbBlocks)
+		for j := range blocks {
+			counters[j] = uint64(j)
+			blocks[j] = make([]uint64, params.StateSize)
+			for i := range blocks[j] {
+				blocks[j][i] = uint64(j*params.StateSize+i) % params.T
+			}
+			cts[j] = cipher.Encrypt(nonce, counters[j], blocks[j])
+		}
+
+		res := tc.DecryptNew(encKey, nonce, counters, cts)
+		require.Len(t, res, params.StateSize)
+
+		for i := range res {
+			values := encoder.DecodeUintNew(decryptor.DecryptNew(res[i]))
+			for j := range blocks {
+				require.Equal(t, blocks[j][i], values[j])
+			}
+		}
+	})
+}
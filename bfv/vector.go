@@ -0,0 +1,373 @@
+package bfv
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Vector is a vector of Len values modulo t longer than the slots of a ciphertext, encrypted in consecutive
+// ciphertexts of N slots: the value of index i is stored in the slot i % N of the ciphertext i / N, i.e. in the
+// row (i % N) / (N/2) and the column i % (N/2) of its 2 x N/2 matrix of slots.
+// The slots after the Len values of the last ciphertext must be zero, which the VectorEvaluator preserves.
+type Vector struct {
+	Value []*Ciphertext
+	Len   int
+}
+
+// EncryptVectorNew encodes and encrypts the values in a new Vector of len(values) values.
+func EncryptVectorNew(params Parameters, encoder Encoder, encryptor Encryptor, values []uint64) (v *Vector) {
+
+	slots := params.N()
+
+	v = &Vector{Value: make([]*Ciphertext, (len(values)+slots-1)/slots), Len: len(values)}
+
+	pt := NewPlaintext(params)
+
+	for i := range v.Value {
+		chunk := make([]uint64, slots)
+		copy(chunk, values[i*slots:utils.MinInt((i+1)*slots, len(values))])
+		encoder.EncodeUint(chunk, pt)
+		v.Value[i] = encryptor.EncryptNew(pt)
+	}
+
+	return
+}
+
+// DecryptVector decrypts and decodes the Vector v and returns its Len values.
+func DecryptVector(params Parameters, encoder Encoder, decryptor Decryptor, v *Vector) (values []uint64) {
+
+	values = make([]uint64, 0, len(v.Value)*params.N())
+
+	for _, ct := range v.Value {
+		values = append(values, encoder.DecodeUintNew(decryptor.DecryptNew(ct))...)
+	}
+
+	return values[:v.Len]
+}
+
+// CopyNew creates a deep copy of the Vector.
+func (v *Vector) CopyNew() (vCopy *Vector) {
+	vCopy = &Vector{Value: make([]*Ciphertext, len(v.Value)), Len: v.Len}
+	for i := range v.Value {
+		vCopy.Value[i] = v.Value[i].CopyNew()
+	}
+	return
+}
+
+// GaloisElementsForVectorRotate returns the Galois elements of the rotation keys required by VectorEvaluator.Rotate
+// to rotate a Vector of length values by each of the given rotations.
+func (p Parameters) GaloisElementsForVectorRotate(length int, rotations []int) (galEls []uint64) {
+
+	galElIndex := make(map[uint64]bool)
+
+	for _, k := range rotations {
+		for _, terms := range vectorRotationTerms(length, p.N(), k) {
+			for term := range terms {
+				if term.rotation != 0 {
+					galElIndex[p.GaloisElementForColumnRotationBy(term.rotation)] = true
+				}
+				if term.swapRows {
+					galElIndex[p.GaloisElementForRowRotation()] = true
+				}
+			}
+		}
+	}
+
+	galEls = make([]uint64, 0, len(galElIndex))
+	for galEl := range galElIndex {
+		galEls = append(galEls, galEl)
+	}
+
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	return
+}
+
+// GaloisElementsForVectorInnerSum returns the Galois elements of the rotation keys required by
+// VectorEvaluator.InnerSum.
+func (p Parameters) GaloisElementsForVectorInnerSum() []uint64 {
+	return p.GaloisElementsForRowInnerSum()
+}
+
+// VectorEvaluator evaluates element-wise operations, rotations and reductions on Vectors with an Evaluator.
+type VectorEvaluator struct {
+	params  Parameters
+	eval    Evaluator
+	encoder Encoder
+}
+
+// NewVectorEvaluator creates a VectorEvaluator evaluating the operations with eval. The encoder encodes the masks
+// of the rotations crossing the boundaries of the ciphertexts.
+func NewVectorEvaluator(params Parameters, eval Evaluator, encoder Encoder) *VectorEvaluator {
+	return &VectorEvaluator{params: params, eval: eval, encoder: encoder}
+}
+
+// newVectorLike allocates a Vector with the length and the number of ciphertexts of v, whose ciphertexts have the
+// given degree.
+func (ve *VectorEvaluator) newVectorLike(v *Vector, degree int) (vOut *Vector) {
+	vOut = &Vector{Value: make([]*Ciphertext, len(v.Value)), Len: v.Len}
+	for i := range v.Value {
+		vOut.Value[i] = NewCiphertext(ve.params, degree)
+	}
+	return
+}
+
+// checkVectors panics if the Vectors do not have the same length.
+func checkVectors(method string, vs ...*Vector) {
+	for _, v := range vs[1:] {
+		if v.Len != vs[0].Len || len(v.Value) != len(vs[0].Value) {
+			panic(fmt.Sprintf("cannot %s: vectors of %d values in %d ciphertexts and of %d values in %d ciphertexts",
+				method, vs[0].Len, len(vs[0].Value), v.Len, len(v.Value)))
+		}
+	}
+}
+
+// Add adds v0 and v1 element-wise and returns the result in vOut.
+func (ve *VectorEvaluator) Add(v0, v1, vOut *Vector) {
+	checkVectors("Add", v0, v1, vOut)
+	for i := range v0.Value {
+		ve.eval.Add(v0.Value[i], v1.Value[i], vOut.Value[i])
+	}
+}
+
+// AddNew adds v0 and v1 element-wise and returns the result in a new Vector.
+func (ve *VectorEvaluator) AddNew(v0, v1 *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v0, utils.MaxInt(v0.Value[0].Degree(), v1.Value[0].Degree()))
+	ve.Add(v0, v1, vOut)
+	return
+}
+
+// Sub subtracts v1 from v0 element-wise and returns the result in vOut.
+func (ve *VectorEvaluator) Sub(v0, v1, vOut *Vector) {
+	checkVectors("Sub", v0, v1, vOut)
+	for i := range v0.Value {
+		ve.eval.Sub(v0.Value[i], v1.Value[i], vOut.Value[i])
+	}
+}
+
+// SubNew subtracts v1 from v0 element-wise and returns the result in a new Vector.
+func (ve *VectorEvaluator) SubNew(v0, v1 *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v0, utils.MaxInt(v0.Value[0].Degree(), v1.Value[0].Degree()))
+	ve.Sub(v0, v1, vOut)
+	return
+}
+
+// Neg negates v and returns the result in vOut.
+func (ve *VectorEvaluator) Neg(v, vOut *Vector) {
+	checkVectors("Neg", v, vOut)
+	for i := range v.Value {
+		ve.eval.Neg(v.Value[i], vOut.Value[i])
+	}
+}
+
+// NegNew negates v and returns the result in a new Vector.
+func (ve *VectorEvaluator) NegNew(v *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v, v.Value[0].Degree())
+	ve.Neg(v, vOut)
+	return
+}
+
+// MulScalar multiplies v by the scalar and returns the result in vOut.
+func (ve *VectorEvaluator) MulScalar(v *Vector, scalar uint64, vOut *Vector) {
+	checkVectors("MulScalar", v, vOut)
+	for i := range v.Value {
+		ve.eval.MulScalar(v.Value[i], scalar, vOut.Value[i])
+	}
+}
+
+// MulScalarNew multiplies v by the scalar and returns the result in a new Vector.
+func (ve *VectorEvaluator) MulScalarNew(v *Vector, scalar uint64) (vOut *Vector) {
+	vOut = ve.newVectorLike(v, v.Value[0].Degree())
+	ve.MulScalar(v, scalar, vOut)
+	return
+}
+
+// Mul multiplies v0 and v1 element-wise and returns the result in vOut.
+func (ve *VectorEvaluator) Mul(v0, v1, vOut *Vector) {
+	checkVectors("Mul", v0, v1, vOut)
+	for i := range v0.Value {
+		ve.eval.Mul(v0.Value[i], v1.Value[i], vOut.Value[i])
+	}
+}
+
+// MulNew multiplies v0 and v1 element-wise and returns the result in a new Vector.
+func (ve *VectorEvaluator) MulNew(v0, v1 *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v0, v0.Value[0].Degree()+v1.Value[0].Degree())
+	ve.Mul(v0, v1, vOut)
+	return
+}
+
+// Relinearize relinearizes the ciphertexts of v and returns the result in vOut.
+// The evaluator must have a relinearization key.
+func (ve *VectorEvaluator) Relinearize(v, vOut *Vector) {
+	checkVectors("Relinearize", v, vOut)
+	for i := range v.Value {
+		ve.eval.Relinearize(v.Value[i], vOut.Value[i])
+	}
+}
+
+// RelinearizeNew relinearizes the ciphertexts of v and returns the result in a new Vector.
+func (ve *VectorEvaluator) RelinearizeNew(v *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v, 1)
+	ve.Relinearize(v, vOut)
+	return
+}
+
+// vectorRotationTerm is a rotation of the columns of an input ciphertext, followed by a swap of its rows if
+// swapRows is true, contributing to an output ciphertext of a rotated Vector.
+type vectorRotationTerm struct {
+	ciphertext int
+	rotation   int
+	swapRows   bool
+}
+
+// vectorRotationTerms returns, for each output ciphertext of the rotation by k of a Vector of length values in
+// ciphertexts of slots slots, the rotations of the input ciphertexts it is the sum of, each with the mask
+// selecting the slots it contributes.
+func vectorRotationTerms(length, slots, k int) (terms []map[vectorRotationTerm][]uint64) {
+
+	columns := slots >> 1
+
+	k = ((k % length) + length) % length
+
+	terms = make([]map[vectorRotationTerm][]uint64, (length+slots-1)/slots)
+
+	for j := range terms {
+
+		terms[j] = make(map[vectorRotationTerm][]uint64)
+
+		for s := 0; s < slots && j*slots+s < length; s++ {
+
+			// The output value j*slots+s is the input value h, stored in the slot h%slots of the ciphertext h/slots
+			h := (j*slots + s + k) % length
+
+			term := vectorRotationTerm{
+				ciphertext: h / slots,
+				rotation:   (h%columns - s%columns + columns) % columns,
+				swapRows:   (h%slots)/columns != s/columns,
+			}
+
+			if _, ok := terms[j][term]; !ok {
+				terms[j][term] = make([]uint64, slots)
+			}
+
+			terms[j][term][s] = 1
+		}
+	}
+
+	return
+}
+
+// RotateNew rotates the Vector v to the left by k positions, i.e. its value of index i is the value of index
+// (i + k) mod Len of v, and returns the result in a new Vector. The rotations crossing the boundaries of the rows
+// and of the ciphertexts are masked with plaintext multiplications.
+// The evaluator must have the rotation keys of the Galois elements returned by Parameters.GaloisElementsForVectorRotate.
+func (ve *VectorEvaluator) RotateNew(v *Vector, k int) (vOut *Vector) {
+
+	slots := ve.params.N()
+
+	terms := vectorRotationTerms(v.Len, slots, k)
+
+	// No mask is needed if each output ciphertext is a single rotation of all the slots of an input ciphertext
+	masked := false
+	for j := range terms {
+		masked = masked || len(terms[j]) > 1 || (j+1)*slots > v.Len
+	}
+
+	// The rotations of the columns of each input ciphertext are evaluated with hoisting
+	rotations := make([]map[int]bool, len(v.Value))
+	for j := range terms {
+		for term := range terms[j] {
+			if rotations[term.ciphertext] == nil {
+				rotations[term.ciphertext] = make(map[int]bool)
+			}
+			rotations[term.ciphertext][term.rotation] = true
+		}
+	}
+
+	rotated := make([]map[int]*Ciphertext, len(v.Value))
+	for i, rotIndex := range rotations {
+		rots := make([]int, 0, len(rotIndex))
+		for rot := range rotIndex {
+			rots = append(rots, rot)
+		}
+		if len(rots) != 0 {
+			rotated[i] = ve.eval.RotateColumnsHoistedNew(v.Value[i], rots)
+		}
+	}
+
+	vOut = &Vector{Value: make([]*Ciphertext, len(terms)), Len: v.Len}
+
+	ptMask := NewPlaintextMul(ve.params)
+	tmp := NewCiphertext(ve.params, 1)
+
+	for j := range terms {
+
+		// The terms are summed in a deterministic order
+		sorted := make([]vectorRotationTerm, 0, len(terms[j]))
+		for term := range terms[j] {
+			sorted = append(sorted, term)
+		}
+
+		sort.Slice(sorted, func(a, b int) bool {
+			if sorted[a].ciphertext != sorted[b].ciphertext {
+				return sorted[a].ciphertext < sorted[b].ciphertext
+			}
+			if sorted[a].rotation != sorted[b].rotation {
+				return sorted[a].rotation < sorted[b].rotation
+			}
+			return !sorted[a].swapRows && sorted[b].swapRows
+		})
+
+		vOut.Value[j] = NewCiphertext(ve.params, 1)
+
+		for _, term := range sorted {
+
+			ct := rotated[term.ciphertext][term.rotation]
+
+			if term.swapRows {
+				ve.eval.RotateRows(ct, tmp)
+			} else {
+				tmp.Copy(ct.El())
+			}
+
+			if !masked {
+				vOut.Value[j].Copy(tmp.El())
+				continue
+			}
+
+			ve.encoder.EncodeUintMul(terms[j][term], ptMask)
+			ve.eval.MulPlainThenAdd(tmp, ptMask, vOut.Value[j])
+		}
+	}
+
+	return
+}
+
+// Rotate rotates the Vector v to the left by k positions and returns the result in vOut, which can alias v.
+// See RotateNew.
+func (ve *VectorEvaluator) Rotate(v *Vector, k int, vOut *Vector) {
+
+	checkVectors("Rotate", v, vOut)
+
+	for i, ct := range ve.RotateNew(v, k).Value {
+		vOut.Value[i].Copy(ct.El())
+	}
+}
+
+// InnerSumNew returns a new Ciphertext whose slots all store the sum modulo t of the Len values of v.
+// The evaluator must have the rotation keys of the Galois elements returned by Parameters.GaloisElementsForVectorInnerSum.
+func (ve *VectorEvaluator) InnerSumNew(v *Vector) (ctOut *Ciphertext) {
+
+	ctOut = v.Value[0].CopyNew()
+
+	for _, ct := range v.Value[1:] {
+		ve.eval.Add(ctOut, ct, ctOut)
+	}
+
+	ve.eval.InnerSum(ctOut, ctOut)
+
+	return
+}
@@ -0,0 +1,539 @@
+// Command capi is a C foreign function interface of the library, built as a shared library with the c-shared build
+// mode, so that the applications written in other languages (Python, Rust, Java, ...) can call it without
+// reimplementing it. It exports the construction of the parameters, the generation of the keys, the encryption and
+// decryption, the operations of an evaluator and the generation of the shares of the parties in the CKG, RKG, RTG, CKS
+// and PCKS protocols of the drlwe package.
+//
+// Usage:
+//
+//	go build -buildmode=c-shared -o liblattigo.so ./capi
+//
+// which also writes the C header liblattigo.h of the exported functions. The parameters and the evaluators are
+// referenced by handles, released with LattigoDelete, and the keys, ciphertexts and shares are exchanged as the byte
+// buffers of their MarshalBinary methods. The functions return NULL on success and an error message otherwise, and
+// the error messages and the output buffers are allocated with malloc and released with LattigoFree, e.g. in Python:
+//
+//	lib = ctypes.CDLL("./liblattigo.so")
+//	params = ctypes.c_size_t()
+//	err = lib.LattigoNewParameters(b"ckks", params_json, ctypes.byref(params))
+//	sk, sk_len = ctypes.POINTER(ctypes.c_uint8)(), ctypes.c_size_t()
+//	err = lib.LattigoGenSecretKey(params, ctypes.byref(sk), ctypes.byref(sk_len))
+//
+// The functions are safe for concurrent use, except the concurrent use of the same evaluator.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// main is required by the c-shared build mode and is not called.
+func main() {}
+
+// handles is the table of the objects referenced by the handles given to the callers, which prevents the garbage
+// collection of the objects while they are referenced by the callers. The handle 0 is never used.
+var handles = struct {
+	sync.Mutex
+	next    uintptr
+	objects map[uintptr]interface{}
+}{objects: make(map[uintptr]interface{})}
+
+// newHandle registers the object in the table and returns its handle.
+func newHandle(object interface{}) uintptr {
+	handles.Lock()
+	defer handles.Unlock()
+	handles.next++
+	handles.objects[handles.next] = object
+	return handles.next
+}
+
+// deleteHandle releases the handle.
+func deleteHandle(h uintptr) {
+	handles.Lock()
+	defer handles.Unlock()
+	delete(handles.objects, h)
+}
+
+// lookupParameters returns the parameters referenced by the handle.
+func lookupParameters(h uintptr) (*parameters, error) {
+	handles.Lock()
+	defer handles.Unlock()
+	if params, ok := handles.objects[h].(*parameters); ok {
+		return params, nil
+	}
+	return nil, fmt.Errorf("invalid parameters handle %d", h)
+}
+
+// lookupEvaluator returns the evaluator referenced by the handle.
+func lookupEvaluator(h uintptr) (*evaluator, error) {
+	handles.Lock()
+	defer handles.Unlock()
+	if eval, ok := handles.objects[h].(*evaluator); ok {
+		return eval, nil
+	}
+	return nil, fmt.Errorf("invalid evaluator handle %d", h)
+}
+
+// catch calls f and returns its error, or the panic of f as an error, since a panic must not cross the boundary of the
+// foreign function interface.
+func catch(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return f()
+}
+
+// parameters are the parameters of the scheme "bfv" or "ckks".
+type parameters struct {
+	scheme string
+	rlwe   rlwe.Parameters
+	bfv    bfv.Parameters
+	ckks   ckks.Parameters
+}
+
+// newParameters returns the parameters of the scheme "bfv" or "ckks" marshaled in JSON.
+func newParameters(scheme string, paramsJSON []byte) (params *parameters, err error) {
+
+	params = &parameters{scheme: scheme}
+
+	switch scheme {
+	case "bfv":
+		if err = json.Unmarshal(paramsJSON, &params.bfv); err != nil {
+			return nil, fmt.Errorf("cannot NewParameters: %w", err)
+		}
+		params.rlwe = params.bfv.Parameters
+	case "ckks":
+		if err = json.Unmarshal(paramsJSON, &params.ckks); err != nil {
+			return nil, fmt.Errorf("cannot NewParameters: %w", err)
+		}
+		params.rlwe = params.ckks.Parameters
+	default:
+		return nil, fmt.Errorf("cannot NewParameters: unknown scheme %q", scheme)
+	}
+
+	return params, nil
+}
+
+// decodeCiphertext returns the marshaled ciphertext of the scheme of the parameters, as a *bfv.Ciphertext or a
+// *ckks.Ciphertext, with its rlwe.Ciphertext.
+func (params *parameters) decodeCiphertext(data []byte) (ct interface{}, ctRLWE *rlwe.Ciphertext, err error) {
+	switch params.scheme {
+	case "bfv":
+		ctBFV := new(bfv.Ciphertext)
+		if err = ctBFV.UnmarshalBinary(data); err != nil {
+			return nil, nil, err
+		}
+		return ctBFV, ctBFV.Ciphertext, nil
+	default:
+		ctCKKS := new(ckks.Ciphertext)
+		if err = ctCKKS.UnmarshalBinary(data); err != nil {
+			return nil, nil, err
+		}
+		return ctCKKS, ctCKKS.Ciphertext, nil
+	}
+}
+
+// genSecretKey returns a marshaled fresh secret key.
+func genSecretKey(params *parameters) ([]byte, error) {
+	return rlwe.NewKeyGenerator(params.rlwe).GenSecretKey().MarshalBinary()
+}
+
+// genPublicKey returns the marshaled public key of the marshaled secret key.
+func genPublicKey(params *parameters, sk []byte) ([]byte, error) {
+	key, err := decodeSecretKey(sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenPublicKey: %w", err)
+	}
+	return rlwe.NewKeyGenerator(params.rlwe).GenPublicKey(key).MarshalBinary()
+}
+
+// genRelinearizationKey returns the marshaled relinearization key of the marshaled secret key.
+func genRelinearizationKey(params *parameters, sk []byte) ([]byte, error) {
+	key, err := decodeSecretKey(sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRelinearizationKey: %w", err)
+	}
+	return rlwe.NewKeyGenerator(params.rlwe).GenRelinearizationKey(key, 1).MarshalBinary()
+}
+
+// genRotationKeys returns the marshaled rotation keys of the marshaled secret key for the rotations by the steps ks,
+// i.e. the column rotations of the BFV scheme and the slot rotations of the CKKS scheme.
+func genRotationKeys(params *parameters, sk []byte, ks []int) ([]byte, error) {
+	key, err := decodeSecretKey(sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRotationKeys: %w", err)
+	}
+	return rlwe.NewKeyGenerator(params.rlwe).GenRotationKeysForRotations(ks, false, key).MarshalBinary()
+}
+
+// encrypt encodes and encrypts the values with the marshaled public key and returns the marshaled ciphertext. The
+// BFV values are rounded to integers.
+func encrypt(params *parameters, pk []byte, values []float64) ([]byte, error) {
+
+	key := new(rlwe.PublicKey)
+	if err := key.UnmarshalBinary(pk); err != nil {
+		return nil, fmt.Errorf("cannot Encrypt: %w", err)
+	}
+
+	switch params.scheme {
+	case "bfv":
+		if len(values) > params.bfv.N() {
+			return nil, fmt.Errorf("cannot Encrypt: more than %d values", params.bfv.N())
+		}
+		coeffs := make([]int64, len(values))
+		for i, v := range values {
+			coeffs[i] = int64(math.Round(v))
+		}
+		pt := bfv.NewPlaintext(params.bfv)
+		bfv.NewEncoder(params.bfv).EncodeInt(coeffs, pt)
+		return bfv.NewEncryptor(params.bfv, key).EncryptNew(pt).MarshalBinary()
+	default:
+		if len(values) > params.ckks.Slots() {
+			return nil, fmt.Errorf("cannot Encrypt: more than %d values", params.ckks.Slots())
+		}
+		pt := ckks.NewEncoder(params.ckks).EncodeNew(values, params.ckks.MaxLevel(), params.ckks.DefaultScale(), params.ckks.LogSlots())
+		return ckks.NewEncryptor(params.ckks, key).EncryptNew(pt).MarshalBinary()
+	}
+}
+
+// decrypt decrypts the marshaled ciphertext with the marshaled secret key and returns the decoded values. The
+// imaginary parts of the CKKS values are discarded.
+func decrypt(params *parameters, sk, ct []byte) (values []float64, err error) {
+
+	key, err := decodeSecretKey(sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Decrypt: %w", err)
+	}
+
+	ciphertext, _, err := params.decodeCiphertext(ct)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Decrypt: %w", err)
+	}
+
+	switch ciphertext := ciphertext.(type) {
+	case *bfv.Ciphertext:
+		coeffs := bfv.NewEncoder(params.bfv).DecodeIntNew(bfv.NewDecryptor(params.bfv, key).DecryptNew(ciphertext))
+		values = make([]float64, len(coeffs))
+		for i, v := range coeffs {
+			values[i] = float64(v)
+		}
+	case *ckks.Ciphertext:
+		slots := ckks.NewEncoder(params.ckks).Decode(ckks.NewDecryptor(params.ckks, key).DecryptNew(ciphertext), params.ckks.LogSlots())
+		values = make([]float64, len(slots))
+		for i, v := range slots {
+			values[i] = real(v)
+		}
+	}
+
+	return values, nil
+}
+
+// evaluator is an evaluator of the scheme of its parameters, with the evaluation keys it was created with.
+type evaluator struct {
+	params *parameters
+	evk    rlwe.EvaluationKey
+	bfv    bfv.Evaluator
+	ckks   ckks.Evaluator
+}
+
+// newEvaluator returns an evaluator with the marshaled relinearization key and rotation keys, which may be empty.
+func newEvaluator(params *parameters, rlk, rtks []byte) (*evaluator, error) {
+
+	var evk rlwe.EvaluationKey
+
+	if len(rlk) > 0 {
+		evk.Rlk = new(rlwe.RelinearizationKey)
+		if err := evk.Rlk.UnmarshalBinary(rlk); err != nil {
+			return nil, fmt.Errorf("cannot NewEvaluator: %w", err)
+		}
+	}
+
+	if len(rtks) > 0 {
+		evk.Rtks = new(rlwe.RotationKeySet)
+		if err := evk.Rtks.UnmarshalBinary(rtks); err != nil {
+			return nil, fmt.Errorf("cannot NewEvaluator: %w", err)
+		}
+	}
+
+	eval := &evaluator{params: params, evk: evk}
+	switch params.scheme {
+	case "bfv":
+		eval.bfv = bfv.NewEvaluator(params.bfv, evk)
+	default:
+		eval.ckks = ckks.NewEvaluator(params.ckks, evk)
+	}
+
+	return eval, nil
+}
+
+// apply decodes the marshaled ciphertexts, applies the operation of the scheme of the evaluator to them and returns
+// the marshaled result.
+func (eval *evaluator) apply(cts [][]byte, opBFV func(cts []*bfv.Ciphertext) (*bfv.Ciphertext, error), opCKKS func(cts []*ckks.Ciphertext) (*ckks.Ciphertext, error)) ([]byte, error) {
+
+	ctsBFV := make([]*bfv.Ciphertext, len(cts))
+	ctsCKKS := make([]*ckks.Ciphertext, len(cts))
+	for i := range cts {
+		ct, _, err := eval.params.decodeCiphertext(cts[i])
+		if err != nil {
+			return nil, err
+		}
+		ctsBFV[i], _ = ct.(*bfv.Ciphertext)
+		ctsCKKS[i], _ = ct.(*ckks.Ciphertext)
+	}
+
+	if eval.params.scheme == "bfv" {
+		ctOut, err := opBFV(ctsBFV)
+		if err != nil {
+			return nil, err
+		}
+		return ctOut.MarshalBinary()
+	}
+
+	ctOut, err := opCKKS(ctsCKKS)
+	if err != nil {
+		return nil, err
+	}
+	return ctOut.MarshalBinary()
+}
+
+// add returns the marshaled sum of the marshaled ciphertexts ct0 and ct1.
+func (eval *evaluator) add(ct0, ct1 []byte) (ct []byte, err error) {
+	if ct, err = eval.apply([][]byte{ct0, ct1},
+		func(cts []*bfv.Ciphertext) (*bfv.Ciphertext, error) { return eval.bfv.AddNew(cts[0], cts[1]), nil },
+		func(cts []*ckks.Ciphertext) (*ckks.Ciphertext, error) { return eval.ckks.AddNew(cts[0], cts[1]), nil },
+	); err != nil {
+		return nil, fmt.Errorf("cannot Add: %w", err)
+	}
+	return
+}
+
+// sub returns the marshaled difference of the marshaled ciphertexts ct0 and ct1.
+func (eval *evaluator) sub(ct0, ct1 []byte) (ct []byte, err error) {
+	if ct, err = eval.apply([][]byte{ct0, ct1},
+		func(cts []*bfv.Ciphertext) (*bfv.Ciphertext, error) { return eval.bfv.SubNew(cts[0], cts[1]), nil },
+		func(cts []*ckks.Ciphertext) (*ckks.Ciphertext, error) { return eval.ckks.SubNew(cts[0], cts[1]), nil },
+	); err != nil {
+		return nil, fmt.Errorf("cannot Sub: %w", err)
+	}
+	return
+}
+
+// mul returns the marshaled product of the marshaled ciphertexts ct0 and ct1, relinearized, and rescaled for the CKKS
+// scheme. The evaluator must have a relinearization key.
+func (eval *evaluator) mul(ct0, ct1 []byte) (ct []byte, err error) {
+	if ct, err = eval.apply([][]byte{ct0, ct1},
+		func(cts []*bfv.Ciphertext) (*bfv.Ciphertext, error) {
+			return eval.bfv.RelinearizeNew(eval.bfv.MulNew(cts[0], cts[1])), nil
+		},
+		func(cts []*ckks.Ciphertext) (*ckks.Ciphertext, error) {
+			ctOut := eval.ckks.MulRelinNew(cts[0], cts[1])
+			return ctOut, eval.ckks.Rescale(ctOut, eval.params.ckks.DefaultScale(), ctOut)
+		},
+	); err != nil {
+		return nil, fmt.Errorf("cannot Mul: %w", err)
+	}
+	return
+}
+
+// rotate returns the marshaled rotation by k of the marshaled ciphertext ct, i.e. the rotation of the columns for the
+// BFV scheme and of the slots for the CKKS scheme. The evaluator must have the rotation key of k.
+func (eval *evaluator) rotate(ct0 []byte, k int) (ct []byte, err error) {
+	galEl := eval.params.rlwe.GaloisElementForColumnRotationBy(k)
+	if eval.evk.Rtks == nil {
+		return nil, fmt.Errorf("cannot Rotate: no rotation key for rotation by %d", k)
+	}
+	if _, ok := eval.evk.Rtks.GetRotationKey(galEl); !ok {
+		return nil, fmt.Errorf("cannot Rotate: no rotation key for rotation by %d", k)
+	}
+	if ct, err = eval.apply([][]byte{ct0},
+		func(cts []*bfv.Ciphertext) (*bfv.Ciphertext, error) { return eval.bfv.RotateColumnsNew(cts[0], k), nil },
+		func(cts []*ckks.Ciphertext) (*ckks.Ciphertext, error) { return eval.ckks.RotateNew(cts[0], k), nil },
+	); err != nil {
+		return nil, fmt.Errorf("cannot Rotate: %w", err)
+	}
+	return
+}
+
+// genCKGShare returns the marshaled share of the marshaled secret key in the CKG protocol, whose common reference
+// polynomial is sampled from the seed of the session.
+func genCKGShare(params *parameters, sk, seed []byte) ([]byte, error) {
+
+	key, crs, err := decodeSecretKeyAndSeed(sk, seed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenCKGShare: %w", err)
+	}
+
+	ckg := drlwe.NewCKGProtocol(params.rlwe)
+	share := ckg.AllocateShare()
+	ckg.GenShare(key, ckg.SampleCRP(crs), share)
+	return share.MarshalBinary()
+}
+
+// genRKGShareRoundOne returns the marshaled ephemeral secret key and share of the marshaled secret key in the first
+// round of the RKG protocol, whose common reference polynomials are sampled from the seed of the session. The
+// ephemeral secret key must be kept secret by the caller until the second round.
+func genRKGShareRoundOne(params *parameters, sk, seed []byte) (ephSk, share []byte, err error) {
+
+	key, crs, err := decodeSecretKeyAndSeed(sk, seed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot GenRKGShareRoundOne: %w", err)
+	}
+
+	rkg := drlwe.NewRKGProtocol(params.rlwe)
+	ephKey, share1, _ := rkg.AllocateShare()
+	rkg.GenShareRoundOne(key, rkg.SampleCRP(crs), ephKey, share1)
+
+	if ephSk, err = ephKey.MarshalBinary(); err != nil {
+		return nil, nil, err
+	}
+	if share, err = share1.MarshalBinary(); err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+// genRKGShareRoundTwo returns the marshaled share of the marshaled secret key in the second round of the RKG protocol,
+// given the marshaled ephemeral secret key of the first round and the marshaled aggregation of the shares of the first
+// round.
+func genRKGShareRoundTwo(params *parameters, ephSk, sk, round1 []byte) ([]byte, error) {
+
+	key, err := decodeSecretKey(sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRKGShareRoundTwo: %w", err)
+	}
+
+	ephKey, err := decodeSecretKey(ephSk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRKGShareRoundTwo: %w", err)
+	}
+
+	rkg := drlwe.NewRKGProtocol(params.rlwe)
+	_, share1, share2 := rkg.AllocateShare()
+	if err = share1.UnmarshalBinary(round1); err != nil {
+		return nil, fmt.Errorf("cannot GenRKGShareRoundTwo: %w", err)
+	}
+
+	rkg.GenShareRoundTwo(ephKey, key, share1, share2)
+	return share2.MarshalBinary()
+}
+
+// genRTGShare returns the marshaled share of the marshaled secret key in the RTG protocol of the Galois element galEl,
+// whose common reference polynomials are sampled from the seed of the session.
+func genRTGShare(params *parameters, sk []byte, galEl uint64, seed []byte) ([]byte, error) {
+
+	key, crs, err := decodeSecretKeyAndSeed(sk, seed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRTGShare: %w", err)
+	}
+
+	rtg := drlwe.NewRTGProtocol(params.rlwe)
+	share := rtg.AllocateShare()
+	rtg.GenShare(key, galEl, rtg.SampleCRP(crs), share)
+	return share.MarshalBinary()
+}
+
+// genCKSShare returns the marshaled share of the marshaled secret key in the CKS protocol from the collective secret
+// key to the zero key, i.e. in the collective decryption of the marshaled ciphertext ct, with a smudging noise of
+// standard deviation sigmaSmudging.
+func genCKSShare(params *parameters, sk, ct []byte, sigmaSmudging float64) ([]byte, error) {
+
+	key, err := decodeSecretKey(sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenCKSShare: %w", err)
+	}
+
+	c1, err := params.ciphertextC1(ct)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenCKSShare: %w", err)
+	}
+
+	cks := drlwe.NewCKSProtocol(params.rlwe, sigmaSmudging)
+	share := cks.AllocateShare(c1.Level())
+	cks.GenShare(key, rlwe.NewSecretKey(params.rlwe), c1, share)
+	return share.MarshalBinary()
+}
+
+// genPCKSShare returns the marshaled share of the marshaled secret key in the PCKS protocol from the collective
+// secret key to the marshaled public key pk of the marshaled ciphertext ct, with a smudging noise of standard
+// deviation sigmaSmudging.
+func genPCKSShare(params *parameters, sk, pk, ct []byte, sigmaSmudging float64) ([]byte, error) {
+
+	key, err := decodeSecretKey(sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenPCKSShare: %w", err)
+	}
+
+	pubKey := new(rlwe.PublicKey)
+	if err = pubKey.UnmarshalBinary(pk); err != nil {
+		return nil, fmt.Errorf("cannot GenPCKSShare: %w", err)
+	}
+
+	c1, err := params.ciphertextC1(ct)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenPCKSShare: %w", err)
+	}
+
+	pcks := drlwe.NewPCKSProtocol(params.rlwe, sigmaSmudging)
+	share := pcks.AllocateShare(c1.Level())
+	pcks.GenShare(key, pubKey, c1, share)
+	return share.MarshalBinary()
+}
+
+// ciphertextC1 returns the degree one polynomial of the marshaled ciphertext.
+func (params *parameters) ciphertextC1(ct []byte) (*ring.Poly, error) {
+
+	_, ciphertext, err := params.decodeCiphertext(ct)
+	if err != nil {
+		return nil, err
+	}
+
+	if ciphertext.Degree() != 1 {
+		return nil, fmt.Errorf("ciphertext of degree %d instead of 1", ciphertext.Degree())
+	}
+
+	return ciphertext.Value[1], nil
+}
+
+// decodeSecretKey returns the marshaled secret key.
+func decodeSecretKey(data []byte) (*rlwe.SecretKey, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty secret key")
+	}
+	sk := new(rlwe.SecretKey)
+	if err := sk.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return sk, nil
+}
+
+// decodeSecretKeyAndSeed returns the marshaled secret key and the CRS of the seed.
+func decodeSecretKeyAndSeed(sk, seed []byte) (*rlwe.SecretKey, drlwe.CRS, error) {
+
+	key, err := decodeSecretKey(sk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(seed) == 0 {
+		return nil, nil, errors.New("empty seed")
+	}
+
+	prng, err := utils.NewKeyedPRNG(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, prng, nil
+}
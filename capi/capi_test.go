@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCAPI(t *testing.T) {
+
+	paramsBFV, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	require.NoError(t, err)
+	paramsCKKS, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		scheme string
+		params interface{}
+		delta  float64
+	}{
+		{"bfv", paramsBFV, 0},
+		{"ckks", paramsCKKS, 1e-3},
+	} {
+
+		paramsJSON, err := json.Marshal(tc.params)
+		require.NoError(t, err)
+		params, err := newParameters(tc.scheme, paramsJSON)
+		require.NoError(t, err)
+
+		sk, err := genSecretKey(params)
+		require.NoError(t, err)
+		pk, err := genPublicKey(params, sk)
+		require.NoError(t, err)
+
+		t.Run(tc.scheme+"/Evaluator", func(t *testing.T) {
+
+			rlk, err := genRelinearizationKey(params, sk)
+			require.NoError(t, err)
+			rtks, err := genRotationKeys(params, sk, []int{1})
+			require.NoError(t, err)
+
+			eval, err := newEvaluator(params, rlk, rtks)
+			require.NoError(t, err)
+
+			values0 := []float64{1, 2, 3, 4}
+			values1 := []float64{5, 6, 7, 8}
+			ct0, err := encrypt(params, pk, values0)
+			require.NoError(t, err)
+			ct1, err := encrypt(params, pk, values1)
+			require.NoError(t, err)
+
+			add, err := eval.add(ct0, ct1)
+			require.NoError(t, err)
+			sub, err := eval.sub(ct0, ct1)
+			require.NoError(t, err)
+			mul, err := eval.mul(ct0, ct1)
+			require.NoError(t, err)
+			rot, err := eval.rotate(ct0, 1)
+			require.NoError(t, err)
+
+			for _, op := range []struct {
+				ct   []byte
+				want func(i int) float64
+			}{
+				{add, func(i int) float64 { return values0[i] + values1[i] }},
+				{sub, func(i int) float64 { return values0[i] - values1[i] }},
+				{mul, func(i int) float64 { return values0[i] * values1[i] }},
+				{rot, func(i int) float64 {
+					if i+1 < len(values0) {
+						return values0[i+1]
+					}
+					return 0
+				}},
+			} {
+				have, err := decrypt(params, sk, op.ct)
+				require.NoError(t, err)
+				for i := range values0 {
+					require.InDelta(t, op.want(i), have[i], tc.delta)
+				}
+			}
+
+			_, err = eval.rotate(ct0, 2)
+			require.Error(t, err)
+
+			// The panics, e.g. of an invalid ciphertext, are returned as errors by the exported functions
+			require.Error(t, catch(func() error {
+				_, err := eval.add(ct0[:len(ct0)/2], ct1)
+				return err
+			}))
+		})
+
+		t.Run(tc.scheme+"/Protocols", func(t *testing.T) {
+
+			sks := [][]byte{sk, nil}
+			sks[1], err = genSecretKey(params)
+			require.NoError(t, err)
+			seed := []byte("capi test")
+
+			// Collective public key
+			ckg := drlwe.NewCKGProtocol(params.rlwe)
+			prng, err := utils.NewKeyedPRNG(seed)
+			require.NoError(t, err)
+			crp := ckg.SampleCRP(prng)
+			shareCKG := ckg.AllocateShare()
+			for i := range sks {
+				data, err := genCKGShare(params, sks[i], seed)
+				require.NoError(t, err)
+				share := ckg.AllocateShare()
+				require.NoError(t, share.UnmarshalBinary(data))
+				ckg.AggregateShare(share, shareCKG, shareCKG)
+			}
+			cpk := rlwe.NewPublicKey(params.rlwe)
+			ckg.GenPublicKey(shareCKG, crp, cpk)
+			cpkData, err := cpk.MarshalBinary()
+			require.NoError(t, err)
+
+			// Relinearization key
+			rkg := drlwe.NewRKGProtocol(params.rlwe)
+			_, shareRKG1, shareRKG2 := rkg.AllocateShare()
+			ephSks := make([][]byte, len(sks))
+			for i := range sks {
+				var data []byte
+				ephSks[i], data, err = genRKGShareRoundOne(params, sks[i], seed)
+				require.NoError(t, err)
+				_, share, _ := rkg.AllocateShare()
+				require.NoError(t, share.UnmarshalBinary(data))
+				rkg.AggregateShare(share, shareRKG1, shareRKG1)
+			}
+			round1, err := shareRKG1.MarshalBinary()
+			require.NoError(t, err)
+			for i := range sks {
+				data, err := genRKGShareRoundTwo(params, ephSks[i], sks[i], round1)
+				require.NoError(t, err)
+				_, _, share := rkg.AllocateShare()
+				require.NoError(t, share.UnmarshalBinary(data))
+				rkg.AggregateShare(share, shareRKG2, shareRKG2)
+			}
+			rlk := rlwe.NewRelinKey(params.rlwe, 1)
+			rkg.GenRelinearizationKey(shareRKG1, shareRKG2, rlk)
+			rlkData, err := rlk.MarshalBinary()
+			require.NoError(t, err)
+
+			// Rotation key
+			galEl := params.rlwe.GaloisElementForColumnRotationBy(1)
+			rtg := drlwe.NewRTGProtocol(params.rlwe)
+			prng, err = utils.NewKeyedPRNG(seed)
+			require.NoError(t, err)
+			crpRTG := rtg.SampleCRP(prng)
+			shareRTG := rtg.AllocateShare()
+			for i := range sks {
+				data, err := genRTGShare(params, sks[i], galEl, seed)
+				require.NoError(t, err)
+				share := rtg.AllocateShare()
+				require.NoError(t, share.UnmarshalBinary(data))
+				rtg.AggregateShare(share, shareRTG, shareRTG)
+			}
+			rtks := rlwe.NewRotationKeySet(params.rlwe, []uint64{galEl})
+			rtg.GenRotationKey(shareRTG, crpRTG, rtks.Keys[galEl])
+			rtksData, err := rtks.MarshalBinary()
+			require.NoError(t, err)
+
+			eval, err := newEvaluator(params, rlkData, rtksData)
+			require.NoError(t, err)
+
+			values := []float64{1, 2, 3, 4}
+			ct, err := encrypt(params, cpkData, values)
+			require.NoError(t, err)
+			ct, err = eval.mul(ct, ct)
+			require.NoError(t, err)
+			ct, err = eval.rotate(ct, 1)
+			require.NoError(t, err)
+			want := []float64{4, 9, 16, 0}
+
+			// Collective decryption
+			_, ctRLWE, err := params.decodeCiphertext(ct)
+			require.NoError(t, err)
+			cks := drlwe.NewCKSProtocol(params.rlwe, 3.2)
+			shareCKS := cks.AllocateShare(ctRLWE.Level())
+			for i := range sks {
+				data, err := genCKSShare(params, sks[i], ct, 3.2)
+				require.NoError(t, err)
+				share := cks.AllocateShare(ctRLWE.Level())
+				require.NoError(t, share.UnmarshalBinary(data))
+				cks.AggregateShare(share, shareCKS, shareCKS)
+			}
+			cks.KeySwitch(ctRLWE, shareCKS, ctRLWE)
+			ctOut, err := encodeCiphertext(params, ct, ctRLWE)
+			require.NoError(t, err)
+			have, err := decrypt(params, rlweSecretKeyZero(t, params), ctOut)
+			require.NoError(t, err)
+			for i := range want {
+				require.InDelta(t, want[i], have[i], 1e-2+tc.delta*10)
+			}
+
+			// Key switch to the public key of the first party
+			pcks := drlwe.NewPCKSProtocol(params.rlwe, 3.2)
+			_, ctRLWE, err = params.decodeCiphertext(ct)
+			require.NoError(t, err)
+			sharePCKS := pcks.AllocateShare(ctRLWE.Level())
+			for i := range sks {
+				data, err := genPCKSShare(params, sks[i], pk, ct, 3.2)
+				require.NoError(t, err)
+				share := pcks.AllocateShare(ctRLWE.Level())
+				require.NoError(t, share.UnmarshalBinary(data))
+				pcks.AggregateShare(share, sharePCKS, sharePCKS)
+			}
+			pcks.KeySwitch(ctRLWE, sharePCKS, ctRLWE)
+			ctOut, err = encodeCiphertext(params, ct, ctRLWE)
+			require.NoError(t, err)
+			have, err = decrypt(params, sk, ctOut)
+			require.NoError(t, err)
+			for i := range want {
+				require.InDelta(t, want[i], have[i], 1e-2+tc.delta*10)
+			}
+		})
+	}
+
+	t.Run("Handles", func(t *testing.T) {
+
+		_, err := newParameters("bgv", []byte("{}"))
+		require.Error(t, err)
+
+		paramsJSON, err := json.Marshal(paramsBFV)
+		require.NoError(t, err)
+		params, err := newParameters("bfv", paramsJSON)
+		require.NoError(t, err)
+
+		h := newHandle(params)
+		p, err := lookupParameters(h)
+		require.NoError(t, err)
+		require.Equal(t, params, p)
+		_, err = lookupEvaluator(h)
+		require.Error(t, err)
+
+		deleteHandle(h)
+		_, err = lookupParameters(h)
+		require.Error(t, err)
+		_, err = lookupParameters(0)
+		require.Error(t, err)
+
+		_, err = genCKGShare(params, nil, []byte("seed"))
+		require.Error(t, err)
+	})
+}
+
+// encodeCiphertext returns the marshaled ciphertext ct whose rlwe.Ciphertext is replaced by ctRLWE.
+func encodeCiphertext(params *parameters, ct []byte, ctRLWE *rlwe.Ciphertext) ([]byte, error) {
+	ciphertext, _, err := params.decodeCiphertext(ct)
+	if err != nil {
+		return nil, err
+	}
+	switch ciphertext := ciphertext.(type) {
+	case *bfv.Ciphertext:
+		ciphertext.Ciphertext = ctRLWE
+		return ciphertext.MarshalBinary()
+	default:
+		ciphertext.(*ckks.Ciphertext).Ciphertext = ctRLWE
+		return ciphertext.(*ckks.Ciphertext).MarshalBinary()
+	}
+}
+
+// rlweSecretKeyZero returns the marshaled zero secret key, the target key of the collective decryption.
+func rlweSecretKeyZero(t *testing.T, params *parameters) []byte {
+	sk, err := rlwe.NewSecretKey(params.rlwe).MarshalBinary()
+	require.NoError(t, err)
+	return sk
+}
@@ -0,0 +1,323 @@
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// maxLen is the maximum length of the arrays of doubles and int64 exchanged with the callers.
+const maxLen = 1 << 27
+
+// LattigoFree releases a buffer or an error message returned by the library.
+//
+//export LattigoFree
+func LattigoFree(ptr unsafe.Pointer) {
+	C.free(ptr)
+}
+
+// LattigoDelete releases the handle of parameters or of an evaluator.
+//
+//export LattigoDelete
+func LattigoDelete(h C.uintptr_t) {
+	deleteHandle(uintptr(h))
+}
+
+// LattigoNewParameters sets params to the handle of the parameters of the scheme "bfv" or "ckks" marshaled in JSON.
+//
+//export LattigoNewParameters
+func LattigoNewParameters(scheme, paramsJSON *C.char, params *C.uintptr_t) *C.char {
+	return cError(catch(func() error {
+		p, err := newParameters(C.GoString(scheme), []byte(C.GoString(paramsJSON)))
+		if err != nil {
+			return err
+		}
+		*params = C.uintptr_t(newHandle(p))
+		return nil
+	}))
+}
+
+// LattigoGaloisElementForRotation sets galEl to the Galois element of the rotation by k, i.e. of the column rotation
+// of the BFV scheme and of the slot rotation of the CKKS scheme, e.g. for the RTG protocol.
+//
+//export LattigoGaloisElementForRotation
+func LattigoGaloisElementForRotation(params C.uintptr_t, k C.int64_t, galEl *C.uint64_t) *C.char {
+	return cError(catch(func() error {
+		p, err := lookupParameters(uintptr(params))
+		if err != nil {
+			return err
+		}
+		*galEl = C.uint64_t(p.rlwe.GaloisElementForColumnRotationBy(int(k)))
+		return nil
+	}))
+}
+
+// LattigoGenSecretKey sets sk to a marshaled fresh secret key.
+//
+//export LattigoGenSecretKey
+func LattigoGenSecretKey(params C.uintptr_t, sk **C.uint8_t, skLen *C.size_t) *C.char {
+	return withParameters(params, sk, skLen, func(p *parameters) ([]byte, error) {
+		return genSecretKey(p)
+	})
+}
+
+// LattigoGenPublicKey sets pk to the marshaled public key of the marshaled secret key sk.
+//
+//export LattigoGenPublicKey
+func LattigoGenPublicKey(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, pk **C.uint8_t, pkLen *C.size_t) *C.char {
+	return withParameters(params, pk, pkLen, func(p *parameters) ([]byte, error) {
+		return genPublicKey(p, goBytes(sk, skLen))
+	})
+}
+
+// LattigoGenRelinearizationKey sets rlk to the marshaled relinearization key of the marshaled secret key sk.
+//
+//export LattigoGenRelinearizationKey
+func LattigoGenRelinearizationKey(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, rlk **C.uint8_t, rlkLen *C.size_t) *C.char {
+	return withParameters(params, rlk, rlkLen, func(p *parameters) ([]byte, error) {
+		return genRelinearizationKey(p, goBytes(sk, skLen))
+	})
+}
+
+// LattigoGenRotationKeys sets rtks to the marshaled rotation keys of the marshaled secret key sk for the rotations by
+// the ksLen steps ks.
+//
+//export LattigoGenRotationKeys
+func LattigoGenRotationKeys(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, ks *C.int64_t, ksLen C.size_t, rtks **C.uint8_t, rtksLen *C.size_t) *C.char {
+	return withParameters(params, rtks, rtksLen, func(p *parameters) ([]byte, error) {
+		steps := make([]int, ksLen)
+		if ksLen > 0 {
+			for i, k := range (*[maxLen]C.int64_t)(unsafe.Pointer(ks))[:ksLen:ksLen] {
+				steps[i] = int(k)
+			}
+		}
+		return genRotationKeys(p, goBytes(sk, skLen), steps)
+	})
+}
+
+// LattigoEncrypt sets ct to the marshaled encryption of the valuesLen values with the marshaled public key pk. The
+// BFV values are rounded to integers.
+//
+//export LattigoEncrypt
+func LattigoEncrypt(params C.uintptr_t, pk *C.uint8_t, pkLen C.size_t, values *C.double, valuesLen C.size_t, ct **C.uint8_t, ctLen *C.size_t) *C.char {
+	return withParameters(params, ct, ctLen, func(p *parameters) ([]byte, error) {
+		v := make([]float64, valuesLen)
+		if valuesLen > 0 {
+			for i, x := range (*[maxLen]C.double)(unsafe.Pointer(values))[:valuesLen:valuesLen] {
+				v[i] = float64(x)
+			}
+		}
+		return encrypt(p, goBytes(pk, pkLen), v)
+	})
+}
+
+// LattigoDecrypt sets values to the decrypted and decoded values of the marshaled ciphertext ct, decrypted with the
+// marshaled secret key sk. The values are released with LattigoFree.
+//
+//export LattigoDecrypt
+func LattigoDecrypt(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, ct *C.uint8_t, ctLen C.size_t, values **C.double, valuesLen *C.size_t) *C.char {
+	return cError(catch(func() error {
+		p, err := lookupParameters(uintptr(params))
+		if err != nil {
+			return err
+		}
+		v, err := decrypt(p, goBytes(sk, skLen), goBytes(ct, ctLen))
+		if err != nil {
+			return err
+		}
+		out := (*[maxLen]C.double)(C.malloc(C.size_t(len(v)) * C.size_t(unsafe.Sizeof(C.double(0)))))
+		for i := range v {
+			out[i] = C.double(v[i])
+		}
+		*values = &out[0]
+		*valuesLen = C.size_t(len(v))
+		return nil
+	}))
+}
+
+// LattigoNewEvaluator sets eval to the handle of an evaluator with the marshaled relinearization key rlk and rotation
+// keys rtks, which may be empty.
+//
+//export LattigoNewEvaluator
+func LattigoNewEvaluator(params C.uintptr_t, rlk *C.uint8_t, rlkLen C.size_t, rtks *C.uint8_t, rtksLen C.size_t, eval *C.uintptr_t) *C.char {
+	return cError(catch(func() error {
+		p, err := lookupParameters(uintptr(params))
+		if err != nil {
+			return err
+		}
+		e, err := newEvaluator(p, goBytes(rlk, rlkLen), goBytes(rtks, rtksLen))
+		if err != nil {
+			return err
+		}
+		*eval = C.uintptr_t(newHandle(e))
+		return nil
+	}))
+}
+
+// LattigoAdd sets ct to the marshaled sum of the marshaled ciphertexts ct0 and ct1.
+//
+//export LattigoAdd
+func LattigoAdd(eval C.uintptr_t, ct0 *C.uint8_t, ct0Len C.size_t, ct1 *C.uint8_t, ct1Len C.size_t, ct **C.uint8_t, ctLen *C.size_t) *C.char {
+	return withEvaluator(eval, ct, ctLen, func(e *evaluator) ([]byte, error) {
+		return e.add(goBytes(ct0, ct0Len), goBytes(ct1, ct1Len))
+	})
+}
+
+// LattigoSub sets ct to the marshaled difference of the marshaled ciphertexts ct0 and ct1.
+//
+//export LattigoSub
+func LattigoSub(eval C.uintptr_t, ct0 *C.uint8_t, ct0Len C.size_t, ct1 *C.uint8_t, ct1Len C.size_t, ct **C.uint8_t, ctLen *C.size_t) *C.char {
+	return withEvaluator(eval, ct, ctLen, func(e *evaluator) ([]byte, error) {
+		return e.sub(goBytes(ct0, ct0Len), goBytes(ct1, ct1Len))
+	})
+}
+
+// LattigoMul sets ct to the marshaled product of the marshaled ciphertexts ct0 and ct1, relinearized, and rescaled for
+// the CKKS scheme. The evaluator must have a relinearization key.
+//
+//export LattigoMul
+func LattigoMul(eval C.uintptr_t, ct0 *C.uint8_t, ct0Len C.size_t, ct1 *C.uint8_t, ct1Len C.size_t, ct **C.uint8_t, ctLen *C.size_t) *C.char {
+	return withEvaluator(eval, ct, ctLen, func(e *evaluator) ([]byte, error) {
+		return e.mul(goBytes(ct0, ct0Len), goBytes(ct1, ct1Len))
+	})
+}
+
+// LattigoRotate sets ct to the marshaled rotation by k of the marshaled ciphertext ct0. The evaluator must have the
+// rotation key of k.
+//
+//export LattigoRotate
+func LattigoRotate(eval C.uintptr_t, ct0 *C.uint8_t, ct0Len C.size_t, k C.int64_t, ct **C.uint8_t, ctLen *C.size_t) *C.char {
+	return withEvaluator(eval, ct, ctLen, func(e *evaluator) ([]byte, error) {
+		return e.rotate(goBytes(ct0, ct0Len), int(k))
+	})
+}
+
+// LattigoGenCKGShare sets share to the marshaled share of the marshaled secret key sk in the CKG protocol of the
+// session seed.
+//
+//export LattigoGenCKGShare
+func LattigoGenCKGShare(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, seed *C.uint8_t, seedLen C.size_t, share **C.uint8_t, shareLen *C.size_t) *C.char {
+	return withParameters(params, share, shareLen, func(p *parameters) ([]byte, error) {
+		return genCKGShare(p, goBytes(sk, skLen), goBytes(seed, seedLen))
+	})
+}
+
+// LattigoGenRKGShareRoundOne sets ephSk and share to the marshaled ephemeral secret key and share of the marshaled
+// secret key sk in the first round of the RKG protocol of the session seed. The ephemeral secret key must be kept
+// secret until the second round.
+//
+//export LattigoGenRKGShareRoundOne
+func LattigoGenRKGShareRoundOne(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, seed *C.uint8_t, seedLen C.size_t, ephSk **C.uint8_t, ephSkLen *C.size_t, share **C.uint8_t, shareLen *C.size_t) *C.char {
+	return cError(catch(func() error {
+		p, err := lookupParameters(uintptr(params))
+		if err != nil {
+			return err
+		}
+		eph, s, err := genRKGShareRoundOne(p, goBytes(sk, skLen), goBytes(seed, seedLen))
+		if err != nil {
+			return err
+		}
+		setBytes(ephSk, ephSkLen, eph)
+		setBytes(share, shareLen, s)
+		return nil
+	}))
+}
+
+// LattigoGenRKGShareRoundTwo sets share to the marshaled share of the marshaled secret key sk in the second round of
+// the RKG protocol, given the marshaled ephemeral secret key ephSk of the first round and the marshaled aggregation
+// round1 of the shares of the first round.
+//
+//export LattigoGenRKGShareRoundTwo
+func LattigoGenRKGShareRoundTwo(params C.uintptr_t, ephSk *C.uint8_t, ephSkLen C.size_t, sk *C.uint8_t, skLen C.size_t, round1 *C.uint8_t, round1Len C.size_t, share **C.uint8_t, shareLen *C.size_t) *C.char {
+	return withParameters(params, share, shareLen, func(p *parameters) ([]byte, error) {
+		return genRKGShareRoundTwo(p, goBytes(ephSk, ephSkLen), goBytes(sk, skLen), goBytes(round1, round1Len))
+	})
+}
+
+// LattigoGenRTGShare sets share to the marshaled share of the marshaled secret key sk in the RTG protocol of the
+// Galois element galEl and of the session seed.
+//
+//export LattigoGenRTGShare
+func LattigoGenRTGShare(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, galEl C.uint64_t, seed *C.uint8_t, seedLen C.size_t, share **C.uint8_t, shareLen *C.size_t) *C.char {
+	return withParameters(params, share, shareLen, func(p *parameters) ([]byte, error) {
+		return genRTGShare(p, goBytes(sk, skLen), uint64(galEl), goBytes(seed, seedLen))
+	})
+}
+
+// LattigoGenCKSShare sets share to the marshaled share of the marshaled secret key sk in the collective decryption of
+// the marshaled ciphertext ct, with a smudging noise of standard deviation sigmaSmudging.
+//
+//export LattigoGenCKSShare
+func LattigoGenCKSShare(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, ct *C.uint8_t, ctLen C.size_t, sigmaSmudging C.double, share **C.uint8_t, shareLen *C.size_t) *C.char {
+	return withParameters(params, share, shareLen, func(p *parameters) ([]byte, error) {
+		return genCKSShare(p, goBytes(sk, skLen), goBytes(ct, ctLen), float64(sigmaSmudging))
+	})
+}
+
+// LattigoGenPCKSShare sets share to the marshaled share of the marshaled secret key sk in the key switch of the
+// marshaled ciphertext ct to the marshaled public key pk, with a smudging noise of standard deviation sigmaSmudging.
+//
+//export LattigoGenPCKSShare
+func LattigoGenPCKSShare(params C.uintptr_t, sk *C.uint8_t, skLen C.size_t, pk *C.uint8_t, pkLen C.size_t, ct *C.uint8_t, ctLen C.size_t, sigmaSmudging C.double, share **C.uint8_t, shareLen *C.size_t) *C.char {
+	return withParameters(params, share, shareLen, func(p *parameters) ([]byte, error) {
+		return genPCKSShare(p, goBytes(sk, skLen), goBytes(pk, pkLen), goBytes(ct, ctLen), float64(sigmaSmudging))
+	})
+}
+
+// withParameters calls f with the parameters of the handle and sets out to its output.
+func withParameters(params C.uintptr_t, out **C.uint8_t, outLen *C.size_t, f func(p *parameters) ([]byte, error)) *C.char {
+	return cError(catch(func() error {
+		p, err := lookupParameters(uintptr(params))
+		if err != nil {
+			return err
+		}
+		b, err := f(p)
+		if err != nil {
+			return err
+		}
+		setBytes(out, outLen, b)
+		return nil
+	}))
+}
+
+// withEvaluator calls f with the evaluator of the handle and sets out to its output.
+func withEvaluator(eval C.uintptr_t, out **C.uint8_t, outLen *C.size_t, f func(e *evaluator) ([]byte, error)) *C.char {
+	return cError(catch(func() error {
+		e, err := lookupEvaluator(uintptr(eval))
+		if err != nil {
+			return err
+		}
+		b, err := f(e)
+		if err != nil {
+			return err
+		}
+		setBytes(out, outLen, b)
+		return nil
+	}))
+}
+
+// goBytes returns a copy of the buffer of n bytes.
+func goBytes(data *C.uint8_t, n C.size_t) []byte {
+	if n == 0 {
+		return nil
+	}
+	return C.GoBytes(unsafe.Pointer(data), C.int(n))
+}
+
+// setBytes sets out to a copy of b allocated with malloc.
+func setBytes(out **C.uint8_t, outLen *C.size_t, b []byte) {
+	*out = (*C.uint8_t)(C.CBytes(b))
+	*outLen = C.size_t(len(b))
+}
+
+// cError returns the error message allocated with malloc, or NULL if err is nil.
+func cError(err error) *C.char {
+	if err == nil {
+		return nil
+	}
+	return C.CString(err.Error())
+}
@@ -53,6 +53,7 @@ type Evaluator interface {
 	Power(ctIn *ckks.Ciphertext, degree int, ctOut *ckks.Ciphertext)
 	PowerNew(ctIn *ckks.Ciphertext, degree int) (ctOut *ckks.Ciphertext)
 	EvaluatePoly(ctIn *ckks.Ciphertext, pol *ckks.Polynomial, targetScale float64) (ctOut *ckks.Ciphertext, err error)
+	EvaluatePolyScaleInvariant(ctIn *ckks.Ciphertext, pol *ckks.Polynomial, targetScale float64, levelStart, levelEnd int) (ctOut *ckks.Ciphertext, err error)
 	EvaluatePolyVector(ctIn *ckks.Ciphertext, pols []*ckks.Polynomial, encoder ckks.Encoder, slotIndex map[int][]int, targetScale float64) (ctOut *ckks.Ciphertext, err error)
 	InverseNew(ctIn *ckks.Ciphertext, steps int) (ctOut *ckks.Ciphertext)
 	LinearTransformNew(ctIn *ckks.Ciphertext, linearTransform interface{}) (ctOut []*ckks.Ciphertext)
@@ -126,6 +127,8 @@ func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
 // Homomorphically encodes a complex vector vReal + i*vImag.
 // If the packing is sparse (n < N/2), then returns ctReal = Ecd(vReal || vImag) and ctImag = nil.
 // If the packing is dense (n == N/2), then returns ctReal = Ecd(vReal) and ctImag = Ecd(vImag).
+// The Evaluator must have the rotation keys for the rotations returned by ctsMatrices.Rotations(logN, logSlots),
+// and the correspondence between the coefficients and the vector vReal + i*vImag is given by ComplexToCoeffs.
 func (eval *evaluator) CoeffsToSlotsNew(ctIn *ckks.Ciphertext, ctsMatrices EncodingMatrix) (ctReal, ctImag *ckks.Ciphertext) {
 	ctReal = ckks.NewCiphertext(eval.params, 1, ctsMatrices.LevelStart, 0)
 
@@ -174,6 +177,8 @@ func (eval *evaluator) CoeffsToSlots(ctIn *ckks.Ciphertext, ctsMatrices Encoding
 // Homomorphically decodes a real vector of size 2n on a complex vector vReal + i*vImag of size n.
 // If the packing is sparse (n < N/2) then ctReal = Ecd(vReal || vImag) and ctImag = nil.
 // If the packing is dense (n == N/2), then ctReal = Ecd(vReal) and ctImag = Ecd(vImag).
+// The Evaluator must have the rotation keys for the rotations returned by stcMatrices.Rotations(logN, logSlots),
+// and the vector vReal + i*vImag can be read from the coefficients of the result with CoeffsToComplex.
 func (eval *evaluator) SlotsToCoeffsNew(ctReal, ctImag *ckks.Ciphertext, stcMatrices EncodingMatrix) (ctOut *ckks.Ciphertext) {
 
 	if ctReal.Level() < stcMatrices.LevelStart || (ctImag != nil && ctImag.Level() < stcMatrices.LevelStart) {
@@ -186,7 +191,7 @@ func (eval *evaluator) SlotsToCoeffsNew(ctReal, ctImag *ckks.Ciphertext, stcMatr
 
 }
 
-// SlotsToCoeffsNew applies the homomorphic decoding and returns the result on the provided ciphertext.
+// SlotsToCoeffs applies the homomorphic decoding and returns the result on the provided ciphertext.
 // Homomorphically decodes a real vector of size 2n on a complex vector vReal + i*vImag of size n.
 // If the packing is sparse (n < N/2) then ctReal = Ecd(vReal || vImag) and ctImag = nil.
 // If the packing is dense (n == N/2), then ctReal = Ecd(vReal) and ctImag = Ecd(vImag).
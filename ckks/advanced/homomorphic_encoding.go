@@ -1,6 +1,16 @@
+// Package advanced implements advanced homomorphic operations for the CKKS scheme, which are the building blocks of
+// the bootstrapping but can also be used on their own:
+//
+// - CoeffsToSlots and SlotsToCoeffs, the homomorphic encoding and decoding, i.e. the homomorphic evaluation of the
+// (factorized) special DFT mapping the coefficients of a plaintext to its slots and conversely. They are
+// parameterized by an EncodingMatrixLiteral, which can be created with NewEncodingMatrixLiteral, and the functions
+// ComplexToCoeffs and CoeffsToComplex give the correspondence between the coefficients and the slots.
+//
+// - EvalMod, the homomorphic modular reduction.
 package advanced
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/ldsec/lattigo/v2/ckks"
@@ -87,6 +97,99 @@ func (mParams *EncodingMatrixLiteral) Rotations(logN, logSlots int) (rotations [
 	return
 }
 
+// NewEncodingMatrixLiteral returns the EncodingMatrixLiteral of the homomorphic encoding (CoeffsToSlots) or decoding
+// (SlotsToCoeffs) for the parameters params, which takes as input a ciphertext at the level levelStart and is factorized
+// in depth matrices. Each matrix consumes one level and is encoded at the scale of the modulus of its level, so that
+// the transform returns ciphertexts of the scale of its input at the level levelStart-depth.
+// The Scaling is set to 1/(2*params.Slots()) for CoeffsToSlots and to 1 for SlotsToCoeffs, so that both transforms are
+// the inverse of each other: CoeffsToSlots maps a plaintext of coefficients ComplexToCoeffs(params, values) to the
+// values (see Evaluator.CoeffsToSlots for the layout of the real and imaginary parts), and SlotsToCoeffs maps them back
+// to the coefficients. The BSGSRatio is set to 16 and the fields of the returned struct can be modified.
+// A smaller depth saves levels at the cost of more rotations, and conversely.
+// It returns an error if depth is not in [1, params.LogSlots()] or if the levels are not in [0, params.MaxLevel()].
+func NewEncodingMatrixLiteral(params ckks.Parameters, ltType LinearTransformType, levelStart, depth int) (mParams EncodingMatrixLiteral, err error) {
+
+	if depth < 1 || depth > params.LogSlots() {
+		return EncodingMatrixLiteral{}, fmt.Errorf("cannot NewEncodingMatrixLiteral: depth %d must be in [1, %d]", depth, params.LogSlots())
+	}
+
+	if levelStart > params.MaxLevel() || levelStart-depth < 0 {
+		return EncodingMatrixLiteral{}, fmt.Errorf("cannot NewEncodingMatrixLiteral: levels [%d, %d] must be in [0, %d]", levelStart-depth, levelStart, params.MaxLevel())
+	}
+
+	mParams = EncodingMatrixLiteral{
+		LinearTransformType: ltType,
+		LogN:                params.LogN(),
+		LogSlots:            params.LogSlots(),
+		Scaling:             1.0,
+		LevelStart:          levelStart,
+		BSGSRatio:           16.0,
+	}
+
+	if ltType == CoeffsToSlots {
+		mParams.Scaling = 1.0 / float64(2*params.Slots())
+	}
+
+	// The first matrix is evaluated at the level levelStart and is the last one of the list
+	mParams.ScalingFactor = make([][]float64, depth)
+	for i := range mParams.ScalingFactor {
+		mParams.ScalingFactor[i] = []float64{params.QiFloat64(levelStart - depth + 1 + i)}
+	}
+
+	return
+}
+
+// ComplexToCoeffs returns the coefficients of the plaintexts of the parameters params that CoeffsToSlots (with the
+// Scaling of NewEncodingMatrixLiteral) maps to the vector values, and conversely the coefficients of the plaintexts to
+// which SlotsToCoeffs maps the vector values. The real and imaginary parts of the i-th value are the
+// coefficients of X^(j*gap) and X^(N/2+j*gap), where j is the bit-reverse of i and gap = N/(2*params.Slots()).
+// The method panics if there are more values than params.Slots().
+func ComplexToCoeffs(params ckks.Parameters, values []complex128) (coeffs []float64) {
+
+	slots := params.Slots()
+
+	if len(values) > slots {
+		panic(fmt.Sprintf("cannot ComplexToCoeffs: %d values > %d slots", len(values), slots))
+	}
+
+	v := make([]complex128, slots)
+	copy(v, values)
+
+	ckks.SliceBitReverseInPlaceComplex128(v, slots)
+
+	coeffs = make([]float64, params.N())
+	gap := params.N() / (2 * slots)
+	for i, idx, jdx := 0, 0, params.N()>>1; i < slots; i, idx, jdx = i+1, idx+gap, jdx+gap {
+		coeffs[idx] = real(v[i])
+		coeffs[jdx] = imag(v[i])
+	}
+
+	return
+}
+
+// CoeffsToComplex is the inverse of ComplexToCoeffs: it returns the params.Slots() values read from the coefficients
+// coeffs of a plaintext of the parameters params, e.g. from the output of SlotsToCoeffs.
+func CoeffsToComplex(params ckks.Parameters, coeffs []float64) (values []complex128) {
+
+	slots := params.Slots()
+
+	values = make([]complex128, slots)
+	gap := params.N() / (2 * slots)
+	for i, idx, jdx := 0, 0, params.N()>>1; i < slots; i, idx, jdx = i+1, idx+gap, jdx+gap {
+		values[i] = complex(coeffs[idx], coeffs[jdx])
+	}
+
+	ckks.SliceBitReverseInPlaceComplex128(values, slots)
+
+	return
+}
+
+// Matrices returns the factorized DFT matrices, in the order in which they are evaluated.
+// Each matrix can be evaluated with ckks.Evaluator.LinearTransform followed by a rescaling.
+func (m *EncodingMatrix) Matrices() []ckks.LinearTransform {
+	return m.matrices
+}
+
 // NewHomomorphicEncodingMatrixFromLiteral generates the factorized encoding matrix.
 // scaling : constant by witch the all the matrices will be multuplied by.
 // encoder : ckks.Encoder.
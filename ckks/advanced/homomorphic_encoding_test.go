@@ -2,6 +2,8 @@ package advanced
 
 import (
 	"flag"
+	"math"
+	"math/big"
 	"runtime"
 	"testing"
 
@@ -51,6 +53,9 @@ func TestHomomorphicEncoding(t *testing.T) {
 	for _, testSet := range []func(params ckks.Parameters, t *testing.T){
 		testCoeffsToSlots,
 		testSlotsToCoeffs,
+		testEncodingRoundTrip,
+		testSchemeSwitching,
+		testLUT,
 	} {
 		testSet(params, t)
 		runtime.GC()
@@ -64,6 +69,9 @@ func TestHomomorphicEncoding(t *testing.T) {
 	for _, testSet := range []func(params ckks.Parameters, t *testing.T){
 		testCoeffsToSlots,
 		testSlotsToCoeffs,
+		testEncodingRoundTrip,
+		testSchemeSwitching,
+		testLUT,
 	} {
 		testSet(params, t)
 		runtime.GC()
@@ -336,6 +344,214 @@ func testSlotsToCoeffs(params ckks.Parameters, t *testing.T) {
 	})
 }
 
+func testEncodingRoundTrip(params ckks.Parameters, t *testing.T) {
+
+	packing := "FullPacking"
+	if params.LogSlots() < params.LogN()-1 {
+		packing = "SparsePacking"
+	}
+
+	t.Run("RoundTrip/"+packing, func(t *testing.T) {
+
+		// Same ring with enough levels for a CoeffsToSlots and a SlotsToCoeffs of depth 2
+		params, err := ckks.NewParametersFromLiteral(ckks.ParametersLiteral{
+			LogN:         params.LogN(),
+			LogSlots:     params.LogSlots(),
+			DefaultScale: params.DefaultScale(),
+			Sigma:        rlwe.DefaultSigma,
+			LogQ:         []int{60, 45, 45, 45, 45},
+			LogP:         []int{61, 61},
+		})
+		require.NoError(t, err)
+
+		CoeffsToSlotsParametersLiteral, err := NewEncodingMatrixLiteral(params, CoeffsToSlots, params.MaxLevel(), 2)
+		require.NoError(t, err)
+
+		SlotsToCoeffsParametersLiteral, err := NewEncodingMatrixLiteral(params, SlotsToCoeffs, params.MaxLevel()-2, 2)
+		require.NoError(t, err)
+
+		_, err = NewEncodingMatrixLiteral(params, SlotsToCoeffs, 1, 2)
+		require.Error(t, err)
+
+		_, err = NewEncodingMatrixLiteral(params, SlotsToCoeffs, params.MaxLevel(), 0)
+		require.Error(t, err)
+
+		kgen := ckks.NewKeyGenerator(params)
+		sk := kgen.GenSecretKey()
+		encoder := ckks.NewEncoder(params)
+		encryptor := ckks.NewEncryptor(params, sk)
+		decryptor := ckks.NewDecryptor(params, sk)
+
+		CoeffsToSlotMatrices := NewHomomorphicEncodingMatrixFromLiteral(CoeffsToSlotsParametersLiteral, encoder)
+		SlotsToCoeffsMatrices := NewHomomorphicEncodingMatrixFromLiteral(SlotsToCoeffsParametersLiteral, encoder)
+
+		require.Len(t, CoeffsToSlotMatrices.Matrices(), 2)
+
+		rotations := append(CoeffsToSlotsParametersLiteral.Rotations(params.LogN(), params.LogSlots()),
+			SlotsToCoeffsParametersLiteral.Rotations(params.LogN(), params.LogSlots())...)
+
+		rotKey := kgen.GenRotationKeysForRotations(rotations, true, sk)
+
+		eval := NewEvaluator(params, rlwe.EvaluationKey{Rlk: nil, Rtks: rotKey})
+
+		values := make([]complex128, params.Slots())
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+		}
+
+		plaintext := ckks.NewPlaintext(params, params.MaxLevel(), params.DefaultScale())
+		encoder.EncodeCoeffs(ComplexToCoeffs(params, values), plaintext)
+
+		ctReal, ctImag := eval.CoeffsToSlotsNew(encryptor.EncryptNew(plaintext), CoeffsToSlotMatrices)
+		require.Equal(t, params.MaxLevel()-2, ctReal.Level())
+
+		ctOut := eval.SlotsToCoeffsNew(ctReal, ctImag, SlotsToCoeffsMatrices)
+		require.Equal(t, 0, ctOut.Level())
+
+		valuesTest := CoeffsToComplex(params, encoder.DecodeCoeffsPublic(decryptor.DecryptNew(ctOut), 0))
+
+		verifyTestVectors(params, encoder, decryptor, values, valuesTest, params.LogSlots(), 0, t)
+	})
+}
+
+func testSchemeSwitching(params ckks.Parameters, t *testing.T) {
+
+	packing := "FullPacking"
+	if params.LogSlots() < params.LogN()-1 {
+		packing = "SparsePacking"
+	}
+
+	t.Run("SchemeSwitching/"+packing, func(t *testing.T) {
+
+		// Same ring with enough levels for a SlotsToCoeffs and a CoeffsToSlots of depth 2
+		params, err := ckks.NewParametersFromLiteral(ckks.ParametersLiteral{
+			LogN:         params.LogN(),
+			LogSlots:     params.LogSlots(),
+			DefaultScale: params.DefaultScale(),
+			Sigma:        rlwe.DefaultSigma,
+			LogQ:         []int{60, 45, 45, 45, 45},
+			LogP:         []int{61, 61},
+		})
+		require.NoError(t, err)
+
+		SlotsToCoeffsParametersLiteral, err := NewEncodingMatrixLiteral(params, SlotsToCoeffs, params.MaxLevel(), 2)
+		require.NoError(t, err)
+
+		CoeffsToSlotsParametersLiteral, err := NewEncodingMatrixLiteral(params, CoeffsToSlots, params.MaxLevel()-2, 2)
+		require.NoError(t, err)
+
+		kgen := ckks.NewKeyGenerator(params)
+		sk := kgen.GenSecretKey()
+		encoder := ckks.NewEncoder(params)
+		encryptor := ckks.NewEncryptor(params, sk)
+		decryptor := ckks.NewDecryptor(params, sk)
+
+		SlotsToCoeffsMatrices := NewHomomorphicEncodingMatrixFromLiteral(SlotsToCoeffsParametersLiteral, encoder)
+		CoeffsToSlotMatrices := NewHomomorphicEncodingMatrixFromLiteral(CoeffsToSlotsParametersLiteral, encoder)
+
+		rotations := append(SlotsToCoeffsParametersLiteral.Rotations(params.LogN(), params.LogSlots()),
+			CoeffsToSlotsParametersLiteral.Rotations(params.LogN(), params.LogSlots())...)
+
+		eval := NewEvaluator(params, rlwe.EvaluationKey{Rlk: nil, Rtks: kgen.GenRotationKeysForRotations(rotations, true, sk)})
+
+		packer := rlwe.NewLWEPacker(params.Parameters, kgen.GenRotationKeys(rlwe.GaloisElementsForLWEPacking(params.Parameters), sk))
+
+		values := make([]complex128, params.Slots())
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), 0)
+		}
+
+		ct := encryptor.EncryptNew(encoder.EncodeNew(values, params.MaxLevel(), params.DefaultScale(), params.LogSlots()))
+
+		idx := []int{0, 1, 5, params.Slots() - 1}
+
+		lwe, scale := SlotsToLWE(eval, params, ct, SlotsToCoeffsMatrices, idx)
+		require.Len(t, lwe, len(idx))
+
+		for k, i := range idx {
+			m, _ := new(big.Float).SetInt(rlwe.DecryptLWE(params.Parameters, lwe[k], sk)).Float64()
+			require.InDelta(t, real(values[i]), m/scale, 1e-6)
+		}
+
+		ctOut := LWEToSlots(eval, params, packer, lwe, scale, CoeffsToSlotMatrices, idx)
+		require.Equal(t, 0, ctOut.Level())
+
+		want := make([]complex128, params.Slots())
+		for _, i := range idx {
+			want[i] = values[i]
+		}
+
+		verifyTestVectors(params, encoder, decryptor, want, ctOut, params.LogSlots(), 0, t)
+	})
+}
+
+func testLUT(params ckks.Parameters, t *testing.T) {
+
+	packing := "FullPacking"
+	if params.LogSlots() < params.LogN()-1 {
+		packing = "SparsePacking"
+	}
+
+	t.Run("LUT/"+packing, func(t *testing.T) {
+
+		// A blind rotation costs 2N key-switchings per slot, hence the smaller ring with the same packing
+		logN := 7
+		params, err := ckks.NewParametersFromLiteral(ckks.ParametersLiteral{
+			LogN:         logN,
+			LogSlots:     params.LogSlots() - (params.LogN() - logN),
+			DefaultScale: params.DefaultScale(),
+			Sigma:        rlwe.DefaultSigma,
+			LogQ:         []int{60, 45, 45, 45, 45},
+			LogP:         []int{61, 61},
+		})
+		require.NoError(t, err)
+
+		SlotsToCoeffsParametersLiteral, err := NewEncodingMatrixLiteral(params, SlotsToCoeffs, params.MaxLevel(), 2)
+		require.NoError(t, err)
+
+		// The look-up table outputs fresh LWE ciphertexts at the maximum level
+		CoeffsToSlotsParametersLiteral, err := NewEncodingMatrixLiteral(params, CoeffsToSlots, params.MaxLevel(), 2)
+		require.NoError(t, err)
+
+		kgen := ckks.NewKeyGenerator(params)
+		sk := kgen.GenSecretKey()
+		encoder := ckks.NewEncoder(params)
+		encryptor := ckks.NewEncryptor(params, sk)
+		decryptor := ckks.NewDecryptor(params, sk)
+
+		SlotsToCoeffsMatrices := NewHomomorphicEncodingMatrixFromLiteral(SlotsToCoeffsParametersLiteral, encoder)
+		CoeffsToSlotMatrices := NewHomomorphicEncodingMatrixFromLiteral(CoeffsToSlotsParametersLiteral, encoder)
+
+		rotations := append(SlotsToCoeffsParametersLiteral.Rotations(params.LogN(), params.LogSlots()),
+			CoeffsToSlotsParametersLiteral.Rotations(params.LogN(), params.LogSlots())...)
+
+		eval := NewLUTEvaluator(params,
+			NewEvaluator(params, rlwe.EvaluationKey{Rlk: nil, Rtks: kgen.GenRotationKeysForRotations(rotations, true, sk)}),
+			rlwe.NewLUTEvaluator(params.Parameters, kgen.GenBlindRotationKey(sk)),
+			rlwe.NewLWEPacker(params.Parameters, kgen.GenRotationKeys(rlwe.GaloisElementsForLWEPacking(params.Parameters), sk)),
+			SlotsToCoeffsMatrices, CoeffsToSlotMatrices)
+
+		// Values close to integers, which are rounded by the look-up table
+		values := make([]complex128, params.Slots())
+		want := make([]complex128, params.Slots())
+		for i := range values {
+			values[i] = complex(float64(i%5-2)+utils.RandFloat64(-0.05, 0.05), utils.RandFloat64(-1, 1))
+			want[i] = complex(math.Round(real(values[i])), 0)
+		}
+
+		ct := encryptor.EncryptNew(encoder.EncodeNew(values, params.MaxLevel(), params.DefaultScale(), params.LogSlots()))
+
+		ctOut, err := eval.EvaluateLUT(ct, LookUpTable{F: math.Round, Bound: 2.5})
+		require.NoError(t, err)
+		require.Equal(t, params.MaxLevel()-2, ctOut.Level())
+
+		verifyTestVectors(params, encoder, decryptor, want, ctOut, params.LogSlots(), 0, t)
+
+		_, err = eval.EvaluateLUT(ct, LookUpTable{F: math.Round, Bound: 1 << 120})
+		require.Error(t, err)
+	})
+}
+
 func verifyTestVectors(params ckks.Parameters, encoder ckks.Encoder, decryptor ckks.Decryptor, valuesWant []complex128, element interface{}, logSlots int, bound float64, t *testing.T) {
 
 	precStats := ckks.GetPrecisionStats(params, encoder, decryptor, valuesWant, element, logSlots, bound)
@@ -0,0 +1,67 @@
+package advanced
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// LookUpTable is a look-up table of the real function F on the interval (-Bound, Bound). F is arbitrary, e.g. a step
+// function or a quantization such as math.Round, that polynomials approximate poorly. It is evaluated up to the
+// resolution of the blind rotation, that is about 2 * Bound / N, plus the noise of the modulus switching.
+type LookUpTable struct {
+	F     func(x float64) float64
+	Bound float64
+}
+
+// LUTEvaluator evaluates look-up tables on the slots of CKKS ciphertexts with a programmable bootstrapping: the slots
+// are switched to LWE ciphertexts with SlotsToLWE, each LWE ciphertext is bootstrapped by the blind rotation of a
+// rlwe.LUTEvaluator, and the results are switched back to the slots with LWEToSlots. Each slot costs a blind rotation,
+// i.e. 2N key-switchings, hence the LUTEvaluator suits small rings or few slots.
+type LUTEvaluator struct {
+	Evaluator
+	params      ckks.Parameters
+	lut         *rlwe.LUTEvaluator
+	packer      *rlwe.LWEPacker
+	stcMatrices EncodingMatrix
+	ctsMatrices EncodingMatrix
+}
+
+// NewLUTEvaluator creates a new LUTEvaluator from the Evaluator eval, which must have the rotation keys of the
+// matrices stcMatrices and ctsMatrices, the rlwe.LUTEvaluator lut and the rlwe.LWEPacker packer. The blind rotation
+// key of lut and the rotation keys of packer must be generated from the secret key of the ciphertexts.
+func NewLUTEvaluator(params ckks.Parameters, eval Evaluator, lut *rlwe.LUTEvaluator, packer *rlwe.LWEPacker, stcMatrices, ctsMatrices EncodingMatrix) *LUTEvaluator {
+	return &LUTEvaluator{
+		Evaluator:   eval,
+		params:      params,
+		lut:         lut,
+		packer:      packer,
+		stcMatrices: stcMatrices,
+		ctsMatrices: ctsMatrices,
+	}
+}
+
+// EvaluateLUT evaluates the look-up table table on the real parts of the slots of ct, which must be in
+// (-table.Bound, table.Bound), and returns the result in the real parts of the slots of a new ciphertext, of imaginary
+// parts zero. The ciphertext is first brought to the coefficients at ct.Level() - depth(stcMatrices), and the result
+// is then brought back to the slots from the level ctsMatrices.LevelStart, hence the latter can be larger than the
+// level of ct, as for a bootstrapping. It returns an error if table.Bound times the scale of ct is too large for the
+// modulus after SlotsToCoeffs.
+func (eval *LUTEvaluator) EvaluateLUT(ct *ckks.Ciphertext, table LookUpTable) (ctOut *ckks.Ciphertext, err error) {
+
+	idx := make([]int, eval.params.Slots())
+	for i := range idx {
+		idx[i] = i
+	}
+
+	lwe, scale := SlotsToLWE(eval.Evaluator, eval.params, ct, eval.stcMatrices, idx)
+
+	lut := rlwe.LUT{F: table.F, Bound: table.Bound, ScaleIn: scale, ScaleOut: scale}
+
+	if lwe, err = eval.lut.EvaluateLUT(lwe, lut, eval.ctsMatrices.LevelStart); err != nil {
+		return nil, fmt.Errorf("cannot EvaluateLUT: %w", err)
+	}
+
+	return LWEToSlots(eval.Evaluator, eval.params, eval.packer, lwe, scale, eval.ctsMatrices, idx), nil
+}
@@ -0,0 +1,93 @@
+package advanced
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// SlotsToLWE switches the real parts of the slots idx of the ciphertext ct to LWE ciphertexts, on which non-polynomial
+// functions (e.g. comparisons or look-up tables) can be evaluated with an FHEW/TFHE-style bootstrapping, after a switch
+// to its modulus and key. The slots are first mapped to the coefficients with SlotsToCoeffs, with the matrices
+// stcMatrices, and each coefficient is extracted as an LWE ciphertext encrypted under the coefficients of the secret key.
+// It returns the LWE ciphertexts and the scale of their messages, which are the real parts of the slots times scale.
+// The Evaluator must have the rotation keys of stcMatrices. The method panics if an index is not in [0, params.Slots()).
+func SlotsToLWE(eval Evaluator, params ckks.Parameters, ct *ckks.Ciphertext, stcMatrices EncodingMatrix, idx []int) (lwe []*rlwe.LWECiphertext, scale float64) {
+
+	scale = ct.Scale
+
+	// If the packing is sparse, the 2n slots are [v, v], which SlotsToCoeffs maps to (1+i) * v,
+	// hence ct is multiplied by (1-i) and the coefficients are 2 * v
+	if params.LogSlots() < params.LogN()-1 {
+		ct = eval.SubNew(ct, eval.MultByiNew(ct))
+		scale *= 2
+	}
+
+	ctCoeffs := eval.SlotsToCoeffsNew(ct, nil, stcMatrices)
+
+	coeffs, err := slotsToCoeffsIndex(params, idx)
+	if err != nil {
+		panic(fmt.Errorf("cannot SlotsToLWE: %w", err))
+	}
+
+	return rlwe.ExtractLWE(params.Parameters, ctCoeffs.Ciphertext, coeffs), scale
+}
+
+// LWEToSlots switches the LWE ciphertexts lwe, whose messages are scaled by scale, back to the real parts of the slots
+// idx of a new ciphertext, whose other slots are zero. The LWE ciphertexts are packed in the coefficients with the
+// LWEPacker packer and the coefficients are mapped to the slots with CoeffsToSlots, with the matrices ctsMatrices.
+// The LWE ciphertexts must be encrypted under the coefficients of the secret key of the rotation keys of the packer and
+// must be at a level greater than or equal to ctsMatrices.LevelStart. The Evaluator must have the rotation keys of
+// ctsMatrices. The method panics if the numbers of LWE ciphertexts and of indexes differ or if an index is not in
+// [0, params.Slots()).
+func LWEToSlots(eval Evaluator, params ckks.Parameters, packer *rlwe.LWEPacker, lwe []*rlwe.LWECiphertext, scale float64, ctsMatrices EncodingMatrix, idx []int) (ct *ckks.Ciphertext) {
+
+	if len(lwe) != len(idx) {
+		panic(fmt.Sprintf("cannot LWEToSlots: %d LWE ciphertexts but %d indexes", len(lwe), len(idx)))
+	}
+
+	coeffs, err := slotsToCoeffsIndex(params, idx)
+	if err != nil {
+		panic(fmt.Errorf("cannot LWEToSlots: %w", err))
+	}
+
+	// The coefficient j * gap is the j-th packed coefficient, with gap = N/(2 * slots)
+	gap := params.N() / (2 * params.Slots())
+	packed := make([]*rlwe.LWECiphertext, 2*params.Slots())
+	for k := range lwe {
+		packed[coeffs[k]/gap] = lwe[k]
+	}
+
+	ctPacked := &ckks.Ciphertext{Ciphertext: packer.Pack(packed), Scale: scale}
+
+	ct, _ = eval.CoeffsToSlotsNew(ctPacked, ctsMatrices)
+
+	// If the packing is sparse, the 2n slots are [vReal, vImag] with vImag = 0 and are replicated to [vReal, vReal]
+	if params.LogSlots() < params.LogN()-1 {
+		eval.Add(ct, eval.RotateNew(ct, params.Slots()), ct)
+	}
+
+	return
+}
+
+// slotsToCoeffsIndex returns the indexes of the coefficients storing the real parts of the slots idx, i.e. the
+// coefficients bitReverse(i) * N/(2 * slots) for i in idx.
+func slotsToCoeffsIndex(params ckks.Parameters, idx []int) (coeffs []int, err error) {
+
+	slots := params.Slots()
+	gap := params.N() / (2 * slots)
+
+	coeffs = make([]int, len(idx))
+	for k, i := range idx {
+
+		if i < 0 || i >= slots {
+			return nil, fmt.Errorf("slot index %d is not in [0, %d)", i, slots)
+		}
+
+		coeffs[k] = int(utils.BitReverse64(uint64(i), uint64(params.LogSlots()))) * gap
+	}
+
+	return
+}
@@ -0,0 +1,154 @@
+package ckks
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// BFVConverter converts ciphertexts between the BFV and the CKKS schemes at compatible parameters, i.e. with the same
+// ring degree and the same moduli Q, and under the same secret key. Both conversions are a change of the scaling of
+// the message and do not require any key.
+//
+// The conversions operate on the coefficients of the plaintext polynomials: the coefficient i of the message of R_t of
+// the BFV ciphertext, in its centered representation, is the coefficient i of the message of the CKKS ciphertext.
+// These messages are encoded with bfv.Encoder.ScaleUp on a bfv.PlaintextRingT whose coefficients are set directly, and
+// with Encoder.EncodeCoeffs for CKKS. A homomorphic CoeffsToSlots or SlotsToCoeffs transform moves the values between
+// the coefficients and the slots of a CKKS ciphertext.
+//
+// - BFV to CKKS divides the ciphertext by the moduli above the target level. The phase Q/t * m + e mod Q becomes
+// Q_level/t * m + e' mod Q_level, a CKKS encryption of m with the scale Q_level/t. Since this scale is the one of the
+// modulus divided by t, the values must be small compared to t to leave room for the CKKS operations: if |m| < B,
+// the ciphertext has log2(t/B) bits of headroom. Hence t should be chosen as large as the CKKS computation requires,
+// or the target level low enough.
+//
+// - CKKS to BFV multiplies the ciphertext by c = round(Q_level/(t * Scale)) and by Q/Q_level, which is exact modulo Q.
+// The phase c * Q/Q_level * (Scale * m + e) mod Q is then Q/t * m + e' mod Q, a BFV encryption of m mod t. The BFV
+// decryption rounds the message, hence the values of the CKKS ciphertext must be integers up to an error smaller
+// than 1/4 (i.e. |e| < Scale/4) and smaller than Q_level/(2 * t * Scale) in absolute value.
+type BFVConverter struct {
+	params    Parameters
+	paramsBFV bfv.Parameters
+
+	tmp  *ring.Poly
+	pool *ring.Poly
+}
+
+// NewBFVConverter creates a new BFVConverter between the CKKS parameters params and the BFV parameters paramsBFV.
+// It returns an error if the parameters are not compatible, i.e. if they do not have the same ring degree and moduli Q,
+// or if params are not of the standard ring type.
+func NewBFVConverter(params Parameters, paramsBFV bfv.Parameters) (*BFVConverter, error) {
+
+	if params.RingType() != ring.Standard {
+		return nil, fmt.Errorf("cannot NewBFVConverter: the CKKS parameters must be of the standard ring type")
+	}
+
+	if params.N() != paramsBFV.N() {
+		return nil, fmt.Errorf("cannot NewBFVConverter: ring degrees %d and %d do not match", params.N(), paramsBFV.N())
+	}
+
+	if len(params.Q()) != len(paramsBFV.Q()) {
+		return nil, fmt.Errorf("cannot NewBFVConverter: the moduli Q do not match")
+	}
+
+	for i, qi := range params.Q() {
+		if qi != paramsBFV.Q()[i] {
+			return nil, fmt.Errorf("cannot NewBFVConverter: the moduli Q do not match")
+		}
+	}
+
+	return &BFVConverter{
+		params:    params,
+		paramsBFV: paramsBFV,
+		tmp:       params.RingQ().NewPoly(),
+		pool:      params.RingQ().NewPoly(),
+	}, nil
+}
+
+// ScaleAtLevel returns the scale Q_level/t of the CKKS ciphertexts returned by BFVToCKKS at the given level.
+func (conv *BFVConverter) ScaleAtLevel(level int) float64 {
+	scale, _ := new(big.Float).Quo(new(big.Float).SetInt(conv.params.QLvl(level)), new(big.Float).SetUint64(conv.paramsBFV.T())).Float64()
+	return scale
+}
+
+// BFVToCKKS converts the BFV ciphertext ctIn to a CKKS ciphertext at the level of ctOut and returns the result in ctOut.
+// The scale of ctOut is set to conv.ScaleAtLevel(ctOut.Level()).
+// The method panics if ctIn and ctOut are not of the same degree.
+func (conv *BFVConverter) BFVToCKKS(ctIn *bfv.Ciphertext, ctOut *Ciphertext) {
+
+	if ctIn.Degree() != ctOut.Degree() {
+		panic("cannot BFVToCKKS: input and output ciphertexts must be of the same degree")
+	}
+
+	ringQ := conv.params.RingQ()
+	maxLevel := conv.params.MaxLevel()
+	level := ctOut.Level()
+
+	for i := range ctIn.Value {
+		ring.CopyValuesLvl(maxLevel, ctIn.Value[i], conv.tmp)
+		ringQ.DivRoundByLastModulusManyLvl(maxLevel, maxLevel-level, conv.tmp, conv.pool, conv.tmp)
+		ringQ.NTTLvl(level, conv.tmp, ctOut.Value[i])
+	}
+
+	ctOut.Scale = conv.ScaleAtLevel(level)
+}
+
+// BFVToCKKSNew converts the BFV ciphertext ctIn to a CKKS ciphertext at the given level and returns the result in a
+// new Ciphertext.
+func (conv *BFVConverter) BFVToCKKSNew(ctIn *bfv.Ciphertext, level int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(conv.params, ctIn.Degree(), level, 0)
+	conv.BFVToCKKS(ctIn, ctOut)
+	return
+}
+
+// CKKSToBFV converts the CKKS ciphertext ctIn to a BFV ciphertext and returns the result in ctOut.
+// It returns an error if the scale of ctIn is larger than Q_level/t, as the message cannot be scaled to Q/t.
+// The method panics if ctIn and ctOut are not of the same degree.
+func (conv *BFVConverter) CKKSToBFV(ctIn *Ciphertext, ctOut *bfv.Ciphertext) (err error) {
+
+	if ctIn.Degree() != ctOut.Degree() {
+		panic("cannot CKKSToBFV: input and output ciphertexts must be of the same degree")
+	}
+
+	ringQ := conv.params.RingQ()
+	level := ctIn.Level()
+
+	// c = round(Q_level/(t * Scale))
+	Qlvl := conv.params.QLvl(level)
+	c := new(big.Float).SetInt(Qlvl)
+	c.Quo(c, new(big.Float).SetUint64(conv.paramsBFV.T()))
+	c.Quo(c, new(big.Float).SetFloat64(ctIn.Scale))
+	c.Add(c, new(big.Float).SetFloat64(0.5))
+
+	cInt, _ := c.Int(nil)
+	if cInt.Sign() == 0 {
+		return fmt.Errorf("cannot CKKSToBFV: the scale %f is larger than Q_level/t", ctIn.Scale)
+	}
+
+	// Q/Q_level lifts the ciphertext modulo Q, as Q/Q_level * (x + k * Q_level) = Q/Q_level * x mod Q.
+	cInt.Mul(cInt, new(big.Int).Quo(ringQ.ModulusBigint, Qlvl))
+
+	for i := range ctIn.Value {
+		ringQ.InvNTTLvl(level, ctIn.Value[i], conv.tmp)
+		ringQ.MulScalarBigintLvl(level, conv.tmp, cInt, ctOut.Value[i])
+		for j := level + 1; j < len(ctOut.Value[i].Coeffs); j++ {
+			for k := range ctOut.Value[i].Coeffs[j] {
+				ctOut.Value[i].Coeffs[j][k] = 0
+			}
+		}
+	}
+
+	return
+}
+
+// CKKSToBFVNew converts the CKKS ciphertext ctIn to a BFV ciphertext and returns the result in a new bfv.Ciphertext.
+// It returns an error if the scale of ctIn is larger than Q_level/t.
+func (conv *BFVConverter) CKKSToBFVNew(ctIn *Ciphertext) (ctOut *bfv.Ciphertext, err error) {
+	ctOut = bfv.NewCiphertext(conv.paramsBFV, ctIn.Degree())
+	if err = conv.CKKSToBFV(ctIn, ctOut); err != nil {
+		return nil, err
+	}
+	return
+}
@@ -1,10 +1,12 @@
 package bootstrapping
 
 import (
+	"context"
 	"math"
 
 	"github.com/ldsec/lattigo/v2/ckks"
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
 )
 
 // Bootstrapp re-encrypt a ciphertext at lvl Q0 to a ciphertext at MaxLevel-k where k is the depth of the bootstrapping circuit.
@@ -12,6 +14,23 @@ import (
 // If the input ciphertext is at level one or more, the input scale does not need to be an exact power of two as one level
 // can be used to do a scale matching.
 func (btp *Bootstrapper) Bootstrapp(ctIn *ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+	return btp.BootstrappContext(context.Background(), ctIn)
+}
+
+// BootstrappContext bootstraps the ciphertext like Bootstrapp, and starts a span named "bootstrapping.Bootstrapp" with
+// the rlwe.Tracer carried by ctx, if any, whose children are the spans of the steps of the bootstrapping: "ModUp",
+// "SubSum", "CoeffsToSlots", "EvalMod" and "SlotsToCoeffs".
+func (btp *Bootstrapper) BootstrappContext(ctx context.Context, ctIn *ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+
+	ctx, span := rlwe.StartSpan(ctx, "bootstrapping.Bootstrapp")
+	span.SetAttribute("level", ctIn.Level())
+	defer span.End(nil)
+
+	// step starts the span of a step of the bootstrapping, which is ended by calling the returned function
+	step := func(name string) func() {
+		_, span := rlwe.StartSpan(ctx, name)
+		return func() { span.End(nil) }
+	}
 
 	ctOut = ctIn.CopyNew()
 
@@ -42,7 +61,9 @@ func (btp *Bootstrapper) Bootstrapp(ctIn *ckks.Ciphertext) (ctOut *ckks.Cipherte
 	}
 
 	// Step 1 : Extend the basis from q to Q
+	end := step("ModUp")
 	ctOut = btp.modUpFromQ0(ctOut)
+	end()
 
 	// Brings the ciphertext scale to EvalMod-ScalingFactor/(Q0/scale) if Q0 < EvalMod-ScalingFactor.
 	// Does it after modUp to avoid plaintext overflow as the scaling used during EvalMod can be larger than Q0.
@@ -52,15 +73,20 @@ func (btp *Bootstrapper) Bootstrapp(ctIn *ckks.Ciphertext) (ctOut *ckks.Cipherte
 	}
 
 	//SubSum X -> (N/dslots) * Y^dslots
+	end = step("SubSum")
 	btp.Trace(ctOut, btp.params.LogSlots(), btp.params.LogN()-1, ctOut)
+	end()
 
 	// Step 2 : CoeffsToSlots (Homomorphic encoding)
+	end = step("CoeffsToSlots")
 	ctReal, ctImag := btp.CoeffsToSlotsNew(ctOut, btp.ctsMatrices)
+	end()
 
 	// Step 3 : EvalMod (Homomorphic modular reduction)
 	// ctReal = Ecd(real)
 	// ctImag = Ecd(imag)
 	// If n < N/2 then ctReal = Ecd(real|imag)
+	end = step("EvalMod")
 	ctReal = btp.EvalModNew(ctReal, btp.evalModPoly)
 	ctReal.Scale = btp.params.DefaultScale()
 
@@ -68,9 +94,79 @@ func (btp *Bootstrapper) Bootstrapp(ctIn *ckks.Ciphertext) (ctOut *ckks.Cipherte
 		ctImag = btp.EvalModNew(ctImag, btp.evalModPoly)
 		ctImag.Scale = btp.params.DefaultScale()
 	}
+	end()
 
 	// Step 4 : SlotsToCoeffs (Homomorphic decoding)
+	end = step("SlotsToCoeffs")
 	ctOut = btp.SlotsToCoeffsNew(ctReal, ctImag, btp.stcMatrices)
+	end()
+
+	return
+}
+
+// BootstrappingReport reports the number of bootstrapping circuits evaluated by BootstrappWithPrecision, the estimated
+// precision of its output and the number of levels consumed by the bootstrapping.
+type BootstrappingReport struct {
+	Iterations     int     // Number of bootstrapping circuits evaluated
+	LogPrecision   float64 // Estimated log2 of the inverse of the error introduced by the bootstrapping
+	LevelsConsumed int     // Number of levels consumed, i.e. MaxLevel minus the level of the output
+}
+
+// BootstrappWithPrecision re-encrypts a ciphertext like Bootstrapp, but iterates the bootstrapping circuit, following
+// Bae et al., "Meta-BTS: Bootstrapping Precision Beyond the Limit" (https://eprint.iacr.org/2022/1167), until the
+// estimated precision of the output reaches targetLogPrecision bits.
+//
+// baseLogPrecision is the precision in bits of a single bootstrapping, which depends on the parameters and can be
+// measured with ckks.GetPrecisionStats. Each iteration bootstraps the error ctIn - ctOut, scaled up by 2^k with k the
+// current precision, and adds it back to ctOut divided by 2^k, which increases the precision by about baseLogPrecision
+// bits. The precision is bounded by the scale of ctIn, which must be the default scale of the parameters.
+// If more than one circuit is evaluated, the output has one level less than the output of Bootstrapp.
+// The method returns the output and the BootstrappingReport; the target precision is not reached if it exceeds
+// the bound.
+func (btp *Bootstrapper) BootstrappWithPrecision(ctIn *ckks.Ciphertext, baseLogPrecision, targetLogPrecision float64) (ctOut *ckks.Ciphertext, report BootstrappingReport) {
+
+	if ctIn.Scale != btp.params.DefaultScale() {
+		panic("cannot BootstrappWithPrecision: ciphertext scale must be the default scale")
+	}
+
+	ctOut = btp.Bootstrapp(ctIn)
+
+	report.Iterations = 1
+	report.LogPrecision = baseLogPrecision
+
+	logScale := math.Floor(math.Log2(ctIn.Scale))
+
+	// The error is scaled up by 2^k with k < logScale, so that the scale of the error stays larger than 1
+	for report.LogPrecision < targetLogPrecision {
+
+		k := math.Min(math.Floor(report.LogPrecision)-1, logScale-1)
+
+		if k < 1 || math.Min(baseLogPrecision+k, logScale) <= report.LogPrecision {
+			break
+		}
+
+		// ctIn - ctOut at level 0 stores the error of the bootstrapping, which is scaled up by 2^k
+		// by decreasing the scale of the ciphertext, so that its message is within the range of EvalMod
+		ctErr := ctIn.CopyNew()
+		btp.DropLevel(ctErr, ctErr.Level())
+		btp.Sub(ctErr, ctOut, ctErr)
+		ctErr.Scale /= math.Exp2(k)
+
+		ctErr = btp.Bootstrapp(ctErr)
+
+		// Divides the bootstrapped error by 2^k, which consumes a level
+		btp.MultByConst(ctErr, math.Exp2(-k), ctErr)
+		if err := btp.Rescale(ctErr, btp.params.DefaultScale(), ctErr); err != nil {
+			panic(err)
+		}
+
+		btp.Add(ctOut, ctErr, ctOut)
+
+		report.Iterations++
+		report.LogPrecision = math.Min(baseLogPrecision+k, logScale)
+	}
+
+	report.LevelsConsumed = btp.params.MaxLevel() - ctOut.Level()
 
 	return
 }
@@ -110,8 +110,40 @@ func (p *Parameters) RotationsForBootstrapping(LogN, LogSlots int) (rotations []
 	return
 }
 
+// RotationKeysCount returns the number of rotation keys needed for the bootstrapping of ciphertexts of 2^LogSlots slots
+// in a ring of degree 2^LogN, including the key for the conjugation.
+// In the dense packing (LogSlots = LogN-1), CoeffsToSlots and SlotsToCoeffs act on 2^(LogN-1) slots instead of
+// 2^(LogSlots+1), hence need more keys than in the sparse packing, but no key for the SubSum.
+func (p *Parameters) RotationKeysCount(LogN, LogSlots int) int {
+
+	rotIndex := make(map[int]bool)
+	for _, rot := range p.RotationsForBootstrapping(LogN, LogSlots) {
+		rotIndex[rot] = true
+	}
+
+	return len(rotIndex) + 1
+}
+
+// RotationKeysSize returns the size in bytes of the rotation keys needed for the bootstrapping with the parameters
+// params, i.e. the size of the marshalled rlwe.RotationKeySet without metadata.
+func (p *Parameters) RotationKeysSize(params ckks.Parameters) int {
+	return p.RotationKeysCount(params.LogN(), params.LogSlots()) * params.Beta() * 2 * params.QPCount() * params.N() * 8
+}
+
+// WithBSGSRatio returns a copy of the Parameters whose CoeffsToSlots and SlotsToCoeffs use the ratio ratio between the
+// inner and outer loops of the baby-step giant-step evaluation of their matrices.
+// This ratio trades the size of the bootstrapping key for the evaluation time: larger ratios use more rotation keys,
+// for hoisted rotations which are cheaper than the rotations of the outer loop, whereas ratios around 1 minimize the
+// number of keys. This trade-off matters especially in the dense packing, whose matrices have the most diagonals.
+func (p Parameters) WithBSGSRatio(ratio float64) Parameters {
+	p.CoeffsToSlotsParameters.BSGSRatio = ratio
+	p.SlotsToCoeffsParameters.BSGSRatio = ratio
+	return p
+}
+
 // DefaultCKKSParameters are default parameters for the bootstrapping.
 // To be used in conjonction with DefaultParameters.
+// They use the dense packing (LogSlots = LogN-1), and the sparse packings are obtained by decreasing LogSlots.
 var DefaultCKKSParameters = []ckks.ParametersLiteral{
 	{
 		LogN:         16,
@@ -1,6 +1,8 @@
 package bootstrapping
 
 import (
+	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"runtime"
@@ -11,6 +13,7 @@ import (
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
 )
 
 var flagLongTest = flag.Bool("long", false, "run the long test suite (all parameters + secure bootstrapping). Overrides -short and requires -timeout=0.")
@@ -40,6 +43,92 @@ func TestBootstrapParametersMarshalling(t *testing.T) {
 	assert.Equal(t, bootstrapParams, *bootstrapParamsNew)
 }
 
+func TestBootstrapRotationKeys(t *testing.T) {
+
+	ckksParams := ckks.PN12QP109
+
+	for _, logSlots := range []int{ckksParams.LogN - 1, ckksParams.LogN - 3} {
+
+		ckksParams.LogSlots = logSlots
+
+		params, err := ckks.NewParametersFromLiteral(ckksParams)
+		if err != nil {
+			panic(err)
+		}
+
+		t.Run(ParamsToString(params, "RotationKeys/"), func(t *testing.T) {
+
+			btpParams := DefaultParameters[0]
+
+			kgen := ckks.NewKeyGenerator(params)
+			sk := kgen.GenSecretKey()
+			rotkeys := kgen.GenRotationKeysForRotations(btpParams.RotationsForBootstrapping(params.LogN(), params.LogSlots()), true, sk)
+
+			assert.Equal(t, len(rotkeys.Keys), btpParams.RotationKeysCount(params.LogN(), params.LogSlots()))
+			assert.Equal(t, rotkeys.GetDataLen(false), btpParams.RotationKeysSize(params))
+
+			// Ratios around 1 minimize the number of keys
+			btpParamsMinKeys := btpParams.WithBSGSRatio(1)
+			btpParamsMaxKeys := btpParams.WithBSGSRatio(16)
+			assert.Less(t, btpParamsMinKeys.RotationKeysCount(params.LogN(), params.LogSlots()), btpParamsMaxKeys.RotationKeysCount(params.LogN(), params.LogSlots()))
+			assert.Equal(t, btpParams.CoeffsToSlotsParameters.BSGSRatio, DefaultParameters[0].CoeffsToSlotsParameters.BSGSRatio)
+		})
+	}
+}
+
+func TestBootstrapEvaluationKeys(t *testing.T) {
+
+	ckksParams := ckks.PN12QP109
+	ckksParams.LogSlots = ckksParams.LogN - 3
+
+	params, err := ckks.NewParametersFromLiteral(ckksParams)
+	if err != nil {
+		panic(err)
+	}
+
+	btpParams := DefaultParameters[0]
+
+	sk := ckks.NewKeyGenerator(params).GenSecretKey()
+
+	tracer := new(spanCounter)
+	btpKeys := GenEvaluationKeysContext(rlwe.ContextWithTracer(context.Background(), tracer), params, btpParams, sk, 4)
+
+	t.Run(ParamsToString(params, "EvaluationKeys/Gen/"), func(t *testing.T) {
+		assert.NotNil(t, btpKeys.Rlk)
+		assert.Equal(t, btpParams.RotationKeysCount(params.LogN(), params.LogSlots()), len(btpKeys.Rtks.Keys))
+		assert.Nil(t, (&bootstrapperBase{Parameters: btpParams, params: params}).CheckKeys(btpKeys.EvaluationKey()))
+	})
+
+	t.Run(ParamsToString(params, "EvaluationKeys/Tracing/"), func(t *testing.T) {
+		assert.Equal(t, 1, tracer.spans["bootstrapping.GenEvaluationKeys"])
+		assert.Equal(t, 1, tracer.spans["rlwe.KeyGenerator/GenRelinearizationKey"])
+		assert.Equal(t, len(btpKeys.Rtks.Keys), tracer.spans["rlwe.KeyGenerator/GenSwitchingKeyForGalois"])
+	})
+
+	t.Run(ParamsToString(params, "EvaluationKeys/Marshalling/"), func(t *testing.T) {
+
+		buf := new(bytes.Buffer)
+		n, err := btpKeys.WriteTo(buf)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(buf.Len()), n)
+
+		data, err := btpKeys.MarshalBinary()
+		assert.Nil(t, err)
+		assert.Equal(t, buf.Bytes(), data)
+
+		btpKeysNew := new(EvaluationKeys)
+		n, err = btpKeysNew.ReadFrom(buf)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(len(data)), n)
+		assert.True(t, btpKeys.Rlk.Equals(btpKeysNew.Rlk))
+		assert.True(t, btpKeys.Rtks.Equals(btpKeysNew.Rtks))
+
+		// Flips a bit in the last key
+		data[len(data)-blake2b.Size256-1] ^= 1
+		assert.NotNil(t, new(EvaluationKeys).UnmarshalBinary(data))
+	})
+}
+
 func TestBootstrap(t *testing.T) {
 
 	if runtime.GOARCH == "wasm" {
@@ -68,6 +157,7 @@ func TestBootstrap(t *testing.T) {
 
 	for _, testSet := range []func(params ckks.Parameters, btpParams Parameters, t *testing.T){
 		testbootstrap,
+		testbootstrapWithPrecision,
 	} {
 		testSet(params, bootstrapParams, t)
 		runtime.GC()
@@ -82,6 +172,7 @@ func TestBootstrap(t *testing.T) {
 
 	for _, testSet := range []func(params ckks.Parameters, btpParams Parameters, t *testing.T){
 		testbootstrap,
+		testbootstrapWithPrecision,
 	} {
 		testSet(params, bootstrapParams, t)
 		runtime.GC()
@@ -133,26 +224,109 @@ func testbootstrap(params ckks.Parameters, btpParams Parameters, t *testing.T) {
 			}
 		}
 
+		// The first ciphertext is bootstrapped with a traced context
+		tracer := new(spanCounter)
+		ctx := rlwe.ContextWithTracer(context.Background(), tracer)
+
 		var wg sync.WaitGroup
 		wg.Add(2)
 		for i := range ciphertexts {
 			go func(index int) {
-				ciphertexts[index] = bootstrappers[index].Bootstrapp(ciphertexts[index])
+				if index == 0 {
+					ciphertexts[index] = bootstrappers[index].BootstrappContext(ctx, ciphertexts[index])
+				} else {
+					ciphertexts[index] = bootstrappers[index].Bootstrapp(ciphertexts[index])
+				}
 				//btp.SetScale(ciphertexts[index], params.Scale())
 				wg.Done()
 			}(i)
 		}
 		wg.Wait()
 
+		for _, name := range []string{"bootstrapping.Bootstrapp", "ModUp", "SubSum", "CoeffsToSlots", "EvalMod", "SlotsToCoeffs"} {
+			assert.Equal(t, 1, tracer.spans[name], name)
+		}
+
 		for i := range ciphertexts {
 			verifyTestVectors(params, encoder, decryptor, values, ciphertexts[i], params.LogSlots(), 0, t)
 		}
 	})
 }
 
+func testbootstrapWithPrecision(params ckks.Parameters, btpParams Parameters, t *testing.T) {
+
+	t.Run(ParamsToString(params, "Bootstrapping/WithPrecision/"), func(t *testing.T) {
+
+		kgen := ckks.NewKeyGenerator(params)
+		sk := kgen.GenSecretKeySparse(btpParams.H)
+		rlk := kgen.GenRelinearizationKey(sk, 2)
+		encoder := ckks.NewEncoder(params)
+		encryptor := ckks.NewEncryptor(params, sk)
+		decryptor := ckks.NewDecryptor(params, sk)
+
+		rotations := btpParams.RotationsForBootstrapping(params.LogN(), params.LogSlots())
+		rotkeys := kgen.GenRotationKeysForRotations(rotations, true, sk)
+
+		btp, err := NewBootstrapper(params, btpParams, rlwe.EvaluationKey{Rlk: rlk, Rtks: rotkeys})
+		if err != nil {
+			panic(err)
+		}
+
+		values := make([]complex128, 1<<params.LogSlots())
+		for i := range values {
+			values[i] = utils.RandComplex128(-1, 1)
+		}
+
+		ciphertext := encryptor.EncryptNew(encoder.EncodeNew(values, 0, params.DefaultScale(), params.LogSlots()))
+
+		ctOnce, report := btp.BootstrappWithPrecision(ciphertext, 20, 20)
+		assert.Equal(t, 1, report.Iterations)
+		assert.Equal(t, params.MaxLevel()-ctOnce.Level(), report.LevelsConsumed)
+
+		ctIterated, report := btp.BootstrappWithPrecision(ciphertext, 20, 30)
+		assert.Equal(t, 2, report.Iterations)
+		assert.GreaterOrEqual(t, report.LogPrecision, 30.0)
+		assert.Equal(t, ctOnce.Level()-1, ctIterated.Level())
+		assert.Equal(t, params.MaxLevel()-ctIterated.Level(), report.LevelsConsumed)
+
+		precOnce := ckks.GetPrecisionStats(params, encoder, decryptor, values, ctOnce, params.LogSlots(), 0)
+		precIterated := ckks.GetPrecisionStats(params, encoder, decryptor, values, ctIterated, params.LogSlots(), 0)
+
+		if *printPrecisionStats {
+			t.Log(precOnce.String())
+			t.Log(precIterated.String())
+		}
+
+		// The precision is also bounded by the encryption noise of the input
+		assert.Greater(t, precIterated.MinPrecision.L2, precOnce.MinPrecision.L2+5)
+	})
+}
+
 func verifyTestVectors(params ckks.Parameters, encoder ckks.Encoder, decryptor ckks.Decryptor, valuesWant []complex128, element interface{}, logSlots int, bound float64, t *testing.T) {
 	precStats := ckks.GetPrecisionStats(params, encoder, decryptor, valuesWant, element, logSlots, bound)
 	if *printPrecisionStats {
 		t.Log(precStats.String())
 	}
 }
+
+// spanCounter is an rlwe.Tracer counting the spans it starts by name.
+type spanCounter struct {
+	sync.Mutex
+	spans map[string]int
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+
+func (noopSpan) End(err error) {}
+
+func (tracer *spanCounter) Start(ctx context.Context, name string) (context.Context, rlwe.Span) {
+	tracer.Lock()
+	defer tracer.Unlock()
+	if tracer.spans == nil {
+		tracer.spans = map[string]int{}
+	}
+	tracer.spans[name]++
+	return ctx, noopSpan{}
+}
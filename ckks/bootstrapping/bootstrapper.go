@@ -11,6 +11,9 @@ import (
 
 // Bootstrapper is a struct to stores a memory pool the plaintext matrices
 // the polynomial approximation and the keys for the bootstrapping.
+// It supports both the dense packing (params.LogSlots() = params.LogN()-1), in which CoeffsToSlots returns the real and
+// imaginary parts of the coefficients in two ciphertexts that are each reduced by EvalMod, and the sparse packings, in
+// which they are stored in a single ciphertext after the SubSum.
 type Bootstrapper struct {
 	advanced.Evaluator
 	*bootstrapperBase
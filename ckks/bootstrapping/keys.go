@@ -0,0 +1,279 @@
+package bootstrapping
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"golang.org/x/crypto/blake2b"
+)
+
+// evaluationKeysMagic identifies the serialization of an EvaluationKeys.
+var evaluationKeysMagic = [4]byte{'B', 'T', 'K', 1}
+
+// EvaluationKeys is the bundle of the evaluation keys needed by the Bootstrapper: the relinearization key and
+// the rotation keys for the CoeffsToSlots, SubSum and SlotsToCoeffs steps, including the conjugation key.
+//
+// The keys are (de)serialized as a stream of independent records, one per switching key, each followed by
+// its blake2b-256 checksum. Hence a bundle can be written to or read from a file without an intermediate
+// buffer of the size of the whole bundle, and the corruption of any key is detected when it is read.
+type EvaluationKeys struct {
+	Rlk  *rlwe.RelinearizationKey
+	Rtks *rlwe.RotationKeySet
+}
+
+// GenEvaluationKeys generates the EvaluationKeys for the bootstrapping of ciphertexts encrypted under sk with the
+// parameters params and btpParams. The switching keys are generated concurrently on at most goroutines goroutines,
+// each with its own KeyGenerator. A value of goroutines smaller than 2 generates the keys sequentially.
+func GenEvaluationKeys(params ckks.Parameters, btpParams Parameters, sk *rlwe.SecretKey, goroutines int) (btpKeys *EvaluationKeys) {
+	return GenEvaluationKeysContext(context.Background(), params, btpParams, sk, goroutines)
+}
+
+// GenEvaluationKeysContext generates the EvaluationKeys like GenEvaluationKeys, and starts a span named
+// "bootstrapping.GenEvaluationKeys" with the rlwe.Tracer carried by ctx, if any, whose children are the spans of the
+// generation of each key (see rlwe.NewTracingKeyGenerator).
+func GenEvaluationKeysContext(ctx context.Context, params ckks.Parameters, btpParams Parameters, sk *rlwe.SecretKey, goroutines int) (btpKeys *EvaluationKeys) {
+
+	rotations := btpParams.RotationsForBootstrapping(params.LogN(), params.LogSlots())
+
+	galEls := make([]uint64, len(rotations), len(rotations)+1)
+	for i, k := range rotations {
+		galEls[i] = params.GaloisElementForColumnRotationBy(k)
+	}
+	galEls = append(galEls, params.GaloisElementForRowRotation())
+
+	// Removes the duplicates, e.g. when a rotation is used by several steps
+	galEls = dedupGaloisElements(galEls)
+
+	if goroutines < 1 {
+		goroutines = 1
+	}
+
+	// The relinearization key is the last task
+	n := len(galEls) + 1
+	if goroutines > n {
+		goroutines = n
+	}
+
+	ctx, span := rlwe.StartSpan(ctx, "bootstrapping.GenEvaluationKeys")
+	span.SetAttribute("keys", n)
+	defer span.End(nil)
+
+	swks := make([]*rlwe.SwitchingKey, len(galEls))
+	var rlk *rlwe.RelinearizationKey
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for w := 0; w < goroutines; w++ {
+		go func(w int) {
+			defer wg.Done()
+			kgen := rlwe.NewTracingKeyGenerator(ctx, ckks.NewKeyGenerator(params))
+			for i := w; i < n; i += goroutines {
+				if i == len(galEls) {
+					rlk = kgen.GenRelinearizationKey(sk, 2)
+				} else {
+					swks[i] = kgen.GenSwitchingKeyForGalois(galEls[i], sk)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	rtks := &rlwe.RotationKeySet{Keys: make(map[uint64]*rlwe.SwitchingKey, len(galEls))}
+	for i, galEl := range galEls {
+		rtks.Keys[galEl] = swks[i]
+	}
+
+	return &EvaluationKeys{Rlk: rlk, Rtks: rtks}
+}
+
+func dedupGaloisElements(galEls []uint64) (unique []uint64) {
+	seen := make(map[uint64]bool, len(galEls))
+	for _, galEl := range galEls {
+		if !seen[galEl] {
+			seen[galEl] = true
+			unique = append(unique, galEl)
+		}
+	}
+	return
+}
+
+// EvaluationKey returns the rlwe.EvaluationKey to give to NewBootstrapper.
+func (btpKeys *EvaluationKeys) EvaluationKey() rlwe.EvaluationKey {
+	return rlwe.EvaluationKey{Rlk: btpKeys.Rlk, Rtks: btpKeys.Rtks}
+}
+
+// WriteTo writes the EvaluationKeys on w. The rotation keys are written by increasing Galois element, so
+// that the serialization of a given bundle is deterministic. It implements io.WriterTo.
+func (btpKeys *EvaluationKeys) WriteTo(w io.Writer) (n int64, err error) {
+
+	if btpKeys.Rlk == nil {
+		return 0, fmt.Errorf("relinearization key is nil")
+	}
+
+	if btpKeys.Rtks == nil {
+		return 0, fmt.Errorf("rotation key is nil")
+	}
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	galEls := make([]uint64, 0, len(btpKeys.Rtks.Keys))
+	for galEl := range btpKeys.Rtks.Keys {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	var header [8]byte
+	copy(header[:4], evaluationKeysMagic[:])
+	binary.BigEndian.PutUint32(header[4:], uint32(len(galEls)))
+	if _, err = cw.Write(header[:]); err != nil {
+		return cw.n, err
+	}
+
+	if err = writeRecord(cw, 0, btpKeys.Rlk); err != nil {
+		return cw.n, err
+	}
+
+	for _, galEl := range galEls {
+		if err = writeRecord(cw, galEl, btpKeys.Rtks.Keys[galEl]); err != nil {
+			return cw.n, err
+		}
+	}
+
+	return cw.n, bw.Flush()
+}
+
+// ReadFrom reads on r EvaluationKeys previously written with WriteTo, verifying the checksum of each key.
+// The keys are read one at a time, hence the memory used in addition to the keys is the size of a single
+// switching key. It implements io.ReaderFrom.
+func (btpKeys *EvaluationKeys) ReadFrom(r io.Reader) (n int64, err error) {
+
+	cr := &countingReader{r: r}
+
+	var header [8]byte
+	if _, err = io.ReadFull(cr, header[:]); err != nil {
+		return cr.n, err
+	}
+
+	if !bytes.Equal(header[:4], evaluationKeysMagic[:]) {
+		return cr.n, fmt.Errorf("invalid bootstrapping keys header")
+	}
+
+	nbRotKeys := int(binary.BigEndian.Uint32(header[4:]))
+
+	rlk := new(rlwe.RelinearizationKey)
+	if _, err = readRecord(cr, rlk); err != nil {
+		return cr.n, fmt.Errorf("relinearization key: %w", err)
+	}
+
+	rtks := &rlwe.RotationKeySet{Keys: make(map[uint64]*rlwe.SwitchingKey, nbRotKeys)}
+	for i := 0; i < nbRotKeys; i++ {
+		swk := new(rlwe.SwitchingKey)
+		var galEl uint64
+		if galEl, err = readRecord(cr, swk); err != nil {
+			return cr.n, fmt.Errorf("rotation key %d: %w", i, err)
+		}
+		rtks.Keys[galEl] = swk
+	}
+
+	btpKeys.Rlk = rlk
+	btpKeys.Rtks = rtks
+
+	return cr.n, nil
+}
+
+// MarshalBinary encodes the EvaluationKeys in a byte slice, in the format of WriteTo.
+func (btpKeys *EvaluationKeys) MarshalBinary() (data []byte, err error) {
+	buf := new(bytes.Buffer)
+	if _, err = btpKeys.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a slice of bytes generated by MarshalBinary or WriteTo, for example a memory-mapped
+// key file, on the target EvaluationKeys.
+func (btpKeys *EvaluationKeys) UnmarshalBinary(data []byte) (err error) {
+	_, err = btpKeys.ReadFrom(bytes.NewReader(data))
+	return
+}
+
+// writeRecord writes the record [galEl | len(data) | data | blake2b-256(data)] of the marshalled key.
+func writeRecord(w io.Writer, galEl uint64, key interface{ MarshalBinary() ([]byte, error) }) (err error) {
+
+	var data []byte
+	if data, err = key.MarshalBinary(); err != nil {
+		return
+	}
+
+	var header [16]byte
+	binary.BigEndian.PutUint64(header[:8], galEl)
+	binary.BigEndian.PutUint64(header[8:], uint64(len(data)))
+
+	sum := blake2b.Sum256(data)
+
+	for _, b := range [][]byte{header[:], data, sum[:]} {
+		if _, err = w.Write(b); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// readRecord reads a record written by writeRecord, verifies its checksum and decodes it on key.
+func readRecord(r io.Reader, key interface{ UnmarshalBinary([]byte) error }) (galEl uint64, err error) {
+
+	var header [16]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+
+	galEl = binary.BigEndian.Uint64(header[:8])
+
+	data := make([]byte, binary.BigEndian.Uint64(header[8:]))
+	if _, err = io.ReadFull(r, data); err != nil {
+		return
+	}
+
+	var sum [blake2b.Size256]byte
+	if _, err = io.ReadFull(r, sum[:]); err != nil {
+		return
+	}
+
+	if blake2b.Sum256(data) != sum {
+		return galEl, fmt.Errorf("checksum mismatch")
+	}
+
+	return galEl, key.UnmarshalBinary(data)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = cw.w.Write(p)
+	cw.n += int64(n)
+	return
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (n int, err error) {
+	n, err = cr.r.Read(p)
+	cr.n += int64(n)
+	return
+}
@@ -0,0 +1,106 @@
+package ckks
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils/cbor"
+)
+
+// CBORValue returns the value encoding the parameters in the CBOR serialization: an object of type "ckks.Parameters"
+// with the keys of rlwe.Parameters, the number of slots "logSlots" and the default scale "defaultScale".
+func (p Parameters) CBORValue() (interface{}, error) {
+	v, err := p.Parameters.CBORValue()
+	if err != nil {
+		return nil, err
+	}
+	m := cbor.Object(v.(map[string]interface{})).Retype("ckks.Parameters", rlwe.CBORVersion)
+	m["logSlots"] = uint64(p.logSlots)
+	m["defaultScale"] = p.defaultScale
+	return m, nil
+}
+
+// SetCBORValue sets the parameters to the decoded CBOR value v generated by CBORValue.
+func (p *Parameters) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "ckks.Parameters", rlwe.CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	var logSlots int
+	var defaultScale float64
+	if logSlots, err = o.Int("logSlots"); err == nil {
+		defaultScale, err = o.Float("defaultScale")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	var rlweParams rlwe.Parameters
+	if err = rlweParams.SetCBORValue(o.Retype("rlwe.Parameters", rlwe.CBORVersion)); err != nil {
+		return err
+	}
+
+	if *p, err = NewParameters(rlweParams, logSlots, defaultScale); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the parameters in the CBOR serialization.
+func (p Parameters) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(p)
+}
+
+// UnmarshalCBOR decodes the parameters encoded by MarshalCBOR.
+func (p *Parameters) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return p.SetCBORValue(v)
+}
+
+// CBORValue returns the value encoding the ciphertext in the CBOR serialization: an object of type "ckks.Ciphertext"
+// with the keys of rlwe.Ciphertext and the scale "scale".
+func (ct *Ciphertext) CBORValue() (interface{}, error) {
+	v, err := ct.Ciphertext.CBORValue()
+	if err != nil {
+		return nil, err
+	}
+	m := cbor.Object(v.(map[string]interface{})).Retype("ckks.Ciphertext", rlwe.CBORVersion)
+	m["scale"] = ct.Scale
+	return m, nil
+}
+
+// SetCBORValue sets the ciphertext to the decoded CBOR value v generated by CBORValue.
+func (ct *Ciphertext) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "ckks.Ciphertext", rlwe.CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	if ct.Scale, err = o.Float("scale"); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	ct.Ciphertext = new(rlwe.Ciphertext)
+	return ct.Ciphertext.SetCBORValue(o.Retype("rlwe.Ciphertext", rlwe.CBORVersion))
+}
+
+// MarshalCBOR encodes the ciphertext in the CBOR serialization.
+func (ct *Ciphertext) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(ct)
+}
+
+// UnmarshalCBOR decodes the ciphertext encoded by MarshalCBOR.
+func (ct *Ciphertext) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return ct.SetCBORValue(v)
+}
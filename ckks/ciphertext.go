@@ -1,6 +1,7 @@
 package ckks
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"math"
@@ -76,24 +77,44 @@ func (ct *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 	return dataLen
 }
 
-// MarshalBinary encodes a Ciphertext on a byte slice. The total size
-// in byte is 4 + 8* N * numberModuliQ * (degree + 1).
+// MarshalBinary encodes a Ciphertext on a byte slice: the rlwe.FormatHeader, the scale and the encoding of the
+// rlwe.Ciphertext without header. The total size in byte is
+// rlwe.FormatHeaderLen + 8 + 1 + (4 + 8 * N * numberModuliQ) * (degree + 1).
 func (ct *Ciphertext) MarshalBinary() (data []byte, err error) {
 
-	dataScale := make([]byte, 8)
-
-	binary.LittleEndian.PutUint64(dataScale, math.Float64bits(ct.Scale))
-
 	var dataCt []byte
 	if dataCt, err = ct.Ciphertext.MarshalBinary(); err != nil {
 		return nil, err
 	}
 
-	return append(dataScale, dataCt...), nil
+	data = make([]byte, len(dataCt)+8)
+	copy(data, dataCt[:rlwe.FormatHeaderLen])
+	binary.LittleEndian.PutUint64(data[rlwe.FormatHeaderLen:], math.Float64bits(ct.Scale))
+	copy(data[rlwe.FormatHeaderLen+8:], dataCt[rlwe.FormatHeaderLen:])
+
+	return data, nil
 }
 
-// UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext.
+// UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext. It accepts the current and the
+// previous versions of the format. A Ciphertext of the version 0, which has no header, starts with its scale, whose
+// first bytes can match rlwe.FormatMagic: such data is decoded as the version 0 if it cannot be decoded with a header.
 func (ct *Ciphertext) UnmarshalBinary(data []byte) (err error) {
+
+	var body []byte
+	if _, body, err = rlwe.DecodeFormat(data); err == nil {
+		err = ct.unmarshalBody(body)
+	}
+
+	if err != nil && bytes.HasPrefix(data, rlwe.FormatMagic[:]) && ct.unmarshalBody(data) == nil {
+		return nil
+	}
+
+	return err
+}
+
+// unmarshalBody decodes the scale and the rlwe.Ciphertext without header of a marshaled Ciphertext.
+func (ct *Ciphertext) unmarshalBody(data []byte) (err error) {
+
 	if len(data) < 10 { // cf. ct.GetDataLen()
 		return errors.New("too small bytearray")
 	}
@@ -0,0 +1,255 @@
+package ckks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// CiphertextCompressor reduces the size of ciphertexts before their serialization by switching
+// their modulus down to the smallest level that can still hold their message at their current scale.
+// Since the ciphertexts are in the RNS and NTT domains, the switching only discards the last moduli
+// and does not add any error.
+type CiphertextCompressor struct {
+	params Parameters
+}
+
+// NewCiphertextCompressor creates a new CiphertextCompressor.
+func NewCiphertextCompressor(params Parameters) *CiphertextCompressor {
+	return &CiphertextCompressor{params: params}
+}
+
+// MinLevel returns the smallest level that can store a message whose slots have a magnitude of at
+// most 2^logBound, encoded at the given scale. One bit is reserved for the sign and one bit of margin
+// is kept for the noise.
+func (cc *CiphertextCompressor) MinLevel(scale, logBound float64) int {
+
+	logMessage := math.Log2(scale) + logBound + 2
+
+	logQl := 0.0
+	for level := 0; level < cc.params.MaxLevel(); level++ {
+		logQl += math.Log2(cc.params.QiFloat64(level))
+		if logQl > logMessage {
+			return level
+		}
+	}
+
+	return cc.params.MaxLevel()
+}
+
+// ModSwitch switches the modulus of ct0 from Q_{ct0.Level()} to Q_{level} and returns the result in ctOut.
+// The level of ctOut is set to level and its scale to the scale of ct0.
+// The method panics if level is negative or larger than the level of ct0.
+func (cc *CiphertextCompressor) ModSwitch(ct0 *Ciphertext, level int, ctOut *Ciphertext) {
+
+	if level < 0 || level > ct0.Level() {
+		panic(fmt.Errorf("cannot ModSwitch: invalid target level %d for a ciphertext at level %d", level, ct0.Level()))
+	}
+
+	if ctOut.Degree() < ct0.Degree() {
+		panic("cannot ModSwitch: receiver operand degree is too small")
+	}
+
+	if ctOut.Level() < level {
+		panic("cannot ModSwitch: receiver operand level is too small")
+	}
+
+	for i := range ct0.Value {
+		if ct0.Value[i] != ctOut.Value[i] {
+			ring.CopyValuesLvl(level, ct0.Value[i], ctOut.Value[i])
+		}
+		ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:level+1]
+	}
+
+	ctOut.Scale = ct0.Scale
+}
+
+// ModSwitchNew switches the modulus of ct0 from Q_{ct0.Level()} to Q_{level} and returns the result in a new ciphertext.
+func (cc *CiphertextCompressor) ModSwitchNew(ct0 *Ciphertext, level int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(cc.params, ct0.Degree(), level, ct0.Scale)
+	cc.ModSwitch(ct0, level, ctOut)
+	return
+}
+
+// MarshalBinary encodes ct in a byte slice at the smallest level given by MinLevel(ct.Scale, logBound),
+// where 2^logBound is an upper bound on the magnitude of the slots of ct. The input ciphertext is not modified.
+func (cc *CiphertextCompressor) MarshalBinary(ct *Ciphertext, logBound float64) (data []byte, err error) {
+
+	level := cc.MinLevel(ct.Scale, logBound)
+
+	if level >= ct.Level() {
+		return ct.MarshalBinary()
+	}
+
+	// Marshals a view of ct restricted to the first level+1 moduli, which shares its backing arrays
+	value := make([]*ring.Poly, len(ct.Value))
+	for i := range ct.Value {
+		value[i] = &ring.Poly{Coeffs: ct.Value[i].Coeffs[:level+1], IsNTT: ct.Value[i].IsNTT, IsMForm: ct.Value[i].IsMForm}
+	}
+
+	return (&Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: value}, Scale: ct.Scale}).MarshalBinary()
+}
+
+// lossyHeaderLen is the size of the header of MarshalBinaryLossy: the scale, the degree, the level and the number
+// of bits per coefficient.
+const lossyHeaderLen = 8 + 1 + 1 + 2
+
+// LossyBits returns the number of bits per coefficient used by MarshalBinaryLossy for a ciphertext at the given
+// level and scale, such that the estimated error added to its slots is at most 2^-logPrecision. The coefficients
+// c mod Q_level are serialized as round(c * 2^bits / Q_level), which adds to the phase c0 + c1*s the rounding
+// error of a unit Q_level/2^bits. The result is at most the bit-size of Q_level, for which the serialization
+// is lossless.
+func (cc *CiphertextCompressor) LossyBits(level int, scale, logPrecision float64) int {
+
+	var logQ float64
+	for i := 0; i <= level; i++ {
+		logQ += math.Log2(cc.params.QiFloat64(i))
+	}
+
+	roundingError := NewTrackingEvaluator(cc.params, nil).roundingError()
+
+	bits := int(math.Ceil(logQ + math.Log2(roundingError) - math.Log2(scale) + logPrecision))
+
+	// In the conjugate invariant ring, the rounding error of the slots is up to four times larger
+	if cc.params.RingType() == ring.ConjugateInvariant {
+		bits += 2
+	}
+
+	return utils.MaxInt(1, utils.MinInt(bits, cc.params.QLvl(level).BitLen()))
+}
+
+// MarshalBinaryLossy encodes ct in a byte slice at the smallest level given by MinLevel(ct.Scale, logBound), where
+// 2^logBound is an upper bound on the magnitude of the slots of ct, and with the LossyBits(level, ct.Scale,
+// logPrecision) most significant bits of each coefficient, i.e. at the cost of an error on the slots of at most
+// about 2^-logPrecision. The input ciphertext is not modified. The method returns an error if ct is not of degree 1.
+func (cc *CiphertextCompressor) MarshalBinaryLossy(ct *Ciphertext, logBound, logPrecision float64) (data []byte, err error) {
+
+	if ct.Degree() != 1 {
+		return nil, fmt.Errorf("cannot MarshalBinaryLossy: ciphertext must be of degree 1")
+	}
+
+	level := utils.MinInt(cc.MinLevel(ct.Scale, logBound), ct.Level())
+	bits := cc.LossyBits(level, ct.Scale, logPrecision)
+
+	ringQ := cc.params.RingQ()
+	N := ringQ.N
+
+	data = make([]byte, lossyHeaderLen+((ct.Degree()+1)*N*bits+7)/8)
+	binary.LittleEndian.PutUint64(data, math.Float64bits(ct.Scale))
+	data[8] = uint8(ct.Degree())
+	data[9] = uint8(level)
+	binary.LittleEndian.PutUint16(data[10:], uint16(bits))
+
+	Q := cc.params.QLvl(level)
+	halfQ := new(big.Int).Rsh(Q, 1)
+
+	pol := ringQ.NewPolyLvl(level)
+	coeffs := make([]*big.Int, N)
+
+	w := &bitWriter{buf: data[lossyHeaderLen:]}
+
+	for i := range ct.Value {
+
+		ringQ.InvNTTLvl(level, ct.Value[i], pol)
+		ringQ.PolyToBigintLvl(level, pol, 1, coeffs)
+
+		// round(c * 2^bits / Q) mod 2^bits
+		for _, c := range coeffs {
+			c.Lsh(c, uint(bits))
+			c.Add(c, halfQ)
+			c.Quo(c, Q)
+			w.write(c, bits)
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinaryLossy decodes a slice of bytes generated by MarshalBinaryLossy on the target Ciphertext. It returns
+// an error, before allocating the ciphertext, if the header is not one written by MarshalBinaryLossy for the
+// parameters, i.e. of degree 1, of a level of the parameters and of at most the bit-size of Q_level bits per
+// coefficient, or if the size of the data does not match the header.
+func (cc *CiphertextCompressor) UnmarshalBinaryLossy(data []byte, ct *Ciphertext) (err error) {
+
+	if len(data) < lossyHeaderLen {
+		return fmt.Errorf("cannot UnmarshalBinaryLossy: too small byte array")
+	}
+
+	scale := math.Float64frombits(binary.LittleEndian.Uint64(data))
+	degree, level := int(data[8]), int(data[9])
+	bits := int(binary.LittleEndian.Uint16(data[10:]))
+
+	ringQ := cc.params.RingQ()
+	N := ringQ.N
+
+	if degree != 1 || level > cc.params.MaxLevel() {
+		return fmt.Errorf("cannot UnmarshalBinaryLossy: invalid degree %d or level %d", degree, level)
+	}
+
+	if bits == 0 || bits > cc.params.QLvl(level).BitLen() || len(data) != lossyHeaderLen+((degree+1)*N*bits+7)/8 {
+		return fmt.Errorf("cannot UnmarshalBinaryLossy: invalid byte array")
+	}
+
+	Q := cc.params.QLvl(level)
+	half := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+
+	ct.Ciphertext = rlwe.NewCiphertextNTT(cc.params.Parameters, degree, level)
+	ct.Scale = scale
+
+	coeffs := make([]*big.Int, N)
+	for j := range coeffs {
+		coeffs[j] = new(big.Int)
+	}
+
+	r := &bitReader{buf: data[lossyHeaderLen:]}
+
+	for i := range ct.Value {
+
+		// round(c * Q / 2^bits)
+		for _, c := range coeffs {
+			r.read(c, bits)
+			c.Mul(c, Q)
+			c.Add(c, half)
+			c.Rsh(c, uint(bits))
+		}
+
+		ringQ.SetCoefficientsBigintLvl(level, coeffs, ct.Value[i])
+		ringQ.NTTLvl(level, ct.Value[i], ct.Value[i])
+	}
+
+	return
+}
+
+// bitWriter writes integers of a given number of bits in a byte slice, from the least significant bit.
+type bitWriter struct {
+	buf []byte
+	pos int
+}
+
+func (w *bitWriter) write(x *big.Int, bits int) {
+	for i := 0; i < bits; i++ {
+		if x.Bit(i) == 1 {
+			w.buf[w.pos>>3] |= 1 << (w.pos & 7)
+		}
+		w.pos++
+	}
+}
+
+// bitReader reads integers written by a bitWriter.
+type bitReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *bitReader) read(x *big.Int, bits int) {
+	x.SetUint64(0)
+	for i := 0; i < bits; i++ {
+		x.SetBit(x, i, uint((r.buf[r.pos>>3]>>(r.pos&7))&1))
+		r.pos++
+	}
+}
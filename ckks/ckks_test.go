@@ -1,14 +1,26 @@
 package ckks
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
+	"math/big"
 	"math/cmplx"
+	"math/rand"
 	"runtime"
+	"sort"
 	"testing"
+	"time"
 
+	"github.com/ldsec/lattigo/v2/bfv"
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -20,6 +32,7 @@ var flagLongTest = flag.Bool("long", false, "run the long test suite (all parame
 var flagPostQuantum = flag.Bool("pq", false, "run post quantum test suite (does not run non-PQ parameters).")
 var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short and -long.")
 var printPrecisionStats = flag.Bool("print-precision", false, "print precision stats")
+var flagUpdateGolden = flag.Bool("update", false, "regenerate the golden deterministic encodings of testdata.")
 
 var minPrec float64 = 15.0
 
@@ -96,18 +109,33 @@ func TestCKKS(t *testing.T) {
 			testEvaluatorAddConst,
 			testEvaluatorMultByConst,
 			testEvaluatorMultByConstAndAdd,
+			testEvaluatorLinearCombination,
 			testEvaluatorMul,
 			testEvaluatorMulAndAdd,
+			testEvaluatorInnerProduct,
+			testEvaluatorAutoScale,
+			testEvaluatorAddAligned,
+			testEvaluatorRescalingStrategy,
 			testFunctions,
 			testDecryptPublic,
 			testEvaluatePoly,
 			testChebyshevInterpolator,
+			testMinimax,
+			testFunctionLibrary,
 			testSwitchKeys,
 			testBridge,
+			testBFVConverter,
 			testAutomorphisms,
 			testInnerSum,
 			testReplicate,
 			testLinearTransform,
+			testMatrixMultiplication,
+			testBlockLinearTransform,
+			testPrecisionTracker,
+			testPlanner,
+			testVector,
+			testMasking,
+			testStreamEncoder,
 			testMarshaller,
 		} {
 			testSet(tc, t)
@@ -281,6 +309,136 @@ func testEncoder(tc *testContext, t *testing.T) {
 		require.GreaterOrEqual(t, math.Log2(1/meanprec), minPrec)
 	})
 
+	t.Run(GetTestName(tc.params, "Encoder/Deterministic"), func(t *testing.T) {
+
+		encoder := NewEncoder(tc.params, WithDeterministicEncoding())
+
+		// The roots of unity are the correctly rounded ones, up to the error of the math package
+		ecd := encoder.(*encoderComplex128)
+		for i, root := range ecd.roots {
+			require.InDelta(t, 0, cmplx.Abs(root-tc.encoder.(*encoderComplex128).roots[i]), 1e-15)
+		}
+
+		values, _, _ := newTestVectors(tc, nil, complex(-1, -1), complex(1, 1), t)
+
+		level := tc.params.MaxLevel()
+		scale := tc.params.DefaultScale()
+		logSlots := tc.params.LogSlots()
+
+		plaintext := encoder.EncodeNew(values, level, scale, logSlots)
+		require.True(t, tc.ringQ.EqualLvl(level, plaintext.Value, encoder.ShallowCopy().EncodeNew(values, level, scale, logSlots).Value))
+
+		verifyTestVectors(tc.params, encoder, tc.decryptor, values, plaintext, logSlots, 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Encoder/Encode32"), func(t *testing.T) {
+
+		logSlots := tc.params.LogSlots()
+
+		values64 := make([]complex64, 1<<logSlots)
+		values128 := make([]complex128, 1<<logSlots)
+		real32 := make([]float32, 1<<logSlots)
+		real64 := make([]float64, 1<<logSlots)
+
+		for i := range values64 {
+			values64[i] = complex64(utils.RandComplex128(-1, 1))
+			if tc.params.RingType() == ring.ConjugateInvariant {
+				values64[i] = complex(real(values64[i]), 0)
+			}
+			values128[i] = complex128(values64[i])
+			real32[i] = real(values64[i])
+			real64[i] = float64(real32[i])
+		}
+
+		level := tc.params.MaxLevel()
+		scale := tc.params.DefaultScale()
+
+		// The 32-bit inputs are encoded exactly as their 64-bit conversions
+		require.True(t, tc.ringQ.EqualLvl(level, tc.encoder.EncodeNew(values128, level, scale, logSlots).Value, tc.encoder.EncodeNew(values64, level, scale, logSlots).Value))
+		require.True(t, tc.ringQ.EqualLvl(level, tc.encoder.EncodeNew(real64, level, scale, logSlots).Value, tc.encoder.EncodeNew(real32, level, scale, logSlots).Value))
+
+		// The 32-bit outputs are the rounded 64-bit outputs
+		plaintext := tc.encoder.EncodeNew(values64, level, scale, logSlots)
+
+		have64 := tc.encoder.DecodeComplex64(plaintext, logSlots)
+		have32 := tc.encoder.DecodeFloat32(plaintext, logSlots)
+		have128 := tc.encoder.Decode(plaintext, logSlots)
+
+		for i := range have128 {
+			require.Equal(t, complex64(have128[i]), have64[i])
+			require.Equal(t, float32(real(have128[i])), have32[i])
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values128, plaintext, logSlots, 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Encoder/BigFloat"), func(t *testing.T) {
+
+		if tc.params.RingType() != ring.Standard {
+			t.Skip("EncoderBigComplex only supports the standard ring")
+		}
+
+		if tc.params.MaxLevel() < 1 {
+			t.Skip("not enough levels")
+		}
+
+		prec := 256
+		logSlots := tc.params.LogSlots()
+		level := tc.params.MaxLevel()
+
+		// A scale that is not exactly representable as a float64, at about 16 bits below the modulus,
+		// i.e. with a precision beyond the 53 bits of a float64 if the modulus is large enough
+		scale := new(big.Float).SetPrec(uint(prec)).SetInt(tc.ringQ.ModulusBigint)
+		for i := level + 1; i < tc.params.QCount(); i++ {
+			scale.Quo(scale, ring.NewFloat(float64(tc.params.Q()[i]), prec))
+		}
+		scale.Quo(scale, ring.NewFloat(3*(1<<16), prec))
+
+		scaleF64, _ := scale.Float64()
+
+		values := make([]*big.Float, 1<<logSlots)
+		for i := range values {
+			values[i] = ring.NewFloat(utils.RandFloat64(-1, 1), prec)
+			values[i].Quo(values[i], ring.NewFloat(3, prec))
+		}
+
+		encoder := NewEncoderBigComplex(tc.params, prec)
+
+		plaintext := NewPlaintext(tc.params, level, scaleF64)
+		encoder.EncodeFloat(values, plaintext, logSlots)
+
+		// Encoding and decoding at the float64 scale
+		logPrec := func(have []*big.Float) (minPrec float64) {
+			minPrec = float64(prec)
+			for i := range values {
+				diff, _ := new(big.Float).Sub(have[i], values[i]).Float64()
+				minPrec = math.Min(minPrec, -math.Log2(math.Abs(diff)))
+			}
+			return
+		}
+
+		require.GreaterOrEqual(t, logPrec(encoder.DecodeFloat(plaintext, logSlots)), 40.0)
+
+		// Encoding and decoding at the exact scale
+		encoder.EncodeAtScale(bigFloatToComplex(values), plaintext, logSlots, scale)
+
+		precExact := logPrec(encoder.DecodeFloatAtScale(plaintext, logSlots, scale))
+
+		if *printPrecisionStats {
+			t.Logf("log2(scale): %.2f, precision: %.2f bits", math.Log2(scaleF64), precExact)
+		}
+
+		// The precision is bounded by the rounding of the encoding, i.e. about log2(scale) - logN/2 bits
+		require.GreaterOrEqual(t, precExact, math.Min(math.Log2(scaleF64)-float64(tc.params.LogN())/2-4, 150))
+	})
+}
+
+func bigFloatToComplex(values []*big.Float) (res []*ring.Complex) {
+	res = make([]*ring.Complex, len(values))
+	for i := range values {
+		res[i] = ring.NewComplex(values[i], ring.NewFloat(0, int(values[i].Prec())))
+	}
+	return
 }
 
 func testEvaluatorAdd(tc *testContext, t *testing.T) {
@@ -536,6 +694,51 @@ func testEvaluatorMultByConstAndAdd(tc *testContext, t *testing.T) {
 
 }
 
+func testEvaluatorLinearCombination(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "Evaluator/LinearCombination"), func(t *testing.T) {
+
+		n := 4
+		cts := make([]*Ciphertext, n)
+		consts := make([]interface{}, n)
+		valuesWant := make([]complex128, tc.params.Slots())
+
+		for j := range cts {
+
+			var values []complex128
+			values, _, cts[j] = newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			var constant complex128
+			switch j {
+			case 0:
+				// Integer constant, no scaling required
+				constant = complex(3, 0)
+				consts[j] = int64(3)
+			default:
+				constant = randomConst(tc.params.RingType(), complex(-1, 1), complex(-1, 1))
+				consts[j] = constant
+			}
+
+			for i := range values {
+				valuesWant[i] += constant * values[i]
+			}
+		}
+
+		ctOut := tc.evaluator.LinearCombinationNew(cts, consts)
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, valuesWant, ctOut, tc.params.LogSlots(), 0, t)
+
+		// The receiver of a larger degree is resized
+		ctOut = tc.evaluator.MulNew(cts[0], cts[1])
+		tc.evaluator.LinearCombination(cts, consts, ctOut)
+		require.Equal(t, 1, ctOut.Degree())
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, valuesWant, ctOut, tc.params.LogSlots(), 0, t)
+
+		// The receiver is one of the inputs
+		tc.evaluator.LinearCombination(cts, consts, cts[1])
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, valuesWant, cts[1], tc.params.LogSlots(), 0, t)
+	})
+}
+
 func testEvaluatorMul(tc *testContext, t *testing.T) {
 
 	t.Run(GetTestName(tc.params, "Evaluator/Mul/ct0*pt->ct0"), func(t *testing.T) {
@@ -577,6 +780,96 @@ func testEvaluatorMul(tc *testContext, t *testing.T) {
 		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext2, tc.params.LogSlots(), 0, t)
 	})
 
+	t.Run(GetTestName(tc.params, "Evaluator/Mul/PlaintextCache"), func(t *testing.T) {
+
+		cache := NewPlaintextCache(tc.params)
+
+		values1, plaintext1, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		ptMul := cache.Get(plaintext1)
+		require.True(t, ptMul == cache.Get(plaintext1))
+		require.Equal(t, 1, cache.Len())
+
+		ciphertext3 := tc.evaluator.MulRelinNew(ciphertext2, ptMul)
+		tc.evaluator.MulRelinAndAdd(ciphertext1, cache.Get(plaintext1), ciphertext3)
+
+		for i := range values1 {
+			values1[i] = values1[i]*values1[i] + values2[i]*values1[i]
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext3, tc.params.LogSlots(), 0, t)
+
+		// The plaintext itself is left unchanged
+		values2, plaintext2, _ := newTestVectors(tc, nil, complex(-1, -1), complex(1, 1), t)
+		cache.Get(plaintext2)
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values2, plaintext2, tc.params.LogSlots(), 0, t)
+
+		cache.Delete(plaintext1)
+		require.Equal(t, 1, cache.Len())
+		cache.Reset()
+		require.Equal(t, 0, cache.Len())
+	})
+
+	t.Run(GetTestName(tc.params, "Evaluator/Mul/Tracing"), func(t *testing.T) {
+
+		if tc.params.MaxLevel() < 1 {
+			t.Skip("test requires at least one rescaling")
+		}
+
+		var events []OperationEvent
+		eval := NewTracingEvaluator(tc.evaluator, func(event OperationEvent) {
+			events = append(events, event)
+		})
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values1[i] *= values1[i]
+		}
+
+		ciphertext2 := eval.MulRelinNew(ciphertext1, ciphertext1)
+		require.NoError(t, eval.Rescale(ciphertext2, tc.params.DefaultScale(), ciphertext2))
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext2, tc.params.LogSlots(), 0, t)
+
+		require.Len(t, events, 2)
+
+		require.Equal(t, "MulRelinNew", events[0].Operation)
+		require.Equal(t, []int{ciphertext1.Level(), ciphertext1.Level()}, events[0].InputLevels)
+		require.Equal(t, []float64{ciphertext1.Scale, ciphertext1.Scale}, events[0].InputScales)
+		require.Equal(t, ciphertext1.Scale*ciphertext1.Scale, events[0].OutputScale)
+		require.True(t, events[0].Output == ciphertext2)
+
+		require.Equal(t, "Rescale", events[1].Operation)
+		require.Equal(t, []int{ciphertext1.Level()}, events[1].InputLevels)
+		require.Equal(t, ciphertext1.Level()-1, events[1].OutputLevel)
+		require.Equal(t, ciphertext2.Scale, events[1].OutputScale)
+	})
+
+	t.Run(GetTestName(tc.params, "Evaluator/Mul/Metrics"), func(t *testing.T) {
+
+		if tc.params.MaxLevel() < 1 {
+			t.Skip("test requires at least one rescaling")
+		}
+
+		metrics := new(testMetrics)
+		eval := NewMetricsEvaluator(tc.evaluator, metrics, func(ct *Ciphertext) float64 {
+			return ModulusBudget(tc.params, ct)
+		})
+
+		_, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		fresh := ModulusBudget(tc.params, ciphertext1)
+
+		ciphertext2 := eval.MulRelinNew(ciphertext1, ciphertext1)
+		require.NoError(t, eval.Rescale(ciphertext2, tc.params.DefaultScale(), ciphertext2))
+
+		require.Equal(t, []string{"ckks.Evaluator/MulRelinNew", "ckks.Evaluator/Rescale"}, metrics.operations)
+		require.Len(t, metrics.budgets, 2)
+		require.InDelta(t, fresh-math.Log2(ciphertext1.Scale), metrics.budgets[0], 1e-9)
+		require.Less(t, metrics.budgets[1], fresh)
+	})
+
 	t.Run(GetTestName(tc.params, "Evaluator/Mul/ct0*ct1->ct0"), func(t *testing.T) {
 
 		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
@@ -688,189 +981,482 @@ func testEvaluatorMul(tc *testContext, t *testing.T) {
 	})
 }
 
-func testEvaluatorMulAndAdd(tc *testContext, t *testing.T) {
+func testEvaluatorAutoScale(tc *testContext, t *testing.T) {
 
-	t.Run(GetTestName(tc.params, "Evaluator/MulAndAdd/ct1*pt0->ct0"), func(t *testing.T) {
+	eval := NewAutoScaleEvaluator(tc.params, tc.evaluator)
 
-		values1, plaintext1, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+	t.Run(GetTestName(tc.params, "Evaluator/AutoScale/Mul/Add/DifferentLevels"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 || tc.params.MaxLevel() < 2 {
+			t.Skip("#Pi is empty or not enough levels")
+		}
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
 		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
+		ciphertext3 := eval.MulRelinNew(ciphertext1, ciphertext2)
+		require.Equal(t, ciphertext1.Level()-1, ciphertext3.Level())
+
+		// The fresh ciphertext is aligned on the scale of the rescaled one without consuming a level
+		ciphertext4 := eval.AddNew(ciphertext1, ciphertext3)
+		require.Equal(t, ciphertext3.Level(), ciphertext4.Level())
+
 		for i := range values1 {
 			values1[i] += values1[i] * values2[i]
 		}
 
-		tc.evaluator.MulRelinAndAdd(ciphertext2, plaintext1, ciphertext1)
-
-		require.Equal(t, ciphertext1.Degree(), 1)
-
-		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext4, tc.params.LogSlots(), 0, t)
 	})
 
-	t.Run(GetTestName(tc.params, "Evaluator/MulRelinAndAdd/ct1*ct1->ct0"), func(t *testing.T) {
+	t.Run(GetTestName(tc.params, "Evaluator/AutoScale/Sub/SameLevel"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 || tc.params.MaxLevel() < 2 {
+			t.Skip("#Pi is empty or not enough levels")
+		}
 
 		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
-		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, _ := newTestVectors(tc, nil, complex(-1, -1), complex(1, 1), t)
 
-		for i := range values1 {
-			values1[i] += values2[i] * values2[i]
-		}
+		level := ciphertext1.Level()
 
-		tc.evaluator.MulRelinAndAdd(ciphertext2, ciphertext2, ciphertext1)
+		plaintext2 := tc.encoder.EncodeNew(values2, level, tc.params.DefaultScale()*1.5, tc.params.LogSlots())
 
-		//fmt.Println(ciphertext1.Value[2].Coeffs[0][:4])
+		eval.Sub(ciphertext1, plaintext2, ciphertext1)
+		require.Equal(t, level-1, ciphertext1.Level())
 
-		require.Equal(t, ciphertext1.Degree(), 1)
+		for i := range values1 {
+			values1[i] -= values2[i]
+		}
 
 		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
 	})
 
-	t.Run(GetTestName(tc.params, "Evaluator/MulAndAdd/ct1*ct1->ct0"), func(t *testing.T) {
+	t.Run(GetTestName(tc.params, "Evaluator/AutoScale/Add/IntegerRatio"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 || tc.params.MaxLevel() < 2 {
+			t.Skip("#Pi is empty or not enough levels")
+		}
 
 		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
-		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, _ := newTestVectors(tc, nil, complex(-1, -1), complex(1, 1), t)
+
+		plaintext2 := tc.encoder.EncodeNew(values2, ciphertext1.Level(), tc.params.DefaultScale()*3, tc.params.LogSlots())
+
+		ciphertext3 := eval.AddNew(ciphertext1, plaintext2)
+		require.Equal(t, ciphertext1.Level(), ciphertext3.Level())
 
 		for i := range values1 {
-			values1[i] += values2[i] * values2[i]
+			values1[i] += values2[i]
 		}
 
-		tc.evaluator.MulAndAdd(ciphertext2, ciphertext2, ciphertext1)
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext3, tc.params.LogSlots(), 0, t)
+	})
 
-		require.Equal(t, ciphertext1.Degree(), 2)
+	t.Run(GetTestName(tc.params, "Evaluator/AutoScale/MultByConst/MulRelinAndAdd"), func(t *testing.T) {
 
-		tc.evaluator.Relinearize(ciphertext1, ciphertext1)
+		if tc.params.PCount() == 0 || tc.params.MaxLevel() < 2 {
+			t.Skip("#Pi is empty or not enough levels")
+		}
 
-		require.Equal(t, ciphertext1.Degree(), 1)
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+		ciphertext3 := eval.MultByConstNew(ciphertext1, 0.25)
+		require.True(t, sameScale(ciphertext1.Scale, ciphertext3.Scale) || ciphertext3.Level() < ciphertext1.Level())
+
+		eval.MulRelinAndAdd(ciphertext1, ciphertext2, ciphertext3)
+
+		for i := range values1 {
+			values1[i] = 0.25*values1[i] + values1[i]*values2[i]
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext3, tc.params.LogSlots(), 0, t)
 	})
 }
 
-func testFunctions(tc *testContext, t *testing.T) {
-
-	t.Run(GetTestName(tc.params, "Evaluator/PowerOf2"), func(t *testing.T) {
+func testEvaluatorAddAligned(tc *testContext, t *testing.T) {
 
-		if tc.params.PCount() == 0 {
-			t.Skip("method is unsuported when params.PCount() == 0")
-		}
+	t.Run(GetTestName(tc.params, "Evaluator/AddAligned"), func(t *testing.T) {
 
-		if tc.params.MaxLevel() < 3 {
-			t.Skip("skipping test for params max level < 3")
+		if tc.params.PCount() == 0 || tc.params.MaxLevel() < 2 {
+			t.Skip("#Pi is empty or not enough levels")
 		}
 
-		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		n := 2
+		ciphertext3 := tc.evaluator.MulRelinNew(ciphertext1, ciphertext2)
+		require.NoError(t, tc.evaluator.Rescale(ciphertext3, tc.params.DefaultScale(), ciphertext3))
 
-		valuesWant := make([]complex128, len(values))
-		for i := 0; i < len(valuesWant); i++ {
-			valuesWant[i] = values[i]
-		}
+		// The operand at the highest level is aligned on the other one and the inputs are not modified
+		level1, scale1 := ciphertext1.Level(), ciphertext1.Scale
+		ciphertext4, err := tc.evaluator.AddAlignedNew(ciphertext1, ciphertext3)
+		require.NoError(t, err)
+		require.Equal(t, ciphertext3.Level(), ciphertext4.Level())
+		require.True(t, sameScale(ciphertext3.Scale, ciphertext4.Scale))
+		require.Equal(t, level1, ciphertext1.Level())
+		require.Equal(t, scale1, ciphertext1.Scale)
 
-		for i := 0; i < n; i++ {
-			for j := 0; j < len(valuesWant); j++ {
-				valuesWant[j] *= valuesWant[j]
-			}
+		for i := range values1 {
+			values1[i] += values1[i] * values2[i]
 		}
 
-		tc.evaluator.PowerOf2(ciphertext, n, ciphertext)
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext4, tc.params.LogSlots(), 0, t)
 
-		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, valuesWant, ciphertext, tc.params.LogSlots(), 0, t)
+		// The alignment is impossible at level zero with a non-integer ratio
+		tc.evaluator.DropLevel(ciphertext3, ciphertext3.Level())
+		ciphertext2.Scale *= 1.5
+		tc.evaluator.DropLevel(ciphertext2, ciphertext2.Level())
+		require.Error(t, tc.evaluator.AddAligned(ciphertext2, ciphertext3, ciphertext4))
 	})
+}
 
-	t.Run(GetTestName(tc.params, "Evaluator/Power"), func(t *testing.T) {
+func testEvaluatorRescalingStrategy(tc *testContext, t *testing.T) {
 
-		if tc.params.PCount() == 0 {
-			t.Skip("method is unsuported when params.PCount() == 0")
-		}
+	t.Run(GetTestName(tc.params, "Evaluator/RescalingStrategy/DotProduct"), func(t *testing.T) {
 
-		if tc.params.MaxLevel() < 4 {
-			t.Skip("skipping test for params max level < 4")
+		if tc.params.PCount() == 0 || tc.params.MaxLevel() < 2 {
+			t.Skip("#Pi is empty or not enough levels")
 		}
 
-		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
-
-		n := 3
+		n := 8
 
+		values := make([][]complex128, n)
+		ciphertexts := make([]*Ciphertext, n)
 		for i := range values {
-			values[i] = cmplx.Pow(values[i], complex(float64(n), 0))
+			values[i], _, ciphertexts[i] = newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
 		}
 
-		tc.evaluator.Power(ciphertext, n, ciphertext)
+		// (sum_i x_i * x_{i+1}) * x_0
+		want := make([]complex128, len(values[0]))
+		for j := range want {
+			for i := range values {
+				want[j] += values[i][j] * values[(i+1)%n][j]
+			}
+			want[j] *= values[0][j]
+		}
 
-		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
-	})
+		evaluate := func(eval Evaluator) (ctOut *Ciphertext) {
 
-	t.Run(GetTestName(tc.params, "Evaluator/Inverse"), func(t *testing.T) {
+			cts := ciphertexts
 
-		if tc.params.PCount() == 0 {
-			t.Skip("method is unsuported when params.PCount() == 0")
-		}
+			acc := NewCiphertext(tc.params, 1, cts[0].Level(), cts[0].Scale*cts[1].Scale)
+			for i := range cts {
+				eval.MulRelinAndAdd(cts[i], cts[(i+1)%n], acc)
+			}
+			require.NoError(t, eval.Rescale(acc, tc.params.DefaultScale(), acc))
 
-		if tc.params.MaxLevel() < 7 {
-			t.Skip("skipping test for params max level < 7")
+			ctOut = eval.MulRelinNew(acc, cts[0])
+			require.NoError(t, eval.Rescale(ctOut, tc.params.DefaultScale(), ctOut))
+			return
 		}
 
-		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(0.1, 0), complex(1, 0), t)
+		eager := evaluate(tc.evaluator)
+		require.Equal(t, tc.params.MaxLevel()-2, eager.Level())
 
-		n := 7
+		lazy := evaluate(NewEvaluator(tc.params, rlwe.EvaluationKey{Rlk: tc.rlk}, WithRescalingStrategy(RescaleBeforeMul)))
 
-		for i := range values {
-			values[i] = 1.0 / values[i]
+		// The rescaling of the result is pending
+		require.Equal(t, tc.params.MaxLevel()-1, lazy.Level())
+
+		precEager := GetPrecisionStats(tc.params, tc.encoder, tc.decryptor, want, eager, tc.params.LogSlots(), 0)
+		precLazy := GetPrecisionStats(tc.params, tc.encoder, tc.decryptor, want, lazy, tc.params.LogSlots(), 0)
+		if *printPrecisionStats {
+			t.Logf("RescaleAfterMul: %.2f bits, RescaleBeforeMul: %.2f bits", precEager.MeanPrecision.L2, precLazy.MeanPrecision.L2)
 		}
 
-		ciphertext = tc.evaluator.InverseNew(ciphertext, n)
+		// The products are summed before being rescaled, so the precision cannot be significantly lower
+		require.GreaterOrEqual(t, precLazy.MeanPrecision.L2, precEager.MeanPrecision.L2-0.5)
 
-		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, want, lazy, tc.params.LogSlots(), 0, t)
 	})
-}
-
-func testEvaluatePoly(tc *testContext, t *testing.T) {
-
-	var err error
-
-	t.Run(GetTestName(tc.params, "EvaluatePoly/PolySingle/Exp"), func(t *testing.T) {
 
-		if tc.params.PCount() == 0 {
-			t.Skip("method is unsuported when params.PCount() == 0")
-		}
+	t.Run(GetTestName(tc.params, "Evaluator/RescalingStrategy/Operands"), func(t *testing.T) {
 
-		if tc.params.MaxLevel() < 3 {
-			t.Skip("skipping test for params max level < 3")
+		if tc.params.PCount() == 0 || tc.params.MaxLevel() < 2 {
+			t.Skip("#Pi is empty or not enough levels")
 		}
 
-		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, 0), complex(1, 0), t)
+		eval := NewEvaluator(tc.params, rlwe.EvaluationKey{Rlk: tc.rlk}, WithRescalingStrategy(RescaleBeforeMul))
 
-		coeffs := []complex128{
-			complex(1.0, 0),
-			complex(1.0, 0),
-			complex(1.0/2, 0),
-			complex(1.0/6, 0),
-			complex(1.0/24, 0),
-			complex(1.0/120, 0),
-			complex(1.0/720, 0),
-			complex(1.0/5040, 0),
-		}
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		poly := NewPoly(coeffs)
+		// The rescaling of the square is pending
+		square := eval.MulRelinNew(ciphertext, ciphertext)
+		require.NoError(t, eval.Rescale(square, tc.params.DefaultScale(), square))
+		require.Equal(t, ciphertext.Level(), square.Level())
+		squareCopy := square.CopyNew()
 
 		for i := range values {
-			values[i] = cmplx.Exp(values[i])
+			values[i] *= values[i] * values[i]
 		}
 
-		if ciphertext, err = tc.evaluator.EvaluatePoly(ciphertext, poly, ciphertext.Scale); err != nil {
-			t.Error(err)
+		// The multiplications leave their pending operands unchanged
+		cube := NewCiphertext(tc.params, 1, square.Level(), 0)
+		eval.MulRelin(square, ciphertext, cube)
+		require.Equal(t, squareCopy.Level(), square.Level())
+		require.Equal(t, squareCopy.Scale, square.Scale)
+		for i := range square.Value {
+			require.True(t, tc.ringQ.Equal(squareCopy.Value[i], square.Value[i]))
 		}
 
-		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+		require.NoError(t, eval.Rescale(cube, tc.params.DefaultScale(), cube))
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, cube, tc.params.LogSlots(), 0, t)
+
+		_, err := eval.InnerProductNew([]Operand{square}, []Operand{ciphertext}, tc.params.DefaultScale())
+		require.NoError(t, err)
+		require.Equal(t, squareCopy.Level(), square.Level())
 	})
+}
 
-	t.Run(GetTestName(tc.params, "EvaluatePoly/PolyVector/Exp"), func(t *testing.T) {
+func testEvaluatorMulAndAdd(tc *testContext, t *testing.T) {
 
-		if tc.params.PCount() == 0 {
-			t.Skip("method is unsuported when params.PCount() == 0")
-		}
+	t.Run(GetTestName(tc.params, "Evaluator/MulAndAdd/ct1*pt0->ct0"), func(t *testing.T) {
 
-		if tc.params.MaxLevel() < 3 {
+		values1, plaintext1, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values1[i] += values1[i] * values2[i]
+		}
+
+		tc.evaluator.MulRelinAndAdd(ciphertext2, plaintext1, ciphertext1)
+
+		require.Equal(t, ciphertext1.Degree(), 1)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Evaluator/MulRelinAndAdd/ct1*ct1->ct0"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values1[i] += values2[i] * values2[i]
+		}
+
+		tc.evaluator.MulRelinAndAdd(ciphertext2, ciphertext2, ciphertext1)
+
+		//fmt.Println(ciphertext1.Value[2].Coeffs[0][:4])
+
+		require.Equal(t, ciphertext1.Degree(), 1)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Evaluator/MulRelinAndAdd/ct1*ct2->ct0"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values3, _, ciphertext3 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values1[i] += values2[i] * values3[i]
+		}
+
+		tc.evaluator.MulRelinAndAdd(ciphertext2, ciphertext3, ciphertext1)
+
+		require.Equal(t, ciphertext1.Degree(), 1)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Evaluator/MulAndAdd/ct1*ct1->ct0"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values1[i] += values2[i] * values2[i]
+		}
+
+		tc.evaluator.MulAndAdd(ciphertext2, ciphertext2, ciphertext1)
+
+		require.Equal(t, ciphertext1.Degree(), 2)
+
+		tc.evaluator.Relinearize(ciphertext1, ciphertext1)
+
+		require.Equal(t, ciphertext1.Degree(), 1)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+	})
+}
+
+func testEvaluatorInnerProduct(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "Evaluator/InnerProduct"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 || tc.params.MaxLevel() < 1 {
+			t.Skip("#Pi is empty or not enough levels")
+		}
+
+		// The even terms are products of ciphertexts and the odd terms products with plaintexts, enough to
+		// trigger the intermediate modular reductions for moduli of 60 bits
+		n := 24
+		op0 := make([]Operand, n)
+		op1 := make([]Operand, n)
+		valuesWant := make([]complex128, tc.params.Slots())
+
+		for j := 0; j < n; j++ {
+
+			values0, _, ct := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+			values1, pt, ct1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			op0[j] = ct
+			if j&1 == 0 {
+				op1[j] = ct1
+			} else {
+				op1[j] = pt
+			}
+
+			for i := range values0 {
+				valuesWant[i] += values0[i] * values1[i]
+			}
+		}
+
+		ctOut, err := tc.evaluator.InnerProductNew(op0, op1, tc.params.DefaultScale())
+		require.NoError(t, err)
+		require.Equal(t, 1, ctOut.Degree())
+		require.Equal(t, tc.params.MaxLevel()-1, ctOut.Level())
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, valuesWant, ctOut, tc.params.LogSlots(), 0, t)
+
+		// The receiver is one of the inputs
+		ct0 := op0[0].(*Ciphertext)
+		require.NoError(t, tc.evaluator.InnerProduct(op0, op1, tc.params.DefaultScale(), ct0))
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, valuesWant, ct0, tc.params.LogSlots(), 0, t)
+
+		require.Panics(t, func() { tc.evaluator.InnerProduct(op0, op1[1:], tc.params.DefaultScale(), ct0) })
+	})
+}
+
+func testFunctions(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "Evaluator/PowerOf2"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < 3 {
+			t.Skip("skipping test for params max level < 3")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		n := 2
+
+		valuesWant := make([]complex128, len(values))
+		for i := 0; i < len(valuesWant); i++ {
+			valuesWant[i] = values[i]
+		}
+
+		for i := 0; i < n; i++ {
+			for j := 0; j < len(valuesWant); j++ {
+				valuesWant[j] *= valuesWant[j]
+			}
+		}
+
+		tc.evaluator.PowerOf2(ciphertext, n, ciphertext)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, valuesWant, ciphertext, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Evaluator/Power"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < 4 {
+			t.Skip("skipping test for params max level < 4")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		n := 3
+
+		for i := range values {
+			values[i] = cmplx.Pow(values[i], complex(float64(n), 0))
+		}
+
+		tc.evaluator.Power(ciphertext, n, ciphertext)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Evaluator/Inverse"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < 7 {
+			t.Skip("skipping test for params max level < 7")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(0.1, 0), complex(1, 0), t)
+
+		n := 7
+
+		for i := range values {
+			values[i] = 1.0 / values[i]
+		}
+
+		ciphertext = tc.evaluator.InverseNew(ciphertext, n)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+	})
+}
+
+func testEvaluatePoly(tc *testContext, t *testing.T) {
+
+	var err error
+
+	t.Run(GetTestName(tc.params, "EvaluatePoly/PolySingle/Exp"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < 3 {
+			t.Skip("skipping test for params max level < 3")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		coeffs := []complex128{
+			complex(1.0, 0),
+			complex(1.0, 0),
+			complex(1.0/2, 0),
+			complex(1.0/6, 0),
+			complex(1.0/24, 0),
+			complex(1.0/120, 0),
+			complex(1.0/720, 0),
+			complex(1.0/5040, 0),
+		}
+
+		poly := NewPoly(coeffs)
+
+		for i := range values {
+			values[i] = cmplx.Exp(values[i])
+		}
+
+		if ciphertext, err = tc.evaluator.EvaluatePoly(ciphertext, poly, ciphertext.Scale); err != nil {
+			t.Error(err)
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "EvaluatePoly/PolyVector/Exp"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < 3 {
 			t.Skip("skipping test for params max level < 3")
 		}
 
@@ -944,6 +1530,225 @@ func testChebyshevInterpolator(tc *testContext, t *testing.T) {
 
 		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
 	})
+
+	t.Run(GetTestName(tc.params, "ChebyshevInterpolator/Sin/ScaleInvariant"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < 5 {
+			t.Skip("skipping test for params max level < 5")
+		}
+
+		params := tc.params
+
+		values, _, _ := newTestVectors(tc, nil, complex(-1, 0), complex(1, 0), t)
+
+		// The input scale is much smaller than the moduli, so that rescaling the powers with the target scale as
+		// threshold would skip rescalings and go below the target scale
+		ciphertext := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values, params.MaxLevel(), params.DefaultScale()/4, params.LogSlots()))
+
+		poly := Approximate(cmplx.Sin, complex(-1, 0), complex(1, 0), 15)
+
+		for i := range values {
+			values[i] = cmplx.Sin(values[i])
+		}
+
+		levelStart := params.MaxLevel() - 1
+		levelEnd := levelStart - poly.Depth()
+
+		_, err = tc.evaluator.EvaluatePolyScaleInvariant(ciphertext, poly, params.DefaultScale(), levelStart, levelEnd+1)
+		require.Error(t, err)
+
+		_, err = tc.evaluator.EvaluatePolyScaleInvariant(tc.evaluator.DropLevelNew(ciphertext, 2), poly, params.DefaultScale(), levelStart, levelEnd)
+		require.Error(t, err)
+
+		if ciphertext, err = tc.evaluator.EvaluatePolyScaleInvariant(ciphertext, poly, params.DefaultScale(), levelStart, levelEnd); err != nil {
+			t.Error(err)
+		}
+
+		require.Equal(t, levelEnd, ciphertext.Level())
+		require.Equal(t, params.DefaultScale(), ciphertext.Scale)
+
+		verifyTestVectors(params, tc.encoder, tc.decryptor, values, ciphertext, params.LogSlots(), 0, t)
+	})
+}
+
+func testMinimax(tc *testContext, t *testing.T) {
+
+	var err error
+
+	// maxError returns the maximum absolute error between f and poly on a dense grid of [poly.A, poly.B]
+	maxError := func(f func(float64) float64, poly *Polynomial) (maxErr float64) {
+		coeffs := make([]float64, len(poly.Coeffs))
+		for i := range coeffs {
+			coeffs[i] = real(poly.Coeffs[i])
+		}
+		for i := 0; i <= 1<<14; i++ {
+			x := poly.A + (poly.B-poly.A)*float64(i)/(1<<14)
+			u := (2*x - poly.A - poly.B) / (poly.B - poly.A)
+			maxErr = math.Max(maxErr, math.Abs(f(x)-chebyshevClenshaw(coeffs, u)))
+		}
+		return
+	}
+
+	t.Run(GetTestName(tc.params, "Minimax/Error"), func(t *testing.T) {
+
+		for _, degree := range []int{1, 3, 7} {
+
+			poly, maxErr, err := ApproximateMinimax(math.Sin, -1.5, 1.5, degree)
+			require.NoError(t, err)
+			require.Equal(t, degree, poly.Degree())
+
+			// The returned error is the one of the polynomial, and is smaller than the one of the Chebyshev interpolant
+			require.InDelta(t, maxErr, maxError(math.Sin, poly), 1e-6*maxErr)
+			require.Less(t, maxErr, maxError(math.Sin, Approximate(math.Sin, -1.5, 1.5, degree)))
+		}
+
+		_, _, err = ApproximateMinimax(math.Sin, -1.5, 1.5, -1)
+		require.Error(t, err)
+
+		_, _, err = ApproximateMinimax(math.Sin, 1.5, -1.5, 7)
+		require.Error(t, err)
+
+		_, _, err = ApproximateMinimax(math.Log, -1, 1, 7)
+		require.Error(t, err)
+	})
+
+	t.Run(GetTestName(tc.params, "Minimax/MaxError"), func(t *testing.T) {
+
+		poly, err := ApproximateMinimaxWithMaxError(math.Exp, -8, 8, 1e-6, 63)
+		require.NoError(t, err)
+		require.LessOrEqual(t, maxError(math.Exp, poly), 1e-6)
+
+		// The degree is the smallest reaching the error
+		_, maxErr, err := ApproximateMinimax(math.Exp, -8, 8, poly.Degree()-1)
+		require.NoError(t, err)
+		require.Greater(t, maxErr, 1e-6)
+
+		_, err = ApproximateMinimaxWithMaxError(math.Abs, -1, 1, 1e-6, 63)
+		require.Error(t, err)
+	})
+
+	t.Run(GetTestName(tc.params, "Minimax/EvaluatePoly/Sin"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < 5 {
+			t.Skip("skipping test for params max level < 5")
+		}
+
+		eval := tc.evaluator
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		poly, _, err := ApproximateMinimax(math.Sin, -1.5, 1.5, 15)
+		require.NoError(t, err)
+
+		for i := range values {
+			values[i] = cmplx.Sin(values[i])
+		}
+
+		eval.MultByConst(ciphertext, 2/(poly.B-poly.A), ciphertext)
+		eval.AddConst(ciphertext, (-poly.A-poly.B)/(poly.B-poly.A), ciphertext)
+		eval.Rescale(ciphertext, tc.params.DefaultScale(), ciphertext)
+
+		if ciphertext, err = eval.EvaluatePoly(ciphertext, poly, ciphertext.Scale); err != nil {
+			t.Error(err)
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+	})
+}
+
+func testFunctionLibrary(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "Functions/Sigmoid"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		sigmoid, err := NewSigmoid(tc.evaluator, -4, 4, 15)
+		require.NoError(t, err)
+		require.Less(t, sigmoid.MaxError(), 1e-5)
+
+		if tc.params.MaxLevel() < sigmoid.Depth() {
+			t.Skip("skipping test for params max level < depth")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-4, 0), complex(4, 0), t)
+
+		for i := range values {
+			values[i] = complex(1/(1+math.Exp(-real(values[i]))), 0)
+		}
+
+		ctOut, err := sigmoid.Evaluate(ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel()-sigmoid.Depth(), ctOut.Level())
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ctOut, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Functions/Inverse"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		inverse, err := NewInverse(tc.evaluator, 1, 1.5, 3)
+		require.NoError(t, err)
+		require.Less(t, inverse.MaxError(), 1e-5)
+
+		if tc.params.MaxLevel() < inverse.Depth() {
+			t.Skip("skipping test for params max level < depth")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(1, 0), complex(1.5, 0), t)
+
+		for i := range values {
+			values[i] = 1 / values[i]
+		}
+
+		ctOut, err := inverse.Evaluate(ciphertext)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, ctOut.Level(), tc.params.MaxLevel()-inverse.Depth())
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ctOut, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Functions/Errors"), func(t *testing.T) {
+
+		_, err := NewLog(tc.evaluator, 0, 1, 15)
+		require.Error(t, err)
+
+		_, err = NewSqrt(tc.evaluator, -1, 1, 15)
+		require.Error(t, err)
+
+		_, err = NewInverse(tc.evaluator, -1, 1, 4)
+		require.Error(t, err)
+
+		_, err = NewInverse(tc.evaluator, 1, 2, 0)
+		require.Error(t, err)
+
+		exp, err := NewExp(tc.evaluator, -2, 2, 7)
+		require.NoError(t, err)
+		require.Equal(t, 4, exp.Depth())
+
+		if tc.params.MaxLevel() < exp.Depth() {
+			t.Skip("skipping test for params max level < depth")
+		}
+
+		_, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		tc.evaluator.DropLevel(ciphertext, ciphertext.Level()-exp.Depth()+1)
+
+		_, err = exp.Evaluate(ciphertext)
+		require.Error(t, err)
+	})
 }
 
 func testDecryptPublic(tc *testContext, t *testing.T) {
@@ -1077,6 +1882,97 @@ func testBridge(tc *testContext, t *testing.T) {
 	})
 }
 
+func testBFVConverter(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	if params.RingType() != ring.Standard {
+		t.Run(GetTestName(params, "BFVConverter"), func(t *testing.T) {
+			t.Skip("only tested for params.RingType() == ring.Standard")
+		})
+		return
+	}
+
+	paramsBFV, err := bfv.NewParameters(params.Parameters, ring.GenerateNTTPrimes(20, 2*params.N(), 1)[0])
+	require.NoError(t, err)
+
+	conv, err := NewBFVConverter(params, paramsBFV)
+	require.NoError(t, err)
+
+	encoderBFV := bfv.NewEncoder(paramsBFV)
+	encryptorBFV := bfv.NewEncryptor(paramsBFV, tc.sk)
+	decryptorBFV := bfv.NewDecryptor(paramsBFV, tc.sk)
+
+	T := paramsBFV.T()
+
+	// Small signed integers on all the coefficients
+	values := make([]int64, params.N())
+	for i := range values {
+		values[i] = int64(utils.RandUint64()%256) - 128
+	}
+
+	encryptBFV := func() *bfv.Ciphertext {
+		ptRt := bfv.NewPlaintextRingT(paramsBFV)
+		for i, v := range values {
+			ptRt.Value.Coeffs[0][i] = uint64((v + int64(T)) % int64(T))
+		}
+		pt := bfv.NewPlaintext(paramsBFV)
+		encoderBFV.ScaleUp(ptRt, pt)
+		return encryptorBFV.EncryptNew(pt)
+	}
+
+	verifyBFV := func(ct *bfv.Ciphertext, t *testing.T) {
+		ptRt := bfv.NewPlaintextRingT(paramsBFV)
+		encoderBFV.ScaleDown(decryptorBFV.DecryptNew(ct), ptRt)
+		for i, v := range values {
+			require.Equal(t, uint64((v+int64(T))%int64(T)), ptRt.Value.Coeffs[0][i])
+		}
+	}
+
+	verifyCKKS := func(ct *Ciphertext, t *testing.T) {
+		have := tc.encoder.DecodeCoeffs(tc.decryptor.DecryptNew(ct))
+		for i, v := range values {
+			require.InDelta(t, float64(v), have[i], 0.25)
+		}
+	}
+
+	t.Run(GetTestName(params, "BFVConverter/BFVToCKKS"), func(t *testing.T) {
+
+		ctBFV := encryptBFV()
+
+		for _, level := range []int{params.MaxLevel(), 0} {
+			ct := conv.BFVToCKKSNew(ctBFV, level)
+			require.Equal(t, level, ct.Level())
+			require.Equal(t, conv.ScaleAtLevel(level), ct.Scale)
+			verifyCKKS(ct, t)
+		}
+
+		// Round trip
+		ctBFVHave, err := conv.CKKSToBFVNew(conv.BFVToCKKSNew(ctBFV, params.MaxLevel()))
+		require.NoError(t, err)
+		verifyBFV(ctBFVHave, t)
+	})
+
+	t.Run(GetTestName(params, "BFVConverter/CKKSToBFV"), func(t *testing.T) {
+
+		valuesFloat := make([]float64, len(values))
+		for i, v := range values {
+			valuesFloat[i] = float64(v)
+		}
+
+		ct := tc.encryptorSk.EncryptNew(tc.encoder.EncodeCoeffsNew(valuesFloat, params.MaxLevel(), params.DefaultScale()))
+
+		ctBFV, err := conv.CKKSToBFVNew(ct)
+		require.NoError(t, err)
+		verifyBFV(ctBFV, t)
+
+		// A scale larger than Q_level/t cannot be converted
+		ct.Scale = math.Exp2(float64(params.LogQLvl(ct.Level())))
+		_, err = conv.CKKSToBFVNew(ct)
+		require.Error(t, err)
+	})
+}
+
 func testAutomorphisms(tc *testContext, t *testing.T) {
 
 	params := tc.params
@@ -1117,19 +2013,49 @@ func testAutomorphisms(tc *testContext, t *testing.T) {
 			t.Skip("method is unsuported when params.PCount() == 0")
 		}
 
-		if params.RingType() != ring.Standard {
-			t.Skip("Conjugate not defined in real-CKKS")
-		}
-
+		if params.RingType() != ring.Standard {
+			t.Skip("Conjugate not defined in real-CKKS")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values {
+			values[i] = complex(real(values[i]), -imag(values[i]))
+		}
+
+		ciphertext = evaluator.ConjugateNew(ciphertext)
+
+		verifyTestVectors(params, tc.encoder, tc.decryptor, values, ciphertext, params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(params, "RealProjection"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
 		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
 
-		for i := range values {
-			values[i] = complex(real(values[i]), -imag(values[i]))
+		ctReal := evaluator.RealProjectionNew(ciphertext)
+		require.Equal(t, ciphertext.Level(), ctReal.Level())
+
+		if params.RingType() == ring.Standard {
+
+			valuesImag := make([]complex128, len(values))
+			for i := range values {
+				valuesImag[i] = complex(imag(values[i]), 0)
+			}
+
+			// In place
+			evaluator.ImagProjection(ciphertext, ciphertext)
+			verifyTestVectors(params, tc.encoder, tc.decryptor, valuesImag, ciphertext, params.LogSlots(), 0, t)
 		}
 
-		ciphertext = evaluator.ConjugateNew(ciphertext)
+		for i := range values {
+			values[i] = complex(real(values[i]), 0)
+		}
 
-		verifyTestVectors(params, tc.encoder, tc.decryptor, values, ciphertext, params.LogSlots(), 0, t)
+		verifyTestVectors(params, tc.encoder, tc.decryptor, values, ctReal, params.LogSlots(), 0, t)
 	})
 
 	t.Run(GetTestName(tc.params, "Rotate"), func(t *testing.T) {
@@ -1370,63 +2296,671 @@ func testLinearTransform(tc *testContext, t *testing.T) {
 
 		rots := linTransf.Rotations()
 
-		rotKey := tc.kgen.GenRotationKeysForRotations(rots, false, tc.sk)
+		rotKey := tc.kgen.GenRotationKeysForRotations(rots, false, tc.sk)
+
+		eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+		eval.LinearTransform(ciphertext1, linTransf, []*Ciphertext{ciphertext1})
+
+		tmp := make([]complex128, params.Slots())
+		copy(tmp, values1)
+
+		for i := 0; i < params.Slots(); i++ {
+			values1[i] += tmp[(i-15+params.Slots())%params.Slots()]
+			values1[i] += tmp[(i-4+params.Slots())%params.Slots()]
+			values1[i] += tmp[(i-1+params.Slots())%params.Slots()]
+			values1[i] += tmp[(i+1)%params.Slots()]
+			values1[i] += tmp[(i+2)%params.Slots()]
+			values1[i] += tmp[(i+3)%params.Slots()]
+			values1[i] += tmp[(i+4)%params.Slots()]
+			values1[i] += tmp[(i+15)%params.Slots()]
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "LinearTransform/Naive"), func(t *testing.T) {
+
+		params := tc.params
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		diagMatrix := make(map[int][]complex128)
+
+		diagMatrix[-1] = make([]complex128, params.Slots())
+		diagMatrix[0] = make([]complex128, params.Slots())
+
+		for i := 0; i < params.Slots(); i++ {
+			diagMatrix[-1][i] = complex(1, 0)
+			diagMatrix[0][i] = complex(1, 0)
+		}
+
+		linTransf := GenLinearTransform(tc.encoder, diagMatrix, params.MaxLevel(), params.DefaultScale(), params.LogSlots())
+
+		rots := linTransf.Rotations()
+
+		rotKey := tc.kgen.GenRotationKeysForRotations(rots, false, tc.sk)
+
+		eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+		eval.LinearTransform(ciphertext1, linTransf, []*Ciphertext{ciphertext1})
+
+		tmp := make([]complex128, params.Slots())
+		copy(tmp, values1)
+
+		for i := 0; i < params.Slots(); i++ {
+			values1[i] += tmp[(i-1+params.Slots())%params.Slots()]
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+	})
+}
+
+func testPrecisionTracker(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "PrecisionTracker"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		if tc.params.MaxLevel() < 2 {
+			t.Skip("not enough levels")
+		}
+
+		rotKey := tc.kgen.GenRotationKeysForRotations([]int{1}, false, tc.sk)
+		eval := NewTrackingEvaluator(tc.params, tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey}))
+
+		values0, _, ciphertext0 := newTestVectors(tc, tc.encryptorPk, complex(-1, -1), complex(1, 1), t)
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		ct0 := eval.Track(ciphertext0, math.Sqrt2)
+		ct1 := eval.Track(ciphertext1, math.Sqrt2)
+
+		freshPrecision := ct0.Precision()
+
+		// (ct0 * ct1 + rotate(ct0, 1)) * 0.5 + 0.25
+		slots := len(values0)
+		want := make([]complex128, slots)
+		for i := range want {
+			want[i] = (values0[i]*values1[i]+values0[(i+1)%slots])*0.5 + 0.25
+		}
+
+		ctOut := &TrackedCiphertext{Ciphertext: NewCiphertext(tc.params, 1, ct0.Level(), ct0.Scale)}
+		eval.MulRelin(ct0, ct1, ctOut)
+		require.NoError(t, eval.Rescale(ctOut, tc.params.DefaultScale(), ctOut))
+
+		ctRot := ct0.CopyNew()
+		eval.Rotate(ctRot, 1, ctRot)
+		eval.Add(ctOut, ctRot, ctOut)
+
+		eval.MultByConst(ctOut, 0.5, ctOut)
+		require.NoError(t, eval.Rescale(ctOut, tc.params.DefaultScale(), ctOut))
+		eval.AddConst(ctOut, 0.25, ctOut)
+
+		require.Less(t, ctOut.Precision(), freshPrecision)
+		require.InDelta(t, (2+math.Sqrt2)*0.5+0.25, ctOut.MessageBound, 1e-9)
+
+		have := tc.encoder.Decode(tc.decryptor.DecryptNew(ctOut.Ciphertext), tc.params.LogSlots())
+
+		var maxErr float64
+		for i := range want {
+			maxErr = math.Max(maxErr, cmplx.Abs(have[i]-want[i]))
+		}
+
+		if *printPrecisionStats {
+			t.Logf("estimated precision: %.2f, actual precision: %.2f", ctOut.Precision(), -math.Log2(maxErr))
+		}
+
+		// The estimate is a bound on the error, which should not be too loose
+		require.LessOrEqual(t, maxErr, ctOut.ErrorBound)
+		require.GreaterOrEqual(t, ctOut.Precision(), -math.Log2(maxErr)-8)
+	})
+}
+
+func testPlanner(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "Planner"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		if tc.params.MaxLevel() < 2 {
+			t.Skip("not enough levels")
+		}
+
+		params := tc.params
+
+		rotKey := tc.kgen.GenRotationKeysForRotations([]int{1}, false, tc.sk)
+		eval := NewTrackingEvaluator(params, tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey}))
+
+		_, _, ciphertext0 := newTestVectors(tc, tc.encryptorPk, complex(-1, -1), complex(1, 1), t)
+		_, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		// (ct0 * ct1 + rotate(ct0, 1)) * 0.5 + 0.25, evaluated and planned
+		ct0 := eval.Track(ciphertext0, math.Sqrt2)
+		ct1 := eval.Track(ciphertext1, math.Sqrt2)
+
+		ctOut := &TrackedCiphertext{Ciphertext: NewCiphertext(params, 1, ct0.Level(), ct0.Scale)}
+		eval.MulRelin(ct0, ct1, ctOut)
+		require.NoError(t, eval.Rescale(ctOut, params.DefaultScale(), ctOut))
+		ctRot := ct0.CopyNew()
+		eval.Rotate(ctRot, 1, ctRot)
+		eval.Add(ctOut, ctRot, ctOut)
+		eval.MultByConst(ctOut, 0.5, ctOut)
+		require.NoError(t, eval.Rescale(ctOut, params.DefaultScale(), ctOut))
+		eval.AddConst(ctOut, 0.25, ctOut)
+
+		planner := NewPlanner(params)
+		sym0 := planner.Fresh(math.Sqrt2)
+		sym1 := planner.Fresh(math.Sqrt2)
+		symOut := planner.Rescale(planner.MulRelin(sym0, sym1), params.DefaultScale())
+		symOut = planner.Add(symOut, planner.Rotate(sym0, 1))
+		symOut = planner.AddConst(planner.Rescale(planner.MultByConst(symOut, 0.5), params.DefaultScale()), 0.25)
+
+		require.Equal(t, ctOut.Level(), symOut.Level)
+		require.Equal(t, ctOut.Scale, symOut.Scale)
+		require.Equal(t, ctOut.MessageBound, symOut.MessageBound)
+
+		// Unlike the TrackingEvaluator, the Planner accounts for the misalignment of the scales of the addition
+		require.LessOrEqual(t, symOut.Precision(), ctOut.Precision())
+
+		have := tc.encoder.Decode(tc.decryptor.DecryptNew(ctOut.Ciphertext), params.LogSlots())
+		values0 := tc.encoder.Decode(tc.decryptor.DecryptNew(ciphertext0), params.LogSlots())
+		values1 := tc.encoder.Decode(tc.decryptor.DecryptNew(ciphertext1), params.LogSlots())
+		slots := len(have)
+		for i := range have {
+			want := (values0[i]*values1[i]+values0[(i+1)%slots])*0.5 + 0.25
+			require.LessOrEqual(t, cmplx.Abs(have[i]-want), symOut.ErrorBound)
+		}
+
+		report := planner.Report(0, symOut)
+
+		if *printPrecisionStats {
+			t.Log("\n" + report.String())
+		}
+
+		require.True(t, report.Fits)
+		require.Len(t, report.Steps, 9)
+		require.Equal(t, symOut.Level, report.UnusedLevels)
+
+		// The rotated operand is one level above the product and at a different scale: the addition wastes a
+		// level and aligns the scales approximately
+		require.Len(t, report.Issues, 2)
+		for _, issue := range report.Issues {
+			require.Equal(t, PlanWarning, issue.Severity)
+			require.Equal(t, 5, issue.Step)
+		}
+
+		// Too much precision is required
+		require.False(t, planner.Report(symOut.Precision()+1, symOut).Fits)
+
+		// Rescaling at level 0 and overflows are errors
+		planner = NewPlanner(params)
+		sym := planner.FreshAt(0, params.DefaultScale(), 1)
+		planner.Rescale(sym, params.DefaultScale())
+		require.False(t, planner.Report(0, sym).Fits)
+
+		planner = NewPlanner(params)
+		sym = planner.MulRelin(planner.FreshAt(0, params.QiFloat64(0), 1), planner.FreshAt(0, params.QiFloat64(0), 1))
+		require.False(t, planner.Report(0, sym).Fits)
+	})
+}
+
+func testMatrixMultiplication(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "MatrixMultiplication/Batched"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < 3 {
+			t.Skip("skipping test for params max level < 3")
+		}
+
+		params := tc.params
+
+		mm, err := NewMatrixMultiplier(params, tc.encoder, 4, params.MaxLevel())
+		require.NoError(t, err)
+		require.Equal(t, params.Slots()/16, mm.Batch())
+
+		// Products of 3x4 and 4x2 matrices, zero-padded to 4x4
+		m, l, n := 3, 4, 2
+		count := utils.MinInt(mm.Batch(), 8)
+
+		randomMatrices := func(rows, cols int) (matrices [][][]complex128) {
+			matrices = make([][][]complex128, count)
+			for b := range matrices {
+				matrices[b] = make([][]complex128, rows)
+				for i := range matrices[b] {
+					matrices[b][i] = make([]complex128, cols)
+					for j := range matrices[b][i] {
+						matrices[b][i][j] = randomConst(params.RingType(), complex(-1, -1), complex(1, 1))
+					}
+				}
+			}
+			return
+		}
+
+		A, B := randomMatrices(m, l), randomMatrices(l, n)
+
+		valuesA, err := mm.EncodeMatrices(A)
+		require.NoError(t, err)
+		valuesB, err := mm.EncodeMatrices(B)
+		require.NoError(t, err)
+
+		ctA := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(valuesA, params.MaxLevel(), params.DefaultScale(), params.LogSlots()))
+		ctB := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(valuesB, params.MaxLevel(), params.DefaultScale(), params.LogSlots()))
+
+		rotKey := tc.kgen.GenRotationKeysForRotations(mm.Rotations(), false, tc.sk)
+		eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+		ctOut := mm.MulNew(eval, ctA, ctB)
+		require.Equal(t, params.MaxLevel()-mm.Depth(), ctOut.Level())
+
+		want := make([][][]complex128, count)
+		for b := range want {
+			want[b] = make([][]complex128, m)
+			for i := range want[b] {
+				want[b][i] = make([]complex128, n)
+				for j := range want[b][i] {
+					for k := 0; k < l; k++ {
+						want[b][i][j] += A[b][i][k] * B[b][k][j]
+					}
+				}
+			}
+		}
+
+		// The slots of the padding and of the unused blocks are zero
+		valuesWant, err := mm.EncodeMatrices(want)
+		require.NoError(t, err)
+
+		verifyTestVectors(params, tc.encoder, tc.decryptor, valuesWant, ctOut, params.LogSlots(), 0, t)
+
+		have := mm.DecodeMatrices(tc.encoder.Decode(tc.decryptor.DecryptNew(ctOut), params.LogSlots()), count, m, n)
+		for b := range want {
+			for i := range want[b] {
+				for j := range want[b][i] {
+					require.InDelta(t, real(want[b][i][j]), real(have[b][i][j]), 1e-3)
+					require.InDelta(t, imag(want[b][i][j]), imag(have[b][i][j]), 1e-3)
+				}
+			}
+		}
+	})
+
+	t.Run(GetTestName(tc.params, "MatrixMultiplication/Errors"), func(t *testing.T) {
+
+		if tc.params.MaxLevel() < 3 {
+			t.Skip("skipping test for params max level < 3")
+		}
+
+		_, err := NewMatrixMultiplier(tc.params, tc.encoder, 1<<(tc.params.LogSlots()/2+1), tc.params.MaxLevel())
+		require.Error(t, err)
+
+		_, err = NewMatrixMultiplier(tc.params, tc.encoder, 4, 2)
+		require.Error(t, err)
+
+		mm, err := NewMatrixMultiplier(tc.params, tc.encoder, 4, tc.params.MaxLevel())
+		require.NoError(t, err)
+
+		_, err = mm.EncodeMatrices([][][]complex128{{{1, 2, 3, 4, 5}}})
+		require.Error(t, err)
+
+		_, err = mm.EncodeMatrices(make([][][]complex128, mm.Batch()+1))
+		require.Error(t, err)
+	})
+}
+
+func testBlockLinearTransform(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "BlockLinearTransform/Rectangular"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		params := tc.params
+
+		logSlots := utils.MinInt(4, params.LogSlots())
+		slots := 1 << logSlots
+
+		// A (slots + slots/2) x (2*slots + 3) matrix spanning three input and two output ciphertexts,
+		// whose bottom-left block is zero and whose bottom-right block has a single non-zero diagonal
+		rows, cols := slots+slots/2, 2*slots+3
+
+		matrix := make([][]complex128, rows)
+		for i := range matrix {
+			matrix[i] = make([]complex128, cols)
+			for j := range matrix[i] {
+				if i < slots || (j >= 2*slots && j == 2*slots+(i-slots)) || (j >= slots && j < 2*slots) {
+					matrix[i][j] = randomConst(params.RingType(), complex(-1, -1), complex(1, 1)) / complex(float64(cols), 0)
+				}
+			}
+		}
+
+		BLT := GenBlockLinearTransform(tc.encoder, matrix, params.MaxLevel(), params.QiFloat64(params.MaxLevel()), logSlots)
+		require.Equal(t, 3, BLT.InputCiphertexts())
+		require.Equal(t, 2, BLT.OutputCiphertexts())
+		require.Nil(t, BLT.Blocks[1][0])
+
+		vector := make([]complex128, cols)
+		for j := range vector {
+			vector[j] = randomConst(params.RingType(), complex(-1, -1), complex(1, 1))
+		}
+
+		ctIn := make([]*Ciphertext, BLT.InputCiphertexts())
+		for j := range ctIn {
+			values := make([]complex128, slots)
+			copy(values, vector[j*slots:utils.MinInt((j+1)*slots, cols)])
+			ctIn[j] = tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values, params.MaxLevel(), params.DefaultScale(), logSlots))
+		}
+
+		rotKey := tc.kgen.GenRotationKeysForRotations(BLT.Rotations(), false, tc.sk)
+		eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+		ctOut := eval.BlockLinearTransformNew(ctIn, BLT)
+		require.Len(t, ctOut, BLT.OutputCiphertexts())
+
+		// The in-place evaluation can alias its inputs
+		eval.BlockLinearTransform(ctIn, BLT, ctIn[:BLT.OutputCiphertexts()])
+
+		for i := range ctOut {
+
+			want := make([]complex128, slots)
+			for r := range want {
+				if i*slots+r < rows {
+					for j := range vector {
+						want[r] += matrix[i*slots+r][j] * vector[j]
+					}
+				}
+			}
+
+			for _, ct := range []*Ciphertext{ctOut[i], ctIn[i]} {
+				require.NoError(t, eval.Rescale(ct, params.DefaultScale(), ct))
+				verifyTestVectors(params, tc.encoder, tc.decryptor, want, ct, logSlots, 0, t)
+			}
+		}
+	})
+}
+
+func testVector(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "Vector"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		params := tc.params
+
+		logSlots := utils.MinInt(4, params.LogSlots())
+		slots := 1 << logSlots
+
+		for _, length := range []int{3 * slots, 2*slots + slots/2} {
+
+			values0 := make([]complex128, length)
+			values1 := make([]complex128, length)
+			for i := range values0 {
+				values0[i] = randomConst(params.RingType(), complex(-1, -1), complex(1, 1))
+				values1[i] = randomConst(params.RingType(), complex(-1, -1), complex(1, 1))
+			}
+
+			v0 := EncryptVectorNew(tc.encoder, tc.encryptorSk, values0, params.MaxLevel(), params.DefaultScale(), logSlots)
+			v1 := EncryptVectorNew(tc.encoder, tc.encryptorSk, values1, params.MaxLevel(), params.DefaultScale(), logSlots)
+			require.Len(t, v0.Value, (length+slots-1)/slots)
+
+			// verifyVector checks the values and the zero padding of each ciphertext of the Vector
+			verifyVector := func(want []complex128, v *Vector) {
+				require.Equal(t, len(want), v.Len)
+				for i, ct := range v.Value {
+					chunk := make([]complex128, slots)
+					copy(chunk, want[i*slots:utils.MinInt((i+1)*slots, len(want))])
+					verifyTestVectors(params, tc.encoder, tc.decryptor, chunk, ct, logSlots, 0, t)
+				}
+			}
+
+			ks := []int{1, -3, slots, slots + 2}
+
+			rotKey := tc.kgen.GenRotationKeysForRotations(append(params.RotationsForVectorRotate(length, logSlots, ks), params.RotationsForVectorInnerSum(logSlots)...), false, tc.sk)
+			ve := NewVectorEvaluator(params, tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey}), tc.encoder)
+
+			want := make([]complex128, length)
+
+			for i := range want {
+				want[i] = values0[i] + values1[i]
+			}
+			verifyVector(want, ve.AddNew(v0, v1))
+
+			for i := range want {
+				want[i] = values0[i] * values1[i]
+			}
+			vMul := ve.MulRelinNew(v0, v1)
+			require.NoError(t, ve.Rescale(vMul, params.DefaultScale(), vMul))
+			verifyVector(want, vMul)
+
+			for _, k := range ks {
+
+				for i := range want {
+					want[i] = values0[((i+k)%length+length)%length]
+				}
+
+				vRot := ve.RotateNew(v0, k)
+
+				// Rotations by multiples of the slots of a Vector filling its ciphertexts are not masked
+				if length%slots == 0 && k%slots == 0 {
+					require.Equal(t, v0.Level(), vRot.Level())
+				} else {
+					require.Equal(t, v0.Level()-1, vRot.Level())
+				}
+
+				require.Equal(t, v0.Scale(), vRot.Scale())
+				verifyVector(want, vRot)
+			}
+
+			var sum complex128
+			for i := range values0 {
+				sum += values0[i]
+			}
+
+			for i := range want {
+				want[i] = sum
+			}
+			verifyTestVectors(params, tc.encoder, tc.decryptor, want[:slots], ve.InnerSumNew(v0), logSlots, 0, t)
+
+			decrypted := DecryptVector(tc.encoder, tc.decryptor, v0.CopyNew())
+			require.Len(t, decrypted, length)
+			for i := range decrypted {
+				require.InDelta(t, 0, cmplx.Abs(decrypted[i]-values0[i]), 1e-3)
+			}
+
+			// The in-place rotation can alias its input
+			ve.Rotate(v1, 1, v1)
+			for i := range want {
+				want[i] = values1[(i+1)%length]
+			}
+			verifyVector(want, v1)
+		}
+	})
+}
+
+func testMasking(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "Masking"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		params := tc.params
+		logSlots := params.LogSlots()
+		slots := 1 << logSlots
+
+		values0, _, ciphertext0 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		// The error of the duplicated slot grows with the number of slots
+		logSlotsDup := utils.MinInt(4, logSlots)
+
+		rotKey := tc.kgen.GenRotationKeysForRotations(params.RotationsForDuplicateSlot(logSlotsDup), false, tc.sk)
+		masker := NewMasker(params, tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey}), tc.encoder)
 
-		eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+		start, end := slots/4, slots/2+1
 
-		eval.LinearTransform(ciphertext1, linTransf, []*Ciphertext{ciphertext1})
+		want := make([]complex128, slots)
+		copy(want[start:end], values0[start:end])
 
-		tmp := make([]complex128, params.Slots())
-		copy(tmp, values1)
+		ctOut := masker.MaskRangeNew(ciphertext0, start, end, logSlots)
+		require.Equal(t, ciphertext0.Level()-1, ctOut.Level())
+		require.Equal(t, ciphertext0.Scale, ctOut.Scale)
+		verifyTestVectors(params, tc.encoder, tc.decryptor, want, ctOut, logSlots, 0, t)
 
-		for i := 0; i < params.Slots(); i++ {
-			values1[i] += tmp[(i-15+params.Slots())%params.Slots()]
-			values1[i] += tmp[(i-4+params.Slots())%params.Slots()]
-			values1[i] += tmp[(i-1+params.Slots())%params.Slots()]
-			values1[i] += tmp[(i+1)%params.Slots()]
-			values1[i] += tmp[(i+2)%params.Slots()]
-			values1[i] += tmp[(i+3)%params.Slots()]
-			values1[i] += tmp[(i+4)%params.Slots()]
-			values1[i] += tmp[(i+15)%params.Slots()]
+		ctDup := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values0[:1<<logSlotsDup], params.MaxLevel(), params.DefaultScale(), logSlotsDup))
+		index := 3
+		wantDup := make([]complex128, 1<<logSlotsDup)
+		for i := range wantDup {
+			wantDup[i] = values0[index]
 		}
+		ctOut = masker.DuplicateSlotNew(ctDup, index, logSlotsDup)
+		require.Equal(t, ctDup.Scale, ctOut.Scale)
+		verifyTestVectors(params, tc.encoder, tc.decryptor, wantDup, ctOut, logSlotsDup, 0, t)
 
-		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+		// The ciphertexts are merged at the smallest level
+		if ciphertext1.Level() > 1 {
+			tc.evaluator.DropLevel(ciphertext1, 1)
+		}
+		copy(want, values0)
+		copy(want[start:], values1[start:])
+		ctOut = masker.MergeNew([]*Ciphertext{ciphertext0, ciphertext1}, [][]float64{RangeMask(logSlots, 0, start), RangeMask(logSlots, start, slots)})
+		require.Equal(t, ciphertext1.Level()-1, ctOut.Level())
+		require.Equal(t, ciphertext0.Scale, ctOut.Scale)
+		verifyTestVectors(params, tc.encoder, tc.decryptor, want, ctOut, logSlots, 0, t)
 	})
+}
 
-	t.Run(GetTestName(tc.params, "LinearTransform/Naive"), func(t *testing.T) {
+func testStreamEncoder(tc *testContext, t *testing.T) {
 
-		params := tc.params
+	params := tc.params
+	logSlots := params.LogSlots()
+	slots := 1 << logSlots
+	fields := 3
 
-		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+	for _, layout := range []SlotLayout{
+		{Order: RowMajor, Fields: fields},
+		{Order: ColumnMajor, Fields: fields, Stride: 5},
+	} {
 
-		diagMatrix := make(map[int][]complex128)
+		t.Run(GetTestName(params, fmt.Sprintf("StreamEncoder/Order=%d/Stride=%d", layout.Order, layout.Stride)), func(t *testing.T) {
 
-		diagMatrix[-1] = make([]complex128, params.Slots())
-		diagMatrix[0] = make([]complex128, params.Slots())
+			se, err := NewStreamEncoder(tc.encoder, layout, params.MaxLevel(), params.DefaultScale(), logSlots)
+			require.NoError(t, err)
 
-		for i := 0; i < params.Slots(); i++ {
-			diagMatrix[-1][i] = complex(1, 0)
-			diagMatrix[0][i] = complex(1, 0)
+			// Two full batches and a partial one, sent on a channel
+			records := make([][]float64, 2*se.RecordsPerBatch()+5)
+			for i := range records {
+				records[i] = make([]float64, fields)
+				for j := range records[i] {
+					records[i][j] = utils.RandFloat64(-1, 1)
+				}
+			}
+
+			ch := make(chan []float64)
+			go func() {
+				for i := range records {
+					ch <- records[i]
+				}
+				close(ch)
+			}()
+
+			var batch int
+			err = se.Encrypt(NewChanSource(ch), tc.encryptorSk, func(ct *Ciphertext, n int) error {
+
+				first := batch * se.RecordsPerBatch()
+				require.Equal(t, utils.MinInt(se.RecordsPerBatch(), len(records)-first), n)
+
+				want := make([]complex128, slots)
+				for r := 0; r < n; r++ {
+					for f, v := range records[first+r] {
+						want[layout.Slot(r, f, slots)] = complex(v, 0)
+					}
+				}
+
+				verifyTestVectors(params, tc.encoder, tc.decryptor, want, ct, logSlots, 0, t)
+				batch++
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 3, batch)
+		})
+	}
+
+	t.Run(GetTestName(params, "StreamEncoder/Reader"), func(t *testing.T) {
+
+		se, err := NewStreamEncoder(tc.encoder, SlotLayout{Order: RowMajor, Fields: fields, Stride: 4}, params.MaxLevel(), params.DefaultScale(), logSlots)
+		require.NoError(t, err)
+
+		want := make([]complex128, slots)
+		buf := new(bytes.Buffer)
+		for r := 0; r < 7; r++ {
+			for f := 0; f < fields; f++ {
+				v := utils.RandFloat64(-1, 1)
+				want[4*r+f] = complex(v, 0)
+				binary.Write(buf, binary.LittleEndian, v)
+			}
 		}
 
-		linTransf := GenLinearTransform(tc.encoder, diagMatrix, params.MaxLevel(), params.DefaultScale(), params.LogSlots())
+		pt, n, err := se.Next(NewReaderSource(buf, fields))
+		require.NoError(t, err)
+		require.Equal(t, 7, n)
+		verifyTestVectors(params, tc.encoder, nil, want, pt, logSlots, 0, t)
 
-		rots := linTransf.Rotations()
+		_, _, err = se.Next(NewReaderSource(buf, fields))
+		require.Equal(t, io.EOF, err)
 
-		rotKey := tc.kgen.GenRotationKeysForRotations(rots, false, tc.sk)
+		_, err = NewStreamEncoder(tc.encoder, SlotLayout{Order: ColumnMajor, Fields: fields, Stride: slots}, params.MaxLevel(), params.DefaultScale(), logSlots)
+		require.Error(t, err)
+	})
 
-		eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+	t.Run(GetTestName(params, "StreamEncoder/EncryptTo"), func(t *testing.T) {
 
-		eval.LinearTransform(ciphertext1, linTransf, []*Ciphertext{ciphertext1})
+		se, err := NewStreamEncoder(tc.encoder, SlotLayout{Order: RowMajor, Fields: fields}, params.MaxLevel(), params.DefaultScale(), logSlots)
+		require.NoError(t, err)
 
-		tmp := make([]complex128, params.Slots())
-		copy(tmp, values1)
+		want := make([]float64, fields*(se.RecordsPerBatch()+5))
+		values := new(bytes.Buffer)
+		for i := range want {
+			want[i] = utils.RandFloat64(-1, 1)
+			binary.Write(values, binary.LittleEndian, want[i])
+		}
 
-		for i := 0; i < params.Slots(); i++ {
-			values1[i] += tmp[(i-1+params.Slots())%params.Slots()]
+		cts := new(bytes.Buffer)
+		records, err := se.EncryptTo(cts, NewReaderSource(values, fields), tc.encryptorPk)
+		require.NoError(t, err)
+		require.Equal(t, se.RecordsPerBatch()+5, records)
+		stream := append([]byte{}, cts.Bytes()...)
+
+		have := new(bytes.Buffer)
+		records, err = se.DecryptFrom(have, cts, tc.decryptor)
+		require.NoError(t, err)
+		require.Equal(t, se.RecordsPerBatch()+5, records)
+		require.Equal(t, 8*len(want), have.Len())
+		for i := range want {
+			require.InDelta(t, want[i], math.Float64frombits(binary.LittleEndian.Uint64(have.Bytes()[8*i:])), 1e-3)
 		}
 
-		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+		// Truncated stream
+		_, err = se.DecryptFrom(new(bytes.Buffer), bytes.NewReader(stream[:len(stream)-1]), tc.decryptor)
+		require.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+		// Ciphertext larger than a ciphertext at the level of the StreamEncoder, or whose size overflows an int64
+		for _, size := range []uint64{uint64(len(stream)), 1 << 63} {
+			invalid := append([]byte{}, stream...)
+			binary.BigEndian.PutUint64(invalid[8:], size)
+			_, err = se.DecryptFrom(new(bytes.Buffer), bytes.NewReader(invalid), tc.decryptor)
+			require.Error(t, err)
+			require.False(t, errors.Is(err, io.ErrUnexpectedEOF))
+		}
 	})
 }
 
@@ -1440,6 +2974,13 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, testctx.params, p)
 		assert.Equal(t, testctx.params.RingQ(), p.RingQ())
+
+		// The decoder decompresses transparently
+		compressed, err := rlwe.Compress(bytes, rlwe.CompressionDeflate)
+		assert.Nil(t, err)
+		var pCompressed Parameters
+		assert.Nil(t, pCompressed.UnmarshalBinary(compressed))
+		assert.True(t, testctx.params.Equals(pCompressed))
 	})
 
 	t.Run(GetTestName(testctx.params, "Marshaller/Parameters/JSON"), func(t *testing.T) {
@@ -1493,6 +3034,55 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 			}
 		})
 
+		t.Run(GetTestName(testctx.params, "Version0"), func(t *testing.T) {
+
+			// The first bytes of the scale of a ciphertext of the version 0, without header, are FormatMagic followed by
+			// the current version
+			magic := uint64(binary.LittleEndian.Uint32(rlwe.FormatMagic[:])) | uint64(rlwe.FormatVersion)<<32
+			scale := math.Float64frombits(math.Float64bits(testctx.params.DefaultScale())&^0xffffffffff | magic)
+
+			ciphertextWant := NewCiphertextRandom(testctx.prng, testctx.params, 1, testctx.params.MaxLevel(), scale)
+			dataCt, err := ciphertextWant.Ciphertext.MarshalBinary()
+			require.NoError(t, err)
+
+			data := make([]byte, 8, 8+len(dataCt))
+			binary.LittleEndian.PutUint64(data, math.Float64bits(scale))
+			data = append(data, dataCt[rlwe.FormatHeaderLen:]...)
+			require.Equal(t, rlwe.FormatMagic[:], data[:len(rlwe.FormatMagic)])
+
+			ciphertextTest := new(Ciphertext)
+			require.NoError(t, ciphertextTest.UnmarshalBinary(data))
+			require.Equal(t, ciphertextWant.Scale, ciphertextTest.Scale)
+			for i := range ciphertextWant.Value {
+				require.True(t, testctx.ringQ.EqualLvl(ciphertextWant.Level(), ciphertextWant.Value[i], ciphertextTest.Value[i]))
+			}
+
+			// Data that is neither of the version 0 nor of a supported version is rejected
+			require.Error(t, ciphertextTest.UnmarshalBinary(data[:len(data)-1]))
+		})
+
+		t.Run(GetTestName(testctx.params, "CBOR"), func(t *testing.T) {
+
+			data, err := testctx.params.MarshalCBOR()
+			require.NoError(t, err)
+			var paramsTest Parameters
+			require.NoError(t, paramsTest.UnmarshalCBOR(data))
+			require.True(t, testctx.params.Equals(paramsTest))
+
+			ciphertextWant := NewCiphertextRandom(testctx.prng, testctx.params, 2, testctx.params.MaxLevel(), testctx.params.DefaultScale())
+			data, err = ciphertextWant.MarshalCBOR()
+			require.NoError(t, err)
+			ciphertextTest := new(Ciphertext)
+			require.NoError(t, ciphertextTest.UnmarshalCBOR(data))
+
+			require.Equal(t, ciphertextWant.Degree(), ciphertextTest.Degree())
+			require.Equal(t, ciphertextWant.Scale, ciphertextTest.Scale)
+
+			for i := range ciphertextWant.Value {
+				require.True(t, testctx.ringQ.EqualLvl(ciphertextWant.Level(), ciphertextWant.Value[i], ciphertextTest.Value[i]))
+			}
+		})
+
 		t.Run(GetTestName(testctx.params, "Minimal"), func(t *testing.T) {
 
 			ciphertext := NewCiphertextRandom(testctx.prng, testctx.params, 0, testctx.params.MaxLevel(), testctx.params.DefaultScale())
@@ -1509,5 +3099,402 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 			require.Equal(t, ciphertext.Scale, testctx.params.DefaultScale())
 			require.Equal(t, len(ciphertext.Value), 1)
 		})
+
+		t.Run(GetTestName(testctx.params, "Compressed"), func(t *testing.T) {
+
+			compressor := NewCiphertextCompressor(testctx.params)
+
+			values, _, ciphertext := newTestVectors(testctx, testctx.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			// The slots have a magnitude of at most sqrt(2) < 2
+			level := compressor.MinLevel(ciphertext.Scale, 1)
+
+			marshalledCiphertext, err := compressor.MarshalBinary(ciphertext, 1)
+			require.NoError(t, err)
+
+			if level < ciphertext.Level() {
+				data, err := ciphertext.MarshalBinary()
+				require.NoError(t, err)
+				require.Less(t, len(marshalledCiphertext), len(data))
+			}
+
+			ciphertextTest := new(Ciphertext)
+			require.NoError(t, ciphertextTest.UnmarshalBinary(marshalledCiphertext))
+			require.Equal(t, utils.MinInt(level, ciphertext.Level()), ciphertextTest.Level())
+			require.Equal(t, ciphertext.Scale, ciphertextTest.Scale)
+
+			verifyTestVectors(testctx.params, testctx.encoder, testctx.decryptor, values, ciphertextTest, testctx.params.LogSlots(), 0, t)
+			verifyTestVectors(testctx.params, testctx.encoder, testctx.decryptor, values, compressor.ModSwitchNew(ciphertext, level), testctx.params.LogSlots(), 0, t)
+		})
+
+		t.Run(GetTestName(testctx.params, "CompressedLossy"), func(t *testing.T) {
+
+			compressor := NewCiphertextCompressor(testctx.params)
+
+			values, _, ciphertext := newTestVectors(testctx, testctx.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			compressed, err := compressor.MarshalBinary(ciphertext, 1)
+			require.NoError(t, err)
+
+			logPrecision := 8.0
+
+			lossy, err := compressor.MarshalBinaryLossy(ciphertext, 1, logPrecision)
+			require.NoError(t, err)
+			require.Less(t, len(lossy), len(compressed))
+
+			ciphertextTest := new(Ciphertext)
+			require.Error(t, compressor.UnmarshalBinaryLossy(lossy[:len(lossy)-1], ciphertextTest))
+
+			// The headers of a degree, a level or a number of bits that MarshalBinaryLossy does not write are rejected
+			for _, header := range [][2]int{{8, 0}, {8, 2}, {8, 255}, {9, testctx.params.MaxLevel() + 1}, {10, 0}, {10, testctx.params.LogQ() + 1}} {
+				invalid := append([]byte{}, lossy...)
+				invalid[header[0]] = uint8(header[1])
+				if header[0] == 10 {
+					binary.LittleEndian.PutUint16(invalid[10:], uint16(header[1]))
+				}
+				require.Error(t, compressor.UnmarshalBinaryLossy(invalid, ciphertextTest))
+			}
+
+			require.NoError(t, compressor.UnmarshalBinaryLossy(lossy, ciphertextTest))
+			require.Equal(t, utils.MinInt(compressor.MinLevel(ciphertext.Scale, 1), ciphertext.Level()), ciphertextTest.Level())
+			require.Equal(t, ciphertext.Scale, ciphertextTest.Scale)
+
+			have := testctx.encoder.Decode(testctx.decryptor.DecryptNew(ciphertextTest), testctx.params.LogSlots())
+			for i := range values {
+				require.LessOrEqual(t, cmplx.Abs(have[i]-values[i]), math.Exp2(-logPrecision))
+			}
+
+			// With enough bits, the serialization is lossless
+			level := ciphertextTest.Level()
+			require.Equal(t, testctx.params.LogQLvl(level), compressor.LossyBits(level, ciphertext.Scale, 1000))
+			lossless, err := compressor.MarshalBinaryLossy(ciphertext, 1, 1000)
+			require.NoError(t, err)
+			require.NoError(t, compressor.UnmarshalBinaryLossy(lossless, ciphertextTest))
+			verifyTestVectors(testctx.params, testctx.encoder, testctx.decryptor, values, ciphertextTest, testctx.params.LogSlots(), 0, t)
+		})
+	})
+}
+
+// goldenEncodingFile stores the SHA-256 digests of the plaintexts encoded with WithDeterministicEncoding and of their
+// decoding, which must be identical on all the architectures.
+const goldenEncodingFile = "testdata/deterministic_encoding.json"
+
+func TestEncoderDeterministicGolden(t *testing.T) {
+
+	data, err := ioutil.ReadFile(goldenEncodingFile)
+	require.NoError(t, err)
+
+	golden := map[string]map[string]string{}
+	require.NoError(t, json.Unmarshal(data, &golden))
+
+	for _, paramsLiteral := range []ParametersLiteral{PN12QP109, PN12QP109CI} {
+
+		params, err := NewParametersFromLiteral(paramsLiteral)
+		require.NoError(t, err)
+
+		name := fmt.Sprintf("RingType=%s/logN=%d/logQP=%d/LogSlots=%d", params.RingType(), params.LogN(), params.LogQP(), params.LogSlots())
+
+		t.Run("Encoder/Deterministic/Golden/"+name, func(t *testing.T) {
+
+			encoder := NewEncoder(params, WithDeterministicEncoding())
+
+			// The values are correctly rounded on all the architectures
+			values := make([]complex128, params.Slots())
+			for i := range values {
+				values[i] = complex(float64(i%7)/7-0.5, float64(i%11)/11-0.5)
+				if params.RingType() == ring.ConjugateInvariant {
+					values[i] = complex(real(values[i]), 0)
+				}
+			}
+
+			plaintext := encoder.EncodeNew(values, params.MaxLevel(), params.DefaultScale(), params.LogSlots())
+
+			data, err := plaintext.Value.MarshalBinary()
+			require.NoError(t, err)
+			digestPlaintext := sha256.Sum256(data)
+
+			hash := sha256.New()
+			for _, v := range encoder.Decode(plaintext, params.LogSlots()) {
+				require.NoError(t, binary.Write(hash, binary.LittleEndian, v))
+			}
+
+			digests := map[string]string{
+				"plaintext": hex.EncodeToString(digestPlaintext[:]),
+				"values":    hex.EncodeToString(hash.Sum(nil)),
+			}
+
+			if *flagUpdateGolden {
+				golden[name] = digests
+			}
+
+			require.Equal(t, golden[name], digests)
+		})
+	}
+
+	if *flagUpdateGolden {
+		data, err := json.MarshalIndent(golden, "", "\t")
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(goldenEncodingFile, append(data, '\n'), 0644))
+	}
+}
+
+func TestGenParams(t *testing.T) {
+
+	t.Run("GenParams/Depth=3", func(t *testing.T) {
+
+		pl, err := GenParams(3, 40, 20, rlwe.Classic128)
+		require.NoError(t, err)
+
+		params, err := NewParametersFromLiteral(pl)
+		require.NoError(t, err)
+
+		require.Equal(t, 3, params.MaxLevel())
+		require.Equal(t, params.LogN()-1, params.LogSlots())
+
+		maxLogQP, err := rlwe.MaxLogQP(params.LogN(), rlwe.Classic128)
+		require.NoError(t, err)
+		require.LessOrEqual(t, params.LogQP(), maxLogQP)
+
+		tc, err := genTestParams(params, 0)
+		require.NoError(t, err)
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := 0; i < 3; i++ {
+			tc.evaluator.MulRelin(ciphertext, ciphertext, ciphertext)
+			require.NoError(t, tc.evaluator.Rescale(ciphertext, params.DefaultScale(), ciphertext))
+			for j := range values {
+				values[j] *= values[j]
+			}
+		}
+
+		require.Equal(t, 0, ciphertext.Level())
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, params.LogSlots(), 0, t)
+	})
+
+	t.Run("GenParams/ConjugateInvariant/Depth=2", func(t *testing.T) {
+
+		pl, err := GenParamsConjugateInvariant(2, 40, 20, rlwe.Classic128)
+		require.NoError(t, err)
+
+		params, err := NewParametersFromLiteral(pl)
+		require.NoError(t, err)
+
+		require.Equal(t, ring.ConjugateInvariant, params.RingType())
+		require.Equal(t, params.LogN(), params.LogSlots())
+
+		tc, err := genTestParams(params, 0)
+		require.NoError(t, err)
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		for i := 0; i < 2; i++ {
+			tc.evaluator.MulRelin(ciphertext, ciphertext, ciphertext)
+			require.NoError(t, tc.evaluator.Rescale(ciphertext, params.DefaultScale(), ciphertext))
+			for j := range values {
+				values[j] *= values[j]
+			}
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, params.LogSlots(), 0, t)
+	})
+
+	t.Run("GenParams/Errors", func(t *testing.T) {
+
+		_, err := GenParams(-1, 40, 20, rlwe.Classic128)
+		require.Error(t, err)
+
+		// The first modulus would exceed 60 bits
+		_, err = GenParams(3, 50, 20, rlwe.Classic128)
+		require.Error(t, err)
+
+		// No supported ring degree is large enough
+		_, err = GenParams(30, 50, 10, rlwe.Classic256)
+		require.Error(t, err)
+	})
+}
+
+func TestSorting(t *testing.T) {
+
+	t.Run("Sorting/ComparisonParameters", func(t *testing.T) {
+
+		cp, err := GenComparisonParameters(7, 4, 10)
+		require.NoError(t, err)
+		require.Equal(t, ComparisonParameters{Degree: 7, CoarseIterations: 1, FineIterations: 3}, cp)
+		require.Equal(t, 12, cp.Depth())
+		require.LessOrEqual(t, cp.MaxError(4), math.Exp2(-10))
+
+		_, err = GenComparisonParameters(4, 4, 10)
+		require.Error(t, err)
+
+		_, err = NewComparator(Parameters{}, nil, ComparisonParameters{Degree: 7})
+		require.Error(t, err)
+	})
+
+	// The networks are deep, so that the tests use parameters with many small moduli, which are not secure
+	logQ := []int{55}
+	for i := 0; i < 24; i++ {
+		logQ = append(logQ, 40)
+	}
+
+	params, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:         10,
+		LogSlots:     3,
+		LogQ:         logQ,
+		LogP:         []int{61},
+		DefaultScale: 1 << 40,
+		Sigma:        rlwe.DefaultSigma,
+	})
+	require.NoError(t, err)
+
+	tc, err := genTestParams(params, 0)
+	require.NoError(t, err)
+
+	// The distinct values are at least 2^-2 apart
+	cp, err := GenComparisonParameters(7, 2, 6)
+	require.NoError(t, err)
+
+	newSorter := func(rotations []int) *Sorter {
+		rotKey := tc.kgen.GenRotationKeysForRotations(rotations, false, tc.sk)
+		cmp, err := NewComparator(params, NewEvaluator(params, rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey}), cp)
+		require.NoError(t, err)
+		return NewSorter(params, tc.encoder, cmp)
+	}
+
+	newValues := func(n int) []complex128 {
+		values := make([]complex128, n)
+		for i, j := range rand.Perm(n) {
+			values[i] = complex(float64(j%4)/4+0.125, 0)
+		}
+		return values
+	}
+
+	t.Run("Sorting/Comparator", func(t *testing.T) {
+
+		cmp := newSorter(nil).cmp
+
+		values0, values1 := newValues(8), newValues(8)
+
+		ct0 := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values0, params.MaxLevel(), params.DefaultScale(), 3))
+		ct1 := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values1, params.MaxLevel(), params.DefaultScale(), 3))
+
+		ctMax, err := cmp.MaxNew(ct0, ct1)
+		require.NoError(t, err)
+		require.Equal(t, params.MaxLevel()-cmp.Depth()-1, ctMax.Level())
+		require.Equal(t, params.DefaultScale(), ctMax.Scale)
+
+		ctMin, err := cmp.MinNew(ct0, ct1)
+		require.NoError(t, err)
+
+		ctSign, err := cmp.SignNew(tc.evaluator.SubNew(ct0, ct1))
+		require.NoError(t, err)
+		require.Equal(t, params.MaxLevel()-cmp.Depth(), ctSign.Level())
+
+		have := [3][]complex128{
+			tc.encoder.Decode(tc.decryptor.DecryptNew(ctMax), 3),
+			tc.encoder.Decode(tc.decryptor.DecryptNew(ctMin), 3),
+			tc.encoder.Decode(tc.decryptor.DecryptNew(ctSign), 3),
+		}
+
+		for i := range values0 {
+			a, b := real(values0[i]), real(values1[i])
+			require.InDelta(t, math.Max(a, b), real(have[0][i]), 0.01)
+			require.InDelta(t, math.Min(a, b), real(have[1][i]), 0.01)
+			if a != b {
+				require.InDelta(t, math.Copysign(1, a-b), real(have[2][i]), 0.1)
+			}
+		}
+
+		_, err = cmp.MaxNew(tc.evaluator.DropLevelNew(ct0, params.MaxLevel()-cmp.Depth()), ct1)
+		require.Error(t, err)
 	})
+
+	for _, logSlots := range []int{1, 3} {
+
+		t.Run(fmt.Sprintf("Sorting/Sort/LogSlots=%d", logSlots), func(t *testing.T) {
+
+			s := newSorter(params.RotationsForSort(4, logSlots))
+
+			values := newValues(4)
+
+			v := EncryptVectorNew(tc.encoder, tc.encryptorSk, values, params.MaxLevel(), params.DefaultScale(), logSlots)
+
+			for _, descending := range []bool{false, true} {
+
+				vOut, err := s.SortNew(v, descending)
+				require.NoError(t, err)
+				require.Equal(t, params.MaxLevel()-s.SortDepth(4), vOut.Level())
+
+				want := make([]float64, len(values))
+				for i := range values {
+					want[i] = real(values[i])
+				}
+				sort.Float64s(want)
+				if descending {
+					sort.Sort(sort.Reverse(sort.Float64Slice(want)))
+				}
+
+				have := DecryptVector(tc.encoder, tc.decryptor, vOut)
+				for i := range want {
+					require.InDelta(t, want[i], real(have[i]), 0.01)
+				}
+			}
+
+			_, err := s.SortNew(&Vector{Value: v.Value, Len: 3, LogSlots: logSlots}, false)
+			require.Error(t, err)
+		})
+
+		t.Run(fmt.Sprintf("Sorting/TopK/LogSlots=%d", logSlots), func(t *testing.T) {
+
+			values := newValues(4)
+
+			want := make([]float64, len(values))
+			for i := range values {
+				want[i] = real(values[i])
+			}
+			sort.Sort(sort.Reverse(sort.Float64Slice(want)))
+
+			v := EncryptVectorNew(tc.encoder, tc.encryptorSk, values, params.MaxLevel(), params.DefaultScale(), logSlots)
+
+			for _, k := range []int{1, 2} {
+
+				s := newSorter(params.RotationsForTopK(4, k, logSlots))
+
+				ctOut, err := s.TopKNew(v, k)
+				require.NoError(t, err)
+				require.Equal(t, params.MaxLevel()-s.TopKDepth(4, k), ctOut.Level())
+
+				have := tc.encoder.Decode(tc.decryptor.DecryptNew(ctOut), logSlots)
+				for i := range have {
+					if i < k {
+						require.InDelta(t, want[i], real(have[i]), 0.01)
+					} else {
+						require.InDelta(t, 0, real(have[i]), 0.01)
+					}
+				}
+			}
+
+			s := newSorter(nil)
+
+			_, err := s.TopKNew(v, 3)
+			require.Error(t, err)
+		})
+	}
+}
+
+// testMetrics is an rlwe.Metrics recording the operations and noise budgets, for sequential use.
+type testMetrics struct {
+	operations []string
+	budgets    []float64
+}
+
+func (m *testMetrics) ObserveOperation(component, operation string, duration time.Duration) {
+	m.operations = append(m.operations, component+"/"+operation)
+}
+
+func (m *testMetrics) ObserveKeySize(component, kind string, size int) {}
+
+func (m *testMetrics) ObserveNoiseBudget(component, operation string, bits float64) {
+	m.budgets = append(m.budgets, bits)
 }
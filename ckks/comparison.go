@@ -0,0 +1,315 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+)
+
+// ComparisonParameters are the parameters of the approximation of the sign function evaluated by a Comparator,
+// following Cheon et al., "Efficient Homomorphic Comparison Methods with Optimal Complexity"
+// (https://eprint.iacr.org/2019/1234). The sign of x in [-1, 1] is approximated by the composition of
+// CoarseIterations iterations of the polynomial g_n, which quickly moves the small values away from 0, followed by
+// FineIterations iterations of the polynomial f_n, which converges to the sign, where Degree = 2n+1.
+type ComparisonParameters struct {
+	// Degree is the degree of the polynomials f_n and g_n, in {3, 5, 7, 9}.
+	Degree int
+	// CoarseIterations is the number of iterations of g_n.
+	CoarseIterations int
+	// FineIterations is the number of iterations of f_n.
+	FineIterations int
+}
+
+// comparisonCoarseCoeffs are the coefficients, scaled by 2^10, of the polynomials g_n of Cheon et al.
+var comparisonCoarseCoeffs = map[int][]float64{
+	3: {0, 2126, 0, -1359},
+	5: {0, 3334, 0, -6108, 0, 3796},
+	7: {0, 4589, 0, -16577, 0, 25614, 0, -12860},
+	9: {0, 5850, 0, -34974, 0, 97015, 0, -113492, 0, 46623},
+}
+
+// coarsePolynomial returns the coefficients of g_n.
+func (cp ComparisonParameters) coarsePolynomial() (coeffs []float64) {
+	coeffs = make([]float64, cp.Degree+1)
+	for i, c := range comparisonCoarseCoeffs[cp.Degree] {
+		coeffs[i] = c / 1024
+	}
+	return
+}
+
+// finePolynomial returns the coefficients of f_n(x) = sum_{i=0}^{n} binomial(2i, i)/4^i * x * (1-x^2)^i.
+func (cp ComparisonParameters) finePolynomial() (coeffs []float64) {
+
+	n := cp.Degree >> 1
+
+	coeffs = make([]float64, cp.Degree+1)
+
+	for i := 0; i <= n; i++ {
+
+		a := 1.0
+		for j := 0; j < i; j++ {
+			a *= float64(2*i-j) / float64(j+1) / 4
+		}
+
+		// x * (1-x^2)^i = sum_{k=0}^{i} (-1)^k * binomial(i, k) * x^(2k+1)
+		b := a
+		for k := 0; k <= i; k++ {
+			coeffs[2*k+1] += b
+			b *= -float64(i-k) / float64(k+1)
+		}
+	}
+
+	return
+}
+
+// polynomials returns the coefficients of the polynomials composed by the approximation of the sign function.
+func (cp ComparisonParameters) polynomials() (polys [][]float64) {
+
+	polys = make([][]float64, 0, cp.CoarseIterations+cp.FineIterations)
+
+	coarse, fine := cp.coarsePolynomial(), cp.finePolynomial()
+
+	for i := 0; i < cp.CoarseIterations; i++ {
+		polys = append(polys, coarse)
+	}
+
+	for i := 0; i < cp.FineIterations; i++ {
+		polys = append(polys, fine)
+	}
+
+	return
+}
+
+// Depth returns the number of levels consumed by the approximation of the sign function.
+func (cp ComparisonParameters) Depth() int {
+	return (cp.CoarseIterations + cp.FineIterations) * int(math.Ceil(math.Log2(float64(cp.Degree+1))))
+}
+
+// MaxError returns the maximum absolute error of the approximation of the sign function over the values x
+// with 2^-logGap <= |x| <= 1, excluding the error introduced by the homomorphic evaluation.
+// It is computed on a grid of points and is therefore an estimate.
+func (cp ComparisonParameters) MaxError(logGap int) (maxErr float64) {
+
+	polys := cp.polynomials()
+
+	// The approximation is odd, so that it is sufficient to consider the positive values
+	gap := math.Exp2(-float64(logGap))
+	points := 64 * (logGap + 1)
+
+	for i := 0; i <= points; i++ {
+
+		x := gap * math.Pow(1/gap, float64(i)/float64(points))
+
+		for _, coeffs := range polys {
+			x = evaluateMonomials(coeffs, x)
+		}
+
+		maxErr = math.Max(maxErr, math.Abs(1-x))
+	}
+
+	return
+}
+
+// evaluateMonomials evaluates the polynomial of the given coefficients in the standard basis on x.
+func evaluateMonomials(coeffs []float64, x float64) (y float64) {
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = y*x + coeffs[i]
+	}
+	return
+}
+
+// GenComparisonParameters returns the ComparisonParameters of the given degree with the smallest depth such that
+// the sign of the values x with 2^-logGap <= |x| <= 1 is approximated with an error smaller than 2^-logPrecision.
+// Among the parameters of the smallest depth, the ones with the most fine iterations are returned.
+// For example, with the degree 7, (logGap, logPrecision) = (4, 10), (8, 10) and (8, 20) require 4, 6 and 6
+// iterations, i.e. a depth of 12, 18 and 18. It returns an error if the degree is not supported or if more than
+// 64 iterations are required.
+func GenComparisonParameters(degree, logGap, logPrecision int) (cp ComparisonParameters, err error) {
+
+	if _, ok := comparisonCoarseCoeffs[degree]; !ok {
+		return cp, fmt.Errorf("cannot GenComparisonParameters: degree %d is not in {3, 5, 7, 9}", degree)
+	}
+
+	if logGap < 0 || logPrecision < 0 {
+		return cp, fmt.Errorf("cannot GenComparisonParameters: logGap and logPrecision must be non-negative")
+	}
+
+	maxErr := math.Exp2(-float64(logPrecision))
+
+	for iterations := 1; iterations <= 64; iterations++ {
+		for fine := iterations; fine >= 0; fine-- {
+			cp = ComparisonParameters{Degree: degree, CoarseIterations: iterations - fine, FineIterations: fine}
+			if cp.MaxError(logGap) <= maxErr {
+				return cp, nil
+			}
+		}
+	}
+
+	return ComparisonParameters{}, fmt.Errorf("cannot GenComparisonParameters: more than 64 iterations are required for logGap = %d and logPrecision = %d", logGap, logPrecision)
+}
+
+// Comparator evaluates the comparison of the slots of ciphertexts with an approximation of the sign function.
+// The compared values must be in [0, 1], or more generally their differences must be in [-1, 1]. The result is
+// exact up to the error of the approximation, given by ComparisonParameters.MaxError, if the compared values are at
+// least 2^-logGap apart. Closer values are compared approximately, e.g. the maximum of two equal values is their
+// value and the maximum of two values closer than 2^-logGap is a convex combination of them.
+type Comparator struct {
+	params Parameters
+	eval   Evaluator
+	cp     ComparisonParameters
+	sign   []*Polynomial
+	step   *Polynomial
+}
+
+// NewComparator creates a Comparator evaluating the ComparisonParameters with eval, which must have a
+// relinearization key. It returns an error if the ComparisonParameters are invalid.
+func NewComparator(params Parameters, eval Evaluator, cp ComparisonParameters) (*Comparator, error) {
+
+	if _, ok := comparisonCoarseCoeffs[cp.Degree]; !ok {
+		return nil, fmt.Errorf("cannot NewComparator: degree %d is not in {3, 5, 7, 9}", cp.Degree)
+	}
+
+	if cp.CoarseIterations < 0 || cp.FineIterations < 0 || cp.CoarseIterations+cp.FineIterations == 0 {
+		return nil, fmt.Errorf("cannot NewComparator: invalid number of iterations (%d, %d)", cp.CoarseIterations, cp.FineIterations)
+	}
+
+	cmp := &Comparator{params: params, eval: eval, cp: cp}
+
+	polys := cp.polynomials()
+
+	cmp.sign = make([]*Polynomial, len(polys))
+	for i, coeffs := range polys {
+		cmp.sign[i] = newRealPoly(coeffs)
+	}
+
+	// step(x) = (1 + sign(x))/2 is evaluated as the composition of the sign polynomials with the last one halved
+	last := polys[len(polys)-1]
+	halved := make([]float64, len(last))
+	for i := range halved {
+		halved[i] = last[i] / 2
+	}
+	halved[0] += 0.5
+	cmp.step = newRealPoly(halved)
+
+	return cmp, nil
+}
+
+// newRealPoly returns the Polynomial in the standard basis of the given real coefficients.
+func newRealPoly(coeffs []float64) *Polynomial {
+	c := make([]complex128, len(coeffs))
+	for i := range coeffs {
+		c[i] = complex(coeffs[i], 0)
+	}
+	return NewPoly(c)
+}
+
+// Parameters returns the ComparisonParameters of the Comparator.
+func (cmp *Comparator) Parameters() ComparisonParameters {
+	return cmp.cp
+}
+
+// Depth returns the number of levels consumed by SignNew. MaxNew and MinNew consume one more level.
+func (cmp *Comparator) Depth() int {
+	return cmp.cp.Depth()
+}
+
+// evaluate composes the polynomials approximating the sign, or the step if step is true, on ct and returns the
+// result in a new Ciphertext at the scale targetScale and at the level ct.Level() - Depth().
+func (cmp *Comparator) evaluate(ct *Ciphertext, step bool, targetScale float64) (ctOut *Ciphertext, err error) {
+
+	if ct.Level() < cmp.Depth() {
+		return nil, fmt.Errorf("%d levels < %d depth", ct.Level(), cmp.Depth())
+	}
+
+	ctOut = ct
+
+	for i, pol := range cmp.sign {
+
+		scale := ct.Scale
+
+		if i == len(cmp.sign)-1 {
+			scale = targetScale
+			if step {
+				pol = cmp.step
+			}
+		}
+
+		level := ctOut.Level()
+
+		if ctOut, err = cmp.eval.EvaluatePolyScaleInvariant(ctOut, pol, scale, level, level-pol.Depth()); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctOut, nil
+}
+
+// SignNew evaluates the approximation of the sign function on the slots of ct, which must be in [-1, 1], and
+// returns the result in a new Ciphertext of the scale of ct at the level ct.Level() - Depth().
+// It returns an error if ct does not have enough levels.
+func (cmp *Comparator) SignNew(ct *Ciphertext) (ctOut *Ciphertext, err error) {
+	if ctOut, err = cmp.evaluate(ct, false, ct.Scale); err != nil {
+		return nil, fmt.Errorf("cannot SignNew: %w", err)
+	}
+	return
+}
+
+// differenceTimesStep returns (ct0 - ct1) * step(ct0 - ct1) at the scale of ct0, which is the difference between
+// the maximum of ct0 and ct1 and ct1.
+func (cmp *Comparator) differenceTimesStep(ct0, ct1 *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	if ct0.Scale != ct1.Scale {
+		return nil, fmt.Errorf("ciphertexts have different scales %f and %f", ct0.Scale, ct1.Scale)
+	}
+
+	diff := cmp.eval.SubNew(ct0, ct1)
+
+	// The step is evaluated at the scale of the modulus divided by the rescaling, so that the product lands exactly on
+	// the scale of the inputs
+	level := diff.Level() - cmp.Depth()
+	if level < 1 {
+		return nil, fmt.Errorf("%d levels < %d depth", diff.Level(), cmp.Depth()+1)
+	}
+
+	step, err := cmp.evaluate(diff, true, cmp.params.QiFloat64(level))
+	if err != nil {
+		return nil, err
+	}
+
+	ctOut = cmp.eval.MulRelinNew(diff, step)
+
+	if err = cmp.eval.Rescale(ctOut, ct0.Scale, ctOut); err != nil {
+		return nil, err
+	}
+
+	return ctOut, nil
+}
+
+// MaxNew returns the slot-wise maximum of ct0 and ct1, which must have the same scale and values in [0, 1], in a new
+// Ciphertext of their scale at the level min(ct0.Level(), ct1.Level()) - Depth() - 1.
+// It returns an error if the ciphertexts do not have enough levels.
+func (cmp *Comparator) MaxNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	// max(a, b) = b + (a-b) * step(a-b)
+	if ctOut, err = cmp.differenceTimesStep(ct0, ct1); err != nil {
+		return nil, fmt.Errorf("cannot MaxNew: %w", err)
+	}
+
+	cmp.eval.Add(ctOut, ct1, ctOut)
+
+	return
+}
+
+// MinNew returns the slot-wise minimum of ct0 and ct1, which must have the same scale and values in [0, 1], in a new
+// Ciphertext of their scale at the level min(ct0.Level(), ct1.Level()) - Depth() - 1.
+// It returns an error if the ciphertexts do not have enough levels.
+func (cmp *Comparator) MinNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	// min(a, b) = a - (a-b) * step(a-b)
+	if ctOut, err = cmp.differenceTimesStep(ct0, ct1); err != nil {
+		return nil, fmt.Errorf("cannot MinNew: %w", err)
+	}
+
+	cmp.eval.Sub(ct0, ctOut, ctOut)
+
+	return
+}
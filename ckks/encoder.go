@@ -28,7 +28,7 @@ var pi = "3.14159265358979323846264338327950288419716939937510582097494459230781
 //               Other operations, like addition or constant multiplication, behave as usual.
 //
 //     - Slots: The coefficients are first subjected to a special Fourier transform before being embedded in the plaintext by using Coeffs encoding.
-//              This encoding can embed []complex128 and []float64 slices of size at most N/2 (N being the ring degree) and leverages the convolution
+//              This encoding can embed []complex128, []complex64, []float64 and []float32 slices of size at most N/2 (N being the ring degree) and leverages the convolution
 //              property of the DFT to preserve point-wise complex multiplication in the plaintext domain, i.e. a ciphertext multiplication will result
 //              in an element-wise multiplication in the plaintext domain. It also enables cyclic rotations on plaintext slots. Other operations, like
 //              constant multiplication, behave as usual. It is considered the default encoding method for CKKS.
@@ -52,6 +52,10 @@ type Encoder interface {
 	DecodeSlots(plaintext *Plaintext, logSlots int) (res []complex128)
 	DecodePublic(plaintext *Plaintext, logSlots int, sigma float64) []complex128
 	DecodeSlotsPublic(plaintext *Plaintext, logSlots int, sigma float64) []complex128
+	DecodeComplex64(plaintext *Plaintext, logSlots int) (res []complex64)
+	DecodeComplex64Public(plaintext *Plaintext, logSlots int, sigma float64) (res []complex64)
+	DecodeFloat32(plaintext *Plaintext, logSlots int) (res []float32)
+	DecodeFloat32Public(plaintext *Plaintext, logSlots int, sigma float64) (res []float32)
 
 	// Coeffs Encoding
 	EncodeCoeffs(values []float64, plaintext *Plaintext)
@@ -80,9 +84,10 @@ type encoder struct {
 
 type encoderComplex128 struct {
 	encoder
-	values      []complex128
-	valuesFloat []float64
-	roots       []complex128
+	values        []complex128
+	valuesFloat   []float64
+	roots         []complex128
+	deterministic bool
 }
 
 // ShallowCopy creates a shallow copy of encoder in which all the read-only data-structures are
@@ -139,24 +144,36 @@ func newEncoder(params Parameters) encoder {
 }
 
 // NewEncoder creates a new Encoder that is used to encode a slice of complex values of size at most N/2 (the number of slots) on a Plaintext.
-func NewEncoder(params Parameters) Encoder {
+// See WithDeterministicEncoding for the options.
+func NewEncoder(params Parameters, options ...EncoderOption) Encoder {
+
+	opts := new(encoderOptions)
+	for _, option := range options {
+		option(opts)
+	}
 
 	ecd := newEncoder(params)
 
-	var angle float64
-	roots := make([]complex128, ecd.m+1)
-	for i := 0; i < ecd.m; i++ {
-		angle = 2 * 3.141592653589793 * float64(i) / float64(ecd.m)
+	var roots []complex128
+	if opts.deterministic {
+		roots = genRootsDeterministic(ecd.m)
+	} else {
+		var angle float64
+		roots = make([]complex128, ecd.m+1)
+		for i := 0; i < ecd.m; i++ {
+			angle = 2 * 3.141592653589793 * float64(i) / float64(ecd.m)
 
-		roots[i] = complex(math.Cos(angle), math.Sin(angle))
+			roots[i] = complex(math.Cos(angle), math.Sin(angle))
+		}
+		roots[ecd.m] = roots[0]
 	}
-	roots[ecd.m] = roots[0]
 
 	return &encoderComplex128{
-		encoder:     ecd,
-		roots:       roots,
-		values:      make([]complex128, ecd.m>>2),
-		valuesFloat: make([]float64, ecd.m>>1),
+		encoder:       ecd,
+		roots:         roots,
+		values:        make([]complex128, ecd.m>>2),
+		valuesFloat:   make([]float64, ecd.m>>1),
+		deterministic: opts.deterministic,
 	}
 }
 
@@ -164,7 +181,7 @@ func NewEncoder(params Parameters) Encoder {
 // This method is identical to "EncodeSlots".
 // Encoding is done at the level and scale of the plaintext.
 // User must ensure that 1 <= len(values) <= 2^logSlots < 2^logN.
-// values.(type) can be either []complex128 of []float64.
+// values.(type) can be either []complex128, []complex64, []float64 or []float32.
 // The imaginary part of []complex128 will be discarded if ringType == ring.ConjugateInvariant.
 // Returned plaintext is always in the NTT domain.
 func (ecd *encoderComplex128) Encode(values interface{}, plaintext *Plaintext, logSlots int) {
@@ -176,7 +193,7 @@ func (ecd *encoderComplex128) Encode(values interface{}, plaintext *Plaintext, l
 // This method is identical to "EncodeSlotsNew".
 // Encoding is done at the provided level and with the provided scale.
 // User must ensure that 1 <= len(values) <= 2^logSlots < 2^logN.
-// values.(type) can be either []complex128 of []float64.
+// values.(type) can be either []complex128, []complex64, []float64 or []float32.
 // The imaginary part of []complex128 will be discarded if ringType == ring.ConjugateInvariant.
 // Returned plaintext is always in the NTT domain.
 func (ecd *encoderComplex128) EncodeNew(values interface{}, level int, scale float64, logSlots int) (plaintext *Plaintext) {
@@ -188,7 +205,7 @@ func (ecd *encoderComplex128) EncodeNew(values interface{}, level int, scale flo
 // EncodeSlots encodes a set of values on the target plaintext.
 // Encoding is done at the level and scale of the plaintext.
 // User must ensure that 1 <= len(values) <= 2^logSlots < 2^logN.
-// values.(type) can be either []complex128 of []float64.
+// values.(type) can be either []complex128, []complex64, []float64 or []float32.
 // The imaginary part of []complex128 will be discarded if ringType == ring.ConjugateInvariant.
 // Returned plaintext is always in the NTT domain.
 func (ecd *encoderComplex128) EncodeSlots(values interface{}, plaintext *Plaintext, logSlots int) {
@@ -198,7 +215,7 @@ func (ecd *encoderComplex128) EncodeSlots(values interface{}, plaintext *Plainte
 // EncodeSlotsNew encodes a set of values on a new plaintext.
 // Encoding is done at the provided level and with the provided scale.
 // User must ensure that 1 <= len(values) <= 2^logSlots < 2^logN.
-// values.(type) can be either []complex128 of []float64.
+// values.(type) can be either []complex128, []complex64, []float64 or []float32.
 // The imaginary part of []complex128 will be discarded if ringType == ring.ConjugateInvariant.
 // Returned plaintext is always in the NTT domain.
 func (ecd *encoderComplex128) EncodeSlotsNew(values interface{}, level int, scale float64, logSlots int) (plaintext *Plaintext) {
@@ -233,6 +250,32 @@ func (ecd *encoderComplex128) DecodeSlotsPublic(plaintext *Plaintext, logSlots i
 	return ecd.decodePublic(plaintext, logSlots, bound)
 }
 
+// DecodeComplex64 decodes the input plaintext on a new slice of complex64, whose values are
+// rounded to the nearest complex64, without allocating an intermediate slice of complex128.
+func (ecd *encoderComplex128) DecodeComplex64(plaintext *Plaintext, logSlots int) (res []complex64) {
+	return ecd.decodeComplex64Public(plaintext, logSlots, 0)
+}
+
+// DecodeComplex64Public decodes the input plaintext on a new slice of complex64, whose values are
+// rounded to the nearest complex64, without allocating an intermediate slice of complex128.
+// Adds, before the decoding step, an error with standard deviation sigma and bound floor(sqrt(2*pi)*sigma).
+func (ecd *encoderComplex128) DecodeComplex64Public(plaintext *Plaintext, logSlots int, bound float64) (res []complex64) {
+	return ecd.decodeComplex64Public(plaintext, logSlots, bound)
+}
+
+// DecodeFloat32 decodes the real part of the input plaintext on a new slice of float32, whose values are
+// rounded to the nearest float32, without allocating an intermediate slice of complex128.
+func (ecd *encoderComplex128) DecodeFloat32(plaintext *Plaintext, logSlots int) (res []float32) {
+	return ecd.decodeFloat32Public(plaintext, logSlots, 0)
+}
+
+// DecodeFloat32Public decodes the real part of the input plaintext on a new slice of float32, whose values are
+// rounded to the nearest float32, without allocating an intermediate slice of complex128.
+// Adds, before the decoding step, an error with standard deviation sigma and bound floor(sqrt(2*pi)*sigma).
+func (ecd *encoderComplex128) DecodeFloat32Public(plaintext *Plaintext, logSlots int, bound float64) (res []float32) {
+	return ecd.decodeFloat32Public(plaintext, logSlots, bound)
+}
+
 // EncodeCoeffs encodes the values on the coefficient of the plaintext polynomial.
 // Encoding is done at the level and scale of the plaintext.
 // User must ensure that 1<= len(values) <= 2^LogN
@@ -280,7 +323,7 @@ func (ecd *encoderComplex128) GetErrSTDCoeffDomain(valuesWant, valuesHave []comp
 	}
 
 	// Runs FFT^-1 with the smallest power of two length that is greater than the input size
-	invfft(ecd.values, 1<<bits.Len64(uint64(len(valuesHave)-1)), ecd.m, ecd.rotGroup, ecd.roots)
+	ecd.invfft(ecd.values, 1<<bits.Len64(uint64(len(valuesHave)-1)))
 
 	for i := range valuesWant {
 		ecd.valuesFloat[2*i] = real(ecd.values[i])
@@ -307,10 +350,11 @@ func (ecd *encoderComplex128) GetErrSTDSlotDomain(valuesWant, valuesHave []compl
 // Encoder can be used concurrently.
 func (ecd *encoderComplex128) ShallowCopy() Encoder {
 	return &encoderComplex128{
-		encoder:     *ecd.encoder.ShallowCopy(),
-		values:      make([]complex128, len(ecd.values)),
-		valuesFloat: make([]float64, len(ecd.valuesFloat)),
-		roots:       ecd.roots,
+		encoder:       *ecd.encoder.ShallowCopy(),
+		values:        make([]complex128, len(ecd.values)),
+		valuesFloat:   make([]float64, len(ecd.valuesFloat)),
+		roots:         ecd.roots,
+		deterministic: ecd.deterministic,
 	}
 }
 
@@ -333,52 +377,44 @@ func (ecd *encoderComplex128) embed(values interface{}, logSlots int, scale floa
 
 	slots := 1 << logSlots
 
-	// First checks the type of input values
+	var length int
+
+	// First checks the type of input values and copies them on the buffer
 	switch values := values.(type) {
 
 	// If complex
 	case []complex128:
 
-		// Checks that the number of values is with the possible range
-		if len(values) > int(ecd.params.RingQ().NthRoot>>1) || len(values) > slots || slots > int(ecd.params.RingQ().NthRoot>>2) {
-			panic("cannot Encode: too many values/slots for the given ring degree")
-		}
+		length = len(values)
+		ecd.checkSlots(length, slots)
 
 		switch ecd.params.RingType() {
-
 		case ring.Standard:
-
-			copy(ecd.values[:len(values)], values)
-
-			for i := len(values); i < slots; i++ {
-				ecd.values[i] = 0
-			}
-
-			invfft(ecd.values, slots, ecd.m, ecd.rotGroup, ecd.roots)
-
-			for i, j := 0, slots; i < slots; i, j = i+1, j+1 {
-				ecd.valuesFloat[i] = real(ecd.values[i])
-				ecd.valuesFloat[j] = imag(ecd.values[i])
-			}
-
+			copy(ecd.values[:length], values)
 		case ring.ConjugateInvariant:
-
 			// Discards the imaginary part
 			for i := range values {
 				ecd.values[i] = complex(real(values[i]), 0)
 			}
+		default:
+			panic("unsuported ringType")
+		}
 
-			for i := len(values); i < slots; i++ {
-				ecd.values[i] = 0
-			}
+	case []complex64:
 
-			invfft(ecd.values, slots, ecd.m, ecd.rotGroup, ecd.roots)
+		length = len(values)
+		ecd.checkSlots(length, slots)
 
-			for i := 0; i < slots; i++ {
-				ecd.valuesFloat[i] = real(ecd.values[i])
+		switch ecd.params.RingType() {
+		case ring.Standard:
+			for i := range values {
+				ecd.values[i] = complex128(values[i])
+			}
+		case ring.ConjugateInvariant:
+			// Discards the imaginary part
+			for i := range values {
+				ecd.values[i] = complex(float64(real(values[i])), 0)
 			}
-
-		// Else panics
 		default:
 			panic("unsuported ringType")
 		}
@@ -386,36 +422,41 @@ func (ecd *encoderComplex128) embed(values interface{}, logSlots int, scale floa
 	// If floats only
 	case []float64:
 
-		if len(values) > int(ecd.params.RingQ().NthRoot>>1) || len(values) > slots || slots > int(ecd.params.RingQ().NthRoot>>2) {
-			panic("cannot Encode: too many values/slots for the given ring degree")
-		}
+		length = len(values)
+		ecd.checkSlots(length, slots)
 
 		for i := range values {
 			ecd.values[i] = complex(values[i], 0)
 		}
 
-		for i := len(values); i < slots; i++ {
-			ecd.values[i] = 0
-		}
-
-		invfft(ecd.values, slots, ecd.m, ecd.rotGroup, ecd.roots)
+	case []float32:
 
-		for i := 0; i < slots; i++ {
-			ecd.valuesFloat[i] = real(ecd.values[i])
-		}
+		length = len(values)
+		ecd.checkSlots(length, slots)
 
-		if ecd.params.RingType() == ring.Standard {
-			for i, j := 0, slots; i < slots; i, j = i+1, j+1 {
-				ecd.valuesFloat[j] = imag(ecd.values[i])
-			}
+		for i := range values {
+			ecd.values[i] = complex(float64(values[i]), 0)
 		}
 
 	default:
-		panic("values must be []complex128 or []float64")
+		panic("values must be []complex128, []complex64, []float64 or []float32")
+	}
+
+	for i := length; i < slots; i++ {
+		ecd.values[i] = 0
+	}
+
+	ecd.invfft(ecd.values, slots)
+
+	for i := 0; i < slots; i++ {
+		ecd.valuesFloat[i] = real(ecd.values[i])
 	}
 
 	switch ecd.params.RingType() {
 	case ring.Standard:
+		for i, j := 0, slots; i < slots; i, j = i+1, j+1 {
+			ecd.valuesFloat[j] = imag(ecd.values[i])
+		}
 		ecd.scaleUp(ecd.valuesFloat[:2*slots], scale, polyOut)
 	case ring.ConjugateInvariant:
 		ecd.scaleUp(ecd.valuesFloat[:slots], scale, polyOut)
@@ -424,6 +465,13 @@ func (ecd *encoderComplex128) embed(values interface{}, logSlots int, scale floa
 	}
 }
 
+// checkSlots checks that the number of values is within the possible range.
+func (ecd *encoderComplex128) checkSlots(length, slots int) {
+	if length > int(ecd.params.RingQ().NthRoot>>1) || length > slots || slots > int(ecd.params.RingQ().NthRoot>>2) {
+		panic("cannot Encode: too many values/slots for the given ring degree")
+	}
+}
+
 func polyToComplexNoCRT(coeffs []uint64, values []complex128, scale float64, logSlots int, isreal bool, ringQ *ring.Ring) {
 
 	slots := 1 << logSlots
@@ -521,6 +569,52 @@ func (ecd *encoderComplex128) plaintextToComplex(level int, scale float64, logSl
 
 func (ecd *encoderComplex128) decodePublic(plaintext *Plaintext, logSlots int, sigma float64) (res []complex128) {
 
+	ecd.decodeToBuffer(plaintext, logSlots, sigma)
+
+	res = make([]complex128, 1<<logSlots)
+
+	for i := range res {
+		res[i] = ecd.values[i]
+	}
+
+	ecd.clearBuffer()
+
+	return
+}
+
+func (ecd *encoderComplex128) decodeComplex64Public(plaintext *Plaintext, logSlots int, sigma float64) (res []complex64) {
+
+	ecd.decodeToBuffer(plaintext, logSlots, sigma)
+
+	res = make([]complex64, 1<<logSlots)
+
+	for i := range res {
+		res[i] = complex64(ecd.values[i])
+	}
+
+	ecd.clearBuffer()
+
+	return
+}
+
+func (ecd *encoderComplex128) decodeFloat32Public(plaintext *Plaintext, logSlots int, sigma float64) (res []float32) {
+
+	ecd.decodeToBuffer(plaintext, logSlots, sigma)
+
+	res = make([]float32, 1<<logSlots)
+
+	for i := range res {
+		res[i] = float32(real(ecd.values[i]))
+	}
+
+	ecd.clearBuffer()
+
+	return
+}
+
+// decodeToBuffer decodes the plaintext on the first 2^logSlots elements of the buffer ecd.values.
+func (ecd *encoderComplex128) decodeToBuffer(plaintext *Plaintext, logSlots int, sigma float64) {
+
 	slots := 1 << logSlots
 
 	if slots > int(ecd.params.RingQ().NthRoot>>2) {
@@ -540,19 +634,13 @@ func (ecd *encoderComplex128) decodePublic(plaintext *Plaintext, logSlots int, s
 
 	ecd.plaintextToComplex(plaintext.Level(), plaintext.Scale, logSlots, ecd.polypool, ecd.values)
 
-	fft(ecd.values, slots, ecd.m, ecd.rotGroup, ecd.roots)
-
-	res = make([]complex128, slots)
-
-	for i := range res {
-		res[i] = ecd.values[i]
-	}
+	ecd.fft(ecd.values, slots)
+}
 
+func (ecd *encoderComplex128) clearBuffer() {
 	for i := range ecd.values {
 		ecd.values[i] = 0
 	}
-
-	return
 }
 
 func invfft(values []complex128, N, M int, rotGroup []int, roots []complex128) {
@@ -681,11 +769,21 @@ func (ecd *encoderComplex128) decodeCoeffsPublic(plaintext *Plaintext, sigma flo
 }
 
 // EncoderBigComplex is an interface that implements the encoding algorithms with arbitrary precision.
+// The values are never converted to float64. The scale of the plaintexts is a float64 too, which is exact for
+// the powers of two but not for the scales obtained after a rescaling, hence the methods At[...] take as input
+// the exact scale as a *big.Float (e.g. the product of the scales of the operands divided by the moduli
+// of the rescalings), which is used instead of the scale of the plaintext.
 type EncoderBigComplex interface {
 	Encode(values []*ring.Complex, plaintext *Plaintext, logSlots int)
 	EncodeNew(values []*ring.Complex, level int, scale float64, logSlots int) (plaintext *Plaintext)
+	EncodeAtScale(values []*ring.Complex, plaintext *Plaintext, logSlots int, scale *big.Float)
+	EncodeFloat(values []*big.Float, plaintext *Plaintext, logSlots int)
+	EncodeFloatNew(values []*big.Float, level int, scale float64, logSlots int) (plaintext *Plaintext)
 	Decode(plaintext *Plaintext, logSlots int) (res []*ring.Complex)
 	DecodePublic(plaintext *Plaintext, logSlots int, sigma float64) (res []*ring.Complex)
+	DecodeAtScale(plaintext *Plaintext, logSlots int, scale *big.Float) (res []*ring.Complex)
+	DecodeFloat(plaintext *Plaintext, logSlots int) (res []*big.Float)
+	DecodeFloatAtScale(plaintext *Plaintext, logSlots int, scale *big.Float) (res []*big.Float)
 	FFT(values []*ring.Complex, N int)
 	InvFFT(values []*ring.Complex, N int)
 	ShallowCopy() EncoderBigComplex
@@ -756,19 +854,59 @@ func NewEncoderBigComplex(params Parameters, logPrecision int) EncoderBigComplex
 // Encoding is done at the level and scale of the plaintext.
 // User must ensure that 1 <= len(values) <= 2^logSlots < 2^LogN.
 func (ecd *encoderBigComplex) Encode(values []*ring.Complex, plaintext *Plaintext, logSlots int) {
+	ecd.encode(values, nil, plaintext, logSlots, ring.NewFloat(plaintext.Scale, ecd.logPrecision))
+}
+
+// EncodeAtScale encodes a set of values on the target plaintext at the level of the plaintext and the exact scale
+// scale, whose float64 approximation should be the scale of the plaintext.
+// User must ensure that 1 <= len(values) <= 2^logSlots < 2^LogN.
+func (ecd *encoderBigComplex) EncodeAtScale(values []*ring.Complex, plaintext *Plaintext, logSlots int, scale *big.Float) {
+	ecd.encode(values, nil, plaintext, logSlots, scale)
+}
+
+// EncodeFloat encodes a set of real values on the target plaintext.
+// Encoding is done at the level and scale of the plaintext.
+// User must ensure that 1 <= len(values) <= 2^logSlots < 2^LogN.
+func (ecd *encoderBigComplex) EncodeFloat(values []*big.Float, plaintext *Plaintext, logSlots int) {
+	ecd.encode(nil, values, plaintext, logSlots, ring.NewFloat(plaintext.Scale, ecd.logPrecision))
+}
+
+// EncodeFloatNew encodes a set of real values on a new plaintext.
+// Encoding is done at the provided level and with the provided scale.
+// User must ensure that 1 <= len(values) <= 2^logSlots < 2^LogN.
+func (ecd *encoderBigComplex) EncodeFloatNew(values []*big.Float, level int, scale float64, logSlots int) (plaintext *Plaintext) {
+	plaintext = NewPlaintext(ecd.params, level, scale)
+	ecd.EncodeFloat(values, plaintext, logSlots)
+	return
+}
+
+// encode encodes either the complex values or the real values valuesReal at the given scale.
+func (ecd *encoderBigComplex) encode(values []*ring.Complex, valuesReal []*big.Float, plaintext *Plaintext, logSlots int, scale *big.Float) {
 
 	slots := 1 << logSlots
 
-	if len(values) > ecd.params.N()/2 || len(values) > slots || logSlots > ecd.params.LogN()-1 {
+	length := len(values)
+	if values == nil {
+		length = len(valuesReal)
+	}
+
+	if length > ecd.params.N()/2 || length > slots || logSlots > ecd.params.LogN()-1 {
 		panic("cannot Encode: too many values/slots for the given ring degree")
 	}
 
-	if len(values) != slots {
+	if length != slots {
 		panic("cannot Encode: number of values must be equal to slots")
 	}
 
-	for i := 0; i < slots; i++ {
-		ecd.values[i].Set(values[i])
+	if values != nil {
+		for i := 0; i < slots; i++ {
+			ecd.values[i].Set(values[i])
+		}
+	} else {
+		for i := 0; i < slots; i++ {
+			ecd.values[i].Real().Set(valuesReal[i])
+			ecd.values[i].Imag().Set(ecd.zero)
+		}
 	}
 
 	ecd.InvFFT(ecd.values, slots)
@@ -780,7 +918,7 @@ func (ecd *encoderBigComplex) Encode(values []*ring.Complex, plaintext *Plaintex
 		ecd.valuesfloat[jdx].Set(ecd.values[i].Imag())
 	}
 
-	scaleUpVecExactBigFloat(ecd.valuesfloat, plaintext.Scale, ecd.params.RingQ().Modulus[:plaintext.Level()+1], plaintext.Value.Coeffs)
+	scaleUpVecExactBigFloat(ecd.valuesfloat, scale, ecd.params.RingQ().Modulus[:plaintext.Level()+1], plaintext.Value.Coeffs)
 
 	for i := 0; i < (ecd.params.RingQ().N >> 1); i++ {
 		ecd.values[i].Real().Set(ecd.zero)
@@ -806,11 +944,38 @@ func (ecd *encoderBigComplex) EncodeNew(values []*ring.Complex, level int, scale
 
 // Decode decodes the input plaintext on a new slice of ring.Complex.
 func (ecd *encoderBigComplex) Decode(plaintext *Plaintext, logSlots int) (res []*ring.Complex) {
-	return ecd.decodePublic(plaintext, logSlots, 0)
+	return ecd.decodePublic(plaintext, logSlots, 0, ring.NewFloat(plaintext.Scale, ecd.logPrecision))
 }
 
+// DecodePublic decodes the input plaintext on a new slice of ring.Complex.
+// Adds, before the decoding step, an error with standard deviation sigma and bound floor(sqrt(2*pi)*sigma).
 func (ecd *encoderBigComplex) DecodePublic(plaintext *Plaintext, logSlots int, sigma float64) (res []*ring.Complex) {
-	return ecd.decodePublic(plaintext, logSlots, sigma)
+	return ecd.decodePublic(plaintext, logSlots, sigma, ring.NewFloat(plaintext.Scale, ecd.logPrecision))
+}
+
+// DecodeAtScale decodes the input plaintext on a new slice of ring.Complex, using the exact scale scale instead of the
+// scale of the plaintext.
+func (ecd *encoderBigComplex) DecodeAtScale(plaintext *Plaintext, logSlots int, scale *big.Float) (res []*ring.Complex) {
+	return ecd.decodePublic(plaintext, logSlots, 0, scale)
+}
+
+// DecodeFloat decodes the real part of the input plaintext on a new slice of *big.Float.
+func (ecd *encoderBigComplex) DecodeFloat(plaintext *Plaintext, logSlots int) (res []*big.Float) {
+	return realParts(ecd.Decode(plaintext, logSlots))
+}
+
+// DecodeFloatAtScale decodes the real part of the input plaintext on a new slice of *big.Float, using the exact scale
+// scale instead of the scale of the plaintext.
+func (ecd *encoderBigComplex) DecodeFloatAtScale(plaintext *Plaintext, logSlots int, scale *big.Float) (res []*big.Float) {
+	return realParts(ecd.DecodeAtScale(plaintext, logSlots, scale))
+}
+
+func realParts(values []*ring.Complex) (res []*big.Float) {
+	res = make([]*big.Float, len(values))
+	for i := range values {
+		res[i] = values[i].Real()
+	}
+	return
 }
 
 // FFT evaluates the decoding matrix on a slice of ring.Complex values.
@@ -898,7 +1063,7 @@ func (ecd *encoderBigComplex) ShallowCopy() EncoderBigComplex {
 	}
 }
 
-func (ecd *encoderBigComplex) decodePublic(plaintext *Plaintext, logSlots int, sigma float64) (res []*ring.Complex) {
+func (ecd *encoderBigComplex) decodePublic(plaintext *Plaintext, logSlots int, sigma float64, scale *big.Float) (res []*ring.Complex) {
 
 	slots := 1 << logSlots
 
@@ -917,8 +1082,6 @@ func (ecd *encoderBigComplex) decodePublic(plaintext *Plaintext, logSlots int, s
 
 	maxSlots := ecd.params.RingQ().N >> 1
 
-	scaleFlo := ring.NewFloat(plaintext.Scale, ecd.logPrecision)
-
 	ecd.qHalf.Set(Q)
 	ecd.qHalf.Rsh(ecd.qHalf, 1)
 
@@ -945,10 +1108,10 @@ func (ecd *encoderBigComplex) decodePublic(plaintext *Plaintext, logSlots int, s
 		}
 
 		ecd.values[i].Real().SetInt(ecd.bigintCoeffs[i])
-		ecd.values[i].Real().Quo(ecd.values[i].Real(), scaleFlo)
+		ecd.values[i].Real().Quo(ecd.values[i].Real(), scale)
 
 		ecd.values[i].Imag().SetInt(ecd.bigintCoeffs[j])
-		ecd.values[i].Imag().Quo(ecd.values[i].Imag(), scaleFlo)
+		ecd.values[i].Imag().Quo(ecd.values[i].Imag(), scale)
 	}
 
 	ecd.FFT(ecd.values, slots)
@@ -0,0 +1,150 @@
+package ckks
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// EncoderOption is an option of NewEncoder.
+type EncoderOption func(*encoderOptions)
+
+type encoderOptions struct {
+	deterministic bool
+}
+
+// WithDeterministicEncoding makes the Encoder bit-reproducible across architectures: for the same inputs, the encoded
+// plaintexts and the decoded values are identical on all platforms. By default, the roots of unity are computed with
+// the math package, whose implementation is architecture dependent, and the compiler may fuse the multiplications and
+// additions of the FFT in fused multiply-add instructions (e.g. on arm64, ppc64le or s390x), which rounds differently.
+// With this option, the roots of unity are computed in arbitrary precision and rounded to float64, and each floating
+// point operation of the FFT is rounded individually. The encoding is slightly slower.
+//
+// The option only applies to the Encoder: it does not make the floating point operations of the other components,
+// e.g. the polynomial evaluation of the Evaluator, reproducible across architectures.
+func WithDeterministicEncoding() EncoderOption {
+	return func(opts *encoderOptions) {
+		opts.deterministic = true
+	}
+}
+
+// deterministicRootsPrecision is the precision of the arbitrary precision computation of the roots of unity.
+const deterministicRootsPrecision = 128
+
+// genRootsDeterministic returns the m-th roots of unity exp(2*pi*i*k/m) for 0 <= k <= m, computed in arbitrary
+// precision and rounded to float64. m must be a multiple of 4.
+func genRootsDeterministic(m int) (roots []complex128) {
+
+	prec := deterministicRootsPrecision
+
+	PI := new(big.Float).SetPrec(uint(prec))
+	PI.SetString(pi)
+
+	// angle = 2*pi/m
+	angle := new(big.Float).Mul(ring.NewFloat(2, prec), PI)
+	angle.Quo(angle, ring.NewFloat(float64(m), prec))
+
+	// w = exp(2*pi*i/m) = cos(angle) + i*cos(pi/2 - angle)
+	PIHalf := new(big.Float).Quo(PI, ring.NewFloat(2, prec))
+	w := ring.NewComplex(ring.Cos(angle), ring.Cos(new(big.Float).Sub(PIHalf, angle)))
+
+	roots = make([]complex128, m+1)
+
+	// The first quarter is computed by successive multiplications by w, whose accumulated error
+	// of at most m/4 ulps at this precision is negligible before the rounding to float64.
+	quarter := m >> 2
+	cMul := ring.NewComplexMultiplier()
+	root := ring.NewComplex(ring.NewFloat(1, prec), ring.NewFloat(0, prec))
+	roots[0] = 1
+	for k := 1; k <= quarter; k++ {
+		cMul.Mul(root, w, root)
+		roots[k] = root.Float64()
+	}
+
+	// The other quarters are the exact multiplications of the previous ones by i
+	for k := quarter + 1; k < m; k++ {
+		r := roots[k-quarter]
+		roots[k] = complex(-imag(r), real(r))
+	}
+
+	roots[m] = roots[0]
+
+	return
+}
+
+// mulDeterministic returns a*b with each product rounded to float64, which prevents their fusion with the additions.
+func mulDeterministic(a, b complex128) complex128 {
+	return complex(float64(real(a)*real(b))-float64(imag(a)*imag(b)), float64(real(a)*imag(b))+float64(imag(a)*real(b)))
+}
+
+// invfftDeterministic is the counterpart of invfft in which each floating point operation is rounded individually.
+func invfftDeterministic(values []complex128, N, M int, rotGroup []int, roots []complex128) {
+
+	var lenh, lenq, gap, idx int
+	var u, v complex128
+
+	for len := N; len >= 1; len >>= 1 {
+		for i := 0; i < N; i += len {
+			lenh = len >> 1
+			lenq = len << 2
+			gap = M / lenq
+			for j := 0; j < lenh; j++ {
+				idx = (lenq - (rotGroup[j] % lenq)) * gap
+				u = values[i+j] + values[i+j+lenh]
+				v = values[i+j] - values[i+j+lenh]
+				values[i+j] = u
+				values[i+j+lenh] = mulDeterministic(v, roots[idx])
+			}
+		}
+	}
+
+	// N is a power of two, hence the division is exact
+	n := float64(N)
+	for i := 0; i < N; i++ {
+		values[i] = complex(real(values[i])/n, imag(values[i])/n)
+	}
+
+	SliceBitReverseInPlaceComplex128(values, N)
+}
+
+// fftDeterministic is the counterpart of fft in which each floating point operation is rounded individually.
+func fftDeterministic(values []complex128, N, M int, rotGroup []int, roots []complex128) {
+
+	var lenh, lenq, gap, idx int
+	var u, v complex128
+
+	SliceBitReverseInPlaceComplex128(values, N)
+
+	for len := 2; len <= N; len <<= 1 {
+		for i := 0; i < N; i += len {
+			lenh = len >> 1
+			lenq = len << 2
+			gap = M / lenq
+			for j := 0; j < lenh; j++ {
+				idx = (rotGroup[j] % lenq) * gap
+				u = values[i+j]
+				v = mulDeterministic(values[i+j+lenh], roots[idx])
+				values[i+j] = u + v
+				values[i+j+lenh] = u - v
+			}
+		}
+	}
+}
+
+// invfft applies the inverse FFT of the encoder on the first N values.
+func (ecd *encoderComplex128) invfft(values []complex128, N int) {
+	if ecd.deterministic {
+		invfftDeterministic(values, N, ecd.m, ecd.rotGroup, ecd.roots)
+	} else {
+		invfft(values, N, ecd.m, ecd.rotGroup, ecd.roots)
+	}
+}
+
+// fft applies the FFT of the encoder on the first N values.
+func (ecd *encoderComplex128) fft(values []complex128, N int) {
+	if ecd.deterministic {
+		fftDeterministic(values, N, ecd.m, ecd.rotGroup, ecd.roots)
+	} else {
+		fft(values, N, ecd.m, ecd.rotGroup, ecd.roots)
+	}
+}
@@ -3,6 +3,7 @@ package ckks
 import (
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
 )
 
 // Encryptor an encryption interface for the CKKS scheme.
@@ -26,6 +27,12 @@ func NewEncryptor(params Parameters, key interface{}) Encryptor {
 	return &encryptor{rlwe.NewEncryptor(params.Parameters, key), params}
 }
 
+// NewEncryptorWithPRNG instantiates a new Encryptor for the CKKS scheme which samples the randomness
+// of the encryptions from the given PRNG (see rlwe.NewEncryptorWithPRNG).
+func NewEncryptorWithPRNG(params Parameters, key interface{}, prng utils.PRNG) Encryptor {
+	return &encryptor{rlwe.NewEncryptorWithPRNG(params.Parameters, key, prng), params}
+}
+
 // Encrypt encrypts the input plaintext and write the result on ciphertext.
 // The level of the output ciphertext is min(plaintext.Level(), ciphertext.Level()).
 func (enc *encryptor) Encrypt(plaintext *Plaintext, ciphertext *Ciphertext) {
@@ -30,6 +30,8 @@ type Evaluator interface {
 	AddNoMod(op0, op1 Operand, ctOut *Ciphertext)
 	AddNew(op0, op1 Operand) (ctOut *Ciphertext)
 	AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext)
+	AddAlignedNew(op0, op1 Operand) (ctOut *Ciphertext, err error)
+	AddAligned(op0, op1 Operand, ctOut *Ciphertext) (err error)
 
 	// Subtraction
 	Sub(op0, op1 Operand, ctOut *Ciphertext)
@@ -54,6 +56,10 @@ type Evaluator interface {
 	MultByConstAndAdd(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext)
 	MultByGaussianIntegerAndAdd(ctIn *Ciphertext, cReal, cImag interface{}, ctOut *Ciphertext)
 
+	// Linear Combination
+	LinearCombinationNew(cts []*Ciphertext, consts []interface{}) (ctOut *Ciphertext)
+	LinearCombination(cts []*Ciphertext, consts []interface{}, ctOut *Ciphertext)
+
 	// Multiplication by the imaginary unit
 	MultByiNew(ctIn *Ciphertext) (ctOut *Ciphertext)
 	MultByi(ctIn *Ciphertext, ctOut *Ciphertext)
@@ -64,6 +70,12 @@ type Evaluator interface {
 	ConjugateNew(ctIn *Ciphertext) (ctOut *Ciphertext)
 	Conjugate(ctIn *Ciphertext, ctOut *Ciphertext)
 
+	// Projections on the real and imaginary parts
+	RealProjectionNew(ctIn *Ciphertext) (ctOut *Ciphertext)
+	RealProjection(ctIn *Ciphertext, ctOut *Ciphertext)
+	ImagProjectionNew(ctIn *Ciphertext) (ctOut *Ciphertext)
+	ImagProjection(ctIn *Ciphertext, ctOut *Ciphertext)
+
 	// Multiplication
 	Mul(op0, op1 Operand, ctOut *Ciphertext)
 	MulNew(op0, op1 Operand) (ctOut *Ciphertext)
@@ -73,6 +85,10 @@ type Evaluator interface {
 	MulAndAdd(op0, op1 Operand, ctOut *Ciphertext)
 	MulRelinAndAdd(op0, op1 Operand, ctOut *Ciphertext)
 
+	// Inner product
+	InnerProductNew(op0, op1 []Operand, minScale float64) (ctOut *Ciphertext, err error)
+	InnerProduct(op0, op1 []Operand, minScale float64, ctOut *Ciphertext) (err error)
+
 	// Slot Rotations
 	RotateNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext)
 	Rotate(ctIn *Ciphertext, k int, ctOut *Ciphertext)
@@ -97,6 +113,7 @@ type Evaluator interface {
 
 	// Polynomial evaluation
 	EvaluatePoly(ctIn *Ciphertext, pol *Polynomial, targetScale float64) (ctOut *Ciphertext, err error)
+	EvaluatePolyScaleInvariant(ctIn *Ciphertext, pol *Polynomial, targetScale float64, levelStart, levelEnd int) (ctOut *Ciphertext, err error)
 	EvaluatePolyVector(ctIn *Ciphertext, pols []*Polynomial, encoder Encoder, slotIndex map[int][]int, targetScale float64) (ctOut *Ciphertext, err error)
 
 	// Inversion
@@ -107,6 +124,8 @@ type Evaluator interface {
 	LinearTransform(ctIn *Ciphertext, linearTransform interface{}, ctOut []*Ciphertext)
 	MultiplyByDiagMatrix(ctIn *Ciphertext, matrix LinearTransform, c2DecompQP []rlwe.PolyQP, ctOut *Ciphertext)
 	MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearTransform, c2DecompQP []rlwe.PolyQP, ctOut *Ciphertext)
+	BlockLinearTransformNew(ctIn []*Ciphertext, BLT BlockLinearTransform) (ctOut []*Ciphertext)
+	BlockLinearTransform(ctIn []*Ciphertext, BLT BlockLinearTransform, ctOut []*Ciphertext)
 
 	// Inner sum
 	InnerSumLog(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext)
@@ -206,7 +225,8 @@ func newEvaluatorBuffers(evalBase *evaluatorBase) *evaluatorBuffers {
 // NewEvaluator creates a new Evaluator, that can be used to do homomorphic
 // operations on the Ciphertexts and/or Plaintexts. It stores a small pool of polynomials
 // and Ciphertexts that will be used for intermediate values.
-func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator {
+// The options, e.g. WithRescalingStrategy, change the default behavior of the Evaluator.
+func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey, options ...EvaluatorOption) Evaluator {
 	eval := new(evaluator)
 	eval.evaluatorBase = newEvaluatorBase(params)
 	eval.evaluatorBuffers = newEvaluatorBuffers(eval.evaluatorBase)
@@ -221,7 +241,7 @@ func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator
 		eval.KeySwitcher = rlwe.NewKeySwitcher(params.Parameters)
 	}
 
-	return eval
+	return applyEvaluatorOptions(params, eval, options)
 }
 
 func (eval *evaluator) permuteNTTIndexesForKey(rtks *rlwe.RotationKeySet) *map[uint64][]uint64 {
@@ -871,6 +891,139 @@ func (eval *evaluator) MultByGaussianIntegerAndAdd(ct0 *Ciphertext, cReal, cImag
 	}
 }
 
+// LinearCombinationNew evaluates the weighted sum consts[0] * cts[0] + ... + consts[n-1] * cts[n-1] and returns the result in a newly created element.
+// The constants can be uint64, int64, int, float64 or complex128.
+func (eval *evaluator) LinearCombinationNew(cts []*Ciphertext, consts []interface{}) (ctOut *Ciphertext) {
+	maxDegree, minLevel := 0, eval.params.MaxLevel()
+	for _, ct := range cts {
+		maxDegree = utils.MaxInt(maxDegree, ct.Degree())
+		minLevel = utils.MinInt(minLevel, ct.Level())
+	}
+	ctOut = NewCiphertext(eval.params, maxDegree, minLevel, 0)
+	eval.LinearCombination(cts, consts, ctOut)
+	return
+}
+
+// LinearCombination evaluates the weighted sum consts[0] * cts[0] + ... + consts[n-1] * cts[n-1] and returns the result in ctOut.
+// The constants can be uint64, int64, int, float64 or complex128. The terms are accumulated with lazy modular reduction,
+// without intermediate ciphertexts, and ctOut can be one of the inputs.
+// The level of the receiver element will be set to the minimum level among the inputs and the receiver.
+// The scale of the receiver element will be set to the largest scale that a term would have after the multiplication
+// by its constant, and the other terms are scaled up to match it.
+// The degree of the receiver element will be set to the largest input degree.
+// The procedure will panic if len(cts) != len(consts), if cts is empty or if ctOut.Degree() is smaller than the largest input degree.
+func (eval *evaluator) LinearCombination(cts []*Ciphertext, consts []interface{}, ctOut *Ciphertext) {
+
+	if len(cts) != len(consts) {
+		panic(fmt.Errorf("cannot LinearCombination: number of ciphertexts (%d) and constants (%d) do not match", len(cts), len(consts)))
+	}
+
+	if len(cts) == 0 {
+		panic("cannot LinearCombination: no input ciphertext")
+	}
+
+	level, maxDegree := ctOut.Level(), 0
+	for _, ct := range cts {
+		level = utils.MinInt(level, ct.Level())
+		maxDegree = utils.MaxInt(maxDegree, ct.Degree())
+	}
+
+	if ctOut.Degree() < maxDegree {
+		panic("cannot LinearCombination: receiver operand degree is too small")
+	}
+
+	// Determines the output scale, such that no term needs to be rescaled down
+	cReal := make([]float64, len(cts))
+	cImag := make([]float64, len(cts))
+	scale := make([]float64, len(cts))
+	targetScale := 0.0
+	for j := range cts {
+		cReal[j], cImag[j], scale[j] = eval.getConstAndScale(level, consts[j])
+		targetScale = math.Max(targetScale, cts[j].Scale*scale[j])
+	}
+
+	for j := range cts {
+		scale[j] = targetScale / cts[j].Scale
+	}
+
+	if ctOut.Level() > level {
+		eval.DropLevel(ctOut, ctOut.Level()-level)
+	}
+
+	ringQ := eval.params.RingQ()
+
+	// Each term is in [0, Qi-1], so QiOverflowMargin-1 terms can be accumulated on top of a reduced value
+	QiOverF := eval.params.QiOverflowMargin(level) - 1
+
+	// Constants in the Montgomery domain for the first and second half of the slots
+	constPlus := make([]uint64, len(cts))
+	constMinus := make([]uint64, len(cts))
+
+	for i := 0; i < level+1; i++ {
+
+		qi := ringQ.Modulus[i]
+		mredParams := ringQ.MredParams[i]
+		bredParams := ringQ.BredParams[i]
+
+		// Component-wise multiplication of the following vector to the ciphertext:
+		// [a + b*psi_qi^2, ....., a + b*psi_qi^2, a - b*psi_qi^2, ...., a - b*psi_qi^2] mod Qi
+		// [{                  N/2                }{                N/2               }]
+		for j := range cts {
+
+			var scaledConstReal, scaledConstImag uint64
+
+			if cReal[j] != 0 {
+				scaledConstReal = scaleUpExact(cReal[j], scale[j], qi)
+			}
+
+			if cImag[j] != 0 {
+				scaledConstImag = ring.MRed(scaleUpExact(cImag[j], scale[j], qi), ringQ.NttPsi[i][1], qi, mredParams)
+			}
+
+			constPlus[j] = ring.MForm(ring.CRed(scaledConstReal+scaledConstImag, qi), qi, bredParams)
+			constMinus[j] = ring.MForm(ring.CRed(scaledConstReal+(qi-scaledConstImag), qi), qi, bredParams)
+		}
+
+		// Accumulates on a buffer so that ctOut can be one of the inputs
+		acc := eval.poolQMul[0].Coeffs[i]
+
+		for u := 0; u < maxDegree+1; u++ {
+
+			reduce := 0
+
+			for j, ct := range cts {
+
+				if ct.Degree() < u {
+					continue
+				}
+
+				p0tmp := ct.Value[u].Coeffs[i]
+
+				if reduce == 0 {
+					ring.MulScalarMontgomeryVec(p0tmp[:ringQ.N>>1], acc[:ringQ.N>>1], constPlus[j], qi, mredParams)
+					ring.MulScalarMontgomeryVec(p0tmp[ringQ.N>>1:], acc[ringQ.N>>1:], constMinus[j], qi, mredParams)
+				} else {
+					ring.MulScalarMontgomeryAndAddNoModVec(p0tmp[:ringQ.N>>1], acc[:ringQ.N>>1], constPlus[j], qi, mredParams)
+					ring.MulScalarMontgomeryAndAddNoModVec(p0tmp[ringQ.N>>1:], acc[ringQ.N>>1:], constMinus[j], qi, mredParams)
+				}
+
+				if reduce%QiOverF == QiOverF-1 {
+					ring.ReduceVec(acc, acc, qi, bredParams)
+				}
+
+				reduce++
+			}
+
+			ring.ReduceVec(acc, ctOut.Value[u].Coeffs[i], qi, bredParams)
+		}
+	}
+
+	// Removes the components of ctOut above the degree of the result
+	ctOut.El().Resize(eval.params.Parameters, maxDegree)
+
+	ctOut.Scale = targetScale
+}
+
 // MultByiNew multiplies ct0 by the imaginary number i, and returns the result in a newly created element.
 // It does not change the scale.
 func (eval *evaluator) MultByiNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
@@ -1239,7 +1392,13 @@ func (eval *evaluator) mulRelin(op0, op1 Operand, relin bool, ctOut *Ciphertext)
 
 		c00 := eval.poolQMul[0]
 
-		ringQ.MFormLvl(level, tmp0.Value[0], c00)
+		// Plaintexts already in the Montgomery domain (e.g. returned by a PlaintextCache) are used as is
+		if tmp0.Value[0].IsMForm {
+			c00 = tmp0.Value[0]
+		} else {
+			ringQ.MFormLvl(level, tmp0.Value[0], c00)
+		}
+
 		ringQ.MulCoeffsMontgomeryLvl(level, c00, tmp1.Value[0], ctOut.Value[0])
 		ringQ.MulCoeffsMontgomeryLvl(level, c00, tmp1.Value[1], ctOut.Value[1])
 	}
@@ -1320,7 +1479,7 @@ func (eval *evaluator) mulRelinAndAdd(op0, op1 Operand, relin bool, ctOut *Ciphe
 
 		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00, tmp1.Value[0], c0) // c0 = c[0]*c[0]
 		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00, tmp1.Value[1], c1) // c1 = c[0]*c[1]
-		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00, tmp1.Value[1], c1) // c1 = c[0]*c[1]
+		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c01, tmp1.Value[0], c1) // c1 += c[1]*c[0]
 
 		if relin {
 			c2.IsNTT = true
@@ -1345,12 +1504,160 @@ func (eval *evaluator) mulRelinAndAdd(op0, op1 Operand, relin bool, ctOut *Ciphe
 
 		c00 := eval.poolQMul[0]
 
-		ringQ.MFormLvl(level, tmp0.Value[0], c00)
+		if tmp0.Value[0].IsMForm {
+			c00 = tmp0.Value[0]
+		} else {
+			ringQ.MFormLvl(level, tmp0.Value[0], c00)
+		}
+
 		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00, tmp1.Value[0], ctOut.Value[0])
 		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00, tmp1.Value[1], ctOut.Value[1])
 	}
 }
 
+// InnerProductNew evaluates the sum of the products op0[0] * op1[0] + ... + op0[n-1] * op1[n-1], relinearizes and
+// rescales it once, and returns the result in a newly created element. See InnerProduct.
+func (eval *evaluator) InnerProductNew(op0, op1 []Operand, minScale float64) (ctOut *Ciphertext, err error) {
+	level := eval.params.MaxLevel()
+	for i := range op0 {
+		level = utils.MinInt(level, op0[i].Level())
+	}
+	for i := range op1 {
+		level = utils.MinInt(level, op1[i].Level())
+	}
+	ctOut = NewCiphertext(eval.params, 1, level, 0)
+	return ctOut, eval.InnerProduct(op0, op1, minScale, ctOut)
+}
+
+// InnerProduct evaluates the sum of the products op0[0] * op1[0] + ... + op0[n-1] * op1[n-1] of ciphertexts and
+// plaintexts, relinearizes and rescales it once, with the semantic of Rescale for minScale, and returns the result in
+// ctOut, which can be one of the inputs. The products are accumulated at the scale of the products, with lazy modular
+// reduction and without intermediate ciphertexts, so that a sum of n products costs a single relinearization and a
+// single rescaling instead of n, and the rounding errors of the rescaling are not summed n times.
+// The level of the receiver element will be set to the minimum level among the inputs and the receiver.
+// The products are scaled up to the largest scale of the products, which should be close to the others.
+// The procedure will panic if len(op0) != len(op1), if op0 is empty, if a product is not of a Ciphertext of degree
+// one with a Ciphertext of degree one or a Plaintext or if the evaluator was not created with a relinearization key
+// and a product is of two ciphertexts. It returns an error if the rescaling fails.
+func (eval *evaluator) InnerProduct(op0, op1 []Operand, minScale float64, ctOut *Ciphertext) (err error) {
+
+	if len(op0) != len(op1) {
+		panic(fmt.Errorf("cannot InnerProduct: number of operands (%d and %d) do not match", len(op0), len(op1)))
+	}
+
+	if len(op0) == 0 {
+		panic("cannot InnerProduct: no input operand")
+	}
+
+	level, targetScale, relin := ctOut.Level(), 0.0, false
+	for i := range op0 {
+
+		if op0[i].Degree() > 1 || op1[i].Degree() > 1 || op0[i].Degree()+op1[i].Degree() == 0 {
+			panic("cannot InnerProduct: the products must be of a Ciphertext of degree 1 with a Ciphertext of degree 1 or a Plaintext")
+		}
+
+		level = utils.MinInt(level, utils.MinInt(op0[i].Level(), op1[i].Level()))
+		targetScale = math.Max(targetScale, op0[i].ScalingFactor()*op1[i].ScalingFactor())
+		relin = relin || op0[i].Degree()+op1[i].Degree() == 2
+	}
+
+	if relin && eval.rlk == nil {
+		panic("cannot InnerProduct: relinearization key is missing")
+	}
+
+	ringQ := eval.params.RingQ()
+
+	// The products are accumulated on buffers, so that ctOut can be one of the inputs
+	acc := [3]*ring.Poly{eval.ctxpool.Value[0], eval.ctxpool.Value[1], eval.poolQMul[2]}
+	c00, c01 := eval.poolQMul[0], eval.poolQMul[1]
+
+	// Each term is in [0, Qi-1], so QiOverflowMargin-1 terms can be accumulated on top of a reduced value
+	QiOverF := eval.params.QiOverflowMargin(level) - 1
+	reduce := [3]int{}
+
+	// accumulate adds a * b on acc[u] and reduces acc[u] before it overflows
+	accumulate := func(u int, a, b *ring.Poly) {
+		if reduce[u] == 0 {
+			ringQ.MulCoeffsMontgomeryLvl(level, a, b, acc[u])
+		} else {
+			ringQ.MulCoeffsMontgomeryAndAddNoModLvl(level, a, b, acc[u])
+		}
+		reduce[u]++
+		if reduce[u]%QiOverF == 0 {
+			ringQ.ReduceLvl(level, acc[u], acc[u])
+		}
+	}
+
+	for i := range op0 {
+
+		tmp0, tmp1 := op0[i].El(), op1[i].El()
+		if tmp0.Degree() > tmp1.Degree() {
+			tmp0, tmp1 = tmp1, tmp0
+		}
+
+		// The products with a smaller scale are scaled up to targetScale
+		factor := uint64(math.Round(targetScale / (op0[i].ScalingFactor() * op1[i].ScalingFactor())))
+
+		// Plaintexts already in the Montgomery domain (e.g. returned by a PlaintextCache) are used as is
+		a0 := c00
+		if tmp0.Degree() == 0 && tmp0.Value[0].IsMForm {
+			a0 = tmp0.Value[0]
+		} else {
+			ringQ.MFormLvl(level, tmp0.Value[0], c00)
+		}
+
+		if factor > 1 {
+			ringQ.MulScalarLvl(level, a0, factor, c00)
+			a0 = c00
+		}
+
+		// Case Ciphertext (x) Ciphertext
+		if tmp0.Degree() == 1 {
+
+			ringQ.MFormLvl(level, tmp0.Value[1], c01)
+
+			if factor > 1 {
+				ringQ.MulScalarLvl(level, c01, factor, c01)
+			}
+
+			accumulate(0, a0, tmp1.Value[0])
+			accumulate(1, a0, tmp1.Value[1])
+			accumulate(1, c01, tmp1.Value[0])
+			accumulate(2, c01, tmp1.Value[1])
+
+			// Case Plaintext (x) Ciphertext
+		} else {
+			accumulate(0, a0, tmp1.Value[0])
+			accumulate(1, a0, tmp1.Value[1])
+		}
+	}
+
+	if ctOut.Level() > level {
+		eval.DropLevel(ctOut, ctOut.Level()-level)
+	}
+
+	ctOut.El().Resize(eval.params.Parameters, 1)
+
+	ringQ.ReduceLvl(level, acc[0], ctOut.Value[0])
+	ringQ.ReduceLvl(level, acc[1], ctOut.Value[1])
+
+	if relin {
+		ringQ.ReduceLvl(level, acc[2], acc[2])
+		acc[2].IsNTT = true
+		eval.SwitchKeysInPlace(level, acc[2], eval.rlk.Keys[0], eval.Pool[1].Q, eval.Pool[2].Q)
+		ringQ.AddLvl(level, ctOut.Value[0], eval.Pool[1].Q, ctOut.Value[0])
+		ringQ.AddLvl(level, ctOut.Value[1], eval.Pool[2].Q, ctOut.Value[1])
+	}
+
+	ctOut.Scale = targetScale
+
+	if err = eval.Rescale(ctOut, minScale, ctOut); err != nil {
+		return fmt.Errorf("cannot InnerProduct: %w", err)
+	}
+
+	return
+}
+
 // RelinearizeNew applies the relinearization procedure on ct0 and returns the result in a newly
 // created Ciphertext. The input Ciphertext must be of degree two.
 func (eval *evaluator) RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
@@ -1470,6 +1777,66 @@ func (eval *evaluator) Conjugate(ct0 *Ciphertext, ctOut *Ciphertext) {
 	eval.permuteNTT(ct0, galEl, ctOut)
 }
 
+// RealProjectionNew returns the real part of the slots of ct0 in a newly created element, i.e. (ct0 + conj(ct0))/2.
+// The division by two is done by doubling the scale, hence no level is consumed. A rotation key for the row
+// rotation (see Parameters.GaloisElementForRealProjection) needs to be provided, except in the conjugate invariant
+// ring in which the slots are already real.
+func (eval *evaluator) RealProjectionNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct0.Degree(), ct0.Level(), ct0.Scale)
+	eval.RealProjection(ct0, ctOut)
+	return
+}
+
+// RealProjection sets the imaginary part of the slots of ct0 to zero, i.e. computes (ct0 + conj(ct0))/2, and returns
+// the result in ctOut. The division by two is done by doubling the scale, hence no level is consumed. A rotation
+// key for the row rotation (see Parameters.GaloisElementForRealProjection) needs to be provided, except in the
+// conjugate invariant ring in which the slots are already real.
+// Besides extracting the real part of complex values, this removes the imaginary error of real values.
+func (eval *evaluator) RealProjection(ct0 *Ciphertext, ctOut *Ciphertext) {
+
+	if eval.params.RingType() == ring.ConjugateInvariant {
+		if ct0 != ctOut {
+			ctOut.Copy(ct0)
+		}
+		return
+	}
+
+	conj := eval.conjugatePool()
+	eval.Conjugate(ct0, conj)
+	eval.Add(ct0, conj, ctOut)
+	ctOut.Scale *= 2
+}
+
+// ImagProjectionNew returns the imaginary part of the slots of ct0 in a newly created element, i.e.
+// (ct0 - conj(ct0))/(2i). The division by two is done by doubling the scale, hence no level is consumed.
+// A rotation key for the row rotation (see Parameters.GaloisElementForRealProjection) needs to be provided.
+func (eval *evaluator) ImagProjectionNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct0.Degree(), ct0.Level(), ct0.Scale)
+	eval.ImagProjection(ct0, ctOut)
+	return
+}
+
+// ImagProjection computes the imaginary part of the slots of ct0, i.e. (ct0 - conj(ct0))/(2i), as real values and
+// returns the result in ctOut. The division by two is done by doubling the scale, hence no level is consumed.
+// A rotation key for the row rotation (see Parameters.GaloisElementForRealProjection) needs to be provided.
+func (eval *evaluator) ImagProjection(ct0 *Ciphertext, ctOut *Ciphertext) {
+
+	if eval.params.RingType() == ring.ConjugateInvariant {
+		panic("method ImagProjection is not supported when params.RingType() == ring.ConjugateInvariant")
+	}
+
+	conj := eval.conjugatePool()
+	eval.Conjugate(ct0, conj)
+	eval.Sub(ct0, conj, ctOut)
+	eval.DivByi(ctOut, ctOut)
+	ctOut.Scale *= 2
+}
+
+// conjugatePool returns a degree one ciphertext sharing its polynomials with the memory pool of the evaluator.
+func (eval *evaluator) conjugatePool() *Ciphertext {
+	return &Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: eval.ctxpool.Value[:2]}, Scale: eval.ctxpool.Scale}
+}
+
 func (eval *evaluator) permuteNTT(ct0 *Ciphertext, galEl uint64, ctOut *Ciphertext) {
 
 	rtk, generated := eval.rtks.GetRotationKey(galEl)
@@ -0,0 +1,290 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// autoScaleTolerance is the relative difference below which two scales are considered equal.
+const autoScaleTolerance = 1.0 / (1 << 40)
+
+// autoScaleMinConstant is the smallest constant by which an operand can be multiplied before a rescaling to be scaled
+// down, which bounds the relative error on its scale.
+const autoScaleMinConstant = 1 << 30
+
+// autoScaleEvaluator is an Evaluator that manages the scales of the operands of the underlying Evaluator.
+type autoScaleEvaluator struct {
+	Evaluator
+	params Parameters
+}
+
+// NewAutoScaleEvaluator creates an Evaluator that performs its operations with eval and manages the scales of the operands:
+//
+// - Add and Sub (and their variants) accept operands of any scale: if the scales differ, one of the operands is
+// multiplied by a constant and rescaled to the scale of the other one. The operand at the highest level is preferred,
+// so that the alignment consumes a level only if both operands are at the same level. If the ratio between the
+// scales is an integer, the operand of smallest scale is multiplied by this integer and no level is consumed.
+// The inputs are never modified by the alignment.
+//
+// - Mul and MulRelin (and their variants) rescale their result to the default scale of the parameters.
+//
+// - MultByConst rescales its result to the scale of its input if the constant required a scaling.
+//
+// All the other methods are the ones of eval. The methods panic if the scales of the operands cannot be aligned,
+// i.e. if both operands are at level zero and the ratio between their scales is not an integer.
+func NewAutoScaleEvaluator(params Parameters, eval Evaluator) Evaluator {
+	return &autoScaleEvaluator{Evaluator: eval, params: params}
+}
+
+// sameScale returns true if the scales a and b are equal up to autoScaleTolerance.
+func sameScale(a, b float64) bool {
+	return math.Abs(a-b) <= autoScaleTolerance*math.Max(a, b)
+}
+
+// align returns the operands op0 and op1 at the same scale.
+func (eval *autoScaleEvaluator) align(op0, op1 Operand) (Operand, Operand) {
+	op0, op1, err := alignScales(eval.params, eval.Evaluator, op0, op1)
+	if err != nil {
+		panic(err)
+	}
+	return op0, op1
+}
+
+// alignScales returns the operands op0 and op1 at the same scale, using eval for the alignment.
+// It returns an error if the scales cannot be aligned.
+func alignScales(params Parameters, eval Evaluator, op0, op1 Operand) (Operand, Operand, error) {
+
+	s0, s1 := op0.ScalingFactor(), op1.ScalingFactor()
+
+	if sameScale(s0, s1) {
+		return op0, op1, nil
+	}
+
+	// opMin is the operand of smallest scale
+	opMin, opMax := op0, op1
+	if s0 > s1 {
+		opMin, opMax = op1, op0
+	}
+
+	sMin, sMax := opMin.ScalingFactor(), opMax.ScalingFactor()
+
+	// Scaling opMax down consumes a level of opMax and is preferred if opMax has the highest level,
+	// unless the constant is too small to set the scale precisely
+	scaleDown := opMax.Level() > 0 && (opMin.Level() == 0 || opMax.Level() > opMin.Level())
+
+	if r := sMax / sMin; r == math.Round(r) {
+		scaleDown = false
+	} else if scaleDown {
+		q := float64(params.RingQ().Modulus[opMax.Level()])
+		scaleDown = q*sMin/sMax >= autoScaleMinConstant || opMin.Level() == 0
+	}
+
+	var err error
+	if scaleDown {
+		opMax, err = setScale(params, eval, opMax, sMin)
+	} else {
+		opMin, err = setScale(params, eval, opMin, sMax)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s0 > s1 {
+		return opMax, opMin, nil
+	}
+
+	return opMin, opMax, nil
+}
+
+// setScale returns a copy of op of scale scale, obtained by multiplying op by an integer constant and
+// rescaling the result, unless scale is an integer multiple of the scale of op.
+func setScale(params Parameters, eval Evaluator, op Operand, scale float64) (*Ciphertext, error) {
+
+	ct := &Ciphertext{Ciphertext: op.El().CopyNew(), Scale: op.ScalingFactor()}
+
+	r := scale / ct.Scale
+
+	if r > 1 && r == math.Round(r) {
+		c, _ := big.NewFloat(r).Int(nil)
+		eval.MultByGaussianInteger(ct, c, uint64(0), ct)
+		ct.Scale = scale
+		return ct, nil
+	}
+
+	level := ct.Level()
+
+	if level == 0 {
+		return nil, fmt.Errorf("cannot align the scale %f to %f: operand at level 0", ct.Scale, scale)
+	}
+
+	// c = round(r * q_level), such that the rescaling by q_level yields the scale r * ct.Scale
+	c := new(big.Float).SetFloat64(r)
+	c.Mul(c, new(big.Float).SetUint64(params.RingQ().Modulus[level]))
+	c.Add(c, big.NewFloat(0.5))
+	cInt, _ := c.Int(nil)
+
+	eval.MultByGaussianInteger(ct, cInt, uint64(0), ct)
+
+	cFloat, _ := new(big.Float).SetInt(cInt).Float64()
+	ct.Scale *= cFloat
+
+	if err := eval.Rescale(ct, scale, ct); err != nil {
+		return nil, err
+	}
+
+	ct.Scale = scale
+
+	return ct, nil
+}
+
+// AddAlignedNew adds op0 to op1 and returns the result in a new Ciphertext, after aligning their scales as
+// the Evaluator returned by NewAutoScaleEvaluator (see AddAligned).
+func (eval *evaluator) AddAlignedNew(op0, op1 Operand) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()), op0.ScalingFactor())
+	return ctOut, eval.AddAligned(op0, op1, ctOut)
+}
+
+// AddAligned adds op0 to op1 and returns the result in ctOut, after aligning their scales: if the scales differ,
+// one of the operands is multiplied by a constant and rescaled to the scale of the other one, the operand at the
+// highest level being preferred so that the alignment consumes a level only if both operands are at the same level.
+// If the ratio between the scales is an integer, the operand of smallest scale is multiplied by this integer and no
+// level is consumed. The result is at the minimum level of the aligned operands, and the inputs are not modified.
+// Returns an error if the scales cannot be aligned, i.e. if both operands are at level zero and the ratio between
+// their scales is not an integer.
+func (eval *evaluator) AddAligned(op0, op1 Operand, ctOut *Ciphertext) (err error) {
+	if op0, op1, err = alignScales(eval.params, eval, op0, op1); err != nil {
+		return
+	}
+	eval.Add(op0, op1, ctOut)
+	return
+}
+
+// rescale rescales ct to minScale if its scale allows it and if it is not at level zero.
+func (eval *autoScaleEvaluator) rescale(ct *Ciphertext, minScale float64) {
+	if ct.Level() > 0 {
+		if err := eval.Evaluator.Rescale(ct, minScale, ct); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (eval *autoScaleEvaluator) newCiphertextBinary(op0, op1 Operand) *Ciphertext {
+	return NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()), op0.ScalingFactor())
+}
+
+// Add adds op0 to op1 and returns the result in ctOut, after aligning their scales.
+func (eval *autoScaleEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.align(op0, op1)
+	eval.Evaluator.Add(op0, op1, ctOut)
+}
+
+// AddNew adds op0 to op1 and returns the result in a new Ciphertext, after aligning their scales.
+func (eval *autoScaleEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextBinary(op0, op1)
+	eval.Add(op0, op1, ctOut)
+	return
+}
+
+// AddNoMod adds op0 to op1 without modular reduction and returns the result in ctOut, after aligning their scales.
+func (eval *autoScaleEvaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.align(op0, op1)
+	eval.Evaluator.AddNoMod(op0, op1, ctOut)
+}
+
+// AddNoModNew adds op0 to op1 without modular reduction and returns the result in a new Ciphertext, after aligning their scales.
+func (eval *autoScaleEvaluator) AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextBinary(op0, op1)
+	eval.AddNoMod(op0, op1, ctOut)
+	return
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut, after aligning their scales.
+func (eval *autoScaleEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.align(op0, op1)
+	eval.Evaluator.Sub(op0, op1, ctOut)
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a new Ciphertext, after aligning their scales.
+func (eval *autoScaleEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextBinary(op0, op1)
+	eval.Sub(op0, op1, ctOut)
+	return
+}
+
+// SubNoMod subtracts op1 from op0 without modular reduction and returns the result in ctOut, after aligning their scales.
+func (eval *autoScaleEvaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.align(op0, op1)
+	eval.Evaluator.SubNoMod(op0, op1, ctOut)
+}
+
+// SubNoModNew subtracts op1 from op0 without modular reduction and returns the result in a new Ciphertext, after aligning their scales.
+func (eval *autoScaleEvaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextBinary(op0, op1)
+	eval.SubNoMod(op0, op1, ctOut)
+	return
+}
+
+// Mul multiplies op0 with op1 without relinearization and returns the result in ctOut, rescaled to the default scale.
+func (eval *autoScaleEvaluator) Mul(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.Evaluator.Mul(op0, op1, ctOut)
+	eval.rescale(ctOut, eval.params.DefaultScale())
+}
+
+// MulNew multiplies op0 with op1 without relinearization and returns the result in a new Ciphertext, rescaled to the default scale.
+func (eval *autoScaleEvaluator) MulNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.Evaluator.MulNew(op0, op1)
+	eval.rescale(ctOut, eval.params.DefaultScale())
+	return
+}
+
+// MulRelin multiplies op0 with op1 with relinearization and returns the result in ctOut, rescaled to the default scale.
+func (eval *autoScaleEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.Evaluator.MulRelin(op0, op1, ctOut)
+	eval.rescale(ctOut, eval.params.DefaultScale())
+}
+
+// MulRelinNew multiplies op0 with op1 with relinearization and returns the result in a new Ciphertext, rescaled to the default scale.
+func (eval *autoScaleEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = eval.Evaluator.MulRelinNew(op0, op1)
+	eval.rescale(ctOut, eval.params.DefaultScale())
+	return
+}
+
+// MulAndAdd multiplies op0 with op1 without relinearization, rescales the result to the default scale and adds it on ctOut.
+func (eval *autoScaleEvaluator) MulAndAdd(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.Add(ctOut, eval.MulNew(op0, op1), ctOut)
+}
+
+// MulRelinAndAdd multiplies op0 with op1 with relinearization, rescales the result to the default scale and adds it on ctOut.
+func (eval *autoScaleEvaluator) MulRelinAndAdd(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.Add(ctOut, eval.MulRelinNew(op0, op1), ctOut)
+}
+
+// MultByConst multiplies ctIn by the constant and returns the result in ctOut, rescaled to the scale of ctIn.
+func (eval *autoScaleEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	scale := ctIn.Scale
+	eval.Evaluator.MultByConst(ctIn, constant, ctOut)
+	eval.rescale(ctOut, scale)
+}
+
+// MultByConstNew multiplies ctIn by the constant and returns the result in a new Ciphertext, rescaled to the scale of ctIn.
+func (eval *autoScaleEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale)
+	eval.MultByConst(ctIn, constant, ctOut)
+	return
+}
+
+// ShallowCopy creates a shallow copy of the underlying Evaluator and returns it with automatic scale management.
+func (eval *autoScaleEvaluator) ShallowCopy() Evaluator {
+	return NewAutoScaleEvaluator(eval.params, eval.Evaluator.ShallowCopy())
+}
+
+// WithKey creates a shallow copy of the underlying Evaluator with the new key and returns it with automatic scale management.
+func (eval *autoScaleEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return NewAutoScaleEvaluator(eval.params, eval.Evaluator.WithKey(evaluationKey))
+}
@@ -0,0 +1,31 @@
+package ckks
+
+import (
+	"math"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// NewMetricsEvaluator creates an Evaluator that performs its operations with eval and records each of them, along with
+// its duration, in metrics as an operation of the component "ckks.Evaluator". If budget is not nil, the budget of the
+// output ciphertext of each operation, e.g. computed by ModulusBudget, is recorded as its noise budget. The operations
+// are those reported by NewTracingEvaluator, and the metrics are recorded from the goroutine performing them.
+func NewMetricsEvaluator(eval Evaluator, metrics rlwe.Metrics, budget func(ct *Ciphertext) float64) Evaluator {
+	return NewTracingEvaluator(eval, func(event OperationEvent) {
+		metrics.ObserveOperation("ckks.Evaluator", event.Operation, event.Duration)
+		if budget != nil && event.Output != nil {
+			metrics.ObserveNoiseBudget("ckks.Evaluator", event.Operation, budget(event.Output))
+		}
+	})
+}
+
+// ModulusBudget returns the budget in bits of the ciphertext, i.e. log2(Q_l/scale) for its level l and its scale: the
+// number of bits of the modulus above the scale, which are consumed by the rescalings and bound the magnitude of the
+// messages. Unlike the noise budget of the BFV scheme, it does not require the secret key.
+func ModulusBudget(params Parameters, ct *Ciphertext) float64 {
+	logQ := 0.0
+	for _, qi := range params.RingQ().Modulus[:ct.Level()+1] {
+		logQ += math.Log2(float64(qi))
+	}
+	return logQ - math.Log2(ct.Scale)
+}
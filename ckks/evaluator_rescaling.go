@@ -0,0 +1,307 @@
+package ckks
+
+import (
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// RescalingStrategy is the order in which an Evaluator rescales the ciphertexts.
+type RescalingStrategy int
+
+const (
+	// RescaleAfterMul rescales the ciphertexts when Rescale is called, usually right after the multiplications.
+	// This is the default strategy.
+	RescaleAfterMul = RescalingStrategy(0)
+
+	// RescaleBeforeMul defers the rescalings to the next multiplication: Rescale leaves its input unchanged and the
+	// operands of the multiplications are rescaled to the default scale of the parameters before being multiplied.
+	// The products are thus added, e.g. in a dot product or a linear combination, at their full scale and rescaled
+	// once, which does not accumulate the rounding errors of the rescalings and reduces the variance of the error
+	// of the result, see Kim et al., "Approximate Homomorphic Encryption with Reduced Approximation Error"
+	// (https://eprint.iacr.org/2020/1118). The ciphertexts whose rescaling is pending can be decrypted as such.
+	// The operands are left unchanged: a pending operand is rescaled in a new ciphertext, which is multiplied.
+	RescaleBeforeMul = RescalingStrategy(1)
+)
+
+// EvaluatorOption is an option of NewEvaluator.
+type EvaluatorOption func(*evaluatorOptions)
+
+type evaluatorOptions struct {
+	rescaling RescalingStrategy
+}
+
+// WithRescalingStrategy sets the RescalingStrategy of the Evaluator.
+func WithRescalingStrategy(strategy RescalingStrategy) EvaluatorOption {
+	return func(opts *evaluatorOptions) {
+		opts.rescaling = strategy
+	}
+}
+
+// applyEvaluatorOptions returns eval with the given options.
+func applyEvaluatorOptions(params Parameters, eval Evaluator, options []EvaluatorOption) Evaluator {
+
+	opts := new(evaluatorOptions)
+	for _, option := range options {
+		option(opts)
+	}
+
+	switch opts.rescaling {
+	case RescaleAfterMul:
+		return eval
+	case RescaleBeforeMul:
+		return &lazyRescalingEvaluator{Evaluator: eval, params: params}
+	default:
+		panic("cannot NewEvaluator: invalid RescalingStrategy")
+	}
+}
+
+// lazyRescalingEvaluator is an Evaluator with the RescaleBeforeMul strategy.
+type lazyRescalingEvaluator struct {
+	Evaluator
+	params Parameters
+}
+
+// Rescale leaves ctIn unchanged and copies it on ctOut: its rescaling is deferred to its next multiplication.
+func (eval *lazyRescalingEvaluator) Rescale(ctIn *Ciphertext, minScale float64, ctOut *Ciphertext) (err error) {
+	if ctIn != ctOut {
+		ctOut.Copy(ctIn)
+	}
+	return nil
+}
+
+// pending returns true if the rescaling of ct is pending, i.e. if Rescale would divide it by at least one modulus.
+func (eval *lazyRescalingEvaluator) pending(ct *Ciphertext) bool {
+	return ct.Level() > 0 && ct.Scale > 0 && ct.Scale/float64(eval.params.RingQ().Modulus[ct.Level()]) >= eval.params.DefaultScale()/2
+}
+
+// rescaled returns ct rescaled to the default scale in a new Ciphertext if its rescaling is pending, and ct otherwise.
+// It leaves ct unchanged. The rescaling of a pending ciphertext satisfies the conditions of Rescale, hence the
+// methods that do not return an error panic only on an invalid ciphertext, e.g. of an invalid degree.
+func (eval *lazyRescalingEvaluator) rescaled(ct *Ciphertext) (*Ciphertext, error) {
+	if !eval.pending(ct) {
+		return ct, nil
+	}
+	ctOut := NewCiphertext(eval.params, ct.Degree(), ct.Level(), ct.Scale)
+	if err := eval.Evaluator.Rescale(ct, eval.params.DefaultScale(), ctOut); err != nil {
+		return nil, err
+	}
+	return ctOut, nil
+}
+
+// rescaledOperand returns op rescaled as by rescaled if it is a Ciphertext, and op otherwise.
+func (eval *lazyRescalingEvaluator) rescaledOperand(op Operand) (Operand, error) {
+	if ct, ok := op.(*Ciphertext); ok {
+		return eval.rescaled(ct)
+	}
+	return op, nil
+}
+
+// mustRescaled returns ct as by rescaled and panics on an error.
+func (eval *lazyRescalingEvaluator) mustRescaled(ct *Ciphertext) *Ciphertext {
+	ct, err := eval.rescaled(ct)
+	if err != nil {
+		panic(err)
+	}
+	return ct
+}
+
+// mustRescaledOperands returns op0 and op1 as by rescaledOperand and panics on an error.
+func (eval *lazyRescalingEvaluator) mustRescaledOperands(op0, op1 Operand) (Operand, Operand) {
+	var err error
+	if op0, err = eval.rescaledOperand(op0); err != nil {
+		panic(err)
+	}
+	if op1, err = eval.rescaledOperand(op1); err != nil {
+		panic(err)
+	}
+	return op0, op1
+}
+
+// rescaledOperands returns the operands as by rescaledOperand in a new slice.
+func (eval *lazyRescalingEvaluator) rescaledOperands(ops []Operand) (rescaled []Operand, err error) {
+	rescaled = make([]Operand, len(ops))
+	for i := range ops {
+		if rescaled[i], err = eval.rescaledOperand(ops[i]); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// mustRescaledCiphertexts returns the ciphertexts as by rescaled in a new slice and panics on an error.
+func (eval *lazyRescalingEvaluator) mustRescaledCiphertexts(cts []*Ciphertext) (rescaled []*Ciphertext) {
+	rescaled = make([]*Ciphertext, len(cts))
+	for i := range cts {
+		rescaled[i] = eval.mustRescaled(cts[i])
+	}
+	return
+}
+
+// Mul rescales the pending operands and multiplies op0 with op1 without relinearization.
+func (eval *lazyRescalingEvaluator) Mul(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.mustRescaledOperands(op0, op1)
+	eval.Evaluator.Mul(op0, op1, ctOut)
+}
+
+// MulNew rescales the pending operands and multiplies op0 with op1 without relinearization.
+func (eval *lazyRescalingEvaluator) MulNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	op0, op1 = eval.mustRescaledOperands(op0, op1)
+	return eval.Evaluator.MulNew(op0, op1)
+}
+
+// MulRelin rescales the pending operands and multiplies op0 with op1 with relinearization.
+func (eval *lazyRescalingEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.mustRescaledOperands(op0, op1)
+	eval.Evaluator.MulRelin(op0, op1, ctOut)
+}
+
+// MulRelinNew rescales the pending operands and multiplies op0 with op1 with relinearization.
+func (eval *lazyRescalingEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	op0, op1 = eval.mustRescaledOperands(op0, op1)
+	return eval.Evaluator.MulRelinNew(op0, op1)
+}
+
+// MulAndAdd rescales the pending operands, multiplies op0 with op1 without relinearization and adds the result on
+// ctOut, which is not rescaled.
+func (eval *lazyRescalingEvaluator) MulAndAdd(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.mustRescaledOperands(op0, op1)
+	eval.Evaluator.MulAndAdd(op0, op1, ctOut)
+}
+
+// MulRelinAndAdd rescales the pending operands, multiplies op0 with op1 with relinearization and adds the result on
+// ctOut, which is not rescaled.
+func (eval *lazyRescalingEvaluator) MulRelinAndAdd(op0, op1 Operand, ctOut *Ciphertext) {
+	op0, op1 = eval.mustRescaledOperands(op0, op1)
+	eval.Evaluator.MulRelinAndAdd(op0, op1, ctOut)
+}
+
+// InnerProduct rescales the pending operands and evaluates their inner product, which is rescaled once.
+func (eval *lazyRescalingEvaluator) InnerProduct(op0, op1 []Operand, minScale float64, ctOut *Ciphertext) (err error) {
+	if op0, err = eval.rescaledOperands(op0); err != nil {
+		return err
+	}
+	if op1, err = eval.rescaledOperands(op1); err != nil {
+		return err
+	}
+	return eval.Evaluator.InnerProduct(op0, op1, minScale, ctOut)
+}
+
+// InnerProductNew rescales the pending operands and evaluates their inner product, which is rescaled once.
+func (eval *lazyRescalingEvaluator) InnerProductNew(op0, op1 []Operand, minScale float64) (ctOut *Ciphertext, err error) {
+	if op0, err = eval.rescaledOperands(op0); err != nil {
+		return nil, err
+	}
+	if op1, err = eval.rescaledOperands(op1); err != nil {
+		return nil, err
+	}
+	return eval.Evaluator.InnerProductNew(op0, op1, minScale)
+}
+
+// MultByConst rescales ctIn if pending and multiplies it by the constant.
+func (eval *lazyRescalingEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	eval.Evaluator.MultByConst(ctIn, constant, ctOut)
+}
+
+// MultByConstNew rescales ctIn if pending and multiplies it by the constant.
+func (eval *lazyRescalingEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	return eval.Evaluator.MultByConstNew(ctIn, constant)
+}
+
+// MultByConstAndAdd rescales ctIn if pending, multiplies it by the constant and adds the result on ctOut, which is
+// not rescaled.
+func (eval *lazyRescalingEvaluator) MultByConstAndAdd(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	eval.Evaluator.MultByConstAndAdd(ctIn, constant, ctOut)
+}
+
+// LinearCombination rescales the pending ciphertexts and evaluates their linear combination.
+func (eval *lazyRescalingEvaluator) LinearCombination(cts []*Ciphertext, consts []interface{}, ctOut *Ciphertext) {
+	eval.Evaluator.LinearCombination(eval.mustRescaledCiphertexts(cts), consts, ctOut)
+}
+
+// LinearCombinationNew rescales the pending ciphertexts and evaluates their linear combination.
+func (eval *lazyRescalingEvaluator) LinearCombinationNew(cts []*Ciphertext, consts []interface{}) (ctOut *Ciphertext) {
+	return eval.Evaluator.LinearCombinationNew(eval.mustRescaledCiphertexts(cts), consts)
+}
+
+// Power rescales ctIn if pending and computes its power.
+func (eval *lazyRescalingEvaluator) Power(ctIn *Ciphertext, degree int, ctOut *Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	eval.Evaluator.Power(ctIn, degree, ctOut)
+}
+
+// PowerNew rescales ctIn if pending and computes its power.
+func (eval *lazyRescalingEvaluator) PowerNew(ctIn *Ciphertext, degree int) (ctOut *Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	return eval.Evaluator.PowerNew(ctIn, degree)
+}
+
+// PowerOf2 rescales ctIn if pending and computes its power.
+func (eval *lazyRescalingEvaluator) PowerOf2(ctIn *Ciphertext, logPow2 int, ctOut *Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	eval.Evaluator.PowerOf2(ctIn, logPow2, ctOut)
+}
+
+// EvaluatePoly rescales ctIn if pending and evaluates the polynomial, whose intermediate products are rescaled eagerly.
+func (eval *lazyRescalingEvaluator) EvaluatePoly(ctIn *Ciphertext, pol *Polynomial, targetScale float64) (ctOut *Ciphertext, err error) {
+	if ctIn, err = eval.rescaled(ctIn); err != nil {
+		return nil, err
+	}
+	return eval.Evaluator.EvaluatePoly(ctIn, pol, targetScale)
+}
+
+// EvaluatePolyScaleInvariant rescales ctIn if pending and evaluates the polynomial.
+func (eval *lazyRescalingEvaluator) EvaluatePolyScaleInvariant(ctIn *Ciphertext, pol *Polynomial, targetScale float64, levelStart, levelEnd int) (ctOut *Ciphertext, err error) {
+	if ctIn, err = eval.rescaled(ctIn); err != nil {
+		return nil, err
+	}
+	return eval.Evaluator.EvaluatePolyScaleInvariant(ctIn, pol, targetScale, levelStart, levelEnd)
+}
+
+// EvaluatePolyVector rescales ctIn if pending and evaluates the polynomials.
+func (eval *lazyRescalingEvaluator) EvaluatePolyVector(ctIn *Ciphertext, pols []*Polynomial, encoder Encoder, slotIndex map[int][]int, targetScale float64) (ctOut *Ciphertext, err error) {
+	if ctIn, err = eval.rescaled(ctIn); err != nil {
+		return nil, err
+	}
+	return eval.Evaluator.EvaluatePolyVector(ctIn, pols, encoder, slotIndex, targetScale)
+}
+
+// InverseNew rescales ctIn if pending and computes its inverse.
+func (eval *lazyRescalingEvaluator) InverseNew(ctIn *Ciphertext, steps int) (ctOut *Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	return eval.Evaluator.InverseNew(ctIn, steps)
+}
+
+// LinearTransform rescales ctIn if pending and evaluates the linear transformations, whose results are not rescaled.
+func (eval *lazyRescalingEvaluator) LinearTransform(ctIn *Ciphertext, linearTransform interface{}, ctOut []*Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	eval.Evaluator.LinearTransform(ctIn, linearTransform, ctOut)
+}
+
+// LinearTransformNew rescales ctIn if pending and evaluates the linear transformations, whose results are not rescaled.
+func (eval *lazyRescalingEvaluator) LinearTransformNew(ctIn *Ciphertext, linearTransform interface{}) (ctOut []*Ciphertext) {
+	ctIn = eval.mustRescaled(ctIn)
+	return eval.Evaluator.LinearTransformNew(ctIn, linearTransform)
+}
+
+// BlockLinearTransform rescales the pending inputs and evaluates the block linear transformation.
+func (eval *lazyRescalingEvaluator) BlockLinearTransform(ctIn []*Ciphertext, BLT BlockLinearTransform, ctOut []*Ciphertext) {
+	eval.Evaluator.BlockLinearTransform(eval.mustRescaledCiphertexts(ctIn), BLT, ctOut)
+}
+
+// BlockLinearTransformNew rescales the pending inputs and evaluates the block linear transformation.
+func (eval *lazyRescalingEvaluator) BlockLinearTransformNew(ctIn []*Ciphertext, BLT BlockLinearTransform) (ctOut []*Ciphertext) {
+	return eval.Evaluator.BlockLinearTransformNew(eval.mustRescaledCiphertexts(ctIn), BLT)
+}
+
+// ShallowCopy creates a shallow copy of the underlying Evaluator and returns it with the RescaleBeforeMul strategy.
+func (eval *lazyRescalingEvaluator) ShallowCopy() Evaluator {
+	return &lazyRescalingEvaluator{Evaluator: eval.Evaluator.ShallowCopy(), params: eval.params}
+}
+
+// WithKey creates a shallow copy of the underlying Evaluator with the new key and returns it with the
+// RescaleBeforeMul strategy.
+func (eval *lazyRescalingEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return &lazyRescalingEvaluator{Evaluator: eval.Evaluator.WithKey(evaluationKey), params: eval.params}
+}
@@ -0,0 +1,544 @@
+package ckks
+
+import (
+	"time"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// OperationEvent describes an operation performed by an Evaluator created with NewTracingEvaluator.
+// The operands are referenced for identification only, e.g. to extract the circuit being evaluated,
+// and must not be modified by the hook.
+type OperationEvent struct {
+	Operation   string        // Name of the Evaluator method
+	Inputs      []Operand     // Input operands, in the order of the method arguments
+	InputLevels []int         // Levels of the input operands before the operation
+	InputScales []float64     // Scales of the input operands before the operation
+	Output      *Ciphertext   // Output ciphertext, nil if the method failed to return one
+	OutputLevel int           // Level of the output ciphertext, -1 if Output is nil
+	OutputScale float64       // Scale of the output ciphertext
+	Duration    time.Duration // Duration of the operation
+}
+
+// TraceHook is a function called after each operation of an Evaluator created with NewTracingEvaluator.
+// A channel can be used as a hook with a function sending the events on it.
+type TraceHook func(event OperationEvent)
+
+// tracingEvaluator is an Evaluator that reports the operations of the underlying Evaluator to a TraceHook.
+// The methods operating on raw polynomials (e.g. the hoisted rotations) and on several outputs
+// (e.g. the linear transformations) are not reported.
+type tracingEvaluator struct {
+	Evaluator
+	hook TraceHook
+}
+
+// NewTracingEvaluator creates an Evaluator that performs its operations with eval and reports each of them,
+// along with the levels and scales of its inputs and output and its duration, to hook. Operations are reported
+// at the granularity of the calls made on the returned Evaluator: the operations performed internally by a method
+// (e.g. the multiplications of EvaluatePoly) are not reported. The methods operating on raw polynomials or on
+// several output ciphertexts are not reported.
+// The hook is called from the goroutine performing the operation, so it must be safe for concurrent use
+// if shallow copies of the returned Evaluator are used concurrently.
+func NewTracingEvaluator(eval Evaluator, hook TraceHook) Evaluator {
+	return &tracingEvaluator{Evaluator: eval, hook: hook}
+}
+
+// trace records the inputs of an operation and returns the function reporting its output to the hook.
+func (eval *tracingEvaluator) trace(operation string, inputs ...Operand) func(ctOut *Ciphertext) {
+
+	levels := make([]int, len(inputs))
+	scales := make([]float64, len(inputs))
+	for i := range inputs {
+		levels[i] = inputs[i].Level()
+		scales[i] = inputs[i].ScalingFactor()
+	}
+
+	start := time.Now()
+
+	return func(ctOut *Ciphertext) {
+
+		duration := time.Since(start)
+
+		outLevel, outScale := -1, 0.0
+		if ctOut != nil {
+			outLevel, outScale = ctOut.Level(), ctOut.Scale
+		}
+
+		eval.hook(OperationEvent{
+			Operation:   operation,
+			Inputs:      inputs,
+			InputLevels: levels,
+			InputScales: scales,
+			Output:      ctOut,
+			OutputLevel: outLevel,
+			OutputScale: outScale,
+			Duration:    duration,
+		})
+	}
+}
+
+func ciphertextsToOperands(cts []*Ciphertext) (ops []Operand) {
+	ops = make([]Operand, len(cts))
+	for i := range cts {
+		ops[i] = cts[i]
+	}
+	return
+}
+
+func (eval *tracingEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("Add", op0, op1)
+	eval.Evaluator.Add(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("AddNoMod", op0, op1)
+	eval.Evaluator.AddNoMod(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("AddNew", op0, op1)
+	ctOut = eval.Evaluator.AddNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("AddNoModNew", op0, op1)
+	ctOut = eval.Evaluator.AddNoModNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) AddAlignedNew(op0, op1 Operand) (ctOut *Ciphertext, err error) {
+	done := eval.trace("AddAlignedNew", op0, op1)
+	ctOut, err = eval.Evaluator.AddAlignedNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) AddAligned(op0, op1 Operand, ctOut *Ciphertext) (err error) {
+	done := eval.trace("AddAligned", op0, op1)
+	err = eval.Evaluator.AddAligned(op0, op1, ctOut)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("Sub", op0, op1)
+	eval.Evaluator.Sub(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("SubNoMod", op0, op1)
+	eval.Evaluator.SubNoMod(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("SubNew", op0, op1)
+	ctOut = eval.Evaluator.SubNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("SubNoModNew", op0, op1)
+	ctOut = eval.Evaluator.SubNoModNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Neg(ctIn *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("Neg", ctIn)
+	eval.Evaluator.Neg(ctIn, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) NegNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("NegNew", ctIn)
+	ctOut = eval.Evaluator.NegNew(ctIn)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) AddConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	done := eval.trace("AddConstNew", ctIn)
+	ctOut = eval.Evaluator.AddConstNew(ctIn, constant)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) AddConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	done := eval.trace("AddConst", ctIn)
+	eval.Evaluator.AddConst(ctIn, constant, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	done := eval.trace("MultByConstNew", ctIn)
+	ctOut = eval.Evaluator.MultByConstNew(ctIn, constant)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	done := eval.trace("MultByConst", ctIn)
+	eval.Evaluator.MultByConst(ctIn, constant, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MultByGaussianInteger(ctIn *Ciphertext, cReal, cImag interface{}, ctOut *Ciphertext) {
+	done := eval.trace("MultByGaussianInteger", ctIn)
+	eval.Evaluator.MultByGaussianInteger(ctIn, cReal, cImag, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MultByConstAndAdd(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+	done := eval.trace("MultByConstAndAdd", ctIn)
+	eval.Evaluator.MultByConstAndAdd(ctIn, constant, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MultByGaussianIntegerAndAdd(ctIn *Ciphertext, cReal, cImag interface{}, ctOut *Ciphertext) {
+	done := eval.trace("MultByGaussianIntegerAndAdd", ctIn)
+	eval.Evaluator.MultByGaussianIntegerAndAdd(ctIn, cReal, cImag, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) LinearCombinationNew(cts []*Ciphertext, consts []interface{}) (ctOut *Ciphertext) {
+	done := eval.trace("LinearCombinationNew", ciphertextsToOperands(cts)...)
+	ctOut = eval.Evaluator.LinearCombinationNew(cts, consts)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) LinearCombination(cts []*Ciphertext, consts []interface{}, ctOut *Ciphertext) {
+	done := eval.trace("LinearCombination", ciphertextsToOperands(cts)...)
+	eval.Evaluator.LinearCombination(cts, consts, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MultByiNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("MultByiNew", ctIn)
+	ctOut = eval.Evaluator.MultByiNew(ctIn)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) MultByi(ctIn *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("MultByi", ctIn)
+	eval.Evaluator.MultByi(ctIn, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) DivByiNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("DivByiNew", ctIn)
+	ctOut = eval.Evaluator.DivByiNew(ctIn)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) DivByi(ctIn *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("DivByi", ctIn)
+	eval.Evaluator.DivByi(ctIn, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) ConjugateNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("ConjugateNew", ctIn)
+	ctOut = eval.Evaluator.ConjugateNew(ctIn)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Conjugate(ctIn *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("Conjugate", ctIn)
+	eval.Evaluator.Conjugate(ctIn, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) RealProjectionNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("RealProjectionNew", ctIn)
+	ctOut = eval.Evaluator.RealProjectionNew(ctIn)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) RealProjection(ctIn *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("RealProjection", ctIn)
+	eval.Evaluator.RealProjection(ctIn, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) ImagProjectionNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("ImagProjectionNew", ctIn)
+	ctOut = eval.Evaluator.ImagProjectionNew(ctIn)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) ImagProjection(ctIn *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("ImagProjection", ctIn)
+	eval.Evaluator.ImagProjection(ctIn, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) Mul(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("Mul", op0, op1)
+	eval.Evaluator.Mul(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MulNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("MulNew", op0, op1)
+	ctOut = eval.Evaluator.MulNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("MulRelin", op0, op1)
+	eval.Evaluator.MulRelin(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	done := eval.trace("MulRelinNew", op0, op1)
+	ctOut = eval.Evaluator.MulRelinNew(op0, op1)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) MulAndAdd(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("MulAndAdd", op0, op1)
+	eval.Evaluator.MulAndAdd(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MulRelinAndAdd(op0, op1 Operand, ctOut *Ciphertext) {
+	done := eval.trace("MulRelinAndAdd", op0, op1)
+	eval.Evaluator.MulRelinAndAdd(op0, op1, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) InnerProductNew(op0, op1 []Operand, minScale float64) (ctOut *Ciphertext, err error) {
+	done := eval.trace("InnerProductNew", append(append([]Operand{}, op0...), op1...)...)
+	ctOut, err = eval.Evaluator.InnerProductNew(op0, op1, minScale)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) InnerProduct(op0, op1 []Operand, minScale float64, ctOut *Ciphertext) (err error) {
+	done := eval.trace("InnerProduct", append(append([]Operand{}, op0...), op1...)...)
+	err = eval.Evaluator.InnerProduct(op0, op1, minScale, ctOut)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) RotateNew(ctIn *Ciphertext, k int) (ctOut *Ciphertext) {
+	done := eval.trace("RotateNew", ctIn)
+	ctOut = eval.Evaluator.RotateNew(ctIn, k)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Rotate(ctIn *Ciphertext, k int, ctOut *Ciphertext) {
+	done := eval.trace("Rotate", ctIn)
+	eval.Evaluator.Rotate(ctIn, k, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) MulByPow2New(ctIn *Ciphertext, pow2 int) (ctOut *Ciphertext) {
+	done := eval.trace("MulByPow2New", ctIn)
+	ctOut = eval.Evaluator.MulByPow2New(ctIn, pow2)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) MulByPow2(ctIn *Ciphertext, pow2 int, ctOut *Ciphertext) {
+	done := eval.trace("MulByPow2", ctIn)
+	eval.Evaluator.MulByPow2(ctIn, pow2, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) PowerOf2(ctIn *Ciphertext, logPow2 int, ctOut *Ciphertext) {
+	done := eval.trace("PowerOf2", ctIn)
+	eval.Evaluator.PowerOf2(ctIn, logPow2, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) Power(ctIn *Ciphertext, degree int, ctOut *Ciphertext) {
+	done := eval.trace("Power", ctIn)
+	eval.Evaluator.Power(ctIn, degree, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) PowerNew(ctIn *Ciphertext, degree int) (ctOut *Ciphertext) {
+	done := eval.trace("PowerNew", ctIn)
+	ctOut = eval.Evaluator.PowerNew(ctIn, degree)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) EvaluatePoly(ctIn *Ciphertext, pol *Polynomial, targetScale float64) (ctOut *Ciphertext, err error) {
+	done := eval.trace("EvaluatePoly", ctIn)
+	ctOut, err = eval.Evaluator.EvaluatePoly(ctIn, pol, targetScale)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) EvaluatePolyScaleInvariant(ctIn *Ciphertext, pol *Polynomial, targetScale float64, levelStart, levelEnd int) (ctOut *Ciphertext, err error) {
+	done := eval.trace("EvaluatePolyScaleInvariant", ctIn)
+	ctOut, err = eval.Evaluator.EvaluatePolyScaleInvariant(ctIn, pol, targetScale, levelStart, levelEnd)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) EvaluatePolyVector(ctIn *Ciphertext, pols []*Polynomial, encoder Encoder, slotIndex map[int][]int, targetScale float64) (ctOut *Ciphertext, err error) {
+	done := eval.trace("EvaluatePolyVector", ctIn)
+	ctOut, err = eval.Evaluator.EvaluatePolyVector(ctIn, pols, encoder, slotIndex, targetScale)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) InverseNew(ctIn *Ciphertext, steps int) (ctOut *Ciphertext) {
+	done := eval.trace("InverseNew", ctIn)
+	ctOut = eval.Evaluator.InverseNew(ctIn, steps)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) InnerSumLog(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext) {
+	done := eval.trace("InnerSumLog", ctIn)
+	eval.Evaluator.InnerSumLog(ctIn, batch, n, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) InnerSum(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext) {
+	done := eval.trace("InnerSum", ctIn)
+	eval.Evaluator.InnerSum(ctIn, batch, n, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) Average(ctIn *Ciphertext, batch int, ctOut *Ciphertext) {
+	done := eval.trace("Average", ctIn)
+	eval.Evaluator.Average(ctIn, batch, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) ReplicateLog(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext) {
+	done := eval.trace("ReplicateLog", ctIn)
+	eval.Evaluator.ReplicateLog(ctIn, batch, n, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) Replicate(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext) {
+	done := eval.trace("Replicate", ctIn)
+	eval.Evaluator.Replicate(ctIn, batch, n, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) Trace(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int, ctOut *Ciphertext) {
+	done := eval.trace("Trace", ctIn)
+	eval.Evaluator.Trace(ctIn, logSlotsStart, logSlotsEnd, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) TraceNew(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int) (ctOut *Ciphertext) {
+	done := eval.trace("TraceNew", ctIn)
+	ctOut = eval.Evaluator.TraceNew(ctIn, logSlotsStart, logSlotsEnd)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) SwitchKeysNew(ctIn *Ciphertext, switchingKey *rlwe.SwitchingKey) (ctOut *Ciphertext) {
+	done := eval.trace("SwitchKeysNew", ctIn)
+	ctOut = eval.Evaluator.SwitchKeysNew(ctIn, switchingKey)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) SwitchKeys(ctIn *Ciphertext, switchingKey *rlwe.SwitchingKey, ctOut *Ciphertext) {
+	done := eval.trace("SwitchKeys", ctIn)
+	eval.Evaluator.SwitchKeys(ctIn, switchingKey, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) RelinearizeNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("RelinearizeNew", ctIn)
+	ctOut = eval.Evaluator.RelinearizeNew(ctIn)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Relinearize(ctIn *Ciphertext, ctOut *Ciphertext) {
+	done := eval.trace("Relinearize", ctIn)
+	eval.Evaluator.Relinearize(ctIn, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) ScaleUpNew(ctIn *Ciphertext, scale float64) (ctOut *Ciphertext) {
+	done := eval.trace("ScaleUpNew", ctIn)
+	ctOut = eval.Evaluator.ScaleUpNew(ctIn, scale)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) ScaleUp(ctIn *Ciphertext, scale float64, ctOut *Ciphertext) {
+	done := eval.trace("ScaleUp", ctIn)
+	eval.Evaluator.ScaleUp(ctIn, scale, ctOut)
+	done(ctOut)
+}
+
+func (eval *tracingEvaluator) SetScale(ctIn *Ciphertext, scale float64) {
+	done := eval.trace("SetScale", ctIn)
+	eval.Evaluator.SetScale(ctIn, scale)
+	done(ctIn)
+}
+
+func (eval *tracingEvaluator) Rescale(ctIn *Ciphertext, minScale float64, ctOut *Ciphertext) (err error) {
+	done := eval.trace("Rescale", ctIn)
+	err = eval.Evaluator.Rescale(ctIn, minScale, ctOut)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) DropLevelNew(ctIn *Ciphertext, levels int) (ctOut *Ciphertext) {
+	done := eval.trace("DropLevelNew", ctIn)
+	ctOut = eval.Evaluator.DropLevelNew(ctIn, levels)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) DropLevel(ctIn *Ciphertext, levels int) {
+	done := eval.trace("DropLevel", ctIn)
+	eval.Evaluator.DropLevel(ctIn, levels)
+	done(ctIn)
+}
+
+func (eval *tracingEvaluator) ReduceNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	done := eval.trace("ReduceNew", ctIn)
+	ctOut = eval.Evaluator.ReduceNew(ctIn)
+	done(ctOut)
+	return
+}
+
+func (eval *tracingEvaluator) Reduce(ctIn *Ciphertext, ctOut *Ciphertext) (err error) {
+	done := eval.trace("Reduce", ctIn)
+	err = eval.Evaluator.Reduce(ctIn, ctOut)
+	done(ctOut)
+	return
+}
+
+// ShallowCopy creates a shallow copy of the underlying Evaluator and returns it wrapped with the same hook.
+func (eval *tracingEvaluator) ShallowCopy() Evaluator {
+	return NewTracingEvaluator(eval.Evaluator.ShallowCopy(), eval.hook)
+}
+
+// WithKey creates a shallow copy of the underlying Evaluator with the new key and returns it wrapped with the same hook.
+func (eval *tracingEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return NewTracingEvaluator(eval.Evaluator.WithKey(evaluationKey), eval.hook)
+}
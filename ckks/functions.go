@@ -0,0 +1,212 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+)
+
+// Function is a homomorphic function of the library of approximate functions.
+// Each function is approximated over a user-defined interval, outside of which its result is undefined, and
+// documents the trade-off between its depth and its precision:
+//
+// - Evaluate evaluates the function on the slots of the input Ciphertext and returns the result in a new Ciphertext
+// of the same scale. The input Ciphertext is not modified. It returns an error if the input does not have enough levels.
+//
+// - Depth returns the number of levels consumed by Evaluate.
+//
+// - MaxError returns the maximum absolute error of the approximation over its interval, excluding the error
+// introduced by the homomorphic evaluation, which depends on the scale of the input.
+//
+// The functions approximated by a polynomial (NewSigmoid, NewExp, NewLog, NewSqrt, NewReLU, NewGeLU and
+// NewPolynomialFunction) use the minimax approximation of the requested degree, which consumes
+// ceil(log2(degree+1)) levels, plus one level to map the interval to [-1, 1] if it is not already [-1, 1].
+// As a rule of thumb, doubling the degree of a smooth function (sigmoid, exp, GeLU) doubles the number of correct
+// bits of the approximation for one more level, whereas the error of the functions that are not smooth on their
+// interval (sqrt around 0, ReLU) only decreases linearly with the degree.
+type Function interface {
+	Evaluate(ct *Ciphertext) (ctOut *Ciphertext, err error)
+	Depth() int
+	MaxError() float64
+}
+
+// PolynomialFunction is a Function evaluated with its minimax polynomial approximation over an interval.
+type PolynomialFunction struct {
+	eval   Evaluator
+	poly   *Polynomial
+	maxErr float64
+}
+
+// NewPolynomialFunction creates a PolynomialFunction that evaluates f with eval, using its minimax polynomial
+// approximation of degree degree over the interval [a, b]. It returns an error if ApproximateMinimax fails.
+// The Evaluator must have a relinearization key.
+func NewPolynomialFunction(eval Evaluator, f func(float64) float64, a, b float64, degree int) (*PolynomialFunction, error) {
+
+	poly, maxErr, err := ApproximateMinimax(f, a, b, degree)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PolynomialFunction{eval: eval, poly: poly, maxErr: maxErr}, nil
+}
+
+// NewSigmoid creates a PolynomialFunction evaluating the sigmoid 1/(1+exp(-x)) over [a, b] with a polynomial of degree degree.
+// Over [-8, 8], the degrees 15, 31 and 63 give a precision of about 2^-10, 2^-19 and 2^-36.
+func NewSigmoid(eval Evaluator, a, b float64, degree int) (*PolynomialFunction, error) {
+	return NewPolynomialFunction(eval, func(x float64) float64 { return 1 / (1 + math.Exp(-x)) }, a, b, degree)
+}
+
+// NewExp creates a PolynomialFunction evaluating exp(x) over [a, b] with a polynomial of degree degree.
+// Over [-1, 1], the degrees 7 and 15 give a precision of about 2^-22 and 2^-50, the limit of the double precision.
+func NewExp(eval Evaluator, a, b float64, degree int) (*PolynomialFunction, error) {
+	return NewPolynomialFunction(eval, math.Exp, a, b, degree)
+}
+
+// NewLog creates a PolynomialFunction evaluating the natural logarithm log(x) over [a, b], with 0 < a, with a polynomial
+// of degree degree. The precision degrades quickly as a approaches 0: over [1, 16], the degrees 15, 31 and 63 give a
+// precision of about 2^-14, 2^-27 and 2^-46.
+func NewLog(eval Evaluator, a, b float64, degree int) (*PolynomialFunction, error) {
+
+	if !(a > 0) {
+		return nil, fmt.Errorf("cannot NewLog: the interval [%f, %f] must be positive", a, b)
+	}
+
+	return NewPolynomialFunction(eval, math.Log, a, b, degree)
+}
+
+// NewSqrt creates a PolynomialFunction evaluating sqrt(x) over [a, b], with 0 <= a, with a polynomial of degree degree.
+// If a = 0, the function is not smooth on the interval and the error only decreases linearly with the degree:
+// over [0, 1], the degrees 15, 31 and 63 give a precision of about 2^-6.7, 2^-7.8 and 2^-8.8.
+func NewSqrt(eval Evaluator, a, b float64, degree int) (*PolynomialFunction, error) {
+
+	if !(a >= 0) {
+		return nil, fmt.Errorf("cannot NewSqrt: the interval [%f, %f] must be non-negative", a, b)
+	}
+
+	return NewPolynomialFunction(eval, math.Sqrt, a, b, degree)
+}
+
+// NewReLU creates a PolynomialFunction evaluating the rectified linear unit max(0, x) over [a, b] with a polynomial of
+// degree degree. The function is not smooth at 0 and the error only decreases linearly with the degree: over [-1, 1],
+// the degrees 15, 31 and 63 give a precision of about 2^-6.6, 2^-7.7 and 2^-8.8.
+func NewReLU(eval Evaluator, a, b float64, degree int) (*PolynomialFunction, error) {
+	return NewPolynomialFunction(eval, func(x float64) float64 { return math.Max(0, x) }, a, b, degree)
+}
+
+// NewGeLU creates a PolynomialFunction evaluating the Gaussian error linear unit x * (1 + erf(x/sqrt(2)))/2 over [a, b]
+// with a polynomial of degree degree. Over [-8, 8], the degrees 15, 31 and 63 give a precision of about 2^-5, 2^-14
+// and 2^-41.
+func NewGeLU(eval Evaluator, a, b float64, degree int) (*PolynomialFunction, error) {
+	return NewPolynomialFunction(eval, func(x float64) float64 { return 0.5 * x * (1 + math.Erf(x/math.Sqrt2)) }, a, b, degree)
+}
+
+// Polynomial returns the polynomial approximating the function.
+func (f *PolynomialFunction) Polynomial() *Polynomial {
+	return f.poly
+}
+
+// Depth returns the number of levels consumed by Evaluate.
+func (f *PolynomialFunction) Depth() int {
+	if f.poly.A == -1 && f.poly.B == 1 {
+		return f.poly.Depth()
+	}
+	return f.poly.Depth() + 1
+}
+
+// MaxError returns the maximum absolute error of the polynomial approximation over its interval.
+func (f *PolynomialFunction) MaxError() float64 {
+	return f.maxErr
+}
+
+// Evaluate evaluates the function on ct and returns the result in a new Ciphertext of the same scale.
+// It returns an error if ct does not have enough levels.
+func (f *PolynomialFunction) Evaluate(ct *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	if ct.Level() < f.Depth() {
+		return nil, fmt.Errorf("cannot Evaluate: %d levels < %d depth", ct.Level(), f.Depth())
+	}
+
+	ctOut = ct.CopyNew()
+
+	// Maps [a, b] to [-1, 1]
+	if a, b := f.poly.A, f.poly.B; a != -1 || b != 1 {
+
+		f.eval.MultByConst(ctOut, 2/(b-a), ctOut)
+		f.eval.AddConst(ctOut, (-a-b)/(b-a), ctOut)
+
+		if err = f.eval.Rescale(ctOut, ct.Scale, ctOut); err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: %w", err)
+		}
+	}
+
+	if ctOut, err = f.eval.EvaluatePoly(ctOut, f.poly, ct.Scale); err != nil {
+		return nil, fmt.Errorf("cannot Evaluate: %w", err)
+	}
+
+	return ctOut, nil
+}
+
+// InverseFunction is a Function evaluating 1/x over an interval [a, b] of positive values with the Newton iteration.
+type InverseFunction struct {
+	eval  Evaluator
+	a, b  float64
+	steps int
+}
+
+// NewInverse creates an InverseFunction evaluating 1/x over [a, b], with 0 < a < b, with steps iterations of the
+// Newton iteration implemented by Evaluator.InverseNew. The input is first scaled by 2/(a+b) to the interval
+// [1-r, 1+r] with r = (b-a)/(b+a), on which the relative error after steps iterations is r^(2^steps): each iteration
+// doubles the number of correct bits for one more level. For example, over [1, 16], 4, 6 and 8 iterations give a
+// relative precision of about 2^-2, 2^-11 and 2^-46. The Evaluator must have a relinearization key.
+func NewInverse(eval Evaluator, a, b float64, steps int) (*InverseFunction, error) {
+
+	if !(a > 0 && a < b) {
+		return nil, fmt.Errorf("cannot NewInverse: invalid interval [%f, %f], must satisfy 0 < a < b", a, b)
+	}
+
+	if steps < 1 {
+		return nil, fmt.Errorf("cannot NewInverse: steps must be positive but is %d", steps)
+	}
+
+	return &InverseFunction{eval: eval, a: a, b: b, steps: steps}, nil
+}
+
+// Depth returns the number of levels consumed by Evaluate.
+func (f *InverseFunction) Depth() int {
+	// The first iteration of InverseNew does not consume any level
+	if f.steps == 1 {
+		return 2
+	}
+	return f.steps + 2
+}
+
+// MaxError returns the maximum absolute error of the Newton iteration over its interval.
+func (f *InverseFunction) MaxError() float64 {
+	r := (f.b - f.a) / (f.b + f.a)
+	return math.Pow(r, math.Pow(2, float64(f.steps))) / f.a
+}
+
+// Evaluate evaluates 1/x on ct and returns the result in a new Ciphertext of the same scale.
+// It returns an error if ct does not have enough levels.
+func (f *InverseFunction) Evaluate(ct *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	if ct.Level() < f.Depth() {
+		return nil, fmt.Errorf("cannot Evaluate: %d levels < %d depth", ct.Level(), f.Depth())
+	}
+
+	// 1/x = c * 1/(c*x) with c = 2/(a+b), such that c*x is in [1-r, 1+r]
+	c := 2 / (f.a + f.b)
+
+	ctOut = f.eval.MultByConstNew(ct, c)
+	if err = f.eval.Rescale(ctOut, ct.Scale, ctOut); err != nil {
+		return nil, fmt.Errorf("cannot Evaluate: %w", err)
+	}
+
+	ctOut = f.eval.InverseNew(ctOut, f.steps)
+
+	f.eval.MultByConst(ctOut, c, ctOut)
+	if err = f.eval.Rescale(ctOut, ct.Scale, ctOut); err != nil {
+		return nil, fmt.Errorf("cannot Evaluate: %w", err)
+	}
+
+	return ctOut, nil
+}
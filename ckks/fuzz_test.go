@@ -0,0 +1,100 @@
+//go:build go1.18
+// +build go1.18
+
+package ckks
+
+import (
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// The fuzz targets check that the decoders of the objects received from untrusted parties return an error instead of
+// panicking or allocating more than their input on invalid encodings, e.g. with
+//
+//	go test ./ckks -run '^$' -fuzz FuzzCiphertextUnmarshalBinary
+
+// newFuzzParameters returns small parameters, so that the seeds of the fuzz targets are short.
+func newFuzzParameters(t testing.TB) Parameters {
+	logN := 5
+	params, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:         logN,
+		Q:            ring.GenerateNTTPrimes(30, 2<<logN, 2),
+		P:            ring.GenerateNTTPrimes(31, 2<<logN, 1),
+		Sigma:        rlwe.DefaultSigma,
+		LogSlots:     logN - 1,
+		DefaultScale: 1 << 20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return params
+}
+
+func FuzzParametersUnmarshalBinary(f *testing.F) {
+	data, err := newFuzzParameters(f).MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var params Parameters
+		if params.UnmarshalBinary(data) != nil {
+			return
+		}
+		if _, err := params.MarshalBinary(); err != nil {
+			t.Fatalf("cannot marshal decoded parameters: %s", err)
+		}
+	})
+}
+
+func FuzzParametersUnmarshalJSON(f *testing.F) {
+	data, err := newFuzzParameters(f).MarshalJSON()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		new(Parameters).UnmarshalJSON(data)
+	})
+}
+
+func FuzzCiphertextUnmarshalBinaryLossy(f *testing.F) {
+	params := newFuzzParameters(f)
+	sk := NewKeyGenerator(params).GenSecretKey()
+	compressor := NewCiphertextCompressor(params)
+	data, err := compressor.MarshalBinaryLossy(NewEncryptor(params, sk).EncryptNew(NewPlaintext(params, params.MaxLevel(), params.DefaultScale())), 1, 8)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ct := new(Ciphertext)
+		if compressor.UnmarshalBinaryLossy(data, ct) != nil {
+			return
+		}
+		if _, err := compressor.MarshalBinaryLossy(ct, 1, 8); err != nil {
+			t.Fatalf("cannot marshal a decoded ciphertext: %s", err)
+		}
+	})
+}
+
+func FuzzCiphertextUnmarshalBinary(f *testing.F) {
+	params := newFuzzParameters(f)
+	sk := NewKeyGenerator(params).GenSecretKey()
+	data, err := NewEncryptor(params, sk).EncryptNew(NewPlaintext(params, params.MaxLevel(), params.DefaultScale())).MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ct := new(Ciphertext)
+		if ct.UnmarshalBinary(data) != nil {
+			return
+		}
+		if _, err := ct.MarshalBinary(); err != nil {
+			t.Fatalf("cannot marshal a decoded ciphertext: %s", err)
+		}
+	})
+}
@@ -285,13 +285,20 @@ func GenLinearTransformBSGS(encoder Encoder, value interface{}, level int, scale
 		panic("encoder should be an encoderComplex128")
 	}
 
+	// N1*N2 = N
+	N1 := FindBestBSGSSplit(value, 1<<logSlots, BSGSRatio)
+
+	return genLinearTransformBSGS(enc, value, level, scale, N1, logSlots)
+}
+
+// genLinearTransformBSGS allocates and encodes a new LinearTransform struct from the linear transforms' matrix in diagonal form `value`
+// for evaluation with a baby-step giant-step approach whose inner loop has N1 iterations.
+func genLinearTransformBSGS(enc *encoderComplex128, value interface{}, level int, scale float64, N1, logSlots int) (LT LinearTransform) {
+
 	params := enc.params
 
 	slots := 1 << logSlots
 
-	// N1*N2 = N
-	N1 := FindBestBSGSSplit(value, slots, BSGSRatio)
-
 	index, _, _ := BsgsIndex(value, slots, N1)
 
 	vec := make(map[int]rlwe.PolyQP)
@@ -851,6 +858,14 @@ func (eval *evaluator) MultiplyByDiagMatrix(ctIn *Ciphertext, matrix LinearTrans
 		}
 	}
 
+	// The matrix only has the diagonal zero: there is no key-switched term
+	if cnt == 0 {
+		ringQ.MulCoeffsMontgomeryLvl(levelQ, matrix.Vec[0].Q, ctInTmp0, c0OutQP.Q) // ctOut = c0_Q * plaintext
+		ringQ.MulCoeffsMontgomeryLvl(levelQ, matrix.Vec[0].Q, ctInTmp1, c1OutQP.Q) // ctOut = c1_Q * plaintext
+		ctOut.Scale = matrix.Scale * ctIn.Scale
+		return
+	}
+
 	if cnt%QiOverF == 0 {
 		ringQ.ReduceLvl(levelQ, c0OutQP.Q, c0OutQP.Q)
 		ringQ.ReduceLvl(levelQ, c1OutQP.Q, c1OutQP.Q)
@@ -0,0 +1,246 @@
+package ckks
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// BlockLinearTransform is a type for linear transformations by matrices of arbitrary dimensions on vectors that
+// span several ciphertexts. A vector of dimension Cols is encrypted in InputCiphertexts() ciphertexts, the i-th one
+// storing the entries [i*2^LogSlots, (i+1)*2^LogSlots) of the vector, zero-padded, and the result of dimension Rows
+// is returned in OutputCiphertexts() ciphertexts with the same layout.
+// The matrix is split in square blocks of dimension 2^LogSlots, zero-padded, and Blocks[i][j] is the LinearTransform
+// of the block mapping the j-th input ciphertext to the i-th output ciphertext, or nil if this block is zero.
+type BlockLinearTransform struct {
+	Rows     int
+	Cols     int
+	LogSlots int
+	Level    int
+	Scale    float64
+	Blocks   [][]*LinearTransform
+}
+
+// GenBlockLinearTransform allocates and encodes a new BlockLinearTransform from the matrix `matrix`, given as a slice
+// of rows, which can be either [][]complex128 or [][]float64. All the rows must have the same number of columns.
+// The non-zero blocks are encoded at the given level and scale for vectors of 2^logSlots slots, and each block is
+// evaluated either naively or with the baby-step giant-step approach, whichever requires the fewest rotations.
+// The method panics if the encoder is not an encoderComplex128 or if the matrix is empty or not rectangular.
+func GenBlockLinearTransform(encoder Encoder, matrix interface{}, level int, scale float64, logSlots int) (BLT BlockLinearTransform) {
+
+	enc, ok := encoder.(*encoderComplex128)
+	if !ok {
+		panic("encoder should be an encoderComplex128")
+	}
+
+	var rows, cols int
+	var at func(i, j int) complex128
+
+	switch m := matrix.(type) {
+	case [][]complex128:
+		rows = len(m)
+		if rows != 0 {
+			cols = len(m[0])
+		}
+		for i := range m {
+			if len(m[i]) != cols {
+				panic(fmt.Sprintf("cannot GenBlockLinearTransform: row %d has %d columns but row 0 has %d", i, len(m[i]), cols))
+			}
+		}
+		at = func(i, j int) complex128 { return m[i][j] }
+	case [][]float64:
+		rows = len(m)
+		if rows != 0 {
+			cols = len(m[0])
+		}
+		for i := range m {
+			if len(m[i]) != cols {
+				panic(fmt.Sprintf("cannot GenBlockLinearTransform: row %d has %d columns but row 0 has %d", i, len(m[i]), cols))
+			}
+		}
+		at = func(i, j int) complex128 { return complex(m[i][j], 0) }
+	default:
+		panic("invalid input, must be [][]complex128 or [][]float64")
+	}
+
+	if rows == 0 || cols == 0 {
+		panic("cannot GenBlockLinearTransform: matrix is empty")
+	}
+
+	slots := 1 << logSlots
+
+	BLT = BlockLinearTransform{Rows: rows, Cols: cols, LogSlots: logSlots, Level: level, Scale: scale}
+
+	BLT.Blocks = make([][]*LinearTransform, BLT.OutputCiphertexts())
+
+	for bi := range BLT.Blocks {
+
+		BLT.Blocks[bi] = make([]*LinearTransform, BLT.InputCiphertexts())
+
+		for bj := range BLT.Blocks[bi] {
+
+			// The k-th diagonal of the block is d_k[i] = M[bi*slots + i, bj*slots + (i+k) mod slots]
+			diags := make(map[int][]complex128)
+
+			for i := 0; i < slots && bi*slots+i < rows; i++ {
+				for k := 0; k < slots; k++ {
+
+					j := (i + k) & (slots - 1)
+
+					if bj*slots+j >= cols {
+						continue
+					}
+
+					if v := at(bi*slots+i, bj*slots+j); v != 0 {
+
+						if _, ok := diags[k]; !ok {
+							diags[k] = make([]complex128, slots)
+						}
+
+						diags[k][i] = v
+					}
+				}
+			}
+
+			if len(diags) == 0 {
+				continue
+			}
+
+			var LT LinearTransform
+			if N1 := bestBSGSSplit(diags, slots); N1 == 0 {
+				LT = GenLinearTransform(encoder, diags, level, scale, logSlots)
+			} else {
+				LT = genLinearTransformBSGS(enc, diags, level, scale, N1, logSlots)
+			}
+
+			BLT.Blocks[bi][bj] = &LT
+		}
+	}
+
+	return
+}
+
+// bestBSGSSplit returns the size N1 of the inner loop of the baby-step giant-step evaluation of the matrix in diagonal
+// form diags that minimizes the number of rotations, or 0 if the naive evaluation requires fewer rotations.
+func bestBSGSSplit(diags map[int][]complex128, slots int) (N1 int) {
+
+	minRot := len(diags)
+
+	for n1 := 1; n1 < slots; n1 <<= 1 {
+		if _, rotN1, rotN2 := BsgsIndex(diags, slots, n1); len(rotN1)+len(rotN2) < minRot {
+			minRot = len(rotN1) + len(rotN2)
+			N1 = n1
+		}
+	}
+
+	return
+}
+
+// InputCiphertexts returns the number of ciphertexts encrypting the input vectors.
+func (BLT *BlockLinearTransform) InputCiphertexts() int {
+	return (BLT.Cols + (1 << BLT.LogSlots) - 1) >> BLT.LogSlots
+}
+
+// OutputCiphertexts returns the number of ciphertexts encrypting the output vectors.
+func (BLT *BlockLinearTransform) OutputCiphertexts() int {
+	return (BLT.Rows + (1 << BLT.LogSlots) - 1) >> BLT.LogSlots
+}
+
+// Rotations returns the list of rotations needed for the evaluation of the BlockLinearTransform.
+func (BLT *BlockLinearTransform) Rotations() (rotations []int) {
+
+	rotIndex := make(map[int]bool)
+
+	for i := range BLT.Blocks {
+		for _, LT := range BLT.Blocks[i] {
+			if LT != nil {
+				for _, rot := range LT.Rotations() {
+					if rot != 0 {
+						rotIndex[rot] = true
+					}
+				}
+			}
+		}
+	}
+
+	rotations = make([]int, 0, len(rotIndex))
+	for rot := range rotIndex {
+		rotations = append(rotations, rot)
+	}
+
+	return
+}
+
+// BlockLinearTransformNew evaluates the BlockLinearTransform on the vector encrypted in ctIn and returns the result
+// in OutputCiphertexts() new ciphertexts. The ciphertexts of ctIn must have the same scale, and the outputs are at the
+// minimum level of the inputs and BLT.Level, with the scale BLT.Scale times the scale of the inputs.
+// The linear transforms of the blocks of a same input ciphertext share the decomposition of this ciphertext.
+// The method panics if the number of input ciphertexts does not match BLT.InputCiphertexts().
+func (eval *evaluator) BlockLinearTransformNew(ctIn []*Ciphertext, BLT BlockLinearTransform) (ctOut []*Ciphertext) {
+
+	if len(ctIn) != BLT.InputCiphertexts() {
+		panic(fmt.Sprintf("cannot BlockLinearTransform: %d input ciphertexts but the matrix has %d column blocks", len(ctIn), BLT.InputCiphertexts()))
+	}
+
+	level := BLT.Level
+	for _, ct := range ctIn {
+		level = utils.MinInt(level, ct.Level())
+	}
+
+	ctOut = make([]*Ciphertext, BLT.OutputCiphertexts())
+
+	for j := range ctIn {
+
+		var LTs []LinearTransform
+		var index []int
+
+		for i := range BLT.Blocks {
+			if LT := BLT.Blocks[i][j]; LT != nil {
+				LTs = append(LTs, *LT)
+				index = append(index, i)
+			}
+		}
+
+		if len(LTs) == 0 {
+			continue
+		}
+
+		res := eval.LinearTransformNew(ctIn[j], LTs)
+
+		for k, i := range index {
+			if ctOut[i] == nil {
+				ctOut[i] = res[k]
+			} else {
+				eval.Add(ctOut[i], res[k], ctOut[i])
+			}
+		}
+	}
+
+	// The outputs of the zero rows of blocks are encryptions of zero
+	for i := range ctOut {
+		if ctOut[i] == nil {
+			ctOut[i] = NewCiphertext(eval.params, 1, level, BLT.Scale*ctIn[0].Scale)
+		}
+	}
+
+	return
+}
+
+// BlockLinearTransform evaluates the BlockLinearTransform on the vector encrypted in ctIn and returns the result in
+// the pre-allocated ciphertexts ctOut, which can alias ctIn. See BlockLinearTransformNew for the levels and scales of
+// the outputs. The method panics if the number of input or output ciphertexts does not match the dimensions of BLT.
+func (eval *evaluator) BlockLinearTransform(ctIn []*Ciphertext, BLT BlockLinearTransform, ctOut []*Ciphertext) {
+
+	if len(ctOut) != BLT.OutputCiphertexts() {
+		panic(fmt.Sprintf("cannot BlockLinearTransform: %d output ciphertexts but the matrix has %d row blocks", len(ctOut), BLT.OutputCiphertexts()))
+	}
+
+	res := eval.BlockLinearTransformNew(ctIn, BLT)
+
+	for i := range ctOut {
+		level := utils.MinInt(res[i].Level(), ctOut[i].Level())
+		ctOut[i].Value[0].Coeffs = ctOut[i].Value[0].Coeffs[:level+1]
+		ctOut[i].Value[1].Coeffs = ctOut[i].Value[1].Coeffs[:level+1]
+		ctOut[i].Copy(res[i])
+	}
+}
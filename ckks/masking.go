@@ -0,0 +1,144 @@
+package ckks
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RangeMask returns the mask of 2^logSlots values selecting the slots of indexes in [start, end).
+func RangeMask(logSlots, start, end int) (mask []float64) {
+
+	slots := 1 << logSlots
+
+	if start < 0 || end > slots || start > end {
+		panic(fmt.Sprintf("cannot RangeMask: invalid range [%d, %d) for %d slots", start, end, slots))
+	}
+
+	mask = make([]float64, slots)
+	for i := start; i < end; i++ {
+		mask[i] = 1
+	}
+
+	return
+}
+
+// OneHotMask returns the mask of 2^logSlots values selecting the slot of the given index.
+func OneHotMask(logSlots, index int) (mask []float64) {
+	return RangeMask(logSlots, index, index+1)
+}
+
+// RotationsForDuplicateSlot returns the rotations required by Masker.DuplicateSlotNew for ciphertexts of 2^logSlots slots.
+func (p Parameters) RotationsForDuplicateSlot(logSlots int) []int {
+	return p.RotationsForInnerSumLog(1, 1<<logSlots)
+}
+
+// Masker multiplies ciphertexts by masks, i.e. vectors of real values (usually zeros and ones) selecting their slots.
+// The masks are encoded at the level of the ciphertexts and with the scale of the modulus removed by the following
+// rescaling, so that each masking consumes exactly one level and preserves the scale of the ciphertexts.
+// The masks are vectors of 2^logSlots values for ciphertexts of 2^logSlots slots.
+type Masker struct {
+	params  Parameters
+	eval    Evaluator
+	encoder Encoder
+}
+
+// NewMasker creates a new Masker from an Evaluator and an Encoder.
+func NewMasker(params Parameters, eval Evaluator, encoder Encoder) *Masker {
+	return &Masker{params: params, eval: eval, encoder: encoder}
+}
+
+func logSlotsOfMask(mask []float64) int {
+	if len(mask) == 0 || len(mask)&(len(mask)-1) != 0 {
+		panic(fmt.Sprintf("invalid mask: length %d is not a power of two", len(mask)))
+	}
+	return bits.Len64(uint64(len(mask))) - 1
+}
+
+// EncodeMaskNew encodes the mask on a new plaintext at the given level, with the scale of the modulus at this level,
+// so that the product of a ciphertext by this plaintext followed by a rescaling preserves the scale of the ciphertext.
+func (m *Masker) EncodeMaskNew(mask []float64, level int) (pt *Plaintext) {
+	return m.encoder.EncodeNew(mask, level, m.params.QiFloat64(level), logSlotsOfMask(mask))
+}
+
+// MaskNew returns ctIn multiplied by the mask on a new ciphertext, at the level of ctIn minus one and at the same scale.
+// The method panics if ctIn is at level 0.
+func (m *Masker) MaskNew(ctIn *Ciphertext, mask []float64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(m.params, ctIn.Degree(), ctIn.Level(), ctIn.Scale)
+	m.Mask(ctIn, mask, ctOut)
+	return
+}
+
+// Mask multiplies ctIn by the mask and returns the result on ctOut, at the level of ctIn minus one and at the same scale.
+// The method panics if ctIn is at level 0.
+func (m *Masker) Mask(ctIn *Ciphertext, mask []float64, ctOut *Ciphertext) {
+
+	if ctIn.Level() == 0 {
+		panic("cannot Mask: input ciphertext is at level 0")
+	}
+
+	scale := ctIn.Scale
+	m.eval.Mul(ctIn, m.EncodeMaskNew(mask, ctIn.Level()), ctOut)
+	m.rescale(ctOut, scale)
+}
+
+// rescale divides ct by its last modulus and sets its scale to scale, to cancel the rounding errors of the
+// floating point computation of the scale.
+func (m *Masker) rescale(ct *Ciphertext, scale float64) {
+	if err := m.eval.Rescale(ct, scale, ct); err != nil {
+		panic(err)
+	}
+	ct.Scale = scale
+}
+
+// MaskRangeNew returns a new ciphertext in which the slots of ctIn of indexes in [start, end) are kept and the others
+// are set to zero. ctIn must have 2^logSlots slots.
+func (m *Masker) MaskRangeNew(ctIn *Ciphertext, start, end, logSlots int) (ctOut *Ciphertext) {
+	return m.MaskNew(ctIn, RangeMask(logSlots, start, end))
+}
+
+// DuplicateSlotNew returns a new ciphertext of 2^logSlots slots all equal to the slot of the given index of ctIn.
+// It consumes one level and requires the rotation keys of Parameters.RotationsForDuplicateSlot.
+func (m *Masker) DuplicateSlotNew(ctIn *Ciphertext, index, logSlots int) (ctOut *Ciphertext) {
+	// The sum of all the rotations of a vector with a single non-zero slot copies this slot everywhere.
+	ctOut = m.MaskNew(ctIn, OneHotMask(logSlots, index))
+	m.eval.InnerSumLog(ctOut, 1, 1<<logSlots, ctOut)
+	return
+}
+
+// MergeNew returns the sum of the ciphertexts of cts, each multiplied by the mask of same index of masks, on a new
+// ciphertext. The ciphertexts must have the same scale, and the result is at the minimum level of the ciphertexts
+// minus one, with the same scale. A single rescaling is applied to the sum.
+// With disjoint masks, this merges the selected slots of each ciphertext in a single ciphertext.
+func (m *Masker) MergeNew(cts []*Ciphertext, masks [][]float64) (ctOut *Ciphertext) {
+
+	if len(cts) == 0 || len(cts) != len(masks) {
+		panic("cannot MergeNew: the number of ciphertexts and masks must be equal and non-zero")
+	}
+
+	level := cts[0].Level()
+	degree := cts[0].Degree()
+	scale := cts[0].Scale
+	for _, ct := range cts[1:] {
+		if ct.Scale != scale {
+			panic("cannot MergeNew: ciphertexts must have the same scale")
+		}
+		level = utils.MinInt(level, ct.Level())
+		degree = utils.MaxInt(degree, ct.Degree())
+	}
+
+	if level == 0 {
+		panic("cannot MergeNew: input ciphertexts must be at level 1 or larger")
+	}
+
+	ctOut = NewCiphertext(m.params, degree, level, scale*m.params.QiFloat64(level))
+
+	for i := range cts {
+		m.eval.MulAndAdd(cts[i], m.EncodeMaskNew(masks[i], level), ctOut)
+	}
+
+	m.rescale(ctOut, scale)
+
+	return
+}
@@ -0,0 +1,259 @@
+package ckks
+
+import (
+	"fmt"
+)
+
+// matrixBSGSRatio is the ratio between the inner and outer loops of the baby-step giant-step evaluation of the
+// permutations of the matrix multiplication with many diagonals.
+const matrixBSGSRatio = 4.0
+
+// MatrixMultiplier evaluates products of encrypted matrices with the algorithm of Jiang et al.,
+// "Secure Outsourced Matrix Computation and Application to Neural Networks" (https://eprint.iacr.org/2018/1041).
+//
+// The slots are split in Batch() blocks of Dim() x Dim() slots, each block storing a square matrix of dimension Dim()
+// in row-major order, so that a ciphertext encrypts a batch of matrices that are multiplied block-wise.
+// The product of two matrices A and B of dimension d is computed as sum_{k=0}^{d-1} phi^k(sigma(A)) * psi^k(tau(B)),
+// where sigma, tau, phi^k and psi^k are permutations of the slots evaluated as LinearTransform.
+// Rectangular matrices of dimensions m x l and l x n, with m, l, n <= d, are multiplied by zero-padding them to
+// square matrices of dimension d, which EncodeMatrices and DecodeMatrices manage.
+//
+// A product consumes three levels: one for sigma and tau, one for phi^k and psi^k and one for the multiplications.
+type MatrixMultiplier struct {
+	params Parameters
+	dim    int
+	level  int
+
+	sigma, tau LinearTransform
+	phi, psi   []LinearTransform
+}
+
+// NewMatrixMultiplier creates a MatrixMultiplier for matrices of dimension dim, whose inputs are encrypted at the
+// level level. The permutations are encoded with the encoder at the scales of the moduli of their levels, so that
+// their rescaling leaves the scale of the ciphertexts unchanged.
+// It returns an error if a matrix of dimension dim does not fit in the slots or if the level is smaller than 3.
+func NewMatrixMultiplier(params Parameters, encoder Encoder, dim, level int) (*MatrixMultiplier, error) {
+
+	if dim < 1 || dim*dim > params.Slots() {
+		return nil, fmt.Errorf("cannot NewMatrixMultiplier: invalid dimension %d for %d slots", dim, params.Slots())
+	}
+
+	if level < 3 || level > params.MaxLevel() {
+		return nil, fmt.Errorf("cannot NewMatrixMultiplier: level %d must be in [3, %d]", level, params.MaxLevel())
+	}
+
+	mm := &MatrixMultiplier{params: params, dim: dim, level: level}
+
+	logSlots := params.LogSlots()
+
+	// sigma(A)_{i, j} = A_{i, i+j}
+	sigma := mm.diagonals(func(i, j int) (int, int) { return i, i + j })
+
+	// tau(B)_{i, j} = B_{i+j, j}
+	tau := mm.diagonals(func(i, j int) (int, int) { return i + j, j })
+
+	mm.sigma = GenLinearTransformBSGS(encoder, sigma, level, params.QiFloat64(level), matrixBSGSRatio, logSlots)
+	mm.tau = GenLinearTransformBSGS(encoder, tau, level, params.QiFloat64(level), matrixBSGSRatio, logSlots)
+
+	mm.phi = make([]LinearTransform, dim-1)
+	mm.psi = make([]LinearTransform, dim-1)
+
+	for k := 1; k < dim; k++ {
+
+		// phi^k(A)_{i, j} = A_{i, j+k}
+		phi := mm.diagonals(func(i, j int) (int, int) { return i, j + k })
+
+		// psi^k(B)_{i, j} = B_{i+k, j}
+		psi := mm.diagonals(func(i, j int) (int, int) { return i + k, j })
+
+		mm.phi[k-1] = GenLinearTransform(encoder, phi, level-1, params.QiFloat64(level-1), logSlots)
+		mm.psi[k-1] = GenLinearTransform(encoder, psi, level-1, params.QiFloat64(level-1), logSlots)
+	}
+
+	return mm, nil
+}
+
+// diagonals returns the non-zero diagonals of the permutation of the slots mapping, in each block, the entry (i, j)
+// to the entry perm(i, j), whose indexes are taken modulo the dimension.
+func (mm *MatrixMultiplier) diagonals(perm func(i, j int) (int, int)) (diags map[int][]complex128) {
+
+	slots := mm.params.Slots()
+	d := mm.dim
+
+	diags = make(map[int][]complex128)
+
+	for b := 0; b < mm.Batch(); b++ {
+		for i := 0; i < d; i++ {
+			for j := 0; j < d; j++ {
+
+				si, sj := perm(i, j)
+
+				out := b*d*d + i*d + j
+				in := b*d*d + (si%d)*d + sj%d
+
+				// The output slot out is the input slot out + k, hence lies on the k-th diagonal
+				k := (in - out + slots) % slots
+
+				if _, ok := diags[k]; !ok {
+					diags[k] = make([]complex128, slots)
+				}
+
+				diags[k][out] = 1
+			}
+		}
+	}
+
+	return
+}
+
+// Dim returns the dimension of the matrices.
+func (mm *MatrixMultiplier) Dim() int {
+	return mm.dim
+}
+
+// Batch returns the number of matrices encrypted in a ciphertext.
+func (mm *MatrixMultiplier) Batch() int {
+	return mm.params.Slots() / (mm.dim * mm.dim)
+}
+
+// Level returns the level of the inputs of the products.
+func (mm *MatrixMultiplier) Level() int {
+	return mm.level
+}
+
+// Depth returns the number of levels consumed by a product.
+func (mm *MatrixMultiplier) Depth() int {
+	return 3
+}
+
+// Rotations returns the rotations required to evaluate the products.
+func (mm *MatrixMultiplier) Rotations() (rotations []int) {
+
+	rotIndex := make(map[int]bool)
+
+	for _, LT := range append([]LinearTransform{mm.sigma, mm.tau}, append(mm.phi, mm.psi...)...) {
+		for _, rot := range LT.Rotations() {
+			if rot != 0 {
+				rotIndex[rot] = true
+			}
+		}
+	}
+
+	rotations = make([]int, 0, len(rotIndex))
+	for rot := range rotIndex {
+		rotations = append(rotations, rot)
+	}
+
+	return
+}
+
+// EncodeMatrices returns the slots encoding the matrices, each matrix being given as a slice of rows of at most
+// Dim() entries, and of at most Dim() rows. The matrices smaller than Dim() x Dim() are zero-padded.
+// It returns an error if there are more than Batch() matrices or if a matrix is too large.
+func (mm *MatrixMultiplier) EncodeMatrices(matrices [][][]complex128) (values []complex128, err error) {
+
+	if len(matrices) > mm.Batch() {
+		return nil, fmt.Errorf("cannot EncodeMatrices: %d matrices > %d batch", len(matrices), mm.Batch())
+	}
+
+	d := mm.dim
+
+	values = make([]complex128, mm.params.Slots())
+
+	for b, matrix := range matrices {
+
+		if len(matrix) > d {
+			return nil, fmt.Errorf("cannot EncodeMatrices: matrix %d has %d rows > %d", b, len(matrix), d)
+		}
+
+		for i, row := range matrix {
+
+			if len(row) > d {
+				return nil, fmt.Errorf("cannot EncodeMatrices: row %d of matrix %d has %d columns > %d", i, b, len(row), d)
+			}
+
+			copy(values[b*d*d+i*d:], row)
+		}
+	}
+
+	return values, nil
+}
+
+// DecodeMatrices returns the count first matrices of dimensions rows x cols stored in the slots values,
+// i.e. the top-left submatrices of the matrices of dimension Dim().
+func (mm *MatrixMultiplier) DecodeMatrices(values []complex128, count, rows, cols int) (matrices [][][]complex128) {
+
+	d := mm.dim
+
+	matrices = make([][][]complex128, count)
+
+	for b := range matrices {
+		matrices[b] = make([][]complex128, rows)
+		for i := range matrices[b] {
+			matrices[b][i] = make([]complex128, cols)
+			copy(matrices[b][i], values[b*d*d+i*d:b*d*d+i*d+cols])
+		}
+	}
+
+	return
+}
+
+// MulNew computes the products of the matrices encrypted in ctA and ctB and returns the result in a new Ciphertext.
+// The Evaluator must have the relinearization key and the rotation keys for the rotations returned by Rotations.
+// The method panics if the inputs are at a level smaller than Level().
+func (mm *MatrixMultiplier) MulNew(eval Evaluator, ctA, ctB *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(mm.params, 1, mm.level-mm.Depth()+1, ctA.Scale*ctB.Scale)
+	mm.Mul(eval, ctA, ctB, ctOut)
+	return
+}
+
+// Mul computes the products of the matrices encrypted in ctA and ctB and returns the result in ctOut, rescaled to the
+// default scale of the parameters. ctOut is at the level Level() - Depth().
+// The Evaluator must have the relinearization key and the rotation keys for the rotations returned by Rotations.
+// The method panics if the inputs are at a level smaller than Level().
+func (mm *MatrixMultiplier) Mul(eval Evaluator, ctA, ctB *Ciphertext, ctOut *Ciphertext) {
+
+	if ctA.Level() < mm.level || ctB.Level() < mm.level {
+		panic(fmt.Sprintf("cannot Mul: inputs must be at least at level %d", mm.level))
+	}
+
+	sigmaA := eval.LinearTransformNew(ctA, mm.sigma)[0]
+	tauB := eval.LinearTransformNew(ctB, mm.tau)[0]
+
+	// The permutations are encoded at the scale of the modulus of their level and the rescalings leave the scales unchanged
+	if err := eval.Rescale(sigmaA, ctA.Scale, sigmaA); err != nil {
+		panic(err)
+	}
+
+	if err := eval.Rescale(tauB, ctB.Scale, tauB); err != nil {
+		panic(err)
+	}
+
+	var phiA, psiB []*Ciphertext
+	if mm.dim > 1 {
+		phiA = eval.LinearTransformNew(sigmaA, mm.phi)
+		psiB = eval.LinearTransformNew(tauB, mm.psi)
+	}
+
+	eval.DropLevel(sigmaA, 1)
+	eval.DropLevel(tauB, 1)
+
+	eval.MulRelin(sigmaA, tauB, ctOut)
+
+	for k := range phiA {
+
+		if err := eval.Rescale(phiA[k], ctA.Scale, phiA[k]); err != nil {
+			panic(err)
+		}
+
+		if err := eval.Rescale(psiB[k], ctB.Scale, psiB[k]); err != nil {
+			panic(err)
+		}
+
+		eval.MulRelinAndAdd(phiA[k], psiB[k], ctOut)
+	}
+
+	if err := eval.Rescale(ctOut, mm.params.DefaultScale(), ctOut); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,439 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// remezMaxIterations is the maximum number of exchanges of the Remez algorithm.
+const remezMaxIterations = 64
+
+// remezConvergence is the relative spread of the extrema of the error at which the Remez algorithm stops.
+const remezConvergence = 1e-6
+
+// remezNumericalPrecision is the precision, relative to the magnitude of the function, below which the errors are
+// dominated by the double precision arithmetic.
+const remezNumericalPrecision = 1e-13
+
+// ApproximateMinimax computes the minimax polynomial approximation of degree degree of the function f over the interval [a, b],
+// i.e. the polynomial minimizing the maximum absolute error over the interval, with the Remez exchange algorithm.
+// It returns the polynomial in the Chebyshev basis, to be used like the output of Approximate, along with its maximum absolute
+// error over the interval. It returns an error if the inputs are invalid, if f is not finite on the interval or if the algorithm
+// does not converge, which can happen if f is not continuous on the interval.
+// The computation is carried in double precision, so that the errors below 1e-13 times the magnitude of f are not reliable.
+func ApproximateMinimax(f func(float64) float64, a, b float64, degree int) (pol *Polynomial, maxErr float64, err error) {
+
+	if degree < 0 {
+		return nil, 0, fmt.Errorf("cannot ApproximateMinimax: degree must be non-negative but is %d", degree)
+	}
+
+	if !(a < b) {
+		return nil, 0, fmt.Errorf("cannot ApproximateMinimax: invalid interval [%f, %f]", a, b)
+	}
+
+	r := &remez{f: f, a: a, b: b, degree: degree}
+
+	var coeffs []float64
+	if coeffs, maxErr, err = r.run(); err != nil {
+		return nil, 0, fmt.Errorf("cannot ApproximateMinimax: %w", err)
+	}
+
+	pol = &Polynomial{
+		Coeffs: make([]complex128, degree+1),
+		MaxDeg: degree,
+		Lead:   true,
+		A:      a,
+		B:      b,
+		Basis:  ChebyshevBasis,
+	}
+
+	for i := range coeffs {
+		pol.Coeffs[i] = complex(coeffs[i], 0)
+	}
+
+	return pol, maxErr, nil
+}
+
+// ApproximateMinimaxWithMaxError computes the minimax polynomial approximation of smallest degree of the function f over
+// the interval [a, b] whose maximum absolute error over the interval is at most maxErr. It returns an error if no
+// polynomial of degree at most maxDegree reaches this error, or in the cases of failure of ApproximateMinimax.
+func ApproximateMinimaxWithMaxError(f func(float64) float64, a, b, maxErr float64, maxDegree int) (pol *Polynomial, err error) {
+
+	if !(maxErr > 0) {
+		return nil, fmt.Errorf("cannot ApproximateMinimaxWithMaxError: maxErr must be positive but is %f", maxErr)
+	}
+
+	approximate := func(degree int) (*Polynomial, bool, error) {
+		p, e, err := ApproximateMinimax(f, a, b, degree)
+		return p, err == nil && e <= maxErr, err
+	}
+
+	// Finds an upper bound on the degree by doubling it, then the smallest degree by bisection
+	var ok bool
+	lo, hi := -1, 0
+	for {
+		if pol, ok, err = approximate(hi); err != nil {
+			return nil, err
+		}
+
+		if ok {
+			break
+		}
+
+		if hi == maxDegree {
+			return nil, fmt.Errorf("cannot ApproximateMinimaxWithMaxError: no polynomial of degree at most %d reaches the error %e", maxDegree, maxErr)
+		}
+
+		lo, hi = hi, 2*hi+1
+		if hi > maxDegree {
+			hi = maxDegree
+		}
+	}
+
+	for hi-lo > 1 {
+
+		mid := (lo + hi) / 2
+
+		var p *Polynomial
+		if p, ok, err = approximate(mid); err != nil {
+			return nil, err
+		}
+
+		if ok {
+			pol, hi = p, mid
+		} else {
+			lo = mid
+		}
+	}
+
+	return pol, nil
+}
+
+// remez computes the minimax approximation of f over [a, b] in the Chebyshev basis of the interval mapped to [-1, 1].
+type remez struct {
+	f      func(float64) float64
+	a, b   float64
+	degree int
+	coeffs []float64
+}
+
+// eval evaluates f at the point u of [-1, 1].
+func (r *remez) eval(u float64) float64 {
+	return r.f(0.5*(r.b-r.a)*u + 0.5*(r.a+r.b))
+}
+
+// err evaluates the approximation error at the point u of [-1, 1].
+func (r *remez) err(u float64) float64 {
+	return r.eval(u) - chebyshevClenshaw(r.coeffs, u)
+}
+
+func (r *remez) run() (coeffs []float64, maxErr float64, err error) {
+
+	n := r.degree + 2
+
+	// Initial reference: the n first extrema of the Chebyshev polynomial of degree n. The reference is not symmetric,
+	// as a symmetric reference yields a null levelled error for odd or even functions, whose error then does not alternate.
+	ref := make([]float64, n)
+	for i := range ref {
+		ref[i] = -math.Cos(math.Pi * float64(i) / float64(n))
+	}
+
+	fRef := make([]float64, n)
+
+	for iter := 0; iter < remezMaxIterations; iter++ {
+
+		for i := range ref {
+			if fRef[i] = r.eval(ref[i]); math.IsNaN(fRef[i]) || math.IsInf(fRef[i], 0) {
+				return nil, 0, fmt.Errorf("function is not finite at %f", 0.5*(r.b-r.a)*ref[i]+0.5*(r.a+r.b))
+			}
+		}
+
+		fMax := 0.0
+		for i := range fRef {
+			fMax = math.Max(fMax, math.Abs(fRef[i]))
+		}
+
+		// Solves sum_j c_j T_j(ref_i) + (-1)^i E = f(ref_i)
+		var levelledErr float64
+		if r.coeffs, levelledErr, err = r.solve(ref, fRef); err != nil {
+			return nil, 0, err
+		}
+
+		// Exchanges the reference with the alternating extrema of the error
+		newRef, gridErr := r.exchange(n)
+
+		if len(newRef) < n {
+
+			// The error does not alternate anymore if it is dominated by the double precision arithmetic
+			if gridErr <= remezNumericalPrecision*fMax {
+				return r.coeffs, gridErr, nil
+			}
+
+			// Otherwise the reference was degenerate (e.g. a null levelled error), and only the global extremum is exchanged
+			newRef = r.exchangeSingle(ref, levelledErr)
+		}
+
+		ref = newRef
+
+		minAbs, maxAbs := math.Inf(1), 0.0
+		for i := range ref {
+			e := math.Abs(r.err(ref[i]))
+			minAbs = math.Min(minAbs, e)
+			maxAbs = math.Max(maxAbs, e)
+		}
+
+		// Stops when the error equioscillates, up to the precision of the double precision arithmetic
+		if maxAbs-minAbs <= remezConvergence*maxAbs+remezNumericalPrecision*fMax {
+			return r.coeffs, maxAbs, nil
+		}
+	}
+
+	return nil, 0, fmt.Errorf("Remez algorithm did not converge after %d iterations", remezMaxIterations)
+}
+
+// solve solves the linear system of the Remez algorithm for the reference ref and the values fRef of f on the reference.
+// It returns the coefficients of the polynomial and the levelled error E.
+func (r *remez) solve(ref, fRef []float64) (coeffs []float64, levelledErr float64, err error) {
+
+	n := len(ref)
+
+	m := make([][]float64, n)
+	for i := range m {
+
+		m[i] = make([]float64, n+1)
+
+		// Chebyshev polynomials T_0, ..., T_degree at ref[i]
+		tPrev, t := 1.0, ref[i]
+		for j := 0; j < n-1; j++ {
+			m[i][j] = tPrev
+			tPrev, t = t, 2*ref[i]*t-tPrev
+		}
+
+		if i&1 == 0 {
+			m[i][n-1] = 1
+		} else {
+			m[i][n-1] = -1
+		}
+
+		m[i][n] = fRef[i]
+	}
+
+	// Gaussian elimination with partial pivoting
+	for col := 0; col < n; col++ {
+
+		pivot := col
+		for i := col + 1; i < n; i++ {
+			if math.Abs(m[i][col]) > math.Abs(m[pivot][col]) {
+				pivot = i
+			}
+		}
+
+		if m[pivot][col] == 0 {
+			return nil, 0, fmt.Errorf("singular Remez system")
+		}
+
+		m[col], m[pivot] = m[pivot], m[col]
+
+		for i := col + 1; i < n; i++ {
+			factor := m[i][col] / m[col][col]
+			for j := col; j < n+1; j++ {
+				m[i][j] -= factor * m[col][j]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		x[i] = m[i][n]
+		for j := i + 1; j < n; j++ {
+			x[i] -= m[i][j] * x[j]
+		}
+		x[i] /= m[i][i]
+	}
+
+	return x[:n-1], x[n-1], nil
+}
+
+// exchange returns n points of [-1, 1] on which the error alternates in sign, among the local extrema of the error
+// and including its global extremum, along with the maximum absolute error. It returns less than n points if the error
+// does not alternate enough.
+func (r *remez) exchange(n int) (ref []float64, maxErr float64) {
+
+	// Samples the error on Chebyshev nodes, which are denser at the ends of the interval where the error oscillates faster
+	samples := 32 * n
+	grid := make([]float64, samples+1)
+	errs := make([]float64, samples+1)
+	for i := range grid {
+		grid[i] = -math.Cos(math.Pi * float64(i) / float64(samples))
+		errs[i] = r.err(grid[i])
+	}
+
+	// One extremum per maximal run of samples of the same sign
+	for i := 0; i < len(grid); {
+
+		j, best := i, i
+		for j < len(grid) && (errs[j] < 0) == (errs[i] < 0) {
+			if math.Abs(errs[j]) > math.Abs(errs[best]) {
+				best = j
+			}
+			j++
+		}
+
+		lo, hi := grid[utils.MaxInt(best-1, i)], grid[utils.MinInt(best+1, j-1)]
+		ref = append(ref, r.extremum(lo, hi))
+		maxErr = math.Max(maxErr, math.Abs(r.err(ref[len(ref)-1])))
+
+		i = j
+	}
+
+	if len(ref) < n {
+		return ref, maxErr
+	}
+
+	// Removes the extra extrema with the smallest errors, by pairs of neighbours to preserve the alternation,
+	// or at the ends of the interval
+	for len(ref) > n {
+
+		if len(ref) == n+1 {
+			if math.Abs(r.err(ref[0])) < math.Abs(r.err(ref[n])) {
+				ref = ref[1:]
+			} else {
+				ref = ref[:n]
+			}
+			break
+		}
+
+		idx := 0
+		for i := range ref {
+			if math.Abs(r.err(ref[i])) < math.Abs(r.err(ref[idx])) {
+				idx = i
+			}
+		}
+
+		switch {
+		case idx == 0:
+			ref = ref[1:]
+		case idx == len(ref)-1:
+			ref = ref[:idx]
+		default:
+			if math.Abs(r.err(ref[idx-1])) < math.Abs(r.err(ref[idx+1])) {
+				idx--
+			}
+			ref = append(ref[:idx], ref[idx+2:]...)
+		}
+	}
+
+	return ref, maxErr
+}
+
+// exchangeSingle returns the reference ref in which the global extremum of the error replaces a point, such that the
+// signs of the errors on the reference, sign((-1)^i * levelledErr), still alternate.
+func (r *remez) exchangeSingle(ref []float64, levelledErr float64) []float64 {
+
+	n := len(ref)
+
+	// Global extremum of the error
+	samples := 32 * n
+	best, bestErr := -1.0, 0.0
+	for i := 0; i <= samples; i++ {
+		u := -math.Cos(math.Pi * float64(i) / float64(samples))
+		if e := math.Abs(r.err(u)); e > bestErr {
+			best, bestErr = u, e
+		}
+	}
+
+	// sign returns true if the error on the i-th point of the reference is negative
+	sign := func(i int) bool {
+		return (levelledErr < 0) != (i&1 == 1)
+	}
+
+	neg := r.err(best) < 0
+
+	newRef := make([]float64, n)
+	copy(newRef, ref)
+
+	idx := sort.SearchFloat64s(ref, best)
+
+	switch {
+	case idx == 0:
+		if sign(0) == neg {
+			newRef[0] = best
+		} else {
+			copy(newRef[1:], ref[:n-1])
+			newRef[0] = best
+		}
+	case idx == n:
+		if sign(n-1) == neg {
+			newRef[n-1] = best
+		} else {
+			copy(newRef[:n-1], ref[1:])
+			newRef[n-1] = best
+		}
+	default:
+		if sign(idx-1) == neg {
+			newRef[idx-1] = best
+		} else {
+			newRef[idx] = best
+		}
+	}
+
+	return newRef
+}
+
+// extremum returns the point of [lo, hi] maximizing the absolute error, by sampling followed by a golden-section search.
+func (r *remez) extremum(lo, hi float64) float64 {
+
+	const samples = 16
+
+	best, bestErr := lo, math.Abs(r.err(lo))
+	step := (hi - lo) / samples
+	for i := 1; i <= samples; i++ {
+		u := lo + float64(i)*step
+		if e := math.Abs(r.err(u)); e > bestErr {
+			best, bestErr = u, e
+		}
+	}
+
+	x0, x1 := math.Max(lo, best-step), math.Min(hi, best+step)
+
+	invPhi := (math.Sqrt(5) - 1) / 2
+
+	c, d := x1-invPhi*(x1-x0), x0+invPhi*(x1-x0)
+	ec, ed := math.Abs(r.err(c)), math.Abs(r.err(d))
+
+	for i := 0; i < 64 && x1-x0 > 1e-15; i++ {
+		if ec > ed {
+			x1, d, ed = d, c, ec
+			c = x1 - invPhi*(x1-x0)
+			ec = math.Abs(r.err(c))
+		} else {
+			x0, c, ec = c, d, ed
+			d = x0 + invPhi*(x1-x0)
+			ed = math.Abs(r.err(d))
+		}
+	}
+
+	if u := 0.5 * (x0 + x1); math.Abs(r.err(u)) > bestErr {
+		return u
+	}
+
+	return best
+}
+
+// chebyshevClenshaw evaluates the polynomial of Chebyshev coefficients coeffs at u with the Clenshaw algorithm.
+func chebyshevClenshaw(coeffs []float64, u float64) float64 {
+
+	var b1, b2 float64
+	for i := len(coeffs) - 1; i > 0; i-- {
+		b1, b2 = 2*u*b1-b2+coeffs[i], b1
+	}
+
+	if len(coeffs) == 0 {
+		return 0
+	}
+
+	return u*b1 - b2 + coeffs[0]
+}
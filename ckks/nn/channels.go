@@ -0,0 +1,113 @@
+package nn
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+)
+
+// ChannelPacker packs the channels of a tensor, each encrypted in the first Height x Width slots of a separate
+// ciphertext, in a single ciphertext with a Layout, and unpacks them.
+type ChannelPacker struct {
+	Layout
+
+	level int
+	masks []ckks.LinearTransform
+}
+
+// NewChannelPacker creates a ChannelPacker for tensors packed with the Layout layout, whose unpacking masks are
+// encoded at the given level. It returns an error if the Layout does not fit in the slots.
+func NewChannelPacker(params ckks.Parameters, encoder ckks.Encoder, layout Layout, level int) (*ChannelPacker, error) {
+
+	slots := params.Slots()
+
+	if err := layout.check(slots); err != nil {
+		return nil, fmt.Errorf("cannot NewChannelPacker: %w", err)
+	}
+
+	if level < 1 || level > params.MaxLevel() {
+		return nil, fmt.Errorf("cannot NewChannelPacker: level %d must be in [1, %d]", level, params.MaxLevel())
+	}
+
+	cp := &ChannelPacker{Layout: layout, level: level}
+
+	size := layout.Height * layout.Width
+
+	// The channel c is rotated to the first slots and masked, which is a single diagonal
+	cp.masks = make([]ckks.LinearTransform, layout.Channels)
+	for c := range cp.masks {
+
+		mask := make([]complex128, slots)
+		for i := 0; i < size; i++ {
+			mask[i] = 1
+		}
+
+		cp.masks[c] = ckks.GenLinearTransform(encoder, map[int][]complex128{c * size: mask}, level, params.QiFloat64(level), params.LogSlots())
+	}
+
+	return cp, nil
+}
+
+// Level returns the level of the inputs of Unpack.
+func (cp *ChannelPacker) Level() int {
+	return cp.level
+}
+
+// Rotations returns the rotations required to pack and unpack the channels.
+func (cp *ChannelPacker) Rotations() (rotations []int) {
+	return Rotations(cp)
+}
+
+func (cp *ChannelPacker) rotations() (rotations []int) {
+
+	size := cp.Height * cp.Width
+
+	for c := 1; c < cp.Channels; c++ {
+		rotations = append(rotations, c*size, -c*size)
+	}
+
+	return
+}
+
+// PackNew packs the channels encrypted in cts, whose slots after the first Height x Width must be zero, e.g. the
+// outputs of UnpackNew, and returns the tensor in a new Ciphertext, at the minimum level of cts and with their scale,
+// which must be the same. Packing does not consume any level.
+// The Evaluator must have the rotation keys for the rotations returned by Rotations.
+// The method panics if the number of ciphertexts is not the number of channels.
+func (cp *ChannelPacker) PackNew(eval ckks.Evaluator, cts []*ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+
+	if len(cts) != cp.Channels {
+		panic(fmt.Sprintf("cannot Pack: %d ciphertexts but the layout has %d channels", len(cts), cp.Channels))
+	}
+
+	size := cp.Height * cp.Width
+
+	ctOut = cts[0].CopyNew()
+
+	for c := 1; c < cp.Channels; c++ {
+		eval.Add(ctOut, eval.RotateNew(cts[c], -c*size), ctOut)
+	}
+
+	return
+}
+
+// UnpackNew unpacks the channels of the tensor encrypted in ct and returns each of them in the first Height x Width
+// slots of a new Ciphertext, whose other slots are zero, at the level Level()-1 and with the scale of ct.
+// The Evaluator must have the rotation keys for the rotations returned by Rotations.
+// The method panics if ct is at a level smaller than Level().
+func (cp *ChannelPacker) UnpackNew(eval ckks.Evaluator, ct *ckks.Ciphertext) (cts []*ckks.Ciphertext) {
+
+	if ct.Level() < cp.level {
+		panic(fmt.Sprintf("cannot Unpack: input must be at least at level %d", cp.level))
+	}
+
+	cts = eval.LinearTransformNew(ct, cp.masks)
+
+	for _, ctc := range cts {
+		if err := eval.Rescale(ctc, ct.Scale, ctc); err != nil {
+			panic(err)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,274 @@
+package nn
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// layerBSGSRatio is the ratio between the inner and outer loops of the baby-step giant-step evaluation of the layers.
+const layerBSGSRatio = 2.0
+
+// Layer is a linear layer mapping a tensor packed with the Layout Input to a tensor packed with the Layout Output,
+// evaluated as a LinearTransform of the slots. The slots after the output tensor are zero.
+type Layer struct {
+	Input  Layout
+	Output Layout
+
+	params ckks.Parameters
+	level  int
+	lt     ckks.LinearTransform
+}
+
+// NewConv2D creates a Layer evaluating the 2D convolution of the tensor packed with the Layout input by the kernels,
+// given as kernels[o][c][a][b], the weight of the pixel (a, b) of the kernel mapping the input channel c to the
+// output channel o. All the kernels must have the same odd dimensions kh x kw and the input is zero-padded by
+// (kh-1)/2 rows and (kw-1)/2 columns on each side, so that the output channel o has the pixels
+//
+// out[o][i][j] = sum_{c, a, b} kernels[o][c][a][b] * in[c][i*stride + a - (kh-1)/2][j*stride + b - (kw-1)/2]
+//
+// for i < ceil(Height/stride) and j < ceil(Width/stride). The Layer is encoded at the given level.
+// It returns an error if the kernels are not consistent with the input or if the tensors do not fit in the slots.
+func NewConv2D(params ckks.Parameters, encoder ckks.Encoder, input Layout, kernels [][][][]float64, stride, level int) (*Layer, error) {
+
+	if len(kernels) == 0 || len(kernels[0]) == 0 || len(kernels[0][0]) == 0 || len(kernels[0][0][0]) == 0 {
+		return nil, fmt.Errorf("cannot NewConv2D: kernels are empty")
+	}
+
+	kh, kw := len(kernels[0][0]), len(kernels[0][0][0])
+
+	if kh&1 == 0 || kw&1 == 0 {
+		return nil, fmt.Errorf("cannot NewConv2D: kernels must have odd dimensions but are %dx%d", kh, kw)
+	}
+
+	for o := range kernels {
+
+		if len(kernels[o]) != input.Channels {
+			return nil, fmt.Errorf("cannot NewConv2D: kernels of output channel %d have %d input channels but the layout has %d", o, len(kernels[o]), input.Channels)
+		}
+
+		for c := range kernels[o] {
+
+			if len(kernels[o][c]) != kh {
+				return nil, fmt.Errorf("cannot NewConv2D: kernel (%d, %d) has %d rows but kernel (0, 0) has %d", o, c, len(kernels[o][c]), kh)
+			}
+
+			for a := range kernels[o][c] {
+				if len(kernels[o][c][a]) != kw {
+					return nil, fmt.Errorf("cannot NewConv2D: kernel (%d, %d) has %d columns but kernel (0, 0) has %d", o, c, len(kernels[o][c][a]), kw)
+				}
+			}
+		}
+	}
+
+	if stride < 1 {
+		return nil, fmt.Errorf("cannot NewConv2D: invalid stride %d", stride)
+	}
+
+	output := Layout{Channels: len(kernels), Height: (input.Height + stride - 1) / stride, Width: (input.Width + stride - 1) / stride}
+
+	ph, pw := (kh-1)/2, (kw-1)/2
+
+	layer, err := newLayer(params, encoder, input, output, level, func(o, i, j int, add func(c, i, j int, w float64)) {
+		for c := range kernels[o] {
+			for a := range kernels[o][c] {
+				for b, w := range kernels[o][c][a] {
+					add(c, i*stride+a-ph, j*stride+b-pw, w)
+				}
+			}
+		}
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot NewConv2D: %w", err)
+	}
+
+	return layer, nil
+}
+
+// NewAvgPool2D creates a Layer evaluating the average pooling of the channels of the tensor packed with the Layout
+// input over non-overlapping windows of window x window pixels, i.e.
+//
+// out[c][i][j] = 1/window^2 * sum_{a, b < window} in[c][i*window + a][j*window + b]
+//
+// for i < Height/window and j < Width/window. The Layer is encoded at the given level.
+// It returns an error if the window is larger than the channels or if the tensors do not fit in the slots.
+func NewAvgPool2D(params ckks.Parameters, encoder ckks.Encoder, input Layout, window, level int) (*Layer, error) {
+
+	if window < 1 || window > input.Height || window > input.Width {
+		return nil, fmt.Errorf("cannot NewAvgPool2D: invalid window %d for %dx%d channels", window, input.Height, input.Width)
+	}
+
+	output := Layout{Channels: input.Channels, Height: input.Height / window, Width: input.Width / window}
+
+	w := 1 / float64(window*window)
+
+	layer, err := newLayer(params, encoder, input, output, level, func(c, i, j int, add func(c, i, j int, w float64)) {
+		for a := 0; a < window; a++ {
+			for b := 0; b < window; b++ {
+				add(c, i*window+a, j*window+b, w)
+			}
+		}
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot NewAvgPool2D: %w", err)
+	}
+
+	return layer, nil
+}
+
+// NewDownsample creates a Layer evaluating the strided downsampling of the channels of the tensor packed with the
+// Layout input, i.e. out[c][i][j] = in[c][i*stride][j*stride] for i < ceil(Height/stride) and j < ceil(Width/stride),
+// which packs the output of a convolution or of a pooling with a stride densely. The Layer is encoded at the given
+// level. It returns an error if the stride is invalid or if the tensors do not fit in the slots.
+func NewDownsample(params ckks.Parameters, encoder ckks.Encoder, input Layout, stride, level int) (*Layer, error) {
+
+	if stride < 1 {
+		return nil, fmt.Errorf("cannot NewDownsample: invalid stride %d", stride)
+	}
+
+	output := Layout{Channels: input.Channels, Height: (input.Height + stride - 1) / stride, Width: (input.Width + stride - 1) / stride}
+
+	layer, err := newLayer(params, encoder, input, output, level, func(c, i, j int, add func(c, i, j int, w float64)) {
+		add(c, i*stride, j*stride, 1)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot NewDownsample: %w", err)
+	}
+
+	return layer, nil
+}
+
+// newLayer creates a Layer from the function pixel, which calls add(c, i, j, w) for each pixel (i, j) of the input
+// channel c contributing with the weight w to the pixel (i, j) of the output channel o. The contributions of the
+// pixels outside of the input channels, i.e. of the zero-padding, are discarded.
+func newLayer(params ckks.Parameters, encoder ckks.Encoder, input, output Layout, level int, pixel func(o, i, j int, add func(c, i, j int, w float64))) (*Layer, error) {
+
+	slots := params.Slots()
+
+	if err := input.check(slots); err != nil {
+		return nil, fmt.Errorf("input: %w", err)
+	}
+
+	if err := output.check(slots); err != nil {
+		return nil, fmt.Errorf("output: %w", err)
+	}
+
+	if level < 1 || level > params.MaxLevel() {
+		return nil, fmt.Errorf("level %d must be in [1, %d]", level, params.MaxLevel())
+	}
+
+	// The output slot out is the input slot out + k, hence lies on the k-th diagonal
+	diags := make(map[int][]complex128)
+
+	for o := 0; o < output.Channels; o++ {
+		for i := 0; i < output.Height; i++ {
+			for j := 0; j < output.Width; j++ {
+
+				out := output.Slot(o, i, j)
+
+				pixel(o, i, j, func(c, i, j int, w float64) {
+
+					if i < 0 || i >= input.Height || j < 0 || j >= input.Width || w == 0 {
+						return
+					}
+
+					k := (input.Slot(c, i, j) - out + slots) % slots
+
+					if _, ok := diags[k]; !ok {
+						diags[k] = make([]complex128, slots)
+					}
+
+					diags[k][out] += complex(w, 0)
+				})
+			}
+		}
+	}
+
+	// The layer is encoded at the scale of the modulus of its level, so that the rescaling leaves the scale unchanged
+	scale := params.QiFloat64(level)
+
+	var lt ckks.LinearTransform
+	if len(diags) == 1 {
+		lt = ckks.GenLinearTransform(encoder, diags, level, scale, params.LogSlots())
+	} else {
+		lt = ckks.GenLinearTransformBSGS(encoder, diags, level, scale, layerBSGSRatio, params.LogSlots())
+	}
+
+	return &Layer{Input: input, Output: output, params: params, level: level, lt: lt}, nil
+}
+
+// Level returns the level of the inputs of the Layer.
+func (l *Layer) Level() int {
+	return l.level
+}
+
+// Rotations returns the rotations required to evaluate the Layer.
+func (l *Layer) Rotations() (rotations []int) {
+	return Rotations(l)
+}
+
+// EvaluateNew evaluates the Layer on ctIn and returns the result in a new Ciphertext, at the level Level()-1 and with
+// the scale of ctIn. The Evaluator must have the rotation keys for the rotations returned by Rotations.
+// The method panics if ctIn is at a level smaller than Level().
+func (l *Layer) EvaluateNew(eval ckks.Evaluator, ctIn *ckks.Ciphertext) (ctOut *ckks.Ciphertext) {
+
+	if ctIn.Level() < l.level {
+		panic(fmt.Sprintf("cannot Evaluate: input must be at least at level %d", l.level))
+	}
+
+	scale := ctIn.Scale
+
+	ctOut = eval.LinearTransformNew(ctIn, l.lt)[0]
+
+	if err := eval.Rescale(ctOut, scale, ctOut); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// Evaluate evaluates the Layer on ctIn and returns the result in ctOut, which can alias ctIn. See EvaluateNew.
+func (l *Layer) Evaluate(eval ckks.Evaluator, ctIn, ctOut *ckks.Ciphertext) {
+
+	res := l.EvaluateNew(eval, ctIn)
+
+	level := utils.MinInt(res.Level(), ctOut.Level())
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:level+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:level+1]
+	ctOut.Copy(res)
+}
+
+// Operation is an operation on packed tensors requiring rotation keys, e.g. a Layer or a ChannelPacker.
+type Operation interface {
+	rotations() []int
+}
+
+func (l *Layer) rotations() []int {
+	return l.lt.Rotations()
+}
+
+// Rotations returns the union of the rotations required to evaluate the operations, e.g. of all the layers of
+// a network, so that the rotation keys can be generated at once.
+func Rotations(operations ...Operation) (rotations []int) {
+
+	rotIndex := make(map[int]bool)
+
+	for _, op := range operations {
+		for _, rot := range op.rotations() {
+			if rot != 0 {
+				rotIndex[rot] = true
+			}
+		}
+	}
+
+	rotations = make([]int, 0, len(rotIndex))
+	for rot := range rotIndex {
+		rotations = append(rotations, rot)
+	}
+
+	return
+}
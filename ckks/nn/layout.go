@@ -0,0 +1,96 @@
+// Package nn implements the linear layers of convolutional neural networks on CKKS ciphertexts: packed 2D
+// convolutions, average pooling, strided downsampling and the packing and unpacking of the channels of a tensor.
+//
+// A tensor of Channels x Height x Width values is packed in the slots of a single ciphertext, channel after channel,
+// each channel in row-major order (see Layout). The layers are evaluated as linear transformations of the slots,
+// so that a layer consumes a single level, whatever its dimensions, and the rotation keys it requires are reported
+// by its Rotations method.
+package nn
+
+import (
+	"fmt"
+)
+
+// Layout is the packing of a tensor of Channels images of Height x Width pixels in the slots of a ciphertext:
+// the pixel (i, j) of the channel c is stored in the slot c * Height * Width + i * Width + j.
+type Layout struct {
+	Channels int
+	Height   int
+	Width    int
+}
+
+// Size returns the number of slots storing the tensor.
+func (l Layout) Size() int {
+	return l.Channels * l.Height * l.Width
+}
+
+// Slot returns the index of the slot storing the pixel (i, j) of the channel c.
+func (l Layout) Slot(c, i, j int) int {
+	return (c*l.Height+i)*l.Width + j
+}
+
+// Flatten returns the values of the slots storing the tensor, given as a slice of channels of rows of pixels,
+// zero-padded to slots values. It returns an error if the tensor does not have the dimensions of the Layout or
+// if the Layout does not fit in slots slots.
+func (l Layout) Flatten(tensor [][][]float64, slots int) (values []float64, err error) {
+
+	if err = l.check(slots); err != nil {
+		return nil, err
+	}
+
+	if len(tensor) != l.Channels {
+		return nil, fmt.Errorf("cannot Flatten: tensor has %d channels but the layout has %d", len(tensor), l.Channels)
+	}
+
+	values = make([]float64, slots)
+
+	for c := range tensor {
+
+		if len(tensor[c]) != l.Height {
+			return nil, fmt.Errorf("cannot Flatten: channel %d has %d rows but the layout has %d", c, len(tensor[c]), l.Height)
+		}
+
+		for i := range tensor[c] {
+
+			if len(tensor[c][i]) != l.Width {
+				return nil, fmt.Errorf("cannot Flatten: row %d of channel %d has %d columns but the layout has %d", i, c, len(tensor[c][i]), l.Width)
+			}
+
+			copy(values[l.Slot(c, i, 0):], tensor[c][i])
+		}
+	}
+
+	return
+}
+
+// Unflatten returns the tensor stored in the real parts of the slots values.
+func (l Layout) Unflatten(values []complex128) (tensor [][][]float64) {
+
+	tensor = make([][][]float64, l.Channels)
+
+	for c := range tensor {
+		tensor[c] = make([][]float64, l.Height)
+		for i := range tensor[c] {
+			tensor[c][i] = make([]float64, l.Width)
+			for j := range tensor[c][i] {
+				tensor[c][i][j] = real(values[l.Slot(c, i, j)])
+			}
+		}
+	}
+
+	return
+}
+
+// check returns an error if the Layout is empty or does not fit in slots slots.
+func (l Layout) check(slots int) error {
+
+	if l.Channels < 1 || l.Height < 1 || l.Width < 1 {
+		return fmt.Errorf("invalid layout %dx%dx%d", l.Channels, l.Height, l.Width)
+	}
+
+	if l.Size() > slots {
+		return fmt.Errorf("layout %dx%dx%d does not fit in %d slots", l.Channels, l.Height, l.Width, slots)
+	}
+
+	return nil
+}
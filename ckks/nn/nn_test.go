@@ -0,0 +1,282 @@
+package nn
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+type testContext struct {
+	params    ckks.Parameters
+	encoder   ckks.Encoder
+	encryptor ckks.Encryptor
+	decryptor ckks.Decryptor
+	kgen      ckks.KeyGenerator
+	sk        *rlwe.SecretKey
+}
+
+func TestNN(t *testing.T) {
+
+	if runtime.GOARCH == "wasm" {
+		t.Skip("skipping nn tests for GOARCH=wasm")
+	}
+
+	params, err := ckks.NewParametersFromLiteral(ckks.PN13QP218)
+	if err != nil {
+		panic(err)
+	}
+
+	tc := &testContext{params: params, encoder: ckks.NewEncoder(params), kgen: ckks.NewKeyGenerator(params)}
+	tc.sk = tc.kgen.GenSecretKey()
+	tc.encryptor = ckks.NewEncryptor(params, tc.sk)
+	tc.decryptor = ckks.NewDecryptor(params, tc.sk)
+
+	for _, testSet := range []func(tc *testContext, t *testing.T){
+		testLayout,
+		testConv2D,
+		testAvgPool2D,
+		testDownsample,
+		testChannelPacker,
+	} {
+		testSet(tc, t)
+		runtime.GC()
+	}
+}
+
+func randomTensor(layout Layout) (tensor [][][]float64) {
+	tensor = make([][][]float64, layout.Channels)
+	for c := range tensor {
+		tensor[c] = make([][]float64, layout.Height)
+		for i := range tensor[c] {
+			tensor[c][i] = make([]float64, layout.Width)
+			for j := range tensor[c][i] {
+				tensor[c][i][j] = utils.RandFloat64(-1, 1)
+			}
+		}
+	}
+	return
+}
+
+func (tc *testContext) encryptTensor(layout Layout, tensor [][][]float64, t *testing.T) *ckks.Ciphertext {
+	values, err := layout.Flatten(tensor, tc.params.Slots())
+	require.NoError(t, err)
+	return tc.encryptor.EncryptNew(tc.encoder.EncodeNew(values, tc.params.MaxLevel(), tc.params.DefaultScale(), tc.params.LogSlots()))
+}
+
+func (tc *testContext) verifyTensor(layout Layout, want [][][]float64, ct *ckks.Ciphertext, t *testing.T) {
+
+	values := tc.encoder.Decode(tc.decryptor.DecryptNew(ct), tc.params.LogSlots())
+
+	have := layout.Unflatten(values)
+	for c := range want {
+		for i := range want[c] {
+			for j := range want[c][i] {
+				require.InDelta(t, want[c][i][j], have[c][i][j], 1e-3, "channel %d pixel (%d, %d)", c, i, j)
+			}
+		}
+	}
+
+	// The slots after the tensor must be zero
+	for k := layout.Size(); k < len(values); k++ {
+		require.InDelta(t, 0, real(values[k]), 1e-3, "slot %d", k)
+	}
+}
+
+func (tc *testContext) evaluator(operations ...Operation) ckks.Evaluator {
+	rotKey := tc.kgen.GenRotationKeysForRotations(Rotations(operations...), false, tc.sk)
+	return ckks.NewEvaluator(tc.params, rlwe.EvaluationKey{Rtks: rotKey})
+}
+
+func testLayout(tc *testContext, t *testing.T) {
+
+	t.Run("Layout", func(t *testing.T) {
+
+		layout := Layout{Channels: 3, Height: 4, Width: 5}
+		require.Equal(t, 60, layout.Size())
+		require.Equal(t, 2*20+3*5+4, layout.Slot(2, 3, 4))
+
+		tensor := randomTensor(layout)
+
+		values, err := layout.Flatten(tensor, 64)
+		require.NoError(t, err)
+		require.Len(t, values, 64)
+
+		complexValues := make([]complex128, len(values))
+		for i := range values {
+			complexValues[i] = complex(values[i], 0)
+		}
+		require.Equal(t, tensor, layout.Unflatten(complexValues))
+
+		_, err = layout.Flatten(tensor, 32)
+		require.Error(t, err)
+
+		_, err = layout.Flatten(tensor[:2], 64)
+		require.Error(t, err)
+	})
+}
+
+func testConv2D(tc *testContext, t *testing.T) {
+
+	for _, stride := range []int{1, 2} {
+
+		t.Run(fmt.Sprintf("Conv2D/Stride=%d", stride), func(t *testing.T) {
+
+			input := Layout{Channels: 2, Height: 5, Width: 6}
+
+			kernels := make([][][][]float64, 3)
+			for o := range kernels {
+				kernels[o] = make([][][]float64, input.Channels)
+				for c := range kernels[o] {
+					kernels[o][c] = randomTensor(Layout{Channels: 3, Height: 3, Width: 3})[0]
+				}
+			}
+
+			layer, err := NewConv2D(tc.params, tc.encoder, input, kernels, stride, tc.params.MaxLevel())
+			require.NoError(t, err)
+			require.Equal(t, Layout{Channels: 3, Height: (5 + stride - 1) / stride, Width: 6 / stride}, layer.Output)
+
+			tensor := randomTensor(input)
+
+			want := make([][][]float64, layer.Output.Channels)
+			for o := range want {
+				want[o] = make([][]float64, layer.Output.Height)
+				for i := range want[o] {
+					want[o][i] = make([]float64, layer.Output.Width)
+					for j := range want[o][i] {
+						for c := range kernels[o] {
+							for a := range kernels[o][c] {
+								for b := range kernels[o][c][a] {
+									if y, x := i*stride+a-1, j*stride+b-1; y >= 0 && y < input.Height && x >= 0 && x < input.Width {
+										want[o][i][j] += kernels[o][c][a][b] * tensor[c][y][x]
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+
+			ctOut := layer.EvaluateNew(tc.evaluator(layer), tc.encryptTensor(input, tensor, t))
+			require.Equal(t, layer.Level()-1, ctOut.Level())
+
+			tc.verifyTensor(layer.Output, want, ctOut, t)
+		})
+	}
+
+	t.Run("Conv2D/InvalidKernels", func(t *testing.T) {
+
+		input := Layout{Channels: 1, Height: 4, Width: 4}
+
+		_, err := NewConv2D(tc.params, tc.encoder, input, [][][][]float64{{{{1, 1}, {1, 1}}}}, 1, tc.params.MaxLevel())
+		require.Error(t, err)
+
+		_, err = NewConv2D(tc.params, tc.encoder, input, [][][][]float64{{{{1}}, {{1}}}}, 1, tc.params.MaxLevel())
+		require.Error(t, err)
+
+		_, err = NewConv2D(tc.params, tc.encoder, input, [][][][]float64{{{{1}}}}, 0, tc.params.MaxLevel())
+		require.Error(t, err)
+	})
+}
+
+func testAvgPool2D(tc *testContext, t *testing.T) {
+
+	t.Run("AvgPool2D", func(t *testing.T) {
+
+		input := Layout{Channels: 2, Height: 4, Width: 6}
+
+		layer, err := NewAvgPool2D(tc.params, tc.encoder, input, 2, tc.params.MaxLevel())
+		require.NoError(t, err)
+		require.Equal(t, Layout{Channels: 2, Height: 2, Width: 3}, layer.Output)
+
+		tensor := randomTensor(input)
+
+		want := make([][][]float64, layer.Output.Channels)
+		for c := range want {
+			want[c] = make([][]float64, layer.Output.Height)
+			for i := range want[c] {
+				want[c][i] = make([]float64, layer.Output.Width)
+				for j := range want[c][i] {
+					want[c][i][j] = (tensor[c][2*i][2*j] + tensor[c][2*i][2*j+1] + tensor[c][2*i+1][2*j] + tensor[c][2*i+1][2*j+1]) / 4
+				}
+			}
+		}
+
+		ctIn := tc.encryptTensor(input, tensor, t)
+
+		// The in-place evaluation can alias its input
+		layer.Evaluate(tc.evaluator(layer), ctIn, ctIn)
+
+		tc.verifyTensor(layer.Output, want, ctIn, t)
+
+		_, err = NewAvgPool2D(tc.params, tc.encoder, input, 5, tc.params.MaxLevel())
+		require.Error(t, err)
+	})
+}
+
+func testDownsample(tc *testContext, t *testing.T) {
+
+	t.Run("Downsample", func(t *testing.T) {
+
+		input := Layout{Channels: 3, Height: 5, Width: 4}
+
+		layer, err := NewDownsample(tc.params, tc.encoder, input, 2, tc.params.MaxLevel())
+		require.NoError(t, err)
+		require.Equal(t, Layout{Channels: 3, Height: 3, Width: 2}, layer.Output)
+
+		tensor := randomTensor(input)
+
+		want := make([][][]float64, layer.Output.Channels)
+		for c := range want {
+			want[c] = make([][]float64, layer.Output.Height)
+			for i := range want[c] {
+				want[c][i] = make([]float64, layer.Output.Width)
+				for j := range want[c][i] {
+					want[c][i][j] = tensor[c][2*i][2*j]
+				}
+			}
+		}
+
+		ctOut := layer.EvaluateNew(tc.evaluator(layer), tc.encryptTensor(input, tensor, t))
+
+		tc.verifyTensor(layer.Output, want, ctOut, t)
+	})
+}
+
+func testChannelPacker(tc *testContext, t *testing.T) {
+
+	t.Run("ChannelPacker", func(t *testing.T) {
+
+		layout := Layout{Channels: 3, Height: 3, Width: 4}
+
+		cp, err := NewChannelPacker(tc.params, tc.encoder, layout, tc.params.MaxLevel())
+		require.NoError(t, err)
+
+		eval := tc.evaluator(cp)
+
+		tensor := randomTensor(layout)
+
+		cts := cp.UnpackNew(eval, tc.encryptTensor(layout, tensor, t))
+		require.Len(t, cts, layout.Channels)
+
+		channel := Layout{Channels: 1, Height: layout.Height, Width: layout.Width}
+		for c := range cts {
+			require.Equal(t, cp.Level()-1, cts[c].Level())
+			tc.verifyTensor(channel, tensor[c:c+1], cts[c], t)
+		}
+
+		ctOut := cp.PackNew(eval, cts)
+		tc.verifyTensor(layout, tensor, ctOut, t)
+
+		require.Panics(t, func() { cp.PackNew(eval, cts[:2]) })
+
+		_, err = NewChannelPacker(tc.params, tc.encoder, Layout{Channels: 1, Height: tc.params.Slots(), Width: 2}, tc.params.MaxLevel())
+		require.Error(t, err)
+
+		require.ElementsMatch(t, cp.Rotations(), Rotations(cp, cp))
+	})
+}
@@ -344,10 +344,13 @@ func NewParameters(rlweParams rlwe.Parameters, logSlot int, defaultScale float64
 		return Parameters{}, fmt.Errorf("provided RLWE parameters are invalid")
 	}
 
-	if logSlot > int(rlweParams.RingQ().NthRoot>>2) {
-		return Parameters{}, fmt.Errorf("logSlot=%d is larger than the logN-1=%d", logSlot, int(rlweParams.RingQ().NthRoot>>2))
+	p = Parameters{rlweParams, logSlot, defaultScale}
+
+	if logSlot < 0 || logSlot > p.MaxLogSlots() {
+		return Parameters{}, fmt.Errorf("logSlot=%d is not between 0 and the maximum %d", logSlot, p.MaxLogSlots())
 	}
-	return Parameters{rlweParams, logSlot, defaultScale}, nil
+
+	return p, nil
 }
 
 // NewParametersFromLiteral instantiate a set of CKKS parameters from a ParametersLiteral specification.
@@ -492,6 +495,13 @@ func (p Parameters) RotationsForReplicateLog(batch, n int) (rotations []int) {
 	return p.RotationsForInnerSumLog(-batch, n)
 }
 
+// GaloisElementForRealProjection returns the Galois element of the rotation key required by Evaluator.RealProjection
+// and Evaluator.ImagProjection, i.e. the element of the conjugation. With GenRotationKeysForRotations, this key is
+// generated by setting includeConjugate to true.
+func (p Parameters) GaloisElementForRealProjection() uint64 {
+	return p.GaloisElementForRowRotation()
+}
+
 // RotationsForTrace generates the rotations that will be performed by the
 // `Evaluator.SubSum` operation.
 func (p Parameters) RotationsForTrace(logSlotsStart, logSlotsEnd int) (rotations []int) {
@@ -560,6 +570,14 @@ func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
 	if err := rlweParams.UnmarshalBinary(data); err != nil {
 		return err
 	}
+
+	// The CKKS parameters follow the RLWE parameters in the decompressed encoding
+	if _, data, err = rlwe.DecodeFormat(data); err != nil {
+		return err
+	}
+	if len(data) != rlweParams.MarshalBinarySize()-rlwe.FormatHeaderLen+9 {
+		return fmt.Errorf("invalid ckks.Parameters serialization")
+	}
 	logSlots := int(data[len(data)-9])
 	scale := math.Float64frombits(binary.BigEndian.Uint64(data[len(data)-8:]))
 	*p, err = NewParameters(rlweParams, logSlots, scale)
@@ -0,0 +1,82 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// GenParams generates a ParametersLiteral supporting circuits of the given multiplicative depth,
+// with a rescaling after each multiplication, for the scale 2^logScale and the target security level.
+// The modulus chain is made of a first modulus of logScale+precision bits, where precision is the
+// number of bits left above the scale at level zero and thus bounds the magnitude of the decrypted
+// values, followed by depth moduli of logScale bits. A single special prime larger than all the moduli
+// of the chain is used for the key-switching. The method selects the smallest ring degree whose security
+// bound admits the modulus chain and uses the full packing with N/2 slots. The primes are generated
+// when the literal is instantiated with NewParametersFromLiteral.
+// It returns an error if the moduli are too large or if no supported ring degree fits the modulus chain.
+func GenParams(depth, logScale, precision int, security rlwe.SecurityLevel) (pl ParametersLiteral, err error) {
+
+	if depth < 0 {
+		return ParametersLiteral{}, fmt.Errorf("invalid depth: must be non-negative but is %d", depth)
+	}
+
+	if logScale < 1 || precision < 1 {
+		return ParametersLiteral{}, fmt.Errorf("invalid logScale=%d or precision=%d: must be positive", logScale, precision)
+	}
+
+	if logScale+precision > rlwe.MaxModuliSize {
+		return ParametersLiteral{}, fmt.Errorf("logScale+precision=%d exceeds the maximum modulus size of %d bits", logScale+precision, rlwe.MaxModuliSize)
+	}
+
+	if depth+1 > rlwe.MaxModuliCount {
+		return ParametersLiteral{}, fmt.Errorf("depth=%d exceeds the maximum number of moduli %d", depth, rlwe.MaxModuliCount)
+	}
+
+	logQ := make([]int, depth+1)
+	logQ[0] = logScale + precision
+	for i := 1; i < depth+1; i++ {
+		logQ[i] = logScale
+	}
+
+	logP := []int{logQ[0] + 1}
+	if logP[0] > 61 {
+		logP[0] = 61
+	}
+
+	logQP := logP[0]
+	for _, logQi := range logQ {
+		logQP += logQi
+	}
+
+	var logN int
+	if logN, err = rlwe.MinLogNForLogQP(logQP, security); err != nil {
+		return ParametersLiteral{}, fmt.Errorf("cannot GenParams: %w", err)
+	}
+
+	return ParametersLiteral{
+		LogN:         logN,
+		LogQ:         logQ,
+		LogP:         logP,
+		Sigma:        rlwe.DefaultSigma,
+		LogSlots:     logN - 1,
+		DefaultScale: math.Exp2(float64(logScale)),
+		RingType:     ring.Standard,
+	}, nil
+}
+
+// GenParamsConjugateInvariant is the counterpart of GenParams for the conjugate invariant variant of CKKS,
+// which encrypts vectors of real values and uses the full packing with N slots.
+func GenParamsConjugateInvariant(depth, logScale, precision int, security rlwe.SecurityLevel) (pl ParametersLiteral, err error) {
+
+	if pl, err = GenParams(depth, logScale, precision, security); err != nil {
+		return ParametersLiteral{}, err
+	}
+
+	pl.RingType = ring.ConjugateInvariant
+	pl.LogSlots = pl.LogN
+
+	return
+}
@@ -0,0 +1,67 @@
+package ckks
+
+import (
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// PlaintextCache stores the precomputed multiplication operands of plaintexts that are multiplied
+// with many ciphertexts, e.g. the weights of a model evaluated on many encrypted inputs.
+// The operand of a plaintext is a copy of it in the Montgomery domain, which the Evaluator uses as is
+// in Mul, MulRelin, MulAndAdd and MulRelinAndAdd instead of converting the plaintext at each call.
+//
+// The cache is keyed on the plaintext pointer: a plaintext must not be modified after its operand
+// has been computed unless it is removed from the cache with Delete. The operands must only be used
+// as multiplication operands and must not be modified. A PlaintextCache is not safe for concurrent use,
+// but the operands it returns can be shared by Evaluators running concurrently.
+type PlaintextCache struct {
+	params Parameters
+	ringQ  *ring.Ring
+	cache  map[*Plaintext]*Plaintext
+}
+
+// NewPlaintextCache creates a new empty PlaintextCache.
+func NewPlaintextCache(params Parameters) *PlaintextCache {
+	return &PlaintextCache{
+		params: params,
+		ringQ:  params.RingQ(),
+		cache:  make(map[*Plaintext]*Plaintext),
+	}
+}
+
+// Get returns the multiplication operand of pt, computing and storing it on the first call.
+// The scale of the operand is updated to the current scale of pt.
+func (pc *PlaintextCache) Get(pt *Plaintext) (ptMul *Plaintext) {
+
+	var ok bool
+	if ptMul, ok = pc.cache[pt]; !ok {
+
+		level := pt.Level()
+
+		ptMul = &Plaintext{Plaintext: rlwe.NewPlaintext(pc.params.Parameters, level)}
+		pc.ringQ.MFormLvl(level, pt.Value, ptMul.Value)
+		ptMul.Value.IsNTT = pt.Value.IsNTT
+		ptMul.Value.IsMForm = true
+
+		pc.cache[pt] = ptMul
+	}
+
+	ptMul.Scale = pt.Scale
+
+	return
+}
+
+// Delete removes the operand of pt from the cache.
+func (pc *PlaintextCache) Delete(pt *Plaintext) {
+	delete(pc.cache, pt)
+}
+
+// Len returns the number of operands stored in the cache.
+func (pc *PlaintextCache) Len() int {
+	return len(pc.cache)
+}
+
+// Reset removes all the operands from the cache.
+func (pc *PlaintextCache) Reset() {
+	pc.cache = make(map[*Plaintext]*Plaintext)
+}
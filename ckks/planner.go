@@ -0,0 +1,354 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// SymbolicCiphertext is the metadata of a ciphertext in the dry run of a Planner: its level, scale and degree, and the
+// estimated bounds on the absolute value and on the error of its slots, as in TrackedCiphertext.
+type SymbolicCiphertext struct {
+	Level        int
+	Scale        float64
+	Degree       int
+	MessageBound float64
+	ErrorBound   float64
+}
+
+// Precision returns the estimated precision in bits of the slots of the SymbolicCiphertext, i.e. -log2(ErrorBound).
+func (ct *SymbolicCiphertext) Precision() float64 {
+	return -math.Log2(ct.ErrorBound)
+}
+
+// PlanSeverity is the severity of a PlanIssue.
+type PlanSeverity int
+
+const (
+	// PlanWarning is an issue that does not prevent the computation, e.g. a wasted level.
+	PlanWarning PlanSeverity = iota
+	// PlanError is an issue that makes the computation fail or return a wrong result, e.g. an overflow.
+	PlanError
+)
+
+// String returns the name of the severity.
+func (s PlanSeverity) String() string {
+	if s == PlanError {
+		return "error"
+	}
+	return "warning"
+}
+
+// PlanStep is an operation of a dry run and the metadata of its result.
+type PlanStep struct {
+	Op        string
+	Level     int
+	LogScale  float64
+	Precision float64
+}
+
+// PlanIssue is an issue found at the step of index Step of a dry run.
+type PlanIssue struct {
+	Step     int
+	Severity PlanSeverity
+	Message  string
+}
+
+// PlanReport is the result of a dry run.
+type PlanReport struct {
+	Steps  []PlanStep
+	Issues []PlanIssue
+	// Fits is true if the computation raised no PlanError.
+	Fits bool
+	// MinPrecision is the minimum estimated precision of the outputs, in bits.
+	MinPrecision float64
+	// UnusedLevels is the minimum level of the outputs, i.e. the number of levels that the computation does not use.
+	UnusedLevels int
+}
+
+// String returns a human readable summary of the PlanReport, with one line per step and per issue.
+func (r *PlanReport) String() string {
+
+	var sb strings.Builder
+
+	for i, step := range r.Steps {
+		fmt.Fprintf(&sb, "%3d %-12s level=%-2d logScale=%6.2f precision=%6.2f\n", i, step.Op, step.Level, step.LogScale, step.Precision)
+	}
+
+	for _, issue := range r.Issues {
+		fmt.Fprintf(&sb, "%s at step %d (%s): %s\n", issue.Severity, issue.Step, r.Steps[issue.Step].Op, issue.Message)
+	}
+
+	fmt.Fprintf(&sb, "fits=%t minPrecision=%.2f unusedLevels=%d\n", r.Fits, r.MinPrecision, r.UnusedLevels)
+
+	return sb.String()
+}
+
+// Planner executes a sequence of operations of the Evaluator symbolically, without any cryptographic computation,
+// tracking the levels, scales and estimated errors of the ciphertexts. Its methods mirror the ones of the Evaluator
+// and follow the same rules for the levels and the scales, and the errors are estimated as in TrackingEvaluator.
+// The Report lists the issues of the computation: the overflows of the modulus, the rescalings at level 0, and the
+// levels wasted by the operations on ciphertexts of different levels. A computation can thus be validated instantly
+// for given parameters, before running it.
+type Planner struct {
+	params  Parameters
+	tracker *TrackingEvaluator
+
+	steps  []PlanStep
+	issues []PlanIssue
+}
+
+// NewPlanner creates a new Planner for the parameters params.
+func NewPlanner(params Parameters) *Planner {
+	return &Planner{params: params, tracker: NewTrackingEvaluator(params, nil)}
+}
+
+// record appends the step of the operation op of result ct and checks that its message does not overflow the modulus.
+func (p *Planner) record(op string, ct *SymbolicCiphertext) *SymbolicCiphertext {
+
+	p.steps = append(p.steps, PlanStep{Op: op, Level: ct.Level, LogScale: math.Log2(ct.Scale), Precision: ct.Precision()})
+
+	if logMessage, logQ := math.Log2(ct.MessageBound*ct.Scale)+1, float64(p.params.LogQLvl(ct.Level)); logMessage >= logQ {
+		p.issue(PlanError, "the message (%.2f bits) overflows the modulus (%.0f bits)", logMessage, logQ)
+	}
+
+	return ct
+}
+
+// issue adds an issue at the last step.
+func (p *Planner) issue(severity PlanSeverity, format string, args ...interface{}) {
+	p.issues = append(p.issues, PlanIssue{Step: len(p.steps) - 1, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// Fresh returns a fresh encryption of values bounded by messageBound, at the maximum level and the default scale.
+func (p *Planner) Fresh(messageBound float64) *SymbolicCiphertext {
+	return p.FreshAt(p.params.MaxLevel(), p.params.DefaultScale(), messageBound)
+}
+
+// FreshAt returns a fresh encryption of values bounded by messageBound, at the given level and scale.
+func (p *Planner) FreshAt(level int, scale, messageBound float64) *SymbolicCiphertext {
+	return p.record("Encrypt", &SymbolicCiphertext{Level: level, Scale: scale, Degree: 1, MessageBound: messageBound, ErrorBound: p.tracker.freshError() / scale})
+}
+
+// binary returns the result of an addition of op0 and op1, whose scales are aligned as in Evaluator.Add.
+func (p *Planner) binary(op string, op0, op1 *SymbolicCiphertext) *SymbolicCiphertext {
+
+	ct := &SymbolicCiphertext{
+		Level:        utils.MinInt(op0.Level, op1.Level),
+		Scale:        math.Max(op0.Scale, op1.Scale),
+		Degree:       utils.MaxInt(op0.Degree, op1.Degree),
+		MessageBound: op0.MessageBound + op1.MessageBound,
+		ErrorBound:   op0.ErrorBound + op1.ErrorBound,
+	}
+
+	// The operand of smaller scale is multiplied by the integer part of the ratio of the scales, hence its message
+	// is multiplied by floor(ratio)/ratio
+	small := op0
+	if op1.Scale < op0.Scale {
+		small = op1
+	}
+
+	ratio := ct.Scale / small.Scale
+	relErr := (ratio - math.Floor(ratio)) / ratio
+	ct.ErrorBound += relErr * small.MessageBound
+
+	p.record(op, ct)
+
+	if relErr != 0 {
+		p.issue(PlanWarning, "the misalignment of the scales introduces a relative error of 2^%.2f", math.Log2(relErr))
+	}
+
+	p.checkLevels(op0, op1)
+
+	return ct
+}
+
+// checkLevels records the levels wasted by an operation on op0 and op1 of different levels.
+func (p *Planner) checkLevels(op0, op1 *SymbolicCiphertext) {
+	if op0.Level != op1.Level {
+		maxLevel, minLevel := utils.MaxInt(op0.Level, op1.Level), utils.MinInt(op0.Level, op1.Level)
+		p.issue(PlanWarning, "%d levels wasted by the operand at level %d", maxLevel-minLevel, maxLevel)
+	}
+}
+
+// Add returns the symbolic result of Evaluator.Add.
+func (p *Planner) Add(op0, op1 *SymbolicCiphertext) *SymbolicCiphertext {
+	return p.binary("Add", op0, op1)
+}
+
+// Sub returns the symbolic result of Evaluator.Sub.
+func (p *Planner) Sub(op0, op1 *SymbolicCiphertext) *SymbolicCiphertext {
+	return p.binary("Sub", op0, op1)
+}
+
+// AddConst returns the symbolic result of Evaluator.AddConst.
+// The constant can be a complex128, float64, int, int64 or uint64.
+func (p *Planner) AddConst(ct0 *SymbolicCiphertext, constant interface{}) *SymbolicCiphertext {
+	ct := *ct0
+	ct.MessageBound += constAbs(constant)
+	ct.ErrorBound += 1 / ct0.Scale
+	return p.record("AddConst", &ct)
+}
+
+// MultByConst returns the symbolic result of Evaluator.MultByConst. A constant with a fractional part is scaled by
+// the modulus of the level, which multiplies the scale of the result.
+// The constant can be a complex128, float64, int, int64 or uint64.
+func (p *Planner) MultByConst(ct0 *SymbolicCiphertext, constant interface{}) *SymbolicCiphertext {
+
+	ct := *ct0
+	c := constAbs(constant)
+
+	ct.MessageBound *= c
+	ct.ErrorBound = ct0.ErrorBound*c + ct0.MessageBound/p.params.QiFloat64(ct0.Level)
+
+	if isFractional(constant) {
+		ct.Scale *= p.params.QiFloat64(ct0.Level)
+	}
+
+	return p.record("MultByConst", &ct)
+}
+
+// isFractional returns true if the real or the imaginary part of the constant has a fractional part.
+func isFractional(constant interface{}) bool {
+	switch c := constant.(type) {
+	case complex128:
+		return real(c) != math.Trunc(real(c)) || imag(c) != math.Trunc(imag(c))
+	case float64:
+		return c != math.Trunc(c)
+	default:
+		return false
+	}
+}
+
+// MulRelin returns the symbolic result of Evaluator.MulRelin. The method records an error if the parameters have
+// no special primes, i.e. if the relinearization is not possible.
+func (p *Planner) MulRelin(op0, op1 *SymbolicCiphertext) *SymbolicCiphertext {
+
+	level := utils.MinInt(op0.Level, op1.Level)
+
+	ct := &SymbolicCiphertext{
+		Level:        level,
+		Scale:        op0.Scale * op1.Scale,
+		Degree:       1,
+		MessageBound: op0.MessageBound * op1.MessageBound,
+		ErrorBound:   op0.MessageBound*op1.ErrorBound + op1.MessageBound*op0.ErrorBound + op0.ErrorBound*op1.ErrorBound,
+	}
+
+	ct.ErrorBound += p.tracker.keySwitchError(level) / ct.Scale
+
+	p.record("MulRelin", ct)
+
+	if p.params.PCount() == 0 {
+		p.issue(PlanError, "the relinearization requires special primes")
+	}
+
+	p.checkLevels(op0, op1)
+
+	return ct
+}
+
+// Rescale returns the symbolic result of Evaluator.Rescale, which divides ct0 by the last moduli of its level while
+// its scale stays larger than minScale/2. The method records an error if ct0 is at level 0.
+func (p *Planner) Rescale(ct0 *SymbolicCiphertext, minScale float64) *SymbolicCiphertext {
+
+	ct := *ct0
+
+	for ct.Level > 0 && ct.Scale/p.params.QiFloat64(ct.Level) >= minScale/2 {
+		ct.Scale /= p.params.QiFloat64(ct.Level)
+		ct.Level--
+	}
+
+	if ct.Level != ct0.Level {
+		ct.ErrorBound += p.tracker.roundingError() / ct.Scale
+	}
+
+	p.record("Rescale", &ct)
+
+	if ct0.Level == 0 {
+		p.issue(PlanError, "cannot rescale a ciphertext at level 0")
+	}
+
+	return &ct
+}
+
+// Rotate returns the symbolic result of Evaluator.Rotate.
+func (p *Planner) Rotate(ct0 *SymbolicCiphertext, k int) *SymbolicCiphertext {
+	return p.keySwitch("Rotate", ct0)
+}
+
+// Conjugate returns the symbolic result of Evaluator.Conjugate.
+func (p *Planner) Conjugate(ct0 *SymbolicCiphertext) *SymbolicCiphertext {
+	return p.keySwitch("Conjugate", ct0)
+}
+
+func (p *Planner) keySwitch(op string, ct0 *SymbolicCiphertext) *SymbolicCiphertext {
+
+	ct := *ct0
+	ct.ErrorBound += p.tracker.keySwitchError(ct0.Level) / ct0.Scale
+
+	p.record(op, &ct)
+
+	if p.params.PCount() == 0 {
+		p.issue(PlanError, "the key-switching requires special primes")
+	}
+
+	if ct0.Degree != 1 {
+		p.issue(PlanError, "the key-switching requires a ciphertext of degree 1")
+	}
+
+	return &ct
+}
+
+// DropLevel returns the symbolic result of Evaluator.DropLevel. The method records the dropped levels as wasted.
+func (p *Planner) DropLevel(ct0 *SymbolicCiphertext, levels int) *SymbolicCiphertext {
+
+	ct := *ct0
+	ct.Level -= levels
+
+	p.record("DropLevel", &ct)
+
+	if ct.Level < 0 {
+		p.issue(PlanError, "cannot drop %d levels of a ciphertext at level %d", levels, ct0.Level)
+	} else {
+		p.issue(PlanWarning, "%d levels wasted", levels)
+	}
+
+	return &ct
+}
+
+// Report returns the PlanReport of the operations executed so far, for the given outputs of the computation.
+// If minPrecision is positive, the report also records an error for the outputs of smaller estimated precision.
+func (p *Planner) Report(minPrecision float64, outputs ...*SymbolicCiphertext) *PlanReport {
+
+	report := &PlanReport{
+		Steps:        append([]PlanStep{}, p.steps...),
+		Issues:       append([]PlanIssue{}, p.issues...),
+		MinPrecision: math.Inf(1),
+		UnusedLevels: p.params.MaxLevel(),
+	}
+
+	for i, ct := range outputs {
+
+		report.MinPrecision = math.Min(report.MinPrecision, ct.Precision())
+		report.UnusedLevels = utils.MinInt(report.UnusedLevels, ct.Level)
+
+		if minPrecision > 0 && ct.Precision() < minPrecision {
+			report.Issues = append(report.Issues, PlanIssue{
+				Step:     len(p.steps) - 1,
+				Severity: PlanError,
+				Message:  fmt.Sprintf("the precision of the output %d (%.2f bits) is smaller than %.2f bits", i, ct.Precision(), minPrecision),
+			})
+		}
+	}
+
+	report.Fits = true
+	for _, issue := range report.Issues {
+		if issue.Severity == PlanError {
+			report.Fits = false
+		}
+	}
+
+	return report
+}
@@ -76,6 +76,9 @@ type polynomialEvaluator struct {
 	powerBasis map[int]*Ciphertext
 	logDegree  int
 	logSplit   int
+
+	// scaleInvariant forces each rescale to divide by exactly one modulus, see EvaluatePolyScaleInvariant
+	scaleInvariant bool
 }
 
 // EvaluatePoly evaluates a polynomial in standard basis on the input Ciphertext in ceil(log2(deg+1)) levels.
@@ -87,7 +90,42 @@ type polynomialEvaluator struct {
 // if the polynomial is "even" or "odd" (to ensure that the even or odd property remains valid
 // after the "splitCoeffs" polynomial decomposition).
 func (eval *evaluator) EvaluatePoly(ct0 *Ciphertext, pol *Polynomial, targetScale float64) (opOut *Ciphertext, err error) {
-	return eval.evaluatePolyVector(ct0, polynomialVector{Value: []*Polynomial{pol}}, targetScale)
+	return eval.evaluatePolyVector(ct0, polynomialVector{Value: []*Polynomial{pol}}, targetScale, false)
+}
+
+// EvaluatePolyScaleInvariant evaluates a polynomial on the input Ciphertext like EvaluatePoly, but consumes
+// exactly the levels in the range [levelEnd, levelStart] allotted by the caller, and outputs exactly at targetScale,
+// whatever the scale of the input:
+//
+// - the input is dropped to the level levelStart before the evaluation, so that the output is at the level
+// levelStart - pol.Depth(),
+//
+// - each product of the evaluation is rescaled by exactly one modulus, instead of as many moduli as targetScale
+// allows, so that the levels consumed do not depend on the scale of the input, even if it is far from the moduli,
+//
+// - the Chebyshev recurrence is evaluated before the rescaling of the products, so that the powers of the input need
+// not have the same scale,
+//
+// - the coefficients are encoded at the scales compensating the scales of the powers of the input and the moduli of
+// the rescalings, so that the output lands on targetScale without a correcting multiplication.
+//
+// Returns an error if the input is at a level smaller than levelStart or if the polynomial cannot be evaluated
+// without going below levelEnd.
+func (eval *evaluator) EvaluatePolyScaleInvariant(ct0 *Ciphertext, pol *Polynomial, targetScale float64, levelStart, levelEnd int) (opOut *Ciphertext, err error) {
+
+	if ct0.Level() < levelStart {
+		return nil, fmt.Errorf("cannot EvaluatePolyScaleInvariant: input level %d < levelStart %d", ct0.Level(), levelStart)
+	}
+
+	if levelStart-pol.Depth() < levelEnd {
+		return nil, fmt.Errorf("cannot EvaluatePolyScaleInvariant: polynomial of depth %d cannot be evaluated in the levels [%d, %d]", pol.Depth(), levelEnd, levelStart)
+	}
+
+	if ct0.Level() > levelStart {
+		ct0 = eval.DropLevelNew(ct0, ct0.Level()-levelStart)
+	}
+
+	return eval.evaluatePolyVector(ct0, polynomialVector{Value: []*Polynomial{pol}}, targetScale, true)
 }
 
 type polynomialVector struct {
@@ -131,10 +169,10 @@ func (eval *evaluator) EvaluatePolyVector(ct0 *Ciphertext, pols []*Polynomial, e
 		}
 	}
 
-	return eval.evaluatePolyVector(ct0, polynomialVector{Encoder: encoder, Value: pols, SlotsIndex: slotsIndex}, targetScale)
+	return eval.evaluatePolyVector(ct0, polynomialVector{Encoder: encoder, Value: pols, SlotsIndex: slotsIndex}, targetScale, false)
 }
 
-func (eval *evaluator) evaluatePolyVector(ct0 *Ciphertext, pol polynomialVector, targetScale float64) (opOut *Ciphertext, err error) {
+func (eval *evaluator) evaluatePolyVector(ct0 *Ciphertext, pol polynomialVector, targetScale float64, scaleInvariant bool) (opOut *Ciphertext, err error) {
 
 	if pol.SlotsIndex != nil && pol.Encoder == nil {
 		return nil, fmt.Errorf("cannot EvaluatePolyVector, missing Encoder input")
@@ -159,14 +197,14 @@ func (eval *evaluator) evaluatePolyVector(ct0 *Ciphertext, pol polynomialVector,
 
 	for i := 2; i < (1 << logSplit); i++ {
 		if !(even || odd) || (i&1 == 0 && even) || (i&1 == 1 && odd) {
-			if err = computePowerBasis(i, poweBasis, targetScale, pol.Value[0].Basis, eval); err != nil {
+			if err = computePowerBasis(i, poweBasis, targetScale, pol.Value[0].Basis, scaleInvariant, eval); err != nil {
 				return nil, err
 			}
 		}
 	}
 
 	for i := logSplit; i < logDegree; i++ {
-		if err = computePowerBasis(1<<i, poweBasis, targetScale, pol.Value[0].Basis, eval); err != nil {
+		if err = computePowerBasis(1<<i, poweBasis, targetScale, pol.Value[0].Basis, scaleInvariant, eval); err != nil {
 			return nil, err
 		}
 	}
@@ -178,6 +216,7 @@ func (eval *evaluator) evaluatePolyVector(ct0 *Ciphertext, pol polynomialVector,
 	polyEval.powerBasis = poweBasis
 	polyEval.logDegree = logDegree
 	polyEval.logSplit = logSplit
+	polyEval.scaleInvariant = scaleInvariant
 
 	opOut, err = polyEval.recurse(targetScale, pol)
 
@@ -188,7 +227,7 @@ func (eval *evaluator) evaluatePolyVector(ct0 *Ciphertext, pol polynomialVector,
 	return opOut, err
 }
 
-func computePowerBasis(n int, C map[int]*Ciphertext, scale float64, basis PolynomialBasis, evaluator *evaluator) (err error) {
+func computePowerBasis(n int, C map[int]*Ciphertext, scale float64, basis PolynomialBasis, scaleInvariant bool, evaluator *evaluator) (err error) {
 
 	if C[n] == nil {
 
@@ -208,16 +247,37 @@ func computePowerBasis(n int, C map[int]*Ciphertext, scale float64, basis Polyno
 			}
 		}
 		// Recurses on the given indexes
-		if err = computePowerBasis(a, C, scale, basis, evaluator); err != nil {
+		if err = computePowerBasis(a, C, scale, basis, scaleInvariant, evaluator); err != nil {
 			return err
 		}
-		if err = computePowerBasis(b, C, scale, basis, evaluator); err != nil {
+		if err = computePowerBasis(b, C, scale, basis, scaleInvariant, evaluator); err != nil {
 			return err
 		}
 
 		// Computes C[n] = C[a]*C[b]
 		C[n] = evaluator.MulRelinNew(C[a], C[b])
 
+		// A scale invariant evaluation does not assume that the powers have the same scale: the Chebyshev recurrence
+		// is computed before the rescaling, at the scale of the product, which is then divided by exactly one modulus
+		if scaleInvariant {
+
+			if basis == ChebyshevBasis {
+
+				evaluator.Add(C[n], C[n], C[n])
+
+				if c == 0 {
+					evaluator.AddConst(C[n], -1, C[n])
+				} else {
+					if err = computePowerBasis(c, C, scale, basis, scaleInvariant, evaluator); err != nil {
+						return err
+					}
+					evaluator.subAtScale(C[n], C[c])
+				}
+			}
+
+			return evaluator.Rescale(C[n], C[n].Scale/evaluator.params.QiFloat64(C[n].Level()), C[n])
+		}
+
 		if err = evaluator.Rescale(C[n], scale, C[n]); err != nil {
 			return err
 		}
@@ -232,7 +292,7 @@ func computePowerBasis(n int, C map[int]*Ciphertext, scale float64, basis Polyno
 				evaluator.AddConst(C[n], -1, C[n])
 			} else {
 				// Since C[0] is not stored (but rather seen as the constant 1), only recurses on c if c!= 0
-				if err = computePowerBasis(c, C, scale, basis, evaluator); err != nil {
+				if err = computePowerBasis(c, C, scale, basis, scaleInvariant, evaluator); err != nil {
 					return err
 				}
 				evaluator.Sub(C[n], C[c], C[n])
@@ -243,6 +303,23 @@ func computePowerBasis(n int, C map[int]*Ciphertext, scale float64, basis Polyno
 	return nil
 }
 
+// subAtScale subtracts ct1 from ct0, whose scale is much larger, after multiplying ct1 by the integer closest to the
+// ratio of their scales, so that the subtraction is exact up to a relative error of the inverse of this ratio.
+func (eval *evaluator) subAtScale(ct0, ct1 *Ciphertext) {
+
+	ratio := new(big.Float).SetFloat64(ct0.Scale / ct1.Scale)
+	ratio.Add(ratio, new(big.Float).SetFloat64(0.5))
+
+	ratioInt := new(big.Int)
+	ratio.Int(ratioInt)
+
+	tmp := NewCiphertext(eval.params, 1, ct0.Level(), ct0.Scale)
+	eval.MultByGaussianInteger(ct1, ratioInt, int64(0), tmp)
+	tmp.Scale = ct0.Scale
+
+	eval.Sub(ct0, tmp, ct0)
+}
+
 func splitCoeffs(coeffs *Polynomial, split int) (coeffsq, coeffsr *Polynomial) {
 
 	// Splits a polynomial p such that p = q*C^degree + r.
@@ -312,6 +389,7 @@ func (polyEval *polynomialEvaluator) recurse(targetScale float64, pol polynomial
 			polyEvalBis.logDegree = logDegree
 			polyEvalBis.logSplit = logSplit
 			polyEvalBis.powerBasis = polyEval.powerBasis
+			polyEvalBis.scaleInvariant = polyEval.scaleInvariant
 
 			return polyEvalBis.recurse(targetScale, pol)
 		}
@@ -354,13 +432,13 @@ func (polyEval *polynomialEvaluator) recurse(targetScale float64, pol polynomial
 	polyEval.MulRelin(res, XPow, res)
 
 	if res.Level() > tmp.Level() {
-		if err = polyEval.Rescale(res, targetScale, res); err != nil {
+		if err = polyEval.rescale(res, targetScale); err != nil {
 			return nil, err
 		}
 		polyEval.Add(res, tmp, res)
 	} else {
 		polyEval.Add(res, tmp, res)
-		if err = polyEval.Rescale(res, targetScale, res); err != nil {
+		if err = polyEval.rescale(res, targetScale); err != nil {
 			return nil, err
 		}
 	}
@@ -555,13 +633,23 @@ func (polyEval *polynomialEvaluator) evaluatePolyFromPowerBasis(targetScale floa
 		}
 	}
 
-	if err = polyEval.Rescale(res, targetScale, res); err != nil {
+	if err = polyEval.rescale(res, targetScale); err != nil {
 		return nil, err
 	}
 
 	return
 }
 
+// rescale rescales ct towards targetScale, by exactly one modulus if the evaluation is scale invariant.
+func (polyEval *polynomialEvaluator) rescale(ct *Ciphertext, targetScale float64) (err error) {
+
+	if polyEval.scaleInvariant {
+		targetScale = ct.Scale / polyEval.Evaluator.(*evaluator).params.QiFloat64(ct.Level())
+	}
+
+	return polyEval.Rescale(ct, targetScale, ct)
+}
+
 func isNotNegligible(c complex128) bool {
 	return (math.Abs(real(c)) > IsNegligbleThreshold || math.Abs(imag(c)) > IsNegligbleThreshold)
 }
@@ -0,0 +1,223 @@
+package ckks
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// errorBoundFactor is the number of standard deviations used to bound the errors, which are approximated
+// as Gaussian by the central limit theorem.
+const errorBoundFactor = 6.0
+
+// TrackedCiphertext is a Ciphertext carrying a bound on the absolute value of its slots and an estimated bound
+// on the absolute error of its slots, both in the message domain (i.e. divided by the scale).
+// The bounds are updated by the operations of a TrackingEvaluator.
+type TrackedCiphertext struct {
+	*Ciphertext
+	MessageBound float64
+	ErrorBound   float64
+}
+
+// Precision returns the estimated precision in bits of the slots of the TrackedCiphertext, i.e. -log2(ErrorBound).
+func (ct *TrackedCiphertext) Precision() float64 {
+	return -math.Log2(ct.ErrorBound)
+}
+
+// CopyNew creates a deep copy of the TrackedCiphertext.
+func (ct *TrackedCiphertext) CopyNew() *TrackedCiphertext {
+	return &TrackedCiphertext{Ciphertext: ct.Ciphertext.CopyNew(), MessageBound: ct.MessageBound, ErrorBound: ct.ErrorBound}
+}
+
+// TrackingEvaluator wraps an Evaluator and updates the bounds of the TrackedCiphertext after each operation with
+// an analytical estimate of the error it introduces, so that the precision of the results can be monitored
+// without decrypting them. Tracking is opt-in: the ciphertexts are only tracked through the methods of the
+// TrackingEvaluator. It does not expose the other operations of the Evaluator, which it cannot track, so that an
+// untracked operation on a TrackedCiphertext does not compile: the bounds of a TrackedCiphertext whose Ciphertext is
+// modified by the Evaluator are no longer valid.
+//
+// The estimates assume a uniform ternary secret and bound each error by 6 standard deviations, and the
+// errors of the operands are added without assuming their independence, hence they are conservative,
+// typically by a few bits. They are heuristic and are not guaranteed bounds.
+type TrackingEvaluator struct {
+	eval   Evaluator
+	params Parameters
+}
+
+// NewTrackingEvaluator creates a new TrackingEvaluator evaluating the operations with the Evaluator eval.
+func NewTrackingEvaluator(params Parameters, eval Evaluator) *TrackingEvaluator {
+	return &TrackingEvaluator{eval: eval, params: params}
+}
+
+// Track returns a new TrackedCiphertext from a fresh encryption ct of values bounded by messageBound, whose
+// error is the error of the encoding and of the encryption, with a public key or a secret key.
+func (eval *TrackingEvaluator) Track(ct *Ciphertext, messageBound float64) *TrackedCiphertext {
+	return &TrackedCiphertext{Ciphertext: ct, MessageBound: messageBound, ErrorBound: eval.freshError() / ct.Scale}
+}
+
+// Add adds op0 to op1 and returns the result in ctOut.
+func (eval *TrackingEvaluator) Add(op0, op1, ctOut *TrackedCiphertext) {
+	messageBound, errorBound := op0.MessageBound+op1.MessageBound, op0.ErrorBound+op1.ErrorBound
+	eval.eval.Add(op0.Ciphertext, op1.Ciphertext, ctOut.Ciphertext)
+	ctOut.MessageBound, ctOut.ErrorBound = messageBound, errorBound
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut.
+func (eval *TrackingEvaluator) Sub(op0, op1, ctOut *TrackedCiphertext) {
+	messageBound, errorBound := op0.MessageBound+op1.MessageBound, op0.ErrorBound+op1.ErrorBound
+	eval.eval.Sub(op0.Ciphertext, op1.Ciphertext, ctOut.Ciphertext)
+	ctOut.MessageBound, ctOut.ErrorBound = messageBound, errorBound
+}
+
+// AddConst adds the constant to ct0 and returns the result in ctOut.
+// The constant can be a complex128, float64, int, int64 or uint64.
+func (eval *TrackingEvaluator) AddConst(ct0 *TrackedCiphertext, constant interface{}, ctOut *TrackedCiphertext) {
+	// The constant is rounded to the nearest multiple of 1/scale
+	messageBound, errorBound := ct0.MessageBound+constAbs(constant), ct0.ErrorBound+1/ct0.Scale
+	eval.eval.AddConst(ct0.Ciphertext, constant, ctOut.Ciphertext)
+	ctOut.MessageBound, ctOut.ErrorBound = messageBound, errorBound
+}
+
+// MultByConst multiplies ct0 by the constant and returns the result in ctOut.
+// The constant can be a complex128, float64, int, int64 or uint64.
+func (eval *TrackingEvaluator) MultByConst(ct0 *TrackedCiphertext, constant interface{}, ctOut *TrackedCiphertext) {
+	// A non-integer constant is scaled by the modulus of the level and rounded
+	c := constAbs(constant)
+	messageBound := ct0.MessageBound * c
+	errorBound := ct0.ErrorBound*c + ct0.MessageBound/eval.params.QiFloat64(ct0.Level())
+	eval.eval.MultByConst(ct0.Ciphertext, constant, ctOut.Ciphertext)
+	ctOut.MessageBound, ctOut.ErrorBound = messageBound, errorBound
+}
+
+// MulRelin multiplies op0 by op1 with relinearization and returns the result in ctOut.
+func (eval *TrackingEvaluator) MulRelin(op0, op1, ctOut *TrackedCiphertext) {
+	level := op0.Level()
+	if op1.Level() < level {
+		level = op1.Level()
+	}
+	messageBound := op0.MessageBound * op1.MessageBound
+	errorBound := op0.MessageBound*op1.ErrorBound + op1.MessageBound*op0.ErrorBound + op0.ErrorBound*op1.ErrorBound
+	errorBound += eval.keySwitchError(level) / (op0.Scale * op1.Scale)
+	eval.eval.MulRelin(op0.Ciphertext, op1.Ciphertext, ctOut.Ciphertext)
+	ctOut.MessageBound, ctOut.ErrorBound = messageBound, errorBound
+}
+
+// Rescale divides ctIn by the last moduli of its level while its scale stays larger than minScale/2 (see
+// Evaluator.Rescale) and returns the result in ctOut.
+func (eval *TrackingEvaluator) Rescale(ctIn *TrackedCiphertext, minScale float64, ctOut *TrackedCiphertext) (err error) {
+	messageBound, errorBound := ctIn.MessageBound, ctIn.ErrorBound
+	if err = eval.eval.Rescale(ctIn.Ciphertext, minScale, ctOut.Ciphertext); err != nil {
+		return
+	}
+	// The division rounds the coefficients of the ciphertext
+	ctOut.MessageBound, ctOut.ErrorBound = messageBound, errorBound+eval.roundingError()/ctOut.Scale
+	return
+}
+
+// Rotate rotates the slots of ct0 by k positions to the left and returns the result in ctOut.
+func (eval *TrackingEvaluator) Rotate(ct0 *TrackedCiphertext, k int, ctOut *TrackedCiphertext) {
+	messageBound, errorBound := ct0.MessageBound, ct0.ErrorBound+eval.keySwitchError(ct0.Level())/ct0.Scale
+	eval.eval.Rotate(ct0.Ciphertext, k, ctOut.Ciphertext)
+	ctOut.MessageBound, ctOut.ErrorBound = messageBound, errorBound
+}
+
+// Conjugate conjugates the slots of ct0 and returns the result in ctOut.
+func (eval *TrackingEvaluator) Conjugate(ct0 *TrackedCiphertext, ctOut *TrackedCiphertext) {
+	messageBound, errorBound := ct0.MessageBound, ct0.ErrorBound+eval.keySwitchError(ct0.Level())/ct0.Scale
+	eval.eval.Conjugate(ct0.Ciphertext, ctOut.Ciphertext)
+	ctOut.MessageBound, ctOut.ErrorBound = messageBound, errorBound
+}
+
+// DropLevel reduces the level of ct0 by levels, which leaves its error unchanged.
+func (eval *TrackingEvaluator) DropLevel(ct0 *TrackedCiphertext, levels int) {
+	eval.eval.DropLevel(ct0.Ciphertext, levels)
+}
+
+// slotsError returns the bound on the error of the slots of a polynomial whose coefficients have an error of
+// variance variance, which is sum_j e_j * zeta^j, of variance N * variance.
+func (eval *TrackingEvaluator) slotsError(variance float64) float64 {
+	return errorBoundFactor * math.Sqrt(float64(eval.params.N())*variance)
+}
+
+// roundingVariance returns the variance of the coefficients of c1 * s + c0 for uniform c0 and c1 in [-1/2, 1/2] and
+// a uniform ternary secret s, i.e. the rounding error of the rescaling.
+func (eval *TrackingEvaluator) roundingVariance() float64 {
+	return (1 + 2*float64(eval.params.N())/3) / 12
+}
+
+// roundingError returns the bound on the error of the slots introduced by a rounding of the coefficients.
+func (eval *TrackingEvaluator) roundingError() float64 {
+	return eval.slotsError(eval.roundingVariance())
+}
+
+// modDownError returns the bound on the error of the slots introduced by the division by P of the key-switching
+// and of the public key encryption. This division rounds the coefficients down, hence introduces the error
+// r0 + r1 * s with r0 and r1 in [0, 1), whose mean (1 + s) * (1 + X + ... + X^{N-1}) / 2 dominates: the slots
+// of 1 + X + ... + X^{N-1} are 2/(1 - zeta) for the 2N-th roots of unity zeta, which are bounded by about N/pi.
+func (eval *TrackingEvaluator) modDownError() float64 {
+	N := float64(eval.params.N())
+	return (1+eval.slotsError(2.0/3))*N/(2*math.Pi) + eval.roundingError()
+}
+
+// freshError returns the bound on the error of the slots of a fresh encryption, which is the error of the public
+// key encryption (u * e_pk + e_0 + s * e_1)/P, or u * e_pk + e_0 + s * e_1 if there is no P, which is larger than
+// the error of the secret key encryption, and the rounding error of the encoding.
+func (eval *TrackingEvaluator) freshError() float64 {
+
+	if eval.params.PCount() != 0 {
+		return eval.modDownError() + eval.slotsError(1.0/12)
+	}
+
+	N := float64(eval.params.N())
+	sigma := eval.params.Sigma()
+
+	return eval.slotsError(sigma*sigma*(1+4*N/3) + 1.0/12)
+}
+
+// keySwitchError returns the bound on the error of the slots introduced by a key-switching at the given level,
+// i.e. sum_i d_i * e_i / P, with d_i uniform in [0, Q_alpha_i), and the error of the division by P.
+func (eval *TrackingEvaluator) keySwitchError(level int) float64 {
+
+	N := float64(eval.params.N())
+	sigma := eval.params.Sigma()
+	alpha := eval.params.PCount()
+
+	if alpha == 0 {
+		alpha = 1
+	}
+
+	var logP float64
+	for i := 0; i < eval.params.PCount(); i++ {
+		logP += math.Log2(float64(eval.params.P()[i]))
+	}
+
+	var variance float64
+	for i := 0; i <= level; i += alpha {
+
+		var logQAlpha float64
+		for j := i; j < i+alpha && j <= level; j++ {
+			logQAlpha += math.Log2(eval.params.QiFloat64(j))
+		}
+
+		variance += N * sigma * sigma * math.Exp2(2*(logQAlpha-logP)) / 12
+	}
+
+	return eval.slotsError(variance) + eval.modDownError()
+}
+
+// constAbs returns the absolute value of a constant of type complex128, float64, int, int64 or uint64.
+func constAbs(constant interface{}) float64 {
+	switch c := constant.(type) {
+	case complex128:
+		return cmplx.Abs(c)
+	case float64:
+		return math.Abs(c)
+	case int:
+		return math.Abs(float64(c))
+	case int64:
+		return math.Abs(float64(c))
+	case uint64:
+		return float64(c)
+	default:
+		panic("constant must be complex128, float64, int, int64 or uint64")
+	}
+}
@@ -0,0 +1,388 @@
+package ckks
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// exchange is the operation of a compare-exchange between the values of index i and i + distance of a sortingStage.
+type exchange int
+
+const (
+	// exchangeNone sets both values to zero.
+	exchangeNone = exchange(iota)
+	// exchangeAscending stores the minimum at the index i and the maximum at the index i + distance.
+	exchangeAscending
+	// exchangeDescending stores the maximum at the index i and the minimum at the index i + distance.
+	exchangeDescending
+	// exchangeMax stores the maximum at the index i and zero at the index i + distance.
+	exchangeMax
+)
+
+// sortingStage is a stage of a sorting network, which compares in parallel the values of index i and i + distance,
+// for the indexes i with i & distance = 0, according to exchange(i).
+type sortingStage struct {
+	distance int
+	exchange func(i int) exchange
+}
+
+// bitonicSortStages appends to stages the stages of the bitonic sort of blocks of size values, in alternating orders,
+// the first block being sorted in descending order if descending is true. The values of index larger than length
+// are discarded.
+func bitonicSortStages(stages []sortingStage, length, size int, descending bool) []sortingStage {
+	for k := 2; k <= size; k <<= 1 {
+		for j := k >> 1; j > 0; j >>= 1 {
+			k := k
+			stages = append(stages, sortingStage{distance: j, exchange: func(i int) exchange {
+				if i >= length {
+					return exchangeNone
+				}
+				if (i&k == 0) != descending {
+					return exchangeAscending
+				}
+				return exchangeDescending
+			}})
+		}
+	}
+	return stages
+}
+
+// topKStages returns the stages of the bitonic top-k selection of Shanbhag et al., "Efficient Top-K Query
+// Processing on Massively Parallel Hardware": the blocks of k values are sorted in alternating orders, then each
+// round keeps the element-wise maximum of the pairs of blocks, which is a bitonic sequence storing the k largest
+// values of the pair, and merges it in alternating orders, until a single block sorted in descending order remains.
+// The blocks kept by the round r are at the indexes multiple of k * 2^(r+1).
+func topKStages(length, k int) (stages []sortingStage) {
+
+	stages = bitonicSortStages(stages, length, k, true)
+
+	for d := k; d < length; d <<= 1 {
+
+		d := d
+
+		stages = append(stages, sortingStage{distance: d, exchange: func(i int) exchange {
+			if i%(2*d) < k {
+				return exchangeMax
+			}
+			return exchangeNone
+		}})
+
+		for j := k >> 1; j > 0; j >>= 1 {
+			stages = append(stages, sortingStage{distance: j, exchange: func(i int) exchange {
+				if i%(2*d) >= k {
+					return exchangeNone
+				}
+				if (i/(2*d))&1 == 0 {
+					return exchangeDescending
+				}
+				return exchangeAscending
+			}})
+		}
+	}
+
+	return
+}
+
+// checkSortingLength returns an error if length is not a power of two.
+func checkSortingLength(length int) error {
+	if length < 1 || length&(length-1) != 0 {
+		return fmt.Errorf("length %d is not a power of two", length)
+	}
+	return nil
+}
+
+// rotationsForStages returns the rotations required to evaluate the stages in ciphertexts of 2^logSlots slots.
+func rotationsForStages(stages []sortingStage, logSlots int) (rots []int) {
+
+	slots := 1 << logSlots
+
+	rotIndex := make(map[int]bool)
+	for _, stage := range stages {
+		if stage.distance < slots {
+			rotIndex[stage.distance] = true
+			rotIndex[slots-stage.distance] = true
+		}
+	}
+
+	rots = make([]int, 0, len(rotIndex))
+	for rot := range rotIndex {
+		rots = append(rots, rot)
+	}
+
+	sort.Ints(rots)
+
+	return
+}
+
+// RotationsForSort returns the rotations required by Sorter.SortNew to sort a Vector of length values in
+// ciphertexts of 2^logSlots slots.
+func (p Parameters) RotationsForSort(length, logSlots int) []int {
+	return rotationsForStages(bitonicSortStages(nil, length, length, false), logSlots)
+}
+
+// RotationsForTopK returns the rotations required by Sorter.TopKNew to select the k largest values of a Vector of
+// length values in ciphertexts of 2^logSlots slots.
+func (p Parameters) RotationsForTopK(length, k, logSlots int) []int {
+	return rotationsForStages(topKStages(length, k), logSlots)
+}
+
+// Sorter sorts the values of Vectors and selects their largest values with the sorting networks of compare-exchange
+// operations evaluated by a Comparator, with the same restrictions on the values: they must be in [0, 1] and are
+// sorted exactly, up to the error of the approximation of the sign function, if they are at least 2^-logGap apart,
+// where logGap is the parameter of GenComparisonParameters. Closer values are sorted approximately: their order is
+// arbitrary and they can be mixed, e.g. two values closer than 2^-logGap can be replaced by two convex
+// combinations of them.
+//
+// Each stage of a network compares all the values in parallel and consumes Comparator.Depth() + 1 levels, so that
+// the networks usually require bootstrapping between the calls on a few values: sorting 2^l values requires
+// l * (l+1) / 2 stages and selecting the 2^m largest of 2^l values requires m * (m+1) / 2 + (l-m) * (m+1) stages.
+type Sorter struct {
+	params  Parameters
+	encoder Encoder
+	cmp     *Comparator
+}
+
+// NewSorter creates a Sorter evaluating the comparisons with cmp. The encoder encodes the masks of the
+// compare-exchange operations.
+func NewSorter(params Parameters, encoder Encoder, cmp *Comparator) *Sorter {
+	return &Sorter{params: params, encoder: encoder, cmp: cmp}
+}
+
+// SortDepth returns the number of levels consumed by SortNew to sort length values.
+func (s *Sorter) SortDepth(length int) int {
+	return len(bitonicSortStages(nil, length, length, false)) * (s.cmp.Depth() + 1)
+}
+
+// TopKDepth returns the number of levels consumed by TopKNew to select the k largest of length values.
+func (s *Sorter) TopKDepth(length, k int) int {
+	return len(topKStages(length, k)) * (s.cmp.Depth() + 1)
+}
+
+// SortNew sorts the values of v, in descending order if descending is true and in ascending order otherwise, with a
+// bitonic sorting network and returns the result in a new Vector at the level v.Level() - SortDepth(v.Len) with the
+// scale of v. The length of v must be a power of two, which can be reached by padding v with zeros.
+// The Evaluator of the Comparator must have the rotation keys for the rotations returned by
+// Parameters.RotationsForSort.
+// It returns an error if the length of v is not a power of two or if v does not have enough levels.
+func (s *Sorter) SortNew(v *Vector, descending bool) (vOut *Vector, err error) {
+
+	if err = checkSortingLength(v.Len); err != nil {
+		return nil, fmt.Errorf("cannot SortNew: %w", err)
+	}
+
+	if v.Level() < s.SortDepth(v.Len) {
+		return nil, fmt.Errorf("cannot SortNew: %d levels < %d depth", v.Level(), s.SortDepth(v.Len))
+	}
+
+	if vOut, err = s.evaluateStages(v, bitonicSortStages(nil, v.Len, v.Len, descending)); err != nil {
+		return nil, fmt.Errorf("cannot SortNew: %w", err)
+	}
+
+	return
+}
+
+// TopKNew selects the k largest values of v with the bitonic top-k selection and returns them in descending order in
+// the first k slots of a new Ciphertext at the level v.Level() - TopKDepth(v.Len, k) with the scale of v. The other
+// slots are zero. The length of v and k must be powers of two, with k smaller than the length of v and than the
+// slots. The Evaluator of the Comparator must have the rotation keys for the rotations returned by
+// Parameters.RotationsForTopK.
+// It returns an error if the length of v or k are invalid or if v does not have enough levels.
+func (s *Sorter) TopKNew(v *Vector, k int) (ctOut *Ciphertext, err error) {
+
+	if err = checkSortingLength(v.Len); err != nil {
+		return nil, fmt.Errorf("cannot TopKNew: %w", err)
+	}
+
+	if k < 1 || k&(k-1) != 0 || k > v.Len || k > 1<<v.LogSlots {
+		return nil, fmt.Errorf("cannot TopKNew: k = %d must be a power of two smaller than the length %d and the slots %d", k, v.Len, 1<<v.LogSlots)
+	}
+
+	if v.Level() < s.TopKDepth(v.Len, k) {
+		return nil, fmt.Errorf("cannot TopKNew: %d levels < %d depth", v.Level(), s.TopKDepth(v.Len, k))
+	}
+
+	vOut, err := s.evaluateStages(v, topKStages(v.Len, k))
+	if err != nil {
+		return nil, fmt.Errorf("cannot TopKNew: %w", err)
+	}
+
+	return vOut.Value[0], nil
+}
+
+// evaluateStages evaluates the stages on v and returns the result in a new Vector.
+func (s *Sorter) evaluateStages(v *Vector, stages []sortingStage) (vOut *Vector, err error) {
+
+	vOut = v.CopyNew()
+
+	slots := 1 << v.LogSlots
+
+	for _, stage := range stages {
+
+		if stage.distance < slots {
+
+			// The values of index i and i + distance are in the same ciphertext: the upper values are rotated on
+			// the lower ones, and conversely after the compare-exchange
+			for c, ct := range vOut.Value {
+
+				rotated := s.cmp.eval.RotateNew(ct, stage.distance)
+
+				lower, upper, err := s.compareExchange(ct, rotated, stage, c*slots, v.LogSlots)
+				if err != nil {
+					return nil, err
+				}
+
+				if upper != nil {
+					s.cmp.eval.Rotate(upper, slots-stage.distance, upper)
+					lower = s.add(lower, upper)
+				}
+
+				vOut.Value[c] = s.zeroIfNil(lower, ct.Level()-s.cmp.Depth()-1, ct.Scale)
+			}
+
+		} else {
+
+			// The values of index i and i + distance are in the ciphertexts c and c + distance / slots
+			d := stage.distance / slots
+
+			for c := range vOut.Value {
+
+				if c&d != 0 {
+					continue
+				}
+
+				ct0, ct1 := vOut.Value[c], vOut.Value[c+d]
+
+				lower, upper, err := s.compareExchange(ct0, ct1, stage, c*slots, v.LogSlots)
+				if err != nil {
+					return nil, err
+				}
+
+				level := utils.MinInt(ct0.Level(), ct1.Level()) - s.cmp.Depth() - 1
+
+				vOut.Value[c] = s.zeroIfNil(lower, level, ct0.Scale)
+				vOut.Value[c+d] = s.zeroIfNil(upper, level, ct0.Scale)
+			}
+		}
+	}
+
+	return
+}
+
+// compareExchange evaluates the compare-exchange of the stage on the values of a and b, storing the values of index
+// offset + i and offset + i + distance in their slot i, and returns the new values of a and b. It returns nil instead
+// of a ciphertext whose slots are all zero.
+func (s *Sorter) compareExchange(a, b *Ciphertext, stage sortingStage, offset, logSlots int) (lower, upper *Ciphertext, err error) {
+
+	slots := 1 << logSlots
+
+	// With t = (a-b) * step(a-b), the minimum is a - t and the maximum b + t, so that
+	// lower = lowerMin * a + lowerMax * b + (lowerMax - lowerMin) * t and
+	// upper = upperMin * a + upperMax * b + (upperMax - upperMin) * t.
+	lowerMin, lowerMax := make([]float64, slots), make([]float64, slots)
+	upperMin, upperMax := make([]float64, slots), make([]float64, slots)
+	lowerDiff, upperDiff := make([]float64, slots), make([]float64, slots)
+
+	for i := 0; i < slots; i++ {
+
+		if (offset+i)&stage.distance != 0 {
+			continue
+		}
+
+		switch stage.exchange(offset + i) {
+		case exchangeAscending:
+			lowerMin[i], upperMax[i] = 1, 1
+		case exchangeDescending:
+			lowerMax[i], upperMin[i] = 1, 1
+		case exchangeMax:
+			lowerMax[i] = 1
+		}
+
+		lowerDiff[i] = lowerMax[i] - lowerMin[i]
+		upperDiff[i] = upperMax[i] - upperMin[i]
+	}
+
+	// The masks are multiplied with the difference before its multiplication with the step, which is evaluated in
+	// parallel, so that the compare-exchange consumes a single level on top of the comparison
+	diff := s.cmp.eval.SubNew(a, b)
+
+	lowerDiffCt, upperDiffCt := s.mulMask(diff, lowerDiff, logSlots), s.mulMask(diff, upperDiff, logSlots)
+
+	if lowerDiffCt != nil || upperDiffCt != nil {
+
+		level := diff.Level() - s.cmp.Depth()
+		if level < 1 {
+			return nil, nil, fmt.Errorf("%d levels < %d depth", diff.Level(), s.cmp.Depth()+1)
+		}
+
+		var step *Ciphertext
+		if step, err = s.cmp.evaluate(diff, true, s.params.QiFloat64(level)); err != nil {
+			return nil, nil, err
+		}
+
+		for _, ct := range []*Ciphertext{lowerDiffCt, upperDiffCt} {
+			if ct != nil {
+				s.cmp.eval.MulRelin(ct, step, ct)
+				if err = s.cmp.eval.Rescale(ct, a.Scale, ct); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	lower = s.add(s.add(s.mulMask(a, lowerMin, logSlots), s.mulMask(b, lowerMax, logSlots)), lowerDiffCt)
+	upper = s.add(s.add(s.mulMask(a, upperMin, logSlots), s.mulMask(b, upperMax, logSlots)), upperDiffCt)
+
+	return
+}
+
+// mulMask multiplies ct by the mask, encoded at the scale of the modulus of the level of ct, and rescales the result
+// to the scale of ct. It returns nil if the mask is zero.
+func (s *Sorter) mulMask(ct *Ciphertext, mask []float64, logSlots int) (ctOut *Ciphertext) {
+
+	zero := true
+	for _, m := range mask {
+		zero = zero && m == 0
+	}
+
+	if zero {
+		return nil
+	}
+
+	level := ct.Level()
+
+	ctOut = s.cmp.eval.MulNew(ct, s.encoder.EncodeNew(mask, level, s.params.QiFloat64(level), logSlots))
+
+	if err := s.cmp.eval.Rescale(ctOut, ct.Scale, ctOut); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// add returns ct0 + ct1, where a nil ciphertext is zero.
+func (s *Sorter) add(ct0, ct1 *Ciphertext) *Ciphertext {
+	switch {
+	case ct0 == nil:
+		return ct1
+	case ct1 == nil:
+		return ct0
+	default:
+		return s.cmp.eval.AddNew(ct0, ct1)
+	}
+}
+
+// zeroIfNil returns ct dropped to the given level, or a zero Ciphertext at the given level and scale if ct is nil.
+func (s *Sorter) zeroIfNil(ct *Ciphertext, level int, scale float64) *Ciphertext {
+
+	if ct == nil {
+		return NewCiphertext(s.params, 1, level, scale)
+	}
+
+	if ct.Level() > level {
+		s.cmp.eval.DropLevel(ct, ct.Level()-level)
+	}
+
+	return ct
+}
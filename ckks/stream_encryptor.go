@@ -0,0 +1,107 @@
+package ckks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// EncryptTo encodes and encrypts all the records of src and writes the ciphertexts on w, each preceded by the number of
+// records it contains and the length in bytes of its binary serialization (both big-endian uint64). It holds in memory
+// a single batch of records at a time, and returns the number of records written. It stops at the first error returned
+// by src or w and returns it.
+func (se *StreamEncoder) EncryptTo(w io.Writer, src RecordSource, encryptor Encryptor) (records int, err error) {
+
+	err = se.Encrypt(src, encryptor, func(ct *Ciphertext, n int) error {
+
+		data, err := ct.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("cannot EncryptTo: %w", err)
+		}
+
+		var header [16]byte
+		binary.BigEndian.PutUint64(header[:8], uint64(n))
+		binary.BigEndian.PutUint64(header[8:], uint64(len(data)))
+		if _, err = w.Write(header[:]); err != nil {
+			return fmt.Errorf("cannot EncryptTo: %w", err)
+		}
+		if _, err = w.Write(data); err != nil {
+			return fmt.Errorf("cannot EncryptTo: %w", err)
+		}
+
+		records += n
+		return nil
+	})
+
+	return
+}
+
+// DecryptFrom reads the ciphertexts written by EncryptTo on r, decrypts and decodes them, and writes their records on
+// w, each value as the 8 bytes of the IEEE 754 representation of its real part in little-endian order, as read by
+// NewReaderSource. The StreamEncoder must have the layout, the number of slots and the level of the StreamEncoder that
+// encrypted the records. It holds in memory a single ciphertext at a time, and returns the number of records written.
+func (se *StreamEncoder) DecryptFrom(w io.Writer, r io.Reader, decryptor Decryptor) (records int, err error) {
+
+	slots := 1 << se.logSlots
+	out := make([]byte, 8*se.layout.Fields*se.RecordsPerBatch())
+
+	// The ciphertexts written by EncryptTo are of degree 1 at the level of the StreamEncoder, hence no larger than a
+	// ciphertext of degree 1 whose polynomials are those of the plaintexts of the StreamEncoder
+	pt := se.encoder.EncodeNew(se.values, se.level, se.scale, se.logSlots)
+	maxSize := uint64(rlwe.FormatHeaderLen + (&Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: []*ring.Poly{pt.Value, pt.Value}}}).GetDataLen(true))
+
+	var data bytes.Buffer
+	ct := new(Ciphertext)
+
+	for {
+
+		var header [16]byte
+		if _, err = io.ReadFull(r, header[:]); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, fmt.Errorf("cannot DecryptFrom: %w", err)
+		}
+
+		n := binary.BigEndian.Uint64(header[:8])
+		if n == 0 || n > uint64(se.RecordsPerBatch()) {
+			return records, fmt.Errorf("cannot DecryptFrom: invalid number of records %d", n)
+		}
+
+		// The ciphertext is copied as it is read, so that an invalid length does not allocate more than the stream
+		size := binary.BigEndian.Uint64(header[8:])
+		if size > maxSize {
+			return records, fmt.Errorf("cannot DecryptFrom: invalid ciphertext size %d", size)
+		}
+		data.Reset()
+		if _, err = io.CopyN(&data, r, int64(size)); err == io.EOF {
+			return records, fmt.Errorf("cannot DecryptFrom: %w", io.ErrUnexpectedEOF)
+		} else if err != nil {
+			return records, fmt.Errorf("cannot DecryptFrom: %w", err)
+		}
+
+		if err = ct.UnmarshalBinary(data.Bytes()); err != nil {
+			return records, fmt.Errorf("cannot DecryptFrom: %w", err)
+		}
+
+		values := se.encoder.Decode(decryptor.DecryptNew(ct), se.logSlots)
+
+		ptr := 0
+		for rec := 0; rec < int(n); rec++ {
+			for f := 0; f < se.layout.Fields; f++ {
+				binary.LittleEndian.PutUint64(out[ptr:], math.Float64bits(real(values[se.layout.Slot(rec, f, slots)])))
+				ptr += 8
+			}
+		}
+
+		if _, err = w.Write(out[:ptr]); err != nil {
+			return records, fmt.Errorf("cannot DecryptFrom: %w", err)
+		}
+
+		records += int(n)
+	}
+}
@@ -166,13 +166,15 @@ func scaleUpVecExact(values []float64, n float64, moduli []uint64, coeffs [][]ui
 			}
 		} else {
 
+			// The explicit conversions prevent the fusion of the multiplication and the addition,
+			// which would round differently on the architectures with fused multiply-add instructions
 			if values[i] < 0 {
 				for j := range moduli {
-					coeffs[j][i] = moduli[j] - (uint64(-n*values[i]+0.5) % moduli[j])
+					coeffs[j][i] = moduli[j] - (uint64(float64(-n*values[i])+0.5) % moduli[j])
 				}
 			} else {
 				for j := range moduli {
-					coeffs[j][i] = uint64(n*values[i]+0.5) % moduli[j]
+					coeffs[j][i] = uint64(float64(n*values[i])+0.5) % moduli[j]
 				}
 			}
 		}
@@ -188,7 +190,7 @@ func scaleUpVecExact(values []float64, n float64, moduli []uint64, coeffs [][]ui
 	}
 }
 
-func scaleUpVecExactBigFloat(values []*big.Float, scale float64, moduli []uint64, coeffs [][]uint64) {
+func scaleUpVecExactBigFloat(values []*big.Float, scale *big.Float, moduli []uint64, coeffs [][]uint64) {
 
 	prec := int(values[0].Prec())
 
@@ -198,12 +200,11 @@ func scaleUpVecExactBigFloat(values []*big.Float, scale float64, moduli []uint64
 
 	zero := ring.NewFloat(0, prec)
 
-	scaleFlo := ring.NewFloat(scale, prec)
 	half := ring.NewFloat(0.5, prec)
 
 	for i := range values {
 
-		xFlo.Mul(scaleFlo, values[i])
+		xFlo.Mul(scale, values[i])
 
 		if values[i].Cmp(zero) < 0 {
 			xFlo.Sub(xFlo, half)
@@ -217,12 +218,9 @@ func scaleUpVecExactBigFloat(values []*big.Float, scale float64, moduli []uint64
 
 			Q := ring.NewUint(moduli[j])
 
+			// big.Int.Mod is the Euclidean modulus, hence is already in [0, Q)
 			tmp.Mod(xInt, Q)
 
-			if values[i].Cmp(zero) < 0 {
-				tmp.Add(tmp, Q)
-			}
-
 			coeffs[j][i] = tmp.Uint64()
 		}
 	}
@@ -0,0 +1,368 @@
+package ckks
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Vector is a vector of Len values longer than the slots of a ciphertext, encrypted in consecutive ciphertexts of
+// 2^LogSlots slots: the value of index i is stored in the slot i % 2^LogSlots of the ciphertext i / 2^LogSlots.
+// The slots after the Len values of the last ciphertext must be zero, which the VectorEvaluator preserves.
+type Vector struct {
+	Value    []*Ciphertext
+	Len      int
+	LogSlots int
+}
+
+// EncryptVectorNew encodes the values on plaintexts of 2^logSlots slots at the given level and scale and encrypts
+// them in a new Vector of len(values) values.
+func EncryptVectorNew(encoder Encoder, encryptor Encryptor, values []complex128, level int, scale float64, logSlots int) (v *Vector) {
+
+	slots := 1 << logSlots
+
+	v = &Vector{Value: make([]*Ciphertext, (len(values)+slots-1)/slots), Len: len(values), LogSlots: logSlots}
+
+	for i := range v.Value {
+		chunk := make([]complex128, slots)
+		copy(chunk, values[i*slots:utils.MinInt((i+1)*slots, len(values))])
+		v.Value[i] = encryptor.EncryptNew(encoder.EncodeNew(chunk, level, scale, logSlots))
+	}
+
+	return
+}
+
+// DecryptVector decrypts and decodes the Vector v and returns its Len values.
+func DecryptVector(encoder Encoder, decryptor Decryptor, v *Vector) (values []complex128) {
+
+	values = make([]complex128, 0, len(v.Value)<<v.LogSlots)
+
+	for _, ct := range v.Value {
+		values = append(values, encoder.Decode(decryptor.DecryptNew(ct), v.LogSlots)...)
+	}
+
+	return values[:v.Len]
+}
+
+// Level returns the minimum level of the ciphertexts of the Vector.
+func (v *Vector) Level() (level int) {
+	level = v.Value[0].Level()
+	for _, ct := range v.Value[1:] {
+		level = utils.MinInt(level, ct.Level())
+	}
+	return
+}
+
+// Scale returns the scale of the ciphertexts of the Vector.
+func (v *Vector) Scale() float64 {
+	return v.Value[0].Scale
+}
+
+// CopyNew creates a deep copy of the Vector.
+func (v *Vector) CopyNew() (vCopy *Vector) {
+	vCopy = &Vector{Value: make([]*Ciphertext, len(v.Value)), Len: v.Len, LogSlots: v.LogSlots}
+	for i := range v.Value {
+		vCopy.Value[i] = v.Value[i].CopyNew()
+	}
+	return
+}
+
+// RotationsForVectorRotate returns the rotations required by VectorEvaluator.Rotate to rotate a Vector of length
+// values in ciphertexts of 2^logSlots slots by each of the given rotations.
+func (p Parameters) RotationsForVectorRotate(length, logSlots int, rotations []int) []int {
+
+	rotIndex := make(map[int]bool)
+
+	for _, k := range rotations {
+		for _, terms := range vectorRotationTerms(length, logSlots, k) {
+			for term := range terms {
+				if term.rotation != 0 {
+					rotIndex[term.rotation] = true
+				}
+			}
+		}
+	}
+
+	rots := make([]int, 0, len(rotIndex))
+	for rot := range rotIndex {
+		rots = append(rots, rot)
+	}
+
+	sort.Ints(rots)
+
+	return rots
+}
+
+// RotationsForVectorInnerSum returns the rotations required by VectorEvaluator.InnerSum on a Vector in
+// ciphertexts of 2^logSlots slots.
+func (p Parameters) RotationsForVectorInnerSum(logSlots int) []int {
+	return p.RotationsForInnerSumLog(1, 1<<logSlots)
+}
+
+// VectorEvaluator evaluates element-wise operations, rotations and reductions on Vectors with an Evaluator.
+type VectorEvaluator struct {
+	params  Parameters
+	eval    Evaluator
+	encoder Encoder
+}
+
+// NewVectorEvaluator creates a VectorEvaluator evaluating the operations with eval. The encoder encodes the masks
+// of the rotations crossing the boundaries of the ciphertexts.
+func NewVectorEvaluator(params Parameters, eval Evaluator, encoder Encoder) *VectorEvaluator {
+	return &VectorEvaluator{params: params, eval: eval, encoder: encoder}
+}
+
+// newVectorLike allocates a Vector with the length and the number of ciphertexts of v, whose ciphertexts have the
+// given degree and the level and scale of the ciphertexts of v.
+func (ve *VectorEvaluator) newVectorLike(v *Vector, degree int) (vOut *Vector) {
+	vOut = &Vector{Value: make([]*Ciphertext, len(v.Value)), Len: v.Len, LogSlots: v.LogSlots}
+	for i, ct := range v.Value {
+		vOut.Value[i] = NewCiphertext(ve.params, degree, ct.Level(), ct.Scale)
+	}
+	return
+}
+
+// checkVectors panics if the Vectors do not have the same length and packing.
+func checkVectors(method string, vs ...*Vector) {
+	for _, v := range vs[1:] {
+		if v.Len != vs[0].Len || v.LogSlots != vs[0].LogSlots || len(v.Value) != len(vs[0].Value) {
+			panic(fmt.Sprintf("cannot %s: vectors of %d values in %d ciphertexts of 2^%d slots and of %d values in %d ciphertexts of 2^%d slots",
+				method, vs[0].Len, len(vs[0].Value), vs[0].LogSlots, v.Len, len(v.Value), v.LogSlots))
+		}
+	}
+}
+
+// Add adds v0 and v1 element-wise and returns the result in vOut.
+func (ve *VectorEvaluator) Add(v0, v1, vOut *Vector) {
+	checkVectors("Add", v0, v1, vOut)
+	for i := range v0.Value {
+		ve.eval.Add(v0.Value[i], v1.Value[i], vOut.Value[i])
+	}
+}
+
+// AddNew adds v0 and v1 element-wise and returns the result in a new Vector.
+func (ve *VectorEvaluator) AddNew(v0, v1 *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v0, utils.MaxInt(v0.Value[0].Degree(), v1.Value[0].Degree()))
+	ve.Add(v0, v1, vOut)
+	return
+}
+
+// Sub subtracts v1 from v0 element-wise and returns the result in vOut.
+func (ve *VectorEvaluator) Sub(v0, v1, vOut *Vector) {
+	checkVectors("Sub", v0, v1, vOut)
+	for i := range v0.Value {
+		ve.eval.Sub(v0.Value[i], v1.Value[i], vOut.Value[i])
+	}
+}
+
+// SubNew subtracts v1 from v0 element-wise and returns the result in a new Vector.
+func (ve *VectorEvaluator) SubNew(v0, v1 *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v0, utils.MaxInt(v0.Value[0].Degree(), v1.Value[0].Degree()))
+	ve.Sub(v0, v1, vOut)
+	return
+}
+
+// Neg negates v and returns the result in vOut.
+func (ve *VectorEvaluator) Neg(v, vOut *Vector) {
+	checkVectors("Neg", v, vOut)
+	for i := range v.Value {
+		ve.eval.Neg(v.Value[i], vOut.Value[i])
+	}
+}
+
+// NegNew negates v and returns the result in a new Vector.
+func (ve *VectorEvaluator) NegNew(v *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v, v.Value[0].Degree())
+	ve.Neg(v, vOut)
+	return
+}
+
+// MultByConst multiplies v by the constant, with the semantic of Evaluator.MultByConst, and returns the result in vOut.
+func (ve *VectorEvaluator) MultByConst(v *Vector, constant interface{}, vOut *Vector) {
+	checkVectors("MultByConst", v, vOut)
+	for i := range v.Value {
+		ve.eval.MultByConst(v.Value[i], constant, vOut.Value[i])
+	}
+}
+
+// MultByConstNew multiplies v by the constant and returns the result in a new Vector.
+func (ve *VectorEvaluator) MultByConstNew(v *Vector, constant interface{}) (vOut *Vector) {
+	vOut = ve.newVectorLike(v, v.Value[0].Degree())
+	ve.MultByConst(v, constant, vOut)
+	return
+}
+
+// MulRelin multiplies v0 and v1 element-wise, relinearizes the products and returns the result in vOut.
+// The evaluator must have a relinearization key.
+func (ve *VectorEvaluator) MulRelin(v0, v1, vOut *Vector) {
+	checkVectors("MulRelin", v0, v1, vOut)
+	for i := range v0.Value {
+		ve.eval.MulRelin(v0.Value[i], v1.Value[i], vOut.Value[i])
+	}
+}
+
+// MulRelinNew multiplies v0 and v1 element-wise, relinearizes the products and returns the result in a new Vector.
+func (ve *VectorEvaluator) MulRelinNew(v0, v1 *Vector) (vOut *Vector) {
+	vOut = ve.newVectorLike(v0, 1)
+	ve.MulRelin(v0, v1, vOut)
+	return
+}
+
+// Rescale rescales the ciphertexts of v, with the semantic of Evaluator.Rescale, and returns the result in vOut.
+func (ve *VectorEvaluator) Rescale(v *Vector, minScale float64, vOut *Vector) (err error) {
+	checkVectors("Rescale", v, vOut)
+	for i := range v.Value {
+		if err = ve.eval.Rescale(v.Value[i], minScale, vOut.Value[i]); err != nil {
+			return fmt.Errorf("cannot Rescale: ciphertext %d: %w", i, err)
+		}
+	}
+	return
+}
+
+// vectorRotationTerm is a rotation of an input ciphertext contributing to an output ciphertext of a rotated Vector.
+type vectorRotationTerm struct {
+	ciphertext int
+	rotation   int
+}
+
+// vectorRotationTerms returns, for each output ciphertext of the rotation by k of a Vector of length values in
+// ciphertexts of 2^logSlots slots, the rotations of the input ciphertexts it is the sum of, each with the mask
+// selecting the slots it contributes.
+func vectorRotationTerms(length, logSlots, k int) (terms []map[vectorRotationTerm][]complex128) {
+
+	slots := 1 << logSlots
+
+	k = ((k % length) + length) % length
+
+	terms = make([]map[vectorRotationTerm][]complex128, (length+slots-1)/slots)
+
+	for j := range terms {
+
+		terms[j] = make(map[vectorRotationTerm][]complex128)
+
+		for s := 0; s < slots && j*slots+s < length; s++ {
+
+			// The output value j*slots+s is the input value h, stored in the slot h%slots of the ciphertext h/slots
+			h := (j*slots + s + k) % length
+
+			term := vectorRotationTerm{ciphertext: h / slots, rotation: (h%slots - s + slots) % slots}
+
+			if _, ok := terms[j][term]; !ok {
+				terms[j][term] = make([]complex128, slots)
+			}
+
+			terms[j][term][s] = 1
+		}
+	}
+
+	return
+}
+
+// RotateNew rotates the Vector v to the left by k positions, i.e. its value of index i is the value of index
+// (i + k) mod Len of v, and returns the result in a new Vector.
+// The rotations crossing the boundaries of the ciphertexts are masked with plaintexts encoded at the scale of the
+// modulus of the level of v, so that the result is at the level v.Level()-1 with the scale of v. The result is at the
+// level of v if no mask is needed, i.e. if each output ciphertext is a rotation of a single input ciphertext, for
+// example if Len is a multiple of the slots and k is a multiple of the slots.
+// The evaluator must have the rotation keys for the rotations returned by Parameters.RotationsForVectorRotate.
+func (ve *VectorEvaluator) RotateNew(v *Vector, k int) (vOut *Vector) {
+
+	terms := vectorRotationTerms(v.Len, v.LogSlots, k)
+
+	// No mask is needed if each output ciphertext is a single rotation of all the slots of an input ciphertext
+	masked := false
+	for j := range terms {
+		masked = masked || len(terms[j]) > 1 || (j+1)<<v.LogSlots > v.Len
+	}
+
+	// The rotations of each input ciphertext are evaluated with hoisting
+	rotations := make([][]int, len(v.Value))
+	for j := range terms {
+		for term := range terms[j] {
+			rotations[term.ciphertext] = append(rotations[term.ciphertext], term.rotation)
+		}
+	}
+
+	rotated := make([]map[int]*Ciphertext, len(v.Value))
+	for i, rots := range rotations {
+		if len(rots) != 0 {
+			rotated[i] = ve.eval.RotateHoistedNew(v.Value[i], rots)
+		}
+	}
+
+	vOut = &Vector{Value: make([]*Ciphertext, len(terms)), Len: v.Len, LogSlots: v.LogSlots}
+
+	for j := range terms {
+
+		// The terms are summed in a deterministic order
+		sorted := make([]vectorRotationTerm, 0, len(terms[j]))
+		for term := range terms[j] {
+			sorted = append(sorted, term)
+		}
+
+		sort.Slice(sorted, func(a, b int) bool {
+			if sorted[a].ciphertext != sorted[b].ciphertext {
+				return sorted[a].ciphertext < sorted[b].ciphertext
+			}
+			return sorted[a].rotation < sorted[b].rotation
+		})
+
+		if !masked {
+			vOut.Value[j] = rotated[sorted[0].ciphertext][sorted[0].rotation].CopyNew()
+			continue
+		}
+
+		for _, term := range sorted {
+
+			ct := rotated[term.ciphertext][term.rotation]
+
+			level := ct.Level()
+
+			pt := ve.encoder.EncodeNew(terms[j][term], level, ve.params.QiFloat64(level), v.LogSlots)
+
+			if vOut.Value[j] == nil {
+				vOut.Value[j] = ve.eval.MulNew(ct, pt)
+			} else {
+				ve.eval.MulAndAdd(ct, pt, vOut.Value[j])
+			}
+		}
+
+		if err := ve.eval.Rescale(vOut.Value[j], v.Value[0].Scale, vOut.Value[j]); err != nil {
+			panic(err)
+		}
+	}
+
+	return
+}
+
+// Rotate rotates the Vector v to the left by k positions and returns the result in vOut, which can alias v.
+// See RotateNew.
+func (ve *VectorEvaluator) Rotate(v *Vector, k int, vOut *Vector) {
+
+	checkVectors("Rotate", v, vOut)
+
+	res := ve.RotateNew(v, k)
+
+	for i, ct := range res.Value {
+		level := utils.MinInt(ct.Level(), vOut.Value[i].Level())
+		vOut.Value[i].Value[0].Coeffs = vOut.Value[i].Value[0].Coeffs[:level+1]
+		vOut.Value[i].Value[1].Coeffs = vOut.Value[i].Value[1].Coeffs[:level+1]
+		vOut.Value[i].Copy(ct)
+	}
+}
+
+// InnerSumNew returns a new Ciphertext of 2^LogSlots slots, each of them storing the sum of the Len values of v.
+// The evaluator must have the rotation keys for the rotations returned by Parameters.RotationsForVectorInnerSum.
+func (ve *VectorEvaluator) InnerSumNew(v *Vector) (ctOut *Ciphertext) {
+
+	ctOut = v.Value[0].CopyNew()
+
+	for _, ct := range v.Value[1:] {
+		ve.eval.Add(ctOut, ct, ctOut)
+	}
+
+	ve.eval.InnerSumLog(ctOut, 1, 1<<v.LogSlots, ctOut)
+
+	return
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"golang.org/x/crypto/blake2b"
+)
+
+// The files written in the output directory by a ceremony.
+const (
+	SecretKeyFile       = "sk.bin"
+	PublicKeyFile       = "pk.bin"
+	RelinearizationFile = "rlk.bin"
+	RotationKeysFile    = "rtk.bin"
+	TranscriptFile      = "transcript.json"
+)
+
+// RunCeremony runs the ceremony of the configuration as the party of the given name: it generates the secret key share
+// of the party, runs the CKG protocol and, if enabled, the RKG and RTG protocols with the other parties, and writes the
+// secret key share, the collective keys and the transcript signed with key in the directory out. The key files are
+// only readable by their owner.
+func RunCeremony(ctx context.Context, cfg *Config, name string, key ed25519.PrivateKey, out string) error {
+
+	params, err := rlwe.NewParametersFromLiteral(cfg.Parameters)
+	if err != nil {
+		return fmt.Errorf("cannot RunCeremony: %w", err)
+	}
+
+	session, err := cfg.NewSession()
+	if err != nil {
+		return fmt.Errorf("cannot RunCeremony: %w", err)
+	}
+
+	self, err := cfg.Party(name)
+	if err != nil {
+		return fmt.Errorf("cannot RunCeremony: %w", err)
+	}
+	point := drlwe.ShamirPublicPoint(self.Point)
+
+	addrs := map[drlwe.ShamirPublicPoint]string{}
+	for _, p := range cfg.Parties {
+		addrs[drlwe.ShamirPublicPoint(p.Point)] = p.Address
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, duration(cfg.ConnectTimeout, time.Minute))
+	nw, err := newNetwork(connectCtx, point, addrs)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("cannot RunCeremony: %w", err)
+	}
+	defer nw.Close()
+
+	paramsDigest := drlwe.NewParametersDigest(params)
+	id := session.ID()
+	transcript := &Transcript{
+		Session:    hex.EncodeToString(id[:]),
+		Party:      name,
+		Point:      self.Point,
+		Parameters: hex.EncodeToString(paramsDigest[:]),
+		Outputs:    map[string]string{},
+	}
+	var mu sync.Mutex
+
+	run := func(domain string, protocol drlwe.RoundProtocol, inputs interface{}) (interface{}, error) {
+
+		o, err := drlwe.NewOrchestrator(protocol, point, session.Points())
+		if err != nil {
+			return nil, err
+		}
+		o.RoundTimeout = duration(cfg.RoundTimeout, 0)
+		o.MaxRetries = cfg.MaxRetries
+
+		transport, err := session.NewTransport(point, domain, nw.Transport(domain))
+		if err != nil {
+			return nil, err
+		}
+
+		output, err := o.Run(ctx, &recordingTransport{Transport: transport, self: point, domain: domain, transcript: transcript, mu: &mu}, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", domain, err)
+		}
+		return output, nil
+	}
+
+	files := map[string]encoding.BinaryMarshaler{}
+
+	sk := rlwe.NewKeyGenerator(params).GenSecretKey()
+	files[SecretKeyFile] = sk
+
+	ckg := drlwe.NewCKGProtocol(params)
+	pk, err := run("CKG", drlwe.CKGRounds{CKGProtocol: ckg}, drlwe.CKGInputs{SecretKey: sk, CRP: ckg.SampleCRP(session.CRS("CKG"))})
+	if err != nil {
+		return fmt.Errorf("cannot RunCeremony: %w", err)
+	}
+	files[PublicKeyFile] = pk.(*rlwe.PublicKey)
+
+	if cfg.Relinearization {
+		rkg := drlwe.NewRKGProtocol(params)
+		rlk, err := run("RKG", drlwe.NewRKGRounds(rkg), drlwe.RKGInputs{SecretKey: sk, CRP: rkg.SampleCRP(session.CRS("RKG"))})
+		if err != nil {
+			return fmt.Errorf("cannot RunCeremony: %w", err)
+		}
+		files[RelinearizationFile] = rlk.(*rlwe.RelinearizationKey)
+	}
+
+	if galEls := cfg.GaloisElements(params); len(galEls) != 0 {
+		rtg := drlwe.NewRTGProtocol(params)
+		rtks := rlwe.NewRotationKeySet(params, galEls)
+		for _, galEl := range galEls {
+			domain := fmt.Sprintf("RTG/%d", galEl)
+			swk, err := run(domain, drlwe.RTGRounds{RTGProtocol: rtg}, drlwe.RTGInputs{SecretKey: sk, GaloisElement: galEl, CRP: rtg.SampleCRP(session.CRS(domain))})
+			if err != nil {
+				return fmt.Errorf("cannot RunCeremony: %w", err)
+			}
+			rtks.Keys[galEl] = swk.(*rlwe.SwitchingKey)
+		}
+		files[RotationKeysFile] = rtks
+	}
+
+	for file, k := range files {
+
+		data, err := k.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("cannot RunCeremony: %s: %w", file, err)
+		}
+
+		if err = ioutil.WriteFile(filepath.Join(out, file), data, 0600); err != nil {
+			return fmt.Errorf("cannot RunCeremony: %w", err)
+		}
+
+		// The secret key share is private to the party and is not recorded in the transcript, and the rotation keys
+		// are recorded separately, as their marshaling depends on the order of the iteration over the set
+		if file != SecretKeyFile && file != RotationKeysFile {
+			digest := blake2b.Sum256(data)
+			transcript.Outputs[file] = hex.EncodeToString(digest[:])
+		}
+	}
+
+	if rtks, ok := files[RotationKeysFile].(*rlwe.RotationKeySet); ok {
+		for galEl, swk := range rtks.Keys {
+			data, err := swk.MarshalBinary()
+			if err != nil {
+				return fmt.Errorf("cannot RunCeremony: %s: %w", RotationKeysFile, err)
+			}
+			digest := blake2b.Sum256(data)
+			transcript.Outputs[fmt.Sprintf("%s/%d", RotationKeysFile, galEl)] = hex.EncodeToString(digest[:])
+		}
+	}
+
+	signed, err := transcript.Sign(key)
+	if err != nil {
+		return fmt.Errorf("cannot RunCeremony: %w", err)
+	}
+
+	data, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot RunCeremony: %w", err)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(out, TranscriptFile), data, 0644); err != nil {
+		return fmt.Errorf("cannot RunCeremony: %w", err)
+	}
+
+	return nil
+}
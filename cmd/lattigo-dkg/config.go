@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/dsession"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Config is the configuration of a ceremony, shared by all the parties.
+type Config struct {
+	// Parameters are the RLWE parameters of the keys.
+	Parameters rlwe.ParametersLiteral `json:"parameters"`
+	// Session is the nonce of the ceremony, which must be unique for each ceremony of the same parties.
+	Session string `json:"session"`
+	// Parties are the parties of the ceremony.
+	Parties []PartyConfig `json:"parties"`
+	// Relinearization enables the generation of the relinearization key.
+	Relinearization bool `json:"relinearization"`
+	// Rotations are the column rotations for which a rotation key is generated.
+	Rotations []int `json:"rotations"`
+	// RowRotation enables the generation of the row rotation (conjugation) key.
+	RowRotation bool `json:"row_rotation"`
+	// ConnectTimeout bounds the time to connect to the other parties, e.g. "1m".
+	ConnectTimeout string `json:"connect_timeout"`
+	// RoundTimeout bounds each attempt of the exchange of the shares of a round, e.g. "30s".
+	RoundTimeout string `json:"round_timeout"`
+	// MaxRetries is the maximum number of retries of the exchange of a round.
+	MaxRetries int `json:"max_retries"`
+}
+
+// PartyConfig is the configuration of a party of the ceremony.
+type PartyConfig struct {
+	// Name is the name of the party, given to the -party flag.
+	Name string `json:"name"`
+	// Point is the public point of the party in the protocols.
+	Point uint64 `json:"point"`
+	// Address is the TCP address on which the party listens, e.g. "10.0.0.1:7000".
+	Address string `json:"address"`
+	// PublicKey is the hexadecimal ed25519 public key of the party, which signs its transcript.
+	PublicKey string `json:"public_key"`
+}
+
+// LoadConfig reads and validates the configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot LoadConfig: %w", err)
+	}
+
+	cfg := new(Config)
+	if err = json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("cannot LoadConfig: %w", err)
+	}
+
+	if err = cfg.validate(); err != nil {
+		return nil, fmt.Errorf("cannot LoadConfig: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// validate checks that the configuration is complete and consistent.
+func (cfg *Config) validate() error {
+
+	if _, err := rlwe.NewParametersFromLiteral(cfg.Parameters); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if _, err := cfg.NewSession(); err != nil {
+		return err
+	}
+
+	if len(cfg.Rotations) != 0 || cfg.RowRotation || cfg.Relinearization {
+		if len(cfg.Parameters.P) == 0 && len(cfg.Parameters.LogP) == 0 {
+			return errors.New("the evaluation keys require the parameters to have a P modulus")
+		}
+	}
+
+	for _, p := range cfg.Parties {
+
+		if p.Address == "" {
+			return fmt.Errorf("the party %q has no address", p.Name)
+		}
+
+		if p.PublicKey != "" {
+			if _, err := p.Key(); err != nil {
+				return fmt.Errorf("the party %q: %w", p.Name, err)
+			}
+		}
+	}
+
+	for _, field := range []struct{ name, value string }{{"connect_timeout", cfg.ConnectTimeout}, {"round_timeout", cfg.RoundTimeout}} {
+		if field.value != "" {
+			if _, err := time.ParseDuration(field.value); err != nil {
+				return fmt.Errorf("invalid %s: %w", field.name, err)
+			}
+		}
+	}
+
+	if cfg.MaxRetries < 0 {
+		return errors.New("max_retries is negative")
+	}
+
+	return nil
+}
+
+// NewSession returns the dsession.Session of the ceremony.
+func (cfg *Config) NewSession() (*dsession.Session, error) {
+	parties := make([]dsession.Party, len(cfg.Parties))
+	for i, p := range cfg.Parties {
+		parties[i] = dsession.Party{Name: p.Name, Point: drlwe.ShamirPublicPoint(p.Point)}
+	}
+	return dsession.NewSession([]byte(cfg.Session), parties)
+}
+
+// Party returns the configuration of the party of the given name.
+func (cfg *Config) Party(name string) (PartyConfig, error) {
+	for _, p := range cfg.Parties {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return PartyConfig{}, fmt.Errorf("the party %q is not in the configuration", name)
+}
+
+// GaloisElements returns the Galois elements of the rotation keys of the ceremony.
+func (cfg *Config) GaloisElements(params rlwe.Parameters) (galEls []uint64) {
+
+	seen := map[uint64]bool{}
+	add := func(galEl uint64) {
+		if !seen[galEl] {
+			seen[galEl] = true
+			galEls = append(galEls, galEl)
+		}
+	}
+
+	for _, k := range cfg.Rotations {
+		add(params.GaloisElementForColumnRotationBy(k))
+	}
+
+	if cfg.RowRotation {
+		add(params.GaloisElementForRowRotation())
+	}
+
+	return
+}
+
+// duration returns the parsed duration value, or def if value is empty.
+func duration(value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, _ := time.ParseDuration(value)
+	return d
+}
+
+// Key returns the ed25519 public key of the party.
+func (p PartyConfig) Key() (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(p.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
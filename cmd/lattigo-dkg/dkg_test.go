@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCeremony(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "lattigo-dkg")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	names := []string{"alice", "bob", "charlie"}
+
+	cfg := &Config{
+		Parameters:      rlwe.TestPN12QP109,
+		Session:         "test",
+		Relinearization: true,
+		Rotations:       []int{1, 1, 2},
+		RowRotation:     true,
+		ConnectTimeout:  "10s",
+		RoundTimeout:    "30s",
+	}
+
+	keys := map[string]ed25519.PrivateKey{}
+	for i, name := range names {
+
+		// Reserves a free port on the loopback interface
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		l.Close()
+
+		pub, err := GenSigningKey(filepath.Join(dir, name+".key"))
+		require.NoError(t, err)
+		keys[name], err = LoadSigningKey(filepath.Join(dir, name+".key"))
+		require.NoError(t, err)
+
+		cfg.Parties = append(cfg.Parties, PartyConfig{Name: name, Point: uint64(i + 1), Address: addr, PublicKey: hex.EncodeToString(pub)})
+	}
+
+	require.NoError(t, cfg.validate())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0700))
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = RunCeremony(ctx, cfg, name, keys[name], filepath.Join(dir, name))
+		}(i, name)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	params, err := rlwe.NewParametersFromLiteral(cfg.Parameters)
+	require.NoError(t, err)
+
+	// The parties produced the same collective keys, from the same shares
+	var reference *SignedTranscript
+	for _, name := range names {
+
+		path := filepath.Join(dir, name, TranscriptFile)
+		require.NoError(t, VerifyTranscript(cfg, name, path))
+
+		st, err := LoadTranscript(path)
+		require.NoError(t, err)
+		require.Len(t, st.Transcript.Outputs, 2+len(cfg.GaloisElements(params)))
+		require.Len(t, st.Transcript.Shares, len(names)*(1+2+len(cfg.GaloisElements(params))))
+
+		if reference == nil {
+			reference = st
+		} else {
+			require.Equal(t, reference.Transcript.Outputs, st.Transcript.Outputs)
+			require.Equal(t, reference.Transcript.Shares, st.Transcript.Shares)
+		}
+
+		// The transcript of a party does not verify as the transcript of another party
+		require.Error(t, VerifyTranscript(cfg, names[(len(name))%len(names)], path))
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, names[0], PublicKeyFile))
+	require.NoError(t, err)
+	pk := new(rlwe.PublicKey)
+	require.NoError(t, pk.UnmarshalBinary(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, names[0], RotationKeysFile))
+	require.NoError(t, err)
+	rtks := new(rlwe.RotationKeySet)
+	require.NoError(t, rtks.UnmarshalBinary(data))
+	require.Len(t, rtks.Keys, 3)
+
+	// A tampered transcript does not verify
+	reference.Transcript.Outputs[PublicKeyFile] = fmt.Sprintf("%x", make([]byte, 32))
+	key, err := cfg.Parties[0].Key()
+	require.NoError(t, err)
+	require.Error(t, reference.Verify(key))
+}
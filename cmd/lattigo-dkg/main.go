@@ -0,0 +1,148 @@
+// Command lattigo-dkg runs the distributed generation of the collective keys of the multiparty schemes among parties
+// connected over TCP: each party runs the command with the same configuration file, which lists the parameters, the
+// session nonce, the parties with their address and ed25519 public key, and the evaluation keys to generate. The
+// parties run the CKG protocol and, if enabled, the RKG and RTG protocols, and each party writes in its output
+// directory its secret key share (sk.bin), the collective public key (pk.bin), relinearization key (rlk.bin) and
+// rotation keys (rtk.bin), and its transcript of the ceremony signed with its ed25519 key (transcript.json). The keys
+// are marshaled with the MarshalBinary method of the rlwe package.
+//
+// The connections between the parties are neither authenticated nor encrypted and must be protected, e.g. by a VPN or
+// a TLS tunnel. The parties should exchange and compare their signed transcripts after the ceremony, which record the
+// digests of the shares and of the collective keys.
+//
+// Usage:
+//
+//	lattigo-dkg -genkey alice.key
+//	lattigo-dkg -config ceremony.json -party alice -key alice.key -out keys/
+//	lattigo-dkg -config ceremony.json -party alice -verify keys/transcript.json
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+func check(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+
+	config := flag.String("config", "", "path of the configuration file of the ceremony")
+	party := flag.String("party", "", "name of the party in the configuration")
+	keyFile := flag.String("key", "", "path of the ed25519 signing key of the party")
+	out := flag.String("out", ".", "output directory of the keys and of the transcript")
+	genkey := flag.String("genkey", "", "generate an ed25519 signing key at the given path and print its public key")
+	verify := flag.String("verify", "", "verify the signed transcript of the party at the given path")
+	flag.Parse()
+
+	if *genkey != "" {
+		pub, err := GenSigningKey(*genkey)
+		check(err)
+		fmt.Println(hex.EncodeToString(pub))
+		return
+	}
+
+	if *config == "" || *party == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := LoadConfig(*config)
+	check(err)
+
+	if *verify != "" {
+		check(VerifyTranscript(cfg, *party, *verify))
+		fmt.Println("transcript OK")
+		return
+	}
+
+	key, err := LoadSigningKey(*keyFile)
+	check(err)
+
+	check(os.MkdirAll(*out, 0700))
+	check(RunCeremony(context.Background(), cfg, *party, key, *out))
+
+	fmt.Printf("ceremony completed, keys written in %s\n", *out)
+}
+
+// GenSigningKey generates a new ed25519 signing key, writes its hexadecimal seed at path and returns its public key.
+func GenSigningKey(path string) (ed25519.PublicKey, error) {
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenSigningKey: %w", err)
+	}
+
+	if err = ioutil.WriteFile(path, []byte(hex.EncodeToString(priv.Seed())+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("cannot GenSigningKey: %w", err)
+	}
+
+	return pub, nil
+}
+
+// LoadSigningKey reads the ed25519 signing key written by GenSigningKey at path.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot LoadSigningKey: %w", err)
+	}
+
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("cannot LoadSigningKey: invalid ed25519 seed")
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// VerifyTranscript checks that the signed transcript at path is the transcript of the party of the given name for the
+// session of the configuration, signed with the public key of the party in the configuration.
+func VerifyTranscript(cfg *Config, name, path string) error {
+
+	p, err := cfg.Party(name)
+	if err != nil {
+		return fmt.Errorf("cannot VerifyTranscript: %w", err)
+	}
+
+	key, err := p.Key()
+	if err != nil {
+		return fmt.Errorf("cannot VerifyTranscript: the party %q: %w", name, err)
+	}
+
+	st, err := LoadTranscript(path)
+	if err != nil {
+		return fmt.Errorf("cannot VerifyTranscript: %w", err)
+	}
+
+	session, err := cfg.NewSession()
+	if err != nil {
+		return fmt.Errorf("cannot VerifyTranscript: %w", err)
+	}
+
+	if id := session.ID(); st.Transcript.Session != hex.EncodeToString(id[:]) {
+		return errors.New("cannot VerifyTranscript: the transcript is for another session")
+	}
+
+	if st.Transcript.Party != name || st.Transcript.Point != p.Point {
+		return fmt.Errorf("cannot VerifyTranscript: the transcript is not the transcript of the party %q", name)
+	}
+
+	if err = st.Verify(key); err != nil {
+		return fmt.Errorf("cannot VerifyTranscript: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+)
+
+// maxFrameLen bounds the length of the frames read from the connections.
+const maxFrameLen = 1 << 31
+
+// network is the full mesh of TCP connections of a party with the other parties of a ceremony. Each party listens on
+// its address and dials every other party: the connection dialed by a party carries the frames it sends, and starts
+// with its public point. A frame is a share for a round of a protocol domain, and the received frames are queued by
+// domain and round, so that the shares sent by the parties that run ahead in the ceremony are not lost. The connections
+// are neither authenticated nor encrypted, hence they must be protected, e.g. by a VPN or a TLS tunnel; the shares are
+// tagged with the session and their sender by the dsession.Transport.
+type network struct {
+	self     drlwe.ShamirPublicPoint
+	size     int
+	listener net.Listener
+
+	peers map[drlwe.ShamirPublicPoint]*peer
+
+	mu       sync.Mutex
+	queues   map[queueKey]chan received
+	incoming []net.Conn
+	closed   bool
+}
+
+// peer is the connection on which a party sends its frames to another party.
+type peer struct {
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+type queueKey struct {
+	domain string
+	round  int
+}
+
+type received struct {
+	party drlwe.ShamirPublicPoint
+	data  []byte
+}
+
+// newNetwork listens on the address of the party self and connects to the other parties of addresses addrs, retrying
+// until ctx is done.
+func newNetwork(ctx context.Context, self drlwe.ShamirPublicPoint, addrs map[drlwe.ShamirPublicPoint]string) (n *network, err error) {
+
+	n = &network{self: self, size: len(addrs), peers: map[drlwe.ShamirPublicPoint]*peer{}, queues: map[queueKey]chan received{}}
+
+	if n.listener, err = net.Listen("tcp", addrs[self]); err != nil {
+		return nil, fmt.Errorf("cannot listen: %w", err)
+	}
+
+	go n.accept(addrs)
+
+	for p, addr := range addrs {
+		if p == self {
+			continue
+		}
+
+		var conn net.Conn
+		if conn, err = dial(ctx, addr); err != nil {
+			n.Close()
+			return nil, fmt.Errorf("cannot connect to the party %d: %w", p, err)
+		}
+
+		var hello [8]byte
+		binary.LittleEndian.PutUint64(hello[:], uint64(self))
+		if _, err = conn.Write(hello[:]); err != nil {
+			conn.Close()
+			n.Close()
+			return nil, fmt.Errorf("cannot connect to the party %d: %w", p, err)
+		}
+
+		n.peers[p] = &peer{conn: conn, w: bufio.NewWriter(conn)}
+	}
+
+	return n, nil
+}
+
+// dial connects to addr, retrying every 100ms until ctx is done.
+func dial(ctx context.Context, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// accept accepts the connections of the other parties until the listener is closed.
+func (n *network) accept(addrs map[drlwe.ShamirPublicPoint]string) {
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		n.mu.Lock()
+		if n.closed {
+			n.mu.Unlock()
+			conn.Close()
+			return
+		}
+		n.incoming = append(n.incoming, conn)
+		n.mu.Unlock()
+
+		go n.read(conn, addrs)
+	}
+}
+
+// read queues the frames received on the connection until it is closed. The connections of unknown parties are
+// closed.
+func (n *network) read(conn net.Conn, addrs map[drlwe.ShamirPublicPoint]string) {
+
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	var hello [8]byte
+	if _, err := io.ReadFull(r, hello[:]); err != nil {
+		return
+	}
+
+	party := drlwe.ShamirPublicPoint(binary.LittleEndian.Uint64(hello[:]))
+	if _, ok := addrs[party]; !ok || party == n.self {
+		return
+	}
+
+	for {
+		domain, round, data, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		n.queue(domain, round) <- received{party: party, data: data}
+	}
+}
+
+// queue returns the queue of the frames received for the round of the protocol domain.
+func (n *network) queue(domain string, round int) chan received {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	key := queueKey{domain, round}
+	if _, ok := n.queues[key]; !ok {
+		// Each party sends its share at most once per attempt of the exchange of the round
+		n.queues[key] = make(chan received, 16*n.size)
+	}
+	return n.queues[key]
+}
+
+// Transport returns the drlwe.Transport of the protocol domain.
+func (n *network) Transport(domain string) drlwe.Transport {
+	return &domainTransport{network: n, domain: domain}
+}
+
+// Close closes the listener and the connections.
+func (n *network) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closed = true
+	n.listener.Close()
+	for _, conn := range n.incoming {
+		conn.Close()
+	}
+	for _, p := range n.peers {
+		p.conn.Close()
+	}
+}
+
+// domainTransport is the drlwe.Transport of a protocol domain over a network.
+type domainTransport struct {
+	*network
+	domain string
+}
+
+// Broadcast sends the share of the round to all the other parties. The parties whose connection fails are skipped, so
+// that their shares are reported as missing by the drlwe.Orchestrator; it returns an error only if ctx is done.
+func (t *domainTransport) Broadcast(ctx context.Context, round int, share []byte) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range t.peers {
+		p.mu.Lock()
+		if deadline, ok := ctx.Deadline(); ok {
+			p.conn.SetWriteDeadline(deadline)
+		}
+		if err := writeFrame(p.w, t.domain, round, share); err == nil {
+			p.w.Flush()
+		}
+		p.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Receive returns the next share received for the round.
+func (t *domainTransport) Receive(ctx context.Context, round int) (party drlwe.ShamirPublicPoint, share []byte, err error) {
+	select {
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case msg := <-t.queue(t.domain, round):
+		return msg.party, msg.data, nil
+	}
+}
+
+// writeFrame writes the frame [len(domain) | domain | round | len(data) | data].
+func writeFrame(w io.Writer, domain string, round int, data []byte) (err error) {
+
+	header := make([]byte, 4+len(domain)+12)
+	binary.LittleEndian.PutUint32(header, uint32(len(domain)))
+	copy(header[4:], domain)
+	binary.LittleEndian.PutUint32(header[4+len(domain):], uint32(round))
+	binary.LittleEndian.PutUint64(header[8+len(domain):], uint64(len(data)))
+
+	if _, err = w.Write(header); err != nil {
+		return
+	}
+	_, err = w.Write(data)
+	return
+}
+
+// readFrame reads a frame written by writeFrame.
+func readFrame(r io.Reader) (domain string, round int, data []byte, err error) {
+
+	var buf [8]byte
+	if _, err = io.ReadFull(r, buf[:4]); err != nil {
+		return
+	}
+
+	domainData := make([]byte, binary.LittleEndian.Uint32(buf[:4]))
+	if len(domainData) > 1<<10 {
+		return "", 0, nil, errors.New("invalid frame: domain is too long")
+	}
+	if _, err = io.ReadFull(r, domainData); err != nil {
+		return
+	}
+
+	if _, err = io.ReadFull(r, buf[:4]); err != nil {
+		return
+	}
+	round = int(binary.LittleEndian.Uint32(buf[:4]))
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		return
+	}
+
+	size := binary.LittleEndian.Uint64(buf[:])
+	if size > maxFrameLen {
+		return "", 0, nil, errors.New("invalid frame: share is too long")
+	}
+
+	data = make([]byte, size)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return
+	}
+
+	return string(domainData), round, data, nil
+}
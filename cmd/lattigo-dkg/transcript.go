@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Transcript is the record of a ceremony by a party: the digests of the shares it sent and received in each round of
+// each protocol, and the digests of the key files it produced. The parties can compare their signed transcripts to
+// check that they received the same shares and produced the same collective keys.
+type Transcript struct {
+	Session    string            `json:"session"`
+	Party      string            `json:"party"`
+	Point      uint64            `json:"point"`
+	Parameters string            `json:"parameters"`
+	Shares     []TranscriptEntry `json:"shares"`
+	Outputs    map[string]string `json:"outputs"`
+}
+
+// TranscriptEntry is the digest of the share sent by a party in a round of a protocol domain.
+type TranscriptEntry struct {
+	Domain string `json:"domain"`
+	Round  int    `json:"round"`
+	Party  uint64 `json:"party"`
+	Digest string `json:"digest"`
+}
+
+// SignedTranscript is a Transcript signed by the party with its ed25519 key.
+type SignedTranscript struct {
+	Transcript Transcript `json:"transcript"`
+	PublicKey  string     `json:"public_key"`
+	Signature  string     `json:"signature"`
+}
+
+// record adds the digest of the share of the party for the round of the protocol domain, unless it was already
+// recorded, e.g. when the share is received again after a retry.
+func (tr *Transcript) record(mu *sync.Mutex, domain string, round int, party drlwe.ShamirPublicPoint, share []byte) {
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, e := range tr.Shares {
+		if e.Domain == domain && e.Round == round && e.Party == uint64(party) {
+			return
+		}
+	}
+
+	digest := blake2b.Sum256(share)
+	tr.Shares = append(tr.Shares, TranscriptEntry{Domain: domain, Round: round, Party: uint64(party), Digest: hex.EncodeToString(digest[:])})
+}
+
+// Sign returns the transcript signed with the private key, with its entries sorted by domain, round and party.
+func (tr *Transcript) Sign(key ed25519.PrivateKey) (*SignedTranscript, error) {
+
+	sort.Slice(tr.Shares, func(i, j int) bool {
+		a, b := tr.Shares[i], tr.Shares[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		return a.Party < b.Party
+	})
+
+	data, err := json.Marshal(tr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Sign: %w", err)
+	}
+
+	return &SignedTranscript{
+		Transcript: *tr,
+		PublicKey:  hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature:  hex.EncodeToString(ed25519.Sign(key, data)),
+	}, nil
+}
+
+// Verify checks the signature of the transcript with the public key of the party that signed it.
+func (st *SignedTranscript) Verify(key ed25519.PublicKey) error {
+
+	if hex.EncodeToString(key) != st.PublicKey {
+		return errors.New("cannot Verify: the transcript is signed with another key")
+	}
+
+	data, err := json.Marshal(&st.Transcript)
+	if err != nil {
+		return fmt.Errorf("cannot Verify: %w", err)
+	}
+
+	signature, err := hex.DecodeString(st.Signature)
+	if err != nil || !ed25519.Verify(key, data, signature) {
+		return errors.New("cannot Verify: invalid signature")
+	}
+
+	return nil
+}
+
+// LoadTranscript reads the signed transcript at path.
+func LoadTranscript(path string) (*SignedTranscript, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot LoadTranscript: %w", err)
+	}
+
+	st := new(SignedTranscript)
+	if err = json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("cannot LoadTranscript: %w", err)
+	}
+
+	return st, nil
+}
+
+// recordingTransport is a drlwe.Transport that records the shares sent and received in a protocol domain in a
+// Transcript.
+type recordingTransport struct {
+	drlwe.Transport
+	self       drlwe.ShamirPublicPoint
+	domain     string
+	transcript *Transcript
+	mu         *sync.Mutex
+}
+
+// Broadcast records the share of the party and broadcasts it.
+func (t *recordingTransport) Broadcast(ctx context.Context, round int, share []byte) error {
+	t.transcript.record(t.mu, t.domain, round, t.self, share)
+	return t.Transport.Broadcast(ctx, round, share)
+}
+
+// Receive returns the next share received for the round and records it.
+func (t *recordingTransport) Receive(ctx context.Context, round int) (party drlwe.ShamirPublicPoint, share []byte, err error) {
+	if party, share, err = t.Transport.Receive(ctx, round); err == nil {
+		t.transcript.record(t.mu, t.domain, round, party, share)
+	}
+	return
+}
@@ -0,0 +1,49 @@
+// Command lattigo-heserver runs the reference server of the homomorphic evaluation service of the services/heservice
+// package, which keeps its sessions in memory and evaluates the default circuits of the package. The parties are not
+// authenticated by the service, hence the server should be run with TLS behind an authenticating proxy, which
+// checks that the Lattigo-Party header of the decryption shares is the identifier of the authenticated party.
+//
+// Usage:
+//
+//	lattigo-heserver -addr :8443 -tls-cert server.crt -tls-key server.key
+//
+// With the -metrics flag, the server also serves the metrics of its evaluations and collective decryptions in the
+// Prometheus text exposition format on the /metrics path.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/ldsec/lattigo/v2/services/heservice"
+	"github.com/ldsec/lattigo/v2/services/metrics"
+)
+
+func main() {
+
+	addr := flag.String("addr", ":8080", "listening address of the server")
+	maxSize := flag.Int64("max-object-size", heservice.DefaultMaxObjectSize, "maximum size in bytes of an uploaded object")
+	tlsCert := flag.String("tls-cert", "", "path of the TLS certificate of the server")
+	tlsKey := flag.String("tls-key", "", "path of the TLS private key of the server")
+	withMetrics := flag.Bool("metrics", false, "serve the metrics of the server on /metrics")
+	flag.Parse()
+
+	server := heservice.NewServer()
+	server.MaxObjectSize = *maxSize
+
+	mux := http.NewServeMux()
+	mux.Handle(heservice.ServicePath, server)
+
+	if *withMetrics {
+		registry := metrics.NewRegistry()
+		server.Metrics = registry
+		mux.Handle("/metrics", registry)
+	}
+
+	log.Printf("serving the homomorphic evaluation service on %s", *addr)
+	if *tlsCert != "" || *tlsKey != "" {
+		log.Fatal(http.ListenAndServeTLS(*addr, *tlsCert, *tlsKey, mux))
+	}
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
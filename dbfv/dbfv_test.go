@@ -89,11 +89,16 @@ func Test_DBFV(t *testing.T) {
 			testRelinKeyGen,
 			testKeyswitching,
 			testPublicKeySwitching,
+			testKeySwitchingProduct,
+			testInteractiveRelin,
 			testRotKeyGenRotRows,
 			testRotKeyGenRotCols,
 			testEncToShares,
 			testRefresh,
+			testNoiseReduction,
 			testRefreshAndPermutation,
+			testRefreshAndLinearTransform,
+			testMigration,
 			testMarshalling,
 		} {
 			testSet(tc, t)
@@ -363,6 +368,167 @@ func testPublicKeySwitching(testCtx *testContext, t *testing.T) {
 
 		verifyTestVectors(testCtx, decryptorSk1, coeffs, ciphertextSwitched, t)
 	})
+
+	t.Run(testString("PublicKeySwitching/MultiRecipient", parties, testCtx.params), func(t *testing.T) {
+
+		mpcks := make([]*MultiPCKSProtocol, parties)
+		shares := make([]*drlwe.MultiPCKSShare, parties)
+		for i := range mpcks {
+			mpcks[i] = NewMultiPCKSProtocol(testCtx.params, 6.36, []*rlwe.PublicKey{pk1, testCtx.pk0})
+			shares[i] = mpcks[i].AllocateShare()
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+		for i := range mpcks {
+			mpcks[i].GenShare(sk0Shards[i], ciphertext.Value[1], shares[i])
+			if i > 0 {
+				mpcks[0].AggregateShare(shares[i], shares[0], shares[0])
+			}
+		}
+
+		ciphertextsSwitched := []*bfv.Ciphertext{bfv.NewCiphertext(testCtx.params, 1), bfv.NewCiphertext(testCtx.params, 1)}
+		mpcks[0].KeySwitch(ciphertext, shares[0], ciphertextsSwitched)
+
+		verifyTestVectors(testCtx, decryptorSk1, coeffs, ciphertextsSwitched[0], t)
+		verifyTestVectors(testCtx, testCtx.decryptorSk0, coeffs, ciphertextsSwitched[1], t)
+	})
+}
+
+func testKeySwitchingProduct(testCtx *testContext, t *testing.T) {
+
+	sk0Shards := testCtx.sk0Shards
+	sk1Shards := testCtx.sk1Shards
+	pk1 := testCtx.pk1
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk1 := testCtx.decryptorSk1
+
+	t.Run(testString("KeySwitchingProduct", parties, testCtx.params), func(t *testing.T) {
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+		for i := range coeffs {
+			coeffs[i] *= coeffs[i]
+			coeffs[i] %= testCtx.ringT.Modulus[0]
+		}
+
+		// The product is not relinearized
+		ciphertextMul := bfv.NewCiphertext(testCtx.params, 2)
+		testCtx.evaluator.Mul(ciphertext, ciphertext, ciphertextMul)
+
+		cks := make([]*CKSProtocol, parties)
+		pcks := make([]*PCKSProtocol, parties)
+		for i := range cks {
+			cks[i] = NewCKSProtocol(testCtx.params, 6.36)
+			pcks[i] = NewPCKSProtocol(testCtx.params, 6.36)
+		}
+
+		// Reduces the degree of the product to 1
+		reductionShares := make([]*drlwe.DegreeReductionShare, parties)
+		for i := range cks {
+			reductionShares[i] = cks[i].AllocateDegreeReductionShare()
+			cks[i].GenDegreeReductionShare(sk0Shards[i], ciphertextMul.Value[2], reductionShares[i])
+			if i > 0 {
+				cks[0].AggregateDegreeReductionShare(reductionShares[0], reductionShares[i], reductionShares[0])
+			}
+		}
+
+		ciphertextReduced := bfv.NewCiphertext(testCtx.params, 1)
+		cks[0].ReduceDegree(ciphertextMul, reductionShares[0], ciphertextReduced)
+		require.Equal(t, 1, ciphertextReduced.Degree())
+
+		// The degree can also be reduced in place
+		cks[0].ReduceDegree(ciphertextMul, reductionShares[0], ciphertextMul)
+		require.Equal(t, 1, ciphertextMul.Degree())
+		require.True(t, testCtx.ringQ.Equal(ciphertextReduced.Value[1], ciphertextMul.Value[1]))
+
+		t.Run("CKS", func(t *testing.T) {
+			shares := make([]*drlwe.CKSShare, parties)
+			for i := range cks {
+				shares[i] = cks[i].AllocateShare()
+				cks[i].GenShare(sk0Shards[i], sk1Shards[i], ciphertextReduced.Value[1], shares[i])
+				if i > 0 {
+					cks[0].AggregateShare(shares[0], shares[i], shares[0])
+				}
+			}
+
+			ksCiphertext := bfv.NewCiphertext(testCtx.params, 1)
+			cks[0].KeySwitch(ciphertextReduced, shares[0], ksCiphertext)
+			verifyTestVectors(testCtx, decryptorSk1, coeffs, ksCiphertext, t)
+		})
+
+		t.Run("PCKS", func(t *testing.T) {
+			shares := make([]*drlwe.PCKSShare, parties)
+			for i := range pcks {
+				shares[i] = pcks[i].AllocateShare()
+				pcks[i].GenShare(sk0Shards[i], pk1, ciphertextReduced.Value[1], shares[i])
+				if i > 0 {
+					pcks[0].AggregateShare(shares[0], shares[i], shares[0])
+				}
+			}
+
+			ksCiphertext := bfv.NewCiphertext(testCtx.params, 1)
+			pcks[0].KeySwitch(ciphertextReduced, shares[0], ksCiphertext)
+			verifyTestVectors(testCtx, decryptorSk1, coeffs, ksCiphertext, t)
+		})
+	})
+}
+
+func testInteractiveRelin(testCtx *testContext, t *testing.T) {
+
+	sk0Shards := testCtx.sk0Shards
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk0 := testCtx.decryptorSk0
+
+	t.Run(testString("InteractiveRelin", parties, testCtx.params), func(t *testing.T) {
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+		for i := range coeffs {
+			coeffs[i] *= coeffs[i]
+			coeffs[i] %= testCtx.ringT.Modulus[0]
+		}
+
+		ciphertextMul := bfv.NewCiphertext(testCtx.params, 2)
+		testCtx.evaluator.Mul(ciphertext, ciphertext, ciphertextMul)
+
+		type Party struct {
+			*InteractiveRelinProtocol
+			sk    *rlwe.SecretKey
+			share *drlwe.DegreeReductionShare
+		}
+
+		relinParties := make([]*Party, parties)
+		for i := range relinParties {
+			p := new(Party)
+			if i == 0 {
+				p.InteractiveRelinProtocol = NewInteractiveRelinProtocol(testCtx.params)
+			} else {
+				p.InteractiveRelinProtocol = relinParties[0].ShallowCopy()
+			}
+			p.sk = sk0Shards[i]
+			p.share = p.AllocateShare()
+			relinParties[i] = p
+		}
+
+		P0 := relinParties[0]
+
+		for i, p := range relinParties {
+			p.GenShare(p.sk, ciphertextMul, p.share)
+			if i > 0 {
+				P0.AggregateShare(p.share, P0.share, P0.share)
+			}
+		}
+
+		ciphertextRelin := bfv.NewCiphertext(testCtx.params, 1)
+		P0.Relinearize(ciphertextMul, P0.share, ciphertextRelin)
+		require.Equal(t, 1, ciphertextRelin.Degree())
+
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertextRelin, t)
+
+		// The ciphertext can also be relinearized in place
+		P0.Relinearize(ciphertextMul, P0.share, ciphertextMul)
+		require.Equal(t, 1, ciphertextMul.Degree())
+		require.True(t, testCtx.ringQ.Equal(ciphertextRelin.Value[1], ciphertextMul.Value[1]))
+	})
 }
 
 func testRotKeyGenRotRows(testCtx *testContext, t *testing.T) {
@@ -528,6 +694,36 @@ func testEncToShares(testCtx *testContext, t *testing.T) {
 
 	})
 
+	t.Run(testString("E2SProtocol/SPDZ", parties, testCtx.params), func(t *testing.T) {
+
+		// The shares of the slots sum to the slots modulo t
+		sum := make([]uint64, params.N())
+		for _, p := range P {
+			spdz := ExportSPDZShare(params, testCtx.encoder, p.secretShare)
+			require.Equal(t, params.T(), spdz.Modulus)
+
+			data, err := spdz.MarshalBinary()
+			require.NoError(t, err)
+			received := new(SPDZShare)
+			require.NoError(t, received.UnmarshalBinary(data))
+			require.Equal(t, spdz, received)
+
+			for i := range sum {
+				sum[i] = (sum[i] + received.Values[i]) % params.T()
+			}
+
+			share := rlwe.NewAdditiveShare(params.Parameters)
+			require.NoError(t, ImportSPDZShare(params, testCtx.encoder, received, share))
+			require.True(t, share.Value.Equals(&p.secretShare.Value))
+		}
+
+		require.True(t, utils.EqualSliceUint64(coeffs, sum))
+
+		share := rlwe.NewAdditiveShare(params.Parameters)
+		require.Error(t, ImportSPDZShare(params, testCtx.encoder, &SPDZShare{Modulus: params.T() + 2, Values: sum}, share))
+		require.Error(t, ImportSPDZShare(params, testCtx.encoder, &SPDZShare{Modulus: params.T(), Values: []uint64{params.T()}}, share))
+	})
+
 	crp := P[0].e2s.SampleCRP(params.MaxLevel(), testCtx.crs)
 
 	t.Run(testString("S2EProtocol", parties, testCtx.params), func(t *testing.T) {
@@ -649,6 +845,199 @@ func testRefresh(testCtx *testContext, t *testing.T) {
 		//Decrypts and compare
 		require.True(t, utils.EqualSliceUint64(coeffs, encoder.DecodeUintNew(decryptorSk0.DecryptNew(ctRes))))
 	})
+
+	t.Run(testString("RefreshAtLevel", parties, testCtx.params), func(t *testing.T) {
+
+		if testCtx.params.MaxLevel() == 0 {
+			t.Skip("the parameters do not have enough levels")
+		}
+
+		level := testCtx.params.MaxLevel() / 2
+
+		type Party struct {
+			*RefreshProtocol
+			s     *rlwe.SecretKey
+			share *RefreshShare
+		}
+
+		RefreshParties := make([]*Party, parties)
+		for i := 0; i < parties; i++ {
+			p := new(Party)
+			if i == 0 {
+				p.RefreshProtocol = NewRefreshProtocol(testCtx.params, 3.2)
+			} else {
+				p.RefreshProtocol = RefreshParties[0].RefreshProtocol.ShallowCopy()
+			}
+			p.s = sk0Shards[i]
+			p.share = p.AllocateShareAtLevel(level)
+			RefreshParties[i] = p
+		}
+
+		P0 := RefreshParties[0]
+
+		crp := P0.SampleCRP(testCtx.params.MaxLevel(), testCtx.crs)
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+		ciphertext = bfv.NewCiphertextCompressor(testCtx.params).ModSwitchNew(ciphertext, level)
+
+		for i, p := range RefreshParties {
+			p.GenShare(p.s, ciphertext.Value[1], crp, p.share)
+			if i > 0 {
+				P0.Aggregate(p.share, P0.share, P0.share)
+			}
+		}
+
+		// The masked-decryption share is at the level of the ciphertext
+		data, err := P0.share.MarshalBinary()
+		require.NoError(t, err)
+		fullData, err := P0.AllocateShare().MarshalBinary()
+		require.NoError(t, err)
+		require.Less(t, len(data), len(fullData))
+
+		shareUnmarshaled := new(RefreshShare)
+		require.NoError(t, shareUnmarshaled.UnmarshalBinary(data))
+		require.True(t, shareUnmarshaled.e2sShare.Value.Equals(P0.share.e2sShare.Value))
+		require.True(t, shareUnmarshaled.s2eShare.Value.Equals(P0.share.s2eShare.Value))
+
+		ctRes := bfv.NewCiphertext(testCtx.params, 1)
+		P0.Finalize(ciphertext, crp, shareUnmarshaled, ctRes)
+
+		require.Equal(t, testCtx.params.MaxLevel(), ctRes.Level())
+		require.True(t, utils.EqualSliceUint64(coeffs, encoder.DecodeUintNew(decryptorSk0.DecryptNew(ctRes))))
+	})
+
+	t.Run(testString("RefreshBatch", parties, testCtx.params), func(t *testing.T) {
+
+		batchSize := 3
+
+		type Party struct {
+			*RefreshBatchProtocol
+			s     *rlwe.SecretKey
+			share *RefreshBatchShare
+		}
+
+		RefreshParties := make([]*Party, parties)
+		for i := 0; i < parties; i++ {
+			p := new(Party)
+			if i == 0 {
+				p.RefreshBatchProtocol = NewRefreshBatchProtocol(testCtx.params, 3.2, 2)
+			} else {
+				p.RefreshBatchProtocol = RefreshParties[0].RefreshBatchProtocol.ShallowCopy()
+			}
+			p.s = sk0Shards[i]
+			p.share = p.AllocateShare(batchSize)
+			RefreshParties[i] = p
+		}
+
+		P0 := RefreshParties[0]
+
+		crp := P0.SampleCRP(batchSize, testCtx.crs)
+
+		coeffs := make([][]uint64, batchSize)
+		ciphertexts := make([]*bfv.Ciphertext, batchSize)
+		ctRes := make([]*bfv.Ciphertext, batchSize)
+		ct1 := make([]*ring.Poly, batchSize)
+		for i := range ciphertexts {
+			coeffs[i], _, ciphertexts[i] = newTestVectors(testCtx, encryptorPk0, t)
+			ct1[i] = ciphertexts[i].Value[1]
+			ctRes[i] = bfv.NewCiphertext(testCtx.params, 1)
+		}
+
+		for i, p := range RefreshParties {
+			p.GenShare(p.s, ct1, crp, p.share)
+			if i > 0 {
+				// The shares of the batch are sent in a single message
+				data, err := p.share.MarshalBinary()
+				require.NoError(t, err)
+				received := P0.AllocateShare(batchSize)
+				require.NoError(t, received.UnmarshalBinary(data))
+				P0.Aggregate(received, P0.share, P0.share)
+			}
+		}
+
+		P0.Finalize(ciphertexts, crp, P0.share, ctRes)
+
+		for i := range ctRes {
+			require.True(t, utils.EqualSliceUint64(coeffs[i], encoder.DecodeUintNew(decryptorSk0.DecryptNew(ctRes[i]))))
+		}
+	})
+}
+
+func testNoiseReduction(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	sk0Shards := testCtx.sk0Shards
+	encoder := testCtx.encoder
+	decryptorSk0 := testCtx.decryptorSk0
+
+	kgen := bfv.NewKeyGenerator(testCtx.params)
+
+	rlk := kgen.GenRelinearizationKey(testCtx.sk0, 2)
+
+	t.Run(testString("NoiseReduction", parties, testCtx.params), func(t *testing.T) {
+
+		type Party struct {
+			*NoiseReductionProtocol
+			s     *rlwe.SecretKey
+			mask  *NoiseReductionMask
+			share *drlwe.CKSShare
+		}
+
+		nrParties := make([]*Party, parties)
+		for i := 0; i < parties; i++ {
+			p := new(Party)
+			if i == 0 {
+				p.NoiseReductionProtocol = NewNoiseReductionProtocol(testCtx.params, 3.2)
+			} else {
+				p.NoiseReductionProtocol = nrParties[0].NoiseReductionProtocol.ShallowCopy()
+			}
+			p.s = sk0Shards[i]
+			p.mask = p.AllocateMask()
+			p.share = p.AllocateShare()
+			nrParties[i] = p
+		}
+
+		P0 := nrParties[0]
+
+		// Offline phase, independent of the ciphertext
+		crp := P0.SampleCRP(testCtx.params.MaxLevel(), testCtx.crs)
+		maskAgg := P0.AllocateShare()
+		for _, p := range nrParties {
+			p.GenMask(p.s, crp, p.mask)
+			P0.AggregateShare(p.mask.Share, maskAgg, maskAgg)
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+		evaluator := testCtx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: rlk, Rtks: nil})
+
+		// Consumes part of the noise budget before the noise reduction
+		evaluator.Relinearize(testCtx.evaluator.MulNew(ciphertext, ciphertext), ciphertext)
+		for j := range coeffs {
+			coeffs[j] = ring.BRed(coeffs[j], coeffs[j], testCtx.ringT.Modulus[0], testCtx.ringT.BredParams[0])
+		}
+
+		// Online phase
+		for i, p := range nrParties {
+			p.GenShare(p.s, ciphertext.Value[1], p.mask, p.share)
+			require.True(t, p.mask.Value.Value.Equals(testCtx.ringT.NewPoly()))
+			if i > 0 {
+				P0.AggregateShare(p.share, P0.share, P0.share)
+			}
+		}
+
+		ctRes := bfv.NewCiphertext(testCtx.params, 1)
+		P0.Finalize(ciphertext, crp, maskAgg, P0.share, ctRes)
+
+		require.True(t, utils.EqualSliceUint64(coeffs, encoder.DecodeUintNew(decryptorSk0.DecryptNew(ctRes))))
+
+		// The rejuvenated ciphertext can be used in further multiplications
+		evaluator.Relinearize(testCtx.evaluator.MulNew(ctRes, ctRes), ctRes)
+		for j := range coeffs {
+			coeffs[j] = ring.BRed(coeffs[j], coeffs[j], testCtx.ringT.Modulus[0], testCtx.ringT.BredParams[0])
+		}
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ctRes, t)
+	})
 }
 
 func testRefreshAndPermutation(testCtx *testContext, t *testing.T) {
@@ -724,6 +1113,66 @@ func testRefreshAndPermutation(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testRefreshAndLinearTransform(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	sk0Shards := testCtx.sk0Shards
+	encoder := testCtx.encoder
+	decryptorSk0 := testCtx.decryptorSk0
+
+	t.Run(testString("RefreshAndLinearTransform", parties, testCtx.params), func(t *testing.T) {
+
+		N := testCtx.params.N()
+		T := testCtx.params.T()
+
+		// Mixes each slot with the next one and the first slot: y_i = 2*m_i + (T-1)*m_{i+1} + m_0
+		lt := LinearTransform{}
+		for i := 0; i < N; i++ {
+			lt[i] = map[int]uint64{i: 2, (i + 1) % N: T - 1}
+			lt[i][0] += 1
+		}
+
+		transform, err := lt.MaskedTransformFunc(testCtx.params)
+		require.NoError(t, err)
+
+		_, err = LinearTransform{N: {0: 1}}.MaskedTransformFunc(testCtx.params)
+		require.Error(t, err)
+		_, err = LinearTransform{0: {-1: 1}}.MaskedTransformFunc(testCtx.params)
+		require.Error(t, err)
+
+		rfp := make([]*MaskedTransformProtocol, parties)
+		shares := make([]*MaskedTransformShare, parties)
+		for i := range rfp {
+			if i == 0 {
+				rfp[i] = NewMaskedTransformProtocol(testCtx.params, 3.2)
+			} else {
+				rfp[i] = rfp[0].ShallowCopy()
+			}
+			shares[i] = rfp[i].AllocateShare()
+		}
+
+		crp := rfp[0].SampleCRP(testCtx.params.MaxLevel(), testCtx.crs)
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+		for i := range rfp {
+			rfp[i].GenShare(sk0Shards[i], ciphertext.Value[1], crp, transform, shares[i])
+			if i > 0 {
+				rfp[0].Aggregate(shares[0], shares[i], shares[0])
+			}
+		}
+
+		rfp[0].Transform(ciphertext, transform, crp, shares[0], ciphertext)
+
+		want := make([]uint64, N)
+		for i := range want {
+			want[i] = (2*coeffs[i] + (T-1)*coeffs[(i+1)%N] + coeffs[0]) % T
+		}
+
+		require.True(t, utils.EqualSliceUint64(want, encoder.DecodeUintNew(decryptorSk0.DecryptNew(ciphertext))))
+	})
+}
+
 func newTestVectors(testCtx *testContext, encryptor bfv.Encryptor, t *testing.T) (coeffs []uint64, plaintext *bfv.Plaintext, ciphertext *bfv.Ciphertext) {
 
 	prng, _ := utils.NewPRNG()
@@ -739,6 +1188,96 @@ func verifyTestVectors(testCtx *testContext, decryptor bfv.Decryptor, coeffs []u
 	require.True(t, utils.EqualSliceUint64(coeffs, testCtx.encoder.DecodeUintNew(decryptor.DecryptNew(ciphertext))))
 }
 
+func testMigration(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	sk0Shards := testCtx.sk0Shards
+
+	t.Run(testString("Migration", parties, testCtx.params), func(t *testing.T) {
+
+		// The output parameters have the same ring degree and plaintext modulus but larger moduli
+		paramsOut, err := bfv.NewParametersFromLiteral(bfv.ParametersLiteral{
+			LogN:  testCtx.params.LogN(),
+			T:     testCtx.params.T(),
+			LogQ:  []int{55, 55, 55},
+			LogP:  []int{56},
+			Sigma: rlwe.DefaultSigma,
+		})
+		require.NoError(t, err)
+
+		kgenOut := bfv.NewKeyGenerator(paramsOut)
+		skOut := bfv.NewSecretKey(paramsOut)
+		skOutShards := make([]*rlwe.SecretKey, parties)
+		for i := range skOutShards {
+			skOutShards[i] = kgenOut.GenSecretKey()
+			paramsOut.RingQP().AddLvl(paramsOut.QCount()-1, paramsOut.PCount()-1, skOut.Value, skOutShards[i].Value, skOut.Value)
+		}
+
+		type Party struct {
+			*MigrationProtocol
+			skIn  *rlwe.SecretKey
+			skOut *rlwe.SecretKey
+			share *drlwe.MigrationShare
+		}
+
+		migrationParties := make([]*Party, parties)
+		for i := range migrationParties {
+			p := new(Party)
+			if i == 0 {
+				p.MigrationProtocol, err = NewMigrationProtocol(testCtx.params, paramsOut, 3.2)
+				require.NoError(t, err)
+			} else {
+				p.MigrationProtocol = migrationParties[0].ShallowCopy()
+			}
+			p.skIn = sk0Shards[i]
+			p.skOut = skOutShards[i]
+			p.share = p.AllocateShare()
+			migrationParties[i] = p
+		}
+
+		P0 := migrationParties[0]
+
+		crp := P0.SampleCRP(testCtx.crs)
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, t)
+
+		for i, p := range migrationParties {
+			p.GenShare(p.skIn, p.skOut, ciphertext.Value[1], crp, p.share)
+			if i > 0 {
+				P0.AggregateShare(p.share, P0.share, P0.share)
+			}
+		}
+
+		ctOut := bfv.NewCiphertext(paramsOut, 1)
+		P0.Migrate(ciphertext, crp, P0.share, ctOut)
+
+		ptOut := bfv.NewDecryptor(paramsOut, skOut).DecryptNew(ctOut)
+		require.True(t, utils.EqualSliceUint64(coeffs, bfv.NewEncoder(paramsOut).DecodeUintNew(ptOut)))
+
+		t.Run("Marshalling", func(t *testing.T) {
+			data, err := P0.share.MarshalBinary()
+			require.NoError(t, err)
+			shareAfter := P0.AllocateShare()
+			require.NoError(t, shareAfter.UnmarshalBinary(data))
+			require.True(t, testCtx.ringQ.Equal(P0.share.DecryptionShare.Value, shareAfter.DecryptionShare.Value))
+			require.True(t, paramsOut.RingQ().Equal(P0.share.EncryptionShare.Value, shareAfter.EncryptionShare.Value))
+		})
+
+		t.Run("InvalidParameters", func(t *testing.T) {
+			paramsT, err := bfv.NewParametersFromLiteral(bfv.ParametersLiteral{
+				LogN:  testCtx.params.LogN(),
+				T:     0x3ee0001,
+				Q:     testCtx.params.Q(),
+				P:     testCtx.params.P(),
+				Sigma: rlwe.DefaultSigma,
+			})
+			require.NoError(t, err)
+			_, err = NewMigrationProtocol(testCtx.params, paramsT, 3.2)
+			require.Error(t, err)
+		})
+	})
+}
+
 func testMarshalling(testCtx *testContext, t *testing.T) {
 	ciphertext := bfv.NewCiphertext(testCtx.params, 1)
 	testCtx.uniformSampler.Read(ciphertext.Value[0])
@@ -775,5 +1314,10 @@ func testMarshalling(testCtx *testContext, t *testing.T) {
 				t.Fatal("Resulting of marshalling not the same as original : RefreshShare")
 			}
 		}
+
+		require.Equal(t, refreshshare.Header, resRefreshShare.Header)
+		require.ErrorIs(t, NewCKSProtocol(testCtx.params, 3.2).AllocateShare().UnmarshalBinary(data), drlwe.ErrIncompatibleShare)
+		data[0] = drlwe.ShareFormatVersion + 1
+		require.ErrorIs(t, new(MaskedTransformShare).UnmarshalBinary(data), drlwe.ErrIncompatibleShare)
 	})
 }
@@ -11,9 +11,10 @@ type CKGProtocol struct {
 	drlwe.CKGProtocol
 }
 
-// NewCKGProtocol creates a new CKGProtocol instance
-func NewCKGProtocol(params bfv.Parameters) *CKGProtocol {
-	return &CKGProtocol{*drlwe.NewCKGProtocol(params.Parameters)}
+// NewCKGProtocol creates a new CKGProtocol instance. The randomness of the shares can be seeded with the
+// drlwe.WithSeed option.
+func NewCKGProtocol(params bfv.Parameters, options ...drlwe.ProtocolOption) *CKGProtocol {
+	return &CKGProtocol{*drlwe.NewCKGProtocol(params.Parameters, options...)}
 }
 
 // ShallowCopy creates a shallow copy of CKGProtocol in which all the read-only data-structures are
@@ -30,9 +31,10 @@ type RKGProtocol struct {
 }
 
 // NewRKGProtocol creates a new RKGProtocol object that will be used to generate a collective evaluation-key
-// among j parties in the given context with the given bit-decomposition.
-func NewRKGProtocol(params bfv.Parameters) *RKGProtocol {
-	return &RKGProtocol{*drlwe.NewRKGProtocol(params.Parameters)}
+// among j parties in the given context with the given bit-decomposition. The number of goroutines of the generation and
+// aggregation of the shares can be set with the drlwe.WithGoroutines option.
+func NewRKGProtocol(params bfv.Parameters, options ...drlwe.ProtocolOption) *RKGProtocol {
+	return &RKGProtocol{*drlwe.NewRKGProtocol(params.Parameters, options...)}
 }
 
 // ShallowCopy creates a shallow copy of RKGProtocol in which all the read-only data-structures are
@@ -48,8 +50,9 @@ type RTGProtocol struct {
 }
 
 // NewRotKGProtocol creates a new rotkg object and will be used to generate collective rotation-keys from a shared secret-key among j parties.
-func NewRotKGProtocol(params bfv.Parameters) (rtg *RTGProtocol) {
-	return &RTGProtocol{*drlwe.NewRTGProtocol(params.Parameters)}
+// The number of goroutines of the generation and aggregation of the shares can be set with the drlwe.WithGoroutines option.
+func NewRotKGProtocol(params bfv.Parameters, options ...drlwe.ProtocolOption) (rtg *RTGProtocol) {
+	return &RTGProtocol{*drlwe.NewRTGProtocol(params.Parameters, options...)}
 }
 
 // ShallowCopy creates a shallow copy of RTGProtocol in which all the read-only data-structures are
@@ -58,3 +61,42 @@ func NewRotKGProtocol(params bfv.Parameters) (rtg *RTGProtocol) {
 func (rtg *RTGProtocol) ShallowCopy() *RTGProtocol {
 	return &RTGProtocol{*rtg.RTGProtocol.ShallowCopy()}
 }
+
+// RTGBatchProtocol is the structure storing the parameters for the collective generation of the rotation keys of a
+// set of Galois elements in a single round.
+type RTGBatchProtocol struct {
+	drlwe.RTGBatchProtocol
+}
+
+// NewRotKGBatchProtocol creates a new RTGBatchProtocol that generates the collective rotation keys of the Galois
+// elements galEls in a single round, with the given number of goroutines (runtime.NumCPU() if smaller than 1).
+func NewRotKGBatchProtocol(params bfv.Parameters, galEls []uint64, goroutines int) (rtg *RTGBatchProtocol) {
+	return &RTGBatchProtocol{*drlwe.NewRTGBatchProtocol(params.Parameters, galEls, goroutines)}
+}
+
+// ShallowCopy creates a shallow copy of RTGBatchProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// RTGBatchProtocol can be used concurrently.
+func (rtg *RTGBatchProtocol) ShallowCopy() *RTGBatchProtocol {
+	return &RTGBatchProtocol{*rtg.RTGBatchProtocol.ShallowCopy()}
+}
+
+// RTGChunkedProtocol is the structure storing the parameters for the collective generation of the rotation keys of a
+// large set of Galois elements in chunks of bounded size.
+type RTGChunkedProtocol struct {
+	drlwe.RTGChunkedProtocol
+}
+
+// NewRotKGChunkedProtocol creates a new RTGChunkedProtocol that generates the collective rotation keys of the Galois
+// elements galEls in chunks of at most chunkSize Galois elements, with the given number of goroutines
+// (runtime.NumCPU() if smaller than 1).
+func NewRotKGChunkedProtocol(params bfv.Parameters, galEls []uint64, chunkSize, goroutines int) (rtg *RTGChunkedProtocol) {
+	return &RTGChunkedProtocol{*drlwe.NewRTGChunkedProtocol(params.Parameters, galEls, chunkSize, goroutines)}
+}
+
+// ShallowCopy creates a shallow copy of RTGChunkedProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// RTGChunkedProtocol can be used concurrently.
+func (rtg *RTGChunkedProtocol) ShallowCopy() *RTGChunkedProtocol {
+	return &RTGChunkedProtocol{*rtg.RTGChunkedProtocol.ShallowCopy()}
+}
@@ -3,6 +3,7 @@ package dbfv
 import (
 	"github.com/ldsec/lattigo/v2/bfv"
 	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
 )
 
 // CKSProtocol is a structure storing the parameters for the collective key-switching protocol.
@@ -13,9 +14,9 @@ type CKSProtocol struct {
 
 // NewCKSProtocol creates a new CKSProtocol that will be used to perform a collective key-switching on a ciphertext encrypted under a collective public-key, whose
 // secret-shares are distributed among j parties, re-encrypting the ciphertext under another public-key, whose secret-shares are also known to the
-// parties.
-func NewCKSProtocol(params bfv.Parameters, sigmaSmudging float64) *CKSProtocol {
-	return &CKSProtocol{*drlwe.NewCKSProtocol(params.Parameters, sigmaSmudging), params.MaxLevel()}
+// parties. The randomness of the shares can be seeded with the drlwe.WithSeed option.
+func NewCKSProtocol(params bfv.Parameters, sigmaSmudging float64, options ...drlwe.ProtocolOption) *CKSProtocol {
+	return &CKSProtocol{*drlwe.NewCKSProtocol(params.Parameters, sigmaSmudging, options...), params.MaxLevel()}
 }
 
 // KeySwitch performs the actual keyswitching operation on a ciphertext ct and put the result in ctOut
@@ -23,6 +24,18 @@ func (cks *CKSProtocol) KeySwitch(ctIn *bfv.Ciphertext, combined *drlwe.CKSShare
 	cks.CKSProtocol.KeySwitch(ctIn.Ciphertext, combined, ctOut.Ciphertext)
 }
 
+// AllocateDegreeReductionShare allocates the share of one party in a round of the reduction of the degree of a
+// ciphertext for BFV.
+func (cks *CKSProtocol) AllocateDegreeReductionShare() *drlwe.DegreeReductionShare {
+	return cks.CKSProtocol.AllocateDegreeReductionShare(cks.maxLevel)
+}
+
+// ReduceDegree adds the aggregated shares of a round of the reduction of the degree of ctIn to ctIn and puts the result,
+// of degree ctIn.Degree()-1, in ctOut.
+func (cks *CKSProtocol) ReduceDegree(ctIn *bfv.Ciphertext, combined *drlwe.DegreeReductionShare, ctOut *bfv.Ciphertext) {
+	cks.CKSProtocol.ReduceDegree(ctIn.Ciphertext, combined, ctOut.Ciphertext)
+}
+
 // AllocateShare allocates the shares of one party in the CKS protocol for BFV.
 func (cks *CKSProtocol) AllocateShare() *drlwe.CKSShare {
 	return cks.CKSProtocol.AllocateShare(cks.maxLevel)
@@ -57,9 +70,129 @@ func (pcks *PCKSProtocol) KeySwitch(ctIn *bfv.Ciphertext, combined *drlwe.PCKSSh
 	pcks.PCKSProtocol.KeySwitch(ctIn.Ciphertext, combined, ctOut.Ciphertext)
 }
 
+// AllocateDegreeReductionShare allocates the share of one party in a round of the reduction of the degree of a
+// ciphertext for BFV.
+func (pcks *PCKSProtocol) AllocateDegreeReductionShare() *drlwe.DegreeReductionShare {
+	return pcks.PCKSProtocol.AllocateDegreeReductionShare(pcks.maxLevel)
+}
+
+// ReduceDegree adds the aggregated shares of a round of the reduction of the degree of ctIn to ctIn and puts the result,
+// of degree ctIn.Degree()-1, in ctOut.
+func (pcks *PCKSProtocol) ReduceDegree(ctIn *bfv.Ciphertext, combined *drlwe.DegreeReductionShare, ctOut *bfv.Ciphertext) {
+	pcks.PCKSProtocol.ReduceDegree(ctIn.Ciphertext, combined, ctOut.Ciphertext)
+}
+
 // ShallowCopy creates a shallow copy of PCKSProtocol in which all the read-only data-structures are
 // shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
 // PCKSProtocol can be used concurrently.
 func (pcks *PCKSProtocol) ShallowCopy() *PCKSProtocol {
 	return &PCKSProtocol{*pcks.PCKSProtocol.ShallowCopy(), pcks.maxLevel}
 }
+
+// MultiPCKSProtocol is the structure storing the parameters for the collective public key-switching of a ciphertext
+// under the public keys of several recipients in a single round.
+type MultiPCKSProtocol struct {
+	drlwe.MultiPCKSProtocol
+	maxLevel int
+}
+
+// NewMultiPCKSProtocol creates a new MultiPCKSProtocol object and will be used to re-encrypt a ciphertext ctx encrypted
+// under a secret-shared key among j parties under the public keys pks of the recipients.
+func NewMultiPCKSProtocol(params bfv.Parameters, sigmaSmudging float64, pks []*rlwe.PublicKey) *MultiPCKSProtocol {
+	return &MultiPCKSProtocol{*drlwe.NewMultiPCKSProtocol(params.Parameters, sigmaSmudging, pks), params.MaxLevel()}
+}
+
+// AllocateShare allocates the shares of one party in the MultiPCKSProtocol for BFV.
+func (mpcks *MultiPCKSProtocol) AllocateShare() *drlwe.MultiPCKSShare {
+	return mpcks.MultiPCKSProtocol.AllocateShare(mpcks.maxLevel)
+}
+
+// KeySwitch performs the actual keyswitching operation on a ciphertext ct and puts its re-encryption under the public
+// key of the j-th recipient in ctOut[j].
+func (mpcks *MultiPCKSProtocol) KeySwitch(ctIn *bfv.Ciphertext, combined *drlwe.MultiPCKSShare, ctOut []*bfv.Ciphertext) {
+	cts := make([]*rlwe.Ciphertext, len(ctOut))
+	for j := range ctOut {
+		cts[j] = ctOut[j].Ciphertext
+	}
+	mpcks.MultiPCKSProtocol.KeySwitch(ctIn.Ciphertext, combined, cts)
+}
+
+// ShallowCopy creates a shallow copy of MultiPCKSProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MultiPCKSProtocol can be used concurrently.
+func (mpcks *MultiPCKSProtocol) ShallowCopy() *MultiPCKSProtocol {
+	return &MultiPCKSProtocol{*mpcks.MultiPCKSProtocol.ShallowCopy(), mpcks.maxLevel}
+}
+
+// InteractiveRelinProtocol is the structure storing the parameters for the interactive relinearization of BFV
+// ciphertexts of degree 2, without a relinearization key.
+type InteractiveRelinProtocol struct {
+	drlwe.InteractiveRelinProtocol
+	maxLevel int
+}
+
+// NewInteractiveRelinProtocol creates a new InteractiveRelinProtocol object that will be used to relinearize a
+// ciphertext of degree 2 encrypted under a secret-shared key among j parties.
+func NewInteractiveRelinProtocol(params bfv.Parameters) *InteractiveRelinProtocol {
+	return &InteractiveRelinProtocol{*drlwe.NewInteractiveRelinProtocol(params.Parameters), params.MaxLevel()}
+}
+
+// AllocateShare allocates the share of one party in the InteractiveRelinProtocol for BFV.
+func (rp *InteractiveRelinProtocol) AllocateShare() *drlwe.DegreeReductionShare {
+	return rp.InteractiveRelinProtocol.AllocateShare(rp.maxLevel)
+}
+
+// GenShare computes the share of one party in the relinearization of the ciphertext ct of degree 2.
+func (rp *InteractiveRelinProtocol) GenShare(sk *rlwe.SecretKey, ct *bfv.Ciphertext, shareOut *drlwe.DegreeReductionShare) {
+	rp.InteractiveRelinProtocol.GenShare(sk, ct.Ciphertext, shareOut)
+}
+
+// Relinearize computes the relinearization of ctIn from the aggregated shares of all the parties and puts the result,
+// of degree 1, in ctOut.
+func (rp *InteractiveRelinProtocol) Relinearize(ctIn *bfv.Ciphertext, combined *drlwe.DegreeReductionShare, ctOut *bfv.Ciphertext) {
+	rp.InteractiveRelinProtocol.Relinearize(ctIn.Ciphertext, combined, ctOut.Ciphertext)
+}
+
+// ShallowCopy creates a shallow copy of InteractiveRelinProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// InteractiveRelinProtocol can be used concurrently.
+func (rp *InteractiveRelinProtocol) ShallowCopy() *InteractiveRelinProtocol {
+	return &InteractiveRelinProtocol{*rp.InteractiveRelinProtocol.ShallowCopy(), rp.maxLevel}
+}
+
+// CKSBatchProtocol is the structure storing the parameters for the collective key-switching of a batch of BFV
+// ciphertexts in a single round.
+type CKSBatchProtocol struct {
+	drlwe.CKSBatchProtocol
+	maxLevel int
+}
+
+// NewCKSBatchProtocol creates a new CKSBatchProtocol whose shares are generated by the given number of goroutines, or
+// by runtime.NumCPU() goroutines if goroutines is smaller than 1.
+func NewCKSBatchProtocol(params bfv.Parameters, sigmaSmudging float64, goroutines int) *CKSBatchProtocol {
+	return &CKSBatchProtocol{*drlwe.NewCKSBatchProtocol(params.Parameters, sigmaSmudging, goroutines), params.MaxLevel()}
+}
+
+// AllocateShare allocates the share of one party in the CKSBatchProtocol for a batch of n BFV ciphertexts.
+func (cks *CKSBatchProtocol) AllocateShare(n int) *drlwe.CKSBatchShare {
+	return cks.CKSBatchProtocol.AllocateShare(cks.maxLevel, n)
+}
+
+// KeySwitch performs the key-switching of all the ciphertexts ctIn of the batch and puts the results in ctOut.
+func (cks *CKSBatchProtocol) KeySwitch(ctIn []*bfv.Ciphertext, combined *drlwe.CKSBatchShare, ctOut []*bfv.Ciphertext) {
+	in, out := make([]*rlwe.Ciphertext, len(ctIn)), make([]*rlwe.Ciphertext, len(ctOut))
+	for i := range ctIn {
+		in[i] = ctIn[i].Ciphertext
+	}
+	for i := range ctOut {
+		out[i] = ctOut[i].Ciphertext
+	}
+	cks.CKSBatchProtocol.KeySwitch(in, combined, out)
+}
+
+// ShallowCopy creates a shallow copy of CKSBatchProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// CKSBatchProtocol can be used concurrently.
+func (cks *CKSBatchProtocol) ShallowCopy() *CKSBatchProtocol {
+	return &CKSBatchProtocol{*cks.CKSBatchProtocol.ShallowCopy(), cks.maxLevel}
+}
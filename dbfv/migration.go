@@ -0,0 +1,149 @@
+package dbfv
+
+import (
+	"errors"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// MigrationProtocol is the structure storing the parameters and temporary buffers required by the migration
+// protocol, which re-encrypts a BFV ciphertext under a collective secret key and a parameter set, e.g. with larger or
+// post-quantum moduli, into a ciphertext under another collective secret key of the same parties and another parameter
+// set, in a single round and without revealing its plaintext.
+//
+// Each party samples a uniform mask M_i modulo t, which it subtracts from its decryption share of the input ciphertext
+// under the input parameters, and adds to its encryption share under the output parameters and a common random
+// polynomial. The aggregated decryption share reveals the masked message m - sum(M_i), which is re-encrypted under the
+// output parameters and added to the aggregated encryption share.
+//
+// The input and output parameters must share the same ring degree and plaintext modulus.
+type MigrationProtocol struct {
+	paramsIn, paramsOut bfv.Parameters
+
+	cksIn, cksOut         CKSProtocol
+	zeroIn, zeroOut       *rlwe.SecretKey
+	encoderIn, encoderOut bfv.Encoder
+	maskSampler           *ring.UniformSampler
+
+	tmpMask    *bfv.PlaintextRingT
+	tmpPtIn    *bfv.Plaintext
+	tmpPtOut   *bfv.Plaintext
+	tmpPtRingT *bfv.PlaintextRingT
+
+	header drlwe.ShareHeader
+}
+
+// NewMigrationProtocol creates a new MigrationProtocol from the BFV parameters paramsIn to the BFV parameters
+// paramsOut. It returns an error if the parameters do not share the same ring degree and plaintext modulus.
+func NewMigrationProtocol(paramsIn, paramsOut bfv.Parameters, sigmaSmudging float64) (mp *MigrationProtocol, err error) {
+
+	if paramsIn.N() != paramsOut.N() || paramsIn.T() != paramsOut.T() {
+		return nil, errors.New("cannot NewMigrationProtocol: the input and output parameters must have the same ring degree and plaintext modulus")
+	}
+
+	mp = new(MigrationProtocol)
+	mp.paramsIn = paramsIn
+	mp.paramsOut = paramsOut
+	mp.header = drlwe.NewShareHeader(drlwe.ProtocolMigration, paramsIn, paramsOut)
+	mp.cksIn = *NewCKSProtocol(paramsIn, sigmaSmudging)
+	mp.cksOut = *NewCKSProtocol(paramsOut, sigmaSmudging)
+	mp.zeroIn = rlwe.NewSecretKey(paramsIn.Parameters)
+	mp.zeroOut = rlwe.NewSecretKey(paramsOut.Parameters)
+	mp.encoderIn = bfv.NewEncoder(paramsIn)
+	mp.encoderOut = bfv.NewEncoder(paramsOut)
+	mp.allocateBuffers()
+
+	return mp, nil
+}
+
+// ShallowCopy creates a shallow copy of MigrationProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MigrationProtocol can be used concurrently.
+func (mp *MigrationProtocol) ShallowCopy() *MigrationProtocol {
+	mpCopy := &MigrationProtocol{
+		paramsIn:   mp.paramsIn,
+		paramsOut:  mp.paramsOut,
+		cksIn:      *mp.cksIn.ShallowCopy(),
+		cksOut:     *mp.cksOut.ShallowCopy(),
+		zeroIn:     mp.zeroIn,
+		zeroOut:    mp.zeroOut,
+		encoderIn:  mp.encoderIn.ShallowCopy(),
+		encoderOut: mp.encoderOut.ShallowCopy(),
+		header:     mp.header,
+	}
+	mpCopy.allocateBuffers()
+	return mpCopy
+}
+
+func (mp *MigrationProtocol) allocateBuffers() {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	mp.maskSampler = ring.NewUniformSampler(prng, mp.paramsIn.RingT())
+	mp.tmpMask = bfv.NewPlaintextRingT(mp.paramsIn)
+	mp.tmpPtIn = bfv.NewPlaintext(mp.paramsIn)
+	mp.tmpPtOut = bfv.NewPlaintext(mp.paramsOut)
+	mp.tmpPtRingT = bfv.NewPlaintextRingT(mp.paramsIn)
+}
+
+// AllocateShare allocates the share of one party in the MigrationProtocol.
+func (mp *MigrationProtocol) AllocateShare() *drlwe.MigrationShare {
+	return &drlwe.MigrationShare{
+		Header:          mp.header,
+		DecryptionShare: *mp.cksIn.AllocateShare(),
+		EncryptionShare: *mp.cksOut.AllocateShare(),
+	}
+}
+
+// SampleCRP samples a common random polynomial of the output parameters to be used in the MigrationProtocol from the
+// provided common reference string.
+func (mp *MigrationProtocol) SampleCRP(crs utils.PRNG) drlwe.CKSCRP {
+	return mp.cksOut.SampleCRP(mp.paramsOut.MaxLevel(), crs)
+}
+
+// GenShare generates the share of a party in the migration of a ciphertext from its secret key share skIn under the
+// input parameters and its secret key share skOut under the output parameters.
+// ct1 is the degree 1 element of the bfv.Ciphertext to migrate, i.e. bfv.Ciphertext.Value[1].
+func (mp *MigrationProtocol) GenShare(skIn, skOut *rlwe.SecretKey, ct1 *ring.Poly, crp drlwe.CKSCRP, shareOut *drlwe.MigrationShare) {
+
+	mp.maskSampler.Read(mp.tmpMask.Value)
+
+	// Returns [c1*s_i - Delta_in * M_i + e] on the decryption share
+	mp.cksIn.GenShare(skIn, mp.zeroIn, ct1, &shareOut.DecryptionShare)
+	mp.encoderIn.ScaleUp(mp.tmpMask, mp.tmpPtIn)
+	mp.paramsIn.RingQ().Sub(shareOut.DecryptionShare.Value, mp.tmpPtIn.Value, shareOut.DecryptionShare.Value)
+
+	// Returns [-crp*s'_i + Delta_out * M_i + e] on the encryption share
+	mp.cksOut.GenShare(mp.zeroOut, skOut, (*ring.Poly)(&crp), &shareOut.EncryptionShare)
+	mp.encoderOut.ScaleUp(mp.tmpMask, mp.tmpPtOut)
+	mp.paramsOut.RingQ().Add(shareOut.EncryptionShare.Value, mp.tmpPtOut.Value, shareOut.EncryptionShare.Value)
+}
+
+// AggregateShare sums share1 and share2 on shareOut.
+func (mp *MigrationProtocol) AggregateShare(share1, share2, shareOut *drlwe.MigrationShare) {
+	mp.cksIn.AggregateShare(&share1.DecryptionShare, &share2.DecryptionShare, &shareOut.DecryptionShare)
+	mp.cksOut.AggregateShare(&share1.EncryptionShare, &share2.EncryptionShare, &shareOut.EncryptionShare)
+}
+
+// Migrate finalizes the migration of the ciphertext ctIn, under the input parameters, to the ciphertext ctOut, under
+// the output parameters, from the aggregated share of all the parties.
+func (mp *MigrationProtocol) Migrate(ctIn *bfv.Ciphertext, crp drlwe.CKSCRP, share *drlwe.MigrationShare, ctOut *bfv.Ciphertext) {
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot Migrate: ctIn and ctOut must have degree 1")
+	}
+
+	// Returns m - sum(M_i) mod t
+	mp.paramsIn.RingQ().Add(ctIn.Value[0], share.DecryptionShare.Value, mp.tmpPtIn.Value)
+	mp.encoderIn.ScaleDown(mp.tmpPtIn, mp.tmpPtRingT)
+
+	// Returns [-crp*s' + Delta_out * m + e]
+	mp.encoderOut.ScaleUp(mp.tmpPtRingT, mp.tmpPtOut)
+	mp.paramsOut.RingQ().Add(mp.tmpPtOut.Value, share.EncryptionShare.Value, ctOut.Value[0])
+	ctOut.Value[1].Copy((*ring.Poly)(&crp))
+}
@@ -0,0 +1,120 @@
+package dbfv
+
+import (
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// NoiseReductionProtocol is a lighter-weight alternative to the RefreshProtocol for BFV ciphertexts that still have
+// noise budget but must be rejuvenated in the middle of a computation. It splits the refresh in two phases:
+//   - an offline phase, independent of the ciphertext, in which each party samples a mask and generates its encryption
+//     share under a common random polynomial (see GenMask). These shares can be generated and aggregated ahead of time.
+//   - an online phase, in which each party only sends a single masked decryption share of the ciphertext (see GenShare).
+//
+// The result is a fresh encryption of the message, whose c1 is the common random polynomial of the offline phase.
+// Each mask must be used for a single ciphertext.
+type NoiseReductionProtocol struct {
+	CKSProtocol
+	params bfv.Parameters
+
+	encoder     bfv.Encoder
+	maskSampler *ring.UniformSampler
+
+	zero       *rlwe.SecretKey
+	tmpPt      *bfv.Plaintext
+	tmpPtRingT *bfv.PlaintextRingT
+}
+
+// NoiseReductionMask is a party's ciphertext-independent material for the NoiseReductionProtocol. Value is the party's
+// secret mask and must not be disclosed, Share is its public encryption share, to be aggregated with the ones of
+// the other parties.
+type NoiseReductionMask struct {
+	Value rlwe.AdditiveShare
+	Share *drlwe.CKSShare
+}
+
+// NewNoiseReductionProtocol creates a new NoiseReductionProtocol instance.
+func NewNoiseReductionProtocol(params bfv.Parameters, sigmaSmudging float64) *NoiseReductionProtocol {
+	nrp := new(NoiseReductionProtocol)
+	nrp.CKSProtocol = *NewCKSProtocol(params, sigmaSmudging)
+	nrp.params = params
+	nrp.encoder = bfv.NewEncoder(params)
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	nrp.maskSampler = ring.NewUniformSampler(prng, params.RingT())
+	nrp.zero = rlwe.NewSecretKey(params.Parameters)
+	nrp.tmpPt = bfv.NewPlaintext(params)
+	nrp.tmpPtRingT = bfv.NewPlaintextRingT(params)
+	return nrp
+}
+
+// ShallowCopy creates a shallow copy of NoiseReductionProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// NoiseReductionProtocol can be used concurrently.
+func (nrp *NoiseReductionProtocol) ShallowCopy() *NoiseReductionProtocol {
+
+	params := nrp.params
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	return &NoiseReductionProtocol{
+		CKSProtocol: *nrp.CKSProtocol.ShallowCopy(),
+		params:      params,
+		encoder:     nrp.encoder.ShallowCopy(),
+		maskSampler: ring.NewUniformSampler(prng, params.RingT()),
+		zero:        nrp.zero,
+		tmpPt:       bfv.NewPlaintext(params),
+		tmpPtRingT:  bfv.NewPlaintextRingT(params),
+	}
+}
+
+// AllocateMask allocates a party's mask in the NoiseReductionProtocol.
+func (nrp *NoiseReductionProtocol) AllocateMask() *NoiseReductionMask {
+	return &NoiseReductionMask{
+		Value: *rlwe.NewAdditiveShare(nrp.params.Parameters),
+		Share: nrp.AllocateShare(),
+	}
+}
+
+// GenMask generates the offline material of a party in the NoiseReductionProtocol: it samples a fresh mask M_i
+// modulo T and computes its encryption share -crp*s_i + Delta*M_i + e_i under the common random polynomial crp.
+func (nrp *NoiseReductionProtocol) GenMask(sk *rlwe.SecretKey, crp drlwe.CKSCRP, maskOut *NoiseReductionMask) {
+	nrp.maskSampler.Read(&maskOut.Value.Value)
+	nrp.encoder.ScaleUp(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &maskOut.Value.Value}}, nrp.tmpPt)
+	nrp.CKSProtocol.GenShare(nrp.zero, sk, (*ring.Poly)(&crp), maskOut.Share)
+	nrp.params.RingQ().Add(maskOut.Share.Value, nrp.tmpPt.Value, maskOut.Share.Value)
+}
+
+// GenShare generates the online share of a party in the NoiseReductionProtocol, that is the decryption share
+// ct1*s_i + e_i of the ciphertext masked by -Delta*M_i.
+// ct1 is the degree 1 element of a bfv.Ciphertext, i.e. bfv.Ciphertext.Value[1].
+// The secret mask is erased after use, as reusing it for another ciphertext would leak the difference of the messages.
+func (nrp *NoiseReductionProtocol) GenShare(sk *rlwe.SecretKey, ct1 *ring.Poly, mask *NoiseReductionMask, shareOut *drlwe.CKSShare) {
+	nrp.encoder.ScaleUp(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &mask.Value.Value}}, nrp.tmpPt)
+	nrp.CKSProtocol.GenShare(sk, nrp.zero, ct1, shareOut)
+	nrp.params.RingQ().Sub(shareOut.Value, nrp.tmpPt.Value, shareOut.Value)
+	mask.Value.Value.Zero()
+}
+
+// Finalize computes the noise-reduced encryption of the message of ctIn from the aggregated offline shares maskAgg
+// (i.e., the aggregation of the Share field of the parties' masks) and the aggregated online shares shareAgg.
+// crp must be the common random polynomial used to generate the masks.
+func (nrp *NoiseReductionProtocol) Finalize(ctIn *bfv.Ciphertext, crp drlwe.CKSCRP, maskAgg, shareAgg *drlwe.CKSShare, ctOut *bfv.Ciphertext) {
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("ctIn and ctOut must have degree 1.")
+	}
+	ringQ := nrp.params.RingQ()
+	ringQ.Add(ctIn.Value[0], shareAgg.Value, nrp.tmpPt.Value) // Delta*(m - sum M_i) + e
+	nrp.encoder.ScaleDown(nrp.tmpPt, nrp.tmpPtRingT)          // m - sum M_i mod T
+	nrp.encoder.ScaleUp(nrp.tmpPtRingT, nrp.tmpPt)
+	ringQ.Add(nrp.tmpPt.Value, maskAgg.Value, ctOut.Value[0])
+	ctOut.Value[1].Copy((*ring.Poly)(&crp))
+}
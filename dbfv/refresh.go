@@ -37,8 +37,17 @@ func (rfp *RefreshProtocol) AllocateShare() *RefreshShare {
 	return &RefreshShare{*share}
 }
 
+// AllocateShareAtLevel allocates the shares of the Refresh protocol for the ciphertexts at the given level, e.g.
+// after a modulus switching with a bfv.CiphertextCompressor, which reduces the size of the masked-decryption share
+// and the cost of its generation. The refreshed ciphertext is at the maximum level.
+func (rfp *RefreshProtocol) AllocateShareAtLevel(level int) *RefreshShare {
+	share := rfp.MaskedTransformProtocol.AllocateShareAtLevel(level)
+	return &RefreshShare{*share}
+}
+
 // GenShare generates a share for the Refresh protocol.
 // ct1 is degree 1 element of a bfv.Ciphertext, i.e. bfv.Ciphertext.Value[1].
+// The masked-decryption share is generated at the minimum level between ct1 and shareOut.
 func (rfp *RefreshProtocol) GenShare(sk *rlwe.SecretKey, ct1 *ring.Poly, crp drlwe.CKSCRP, shareOut *RefreshShare) {
 	rfp.MaskedTransformProtocol.GenShare(sk, ct1, crp, nil, &shareOut.MaskedTransformShare)
 }
@@ -49,6 +58,7 @@ func (rfp *RefreshProtocol) Aggregate(share1, share2, shareOut *RefreshShare) {
 }
 
 // Finalize applies Decrypt, Recode and Recrypt on the input ciphertext.
+// The input ciphertext can be at any level, and the output ciphertext must be at the maximum level.
 func (rfp *RefreshProtocol) Finalize(ctIn *bfv.Ciphertext, crp drlwe.CKSCRP, share *RefreshShare, ctOut *bfv.Ciphertext) {
 	rfp.MaskedTransformProtocol.Transform(ctIn, nil, crp, &share.MaskedTransformShare, ctOut)
 }
@@ -0,0 +1,210 @@
+package dbfv
+
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RefreshBatchShare is the share of a party in the RefreshBatchProtocol, i.e. its Refresh shares for all the
+// ciphertexts of the batch, sent in a single message.
+type RefreshBatchShare struct {
+	Header drlwe.ShareHeader
+	Value  []*RefreshShare
+}
+
+// RefreshBatchCRP is a type for the common reference polynomials of the RefreshBatchProtocol, i.e. one per ciphertext
+// of the batch.
+type RefreshBatchCRP []drlwe.CKSCRP
+
+// RefreshBatchProtocol is the structure storing the parameters for the collective refresh of a batch of ciphertexts
+// in a single round: each party generates the Refresh shares of all the ciphertexts at once, in parallel, and sends
+// them in a single RefreshBatchShare, which amortizes the overhead per message and the set-up of the samplers when many
+// ciphertexts must be refreshed together.
+type RefreshBatchProtocol struct {
+	params bfv.Parameters
+	rfp    []*RefreshProtocol
+	header drlwe.ShareHeader
+}
+
+// NewRefreshBatchProtocol creates a new RefreshBatchProtocol whose shares are generated by the given number of
+// goroutines, or by runtime.NumCPU() goroutines if goroutines is smaller than 1.
+func NewRefreshBatchProtocol(params bfv.Parameters, sigmaSmudging float64, goroutines int) *RefreshBatchProtocol {
+
+	if goroutines < 1 {
+		goroutines = runtime.NumCPU()
+	}
+
+	rfp := make([]*RefreshProtocol, goroutines)
+	rfp[0] = NewRefreshProtocol(params, sigmaSmudging)
+	for i := 1; i < goroutines; i++ {
+		rfp[i] = rfp[0].ShallowCopy()
+	}
+
+	return &RefreshBatchProtocol{params: params, rfp: rfp, header: drlwe.NewShareHeader(drlwe.ProtocolBFVRefreshBatch, params)}
+}
+
+// ShallowCopy creates a shallow copy of RefreshBatchProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// RefreshBatchProtocol can be used concurrently.
+func (rfp *RefreshBatchProtocol) ShallowCopy() *RefreshBatchProtocol {
+	workers := make([]*RefreshProtocol, len(rfp.rfp))
+	for i := range workers {
+		workers[i] = rfp.rfp[i].ShallowCopy()
+	}
+	return &RefreshBatchProtocol{params: rfp.params, rfp: workers, header: rfp.header}
+}
+
+// AllocateShare allocates the share of a party in the RefreshBatchProtocol for a batch of n ciphertexts.
+func (rfp *RefreshBatchProtocol) AllocateShare(n int) (share *RefreshBatchShare) {
+	share = &RefreshBatchShare{Header: rfp.header, Value: make([]*RefreshShare, n)}
+	for i := range share.Value {
+		share.Value[i] = rfp.rfp[0].AllocateShare()
+	}
+	return
+}
+
+// SampleCRP samples the common random polynomials of a batch of n ciphertexts from the provided common reference
+// string.
+func (rfp *RefreshBatchProtocol) SampleCRP(n int, crs utils.PRNG) RefreshBatchCRP {
+	crp := make(RefreshBatchCRP, n)
+	for i := range crp {
+		crp[i] = rfp.rfp[0].SampleCRP(rfp.params.MaxLevel(), crs)
+	}
+	return crp
+}
+
+// GenShare generates the party's Refresh shares of all the ciphertexts of the batch in parallel, where ct1[i] is the
+// degree 1 element of the i-th ciphertext. It panics if the batch, crp and the share do not have the same size.
+func (rfp *RefreshBatchProtocol) GenShare(sk *rlwe.SecretKey, ct1 []*ring.Poly, crp RefreshBatchCRP, shareOut *RefreshBatchShare) {
+
+	if len(ct1) != len(shareOut.Value) || len(crp) != len(shareOut.Value) {
+		panic("cannot GenShare: the batch, crp and the share do not have the same size")
+	}
+
+	rfp.parallel(len(ct1), func(worker *RefreshProtocol, i int) {
+		worker.GenShare(sk, ct1[i], crp[i], shareOut.Value[i])
+	})
+}
+
+// Aggregate aggregates the shares share1 and share2 of all the ciphertexts of the batch in parallel.
+func (rfp *RefreshBatchProtocol) Aggregate(share1, share2, shareOut *RefreshBatchShare) {
+
+	if len(share1.Value) != len(share2.Value) || len(share1.Value) != len(shareOut.Value) {
+		panic("cannot Aggregate: the shares do not have the same size")
+	}
+
+	rfp.parallel(len(shareOut.Value), func(worker *RefreshProtocol, i int) {
+		worker.Aggregate(share1.Value[i], share2.Value[i], shareOut.Value[i])
+	})
+}
+
+// Finalize refreshes all the ciphertexts ctIn of the batch in parallel from the aggregated share and puts the results
+// in ctOut. It panics if the batch, crp and the share do not have the same size.
+func (rfp *RefreshBatchProtocol) Finalize(ctIn []*bfv.Ciphertext, crp RefreshBatchCRP, share *RefreshBatchShare, ctOut []*bfv.Ciphertext) {
+
+	if len(ctIn) != len(share.Value) || len(ctOut) != len(share.Value) || len(crp) != len(share.Value) {
+		panic("cannot Finalize: the batch, crp and the share do not have the same size")
+	}
+
+	rfp.parallel(len(ctIn), func(worker *RefreshProtocol, i int) {
+		worker.Finalize(ctIn[i], crp[i], share.Value[i], ctOut[i])
+	})
+}
+
+// parallel calls f on each of the n ciphertexts of the batch, distributed among the goroutines.
+func (rfp *RefreshBatchProtocol) parallel(n int, f func(worker *RefreshProtocol, i int)) {
+
+	var wg sync.WaitGroup
+	for w := range rfp.rfp {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < n; i += len(rfp.rfp) {
+				f(rfp.rfp[w], i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (share *RefreshBatchShare) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, drlwe.ShareHeaderLen+4)
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(data[drlwe.ShareHeaderLen:], uint32(len(share.Value)))
+
+	for _, s := range share.Value {
+
+		var shareData []byte
+		if shareData, err = s.MarshalBinary(); err != nil {
+			return nil, err
+		}
+
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(len(shareData)))
+
+		data = append(data, size...)
+		data = append(data, shareData...)
+	}
+
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (share *RefreshBatchShare) UnmarshalBinary(data []byte) (err error) {
+
+	var ptr int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	data = data[ptr:]
+
+	if len(data) < 4 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+
+	if n > len(data)/4 {
+		return errors.New("cannot UnmarshalBinary: invalid number of shares")
+	}
+
+	share.Value = make([]*RefreshShare, n)
+
+	for i := range share.Value {
+
+		if len(data) < 4 {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
+		size := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+
+		if size == 0 || size > len(data) {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
+		// The Refresh shares of the batch are generated with the parameters of the batch
+		share.Value[i] = new(RefreshShare)
+		share.Value[i].Header = drlwe.ShareHeader{Version: share.Header.Version, Protocol: drlwe.ProtocolBFVMaskedTransform, Params: share.Header.Params}
+		if err = share.Value[i].UnmarshalBinary(data[:size]); err != nil {
+			return err
+		}
+
+		data = data[size:]
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package dbfv
 
 import (
+	"math/big"
+
 	"github.com/ldsec/lattigo/v2/bfv"
 	"github.com/ldsec/lattigo/v2/drlwe"
 	"github.com/ldsec/lattigo/v2/ring"
@@ -62,14 +64,53 @@ func NewE2SProtocol(params bfv.Parameters, sigmaSmudging float64) *E2SProtocol {
 	return e2s
 }
 
+// AllocateShareAtLevel allocates the public masked-decryption share of one party in the encryption-to-shares protocol
+// of the ciphertexts at the given level, e.g. after a modulus switching.
+func (e2s *E2SProtocol) AllocateShareAtLevel(level int) *drlwe.CKSShare {
+	return e2s.CKSProtocol.CKSProtocol.AllocateShare(level)
+}
+
 // GenShare generates a party's share in the encryption-to-shares protocol. This share consist in the additive secret-share of the party
 // which is written in secretShareOut and in the public masked-decryption share written in publicShareOut.
 // ct1 is degree 1 element of a bfv.Ciphertext, i.e. bfv.Ciphertext.Value[1].
+// The public share is generated at the minimum level between ct1 and publicShareOut.
 func (e2s *E2SProtocol) GenShare(sk *rlwe.SecretKey, ct1 *ring.Poly, secretShareOut *rlwe.AdditiveShare, publicShareOut *drlwe.CKSShare) {
 	e2s.CKSProtocol.GenShare(sk, e2s.zero, ct1, publicShareOut)
 	e2s.maskSampler.Read(&secretShareOut.Value)
-	e2s.encoder.ScaleUp(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &secretShareOut.Value}}, e2s.tmpPlaintext)
-	e2s.params.RingQ().Sub(publicShareOut.Value, e2s.tmpPlaintext.Value, publicShareOut.Value)
+	level := publicShareOut.Value.Level()
+	e2s.scaleUp(level, &secretShareOut.Value, e2s.tmpPlaintext.Value)
+	e2s.params.RingQ().SubLvl(level, publicShareOut.Value, e2s.tmpPlaintext.Value, publicShareOut.Value)
+}
+
+// scaleUp writes round(Q_level/t * ptRt) on pOut, where Q_level is the product of the moduli up to the given level.
+// Contrary to bfv.Encoder.ScaleUp, which always scales up by the full modulus Q, it applies to the ciphertexts that
+// have been modulus-switched to a lower level.
+func (e2s *E2SProtocol) scaleUp(level int, ptRt, pOut *ring.Poly) {
+
+	if level == e2s.params.MaxLevel() {
+		e2s.encoder.ScaleUp(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: ptRt}}, &bfv.Plaintext{Plaintext: &rlwe.Plaintext{Value: pOut}})
+		return
+	}
+
+	ringQ := e2s.params.RingQ()
+
+	Q := ring.NewUint(1)
+	for _, qi := range ringQ.Modulus[:level+1] {
+		Q.Mul(Q, ring.NewUint(qi))
+	}
+
+	T := ring.NewUint(e2s.params.T())
+	halfT := new(big.Int).Rsh(T, 1)
+
+	coeffs := make([]*big.Int, ringQ.N)
+	for i, c := range ptRt.Coeffs[0] {
+		coeffs[i] = ring.NewUint(c)
+		coeffs[i].Mul(coeffs[i], Q)
+		coeffs[i].Add(coeffs[i], halfT)
+		coeffs[i].Quo(coeffs[i], T)
+	}
+
+	ringQ.SetCoefficientsBigintLvl(level, coeffs, pOut)
 }
 
 // GetShare is the final step of the encryption-to-share protocol. It performs the masked decryption of the target ciphertext followed by a
@@ -77,9 +118,12 @@ func (e2s *E2SProtocol) GenShare(sk *rlwe.SecretKey, ct1 *ring.Poly, secretShare
 // If the caller is not secret-key-share holder (i.e., didn't generate a decryption share), `secretShare` can be set to nil.
 // Therefore, in order to obtain an additive sharing of the message, only one party should call this method, and the other parties should use
 // the secretShareOut output of the GenShare method.
+// The masked decryption is performed at the minimum level between ct and aggregatePublicShare.
 func (e2s *E2SProtocol) GetShare(secretShare *rlwe.AdditiveShare, aggregatePublicShare *drlwe.CKSShare, ct *bfv.Ciphertext, secretShareOut *rlwe.AdditiveShare) {
-	e2s.params.RingQ().Add(aggregatePublicShare.Value, ct.Value[0], e2s.tmpPlaintext.Value)
-	e2s.encoder.ScaleDown(e2s.tmpPlaintext, e2s.tmpPlaintextRingT)
+	level := utils.MinInt(aggregatePublicShare.Value.Level(), ct.Level())
+	pt := &bfv.Plaintext{Plaintext: &rlwe.Plaintext{Value: &ring.Poly{Coeffs: e2s.tmpPlaintext.Value.Coeffs[:level+1]}}}
+	e2s.params.RingQ().AddLvl(level, aggregatePublicShare.Value, ct.Value[0], pt.Value)
+	e2s.encoder.ScaleDown(pt, e2s.tmpPlaintextRingT)
 	if secretShare != nil {
 		e2s.params.RingT().Add(&secretShare.Value, e2s.tmpPlaintextRingT.Value, &secretShareOut.Value)
 	} else {
@@ -0,0 +1,92 @@
+package dbfv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// SPDZShare is the additive share of a party of a vector of values modulo a prime, in the format of the SPDZ-style MPC
+// frameworks over a prime field: the shares of all the parties sum to the shared values modulo Modulus. It enables the
+// parties to use the encryption-to-shares and shares-to-encryption protocols as the input and output layer of such a
+// framework, with Modulus = t, the plaintext modulus of the BFV parameters, as the prime of the field.
+//
+// The shares do not carry the MAC shares of the SPDZ protocols, which must be generated by the framework, e.g. by
+// inputting them as private inputs. The conversion of the shares modulo t to shares modulo 2^k, as expected by the
+// SPDZ2k frameworks, cannot be done locally and requires an interactive protocol, e.g. in the framework.
+type SPDZShare struct {
+	Modulus uint64
+	Values  []uint64
+}
+
+// ExportSPDZShare returns the SPDZShare of the values of the plaintext slots shared by share, e.g. the secret share
+// output by the E2SProtocol. Since the decoding is linear, the exported shares of all the parties sum to the values of
+// the slots of the shared plaintext modulo t.
+func ExportSPDZShare(params bfv.Parameters, encoder bfv.Encoder, share *rlwe.AdditiveShare) *SPDZShare {
+	return &SPDZShare{
+		Modulus: params.T(),
+		Values:  encoder.DecodeUintNew(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &share.Value}}),
+	}
+}
+
+// ImportSPDZShare encodes the SPDZShare of the values of the plaintext slots on shareOut, e.g. to be input to the
+// S2EProtocol. The slots without a value are set to zero. It returns an error if the modulus of the share is not the
+// plaintext modulus t, if the share has more values than slots or if a value is not reduced modulo t.
+func ImportSPDZShare(params bfv.Parameters, encoder bfv.Encoder, share *SPDZShare, shareOut *rlwe.AdditiveShare) error {
+
+	if share.Modulus != params.T() {
+		return fmt.Errorf("cannot ImportSPDZShare: the modulus %d is not the plaintext modulus %d", share.Modulus, params.T())
+	}
+
+	if len(share.Values) > params.N() {
+		return fmt.Errorf("cannot ImportSPDZShare: %d values but only %d slots", len(share.Values), params.N())
+	}
+
+	for _, v := range share.Values {
+		if v >= share.Modulus {
+			return fmt.Errorf("cannot ImportSPDZShare: the value %d is not reduced modulo %d", v, share.Modulus)
+		}
+	}
+
+	shareOut.Value.Zero()
+	encoder.EncodeUintRingT(share.Values, &bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &shareOut.Value}})
+
+	return nil
+}
+
+// MarshalBinary encodes an SPDZShare on a slice of bytes: the modulus, the number of values and the values, each on 8
+// bytes in little-endian order.
+func (share *SPDZShare) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 16+8*len(share.Values))
+	binary.LittleEndian.PutUint64(data, share.Modulus)
+	binary.LittleEndian.PutUint64(data[8:], uint64(len(share.Values)))
+	for i, v := range share.Values {
+		binary.LittleEndian.PutUint64(data[16+8*i:], v)
+	}
+	return
+}
+
+// UnmarshalBinary decodes a marshaled SPDZShare on the target SPDZShare.
+func (share *SPDZShare) UnmarshalBinary(data []byte) error {
+
+	if len(data) < 16 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	n := binary.LittleEndian.Uint64(data[8:])
+
+	if n != uint64(len(data)-16)/8 || len(data)%8 != 0 {
+		return errors.New("cannot UnmarshalBinary: invalid number of values")
+	}
+
+	share.Modulus = binary.LittleEndian.Uint64(data)
+	share.Values = make([]uint64, n)
+	for i := range share.Values {
+		share.Values[i] = binary.LittleEndian.Uint64(data[16+8*i:])
+	}
+
+	return nil
+}
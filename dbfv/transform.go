@@ -1,6 +1,10 @@
 package dbfv
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
 	"github.com/ldsec/lattigo/v2/bfv"
 	"github.com/ldsec/lattigo/v2/drlwe"
 	"github.com/ldsec/lattigo/v2/ring"
@@ -16,6 +20,8 @@ type MaskedTransformProtocol struct {
 	tmpPt       bfv.Plaintext
 	tmpMask     *ring.Poly
 	tmpMaskPerm *ring.Poly
+
+	header drlwe.ShareHeader
 }
 
 // ShallowCopy creates a shallow copy of MaskedTransformProtocol in which all the read-only data-structures are
@@ -30,40 +36,111 @@ func (rfp *MaskedTransformProtocol) ShallowCopy() *MaskedTransformProtocol {
 		tmpPt:       *bfv.NewPlaintext(params),
 		tmpMask:     params.RingT().NewPoly(),
 		tmpMaskPerm: params.RingT().NewPoly(),
+		header:      rfp.header,
 	}
 }
 
 // MaskedTransformFunc represents a user-defined in-place function that can be applied to masked BFV plaintexts, as a part of the
 // Masked Transform Protocol.
 // The function is called with a vector of integers modulo bfv.Parameters.T() of size bfv.Parameters.N() as input, and must write
-// its output on the same buffer.
+// its output on the same buffer. Since it is applied to the masks of the parties and to the masked plaintext separately,
+// the function must be linear over the integers modulo bfv.Parameters.T(), e.g. the function of a LinearTransform.
 type MaskedTransformFunc func(coeffs []uint64)
 
+// LinearTransform is a public linear map over the plaintext slots, given by its non-zero entries: the i-th slot of the
+// output is the sum of LinearTransform[i][j] * m_j mod T over the entries of the i-th row, where m_j is the j-th slot of
+// the input, and the slots of the output without entries are zero. For example, a permutation has a single entry of
+// value 1 per row, and an oblivious shuffle-with-mixing or a re-encoding of the slots has several entries per row.
+type LinearTransform map[int]map[int]uint64
+
+// linearTerm is an entry of a row of a LinearTransform.
+type linearTerm struct {
+	slot  int
+	value uint64
+}
+
+// MaskedTransformFunc returns the MaskedTransformFunc applying the LinearTransform to the plaintext slots, to be given to
+// the GenShare and Transform methods of the MaskedTransformProtocol. It returns an error if an index of the
+// LinearTransform is not a slot of the parameters.
+func (lt LinearTransform) MaskedTransformFunc(params bfv.Parameters) (MaskedTransformFunc, error) {
+
+	ringT := params.RingT()
+	T, bredParams := ringT.Modulus[0], ringT.BredParams[0]
+
+	rows := make([][]linearTerm, params.N())
+	for i, row := range lt {
+		if i < 0 || i >= params.N() {
+			return nil, fmt.Errorf("cannot MaskedTransformFunc: invalid output slot %d", i)
+		}
+		for j, v := range row {
+			if j < 0 || j >= params.N() {
+				return nil, fmt.Errorf("cannot MaskedTransformFunc: invalid input slot %d", j)
+			}
+			if v %= T; v != 0 {
+				rows[i] = append(rows[i], linearTerm{slot: j, value: v})
+			}
+		}
+	}
+
+	return func(coeffs []uint64) {
+		out := make([]uint64, len(coeffs))
+		for i, row := range rows {
+			for _, term := range row {
+				out[i] = ring.CRed(out[i]+ring.BRed(term.value, coeffs[term.slot], T, bredParams), T)
+			}
+		}
+		copy(coeffs, out)
+	}, nil
+}
+
 // MaskedTransformShare is a struct storing the decryption and recryption shares.
 type MaskedTransformShare struct {
+	Header   drlwe.ShareHeader
 	e2sShare drlwe.CKSShare
 	s2eShare drlwe.CKSShare
 }
 
 // MarshalBinary encodes a RefreshShare on a slice of bytes.
-func (share *MaskedTransformShare) MarshalBinary() ([]byte, error) {
-	e2sData, err := share.e2sShare.MarshalBinary()
-	if err != nil {
+func (share *MaskedTransformShare) MarshalBinary() (data []byte, err error) {
+	var e2sData, s2eData []byte
+	if e2sData, err = share.e2sShare.MarshalBinary(); err != nil {
 		return nil, err
 	}
-	s2eData, err := share.s2eShare.MarshalBinary()
-	if err != nil {
+	if s2eData, err = share.s2eShare.MarshalBinary(); err != nil {
 		return nil, err
 	}
-	return append(e2sData, s2eData...), nil
+	data = make([]byte, drlwe.ShareHeaderLen+8)
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint64(data[drlwe.ShareHeaderLen:], uint64(len(e2sData)))
+	data = append(data, e2sData...)
+	return append(data, s2eData...), nil
 }
 
 // UnmarshalBinary decodes a marshaled RefreshShare on the target RefreshShare.
 func (share *MaskedTransformShare) UnmarshalBinary(data []byte) error {
-	shareLen := len(data) >> 1
-	share.e2sShare.UnmarshalBinary(data[:shareLen])
-	share.s2eShare.UnmarshalBinary(data[shareLen:])
-	return nil
+
+	ptr, err := share.Header.Decode(data)
+	if err != nil {
+		return err
+	}
+	data = data[ptr:]
+
+	if len(data) < 8 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	e2sDataLen := binary.LittleEndian.Uint64(data[:8])
+
+	if e2sDataLen > uint64(len(data)-8) {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	if err := share.e2sShare.UnmarshalBinary(data[8 : e2sDataLen+8]); err != nil {
+		return err
+	}
+	return share.s2eShare.UnmarshalBinary(data[8+e2sDataLen:])
 }
 
 // NewMaskedTransformProtocol creates a new instance of the PermuteProtocol.
@@ -76,6 +153,7 @@ func NewMaskedTransformProtocol(params bfv.Parameters, sigmaSmudging float64) (r
 	rfp.tmpPt = *bfv.NewPlaintext(params)
 	rfp.tmpMask = params.RingT().NewPoly()
 	rfp.tmpMaskPerm = params.RingT().NewPoly()
+	rfp.header = drlwe.NewShareHeader(drlwe.ProtocolBFVMaskedTransform, params)
 	return
 }
 
@@ -87,11 +165,27 @@ func (rfp *MaskedTransformProtocol) SampleCRP(level int, crs utils.PRNG) drlwe.C
 
 // AllocateShare allocates the shares of the PermuteProtocol.
 func (rfp *MaskedTransformProtocol) AllocateShare() *MaskedTransformShare {
-	return &MaskedTransformShare{*rfp.e2s.AllocateShare(), *rfp.s2e.AllocateShare()}
+	return &MaskedTransformShare{
+		Header:   rfp.header,
+		e2sShare: *rfp.e2s.AllocateShare(),
+		s2eShare: *rfp.s2e.AllocateShare(),
+	}
+}
+
+// AllocateShareAtLevel allocates the shares of the PermuteProtocol for the ciphertexts at the given level, e.g. after
+// a modulus switching: the masked-decryption share is at the given level, and the size and cost of its generation
+// are reduced accordingly, while the recryption share remains at the maximum level.
+func (rfp *MaskedTransformProtocol) AllocateShareAtLevel(level int) *MaskedTransformShare {
+	return &MaskedTransformShare{
+		Header:   rfp.header,
+		e2sShare: *rfp.e2s.AllocateShareAtLevel(level),
+		s2eShare: *rfp.s2e.AllocateShare(),
+	}
 }
 
 // GenShare generates the shares of the PermuteProtocol.
 // ct1 is the degree 1 element of a bfv.Ciphertext, i.e. bfv.Ciphertext.Value[1].
+// The masked-decryption share is generated at the minimum level between ct1 and shareOut.
 func (rfp *MaskedTransformProtocol) GenShare(sk *rlwe.SecretKey, c1 *ring.Poly, crs drlwe.CKSCRP, transform MaskedTransformFunc, shareOut *MaskedTransformShare) {
 	rfp.e2s.GenShare(sk, c1, &rlwe.AdditiveShare{Value: *rfp.tmpMask}, &shareOut.e2sShare)
 	mask := rfp.tmpMask
@@ -111,6 +205,7 @@ func (rfp *MaskedTransformProtocol) Aggregate(share1, share2, shareOut *MaskedTr
 }
 
 // Transform applies Decrypt, Recode and Recrypt on the input ciphertext.
+// The input ciphertext can be at any level, and the output ciphertext must be at the maximum level.
 func (rfp *MaskedTransformProtocol) Transform(ciphertext *bfv.Ciphertext, transform MaskedTransformFunc, crs drlwe.CKSCRP, share *MaskedTransformShare, ciphertextOut *bfv.Ciphertext) {
 	rfp.e2s.GetShare(nil, &share.e2sShare, ciphertext, &rlwe.AdditiveShare{Value: *rfp.tmpMask}) // tmpMask RingT(m - sum M_i)
 	mask := rfp.tmpMask
@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/ldsec/lattigo/v2/bfv"
 	"github.com/ldsec/lattigo/v2/ckks"
 	"github.com/ldsec/lattigo/v2/drlwe"
 	"github.com/ldsec/lattigo/v2/ring"
@@ -101,13 +102,19 @@ func TestDCKKS(t *testing.T) {
 		for _, testSet := range []func(tc *testContext, t *testing.T){
 			testPublicKeyGen,
 			testRelinKeyGen,
+			testInteractiveRelin,
 			testKeyswitching,
 			testPublicKeySwitching,
 			testRotKeyGenConjugate,
 			testRotKeyGenCols,
+			testRotKeyGenBatch,
+			testSparseKeyGen,
 			testE2SProtocol,
 			testRefresh,
 			testRefreshAndTransform,
+			testRefreshAndMatrixTransform,
+			testSchemeSwitching,
+			testMigration,
 			testMarshalling,
 		} {
 			testSet(tc, t)
@@ -280,6 +287,61 @@ func testRelinKeyGen(testCtx *testContext, t *testing.T) {
 
 }
 
+func testInteractiveRelin(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk0 := testCtx.decryptorSk0
+	sk0Shards := testCtx.sk0Shards
+	params := testCtx.params
+
+	t.Run(testString("InteractiveRelin", parties, params), func(t *testing.T) {
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, -1, 1, t)
+
+		for i := range coeffs {
+			coeffs[i] *= coeffs[i]
+		}
+
+		ciphertext = testCtx.evaluator.MulNew(ciphertext, ciphertext)
+		require.Equal(t, 2, ciphertext.Degree())
+
+		type Party struct {
+			*InteractiveRelinProtocol
+			sk    *rlwe.SecretKey
+			share *drlwe.DegreeReductionShare
+		}
+
+		relinParties := make([]*Party, parties)
+		for i := range relinParties {
+			p := new(Party)
+			if i == 0 {
+				p.InteractiveRelinProtocol = NewInteractiveRelinProtocol(params)
+			} else {
+				p.InteractiveRelinProtocol = relinParties[0].ShallowCopy()
+			}
+			p.sk = sk0Shards[i]
+			p.share = p.AllocateShare(ciphertext.Level())
+			relinParties[i] = p
+		}
+
+		P0 := relinParties[0]
+
+		for i, p := range relinParties {
+			p.GenShare(p.sk, ciphertext, p.share)
+			if i > 0 {
+				P0.AggregateShare(p.share, P0.share, P0.share)
+			}
+		}
+
+		P0.Relinearize(ciphertext, P0.share, ciphertext)
+		require.Equal(t, 1, ciphertext.Degree())
+
+		testCtx.evaluator.Rescale(ciphertext, params.DefaultScale(), ciphertext)
+
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
+	})
+}
+
 func testKeyswitching(testCtx *testContext, t *testing.T) {
 
 	encryptorPk0 := testCtx.encryptorPk0
@@ -514,6 +576,118 @@ func testRotKeyGenCols(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testRotKeyGenBatch(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk0 := testCtx.decryptorSk0
+	sk0Shards := testCtx.sk0Shards
+	params := testCtx.params
+
+	t.Run(testString("RotKeyGenBatch", parties, params), func(t *testing.T) {
+
+		galEls := params.GaloisElementsForRowInnerSum()
+
+		type Party struct {
+			*RTGBatchProtocol
+			s     *rlwe.SecretKey
+			share *drlwe.RTGBatchShare
+		}
+
+		rtgParties := make([]*Party, parties)
+		for i := 0; i < parties; i++ {
+			p := new(Party)
+			p.RTGBatchProtocol = NewRotKGBatchProtocol(params, galEls, 0)
+			p.s = sk0Shards[i]
+			p.share = p.AllocateShare()
+			rtgParties[i] = p
+		}
+
+		P0 := rtgParties[0]
+
+		crp := P0.SampleCRP(testCtx.crs)
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, -1, 1, t)
+
+		receiver := ckks.NewCiphertext(params, ciphertext.Degree(), ciphertext.Level(), ciphertext.Scale)
+
+		rotKeySet := ckks.NewRotationKeySet(params, galEls)
+
+		for i, p := range rtgParties {
+			p.GenShare(p.s, crp, p.share)
+			if i > 0 {
+				P0.AggregateShare(p.share, P0.share, P0.share)
+			}
+		}
+
+		P0.GenRotationKeys(P0.share, crp, rotKeySet)
+
+		evaluator := testCtx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: nil, Rtks: rotKeySet})
+
+		for k := 1; k < params.Slots(); k <<= 1 {
+			evaluator.Rotate(ciphertext, int(k), receiver)
+
+			coeffsWant := utils.RotateComplex128Slice(coeffs, int(k))
+
+			verifyTestVectors(testCtx, decryptorSk0, coeffsWant, receiver, t)
+		}
+	})
+}
+
+func testSparseKeyGen(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	decryptorSk0 := testCtx.decryptorSk0
+	sk0Shards := testCtx.sk0Shards
+	params := testCtx.params
+
+	t.Run(testString("SparseKeyGen", parties, params), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		ringQP, levelQ, levelP := params.RingQP(), params.QCount()-1, params.PCount()-1
+
+		skSparseShards := make([]*rlwe.SecretKey, parties)
+		skSparse := ckks.NewSecretKey(params)
+		for i := range skSparseShards {
+			skSparseShards[i] = GenSparseSecretKeyShare(params, 64, parties)
+			ringQP.AddLvl(levelQ, levelP, skSparse.Value, skSparseShards[i].Value, skSparse.Value)
+		}
+
+		skg := NewSKGProtocol(params)
+		crpDtS, crpStD := skg.SampleCRP(testCtx.crs), skg.SampleCRP(testCtx.crs)
+		shareDtS, shareStD := skg.AllocateShare(), skg.AllocateShare()
+		share := skg.AllocateShare()
+
+		for i := range sk0Shards {
+			p := skg.ShallowCopy()
+			p.GenShareDenseToSparse(sk0Shards[i], skSparseShards[i], crpDtS, share)
+			p.AggregateShare(shareDtS, share, shareDtS)
+			p.GenShareSparseToDense(skSparseShards[i], sk0Shards[i], crpStD, share)
+			p.AggregateShare(shareStD, share, shareStD)
+		}
+
+		data, err := shareDtS.MarshalBinary()
+		require.NoError(t, err)
+		shareDtS = new(drlwe.SKGShare)
+		require.NoError(t, shareDtS.UnmarshalBinary(data))
+
+		swkDtS, swkStD := ckks.NewSwitchingKey(params), ckks.NewSwitchingKey(params)
+		skg.GenSwitchingKey(shareDtS, crpDtS, swkDtS)
+		skg.GenSwitchingKey(shareStD, crpStD, swkStD)
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, -1, 1, t)
+
+		// The ciphertext is switched to the sparse collective key and back to the dense collective key
+		testCtx.evaluator.SwitchKeys(ciphertext, swkDtS, ciphertext)
+		verifyTestVectors(testCtx, ckks.NewDecryptor(params, skSparse), coeffs, ciphertext, t)
+
+		testCtx.evaluator.SwitchKeys(ciphertext, swkStD, ciphertext)
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
+	})
+}
+
 func testE2SProtocol(testCtx *testContext, t *testing.T) {
 
 	params := testCtx.params
@@ -657,6 +831,65 @@ func testRefresh(testCtx *testContext, t *testing.T) {
 
 		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
 	})
+
+	t.Run(testString("RefreshAtLevelAndScale", parties, params), func(t *testing.T) {
+
+		var minLevel, logBound int
+		var ok bool
+		if minLevel, logBound, ok = GetMinimumLevelForBootstrapping(128, params.DefaultScale(), parties, params.Q()); ok != true || minLevel+2 > params.MaxLevel() {
+			t.Skip("Not enough levels to ensure correcness and 128 security")
+		}
+
+		type Party struct {
+			*RefreshProtocol
+			s     *rlwe.SecretKey
+			share *RefreshShare
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, -1, 1, t)
+
+		// Brings ciphertext to minLevel + 1
+		testCtx.evaluator.DropLevel(ciphertext, ciphertext.Level()-minLevel-1)
+
+		levelIn := minLevel
+		levelOut := params.MaxLevel() - 1
+		outputScale := params.DefaultScale() / 4
+
+		RefreshParties := make([]*Party, parties)
+		for i := 0; i < parties; i++ {
+			p := new(Party)
+			if i == 0 {
+				p.RefreshProtocol = NewRefreshProtocol(params, logBound, 3.2)
+			} else {
+				p.RefreshProtocol = RefreshParties[0].RefreshProtocol.ShallowCopy()
+			}
+
+			p.s = sk0Shards[i]
+			p.share = p.AllocateShare(levelIn, levelOut)
+			RefreshParties[i] = p
+		}
+
+		P0 := RefreshParties[0]
+
+		crp := P0.SampleCRP(levelOut, testCtx.crs)
+
+		for i, p := range RefreshParties {
+
+			p.GenShareAtScale(p.s, logBound, params.LogSlots(), ciphertext.Value[1], ciphertext.Scale, outputScale, crp, p.share)
+
+			if i > 0 {
+				P0.AggregateShare(p.share, P0.share, P0.share)
+			}
+		}
+
+		ctOut := ckks.NewCiphertext(params, 1, params.MaxLevel(), params.DefaultScale())
+		P0.FinalizeAtScale(ciphertext, params.LogSlots(), crp, P0.share, outputScale, ctOut)
+
+		require.Equal(t, levelOut, ctOut.Level())
+		require.Equal(t, outputScale, ctOut.Scale)
+
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ctOut, t)
+	})
 }
 
 func testRefreshAndTransform(testCtx *testContext, t *testing.T) {
@@ -729,6 +962,315 @@ func testRefreshAndTransform(testCtx *testContext, t *testing.T) {
 
 		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
 	})
+
+	t.Run(testString("RefreshAndTransform/ComplexAtInputScale", parties, params), func(t *testing.T) {
+
+		if params.RingType() != ring.Standard {
+			t.Skip("the slots are real")
+		}
+
+		var minLevel, logBound int
+		var ok bool
+		if minLevel, logBound, ok = GetMinimumLevelForBootstrapping(128, params.DefaultScale(), parties, params.Q()); ok != true || minLevel+1 > params.MaxLevel() {
+			t.Skip("Not enough levels to ensure correcness and 128 security")
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, complex(-1, -1), complex(1, 1), t)
+
+		// Drops the ciphertext to the minimum level that ensures correctness and 128-bit security
+		testCtx.evaluator.DropLevel(ciphertext, ciphertext.Level()-minLevel-1)
+
+		// Doubles the scale of the ciphertext without changing its values, so that the masks of both the real and the
+		// imaginary parts must be rescaled to the default scale
+		testCtx.evaluator.MultByGaussianInteger(ciphertext, int64(2), int64(0), ciphertext)
+		ciphertext.Scale *= 2
+
+		levelIn := minLevel
+		levelOut := params.MaxLevel()
+
+		rfp := make([]*MaskedTransformProtocol, parties)
+		shares := make([]*MaskedTransformShare, parties)
+		for i := range rfp {
+			if i == 0 {
+				rfp[i] = NewMaskedTransformProtocol(params, logBound, 3.2)
+			} else {
+				rfp[i] = rfp[0].ShallowCopy()
+			}
+			shares[i] = rfp[i].AllocateShare(levelIn, levelOut)
+		}
+
+		crp := rfp[0].SampleCRP(levelOut, testCtx.crs)
+
+		for i := range rfp {
+			rfp[i].GenShare(sk0Shards[i], logBound, params.LogSlots(), ciphertext.Value[1], ciphertext.Scale, crp, nil, shares[i])
+			if i > 0 {
+				rfp[0].AggregateShare(shares[i], shares[0], shares[0])
+			}
+		}
+
+		rfp[0].Transform(ciphertext, params.LogSlots(), nil, crp, shares[0], ciphertext)
+
+		require.Equal(t, params.DefaultScale(), ciphertext.Scale)
+
+		verifyTestVectors(testCtx, decryptorSk0, coeffs, ciphertext, t)
+	})
+}
+
+func testRefreshAndMatrixTransform(testCtx *testContext, t *testing.T) {
+
+	encryptorPk0 := testCtx.encryptorPk0
+	sk0Shards := testCtx.sk0Shards
+	params := testCtx.params
+	decryptorSk0 := testCtx.decryptorSk0
+
+	t.Run(testString("RefreshAndMatrixTransform", parties, params), func(t *testing.T) {
+
+		var minLevel, logBound int
+		var ok bool
+		if minLevel, logBound, ok = GetMinimumLevelForBootstrapping(128, params.DefaultScale(), parties, params.Q()); ok != true || minLevel+1 > params.MaxLevel() {
+			t.Skip("Not enough levels to ensure correcness and 128 security")
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, encryptorPk0, -1, 1, t)
+
+		// Drops the ciphertext to the minimum level that ensures correctness and 128-bit security
+		testCtx.evaluator.DropLevel(ciphertext, ciphertext.Level()-minLevel-1)
+
+		levelIn := minLevel
+		levelOut := params.MaxLevel()
+
+		rfp := make([]*MaskedTransformProtocol, parties)
+		shares := make([]*MaskedTransformShare, parties)
+		for i := range rfp {
+			if i == 0 {
+				rfp[i] = NewMaskedTransformProtocol(params, logBound, 3.2)
+			} else {
+				rfp[i] = rfp[0].ShallowCopy()
+			}
+			shares[i] = rfp[i].AllocateShare(levelIn, levelOut)
+		}
+
+		crp := rfp[0].SampleCRP(levelOut, testCtx.crs)
+
+		// A tridiagonal matrix, with complex entries if the slots are complex
+		slots := params.Slots()
+		matrix := map[int][]complex128{0: make([]complex128, slots), 1: make([]complex128, slots), -1: make([]complex128, slots)}
+		for _, diag := range matrix {
+			for i := range diag {
+				diag[i] = complex(utils.RandFloat64(-0.5, 0.5), 0)
+				if params.RingType() == ring.Standard {
+					diag[i] += complex(0, utils.RandFloat64(-0.5, 0.5))
+				}
+			}
+		}
+
+		transform, err := rfp[0].MatrixTransform(matrix, params.LogSlots())
+		require.NoError(t, err)
+
+		_, err = rfp[0].MatrixTransform(map[int][]complex128{0: make([]complex128, slots-1)}, params.LogSlots())
+		require.Error(t, err)
+		_, err = rfp[0].MatrixTransform(map[int][]complex128{1: make([]complex128, slots), 1 - slots: make([]complex128, slots)}, params.LogSlots())
+		require.Error(t, err)
+
+		for i := range rfp {
+			rfp[i].GenShare(sk0Shards[i], logBound, params.LogSlots(), ciphertext.Value[1], ciphertext.Scale, crp, transform, shares[i])
+			if i > 0 {
+				rfp[0].AggregateShare(shares[i], shares[0], shares[0])
+			}
+		}
+
+		rfp[0].Transform(ciphertext, params.LogSlots(), transform, crp, shares[0], ciphertext)
+
+		want := make([]complex128, slots)
+		for i := range want {
+			want[i] = matrix[0][i]*coeffs[i] + matrix[1][i]*coeffs[(i+1)%slots] + matrix[-1][i]*coeffs[(i-1+slots)%slots]
+		}
+
+		verifyTestVectors(testCtx, decryptorSk0, want, ciphertext, t)
+	})
+}
+
+func testSchemeSwitching(testCtx *testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	t.Run(testString("SchemeSwitching", parties, params), func(t *testing.T) {
+
+		if params.RingType() != ring.Standard {
+			t.Skip("scheme switching requires the standard ring")
+		}
+
+		paramsBFV, err := bfv.NewParameters(params.Parameters, ring.GenerateNTTPrimes(25, 2*params.N(), 1)[0])
+		require.NoError(t, err)
+
+		logBound := 16
+		logSlots := params.LogSlots()
+		slots := 1 << logSlots
+
+		type Party struct {
+			*SchemeSwitchingProtocol
+			sk    *rlwe.SecretKey
+			share *SchemeSwitchingShare
+		}
+
+		ssParties := make([]*Party, parties)
+		for i := range ssParties {
+			p := new(Party)
+			if i == 0 {
+				p.SchemeSwitchingProtocol, err = NewSchemeSwitchingProtocol(paramsBFV, params, 256, 3.2)
+				require.NoError(t, err)
+			} else {
+				p.SchemeSwitchingProtocol = ssParties[0].ShallowCopy()
+			}
+			p.sk = testCtx.sk0Shards[i]
+			ssParties[i] = p
+		}
+
+		P0 := ssParties[0]
+
+		message := make([]int64, paramsBFV.N())
+		for i := range message {
+			message[i] = int64(utils.RandUint64()%512) - 256
+		}
+
+		t.Run("BFVToCKKS", func(t *testing.T) {
+
+			pt := bfv.NewPlaintext(paramsBFV)
+			bfv.NewEncoder(paramsBFV).EncodeInt(message, pt)
+			ctBFV := bfv.NewEncryptor(paramsBFV, testCtx.pk0).EncryptNew(pt)
+
+			crp := P0.SampleCRP(params.MaxLevel(), testCtx.crs)
+
+			for i, p := range ssParties {
+				p.share = p.AllocateShare(paramsBFV.MaxLevel(), params.MaxLevel())
+				p.GenShareBFVToCKKS(p.sk, logBound, logSlots, ctBFV.Value[1], crp, p.share)
+				if i > 0 {
+					P0.AggregateShare(p.share, P0.share, P0.share)
+				}
+			}
+
+			ctCKKS := ckks.NewCiphertext(params, 1, params.MaxLevel(), params.DefaultScale())
+			P0.SwitchToCKKS(ctBFV, logSlots, crp, P0.share, ctCKKS)
+
+			values := make([]complex128, slots)
+			for i := range values {
+				values[i] = complex(float64(message[i]), 0)
+			}
+
+			verifyTestVectors(testCtx, testCtx.decryptorSk0, values, ctCKKS, t)
+		})
+
+		t.Run("CKKSToBFV", func(t *testing.T) {
+
+			values := make([]complex128, slots)
+			for i := range values {
+				values[i] = complex(float64(message[i]), 0)
+			}
+
+			ctCKKS := testCtx.encryptorPk0.EncryptNew(testCtx.encoder.EncodeNew(values, params.MaxLevel(), params.DefaultScale(), logSlots))
+
+			crp := P0.SampleCRP(paramsBFV.MaxLevel(), testCtx.crs)
+
+			for i, p := range ssParties {
+				p.share = p.AllocateShare(ctCKKS.Level(), paramsBFV.MaxLevel())
+				p.GenShareCKKSToBFV(p.sk, logBound, logSlots, ctCKKS.Value[1], ctCKKS.Scale, crp, p.share)
+				if i > 0 {
+					P0.AggregateShare(p.share, P0.share, P0.share)
+				}
+			}
+
+			ctBFV := bfv.NewCiphertext(paramsBFV, 1)
+			P0.SwitchToBFV(ctCKKS, logSlots, crp, P0.share, ctBFV)
+
+			have := bfv.NewEncoder(paramsBFV).DecodeIntNew(bfv.NewDecryptor(paramsBFV, testCtx.sk0).DecryptNew(ctBFV))
+
+			want := make([]int64, paramsBFV.N())
+			copy(want, message[:slots])
+
+			require.Equal(t, want, have)
+		})
+	})
+}
+
+func testMigration(testCtx *testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	t.Run(testString("Migration", parties, params), func(t *testing.T) {
+
+		if params.RingType() != ring.Standard {
+			t.Skip("migration requires the standard ring")
+		}
+
+		// The output parameters have a larger ring degree and other moduli
+		paramsOut, err := ckks.NewParametersFromLiteral(ckks.ParametersLiteral{
+			LogN:         params.LogN() + 1,
+			LogSlots:     params.LogSlots(),
+			LogQ:         []int{55, 45, 45},
+			LogP:         []int{56},
+			Sigma:        rlwe.DefaultSigma,
+			DefaultScale: 1 << 45,
+		})
+		require.NoError(t, err)
+
+		kgenOut := ckks.NewKeyGenerator(paramsOut)
+		skOut := ckks.NewSecretKey(paramsOut)
+		skOutShards := make([]*rlwe.SecretKey, parties)
+		for i := range skOutShards {
+			skOutShards[i] = kgenOut.GenSecretKey()
+			paramsOut.RingQP().AddLvl(paramsOut.QCount()-1, paramsOut.PCount()-1, skOut.Value, skOutShards[i].Value, skOut.Value)
+		}
+
+		logBound := 32
+		logSlots := params.LogSlots()
+
+		type Party struct {
+			*MigrationProtocol
+			skIn  *rlwe.SecretKey
+			skOut *rlwe.SecretKey
+			share *drlwe.MigrationShare
+		}
+
+		migrationParties := make([]*Party, parties)
+		for i := range migrationParties {
+			p := new(Party)
+			if i == 0 {
+				p.MigrationProtocol, err = NewMigrationProtocol(params, paramsOut, 256, 3.2)
+				require.NoError(t, err)
+			} else {
+				p.MigrationProtocol = migrationParties[0].ShallowCopy()
+			}
+			p.skIn = testCtx.sk0Shards[i]
+			p.skOut = skOutShards[i]
+			migrationParties[i] = p
+		}
+
+		P0 := migrationParties[0]
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, testCtx.encryptorPk0, -1, 1, t)
+
+		crp := P0.SampleCRP(paramsOut.MaxLevel(), testCtx.crs)
+
+		for i, p := range migrationParties {
+			p.share = p.AllocateShare(ciphertext.Level(), paramsOut.MaxLevel())
+			p.GenShare(p.skIn, p.skOut, logBound, logSlots, ciphertext.Value[1], ciphertext.Scale, crp, p.share)
+			if i > 0 {
+				P0.AggregateShare(p.share, P0.share, P0.share)
+			}
+		}
+
+		ctOut := ckks.NewCiphertext(paramsOut, 1, paramsOut.MaxLevel(), paramsOut.DefaultScale())
+		P0.Migrate(ciphertext, logSlots, crp, P0.share, ctOut)
+
+		precStats := ckks.GetPrecisionStats(paramsOut, ckks.NewEncoder(paramsOut), ckks.NewDecryptor(paramsOut, skOut), coeffs, ctOut, logSlots, 0)
+
+		if *printPrecisionStats {
+			t.Log(precStats.String())
+		}
+
+		require.GreaterOrEqual(t, precStats.MeanPrecision.Real, minPrec)
+		require.GreaterOrEqual(t, precStats.MeanPrecision.Imag, minPrec)
+	})
 }
 
 func testMarshalling(testCtx *testContext, t *testing.T) {
@@ -779,6 +1321,11 @@ func testMarshalling(testCtx *testContext, t *testing.T) {
 			}
 
 		}
+
+		require.Equal(t, refreshshare.Header, resRefreshShare.Header)
+		require.ErrorIs(t, NewCKSProtocol(params, 3.2).AllocateShare(params.MaxLevel()).UnmarshalBinary(data), drlwe.ErrIncompatibleShare)
+		data[0] = drlwe.ShareFormatVersion + 1
+		require.ErrorIs(t, new(MaskedTransformShare).UnmarshalBinary(data), drlwe.ErrIncompatibleShare)
 	})
 }
 
@@ -4,6 +4,7 @@ package dckks
 import (
 	"github.com/ldsec/lattigo/v2/ckks"
 	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
 )
 
 // CKGProtocol is the structure storing the parameters and state for a party in the collective key generation protocol.
@@ -11,9 +12,10 @@ type CKGProtocol struct {
 	drlwe.CKGProtocol
 }
 
-// NewCKGProtocol creates a new CKGProtocol instance
-func NewCKGProtocol(params ckks.Parameters) *CKGProtocol {
-	return &CKGProtocol{*drlwe.NewCKGProtocol(params.Parameters)}
+// NewCKGProtocol creates a new CKGProtocol instance. The randomness of the shares can be seeded with the
+// drlwe.WithSeed option.
+func NewCKGProtocol(params ckks.Parameters, options ...drlwe.ProtocolOption) *CKGProtocol {
+	return &CKGProtocol{*drlwe.NewCKGProtocol(params.Parameters, options...)}
 }
 
 // ShallowCopy creates a shallow copy of CKGProtocol in which all the read-only data-structures are
@@ -30,9 +32,10 @@ type RKGProtocol struct {
 }
 
 // NewRKGProtocol creates a new RKGProtocol object that will be used to generate a collective evaluation-key
-// among j parties in the given context with the given bit-decomposition.
-func NewRKGProtocol(params ckks.Parameters) *RKGProtocol {
-	return &RKGProtocol{*drlwe.NewRKGProtocol(params.Parameters)}
+// among j parties in the given context with the given bit-decomposition. The number of goroutines of the generation and
+// aggregation of the shares can be set with the drlwe.WithGoroutines option.
+func NewRKGProtocol(params ckks.Parameters, options ...drlwe.ProtocolOption) *RKGProtocol {
+	return &RKGProtocol{*drlwe.NewRKGProtocol(params.Parameters, options...)}
 }
 
 // ShallowCopy creates a shallow copy of RKGProtocol in which all the read-only data-structures are
@@ -48,8 +51,9 @@ type RTGProtocol struct {
 }
 
 // NewRotKGProtocol creates a new rotkg object and will be used to generate collective rotation-keys from a shared secret-key among j parties.
-func NewRotKGProtocol(params ckks.Parameters) (rtg *RTGProtocol) {
-	return &RTGProtocol{*drlwe.NewRTGProtocol(params.Parameters)}
+// The number of goroutines of the generation and aggregation of the shares can be set with the drlwe.WithGoroutines option.
+func NewRotKGProtocol(params ckks.Parameters, options ...drlwe.ProtocolOption) (rtg *RTGProtocol) {
+	return &RTGProtocol{*drlwe.NewRTGProtocol(params.Parameters, options...)}
 }
 
 // ShallowCopy creates a shallow copy of RTGProtocol in which all the read-only data-structures are
@@ -58,3 +62,84 @@ func NewRotKGProtocol(params ckks.Parameters) (rtg *RTGProtocol) {
 func (rtg *RTGProtocol) ShallowCopy() *RTGProtocol {
 	return &RTGProtocol{*rtg.RTGProtocol.ShallowCopy()}
 }
+
+// RTGBatchProtocol is the structure storing the parameters for the collective generation of the rotation keys of a
+// set of Galois elements in a single round.
+type RTGBatchProtocol struct {
+	drlwe.RTGBatchProtocol
+}
+
+// NewRotKGBatchProtocol creates a new RTGBatchProtocol that generates the collective rotation keys of the Galois
+// elements galEls in a single round, with the given number of goroutines (runtime.NumCPU() if smaller than 1).
+func NewRotKGBatchProtocol(params ckks.Parameters, galEls []uint64, goroutines int) (rtg *RTGBatchProtocol) {
+	return &RTGBatchProtocol{*drlwe.NewRTGBatchProtocol(params.Parameters, galEls, goroutines)}
+}
+
+// ShallowCopy creates a shallow copy of RTGBatchProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// RTGBatchProtocol can be used concurrently.
+func (rtg *RTGBatchProtocol) ShallowCopy() *RTGBatchProtocol {
+	return &RTGBatchProtocol{*rtg.RTGBatchProtocol.ShallowCopy()}
+}
+
+// RTGChunkedProtocol is the structure storing the parameters for the collective generation of the rotation keys of a
+// large set of Galois elements in chunks of bounded size.
+type RTGChunkedProtocol struct {
+	drlwe.RTGChunkedProtocol
+}
+
+// NewRotKGChunkedProtocol creates a new RTGChunkedProtocol that generates the collective rotation keys of the Galois
+// elements galEls in chunks of at most chunkSize Galois elements, with the given number of goroutines
+// (runtime.NumCPU() if smaller than 1).
+func NewRotKGChunkedProtocol(params ckks.Parameters, galEls []uint64, chunkSize, goroutines int) (rtg *RTGChunkedProtocol) {
+	return &RTGChunkedProtocol{*drlwe.NewRTGChunkedProtocol(params.Parameters, galEls, chunkSize, goroutines)}
+}
+
+// ShallowCopy creates a shallow copy of RTGChunkedProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// RTGChunkedProtocol can be used concurrently.
+func (rtg *RTGChunkedProtocol) ShallowCopy() *RTGChunkedProtocol {
+	return &RTGChunkedProtocol{*rtg.RTGChunkedProtocol.ShallowCopy()}
+}
+
+// SKGProtocol is the structure storing the parameters for the collective generation of the switching keys between the
+// dense collective secret key and a sparse collective secret key, held by the same parties, which let a threshold
+// deployment use the non-interactive CKKS bootstrapping instead of the interactive RefreshProtocol: the ciphertexts
+// are switched from the dense key to the sparse key, bootstrapped with the evaluation keys of the sparse key, generated
+// with the RKGProtocol and the RTGProtocol from the sparse shares, and switched back to the dense key.
+type SKGProtocol struct {
+	drlwe.SKGProtocol
+}
+
+// NewSKGProtocol creates a new SKGProtocol instance. The number of goroutines of the generation and aggregation of the
+// shares can be set with the drlwe.WithGoroutines option.
+func NewSKGProtocol(params ckks.Parameters, options ...drlwe.ProtocolOption) *SKGProtocol {
+	return &SKGProtocol{*drlwe.NewSKGProtocol(params.Parameters, options...)}
+}
+
+// ShallowCopy creates a shallow copy of SKGProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// SKGProtocol can be used concurrently.
+func (skg *SKGProtocol) ShallowCopy() *SKGProtocol {
+	return &SKGProtocol{*skg.SKGProtocol.ShallowCopy()}
+}
+
+// GenShareDenseToSparse generates the party's share of the switching key from the dense collective secret key to the
+// sparse collective secret key, from its shares skDense and skSparse of the keys.
+func (skg *SKGProtocol) GenShareDenseToSparse(skDense, skSparse *rlwe.SecretKey, crp drlwe.SKGCRP, shareOut *drlwe.SKGShare) {
+	skg.GenShare(skDense, skSparse, crp, shareOut)
+}
+
+// GenShareSparseToDense generates the party's share of the switching key from the sparse collective secret key to the
+// dense collective secret key, from its shares skSparse and skDense of the keys.
+func (skg *SKGProtocol) GenShareSparseToDense(skSparse, skDense *rlwe.SecretKey, crp drlwe.SKGCRP, shareOut *drlwe.SKGShare) {
+	skg.GenShare(skSparse, skDense, crp, shareOut)
+}
+
+// GenSparseSecretKeyShare generates the share of a party of a sparse collective secret key of Hamming weight at most h
+// shared among nParties parties, i.e. a ternary secret key of Hamming weight ceil(h/nParties). The collective secret
+// key is not ternary, but the sum of the absolute values of its coefficients, which bounds the message growth in the
+// bootstrapping, is at most nParties*ceil(h/nParties).
+func GenSparseSecretKeyShare(params ckks.Parameters, h, nParties int) *rlwe.SecretKey {
+	return ckks.NewKeyGenerator(params).GenSecretKeySparse((h + nParties - 1) / nParties)
+}
@@ -3,6 +3,7 @@ package dckks
 import (
 	"github.com/ldsec/lattigo/v2/ckks"
 	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
 )
 
 // CKSProtocol is a structure storing the parameters for the collective key-switching protocol.
@@ -12,9 +13,9 @@ type CKSProtocol struct {
 
 // NewCKSProtocol creates a new CKSProtocol that will be used to perform a collective key-switching on a ciphertext encrypted under a collective public-key, whose
 // secret-shares are distributed among j parties, re-encrypting the ciphertext under another public-key, whose secret-shares are also known to the
-// parties.
-func NewCKSProtocol(params ckks.Parameters, sigmaSmudging float64) (cks *CKSProtocol) {
-	return &CKSProtocol{*drlwe.NewCKSProtocol(params.Parameters, sigmaSmudging)}
+// parties. The randomness of the shares can be seeded with the drlwe.WithSeed option.
+func NewCKSProtocol(params ckks.Parameters, sigmaSmudging float64, options ...drlwe.ProtocolOption) (cks *CKSProtocol) {
+	return &CKSProtocol{*drlwe.NewCKSProtocol(params.Parameters, sigmaSmudging, options...)}
 }
 
 // KeySwitch performs the actual keyswitching operation on a ciphertext ct and put the result in ctOut
@@ -23,6 +24,13 @@ func (cks *CKSProtocol) KeySwitch(ctIn *ckks.Ciphertext, combined *drlwe.CKSShar
 	ctOut.Scale = ctIn.Scale
 }
 
+// ReduceDegree adds the aggregated shares of a round of the reduction of the degree of ctIn to ctIn and puts the result,
+// of degree ctIn.Degree()-1, in ctOut.
+func (cks *CKSProtocol) ReduceDegree(ctIn *ckks.Ciphertext, combined *drlwe.DegreeReductionShare, ctOut *ckks.Ciphertext) {
+	cks.CKSProtocol.ReduceDegree(ctIn.Ciphertext, combined, ctOut.Ciphertext)
+	ctOut.Scale = ctIn.Scale
+}
+
 // ShallowCopy creates a shallow copy of CKSProtocol in which all the read-only data-structures are
 // shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
 // CKSProtocol can be used concurrently.
@@ -47,9 +55,113 @@ func (pcks *PCKSProtocol) KeySwitch(ctIn *ckks.Ciphertext, combined *drlwe.PCKSS
 	ctOut.Scale = ctIn.Scale
 }
 
+// ReduceDegree adds the aggregated shares of a round of the reduction of the degree of ctIn to ctIn and puts the result,
+// of degree ctIn.Degree()-1, in ctOut.
+func (pcks *PCKSProtocol) ReduceDegree(ctIn *ckks.Ciphertext, combined *drlwe.DegreeReductionShare, ctOut *ckks.Ciphertext) {
+	pcks.PCKSProtocol.ReduceDegree(ctIn.Ciphertext, combined, ctOut.Ciphertext)
+	ctOut.Scale = ctIn.Scale
+}
+
 // ShallowCopy creates a shallow copy of PCKSProtocol in which all the read-only data-structures are
 // shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
 // PCKSProtocol can be used concurrently.
 func (pcks *PCKSProtocol) ShallowCopy() *PCKSProtocol {
 	return &PCKSProtocol{*pcks.PCKSProtocol.ShallowCopy()}
 }
+
+// MultiPCKSProtocol is the structure storing the parameters for the collective public key-switching of a ciphertext
+// under the public keys of several recipients in a single round.
+type MultiPCKSProtocol struct {
+	drlwe.MultiPCKSProtocol
+}
+
+// NewMultiPCKSProtocol creates a new MultiPCKSProtocol object and will be used to re-encrypt a ciphertext ctx encrypted
+// under a secret-shared key among j parties under the public keys pks of the recipients.
+func NewMultiPCKSProtocol(params ckks.Parameters, sigmaSmudging float64, pks []*rlwe.PublicKey) *MultiPCKSProtocol {
+	return &MultiPCKSProtocol{*drlwe.NewMultiPCKSProtocol(params.Parameters, sigmaSmudging, pks)}
+}
+
+// KeySwitch performs the actual keyswitching operation on a ciphertext ct and puts its re-encryption under the public
+// key of the j-th recipient in ctOut[j].
+func (mpcks *MultiPCKSProtocol) KeySwitch(ctIn *ckks.Ciphertext, combined *drlwe.MultiPCKSShare, ctOut []*ckks.Ciphertext) {
+	cts := make([]*rlwe.Ciphertext, len(ctOut))
+	for j := range ctOut {
+		cts[j] = ctOut[j].Ciphertext
+	}
+	mpcks.MultiPCKSProtocol.KeySwitch(ctIn.Ciphertext, combined, cts)
+	for j := range ctOut {
+		ctOut[j].Scale = ctIn.Scale
+	}
+}
+
+// ShallowCopy creates a shallow copy of MultiPCKSProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MultiPCKSProtocol can be used concurrently.
+func (mpcks *MultiPCKSProtocol) ShallowCopy() *MultiPCKSProtocol {
+	return &MultiPCKSProtocol{*mpcks.MultiPCKSProtocol.ShallowCopy()}
+}
+
+// InteractiveRelinProtocol is the structure storing the parameters for the interactive relinearization of CKKS
+// ciphertexts of degree 2, without a relinearization key.
+type InteractiveRelinProtocol struct {
+	drlwe.InteractiveRelinProtocol
+}
+
+// NewInteractiveRelinProtocol creates a new InteractiveRelinProtocol object that will be used to relinearize a
+// ciphertext of degree 2 encrypted under a secret-shared key among j parties.
+func NewInteractiveRelinProtocol(params ckks.Parameters) *InteractiveRelinProtocol {
+	return &InteractiveRelinProtocol{*drlwe.NewInteractiveRelinProtocol(params.Parameters)}
+}
+
+// GenShare computes the share of one party in the relinearization of the ciphertext ct of degree 2.
+func (rp *InteractiveRelinProtocol) GenShare(sk *rlwe.SecretKey, ct *ckks.Ciphertext, shareOut *drlwe.DegreeReductionShare) {
+	rp.InteractiveRelinProtocol.GenShare(sk, ct.Ciphertext, shareOut)
+}
+
+// Relinearize computes the relinearization of ctIn from the aggregated shares of all the parties and puts the result,
+// of degree 1, in ctOut.
+func (rp *InteractiveRelinProtocol) Relinearize(ctIn *ckks.Ciphertext, combined *drlwe.DegreeReductionShare, ctOut *ckks.Ciphertext) {
+	rp.InteractiveRelinProtocol.Relinearize(ctIn.Ciphertext, combined, ctOut.Ciphertext)
+	ctOut.Scale = ctIn.Scale
+}
+
+// ShallowCopy creates a shallow copy of InteractiveRelinProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// InteractiveRelinProtocol can be used concurrently.
+func (rp *InteractiveRelinProtocol) ShallowCopy() *InteractiveRelinProtocol {
+	return &InteractiveRelinProtocol{*rp.InteractiveRelinProtocol.ShallowCopy()}
+}
+
+// CKSBatchProtocol is the structure storing the parameters for the collective key-switching of a batch of CKKS
+// ciphertexts in a single round.
+type CKSBatchProtocol struct {
+	drlwe.CKSBatchProtocol
+}
+
+// NewCKSBatchProtocol creates a new CKSBatchProtocol whose shares are generated by the given number of goroutines, or
+// by runtime.NumCPU() goroutines if goroutines is smaller than 1.
+func NewCKSBatchProtocol(params ckks.Parameters, sigmaSmudging float64, goroutines int) *CKSBatchProtocol {
+	return &CKSBatchProtocol{*drlwe.NewCKSBatchProtocol(params.Parameters, sigmaSmudging, goroutines)}
+}
+
+// KeySwitch performs the key-switching of all the ciphertexts ctIn of the batch and puts the results in ctOut.
+func (cks *CKSBatchProtocol) KeySwitch(ctIn []*ckks.Ciphertext, combined *drlwe.CKSBatchShare, ctOut []*ckks.Ciphertext) {
+	in, out := make([]*rlwe.Ciphertext, len(ctIn)), make([]*rlwe.Ciphertext, len(ctOut))
+	for i := range ctIn {
+		in[i] = ctIn[i].Ciphertext
+	}
+	for i := range ctOut {
+		out[i] = ctOut[i].Ciphertext
+	}
+	cks.CKSBatchProtocol.KeySwitch(in, combined, out)
+	for i := range ctOut {
+		ctOut[i].Scale = ctIn[i].Scale
+	}
+}
+
+// ShallowCopy creates a shallow copy of CKSBatchProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// CKSBatchProtocol can be used concurrently.
+func (cks *CKSBatchProtocol) ShallowCopy() *CKSBatchProtocol {
+	return &CKSBatchProtocol{*cks.CKSBatchProtocol.ShallowCopy()}
+}
@@ -0,0 +1,221 @@
+package dckks
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// MigrationProtocol is the structure storing the parameters and temporary buffers required by the migration
+// protocol, which re-encrypts a CKKS ciphertext under a collective secret key and a parameter set into a ciphertext
+// under another collective secret key of the same parties and another parameter set, e.g. with a larger ring degree
+// for the bootstrapping or with post-quantum moduli, in a single round and without revealing its plaintext.
+//
+// Each party adds a random mask M_i, encoded in the slots of the input parameters, to its decryption share of the input
+// ciphertext, and the negation of the same mask, encoded in the slots of the output parameters, to its encryption share
+// under a common random polynomial. The aggregated decryption share reveals the masked message m + sum(M_i), which is
+// re-encoded in the slots of the output parameters and added to the aggregated encryption share.
+//
+// Both parameter sets must be defined over the standard ring, and the ciphertexts must have at most
+// min(paramsIn.Slots(), paramsOut.Slots()) slots.
+type MigrationProtocol struct {
+	paramsIn, paramsOut ckks.Parameters
+	precision           int
+
+	cksIn, cksOut         CKSProtocol
+	zeroIn, zeroOut       *rlwe.SecretKey
+	encoderIn, encoderOut ckks.EncoderBigComplex
+
+	values []*ring.Complex
+
+	header drlwe.ShareHeader
+}
+
+// NewMigrationProtocol creates a new MigrationProtocol from the CKKS parameters paramsIn to the CKKS parameters
+// paramsOut.
+// precision : the log2 of decimal precision of the internal CKKS encoders.
+// It returns an error if the parameters are not defined over the standard ring.
+func NewMigrationProtocol(paramsIn, paramsOut ckks.Parameters, precision int, sigmaSmudging float64) (mp *MigrationProtocol, err error) {
+
+	if paramsIn.RingType() != ring.Standard || paramsOut.RingType() != ring.Standard {
+		return nil, errors.New("cannot NewMigrationProtocol: the parameters must be defined over the standard ring")
+	}
+
+	mp = new(MigrationProtocol)
+	mp.paramsIn = paramsIn
+	mp.paramsOut = paramsOut
+	mp.header = drlwe.NewShareHeader(drlwe.ProtocolMigration, paramsIn, paramsOut)
+	mp.precision = precision
+	mp.cksIn = *NewCKSProtocol(paramsIn, sigmaSmudging)
+	mp.cksOut = *NewCKSProtocol(paramsOut, sigmaSmudging)
+	mp.zeroIn = rlwe.NewSecretKey(paramsIn.Parameters)
+	mp.zeroOut = rlwe.NewSecretKey(paramsOut.Parameters)
+	mp.encoderIn = ckks.NewEncoderBigComplex(paramsIn, precision)
+	mp.encoderOut = ckks.NewEncoderBigComplex(paramsOut, precision)
+	mp.allocateBuffers()
+
+	return mp, nil
+}
+
+// ShallowCopy creates a shallow copy of MigrationProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MigrationProtocol can be used concurrently.
+func (mp *MigrationProtocol) ShallowCopy() *MigrationProtocol {
+	mpCopy := &MigrationProtocol{
+		paramsIn:   mp.paramsIn,
+		paramsOut:  mp.paramsOut,
+		precision:  mp.precision,
+		cksIn:      *mp.cksIn.ShallowCopy(),
+		cksOut:     *mp.cksOut.ShallowCopy(),
+		zeroIn:     mp.zeroIn,
+		zeroOut:    mp.zeroOut,
+		encoderIn:  mp.encoderIn.ShallowCopy(),
+		encoderOut: mp.encoderOut.ShallowCopy(),
+		header:     mp.header,
+	}
+	mpCopy.allocateBuffers()
+	return mpCopy
+}
+
+func (mp *MigrationProtocol) allocateBuffers() {
+	mp.values = make([]*ring.Complex, utils.MinInt(mp.paramsIn.Slots(), mp.paramsOut.Slots()))
+	for i := range mp.values {
+		mp.values[i] = ring.NewComplex(ring.NewFloat(0, mp.precision), ring.NewFloat(0, mp.precision))
+	}
+}
+
+// AllocateShare allocates the share of one party in the MigrationProtocol, whose decryption share is at level
+// levelDecrypt of the input parameters and encryption share at level levelEncrypt of the output parameters.
+func (mp *MigrationProtocol) AllocateShare(levelDecrypt, levelEncrypt int) *drlwe.MigrationShare {
+	return &drlwe.MigrationShare{
+		Header:          mp.header,
+		DecryptionShare: *mp.cksIn.AllocateShare(levelDecrypt),
+		EncryptionShare: *mp.cksOut.AllocateShare(levelEncrypt),
+	}
+}
+
+// SampleCRP samples a common random polynomial of the output parameters to be used in the MigrationProtocol from the
+// provided common reference string.
+func (mp *MigrationProtocol) SampleCRP(level int, crs utils.PRNG) drlwe.CKSCRP {
+	return mp.cksOut.SampleCRP(level, crs)
+}
+
+// GenShare generates the share of a party in the migration of a ciphertext from its secret key share skIn under the
+// input parameters and its secret key share skOut under the output parameters.
+// This protocol requires additional inputs which are :
+// logBound : the bit length of the masks.
+// logSlots : the bit length of the number of slots of the ciphertext.
+// ct1      : the degree 1 element of the ckks.Ciphertext to migrate, i.e. ct1 = ckks.Ciphertext.Value[1].
+// scale    : the scale of the ciphertext.
+// The masked message is decoded at the scale of the ciphertext, hence scale*(|m| + nParties*2^logBound) must be smaller
+// than half the modulus at the level of the ciphertext, and logBound should exceed the bit length of |m| by the desired
+// statistical security parameter.
+func (mp *MigrationProtocol) GenShare(skIn, skOut *rlwe.SecretKey, logBound, logSlots int, ct1 *ring.Poly, scale float64, crp drlwe.CKSCRP, shareOut *drlwe.MigrationShare) {
+
+	slots := 1 << logSlots
+	if slots > len(mp.values) {
+		panic("cannot GenShare: logSlots is larger than the number of slots of the parameters")
+	}
+
+	ringQ := mp.paramsIn.RingQ()
+
+	levelQ := utils.MinInt(ct1.Level(), shareOut.DecryptionShare.Value.Level())
+
+	boundMax := ring.NewUint(ringQ.Modulus[0])
+	for i := 1; i < levelQ+1; i++ {
+		boundMax.Mul(boundMax, ring.NewUint(ringQ.Modulus[i]))
+	}
+
+	if float64(boundMax.BitLen()) <= float64(logBound)+math.Log2(scale)+1 {
+		panic("cannot GenShare: ciphertext level is not large enough for the masks")
+	}
+
+	if (*ring.Poly)(&crp).Level() != shareOut.EncryptionShare.Value.Level() {
+		panic("cannot GenShare: crp level must be equal to the encryption share level")
+	}
+
+	bound := ring.NewUint(1)
+	bound.Lsh(bound, uint(logBound))
+
+	// Samples the masks M_i in the slots
+	for i := 0; i < slots; i++ {
+		mp.values[i].Real().SetInt(ring.RandInt(bound))
+		mp.values[i].Imag().SetInt(ring.RandInt(bound))
+	}
+
+	// Returns [c1*s_i + Delta * M_i + e] on the decryption share
+	mp.cksIn.GenShare(skIn, mp.zeroIn, ct1, &shareOut.DecryptionShare)
+	pt := ckks.NewPlaintext(mp.paramsIn, levelQ, scale)
+	mp.encoderIn.EncodeAtScale(mp.values[:slots], pt, logSlots, ring.NewFloat(scale, mp.precision))
+	ringQ.AddLvl(levelQ, shareOut.DecryptionShare.Value, pt.Value, shareOut.DecryptionShare.Value)
+
+	// Returns [-crp*s'_i - Delta' * M_i + e] on the encryption share
+	for i := 0; i < slots; i++ {
+		mp.values[i].Real().Neg(mp.values[i].Real())
+		mp.values[i].Imag().Neg(mp.values[i].Imag())
+	}
+
+	mp.genEncryptionShare(skOut, logSlots, crp, &shareOut.EncryptionShare)
+}
+
+// AggregateShare sums share1 and share2 on shareOut.
+func (mp *MigrationProtocol) AggregateShare(share1, share2, shareOut *drlwe.MigrationShare) {
+
+	if share1.DecryptionShare.Value.Level() != share2.DecryptionShare.Value.Level() || share1.DecryptionShare.Value.Level() != shareOut.DecryptionShare.Value.Level() {
+		panic("all decryption shares must be at the same level")
+	}
+
+	if share1.EncryptionShare.Value.Level() != share2.EncryptionShare.Value.Level() || share1.EncryptionShare.Value.Level() != shareOut.EncryptionShare.Value.Level() {
+		panic("all encryption shares must be at the same level")
+	}
+
+	mp.cksIn.AggregateShare(&share1.DecryptionShare, &share2.DecryptionShare, &shareOut.DecryptionShare)
+	mp.cksOut.AggregateShare(&share1.EncryptionShare, &share2.EncryptionShare, &shareOut.EncryptionShare)
+}
+
+// Migrate finalizes the migration of the ciphertext ctIn, under the input parameters, to the ciphertext ctOut, under
+// the output parameters at the level of crp and at their default scale, from the aggregated share of all the parties.
+func (mp *MigrationProtocol) Migrate(ctIn *ckks.Ciphertext, logSlots int, crp drlwe.CKSCRP, share *drlwe.MigrationShare, ctOut *ckks.Ciphertext) {
+
+	c1 := (*ring.Poly)(&crp)
+
+	if ctOut.Level() != c1.Level() || share.EncryptionShare.Value.Level() != c1.Level() {
+		panic("cannot Migrate: ctOut, crp and the encryption share must be at the same level")
+	}
+
+	levelQ := utils.MinInt(ctIn.Level(), share.DecryptionShare.Value.Level())
+
+	// Returns m + sum(M_i)
+	pt := ckks.NewPlaintext(mp.paramsIn, levelQ, ctIn.Scale)
+	mp.paramsIn.RingQ().AddLvl(levelQ, ctIn.Value[0], share.DecryptionShare.Value, pt.Value)
+	values := mp.encoderIn.DecodeAtScale(pt, logSlots, ring.NewFloat(ctIn.Scale, mp.precision))
+
+	// Returns [-crp*s' + Delta' * m + e]
+	scale := mp.paramsOut.DefaultScale()
+	ptOut := &ckks.Plaintext{Plaintext: &rlwe.Plaintext{Value: ctOut.Value[0]}, Scale: scale}
+	mp.encoderOut.EncodeAtScale(values, ptOut, logSlots, ring.NewFloat(scale, mp.precision))
+	mp.paramsOut.RingQ().AddLvl(c1.Level(), ctOut.Value[0], share.EncryptionShare.Value, ctOut.Value[0])
+
+	ctOut.Value[1].Copy(c1)
+	ctOut.Value[1].IsNTT = true
+	ctOut.Scale = scale
+}
+
+// genEncryptionShare returns [-crp*s'_i + Delta' * values + e] on shareOut, at the level of crp and at the default
+// scale of the output parameters.
+func (mp *MigrationProtocol) genEncryptionShare(sk *rlwe.SecretKey, logSlots int, crp drlwe.CKSCRP, shareOut *drlwe.CKSShare) {
+
+	c1 := ring.Poly(crp)
+	c1.IsNTT = true
+	mp.cksOut.GenShare(mp.zeroOut, sk, &c1, shareOut)
+
+	scale := mp.paramsOut.DefaultScale()
+	pt := ckks.NewPlaintext(mp.paramsOut, c1.Level(), scale)
+	mp.encoderOut.EncodeAtScale(mp.values[:1<<logSlots], pt, logSlots, ring.NewFloat(scale, mp.precision))
+	mp.paramsOut.RingQ().AddLvl(c1.Level(), shareOut.Value, pt.Value, shareOut.Value)
+}
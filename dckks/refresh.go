@@ -50,13 +50,27 @@ func (rfp *RefreshProtocol) GenShare(sk *rlwe.SecretKey, logBound, logSlots int,
 	rfp.MaskedTransformProtocol.GenShare(sk, logBound, logSlots, ct1, scale, crs, nil, &shareOut.MaskedTransformShare)
 }
 
+// GenShareAtScale generates a share for the Refresh protocol of a ciphertext refreshed at the scale outputScale
+// instead of the default scale, e.g. the scale expected by the next stage of a circuit. It requires the same
+// additional inputs as GenShare, and all the parties and the FinalizeAtScale method must use the same outputScale.
+// The level of the refreshed ciphertext is the level of crs.
+func (rfp *RefreshProtocol) GenShareAtScale(sk *rlwe.SecretKey, logBound, logSlots int, ct1 *ring.Poly, scale, outputScale float64, crs drlwe.CKSCRP, shareOut *RefreshShare) {
+	rfp.MaskedTransformProtocol.GenShareAtScale(sk, logBound, logSlots, ct1, scale, outputScale, crs, nil, &shareOut.MaskedTransformShare)
+}
+
 // AggregateShare aggregates two parties' shares in the Refresh protocol.
 func (rfp *RefreshProtocol) AggregateShare(share1, share2, shareOut *RefreshShare) {
 	rfp.MaskedTransformProtocol.AggregateShare(&share1.MaskedTransformShare, &share2.MaskedTransformShare, &shareOut.MaskedTransformShare)
 }
 
 // Finalize applies Decrypt, Recode and Recrypt on the input ciphertext.
-// The ciphertext scale is reset to the default scale.
+// The refreshed ciphertext is at the level of crs and its scale is reset to the default scale.
 func (rfp *RefreshProtocol) Finalize(ctIn *ckks.Ciphertext, logSlots int, crs drlwe.CKSCRP, share *RefreshShare, ctOut *ckks.Ciphertext) {
 	rfp.MaskedTransformProtocol.Transform(ctIn, logSlots, nil, crs, &share.MaskedTransformShare, ctOut)
 }
+
+// FinalizeAtScale applies Decrypt, Recode and Recrypt on the input ciphertext from the shares generated with
+// GenShareAtScale for the same outputScale. The refreshed ciphertext is at the level of crs and at the scale outputScale.
+func (rfp *RefreshProtocol) FinalizeAtScale(ctIn *ckks.Ciphertext, logSlots int, crs drlwe.CKSCRP, share *RefreshShare, outputScale float64, ctOut *ckks.Ciphertext) {
+	rfp.MaskedTransformProtocol.TransformAtScale(ctIn, logSlots, nil, crs, &share.MaskedTransformShare, outputScale, ctOut)
+}
@@ -0,0 +1,381 @@
+package dckks
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/big"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// SchemeSwitchingProtocol is the structure storing the parameters and temporary buffers required by the scheme-switching
+// protocol, which converts a ciphertext encrypted under the collective secret key from the BFV scheme to the CKKS scheme,
+// and back, in a single round and without revealing its plaintext.
+//
+// The protocol follows the masked-transform approach: each party adds a random integer mask, encoded in the slots of the
+// input scheme, to its decryption share, and the negation of the same mask, encoded in the slots of the output scheme, to
+// its encryption share. The aggregated decryption share reveals the masked message, which is re-encoded in the output
+// scheme and added to the aggregated encryption share. The j-th slot of the BFV ciphertext is mapped to the real part of
+// the j-th slot of the CKKS ciphertext, for j < 2^logSlots.
+//
+// The BFV and CKKS parameters must share the same ring degree and moduli, so that the secret key shares of the parties
+// are valid in both schemes.
+type SchemeSwitchingProtocol struct {
+	paramsBFV  bfv.Parameters
+	paramsCKKS ckks.Parameters
+	precision  int
+
+	cks         CKSProtocol
+	zero        *rlwe.SecretKey
+	encoderBFV  bfv.Encoder
+	encoderCKKS ckks.EncoderBigComplex
+
+	tmpPtBFV   *bfv.Plaintext
+	tmpInt     []int64
+	maskBigint []*big.Int
+	values     []*ring.Complex
+
+	header drlwe.ShareHeader
+}
+
+// SchemeSwitchingShare is a struct storing the decryption and encryption shares of the scheme-switching protocol.
+type SchemeSwitchingShare struct {
+	Header   drlwe.ShareHeader
+	decShare drlwe.CKSShare
+	encShare drlwe.CKSShare
+}
+
+// MarshalBinary encodes a SchemeSwitchingShare on a slice of bytes.
+func (share *SchemeSwitchingShare) MarshalBinary() (data []byte, err error) {
+	var decData, encData []byte
+	if decData, err = share.decShare.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	if encData, err = share.encShare.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	data = make([]byte, drlwe.ShareHeaderLen+8)
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint64(data[drlwe.ShareHeaderLen:], uint64(len(decData)))
+	data = append(data, decData...)
+	data = append(data, encData...)
+	return data, nil
+}
+
+// UnmarshalBinary decodes a marshaled SchemeSwitchingShare on the target SchemeSwitchingShare.
+func (share *SchemeSwitchingShare) UnmarshalBinary(data []byte) error {
+
+	ptr, err := share.Header.Decode(data)
+	if err != nil {
+		return err
+	}
+	data = data[ptr:]
+
+	if len(data) < 8 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	decDataLen := binary.LittleEndian.Uint64(data[:8])
+
+	if decDataLen > uint64(len(data)-8) {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	if err := share.decShare.UnmarshalBinary(data[8 : decDataLen+8]); err != nil {
+		return err
+	}
+	if err := share.encShare.UnmarshalBinary(data[8+decDataLen:]); err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewSchemeSwitchingProtocol creates a new SchemeSwitchingProtocol between the BFV parameters paramsBFV and the CKKS
+// parameters paramsCKKS.
+// precision : the log2 of decimal precision of the internal CKKS encoder.
+// It returns an error if the parameters do not share the same ring degree and moduli, or if the CKKS parameters are
+// not defined over the standard ring.
+func NewSchemeSwitchingProtocol(paramsBFV bfv.Parameters, paramsCKKS ckks.Parameters, precision int, sigmaSmudging float64) (ssp *SchemeSwitchingProtocol, err error) {
+
+	if paramsBFV.N() != paramsCKKS.N() || !utils.EqualSliceUint64(paramsBFV.Q(), paramsCKKS.Q()) || !utils.EqualSliceUint64(paramsBFV.P(), paramsCKKS.P()) {
+		return nil, errors.New("cannot NewSchemeSwitchingProtocol: the BFV and CKKS parameters must have the same ring degree and moduli")
+	}
+
+	if paramsBFV.RingType() != ring.Standard || paramsCKKS.RingType() != ring.Standard {
+		return nil, errors.New("cannot NewSchemeSwitchingProtocol: the parameters must be defined over the standard ring")
+	}
+
+	ssp = new(SchemeSwitchingProtocol)
+	ssp.paramsBFV = paramsBFV
+	ssp.paramsCKKS = paramsCKKS
+	ssp.header = drlwe.NewShareHeader(drlwe.ProtocolSchemeSwitching, paramsBFV, paramsCKKS)
+	ssp.precision = precision
+	ssp.cks = *NewCKSProtocol(paramsCKKS, sigmaSmudging)
+	ssp.zero = rlwe.NewSecretKey(paramsCKKS.Parameters)
+	ssp.encoderBFV = bfv.NewEncoder(paramsBFV)
+	ssp.encoderCKKS = ckks.NewEncoderBigComplex(paramsCKKS, precision)
+	ssp.allocateBuffers()
+
+	return ssp, nil
+}
+
+// ShallowCopy creates a shallow copy of SchemeSwitchingProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// SchemeSwitchingProtocol can be used concurrently.
+func (ssp *SchemeSwitchingProtocol) ShallowCopy() *SchemeSwitchingProtocol {
+	sspCopy := &SchemeSwitchingProtocol{
+		paramsBFV:   ssp.paramsBFV,
+		paramsCKKS:  ssp.paramsCKKS,
+		precision:   ssp.precision,
+		cks:         *ssp.cks.ShallowCopy(),
+		zero:        ssp.zero,
+		encoderBFV:  ssp.encoderBFV.ShallowCopy(),
+		encoderCKKS: ssp.encoderCKKS.ShallowCopy(),
+		header:      ssp.header,
+	}
+	sspCopy.allocateBuffers()
+	return sspCopy
+}
+
+func (ssp *SchemeSwitchingProtocol) allocateBuffers() {
+	ssp.tmpPtBFV = bfv.NewPlaintext(ssp.paramsBFV)
+	ssp.tmpInt = make([]int64, ssp.paramsBFV.N())
+	ssp.maskBigint = make([]*big.Int, ssp.paramsBFV.N())
+	for i := range ssp.maskBigint {
+		ssp.maskBigint[i] = new(big.Int)
+	}
+	ssp.values = make([]*ring.Complex, ssp.paramsCKKS.Slots())
+	for i := range ssp.values {
+		ssp.values[i] = ring.NewComplex(ring.NewFloat(0, ssp.precision), ring.NewFloat(0, ssp.precision))
+	}
+}
+
+// AllocateShare allocates the shares of the SchemeSwitchingProtocol, whose decryption share is at level levelDecrypt
+// and encryption share at level levelEncrypt. The BFV shares must be at the maximum level.
+func (ssp *SchemeSwitchingProtocol) AllocateShare(levelDecrypt, levelEncrypt int) *SchemeSwitchingShare {
+	return &SchemeSwitchingShare{
+		Header:   ssp.header,
+		decShare: *ssp.cks.AllocateShare(levelDecrypt),
+		encShare: *ssp.cks.AllocateShare(levelEncrypt),
+	}
+}
+
+// SampleCRP samples a common random polynomial to be used in the SchemeSwitchingProtocol from the provided
+// common reference string. For a conversion to BFV, the level must be the maximum level.
+func (ssp *SchemeSwitchingProtocol) SampleCRP(level int, crs utils.PRNG) drlwe.CKSCRP {
+	return ssp.cks.SampleCRP(level, crs)
+}
+
+// GenShareBFVToCKKS generates the party's share in the conversion of a BFV ciphertext to a CKKS ciphertext.
+// This protocol requires additional inputs which are :
+// logBound : the bit length of the masks.
+// logSlots : the bit length of the number of slots of the CKKS ciphertext.
+// ct1      : the degree 1 element of the BFV ciphertext to convert, i.e. ct1 = bfv.Ciphertext.Value[1].
+// The masked message is decoded modulo t, hence the plaintext modulus t must be larger than 2*(|m| + nParties*2^logBound)
+// for the BFV message m to be recovered exactly, and logBound should exceed the bit length of |m| by the desired
+// statistical security parameter.
+func (ssp *SchemeSwitchingProtocol) GenShareBFVToCKKS(sk *rlwe.SecretKey, logBound, logSlots int, ct1 *ring.Poly, crp drlwe.CKSCRP, shareOut *SchemeSwitchingShare) {
+
+	if logBound >= bits.Len64(ssp.paramsBFV.T())-1 {
+		panic("cannot GenShareBFVToCKKS: logBound must be smaller than log2(t)-1")
+	}
+
+	if (*ring.Poly)(&crp).Level() != shareOut.encShare.Value.Level() {
+		panic("cannot GenShareBFVToCKKS: crp level must be equal to the encryption share level")
+	}
+
+	ringQ := ssp.paramsCKKS.RingQ()
+
+	bound := ring.NewUint(1)
+	bound.Lsh(bound, uint(logBound))
+
+	// Samples the masks M_i in the BFV slots
+	for i := range ssp.maskBigint {
+		ssp.maskBigint[i] = ring.RandInt(bound)
+	}
+
+	// Returns [a*s_i + Q/t * M_i + e] on the decryption share
+	ssp.cks.GenShare(sk, ssp.zero, ct1, &shareOut.decShare)
+	ssp.encoderBFV.EncodeBigInt(ssp.maskBigint, ssp.tmpPtBFV)
+	ringQ.Add(shareOut.decShare.Value, ssp.tmpPtBFV.Value, shareOut.decShare.Value)
+
+	// Returns [-crp*s_i - Delta * M_i + e] on the encryption share
+	slots := 1 << logSlots
+	for i := 0; i < slots; i++ {
+		ssp.values[i].Real().SetInt(ssp.maskBigint[i])
+		ssp.values[i].Real().Neg(ssp.values[i].Real())
+		ssp.values[i].Imag().SetInt64(0)
+	}
+
+	ssp.genEncryptionShareCKKS(sk, logSlots, crp, &shareOut.encShare)
+}
+
+// SwitchToCKKS finalizes the conversion of the BFV ciphertext ct to the CKKS ciphertext ctOut, at the level of crp and
+// at the default scale, from the aggregated share of the parties.
+func (ssp *SchemeSwitchingProtocol) SwitchToCKKS(ct *bfv.Ciphertext, logSlots int, crp drlwe.CKSCRP, share *SchemeSwitchingShare, ctOut *ckks.Ciphertext) {
+
+	c1 := (*ring.Poly)(&crp)
+
+	if ctOut.Level() != c1.Level() || share.encShare.Value.Level() != c1.Level() {
+		panic("cannot SwitchToCKKS: ctOut, crp and the encryption share must be at the same level")
+	}
+
+	ringQ := ssp.paramsCKKS.RingQ()
+
+	// Returns sum(M_i) + m mod t
+	ringQ.Add(ct.Value[0], share.decShare.Value, ssp.tmpPtBFV.Value)
+	ssp.encoderBFV.DecodeInt(ssp.tmpPtBFV, ssp.tmpInt)
+
+	// Returns [-crp*s + Delta * m + e]
+	slots := 1 << logSlots
+	for i := 0; i < slots; i++ {
+		ssp.values[i].Real().SetInt64(ssp.tmpInt[i])
+		ssp.values[i].Imag().SetInt64(0)
+	}
+
+	scale := ssp.paramsCKKS.DefaultScale()
+	pt := &ckks.Plaintext{Plaintext: &rlwe.Plaintext{Value: ctOut.Value[0]}, Scale: scale}
+	ssp.encoderCKKS.EncodeAtScale(ssp.values[:slots], pt, logSlots, ring.NewFloat(scale, ssp.precision))
+	ringQ.AddLvl(c1.Level(), ctOut.Value[0], share.encShare.Value, ctOut.Value[0])
+
+	ctOut.Value[1].Copy(c1)
+	ctOut.Value[1].IsNTT = true
+	ctOut.Scale = scale
+}
+
+// GenShareCKKSToBFV generates the party's share in the conversion of a CKKS ciphertext to a BFV ciphertext.
+// This protocol requires additional inputs which are :
+// logBound : the bit length of the masks.
+// logSlots : the bit length of the number of slots of the CKKS ciphertext.
+// ct1      : the degree 1 element of the CKKS ciphertext to convert, i.e. ct1 = ckks.Ciphertext.Value[1].
+// scale    : the scale of the CKKS ciphertext.
+// The real parts of the slots are rounded to the nearest integer and reduced modulo t, and their imaginary parts are
+// discarded. The masked message is decoded at the scale of the ciphertext, hence scale*(|m| + nParties*2^logBound) must
+// be smaller than half the modulus at the level of the ciphertext, and logBound should exceed the bit length of |m| by
+// the desired statistical security parameter.
+func (ssp *SchemeSwitchingProtocol) GenShareCKKSToBFV(sk *rlwe.SecretKey, logBound, logSlots int, ct1 *ring.Poly, scale float64, crp drlwe.CKSCRP, shareOut *SchemeSwitchingShare) {
+
+	ringQ := ssp.paramsCKKS.RingQ()
+
+	levelQ := utils.MinInt(ct1.Level(), shareOut.decShare.Value.Level())
+
+	boundMax := ring.NewUint(ringQ.Modulus[0])
+	for i := 1; i < levelQ+1; i++ {
+		boundMax.Mul(boundMax, ring.NewUint(ringQ.Modulus[i]))
+	}
+
+	if float64(boundMax.BitLen()) <= float64(logBound)+math.Log2(scale)+1 {
+		panic("cannot GenShareCKKSToBFV: ciphertext level is not large enough for the masks")
+	}
+
+	if (*ring.Poly)(&crp).Level() != ssp.paramsBFV.MaxLevel() {
+		panic("cannot GenShareCKKSToBFV: crp must be at the maximum level")
+	}
+
+	bound := ring.NewUint(1)
+	bound.Lsh(bound, uint(logBound))
+
+	slots := 1 << logSlots
+
+	// Samples the masks M_i in the CKKS slots, the real part of which are also the masks of the BFV slots
+	for i := range ssp.maskBigint {
+		ssp.maskBigint[i].SetUint64(0)
+	}
+
+	for i := 0; i < slots; i++ {
+		ssp.maskBigint[i] = ring.RandInt(bound)
+		ssp.values[i].Real().SetInt(ssp.maskBigint[i])
+		ssp.values[i].Imag().SetInt(ring.RandInt(bound))
+	}
+
+	// Returns [a*s_i + Delta * M_i + e] on the decryption share
+	ssp.cks.GenShare(sk, ssp.zero, ct1, &shareOut.decShare)
+
+	pt := ckks.NewPlaintext(ssp.paramsCKKS, levelQ, scale)
+	ssp.encoderCKKS.EncodeAtScale(ssp.values[:slots], pt, logSlots, ring.NewFloat(scale, ssp.precision))
+	ringQ.AddLvl(levelQ, shareOut.decShare.Value, pt.Value, shareOut.decShare.Value)
+
+	// Returns [-crp*s_i - Q/t * M_i + e] on the encryption share
+	for i := 0; i < slots; i++ {
+		ssp.maskBigint[i].Neg(ssp.maskBigint[i])
+	}
+
+	ssp.cks.GenShare(ssp.zero, sk, (*ring.Poly)(&crp), &shareOut.encShare)
+	ssp.encoderBFV.EncodeBigInt(ssp.maskBigint, ssp.tmpPtBFV)
+	ringQ.Add(shareOut.encShare.Value, ssp.tmpPtBFV.Value, shareOut.encShare.Value)
+}
+
+// SwitchToBFV finalizes the conversion of the CKKS ciphertext ct to the BFV ciphertext ctOut from the aggregated share
+// of the parties.
+func (ssp *SchemeSwitchingProtocol) SwitchToBFV(ct *ckks.Ciphertext, logSlots int, crp drlwe.CKSCRP, share *SchemeSwitchingShare, ctOut *bfv.Ciphertext) {
+
+	if ctOut.Degree() != 1 {
+		panic("cannot SwitchToBFV: ctOut must have degree 1")
+	}
+
+	ringQ := ssp.paramsCKKS.RingQ()
+
+	levelQ := utils.MinInt(ct.Level(), share.decShare.Value.Level())
+
+	// Returns sum(M_i) + m
+	pt := ckks.NewPlaintext(ssp.paramsCKKS, levelQ, ct.Scale)
+	ringQ.AddLvl(levelQ, ct.Value[0], share.decShare.Value, pt.Value)
+	values := ssp.encoderCKKS.DecodeAtScale(pt, logSlots, ring.NewFloat(ct.Scale, ssp.precision))
+
+	for i := range ssp.maskBigint {
+		ssp.maskBigint[i].SetUint64(0)
+	}
+
+	half := ring.NewFloat(0.5, ssp.precision)
+	tmp := ring.NewFloat(0, ssp.precision)
+	for i := range values {
+		if values[i].Real().Sign() < 0 {
+			tmp.Sub(values[i].Real(), half)
+		} else {
+			tmp.Add(values[i].Real(), half)
+		}
+		tmp.Int(ssp.maskBigint[i])
+	}
+
+	// Returns [-crp*s + Q/t * round(m) + e]
+	ssp.encoderBFV.EncodeBigInt(ssp.maskBigint, ssp.tmpPtBFV)
+	ringQ.Add(share.encShare.Value, ssp.tmpPtBFV.Value, ctOut.Value[0])
+	ctOut.Value[1].Copy((*ring.Poly)(&crp))
+}
+
+// AggregateShare sums share1 and share2 on shareOut.
+func (ssp *SchemeSwitchingProtocol) AggregateShare(share1, share2, shareOut *SchemeSwitchingShare) {
+
+	if share1.decShare.Value.Level() != share2.decShare.Value.Level() || share1.decShare.Value.Level() != shareOut.decShare.Value.Level() {
+		panic("all decryption shares must be at the same level")
+	}
+
+	if share1.encShare.Value.Level() != share2.encShare.Value.Level() || share1.encShare.Value.Level() != shareOut.encShare.Value.Level() {
+		panic("all encryption shares must be at the same level")
+	}
+
+	ssp.cks.AggregateShare(&share1.decShare, &share2.decShare, &shareOut.decShare)
+	ssp.cks.AggregateShare(&share1.encShare, &share2.encShare, &shareOut.encShare)
+}
+
+// genEncryptionShareCKKS returns [-crp*s_i + Delta * values + e] on shareOut, at the level of crp and at the default scale.
+func (ssp *SchemeSwitchingProtocol) genEncryptionShareCKKS(sk *rlwe.SecretKey, logSlots int, crp drlwe.CKSCRP, shareOut *drlwe.CKSShare) {
+
+	c1 := ring.Poly(crp)
+	c1.IsNTT = true
+	ssp.cks.GenShare(ssp.zero, sk, &c1, shareOut)
+
+	scale := ssp.paramsCKKS.DefaultScale()
+	pt := ckks.NewPlaintext(ssp.paramsCKKS, c1.Level(), scale)
+	ssp.encoderCKKS.EncodeAtScale(ssp.values[:1<<logSlots], pt, logSlots, ring.NewFloat(scale, ssp.precision))
+	ssp.paramsCKKS.RingQ().AddLvl(c1.Level(), shareOut.Value, pt.Value, shareOut.Value)
+}
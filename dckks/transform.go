@@ -1,9 +1,11 @@
 package dckks
 
 import (
-	"math/big"
-
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
 
 	"github.com/ldsec/lattigo/v2/ckks"
 	"github.com/ldsec/lattigo/v2/drlwe"
@@ -22,6 +24,8 @@ type MaskedTransformProtocol struct {
 
 	tmpMask []*big.Int
 	encoder ckks.EncoderBigComplex
+
+	header drlwe.ShareHeader
 }
 
 // ShallowCopy creates a shallow copy of MaskedTransformProtocol in which all the read-only data-structures are
@@ -44,17 +48,20 @@ func (rfp *MaskedTransformProtocol) ShallowCopy() *MaskedTransformProtocol {
 		defaultScale: rfp.defaultScale,
 		tmpMask:      tmpMask,
 		encoder:      rfp.encoder.ShallowCopy(),
+		header:       rfp.header,
 	}
 }
 
 // MaskedTransformFunc represents a user-defined in-place function that can be evaluated on masked CKKS plaintexts, as a part of the
 // Masked Transform Protocol.
 // The function is called with a vector of *ring.Complex modulo ckks.Parameters.Slots() as input, and must write
-// its output on the same buffer.
+// its output on the same buffer. Since it is applied to the masks of the parties and to the masked plaintext separately,
+// the function must be linear, e.g. the function returned by MatrixTransform.
 type MaskedTransformFunc func(coeffs []*ring.Complex)
 
 // MaskedTransformShare is a struct storing the decryption and recryption shares.
 type MaskedTransformShare struct {
+	Header   drlwe.ShareHeader
 	e2sShare drlwe.CKSShare
 	s2eShare drlwe.CKSShare
 }
@@ -68,8 +75,11 @@ func (share *MaskedTransformShare) MarshalBinary() (data []byte, err error) {
 	if s2eData, err = share.s2eShare.MarshalBinary(); err != nil {
 		return nil, err
 	}
-	data = make([]byte, 8)
-	binary.LittleEndian.PutUint64(data, uint64(len(e2sData)))
+	data = make([]byte, drlwe.ShareHeaderLen+8)
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint64(data[drlwe.ShareHeaderLen:], uint64(len(e2sData)))
 	data = append(data, e2sData...)
 	data = append(data, s2eData...)
 	return data, nil
@@ -78,8 +88,22 @@ func (share *MaskedTransformShare) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decodes a marshaled RefreshShare on the target RefreshShare.
 func (share *MaskedTransformShare) UnmarshalBinary(data []byte) error {
 
+	ptr, err := share.Header.Decode(data)
+	if err != nil {
+		return err
+	}
+	data = data[ptr:]
+
+	if len(data) < 8 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
 	e2sDataLen := binary.LittleEndian.Uint64(data[:8])
 
+	if e2sDataLen > uint64(len(data)-8) {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
 	if err := share.e2sShare.UnmarshalBinary(data[8 : e2sDataLen+8]); err != nil {
 		return err
 	}
@@ -106,12 +130,81 @@ func NewMaskedTransformProtocol(params ckks.Parameters, precision int, sigmaSmud
 		rfp.tmpMask[i] = new(big.Int)
 	}
 	rfp.encoder = ckks.NewEncoderBigComplex(params, precision)
+	rfp.header = drlwe.NewShareHeader(drlwe.ProtocolCKKSMaskedTransform, params)
 	return
 }
 
 // AllocateShare allocates the shares of the PermuteProtocol
 func (rfp *MaskedTransformProtocol) AllocateShare(levelDecrypt, levelRecrypt int) *MaskedTransformShare {
-	return &MaskedTransformShare{*rfp.e2s.AllocateShare(levelDecrypt), *rfp.s2e.AllocateShare(levelRecrypt)}
+	return &MaskedTransformShare{
+		Header:   rfp.header,
+		e2sShare: *rfp.e2s.AllocateShare(levelDecrypt),
+		s2eShare: *rfp.s2e.AllocateShare(levelRecrypt),
+	}
+}
+
+// MatrixTransform returns the MaskedTransformFunc applying the public complex matrix given in diagonal form, i.e. in the
+// format of ckks.GenLinearTransform, to the 2^logSlots plaintext slots: the i-th slot of the output is the sum over the
+// non-zero diagonals k of matrix[k][i] * m_{(i+k) mod 2^logSlots}, where m_j is the j-th slot of the input and k can be
+// negative. The matrix is applied with the precision of the protocol, to the masks of the parties and to the masked
+// plaintext, which enables e.g. a collective homomorphic DFT during the refresh. It returns an error if a diagonal does
+// not have 2^logSlots values or if two diagonals are equal modulo 2^logSlots.
+func (rfp *MaskedTransformProtocol) MatrixTransform(matrix map[int][]complex128, logSlots int) (MaskedTransformFunc, error) {
+
+	slots := 1 << logSlots
+	prec := rfp.precision
+
+	diags := make(map[int][]*ring.Complex, len(matrix))
+	for k, diag := range matrix {
+
+		if len(diag) != slots {
+			return nil, fmt.Errorf("cannot MatrixTransform: diagonal %d has %d values instead of %d", k, len(diag), slots)
+		}
+
+		rot := k % slots
+		if rot < 0 {
+			rot += slots
+		}
+
+		if _, ok := diags[rot]; ok {
+			return nil, fmt.Errorf("cannot MatrixTransform: diagonal %d is given twice", rot)
+		}
+
+		diags[rot] = make([]*ring.Complex, slots)
+		for i, v := range diag {
+			diags[rot][i] = ring.NewComplex(ring.NewFloat(real(v), prec), ring.NewFloat(imag(v), prec))
+		}
+	}
+
+	// The diagonals are accumulated in the same order by all the parties
+	rots := make([]int, 0, len(diags))
+	for rot := range diags {
+		rots = append(rots, rot)
+	}
+	sort.Ints(rots)
+
+	return func(values []*ring.Complex) {
+
+		cMul := ring.NewComplexMultiplier()
+		tmp := ring.NewComplex(ring.NewFloat(0, prec), ring.NewFloat(0, prec))
+
+		out := make([]*ring.Complex, slots)
+		for i := range out {
+			out[i] = ring.NewComplex(ring.NewFloat(0, prec), ring.NewFloat(0, prec))
+		}
+
+		for _, rot := range rots {
+			diag := diags[rot]
+			for i := range out {
+				cMul.Mul(diag[i], values[(i+rot)&(slots-1)], tmp)
+				out[i].Add(out[i], tmp)
+			}
+		}
+
+		for i := range out {
+			values[i].Set(out[i])
+		}
+	}, nil
 }
 
 // SampleCRP samples a common random polynomial to be used in the Masked-Transform protocol from the provided
@@ -129,6 +222,24 @@ func (rfp *MaskedTransformProtocol) SampleCRP(level int, crs utils.PRNG) drlwe.C
 // The method "GetMinimumLevelForBootstrapping" should be used to get the minimum level at which the masked transform can be called while still ensure 128-bits of security, as well as the
 // value for logBound.
 func (rfp *MaskedTransformProtocol) GenShare(sk *rlwe.SecretKey, logBound, logSlots int, ct1 *ring.Poly, scale float64, crs drlwe.CKSCRP, transform MaskedTransformFunc, shareOut *MaskedTransformShare) {
+	rfp.genShare(sk, logBound, logSlots, ct1, scale, rfp.defaultScale, crs, transform, shareOut)
+}
+
+// GenShareAtScale generates the shares of the PermuteProtocol for an output ciphertext at the scale outputScale
+// instead of the default scale, e.g. the scale expected by the next stage of a circuit. It requires the same
+// additional inputs as GenShare, and all the parties and the TransformAtScale method must use the same outputScale.
+func (rfp *MaskedTransformProtocol) GenShareAtScale(sk *rlwe.SecretKey, logBound, logSlots int, ct1 *ring.Poly, scale, outputScale float64, crs drlwe.CKSCRP, transform MaskedTransformFunc, shareOut *MaskedTransformShare) {
+	rfp.genShare(sk, logBound, logSlots, ct1, scale, rfp.scaleToBigint(outputScale), crs, transform, shareOut)
+}
+
+// scaleToBigint returns the scale as a big.Int rounded with the precision of the protocol.
+func (rfp *MaskedTransformProtocol) scaleToBigint(scale float64) (scaleInt *big.Int) {
+	scaleInt = new(big.Int)
+	ring.NewFloat(scale, rfp.precision).Int(scaleInt)
+	return
+}
+
+func (rfp *MaskedTransformProtocol) genShare(sk *rlwe.SecretKey, logBound, logSlots int, ct1 *ring.Poly, scale float64, outputScale *big.Int, crs drlwe.CKSCRP, transform MaskedTransformFunc, shareOut *MaskedTransformShare) {
 
 	ringQ := rfp.s2e.params.RingQ()
 
@@ -205,7 +316,7 @@ func (rfp *MaskedTransformProtocol) GenShare(sk *rlwe.SecretKey, logBound, logSl
 
 	// Scales the mask by the ratio between the two scales
 	for i := 0; i < dslots; i++ {
-		rfp.tmpMask[i].Mul(rfp.tmpMask[i], rfp.defaultScale)
+		rfp.tmpMask[i].Mul(rfp.tmpMask[i], outputScale)
 		rfp.tmpMask[i].Quo(rfp.tmpMask[i], inputScaleInt)
 	}
 
@@ -231,8 +342,18 @@ func (rfp *MaskedTransformProtocol) AggregateShare(share1, share2, shareOut *Mas
 }
 
 // Transform applies Decrypt, Recode and Recrypt on the input ciphertext.
-// The ciphertext scale is reset to the default scale.
+// The output ciphertext is at the level of crs and its scale is reset to the default scale.
 func (rfp *MaskedTransformProtocol) Transform(ct *ckks.Ciphertext, logSlots int, transform MaskedTransformFunc, crs drlwe.CKSCRP, share *MaskedTransformShare, ciphertextOut *ckks.Ciphertext) {
+	rfp.transform(ct, logSlots, transform, crs, share, rfp.e2s.params.DefaultScale(), rfp.defaultScale, ciphertextOut)
+}
+
+// TransformAtScale applies Decrypt, Recode and Recrypt on the input ciphertext from the shares generated with
+// GenShareAtScale for the same outputScale. The output ciphertext is at the level of crs and at the scale outputScale.
+func (rfp *MaskedTransformProtocol) TransformAtScale(ct *ckks.Ciphertext, logSlots int, transform MaskedTransformFunc, crs drlwe.CKSCRP, share *MaskedTransformShare, outputScale float64, ciphertextOut *ckks.Ciphertext) {
+	rfp.transform(ct, logSlots, transform, crs, share, outputScale, rfp.scaleToBigint(outputScale), ciphertextOut)
+}
+
+func (rfp *MaskedTransformProtocol) transform(ct *ckks.Ciphertext, logSlots int, transform MaskedTransformFunc, crs drlwe.CKSCRP, share *MaskedTransformShare, outputScale float64, outputScaleInt *big.Int, ciphertextOut *ckks.Ciphertext) {
 
 	if ct.Level() < share.e2sShare.Value.Level() {
 		panic("input ciphertext level must be at least equal to e2s level")
@@ -309,11 +430,17 @@ func (rfp *MaskedTransformProtocol) Transform(ct *ckks.Ciphertext, logSlots int,
 	ring.NewFloat(ct.Scale, 256).Int(inputScaleInt)
 
 	// Scales the mask by the ratio between the two scales
-	for i := 0; i < slots; i++ {
-		rfp.tmpMask[i].Mul(rfp.tmpMask[i], rfp.defaultScale)
+	for i := 0; i < dslots; i++ {
+		rfp.tmpMask[i].Mul(rfp.tmpMask[i], outputScaleInt)
 		rfp.tmpMask[i].Quo(rfp.tmpMask[i], inputScaleInt)
 	}
 
+	// Drops the levels of the ciphertext above the output level
+	if ciphertextOut.Level() > maxLevel {
+		ciphertextOut.Value[0].Coeffs = ciphertextOut.Value[0].Coeffs[:maxLevel+1]
+		ciphertextOut.Value[1].Coeffs = ciphertextOut.Value[1].Coeffs[:maxLevel+1]
+	}
+
 	// Extend the levels of the ciphertext for future allocation
 	for ciphertextOut.Level() != maxLevel {
 		level := ciphertextOut.Level() + 1
@@ -337,5 +464,5 @@ func (rfp *MaskedTransformProtocol) Transform(ct *ckks.Ciphertext, logSlots int,
 	// Copies the result on the out ciphertext
 	rfp.s2e.GetEncryption(&drlwe.CKSShare{Value: ciphertextOut.Value[0]}, crs, ciphertextOut)
 
-	ciphertextOut.Scale = rfp.e2s.params.DefaultScale()
+	ciphertextOut.Scale = outputScale
 }
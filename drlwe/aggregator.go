@@ -0,0 +1,194 @@
+package drlwe
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AggregateFunc aggregates the shares share1 and share2 of a multiparty protocol and returns the result in shareOut,
+// e.g. func(share1, share2, shareOut interface{}) { ckg.AggregateShare(share1.(*CKGShare), share2.(*CKGShare), shareOut.(*CKGShare)) }.
+type AggregateFunc func(share1, share2, shareOut interface{})
+
+// ShareAggregator aggregates the shares of a round of a multiparty protocol while tolerating unresponsive parties.
+// It tracks which parties contributed a share, and supports the withdrawal and the replacement of a share until the
+// aggregation is finalized. The aggregation can be finalized once all the active parties have contributed.
+//
+// With an N-out-of-N sharing of the secret key, all the parties are active and must contribute. With a T-out-of-N
+// threshold sharing (see Thresholdizer and Combiner), the shares are generated from the additive shares of the secret
+// key with respect to a set of at least T active parties, and a single unresponsive party does not stall the protocol:
+// the set of active parties is changed with SetActiveParties, which discards the shares generated for the previous
+// set, and the active parties regenerate their shares from their additive shares with respect to the new set.
+type ShareAggregator struct {
+	parties   map[ShamirPublicPoint]bool
+	threshold int
+	aggregate AggregateFunc
+
+	active map[ShamirPublicPoint]bool
+	shares map[ShamirPublicPoint]interface{}
+
+	commitments *ShareCommitments
+}
+
+// NewShareAggregator creates a new ShareAggregator for the parties of public points parties, among which at least
+// threshold parties must be active, and with the aggregation function aggregate. All the parties are initially active.
+// It returns an error if the public points are not non-zero and distinct or if threshold is not in [1, len(parties)].
+func NewShareAggregator(parties []ShamirPublicPoint, threshold int, aggregate AggregateFunc) (*ShareAggregator, error) {
+
+	if err := checkPublicPoints(parties); err != nil {
+		return nil, fmt.Errorf("cannot NewShareAggregator: %w", err)
+	}
+
+	if threshold < 1 || threshold > len(parties) {
+		return nil, fmt.Errorf("cannot NewShareAggregator: threshold %d is not in [1, %d]", threshold, len(parties))
+	}
+
+	agg := &ShareAggregator{
+		parties:   make(map[ShamirPublicPoint]bool, len(parties)),
+		threshold: threshold,
+		aggregate: aggregate,
+		active:    make(map[ShamirPublicPoint]bool, len(parties)),
+		shares:    make(map[ShamirPublicPoint]interface{}, len(parties)),
+	}
+
+	for _, p := range parties {
+		agg.parties[p] = true
+		agg.active[p] = true
+	}
+
+	return agg, nil
+}
+
+// SetActiveParties sets the active parties, whose shares are aggregated, and discards all the shares received so far.
+// It returns an error if there are less than threshold active parties, or if an active party is unknown or duplicated.
+func (agg *ShareAggregator) SetActiveParties(active []ShamirPublicPoint) error {
+
+	if err := checkPublicPoints(active); err != nil {
+		return fmt.Errorf("cannot SetActiveParties: %w", err)
+	}
+
+	if len(active) < agg.threshold {
+		return fmt.Errorf("cannot SetActiveParties: %d active parties but the threshold is %d", len(active), agg.threshold)
+	}
+
+	for _, p := range active {
+		if !agg.parties[p] {
+			return fmt.Errorf("cannot SetActiveParties: unknown party %d", p)
+		}
+	}
+
+	agg.active = make(map[ShamirPublicPoint]bool, len(active))
+	for _, p := range active {
+		agg.active[p] = true
+	}
+
+	agg.shares = make(map[ShamirPublicPoint]interface{}, len(active))
+
+	return nil
+}
+
+// ActiveParties returns the public points of the active parties, in increasing order.
+func (agg *ShareAggregator) ActiveParties() []ShamirPublicPoint {
+	return sortedPoints(agg.active, func(p ShamirPublicPoint) bool { return true })
+}
+
+// SetCommitments sets the commitments against which the shares are checked before they are added or replaced (see
+// ShareCommitments), or disables the check if commitments is nil. The commitments must be reset when the shares are
+// regenerated, e.g. along with the active parties or before a regenerated share is replaced.
+func (agg *ShareAggregator) SetCommitments(commitments *ShareCommitments) {
+	agg.commitments = commitments
+}
+
+// Add adds the share of the party. The share is not copied and must not be modified until the aggregation is
+// finalized. It returns an error if the party is not active or has already contributed, in which case Replace
+// must be used, and an error wrapping an IdentifiedAbortError if the share does not match the commitment of the party
+// set with SetCommitments. If the parties attach a ShareProof to their shares, it must be verified before the share
+// is added.
+func (agg *ShareAggregator) Add(party ShamirPublicPoint, share interface{}) error {
+
+	if !agg.active[party] {
+		return fmt.Errorf("cannot Add: party %d is not active", party)
+	}
+
+	if _, ok := agg.shares[party]; ok {
+		return fmt.Errorf("cannot Add: party %d has already contributed", party)
+	}
+
+	if err := verifyCommitted(agg.commitments, party, share); err != nil {
+		return fmt.Errorf("cannot Add: %w", err)
+	}
+
+	agg.shares[party] = share
+
+	return nil
+}
+
+// Replace replaces the share of the party by share. It returns an error if the party has not contributed.
+func (agg *ShareAggregator) Replace(party ShamirPublicPoint, share interface{}) error {
+
+	if _, ok := agg.shares[party]; !ok {
+		return fmt.Errorf("cannot Replace: party %d has not contributed", party)
+	}
+
+	if err := verifyCommitted(agg.commitments, party, share); err != nil {
+		return fmt.Errorf("cannot Replace: %w", err)
+	}
+
+	agg.shares[party] = share
+
+	return nil
+}
+
+// Withdraw removes the share of the party. It returns an error if the party has not contributed.
+func (agg *ShareAggregator) Withdraw(party ShamirPublicPoint) error {
+
+	if _, ok := agg.shares[party]; !ok {
+		return fmt.Errorf("cannot Withdraw: party %d has not contributed", party)
+	}
+
+	delete(agg.shares, party)
+
+	return nil
+}
+
+// Contributors returns the public points of the active parties that have contributed, in increasing order.
+func (agg *ShareAggregator) Contributors() []ShamirPublicPoint {
+	return sortedPoints(agg.active, func(p ShamirPublicPoint) bool { _, ok := agg.shares[p]; return ok })
+}
+
+// Missing returns the public points of the active parties that have not contributed, in increasing order.
+func (agg *ShareAggregator) Missing() []ShamirPublicPoint {
+	return sortedPoints(agg.active, func(p ShamirPublicPoint) bool { _, ok := agg.shares[p]; return !ok })
+}
+
+// Ready returns true if all the active parties have contributed.
+func (agg *ShareAggregator) Ready() bool {
+	return len(agg.shares) == len(agg.active)
+}
+
+// Finalize aggregates the shares of all the active parties and adds the result to shareOut, which must be a zero
+// share, e.g. allocated by the AllocateShare method of the protocol. It returns an error listing the missing parties
+// if not all the active parties have contributed.
+func (agg *ShareAggregator) Finalize(shareOut interface{}) error {
+
+	if !agg.Ready() {
+		return fmt.Errorf("cannot Finalize: missing shares of the parties %v", agg.Missing())
+	}
+
+	for _, p := range agg.Contributors() {
+		agg.aggregate(shareOut, agg.shares[p], shareOut)
+	}
+
+	return nil
+}
+
+// sortedPoints returns the public points of set that satisfy filter, in increasing order.
+func sortedPoints(set map[ShamirPublicPoint]bool, filter func(p ShamirPublicPoint) bool) (points []ShamirPublicPoint) {
+	points = []ShamirPublicPoint{}
+	for p := range set {
+		if filter(p) {
+			points = append(points, p)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	return
+}
@@ -0,0 +1,242 @@
+package drlwe
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// PartyShare is a share of a party received by a StreamAggregator.
+type PartyShare struct {
+	Party ShamirPublicPoint
+	Share interface{}
+}
+
+// StreamAggregator aggregates the shares of a round of a multiparty protocol as they arrive, e.g. from the handlers of
+// an event-driven server or from a channel, instead of collecting all the shares before aggregating them. Each share is
+// aggregated as soon as it is received, and Done is closed once the shares of all the parties have been aggregated. A
+// StreamAggregator can be used concurrently by several goroutines.
+//
+// The CKGAggregator, RKGAggregator, RTGAggregator, CKSAggregator and PCKSAggregator types are StreamAggregators for the
+// protocols of this package, created by the NewAggregator method of the protocols.
+type StreamAggregator struct {
+	mu          sync.Mutex
+	aggregate   AggregateFunc
+	shareOut    interface{}
+	parties     []ShamirPublicPoint
+	pending     map[ShamirPublicPoint]bool
+	done        chan struct{}
+	commitments *ShareCommitments
+}
+
+// NewStreamAggregator creates a new StreamAggregator for the parties of public points parties, which aggregates their
+// shares in shareOut with the aggregation function aggregate. shareOut must be a zero share, e.g. allocated by the
+// AllocateShare method of the protocol, and must not be read until Done is closed. It returns an error if the public
+// points are not non-zero and distinct.
+func NewStreamAggregator(parties []ShamirPublicPoint, shareOut interface{}, aggregate AggregateFunc) (*StreamAggregator, error) {
+
+	if err := checkPublicPoints(parties); err != nil {
+		return nil, fmt.Errorf("cannot NewStreamAggregator: %w", err)
+	}
+
+	agg := &StreamAggregator{
+		aggregate: aggregate,
+		shareOut:  shareOut,
+		parties:   sortPoints(parties),
+		pending:   make(map[ShamirPublicPoint]bool, len(parties)),
+		done:      make(chan struct{}),
+	}
+
+	for _, p := range parties {
+		agg.pending[p] = true
+	}
+
+	if len(parties) == 0 {
+		close(agg.done)
+	}
+
+	return agg, nil
+}
+
+// SetCommitments sets the commitments against which the shares are checked before they are aggregated (see
+// ShareCommitments), or disables the check if commitments is nil.
+func (agg *StreamAggregator) SetCommitments(commitments *ShareCommitments) {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+	agg.commitments = commitments
+}
+
+// Put aggregates the share of the party. The share can be reused once Put returns. It returns an error if the party is
+// unknown or if its share was already aggregated, and an error wrapping an IdentifiedAbortError if the share does not
+// match the commitment of the party set with SetCommitments. If the parties attach a ShareProof to their shares, it
+// must be verified before the share is put.
+func (agg *StreamAggregator) Put(party ShamirPublicPoint, share interface{}) error {
+
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	if !agg.pending[party] {
+		return fmt.Errorf("cannot Put: party %d is unknown or has already contributed", party)
+	}
+
+	if err := verifyCommitted(agg.commitments, party, share); err != nil {
+		return fmt.Errorf("cannot Put: %w", err)
+	}
+
+	agg.aggregate(agg.shareOut, share, agg.shareOut)
+	delete(agg.pending, party)
+
+	if len(agg.pending) == 0 {
+		close(agg.done)
+	}
+
+	return nil
+}
+
+// Consume puts the shares received from the channel shares until the shares of all the parties have been aggregated. It
+// returns an error if ctx is done or shares is closed before, or if a share cannot be put.
+func (agg *StreamAggregator) Consume(ctx context.Context, shares <-chan PartyShare) error {
+	for {
+		select {
+		case <-agg.done:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("cannot Consume: missing shares of the parties %v: %w", agg.Missing(), ctx.Err())
+		case s, ok := <-shares:
+			if !ok {
+				return fmt.Errorf("cannot Consume: channel closed with missing shares of the parties %v", agg.Missing())
+			}
+			if err := agg.Put(s.Party, s.Share); err != nil {
+				return fmt.Errorf("cannot Consume: %w", err)
+			}
+		}
+	}
+}
+
+// Done returns a channel that is closed once the shares of all the parties have been aggregated.
+func (agg *StreamAggregator) Done() <-chan struct{} {
+	return agg.done
+}
+
+// Wait blocks until the shares of all the parties have been aggregated and returns the aggregated share. It returns an
+// error listing the missing parties if ctx is done before.
+func (agg *StreamAggregator) Wait(ctx context.Context) (shareOut interface{}, err error) {
+	select {
+	case <-agg.done:
+		return agg.shareOut, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("cannot Wait: missing shares of the parties %v: %w", agg.Missing(), ctx.Err())
+	}
+}
+
+// Missing returns the public points of the parties whose share was not aggregated yet, in increasing order.
+func (agg *StreamAggregator) Missing() []ShamirPublicPoint {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+	return sortedPoints(agg.pending, func(p ShamirPublicPoint) bool { return true })
+}
+
+// CKGAggregator is a StreamAggregator of CKG shares.
+type CKGAggregator struct {
+	*StreamAggregator
+}
+
+// NewAggregator creates a new CKGAggregator of the shares of the parties in shareOut, which must be a zero share.
+func (ckg *CKGProtocol) NewAggregator(parties []ShamirPublicPoint, shareOut *CKGShare) (*CKGAggregator, error) {
+	agg, err := NewStreamAggregator(parties, shareOut, func(share1, share2, shareOut interface{}) {
+		ckg.AggregateShare(share1.(*CKGShare), share2.(*CKGShare), shareOut.(*CKGShare))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CKGAggregator{agg}, nil
+}
+
+// Put aggregates the CKG share of the party.
+func (agg *CKGAggregator) Put(party ShamirPublicPoint, share *CKGShare) error {
+	return agg.StreamAggregator.Put(party, share)
+}
+
+// RKGAggregator is a StreamAggregator of RKG shares, for one of the two rounds of the RKG protocol.
+type RKGAggregator struct {
+	*StreamAggregator
+}
+
+// NewAggregator creates a new RKGAggregator of the shares of the parties in shareOut, which must be a zero share.
+func (rkg *RKGProtocol) NewAggregator(parties []ShamirPublicPoint, shareOut *RKGShare) (*RKGAggregator, error) {
+	agg, err := NewStreamAggregator(parties, shareOut, func(share1, share2, shareOut interface{}) {
+		rkg.AggregateShare(share1.(*RKGShare), share2.(*RKGShare), shareOut.(*RKGShare))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RKGAggregator{agg}, nil
+}
+
+// Put aggregates the RKG share of the party.
+func (agg *RKGAggregator) Put(party ShamirPublicPoint, share *RKGShare) error {
+	return agg.StreamAggregator.Put(party, share)
+}
+
+// RTGAggregator is a StreamAggregator of RTG shares.
+type RTGAggregator struct {
+	*StreamAggregator
+}
+
+// NewAggregator creates a new RTGAggregator of the shares of the parties in shareOut, which must be a zero share.
+func (rtg *RTGProtocol) NewAggregator(parties []ShamirPublicPoint, shareOut *RTGShare) (*RTGAggregator, error) {
+	agg, err := NewStreamAggregator(parties, shareOut, func(share1, share2, shareOut interface{}) {
+		rtg.AggregateShare(share1.(*RTGShare), share2.(*RTGShare), shareOut.(*RTGShare))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RTGAggregator{agg}, nil
+}
+
+// Put aggregates the RTG share of the party.
+func (agg *RTGAggregator) Put(party ShamirPublicPoint, share *RTGShare) error {
+	return agg.StreamAggregator.Put(party, share)
+}
+
+// CKSAggregator is a StreamAggregator of CKS shares.
+type CKSAggregator struct {
+	*StreamAggregator
+}
+
+// NewAggregator creates a new CKSAggregator of the shares of the parties in shareOut, which must be a zero share.
+func (cks *CKSProtocol) NewAggregator(parties []ShamirPublicPoint, shareOut *CKSShare) (*CKSAggregator, error) {
+	agg, err := NewStreamAggregator(parties, shareOut, func(share1, share2, shareOut interface{}) {
+		cks.AggregateShare(share1.(*CKSShare), share2.(*CKSShare), shareOut.(*CKSShare))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CKSAggregator{agg}, nil
+}
+
+// Put aggregates the CKS share of the party.
+func (agg *CKSAggregator) Put(party ShamirPublicPoint, share *CKSShare) error {
+	return agg.StreamAggregator.Put(party, share)
+}
+
+// PCKSAggregator is a StreamAggregator of PCKS shares.
+type PCKSAggregator struct {
+	*StreamAggregator
+}
+
+// NewAggregator creates a new PCKSAggregator of the shares of the parties in shareOut, which must be a zero share.
+func (pcks *PCKSProtocol) NewAggregator(parties []ShamirPublicPoint, shareOut *PCKSShare) (*PCKSAggregator, error) {
+	agg, err := NewStreamAggregator(parties, shareOut, func(share1, share2, shareOut interface{}) {
+		pcks.AggregateShare(share1.(*PCKSShare), share2.(*PCKSShare), shareOut.(*PCKSShare))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PCKSAggregator{agg}, nil
+}
+
+// Put aggregates the PCKS share of the party.
+func (agg *PCKSAggregator) Put(party ShamirPublicPoint, share *PCKSShare) error {
+	return agg.StreamAggregator.Put(party, share)
+}
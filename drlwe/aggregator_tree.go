@@ -0,0 +1,211 @@
+package drlwe
+
+import (
+	"context"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// AggregationNode is a node of an aggregation tree, in which the shares of a round of a multiparty protocol are
+// aggregated hierarchically instead of by a single node that receives the shares of all the parties: each node
+// aggregates the shares of its leaves and the partial aggregates forwarded by its children, e.g. with a
+// StreamAggregator created by its NewAggregator method, and forwards the partial aggregate of its subtree to its
+// parent (see StreamAggregator.PutPartial and StreamAggregator.Partial). The root obtains the aggregation of the shares
+// of all the parties. With a fan-in f, each node receives at most f shares or partial aggregates, and the tree of n
+// parties has a depth of about log_f(n).
+type AggregationNode struct {
+	Parties  []ShamirPublicPoint // the parties of the subtree of the node, in increasing order
+	Leaves   []ShamirPublicPoint // the parties that send their share to the node, in increasing order
+	Children []*AggregationNode  // the nodes that forward their partial aggregate to the node
+}
+
+// NewAggregationTree creates a balanced aggregation tree of the parties of public points parties, in which each node
+// has at most fanIn leaves or children, and returns its root. It returns an error if there is no party, if the public
+// points are not non-zero and distinct or if fanIn is smaller than 2.
+func NewAggregationTree(parties []ShamirPublicPoint, fanIn int) (root *AggregationNode, err error) {
+
+	if len(parties) == 0 {
+		return nil, errors.New("cannot NewAggregationTree: there is no party")
+	}
+
+	if err = checkPublicPoints(parties); err != nil {
+		return nil, fmt.Errorf("cannot NewAggregationTree: %w", err)
+	}
+
+	if fanIn < 2 {
+		return nil, fmt.Errorf("cannot NewAggregationTree: fanIn %d is smaller than 2", fanIn)
+	}
+
+	parties = sortPoints(parties)
+
+	nodes := []*AggregationNode{}
+	for i := 0; i < len(parties); i += fanIn {
+		leaves := parties[i:utils.MinInt(i+fanIn, len(parties))]
+		nodes = append(nodes, &AggregationNode{
+			Parties: append([]ShamirPublicPoint(nil), leaves...),
+			Leaves:  append([]ShamirPublicPoint(nil), leaves...),
+		})
+	}
+
+	for len(nodes) > 1 {
+		parents := []*AggregationNode{}
+		for i := 0; i < len(nodes); i += fanIn {
+			parent := &AggregationNode{Leaves: []ShamirPublicPoint{}}
+			for _, child := range nodes[i:utils.MinInt(i+fanIn, len(nodes))] {
+				parent.Parties = append(parent.Parties, child.Parties...)
+				parent.Children = append(parent.Children, child)
+			}
+			parents = append(parents, parent)
+		}
+		nodes = parents
+	}
+
+	return nodes[0], nil
+}
+
+// Depth returns the depth of the subtree of the node, i.e. 1 for a node without children.
+func (node *AggregationNode) Depth() int {
+	depth := 0
+	for _, child := range node.Children {
+		if d := child.Depth(); d > depth {
+			depth = d
+		}
+	}
+	return depth + 1
+}
+
+// Node returns the node of the subtree of the node to which the party sends its share, or nil if the party is not in
+// the subtree.
+func (node *AggregationNode) Node(party ShamirPublicPoint) *AggregationNode {
+
+	for _, p := range node.Leaves {
+		if p == party {
+			return node
+		}
+	}
+
+	for _, child := range node.Children {
+		if n := child.Node(party); n != nil {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// NewAggregator creates a new StreamAggregator of the shares of the parties of the subtree of the node in shareOut,
+// which must be a zero share, with the aggregation function aggregate. The shares of its leaves are aggregated with
+// Put and the partial aggregates of its children with PutPartial.
+func (node *AggregationNode) NewAggregator(shareOut interface{}, aggregate AggregateFunc) (*StreamAggregator, error) {
+	return NewStreamAggregator(node.Parties, shareOut, aggregate)
+}
+
+// PartialAggregate is the aggregation of the shares of a subset of the parties, which an intermediate node of an
+// aggregation tree forwards to its parent.
+type PartialAggregate struct {
+	Parties []ShamirPublicPoint
+	Share   interface{}
+}
+
+// MarshalBinary encodes a PartialAggregate on a slice of bytes. It returns an error if the share does not implement
+// encoding.BinaryMarshaler.
+func (partial *PartialAggregate) MarshalBinary() (data []byte, err error) {
+
+	share, ok := partial.Share.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("cannot MarshalBinary: share of type %T cannot be marshaled", partial.Share)
+	}
+
+	var shareData []byte
+	if shareData, err = share.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	data = make([]byte, 8+8*len(partial.Parties), 8+8*len(partial.Parties)+len(shareData))
+	binary.LittleEndian.PutUint64(data, uint64(len(partial.Parties)))
+	for i, p := range partial.Parties {
+		binary.LittleEndian.PutUint64(data[8+8*i:], uint64(p))
+	}
+
+	return append(data, shareData...), nil
+}
+
+// UnmarshalBinary decodes a marshaled PartialAggregate on the target PartialAggregate, whose Share must be set to the
+// target share, e.g. allocated by the AllocateShare method of the protocol. It returns an error if the share does not
+// implement encoding.BinaryUnmarshaler.
+func (partial *PartialAggregate) UnmarshalBinary(data []byte) error {
+
+	share, ok := partial.Share.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("cannot UnmarshalBinary: share of type %T cannot be unmarshaled", partial.Share)
+	}
+
+	if len(data) < 8 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	nParties := binary.LittleEndian.Uint64(data)
+
+	if nParties > uint64(len(data)-8)/8 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	partial.Parties = make([]ShamirPublicPoint, nParties)
+	for i := range partial.Parties {
+		partial.Parties[i] = ShamirPublicPoint(binary.LittleEndian.Uint64(data[8+8*i:]))
+	}
+
+	return share.UnmarshalBinary(data[8+8*nParties:])
+}
+
+// PutPartial aggregates the partial aggregate of the shares of a subset of the parties, e.g. forwarded by a child in an
+// aggregation tree. The share can be reused once PutPartial returns. It returns an error if the subset is empty, or if
+// one of its parties is duplicated, unknown or has already contributed. The partial aggregate is not checked against
+// the commitments set with SetCommitments, which must be checked by the node that receives the share of each party.
+func (agg *StreamAggregator) PutPartial(partial *PartialAggregate) error {
+
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	if len(partial.Parties) == 0 {
+		return errors.New("cannot PutPartial: the partial aggregate has no party")
+	}
+
+	if err := checkPublicPoints(partial.Parties); err != nil {
+		return fmt.Errorf("cannot PutPartial: %w", err)
+	}
+
+	for _, p := range partial.Parties {
+		if !agg.pending[p] {
+			return fmt.Errorf("cannot PutPartial: party %d is unknown or has already contributed", p)
+		}
+	}
+
+	agg.aggregate(agg.shareOut, partial.Share, agg.shareOut)
+	for _, p := range partial.Parties {
+		delete(agg.pending, p)
+	}
+
+	if len(agg.pending) == 0 {
+		close(agg.done)
+	}
+
+	return nil
+}
+
+// Partial blocks until the shares of all the parties have been aggregated and returns their partial aggregate, to be
+// forwarded to the parent of the node in an aggregation tree. It returns an error listing the missing parties if ctx
+// is done before.
+func (agg *StreamAggregator) Partial(ctx context.Context) (*PartialAggregate, error) {
+
+	share, err := agg.Wait(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Partial: %w", err)
+	}
+
+	return &PartialAggregate{Parties: append([]ShamirPublicPoint(nil), agg.parties...), Share: share}, nil
+}
@@ -0,0 +1,123 @@
+package drlwe
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"golang.org/x/crypto/blake2b"
+)
+
+// The shares of a party can be made auditable by deriving all the randomness of the party in a session, i.e. its
+// secret key share and the randomness of its shares, from a SessionSeed, to which it commits before the session. If
+// the session fails, e.g. because the collective keys are malformed, the parties open their seeds, which are checked
+// against their commitments, and every party regenerates the shares of the others from their seeds to identify the
+// parties that did not generate their shares correctly (see AuditShare). This is a pragmatic accountability mechanism
+// short of the ShareProofs: the shares are not verified during the session, and opening the seed reveals the secret key
+// share derived from it, hence the session must be restarted with new seeds after an audit.
+
+// SeedCommitment is the commitment of a party to its SessionSeed for a session.
+type SeedCommitment [blake2b.Size256]byte
+
+// SessionSeed is the secret seed from which a party derives its secret key share and the randomness of its shares in
+// a session.
+type SessionSeed struct {
+	Seed []byte
+}
+
+// NewSessionSeed samples a new random SessionSeed.
+func NewSessionSeed() *SessionSeed {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	s := &SessionSeed{Seed: make([]byte, 64)}
+	prng.Clock(s.Seed)
+	return s
+}
+
+// Commit returns the commitment of the party to the seed for the session, which must be sent to all the other parties
+// before the session starts.
+func (s *SessionSeed) Commit(party ShamirPublicPoint, session []byte) (cmt SeedCommitment) {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	h.Write([]byte("SessionSeed"))
+	writePublicPoint(h, party)
+	h.Write(session)
+	h.Write(s.Seed)
+	copy(cmt[:], h.Sum(nil))
+	return
+}
+
+// ProtocolSeed returns the seed of the protocol instance identified by label, e.g. "CKG" or "RTG/5", to be given to
+// the constructor of the protocol with the WithSeed option. Each protocol instance of the session must have a distinct
+// label.
+func (s *SessionSeed) ProtocolSeed(label string) []byte {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	h.Write([]byte("ProtocolSeed"))
+	h.Write(s.Seed)
+	h.Write([]byte(label))
+	return h.Sum(nil)
+}
+
+// GenSecretKey generates the ternary secret key share of the party for the session from the seed.
+func (s *SessionSeed) GenSecretKey(params rlwe.Parameters) (sk *rlwe.SecretKey) {
+
+	sampler := ring.NewTernarySampler(newPRNG(s.ProtocolSeed("SecretKey"), "SecretKey", 0), params.RingQ(), 1.0/3, false)
+
+	sk = rlwe.NewSecretKey(params)
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	sampler.Read(sk.Value.Q)
+	if levelP > -1 {
+		ringQP := params.RingQP()
+		ringQP.ExtendBasisSmallNormAndCenter(sk.Value.Q, levelP, nil, sk.Value.P)
+		ringQP.NTTLvl(levelQ, levelP, sk.Value, sk.Value)
+		ringQP.MFormLvl(levelQ, levelP, sk.Value, sk.Value)
+	} else {
+		params.RingQ().NTT(sk.Value.Q, sk.Value.Q)
+		params.RingQ().MForm(sk.Value.Q, sk.Value.Q)
+	}
+
+	return
+}
+
+// AuditShare checks the share sent by the party in the session against the seed it opened: the seed must match the
+// commitment cmt of the party, and the share must be equal to the share regenerated by regen from the seed, e.g. by
+// generating the secret key share with SessionSeed.GenSecretKey and the share with a protocol seeded with
+// SessionSeed.ProtocolSeed, with the same inputs as in the session. It returns an IdentifiedAbortError identifying the
+// party if the seed or the share are invalid, or an error if regen does or if a share cannot be marshaled.
+func AuditShare(party ShamirPublicPoint, session []byte, cmt SeedCommitment, seed *SessionSeed, share Share, regen func(seed *SessionSeed) (Share, error)) error {
+
+	if seed.Commit(party, session) != cmt {
+		return &IdentifiedAbortError{Parties: []ShamirPublicPoint{party}, Reason: "invalid session seed"}
+	}
+
+	expected, err := regen(seed)
+	if err != nil {
+		return fmt.Errorf("cannot AuditShare: %w", err)
+	}
+
+	dataExpected, err := expected.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("cannot AuditShare: %w", err)
+	}
+
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("cannot AuditShare: %w", err)
+	}
+
+	if !bytes.Equal(data, dataExpected) {
+		return &IdentifiedAbortError{Parties: []ShamirPublicPoint{party}, Reason: "share not generated from the session seed"}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package drlwe
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// When the output of a protocol, e.g. a collective key or a key-switched ciphertext, fails its validation, the shares
+// received in the round are tested to identify the parties that contributed a malformed share, which are reported in
+// an IdentifiedAbortError for accountability:
+//  - individually, with a ShareTest such as the verification of the ShareProof of the share (see CKSProtocol.ProofTest)
+//    or of its hash commitment (see ShareCommitments.Verify), with the Identify method of the ShareAggregator,
+//  - by bisection, with an AggregateTest on the aggregation of the shares of subsets of the parties, such as the
+//    comparison with the aggregation of their SecretKeyCommitments (see CKGProtocol.CommitmentTest), with the Bisect
+//    method of the ShareAggregator, which only tests O(f*log(n)) subsets of the n parties to identify f faulty parties.
+
+// SubsetTest reports whether the shares of a subset of the parties are well formed. It must fail for a subset if and
+// only if it fails for one of its halves.
+type SubsetTest func(parties []ShamirPublicPoint) (ok bool, err error)
+
+// ShareTest returns an error if the share of the party is malformed.
+type ShareTest func(party ShamirPublicPoint, share interface{}) error
+
+// AggregateTest reports whether the aggregation of the shares of a subset of the parties is well formed.
+type AggregateTest func(parties []ShamirPublicPoint, aggregated interface{}) (ok bool, err error)
+
+// Bisect identifies the parties whose shares fail the test, by testing the set of all the parties and recursively
+// bisecting the subsets that fail it. It returns the identified parties in increasing order, which are empty if the
+// test succeeds for the set of all the parties, and an error if the test returns an error.
+// For a test that compares the aggregation of the shares of the subset to a reference, e.g. an AggregateTest, each
+// identified party contributed a malformed share, but malformed shares whose deviations cancel each other in a subset
+// may go undetected.
+func Bisect(parties []ShamirPublicPoint, test SubsetTest) (faulty []ShamirPublicPoint, err error) {
+
+	faulty = []ShamirPublicPoint{}
+
+	if len(parties) == 0 {
+		return faulty, nil
+	}
+
+	var ok bool
+	if ok, err = test(parties); err != nil {
+		return nil, fmt.Errorf("cannot Bisect: %w", err)
+	}
+
+	if ok {
+		return faulty, nil
+	}
+
+	stack := [][]ShamirPublicPoint{sortPoints(parties)}
+
+	for len(stack) != 0 {
+
+		subset := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if len(subset) == 1 {
+			faulty = append(faulty, subset[0])
+			continue
+		}
+
+		// The subset fails the test: the halves that fail it are bisected
+		for _, half := range [][]ShamirPublicPoint{subset[:len(subset)/2], subset[len(subset)/2:]} {
+			if ok, err = test(half); err != nil {
+				return nil, fmt.Errorf("cannot Bisect: %w", err)
+			}
+			if !ok {
+				stack = append(stack, half)
+			}
+		}
+	}
+
+	return sortPoints(faulty), nil
+}
+
+// Identify tests the share of each party that contributed with the test, and returns an IdentifiedAbortError
+// identifying the parties whose share fails it, or nil if all the shares pass it.
+func (agg *ShareAggregator) Identify(test ShareTest) error {
+
+	faulty := []ShamirPublicPoint{}
+	for _, p := range agg.Contributors() {
+		if test(p, agg.shares[p]) != nil {
+			faulty = append(faulty, p)
+		}
+	}
+
+	if len(faulty) != 0 {
+		return &IdentifiedAbortError{Parties: faulty, Reason: "malformed share"}
+	}
+
+	return nil
+}
+
+// Bisect identifies the parties that contributed a malformed share by bisection: the shares of the subsets of the
+// parties that contributed are aggregated on the zero share returned by newShare, e.g. the AllocateShare method of the
+// protocol, and tested with the test (see the Bisect function). It returns an IdentifiedAbortError identifying these
+// parties, nil if the aggregation of all the shares passes the test, or an error if the test returns an error.
+func (agg *ShareAggregator) Bisect(newShare func() interface{}, test AggregateTest) error {
+
+	faulty, err := Bisect(agg.Contributors(), func(parties []ShamirPublicPoint) (bool, error) {
+		aggregated := newShare()
+		for _, p := range parties {
+			agg.aggregate(aggregated, agg.shares[p], aggregated)
+		}
+		return test(parties, aggregated)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if len(faulty) != 0 {
+		return &IdentifiedAbortError{Parties: faulty, Reason: "malformed share"}
+	}
+
+	return nil
+}
+
+// CommitmentTest returns the AggregateTest of the aggregated *CKGShare of a subset of the parties against the
+// aggregation of their SecretKeyCommitments, i.e. of the CKG shares they committed to beforehand, e.g. to identify the
+// parties whose share does not match their commitment when the collective public key does not match the commitments.
+// The test returns an error if the commitment of a party is missing or is not for the common reference polynomial crp.
+func (ckg *CKGProtocol) CommitmentTest(crp CKGCRP, commitments map[ShamirPublicPoint]*SecretKeyCommitment) AggregateTest {
+	return func(parties []ShamirPublicPoint, aggregated interface{}) (bool, error) {
+
+		share, ok := aggregated.(*CKGShare)
+		if !ok {
+			return false, fmt.Errorf("cannot CommitmentTest: invalid share type %T", aggregated)
+		}
+
+		sum := ckg.AllocateShare()
+		for _, p := range parties {
+			cmt, ok := commitments[p]
+			if !ok {
+				return false, fmt.Errorf("cannot CommitmentTest: missing commitment of the party %d", p)
+			}
+			if crpCmt := rlwe.PolyQP(cmt.CRP); !crpCmt.Equals(rlwe.PolyQP(crp)) {
+				return false, fmt.Errorf("cannot CommitmentTest: the commitment of the party %d is not for the common reference polynomial", p)
+			}
+			ckg.AggregateShare(sum, cmt.Share, sum)
+		}
+
+		return sum.Value.Equals(share.Value), nil
+	}
+}
+
+// ProofTest returns the ShareTest of the *CKSShares of the parties for the ciphertext element c1 against their
+// ShareProofs and their commitments to their input and output secret keys, as in AggregateVerifiedShares. The
+// commitments to the output secret keys are nil for a collective decryption.
+func (cks *CKSProtocol) ProofTest(c1 *ring.Poly, proofs map[ShamirPublicPoint]*ShareProof, cmtInput, cmtOutput map[ShamirPublicPoint]*SecretKeyCommitment) ShareTest {
+	return func(party ShamirPublicPoint, share interface{}) error {
+
+		cksShare, ok := share.(*CKSShare)
+		if !ok {
+			return fmt.Errorf("cannot ProofTest: invalid share type %T", share)
+		}
+
+		proof, okProof := proofs[party]
+		cmtIn, okIn := cmtInput[party]
+		cmtOut, okOut := cmtOutput[party]
+
+		if !okProof || !okIn || (cmtOutput != nil && !okOut) {
+			return &IdentifiedAbortError{Parties: []ShamirPublicPoint{party}, Reason: "missing proof or commitment"}
+		}
+
+		return cks.VerifyShare(c1, cksShare, cmtIn, cmtOut, proof)
+	}
+}
@@ -0,0 +1,263 @@
+package drlwe
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils/cbor"
+)
+
+// The CBOR serialization of the shares is an alternative to their binary serialization for the parties that are not
+// written in Go (see rlwe.CBORVersion): the shares are objects of type "drlwe.CKGShare", "drlwe.RKGShare", ...,
+// with the share "value" and the "header" map of the ShareHeader, with the keys "version", "protocol" and "params".
+// Unmarshaling a share checks its header as UnmarshalBinary does.
+
+// newShareObject returns the CBOR object of a share of the given type.
+func newShareObject(typ string, h ShareHeader, value interface{}) map[string]interface{} {
+	m := cbor.NewObject(typ, rlwe.CBORVersion)
+	m["header"] = map[string]interface{}{
+		"version":  uint64(h.Version),
+		"protocol": uint64(h.Protocol),
+		"params":   append([]byte{}, h.Params[:]...),
+	}
+	m["value"] = value
+	return m
+}
+
+// decodeShareObject decodes the header of the CBOR object of a share of the given type on h, with the semantics of
+// ShareHeader.Decode, and returns the value of the share.
+func decodeShareObject(v interface{}, typ string, h *ShareHeader) (value interface{}, err error) {
+
+	o, err := cbor.AsTypedObject(v, typ, rlwe.CBORVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := o.Object("header")
+	if err != nil {
+		return nil, err
+	}
+
+	var version, protocol uint64
+	var params []byte
+	if version, err = header.Uint("version"); err == nil {
+		if protocol, err = header.Uint("protocol"); err == nil {
+			params, err = header.Bytes("params")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if version > 0xff || protocol > 0xff || len(params) != ParametersDigestLen {
+		return nil, errors.New("invalid share header")
+	}
+
+	data := make([]byte, ShareHeaderLen)
+	data[0], data[1] = uint8(version), uint8(protocol)
+	copy(data[2:], params)
+
+	if _, err = h.Decode(data); err != nil {
+		return nil, err
+	}
+
+	return o.Value("value")
+}
+
+// unmarshalCBOR decodes the CBOR data of a share with setCBORValue.
+func unmarshalCBOR(data []byte, setCBORValue func(v interface{}) error) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return setCBORValue(v)
+}
+
+// CBORValue returns the value encoding the share in the CBOR serialization: an object of type "drlwe.CKGShare" whose
+// value is a polynomial of QP.
+func (share *CKGShare) CBORValue() (interface{}, error) {
+	return newShareObject("drlwe.CKGShare", share.Header, &share.Value), nil
+}
+
+// SetCBORValue sets the share to the decoded CBOR value v generated by CBORValue.
+func (share *CKGShare) SetCBORValue(v interface{}) (err error) {
+	if v, err = decodeShareObject(v, "drlwe.CKGShare", &share.Header); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+	return share.Value.SetCBORValue(v)
+}
+
+// MarshalCBOR encodes the share in the CBOR serialization.
+func (share *CKGShare) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(share)
+}
+
+// UnmarshalCBOR decodes the share encoded by MarshalCBOR.
+func (share *CKGShare) UnmarshalCBOR(data []byte) error {
+	return unmarshalCBOR(data, share.SetCBORValue)
+}
+
+// CBORValue returns the value encoding the share in the CBOR serialization: an object of type "drlwe.RKGShare" whose
+// value is the array of the pairs of polynomials of QP of the elements of the decomposition basis.
+func (share *RKGShare) CBORValue() (interface{}, error) {
+	value := make([]interface{}, len(share.Value))
+	for i := range value {
+		value[i] = []interface{}{&share.Value[i][0], &share.Value[i][1]}
+	}
+	return newShareObject("drlwe.RKGShare", share.Header, value), nil
+}
+
+// SetCBORValue sets the share to the decoded CBOR value v generated by CBORValue.
+func (share *RKGShare) SetCBORValue(v interface{}) (err error) {
+
+	if v, err = decodeShareObject(v, "drlwe.RKGShare", &share.Header); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	value, err := cbor.AsArray(v)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	share.Value = make([][2]rlwe.PolyQP, len(value))
+	for i := range value {
+
+		var pair []interface{}
+		if pair, err = cbor.AsArray(value[i]); err != nil {
+			return fmt.Errorf("cannot SetCBORValue: %w", err)
+		}
+
+		if len(pair) != 2 {
+			return errors.New("cannot SetCBORValue: expected a pair of polynomials")
+		}
+
+		for j := range pair {
+			if err = share.Value[i][j].SetCBORValue(pair[j]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the share in the CBOR serialization.
+func (share *RKGShare) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(share)
+}
+
+// UnmarshalCBOR decodes the share encoded by MarshalCBOR.
+func (share *RKGShare) UnmarshalCBOR(data []byte) error {
+	return unmarshalCBOR(data, share.SetCBORValue)
+}
+
+// CBORValue returns the value encoding the share in the CBOR serialization: an object of type "drlwe.RTGShare" whose
+// value is the array of the polynomials of QP of the elements of the decomposition basis.
+func (share *RTGShare) CBORValue() (interface{}, error) {
+	value := make([]interface{}, len(share.Value))
+	for i := range value {
+		value[i] = &share.Value[i]
+	}
+	return newShareObject("drlwe.RTGShare", share.Header, value), nil
+}
+
+// SetCBORValue sets the share to the decoded CBOR value v generated by CBORValue.
+func (share *RTGShare) SetCBORValue(v interface{}) (err error) {
+
+	if v, err = decodeShareObject(v, "drlwe.RTGShare", &share.Header); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	value, err := cbor.AsArray(v)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	share.Value = make([]rlwe.PolyQP, len(value))
+	for i := range value {
+		if err = share.Value[i].SetCBORValue(value[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the share in the CBOR serialization.
+func (share *RTGShare) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(share)
+}
+
+// UnmarshalCBOR decodes the share encoded by MarshalCBOR.
+func (share *RTGShare) UnmarshalCBOR(data []byte) error {
+	return unmarshalCBOR(data, share.SetCBORValue)
+}
+
+// CBORValue returns the value encoding the share in the CBOR serialization: an object of type "drlwe.CKSShare" whose
+// value is a polynomial of Q.
+func (share *CKSShare) CBORValue() (interface{}, error) {
+	return newShareObject("drlwe.CKSShare", share.Header, share.Value), nil
+}
+
+// SetCBORValue sets the share to the decoded CBOR value v generated by CBORValue.
+func (share *CKSShare) SetCBORValue(v interface{}) (err error) {
+	if v, err = decodeShareObject(v, "drlwe.CKSShare", &share.Header); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+	share.Value = new(ring.Poly)
+	return share.Value.SetCBORValue(v)
+}
+
+// MarshalCBOR encodes the share in the CBOR serialization.
+func (share *CKSShare) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(share)
+}
+
+// UnmarshalCBOR decodes the share encoded by MarshalCBOR.
+func (share *CKSShare) UnmarshalCBOR(data []byte) error {
+	return unmarshalCBOR(data, share.SetCBORValue)
+}
+
+// CBORValue returns the value encoding the share in the CBOR serialization: an object of type "drlwe.PCKSShare" whose
+// value is a pair of polynomials of Q.
+func (share *PCKSShare) CBORValue() (interface{}, error) {
+	return newShareObject("drlwe.PCKSShare", share.Header, []interface{}{share.Value[0], share.Value[1]}), nil
+}
+
+// SetCBORValue sets the share to the decoded CBOR value v generated by CBORValue.
+func (share *PCKSShare) SetCBORValue(v interface{}) (err error) {
+
+	if v, err = decodeShareObject(v, "drlwe.PCKSShare", &share.Header); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	value, err := cbor.AsArray(v)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	if len(value) != 2 {
+		return errors.New("cannot SetCBORValue: expected a pair of polynomials")
+	}
+
+	for i := range value {
+		share.Value[i] = new(ring.Poly)
+		if err = share.Value[i].SetCBORValue(value[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the share in the CBOR serialization.
+func (share *PCKSShare) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(share)
+}
+
+// UnmarshalCBOR decodes the share encoded by MarshalCBOR.
+func (share *PCKSShare) UnmarshalCBOR(data []byte) error {
+	return unmarshalCBOR(data, share.SetCBORValue)
+}
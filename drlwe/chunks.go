@@ -0,0 +1,235 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ShareChunkHeaderLen is the length in bytes of the header of a chunk of a marshaled share: the digest of the whole
+// share, the length of the share, the index of the chunk, the number of chunks and the checksum of the chunk.
+const ShareChunkHeaderLen = blake2b.Size256 + 8 + 4 + 4 + blake2b.Size256
+
+// maxShareChunks bounds the number of chunks of a marshaled share.
+const maxShareChunks = 1 << 20
+
+// MarshalChunks marshals the share and splits it into chunks of at most maxChunkSize bytes, headers included, which
+// can be sent as separate messages over a transport with a message size limit, e.g. a message bus, and reassembled in
+// any order with a ShareReassembler. Each chunk carries the digest of the whole share, its index, the number of chunks
+// and its own checksum, so that the corrupted, duplicated or foreign chunks are detected on reassembly. The payload of
+// a chunk is of maxChunkSize - ShareChunkHeaderLen bytes, except for the last chunk. It returns an error if
+// maxChunkSize is not larger than ShareChunkHeaderLen or if the share cannot be marshaled.
+func MarshalChunks(share Share, maxChunkSize int) (chunks [][]byte, err error) {
+
+	if maxChunkSize <= ShareChunkHeaderLen {
+		return nil, fmt.Errorf("cannot MarshalChunks: maxChunkSize must be larger than %d", ShareChunkHeaderLen)
+	}
+
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("cannot MarshalChunks: %w", err)
+	}
+
+	payload := maxChunkSize - ShareChunkHeaderLen
+	count := (len(data) + payload - 1) / payload
+	if count == 0 {
+		count = 1
+	}
+
+	if count > maxShareChunks {
+		return nil, errors.New("cannot MarshalChunks: too many chunks, maxChunkSize is too small")
+	}
+
+	digest := blake2b.Sum256(data)
+
+	// The chunks are laid out in a single buffer, to avoid an allocation per chunk
+	buf := make([]byte, count*ShareChunkHeaderLen+len(data))
+	chunks = make([][]byte, count)
+
+	for i := range chunks {
+
+		start, end := i*payload, (i+1)*payload
+		if end > len(data) {
+			end = len(data)
+		}
+
+		chunk := buf[:ShareChunkHeaderLen+end-start]
+		buf = buf[len(chunk):]
+
+		copy(chunk, digest[:])
+		binary.LittleEndian.PutUint64(chunk[blake2b.Size256:], uint64(len(data)))
+		binary.LittleEndian.PutUint32(chunk[blake2b.Size256+8:], uint32(i))
+		binary.LittleEndian.PutUint32(chunk[blake2b.Size256+12:], uint32(count))
+		copy(chunk[ShareChunkHeaderLen:], data[start:end])
+
+		checksum := chunkChecksum(chunk)
+		copy(chunk[blake2b.Size256+16:], checksum[:])
+
+		chunks[i] = chunk
+	}
+
+	return chunks, nil
+}
+
+// chunkChecksum returns the checksum of the chunk, i.e. the hash of its header, checksum excluded, and of its payload.
+func chunkChecksum(chunk []byte) [blake2b.Size256]byte {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	h.Write(chunk[:blake2b.Size256+16])
+	h.Write(chunk[ShareChunkHeaderLen:])
+	var sum [blake2b.Size256]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// ShareReassembler reassembles a share from the chunks generated by MarshalChunks, received in any order. The first
+// chunk added fixes the share to reassemble: the chunks of another share are rejected. A ShareReassembler is not safe
+// for concurrent use.
+type ShareReassembler struct {
+	digest   [blake2b.Size256]byte
+	data     []byte
+	received []bool
+	missing  int
+	payload  int
+}
+
+// NewShareReassembler creates a new ShareReassembler.
+func NewShareReassembler() *ShareReassembler {
+	return new(ShareReassembler)
+}
+
+// Add adds a chunk to the reassembler and returns true if all the chunks of the share were added. It returns an error,
+// and ignores the chunk, if its checksum is invalid, if it is a chunk of another share, or if it was already added.
+func (sr *ShareReassembler) Add(chunk []byte) (complete bool, err error) {
+
+	if len(chunk) < ShareChunkHeaderLen {
+		return sr.missing == 0 && sr.received != nil, errors.New("cannot Add: chunk is too short")
+	}
+
+	var checksum [blake2b.Size256]byte
+	copy(checksum[:], chunk[blake2b.Size256+16:])
+	if chunkChecksum(chunk) != checksum {
+		return sr.missing == 0 && sr.received != nil, errors.New("cannot Add: invalid chunk checksum")
+	}
+
+	var digest [blake2b.Size256]byte
+	copy(digest[:], chunk)
+	length := binary.LittleEndian.Uint64(chunk[blake2b.Size256:])
+	index := int(binary.LittleEndian.Uint32(chunk[blake2b.Size256+8:]))
+	count := int(binary.LittleEndian.Uint32(chunk[blake2b.Size256+12:]))
+	payload := chunk[ShareChunkHeaderLen:]
+
+	if sr.received == nil {
+
+		if count == 0 || count > maxShareChunks || index >= count {
+			return false, errors.New("cannot Add: invalid chunk index")
+		}
+
+		sr.digest = digest
+		sr.data = make([]byte, length)
+		sr.received = make([]bool, count)
+		sr.missing = count
+		sr.payload = -1
+	}
+
+	if digest != sr.digest || uint64(len(sr.data)) != length || len(sr.received) != count {
+		return sr.missing == 0, errors.New("cannot Add: chunk of another share")
+	}
+
+	if index >= count {
+		return sr.missing == 0, errors.New("cannot Add: invalid chunk index")
+	}
+
+	if sr.received[index] {
+		return sr.missing == 0, fmt.Errorf("cannot Add: duplicated chunk %d", index)
+	}
+
+	// The length of the payload of all the chunks but the last one is fixed by the first of them that is added
+	if index != count-1 && sr.payload == -1 {
+		if uint64(len(payload))*uint64(count-1) >= length || uint64(len(payload))*uint64(count) < length {
+			return sr.missing == 0, errors.New("cannot Add: invalid chunk length")
+		}
+		sr.payload = len(payload)
+	}
+
+	var start int
+	if index != count-1 {
+		if len(payload) != sr.payload {
+			return sr.missing == 0, errors.New("cannot Add: invalid chunk length")
+		}
+		start = index * sr.payload
+	} else {
+		if uint64(len(payload)) > length || (sr.payload != -1 && uint64(sr.payload)*uint64(count-1)+uint64(len(payload)) != length) {
+			return sr.missing == 0, errors.New("cannot Add: invalid chunk length")
+		}
+		start = len(sr.data) - len(payload)
+	}
+
+	copy(sr.data[start:], payload)
+	sr.received[index] = true
+	sr.missing--
+
+	return sr.missing == 0, nil
+}
+
+// Missing returns the indexes of the chunks that were not added yet, or nil if no chunk was added.
+func (sr *ShareReassembler) Missing() (indexes []int) {
+	for i, ok := range sr.received {
+		if !ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return
+}
+
+// Unmarshal checks the digest of the reassembled share and decodes it on share. It returns an error if some chunks
+// are missing or if the digest does not match.
+func (sr *ShareReassembler) Unmarshal(share Share) error {
+
+	if sr.received == nil || sr.missing != 0 {
+		return errors.New("cannot Unmarshal: missing chunks")
+	}
+
+	if blake2b.Sum256(sr.data) != sr.digest {
+		return errors.New("cannot Unmarshal: share digest mismatch")
+	}
+
+	if err := share.UnmarshalBinary(sr.data); err != nil {
+		return fmt.Errorf("cannot Unmarshal: %w", err)
+	}
+
+	return nil
+}
+
+// UnmarshalChunks reassembles the share from its chunks generated by MarshalChunks, in any order, and decodes it on
+// share.
+func UnmarshalChunks(chunks [][]byte, share Share) error {
+
+	sr := NewShareReassembler()
+	for _, chunk := range chunks {
+		if _, err := sr.Add(chunk); err != nil {
+			return fmt.Errorf("cannot UnmarshalChunks: %w", err)
+		}
+	}
+
+	if err := sr.Unmarshal(share); err != nil {
+		return fmt.Errorf("cannot UnmarshalChunks: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalChunks marshals the share in chunks of at most maxChunkSize bytes (see MarshalChunks), e.g. to send the
+// shares of the RKG protocol for the bootstrapping parameters, which are of tens of megabytes, over a message bus.
+func (share *RKGShare) MarshalChunks(maxChunkSize int) ([][]byte, error) {
+	return MarshalChunks(share, maxChunkSize)
+}
+
+// UnmarshalChunks decodes on the share the chunks generated by MarshalChunks, in any order.
+func (share *RKGShare) UnmarshalChunks(chunks [][]byte) error {
+	return UnmarshalChunks(chunks, share)
+}
@@ -0,0 +1,164 @@
+package drlwe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// CollectiveEncryption is a convenience layer over the CKG, CKS and PCKS protocols for the applications that only need
+// the N-out-of-N threshold encryption and decryption, and none of the evaluation keys: the parties generate the
+// collective public key with GenPublicKey, anyone encrypts under it, and the parties collectively decrypt a ciphertext
+// to one of them with DecryptTo, or re-encrypt it under the public key of an external recipient with ReEncryptTo. Each
+// operation runs an Orchestrator of the protocol over the given Transport, which must not be shared with another
+// operation, e.g. a dsession Transport of a distinct domain for each operation.
+type CollectiveEncryption struct {
+	params        rlwe.Parameters
+	sk            *rlwe.SecretKey
+	self          ShamirPublicPoint
+	parties       []ShamirPublicPoint
+	sigmaSmudging float64
+	pk            *rlwe.PublicKey
+}
+
+// NewCollectiveEncryption creates a new CollectiveEncryption for the party of public point self and secret key share
+// sk among the parties of public points parties. sigmaSmudging is the standard deviation of the smudging noise of the
+// decryptions and re-encryptions, e.g. computed with SmudgingParams. It returns an error if the public points are not
+// non-zero and distinct or if self is not among the parties.
+func NewCollectiveEncryption(params rlwe.Parameters, sk *rlwe.SecretKey, self ShamirPublicPoint, parties []ShamirPublicPoint, sigmaSmudging float64) (*CollectiveEncryption, error) {
+
+	if err := checkPublicPoints(parties); err != nil {
+		return nil, fmt.Errorf("cannot NewCollectiveEncryption: %w", err)
+	}
+
+	var isParty bool
+	for _, p := range parties {
+		isParty = isParty || p == self
+	}
+
+	if !isParty {
+		return nil, fmt.Errorf("cannot NewCollectiveEncryption: the party %d is not among the parties", self)
+	}
+
+	return &CollectiveEncryption{
+		params:        params,
+		sk:            sk,
+		self:          self,
+		parties:       append([]ShamirPublicPoint(nil), parties...),
+		sigmaSmudging: sigmaSmudging,
+	}, nil
+}
+
+// GenPublicKey runs the CKG protocol with the other parties, whose common random polynomial is sampled from crs, and
+// returns the collective public key, which is also set as the public key of the receiver.
+func (ce *CollectiveEncryption) GenPublicKey(ctx context.Context, transport Transport, crs CRS) (*rlwe.PublicKey, error) {
+
+	ckg := NewCKGProtocol(ce.params)
+	inputs := CKGInputs{SecretKey: ce.sk, CRP: ckg.SampleCRP(crs)}
+
+	output, err := ce.run(ctx, transport, CKGRounds{ckg}, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenPublicKey: %w", err)
+	}
+
+	ce.pk = output.(*rlwe.PublicKey)
+
+	return ce.pk, nil
+}
+
+// SetPublicKey sets the collective public key of the receiver, e.g. loaded from a previous run of GenPublicKey.
+func (ce *CollectiveEncryption) SetPublicKey(pk *rlwe.PublicKey) {
+	ce.pk = pk
+}
+
+// PublicKey returns the collective public key, or nil if it was not generated or set.
+func (ce *CollectiveEncryption) PublicKey() *rlwe.PublicKey {
+	return ce.pk
+}
+
+// NewEncryptor returns an rlwe.Encryptor under the collective public key. It returns an error if the collective public
+// key was not generated or set.
+func (ce *CollectiveEncryption) NewEncryptor() (rlwe.Encryptor, error) {
+	if ce.pk == nil {
+		return nil, errors.New("cannot NewEncryptor: the collective public key is not set")
+	}
+	return rlwe.NewEncryptor(ce.params, ce.pk), nil
+}
+
+// NewDecryptor returns an rlwe.Decryptor under the secret key share of the party, which decrypts the ciphertexts
+// returned by DecryptTo for the party.
+func (ce *CollectiveEncryption) NewDecryptor() rlwe.Decryptor {
+	return rlwe.NewDecryptor(ce.params, ce.sk)
+}
+
+// DecryptTo runs the CKS protocol with the other parties to decrypt the ciphertext ct of degree 1, encrypted under the
+// collective secret key, to the party of public point target: the key is switched from the collective secret key to
+// the secret key share of the target, so that only the target can decrypt the returned ciphertext, with NewDecryptor.
+// All the parties obtain the returned ciphertext. It returns an error if ct is not of degree 1 or if target is not
+// among the parties.
+func (ce *CollectiveEncryption) DecryptTo(ctx context.Context, transport Transport, ct *rlwe.Ciphertext, target ShamirPublicPoint) (*rlwe.Ciphertext, error) {
+
+	if ct.Degree() != 1 {
+		return nil, fmt.Errorf("cannot DecryptTo: the ciphertext must be of degree 1 but is of degree %d", ct.Degree())
+	}
+
+	if !ce.isParty(target) {
+		return nil, fmt.Errorf("cannot DecryptTo: the party %d is not among the parties", target)
+	}
+
+	// The target switches its share to itself, the other parties to zero.
+	skOut := rlwe.NewSecretKey(ce.params)
+	if target == ce.self {
+		skOut = ce.sk
+	}
+
+	inputs := CKSInputs{SecretKeyIn: ce.sk, SecretKeyOut: skOut, Ciphertext: ct}
+
+	output, err := ce.run(ctx, transport, CKSRounds{NewCKSProtocol(ce.params, ce.sigmaSmudging)}, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot DecryptTo: %w", err)
+	}
+
+	return output.(*rlwe.Ciphertext), nil
+}
+
+// ReEncryptTo runs the PCKS protocol with the other parties to re-encrypt the ciphertext ct of degree 1, encrypted
+// under the collective secret key, under the public key pk of a recipient, e.g. external to the parties. It returns an
+// error if ct is not of degree 1.
+func (ce *CollectiveEncryption) ReEncryptTo(ctx context.Context, transport Transport, ct *rlwe.Ciphertext, pk *rlwe.PublicKey) (*rlwe.Ciphertext, error) {
+
+	if ct.Degree() != 1 {
+		return nil, fmt.Errorf("cannot ReEncryptTo: the ciphertext must be of degree 1 but is of degree %d", ct.Degree())
+	}
+
+	inputs := PCKSInputs{SecretKey: ce.sk, PublicKey: pk, Ciphertext: ct}
+
+	output, err := ce.run(ctx, transport, PCKSRounds{NewPCKSProtocol(ce.params, ce.sigmaSmudging)}, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot ReEncryptTo: %w", err)
+	}
+
+	return output.(*rlwe.Ciphertext), nil
+}
+
+// run runs an Orchestrator of the protocol with the inputs of the party over transport.
+func (ce *CollectiveEncryption) run(ctx context.Context, transport Transport, protocol RoundProtocol, inputs interface{}) (output interface{}, err error) {
+
+	o, err := NewOrchestrator(protocol, ce.self, ce.parties)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Run(ctx, transport, inputs)
+}
+
+func (ce *CollectiveEncryption) isParty(party ShamirPublicPoint) bool {
+	for _, p := range ce.parties {
+		if p == party {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package drlwe
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ShareCommitment is the binding commitment of a party to its share in a round of a multiparty protocol, i.e. the hash
+// of its public point and of its marshaled share, which includes the ShareHeader of the share. The parties broadcast
+// the commitments to their shares before the shares, and the aggregators check the received shares against them, so
+// that a party sending different shares to different parties and the shares corrupted in transmission are detected
+// before they are aggregated. The commitment is not hiding, which the shares of the protocols, masked by their errors,
+// do not require.
+type ShareCommitment [blake2b.Size256]byte
+
+// CommitShare returns the commitment of the party to its share, generated by the GenShare method of the protocol.
+// It returns an error if the share cannot be marshaled.
+func CommitShare(party ShamirPublicPoint, share Share) (cmt ShareCommitment, err error) {
+
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return cmt, fmt.Errorf("cannot CommitShare: %w", err)
+	}
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	h.Write([]byte("ShareCommitment"))
+	writePublicPoint(h, party)
+	h.Write(data)
+	copy(cmt[:], h.Sum(nil))
+
+	return cmt, nil
+}
+
+// ShareCommitments records the commitments received from the parties in a round, against which their shares are
+// checked with Verify, or by the aggregators with SetCommitments. Once all the commitments are received, the parties
+// can compare their Digest, or echo their Commitments to each other and check them with CheckTranscripts, to detect a
+// party that sent different commitments to different parties. ShareCommitments can be used concurrently by several
+// goroutines.
+type ShareCommitments struct {
+	mu          sync.RWMutex
+	commitments map[ShamirPublicPoint]ShareCommitment
+}
+
+// NewShareCommitments creates a new empty ShareCommitments.
+func NewShareCommitments() *ShareCommitments {
+	return &ShareCommitments{commitments: map[ShamirPublicPoint]ShareCommitment{}}
+}
+
+// Add records the commitment received from the party. It returns an IdentifiedAbortError identifying the party if
+// another commitment of the party was already recorded.
+func (sc *ShareCommitments) Add(party ShamirPublicPoint, cmt ShareCommitment) error {
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if prev, ok := sc.commitments[party]; ok && prev != cmt {
+		return &IdentifiedAbortError{Parties: []ShamirPublicPoint{party}, Reason: "conflicting share commitments"}
+	}
+
+	sc.commitments[party] = cmt
+
+	return nil
+}
+
+// Verify checks the share received from the party against the commitment of the party. It returns an
+// IdentifiedAbortError identifying the party if its commitment is missing or does not match the share, in which case
+// the share must not be aggregated.
+func (sc *ShareCommitments) Verify(party ShamirPublicPoint, share Share) error {
+
+	sc.mu.RLock()
+	cmt, ok := sc.commitments[party]
+	sc.mu.RUnlock()
+
+	if !ok {
+		return &IdentifiedAbortError{Parties: []ShamirPublicPoint{party}, Reason: "missing share commitment"}
+	}
+
+	received, err := CommitShare(party, share)
+	if err != nil {
+		return fmt.Errorf("cannot Verify: %w", err)
+	}
+
+	if received != cmt {
+		return &IdentifiedAbortError{Parties: []ShamirPublicPoint{party}, Reason: "share does not match its commitment"}
+	}
+
+	return nil
+}
+
+// Commitments returns the recorded commitments as ShareDigests, e.g. to be echoed and checked with CheckTranscripts.
+func (sc *ShareCommitments) Commitments() map[ShamirPublicPoint]ShareDigest {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	commitments := make(map[ShamirPublicPoint]ShareDigest, len(sc.commitments))
+	for p, cmt := range sc.commitments {
+		commitments[p] = ShareDigest(cmt)
+	}
+	return commitments
+}
+
+// Digest returns the aggregate verification hash of the recorded commitments, which is the same for all the parties if
+// and only if they recorded the same commitments for the same parties.
+func (sc *ShareCommitments) Digest() (digest ShareDigest) {
+
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	h.Write([]byte("ShareCommitments"))
+
+	parties := make([]ShamirPublicPoint, 0, len(sc.commitments))
+	for p := range sc.commitments {
+		parties = append(parties, p)
+	}
+
+	for _, p := range sortPoints(parties) {
+		cmt := sc.commitments[p]
+		writePublicPoint(h, p)
+		h.Write(cmt[:])
+	}
+
+	copy(digest[:], h.Sum(nil))
+	return
+}
+
+// verifyCommitted checks the share of the party against the commitments, if not nil.
+func verifyCommitted(commitments *ShareCommitments, party ShamirPublicPoint, share interface{}) error {
+
+	if commitments == nil {
+		return nil
+	}
+
+	s, ok := share.(Share)
+	if !ok {
+		return fmt.Errorf("the share of party %d cannot be marshaled", party)
+	}
+
+	return commitments.Verify(party, s)
+}
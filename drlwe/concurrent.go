@@ -0,0 +1,289 @@
+package drlwe
+
+import (
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// The concurrent protocols are wrappers of the protocols that are safe for concurrent use, e.g. by a server processing
+// the shares of many sessions in parallel. Each call to a method of a wrapper runs the method of a ShallowCopy of the
+// wrapped protocol that is not used by any other call in progress, taken from a pool of copies which grows with the
+// number of concurrent calls, so that the wrapper never blocks. The calls must still not share their output elements.
+// The copies sample their randomness from fresh PRNGs, hence the shares generated by a wrapper of a protocol seeded
+// with the WithSeed option cannot be reproduced to audit them.
+
+// ConcurrentCKGProtocol is a CKGProtocol that is safe for concurrent use.
+type ConcurrentCKGProtocol struct {
+	pool sync.Pool
+}
+
+// NewConcurrentCKGProtocol creates a new ConcurrentCKGProtocol from the CKGProtocol ckg, which must not be used
+// afterwards.
+func NewConcurrentCKGProtocol(ckg *CKGProtocol) *ConcurrentCKGProtocol {
+	c := new(ConcurrentCKGProtocol)
+	c.pool.Put(ckg)
+	c.pool.New = func() interface{} {
+		return ckg.ShallowCopy()
+	}
+	return c
+}
+
+func (c *ConcurrentCKGProtocol) get() *CKGProtocol {
+	return c.pool.Get().(*CKGProtocol)
+}
+
+// AllocateShare allocates the share of the CKG protocol.
+func (c *ConcurrentCKGProtocol) AllocateShare() *CKGShare {
+	ckg := c.get()
+	defer c.pool.Put(ckg)
+	return ckg.AllocateShare()
+}
+
+// SampleCRP samples a common random polynomial to be used in the CKG protocol from the provided common reference
+// string.
+func (c *ConcurrentCKGProtocol) SampleCRP(crs CRS) CKGCRP {
+	ckg := c.get()
+	defer c.pool.Put(ckg)
+	return ckg.SampleCRP(crs)
+}
+
+// GenShare generates the party's public key share from its secret key (see CKGProtocol.GenShare).
+func (c *ConcurrentCKGProtocol) GenShare(sk *rlwe.SecretKey, crp CKGCRP, shareOut *CKGShare) {
+	ckg := c.get()
+	defer c.pool.Put(ckg)
+	ckg.GenShare(sk, crp, shareOut)
+}
+
+// AggregateShare aggregates a new share to the aggregate key.
+func (c *ConcurrentCKGProtocol) AggregateShare(share1, share2, shareOut *CKGShare) {
+	ckg := c.get()
+	defer c.pool.Put(ckg)
+	ckg.AggregateShare(share1, share2, shareOut)
+}
+
+// GenPublicKey return the current aggregation of the received shares as a public key.
+func (c *ConcurrentCKGProtocol) GenPublicKey(roundShare *CKGShare, crp CKGCRP, pubkey *rlwe.PublicKey) {
+	ckg := c.get()
+	defer c.pool.Put(ckg)
+	ckg.GenPublicKey(roundShare, crp, pubkey)
+}
+
+// ConcurrentRKGProtocol is a RKGProtocol that is safe for concurrent use.
+type ConcurrentRKGProtocol struct {
+	pool sync.Pool
+}
+
+// NewConcurrentRKGProtocol creates a new ConcurrentRKGProtocol from the RKGProtocol rkg, which must not be used
+// afterwards.
+func NewConcurrentRKGProtocol(rkg *RKGProtocol) *ConcurrentRKGProtocol {
+	c := new(ConcurrentRKGProtocol)
+	c.pool.Put(rkg)
+	c.pool.New = func() interface{} {
+		return rkg.ShallowCopy()
+	}
+	return c
+}
+
+func (c *ConcurrentRKGProtocol) get() *RKGProtocol {
+	return c.pool.Get().(*RKGProtocol)
+}
+
+// AllocateShare allocates the share of the RKG protocol.
+func (c *ConcurrentRKGProtocol) AllocateShare() (ephSk *rlwe.SecretKey, r1 *RKGShare, r2 *RKGShare) {
+	rkg := c.get()
+	defer c.pool.Put(rkg)
+	return rkg.AllocateShare()
+}
+
+// SampleCRP samples a common random polynomial to be used in the RKG protocol from the provided common reference
+// string.
+func (c *ConcurrentRKGProtocol) SampleCRP(crs CRS) RKGCRP {
+	rkg := c.get()
+	defer c.pool.Put(rkg)
+	return rkg.SampleCRP(crs)
+}
+
+// GenShareRoundOne generates the share of the party in the first round of the RKG protocol (see
+// RKGProtocol.GenShareRoundOne).
+func (c *ConcurrentRKGProtocol) GenShareRoundOne(sk *rlwe.SecretKey, crp RKGCRP, ephSkOut *rlwe.SecretKey, shareOut *RKGShare) {
+	rkg := c.get()
+	defer c.pool.Put(rkg)
+	rkg.GenShareRoundOne(sk, crp, ephSkOut, shareOut)
+}
+
+// GenShareRoundTwo generates the share of the party in the second round of the RKG protocol (see
+// RKGProtocol.GenShareRoundTwo).
+func (c *ConcurrentRKGProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *RKGShare, shareOut *RKGShare) {
+	rkg := c.get()
+	defer c.pool.Put(rkg)
+	rkg.GenShareRoundTwo(ephSk, sk, round1, shareOut)
+}
+
+// AggregateShare aggregates two shares of the same round in shareOut.
+func (c *ConcurrentRKGProtocol) AggregateShare(share1, share2, shareOut *RKGShare) {
+	rkg := c.get()
+	defer c.pool.Put(rkg)
+	rkg.AggregateShare(share1, share2, shareOut)
+}
+
+// GenRelinearizationKey computes the relinearization key from the aggregated shares of the two rounds.
+func (c *ConcurrentRKGProtocol) GenRelinearizationKey(round1 *RKGShare, round2 *RKGShare, evalKeyOut *rlwe.RelinearizationKey) {
+	rkg := c.get()
+	defer c.pool.Put(rkg)
+	rkg.GenRelinearizationKey(round1, round2, evalKeyOut)
+}
+
+// ConcurrentRTGProtocol is a RTGProtocol that is safe for concurrent use.
+type ConcurrentRTGProtocol struct {
+	pool sync.Pool
+}
+
+// NewConcurrentRTGProtocol creates a new ConcurrentRTGProtocol from the RTGProtocol rtg, which must not be used
+// afterwards.
+func NewConcurrentRTGProtocol(rtg *RTGProtocol) *ConcurrentRTGProtocol {
+	c := new(ConcurrentRTGProtocol)
+	c.pool.Put(rtg)
+	c.pool.New = func() interface{} {
+		return rtg.ShallowCopy()
+	}
+	return c
+}
+
+func (c *ConcurrentRTGProtocol) get() *RTGProtocol {
+	return c.pool.Get().(*RTGProtocol)
+}
+
+// AllocateShare allocates the share of the RTG protocol.
+func (c *ConcurrentRTGProtocol) AllocateShare() (rtgShare *RTGShare) {
+	rtg := c.get()
+	defer c.pool.Put(rtg)
+	return rtg.AllocateShare()
+}
+
+// SampleCRP samples a common random polynomial to be used in the RTG protocol from the provided common reference
+// string.
+func (c *ConcurrentRTGProtocol) SampleCRP(crs CRS) RTGCRP {
+	rtg := c.get()
+	defer c.pool.Put(rtg)
+	return rtg.SampleCRP(crs)
+}
+
+// GenShare generates the party's share of the rotation key of Galois element galEl (see RTGProtocol.GenShare).
+func (c *ConcurrentRTGProtocol) GenShare(sk *rlwe.SecretKey, galEl uint64, crp RTGCRP, shareOut *RTGShare) {
+	rtg := c.get()
+	defer c.pool.Put(rtg)
+	rtg.GenShare(sk, galEl, crp, shareOut)
+}
+
+// AggregateShare aggregates two shares in shareOut.
+func (c *ConcurrentRTGProtocol) AggregateShare(share1, share2, shareOut *RTGShare) {
+	rtg := c.get()
+	defer c.pool.Put(rtg)
+	rtg.AggregateShare(share1, share2, shareOut)
+}
+
+// GenRotationKey finalizes the RTG protocol and populates the input computed switching key.
+func (c *ConcurrentRTGProtocol) GenRotationKey(share *RTGShare, crp RTGCRP, rotKey *rlwe.SwitchingKey) {
+	rtg := c.get()
+	defer c.pool.Put(rtg)
+	rtg.GenRotationKey(share, crp, rotKey)
+}
+
+// ConcurrentCKSProtocol is a CKSProtocol that is safe for concurrent use.
+type ConcurrentCKSProtocol struct {
+	pool sync.Pool
+}
+
+// NewConcurrentCKSProtocol creates a new ConcurrentCKSProtocol from the CKSProtocol cks, which must not be used
+// afterwards.
+func NewConcurrentCKSProtocol(cks *CKSProtocol) *ConcurrentCKSProtocol {
+	c := new(ConcurrentCKSProtocol)
+	c.pool.Put(cks)
+	c.pool.New = func() interface{} {
+		return cks.ShallowCopy()
+	}
+	return c
+}
+
+func (c *ConcurrentCKSProtocol) get() *CKSProtocol {
+	return c.pool.Get().(*CKSProtocol)
+}
+
+// AllocateShare allocates the share of the CKS protocol at the given level.
+func (c *ConcurrentCKSProtocol) AllocateShare(level int) *CKSShare {
+	cks := c.get()
+	defer c.pool.Put(cks)
+	return cks.AllocateShare(level)
+}
+
+// GenShare computes the party's share of the key-switching of c1 from skInput to skOutput (see CKSProtocol.GenShare).
+func (c *ConcurrentCKSProtocol) GenShare(skInput, skOutput *rlwe.SecretKey, c1 *ring.Poly, shareOut *CKSShare) {
+	cks := c.get()
+	defer c.pool.Put(cks)
+	cks.GenShare(skInput, skOutput, c1, shareOut)
+}
+
+// AggregateShare aggregates two shares in shareOut.
+func (c *ConcurrentCKSProtocol) AggregateShare(share1, share2, shareOut *CKSShare) {
+	cks := c.get()
+	defer c.pool.Put(cks)
+	cks.AggregateShare(share1, share2, shareOut)
+}
+
+// KeySwitch switches the key of ctIn with the aggregated share and writes the result in ctOut.
+func (c *ConcurrentCKSProtocol) KeySwitch(ctIn *rlwe.Ciphertext, combined *CKSShare, ctOut *rlwe.Ciphertext) {
+	cks := c.get()
+	defer c.pool.Put(cks)
+	cks.KeySwitch(ctIn, combined, ctOut)
+}
+
+// ConcurrentPCKSProtocol is a PCKSProtocol that is safe for concurrent use.
+type ConcurrentPCKSProtocol struct {
+	pool sync.Pool
+}
+
+// NewConcurrentPCKSProtocol creates a new ConcurrentPCKSProtocol from the PCKSProtocol pcks, which must not be used
+// afterwards.
+func NewConcurrentPCKSProtocol(pcks *PCKSProtocol) *ConcurrentPCKSProtocol {
+	c := new(ConcurrentPCKSProtocol)
+	c.pool.Put(pcks)
+	c.pool.New = func() interface{} {
+		return pcks.ShallowCopy()
+	}
+	return c
+}
+
+func (c *ConcurrentPCKSProtocol) get() *PCKSProtocol {
+	return c.pool.Get().(*PCKSProtocol)
+}
+
+// AllocateShare allocates the share of the PCKS protocol at the given level.
+func (c *ConcurrentPCKSProtocol) AllocateShare(levelQ int) *PCKSShare {
+	pcks := c.get()
+	defer c.pool.Put(pcks)
+	return pcks.AllocateShare(levelQ)
+}
+
+// GenShare computes the party's share of the public key-switching of ct1 to the public key pk (see
+// PCKSProtocol.GenShare).
+func (c *ConcurrentPCKSProtocol) GenShare(sk *rlwe.SecretKey, pk *rlwe.PublicKey, ct1 *ring.Poly, shareOut *PCKSShare) {
+	pcks := c.get()
+	defer c.pool.Put(pcks)
+	pcks.GenShare(sk, pk, ct1, shareOut)
+}
+
+// AggregateShare aggregates two shares in shareOut.
+func (c *ConcurrentPCKSProtocol) AggregateShare(share1, share2, shareOut *PCKSShare) {
+	pcks := c.get()
+	defer c.pool.Put(pcks)
+	pcks.AggregateShare(share1, share2, shareOut)
+}
+
+// KeySwitch switches the key of ctIn with the aggregated share and writes the result in ctOut.
+func (c *ConcurrentPCKSProtocol) KeySwitch(ctIn *rlwe.Ciphertext, combined *PCKSShare, ctOut *rlwe.Ciphertext) {
+	pcks := c.get()
+	defer c.pool.Put(pcks)
+	pcks.KeySwitch(ctIn, combined, ctOut)
+}
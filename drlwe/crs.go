@@ -2,6 +2,7 @@ package drlwe
 
 import (
 	"github.com/ldsec/lattigo/v2/utils"
+	"golang.org/x/crypto/blake2b"
 )
 
 // CRS is an interface for Common Reference Strings.
@@ -10,3 +11,39 @@ import (
 type CRS interface {
 	utils.PRNG
 }
+
+// CRSDomain identifies a protocol instance whose common reference polynomials are derived from a master seed by
+// DeriveCRS: the name of the protocol, e.g. ProtocolCKG.String(), the identifier of the session, the round of the
+// protocol and the index of the instance within the session, e.g. the Galois element of an RTG instance.
+type CRSDomain struct {
+	Protocol string
+	Session  []byte
+	Round    int
+	Instance uint64
+}
+
+// DeriveCRS returns the CRS of the protocol instance of the domain derived from the master seed, e.g. a seed published
+// for the session. The CRSs of distinct domains are independent, so that a single seed can drive the CKG, RKG and all
+// the RTG instances of a session without reusing a common reference polynomial across protocol instances. The CRS is a
+// fresh PRNG: the parties must read the common reference polynomials of a domain in the same order.
+func DeriveCRS(seed []byte, domain CRSDomain) CRS {
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	writeBeaconBytes(h, []byte("lattigo/drlwe/DeriveCRS"))
+	writeBeaconBytes(h, seed)
+	writeBeaconBytes(h, []byte(domain.Protocol))
+	writeBeaconBytes(h, domain.Session)
+	writeBeaconUint64(h, uint64(domain.Round))
+	writeBeaconUint64(h, domain.Instance)
+
+	prng, err := utils.NewKeyedPRNG(h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+
+	return prng
+}
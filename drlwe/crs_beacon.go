@@ -0,0 +1,246 @@
+package drlwe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/ldsec/lattigo/v2/utils"
+	"golang.org/x/crypto/blake2b"
+)
+
+// BeaconRound is the output of a public randomness beacon for a round, e.g. of drand, whose Randomness is the SHA-256
+// digest of the Signature of the beacon on the round (and, for chained beacons, on the PreviousSignature).
+type BeaconRound struct {
+	Round             uint64
+	Randomness        []byte
+	Signature         []byte
+	PreviousSignature []byte
+}
+
+// BeaconVerifier verifies the signature of a BeaconRound under the public key of the beacon, e.g. with the drand
+// client library.
+type BeaconVerifier interface {
+	VerifyBeacon(beacon *BeaconRound) error
+}
+
+// BeaconVerifierFunc is a function implementing BeaconVerifier.
+type BeaconVerifierFunc func(beacon *BeaconRound) error
+
+// VerifyBeacon calls f(beacon).
+func (f BeaconVerifierFunc) VerifyBeacon(beacon *BeaconRound) error {
+	return f(beacon)
+}
+
+// BeaconCRS is a CRS seeded from the output of a public randomness beacon and from a context, e.g. the session
+// identifier and the protocol, which records the transcript of the bytes read from it. Since the beacon output is
+// unpredictable before its round and publicly verifiable after it, a CRS derived from a beacon round agreed upon before
+// it is published cannot be chosen adversarially, e.g. by the aggregator distributing the common reference polynomials,
+// and every party can check it independently with VerifyBeaconTranscript.
+type BeaconCRS struct {
+	prng       *utils.KeyedPRNG
+	transcript hash.Hash
+	context    []byte
+	beacon     BeaconRound
+}
+
+// NewBeaconCRS creates a new BeaconCRS from the context and the beacon round. It returns an error if the randomness of
+// the beacon round is not the SHA-256 digest of its signature, or if verifier rejects the beacon round.
+func NewBeaconCRS(context []byte, beacon *BeaconRound, verifier BeaconVerifier) (*BeaconCRS, error) {
+
+	if err := checkBeacon(beacon, verifier); err != nil {
+		return nil, fmt.Errorf("cannot NewBeaconCRS: %w", err)
+	}
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	writeBeaconBytes(h, []byte("lattigo/drlwe/BeaconCRS"))
+	writeBeaconBytes(h, context)
+	writeBeaconUint64(h, beacon.Round)
+	writeBeaconBytes(h, beacon.Randomness)
+
+	prng, err := utils.NewKeyedPRNG(h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+
+	transcript, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return &BeaconCRS{
+		prng:       prng,
+		transcript: transcript,
+		context:    append([]byte(nil), context...),
+		beacon:     copyBeacon(beacon),
+	}, nil
+}
+
+func checkBeacon(beacon *BeaconRound, verifier BeaconVerifier) error {
+
+	if verifier == nil {
+		return errors.New("no beacon verifier")
+	}
+
+	if digest := sha256.Sum256(beacon.Signature); !bytes.Equal(digest[:], beacon.Randomness) {
+		return fmt.Errorf("the randomness of the beacon round %d is not the digest of its signature", beacon.Round)
+	}
+
+	if err := verifier.VerifyBeacon(beacon); err != nil {
+		return fmt.Errorf("invalid beacon round %d: %w", beacon.Round, err)
+	}
+
+	return nil
+}
+
+// Clock reads bytes from the BeaconCRS on sum and records them in the transcript.
+func (crs *BeaconCRS) Clock(sum []byte) {
+	crs.prng.Clock(sum)
+	crs.transcript.Write(sum)
+}
+
+// GetClock returns the number of reads from the BeaconCRS.
+func (crs *BeaconCRS) GetClock() uint64 {
+	return crs.prng.GetClock()
+}
+
+// SetClock sets the number of reads from the BeaconCRS to n by reading and recording bytes on sum. It returns an
+// error if n is smaller than the current number of reads.
+func (crs *BeaconCRS) SetClock(sum []byte, n uint64) error {
+	if crs.GetClock() > n {
+		return errors.New("cannot SetClock: cannot set the clock to a previous state")
+	}
+	for crs.GetClock() != n {
+		crs.Clock(sum)
+	}
+	return nil
+}
+
+// Transcript returns the transcript of the BeaconCRS, i.e. its context and beacon round and the digest of the bytes
+// read from it so far. It is published with the common reference polynomials sampled from the BeaconCRS so that the
+// parties can check them with VerifyBeaconTranscript.
+func (crs *BeaconCRS) Transcript() *BeaconTranscript {
+	t := &BeaconTranscript{
+		Context: append([]byte(nil), crs.context...),
+		Beacon:  copyBeacon(&crs.beacon),
+		Reads:   crs.GetClock(),
+	}
+	copy(t.Digest[:], crs.transcript.Sum(nil))
+	return t
+}
+
+// BeaconTranscript is the transcript of a BeaconCRS.
+type BeaconTranscript struct {
+	Context []byte
+	Beacon  BeaconRound
+	Reads   uint64
+	Digest  [blake2b.Size256]byte
+}
+
+// VerifyBeaconTranscript checks that the transcript is the one of a BeaconCRS of the given context, seeded from a
+// valid beacon round not older than minRound, from which the common reference polynomials were sampled by replay:
+// replay is called on a new BeaconCRS and must read from it exactly as the sampling of the common reference
+// polynomials did, e.g. func(crs CRS) { ckg.SampleCRP(crs) }. minRound must be a round published after the context
+// was agreed upon, so that the beacon round could not be chosen among several.
+func VerifyBeaconTranscript(transcript *BeaconTranscript, context []byte, minRound uint64, verifier BeaconVerifier, replay func(crs CRS)) error {
+
+	if !bytes.Equal(transcript.Context, context) {
+		return errors.New("cannot VerifyBeaconTranscript: the transcript is for another context")
+	}
+
+	if transcript.Beacon.Round < minRound {
+		return fmt.Errorf("cannot VerifyBeaconTranscript: the beacon round %d is older than %d", transcript.Beacon.Round, minRound)
+	}
+
+	crs, err := NewBeaconCRS(context, &transcript.Beacon, verifier)
+	if err != nil {
+		return fmt.Errorf("cannot VerifyBeaconTranscript: %w", err)
+	}
+
+	replay(crs)
+
+	if replayed := crs.Transcript(); replayed.Reads != transcript.Reads || replayed.Digest != transcript.Digest {
+		return errors.New("cannot VerifyBeaconTranscript: the common reference polynomials do not match the transcript")
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes the transcript on a slice of bytes.
+func (t *BeaconTranscript) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 0, 8+4+len(t.Context)+3*4+len(t.Beacon.Randomness)+len(t.Beacon.Signature)+len(t.Beacon.PreviousSignature)+8+len(t.Digest))
+	data = appendBeaconUint64(data, t.Beacon.Round)
+	for _, b := range [][]byte{t.Context, t.Beacon.Randomness, t.Beacon.Signature, t.Beacon.PreviousSignature} {
+		if uint64(len(b)) > 0xFFFFFFFF {
+			return nil, errors.New("cannot MarshalBinary: field is too long")
+		}
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(len(b)))
+		data = append(data, buf[:]...)
+		data = append(data, b...)
+	}
+	data = appendBeaconUint64(data, t.Reads)
+	return append(data, t.Digest[:]...), nil
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target transcript.
+func (t *BeaconTranscript) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 8 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+	t.Beacon.Round = binary.LittleEndian.Uint64(data)
+	data = data[8:]
+
+	for _, b := range []*[]byte{&t.Context, &t.Beacon.Randomness, &t.Beacon.Signature, &t.Beacon.PreviousSignature} {
+		if len(data) < 4 {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+		l := uint64(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+		if l > uint64(len(data)) {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+		*b = append([]byte(nil), data[:l]...)
+		data = data[l:]
+	}
+
+	if len(data) != 8+len(t.Digest) {
+		return errors.New("cannot UnmarshalBinary: invalid data length")
+	}
+	t.Reads = binary.LittleEndian.Uint64(data)
+	copy(t.Digest[:], data[8:])
+
+	return nil
+}
+
+func copyBeacon(beacon *BeaconRound) BeaconRound {
+	return BeaconRound{
+		Round:             beacon.Round,
+		Randomness:        append([]byte(nil), beacon.Randomness...),
+		Signature:         append([]byte(nil), beacon.Signature...),
+		PreviousSignature: append([]byte(nil), beacon.PreviousSignature...),
+	}
+}
+
+func appendBeaconUint64(data []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(data, buf[:]...)
+}
+
+func writeBeaconUint64(h hash.Hash, v uint64) {
+	h.Write(appendBeaconUint64(nil, v))
+}
+
+func writeBeaconBytes(h hash.Hash, b []byte) {
+	writeBeaconUint64(h, uint64(len(b)))
+	h.Write(b)
+}
@@ -0,0 +1,183 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// PartialDecryption is the partial decryption of a ciphertext by a party in a publicly verifiable collective
+// decryption, i.e. its CKS share c1 * s_i + e_i for the zero output key, with the ShareProof that the share was
+// correctly formed from the secret key share committed to by the SecretKeyCommitment of the party. The partial
+// decryptions can be published: any observer knowing the collective public key and the SecretKeyCommitments of the
+// parties, i.e. their CKG shares, can verify them and recompute the decryption with VerifyDecryption.
+type PartialDecryption struct {
+	Party ShamirPublicPoint
+	Share *CKSShare
+	Proof *ShareProof
+}
+
+// GenPartialDecryption generates the PartialDecryption of the ciphertext ct of degree 1 by the party of public point
+// party and secret key share sk, committed to by cmt. It returns an error if ct is not of degree 1 or if the proof
+// cannot be generated, e.g. because cmt is not a commitment to sk.
+func (cks *CKSProtocol) GenPartialDecryption(party ShamirPublicPoint, sk *rlwe.SecretKey, ct *rlwe.Ciphertext, cmt *SecretKeyCommitment) (*PartialDecryption, error) {
+
+	if ct.Degree() != 1 {
+		return nil, fmt.Errorf("cannot GenPartialDecryption: the ciphertext must be of degree 1 but is of degree %d", ct.Degree())
+	}
+
+	zero := rlwe.NewSecretKey(cks.params)
+
+	share := cks.AllocateShare(ct.Level())
+	cks.GenShare(sk, zero, ct.Value[1], share)
+
+	proof, err := cks.GenProof(sk, zero, ct.Value[1], share, cmt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenPartialDecryption: %w", err)
+	}
+
+	return &PartialDecryption{Party: party, Share: share, Proof: proof}, nil
+}
+
+// VerifyDecryption verifies the publicly verifiable collective decryption of the ciphertext ct of degree 1, encrypted
+// under the collective public key pk, from the PartialDecryptions of the parties and their SecretKeyCommitments, and
+// returns the decryption in ptOut. It checks that the commitments are the CKG shares that generated pk, so that the
+// parties are bound to the published public key, that each committed party sent exactly one PartialDecryption and that
+// all the ShareProofs are valid. It returns an IdentifiedAbortError identifying the parties whose PartialDecryption is
+// missing, duplicated or invalid, and an error if the commitments do not match pk or if a PartialDecryption is from an
+// unknown party, in which cases ptOut is not modified.
+func (cks *CKSProtocol) VerifyDecryption(pk *rlwe.PublicKey, ct *rlwe.Ciphertext, commitments map[ShamirPublicPoint]*SecretKeyCommitment, partials []*PartialDecryption, ptOut *rlwe.Plaintext) error {
+
+	if ct.Degree() != 1 {
+		return fmt.Errorf("cannot VerifyDecryption: the ciphertext must be of degree 1 but is of degree %d", ct.Degree())
+	}
+
+	if err := cks.checkKeyCommitments(pk, commitments); err != nil {
+		return fmt.Errorf("cannot VerifyDecryption: %w", err)
+	}
+
+	shares := make(map[ShamirPublicPoint]*CKSShare, len(partials))
+	proofs := make(map[ShamirPublicPoint]*ShareProof, len(partials))
+	duplicated := []ShamirPublicPoint{}
+
+	for _, pd := range partials {
+
+		if _, ok := commitments[pd.Party]; !ok {
+			return fmt.Errorf("cannot VerifyDecryption: partial decryption of the unknown party %d", pd.Party)
+		}
+
+		if _, ok := shares[pd.Party]; ok {
+			duplicated = append(duplicated, pd.Party)
+			continue
+		}
+
+		shares[pd.Party], proofs[pd.Party] = pd.Share, pd.Proof
+	}
+
+	if len(duplicated) != 0 {
+		return &IdentifiedAbortError{Parties: sortPoints(duplicated), Reason: "duplicated partial decryption"}
+	}
+
+	missing := []ShamirPublicPoint{}
+	for p := range commitments {
+		if _, ok := shares[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+
+	if len(missing) != 0 {
+		return &IdentifiedAbortError{Parties: sortPoints(missing), Reason: "missing partial decryption"}
+	}
+
+	level := utils.MinInt(ct.Level(), ptOut.Level())
+	for p, share := range shares {
+		if share.Value.Level() < level {
+			return &IdentifiedAbortError{Parties: []ShamirPublicPoint{p}, Reason: "invalid partial decryption level"}
+		}
+	}
+
+	combined := cks.AllocateShare(level)
+	if err := cks.AggregateVerifiedShares(ct.Value[1], shares, proofs, commitments, nil, combined); err != nil {
+		return err
+	}
+
+	// c0 + sum_i (c1 * s_i + e_i)
+	cks.params.RingQ().AddLvl(level, ct.Value[0], combined.Value, ptOut.Value)
+	ptOut.Value.Coeffs = ptOut.Value.Coeffs[:level+1]
+	ptOut.Value.IsNTT = ct.Value[0].IsNTT
+
+	return nil
+}
+
+// checkKeyCommitments checks that the SecretKeyCommitments are CKG shares for the same common reference polynomial
+// that aggregate to the collective public key pk.
+func (cks *CKSProtocol) checkKeyCommitments(pk *rlwe.PublicKey, commitments map[ShamirPublicPoint]*SecretKeyCommitment) error {
+
+	if len(commitments) == 0 {
+		return errors.New("no secret key commitment")
+	}
+
+	ringQP := cks.params.RingQP()
+	levelQ, levelP := cks.params.QCount()-1, cks.params.PCount()-1
+
+	sum := ringQP.NewPoly()
+	for p, cmt := range commitments {
+		if !pk.Value[1].Equals(rlwe.PolyQP(cmt.CRP)) {
+			return fmt.Errorf("the secret key commitment of the party %d is not for the common reference polynomial of the public key", p)
+		}
+		ringQP.AddLvl(levelQ, levelP, sum, cmt.Share.Value, sum)
+	}
+
+	if !pk.Value[0].Equals(sum) {
+		return errors.New("the secret key commitments do not aggregate to the public key")
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (pd *PartialDecryption) MarshalBinary() (data []byte, err error) {
+
+	var share, proof []byte
+	if share, err = pd.Share.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	if proof, err = pd.Proof.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	data = make([]byte, 12, 12+len(share)+len(proof))
+	binary.LittleEndian.PutUint64(data, uint64(pd.Party))
+	binary.LittleEndian.PutUint32(data[8:], uint32(len(share)))
+	data = append(data, share...)
+	data = append(data, proof...)
+
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (pd *PartialDecryption) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 12 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	pd.Party = ShamirPublicPoint(binary.LittleEndian.Uint64(data))
+	size := int(binary.LittleEndian.Uint32(data[8:]))
+	data = data[12:]
+
+	if size == 0 || size > len(data) {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	pd.Share = new(CKSShare)
+	if err = pd.Share.UnmarshalBinary(data[:size]); err != nil {
+		return err
+	}
+
+	pd.Proof = new(ShareProof)
+	return pd.Proof.UnmarshalBinary(data[size:])
+}
@@ -1,19 +1,27 @@
 package drlwe
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"math"
 	"math/big"
 	"math/bits"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
 )
 
 var nbParties = int(3)
@@ -83,10 +91,28 @@ func TestDRLWE(t *testing.T) {
 		for _, testSet := range []func(textCtx testContext, t *testing.T){
 			testPublicKeyGen,
 			testKeySwitching,
+			testSmudgingParams,
 			testPublicKeySwitching,
+			testKeySwitchingNoise,
 			testRelinKeyGen,
 			testRotKeyGen,
+			testRotKeyGenBatch,
+			testRotKeyGenChunked,
+			testThreshold,
+			testResharing,
+			testMembership,
+			testShareAggregator,
+			testStreamAggregator,
+			testAggregationTree,
+			testShareProof,
+			testOrchestrator,
+			testCollectiveEncryption,
+			testMalicious,
+			testBlame,
+			testBeaconCRS,
+			testMultiKey,
 			testMarshalling,
+			testMetrics,
 		} {
 			testSet(textCtx, t)
 			runtime.GC()
@@ -141,6 +167,62 @@ func testPublicKeyGen(testCtx testContext, t *testing.T) {
 		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].Q.Level(), ringQ, pk.Value[0].Q))
 		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].P.Level(), ringP, pk.Value[0].P))
 	})
+
+	t.Run(testString(params, "PublicKeyGen/Concurrent"), func(t *testing.T) {
+
+		ckg := NewConcurrentCKGProtocol(NewCKGProtocol(params))
+
+		var _ CollectivePublicKeyGenerator = ckg
+		var _ RelinearizationKeyGenerator = NewConcurrentRKGProtocol(NewRKGProtocol(params))
+		var _ RotationKeyGenerator = NewConcurrentRTGProtocol(NewRTGProtocol(params))
+		var _ KeySwitchingProtocol = NewConcurrentCKSProtocol(NewCKSProtocol(params, rlwe.DefaultSigma))
+		var _ PublicKeySwitchingProtocol = NewConcurrentPCKSProtocol(NewPCKSProtocol(params, rlwe.DefaultSigma))
+
+		// The shares of the parties in several sessions are generated and aggregated concurrently
+		nbSessions := 4
+		crp := make([]CKGCRP, nbSessions)
+		shares := make([][]*CKGShare, nbSessions)
+		for s := range shares {
+			crp[s] = ckg.SampleCRP(testCtx.crs)
+			shares[s] = make([]*CKGShare, nbParties)
+		}
+
+		var wg sync.WaitGroup
+		for s := range shares {
+			for i := range shares[s] {
+				wg.Add(1)
+				go func(s, i int) {
+					defer wg.Done()
+					shares[s][i] = ckg.AllocateShare()
+					ckg.GenShare(testCtx.skShares[i], crp[s], shares[s][i])
+				}(s, i)
+			}
+		}
+		wg.Wait()
+
+		pks := make([]*rlwe.PublicKey, nbSessions)
+		for s := range pks {
+			wg.Add(1)
+			go func(s int) {
+				defer wg.Done()
+				for i := 1; i < nbParties; i++ {
+					ckg.AggregateShare(shares[s][0], shares[s][i], shares[s][0])
+				}
+				pks[s] = rlwe.NewPublicKey(params)
+				ckg.GenPublicKey(shares[s][0], crp[s], pks[s])
+			}(s)
+		}
+		wg.Wait()
+
+		for _, pk := range pks {
+			ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, testCtx.skIdeal.Value, pk.Value[1], pk.Value[0])
+			ringQP.InvNTTLvl(levelQ, levelP, pk.Value[0], pk.Value[0])
+
+			log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+			require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].Q.Level(), ringQ, pk.Value[0].Q))
+			require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].P.Level(), ringP, pk.Value[0].P))
+		}
+	})
 }
 
 func testKeySwitching(testCtx testContext, t *testing.T) {
@@ -161,277 +243,2556 @@ func testKeySwitching(testCtx testContext, t *testing.T) {
 			}
 		}
 
-		var _ KeySwitchingProtocol = cks[0]
+		var _ KeySwitchingProtocol = cks[0]
+
+		skout := make([]*rlwe.SecretKey, nbParties)
+		skOutIdeal := rlwe.NewSecretKey(params)
+		for i := range skout {
+			skout[i] = testCtx.kgen.GenSecretKey()
+			ringQP.AddLvl(levelQ, levelP, skOutIdeal.Value, skout[i].Value, skOutIdeal.Value)
+		}
+
+		ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+		testCtx.uniformSampler.Read(ciphertext.Value[1])
+		ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
+		ciphertext.Value[0].IsNTT = true
+		ciphertext.Value[1].IsNTT = true
+
+		shares := make([]*CKSShare, nbParties)
+		for i := range shares {
+			shares[i] = cks[i].AllocateShare(ciphertext.Level())
+		}
+
+		for i := range shares {
+			cks[i].GenShare(testCtx.skShares[i], skout[i], ciphertext.Value[1], shares[i])
+		}
+
+		for i := 1; i < nbParties; i++ {
+			cks[i].AggregateShare(shares[0], shares[i], shares[0])
+		}
+
+		ksCiphertext := &rlwe.Ciphertext{Value: []*ring.Poly{params.RingQ().NewPoly(), params.RingQ().NewPoly()}}
+
+		cks[0].KeySwitch(ciphertext, shares[0], ksCiphertext)
+
+		// [-as + e] + [as]
+		ringQ.MulCoeffsMontgomeryAndAdd(ksCiphertext.Value[1], skOutIdeal.Value.Q, ksCiphertext.Value[0])
+		ringQ.InvNTT(ksCiphertext.Value[0], ksCiphertext.Value[0])
+		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(ksCiphertext.Value[0].Level(), ringQ, ksCiphertext.Value[0]))
+
+	})
+
+	t.Run(testString(params, "KeySwitching/Batch"), func(t *testing.T) {
+
+		batchSize := 5
+
+		cks := make([]*CKSBatchProtocol, nbParties)
+		for i := range cks {
+			if i == 0 {
+				cks[i] = NewCKSBatchProtocol(params, rlwe.DefaultSigma, 2)
+			} else {
+				cks[i] = cks[0].ShallowCopy()
+			}
+		}
+
+		skout := make([]*rlwe.SecretKey, nbParties)
+		skOutIdeal := rlwe.NewSecretKey(params)
+		for i := range skout {
+			skout[i] = testCtx.kgen.GenSecretKey()
+			ringQP.AddLvl(levelQ, levelP, skOutIdeal.Value, skout[i].Value, skOutIdeal.Value)
+		}
+
+		ciphertexts := make([]*rlwe.Ciphertext, batchSize)
+		ksCiphertexts := make([]*rlwe.Ciphertext, batchSize)
+		c1 := make([]*ring.Poly, batchSize)
+		for i := range ciphertexts {
+			ciphertexts[i] = &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+			testCtx.uniformSampler.Read(ciphertexts[i].Value[1])
+			ringQ.MulCoeffsMontgomeryAndSub(ciphertexts[i].Value[1], testCtx.skIdeal.Value.Q, ciphertexts[i].Value[0])
+			ciphertexts[i].Value[0].IsNTT = true
+			ciphertexts[i].Value[1].IsNTT = true
+			c1[i] = ciphertexts[i].Value[1]
+			ksCiphertexts[i] = &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+		}
+
+		shares := make([]*CKSBatchShare, nbParties)
+		for i := range shares {
+			shares[i] = cks[i].AllocateShare(params.MaxLevel(), batchSize)
+			cks[i].GenShare(testCtx.skShares[i], skout[i], c1, shares[i])
+		}
+
+		require.Panics(t, func() { cks[0].GenShare(testCtx.skShares[0], skout[0], c1[1:], shares[0]) })
+
+		// The shares are sent in a single message
+		for i := 1; i < nbParties; i++ {
+			data, err := shares[i].MarshalBinary()
+			require.NoError(t, err)
+			received := cks[0].AllocateShare(params.MaxLevel(), batchSize)
+			require.NoError(t, received.UnmarshalBinary(data))
+			cks[0].AggregateShare(shares[0], received, shares[0])
+		}
+
+		cks[0].KeySwitch(ciphertexts, shares[0], ksCiphertexts)
+
+		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+		for _, ct := range ksCiphertexts {
+			// [-as + e] + [as]
+			ringQ.MulCoeffsMontgomeryAndAdd(ct.Value[1], skOutIdeal.Value.Q, ct.Value[0])
+			ringQ.InvNTT(ct.Value[0], ct.Value[0])
+			require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(ct.Value[0].Level(), ringQ, ct.Value[0]))
+		}
+	})
+}
+
+func testSmudgingParams(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+
+	t.Run(testString(params, "SmudgingParams"), func(t *testing.T) {
+
+		// The output is decrypted modulo the first modulus only
+		decryptionBound := float64(ringQ.Modulus[0]) / 2
+
+		_, _, err := SmudgingParams(params, 0, nbParties, 1, decryptionBound)
+		require.Error(t, err)
+		_, _, err = SmudgingParams(params, 8, 0, 1, decryptionBound)
+		require.Error(t, err)
+		_, _, err = SmudgingParams(params, 8, nbParties, -1, decryptionBound)
+		require.Error(t, err)
+		_, _, err = SmudgingParams(params, 8, nbParties, 1, 0)
+		require.Error(t, err)
+
+		// 128 bits of statistical security cannot be reached with 64-bit moduli
+		_, _, err = SmudgingParams(params, 128, nbParties, 1, decryptionBound)
+		require.Error(t, err)
+
+		noiseBound := math.Floor(6 * rlwe.DefaultSigma)
+		sigmaSmudging, outputNoiseBound, err := SmudgingParams(params, 8, nbParties, noiseBound, decryptionBound)
+		require.NoError(t, err)
+		require.Equal(t, math.Sqrt(float64(params.N()))*noiseBound*math.Exp2(7), sigmaSmudging)
+
+		// The output noise exceeds a smaller decryption bound, e.g. Δ/2 for a large plaintext modulus t
+		_, _, err = SmudgingParams(params, 8, nbParties, noiseBound, outputNoiseBound)
+		require.Error(t, err)
+
+		// Collective decryption of [-as + e]
+		ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+		testCtx.uniformSampler.Read(ciphertext.Value[1])
+		ring.NewGaussianSampler(testCtx.crs, ringQ, rlwe.DefaultSigma, int(noiseBound)).Read(ciphertext.Value[0])
+		ringQ.NTT(ciphertext.Value[0], ciphertext.Value[0])
+		ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
+		ciphertext.Value[0].IsNTT = true
+		ciphertext.Value[1].IsNTT = true
+
+		cks := NewCKSProtocol(params, sigmaSmudging)
+		zero := rlwe.NewSecretKey(params)
+
+		shareOut := cks.AllocateShare(ciphertext.Level())
+		share := cks.AllocateShare(ciphertext.Level())
+		for i := 0; i < nbParties; i++ {
+			cks.GenShare(testCtx.skShares[i], zero, ciphertext.Value[1], share)
+			cks.AggregateShare(share, shareOut, shareOut)
+		}
+
+		pt := ringQ.NewPoly()
+		ringQ.Add(ciphertext.Value[0], shareOut.Value, pt)
+		ringQ.InvNTT(pt, pt)
+
+		q := ringQ.Modulus[0]
+		for _, c := range pt.Coeffs[0] {
+			if c > q>>1 {
+				c = q - c
+			}
+			require.LessOrEqual(t, float64(c), outputNoiseBound)
+		}
+	})
+
+	t.Run(testString(params, "SmudgingParams/ShareProof"), func(t *testing.T) {
+
+		// The smudging noise of the parameters of 60-bit moduli is bounded by the share proofs, that of the test
+		// parameters of smaller moduli by the moduli
+		paramsLarge, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: 12, LogQ: []int{60, 60}, LogP: []int{61}, Sigma: rlwe.DefaultSigma})
+		require.NoError(t, err)
+
+		for _, params := range []rlwe.Parameters{params, paramsLarge} {
+
+			// The largest smudging noise returned by SmudgingParams can be proven
+			var sigmaSmudging float64
+			for securityParameter := 8; ; securityParameter++ {
+				sigma, _, err := SmudgingParams(params, securityParameter, nbParties, 1, math.MaxFloat64)
+				if err != nil {
+					break
+				}
+				sigmaSmudging = sigma
+			}
+			require.NotZero(t, sigmaSmudging)
+			require.Panics(t, func() { NewCKSProtocol(params, maxSmudgingBound(params)) })
+
+			kgen := rlwe.NewKeyGenerator(params)
+			ckg := NewCKGProtocol(params)
+			cks := NewCKSProtocol(params, sigmaSmudging)
+
+			skIn, skOut := kgen.GenSecretKey(), kgen.GenSecretKey()
+
+			commit := func(sk *rlwe.SecretKey) *SecretKeyCommitment {
+				cmt := &SecretKeyCommitment{CRP: ckg.SampleCRP(testCtx.crs), Share: ckg.AllocateShare()}
+				ckg.GenShare(sk, cmt.CRP, cmt.Share)
+				return cmt
+			}
+
+			cmtIn, cmtOut := commit(skIn), commit(skOut)
+
+			c1 := params.RingQ().NewPoly()
+			ring.NewUniformSampler(testCtx.crs, params.RingQ()).Read(c1)
+			c1.IsNTT = true
+
+			share := cks.AllocateShare(c1.Level())
+			cks.GenShare(skIn, skOut, c1, share)
+
+			proof, err := cks.GenProof(skIn, skOut, c1, share, cmtIn, cmtOut)
+			require.NoError(t, err)
+			require.NoError(t, cks.VerifyShare(c1, share, cmtIn, cmtOut, proof))
+		}
+	})
+}
+
+func testPublicKeySwitching(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+
+	t.Run(testString(params, "PublicKeySwitching"), func(t *testing.T) {
+
+		skOut, pkOut := testCtx.kgen.GenKeyPair()
+
+		pcks := make([]*PCKSProtocol, nbParties)
+		for i := range pcks {
+			if i == 0 {
+				pcks[i] = NewPCKSProtocol(params, rlwe.DefaultSigma)
+			} else {
+				pcks[i] = pcks[0].ShallowCopy()
+			}
+		}
+
+		var _ PublicKeySwitchingProtocol = pcks[0]
+
+		ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+		testCtx.uniformSampler.Read(ciphertext.Value[1])
+		ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
+		ciphertext.Value[0].IsNTT = true
+		ciphertext.Value[1].IsNTT = true
+
+		shares := make([]*PCKSShare, nbParties)
+		for i := range shares {
+			shares[i] = pcks[i].AllocateShare(ciphertext.Level())
+		}
+
+		for i := range shares {
+			pcks[i].GenShare(testCtx.skShares[i], pkOut, ciphertext.Value[1], shares[i])
+		}
+
+		for i := 1; i < nbParties; i++ {
+			pcks[0].AggregateShare(shares[0], shares[i], shares[0])
+		}
+
+		ksCiphertext := &rlwe.Ciphertext{Value: []*ring.Poly{params.RingQ().NewPoly(), params.RingQ().NewPoly()}}
+
+		pcks[0].KeySwitch(ciphertext, shares[0], ksCiphertext)
+
+		// [-as + e] + [as]
+		ringQ.MulCoeffsMontgomeryAndAdd(ksCiphertext.Value[1], skOut.Value.Q, ksCiphertext.Value[0])
+		ringQ.InvNTT(ksCiphertext.Value[0], ksCiphertext.Value[0])
+		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+		require.GreaterOrEqual(t, log2Bound+5, log2OfInnerSum(ksCiphertext.Value[0].Level(), ringQ, ksCiphertext.Value[0]))
+
+	})
+
+	t.Run(testString(params, "PublicKeySwitching/MultiRecipient"), func(t *testing.T) {
+
+		nbRecipients := 3
+
+		sksOut := make([]*rlwe.SecretKey, nbRecipients)
+		pksOut := make([]*rlwe.PublicKey, nbRecipients)
+		for j := range sksOut {
+			sksOut[j], pksOut[j] = testCtx.kgen.GenKeyPair()
+		}
+
+		mpcks := make([]*MultiPCKSProtocol, nbParties)
+		for i := range mpcks {
+			if i == 0 {
+				mpcks[i] = NewMultiPCKSProtocol(params, rlwe.DefaultSigma, pksOut)
+			} else {
+				mpcks[i] = mpcks[0].ShallowCopy()
+			}
+		}
+
+		require.Equal(t, nbRecipients, mpcks[0].Recipients())
+
+		// The ciphertext is in the NTT domain for the first key-switching and in the coefficient domain for the second
+		for _, isNTT := range []bool{true, false} {
+
+			ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+			testCtx.uniformSampler.Read(ciphertext.Value[1])
+			ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
+			ciphertext.Value[0].IsNTT = true
+			ciphertext.Value[1].IsNTT = true
+			if !isNTT {
+				ringQ.InvNTT(ciphertext.Value[0], ciphertext.Value[0])
+				ringQ.InvNTT(ciphertext.Value[1], ciphertext.Value[1])
+				ciphertext.Value[0].IsNTT = false
+				ciphertext.Value[1].IsNTT = false
+			}
+
+			shares := make([]*MultiPCKSShare, nbParties)
+			for i := range shares {
+				shares[i] = mpcks[i].AllocateShare(ciphertext.Level())
+				mpcks[i].GenShare(testCtx.skShares[i], ciphertext.Value[1], shares[i])
+			}
+
+			for i := 1; i < nbParties; i++ {
+				mpcks[0].AggregateShare(shares[0], shares[i], shares[0])
+			}
+
+			data, err := shares[0].MarshalBinary()
+			require.NoError(t, err)
+			combined := mpcks[0].AllocateShare(ciphertext.Level())
+			require.NoError(t, combined.UnmarshalBinary(data))
+			require.Len(t, combined.Value, nbRecipients)
+
+			ksCiphertexts := make([]*rlwe.Ciphertext, nbRecipients)
+			for j := range ksCiphertexts {
+				ksCiphertexts[j] = &rlwe.Ciphertext{Value: []*ring.Poly{params.RingQ().NewPoly(), params.RingQ().NewPoly()}}
+			}
+
+			mpcks[0].KeySwitch(ciphertext, combined, ksCiphertexts)
+
+			for j, ksCiphertext := range ksCiphertexts {
+				// [-as + e] + [as]
+				if !isNTT {
+					ringQ.NTT(ksCiphertext.Value[0], ksCiphertext.Value[0])
+					ringQ.NTT(ksCiphertext.Value[1], ksCiphertext.Value[1])
+				}
+				ringQ.MulCoeffsMontgomeryAndAdd(ksCiphertext.Value[1], sksOut[j].Value.Q, ksCiphertext.Value[0])
+				ringQ.InvNTT(ksCiphertext.Value[0], ksCiphertext.Value[0])
+				log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+				require.GreaterOrEqual(t, log2Bound+5, log2OfInnerSum(ksCiphertext.Value[0].Level(), ringQ, ksCiphertext.Value[0]))
+			}
+		}
+	})
+}
+
+func testKeySwitchingNoise(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+
+	// The smudging noise is large enough for the other noise terms to be negligible, so that the standard deviation
+	// of the noise of the output is the one of the sum of the smudging noises of the parties.
+	sigmaSmudging := float64(1 << 15)
+	sigmaWant := math.Sqrt(float64(nbParties)) * sigmaSmudging
+
+	// Encryption of zero without noise [-as, a]
+	ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+	testCtx.uniformSampler.Read(ciphertext.Value[1])
+	ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
+	ciphertext.Value[0].IsNTT = true
+	ciphertext.Value[1].IsNTT = true
+
+	t.Run(testString(params, "KeySwitchingNoise/CKS"), func(t *testing.T) {
+
+		cks := NewCKSProtocol(params, sigmaSmudging)
+		zero := rlwe.NewSecretKey(params)
+
+		shareOut := cks.AllocateShare(ciphertext.Level())
+		share := cks.AllocateShare(ciphertext.Level())
+		for i := 0; i < nbParties; i++ {
+			cks.GenShare(testCtx.skShares[i], zero, ciphertext.Value[1], share)
+			cks.AggregateShare(share, shareOut, shareOut)
+		}
+
+		pt := ringQ.NewPoly()
+		ringQ.Add(ciphertext.Value[0], shareOut.Value, pt)
+		ringQ.InvNTT(pt, pt)
+
+		require.InDelta(t, sigmaWant, standardDeviation(ringQ.Modulus[0], pt.Coeffs[0]), 0.1*sigmaWant)
+	})
+
+	t.Run(testString(params, "KeySwitchingNoise/PCKS"), func(t *testing.T) {
+
+		skOut, pkOut := testCtx.kgen.GenKeyPair()
+
+		pcks := NewPCKSProtocol(params, sigmaSmudging)
+
+		shareOut := pcks.AllocateShare(ciphertext.Level())
+		share := pcks.AllocateShare(ciphertext.Level())
+		for i := 0; i < nbParties; i++ {
+			pcks.GenShare(testCtx.skShares[i], pkOut, ciphertext.Value[1], share)
+			pcks.AggregateShare(share, shareOut, shareOut)
+		}
+
+		ksCiphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+		pcks.KeySwitch(ciphertext, shareOut, ksCiphertext)
+
+		ringQ.MulCoeffsMontgomeryAndAdd(ksCiphertext.Value[1], skOut.Value.Q, ksCiphertext.Value[0])
+		ringQ.InvNTT(ksCiphertext.Value[0], ksCiphertext.Value[0])
+
+		require.InDelta(t, sigmaWant, standardDeviation(ringQ.Modulus[0], ksCiphertext.Value[0].Coeffs[0]), 0.1*sigmaWant)
+	})
+}
+
+func testRelinKeyGen(testCtx testContext, t *testing.T) {
+	params := testCtx.params
+	ringQ := params.RingQ()
+	ringP := params.RingP()
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	// The loops over the decomposition basis are executed sequentially and by a pool of runtime.NumCPU() goroutines
+	for _, goroutines := range []int{1, 0} {
+		t.Run(testString(params, fmt.Sprintf("RelinKeyGen/Goroutines=%d", goroutines)), func(t *testing.T) {
+
+			if params.PCount() == 0 {
+				t.Skip("method is unsuported when params.PCount() == 0")
+			}
+
+			rkg := make([]*RKGProtocol, nbParties)
+
+			for i := range rkg {
+				if i == 0 {
+					rkg[i] = NewRKGProtocol(params, WithGoroutines(goroutines))
+				} else {
+					rkg[i] = rkg[0].ShallowCopy()
+				}
+			}
+
+			var _ RelinearizationKeyGenerator = rkg[0]
+
+			ephSk := make([]*rlwe.SecretKey, nbParties)
+			share1 := make([]*RKGShare, nbParties)
+			share2 := make([]*RKGShare, nbParties)
+
+			for i := range rkg {
+				ephSk[i], share1[i], share2[i] = rkg[i].AllocateShare()
+			}
+
+			crp := rkg[0].SampleCRP(testCtx.crs)
+			for i := range rkg {
+				rkg[i].GenShareRoundOne(testCtx.skShares[i], crp, ephSk[i], share1[i])
+			}
+
+			for i := 1; i < nbParties; i++ {
+				rkg[0].AggregateShare(share1[0], share1[i], share1[0])
+			}
+
+			for i := range rkg {
+				rkg[i].GenShareRoundTwo(ephSk[i], testCtx.skShares[i], share1[0], share2[i])
+			}
+
+			for i := 1; i < nbParties; i++ {
+				rkg[0].AggregateShare(share2[0], share2[i], share2[0])
+			}
+
+			rlk := rlwe.NewRelinKey(params, 2)
+			rkg[0].GenRelinearizationKey(share1[0], share2[0], rlk)
+
+			skIn := testCtx.skIdeal.CopyNew()
+			skOut := testCtx.skIdeal.CopyNew()
+			ringQP.MulCoeffsMontgomeryLvl(levelQ, levelP, skIn.Value, skIn.Value, skIn.Value)
+
+			swk := rlk.Keys[0]
+
+			// Decrypts
+			// [-asIn + w*P*sOut + e, a] + [asIn]
+			for j := range swk.Value {
+				ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, swk.Value[j][1], skOut.Value, swk.Value[j][0])
+			}
+
+			poly := swk.Value[0][0]
+
+			// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
+			// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
+			for j := range swk.Value {
+				if j > 0 {
+					ringQP.AddLvl(levelQ, levelP, poly, swk.Value[j][0], poly)
+				}
+			}
+
+			// sOut * P
+			ringQ.MulScalarBigint(skIn.Value.Q, ringP.ModulusBigint, skIn.Value.Q)
+
+			// P*s^i + sum(e) - P*s^i = sum(e)
+			ringQ.Sub(swk.Value[0][0].Q, skIn.Value.Q, swk.Value[0][0].Q)
+
+			// Checks that the error is below the bound
+			// Worst error bound is N * floor(6*sigma) * #Keys
+			ringQP.InvNTTLvl(levelQ, levelP, poly, poly)
+			ringQP.InvMFormLvl(levelQ, levelP, poly, poly)
+
+			// Worst bound of inner sum
+			// N*#Keys*(N * #Parties * floor(sigma*6) + #Parties * floor(sigma*6) + N * #Parties  +  #Parties * floor(6*sigma))
+			log2Bound := bits.Len64(uint64(params.N() * len(swk.Value) * (params.N()*3*int(math.Floor(rlwe.DefaultSigma*6)) + 2*3*int(math.Floor(rlwe.DefaultSigma*6)) + params.N()*3)))
+			require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringQ.Modulus)-1, ringQ, swk.Value[0][0].Q))
+			require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringP.Modulus)-1, ringP, swk.Value[0][0].P))
+		})
+	}
+}
+
+func testRotKeyGen(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	// The loops over the decomposition basis are executed sequentially and by a pool of runtime.NumCPU() goroutines
+	for _, goroutines := range []int{1, 0} {
+		t.Run(testString(params, fmt.Sprintf("RotKeyGen/Goroutines=%d", goroutines)), func(t *testing.T) {
+
+			if params.PCount() == 0 {
+				t.Skip("method is unsuported when params.PCount() == 0")
+			}
+
+			rtg := make([]*RTGProtocol, nbParties)
+			for i := range rtg {
+				if i == 0 {
+					rtg[i] = NewRTGProtocol(params, WithGoroutines(goroutines))
+				} else {
+					rtg[i] = rtg[0].ShallowCopy()
+				}
+			}
+
+			var _ RotationKeyGenerator = rtg[0]
+
+			shares := make([]*RTGShare, nbParties)
+			for i := range shares {
+				shares[i] = rtg[i].AllocateShare()
+			}
+
+			crp := rtg[0].SampleCRP(testCtx.crs)
+
+			galEl := params.GaloisElementForRowRotation()
+
+			for i := range shares {
+				rtg[i].GenShare(testCtx.skShares[i], galEl, crp, shares[i])
+			}
+
+			for i := 1; i < nbParties; i++ {
+				rtg[0].AggregateShare(shares[0], shares[i], shares[0])
+			}
+
+			rotKeySet := rlwe.NewRotationKeySet(params, []uint64{galEl})
+			rtg[0].GenRotationKey(shares[0], crp, rotKeySet.Keys[galEl])
+
+			verifyRotationKey(testCtx, galEl, rotKeySet.Keys[galEl], t)
+		})
+	}
+}
+
+func testRotKeyGenBatch(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	t.Run(testString(params, "RotKeyGenBatch"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		galEls := []uint64{params.GaloisElementForRowRotation(), params.GaloisElementForColumnRotationBy(1), params.GaloisElementForColumnRotationBy(5)}
+
+		rtg := make([]*RTGBatchProtocol, nbParties)
+		for i := range rtg {
+			if i == 0 {
+				rtg[i] = NewRTGBatchProtocol(params, galEls, 2)
+			} else {
+				rtg[i] = rtg[0].ShallowCopy()
+			}
+		}
+
+		shares := make([]*RTGBatchShare, nbParties)
+		for i := range shares {
+			shares[i] = rtg[i].AllocateShare()
+		}
+
+		crp := rtg[0].SampleCRP(testCtx.crs)
+
+		for i := range shares {
+			rtg[i].GenShare(testCtx.skShares[i], crp, shares[i])
+		}
+
+		// The shares are sent in a single message
+		data, err := shares[1].MarshalBinary()
+		require.NoError(t, err)
+		shares[1] = new(RTGBatchShare)
+		require.NoError(t, shares[1].UnmarshalBinary(data))
+		require.Equal(t, galEls, shares[1].GaloisElements)
+
+		for i := 1; i < nbParties; i++ {
+			rtg[0].AggregateShare(shares[0], shares[i], shares[0])
+		}
+
+		rotKeySet := rlwe.NewRotationKeySet(params, galEls)
+		rtg[0].GenRotationKeys(shares[0], crp, rotKeySet)
+
+		for _, galEl := range galEls {
+			verifyRotationKey(testCtx, galEl, rotKeySet.Keys[galEl], t)
+		}
+	})
+}
+
+func testRotKeyGenChunked(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	t.Run(testString(params, "RotKeyGenChunked"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		galEls := []uint64{params.GaloisElementForRowRotation()}
+		for k := 1; k < 5; k++ {
+			galEls = append(galEls, params.GaloisElementForColumnRotationBy(k))
+		}
+
+		rtg := NewRTGChunkedProtocol(params, galEls, 2, 2)
+		require.Equal(t, 3, rtg.Chunks())
+		require.Equal(t, galEls[4:], rtg.Chunk(2))
+
+		// The aggregator collects the marshaled shares of each chunk and sends back their aggregation
+		sharesIn := make(chan []byte)
+		sharesOut := make([]chan *RTGBatchShare, nbParties)
+		for i := range sharesOut {
+			sharesOut[i] = make(chan *RTGBatchShare, 1)
+		}
+
+		go func() {
+			agg := rtg.ShallowCopy()
+			for chunk := 0; chunk < rtg.Chunks(); chunk++ {
+				var combined *RTGBatchShare
+				for i := 0; i < nbParties; i++ {
+					share := new(RTGBatchShare)
+					if err := share.UnmarshalBinary(<-sharesIn); err != nil {
+						panic(err)
+					}
+					if combined == nil {
+						combined = share
+					} else {
+						agg.AggregateShare(combined, share, combined)
+					}
+				}
+				for i := range sharesOut {
+					sharesOut[i] <- combined
+				}
+			}
+		}()
+
+		rotKeys := make([]*rlwe.RotationKeySet, nbParties)
+		progress := make([][]int, nbParties)
+		errs := make([]error, nbParties)
+
+		var wg sync.WaitGroup
+		for i := 0; i < nbParties; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				crs, err := utils.NewKeyedPRNG([]byte{'c', 'h', 'u', 'n', 'k', 'e', 'd'})
+				if err != nil {
+					panic(err)
+				}
+
+				rotKeys[i] = &rlwe.RotationKeySet{Keys: map[uint64]*rlwe.SwitchingKey{}}
+
+				exchange := func(chunk int, share *RTGBatchShare) (*RTGBatchShare, error) {
+					data, err := share.MarshalBinary()
+					if err != nil {
+						return nil, err
+					}
+					sharesIn <- data
+					return <-sharesOut[i], nil
+				}
+
+				finalize := func(chunk int, keys *rlwe.RotationKeySet) error {
+					for galEl, swk := range keys.Keys {
+						rotKeys[i].Keys[galEl] = swk
+					}
+					return nil
+				}
+
+				errs[i] = rtg.ShallowCopy().Run(testCtx.skShares[i], crs, exchange, finalize, func(done, total int) {
+					progress[i] = append(progress[i], done, total)
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < nbParties; i++ {
+			require.NoError(t, errs[i])
+			require.Equal(t, []int{1, 3, 2, 3, 3, 3}, progress[i])
+			require.Len(t, rotKeys[i].Keys, len(galEls))
+		}
+
+		for _, galEl := range galEls {
+			require.True(t, rotKeys[0].Keys[galEl].Equals(rotKeys[1].Keys[galEl]))
+			verifyRotationKey(testCtx, galEl, rotKeys[0].Keys[galEl], t)
+		}
+
+		// An aggregated share that does not match the chunk is rejected
+		crs, err := utils.NewKeyedPRNG([]byte{'c', 'h', 'u', 'n', 'k', 'e', 'd'})
+		require.NoError(t, err)
+		err = rtg.Run(testCtx.skShares[0], crs, func(chunk int, share *RTGBatchShare) (*RTGBatchShare, error) {
+			return &RTGBatchShare{GaloisElements: galEls}, nil
+		}, func(int, *rlwe.RotationKeySet) error { return nil }, nil)
+		require.Error(t, err)
+	})
+}
+
+// verifyRotationKey checks that the switching key swk is a rotation key of the ideal secret key for the Galois element galEl.
+func verifyRotationKey(testCtx testContext, galEl uint64, swk *rlwe.SwitchingKey, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+	ringP := params.RingP()
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	skIn := testCtx.skIdeal.CopyNew()
+	skOut := testCtx.skIdeal.CopyNew()
+	galElInv := ring.ModExp(galEl, uint64(2*params.N()-1), uint64(2*params.N()))
+	ringQ.PermuteNTT(testCtx.skIdeal.Value.Q, galElInv, skOut.Value.Q)
+	ringP.PermuteNTT(testCtx.skIdeal.Value.P, galElInv, skOut.Value.P)
+
+	// Decrypts
+	// [-asIn + w*P*sOut + e, a] + [asIn]
+	for j := range swk.Value {
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, swk.Value[j][1], skOut.Value, swk.Value[j][0])
+
+	}
+
+	// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
+	// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
+	for j := range swk.Value {
+		if j > 0 {
+			ringQP.AddLvl(levelQ, levelP, swk.Value[0][0], swk.Value[j][0], swk.Value[0][0])
+		}
+	}
+
+	// sOut * P
+	ringQ.MulScalarBigint(skIn.Value.Q, ringP.ModulusBigint, skIn.Value.Q)
+
+	// P*s^i + sum(e) - P*s^i = sum(e)
+	ringQ.Sub(swk.Value[0][0].Q, skIn.Value.Q, swk.Value[0][0].Q)
+
+	// Checks that the error is below the bound
+	// Worst error bound is N * floor(6*sigma) * #Keys
+	ringQP.InvNTTLvl(levelQ, levelP, swk.Value[0][0], swk.Value[0][0])
+	ringQP.InvMFormLvl(levelQ, levelP, swk.Value[0][0], swk.Value[0][0])
+
+	// Worst bound of inner sum
+	// N*#Keys*(N * #Parties * floor(sigma*6) + #Parties * floor(sigma*6) + N * #Parties  +  #Parties * floor(6*sigma))
+	log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+	require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringQ.Modulus)-1, ringQ, swk.Value[0][0].Q))
+	require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringP.Modulus)-1, ringP, swk.Value[0][0].P))
+}
+
+func testThreshold(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	t.Run(testString(params, "Threshold"), func(t *testing.T) {
+
+		threshold := nbParties - 1
+		points := make([]ShamirPublicPoint, nbParties)
+		for i := range points {
+			points[i] = ShamirPublicPoint(i + 1)
+		}
+
+		thr := NewThresholdizer(params)
+
+		_, err := thr.GenShamirPolynomial(0, testCtx.skShares[0])
+		require.Error(t, err)
+
+		tsk := genThresholdSecretShares(thr, testCtx.skShares, points, threshold)
+
+		cmb := NewCombiner(params, threshold)
+
+		// Any set of threshold parties reconstructs the ideal secret key
+		for _, active := range [][]ShamirPublicPoint{points[:threshold], points[1:], points} {
+
+			skSum := rlwe.NewSecretKey(params)
+			sk := rlwe.NewSecretKey(params)
+			for _, p := range active {
+				require.NoError(t, cmb.GenAdditiveShare(active, p, tsk[p-1], sk))
+				ringQP.AddLvl(levelQ, levelP, skSum.Value, sk.Value, skSum.Value)
+			}
+
+			require.True(t, skSum.Value.Equals(testCtx.skIdeal.Value))
+		}
+
+		sk := rlwe.NewSecretKey(params)
+		require.Error(t, cmb.GenAdditiveShare(points[:threshold-1], points[0], tsk[0], sk))
+		require.Error(t, cmb.GenAdditiveShare(points[1:], points[0], tsk[0], sk))
+		require.Error(t, cmb.GenAdditiveShare([]ShamirPublicPoint{points[0], points[0]}, points[0], tsk[0], sk))
+	})
+}
+
+func testResharing(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	// sumSecretKeys returns the sum of the secret keys.
+	sumSecretKeys := func(sks []*rlwe.SecretKey) *rlwe.SecretKey {
+		sum := rlwe.NewSecretKey(params)
+		for _, sk := range sks {
+			ringQP.AddLvl(levelQ, levelP, sum.Value, sk.Value, sum.Value)
+		}
+		return sum
+	}
+
+	t.Run(testString(params, "Resharing/Additive"), func(t *testing.T) {
+
+		rsp := NewResharingProtocol(params)
+
+		// shares[i][j] is sent by the party i to the party j
+		shares := make([][]*KeyRefreshShare, nbParties)
+		for i := range shares {
+			shares[i] = make([]*KeyRefreshShare, nbParties)
+			for j := range shares[i] {
+				shares[i][j] = rsp.AllocateShare()
+			}
+			rsp.ShallowCopy().GenKeyRefreshShares(shares[i])
+		}
+
+		data, err := shares[0][1].MarshalBinary()
+		require.NoError(t, err)
+		shares[0][1] = rsp.AllocateShare()
+		require.NoError(t, shares[0][1].UnmarshalBinary(data))
+
+		skRefreshed := make([]*rlwe.SecretKey, nbParties)
+		for j := range skRefreshed {
+			received := make([]*KeyRefreshShare, nbParties)
+			for i := range received {
+				received[i] = shares[i][j]
+			}
+			skRefreshed[j] = rlwe.NewSecretKey(params)
+			rsp.RefreshSecretKey(testCtx.skShares[j], received, skRefreshed[j])
+			require.False(t, skRefreshed[j].Value.Equals(testCtx.skShares[j].Value))
+		}
+
+		require.True(t, sumSecretKeys(skRefreshed).Value.Equals(testCtx.skIdeal.Value))
+
+		// The new commitments of the parties commit to the same collective secret key
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+		genShares := func(sks []*rlwe.SecretKey) map[ShamirPublicPoint]*CKGShare {
+			shares := map[ShamirPublicPoint]*CKGShare{}
+			for i, sk := range sks {
+				shares[ShamirPublicPoint(i+1)] = ckg.AllocateShare()
+				ckg.GenShare(sk, crp, shares[ShamirPublicPoint(i+1)])
+			}
+			return shares
+		}
+
+		sharesOld := genShares(testCtx.skShares)
+		require.NoError(t, rsp.CheckRefresh(sharesOld, genShares(skRefreshed)))
+
+		// A party that does not apply a refresh share
+		skRefreshed[0] = testCtx.skShares[0]
+		require.Error(t, rsp.CheckRefresh(sharesOld, genShares(skRefreshed)))
+	})
+
+	t.Run(testString(params, "Resharing/Threshold"), func(t *testing.T) {
+
+		threshold := nbParties - 1
+		points := make([]ShamirPublicPoint, nbParties)
+		for i := range points {
+			points[i] = ShamirPublicPoint(i + 1)
+		}
+
+		thr := NewThresholdizer(params)
+		rsp := NewResharingProtocol(params)
+		tsk := genThresholdSecretShares(thr, testCtx.skShares, points, threshold)
+
+		// combine returns the collective secret key reconstructed from the shares of the active parties.
+		combine := func(cmb *Combiner, active []ShamirPublicPoint, tsk map[ShamirPublicPoint]*ShamirSecretShare) *rlwe.SecretKey {
+			sks := make([]*rlwe.SecretKey, len(active))
+			for i, p := range active {
+				sks[i] = rlwe.NewSecretKey(params)
+				require.NoError(t, cmb.GenAdditiveShare(active, p, tsk[p], sks[i]))
+			}
+			return sumSecretKeys(sks)
+		}
+
+		// Refresh of the T-out-of-N sharing
+		refreshed := map[ShamirPublicPoint]*ShamirSecretShare{}
+		for _, p := range points {
+			refreshed[p] = thr.AllocateThresholdSecretShare()
+			refreshed[p].Copy(tsk[p-1].PolyQP)
+		}
+
+		share := thr.AllocateThresholdSecretShare()
+		for range points {
+			poly, err := rsp.GenRefreshPolynomial(threshold)
+			require.NoError(t, err)
+			for _, p := range points {
+				thr.GenShamirSecretShare(p, poly, share)
+				thr.AggregateShares(refreshed[p], share, refreshed[p])
+			}
+		}
+
+		require.False(t, refreshed[1].Equals(tsk[0].PolyQP))
+		require.True(t, combine(NewCombiner(params, threshold), points[1:], refreshed).Value.Equals(testCtx.skIdeal.Value))
+
+		// Redistribution to new parties with a new threshold by threshold active parties
+		active := points[:threshold]
+		newThreshold := nbParties
+		newPoints := make([]ShamirPublicPoint, nbParties+1)
+		for i := range newPoints {
+			newPoints[i] = ShamirPublicPoint(nbParties + 1 + i)
+		}
+
+		reshared := map[ShamirPublicPoint]*ShamirSecretShare{}
+		for _, p := range newPoints {
+			reshared[p] = thr.AllocateThresholdSecretShare()
+		}
+
+		cmb := NewCombiner(params, threshold)
+		for _, own := range active {
+			poly, err := rsp.GenResharingPolynomial(cmb, active, own, refreshed[own], newThreshold)
+			require.NoError(t, err)
+			for _, p := range newPoints {
+				thr.GenShamirSecretShare(p, poly, share)
+				thr.AggregateShares(reshared[p], share, reshared[p])
+			}
+		}
+
+		require.True(t, combine(NewCombiner(params, newThreshold), newPoints[1:], reshared).Value.Equals(testCtx.skIdeal.Value))
+
+		_, err := rsp.GenResharingPolynomial(cmb, active[:threshold-1], active[0], refreshed[active[0]], newThreshold)
+		require.Error(t, err)
+		_, err = rsp.GenRefreshPolynomial(0)
+		require.Error(t, err)
+	})
+}
+
+func testMembership(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+	ringP := params.RingP()
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	t.Run(testString(params, "Membership/Join"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		// The last party joins the other parties, whose collective keys are generated beforehand
+		members, joining := testCtx.skShares[:nbParties-1], testCtx.skShares[nbParties-1]
+
+		skMembers := rlwe.NewSecretKey(params)
+		for _, sk := range members {
+			ringQP.AddLvl(levelQ, levelP, skMembers.Value, sk.Value, skMembers.Value)
+		}
+
+		ckg := NewCKGProtocol(params)
+		ckgCRP := ckg.SampleCRP(testCtx.crs)
+		ckgShare, ckgCombined := ckg.AllocateShare(), ckg.AllocateShare()
+		for _, sk := range members {
+			ckg.GenShare(sk, ckgCRP, ckgShare)
+			ckg.AggregateShare(ckgCombined, ckgShare, ckgCombined)
+		}
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(ckgCombined, ckgCRP, pk)
+
+		galEl := params.GaloisElementForRowRotation()
+		rtg := NewRTGProtocol(params)
+		rtgCRP := rtg.SampleCRP(testCtx.crs)
+		rtgShare, rtgCombined := rtg.AllocateShare(), rtg.AllocateShare()
+		for _, sk := range members {
+			rtg.GenShare(sk, galEl, rtgCRP, rtgShare)
+			rtg.AggregateShare(rtgCombined, rtgShare, rtgCombined)
+		}
+		rotKeySet := rlwe.NewRotationKeySet(params, []uint64{galEl})
+		rtg.GenRotationKey(rtgCombined, rtgCRP, rotKeySet.Keys[galEl])
+
+		ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+		testCtx.uniformSampler.Read(ciphertext.Value[1])
+		ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], skMembers.Value.Q, ciphertext.Value[0])
+		ciphertext.Value[0].IsNTT = true
+		ciphertext.Value[1].IsNTT = true
+
+		mp := NewMembershipProtocol(params, rlwe.DefaultSigma).ShallowCopy()
+
+		mp.GenJoinPublicKeyShare(joining, pk, ckgShare)
+		mp.UpdatePublicKey(pk, ckgShare, pk)
+
+		mp.GenJoinRotationKeyShare(joining, galEl, rotKeySet.Keys[galEl], rtgShare)
+		mp.UpdateRotationKey(rotKeySet.Keys[galEl], rtgShare, rotKeySet.Keys[galEl])
+
+		cksShare := mp.cks.AllocateShare(ciphertext.Level())
+		mp.GenJoinCiphertextShare(joining, ciphertext.Value[1], cksShare)
+		mp.cks.KeySwitch(ciphertext, cksShare, ciphertext)
+
+		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+
+		// The keys and the ciphertext are under the collective secret key of all the parties
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, testCtx.skIdeal.Value, pk.Value[1], pk.Value[0])
+		ringQP.InvNTTLvl(levelQ, levelP, pk.Value[0], pk.Value[0])
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].Q.Level(), ringQ, pk.Value[0].Q))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].P.Level(), ringP, pk.Value[0].P))
+
+		verifyRotationKey(testCtx, galEl, rotKeySet.Keys[galEl], t)
+
+		ringQ.MulCoeffsMontgomeryAndAdd(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
+		ringQ.InvNTT(ciphertext.Value[0], ciphertext.Value[0])
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(ciphertext.Value[0].Level(), ringQ, ciphertext.Value[0]))
+	})
+
+	t.Run(testString(params, "Membership/Leave"), func(t *testing.T) {
+
+		mp := NewMembershipProtocol(params, rlwe.DefaultSigma)
+		rsp := NewResharingProtocol(params)
+
+		// The first party leaves and sends a share to each of the remaining parties
+		shares := make([]*KeyRefreshShare, nbParties-1)
+		for i := range shares {
+			shares[i] = rsp.AllocateShare()
+		}
+		mp.GenLeaveShares(testCtx.skShares[0], shares)
+
+		remaining := make([]*rlwe.SecretKey, nbParties-1)
+		for i := range remaining {
+			remaining[i] = rlwe.NewSecretKey(params)
+			rsp.RefreshSecretKey(testCtx.skShares[i+1], []*KeyRefreshShare{shares[i]}, remaining[i])
+		}
+
+		sum := rlwe.NewSecretKey(params)
+		for _, sk := range remaining {
+			ringQP.AddLvl(levelQ, levelP, sum.Value, sk.Value, sum.Value)
+		}
+
+		require.True(t, sum.Value.Equals(testCtx.skIdeal.Value))
+	})
+}
+
+// genThresholdSecretShares returns the threshold secret shares of the parties of public points points, whose
+// secret keys are skShares.
+func genThresholdSecretShares(thr *Thresholdizer, skShares []*rlwe.SecretKey, points []ShamirPublicPoint, threshold int) (tsk []*ShamirSecretShare) {
+
+	tsk = make([]*ShamirSecretShare, len(points))
+	for i := range tsk {
+		tsk[i] = thr.AllocateThresholdSecretShare()
+	}
+
+	share := thr.AllocateThresholdSecretShare()
+	for _, sk := range skShares {
+		poly, err := thr.GenShamirPolynomial(threshold, sk)
+		if err != nil {
+			panic(err)
+		}
+		for j, p := range points {
+			thr.GenShamirSecretShare(p, poly, share)
+			thr.AggregateShares(tsk[j], share, tsk[j])
+		}
+	}
+
+	return
+}
+
+func testShareAggregator(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	t.Run(testString(params, "ShareAggregator"), func(t *testing.T) {
+
+		threshold := nbParties - 1
+		points := make([]ShamirPublicPoint, nbParties)
+		for i := range points {
+			points[i] = ShamirPublicPoint(i + 1)
+		}
+
+		tsk := genThresholdSecretShares(NewThresholdizer(params), testCtx.skShares, points, threshold)
+		cmb := NewCombiner(params, threshold)
+
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		genShare := func(active []ShamirPublicPoint, p ShamirPublicPoint) *CKGShare {
+			sk := rlwe.NewSecretKey(params)
+			require.NoError(t, cmb.GenAdditiveShare(active, p, tsk[p-1], sk))
+			share := ckg.AllocateShare()
+			ckg.GenShare(sk, crp, share)
+			return share
+		}
+
+		_, err := NewShareAggregator(points, nbParties+1, nil)
+		require.Error(t, err)
+
+		agg, err := NewShareAggregator(points, threshold, func(share1, share2, shareOut interface{}) {
+			ckg.AggregateShare(share1.(*CKGShare), share2.(*CKGShare), shareOut.(*CKGShare))
+		})
+		require.NoError(t, err)
+		require.Equal(t, points, agg.ActiveParties())
+
+		// The last party does not respond
+		for _, p := range points[:nbParties-1] {
+			require.NoError(t, agg.Add(p, genShare(points, p)))
+		}
+
+		require.Error(t, agg.Add(points[0], genShare(points, points[0])))
+		require.False(t, agg.Ready())
+		require.Equal(t, points[nbParties-1:], agg.Missing())
+		require.Error(t, agg.Finalize(ckg.AllocateShare()))
+
+		// The unresponsive party is excluded and the active parties regenerate their shares
+		require.Error(t, agg.SetActiveParties(points[:threshold-1]))
+		require.Error(t, agg.SetActiveParties([]ShamirPublicPoint{points[0], ShamirPublicPoint(nbParties + 1)}))
+		require.NoError(t, agg.SetActiveParties(points[:threshold]))
+		require.Empty(t, agg.Contributors())
+
+		require.Error(t, agg.Add(points[nbParties-1], genShare(points, points[nbParties-1])))
+
+		// A share generated for the previous set of active parties is replaced, and a share is withdrawn and added again
+		require.NoError(t, agg.Add(points[0], genShare(points, points[0])))
+		require.NoError(t, agg.Replace(points[0], genShare(points[:threshold], points[0])))
+		for _, p := range points[1:threshold] {
+			require.NoError(t, agg.Add(p, genShare(points[:threshold], p)))
+		}
+		require.NoError(t, agg.Withdraw(points[1]))
+		require.Error(t, agg.Withdraw(points[1]))
+		require.Error(t, agg.Replace(points[1], nil))
+		require.NoError(t, agg.Add(points[1], genShare(points[:threshold], points[1])))
+
+		require.True(t, agg.Ready())
+		require.Equal(t, points[:threshold], agg.Contributors())
+
+		share := ckg.AllocateShare()
+		require.NoError(t, agg.Finalize(share))
+
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(share, crp, pk)
+
+		// [-as + e] + [as]
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, testCtx.skIdeal.Value, pk.Value[1], pk.Value[0])
+		ringQP.InvNTTLvl(levelQ, levelP, pk.Value[0], pk.Value[0])
+
+		log2Bound := bits.Len64(uint64(threshold) * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].Q.Level(), ringQ, pk.Value[0].Q))
+	})
+}
+
+func testStreamAggregator(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	t.Run(testString(params, "StreamAggregator"), func(t *testing.T) {
+
+		points := make([]ShamirPublicPoint, nbParties)
+		for i := range points {
+			points[i] = ShamirPublicPoint(i + 1)
+		}
+
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		shares := make([]*CKGShare, nbParties)
+		for i := range shares {
+			shares[i] = ckg.AllocateShare()
+			ckg.GenShare(testCtx.skShares[i], crp, shares[i])
+		}
+
+		_, err := ckg.NewAggregator([]ShamirPublicPoint{0}, ckg.AllocateShare())
+		require.Error(t, err)
+
+		shareOut := ckg.AllocateShare()
+		agg, err := ckg.NewAggregator(points, shareOut)
+		require.NoError(t, err)
+
+		// The first party's share arrives through a callback, the others concurrently through a channel
+		require.NoError(t, agg.Put(points[0], shares[0]))
+		require.Error(t, agg.Put(points[0], shares[0]))
+		require.Error(t, agg.Put(ShamirPublicPoint(nbParties+1), shares[0]))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err = agg.Wait(ctx)
+		cancel()
+		require.Error(t, err)
+		require.Equal(t, points[1:], agg.Missing())
+
+		ch := make(chan PartyShare)
+		for i := 1; i < nbParties; i++ {
+			go func(i int) { ch <- PartyShare{Party: points[i], Share: shares[i]} }(i)
+		}
+
+		require.NoError(t, agg.Consume(context.Background(), ch))
+		<-agg.Done()
+		require.Empty(t, agg.Missing())
+
+		res, err := agg.Wait(context.Background())
+		require.NoError(t, err)
+		require.True(t, res == shareOut)
+
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(shareOut, crp, pk)
+
+		// [-as + e] + [as]
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, testCtx.skIdeal.Value, pk.Value[1], pk.Value[0])
+		ringQP.InvNTTLvl(levelQ, levelP, pk.Value[0], pk.Value[0])
+
+		log2Bound := bits.Len64(uint64(nbParties) * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].Q.Level(), ringQ, pk.Value[0].Q))
+	})
+}
+
+func testAggregationTree(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	t.Run(testString(params, "AggregationTree"), func(t *testing.T) {
+
+		// The shares of the nbParties parties are replicated to simulate a larger ceremony
+		nParties := 4*nbParties + 1
+
+		points := make([]ShamirPublicPoint, nParties)
+		for i := range points {
+			points[i] = ShamirPublicPoint(i + 1)
+		}
+
+		_, err := NewAggregationTree(points, 1)
+		require.Error(t, err)
+		_, err = NewAggregationTree([]ShamirPublicPoint{}, 2)
+		require.Error(t, err)
+
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		shares := make([]*CKGShare, nbParties)
+		for i := range shares {
+			shares[i] = ckg.AllocateShare()
+			ckg.GenShare(testCtx.skShares[i], crp, shares[i])
+		}
+
+		share := func(p ShamirPublicPoint) *CKGShare { return shares[int(p-1)%nbParties] }
+
+		aggregate := func(share1, share2, shareOut interface{}) {
+			ckg.AggregateShare(share1.(*CKGShare), share2.(*CKGShare), shareOut.(*CKGShare))
+		}
+
+		root, err := NewAggregationTree(points, 3)
+		require.NoError(t, err)
+		require.Equal(t, points, root.Parties)
+		require.Equal(t, 3, root.Depth())
+		require.Nil(t, root.Node(ShamirPublicPoint(nParties+1)))
+
+		// Each node aggregates the shares of its leaves and the marshaled partial aggregates of its children
+		var aggregateNode func(node *AggregationNode) []byte
+		aggregateNode = func(node *AggregationNode) []byte {
+
+			require.LessOrEqual(t, len(node.Leaves)+len(node.Children), 3)
+
+			agg, err := node.NewAggregator(ckg.AllocateShare(), aggregate)
+			require.NoError(t, err)
+
+			for _, p := range node.Leaves {
+				require.True(t, root.Node(p) == node)
+				require.NoError(t, agg.Put(p, share(p)))
+			}
+
+			for _, child := range node.Children {
+				partial := &PartialAggregate{Share: ckg.AllocateShare()}
+				require.NoError(t, partial.UnmarshalBinary(aggregateNode(child)))
+				require.Equal(t, child.Parties, partial.Parties)
+				require.NoError(t, agg.PutPartial(partial))
+				require.Error(t, agg.PutPartial(partial))
+			}
+
+			partial, err := agg.Partial(context.Background())
+			require.NoError(t, err)
+			data, err := partial.MarshalBinary()
+			require.NoError(t, err)
+			return data
+		}
+
+		partial := &PartialAggregate{Share: ckg.AllocateShare()}
+		require.NoError(t, partial.UnmarshalBinary(aggregateNode(root)))
+		require.Equal(t, points, partial.Parties)
+
+		shareOut := ckg.AllocateShare()
+		for _, p := range points {
+			ckg.AggregateShare(shareOut, share(p), shareOut)
+		}
+
+		require.True(t, shareOut.Value.Equals(partial.Share.(*CKGShare).Value))
+	})
+}
+
+func testShareProof(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+
+	t.Run(testString(params, "ShareProof/CKG"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		share := ckg.AllocateShare()
+		ckg.GenShare(testCtx.skShares[0], crp, share)
+
+		proof, err := ckg.GenProof(testCtx.skShares[0], crp, share)
+		require.NoError(t, err)
+		require.NoError(t, ckg.VerifyShare(crp, share, proof))
+
+		data, err := proof.MarshalBinary()
+		require.NoError(t, err)
+		proofNew := new(ShareProof)
+		require.NoError(t, proofNew.UnmarshalBinary(data))
+		require.NoError(t, ckg.VerifyShare(crp, share, proofNew))
+
+		// The proof is bound to the common reference polynomial and to the secret key
+		require.Error(t, ckg.VerifyShare(ckg.SampleCRP(testCtx.crs), share, proof))
+		_, err = ckg.GenProof(testCtx.skShares[1], crp, share)
+		require.Error(t, err)
+
+		// Malformed share
+		malformed := ckg.AllocateShare()
+		malformed.Value.Copy(share.Value)
+		ringQ.AddScalar(malformed.Value.Q, 1<<20, malformed.Value.Q)
+		require.Error(t, ckg.VerifyShare(crp, malformed, proof))
+		_, err = ckg.GenProof(testCtx.skShares[0], crp, malformed)
+		require.Error(t, err)
+
+		// Malformed proof
+		proofNew.Z[0][0]++
+		require.Error(t, ckg.VerifyShare(crp, share, proofNew))
+	})
+
+	t.Run(testString(params, "ShareProof/CKS"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+		cks := NewCKSProtocol(params, rlwe.DefaultSigma)
+
+		skIn, skOut := testCtx.skShares[0], testCtx.kgen.GenSecretKey()
+
+		commit := func(sk *rlwe.SecretKey) *SecretKeyCommitment {
+			cmt := &SecretKeyCommitment{CRP: ckg.SampleCRP(testCtx.crs), Share: ckg.AllocateShare()}
+			ckg.GenShare(sk, cmt.CRP, cmt.Share)
+			return cmt
+		}
+
+		cmtIn, cmtOut := commit(skIn), commit(skOut)
+
+		c1 := ringQ.NewPoly()
+		testCtx.uniformSampler.Read(c1)
+		c1.IsNTT = true
+
+		share := cks.AllocateShare(c1.Level())
+		cks.GenShare(skIn, skOut, c1, share)
+
+		proof, err := cks.GenProof(skIn, skOut, c1, share, cmtIn, cmtOut)
+		require.NoError(t, err)
+		require.NoError(t, cks.VerifyShare(c1, share, cmtIn, cmtOut, proof))
+
+		// The proof is bound to the commitments
+		require.Error(t, cks.VerifyShare(c1, share, commit(testCtx.skShares[1]), cmtOut, proof))
+		_, err = cks.GenProof(skIn, skOut, c1, share, commit(testCtx.skShares[1]), cmtOut)
+		require.Error(t, err)
+
+		// Collective decryption
+		zero := rlwe.NewSecretKey(params)
+		cks.GenShare(skIn, zero, c1, share)
+		proof, err = cks.GenProof(skIn, zero, c1, share, cmtIn, nil)
+		require.NoError(t, err)
+		require.NoError(t, cks.VerifyShare(c1, share, cmtIn, nil, proof))
+
+		// Malformed share
+		ringQ.AddScalar(share.Value, 1<<20, share.Value)
+		require.Error(t, cks.VerifyShare(c1, share, cmtIn, nil, proof))
+		_, err = cks.GenProof(skIn, zero, c1, share, cmtIn, nil)
+		require.Error(t, err)
+
+		// The masks of the proofs of an error bound of 6 * 2^50 overflow an int64
+		cks.GenShare(skIn, zero, c1, share)
+		cks.sigmaSmudging = math.Exp2(50)
+		_, err = cks.GenProof(skIn, zero, c1, share, cmtIn, nil)
+		require.Error(t, err)
+		require.Error(t, cks.VerifyShare(c1, share, cmtIn, nil, proof))
+	})
+
+	t.Run(testString(params, "ShareProof/VerifiableDecryption"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+		cks := NewCKSProtocol(params, rlwe.DefaultSigma)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		// The CKG shares of the collective public key are the published commitments of the parties
+		commitments := map[ShamirPublicPoint]*SecretKeyCommitment{}
+		aggregated := ckg.AllocateShare()
+		for i := 0; i < nbParties; i++ {
+			cmt := &SecretKeyCommitment{CRP: crp, Share: ckg.AllocateShare()}
+			ckg.GenShare(testCtx.skShares[i], crp, cmt.Share)
+			ckg.AggregateShare(aggregated, cmt.Share, aggregated)
+			commitments[ShamirPublicPoint(i+1)] = cmt
+		}
+
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(aggregated, crp, pk)
+
+		ct := rlwe.NewCiphertextNTT(params, 1, params.MaxLevel())
+		rlwe.NewEncryptor(params, pk).Encrypt(rlwe.NewPlaintext(params, params.MaxLevel()), ct)
+
+		partials := make([]*PartialDecryption, nbParties)
+		for i := range partials {
+			var err error
+			partials[i], err = cks.GenPartialDecryption(ShamirPublicPoint(i+1), testCtx.skShares[i], ct, commitments[ShamirPublicPoint(i+1)])
+			require.NoError(t, err)
+		}
+
+		_, err := cks.GenPartialDecryption(1, testCtx.skShares[1], ct, commitments[1])
+		require.Error(t, err)
+
+		// The partial decryptions are published
+		data, err := partials[1].MarshalBinary()
+		require.NoError(t, err)
+		partials[1] = new(PartialDecryption)
+		require.NoError(t, partials[1].UnmarshalBinary(data))
+		require.Equal(t, ShamirPublicPoint(2), partials[1].Party)
+
+		pt := rlwe.NewPlaintext(params, ct.Level())
+		require.NoError(t, cks.VerifyDecryption(pk, ct, commitments, partials, pt))
+
+		if pt.Value.IsNTT {
+			ringQ.InvNTTLvl(pt.Level(), pt.Value, pt.Value)
+		}
+		log2Bound := bits.Len64(uint64(params.N()) * uint64(params.N()) * 64 * uint64(nbParties))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pt.Level(), ringQ, pt.Value))
+
+		var abort *IdentifiedAbortError
+
+		// Missing and duplicated partial decryptions
+		err = cks.VerifyDecryption(pk, ct, commitments, partials[1:], pt)
+		require.True(t, errors.As(err, &abort))
+		require.Equal(t, []ShamirPublicPoint{1}, abort.Parties)
+
+		err = cks.VerifyDecryption(pk, ct, commitments, append(partials, partials[0]), pt)
+		require.True(t, errors.As(err, &abort))
+		require.Equal(t, []ShamirPublicPoint{1}, abort.Parties)
+
+		// Invalid partial decryption
+		tampered := &PartialDecryption{Party: 2, Share: cks.AllocateShare(ct.Level()), Proof: partials[1].Proof}
+		tampered.Share.Value.Copy(partials[1].Share.Value)
+		ringQ.AddScalar(tampered.Share.Value, 1<<20, tampered.Share.Value)
+		err = cks.VerifyDecryption(pk, ct, commitments, append([]*PartialDecryption{partials[0], tampered}, partials[2:]...), pt)
+		require.True(t, errors.As(err, &abort))
+		require.Equal(t, []ShamirPublicPoint{2}, abort.Parties)
+
+		// The commitments are bound to the public key
+		require.Error(t, cks.VerifyDecryption(rlwe.NewPublicKey(params), ct, commitments, partials, pt))
+	})
+}
+
+// memoryHub routes the shares broadcast by the parties of an Orchestrator in memory.
+type memoryHub struct {
+	sync.Mutex
+	parties []ShamirPublicPoint
+	inboxes map[ShamirPublicPoint]map[int]chan memoryMessage
+
+	// received is called, if not nil, after each share received by a party.
+	received func()
+}
+
+type memoryMessage struct {
+	party ShamirPublicPoint
+	share []byte
+}
+
+func newMemoryHub(parties []ShamirPublicPoint) *memoryHub {
+	return &memoryHub{parties: parties, inboxes: map[ShamirPublicPoint]map[int]chan memoryMessage{}}
+}
+
+func (hub *memoryHub) inbox(party ShamirPublicPoint, round int) chan memoryMessage {
+	hub.Lock()
+	defer hub.Unlock()
+	if hub.inboxes[party] == nil {
+		hub.inboxes[party] = map[int]chan memoryMessage{}
+	}
+	if hub.inboxes[party][round] == nil {
+		hub.inboxes[party][round] = make(chan memoryMessage, 16*len(hub.parties))
+	}
+	return hub.inboxes[party][round]
+}
+
+type memoryTransport struct {
+	hub  *memoryHub
+	self ShamirPublicPoint
+}
+
+func (tr memoryTransport) Broadcast(ctx context.Context, round int, share []byte) error {
+	for _, p := range tr.hub.parties {
+		if p != tr.self {
+			tr.hub.inbox(p, round) <- memoryMessage{tr.self, share}
+		}
+	}
+	return nil
+}
+
+func (tr memoryTransport) Receive(ctx context.Context, round int) (ShamirPublicPoint, []byte, error) {
+	select {
+	case msg := <-tr.hub.inbox(tr.self, round):
+		if tr.hub.received != nil {
+			tr.hub.received()
+		}
+		return msg.party, msg.share, nil
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+}
+
+// runOrchestrators runs the orchestrators concurrently and returns their outputs and errors.
+func runOrchestrators(orchestrators []*Orchestrator, hub *memoryHub, inputs []interface{}) (outputs []interface{}, errs []error) {
+	outputs, errs = make([]interface{}, len(orchestrators)), make([]error, len(orchestrators))
+	var wg sync.WaitGroup
+	for i := range orchestrators {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs[i], errs[i] = orchestrators[i].Run(context.Background(), memoryTransport{hub, hub.parties[i]}, inputs[i])
+		}(i)
+	}
+	wg.Wait()
+	return
+}
+
+func testOrchestrator(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+	ringP := params.RingP()
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	parties := make([]ShamirPublicPoint, nbParties)
+	for i := range parties {
+		parties[i] = ShamirPublicPoint(i + 1)
+	}
+
+	t.Run(testString(params, "Orchestrator/CKG"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		orchestrators := make([]*Orchestrator, nbParties)
+		inputs := make([]interface{}, nbParties)
+		for i := range orchestrators {
+			var err error
+			orchestrators[i], err = NewOrchestrator(CKGRounds{ckg.ShallowCopy()}, parties[i], parties)
+			require.NoError(t, err)
+			inputs[i] = CKGInputs{SecretKey: testCtx.skShares[i], CRP: crp}
+		}
+
+		outputs, errs := runOrchestrators(orchestrators, newMemoryHub(parties), inputs)
+
+		for i := range outputs {
+			require.NoError(t, errs[i])
+			require.True(t, outputs[i].(*rlwe.PublicKey).Equals(outputs[0].(*rlwe.PublicKey)))
+			require.Equal(t, PhaseDone, orchestrators[i].State().Phase)
+		}
+
+		pk := outputs[0].(*rlwe.PublicKey)
+
+		// [-as + e] + [as]
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, testCtx.skIdeal.Value, pk.Value[1], pk.Value[0])
+		ringQP.InvNTTLvl(levelQ, levelP, pk.Value[0], pk.Value[0])
+
+		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].Q.Level(), ringQ, pk.Value[0].Q))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].P.Level(), ringP, pk.Value[0].P))
+
+		_, err := NewOrchestrator(CKGRounds{ckg}, 0, parties)
+		require.Error(t, err)
+		_, err = NewOrchestrator(CKGRounds{ckg}, ShamirPublicPoint(nbParties+1), parties)
+		require.Error(t, err)
+	})
+
+	t.Run(testString(params, "Orchestrator/Tracing"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+		hub := newMemoryHub(parties)
+		tracer := new(spanRecorder)
+
+		var wg sync.WaitGroup
+		errs := make([]error, nbParties)
+		for i := range parties {
+			orchestrator, err := NewOrchestrator(CKGRounds{ckg.ShallowCopy()}, parties[i], parties)
+			require.NoError(t, err)
+			ctx := context.Background()
+			if i == 0 {
+				ctx = rlwe.ContextWithTracer(ctx, tracer)
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = orchestrator.Run(ctx, memoryTransport{hub, parties[i]}, CKGInputs{SecretKey: testCtx.skShares[i], CRP: crp})
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range errs {
+			require.NoError(t, errs[i])
+		}
+
+		// Only the orchestrator of the traced context starts spans
+		require.Equal(t, []string{
+			"drlwe.Orchestrator/Run",
+			"drlwe.Orchestrator/Run>drlwe.Orchestrator/GenShare",
+			"drlwe.Orchestrator/Run>drlwe.Orchestrator/Exchange",
+			"drlwe.Orchestrator/Run>drlwe.Orchestrator/AggregateShares",
+			"drlwe.Orchestrator/Run>drlwe.Orchestrator/Finalize",
+		}, tracer.spans)
+		require.Equal(t, len(tracer.spans), tracer.ended)
+	})
+
+	t.Run(testString(params, "Orchestrator/RKG/Resume"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		rkg := NewRKGProtocol(params)
+		crp := rkg.SampleCRP(testCtx.crs)
+
+		// The round timeout expires once the responding parties have received all the shares of each other
+		expiry := make(chan time.Time)
+		var expire sync.Once
+		var nbReceived int32
+
+		hub := newMemoryHub(parties)
+		hub.received = func() {
+			if atomic.AddInt32(&nbReceived, 1) == int32((nbParties-1)*(nbParties-2)) {
+				expire.Do(func() { close(expiry) })
+			}
+		}
+
+		rounds := make([]*RKGRounds, nbParties)
+		orchestrators := make([]*Orchestrator, nbParties)
+		inputs := make([]interface{}, nbParties)
+		for i := range orchestrators {
+			rounds[i] = NewRKGRounds(rkg.ShallowCopy())
+			orchestrators[i], _ = NewOrchestrator(rounds[i], parties[i], parties)
+			inputs[i] = RKGInputs{SecretKey: testCtx.skShares[i], CRP: crp}
+		}
+
+		for _, o := range orchestrators[:nbParties-1] {
+			o.RoundTimeout = time.Minute
+			o.MaxRetries = 1
+			o.after = func(time.Duration) <-chan time.Time { return expiry }
+		}
+
+		// The last party does not respond: the first round times out after the retry
+		_, errs := runOrchestrators(orchestrators[:nbParties-1], hub, inputs[:nbParties-1])
+		for i := range errs {
+			require.ErrorIs(t, errs[i], context.DeadlineExceeded)
+			state := orchestrators[i].State()
+			require.Equal(t, 0, state.Round)
+			require.Equal(t, PhaseExchange, state.Phase)
+			require.Len(t, state.Received, nbParties-2)
+
+			// The run is resumed on a new Orchestrator
+			orchestrators[i], _ = NewOrchestrator(rounds[i], parties[i], parties)
+			require.NoError(t, orchestrators[i].Resume(state))
+		}
+
+		outputs, errs := runOrchestrators(orchestrators, hub, inputs)
+
+		for i := range outputs {
+			require.NoError(t, errs[i])
+			require.True(t, outputs[i].(*rlwe.RelinearizationKey).Equals(outputs[0].(*rlwe.RelinearizationKey)))
+		}
+
+		require.Error(t, orchestrators[0].Resume(&OrchestratorState{Round: 1}))
+	})
+
+	t.Run(testString(params, "Orchestrator/Simulate"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		link := LinkProfile{Latency: 10 * time.Millisecond, Bandwidth: 1 << 30}
+		net := NewSimulatedNetwork(parties, link)
+
+		rkg := NewRKGProtocol(params)
+		crp := rkg.SampleCRP(testCtx.crs)
+
+		protocols := make([]RoundProtocol, nbParties)
+		inputs := make([]interface{}, nbParties)
+		for i := range protocols {
+			protocols[i] = NewRKGRounds(rkg.ShallowCopy())
+			inputs[i] = RKGInputs{SecretKey: testCtx.skShares[i], CRP: crp}
+		}
+
+		outputs, stats, err := Simulate(context.Background(), net, protocols, inputs)
+		require.NoError(t, err)
+
+		for i := range outputs {
+			require.True(t, outputs[i].(*rlwe.RelinearizationKey).Equals(outputs[0].(*rlwe.RelinearizationKey)))
+		}
+
+		require.Len(t, stats, 2)
+		for r, rs := range stats {
+			require.Equal(t, r, rs.Round)
+			require.Equal(t, nbParties*(nbParties-1), rs.Messages)
+			require.Equal(t, nbParties*(nbParties-1)*rs.MaxShareSize, rs.Bytes)
+			require.GreaterOrEqual(t, int64(rs.WallTime), int64(link.Latency+link.transmissionTime((nbParties-1)*rs.MaxShareSize)))
+		}
+
+		_, _, err = Simulate(context.Background(), net, protocols[1:], inputs)
+		require.Error(t, err)
+	})
+}
+
+func testCollectiveEncryption(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+
+	parties := make([]ShamirPublicPoint, nbParties)
+	for i := range parties {
+		parties[i] = ShamirPublicPoint(i + 1)
+	}
+
+	t.Run(testString(params, "CollectiveEncryption"), func(t *testing.T) {
+
+		ce := make([]*CollectiveEncryption, nbParties)
+		for i := range ce {
+			var err error
+			ce[i], err = NewCollectiveEncryption(params, testCtx.skShares[i], parties[i], parties, rlwe.DefaultSigma)
+			require.NoError(t, err)
+		}
+
+		_, err := NewCollectiveEncryption(params, testCtx.skShares[0], ShamirPublicPoint(nbParties+1), parties, rlwe.DefaultSigma)
+		require.Error(t, err)
+
+		_, err = ce[0].NewEncryptor()
+		require.Error(t, err)
+
+		// run runs the operation op of all the parties concurrently, each operation over its own memoryHub
+		run := func(op func(i int, transport Transport) (interface{}, error)) (outputs []interface{}) {
+			hub := newMemoryHub(parties)
+			outputs, errs := make([]interface{}, nbParties), make([]error, nbParties)
+			var wg sync.WaitGroup
+			for i := range ce {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					outputs[i], errs[i] = op(i, memoryTransport{hub, parties[i]})
+				}(i)
+			}
+			wg.Wait()
+			for i := range errs {
+				require.NoError(t, errs[i])
+			}
+			return
+		}
+
+		run(func(i int, transport Transport) (interface{}, error) {
+			crs, err := utils.NewKeyedPRNG([]byte{'c', 'k', 'g'})
+			if err != nil {
+				return nil, err
+			}
+			return ce[i].GenPublicKey(context.Background(), transport, crs)
+		})
+
+		pk := ce[0].PublicKey()
+		for i := range ce {
+			require.True(t, pk.Equals(ce[i].PublicKey()))
+		}
+
+		enc, err := ce[0].NewEncryptor()
+		require.NoError(t, err)
+
+		ct := rlwe.NewCiphertextNTT(params, 1, params.MaxLevel())
+		enc.Encrypt(rlwe.NewPlaintext(params, params.MaxLevel()), ct)
+
+		log2Bound := bits.Len64(uint64(params.N()) * uint64(params.N()) * 64 * uint64(nbParties))
+
+		// verifyDecryption checks that the ciphertext is an encryption of zero under sk
+		verifyDecryption := func(dec rlwe.Decryptor, ct *rlwe.Ciphertext) {
+			pt := rlwe.NewPlaintext(params, ct.Level())
+			dec.Decrypt(ct, pt)
+			if pt.Value.IsNTT {
+				ringQ.InvNTTLvl(pt.Level(), pt.Value, pt.Value)
+			}
+			require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pt.Level(), ringQ, pt.Value))
+		}
+
+		t.Run("DecryptTo", func(t *testing.T) {
+
+			target := parties[nbParties-1]
+
+			outputs := run(func(i int, transport Transport) (interface{}, error) {
+				return ce[i].DecryptTo(context.Background(), transport, ct, target)
+			})
+
+			for i := range outputs {
+				require.True(t, outputs[i].(*rlwe.Ciphertext).Value[0].Equals(outputs[0].(*rlwe.Ciphertext).Value[0]))
+			}
+
+			verifyDecryption(ce[nbParties-1].NewDecryptor(), outputs[0].(*rlwe.Ciphertext))
+
+			_, err := ce[0].DecryptTo(context.Background(), nil, ct, ShamirPublicPoint(nbParties+1))
+			require.Error(t, err)
+			_, err = ce[0].DecryptTo(context.Background(), nil, rlwe.NewCiphertextNTT(params, 2, params.MaxLevel()), target)
+			require.Error(t, err)
+		})
+
+		t.Run("ReEncryptTo", func(t *testing.T) {
+
+			skOut, pkOut := testCtx.kgen.GenKeyPair()
+
+			outputs := run(func(i int, transport Transport) (interface{}, error) {
+				return ce[i].ReEncryptTo(context.Background(), transport, ct, pkOut)
+			})
+
+			verifyDecryption(rlwe.NewDecryptor(params, skOut), outputs[0].(*rlwe.Ciphertext))
+		})
+	})
+
+	t.Run(testString(params, "Roles"), func(t *testing.T) {
+
+		// The first party is an input party, the others are compute parties
+		input := NewInputParty(params, testCtx.skShares[0], rlwe.DefaultSigma)
+		compute := make([]*ComputeParty, nbParties-1)
+		for i := range compute {
+			compute[i] = NewComputeParty(params, testCtx.skShares[i+1], rlwe.DefaultSigma)
+		}
+
+		_, err := input.NewEncryptor()
+		require.Error(t, err)
+
+		ckg := compute[0].CKG()
+		require.True(t, ckg == compute[0].CKG())
+
+		crp := ckg.SampleCRP(testCtx.crs)
+		ckgShare := input.GenCKGShare(crp)
+		for _, cp := range compute {
+			share := cp.CKG().AllocateShare()
+			cp.CKG().GenShare(cp.SecretKey(), crp, share)
+			ckg.AggregateShare(ckgShare, share, ckgShare)
+		}
+
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(ckgShare, crp, pk)
+		input.SetPublicKey(pk)
+		require.True(t, pk == input.PublicKey())
+
+		enc, err := input.NewEncryptor()
+		require.NoError(t, err)
+
+		ct := rlwe.NewCiphertextNTT(params, 1, params.MaxLevel())
+		enc.Encrypt(rlwe.NewPlaintext(params, params.MaxLevel()), ct)
+
+		// The ciphertext is decrypted with the decryption shares of all the parties
+		cksShare := input.GenDecryptionShare(ct.Value[1])
+		zero := rlwe.NewSecretKey(params)
+		for _, cp := range compute {
+			share := cp.CKS().AllocateShare(ct.Level())
+			cp.CKS().GenShare(cp.SecretKey(), zero, ct.Value[1], share)
+			cp.CKS().AggregateShare(cksShare, share, cksShare)
+		}
+
+		pt := ringQ.NewPoly()
+		ringQ.Add(ct.Value[0], cksShare.Value, pt)
+		ringQ.InvNTT(pt, pt)
+
+		log2Bound := bits.Len64(uint64(params.N()) * uint64(params.N()) * 64 * uint64(nbParties))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pt.Level(), ringQ, pt))
+	})
+}
+
+func testMalicious(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+
+	parties := make([]ShamirPublicPoint, nbParties)
+	for i := range parties {
+		parties[i] = ShamirPublicPoint(i + 1)
+	}
+
+	t.Run(testString(params, "Malicious/CRS"), func(t *testing.T) {
+
+		commitments := map[ShamirPublicPoint]CRSCommitment{}
+		contributions := map[ShamirPublicPoint]*CRSContribution{}
+		for _, p := range parties {
+			contributions[p] = NewCRSContribution()
+			commitments[p] = contributions[p].Commit(p)
+		}
+
+		crs0, err := NewCommittedCRS(commitments, contributions)
+		require.NoError(t, err)
+		crs1, err := NewCommittedCRS(commitments, contributions)
+		require.NoError(t, err)
+
+		buf0, buf1 := make([]byte, 64), make([]byte, 64)
+		crs0.Clock(buf0)
+		crs1.Clock(buf1)
+		require.Equal(t, buf0, buf1)
+
+		// The last party changes its contribution after the commitments
+		contributions[parties[nbParties-1]] = NewCRSContribution()
+		_, err = NewCommittedCRS(commitments, contributions)
+		require.Equal(t, &IdentifiedAbortError{Parties: parties[nbParties-1:], Reason: "invalid CRS contribution"}, err)
+	})
+
+	t.Run(testString(params, "Malicious/Transcripts"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		shares := make([]*CKGShare, nbParties)
+		for i := range shares {
+			shares[i] = ckg.AllocateShare()
+			ckg.GenShare(testCtx.skShares[i], crp, shares[i])
+		}
+
+		echoes := map[ShamirPublicPoint]map[ShamirPublicPoint]ShareDigest{}
+		for i, receiver := range parties {
+			tr := NewShareTranscript()
+			for j, sender := range parties {
+				if i != j {
+					require.NoError(t, tr.Add(sender, shares[j]))
+				}
+			}
+			require.Error(t, tr.Add(parties[(i+1)%nbParties], shares[0]))
+			echoes[receiver] = tr.Digests()
+		}
+
+		require.NoError(t, CheckTranscripts(echoes))
+
+		// The first party sends a different share to the last party
+		tr := NewShareTranscript()
+		equivocated := ckg.AllocateShare()
+		ckg.GenShare(testCtx.skShares[0], crp, equivocated)
+		for j, sender := range parties[:nbParties-1] {
+			if j == 0 {
+				require.NoError(t, tr.Add(sender, equivocated))
+			} else {
+				require.NoError(t, tr.Add(sender, shares[j]))
+			}
+		}
+		echoes[parties[nbParties-1]] = tr.Digests()
+
+		require.Equal(t, &IdentifiedAbortError{Parties: parties[:1], Reason: "inconsistent shares"}, CheckTranscripts(echoes))
+	})
+
+	t.Run(testString(params, "Malicious/Commitments"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		shares := make([]*CKGShare, nbParties)
+		commitments := NewShareCommitments()
+		for i, p := range parties {
+			shares[i] = ckg.AllocateShare()
+			ckg.GenShare(testCtx.skShares[i], crp, shares[i])
+			cmt, err := CommitShare(p, shares[i])
+			require.NoError(t, err)
+			require.NoError(t, commitments.Add(p, cmt))
+			require.NoError(t, commitments.Add(p, cmt))
+		}
+
+		// The commitments bind the shares to the parties
+		cmt, err := CommitShare(parties[1], shares[0])
+		require.NoError(t, err)
+		require.Equal(t, &IdentifiedAbortError{Parties: parties[:1], Reason: "conflicting share commitments"}, commitments.Add(parties[0], cmt))
+
+		// The parties that recorded the same commitments have the same aggregate digest
+		other := NewShareCommitments()
+		for p, cmt := range commitments.Commitments() {
+			require.NoError(t, other.Add(p, ShareCommitment(cmt)))
+		}
+		require.Equal(t, commitments.Digest(), other.Digest())
+		require.NotEqual(t, commitments.Digest(), NewShareCommitments().Digest())
+
+		shareOut := ckg.AllocateShare()
+		agg, err := ckg.NewAggregator(parties, shareOut)
+		require.NoError(t, err)
+		agg.SetCommitments(commitments)
+
+		// A share corrupted in transmission is rejected
+		corrupted := ckg.AllocateShare()
+		corrupted.Value.Copy(shares[0].Value)
+		corrupted.Value.Q.Coeffs[0][0]++
+		var abort *IdentifiedAbortError
+		require.True(t, errors.As(agg.Put(parties[0], corrupted), &abort))
+		require.Equal(t, parties[:1], abort.Parties)
+
+		for i, p := range parties {
+			require.NoError(t, agg.Put(p, shares[i]))
+		}
+
+		want := ckg.AllocateShare()
+		for _, share := range shares {
+			ckg.AggregateShare(want, share, want)
+		}
+		require.True(t, want.Value.Equals(shareOut.Value))
+
+		// An uncommitted party is rejected by a ShareAggregator
+		tagg, err := NewShareAggregator(append(parties, ShamirPublicPoint(nbParties+1)), 1, nil)
+		require.NoError(t, err)
+		tagg.SetCommitments(commitments)
+		require.NoError(t, tagg.Add(parties[0], shares[0]))
+		require.Error(t, tagg.Replace(parties[0], shares[1]))
+		require.Error(t, tagg.Add(ShamirPublicPoint(nbParties+1), shares[0]))
+	})
+
+	t.Run(testString(params, "Malicious/CKG+Decryption"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		ckg := NewCKGProtocol(params)
+		cks := NewCKSProtocol(params, rlwe.DefaultSigma)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		shares := map[ShamirPublicPoint]*CKGShare{}
+		proofs := map[ShamirPublicPoint]*ShareProof{}
+		commitments := map[ShamirPublicPoint]*SecretKeyCommitment{}
+		for i, p := range parties {
+			shares[p] = ckg.AllocateShare()
+			ckg.GenShare(testCtx.skShares[i], crp, shares[p])
+			var err error
+			proofs[p], err = ckg.GenProof(testCtx.skShares[i], crp, shares[p])
+			require.NoError(t, err)
+			commitments[p] = &SecretKeyCommitment{CRP: crp, Share: shares[p]}
+		}
 
-		skout := make([]*rlwe.SecretKey, nbParties)
-		skOutIdeal := rlwe.NewSecretKey(params)
-		for i := range skout {
-			skout[i] = testCtx.kgen.GenSecretKey()
-			ringQP.AddLvl(levelQ, levelP, skOutIdeal.Value, skout[i].Value, skOutIdeal.Value)
+		// The proof of the second party is replaced by the proof of the first party
+		proof := proofs[parties[1]]
+		proofs[parties[1]] = proofs[parties[0]]
+		shareOut := ckg.AllocateShare()
+		require.Equal(t, &IdentifiedAbortError{Parties: parties[1:2], Reason: "invalid CKG share"}, ckg.AggregateVerifiedShares(crp, shares, proofs, shareOut))
+		require.True(t, shareOut.Value.Equals(ckg.AllocateShare().Value))
+
+		proofs[parties[1]] = proof
+		require.NoError(t, ckg.AggregateVerifiedShares(crp, shares, proofs, shareOut))
+
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(shareOut, crp, pk)
+
+		// Collective decryption of an encryption of zero, bound to the CKG shares
+		ciphertext := rlwe.NewCiphertextNTT(params, 1, params.MaxLevel())
+		rlwe.NewEncryptor(params, pk).Encrypt(&rlwe.Plaintext{Value: ringQ.NewPoly()}, ciphertext)
+
+		zero := rlwe.NewSecretKey(params)
+		decShares := map[ShamirPublicPoint]*CKSShare{}
+		decProofs := map[ShamirPublicPoint]*ShareProof{}
+		for i, p := range parties {
+			decShares[p] = cks.AllocateShare(ciphertext.Level())
+			cks.GenShare(testCtx.skShares[i], zero, ciphertext.Value[1], decShares[p])
+			var err error
+			decProofs[p], err = cks.GenProof(testCtx.skShares[i], zero, ciphertext.Value[1], decShares[p], commitments[p], nil)
+			require.NoError(t, err)
 		}
 
-		ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
-		testCtx.uniformSampler.Read(ciphertext.Value[1])
-		ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
-		ciphertext.Value[0].IsNTT = true
-		ciphertext.Value[1].IsNTT = true
+		// The last party adds an error to its share after the proof
+		ringQ.AddScalar(decShares[parties[nbParties-1]].Value, 1<<20, decShares[parties[nbParties-1]].Value)
 
-		shares := make([]*CKSShare, nbParties)
-		for i := range shares {
-			shares[i] = cks[i].AllocateShare(ciphertext.Level())
+		decShareOut := cks.AllocateShare(ciphertext.Level())
+		err := cks.AggregateVerifiedShares(ciphertext.Value[1], decShares, decProofs, commitments, nil, decShareOut)
+		require.Equal(t, &IdentifiedAbortError{Parties: parties[nbParties-1:], Reason: "invalid CKS share"}, err)
+
+		ringQ.SubScalar(decShares[parties[nbParties-1]].Value, 1<<20, decShares[parties[nbParties-1]].Value)
+		require.NoError(t, cks.AggregateVerifiedShares(ciphertext.Value[1], decShares, decProofs, commitments, nil, decShareOut))
+
+		cks.KeySwitch(ciphertext, decShareOut, ciphertext)
+		ringQ.InvNTT(ciphertext.Value[0], ciphertext.Value[0])
+
+		// The decryption is u*e_pk + e0 + e1*s + sum(e_cks): u is ternary, e_pk is the sum of nbParties errors and
+		// s the sum of nbParties ternary secrets, so each coefficient is bounded by B*(2*N*nbParties + 1 + nbParties),
+		// with B the bound of the errors, plus nbParties for the rounding of the shares.
+		B := uint64(math.Floor(rlwe.DefaultSigma * 6))
+		N := uint64(params.N())
+		log2Bound := bits.Len64(N * (B*(2*N*uint64(nbParties)+1+uint64(nbParties)) + uint64(nbParties)))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(ciphertext.Level(), ringQ, ciphertext.Value[0]))
+	})
+
+	t.Run(testString(params, "Malicious/ProofOfPossession"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("#Pi is empty")
 		}
 
-		for i := range shares {
-			cks[i].GenShare(testCtx.skShares[i], skout[i], ciphertext.Value[1], shares[i])
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
+
+		shares := map[ShamirPublicPoint]*CKGShare{}
+		proofs := map[ShamirPublicPoint]*ShareProof{}
+		for i, p := range parties {
+			shares[p] = ckg.AllocateShare()
+			ckg.GenShare(testCtx.skShares[i], crp, shares[p])
+			var err error
+			proofs[p], err = ckg.GenPossessionProof(p, testCtx.skShares[i], crp, shares[p])
+			require.NoError(t, err)
 		}
 
-		for i := 1; i < nbParties; i++ {
-			cks[i].AggregateShare(shares[0], shares[i], shares[0])
+		// The proof of a party does not verify for another party, even with the same share
+		require.Error(t, ckg.VerifyPossession(parties[1], crp, shares[parties[0]], proofs[parties[0]]))
+
+		// The rogue share of the last party cancels the shares of the other parties
+		last := parties[nbParties-1]
+		rogue := ckg.AllocateShare()
+		ckg.GenShare(testCtx.skShares[nbParties-1], crp, rogue)
+		for _, p := range parties[:nbParties-1] {
+			params.RingQP().SubLvl(params.QCount()-1, params.PCount()-1, rogue.Value, shares[p].Value, rogue.Value)
 		}
+		_, err := ckg.GenPossessionProof(last, testCtx.skShares[nbParties-1], crp, rogue)
+		require.Error(t, err)
 
-		ksCiphertext := &rlwe.Ciphertext{Value: []*ring.Poly{params.RingQ().NewPoly(), params.RingQ().NewPoly()}}
+		share, proof := shares[last], proofs[last]
+		shares[last] = rogue
+		shareOut := ckg.AllocateShare()
+		require.Equal(t, &IdentifiedAbortError{Parties: []ShamirPublicPoint{last}, Reason: "unproven possession of the CKG share"}, ckg.AggregatePossessedShares(crp, shares, proofs, shareOut))
+		require.True(t, shareOut.Value.Equals(ckg.AllocateShare().Value))
 
-		cks[0].KeySwitch(ciphertext, shares[0], ksCiphertext)
+		shares[last] = share
+		delete(proofs, last)
+		require.Error(t, ckg.AggregatePossessedShares(crp, shares, proofs, shareOut))
 
-		// [-as + e] + [as]
-		ringQ.MulCoeffsMontgomeryAndAdd(ksCiphertext.Value[1], skOutIdeal.Value.Q, ksCiphertext.Value[0])
-		ringQ.InvNTT(ksCiphertext.Value[0], ksCiphertext.Value[0])
-		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
-		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(ksCiphertext.Value[0].Level(), ringQ, ksCiphertext.Value[0]))
+		proofs[last] = proof
+		require.NoError(t, ckg.AggregatePossessedShares(crp, shares, proofs, shareOut))
 
+		expected := ckg.AllocateShare()
+		for _, p := range parties {
+			ckg.AggregateShare(expected, shares[p], expected)
+		}
+		require.True(t, shareOut.Value.Equals(expected.Value))
 	})
 }
 
-func testPublicKeySwitching(testCtx testContext, t *testing.T) {
+func testBlame(testCtx testContext, t *testing.T) {
 
 	params := testCtx.params
-	ringQ := params.RingQ()
 
-	t.Run(testString(params, "PublicKeySwitching"), func(t *testing.T) {
+	t.Run(testString(params, "Blame/Bisect"), func(t *testing.T) {
 
-		skOut, pkOut := testCtx.kgen.GenKeyPair()
+		parties := make([]ShamirPublicPoint, 16)
+		for i := range parties {
+			parties[i] = ShamirPublicPoint(i + 1)
+		}
 
-		pcks := make([]*PCKSProtocol, nbParties)
-		for i := range pcks {
-			if i == 0 {
-				pcks[i] = NewPCKSProtocol(params, rlwe.DefaultSigma)
-			} else {
-				pcks[i] = pcks[0].ShallowCopy()
+		faulty := map[ShamirPublicPoint]bool{3: true, 11: true, 12: true}
+
+		tests := 0
+		identified, err := Bisect(parties, func(subset []ShamirPublicPoint) (bool, error) {
+			tests++
+			for _, p := range subset {
+				if faulty[p] {
+					return false, nil
+				}
 			}
-		}
+			return true, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []ShamirPublicPoint{3, 11, 12}, identified)
+		require.Less(t, tests, len(parties))
 
-		var _ PublicKeySwitchingProtocol = pcks[0]
+		identified, err = Bisect(parties, func(subset []ShamirPublicPoint) (bool, error) { return true, nil })
+		require.NoError(t, err)
+		require.Empty(t, identified)
 
-		ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
-		testCtx.uniformSampler.Read(ciphertext.Value[1])
-		ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
-		ciphertext.Value[0].IsNTT = true
-		ciphertext.Value[1].IsNTT = true
+		_, err = Bisect(parties, func(subset []ShamirPublicPoint) (bool, error) { return false, errors.New("test error") })
+		require.Error(t, err)
+	})
 
-		shares := make([]*PCKSShare, nbParties)
-		for i := range shares {
-			shares[i] = pcks[i].AllocateShare(ciphertext.Level())
-		}
+	t.Run(testString(params, "Blame/CKG"), func(t *testing.T) {
 
-		for i := range shares {
-			pcks[i].GenShare(testCtx.skShares[i], pkOut, ciphertext.Value[1], shares[i])
-		}
+		n := 8
+		ckg := NewCKGProtocol(params)
+		crp := ckg.SampleCRP(testCtx.crs)
 
-		for i := 1; i < nbParties; i++ {
-			pcks[0].AggregateShare(shares[0], shares[i], shares[0])
+		parties := make([]ShamirPublicPoint, n)
+		for i := range parties {
+			parties[i] = ShamirPublicPoint(i + 1)
 		}
+		commitments := make(map[ShamirPublicPoint]*SecretKeyCommitment, n)
 
-		ksCiphertext := &rlwe.Ciphertext{Value: []*ring.Poly{params.RingQ().NewPoly(), params.RingQ().NewPoly()}}
+		agg, err := NewShareAggregator(parties, n, func(share1, share2, shareOut interface{}) {
+			ckg.AggregateShare(share1.(*CKGShare), share2.(*CKGShare), shareOut.(*CKGShare))
+		})
+		require.NoError(t, err)
 
-		pcks[0].KeySwitch(ciphertext, shares[0], ksCiphertext)
+		// The parties commit to their CKG shares, and parties 2 and 7 send a share that does not match its commitment
+		for _, p := range parties {
+			share := ckg.AllocateShare()
+			ckg.GenShare(testCtx.kgen.GenSecretKey(), crp, share)
+			commitments[p] = &SecretKeyCommitment{CRP: crp, Share: share}
+			if p == 2 || p == 7 {
+				malformed := ckg.AllocateShare()
+				malformed.Value.Copy(share.Value)
+				params.RingQ().AddScalar(malformed.Value.Q, 1, malformed.Value.Q)
+				share = malformed
+			}
+			require.NoError(t, agg.Add(p, share))
+		}
 
-		// [-as + e] + [as]
-		ringQ.MulCoeffsMontgomeryAndAdd(ksCiphertext.Value[1], skOut.Value.Q, ksCiphertext.Value[0])
-		ringQ.InvNTT(ksCiphertext.Value[0], ksCiphertext.Value[0])
-		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
-		require.GreaterOrEqual(t, log2Bound+5, log2OfInnerSum(ksCiphertext.Value[0].Level(), ringQ, ksCiphertext.Value[0]))
+		shareOut := ckg.AllocateShare()
+		require.NoError(t, agg.Finalize(shareOut))
+		ok, err := ckg.CommitmentTest(crp, commitments)(parties, shareOut)
+		require.NoError(t, err)
+		require.False(t, ok) // the public key does not match the commitments
 
+		err = agg.Bisect(func() interface{} { return ckg.AllocateShare() }, ckg.CommitmentTest(crp, commitments))
+		var abort *IdentifiedAbortError
+		require.True(t, errors.As(err, &abort))
+		require.Equal(t, []ShamirPublicPoint{2, 7}, abort.Parties)
+
+		// The commitments must be for the common reference polynomial of the shares
+		err = agg.Bisect(func() interface{} { return ckg.AllocateShare() }, ckg.CommitmentTest(ckg.SampleCRP(testCtx.crs), commitments))
+		require.Error(t, err)
+		require.False(t, errors.As(err, &abort))
 	})
-}
 
-func testRelinKeyGen(testCtx testContext, t *testing.T) {
-	params := testCtx.params
-	ringQ := params.RingQ()
-	ringP := params.RingP()
-	ringQP := params.RingQP()
-	levelQ, levelP := params.QCount()-1, params.PCount()-1
+	t.Run(testString(params, "Blame/CKS"), func(t *testing.T) {
 
-	t.Run(testString(params, "RelinKeyGen"), func(t *testing.T) {
+		ckg := NewCKGProtocol(params)
+		cks := NewCKSProtocol(params, rlwe.DefaultSigma)
+		zero := rlwe.NewSecretKey(params)
 
-		if params.PCount() == 0 {
-			t.Skip("method is unsuported when params.PCount() == 0")
+		c1 := params.RingQ().NewPoly()
+		testCtx.uniformSampler.Read(c1)
+		c1.IsNTT = true
+
+		parties := make([]ShamirPublicPoint, nbParties)
+		for i := range parties {
+			parties[i] = ShamirPublicPoint(i + 1)
 		}
 
-		rkg := make([]*RKGProtocol, nbParties)
+		agg, err := NewShareAggregator(parties, nbParties, func(share1, share2, shareOut interface{}) {
+			cks.AggregateShare(share1.(*CKSShare), share2.(*CKSShare), shareOut.(*CKSShare))
+		})
+		require.NoError(t, err)
 
-		for i := range rkg {
-			if i == 0 {
-				rkg[i] = NewRKGProtocol(params)
-			} else {
-				rkg[i] = rkg[0].ShallowCopy()
+		proofs := map[ShamirPublicPoint]*ShareProof{}
+		commitments := map[ShamirPublicPoint]*SecretKeyCommitment{}
+		for i, p := range parties {
+			sk := testCtx.skShares[i]
+			cmt := &SecretKeyCommitment{CRP: ckg.SampleCRP(testCtx.crs), Share: ckg.AllocateShare()}
+			ckg.GenShare(sk, cmt.CRP, cmt.Share)
+			commitments[p] = cmt
+
+			share := cks.AllocateShare(c1.Level())
+			cks.GenShare(sk, zero, c1, share)
+			proofs[p], err = cks.GenProof(sk, zero, c1, share, cmt, nil)
+			require.NoError(t, err)
+
+			// The last party sends a malformed share with the proof of its well-formed share
+			if i == nbParties-1 {
+				params.RingQ().AddScalar(share.Value, 1<<20, share.Value)
 			}
+			require.NoError(t, agg.Add(p, share))
 		}
 
-		var _ RelinearizationKeyGenerator = rkg[0]
+		err = agg.Identify(cks.ProofTest(c1, proofs, commitments, nil))
+		var abort *IdentifiedAbortError
+		require.True(t, errors.As(err, &abort))
+		require.Equal(t, []ShamirPublicPoint{parties[nbParties-1]}, abort.Parties)
+	})
 
-		ephSk := make([]*rlwe.SecretKey, nbParties)
-		share1 := make([]*RKGShare, nbParties)
-		share2 := make([]*RKGShare, nbParties)
+	t.Run(testString(params, "Blame/Audit"), func(t *testing.T) {
 
-		for i := range rkg {
-			ephSk[i], share1[i], share2[i] = rkg[i].AllocateShare()
-		}
+		session := []byte("session")
+		crp := NewCKGProtocol(params).SampleCRP(testCtx.crs)
 
-		crp := rkg[0].SampleCRP(testCtx.crs)
-		for i := range rkg {
-			rkg[i].GenShareRoundOne(testCtx.skShares[i], crp, ephSk[i], share1[i])
+		// genShare generates the CKG share of the session of a party from its seed
+		genShare := func(seed *SessionSeed) (Share, error) {
+			ckg := NewCKGProtocol(params, WithSeed(seed.ProtocolSeed("CKG")))
+			share := ckg.AllocateShare()
+			ckg.GenShare(seed.GenSecretKey(params), crp, share)
+			return share, nil
 		}
 
-		for i := 1; i < nbParties; i++ {
-			rkg[0].AggregateShare(share1[0], share1[i], share1[0])
+		seeds := make([]*SessionSeed, nbParties)
+		commitments := make([]SeedCommitment, nbParties)
+		shares := make([]Share, nbParties)
+		for i := range seeds {
+			seeds[i] = NewSessionSeed()
+			commitments[i] = seeds[i].Commit(ShamirPublicPoint(i+1), session)
+			shares[i], _ = genShare(seeds[i])
 		}
 
-		for i := range rkg {
-			rkg[i].GenShareRoundTwo(ephSk[i], testCtx.skShares[i], share1[0], share2[i])
+		// The shares generated from the same seed are identical, and differ from the shares of other seeds
+		share, err := genShare(seeds[0])
+		require.NoError(t, err)
+		require.True(t, share.(*CKGShare).Value.Equals(shares[0].(*CKGShare).Value))
+		require.False(t, share.(*CKGShare).Value.Equals(shares[1].(*CKGShare).Value))
+
+		// The seeded RTG shares are identical for the same seed and number of goroutines
+		galEl := params.GaloisElementForRowRotation()
+		rtgCRP := NewRTGProtocol(params).SampleCRP(testCtx.crs)
+		rtgShares := make([]*RTGShare, 2)
+		for i := range rtgShares {
+			rtg := NewRTGProtocol(params, WithGoroutines(2), WithSeed(seeds[0].ProtocolSeed("RTG")))
+			rtgShares[i] = rtg.AllocateShare()
+			rtg.GenShare(testCtx.skShares[0], galEl, rtgCRP, rtgShares[i])
+		}
+		for i := range rtgShares[0].Value {
+			require.True(t, rtgShares[0].Value[i].Equals(rtgShares[1].Value[i]))
 		}
 
-		for i := 1; i < nbParties; i++ {
-			rkg[0].AggregateShare(share2[0], share2[i], share2[0])
+		// The last party sends a malformed share
+		params.RingQ().AddScalar(shares[nbParties-1].(*CKGShare).Value.Q, 1, shares[nbParties-1].(*CKGShare).Value.Q)
+
+		for i := range seeds {
+			err := AuditShare(ShamirPublicPoint(i+1), session, commitments[i], seeds[i], shares[i], genShare)
+			if i == nbParties-1 {
+				var abort *IdentifiedAbortError
+				require.True(t, errors.As(err, &abort))
+				require.Equal(t, []ShamirPublicPoint{ShamirPublicPoint(i + 1)}, abort.Parties)
+			} else {
+				require.NoError(t, err)
+			}
 		}
 
-		rlk := rlwe.NewRelinKey(params, 2)
-		rkg[0].GenRelinearizationKey(share1[0], share2[0], rlk)
+		// A party that opens another seed than the one it committed to
+		err = AuditShare(1, session, commitments[0], seeds[1], shares[1], genShare)
+		var abort *IdentifiedAbortError
+		require.True(t, errors.As(err, &abort))
+		require.Equal(t, "invalid session seed", abort.Reason)
+	})
+}
+
+func testBeaconCRS(testCtx testContext, t *testing.T) {
 
-		skIn := testCtx.skIdeal.CopyNew()
-		skOut := testCtx.skIdeal.CopyNew()
-		ringQP.MulCoeffsMontgomeryLvl(levelQ, levelP, skIn.Value, skIn.Value, skIn.Value)
+	params := testCtx.params
 
-		swk := rlk.Keys[0]
+	t.Run(testString(params, "BeaconCRS"), func(t *testing.T) {
 
-		// Decrypts
-		// [-asIn + w*P*sOut + e, a] + [asIn]
-		for j := range swk.Value {
-			ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, swk.Value[j][1], skOut.Value, swk.Value[j][0])
+		// The beacon signs the rounds with a MAC standing for its signature scheme
+		beaconKey := []byte("beacon key")
+		sign := func(round uint64) []byte {
+			h, _ := blake2b.New256(beaconKey)
+			writeBeaconUint64(h, round)
+			return h.Sum(nil)
+		}
+		newBeacon := func(round uint64) *BeaconRound {
+			sig := sign(round)
+			randomness := sha256.Sum256(sig)
+			return &BeaconRound{Round: round, Randomness: randomness[:], Signature: sig}
 		}
+		verifier := BeaconVerifierFunc(func(beacon *BeaconRound) error {
+			if !bytes.Equal(beacon.Signature, sign(beacon.Round)) {
+				return errors.New("invalid signature")
+			}
+			return nil
+		})
 
-		poly := swk.Value[0][0]
+		context := []byte("session/CKG")
+		ckg := NewCKGProtocol(params)
 
-		// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
-		// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
-		for j := range swk.Value {
-			if j > 0 {
-				ringQP.AddLvl(levelQ, levelP, poly, swk.Value[j][0], poly)
-			}
-		}
+		crs, err := NewBeaconCRS(context, newBeacon(42), verifier)
+		require.NoError(t, err)
+		crp := rlwe.PolyQP(ckg.SampleCRP(crs))
+
+		other, err := NewBeaconCRS(context, newBeacon(42), verifier)
+		require.NoError(t, err)
+		require.True(t, crp.Equals(rlwe.PolyQP(ckg.SampleCRP(other))))
+
+		other, err = NewBeaconCRS(context, newBeacon(43), verifier)
+		require.NoError(t, err)
+		require.False(t, crp.Equals(rlwe.PolyQP(ckg.SampleCRP(other))))
 
-		// sOut * P
-		ringQ.MulScalarBigint(skIn.Value.Q, ringP.ModulusBigint, skIn.Value.Q)
+		data, err := crs.Transcript().MarshalBinary()
+		require.NoError(t, err)
+		transcript := new(BeaconTranscript)
+		require.NoError(t, transcript.UnmarshalBinary(data))
+		require.Equal(t, crs.Transcript(), transcript)
+
+		replay := func(crs CRS) { ckg.SampleCRP(crs) }
+		require.NoError(t, VerifyBeaconTranscript(transcript, context, 40, verifier, replay))
+
+		// Other common reference polynomials, context or an old beacon round
+		require.Error(t, VerifyBeaconTranscript(transcript, context, 40, verifier, func(crs CRS) { ckg.SampleCRP(crs); ckg.SampleCRP(crs) }))
+		require.Error(t, VerifyBeaconTranscript(transcript, []byte("session/RKG"), 40, verifier, replay))
+		require.Error(t, VerifyBeaconTranscript(transcript, context, 43, verifier, replay))
+
+		// An invalid beacon round
+		forged := newBeacon(42)
+		forged.Signature[0] ^= 1
+		_, err = NewBeaconCRS(context, forged, verifier)
+		require.Error(t, err)
+		forged.Randomness = newBeacon(42).Randomness
+		_, err = NewBeaconCRS(context, forged, verifier)
+		require.Error(t, err)
+		_, err = NewBeaconCRS(context, newBeacon(42), nil)
+		require.Error(t, err)
+	})
+
+	t.Run(testString(params, "DerivedCRS"), func(t *testing.T) {
+
+		seed := []byte("published seed")
+		session := []byte("session")
 
-		// P*s^i + sum(e) - P*s^i = sum(e)
-		ringQ.Sub(swk.Value[0][0].Q, skIn.Value.Q, swk.Value[0][0].Q)
+		ckg := NewCKGProtocol(params)
+		sample := func(domain CRSDomain) *rlwe.PolyQP {
+			crp := rlwe.PolyQP(ckg.SampleCRP(DeriveCRS(seed, domain)))
+			return &crp
+		}
+
+		domain := CRSDomain{Protocol: ProtocolRTG.String(), Session: session, Round: 0, Instance: 5}
+		crp := sample(domain)
 
-		// Checks that the error is below the bound
-		// Worst error bound is N * floor(6*sigma) * #Keys
-		ringQP.InvNTTLvl(levelQ, levelP, poly, poly)
-		ringQP.InvMFormLvl(levelQ, levelP, poly, poly)
+		// The CRS of a domain is the same for all the parties
+		require.True(t, crp.Equals(*sample(domain)))
 
-		// Worst bound of inner sum
-		// N*#Keys*(N * #Parties * floor(sigma*6) + #Parties * floor(sigma*6) + N * #Parties  +  #Parties * floor(6*sigma))
-		log2Bound := bits.Len64(uint64(params.N() * len(swk.Value) * (params.N()*3*int(math.Floor(rlwe.DefaultSigma*6)) + 2*3*int(math.Floor(rlwe.DefaultSigma*6)) + params.N()*3)))
-		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringQ.Modulus)-1, ringQ, swk.Value[0][0].Q))
-		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringP.Modulus)-1, ringP, swk.Value[0][0].P))
+		// and independent from the CRSs of the other domains and seeds
+		others := []CRSDomain{
+			{Protocol: ProtocolRTG.String(), Session: session, Round: 0, Instance: 25},
+			{Protocol: ProtocolRTG.String(), Session: session, Round: 1, Instance: 5},
+			{Protocol: ProtocolRTG.String(), Session: []byte("other session"), Round: 0, Instance: 5},
+			{Protocol: ProtocolCKG.String(), Session: session, Round: 0, Instance: 5},
+			{Protocol: "RT", Session: append([]byte("G"), session...), Round: 0, Instance: 5},
+		}
+		for _, other := range others {
+			require.False(t, crp.Equals(*sample(other)))
+		}
+		require.False(t, crp.Equals(rlwe.PolyQP(ckg.SampleCRP(DeriveCRS([]byte("other seed"), domain)))))
 	})
 }
 
-func testRotKeyGen(testCtx testContext, t *testing.T) {
+func testMultiKey(testCtx testContext, t *testing.T) {
 
 	params := testCtx.params
 	ringQ := params.RingQ()
-	ringP := params.RingP()
-	ringQP := params.RingQP()
-	levelQ, levelP := params.QCount()-1, params.PCount()-1
 
-	t.Run(testString(params, "RotKeyGen"), func(t *testing.T) {
+	t.Run(testString(params, "MultiKey"), func(t *testing.T) {
 
-		if params.PCount() == 0 {
-			t.Skip("method is unsuported when params.PCount() == 0")
+		points := make([]ShamirPublicPoint, nbParties)
+		for i := range points {
+			points[i] = ShamirPublicPoint(i + 1)
 		}
 
-		rtg := make([]*RTGProtocol, nbParties)
-		for i := range rtg {
-			if i == 0 {
-				rtg[i] = NewRTGProtocol(params)
-			} else {
-				rtg[i] = rtg[0].ShallowCopy()
+		mkg := NewMKKeyGenerator(params)
+		crp := mkg.SampleCRP(testCtx.crs)
+
+		evk := map[ShamirPublicPoint]*MKEvaluationKey{}
+		for i, p := range points {
+			evk[p] = NewMKEvaluationKey(params)
+			mkg.ShallowCopy().GenEvaluationKey(testCtx.skShares[i], crp, evk[p])
+		}
+
+		// Encryptions of zero without noise under the individual keys of the parties
+		newCiphertext := func(parties []ShamirPublicPoint) *MKCiphertext {
+			ct := NewMKCiphertext(params, parties, params.MaxLevel())
+			for i, p := range ct.Parties {
+				testCtx.uniformSampler.Read(ct.Value[i+1])
+				ringQ.MulCoeffsMontgomeryAndSub(ct.Value[i+1], testCtx.skShares[p-1].Value.Q, ct.Value[0])
+			}
+			for i := range ct.Value {
+				ct.Value[i].IsNTT = true
 			}
+			return ct
 		}
 
-		var _ RotationKeyGenerator = rtg[0]
+		mkd := NewMKDecryptionProtocol(params, rlwe.DefaultSigma)
 
-		shares := make([]*RTGShare, nbParties)
-		for i := range shares {
-			shares[i] = rtg[i].AllocateShare()
+		decrypt := func(ct *MKCiphertext) *ring.Poly {
+			shares := map[ShamirPublicPoint]*CKSShare{}
+			for _, p := range ct.Parties {
+				shares[p] = mkd.AllocateShare(ct.Level())
+				require.NoError(t, mkd.GenShare(testCtx.skShares[p-1], p, ct, shares[p]))
+			}
+			pt := ringQ.NewPoly()
+			delete(shares, ct.Parties[0])
+			require.Error(t, mkd.Decrypt(ct, shares, pt))
+			shares[ct.Parties[0]] = mkd.AllocateShare(ct.Level())
+			require.NoError(t, mkd.GenShare(testCtx.skShares[ct.Parties[0]-1], ct.Parties[0], ct, shares[ct.Parties[0]]))
+			require.NoError(t, mkd.Decrypt(ct, shares, pt))
+			ringQ.InvNTT(pt, pt)
+			return pt
 		}
 
-		crp := rtg[0].SampleCRP(testCtx.crs)
-
-		galEl := params.GaloisElementForRowRotation()
+		ct0 := newCiphertext(points[:2])
+		ct1 := newCiphertext(points[1:])
 
-		for i := range shares {
-			rtg[i].GenShare(testCtx.skShares[i], galEl, crp, shares[i])
-		}
+		require.Error(t, mkd.GenShare(testCtx.skShares[2], points[2], ct0, mkd.AllocateShare(ct0.Level())))
 
-		for i := 1; i < nbParties; i++ {
-			rtg[0].AggregateShare(shares[0], shares[i], shares[0])
-		}
+		mke := NewMKEvaluator(params)
 
-		rotKeySet := rlwe.NewRotationKeySet(params, []uint64{galEl})
-		rtg[0].GenRotationKey(shares[0], crp, rotKeySet.Keys[galEl])
+		union := MKUnionParties(ct0, ct1)
+		require.Equal(t, points, union)
 
-		skIn := testCtx.skIdeal.CopyNew()
-		skOut := testCtx.skIdeal.CopyNew()
-		galElInv := ring.ModExp(galEl, uint64(2*params.N()-1), uint64(2*params.N()))
-		ringQ.PermuteNTT(testCtx.skIdeal.Value.Q, galElInv, skOut.Value.Q)
-		ringP.PermuteNTT(testCtx.skIdeal.Value.P, galElInv, skOut.Value.P)
+		require.Panics(t, func() { mke.Add(ct0, ct1, NewMKCiphertext(params, points[:2], params.MaxLevel())) })
 
-		swk := rotKeySet.Keys[galEl]
+		ctAdd := NewMKCiphertext(params, union, params.MaxLevel())
+		mke.Add(ct0, ct1, ctAdd)
 
-		// Decrypts
-		// [-asIn + w*P*sOut + e, a] + [asIn]
-		for j := range swk.Value {
-			ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, swk.Value[j][1], skOut.Value, swk.Value[j][0])
+		log2Bound := bits.Len64(uint64(nbParties) * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(ctAdd.Level(), ringQ, decrypt(ctAdd)))
 
+		// Tensor product of ct0 and ct1 extended to the union of their parties
+		components := func(ct *MKCiphertext) []*ring.Poly {
+			c := []*ring.Poly{ct.Value[0]}
+			for _, p := range union {
+				if ci := ct.Component(p); ci != nil {
+					c = append(c, ci)
+				} else {
+					c = append(c, ringQ.NewPoly())
+				}
+			}
+			return c
 		}
 
-		// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
-		// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
-		for j := range swk.Value {
-			if j > 0 {
-				ringQP.AddLvl(levelQ, levelP, swk.Value[0][0], swk.Value[j][0], swk.Value[0][0])
+		c0, c1 := components(ct0), components(ct1)
+		tensor := make([][]*ring.Poly, len(union)+1)
+		for i := range tensor {
+			tensor[i] = make([]*ring.Poly, len(union)+1)
+			for j := range tensor[i] {
+				tensor[i][j] = ringQ.NewPoly()
+				ringQ.MForm(c0[i], tensor[i][j])
+				ringQ.MulCoeffsMontgomery(tensor[i][j], c1[j], tensor[i][j])
+				tensor[i][j].IsNTT = true
 			}
 		}
 
-		// sOut * P
-		ringQ.MulScalarBigint(skIn.Value.Q, ringP.ModulusBigint, skIn.Value.Q)
+		ctMul := NewMKCiphertext(params, union, params.MaxLevel())
 
-		// P*s^i + sum(e) - P*s^i = sum(e)
-		ringQ.Sub(swk.Value[0][0].Q, skIn.Value.Q, swk.Value[0][0].Q)
+		require.Panics(t, func() { mke.Relinearize(tensor, map[ShamirPublicPoint]*MKEvaluationKey{}, ctMul) })
 
-		// Checks that the error is below the bound
-		// Worst error bound is N * floor(6*sigma) * #Keys
-		ringQP.InvNTTLvl(levelQ, levelP, swk.Value[0][0], swk.Value[0][0])
-		ringQP.InvMFormLvl(levelQ, levelP, swk.Value[0][0], swk.Value[0][0])
+		mke.ShallowCopy().Relinearize(tensor, evk, ctMul)
 
-		// Worst bound of inner sum
-		// N*#Keys*(N * #Parties * floor(sigma*6) + #Parties * floor(sigma*6) + N * #Parties  +  #Parties * floor(6*sigma))
-		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
-		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringQ.Modulus)-1, ringQ, swk.Value[0][0].Q))
-		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringP.Modulus)-1, ringP, swk.Value[0][0].P))
+		// The relinearization noise is dominated by the products of the ephemeral and secret keys with the rounding
+		// errors of the key-switchings, i.e. about N^2 per pair of parties.
+		log2Bound = bits.Len64(uint64(nbParties*nbParties) * uint64(params.N()) * uint64(params.N()) * uint64(params.Beta()))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(ctMul.Level(), ringQ, decrypt(ctMul)))
+
+		data, err := evk[points[0]].MarshalBinary()
+		require.NoError(t, err)
+		evkNew := new(MKEvaluationKey)
+		require.NoError(t, evkNew.UnmarshalBinary(data))
+		for i := range evkNew.B {
+			require.True(t, evk[points[0]].B[i].Equals(evkNew.B[i]))
+			require.True(t, evk[points[0]].D0[i].Equals(evkNew.D0[i]))
+			require.True(t, evk[points[0]].D1[i].Equals(evkNew.D1[i]))
+			require.True(t, evk[points[0]].D2[i].Equals(evkNew.D2[i]))
+		}
+		require.Error(t, evkNew.UnmarshalBinary(data[:len(data)/2]))
 	})
 }
 
@@ -515,6 +2876,23 @@ func testMarshalling(testCtx testContext, t *testing.T) {
 		require.Equal(t, cksshare.Value.Coeffs, cksshareAfter.Value.Coeffs)
 	})
 
+	t.Run(testString(params, "Marshalling/DegreeReduction"), func(t *testing.T) {
+
+		cksp := NewCKSProtocol(testCtx.params, testCtx.params.Sigma())
+		share := cksp.AllocateDegreeReductionShare(ciphertext.Level())
+		cksp.GenDegreeReductionShare(testCtx.skShares[0], ciphertext.Value[1], share)
+
+		data, err := share.MarshalBinary()
+		require.NoError(t, err)
+		shareAfter := cksp.AllocateDegreeReductionShare(ciphertext.Level())
+		require.NoError(t, shareAfter.UnmarshalBinary(data))
+		require.Equal(t, share.Header, shareAfter.Header)
+		require.Equal(t, share.Value.Coeffs, shareAfter.Value.Coeffs)
+
+		// The shares of the degree reduction are not CKS shares
+		require.ErrorIs(t, cksp.AllocateShare(ciphertext.Level()).UnmarshalBinary(data), ErrIncompatibleShare)
+	})
+
 	t.Run(testString(params, "Marshalling/RKG"), func(t *testing.T) {
 
 		if params.PCount() == 0 {
@@ -550,6 +2928,50 @@ func testMarshalling(testCtx testContext, t *testing.T) {
 			require.Equal(t, rkgShare.Value[i][1].Q.Coeffs, val[1].Q.Coeffs)
 			require.Equal(t, rkgShare.Value[i][1].P.Coeffs, val[1].P.Coeffs)
 		}
+
+		// Chunked marshalling, with chunks of about a fifth of the share
+		chunks, err := share10.MarshalChunks(len(data)/5 + 1 + ShareChunkHeaderLen)
+		require.NoError(t, err)
+		require.Equal(t, 5, len(chunks))
+
+		rkgShare = new(RKGShare)
+		require.NoError(t, rkgShare.UnmarshalChunks(append(chunks[3:], chunks[:3]...)))
+		require.Equal(t, share10.Value, rkgShare.Value)
+
+		sr := NewShareReassembler()
+		for i := range chunks[1:] {
+			complete, err := sr.Add(chunks[i+1])
+			require.NoError(t, err)
+			require.False(t, complete)
+		}
+		require.Equal(t, []int{0}, sr.Missing())
+		require.Error(t, sr.Unmarshal(new(RKGShare)))
+
+		// Duplicated, corrupted and foreign chunks are rejected
+		_, err = sr.Add(chunks[1])
+		require.Error(t, err)
+
+		corrupted := append([]byte(nil), chunks[0]...)
+		corrupted[len(corrupted)-1] ^= 1
+		_, err = sr.Add(corrupted)
+		require.Error(t, err)
+
+		foreign, err := share10.MarshalChunks(len(data)/2 + ShareChunkHeaderLen)
+		require.NoError(t, err)
+		_, err = sr.Add(foreign[0])
+		require.Error(t, err)
+
+		complete, err := sr.Add(chunks[0])
+		require.NoError(t, err)
+		require.True(t, complete)
+		require.Nil(t, sr.Missing())
+
+		rkgShare = new(RKGShare)
+		require.NoError(t, sr.Unmarshal(rkgShare))
+		require.Equal(t, share10.Value, rkgShare.Value)
+
+		_, err = share10.MarshalChunks(ShareChunkHeaderLen)
+		require.Error(t, err)
 	})
 
 	t.Run(testString(params, "Marshalling/RTG"), func(t *testing.T) {
@@ -585,9 +3007,108 @@ func testMarshalling(testCtx testContext, t *testing.T) {
 			require.Equal(t, resRTGShare.Value[i].P.Coeffs, val.P.Coeffs)
 		}
 	})
+
+	t.Run(testString(params, "Marshalling/Header"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+		share := ckg.AllocateShare()
+		ckg.GenShare(testCtx.skShares[0], ckg.SampleCRP(testCtx.crs), share)
+
+		data, err := share.MarshalBinary()
+		require.NoError(t, err)
+
+		shareOut := ckg.AllocateShare()
+		require.NoError(t, shareOut.UnmarshalBinary(data))
+		require.Equal(t, share.Header, shareOut.Header)
+		require.True(t, share.Value.Equals(shareOut.Value))
+
+		// A share of another protocol
+		require.ErrorIs(t, NewCKSProtocol(params, params.Sigma()).AllocateShare(params.MaxLevel()).UnmarshalBinary(data), ErrIncompatibleShare)
+
+		// A share generated with other parameters
+		paramsOther, err := rlwe.NewParameters(params.LogN(), params.Q(), params.P(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		require.ErrorIs(t, NewCKGProtocol(paramsOther).AllocateShare().UnmarshalBinary(data), ErrIncompatibleShare)
+
+		// A share of another format version
+		data[0] = ShareFormatVersion + 1
+		require.ErrorIs(t, new(CKGShare).UnmarshalBinary(data), ErrIncompatibleShare)
+	})
+
+	t.Run(testString(params, "Marshalling/CBOR"), func(t *testing.T) {
+
+		type share interface {
+			MarshalBinary() ([]byte, error)
+			MarshalCBOR() ([]byte, error)
+			UnmarshalCBOR([]byte) error
+		}
+
+		// The shares must have the same binary encoding after a round trip in the CBOR serialization
+		roundTrip := func(shareIn, shareOut share) {
+			data, err := shareIn.MarshalCBOR()
+			require.NoError(t, err)
+			require.NoError(t, shareOut.UnmarshalCBOR(data))
+			want, err := shareIn.MarshalBinary()
+			require.NoError(t, err)
+			have, err := shareOut.MarshalBinary()
+			require.NoError(t, err)
+			require.Equal(t, want, have)
+		}
+
+		ckg := NewCKGProtocol(params)
+		ckgShare := ckg.AllocateShare()
+		ckg.GenShare(testCtx.skShares[0], ckg.SampleCRP(testCtx.crs), ckgShare)
+		roundTrip(ckgShare, ckg.AllocateShare())
+
+		cks := NewCKSProtocol(params, params.Sigma())
+		cksShare := cks.AllocateShare(ciphertext.Level())
+		cks.GenShare(testCtx.skShares[0], testCtx.skShares[1], ciphertext.Value[1], cksShare)
+		roundTrip(cksShare, new(CKSShare))
+
+		pcks := NewPCKSProtocol(params, params.Sigma())
+		pcksShare := pcks.AllocateShare(ciphertext.Level())
+		_, pkOut := testCtx.kgen.GenKeyPair()
+		pcks.GenShare(testCtx.skShares[0], pkOut, ciphertext.Value[1], pcksShare)
+		roundTrip(pcksShare, new(PCKSShare))
+
+		// The header is checked as in the binary serialization
+		data, err := ckgShare.MarshalCBOR()
+		require.NoError(t, err)
+		paramsOther, err := rlwe.NewParameters(params.LogN(), params.Q(), params.P(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		require.ErrorIs(t, NewCKGProtocol(paramsOther).AllocateShare().UnmarshalCBOR(data), ErrIncompatibleShare)
+		require.Error(t, new(CKSShare).UnmarshalCBOR(data))
+
+		if params.PCount() == 0 {
+			return
+		}
+
+		rtg := NewRTGProtocol(params)
+		rtgShare := rtg.AllocateShare()
+		rtg.GenShare(testCtx.skShares[0], params.GaloisElementForColumnRotationBy(64), rtg.SampleCRP(testCtx.crs), rtgShare)
+		roundTrip(rtgShare, new(RTGShare))
+
+		rkg := NewRKGProtocol(params)
+		ephSk, rkgShare, _ := rkg.AllocateShare()
+		rkg.GenShareRoundOne(testCtx.skShares[0], rkg.SampleCRP(testCtx.crs), ephSk, rkgShare)
+		roundTrip(rkgShare, new(RKGShare))
+	})
 }
 
 // Returns the ceil(log2) of the sum of the absolute value of all the coefficients
+// standardDeviation returns the standard deviation of the coefficients modulo q, centered in (-q/2, q/2].
+func standardDeviation(q uint64, coeffs []uint64) float64 {
+	var sum float64
+	for _, c := range coeffs {
+		v := float64(c)
+		if c > q>>1 {
+			v = -float64(q - c)
+		}
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(coeffs)))
+}
+
 func log2OfInnerSum(level int, ringQ *ring.Ring, poly *ring.Poly) (logSum int) {
 	sumRNS := make([]uint64, level+1)
 	var sum uint64
@@ -651,3 +3172,103 @@ func log2OfInnerSum(level int, ringQ *ring.Ring, poly *ring.Poly) (logSum int) {
 
 	return
 }
+
+// metricsRecorder is an rlwe.Metrics recording the operations and the key sizes.
+type metricsRecorder struct {
+	mu         sync.Mutex
+	operations map[string]int
+	sizes      map[string]int
+}
+
+func (m *metricsRecorder) ObserveOperation(component, operation string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.operations[component+"/"+operation]++
+}
+
+func (m *metricsRecorder) ObserveKeySize(component, kind string, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sizes[component+"/"+kind] = size
+}
+
+func (m *metricsRecorder) ObserveNoiseBudget(component, operation string, bits float64) {}
+
+func testMetrics(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	t.Run(testString(params, "Metrics"), func(t *testing.T) {
+
+		metrics := &metricsRecorder{operations: make(map[string]int), sizes: make(map[string]int)}
+
+		ckg := []*CKGProtocol{NewCKGProtocol(params, WithMetrics(metrics))}
+		for i := 1; i < nbParties; i++ {
+			ckg = append(ckg, ckg[0].ShallowCopy())
+		}
+
+		crp := ckg[0].SampleCRP(testCtx.crs)
+		shares := make([]*CKGShare, nbParties)
+		for i := range shares {
+			shares[i] = ckg[i].AllocateShare()
+			ckg[i].GenShare(testCtx.skShares[i], crp, shares[i])
+		}
+		for i := 1; i < nbParties; i++ {
+			ckg[0].AggregateShare(shares[0], shares[i], shares[0])
+		}
+		pk := rlwe.NewPublicKey(params)
+		ckg[0].GenPublicKey(shares[0], crp, pk)
+
+		require.Equal(t, map[string]int{
+			"drlwe.CKGProtocol/GenShare":       nbParties,
+			"drlwe.CKGProtocol/AggregateShare": nbParties - 1,
+			"drlwe.CKGProtocol/GenPublicKey":   1,
+		}, metrics.operations)
+
+		shareData, err := shares[0].MarshalBinary()
+		require.NoError(t, err)
+		pkData, err := pk.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{
+			"drlwe.CKGProtocol/CKGShare":  len(shareData),
+			"drlwe.CKGProtocol/PublicKey": len(pkData),
+		}, metrics.sizes)
+
+		// The protocols without the option do not record their operations
+		NewCKGProtocol(params).GenShare(testCtx.skShares[0], crp, shares[0])
+		require.Equal(t, nbParties, metrics.operations["drlwe.CKGProtocol/GenShare"])
+	})
+}
+
+// spanRecorder is an rlwe.Tracer recording the names of the spans it starts, prefixed by the name of their parent.
+type spanRecorder struct {
+	sync.Mutex
+	spans []string
+	ended int
+}
+
+type recordedSpan struct {
+	recorder *spanRecorder
+	name     string
+}
+
+type recordedSpanKey struct{}
+
+func (span recordedSpan) SetAttribute(key string, value interface{}) {}
+
+func (span recordedSpan) End(err error) {
+	span.recorder.Lock()
+	defer span.recorder.Unlock()
+	span.recorder.ended++
+}
+
+func (tracer *spanRecorder) Start(ctx context.Context, name string) (context.Context, rlwe.Span) {
+	tracer.Lock()
+	defer tracer.Unlock()
+	if parent, ok := ctx.Value(recordedSpanKey{}).(recordedSpan); ok {
+		name = parent.name + ">" + name
+	}
+	span := recordedSpan{tracer, name}
+	tracer.spans = append(tracer.spans, name)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
@@ -0,0 +1,181 @@
+//go:build go1.18
+// +build go1.18
+
+package drlwe
+
+import (
+	"encoding"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// The fuzz targets check that the decoders of the shares received from the other parties return an error instead of
+// panicking or allocating more than their input on invalid encodings, e.g. with
+//
+//	go test ./drlwe -run '^$' -fuzz FuzzCKGShareUnmarshalBinary
+
+// fuzzContext holds the small parameters and the secret key with which the seeds of the fuzz targets are generated.
+type fuzzContext struct {
+	params rlwe.Parameters
+	sk     *rlwe.SecretKey
+	pk     *rlwe.PublicKey
+	crs    utils.PRNG
+}
+
+func newFuzzContext(f *testing.F) (fc fuzzContext) {
+	logN := 5
+	var err error
+	if fc.params, err = rlwe.NewParameters(logN, ring.GenerateNTTPrimes(30, 2<<logN, 2), ring.GenerateNTTPrimes(31, 2<<logN, 1), rlwe.DefaultSigma, ring.Standard); err != nil {
+		f.Fatal(err)
+	}
+	fc.sk, fc.pk = rlwe.NewKeyGenerator(fc.params).GenKeyPair()
+	if fc.crs, err = utils.NewKeyedPRNG([]byte{'f', 'u', 'z', 'z'}); err != nil {
+		f.Fatal(err)
+	}
+	return
+}
+
+// genCKGShare returns the CKG share of the secret key of the context and its proof.
+func (fc fuzzContext) genCKGShare(f *testing.F) (*CKGShare, *ShareProof) {
+	ckg := NewCKGProtocol(fc.params)
+	crp := ckg.SampleCRP(fc.crs)
+	share := ckg.AllocateShare()
+	ckg.GenShare(fc.sk, crp, share)
+	proof, err := ckg.GenProof(fc.sk, crp, share)
+	if err != nil {
+		f.Fatal(err)
+	}
+	return share, proof
+}
+
+// fuzzUnmarshalBinary fuzzes the UnmarshalBinary method of the objects returned by newObject, seeded with the
+// serializations of seeds. A successfully decoded object must be marshaled again without error.
+func fuzzUnmarshalBinary(f *testing.F, newObject func() encoding.BinaryUnmarshaler, seeds ...encoding.BinaryMarshaler) {
+
+	for _, seed := range seeds {
+		data, err := seed.MarshalBinary()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		object := newObject()
+		if object.UnmarshalBinary(data) != nil {
+			return
+		}
+		if _, err := object.(encoding.BinaryMarshaler).MarshalBinary(); err != nil {
+			t.Fatalf("cannot marshal a decoded object: %s", err)
+		}
+	})
+}
+
+func FuzzCKGShareUnmarshalBinary(f *testing.F) {
+	share, _ := newFuzzContext(f).genCKGShare(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(CKGShare) }, share)
+}
+
+func FuzzRKGShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	rkg := NewRKGProtocol(fc.params)
+	ephSk, share, _ := rkg.AllocateShare()
+	rkg.GenShareRoundOne(fc.sk, rkg.SampleCRP(fc.crs), ephSk, share)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(RKGShare) }, share)
+}
+
+func FuzzRTGShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	rtg := NewRTGProtocol(fc.params)
+	share := rtg.AllocateShare()
+	rtg.GenShare(fc.sk, fc.params.GaloisElementForColumnRotationBy(1), rtg.SampleCRP(fc.crs), share)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(RTGShare) }, share)
+}
+
+func FuzzRTGBatchShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	rtg := NewRTGBatchProtocol(fc.params, []uint64{fc.params.GaloisElementForColumnRotationBy(1), fc.params.GaloisElementForRowRotation()}, 1)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(RTGBatchShare) }, rtg.AllocateShare())
+}
+
+func FuzzSKGShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(SKGShare) }, NewSKGProtocol(fc.params).AllocateShare())
+}
+
+func FuzzCKSShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(CKSShare) }, NewCKSProtocol(fc.params, fc.params.Sigma()).AllocateShare(fc.params.MaxLevel()))
+}
+
+func FuzzCKSBatchShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(CKSBatchShare) }, NewCKSBatchProtocol(fc.params, fc.params.Sigma(), 1).AllocateShare(fc.params.MaxLevel(), 2))
+}
+
+func FuzzPCKSShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(PCKSShare) }, NewPCKSProtocol(fc.params, fc.params.Sigma()).AllocateShare(fc.params.MaxLevel()))
+}
+
+func FuzzMultiPCKSShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	mpcks := NewMultiPCKSProtocol(fc.params, fc.params.Sigma(), []*rlwe.PublicKey{fc.pk, fc.pk})
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(MultiPCKSShare) }, mpcks.AllocateShare(fc.params.MaxLevel()))
+}
+
+func FuzzDegreeReductionShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(DegreeReductionShare) }, NewInteractiveRelinProtocol(fc.params).AllocateShare(fc.params.MaxLevel()))
+}
+
+func FuzzKeyRefreshShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(KeyRefreshShare) }, NewResharingProtocol(fc.params).AllocateShare())
+}
+
+func FuzzMigrationShareUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	cks := NewCKSProtocol(fc.params, fc.params.Sigma())
+	share := &MigrationShare{
+		Header:          NewShareHeader(ProtocolMigration, fc.params, fc.params),
+		DecryptionShare: *cks.AllocateShare(fc.params.MaxLevel()),
+		EncryptionShare: *cks.AllocateShare(fc.params.MaxLevel()),
+	}
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(MigrationShare) }, share)
+}
+
+func FuzzShareProofUnmarshalBinary(f *testing.F) {
+	_, proof := newFuzzContext(f).genCKGShare(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(ShareProof) }, proof)
+}
+
+func FuzzPartialAggregateUnmarshalBinary(f *testing.F) {
+	share, _ := newFuzzContext(f).genCKGShare(f)
+	partial := &PartialAggregate{Parties: []ShamirPublicPoint{1, 2}, Share: share}
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return &PartialAggregate{Share: new(CKGShare)} }, partial)
+}
+
+func FuzzPartialDecryptionUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	_, proof := fc.genCKGShare(f)
+	pd := &PartialDecryption{Party: 1, Share: NewCKSProtocol(fc.params, fc.params.Sigma()).AllocateShare(fc.params.MaxLevel()), Proof: proof}
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(PartialDecryption) }, pd)
+}
+
+func FuzzBeaconTranscriptUnmarshalBinary(f *testing.F) {
+	transcript := &BeaconTranscript{
+		Context: []byte("fuzz"),
+		Beacon:  BeaconRound{Round: 42, Randomness: make([]byte, 32), Signature: make([]byte, 96), PreviousSignature: make([]byte, 96)},
+		Reads:   3,
+	}
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(BeaconTranscript) }, transcript)
+}
+
+func FuzzMKEvaluationKeyUnmarshalBinary(f *testing.F) {
+	fc := newFuzzContext(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(MKEvaluationKey) }, NewMKEvaluationKey(fc.params))
+}
@@ -1,7 +1,18 @@
 //Package drlwe implements a distributed (or threshold) version of the CKKS scheme that enables secure multiparty computation solutions with secret-shared secret keys.
+//
+// Concurrency: the protocol structs, e.g. CKGProtocol, RKGProtocol, RTGProtocol, CKSProtocol and PCKSProtocol, hold
+// samplers and temporary buffers that are written by their methods, hence an instance must not be used by several
+// goroutines at the same time, including for the methods that only aggregate shares. Their read-only data-structures
+// are shared by the copies returned by their ShallowCopy method, which can be used concurrently with the receiver, one
+// copy per goroutine. The Concurrent*Protocol wrappers, e.g. ConcurrentCKGProtocol, manage a pool of such copies and are
+// safe for concurrent use. The input and output elements of a method, e.g. share1, share2 and shareOut of
+// AggregateShare, can be the same, but the concurrent calls must not share their output elements. The shares,
+// keys and ciphertexts are not safe for concurrent writes.
 package drlwe
 
 import (
+	"time"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -19,6 +30,8 @@ type CollectivePublicKeyGenerator interface {
 type CKGProtocol struct {
 	params           rlwe.Parameters
 	gaussianSamplerQ *ring.GaussianSampler
+	metrics          protocolMetrics
+	header           ShareHeader
 }
 
 // ShallowCopy creates a shallow copy of CKGProtocol in which all the read-only data-structures are
@@ -30,21 +43,30 @@ func (ckg *CKGProtocol) ShallowCopy() *CKGProtocol {
 		panic(err)
 	}
 
-	return &CKGProtocol{ckg.params, ring.NewGaussianSampler(prng, ckg.params.RingQ(), ckg.params.Sigma(), int(6*ckg.params.Sigma()))}
+	return &CKGProtocol{ckg.params, ring.NewGaussianSampler(prng, ckg.params.RingQ(), ckg.params.Sigma(), int(6*ckg.params.Sigma())), ckg.metrics, ckg.header}
 }
 
 // CKGShare is a struct storing the CKG protocol's share.
 type CKGShare struct {
-	Value rlwe.PolyQP
+	Header ShareHeader
+	Value  rlwe.PolyQP
 }
 
 // CKGCRP is a type for common reference polynomials in the CKG protocol.
 type CKGCRP rlwe.PolyQP
 
+// GetDataLen returns the length in bytes of the target element.
+func (share *CKGShare) GetDataLen(WithMetadata bool) int {
+	return ShareHeaderLen + share.Value.GetDataLen(WithMetadata)
+}
+
 // MarshalBinary encodes the target element on a slice of bytes.
 func (share *CKGShare) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, share.Value.GetDataLen(true))
-	if _, err = share.Value.WriteTo(data); err != nil {
+	data = make([]byte, share.GetDataLen(true))
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	if _, err = share.Value.WriteTo(data[ShareHeaderLen:]); err != nil {
 		return nil, err
 	}
 	return
@@ -52,26 +74,30 @@ func (share *CKGShare) MarshalBinary() (data []byte, err error) {
 
 // UnmarshalBinary decodes a slice of bytes on the target element.
 func (share *CKGShare) UnmarshalBinary(data []byte) (err error) {
-	_, err = share.Value.DecodePolyNew(data)
+	var ptr int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	_, err = share.Value.DecodePolyNew(data[ptr:])
 	return err
 }
 
-// NewCKGProtocol creates a new CKGProtocol instance
-func NewCKGProtocol(params rlwe.Parameters) *CKGProtocol {
+// NewCKGProtocol creates a new CKGProtocol instance. The randomness of the shares can be seeded with the WithSeed
+// option, and its operations recorded with the WithMetrics option.
+func NewCKGProtocol(params rlwe.Parameters, options ...ProtocolOption) *CKGProtocol {
 	ckg := new(CKGProtocol)
 	ckg.params = params
-	var err error
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
+	ckg.header = NewShareHeader(ProtocolCKG, params)
+	opts := newProtocolOptions(params, options)
+	ckg.metrics = protocolMetrics{opts.metrics, "drlwe.CKGProtocol"}
+	prng := newPRNG(opts.seed, "CKG", 0)
 	ckg.gaussianSamplerQ = ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma()))
 	return ckg
 }
 
 // AllocateShare allocates the share of the CKG protocol.
 func (ckg *CKGProtocol) AllocateShare() *CKGShare {
-	return &CKGShare{ckg.params.RingQP().NewPoly()}
+	return &CKGShare{Header: ckg.header, Value: ckg.params.RingQP().NewPoly()}
 }
 
 // SampleCRP samples a common random polynomial to be used in the CKG protocol from the provided
@@ -88,6 +114,9 @@ func (ckg *CKGProtocol) SampleCRP(crs CRS) CKGCRP {
 //
 // for the receiver protocol. Has no effect is the share was already generated.
 func (ckg *CKGProtocol) GenShare(sk *rlwe.SecretKey, crp CKGCRP, shareOut *CKGShare) {
+	defer ckg.metrics.operation("GenShare", time.Now())
+	defer ckg.metrics.shareSize("CKGShare", shareOut)
+
 	ringQP := ckg.params.RingQP()
 
 	ckg.gaussianSamplerQ.Read(shareOut.Value.Q)
@@ -100,11 +129,14 @@ func (ckg *CKGProtocol) GenShare(sk *rlwe.SecretKey, crp CKGCRP, shareOut *CKGSh
 
 // AggregateShare aggregates a new share to the aggregate key
 func (ckg *CKGProtocol) AggregateShare(share1, share2, shareOut *CKGShare) {
+	defer ckg.metrics.operation("AggregateShare", time.Now())
 	ckg.params.RingQP().AddLvl(ckg.params.QCount()-1, ckg.params.PCount()-1, share1.Value, share2.Value, shareOut.Value)
 }
 
 // GenPublicKey return the current aggregation of the received shares as a bfv.PublicKey.
 func (ckg *CKGProtocol) GenPublicKey(roundShare *CKGShare, crp CKGCRP, pubkey *rlwe.PublicKey) {
+	defer ckg.metrics.operation("GenPublicKey", time.Now())
+	defer ckg.metrics.keySize("PublicKey", pubkey)
 	pubkey.Value[0].Copy(roundShare.Value)
 	pubkey.Value[1].Copy(rlwe.PolyQP(crp))
 }
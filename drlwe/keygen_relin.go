@@ -1,6 +1,8 @@
 package drlwe
 
 import (
+	"time"
+
 	"errors"
 	"math/big"
 
@@ -20,14 +22,15 @@ type RelinearizationKeyGenerator interface {
 
 // RKGProtocol is the structure storing the parameters and and precomputations for the collective relinearization key generation protocol.
 type RKGProtocol struct {
-	params           rlwe.Parameters
-	ephSkPr          float64
-	pBigInt          *big.Int
-	gaussianSamplerQ *ring.GaussianSampler
-	ternarySamplerQ  *ring.TernarySampler // sampling in Montgomerry form
+	params          rlwe.Parameters
+	ephSkPr         float64
+	pBigInt         *big.Int
+	ternarySamplerQ *ring.TernarySampler // sampling in Montgomerry form
 
 	tmpPoly1 rlwe.PolyQP
-	tmpPoly2 rlwe.PolyQP
+	workers  []decompWorker
+	metrics  protocolMetrics
+	header   ShareHeader
 }
 
 // ShallowCopy creates a shallow copy of RKGProtocol in which all the read-only data-structures are
@@ -43,40 +46,41 @@ func (ekg *RKGProtocol) ShallowCopy() *RKGProtocol {
 	params := ekg.params
 
 	return &RKGProtocol{
-		params:           ekg.params,
-		pBigInt:          ekg.pBigInt,
-		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
-		ternarySamplerQ:  ring.NewTernarySampler(prng, params.RingQ(), ekg.ephSkPr, false),
-		tmpPoly1:         params.RingQP().NewPoly(),
-		tmpPoly2:         params.RingQP().NewPoly(),
+		params:          ekg.params,
+		ephSkPr:         ekg.ephSkPr,
+		pBigInt:         ekg.pBigInt,
+		ternarySamplerQ: ring.NewTernarySampler(prng, params.RingQ(), ekg.ephSkPr, false),
+		tmpPoly1:        params.RingQP().NewPoly(),
+		workers:         newDecompWorkers(params, len(ekg.workers), nil),
+		metrics:         ekg.metrics,
+		header:          ekg.header,
 	}
 }
 
 // RKGShare is a share in the RKG protocol.
 type RKGShare struct {
-	Value [][2]rlwe.PolyQP
+	Header ShareHeader
+	Value  [][2]rlwe.PolyQP
 }
 
 // RKGCRP is a type for common reference polynomials in the RKG protocol.
 type RKGCRP []rlwe.PolyQP
 
-// NewRKGProtocol creates a new RKG protocol struct.
-func NewRKGProtocol(params rlwe.Parameters) *RKGProtocol {
+// NewRKGProtocol creates a new RKG protocol struct. The number of goroutines of GenShareRoundOne, GenShareRoundTwo and
+// AggregateShare can be set with the WithGoroutines option, and its operations recorded with the WithMetrics option.
+func NewRKGProtocol(params rlwe.Parameters, options ...ProtocolOption) *RKGProtocol {
 	rkg := new(RKGProtocol)
 	rkg.params = params
 	rkg.ephSkPr = 0.5 // TODO: read from Params
 
-	var err error
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
+	opts := newProtocolOptions(params, options)
 
 	rkg.pBigInt = params.PBigInt()
-	rkg.gaussianSamplerQ = ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma()))
-	rkg.ternarySamplerQ = ring.NewTernarySampler(prng, params.RingQ(), rkg.ephSkPr, false)
+	rkg.ternarySamplerQ = ring.NewTernarySampler(newPRNG(opts.seed, "RKG", 0), params.RingQ(), rkg.ephSkPr, false)
 	rkg.tmpPoly1 = params.RingQP().NewPoly()
-	rkg.tmpPoly2 = params.RingQP().NewPoly()
+	rkg.workers = newDecompWorkers(params, opts.goroutines, opts.seed)
+	rkg.metrics = protocolMetrics{opts.metrics, "drlwe.RKGProtocol"}
+	rkg.header = NewShareHeader(ProtocolRKG, params)
 	return rkg
 }
 
@@ -84,6 +88,8 @@ func NewRKGProtocol(params rlwe.Parameters) *RKGProtocol {
 func (ekg *RKGProtocol) AllocateShare() (ephSk *rlwe.SecretKey, r1 *RKGShare, r2 *RKGShare) {
 	ephSk = rlwe.NewSecretKey(ekg.params)
 	r1, r2 = new(RKGShare), new(RKGShare)
+	r1.Header = ekg.header
+	r2.Header = r1.Header
 	r1.Value = make([][2]rlwe.PolyQP, ekg.params.Beta())
 	r2.Value = make([][2]rlwe.PolyQP, ekg.params.Beta())
 	for i := 0; i < ekg.params.Beta(); i++ {
@@ -111,6 +117,9 @@ func (ekg *RKGProtocol) SampleCRP(crs CRS) RKGCRP {
 // its secret share of the key s_i under its ephemeral key u_i : [-u_i*a + s_i*w + e_i] and broadcasts it to the other
 // j-1 parties.
 func (ekg *RKGProtocol) GenShareRoundOne(sk *rlwe.SecretKey, crp RKGCRP, ephSkOut *rlwe.SecretKey, shareOut *RKGShare) {
+	defer ekg.metrics.operation("GenShareRoundOne", time.Now())
+	defer ekg.metrics.shareSize("RKGShare", shareOut)
+
 	// Given a base decomposition w_i (here the CRT decomposition)
 	// computes [-u*a_i + P*s_i + e_i]
 	// where a_i = crp_i
@@ -128,9 +137,9 @@ func (ekg *RKGProtocol) GenShareRoundOne(sk *rlwe.SecretKey, crp RKGCRP, ephSkOu
 	ringQP.NTTLvl(levelQ, levelP, ephSkOut.Value, ephSkOut.Value)
 	ringQP.MFormLvl(levelQ, levelP, ephSkOut.Value, ephSkOut.Value)
 
-	for i := 0; i < ekg.params.Beta(); i++ {
+	parallelDecomp(ekg.workers, ekg.params.Beta(), func(worker *decompWorker, i int) {
 		// h = e
-		ekg.gaussianSamplerQ.Read(shareOut.Value[i][0].Q)
+		worker.gaussianSamplerQ.Read(shareOut.Value[i][0].Q)
 		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i][0].Q, levelP, nil, shareOut.Value[i][0].P)
 		ringQP.NTTLvl(levelQ, levelP, shareOut.Value[i][0], shareOut.Value[i][0])
 
@@ -157,12 +166,12 @@ func (ekg *RKGProtocol) GenShareRoundOne(sk *rlwe.SecretKey, crp RKGCRP, ephSkOu
 
 		// Second Element
 		// e_2i
-		ekg.gaussianSamplerQ.Read(shareOut.Value[i][1].Q)
+		worker.gaussianSamplerQ.Read(shareOut.Value[i][1].Q)
 		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i][1].Q, levelP, nil, shareOut.Value[i][1].P)
 		ringQP.NTTLvl(levelQ, levelP, shareOut.Value[i][1], shareOut.Value[i][1])
 		// s*a + e_2i
 		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, sk.Value, crp[i], shareOut.Value[i][1])
-	}
+	})
 }
 
 // GenShareRoundTwo is the second of three rounds of the RKGProtocol protocol. Upon receiving the j-1 shares, each party computes :
@@ -173,6 +182,8 @@ func (ekg *RKGProtocol) GenShareRoundOne(sk *rlwe.SecretKey, crp RKGCRP, ephSkOu
 //
 // and broadcasts both values to the other j-1 parties.
 func (ekg *RKGProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *RKGShare, shareOut *RKGShare) {
+	defer ekg.metrics.operation("GenShareRoundTwo", time.Now())
+	defer ekg.metrics.shareSize("RKGShare", shareOut)
 
 	ringQP := ekg.params.RingQP()
 	levelQ := ekg.params.QCount() - 1
@@ -183,7 +194,7 @@ func (ekg *RKGProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *RKGS
 
 	// Each sample is of the form [-u*a_i + s*w_i + e_i]
 	// So for each element of the base decomposition w_i:
-	for i := 0; i < ekg.params.Beta(); i++ {
+	parallelDecomp(ekg.workers, ekg.params.Beta(), func(worker *decompWorker, i int) {
 
 		// Computes [(sum samples)*sk + e_1i, sk*a + e_2i]
 
@@ -191,32 +202,35 @@ func (ekg *RKGProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *RKGS
 		ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, round1.Value[i][0], sk.Value, shareOut.Value[i][0])
 
 		// (AggregateShareRoundTwo samples) * sk + e_1i
-		ekg.gaussianSamplerQ.Read(ekg.tmpPoly2.Q)
-		ringQP.ExtendBasisSmallNormAndCenter(ekg.tmpPoly2.Q, levelP, nil, ekg.tmpPoly2.P)
-		ringQP.NTTLvl(levelQ, levelP, ekg.tmpPoly2, ekg.tmpPoly2)
-		ringQP.AddLvl(levelQ, levelP, shareOut.Value[i][0], ekg.tmpPoly2, shareOut.Value[i][0])
+		worker.gaussianSamplerQ.Read(worker.tmpPoly.Q)
+		ringQP.ExtendBasisSmallNormAndCenter(worker.tmpPoly.Q, levelP, nil, worker.tmpPoly.P)
+		ringQP.NTTLvl(levelQ, levelP, worker.tmpPoly, worker.tmpPoly)
+		ringQP.AddLvl(levelQ, levelP, shareOut.Value[i][0], worker.tmpPoly, shareOut.Value[i][0])
 
 		// second part
 		// (u - s) * (sum [x][s*a_i + e_2i]) + e3i
-		ekg.gaussianSamplerQ.Read(shareOut.Value[i][1].Q)
+		worker.gaussianSamplerQ.Read(shareOut.Value[i][1].Q)
 		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i][1].Q, levelP, nil, shareOut.Value[i][1].P)
 		ringQP.NTTLvl(levelQ, levelP, shareOut.Value[i][1], shareOut.Value[i][1])
 		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, ekg.tmpPoly1, round1.Value[i][1], shareOut.Value[i][1])
-	}
+	})
 
 }
 
 // AggregateShare combines two RKG shares into a single one.
 func (ekg *RKGProtocol) AggregateShare(share1, share2, shareOut *RKGShare) {
+	defer ekg.metrics.operation("AggregateShare", time.Now())
 	ringQP, levelQ, levelP := ekg.params.RingQP(), ekg.params.QCount()-1, ekg.params.PCount()-1
-	for i := 0; i < ekg.params.Beta(); i++ {
+	parallelDecomp(ekg.workers, ekg.params.Beta(), func(_ *decompWorker, i int) {
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i][0], share2.Value[i][0], shareOut.Value[i][0])
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i][1], share2.Value[i][1], shareOut.Value[i][1])
-	}
+	})
 }
 
 // GenRelinearizationKey computes the generated RLK from the public shares and write the result in evalKeyOut.
 func (ekg *RKGProtocol) GenRelinearizationKey(round1 *RKGShare, round2 *RKGShare, evalKeyOut *rlwe.RelinearizationKey) {
+	defer ekg.metrics.operation("GenRelinearizationKey", time.Now())
+	defer ekg.metrics.keySize("RelinearizationKey", evalKeyOut)
 	ringQP, levelQ, levelP := ekg.params.RingQP(), ekg.params.QCount()-1, ekg.params.PCount()-1
 	for i := 0; i < ekg.params.Beta(); i++ {
 		ringQP.AddLvl(levelQ, levelP, round2.Value[i][0], round2.Value[i][1], evalKeyOut.Keys[0].Value[i][0])
@@ -226,20 +240,30 @@ func (ekg *RKGProtocol) GenRelinearizationKey(round1 *RKGShare, round2 *RKGShare
 	}
 }
 
+// GetDataLen returns the length in bytes of the target element.
+func (share *RKGShare) GetDataLen(WithMetadata bool) int {
+	//we have modulus * bitLog * Len of 1 ring rings
+	return ShareHeaderLen + 1 + 2*share.Value[0][0].GetDataLen(WithMetadata)*len(share.Value)
+}
+
 // MarshalBinary encodes the target element on a slice of bytes.
 func (share *RKGShare) MarshalBinary() ([]byte, error) {
-	//we have modulus * bitLog * Len of 1 ring rings
-	data := make([]byte, 1+2*share.Value[0][0].GetDataLen(true)*len(share.Value))
+	data := make([]byte, share.GetDataLen(true))
 	if len(share.Value) > 0xFF {
 		return []byte{}, errors.New("RKGShare : uint8 overflow on length")
 	}
-	data[0] = uint8(len(share.Value))
+
+	ptr, err := share.Header.WriteTo(data)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	data[ptr] = uint8(len(share.Value))
+	ptr++
 
 	//write all of our rings in the data
 	//write all the polys
-	ptr := 1
 	var inc int
-	var err error
 	for _, elem := range share.Value {
 
 		if inc, err = elem[0].WriteTo(data[ptr:]); err != nil {
@@ -259,9 +283,18 @@ func (share *RKGShare) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary decodes a slice of bytes on the target element.
 func (share *RKGShare) UnmarshalBinary(data []byte) (err error) {
-	share.Value = make([][2]rlwe.PolyQP, data[0])
-	ptr := 1
-	var inc int
+	var ptr, inc int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	if len(data) <= ptr {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+	if data[ptr] == 0 {
+		return errors.New("cannot UnmarshalBinary: share has no value")
+	}
+	share.Value = make([][2]rlwe.PolyQP, data[ptr])
+	ptr++
 	for i := range share.Value {
 		if inc, err = share.Value[i][0].DecodePolyNew(data[ptr:]); err != nil {
 			return err
@@ -272,6 +305,9 @@ func (share *RKGShare) UnmarshalBinary(data []byte) (err error) {
 			return err
 		}
 		ptr += inc
+		if share.Value[i][0].GetDataLen(true) != share.Value[0][0].GetDataLen(true) || share.Value[i][1].GetDataLen(true) != share.Value[0][0].GetDataLen(true) {
+			return errors.New("cannot UnmarshalBinary: inconsistent polynomial sizes")
+		}
 	}
 
 	return nil
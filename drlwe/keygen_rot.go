@@ -1,11 +1,12 @@
 package drlwe
 
 import (
+	"time"
+
 	"errors"
 
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
-	"github.com/ldsec/lattigo/v2/utils"
 )
 
 // RotationKeyGenerator is an interface for the local operation in the generation of rotation keys.
@@ -18,7 +19,8 @@ type RotationKeyGenerator interface {
 
 // RTGShare is represent a Party's share in the RTG protocol.
 type RTGShare struct {
-	Value []rlwe.PolyQP
+	Header ShareHeader
+	Value  []rlwe.PolyQP
 }
 
 // RTGCRP is a type for common reference polynomials in the RTG protocol.
@@ -26,49 +28,49 @@ type RTGCRP []rlwe.PolyQP
 
 // RTGProtocol is the structure storing the parameters for the collective rotation-keys generation.
 type RTGProtocol struct {
-	params           rlwe.Parameters
-	tmpPoly0         rlwe.PolyQP
-	tmpPoly1         rlwe.PolyQP
-	gaussianSamplerQ *ring.GaussianSampler
+	params   rlwe.Parameters
+	tmpPoly0 rlwe.PolyQP
+	tmpPoly1 rlwe.PolyQP
+	workers  []decompWorker
+	metrics  protocolMetrics
+	header   ShareHeader
 }
 
 // ShallowCopy creates a shallow copy of RTGProtocol in which all the read-only data-structures are
 // shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
 // RTGProtocol can be used concurrently.
 func (rtg *RTGProtocol) ShallowCopy() *RTGProtocol {
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
 
 	params := rtg.params
 
 	return &RTGProtocol{
-		params:           rtg.params,
-		tmpPoly0:         params.RingQP().NewPoly(),
-		tmpPoly1:         params.RingQP().NewPoly(),
-		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
+		params:   rtg.params,
+		tmpPoly0: params.RingQP().NewPoly(),
+		tmpPoly1: params.RingQP().NewPoly(),
+		workers:  newDecompWorkers(params, len(rtg.workers), nil),
+		metrics:  rtg.metrics,
+		header:   rtg.header,
 	}
 }
 
-// NewRTGProtocol creates a RTGProtocol instance.
-func NewRTGProtocol(params rlwe.Parameters) *RTGProtocol {
+// NewRTGProtocol creates a RTGProtocol instance. The number of goroutines of GenShare and AggregateShare can be set
+// with the WithGoroutines option, and its operations recorded with the WithMetrics option.
+func NewRTGProtocol(params rlwe.Parameters, options ...ProtocolOption) *RTGProtocol {
 	rtg := new(RTGProtocol)
 	rtg.params = params
-
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
-	rtg.gaussianSamplerQ = ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma()))
+	opts := newProtocolOptions(params, options)
+	rtg.workers = newDecompWorkers(params, opts.goroutines, opts.seed)
 	rtg.tmpPoly0 = params.RingQP().NewPoly()
 	rtg.tmpPoly1 = params.RingQP().NewPoly()
+	rtg.metrics = protocolMetrics{opts.metrics, "drlwe.RTGProtocol"}
+	rtg.header = NewShareHeader(ProtocolRTG, params)
 	return rtg
 }
 
 // AllocateShare allocates a party's share in the RTG protocol.
 func (rtg *RTGProtocol) AllocateShare() (rtgShare *RTGShare) {
 	rtgShare = new(RTGShare)
+	rtgShare.Header = rtg.header
 	rtgShare.Value = make([]rlwe.PolyQP, rtg.params.Beta())
 	for i := range rtgShare.Value {
 		rtgShare.Value[i] = rtg.params.RingQP().NewPoly()
@@ -90,6 +92,8 @@ func (rtg *RTGProtocol) SampleCRP(crs CRS) RTGCRP {
 
 // GenShare generates a party's share in the RTG protocol.
 func (rtg *RTGProtocol) GenShare(sk *rlwe.SecretKey, galEl uint64, crp RTGCRP, shareOut *RTGShare) {
+	defer rtg.metrics.operation("GenShare", time.Now())
+	defer rtg.metrics.shareSize("RTGShare", shareOut)
 
 	ringQ := rtg.params.RingQ()
 	ringP := rtg.params.RingP()
@@ -104,12 +108,10 @@ func (rtg *RTGProtocol) GenShare(sk *rlwe.SecretKey, galEl uint64, crp RTGCRP, s
 
 	ringQ.MulScalarBigint(sk.Value.Q, ringP.ModulusBigint, rtg.tmpPoly0.Q)
 
-	var index int
-
-	for i := 0; i < rtg.params.Beta(); i++ {
+	parallelDecomp(rtg.workers, rtg.params.Beta(), func(worker *decompWorker, i int) {
 
 		// e
-		rtg.gaussianSamplerQ.Read(shareOut.Value[i].Q)
+		worker.gaussianSamplerQ.Read(shareOut.Value[i].Q)
 		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i].Q, levelP, nil, shareOut.Value[i].P)
 		ringQP.NTTLazyLvl(levelQ, levelP, shareOut.Value[i], shareOut.Value[i])
 		ringQP.MFormLvl(levelQ, levelP, shareOut.Value[i], shareOut.Value[i])
@@ -120,7 +122,7 @@ func (rtg *RTGProtocol) GenShare(sk *rlwe.SecretKey, galEl uint64, crp RTGCRP, s
 		// (qiBarre*qiStar)%qi = 1, else 0
 		for j := 0; j < rtg.params.PCount(); j++ {
 
-			index = i*rtg.params.PCount() + j
+			index := i*rtg.params.PCount() + j
 
 			// Handles the case where nb pj does not divides nb qi
 			if index >= rtg.params.QCount() {
@@ -138,33 +140,45 @@ func (rtg *RTGProtocol) GenShare(sk *rlwe.SecretKey, galEl uint64, crp RTGCRP, s
 
 		// sk_in * (qiBarre*qiStar) * 2^w - a*sk + e
 		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, crp[i], rtg.tmpPoly1, shareOut.Value[i])
-	}
+	})
 }
 
 // AggregateShare aggregates two share in the Rotation Key Generation protocol.
 func (rtg *RTGProtocol) AggregateShare(share1, share2, shareOut *RTGShare) {
+	defer rtg.metrics.operation("AggregateShare", time.Now())
 	ringQP, levelQ, levelP := rtg.params.RingQP(), rtg.params.QCount()-1, rtg.params.PCount()-1
-	for i := 0; i < rtg.params.Beta(); i++ {
+	parallelDecomp(rtg.workers, rtg.params.Beta(), func(_ *decompWorker, i int) {
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i], share2.Value[i], shareOut.Value[i])
-	}
+	})
 }
 
 // GenRotationKey finalizes the RTG protocol and populates the input RotationKey with the computed collective SwitchingKey.
 func (rtg *RTGProtocol) GenRotationKey(share *RTGShare, crp RTGCRP, rotKey *rlwe.SwitchingKey) {
+	defer rtg.metrics.operation("GenRotationKey", time.Now())
+	defer rtg.metrics.keySize("SwitchingKey", rotKey)
 	for i := 0; i < rtg.params.Beta(); i++ {
 		rotKey.Value[i][0].CopyValues(share.Value[i])
 		rotKey.Value[i][1].CopyValues(crp[i])
 	}
 }
 
+// GetDataLen returns the length in bytes of the target element.
+func (share *RTGShare) GetDataLen(WithMetadata bool) int {
+	return ShareHeaderLen + 1 + share.Value[0].GetDataLen(WithMetadata)*len(share.Value)
+}
+
 // MarshalBinary encode the target element on a slice of byte.
 func (share *RTGShare) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, 1+share.Value[0].GetDataLen(true)*len(share.Value))
+	data = make([]byte, share.GetDataLen(true))
 	if len(share.Value) > 0xFF {
 		return []byte{}, errors.New("RKGShare : uint8 overflow on length")
 	}
-	data[0] = uint8(len(share.Value))
-	ptr := 1
+	ptr, err := share.Header.WriteTo(data)
+	if err != nil {
+		return []byte{}, err
+	}
+	data[ptr] = uint8(len(share.Value))
+	ptr++
 	var inc int
 	for _, val := range share.Value {
 		if inc, err = val.WriteTo(data[ptr:]); err != nil {
@@ -178,14 +192,26 @@ func (share *RTGShare) MarshalBinary() (data []byte, err error) {
 
 // UnmarshalBinary decodes a slice of bytes on the target element.
 func (share *RTGShare) UnmarshalBinary(data []byte) (err error) {
-	share.Value = make([]rlwe.PolyQP, data[0])
-	ptr := 1
-	var inc int
+	var ptr, inc int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	if len(data) <= ptr {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+	if data[ptr] == 0 {
+		return errors.New("cannot UnmarshalBinary: share has no value")
+	}
+	share.Value = make([]rlwe.PolyQP, data[ptr])
+	ptr++
 	for i := range share.Value {
 		if inc, err = share.Value[i].DecodePolyNew(data[ptr:]); err != nil {
 			return err
 		}
 		ptr += inc
+		if share.Value[i].GetDataLen(true) != share.Value[0].GetDataLen(true) {
+			return errors.New("cannot UnmarshalBinary: inconsistent polynomial sizes")
+		}
 	}
 
 	return nil
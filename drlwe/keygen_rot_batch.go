@@ -0,0 +1,202 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// RTGBatchShare is the share of a party in the RTGBatchProtocol, i.e. its RTG shares for all the Galois elements of
+// the batch, sent in a single message.
+type RTGBatchShare struct {
+	Header         ShareHeader
+	GaloisElements []uint64
+	Value          []*RTGShare
+}
+
+// RTGBatchCRP is a type for the common reference polynomials of the RTGBatchProtocol, i.e. one RTGCRP per Galois
+// element of the batch.
+type RTGBatchCRP []RTGCRP
+
+// RTGBatchProtocol is the structure storing the parameters for the collective generation of the rotation keys of a
+// set of Galois elements in a single round: each party generates the RTG shares of all the Galois elements at once,
+// in parallel, and the rotation keys are generated from the aggregated RTGBatchShare.
+type RTGBatchProtocol struct {
+	params         rlwe.Parameters
+	galoisElements []uint64
+	rtg            []*RTGProtocol
+	header         ShareHeader
+}
+
+// NewRTGBatchProtocol creates a new RTGBatchProtocol for the Galois elements galEls, whose shares are generated by
+// the given number of goroutines, or by runtime.NumCPU() goroutines if goroutines is smaller than 1.
+func NewRTGBatchProtocol(params rlwe.Parameters, galEls []uint64, goroutines int) *RTGBatchProtocol {
+
+	if goroutines < 1 {
+		goroutines = runtime.NumCPU()
+	}
+
+	rtg := make([]*RTGProtocol, goroutines)
+	rtg[0] = NewRTGProtocol(params)
+	for i := 1; i < goroutines; i++ {
+		rtg[i] = rtg[0].ShallowCopy()
+	}
+
+	return &RTGBatchProtocol{params: params, galoisElements: append([]uint64(nil), galEls...), rtg: rtg, header: NewShareHeader(ProtocolRTGBatch, params)}
+}
+
+// ShallowCopy creates a shallow copy of RTGBatchProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// RTGBatchProtocol can be used concurrently.
+func (rtg *RTGBatchProtocol) ShallowCopy() *RTGBatchProtocol {
+	rtgs := make([]*RTGProtocol, len(rtg.rtg))
+	for i := range rtgs {
+		rtgs[i] = rtg.rtg[i].ShallowCopy()
+	}
+	return &RTGBatchProtocol{params: rtg.params, galoisElements: rtg.galoisElements, rtg: rtgs, header: rtg.header}
+}
+
+// GaloisElements returns the Galois elements of the batch.
+func (rtg *RTGBatchProtocol) GaloisElements() []uint64 {
+	return append([]uint64(nil), rtg.galoisElements...)
+}
+
+// AllocateShare allocates a party's share in the RTGBatchProtocol.
+func (rtg *RTGBatchProtocol) AllocateShare() (share *RTGBatchShare) {
+	share = &RTGBatchShare{
+		Header:         rtg.header,
+		GaloisElements: rtg.GaloisElements(),
+		Value:          make([]*RTGShare, len(rtg.galoisElements)),
+	}
+	for i := range share.Value {
+		share.Value[i] = rtg.rtg[0].AllocateShare()
+	}
+	return
+}
+
+// SampleCRP samples the common random polynomials of all the Galois elements of the batch from the provided common
+// reference string.
+func (rtg *RTGBatchProtocol) SampleCRP(crs CRS) RTGBatchCRP {
+	crp := make(RTGBatchCRP, len(rtg.galoisElements))
+	for i := range crp {
+		crp[i] = rtg.rtg[0].SampleCRP(crs)
+	}
+	return crp
+}
+
+// GenShare generates the party's shares of all the Galois elements of the batch in parallel.
+func (rtg *RTGBatchProtocol) GenShare(sk *rlwe.SecretKey, crp RTGBatchCRP, shareOut *RTGBatchShare) {
+	rtg.parallel(func(worker *RTGProtocol, i int) {
+		worker.GenShare(sk, rtg.galoisElements[i], crp[i], shareOut.Value[i])
+	})
+}
+
+// AggregateShare aggregates the shares share1 and share2 of all the Galois elements of the batch in parallel.
+func (rtg *RTGBatchProtocol) AggregateShare(share1, share2, shareOut *RTGBatchShare) {
+	rtg.parallel(func(worker *RTGProtocol, i int) {
+		worker.AggregateShare(share1.Value[i], share2.Value[i], shareOut.Value[i])
+	})
+}
+
+// GenRotationKeys finalizes the RTGBatchProtocol and populates rotKeys, which must have a SwitchingKey for each Galois
+// element of the batch (e.g. allocated with rlwe.NewRotationKeySet), with the collective rotation keys.
+func (rtg *RTGBatchProtocol) GenRotationKeys(share *RTGBatchShare, crp RTGBatchCRP, rotKeys *rlwe.RotationKeySet) {
+	for i, galEl := range rtg.galoisElements {
+		rtg.rtg[0].GenRotationKey(share.Value[i], crp[i], rotKeys.Keys[galEl])
+	}
+}
+
+// parallel calls f on each Galois element of the batch, distributed among the goroutines.
+func (rtg *RTGBatchProtocol) parallel(f func(worker *RTGProtocol, i int)) {
+
+	var wg sync.WaitGroup
+	for w := range rtg.rtg {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(rtg.galoisElements); i += len(rtg.rtg) {
+				f(rtg.rtg[w], i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (share *RTGBatchShare) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, ShareHeaderLen+4)
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(data[ShareHeaderLen:], uint32(len(share.Value)))
+
+	for i, s := range share.Value {
+
+		var shareData []byte
+		if shareData, err = s.MarshalBinary(); err != nil {
+			return nil, err
+		}
+
+		header := make([]byte, 12)
+		binary.LittleEndian.PutUint64(header, share.GaloisElements[i])
+		binary.LittleEndian.PutUint32(header[8:], uint32(len(shareData)))
+
+		data = append(data, header...)
+		data = append(data, shareData...)
+	}
+
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (share *RTGBatchShare) UnmarshalBinary(data []byte) (err error) {
+
+	var ptr int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	data = data[ptr:]
+
+	if len(data) < 4 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+
+	if n > len(data)/12 {
+		return errors.New("cannot UnmarshalBinary: invalid number of shares")
+	}
+
+	share.GaloisElements = make([]uint64, n)
+	share.Value = make([]*RTGShare, n)
+
+	for i := range share.Value {
+
+		if len(data) < 12 {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
+		share.GaloisElements[i] = binary.LittleEndian.Uint64(data)
+		size := int(binary.LittleEndian.Uint32(data[8:]))
+		data = data[12:]
+
+		if size == 0 || size > len(data) {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
+		// The RTG shares of the batch are generated with the parameters of the batch
+		share.Value[i] = &RTGShare{Header: ShareHeader{Version: share.Header.Version, Protocol: ProtocolRTG, Params: share.Header.Params}}
+		if err = share.Value[i].UnmarshalBinary(data[:size]); err != nil {
+			return err
+		}
+
+		data = data[size:]
+	}
+
+	return nil
+}
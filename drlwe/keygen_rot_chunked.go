@@ -0,0 +1,187 @@
+package drlwe
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// RTGChunkExchange sends the share of the party for the chunk-th chunk of Galois elements, e.g. to an aggregator, and
+// returns the aggregation of the shares of all the parties for this chunk. The share is reused for the next chunks and
+// must not be retained after the call.
+type RTGChunkExchange func(chunk int, share *RTGBatchShare) (*RTGBatchShare, error)
+
+// RTGChunkedProtocol is the structure storing the parameters for the collective generation of the rotation keys of a
+// large set of Galois elements, e.g. the rotation keys of the bootstrapping, in consecutive chunks of at most chunkSize
+// Galois elements: the shares of each chunk are generated, exchanged and finalized into rotation keys before the next
+// chunk is processed, so that the memory used by a party for the shares and the common reference polynomials is bounded
+// by the size of a chunk instead of the size of the whole key set. The shares of a chunk are RTGBatchShares and the
+// rotation keys are identical to those generated by the RTGBatchProtocol for the same Galois elements and common
+// reference string. The relinearization key, whose share is of the size of a single rotation key, does not require
+// chunking.
+type RTGChunkedProtocol struct {
+	params         rlwe.Parameters
+	galoisElements []uint64
+	chunkSize      int
+	rtg            []*RTGProtocol
+	header         ShareHeader
+}
+
+// NewRTGChunkedProtocol creates a new RTGChunkedProtocol for the Galois elements galEls, processed in chunks of at most
+// chunkSize Galois elements whose shares are generated by the given number of goroutines, or by runtime.NumCPU()
+// goroutines if goroutines is smaller than 1. It panics if chunkSize is smaller than 1.
+func NewRTGChunkedProtocol(params rlwe.Parameters, galEls []uint64, chunkSize, goroutines int) *RTGChunkedProtocol {
+
+	if chunkSize < 1 {
+		panic("cannot NewRTGChunkedProtocol: chunkSize must be at least 1")
+	}
+
+	if goroutines < 1 {
+		goroutines = runtime.NumCPU()
+	}
+
+	rtg := make([]*RTGProtocol, goroutines)
+	rtg[0] = NewRTGProtocol(params)
+	for i := 1; i < goroutines; i++ {
+		rtg[i] = rtg[0].ShallowCopy()
+	}
+
+	return &RTGChunkedProtocol{params: params, galoisElements: append([]uint64(nil), galEls...), chunkSize: chunkSize, rtg: rtg, header: NewShareHeader(ProtocolRTGBatch, params)}
+}
+
+// ShallowCopy creates a shallow copy of RTGChunkedProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// RTGChunkedProtocol can be used concurrently.
+func (rtg *RTGChunkedProtocol) ShallowCopy() *RTGChunkedProtocol {
+	rtgs := make([]*RTGProtocol, len(rtg.rtg))
+	for i := range rtgs {
+		rtgs[i] = rtg.rtg[i].ShallowCopy()
+	}
+	return &RTGChunkedProtocol{params: rtg.params, galoisElements: rtg.galoisElements, chunkSize: rtg.chunkSize, rtg: rtgs, header: rtg.header}
+}
+
+// Chunks returns the number of chunks of the protocol.
+func (rtg *RTGChunkedProtocol) Chunks() int {
+	return (len(rtg.galoisElements) + rtg.chunkSize - 1) / rtg.chunkSize
+}
+
+// Chunk returns the Galois elements of the chunk-th chunk.
+func (rtg *RTGChunkedProtocol) Chunk(chunk int) []uint64 {
+	return append([]uint64(nil), rtg.chunk(chunk)...)
+}
+
+func (rtg *RTGChunkedProtocol) chunk(chunk int) []uint64 {
+	if chunk < 0 || chunk >= rtg.Chunks() {
+		panic(fmt.Sprintf("cannot Chunk: invalid chunk index %d", chunk))
+	}
+	start := chunk * rtg.chunkSize
+	return rtg.galoisElements[start:utils.MinInt(start+rtg.chunkSize, len(rtg.galoisElements))]
+}
+
+// batch returns the RTGBatchProtocol of the given Galois elements, which shares the goroutines of the receiver.
+func (rtg *RTGChunkedProtocol) batch(galEls []uint64) *RTGBatchProtocol {
+	return &RTGBatchProtocol{params: rtg.params, galoisElements: galEls, rtg: rtg.rtg, header: rtg.header}
+}
+
+// AllocateShare allocates a party's share in the RTGChunkedProtocol, which is large enough for any chunk and is reused
+// from one chunk to the next.
+func (rtg *RTGChunkedProtocol) AllocateShare() (share *RTGBatchShare) {
+	return rtg.batch(rtg.galoisElements[:utils.MinInt(rtg.chunkSize, len(rtg.galoisElements))]).AllocateShare()
+}
+
+// SampleCRP samples the common random polynomials of the Galois elements of the chunk-th chunk from the provided
+// common reference string. The chunks must be sampled in order from the same common reference string by all the
+// parties, in which case the common random polynomials are those of the RTGBatchProtocol.
+func (rtg *RTGChunkedProtocol) SampleCRP(chunk int, crs CRS) RTGBatchCRP {
+	return rtg.batch(rtg.chunk(chunk)).SampleCRP(crs)
+}
+
+// GenShare generates the party's shares of the Galois elements of the chunk-th chunk in shareOut, which is resized to
+// the chunk.
+func (rtg *RTGChunkedProtocol) GenShare(sk *rlwe.SecretKey, chunk int, crp RTGBatchCRP, shareOut *RTGBatchShare) {
+	galEls := rtg.chunk(chunk)
+	rtg.resizeShare(shareOut, galEls)
+	rtg.batch(galEls).GenShare(sk, crp, shareOut)
+}
+
+// AggregateShare aggregates the shares share1 and share2 of the same chunk in shareOut, which is resized to the chunk.
+func (rtg *RTGChunkedProtocol) AggregateShare(share1, share2, shareOut *RTGBatchShare) {
+	rtg.resizeShare(shareOut, share1.GaloisElements)
+	rtg.batch(share1.GaloisElements).AggregateShare(share1, share2, shareOut)
+}
+
+// GenRotationKeys finalizes the chunk-th chunk and populates rotKeys, which must have a SwitchingKey for each Galois
+// element of the chunk, with its collective rotation keys.
+func (rtg *RTGChunkedProtocol) GenRotationKeys(chunk int, share *RTGBatchShare, crp RTGBatchCRP, rotKeys *rlwe.RotationKeySet) {
+	rtg.batch(rtg.chunk(chunk)).GenRotationKeys(share, crp, rotKeys)
+}
+
+// Run executes the protocol for all the chunks in order: for each chunk, it samples the common random polynomials from
+// crs, generates the share of the party, exchanges it for the aggregated share with exchange and generates the rotation
+// keys of the chunk, which are handed over to finalize, e.g. to be stored or merged in a rlwe.RotationKeySet. After
+// each chunk, progress, if not nil, is called with the number of chunks done and the total number of chunks. A single
+// share is allocated for all the chunks. It returns an error if exchange or finalize does, or if the aggregated share
+// does not match the Galois elements of the chunk.
+func (rtg *RTGChunkedProtocol) Run(sk *rlwe.SecretKey, crs CRS, exchange RTGChunkExchange, finalize func(chunk int, rotKeys *rlwe.RotationKeySet) error, progress func(done, total int)) error {
+
+	share := rtg.AllocateShare()
+	total := rtg.Chunks()
+
+	for chunk := 0; chunk < total; chunk++ {
+
+		galEls := rtg.chunk(chunk)
+		crp := rtg.SampleCRP(chunk, crs)
+
+		rtg.GenShare(sk, chunk, crp, share)
+
+		combined, err := exchange(chunk, share)
+		if err != nil {
+			return fmt.Errorf("cannot Run: chunk %d: %w", chunk, err)
+		}
+
+		if !equalGaloisElements(combined.GaloisElements, galEls) || len(combined.Value) != len(galEls) {
+			return fmt.Errorf("cannot Run: chunk %d: the aggregated share does not match the Galois elements of the chunk", chunk)
+		}
+
+		rotKeys := rlwe.NewRotationKeySet(rtg.params, galEls)
+		rtg.GenRotationKeys(chunk, combined, crp, rotKeys)
+
+		if err = finalize(chunk, rotKeys); err != nil {
+			return fmt.Errorf("cannot Run: chunk %d: %w", chunk, err)
+		}
+
+		if progress != nil {
+			progress(chunk+1, total)
+		}
+	}
+
+	return nil
+}
+
+// resizeShare resizes share to the Galois elements galEls, reusing its RTGShares.
+func (rtg *RTGChunkedProtocol) resizeShare(share *RTGBatchShare, galEls []uint64) {
+	if n := len(galEls); cap(share.Value) < n {
+		share.Value = append(share.Value[:cap(share.Value)], make([]*RTGShare, n-cap(share.Value))...)
+	}
+	share.Value = share.Value[:len(galEls)]
+	for i := range share.Value {
+		if share.Value[i] == nil {
+			share.Value[i] = rtg.rtg[0].AllocateShare()
+		}
+	}
+	share.GaloisElements = append(share.GaloisElements[:0], galEls...)
+}
+
+func equalGaloisElements(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package drlwe
+
+import (
+	"errors"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// SKGShare is the share of a party in the SKG protocol.
+type SKGShare struct {
+	Header ShareHeader
+	Value  []rlwe.PolyQP
+}
+
+// SKGCRP is a type for common reference polynomials in the SKG protocol.
+type SKGCRP []rlwe.PolyQP
+
+// SKGProtocol is the structure storing the parameters for the collective generation of a switching key from a
+// collective secret key skIn to another collective secret key skOut, both additively shared among the same parties,
+// e.g. the switching keys between the dense and the sparse collective secret keys used by the CKKS bootstrapping (see
+// dckks.SKGProtocol). Each party generates its share from its shares of skIn and skOut, and the aggregation of the
+// shares is the switching key from skIn to skOut, as generated by rlwe.KeyGenerator.GenSwitchingKey.
+type SKGProtocol struct {
+	params  rlwe.Parameters
+	tmpPoly rlwe.PolyQP
+	workers []decompWorker
+	header  ShareHeader
+}
+
+// NewSKGProtocol creates a new SKGProtocol instance. The number of goroutines of GenShare and AggregateShare can be set
+// with the WithGoroutines option, and the randomness of the shares can be seeded with the WithSeed option.
+func NewSKGProtocol(params rlwe.Parameters, options ...ProtocolOption) *SKGProtocol {
+	opts := newProtocolOptions(params, options)
+	return &SKGProtocol{
+		params:  params,
+		tmpPoly: params.RingQP().NewPoly(),
+		workers: newDecompWorkers(params, opts.goroutines, opts.seed),
+		header:  NewShareHeader(ProtocolSKG, params),
+	}
+}
+
+// ShallowCopy creates a shallow copy of SKGProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// SKGProtocol can be used concurrently.
+func (skg *SKGProtocol) ShallowCopy() *SKGProtocol {
+	return &SKGProtocol{
+		params:  skg.params,
+		tmpPoly: skg.params.RingQP().NewPoly(),
+		workers: newDecompWorkers(skg.params, len(skg.workers), nil),
+		header:  skg.header,
+	}
+}
+
+// AllocateShare allocates a party's share in the SKG protocol.
+func (skg *SKGProtocol) AllocateShare() (share *SKGShare) {
+	share = &SKGShare{Header: skg.header, Value: make([]rlwe.PolyQP, skg.params.Beta())}
+	for i := range share.Value {
+		share.Value[i] = skg.params.RingQP().NewPoly()
+	}
+	return
+}
+
+// SampleCRP samples a common random polynomial to be used in the SKG protocol from the provided
+// common reference string.
+func (skg *SKGProtocol) SampleCRP(crs CRS) SKGCRP {
+	crp := make([]rlwe.PolyQP, skg.params.Beta())
+	us := rlwe.NewUniformSamplerQP(skg.params, crs, skg.params.RingQP())
+	for i := range crp {
+		crp[i] = skg.params.RingQP().NewPoly()
+		us.Read(&crp[i])
+	}
+	return SKGCRP(crp)
+}
+
+// GenShare generates the party's share in the SKG protocol from its share skIn of the input collective secret key
+// and its share skOut of the output collective secret key.
+func (skg *SKGProtocol) GenShare(skIn, skOut *rlwe.SecretKey, crp SKGCRP, shareOut *SKGShare) {
+
+	ringQ := skg.params.RingQ()
+	ringP := skg.params.RingP()
+	ringQP := skg.params.RingQP()
+	levelQ := skg.params.QCount() - 1
+	levelP := skg.params.PCount() - 1
+
+	ringQ.MulScalarBigint(skIn.Value.Q, ringP.ModulusBigint, skg.tmpPoly.Q)
+
+	parallelDecomp(skg.workers, skg.params.Beta(), func(worker *decompWorker, i int) {
+
+		// e
+		worker.gaussianSamplerQ.Read(shareOut.Value[i].Q)
+		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i].Q, levelP, nil, shareOut.Value[i].P)
+		ringQP.NTTLazyLvl(levelQ, levelP, shareOut.Value[i], shareOut.Value[i])
+		ringQP.MFormLvl(levelQ, levelP, shareOut.Value[i], shareOut.Value[i])
+
+		// e + skIn * (qiBarre*qiStar) * 2^w
+		// (qiBarre*qiStar)%qi = 1, else 0
+		for j := 0; j < skg.params.PCount(); j++ {
+
+			index := i*skg.params.PCount() + j
+
+			// Handles the case where nb pj does not divides nb qi
+			if index >= skg.params.QCount() {
+				break
+			}
+
+			qi := ringQ.Modulus[index]
+			tmp0 := skg.tmpPoly.Q.Coeffs[index]
+			tmp1 := shareOut.Value[i].Q.Coeffs[index]
+
+			for w := 0; w < ringQ.N; w++ {
+				tmp1[w] = ring.CRed(tmp1[w]+tmp0[w], qi)
+			}
+		}
+
+		// skIn * (qiBarre*qiStar) * 2^w - a*skOut + e
+		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, crp[i], skOut.Value, shareOut.Value[i])
+	})
+}
+
+// AggregateShare aggregates two shares in the SKG protocol.
+func (skg *SKGProtocol) AggregateShare(share1, share2, shareOut *SKGShare) {
+	ringQP, levelQ, levelP := skg.params.RingQP(), skg.params.QCount()-1, skg.params.PCount()-1
+	parallelDecomp(skg.workers, skg.params.Beta(), func(_ *decompWorker, i int) {
+		ringQP.AddLvl(levelQ, levelP, share1.Value[i], share2.Value[i], shareOut.Value[i])
+	})
+}
+
+// GenSwitchingKey finalizes the SKG protocol and populates the input switching key with the switching key from the
+// input collective secret key to the output collective secret key.
+func (skg *SKGProtocol) GenSwitchingKey(share *SKGShare, crp SKGCRP, swk *rlwe.SwitchingKey) {
+	for i := 0; i < skg.params.Beta(); i++ {
+		swk.Value[i][0].CopyValues(share.Value[i])
+		swk.Value[i][1].CopyValues(crp[i])
+	}
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (share *SKGShare) MarshalBinary() (data []byte, err error) {
+	if len(share.Value) > 0xFF {
+		return nil, errors.New("cannot MarshalBinary: uint8 overflow on length")
+	}
+	data = make([]byte, ShareHeaderLen+1+share.Value[0].GetDataLen(true)*len(share.Value))
+	ptr, err := share.Header.WriteTo(data)
+	if err != nil {
+		return nil, err
+	}
+	data[ptr] = uint8(len(share.Value))
+	ptr++
+	var inc int
+	for _, val := range share.Value {
+		if inc, err = val.WriteTo(data[ptr:]); err != nil {
+			return nil, err
+		}
+		ptr += inc
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (share *SKGShare) UnmarshalBinary(data []byte) (err error) {
+	var ptr, inc int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	if len(data) <= ptr {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+	if data[ptr] == 0 {
+		return errors.New("cannot UnmarshalBinary: share has no value")
+	}
+	share.Value = make([]rlwe.PolyQP, data[ptr])
+	ptr++
+	for i := range share.Value {
+		if inc, err = share.Value[i].DecodePolyNew(data[ptr:]); err != nil {
+			return err
+		}
+		ptr += inc
+		if share.Value[i].GetDataLen(true) != share.Value[0].GetDataLen(true) {
+			return errors.New("cannot UnmarshalBinary: inconsistent polynomial sizes")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// CKSBatchShare is the share of a party in the CKSBatchProtocol, i.e. its CKS shares for all the ciphertexts of the
+// batch, sent in a single message.
+type CKSBatchShare struct {
+	Header ShareHeader
+	Value  []*CKSShare
+}
+
+// CKSBatchProtocol is the structure storing the parameters for the collective key-switching of a batch of ciphertexts
+// in a single round: each party generates the CKS shares of all the ciphertexts at once, in parallel, and sends them in
+// a single CKSBatchShare, which amortizes the overhead per message and the set-up of the samplers when many
+// ciphertexts must be key-switched together.
+type CKSBatchProtocol struct {
+	params rlwe.Parameters
+	cks    []*CKSProtocol
+	header ShareHeader
+}
+
+// NewCKSBatchProtocol creates a new CKSBatchProtocol whose shares are generated by the given number of goroutines, or
+// by runtime.NumCPU() goroutines if goroutines is smaller than 1.
+func NewCKSBatchProtocol(params rlwe.Parameters, sigmaSmudging float64, goroutines int) *CKSBatchProtocol {
+
+	if goroutines < 1 {
+		goroutines = runtime.NumCPU()
+	}
+
+	cks := make([]*CKSProtocol, goroutines)
+	cks[0] = NewCKSProtocol(params, sigmaSmudging)
+	for i := 1; i < goroutines; i++ {
+		cks[i] = cks[0].ShallowCopy()
+	}
+
+	return &CKSBatchProtocol{params: params, cks: cks, header: NewShareHeader(ProtocolCKSBatch, params)}
+}
+
+// ShallowCopy creates a shallow copy of CKSBatchProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// CKSBatchProtocol can be used concurrently.
+func (cks *CKSBatchProtocol) ShallowCopy() *CKSBatchProtocol {
+	workers := make([]*CKSProtocol, len(cks.cks))
+	for i := range workers {
+		workers[i] = cks.cks[i].ShallowCopy()
+	}
+	return &CKSBatchProtocol{params: cks.params, cks: workers, header: cks.header}
+}
+
+// AllocateShare allocates the share of a party in the CKSBatchProtocol for a batch of n ciphertexts at the given level.
+func (cks *CKSBatchProtocol) AllocateShare(level, n int) (share *CKSBatchShare) {
+	share = &CKSBatchShare{Header: cks.header, Value: make([]*CKSShare, n)}
+	for i := range share.Value {
+		share.Value[i] = cks.cks[0].AllocateShare(level)
+	}
+	return
+}
+
+// GenShare generates the party's CKS shares of all the ciphertexts of the batch in parallel, where c1[i] is the degree
+// 1 element of the i-th ciphertext (see CKSProtocol.GenShare). It panics if the batch and the share do not have the
+// same size.
+func (cks *CKSBatchProtocol) GenShare(skInput, skOutput *rlwe.SecretKey, c1 []*ring.Poly, shareOut *CKSBatchShare) {
+
+	if len(c1) != len(shareOut.Value) {
+		panic("cannot GenShare: the batch and the share do not have the same size")
+	}
+
+	cks.parallel(len(c1), func(worker *CKSProtocol, i int) {
+		worker.GenShare(skInput, skOutput, c1[i], shareOut.Value[i])
+	})
+}
+
+// AggregateShare aggregates the shares share1 and share2 of all the ciphertexts of the batch in parallel.
+func (cks *CKSBatchProtocol) AggregateShare(share1, share2, shareOut *CKSBatchShare) {
+
+	if len(share1.Value) != len(share2.Value) || len(share1.Value) != len(shareOut.Value) {
+		panic("cannot AggregateShare: the shares do not have the same size")
+	}
+
+	cks.parallel(len(shareOut.Value), func(worker *CKSProtocol, i int) {
+		worker.AggregateShare(share1.Value[i], share2.Value[i], shareOut.Value[i])
+	})
+}
+
+// KeySwitch performs the key-switching of all the ciphertexts ctIn of the batch from the aggregated share combined and
+// puts the results in ctOut. It panics if the batch and the share do not have the same size.
+func (cks *CKSBatchProtocol) KeySwitch(ctIn []*rlwe.Ciphertext, combined *CKSBatchShare, ctOut []*rlwe.Ciphertext) {
+
+	if len(ctIn) != len(combined.Value) || len(ctOut) != len(combined.Value) {
+		panic("cannot KeySwitch: the batch and the share do not have the same size")
+	}
+
+	for i := range ctIn {
+		cks.cks[0].KeySwitch(ctIn[i], combined.Value[i], ctOut[i])
+	}
+}
+
+// parallel calls f on each of the n ciphertexts of the batch, distributed among the goroutines.
+func (cks *CKSBatchProtocol) parallel(n int, f func(worker *CKSProtocol, i int)) {
+
+	var wg sync.WaitGroup
+	for w := range cks.cks {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < n; i += len(cks.cks) {
+				f(cks.cks[w], i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (share *CKSBatchShare) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, ShareHeaderLen+4)
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(data[ShareHeaderLen:], uint32(len(share.Value)))
+
+	// The CKS shares of the batch are written without their header
+	for _, s := range share.Value {
+
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(s.Value.GetDataLen(true)))
+
+		polyData := make([]byte, s.Value.GetDataLen(true))
+		if _, err = s.Value.WriteTo(polyData); err != nil {
+			return nil, err
+		}
+
+		data = append(data, size...)
+		data = append(data, polyData...)
+	}
+
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (share *CKSBatchShare) UnmarshalBinary(data []byte) (err error) {
+
+	var ptr int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	data = data[ptr:]
+
+	if len(data) < 4 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+
+	if n > len(data)/4 {
+		return errors.New("cannot UnmarshalBinary: invalid number of shares")
+	}
+
+	share.Value = make([]*CKSShare, n)
+
+	for i := range share.Value {
+
+		if len(data) < 4 {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
+		size := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+
+		if size == 0 || size > len(data) {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
+		// The CKS shares of the batch are generated with the parameters of the batch
+		share.Value[i] = &CKSShare{Header: ShareHeader{Version: share.Header.Version, Protocol: ProtocolCKS, Params: share.Header.Params}, Value: new(ring.Poly)}
+		if err = share.Value[i].Value.UnmarshalBinary(data[:size]); err != nil {
+			return err
+		}
+
+		data = data[size:]
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package drlwe
+
+import (
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// DegreeReductionShare is the share of a party in a round of the reduction of the degree of a ciphertext.
+type DegreeReductionShare struct {
+	Header ShareHeader
+	Value  *ring.Poly
+}
+
+// degreeReducer implements the collective reduction of the degree of the ciphertexts of the CKSProtocol and of the
+// PCKSProtocol. The key-switching of a ciphertext (c_0, ..., c_d) of degree d > 1 under the collective secret key s,
+// e.g. the product of two ciphertexts before its relinearization, is preceded by d-1 rounds, each of which replaces the
+// ciphertext by (c_0, ..., c_{d-2}, c_{d-1} + c_d*s + e) of degree d-1: each party generates its share
+// c_d*s_i + e_i with GenDegreeReductionShare, and the aggregated shares are added to the ciphertext with ReduceDegree.
+// The error e_i is sampled with the standard deviation of the parameters, and the error e*s^{d-1} added to the
+// ciphertext is of the order of the error of a relinearization, which the smudging noise of the key-switching must
+// account for. This avoids the generation of a collective relinearization key in the workflows that only decrypt or
+// re-encrypt the products.
+type degreeReducer struct {
+	params          rlwe.Parameters
+	gaussianSampler *ring.GaussianSampler
+	tmpQ            *ring.Poly
+	header          ShareHeader
+}
+
+func newDegreeReducer(params rlwe.Parameters) degreeReducer {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return degreeReducer{
+		params:          params,
+		gaussianSampler: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
+		tmpQ:            params.RingQ().NewPoly(),
+		header:          NewShareHeader(ProtocolDegreeReduction, params),
+	}
+}
+
+// shallowCopy returns a copy of the degreeReducer with the same parameters and header, and new samplers and buffers.
+func (dr *degreeReducer) shallowCopy() degreeReducer {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return degreeReducer{
+		params:          dr.params,
+		gaussianSampler: ring.NewGaussianSampler(prng, dr.params.RingQ(), dr.params.Sigma(), int(6*dr.params.Sigma())),
+		tmpQ:            dr.params.RingQ().NewPoly(),
+		header:          dr.header,
+	}
+}
+
+// AllocateDegreeReductionShare allocates a share of a round of the reduction of the degree of a ciphertext.
+func (dr *degreeReducer) AllocateDegreeReductionShare(level int) *DegreeReductionShare {
+	return &DegreeReductionShare{Header: dr.header, Value: dr.params.RingQ().NewPolyLvl(level)}
+}
+
+// GenDegreeReductionShare computes the share of a party in a round of the reduction of the degree of a ciphertext,
+// i.e. cd * skInput + e.
+// cd is the element of largest degree of the rlwe.Ciphertext to reduce, i.e. cd = rlwe.Ciphertext.Value[d].
+// NTT flag for cd is expected to be set correctly.
+func (dr *degreeReducer) GenDegreeReductionShare(skInput *rlwe.SecretKey, cd *ring.Poly, shareOut *DegreeReductionShare) {
+
+	ringQ := dr.params.RingQ()
+
+	levelQ := utils.MinInt(shareOut.Value.Level(), cd.Level())
+
+	ct := cd
+	if !cd.IsNTT {
+		ringQ.NTTLazyLvl(levelQ, cd, dr.tmpQ)
+		ct = dr.tmpQ
+	}
+
+	// cd * skInput mod Q
+	ringQ.MulCoeffsMontgomeryLvl(levelQ, ct, skInput.Value.Q, shareOut.Value)
+
+	if !cd.IsNTT {
+		// cd * skInput + e mod Q
+		ringQ.InvNTTLvl(levelQ, shareOut.Value, shareOut.Value)
+		dr.gaussianSampler.ReadAndAddLvl(levelQ, shareOut.Value)
+	} else {
+		// Samples e in Q and takes it to the NTT domain
+		dr.gaussianSampler.ReadLvl(levelQ, dr.tmpQ)
+		ringQ.NTTLvl(levelQ, dr.tmpQ, dr.tmpQ)
+
+		// cd * skInput + e mod Q
+		ringQ.AddLvl(levelQ, shareOut.Value, dr.tmpQ, shareOut.Value)
+	}
+
+	shareOut.Value.Coeffs = shareOut.Value.Coeffs[:levelQ+1]
+}
+
+// AggregateDegreeReductionShare aggregates two shares of a round of the reduction of the degree of a ciphertext.
+func (dr *degreeReducer) AggregateDegreeReductionShare(share1, share2, shareOut *DegreeReductionShare) {
+	dr.params.RingQ().AddLvl(share1.Value.Level(), share1.Value, share2.Value, shareOut.Value)
+}
+
+// ReduceDegree adds the aggregated shares of a round of the reduction of the degree of ctIn, of degree d > 1, to its
+// element of degree d-1 and returns the resulting ciphertext of degree d-1 in ctOut, which is resized if necessary.
+func (dr *degreeReducer) ReduceDegree(ctIn *rlwe.Ciphertext, combined *DegreeReductionShare, ctOut *rlwe.Ciphertext) {
+
+	degree := ctIn.Degree()
+	if degree < 2 {
+		panic("cannot ReduceDegree: the degree of the ciphertext must be at least 2")
+	}
+
+	level := utils.MinInt(ctIn.Level(), ctOut.Level())
+
+	ctOut.Resize(dr.params, degree-1)
+
+	for i := 0; i < degree-1; i++ {
+		if ctIn.Value[i] != ctOut.Value[i] {
+			ring.CopyValuesLvl(level, ctIn.Value[i], ctOut.Value[i])
+			ctOut.Value[i].IsNTT = ctIn.Value[i].IsNTT
+		}
+	}
+
+	dr.params.RingQ().AddLvl(level, ctIn.Value[degree-1], combined.Value, ctOut.Value[degree-1])
+	ctOut.Value[degree-1].IsNTT = ctIn.Value[degree-1].IsNTT
+}
+
+// MarshalBinary encodes a DegreeReductionShare on a slice of bytes.
+func (share *DegreeReductionShare) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, ShareHeaderLen+share.Value.GetDataLen(true))
+	var ptr int
+	if ptr, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	if _, err = share.Value.WriteTo(data[ptr:]); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// UnmarshalBinary decodes a marshaled DegreeReductionShare on the target DegreeReductionShare.
+func (share *DegreeReductionShare) UnmarshalBinary(data []byte) (err error) {
+	var ptr int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	share.Value = new(ring.Poly)
+	return share.Value.UnmarshalBinary(data[ptr:])
+}
@@ -1,6 +1,8 @@
 package drlwe
 
 import (
+	"time"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -16,11 +18,13 @@ type PublicKeySwitchingProtocol interface {
 
 // PCKSShare represents a party's share in the PCKS protocol.
 type PCKSShare struct {
-	Value [2]*ring.Poly
+	Header ShareHeader
+	Value  [2]*ring.Poly
 }
 
 // PCKSProtocol is the structure storing the parameters for the collective public key-switching.
 type PCKSProtocol struct {
+	degreeReducer
 	params        rlwe.Parameters
 	sigmaSmudging float64
 
@@ -30,6 +34,9 @@ type PCKSProtocol struct {
 	basisExtender             *ring.BasisExtender
 	gaussianSampler           *ring.GaussianSampler
 	ternarySamplerMontgomeryQ *ring.TernarySampler
+
+	metrics protocolMetrics
+	header  ShareHeader
 }
 
 // ShallowCopy creates a shallow copy of PCKSProtocol in which all the read-only data-structures are
@@ -44,6 +51,7 @@ func (pcks *PCKSProtocol) ShallowCopy() *PCKSProtocol {
 	params := pcks.params
 
 	return &PCKSProtocol{
+		degreeReducer:             pcks.degreeReducer.shallowCopy(),
 		params:                    params,
 		sigmaSmudging:             pcks.sigmaSmudging,
 		tmpQP:                     params.RingQP().NewPoly(),
@@ -51,15 +59,20 @@ func (pcks *PCKSProtocol) ShallowCopy() *PCKSProtocol {
 		basisExtender:             pcks.basisExtender.ShallowCopy(),
 		gaussianSampler:           ring.NewGaussianSampler(prng, params.RingQ(), pcks.sigmaSmudging, int(6*pcks.sigmaSmudging)),
 		ternarySamplerMontgomeryQ: ring.NewTernarySampler(prng, params.RingQ(), 0.5, false),
+		metrics:                   pcks.metrics,
+		header:                    pcks.header,
 	}
 }
 
 // NewPCKSProtocol creates a new PCKSProtocol object and will be used to re-encrypt a ciphertext ctx encrypted under a secret-shared key among j parties under a new
-// collective public-key.
-func NewPCKSProtocol(params rlwe.Parameters, sigmaSmudging float64) (pcks *PCKSProtocol) {
+// collective public-key. sigmaSmudging is the standard deviation of the smudging noise added by each party to its share,
+// e.g. computed with SmudgingParams. The operations of the protocol can be recorded with the WithMetrics option.
+func NewPCKSProtocol(params rlwe.Parameters, sigmaSmudging float64, options ...ProtocolOption) (pcks *PCKSProtocol) {
 	pcks = new(PCKSProtocol)
 	pcks.params = params
 	pcks.sigmaSmudging = sigmaSmudging
+	pcks.header = NewShareHeader(ProtocolPCKS, params)
+	pcks.metrics = protocolMetrics{newProtocolOptions(params, options).metrics, "drlwe.PCKSProtocol"}
 
 	pcks.tmpQP = params.RingQP().NewPoly()
 	pcks.tmpP = [2]*ring.Poly{params.RingP().NewPoly(), params.RingP().NewPoly()}
@@ -71,23 +84,31 @@ func NewPCKSProtocol(params rlwe.Parameters, sigmaSmudging float64) (pcks *PCKSP
 	}
 	pcks.gaussianSampler = ring.NewGaussianSampler(prng, params.RingQ(), sigmaSmudging, int(6*sigmaSmudging))
 	pcks.ternarySamplerMontgomeryQ = ring.NewTernarySampler(prng, params.RingQ(), 0.5, false)
+	pcks.degreeReducer = newDegreeReducer(params)
 
 	return pcks
 }
 
 // AllocateShare allocates the shares of the PCKS protocol.
 func (pcks *PCKSProtocol) AllocateShare(levelQ int) (s *PCKSShare) {
-	return &PCKSShare{[2]*ring.Poly{pcks.params.RingQ().NewPolyLvl(levelQ), pcks.params.RingQ().NewPolyLvl(levelQ)}}
+	return &PCKSShare{
+		Header: pcks.header,
+		Value:  [2]*ring.Poly{pcks.params.RingQ().NewPolyLvl(levelQ), pcks.params.RingQ().NewPolyLvl(levelQ)},
+	}
 }
 
 // GenShare is the first part of the unique round of the PCKSProtocol protocol. Each party computes the following :
 //
-// [s_i * ct[1] + (u_i * pk[0] + e_0i)/P, (u_i * pk[1] + e_1i)/P]
+// [s_i * ct[1] + (u_i * pk[0] + e_0i)/P + e_i, (u_i * pk[1] + e_1i)/P]
 //
-// and broadcasts the result to the other j-1 parties.
-// ct1 is the degree 1 element of the rlwe.Ciphertext to keyswitch, i.e. ct1 = rlwe.Ciphertext.Value[1].
+// where e_0i and e_1i are sampled with the standard deviation of the parameters and e_i is the smudging noise, and
+// broadcasts the result to the other j-1 parties.
+// ct1 is the degree 1 element of the rlwe.Ciphertext to keyswitch, i.e. ct1 = rlwe.Ciphertext.Value[1]. A ciphertext of
+// degree larger than 1 must first be reduced to degree 1 with GenDegreeReductionShare and ReduceDegree.
 // NTT flag for ct1 is expected to be set correctly.
 func (pcks *PCKSProtocol) GenShare(sk *rlwe.SecretKey, pk *rlwe.PublicKey, ct1 *ring.Poly, shareOut *PCKSShare) {
+	defer pcks.metrics.operation("GenShare", time.Now())
+	defer pcks.metrics.shareSize("PCKSShare", shareOut)
 
 	ringQ := pcks.params.RingQ()
 	ringP := pcks.params.RingP()
@@ -113,13 +134,15 @@ func (pcks *PCKSProtocol) GenShare(sk *rlwe.SecretKey, pk *rlwe.PublicKey, ct1 *
 	ringQP.InvNTTLvl(levelQ, levelP, shareOutQP0, shareOutQP0)
 	ringQP.InvNTTLvl(levelQ, levelP, shareOutQP1, shareOutQP1)
 
-	// h_0 = u_i * pk_0
-	pcks.gaussianSampler.ReadLvl(levelQ, pcks.tmpQP.Q)
+	sigma := pcks.params.Sigma()
+
+	// h_0 = u_i * pk_0 + e0
+	pcks.gaussianSampler.ReadFromDistLvl(levelQ, pcks.tmpQP.Q, ringQ, sigma, int(6*sigma))
 	ringQP.ExtendBasisSmallNormAndCenter(pcks.tmpQP.Q, levelP, nil, pcks.tmpQP.P)
 	ringQP.AddLvl(levelQ, levelP, shareOutQP0, pcks.tmpQP, shareOutQP0)
 
 	// h_1 = u_i * pk_1 + e1
-	pcks.gaussianSampler.ReadLvl(levelQ, pcks.tmpQP.Q)
+	pcks.gaussianSampler.ReadFromDistLvl(levelQ, pcks.tmpQP.Q, ringQ, sigma, int(6*sigma))
 	ringQP.ExtendBasisSmallNormAndCenter(pcks.tmpQP.Q, levelP, nil, pcks.tmpQP.P)
 	ringQP.AddLvl(levelQ, levelP, shareOutQP1, pcks.tmpQP, shareOutQP1)
 
@@ -129,7 +152,10 @@ func (pcks *PCKSProtocol) GenShare(sk *rlwe.SecretKey, pk *rlwe.PublicKey, ct1 *
 	// h_1 = (u_i * pk_1 + e1)/P
 	pcks.basisExtender.ModDownQPtoQ(levelQ, levelP, shareOutQP1.Q, shareOutQP1.P, shareOutQP1.Q)
 
-	// h_0 = s_i*c_1 + (u_i * pk_0 + e0)/P
+	// h_0 = (u_i * pk_0 + e0)/P + e_smudging
+	pcks.gaussianSampler.ReadAndAddLvl(levelQ, shareOutQP0.Q)
+
+	// h_0 = s_i*c_1 + (u_i * pk_0 + e0)/P + e_smudging
 	if ct1.IsNTT {
 		ringQ.NTTLvl(levelQ, shareOut.Value[0], shareOut.Value[0])
 		ringQ.NTTLvl(levelQ, shareOut.Value[1], shareOut.Value[1])
@@ -150,6 +176,7 @@ func (pcks *PCKSProtocol) GenShare(sk *rlwe.SecretKey, pk *rlwe.PublicKey, ct1 *
 //
 // [ctx[0] + sum(s_i * ctx[0] + u_i * pk[0] + e_0i), sum(u_i * pk[1] + e_1i)]
 func (pcks *PCKSProtocol) AggregateShare(share1, share2, shareOut *PCKSShare) {
+	defer pcks.metrics.operation("AggregateShare", time.Now())
 	levelQ1, levelQ2 := len(share1.Value[0].Coeffs)-1, len(share2.Value[1].Coeffs)-1
 	if levelQ1 != levelQ2 {
 		panic("cannot aggreate two shares at different levelQs.")
@@ -159,17 +186,27 @@ func (pcks *PCKSProtocol) AggregateShare(share1, share2, shareOut *PCKSShare) {
 
 }
 
-// KeySwitch performs the actual keyswitching operation on a ciphertext ct and put the result in ctOut
+// KeySwitch performs the actual keyswitching operation on a ciphertext ct of degree 1 and put the result in ctOut
 func (pcks *PCKSProtocol) KeySwitch(ctIn *rlwe.Ciphertext, combined *PCKSShare, ctOut *rlwe.Ciphertext) {
+	defer pcks.metrics.operation("KeySwitch", time.Now())
 	level := utils.MinInt(ctIn.Level(), ctOut.Level())
 	pcks.params.RingQ().AddLvl(level, ctIn.Value[0], combined.Value[0], ctOut.Value[0])
 	ring.CopyValuesLvl(level, combined.Value[1], ctOut.Value[1])
 }
 
+// GetDataLen returns the length in bytes of the PCKS share.
+func (share *PCKSShare) GetDataLen(WithMetadata bool) int {
+	return ShareHeaderLen + share.Value[0].GetDataLen(WithMetadata) + share.Value[1].GetDataLen(WithMetadata)
+}
+
 // MarshalBinary encodes a PCKS share on a slice of bytes.
 func (share *PCKSShare) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, share.Value[0].GetDataLen(true)+share.Value[1].GetDataLen(true))
+	data = make([]byte, share.GetDataLen(true))
 	var inc, pt int
+	if pt, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+
 	if inc, err = share.Value[0].WriteTo(data[pt:]); err != nil {
 		return nil, err
 	}
@@ -184,6 +221,10 @@ func (share *PCKSShare) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decodes marshaled PCKS share on the target PCKS share.
 func (share *PCKSShare) UnmarshalBinary(data []byte) (err error) {
 	var pt, inc int
+	if pt, err = share.Header.Decode(data); err != nil {
+		return
+	}
+
 	share.Value[0] = new(ring.Poly)
 	if inc, err = share.Value[0].DecodePolyNew(data[pt:]); err != nil {
 		return
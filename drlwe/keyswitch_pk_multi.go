@@ -0,0 +1,246 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// MultiPCKSShare is the share of a party in the MultiPCKSProtocol, i.e. its PCKS shares for all the recipients, sent in
+// a single message.
+type MultiPCKSShare struct {
+	Header ShareHeader
+	Value  []*PCKSShare
+}
+
+// MultiPCKSProtocol is the structure storing the parameters for the collective public key-switching of a ciphertext
+// under the public keys of several recipients in a single round: each party generates its PCKS shares for all the
+// recipients at once, and the aggregated MultiPCKSShare yields one re-encryption of the ciphertext per recipient. The
+// product of the secret key share of the party with the ciphertext and the ephemeral key u_i are computed once for all
+// the recipients, the errors and the smudging noise being sampled for each recipient. Reusing u_i across the recipients
+// is secure as long as their public keys are generated independently.
+type MultiPCKSProtocol struct {
+	pcks *PCKSProtocol
+	pks  []*rlwe.PublicKey
+
+	tmpU  rlwe.PolyQP
+	tmpSc *ring.Poly
+
+	header ShareHeader
+}
+
+// NewMultiPCKSProtocol creates a new MultiPCKSProtocol that re-encrypts a ciphertext encrypted under a secret-shared key
+// among j parties under the public keys pks of the recipients. sigmaSmudging is the standard deviation of the smudging
+// noise added by each party to each of its shares, e.g. computed with SmudgingParams.
+func NewMultiPCKSProtocol(params rlwe.Parameters, sigmaSmudging float64, pks []*rlwe.PublicKey) *MultiPCKSProtocol {
+	return &MultiPCKSProtocol{
+		pcks:  NewPCKSProtocol(params, sigmaSmudging),
+		pks:   append([]*rlwe.PublicKey(nil), pks...),
+		tmpU:  params.RingQP().NewPoly(),
+		tmpSc: params.RingQ().NewPoly(),
+
+		header: NewShareHeader(ProtocolMultiPCKS, params),
+	}
+}
+
+// ShallowCopy creates a shallow copy of MultiPCKSProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MultiPCKSProtocol can be used concurrently.
+func (mpcks *MultiPCKSProtocol) ShallowCopy() *MultiPCKSProtocol {
+	params := mpcks.pcks.params
+	return &MultiPCKSProtocol{
+		pcks:  mpcks.pcks.ShallowCopy(),
+		pks:   mpcks.pks,
+		tmpU:  params.RingQP().NewPoly(),
+		tmpSc: params.RingQ().NewPoly(),
+
+		header: mpcks.header,
+	}
+}
+
+// Recipients returns the number of recipients of the MultiPCKSProtocol.
+func (mpcks *MultiPCKSProtocol) Recipients() int {
+	return len(mpcks.pks)
+}
+
+// AllocateShare allocates the shares of the MultiPCKSProtocol.
+func (mpcks *MultiPCKSProtocol) AllocateShare(levelQ int) (share *MultiPCKSShare) {
+	share = &MultiPCKSShare{
+		Header: mpcks.header,
+		Value:  make([]*PCKSShare, len(mpcks.pks)),
+	}
+	for i := range share.Value {
+		share.Value[i] = mpcks.pcks.AllocateShare(levelQ)
+	}
+	return
+}
+
+// GenShare computes the PCKS shares of the party for all the recipients, i.e. for the j-th recipient:
+//
+// [s_i * ct[1] + (u_i * pk_j[0] + e_0ij)/P + e_ij, (u_i * pk_j[1] + e_1ij)/P]
+//
+// where e_0ij and e_1ij are sampled with the standard deviation of the parameters and e_ij is the smudging noise.
+// ct1 is the degree 1 element of the rlwe.Ciphertext to keyswitch, i.e. ct1 = rlwe.Ciphertext.Value[1].
+// NTT flag for ct1 is expected to be set correctly.
+func (mpcks *MultiPCKSProtocol) GenShare(sk *rlwe.SecretKey, ct1 *ring.Poly, shareOut *MultiPCKSShare) {
+
+	pcks := mpcks.pcks
+
+	ringQ := pcks.params.RingQ()
+	ringP := pcks.params.RingP()
+	ringQP := pcks.params.RingQP()
+
+	levelQ := utils.MinInt(shareOut.Value[0].Value[0].Level(), ct1.Level())
+	levelP := len(ringP.Modulus) - 1
+
+	// samples MForm(u_i) in Q and P separately, once for all the recipients
+	pcks.ternarySamplerMontgomeryQ.ReadLvl(levelQ, mpcks.tmpU.Q)
+	ringQP.ExtendBasisSmallNormAndCenter(mpcks.tmpU.Q, levelP, nil, mpcks.tmpU.P)
+	ringQP.MFormLvl(levelQ, levelP, mpcks.tmpU, mpcks.tmpU)
+	ringQP.NTTLvl(levelQ, levelP, mpcks.tmpU, mpcks.tmpU)
+
+	// s_i*c_1, once for all the recipients
+	if ct1.IsNTT {
+		ringQ.MulCoeffsMontgomeryLvl(levelQ, ct1, sk.Value.Q, mpcks.tmpSc)
+	} else {
+		ringQ.NTTLazyLvl(levelQ, ct1, mpcks.tmpSc)
+		ringQ.MulCoeffsMontgomeryConstantLvl(levelQ, mpcks.tmpSc, sk.Value.Q, mpcks.tmpSc)
+		ringQ.InvNTTLvl(levelQ, mpcks.tmpSc, mpcks.tmpSc)
+	}
+
+	sigma := pcks.params.Sigma()
+
+	for j, pk := range mpcks.pks {
+
+		share := shareOut.Value[j]
+
+		shareOutQP0 := rlwe.PolyQP{Q: share.Value[0], P: pcks.tmpP[0]}
+		shareOutQP1 := rlwe.PolyQP{Q: share.Value[1], P: pcks.tmpP[1]}
+
+		// h_0 = u_i * pk_j_0
+		// h_1 = u_i * pk_j_1
+		ringQP.MulCoeffsMontgomeryLvl(levelQ, levelP, mpcks.tmpU, pk.Value[0], shareOutQP0)
+		ringQP.MulCoeffsMontgomeryLvl(levelQ, levelP, mpcks.tmpU, pk.Value[1], shareOutQP1)
+
+		ringQP.InvNTTLvl(levelQ, levelP, shareOutQP0, shareOutQP0)
+		ringQP.InvNTTLvl(levelQ, levelP, shareOutQP1, shareOutQP1)
+
+		// h_0 = u_i * pk_j_0 + e0
+		pcks.gaussianSampler.ReadFromDistLvl(levelQ, pcks.tmpQP.Q, ringQ, sigma, int(6*sigma))
+		ringQP.ExtendBasisSmallNormAndCenter(pcks.tmpQP.Q, levelP, nil, pcks.tmpQP.P)
+		ringQP.AddLvl(levelQ, levelP, shareOutQP0, pcks.tmpQP, shareOutQP0)
+
+		// h_1 = u_i * pk_j_1 + e1
+		pcks.gaussianSampler.ReadFromDistLvl(levelQ, pcks.tmpQP.Q, ringQ, sigma, int(6*sigma))
+		ringQP.ExtendBasisSmallNormAndCenter(pcks.tmpQP.Q, levelP, nil, pcks.tmpQP.P)
+		ringQP.AddLvl(levelQ, levelP, shareOutQP1, pcks.tmpQP, shareOutQP1)
+
+		// h_0 = (u_i * pk_j_0 + e0)/P
+		pcks.basisExtender.ModDownQPtoQ(levelQ, levelP, shareOutQP0.Q, shareOutQP0.P, shareOutQP0.Q)
+
+		// h_1 = (u_i * pk_j_1 + e1)/P
+		pcks.basisExtender.ModDownQPtoQ(levelQ, levelP, shareOutQP1.Q, shareOutQP1.P, shareOutQP1.Q)
+
+		// h_0 = (u_i * pk_j_0 + e0)/P + e_smudging
+		pcks.gaussianSampler.ReadAndAddLvl(levelQ, shareOutQP0.Q)
+
+		if ct1.IsNTT {
+			ringQ.NTTLvl(levelQ, share.Value[0], share.Value[0])
+			ringQ.NTTLvl(levelQ, share.Value[1], share.Value[1])
+		}
+
+		// h_0 = s_i*c_1 + (u_i * pk_j_0 + e0)/P + e_smudging
+		ringQ.AddLvl(levelQ, share.Value[0], mpcks.tmpSc, share.Value[0])
+	}
+}
+
+// AggregateShare aggregates the shares share1 and share2 of all the recipients.
+func (mpcks *MultiPCKSProtocol) AggregateShare(share1, share2, shareOut *MultiPCKSShare) {
+	for j := range mpcks.pks {
+		mpcks.pcks.AggregateShare(share1.Value[j], share2.Value[j], shareOut.Value[j])
+	}
+}
+
+// KeySwitch performs the actual keyswitching operation on a ciphertext ct of degree 1 and puts its re-encryption
+// under the public key of the j-th recipient in ctOut[j].
+func (mpcks *MultiPCKSProtocol) KeySwitch(ctIn *rlwe.Ciphertext, combined *MultiPCKSShare, ctOut []*rlwe.Ciphertext) {
+	for j := range mpcks.pks {
+		mpcks.pcks.KeySwitch(ctIn, combined.Value[j], ctOut[j])
+	}
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (share *MultiPCKSShare) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, ShareHeaderLen+4)
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(data[ShareHeaderLen:], uint32(len(share.Value)))
+
+	for _, s := range share.Value {
+
+		var shareData []byte
+		if shareData, err = s.MarshalBinary(); err != nil {
+			return nil, err
+		}
+
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], uint32(len(shareData)))
+
+		data = append(data, size[:]...)
+		data = append(data, shareData...)
+	}
+
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (share *MultiPCKSShare) UnmarshalBinary(data []byte) (err error) {
+
+	var ptr int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	data = data[ptr:]
+
+	if len(data) < 4 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+
+	if n > len(data)/4 {
+		return errors.New("cannot UnmarshalBinary: invalid number of shares")
+	}
+
+	share.Value = make([]*PCKSShare, n)
+
+	for i := range share.Value {
+
+		if len(data) < 4 {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
+		size := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+
+		if size == 0 || size > len(data) {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
+		// The PCKS shares of the recipients are generated with the parameters of the MultiPCKSShare
+		share.Value[i] = &PCKSShare{Header: ShareHeader{Version: share.Header.Version, Protocol: ProtocolPCKS, Params: share.Header.Params}}
+		if err = share.Value[i].UnmarshalBinary(data[:size]); err != nil {
+			return err
+		}
+
+		data = data[size:]
+	}
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package drlwe
 
 import (
+	"fmt"
+	"math"
+	"time"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -16,12 +20,14 @@ type KeySwitchingProtocol interface {
 
 // CKSProtocol is the structure storing the parameters and and precomputations for the collective key-switching protocol.
 type CKSProtocol struct {
+	degreeReducer
 	params          rlwe.Parameters
 	sigmaSmudging   float64
 	gaussianSampler *ring.GaussianSampler
-	basisExtender   *ring.BasisExtender
-	tmpQP           rlwe.PolyQP
+	tmpQ            *ring.Poly
 	tmpDelta        *ring.Poly
+	metrics         protocolMetrics
+	header          ShareHeader
 }
 
 // ShallowCopy creates a shallow copy of CKSProtocol in which all the read-only data-structures are
@@ -36,54 +42,80 @@ func (cks *CKSProtocol) ShallowCopy() *CKSProtocol {
 	params := cks.params
 
 	return &CKSProtocol{
+		degreeReducer:   cks.degreeReducer.shallowCopy(),
 		params:          params,
+		sigmaSmudging:   cks.sigmaSmudging,
 		gaussianSampler: ring.NewGaussianSampler(prng, params.RingQ(), cks.sigmaSmudging, int(6*cks.sigmaSmudging)),
-		basisExtender:   cks.basisExtender.ShallowCopy(),
-		tmpQP:           params.RingQP().NewPoly(),
+		tmpQ:            params.RingQ().NewPoly(),
 		tmpDelta:        params.RingQ().NewPoly(),
+		metrics:         cks.metrics,
+		header:          cks.header,
 	}
 }
 
 // CKSShare is a type for the CKS protocol shares.
 type CKSShare struct {
-	Value *ring.Poly
+	Header ShareHeader
+	Value  *ring.Poly
 }
 
 // CKSCRP is a type for common reference polynomials in the CKS protocol.
 type CKSCRP ring.Poly
 
+// GetDataLen returns the length in bytes of the CKS share.
+func (ckss *CKSShare) GetDataLen(WithMetadata bool) int {
+	return ShareHeaderLen + ckss.Value.GetDataLen(WithMetadata)
+}
+
 // MarshalBinary encodes a CKS share on a slice of bytes.
 func (ckss *CKSShare) MarshalBinary() (data []byte, err error) {
-	return ckss.Value.MarshalBinary()
+	data = make([]byte, ckss.GetDataLen(true))
+	var ptr int
+	if ptr, err = ckss.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	if _, err = ckss.Value.WriteTo(data[ptr:]); err != nil {
+		return nil, err
+	}
+	return
 }
 
 // UnmarshalBinary decodes marshaled CKS share on the target CKS share.
 func (ckss *CKSShare) UnmarshalBinary(data []byte) (err error) {
+	var ptr int
+	if ptr, err = ckss.Header.Decode(data); err != nil {
+		return err
+	}
 	ckss.Value = new(ring.Poly)
-	return ckss.Value.UnmarshalBinary(data)
+	return ckss.Value.UnmarshalBinary(data[ptr:])
 }
 
 // NewCKSProtocol creates a new CKSProtocol that will be used to perform a collective key-switching on a ciphertext encrypted under a collective public-key, whose
 // secret-shares are distributed among j parties, re-encrypting the ciphertext under another public-key, whose secret-shares are also known to the
-// parties.
-func NewCKSProtocol(params rlwe.Parameters, sigmaSmudging float64) *CKSProtocol {
+// parties. sigmaSmudging is the standard deviation of the smudging noise added by each party to its share, e.g. computed
+// with SmudgingParams. The randomness of the shares can be seeded with the WithSeed option, and the operations of the
+// protocol recorded with the WithMetrics option.
+func NewCKSProtocol(params rlwe.Parameters, sigmaSmudging float64, options ...ProtocolOption) *CKSProtocol {
+	if maxBound := maxSmudgingBound(params); math.Ceil(6*sigmaSmudging) > maxBound {
+		panic(fmt.Sprintf("cannot NewCKSProtocol: the smudging bound 6 * sigmaSmudging = 2^%.2f is larger than the largest bound 2^%.2f of the share proofs", math.Log2(6*sigmaSmudging), math.Log2(maxBound)))
+	}
 	cks := new(CKSProtocol)
 	cks.params = params
 	cks.sigmaSmudging = sigmaSmudging
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
+	cks.header = NewShareHeader(ProtocolCKS, params)
+	opts := newProtocolOptions(params, options)
+	cks.metrics = protocolMetrics{opts.metrics, "drlwe.CKSProtocol"}
+	prng := newPRNG(opts.seed, "CKS", 0)
 	cks.gaussianSampler = ring.NewGaussianSampler(prng, params.RingQ(), sigmaSmudging, int(6*sigmaSmudging))
-	cks.basisExtender = ring.NewBasisExtender(params.RingQ(), params.RingP())
-	cks.tmpQP = params.RingQP().NewPoly()
+	cks.tmpQ = params.RingQ().NewPoly()
 	cks.tmpDelta = params.RingQ().NewPoly()
+	cks.degreeReducer = newDegreeReducer(params)
 	return cks
 }
 
 // AllocateShare allocates the shares of the CKSProtocol
 func (cks *CKSProtocol) AllocateShare(level int) *CKSShare {
-	return &CKSShare{cks.params.RingQ().NewPolyLvl(level)}
+	return &CKSShare{Header: cks.header, Value: cks.params.RingQ().NewPolyLvl(level)}
 }
 
 // SampleCRP samples a common random polynomial to be used in the CKS protocol from the provided
@@ -94,65 +126,41 @@ func (cks *CKSProtocol) SampleCRP(level int, crs CRS) CKSCRP {
 	return CKSCRP(*crp)
 }
 
-// GenShare computes a party's share in the CKS protocol.
-// ct1 is the degree 1 element of the rlwe.Ciphertext to keyswitch, i.e. ct1 = rlwe.Ciphertext.Value[1].
+// GenShare computes a party's share in the CKS protocol, i.e. c1 * (skInput - skOutput) + e, where e is the smudging
+// noise.
+// ct1 is the degree 1 element of the rlwe.Ciphertext to keyswitch, i.e. ct1 = rlwe.Ciphertext.Value[1]. A ciphertext of
+// degree larger than 1 must first be reduced to degree 1 with GenDegreeReductionShare and ReduceDegree.
 // NTT flag for ct1 is expected to be set correctly.
 func (cks *CKSProtocol) GenShare(skInput, skOutput *rlwe.SecretKey, c1 *ring.Poly, shareOut *CKSShare) {
+	defer cks.metrics.operation("GenShare", time.Now())
+	defer cks.metrics.shareSize("CKSShare", shareOut)
 
 	ringQ := cks.params.RingQ()
-	ringP := cks.params.RingP()
-	ringQP := cks.params.RingQP()
 
 	levelQ := utils.MinInt(shareOut.Value.Level(), c1.Level())
-	levelP := cks.params.PCount() - 1
 
 	ringQ.SubLvl(levelQ, skInput.Value.Q, skOutput.Value.Q, cks.tmpDelta)
 
 	ct1 := c1
 	if !c1.IsNTT {
-		ringQ.NTTLazyLvl(levelQ, c1, cks.tmpQP.Q)
-		ct1 = cks.tmpQP.Q
+		ringQ.NTTLazyLvl(levelQ, c1, cks.tmpQ)
+		ct1 = cks.tmpQ
 	}
 
 	// a * (skIn - skOut) mod Q
-	ringQ.MulCoeffsMontgomeryConstantLvl(levelQ, ct1, cks.tmpDelta, shareOut.Value)
-
-	// P * a * (skIn - skOut) mod QP (mod P = 0)
-	ringQ.MulScalarBigintLvl(levelQ, shareOut.Value, ringP.ModulusBigint, shareOut.Value)
+	ringQ.MulCoeffsMontgomeryLvl(levelQ, ct1, cks.tmpDelta, shareOut.Value)
 
 	if !c1.IsNTT {
-		// InvNTT(P * a * (skIn - skOut)) mod QP (mod P = 0)
-		ringQ.InvNTTLazyLvl(levelQ, shareOut.Value, shareOut.Value)
-
-		// Samples e in Q
-		cks.gaussianSampler.ReadLvl(levelQ, cks.tmpQP.Q)
-
-		// Extend e to P (assumed to have norm < qi)
-		ringQP.ExtendBasisSmallNormAndCenter(cks.tmpQP.Q, levelP, nil, cks.tmpQP.P)
-
-		// InvNTT(P * a * (skIn - skOut) + e) mod QP (mod P = e)
-		ringQ.AddNoModLvl(levelQ, shareOut.Value, cks.tmpQP.Q, shareOut.Value)
-
-		// InvNTT(P * a * (skIn - skOut) + e) * (1/P) mod QP (mod P = e)
-		cks.basisExtender.ModDownQPtoQ(levelQ, levelP, shareOut.Value, cks.tmpQP.P, shareOut.Value)
-
-	} else {
-		// Sample e in Q
-		cks.gaussianSampler.ReadLvl(levelQ, cks.tmpQP.Q)
-
-		// Extend e to P (assumed to have norm < qi)
-		ringQP.ExtendBasisSmallNormAndCenter(cks.tmpQP.Q, levelP, nil, cks.tmpQP.P)
-
-		// Takes the error to the NTT domain
+		// a * (skIn - skOut) + e mod Q
 		ringQ.InvNTTLvl(levelQ, shareOut.Value, shareOut.Value)
+		cks.gaussianSampler.ReadAndAddLvl(levelQ, shareOut.Value)
+	} else {
+		// Samples e in Q and takes it to the NTT domain
+		cks.gaussianSampler.ReadLvl(levelQ, cks.tmpQ)
+		ringQ.NTTLvl(levelQ, cks.tmpQ, cks.tmpQ)
 
-		// P * a * (skIn - skOut) + e mod Q (mod P = 0, so P = e)
-		ringQ.AddLvl(levelQ, shareOut.Value, cks.tmpQP.Q, shareOut.Value)
-
-		// (P * a * (skIn - skOut) + e) * (1/P) mod QP (mod P = e)
-		cks.basisExtender.ModDownQPtoQ(levelQ, levelP, shareOut.Value, cks.tmpQP.P, shareOut.Value)
-
-		ringQ.NTTLvl(levelQ, shareOut.Value, shareOut.Value)
+		// a * (skIn - skOut) + e mod Q
+		ringQ.AddLvl(levelQ, shareOut.Value, cks.tmpQ, shareOut.Value)
 	}
 
 	shareOut.Value.Coeffs = shareOut.Value.Coeffs[:levelQ+1]
@@ -162,11 +170,13 @@ func (cks *CKSProtocol) GenShare(skInput, skOutput *rlwe.SecretKey, c1 *ring.Pol
 //
 // [ctx[0] + sum((skInput_i - skOutput_i) * ctx[0] + e_i), ctx[1]]
 func (cks *CKSProtocol) AggregateShare(share1, share2, shareOut *CKSShare) {
+	defer cks.metrics.operation("AggregateShare", time.Now())
 	cks.params.RingQ().AddLvl(share1.Value.Level(), share1.Value, share2.Value, shareOut.Value)
 }
 
-// KeySwitch performs the actual keyswitching operation on a ciphertext ct and put the result in ctOut
+// KeySwitch performs the actual keyswitching operation on a ciphertext ct of degree 1 and put the result in ctOut
 func (cks *CKSProtocol) KeySwitch(ctIn *rlwe.Ciphertext, combined *CKSShare, ctOut *rlwe.Ciphertext) {
+	defer cks.metrics.operation("KeySwitch", time.Now())
 	level := utils.MinInt(ctIn.Level(), ctOut.Level())
 	cks.params.RingQ().AddLvl(level, ctIn.Value[0], combined.Value, ctOut.Value[0])
 	ring.CopyValuesLvl(level, ctIn.Value[1], ctOut.Value[1])
@@ -0,0 +1,309 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+	"golang.org/x/crypto/blake2b"
+)
+
+// The maliciously secure variants of the protocols harden them against active adversaries with
+//  - committed CRS contributions: the CRS is generated from the contributions of all the parties, which commit to
+//    them before revealing them, so that it is uniform as long as one party is honest (see NewCommittedCRS),
+//  - share consistency checks: the parties echo the digests of the shares they received, so that a party sending
+//    different shares to different parties is detected (see ShareTranscript and CheckTranscripts),
+//  - share commitments: the parties broadcast commitments to their shares before the shares, which are checked
+//    against them before they are aggregated (see ShareCommitments and the SetCommitments methods of the aggregators),
+//  - verified aggregations: the shares are aggregated only if their ShareProofs are valid (see the
+//    AggregateVerifiedShares methods of CKGProtocol and CKSProtocol),
+//  - proofs of possession: the CKG shares are aggregated only if the parties prove the possession of their secret key
+//    with a proof bound to their identity, so that no party can choose its share as a function of the shares of the
+//    other parties (see the AggregatePossessedShares method of CKGProtocol),
+// and identify the misbehaving parties with an IdentifiedAbortError, so that the protocol can be restarted without
+// them. The collective decryption is the CKS protocol with a zero output key, whose shares are bound to the CKG shares
+// and can be verified by any observer (see PartialDecryption and VerifyDecryption).
+// The parties that contributed a malformed share to an output that fails its validation are identified after the
+// fact with the Identify and Bisect methods of the ShareAggregator.
+
+// IdentifiedAbortError is the error returned by the maliciously secure variants of the protocols when they must be
+// aborted because of the misbehavior of some parties, which it identifies.
+type IdentifiedAbortError struct {
+	Parties []ShamirPublicPoint
+	Reason  string
+}
+
+func (e *IdentifiedAbortError) Error() string {
+	return fmt.Sprintf("abort: %s by the parties %v", e.Reason, e.Parties)
+}
+
+// CRSCommitment is the commitment of a party to its CRSContribution.
+type CRSCommitment [blake2b.Size256]byte
+
+// CRSContribution is the contribution of a party to a CRS generated with NewCommittedCRS.
+type CRSContribution struct {
+	Seed []byte
+}
+
+// NewCRSContribution samples a new random CRSContribution.
+func NewCRSContribution() *CRSContribution {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	c := &CRSContribution{Seed: make([]byte, 64)}
+	prng.Clock(c.Seed)
+	return c
+}
+
+// Commit returns the commitment of the party to the contribution, which must be sent to all the other parties before
+// the contribution is revealed.
+func (c *CRSContribution) Commit(party ShamirPublicPoint) (cmt CRSCommitment) {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	h.Write([]byte("CRS"))
+	writePublicPoint(h, party)
+	h.Write(c.Seed)
+	copy(cmt[:], h.Sum(nil))
+	return
+}
+
+// NewCommittedCRS returns the CRS generated from the revealed contributions of all the parties, whose commitments were
+// received beforehand. It returns an IdentifiedAbortError identifying the parties whose contribution is missing or does
+// not match their commitment.
+func NewCommittedCRS(commitments map[ShamirPublicPoint]CRSCommitment, contributions map[ShamirPublicPoint]*CRSContribution) (CRS, error) {
+
+	cheaters := []ShamirPublicPoint{}
+	for p, cmt := range commitments {
+		if c, ok := contributions[p]; !ok || c.Commit(p) != cmt {
+			cheaters = append(cheaters, p)
+		}
+	}
+
+	for p := range contributions {
+		if _, ok := commitments[p]; !ok {
+			cheaters = append(cheaters, p)
+		}
+	}
+
+	if len(cheaters) != 0 {
+		return nil, &IdentifiedAbortError{Parties: sortPoints(cheaters), Reason: "invalid CRS contribution"}
+	}
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	parties := []ShamirPublicPoint{}
+	for p := range commitments {
+		parties = append(parties, p)
+	}
+
+	for _, p := range sortPoints(parties) {
+		writePublicPoint(h, p)
+		h.Write(contributions[p].Seed)
+	}
+
+	prng, err := utils.NewKeyedPRNG(h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+
+	return prng, nil
+}
+
+// ShareDigest is the digest of a share.
+type ShareDigest [blake2b.Size256]byte
+
+// ShareTranscript records the digests of the shares received by a party in a round. Once all the shares are received,
+// the parties echo their Digests to each other and check them with CheckTranscripts before aggregating the shares.
+type ShareTranscript struct {
+	digests map[ShamirPublicPoint]ShareDigest
+}
+
+// NewShareTranscript creates a new empty ShareTranscript.
+func NewShareTranscript() *ShareTranscript {
+	return &ShareTranscript{digests: map[ShamirPublicPoint]ShareDigest{}}
+}
+
+// Add records the share received from the party. It returns an error if a share of the party was already recorded or
+// if the share cannot be marshaled.
+func (tr *ShareTranscript) Add(party ShamirPublicPoint, share Share) error {
+
+	if _, ok := tr.digests[party]; ok {
+		return fmt.Errorf("cannot Add: a share of the party %d was already recorded", party)
+	}
+
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("cannot Add: %w", err)
+	}
+
+	tr.digests[party] = blake2b.Sum256(data)
+
+	return nil
+}
+
+// Digests returns the digests of the recorded shares.
+func (tr *ShareTranscript) Digests() map[ShamirPublicPoint]ShareDigest {
+	digests := make(map[ShamirPublicPoint]ShareDigest, len(tr.digests))
+	for p, d := range tr.digests {
+		digests[p] = d
+	}
+	return digests
+}
+
+// CheckTranscripts checks the digests echoed by the parties, echoes[receiver][sender] being the digest of the share
+// of sender received by receiver. It returns an IdentifiedAbortError identifying the senders whose share is not the
+// same for all the receivers, e.g. because they sent different shares to different parties. Without authenticated
+// shares, such an equivocation cannot be distinguished from a false echo of a receiver, hence the honest parties must
+// then exclude both the identified senders and the receivers whose echoes differ from the majority.
+func CheckTranscripts(echoes map[ShamirPublicPoint]map[ShamirPublicPoint]ShareDigest) error {
+
+	senders := map[ShamirPublicPoint]bool{}
+	for _, digests := range echoes {
+		for sender := range digests {
+			senders[sender] = true
+		}
+	}
+
+	cheaters := []ShamirPublicPoint{}
+	for sender := range senders {
+
+		var ref *ShareDigest
+		for receiver, digests := range echoes {
+
+			if receiver == sender {
+				continue
+			}
+
+			d, ok := digests[sender]
+			if !ok || (ref != nil && *ref != d) {
+				cheaters = append(cheaters, sender)
+				break
+			}
+
+			ref = &d
+		}
+	}
+
+	if len(cheaters) != 0 {
+		return &IdentifiedAbortError{Parties: sortPoints(cheaters), Reason: "inconsistent shares"}
+	}
+
+	return nil
+}
+
+// AggregateVerifiedShares verifies the ShareProofs of the CKG shares of the parties for the common reference polynomial
+// crp and aggregates the shares in shareOut, which must be a zero share. It returns an IdentifiedAbortError identifying
+// the parties whose proof is missing or invalid, in which case shareOut is not modified.
+func (ckg *CKGProtocol) AggregateVerifiedShares(crp CKGCRP, shares map[ShamirPublicPoint]*CKGShare, proofs map[ShamirPublicPoint]*ShareProof, shareOut *CKGShare) error {
+
+	cheaters := []ShamirPublicPoint{}
+	parties := []ShamirPublicPoint{}
+	for p := range shares {
+		parties = append(parties, p)
+	}
+	parties = sortPoints(parties)
+
+	for _, p := range parties {
+		if proof, ok := proofs[p]; !ok || ckg.VerifyShare(crp, shares[p], proof) != nil {
+			cheaters = append(cheaters, p)
+		}
+	}
+
+	if len(cheaters) != 0 {
+		return &IdentifiedAbortError{Parties: cheaters, Reason: "invalid CKG share"}
+	}
+
+	for _, p := range parties {
+		ckg.AggregateShare(shareOut, shares[p], shareOut)
+	}
+
+	return nil
+}
+
+// AggregatePossessedShares verifies the proofs of possession of the CKG shares of the parties (see GenPossessionProof)
+// for the common reference polynomial crp and aggregates the shares in shareOut, which must be a zero share. It returns
+// an IdentifiedAbortError identifying the parties whose proof is missing or invalid, in which case shareOut is not
+// modified.
+func (ckg *CKGProtocol) AggregatePossessedShares(crp CKGCRP, shares map[ShamirPublicPoint]*CKGShare, proofs map[ShamirPublicPoint]*ShareProof, shareOut *CKGShare) error {
+
+	cheaters := []ShamirPublicPoint{}
+	parties := []ShamirPublicPoint{}
+	for p := range shares {
+		parties = append(parties, p)
+	}
+	parties = sortPoints(parties)
+
+	for _, p := range parties {
+		if proof, ok := proofs[p]; !ok || ckg.VerifyPossession(p, crp, shares[p], proof) != nil {
+			cheaters = append(cheaters, p)
+		}
+	}
+
+	if len(cheaters) != 0 {
+		return &IdentifiedAbortError{Parties: cheaters, Reason: "unproven possession of the CKG share"}
+	}
+
+	for _, p := range parties {
+		ckg.AggregateShare(shareOut, shares[p], shareOut)
+	}
+
+	return nil
+}
+
+// AggregateVerifiedShares verifies the ShareProofs of the CKS shares of the parties for the ciphertext element c1 and
+// the commitments of the parties to their input and output secret keys, and aggregates the shares in shareOut, which
+// must be a zero share. The commitments to the output secret keys are nil for a collective decryption. It returns an
+// IdentifiedAbortError identifying the parties whose proof or commitment is missing or invalid, in which case shareOut
+// is not modified.
+func (cks *CKSProtocol) AggregateVerifiedShares(c1 *ring.Poly, shares map[ShamirPublicPoint]*CKSShare, proofs map[ShamirPublicPoint]*ShareProof, cmtInput, cmtOutput map[ShamirPublicPoint]*SecretKeyCommitment, shareOut *CKSShare) error {
+
+	cheaters := []ShamirPublicPoint{}
+	parties := []ShamirPublicPoint{}
+	for p := range shares {
+		parties = append(parties, p)
+	}
+	parties = sortPoints(parties)
+
+	for _, p := range parties {
+
+		proof, okProof := proofs[p]
+		cmtIn, okIn := cmtInput[p]
+		cmtOut, okOut := cmtOutput[p]
+
+		if !okProof || !okIn || (cmtOutput != nil && !okOut) || cks.VerifyShare(c1, shares[p], cmtIn, cmtOut, proof) != nil {
+			cheaters = append(cheaters, p)
+		}
+	}
+
+	if len(cheaters) != 0 {
+		return &IdentifiedAbortError{Parties: cheaters, Reason: "invalid CKS share"}
+	}
+
+	for _, p := range parties {
+		cks.AggregateShare(shareOut, shares[p], shareOut)
+	}
+
+	return nil
+}
+
+func writePublicPoint(h interface{ Write(p []byte) (int, error) }, p ShamirPublicPoint) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(p))
+	h.Write(buf[:])
+}
+
+// sortPoints returns the distinct public points in increasing order.
+func sortPoints(points []ShamirPublicPoint) []ShamirPublicPoint {
+	set := map[ShamirPublicPoint]bool{}
+	for _, p := range points {
+		set[p] = true
+	}
+	return sortedPoints(set, func(p ShamirPublicPoint) bool { return true })
+}
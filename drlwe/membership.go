@@ -0,0 +1,120 @@
+package drlwe
+
+import (
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// MembershipProtocol updates the collective keys of an N-out-of-N sharing of the collective secret key when a party
+// joins or leaves the set of parties, without restarting the key generation ceremony:
+//   - when a party joins, its secret key share s_j is added to the collective secret key s. Since the collective public
+//     key and the rotation keys are linear in s for a fixed common reference polynomial, they are updated by adding the
+//     share of the new party, generated for the common reference polynomials of the existing keys (see
+//     UpdatePublicKey and UpdateRotationKey), and the ciphertexts encrypted under s are switched to s + s_j with a
+//     single CKS share of the new party (see GenJoinCiphertextShare). The relinearization key, which is quadratic in
+//     s, must be regenerated by all the parties with the RKGProtocol.
+//   - when a party leaves, it splits its secret key share into KeyRefreshShares for the remaining parties, which add
+//     them to their own shares with ResharingProtocol.RefreshSecretKey (see GenLeaveShares). The collective secret key
+//     does not change, hence the collective keys and the ciphertexts remain valid. Since the leaving party knows the
+//     shares it sent, the remaining parties should refresh their shares with the ResharingProtocol afterward.
+//
+// The KeyRefreshShares must be sent over confidential channels.
+type MembershipProtocol struct {
+	params rlwe.Parameters
+	ckg    *CKGProtocol
+	rtg    *RTGProtocol
+	cks    *CKSProtocol
+	rsp    *ResharingProtocol
+	zero   *rlwe.SecretKey
+}
+
+// NewMembershipProtocol creates a new MembershipProtocol. sigmaSmudging is the standard deviation of the smudging noise
+// of the shares generated by GenJoinCiphertextShare, e.g. computed with SmudgingParams, and the options are given to the
+// RTG protocol.
+func NewMembershipProtocol(params rlwe.Parameters, sigmaSmudging float64, options ...ProtocolOption) *MembershipProtocol {
+	return &MembershipProtocol{
+		params: params,
+		ckg:    NewCKGProtocol(params),
+		rtg:    NewRTGProtocol(params, options...),
+		cks:    NewCKSProtocol(params, sigmaSmudging),
+		rsp:    NewResharingProtocol(params),
+		zero:   rlwe.NewSecretKey(params),
+	}
+}
+
+// ShallowCopy creates a shallow copy of MembershipProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MembershipProtocol can be used concurrently.
+func (mp *MembershipProtocol) ShallowCopy() *MembershipProtocol {
+	return &MembershipProtocol{
+		params: mp.params,
+		ckg:    mp.ckg.ShallowCopy(),
+		rtg:    mp.rtg.ShallowCopy(),
+		cks:    mp.cks.ShallowCopy(),
+		rsp:    mp.rsp.ShallowCopy(),
+		zero:   mp.zero,
+	}
+}
+
+// GenJoinPublicKeyShare generates the CKG share of a joining party of secret key share sk for the common reference
+// polynomial of the collective public key pk.
+func (mp *MembershipProtocol) GenJoinPublicKeyShare(sk *rlwe.SecretKey, pk *rlwe.PublicKey, shareOut *CKGShare) {
+	mp.ckg.GenShare(sk, CKGCRP(pk.Value[1]), shareOut)
+}
+
+// UpdatePublicKey adds the CKG share of a joining party, generated with GenJoinPublicKeyShare, to the collective public
+// key pk and returns the result in pkOut, which is the collective public key of the extended set of parties.
+func (mp *MembershipProtocol) UpdatePublicKey(pk *rlwe.PublicKey, share *CKGShare, pkOut *rlwe.PublicKey) {
+	mp.params.RingQP().AddLvl(mp.params.QCount()-1, mp.params.PCount()-1, pk.Value[0], share.Value, pkOut.Value[0])
+	pkOut.Value[1].Copy(pk.Value[1])
+}
+
+// GenJoinRotationKeyShare generates the RTG share of a joining party of secret key share sk for the Galois element
+// galEl and the common reference polynomials of the collective rotation key rotKey.
+func (mp *MembershipProtocol) GenJoinRotationKeyShare(sk *rlwe.SecretKey, galEl uint64, rotKey *rlwe.SwitchingKey, shareOut *RTGShare) {
+	mp.rtg.GenShare(sk, galEl, rotationKeyCRP(rotKey), shareOut)
+}
+
+// UpdateRotationKey adds the RTG share of a joining party, generated with GenJoinRotationKeyShare, to the collective
+// rotation key rotKey and returns the result in rotKeyOut, which is the collective rotation key of the extended set of
+// parties.
+func (mp *MembershipProtocol) UpdateRotationKey(rotKey *rlwe.SwitchingKey, share *RTGShare, rotKeyOut *rlwe.SwitchingKey) {
+	ringQP, levelQ, levelP := mp.params.RingQP(), mp.params.QCount()-1, mp.params.PCount()-1
+	for i := range rotKey.Value {
+		ringQP.AddLvl(levelQ, levelP, rotKey.Value[i][0], share.Value[i], rotKeyOut.Value[i][0])
+		rotKeyOut.Value[i][1].CopyValues(rotKey.Value[i][1])
+	}
+}
+
+// GenJoinCiphertextShare generates the share of a joining party of secret key share sk in the key-switching of a
+// ciphertext of degree 1 element c1 from the collective secret key s to s + sk, i.e. its share in the CKS protocol from
+// the zero secret key to sk. The share is the aggregated share of the key-switching: the ciphertext is switched with
+// CKSProtocol.KeySwitch without a share of the other parties.
+func (mp *MembershipProtocol) GenJoinCiphertextShare(sk *rlwe.SecretKey, c1 *ring.Poly, shareOut *CKSShare) {
+	mp.cks.GenShare(mp.zero, sk, c1, shareOut)
+}
+
+// GenLeaveShares generates the KeyRefreshShares of a leaving party of secret key share sk for the remaining parties,
+// i.e. uniformly random polynomials summing to sk, sharesOut[i] being sent to the i-th remaining party, which adds it to
+// its secret key share with ResharingProtocol.RefreshSecretKey. The secret key shares of the remaining parties then sum
+// to the same collective secret key.
+func (mp *MembershipProtocol) GenLeaveShares(sk *rlwe.SecretKey, sharesOut []*KeyRefreshShare) {
+
+	if len(sharesOut) == 0 {
+		return
+	}
+
+	mp.rsp.GenKeyRefreshShares(sharesOut)
+
+	last := sharesOut[len(sharesOut)-1]
+	mp.params.RingQP().AddLvl(mp.params.QCount()-1, mp.params.PCount()-1, last.Value, sk.Value, last.Value)
+}
+
+// rotationKeyCRP returns the common reference polynomials of the collective rotation key rotKey.
+func rotationKeyCRP(rotKey *rlwe.SwitchingKey) RTGCRP {
+	crp := make(RTGCRP, len(rotKey.Value))
+	for i := range crp {
+		crp[i] = rotKey.Value[i][1]
+	}
+	return crp
+}
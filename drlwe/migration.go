@@ -0,0 +1,59 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// MigrationShare is the share of a party in the migration of a ciphertext from a parameter set and collective secret
+// key to another, e.g. the dbfv.MigrationProtocol and the dckks.MigrationProtocol. DecryptionShare is the masked
+// decryption share of the input ciphertext under the input parameters, and EncryptionShare is the encryption share of
+// the same mask under the output parameters.
+type MigrationShare struct {
+	Header          ShareHeader
+	DecryptionShare CKSShare
+	EncryptionShare CKSShare
+}
+
+// MarshalBinary encodes a MigrationShare on a slice of bytes.
+func (share *MigrationShare) MarshalBinary() (data []byte, err error) {
+	var decData, encData []byte
+	if decData, err = share.DecryptionShare.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	if encData, err = share.EncryptionShare.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	data = make([]byte, ShareHeaderLen+8)
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint64(data[ShareHeaderLen:], uint64(len(decData)))
+	data = append(data, decData...)
+	return append(data, encData...), nil
+}
+
+// UnmarshalBinary decodes a marshaled MigrationShare on the target MigrationShare.
+func (share *MigrationShare) UnmarshalBinary(data []byte) error {
+
+	ptr, err := share.Header.Decode(data)
+	if err != nil {
+		return err
+	}
+	data = data[ptr:]
+
+	if len(data) < 8 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	decDataLen := binary.LittleEndian.Uint64(data[:8])
+
+	if decDataLen > uint64(len(data)-8) {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	if err := share.DecryptionShare.UnmarshalBinary(data[8 : decDataLen+8]); err != nil {
+		return err
+	}
+	return share.EncryptionShare.UnmarshalBinary(data[8+decDataLen:])
+}
@@ -0,0 +1,496 @@
+package drlwe
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// The multi-key variants of the protocols let the parties evaluate circuits on ciphertexts encrypted under their
+// individual keys, without a prior collective key generation. A multi-key ciphertext (see MKCiphertext) involving k
+// parties has k+1 components (c_0, c_1, ..., c_k) and decrypts as c_0 + c_1*s_1 + ... + c_k*s_k. Two multi-key
+// ciphertexts are combined on the fly, by extending them to the union of their parties, and the product of two
+// multi-key ciphertexts is relinearized with the public MKEvaluationKeys of the parties, which each party generates
+// locally from the common reference polynomials (see MKKeyGenerator). This is the scheme of Chen, Dai, Kim and Song,
+// "Efficient Multi-Key Homomorphic Encryption with Packed Ciphertexts with Application to Oblivious Neural Network
+// Inference" (https://eprint.iacr.org/2019/524), with the RNS gadget decomposition of the key-switching of rlwe.
+// The multi-key ciphertexts are decrypted with the MKDecryptionProtocol.
+
+// MKCRP is a type for the common reference polynomials of the multi-key evaluation keys.
+type MKCRP []rlwe.PolyQP
+
+// MKEvaluationKey is the public evaluation key of a party in the multi-key setting, generated from its secret key s and
+// an ephemeral secret r. Each field is a vector of Beta polynomials, w being the gadget vector of the RNS decomposition:
+//   - B  = -s*a + e (a being the common reference polynomials)
+//   - D0 = -s*d1 + r*P*w + e
+//   - D1 = d1 (sampled uniformly by the party)
+//   - D2 = r*a + s*P*w + e
+//
+// The polynomials are stored in the NTT and Montgomery domains.
+type MKEvaluationKey struct {
+	B  []rlwe.PolyQP
+	D0 []rlwe.PolyQP
+	D1 []rlwe.PolyQP
+	D2 []rlwe.PolyQP
+}
+
+// NewMKEvaluationKey allocates a new MKEvaluationKey.
+func NewMKEvaluationKey(params rlwe.Parameters) *MKEvaluationKey {
+	ringQP := params.RingQP()
+	evk := &MKEvaluationKey{
+		B:  make([]rlwe.PolyQP, params.Beta()),
+		D0: make([]rlwe.PolyQP, params.Beta()),
+		D1: make([]rlwe.PolyQP, params.Beta()),
+		D2: make([]rlwe.PolyQP, params.Beta()),
+	}
+	for i := 0; i < params.Beta(); i++ {
+		evk.B[i] = ringQP.NewPoly()
+		evk.D0[i] = ringQP.NewPoly()
+		evk.D1[i] = ringQP.NewPoly()
+		evk.D2[i] = ringQP.NewPoly()
+	}
+	return evk
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (evk *MKEvaluationKey) MarshalBinary() (data []byte, err error) {
+
+	if len(evk.B) > 0xFF {
+		return nil, errors.New("cannot MarshalBinary: uint8 overflow on length")
+	}
+
+	if len(evk.D0) != len(evk.B) || len(evk.D1) != len(evk.B) || len(evk.D2) != len(evk.B) {
+		return nil, errors.New("cannot MarshalBinary: inconsistent lengths")
+	}
+
+	dataLen := 1
+	for i := range evk.B {
+		dataLen += evk.B[i].GetDataLen(true) + evk.D0[i].GetDataLen(true) + evk.D1[i].GetDataLen(true) + evk.D2[i].GetDataLen(true)
+	}
+
+	data = make([]byte, dataLen)
+	data[0] = uint8(len(evk.B))
+
+	ptr := 1
+	var inc int
+	for i := range evk.B {
+		for _, p := range []rlwe.PolyQP{evk.B[i], evk.D0[i], evk.D1[i], evk.D2[i]} {
+			if inc, err = p.WriteTo(data[ptr:]); err != nil {
+				return nil, err
+			}
+			ptr += inc
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (evk *MKEvaluationKey) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 1 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	beta := int(data[0])
+	evk.B = make([]rlwe.PolyQP, beta)
+	evk.D0 = make([]rlwe.PolyQP, beta)
+	evk.D1 = make([]rlwe.PolyQP, beta)
+	evk.D2 = make([]rlwe.PolyQP, beta)
+
+	ptr := 1
+	var inc int
+	for i := 0; i < beta; i++ {
+		for _, p := range []*rlwe.PolyQP{&evk.B[i], &evk.D0[i], &evk.D1[i], &evk.D2[i]} {
+			if ptr+2 > len(data) {
+				return errors.New("cannot UnmarshalBinary: data is too short")
+			}
+			if inc, err = p.DecodePolyNew(data[ptr:]); err != nil {
+				return err
+			}
+			ptr += inc
+		}
+	}
+
+	return nil
+}
+
+// MKKeyGenerator is the structure storing the parameters and precomputations for the generation of the multi-key
+// evaluation keys. The generation is local: it only requires the common reference polynomials.
+type MKKeyGenerator struct {
+	params           rlwe.Parameters
+	ephSkPr          float64
+	pBigInt          *big.Int
+	gaussianSamplerQ *ring.GaussianSampler
+	ternarySamplerQ  *ring.TernarySampler
+	uniformSamplerQP rlwe.UniformSamplerQP
+
+	ephSk   rlwe.PolyQP
+	tmpSkP  *ring.Poly
+	tmpEphP *ring.Poly
+}
+
+// NewMKKeyGenerator creates a new MKKeyGenerator.
+func NewMKKeyGenerator(params rlwe.Parameters) *MKKeyGenerator {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	mkg := &MKKeyGenerator{
+		params:  params,
+		ephSkPr: 0.5,
+		pBigInt: params.PBigInt(),
+	}
+
+	mkg.gaussianSamplerQ = ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma()))
+	mkg.ternarySamplerQ = ring.NewTernarySampler(prng, params.RingQ(), mkg.ephSkPr, false)
+	mkg.uniformSamplerQP = rlwe.NewUniformSamplerQP(params, prng, params.RingQP())
+	mkg.ephSk = params.RingQP().NewPoly()
+	mkg.tmpSkP = params.RingQ().NewPoly()
+	mkg.tmpEphP = params.RingQ().NewPoly()
+	return mkg
+}
+
+// ShallowCopy creates a shallow copy of MKKeyGenerator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MKKeyGenerator can be used concurrently.
+func (mkg *MKKeyGenerator) ShallowCopy() *MKKeyGenerator {
+	return NewMKKeyGenerator(mkg.params)
+}
+
+// SampleCRP samples the common random polynomials of the multi-key evaluation keys from the provided common reference
+// string. All the parties must use the same polynomials.
+func (mkg *MKKeyGenerator) SampleCRP(crs CRS) MKCRP {
+	crp := make(MKCRP, mkg.params.Beta())
+	us := rlwe.NewUniformSamplerQP(mkg.params, crs, mkg.params.RingQP())
+	for i := range crp {
+		crp[i] = mkg.params.RingQP().NewPoly()
+		us.Read(&crp[i])
+	}
+	return crp
+}
+
+// GenEvaluationKey generates the MKEvaluationKey of the party of secret key sk for the common reference polynomials
+// crp and writes it in evkOut. The key is public and must be sent to the evaluator.
+func (mkg *MKKeyGenerator) GenEvaluationKey(sk *rlwe.SecretKey, crp MKCRP, evkOut *MKEvaluationKey) {
+
+	ringQ := mkg.params.RingQ()
+	ringQP := mkg.params.RingQP()
+	levelQ := mkg.params.QCount() - 1
+	levelP := mkg.params.PCount() - 1
+
+	// P*s, out of the Montgomery domain
+	ringQ.MulScalarBigint(sk.Value.Q, mkg.pBigInt, mkg.tmpSkP)
+	ringQ.InvMForm(mkg.tmpSkP, mkg.tmpSkP)
+
+	// Ephemeral secret r, in the Montgomery domain, and P*r out of the Montgomery domain
+	mkg.ternarySamplerQ.Read(mkg.ephSk.Q)
+	ringQP.ExtendBasisSmallNormAndCenter(mkg.ephSk.Q, levelP, nil, mkg.ephSk.P)
+	ringQP.NTTLvl(levelQ, levelP, mkg.ephSk, mkg.ephSk)
+	ringQP.MFormLvl(levelQ, levelP, mkg.ephSk, mkg.ephSk)
+	ringQ.MulScalarBigint(mkg.ephSk.Q, mkg.pBigInt, mkg.tmpEphP)
+	ringQ.InvMForm(mkg.tmpEphP, mkg.tmpEphP)
+
+	for i := 0; i < mkg.params.Beta(); i++ {
+
+		// B = -s*a + e
+		mkg.genError(evkOut.B[i])
+		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, sk.Value, crp[i], evkOut.B[i])
+
+		// D1 = d1
+		mkg.uniformSamplerQP.Read(&evkOut.D1[i])
+
+		// D0 = -s*d1 + r*P*w + e
+		mkg.genError(evkOut.D0[i])
+		mkg.addGadget(i, mkg.tmpEphP, evkOut.D0[i].Q)
+		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, sk.Value, evkOut.D1[i], evkOut.D0[i])
+
+		// D2 = r*a + s*P*w + e
+		mkg.genError(evkOut.D2[i])
+		mkg.addGadget(i, mkg.tmpSkP, evkOut.D2[i].Q)
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, mkg.ephSk, crp[i], evkOut.D2[i])
+
+		ringQP.MFormLvl(levelQ, levelP, evkOut.B[i], evkOut.B[i])
+		ringQP.MFormLvl(levelQ, levelP, evkOut.D0[i], evkOut.D0[i])
+		ringQP.MFormLvl(levelQ, levelP, evkOut.D1[i], evkOut.D1[i])
+		ringQP.MFormLvl(levelQ, levelP, evkOut.D2[i], evkOut.D2[i])
+	}
+}
+
+// genError samples an error polynomial in the NTT domain of QP.
+func (mkg *MKKeyGenerator) genError(pOut rlwe.PolyQP) {
+	levelQ, levelP := mkg.params.QCount()-1, mkg.params.PCount()-1
+	mkg.gaussianSamplerQ.Read(pOut.Q)
+	mkg.params.RingQP().ExtendBasisSmallNormAndCenter(pOut.Q, levelP, nil, pOut.P)
+	mkg.params.RingQP().NTTLvl(levelQ, levelP, pOut, pOut)
+}
+
+// addGadget adds x*w_i to pOut, i.e. x to the moduli of the i-th element of the RNS decomposition.
+func (mkg *MKKeyGenerator) addGadget(i int, x, pOut *ring.Poly) {
+	ringQ := mkg.params.RingQ()
+	for j := 0; j < mkg.params.PCount(); j++ {
+
+		index := i*mkg.params.PCount() + j
+
+		// Handles the case where nb pj does not divides nb qi
+		if index >= mkg.params.QCount() {
+			break
+		}
+
+		qi := ringQ.Modulus[index]
+		xi := x.Coeffs[index]
+		pi := pOut.Coeffs[index]
+
+		for w := 0; w < ringQ.N; w++ {
+			pi[w] = ring.CRed(pi[w]+xi[w], qi)
+		}
+	}
+}
+
+// MKCiphertext is a multi-key ciphertext. Value[0] is the component c_0 and Value[i+1] is the component of the
+// party Parties[i], the parties being distinct and sorted in increasing order.
+type MKCiphertext struct {
+	Parties []ShamirPublicPoint
+	*rlwe.Ciphertext
+}
+
+// NewMKCiphertext allocates a new MKCiphertext of the parties at the given level. The parties are sorted and
+// deduplicated.
+func NewMKCiphertext(params rlwe.Parameters, parties []ShamirPublicPoint, level int) *MKCiphertext {
+	parties = sortPoints(parties)
+	return &MKCiphertext{Parties: parties, Ciphertext: rlwe.NewCiphertext(params, len(parties), level)}
+}
+
+// NewMKCiphertextFromCiphertext returns the MKCiphertext of the party of the single-key ciphertext ct, which shares the
+// polynomials of ct.
+func NewMKCiphertextFromCiphertext(ct *rlwe.Ciphertext, party ShamirPublicPoint) *MKCiphertext {
+	if ct.Degree() != 1 {
+		panic("cannot NewMKCiphertextFromCiphertext: ct must be of degree 1")
+	}
+	return &MKCiphertext{Parties: []ShamirPublicPoint{party}, Ciphertext: ct}
+}
+
+// MKUnionParties returns the union of the parties of the MKCiphertexts, sorted in increasing order.
+func MKUnionParties(cts ...*MKCiphertext) []ShamirPublicPoint {
+	parties := []ShamirPublicPoint{}
+	for _, ct := range cts {
+		parties = append(parties, ct.Parties...)
+	}
+	return sortPoints(parties)
+}
+
+// Component returns the component of the party, or nil if the ciphertext does not involve the party.
+func (ct *MKCiphertext) Component(party ShamirPublicPoint) *ring.Poly {
+	i := sort.Search(len(ct.Parties), func(i int) bool { return ct.Parties[i] >= party })
+	if i < len(ct.Parties) && ct.Parties[i] == party {
+		return ct.Value[i+1]
+	}
+	return nil
+}
+
+// MKEvaluator is the structure storing the parameters and buffers for the party-independent operations on the
+// MKCiphertexts, i.e. the addition and the relinearization. The tensoring and the rescaling depend on the scheme and are
+// implemented by the mkbfv and mkckks packages.
+type MKEvaluator struct {
+	params rlwe.Parameters
+	ks     *rlwe.KeySwitcher
+	cPrime *ring.Poly
+	tmp0   *ring.Poly
+	tmp1   *ring.Poly
+}
+
+// NewMKEvaluator creates a new MKEvaluator.
+func NewMKEvaluator(params rlwe.Parameters) *MKEvaluator {
+	ringQ := params.RingQ()
+	return &MKEvaluator{
+		params: params,
+		ks:     rlwe.NewKeySwitcher(params),
+		cPrime: ringQ.NewPoly(),
+		tmp0:   ringQ.NewPoly(),
+		tmp1:   ringQ.NewPoly(),
+	}
+}
+
+// ShallowCopy creates a shallow copy of MKEvaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MKEvaluator can be used concurrently.
+func (mke *MKEvaluator) ShallowCopy() *MKEvaluator {
+	ringQ := mke.params.RingQ()
+	return &MKEvaluator{
+		params: mke.params,
+		ks:     mke.ks.ShallowCopy(),
+		cPrime: ringQ.NewPoly(),
+		tmp0:   ringQ.NewPoly(),
+		tmp1:   ringQ.NewPoly(),
+	}
+}
+
+// Add adds ct0 to ct1 and writes the result in ctOut, which must involve the union of the parties of ct0 and ct1, e.g.
+// allocated with NewMKCiphertext(params, MKUnionParties(ct0, ct1), level). The ciphertexts must be in the same domain.
+func (mke *MKEvaluator) Add(ct0, ct1, ctOut *MKCiphertext) {
+
+	if !equalPoints(ctOut.Parties, MKUnionParties(ct0, ct1)) {
+		panic("cannot Add: ctOut must involve the union of the parties of the inputs")
+	}
+
+	ringQ := mke.params.RingQ()
+	level := utils.MinInt(utils.MinInt(ct0.Level(), ct1.Level()), ctOut.Level())
+
+	ringQ.AddLvl(level, ct0.Value[0], ct1.Value[0], ctOut.Value[0])
+
+	for i, p := range ctOut.Parties {
+		c0, c1 := ct0.Component(p), ct1.Component(p)
+		switch {
+		case c0 != nil && c1 != nil:
+			ringQ.AddLvl(level, c0, c1, ctOut.Value[i+1])
+		case c0 != nil:
+			ring.CopyValuesLvl(level, c0, ctOut.Value[i+1])
+		default:
+			ring.CopyValuesLvl(level, c1, ctOut.Value[i+1])
+		}
+	}
+
+	for i := range ctOut.Value {
+		ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:level+1]
+		ctOut.Value[i].IsNTT = ct0.Value[0].IsNTT
+	}
+}
+
+// Relinearize relinearizes the tensor product of two MKCiphertexts of the parties of ctOut and writes the result in
+// ctOut. tensor[i][j] is the component of the tensor product for the pair of components (i, j) of the ciphertexts,
+// i.e. the component that decrypts with s_i*s_j, s_0 being 1, in the NTT domain. evk must contain the MKEvaluationKey
+// of each party of ctOut. The result is in the NTT domain, at the level of ctOut.
+func (mke *MKEvaluator) Relinearize(tensor [][]*ring.Poly, evk map[ShamirPublicPoint]*MKEvaluationKey, ctOut *MKCiphertext) {
+
+	k := len(ctOut.Parties)
+
+	if len(tensor) != k+1 {
+		panic("cannot Relinearize: the tensor does not match the parties of ctOut")
+	}
+
+	keys := make([]*MKEvaluationKey, k)
+	for i, p := range ctOut.Parties {
+		if keys[i] = evk[p]; keys[i] == nil {
+			panic(fmt.Sprintf("cannot Relinearize: missing evaluation key of party %d", p))
+		}
+	}
+
+	ringQ := mke.params.RingQ()
+	level := ctOut.Level()
+
+	// c'_0 = c_00 and c'_i = c_0i + c_i0
+	ring.CopyValuesLvl(level, tensor[0][0], ctOut.Value[0])
+	for i := 1; i < k+1; i++ {
+		ringQ.AddLvl(level, tensor[0][i], tensor[i][0], ctOut.Value[i])
+	}
+
+	mke.cPrime.IsNTT = true
+	for i := 1; i < k+1; i++ {
+		for j := 1; j < k+1; j++ {
+
+			// cPrime = <g^-1(c_ij), b_j> ~ -s_j*<g^-1(c_ij), a> and tmp1 = <g^-1(c_ij), d2_i> ~ r_i*<g^-1(c_ij), a> + s_i*c_ij
+			mke.ks.SwitchKeysInPlace(level, tensor[i][j], mkSwitchingKey(keys[j-1].B, keys[i-1].D2), mke.cPrime, mke.tmp1)
+			ringQ.AddLvl(level, ctOut.Value[j], mke.tmp1, ctOut.Value[j])
+
+			// (tmp0, tmp1) = <g^-1(cPrime), (d0_i, d1_i)>, i.e. tmp0 + tmp1*s_i ~ r_i*cPrime
+			mke.ks.SwitchKeysInPlace(level, mke.cPrime, mkSwitchingKey(keys[i-1].D0, keys[i-1].D1), mke.tmp0, mke.tmp1)
+			ringQ.AddLvl(level, ctOut.Value[0], mke.tmp0, ctOut.Value[0])
+			ringQ.AddLvl(level, ctOut.Value[i], mke.tmp1, ctOut.Value[i])
+		}
+	}
+
+	for i := range ctOut.Value {
+		ctOut.Value[i].IsNTT = true
+	}
+}
+
+// mkSwitchingKey returns the switching key whose elements are the vectors v0 and v1.
+func mkSwitchingKey(v0, v1 []rlwe.PolyQP) *rlwe.SwitchingKey {
+	swk := &rlwe.SwitchingKey{Value: make([][2]rlwe.PolyQP, len(v0))}
+	for i := range swk.Value {
+		swk.Value[i] = [2]rlwe.PolyQP{v0[i], v1[i]}
+	}
+	return swk
+}
+
+// MKDecryptionProtocol is the protocol for the decryption of the MKCiphertexts: each party involved in the ciphertext
+// sends a share computed from its component and its secret key, and the shares are combined into the plaintext. It is a
+// CKSProtocol towards the zero key for each component, hence the parties must add a smudging noise to their share (see
+// SmudgingParams).
+type MKDecryptionProtocol struct {
+	params rlwe.Parameters
+	cks    *CKSProtocol
+	zero   *rlwe.SecretKey
+}
+
+// NewMKDecryptionProtocol creates a new MKDecryptionProtocol, sigmaSmudging being the standard deviation of the
+// smudging noise added by each party to its share.
+func NewMKDecryptionProtocol(params rlwe.Parameters, sigmaSmudging float64) *MKDecryptionProtocol {
+	return &MKDecryptionProtocol{params: params, cks: NewCKSProtocol(params, sigmaSmudging), zero: rlwe.NewSecretKey(params)}
+}
+
+// ShallowCopy creates a shallow copy of MKDecryptionProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// MKDecryptionProtocol can be used concurrently.
+func (mkd *MKDecryptionProtocol) ShallowCopy() *MKDecryptionProtocol {
+	return &MKDecryptionProtocol{params: mkd.params, cks: mkd.cks.ShallowCopy(), zero: mkd.zero}
+}
+
+// AllocateShare allocates a share of the MKDecryptionProtocol at the given level.
+func (mkd *MKDecryptionProtocol) AllocateShare(level int) *CKSShare {
+	return mkd.cks.AllocateShare(level)
+}
+
+// GenShare computes the share of the party of secret key sk for the decryption of ct, i.e. c_i*s_i + e. It returns an
+// error if ct does not involve the party.
+func (mkd *MKDecryptionProtocol) GenShare(sk *rlwe.SecretKey, party ShamirPublicPoint, ct *MKCiphertext, shareOut *CKSShare) error {
+	c := ct.Component(party)
+	if c == nil {
+		return fmt.Errorf("cannot GenShare: the ciphertext does not involve party %d", party)
+	}
+	mkd.cks.GenShare(sk, mkd.zero, c, shareOut)
+	return nil
+}
+
+// Decrypt combines the shares of the parties of ct into the plaintext ptOut = c_0 + sum(c_i*s_i + e_i), in the domain
+// of ct. It returns an error if the share of a party of ct is missing.
+func (mkd *MKDecryptionProtocol) Decrypt(ct *MKCiphertext, shares map[ShamirPublicPoint]*CKSShare, ptOut *ring.Poly) error {
+
+	ringQ := mkd.params.RingQ()
+	level := utils.MinInt(ct.Level(), ptOut.Level())
+
+	for _, p := range ct.Parties {
+		if shares[p] == nil {
+			return fmt.Errorf("cannot Decrypt: missing share of party %d", p)
+		}
+		level = utils.MinInt(level, shares[p].Value.Level())
+	}
+
+	ring.CopyValuesLvl(level, ct.Value[0], ptOut)
+	for _, p := range ct.Parties {
+		ringQ.AddLvl(level, ptOut, shares[p].Value, ptOut)
+	}
+
+	ptOut.Coeffs = ptOut.Coeffs[:level+1]
+	ptOut.IsNTT = ct.Value[0].IsNTT
+
+	return nil
+}
+
+func equalPoints(a, b []ShamirPublicPoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
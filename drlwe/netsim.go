@@ -0,0 +1,239 @@
+package drlwe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LinkProfile describes the simulated link of each party of a SimulatedNetwork.
+type LinkProfile struct {
+	// Latency is the one-way delay of the delivery of a message.
+	Latency time.Duration
+	// Bandwidth is the upload bandwidth of each party in bytes per second, or zero for an unlimited bandwidth.
+	Bandwidth float64
+}
+
+// transmissionTime returns the time taken by the upload of size bytes.
+func (link LinkProfile) transmissionTime(size int) time.Duration {
+	if link.Bandwidth <= 0 {
+		return 0
+	}
+	return time.Duration(float64(size) / link.Bandwidth * float64(time.Second))
+}
+
+// RoundStats are the statistics of a round of a simulated run of a multiparty protocol.
+type RoundStats struct {
+	Round int
+	// Messages is the number of messages delivered in the round and Bytes their total size.
+	Messages int
+	Bytes    int
+	// MaxShareSize is the size of the largest share of the round.
+	MaxShareSize int
+	// WallTime is the time elapsed between the end of the previous round, or the start of the simulation, and the
+	// delivery of the last message of the round, which includes the generation of the shares and their exchange.
+	WallTime time.Duration
+}
+
+// String returns a one-line summary of the statistics.
+func (rs RoundStats) String() string {
+	return fmt.Sprintf("round %d: %v, %d messages, %d bytes (largest share %d bytes)", rs.Round, rs.WallTime, rs.Messages, rs.Bytes, rs.MaxShareSize)
+}
+
+// SimulatedNetwork is an in-memory network among parties connected by simulated links, over which the Orchestrators
+// of the multiparty protocols exchange their shares, e.g. to predict the duration of a ceremony from the party count
+// and the latency and bandwidth of the links before deploying it (see Simulate). A broadcast share is uploaded once
+// per recipient on the uplink of the sender, whose uploads are sequential, and is delivered to each recipient a
+// Latency after its upload. The SimulatedNetwork records the RoundStats of the rounds.
+type SimulatedNetwork struct {
+	mu      sync.Mutex
+	parties []ShamirPublicPoint
+	link    LinkProfile
+	inboxes map[ShamirPublicPoint]map[int]chan simulatedMessage
+	uplinks map[ShamirPublicPoint]time.Time
+	start   time.Time
+	stats   map[int]*roundRecord
+}
+
+type simulatedMessage struct {
+	party ShamirPublicPoint
+	share []byte
+}
+
+type roundRecord struct {
+	RoundStats
+	end time.Time
+}
+
+// NewSimulatedNetwork creates a new SimulatedNetwork among the parties of public points parties, connected by links
+// of profile link. The simulation starts at its creation.
+func NewSimulatedNetwork(parties []ShamirPublicPoint, link LinkProfile) *SimulatedNetwork {
+	net := &SimulatedNetwork{parties: append([]ShamirPublicPoint(nil), parties...), link: link}
+	net.Reset()
+	return net
+}
+
+// Reset restarts the simulation and clears the recorded statistics. It must not be called while shares are exchanged.
+func (net *SimulatedNetwork) Reset() {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.inboxes = map[ShamirPublicPoint]map[int]chan simulatedMessage{}
+	net.uplinks = map[ShamirPublicPoint]time.Time{}
+	net.stats = map[int]*roundRecord{}
+	net.start = time.Now()
+}
+
+// Transport returns the Transport of the party of public point self.
+func (net *SimulatedNetwork) Transport(self ShamirPublicPoint) Transport {
+	return simulatedTransport{net: net, self: self}
+}
+
+// Stats returns the statistics of the rounds in increasing order.
+func (net *SimulatedNetwork) Stats() []RoundStats {
+
+	net.mu.Lock()
+	defer net.mu.Unlock()
+
+	rounds := make([]int, 0, len(net.stats))
+	for r := range net.stats {
+		rounds = append(rounds, r)
+	}
+	sort.Ints(rounds)
+
+	stats := make([]RoundStats, len(rounds))
+	prev := net.start
+	for i, r := range rounds {
+		rec := net.stats[r]
+		stats[i] = rec.RoundStats
+		stats[i].WallTime = rec.end.Sub(prev)
+		prev = rec.end
+	}
+
+	return stats
+}
+
+// inbox returns the inbox of the party for the round.
+func (net *SimulatedNetwork) inbox(party ShamirPublicPoint, round int) chan simulatedMessage {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	return net.inboxLocked(party, round)
+}
+
+func (net *SimulatedNetwork) inboxLocked(party ShamirPublicPoint, round int) chan simulatedMessage {
+	if net.inboxes[party] == nil {
+		net.inboxes[party] = map[int]chan simulatedMessage{}
+	}
+	if net.inboxes[party][round] == nil {
+		net.inboxes[party][round] = make(chan simulatedMessage, 16*len(net.parties))
+	}
+	return net.inboxes[party][round]
+}
+
+// broadcast schedules the delivery of the share of the sender to the other parties.
+func (net *SimulatedNetwork) broadcast(sender ShamirPublicPoint, round int, share []byte) {
+
+	net.mu.Lock()
+	defer net.mu.Unlock()
+
+	now := time.Now()
+	uplink := net.uplinks[sender]
+	if uplink.Before(now) {
+		uplink = now
+	}
+
+	rec, ok := net.stats[round]
+	if !ok {
+		rec = &roundRecord{RoundStats: RoundStats{Round: round}}
+		net.stats[round] = rec
+	}
+
+	if len(share) > rec.MaxShareSize {
+		rec.MaxShareSize = len(share)
+	}
+
+	for _, p := range net.parties {
+
+		if p == sender {
+			continue
+		}
+
+		uplink = uplink.Add(net.link.transmissionTime(len(share)))
+		deliver := uplink.Add(net.link.Latency)
+
+		rec.Messages++
+		rec.Bytes += len(share)
+		if deliver.After(rec.end) {
+			rec.end = deliver
+		}
+
+		inbox := net.inboxLocked(p, round)
+		msg := simulatedMessage{sender, share}
+		time.AfterFunc(time.Until(deliver), func() { inbox <- msg })
+	}
+
+	net.uplinks[sender] = uplink
+}
+
+type simulatedTransport struct {
+	net  *SimulatedNetwork
+	self ShamirPublicPoint
+}
+
+// Broadcast schedules the delivery of the share to the other parties and returns immediately.
+func (tr simulatedTransport) Broadcast(ctx context.Context, round int, share []byte) error {
+	tr.net.broadcast(tr.self, round, share)
+	return nil
+}
+
+// Receive returns the next share delivered to the party for the round.
+func (tr simulatedTransport) Receive(ctx context.Context, round int) (ShamirPublicPoint, []byte, error) {
+	select {
+	case msg := <-tr.net.inbox(tr.self, round):
+		return msg.party, msg.share, nil
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+}
+
+// Simulate runs the RoundProtocols of the parties of the SimulatedNetwork, one per party in the order of the parties
+// of the network, with their inputs, and returns the outputs of the parties and the RoundStats of the run. The
+// protocols must not share temporary buffers, e.g. be ShallowCopies of each other. It returns an error if the run of
+// a party fails.
+func Simulate(ctx context.Context, net *SimulatedNetwork, protocols []RoundProtocol, inputs []interface{}) (outputs []interface{}, stats []RoundStats, err error) {
+
+	if len(protocols) != len(net.parties) || len(inputs) != len(net.parties) {
+		return nil, nil, fmt.Errorf("cannot Simulate: %d parties but %d protocols and %d inputs", len(net.parties), len(protocols), len(inputs))
+	}
+
+	orchestrators := make([]*Orchestrator, len(net.parties))
+	for i, p := range net.parties {
+		if orchestrators[i], err = NewOrchestrator(protocols[i], p, net.parties); err != nil {
+			return nil, nil, fmt.Errorf("cannot Simulate: %w", err)
+		}
+	}
+
+	net.Reset()
+
+	outputs = make([]interface{}, len(net.parties))
+	errs := make([]error, len(net.parties))
+
+	var wg sync.WaitGroup
+	for i := range orchestrators {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs[i], errs[i] = orchestrators[i].Run(ctx, net.Transport(net.parties[i]), inputs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot Simulate: party %d: %w", net.parties[i], err)
+		}
+	}
+
+	return outputs, net.Stats(), nil
+}
@@ -0,0 +1,176 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"golang.org/x/crypto/blake2b"
+)
+
+// ProtocolOption is an option of NewRKGProtocol and NewRTGProtocol. The WithSeed option is also an option of
+// NewCKGProtocol and NewCKSProtocol, and the WithMetrics option an option of all the protocol constructors of the
+// package, i.e. of NewCKGProtocol, NewRKGProtocol, NewRTGProtocol, NewCKSProtocol and NewPCKSProtocol.
+type ProtocolOption func(*protocolOptions)
+
+type protocolOptions struct {
+	goroutines int
+	seed       []byte
+	metrics    rlwe.Metrics
+}
+
+// WithGoroutines makes the protocol distribute the loops over the elements of the decomposition basis in GenShare
+// (GenShareRoundOne and GenShareRoundTwo for the RKGProtocol) and AggregateShare among a pool of at most goroutines
+// goroutines, or of runtime.NumCPU() goroutines if goroutines is smaller than 1. Each goroutine has its own Gaussian
+// sampler and temporary buffers, so the memory footprint of the protocol grows with the size of the pool. By default,
+// the loops are executed sequentially by the calling goroutine. The shares are distributed as in the sequential
+// execution.
+func WithGoroutines(goroutines int) ProtocolOption {
+	return func(opts *protocolOptions) {
+		if goroutines < 1 {
+			goroutines = runtime.NumCPU()
+		}
+		opts.goroutines = goroutines
+	}
+}
+
+// WithSeed makes the protocol sample the randomness of its shares from PRNGs keyed with seed instead of fresh PRNGs,
+// e.g. with the seed derived from a SessionSeed by SessionSeed.ProtocolSeed, so that the shares generated by the
+// protocol can be reproduced from the seed to audit them (see AuditShare). The shares are reproduced by the same
+// sequence of calls to GenShare with the same inputs and the same number of goroutines. The protocols returned by
+// ShallowCopy sample their randomness from fresh PRNGs.
+func WithSeed(seed []byte) ProtocolOption {
+	return func(opts *protocolOptions) {
+		opts.seed = append([]byte(nil), seed...)
+	}
+}
+
+// WithMetrics makes the protocol record in metrics its operations, i.e. the calls to its methods generating and
+// aggregating the shares and producing the output key or ciphertext, along with their durations, and the sizes of the
+// binary serializations of the shares and keys it generates, as the component "drlwe.CKGProtocol", "drlwe.RKGProtocol",
+// "drlwe.RTGProtocol", "drlwe.CKSProtocol" or "drlwe.PCKSProtocol". The protocols returned by ShallowCopy record their
+// operations in the same metrics.
+func WithMetrics(metrics rlwe.Metrics) ProtocolOption {
+	return func(opts *protocolOptions) {
+		opts.metrics = metrics
+	}
+}
+
+// protocolMetrics records the metrics of a protocol in the rlwe.Metrics of the WithMetrics option, if any.
+type protocolMetrics struct {
+	metrics   rlwe.Metrics
+	component string
+}
+
+// operation records the operation started at start.
+func (m protocolMetrics) operation(operation string, start time.Time) {
+	if m.metrics != nil {
+		m.metrics.ObserveOperation(m.component, operation, time.Since(start))
+	}
+}
+
+// shareSize records the size of the binary serialization of a share.
+func (m protocolMetrics) shareSize(kind string, share interface{ GetDataLen(WithMetadata bool) int }) {
+	if m.metrics != nil {
+		m.metrics.ObserveKeySize(m.component, kind, share.GetDataLen(true))
+	}
+}
+
+// keySize records the size of the binary serialization of a key, which has a FormatHeader.
+func (m protocolMetrics) keySize(kind string, key interface{ GetDataLen(WithMetadata bool) int }) {
+	if m.metrics != nil {
+		m.metrics.ObserveKeySize(m.component, kind, rlwe.FormatHeaderLen+key.GetDataLen(true))
+	}
+}
+
+// newProtocolOptions returns the protocolOptions of the given options, bounding the number of goroutines by the
+// number of elements of the decomposition basis.
+func newProtocolOptions(params rlwe.Parameters, options []ProtocolOption) protocolOptions {
+
+	opts := protocolOptions{goroutines: 1}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.goroutines > params.Beta() {
+		opts.goroutines = params.Beta()
+	}
+
+	return opts
+}
+
+// decompWorker holds the Gaussian sampler and the temporary buffer of a goroutine of the loops over the elements of
+// the decomposition basis.
+type decompWorker struct {
+	gaussianSamplerQ *ring.GaussianSampler
+	tmpPoly          rlwe.PolyQP
+}
+
+// newPRNG returns a fresh PRNG if seed is nil, and otherwise the PRNG keyed with the hash of seed, label and index.
+func newPRNG(seed []byte, label string, index int) utils.PRNG {
+
+	if seed == nil {
+		prng, err := utils.NewPRNG()
+		if err != nil {
+			panic(err)
+		}
+		return prng
+	}
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(index))
+	h.Write(seed)
+	h.Write([]byte(label))
+	h.Write(buf[:])
+
+	prng, err := utils.NewKeyedPRNG(h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+	return prng
+}
+
+// newDecompWorkers allocates the given number of decompWorkers, each with its own PRNG, keyed with seed if seed is not
+// nil.
+func newDecompWorkers(params rlwe.Parameters, goroutines int, seed []byte) []decompWorker {
+	workers := make([]decompWorker, goroutines)
+	for w := range workers {
+		prng := newPRNG(seed, "worker", w)
+		workers[w].gaussianSamplerQ = ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma()))
+		workers[w].tmpPoly = params.RingQP().NewPoly()
+	}
+	return workers
+}
+
+// parallelDecomp calls f(worker, i) for each element i of the decomposition basis, distributed among the workers. With
+// a single worker, the loop is executed by the calling goroutine.
+func parallelDecomp(workers []decompWorker, beta int, f func(worker *decompWorker, i int)) {
+
+	if len(workers) == 1 {
+		for i := 0; i < beta; i++ {
+			f(&workers[0], i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < beta; i += len(workers) {
+				f(&workers[w], i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
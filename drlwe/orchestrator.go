@@ -0,0 +1,388 @@
+package drlwe
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Share is the share of a party in a round of a multiparty protocol.
+type Share interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// RoundProtocol describes the round structure of a multiparty protocol for an Orchestrator: in each round, every party
+// allocates and generates its share from its inputs and the aggregated shares of the previous rounds, the shares are
+// exchanged and aggregated, and the output is finalized from the aggregated shares of all the rounds.
+// CKGRounds, RKGRounds, RTGRounds, CKSRounds, PCKSRounds and RelinRounds implement it for the protocols of this package.
+type RoundProtocol interface {
+	// Rounds returns the number of rounds of the protocol.
+	Rounds() int
+	// AllocateShare allocates a zero share of the round.
+	AllocateShare(round int, inputs interface{}) Share
+	// GenShare generates the share of the party for the round and returns it in shareOut.
+	GenShare(round int, inputs interface{}, aggregated []Share, shareOut Share) error
+	// AggregateShares aggregates the shares share1 and share2 of the round and returns the result in shareOut.
+	AggregateShares(round int, share1, share2, shareOut Share)
+	// Finalize returns the output of the protocol from the aggregated shares of all the rounds.
+	Finalize(inputs interface{}, aggregated []Share) (output interface{}, err error)
+}
+
+// OrchestratorPhase is the phase of the current round of an Orchestrator.
+type OrchestratorPhase int
+
+const (
+	// PhaseGenerate is the phase in which the share of the party is generated.
+	PhaseGenerate OrchestratorPhase = iota
+	// PhaseExchange is the phase in which the shares are exchanged and aggregated.
+	PhaseExchange
+	// PhaseDone is the phase after the last round.
+	PhaseDone
+)
+
+// OrchestratorState is the state of an Orchestrator, from which an interrupted run can be resumed. The shares are
+// marshaled, so that the state can be persisted.
+type OrchestratorState struct {
+	Round      int
+	Phase      OrchestratorPhase
+	Share      []byte
+	Received   map[ShamirPublicPoint][]byte
+	Aggregated [][]byte
+}
+
+// CopyNew returns a deep copy of the state.
+func (st *OrchestratorState) CopyNew() *OrchestratorState {
+	stCopy := &OrchestratorState{
+		Round:      st.Round,
+		Phase:      st.Phase,
+		Share:      append([]byte(nil), st.Share...),
+		Received:   make(map[ShamirPublicPoint][]byte, len(st.Received)),
+		Aggregated: make([][]byte, len(st.Aggregated)),
+	}
+	for p, share := range st.Received {
+		stCopy.Received[p] = append([]byte(nil), share...)
+	}
+	for i := range st.Aggregated {
+		stCopy.Aggregated[i] = append([]byte(nil), st.Aggregated[i]...)
+	}
+	return stCopy
+}
+
+// Orchestrator drives a party through the rounds of a RoundProtocol as a resumable state machine: each round goes
+// through the phases PhaseGenerate and PhaseExchange, and the run ends in PhaseDone. The exchange of a round is bounded
+// by RoundTimeout and is retried at most MaxRetries times, the shares already received being kept across the retries.
+// If the run fails, e.g. because a party does not respond, it can be resumed by calling Run again, possibly on another
+// Orchestrator after Resume.
+type Orchestrator struct {
+	// RoundTimeout bounds the duration of each attempt of the exchange of a round. It is unbounded if zero.
+	RoundTimeout time.Duration
+	// MaxRetries is the maximum number of retries of the exchange of a round.
+	MaxRetries int
+
+	protocol RoundProtocol
+	self     ShamirPublicPoint
+	parties  []ShamirPublicPoint
+	state    *OrchestratorState
+
+	// after replaces the timer of RoundTimeout if not nil, e.g. by a clock controlled by the tests.
+	after func(d time.Duration) <-chan time.Time
+}
+
+// NewOrchestrator creates a new Orchestrator of the RoundProtocol protocol for the party of public point self among the
+// parties of public points parties. It returns an error if the public points are not non-zero and distinct or if self
+// is not among the parties.
+func NewOrchestrator(protocol RoundProtocol, self ShamirPublicPoint, parties []ShamirPublicPoint) (*Orchestrator, error) {
+
+	if err := checkPublicPoints(parties); err != nil {
+		return nil, fmt.Errorf("cannot NewOrchestrator: %w", err)
+	}
+
+	var isParty bool
+	for _, p := range parties {
+		isParty = isParty || p == self
+	}
+
+	if !isParty {
+		return nil, fmt.Errorf("cannot NewOrchestrator: the party %d is not among the parties", self)
+	}
+
+	return &Orchestrator{
+		protocol: protocol,
+		self:     self,
+		parties:  append([]ShamirPublicPoint(nil), parties...),
+		state:    &OrchestratorState{Received: map[ShamirPublicPoint][]byte{}},
+	}, nil
+}
+
+// State returns a copy of the current state of the Orchestrator.
+func (o *Orchestrator) State() *OrchestratorState {
+	return o.state.CopyNew()
+}
+
+// Resume sets the state of the Orchestrator to a copy of state, e.g. saved with State before an interruption.
+// It returns an error if the state is inconsistent with the protocol.
+func (o *Orchestrator) Resume(state *OrchestratorState) error {
+
+	if state.Round < 0 || state.Round > o.protocol.Rounds() || len(state.Aggregated) != state.Round {
+		return fmt.Errorf("cannot Resume: invalid round %d", state.Round)
+	}
+
+	if (state.Phase == PhaseDone) != (state.Round == o.protocol.Rounds()) {
+		return errors.New("cannot Resume: invalid phase")
+	}
+
+	o.state = state.CopyNew()
+
+	return nil
+}
+
+// Run runs the remaining rounds of the protocol with the inputs of the party, exchanging the shares over transport, and
+// returns the output of the protocol. It returns an error if ctx is done or if the exchange of a round fails after
+// MaxRetries retries, in which case the run can be resumed by calling Run again.
+//
+// If ctx carries an rlwe.Tracer (see rlwe.ContextWithTracer), Run starts a span named "drlwe.Orchestrator/Run", whose
+// children are the spans of the phases of the rounds, "drlwe.Orchestrator/GenShare", "drlwe.Orchestrator/Exchange" (one
+// per attempt) and "drlwe.Orchestrator/AggregateShares", each with the attribute "round", and of the finalization,
+// "drlwe.Orchestrator/Finalize".
+func (o *Orchestrator) Run(ctx context.Context, transport Transport, inputs interface{}) (output interface{}, err error) {
+
+	ctx, runSpan := rlwe.StartSpan(ctx, "drlwe.Orchestrator/Run")
+	runSpan.SetAttribute("party", uint64(o.self))
+	runSpan.SetAttribute("parties", len(o.parties))
+	defer func() { runSpan.End(err) }()
+
+	for o.state.Phase != PhaseDone {
+
+		switch o.state.Phase {
+		case PhaseGenerate:
+
+			_, span := o.startSpan(ctx, "GenShare")
+			err = o.generate(inputs)
+			span.End(err)
+
+			if err != nil {
+				return nil, fmt.Errorf("cannot Run: round %d: %w", o.state.Round, err)
+			}
+
+		case PhaseExchange:
+
+			for attempt := 0; ; attempt++ {
+
+				ctx, span := o.startSpan(ctx, "Exchange")
+				span.SetAttribute("attempt", attempt)
+				err = o.exchange(ctx, transport)
+				span.End(err)
+
+				if err == nil {
+					break
+				}
+
+				if ctx.Err() != nil || attempt == o.MaxRetries {
+					return nil, fmt.Errorf("cannot Run: round %d: %w", o.state.Round, err)
+				}
+			}
+
+			_, span := o.startSpan(ctx, "AggregateShares")
+			err = o.aggregate(inputs)
+			span.End(err)
+
+			if err != nil {
+				return nil, fmt.Errorf("cannot Run: round %d: %w", o.state.Round, err)
+			}
+		}
+	}
+
+	_, finalizeSpan := rlwe.StartSpan(ctx, "drlwe.Orchestrator/Finalize")
+	defer func() { finalizeSpan.End(err) }()
+
+	var aggregated []Share
+	if aggregated, err = o.aggregatedShares(inputs); err != nil {
+		return nil, fmt.Errorf("cannot Run: %w", err)
+	}
+
+	if output, err = o.protocol.Finalize(inputs, aggregated); err != nil {
+		return nil, fmt.Errorf("cannot Run: %w", err)
+	}
+
+	return output, nil
+}
+
+// startSpan starts the span of a phase of the current round.
+func (o *Orchestrator) startSpan(ctx context.Context, phase string) (context.Context, rlwe.Span) {
+	ctx, span := rlwe.StartSpan(ctx, "drlwe.Orchestrator/"+phase)
+	span.SetAttribute("round", o.state.Round)
+	return ctx, span
+}
+
+// generate generates the share of the party for the current round and moves to PhaseExchange.
+func (o *Orchestrator) generate(inputs interface{}) (err error) {
+
+	aggregated, err := o.aggregatedShares(inputs)
+	if err != nil {
+		return err
+	}
+
+	share := o.protocol.AllocateShare(o.state.Round, inputs)
+	if err = o.protocol.GenShare(o.state.Round, inputs, aggregated, share); err != nil {
+		return err
+	}
+
+	if o.state.Share, err = share.MarshalBinary(); err != nil {
+		return err
+	}
+
+	o.state.Received = map[ShamirPublicPoint][]byte{}
+	o.state.Phase = PhaseExchange
+
+	return nil
+}
+
+// exchange broadcasts the share of the party and receives the shares of the other parties for the current round.
+func (o *Orchestrator) exchange(ctx context.Context, transport Transport) (err error) {
+
+	// expired is closed when RoundTimeout elapses, which cancels the exchange.
+	expired := make(chan struct{})
+
+	if o.RoundTimeout > 0 {
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+
+		var expiry <-chan time.Time
+		if o.after != nil {
+			expiry = o.after(o.RoundTimeout)
+		} else {
+			timer := time.NewTimer(o.RoundTimeout)
+			defer timer.Stop()
+			expiry = timer.C
+		}
+
+		go func() {
+			select {
+			case <-expiry:
+				close(expired)
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	// timeout returns context.DeadlineExceeded instead of err if the exchange was canceled by RoundTimeout.
+	timeout := func(err error) error {
+		select {
+		case <-expired:
+			return context.DeadlineExceeded
+		default:
+			return err
+		}
+	}
+
+	if err = transport.Broadcast(ctx, o.state.Round, o.state.Share); err != nil {
+		return timeout(err)
+	}
+
+	for len(o.state.Received) < len(o.parties)-1 {
+
+		var party ShamirPublicPoint
+		var share []byte
+		if party, share, err = transport.Receive(ctx, o.state.Round); err != nil {
+			return fmt.Errorf("missing shares of the parties %v: %w", o.missing(), timeout(err))
+		}
+
+		// Shares of unknown parties and duplicated shares are ignored.
+		if _, ok := o.state.Received[party]; !ok && party != o.self && o.isParty(party) {
+			o.state.Received[party] = share
+		}
+	}
+
+	return nil
+}
+
+// aggregate aggregates the shares of the current round and moves to the next round.
+func (o *Orchestrator) aggregate(inputs interface{}) (err error) {
+
+	round := o.state.Round
+
+	agg, err := NewShareAggregator(o.parties, len(o.parties), func(share1, share2, shareOut interface{}) {
+		o.protocol.AggregateShares(round, share1.(Share), share2.(Share), shareOut.(Share))
+	})
+
+	if err != nil {
+		return err
+	}
+
+	shares := map[ShamirPublicPoint][]byte{o.self: o.state.Share}
+	for p, share := range o.state.Received {
+		shares[p] = share
+	}
+
+	for p, data := range shares {
+		share := o.protocol.AllocateShare(round, inputs)
+		if err = share.UnmarshalBinary(data); err != nil {
+			return fmt.Errorf("invalid share of the party %d: %w", p, err)
+		}
+		if err = agg.Add(p, share); err != nil {
+			return err
+		}
+	}
+
+	shareOut := o.protocol.AllocateShare(round, inputs)
+	if err = agg.Finalize(shareOut); err != nil {
+		return err
+	}
+
+	var data []byte
+	if data, err = shareOut.MarshalBinary(); err != nil {
+		return err
+	}
+
+	o.state.Aggregated = append(o.state.Aggregated, data)
+	o.state.Share = nil
+	o.state.Received = map[ShamirPublicPoint][]byte{}
+	o.state.Round++
+
+	if o.state.Round == o.protocol.Rounds() {
+		o.state.Phase = PhaseDone
+	} else {
+		o.state.Phase = PhaseGenerate
+	}
+
+	return nil
+}
+
+// aggregatedShares returns the aggregated shares of the previous rounds.
+func (o *Orchestrator) aggregatedShares(inputs interface{}) (aggregated []Share, err error) {
+	aggregated = make([]Share, len(o.state.Aggregated))
+	for i, data := range o.state.Aggregated {
+		aggregated[i] = o.protocol.AllocateShare(i, inputs)
+		if err = aggregated[i].UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (o *Orchestrator) isParty(party ShamirPublicPoint) bool {
+	for _, p := range o.parties {
+		if p == party {
+			return true
+		}
+	}
+	return false
+}
+
+// missing returns the public points of the other parties whose share of the current round was not received.
+func (o *Orchestrator) missing() (missing []ShamirPublicPoint) {
+	for _, p := range o.parties {
+		if _, ok := o.state.Received[p]; !ok && p != o.self {
+			missing = append(missing, p)
+		}
+	}
+	return
+}
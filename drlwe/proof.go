@@ -0,0 +1,743 @@
+package drlwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"golang.org/x/crypto/blake2b"
+)
+
+// ShareProof is a non-interactive zero-knowledge proof that a share of a multiparty protocol was correctly formed from
+// the secret key of a party, i.e. that it is of the form sum_i a_i * s_i + e for the public polynomials a_i of the
+// protocol, ternary secrets s_i and an error e bounded by the protocol. It is a Fiat-Shamir with aborts proof of
+// knowledge of the secrets and errors x: the challenge c is a sparse ternary polynomial derived from the hash of the
+// statement and of the commitments w = sum_i a_i * y_i + y_e for random masks y, and the responses are z = y + c * x,
+// which are small and rejected until they leak nothing on x. As usual for lattice-based proofs, the soundness is
+// relaxed: the extracted secrets and errors are small up to a factor of about the norm of the responses.
+type ShareProof struct {
+	Challenge [blake2b.Size256]byte
+	Z         [][]int64
+}
+
+// MarshalBinary encodes the proof on a slice of bytes.
+func (proof *ShareProof) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 0, len(proof.Challenge)+binary.MaxVarintLen64)
+	data = append(data, proof.Challenge[:]...)
+	data = appendVarint(data, int64(len(proof.Z)))
+	for _, z := range proof.Z {
+		data = appendVarint(data, int64(len(z)))
+		for _, zi := range z {
+			data = appendVarint(data, zi)
+		}
+	}
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes generated by MarshalBinary on the proof.
+func (proof *ShareProof) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < len(proof.Challenge) {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	copy(proof.Challenge[:], data)
+	data = data[len(proof.Challenge):]
+
+	var n int64
+	if n, data, err = readVarint(data); err != nil {
+		return err
+	}
+
+	if n < 0 || n > int64(len(data)) {
+		return errors.New("cannot UnmarshalBinary: invalid number of responses")
+	}
+
+	proof.Z = make([][]int64, n)
+	for i := range proof.Z {
+
+		var m int64
+		if m, data, err = readVarint(data); err != nil {
+			return err
+		}
+
+		if m < 0 || m > int64(len(data)) {
+			return errors.New("cannot UnmarshalBinary: invalid response size")
+		}
+
+		proof.Z[i] = make([]int64, m)
+		for j := range proof.Z[i] {
+			if proof.Z[i][j], data, err = readVarint(data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(data) != 0 {
+		return errors.New("cannot UnmarshalBinary: trailing bytes")
+	}
+
+	return
+}
+
+func appendVarint(data []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutVarint(buf[:], v)]...)
+}
+
+func readVarint(data []byte) (v int64, rest []byte, err error) {
+	n := 0
+	if v, n = binary.Varint(data); n <= 0 {
+		return 0, nil, errors.New("cannot UnmarshalBinary: invalid encoding")
+	}
+	return v, data[n:], nil
+}
+
+// SecretKeyCommitment is the public commitment of a party to its secret key s, namely its share b = -crp * s + e of
+// the CKG protocol for the common reference polynomial crp. The proofs of the other protocols are bound to it.
+type SecretKeyCommitment struct {
+	CRP   CKGCRP
+	Share *CKGShare
+}
+
+// GenProof generates a ShareProof that share = -crp * sk + e, with sk ternary and e bounded by 6 * sigma, i.e. that
+// share was generated by GenShare from the secret key sk. It returns an error if the share is not of this form.
+func (ckg *CKGProtocol) GenProof(sk *rlwe.SecretKey, crp CKGCRP, share *CKGShare) (*ShareProof, error) {
+
+	s, err := ternaryCoefficients(ckg.params, sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenProof: %w", err)
+	}
+
+	proof, err := newShareProver(ckg.params, "CKG").prove([]relation{ckgRelation(ckg.params, crp, share.Value, 0, 1)}, [][]int64{s})
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenProof: %w", err)
+	}
+
+	return proof, nil
+}
+
+// VerifyShare verifies the ShareProof of the share for the common reference polynomial crp. It returns an error if
+// the proof is invalid, in which case the share must not be aggregated.
+func (ckg *CKGProtocol) VerifyShare(crp CKGCRP, share *CKGShare, proof *ShareProof) error {
+	if err := newShareProver(ckg.params, "CKG").verify([]relation{ckgRelation(ckg.params, crp, share.Value, 0, 1)}, 1, proof); err != nil {
+		return fmt.Errorf("cannot VerifyShare: %w", err)
+	}
+	return nil
+}
+
+// GenPossessionProof generates a proof of possession of the secret key sk for the CKG share of the party: a ShareProof
+// that share = -crp * sk + e, as for GenProof, whose challenge is also bound to the public point of the party. It
+// prevents rogue-key attacks, in which a malicious party chooses its share as a function of the shares of the other
+// parties, e.g. to cancel them in the collective public key, or copies the share of another party: such a share is not
+// formed from a secret key known to the party, hence it cannot prove its possession. The proof of a party does not
+// verify for another party.
+func (ckg *CKGProtocol) GenPossessionProof(party ShamirPublicPoint, sk *rlwe.SecretKey, crp CKGCRP, share *CKGShare) (*ShareProof, error) {
+
+	s, err := ternaryCoefficients(ckg.params, sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenPossessionProof: %w", err)
+	}
+
+	proof, err := newShareProver(ckg.params, possessionLabel(party)).prove([]relation{ckgRelation(ckg.params, crp, share.Value, 0, 1)}, [][]int64{s})
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenPossessionProof: %w", err)
+	}
+
+	return proof, nil
+}
+
+// VerifyPossession verifies the proof of possession of the CKG share of the party for the common reference polynomial
+// crp. It returns an error if the proof is invalid, in which case the share must not be aggregated.
+func (ckg *CKGProtocol) VerifyPossession(party ShamirPublicPoint, crp CKGCRP, share *CKGShare, proof *ShareProof) error {
+	if err := newShareProver(ckg.params, possessionLabel(party)).verify([]relation{ckgRelation(ckg.params, crp, share.Value, 0, 1)}, 1, proof); err != nil {
+		return fmt.Errorf("cannot VerifyPossession: %w", err)
+	}
+	return nil
+}
+
+// possessionLabel returns the label of the proofs of possession of the party.
+func possessionLabel(party ShamirPublicPoint) string {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(party))
+	return "CKG/PoP/" + string(buf[:])
+}
+
+// GenProof generates a ShareProof that share = c1 * (skInput - skOutput) + e, with e bounded by 6 * sigmaSmudging,
+// and where skInput and skOutput are the secret keys committed to by cmtInput and cmtOutput
+// (see SecretKeyCommitment). If cmtOutput is nil, skOutput must be zero, e.g. for a collective decryption. It returns
+// an error if the share or the commitments are not of this form.
+func (cks *CKSProtocol) GenProof(skInput, skOutput *rlwe.SecretKey, c1 *ring.Poly, share *CKSShare, cmtInput, cmtOutput *SecretKeyCommitment) (*ShareProof, error) {
+
+	secrets := [][]int64{nil}
+
+	var err error
+	if secrets[0], err = ternaryCoefficients(cks.params, skInput); err != nil {
+		return nil, fmt.Errorf("cannot GenProof: %w", err)
+	}
+
+	if cmtOutput != nil {
+
+		var s []int64
+		if s, err = ternaryCoefficients(cks.params, skOutput); err != nil {
+			return nil, fmt.Errorf("cannot GenProof: %w", err)
+		}
+
+		secrets = append(secrets, s)
+	}
+
+	proof, err := newShareProver(cks.params, "CKS").prove(cks.relations(c1, share, cmtInput, cmtOutput), secrets)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenProof: %w", err)
+	}
+
+	return proof, nil
+}
+
+// VerifyShare verifies the ShareProof of the share for the ciphertext element c1 and the commitments to the secret keys
+// cmtInput and cmtOutput, which must be the same as for GenProof. It returns an error if the proof is invalid, in which
+// case the share must not be aggregated.
+func (cks *CKSProtocol) VerifyShare(c1 *ring.Poly, share *CKSShare, cmtInput, cmtOutput *SecretKeyCommitment, proof *ShareProof) error {
+
+	nbSecrets := 1
+	if cmtOutput != nil {
+		nbSecrets = 2
+	}
+
+	if err := newShareProver(cks.params, "CKS").verify(cks.relations(c1, share, cmtInput, cmtOutput), nbSecrets, proof); err != nil {
+		return fmt.Errorf("cannot VerifyShare: %w", err)
+	}
+
+	return nil
+}
+
+// relations returns the relations proven for a CKS share: share = c1 * skInput - c1 * skOutput + e mod Q_level,
+// and the CKG relations of the commitments.
+func (cks *CKSProtocol) relations(c1 *ring.Poly, share *CKSShare, cmtInput, cmtOutput *SecretKeyCommitment) []relation {
+
+	ringQ := cks.params.RingQ()
+
+	levelQ := utils.MinInt(share.Value.Level(), c1.Level())
+
+	a := ringQ.NewPolyLvl(levelQ)
+	t := ringQ.NewPolyLvl(levelQ)
+	ring.CopyValuesLvl(levelQ, c1, a)
+	ring.CopyValuesLvl(levelQ, share.Value, t)
+
+	if !c1.IsNTT {
+		ringQ.NTTLvl(levelQ, a, a)
+		ringQ.NTTLvl(levelQ, t, t)
+	}
+
+	// The error is the smudging noise.
+	bound := uint64(math.Ceil(6 * cks.sigmaSmudging))
+
+	keySwitch := relation{levelQ: levelQ, levelP: -1, a: []rlwe.PolyQP{{Q: a}}, t: rlwe.PolyQP{Q: t}, bound: bound}
+
+	rels := []relation{keySwitch, ckgRelation(cks.params, cmtInput.CRP, cmtInput.Share.Value, 0, 1)}
+
+	if cmtOutput != nil {
+		aNeg := ringQ.NewPolyLvl(levelQ)
+		ringQ.NegLvl(levelQ, a, aNeg)
+		rels[0].a = append(rels[0].a, rlwe.PolyQP{Q: aNeg})
+		rels = append(rels, ckgRelation(cks.params, cmtOutput.CRP, cmtOutput.Share.Value, 1, 2))
+	}
+
+	return rels
+}
+
+// maxSmudgingBound returns the largest bound 6 * sigmaSmudging of the smudging noise of a CKSProtocol whose shares can
+// be proven by GenProof, i.e. the largest bound of the proofs of the key-switching relation and of the CKG relations
+// of the two commitments, with their two secrets.
+func maxSmudgingBound(params rlwe.Parameters) float64 {
+	return float64(newShareProver(params, "CKS").maxBound(5))
+}
+
+// ckgRelation returns the relation b = -crp * s_idx + e mod QP among nbSecrets secrets.
+func ckgRelation(params rlwe.Parameters, crp CKGCRP, b rlwe.PolyQP, idx, nbSecrets int) relation {
+
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	a := params.RingQP().NewPoly()
+	params.RingQ().NegLvl(levelQ, crp.Q, a.Q)
+	if levelP > -1 {
+		params.RingP().NegLvl(levelP, crp.P, a.P)
+	}
+
+	rel := relation{levelQ: levelQ, levelP: levelP, a: make([]rlwe.PolyQP, nbSecrets), t: b, bound: uint64(math.Ceil(6 * params.Sigma()))}
+	rel.a[idx] = a
+
+	return rel
+}
+
+// ternaryCoefficients returns the coefficients of the secret key sk, and an error if it is not ternary.
+func ternaryCoefficients(params rlwe.Parameters, sk *rlwe.SecretKey) ([]int64, error) {
+
+	ringQ := params.RingQ()
+
+	tmp := ringQ.NewPolyLvl(0)
+	ringQ.InvMFormLvl(0, sk.Value.Q, tmp)
+	ringQ.InvNTTLvl(0, tmp, tmp)
+
+	s := make([]int64, ringQ.N)
+	q := ringQ.Modulus[0]
+	for i, c := range tmp.Coeffs[0] {
+		switch c {
+		case 0, 1:
+			s[i] = int64(c)
+		case q - 1:
+			s[i] = -1
+		default:
+			return nil, errors.New("the secret key is not ternary")
+		}
+	}
+
+	return s, nil
+}
+
+// relation is the relation t = sum_i a[i] * s_i + e mod Q_levelQ * P_levelP, with ||e||_inf <= bound, where the
+// polynomials are in the NTT domain and levelP = -1 if the relation is modulo Q only. The polynomials a[i] of the
+// secrets that do not appear in the relation have a nil Q.
+type relation struct {
+	levelQ, levelP int
+	a              []rlwe.PolyQP
+	t              rlwe.PolyQP
+	bound          uint64
+}
+
+// securityParameter is the logarithm of the size of the space of the challenges.
+const securityParameter = 128
+
+// maxProofAttempts is the number of attempts after which the prover aborts with an error. Each attempt is accepted
+// with probability about 1/e, hence an honest prover aborts with probability about (1-1/e)^maxProofAttempts < 2^-128.
+const maxProofAttempts = 256
+
+// shareProver generates and verifies ShareProofs.
+type shareProver struct {
+	params rlwe.Parameters
+	label  string
+	kappa  int
+}
+
+func newShareProver(params rlwe.Parameters, label string) *shareProver {
+
+	// The challenges are the ternary polynomials of Hamming weight kappa, of which there are binomial(N, kappa) * 2^kappa.
+	N := float64(params.N())
+	kappa := 1
+	for {
+		lgN, _ := math.Lgamma(N + 1)
+		lgK, _ := math.Lgamma(float64(kappa) + 1)
+		lgNK, _ := math.Lgamma(N - float64(kappa) + 1)
+		if (lgN-lgK-lgNK)/math.Ln2+float64(kappa) >= securityParameter || float64(kappa) >= N {
+			break
+		}
+		kappa++
+	}
+
+	return &shareProver{params: params, label: label, kappa: kappa}
+}
+
+// maxBound returns the largest bound of the secrets and of the errors of a proof of m secrets and errors, for which
+// the bound gamma of their masks and the size 2*gamma+1 of the range of the masks fit in an int64.
+func (sp *shareProver) maxBound(m int) uint64 {
+	return uint64((math.MaxInt64 - 1) / 2 / (int64(sp.kappa) * int64(sp.params.N()) * int64(m)))
+}
+
+// gammas returns the bounds of the masks of the secrets and of the errors, such that each attempt is accepted with
+// probability about 1/e. It returns an error if a bound is larger than maxBound or if a rejection bound is not positive.
+func (sp *shareProver) gammas(rels []relation, nbSecrets int) (gammas []int64, err error) {
+	m := nbSecrets + len(rels)
+	maxBound := sp.maxBound(m)
+	gammas = make([]int64, m)
+	for i := range gammas {
+		bound := uint64(1)
+		if i >= nbSecrets {
+			bound = rels[i-nbSecrets].bound
+		}
+		if bound > maxBound {
+			return nil, fmt.Errorf("the bound 2^%.2f of the error of the relation %d is larger than the largest bound 2^%.2f of the proofs", math.Log2(float64(bound)), i-nbSecrets, math.Log2(float64(maxBound)))
+		}
+		gammas[i] = int64(sp.kappa) * int64(bound) * int64(sp.params.N()) * int64(m)
+		if gammas[i]-int64(sp.kappa)*int64(bound) <= 0 {
+			return nil, fmt.Errorf("the rejection bound of the response %d is not positive", i)
+		}
+	}
+	return
+}
+
+// bounds returns the bounds of the secrets and of the errors.
+func (sp *shareProver) bounds(rels []relation, nbSecrets int) (bounds []int64) {
+	bounds = make([]int64, nbSecrets+len(rels))
+	for i := range bounds {
+		bounds[i] = 1
+		if i >= nbSecrets {
+			bounds[i] = int64(rels[i-nbSecrets].bound)
+		}
+	}
+	return
+}
+
+func (sp *shareProver) prove(rels []relation, secrets [][]int64) (proof *ShareProof, err error) {
+
+	nbSecrets := len(secrets)
+
+	// Computes the errors e = t - sum_i a_i * s_i.
+	x := append([][]int64{}, secrets...)
+	for j, rel := range rels {
+
+		e := sp.newPoly(rel)
+		ringQP := sp.params.RingQP()
+		ringQP.CopyValuesLvl(rel.levelQ, rel.levelP, rel.t, e)
+		for i, a := range rel.a {
+			if a.Q != nil {
+				s := sp.lift(rel, secrets[i], true)
+				sp.mulCoeffsAndSub(rel, a, s, e)
+			}
+		}
+
+		sp.invNTT(rel, e)
+
+		var ok bool
+		var coeffs []int64
+		if coeffs, ok = sp.center(rel, e); !ok {
+			return nil, fmt.Errorf("the share does not satisfy the relation %d", j)
+		}
+
+		x = append(x, coeffs)
+	}
+
+	gammas, err := sp.gammas(rels, nbSecrets)
+	if err != nil {
+		return nil, err
+	}
+	bounds := sp.bounds(rels, nbSecrets)
+
+	for i := range x {
+		if norm(x[i]) > bounds[i] {
+			return nil, fmt.Errorf("the error of the relation %d is too large", i-nbSecrets)
+		}
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	N := sp.params.N()
+
+	proof = &ShareProof{Z: make([][]int64, len(x))}
+	y := make([][]int64, len(x))
+	for i := range y {
+		y[i] = make([]int64, N)
+		proof.Z[i] = make([]int64, N)
+	}
+
+	cx := make([]int64, N)
+
+	for attempt := 0; attempt < maxProofAttempts; attempt++ {
+
+		for i := range y {
+			sampleUniformInt(prng, gammas[i], y[i])
+		}
+
+		w := make([]rlwe.PolyQP, len(rels))
+		for j, rel := range rels {
+			w[j] = sp.lift(rel, y[nbSecrets+j], false)
+			for i, a := range rel.a {
+				if a.Q != nil {
+					sp.mulCoeffsAndAdd(rel, a, sp.lift(rel, y[i], true), w[j])
+				}
+			}
+		}
+
+		proof.Challenge = sp.hash(rels, w)
+		c := sp.challenge(proof.Challenge)
+
+		accept := true
+		for i := range x {
+			mulChallenge(c, x[i], cx)
+			for k := range cx {
+				proof.Z[i][k] = y[i][k] + cx[k]
+			}
+			accept = accept && norm(proof.Z[i]) <= gammas[i]-int64(sp.kappa)*bounds[i]
+		}
+
+		if accept {
+			return proof, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no attempt accepted after %d attempts", maxProofAttempts)
+}
+
+func (sp *shareProver) verify(rels []relation, nbSecrets int, proof *ShareProof) (err error) {
+
+	if proof == nil || len(proof.Z) != nbSecrets+len(rels) {
+		return errors.New("invalid number of responses")
+	}
+
+	gammas, err := sp.gammas(rels, nbSecrets)
+	if err != nil {
+		return err
+	}
+	bounds := sp.bounds(rels, nbSecrets)
+
+	for i, z := range proof.Z {
+		if len(z) != sp.params.N() {
+			return errors.New("invalid response size")
+		}
+		if norm(z) > gammas[i]-int64(sp.kappa)*bounds[i] {
+			return errors.New("response is too large")
+		}
+	}
+
+	c := sp.challenge(proof.Challenge)
+
+	// Recomputes the commitments w = sum_i a_i * z_i + z_e - c * t.
+	w := make([]rlwe.PolyQP, len(rels))
+	for j, rel := range rels {
+		w[j] = sp.lift(rel, proof.Z[nbSecrets+j], false)
+		for i, a := range rel.a {
+			if a.Q != nil {
+				sp.mulCoeffsAndAdd(rel, a, sp.lift(rel, proof.Z[i], true), w[j])
+			}
+		}
+		sp.mulCoeffsAndSub(rel, rel.t, sp.lift(rel, c, true), w[j])
+	}
+
+	if sp.hash(rels, w) != proof.Challenge {
+		return errors.New("invalid proof")
+	}
+
+	return nil
+}
+
+// hash returns the hash of the statement and of the commitments w.
+func (sp *shareProver) hash(rels []relation, w []rlwe.PolyQP) (digest [blake2b.Size256]byte) {
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	h.Write([]byte(sp.label))
+
+	buf := make([]byte, 8*sp.params.N())
+	write := func(rel relation, p rlwe.PolyQP) {
+		for _, coeffs := range sp.coeffs(rel, p) {
+			for k, c := range coeffs {
+				binary.LittleEndian.PutUint64(buf[8*k:], c)
+			}
+			h.Write(buf)
+		}
+	}
+
+	for j, rel := range rels {
+		for _, a := range rel.a {
+			if a.Q != nil {
+				write(rel, a)
+			}
+		}
+		write(rel, rel.t)
+		write(rel, w[j])
+	}
+
+	copy(digest[:], h.Sum(nil))
+
+	return
+}
+
+// challenge returns the ternary polynomial of Hamming weight kappa derived from the digest.
+func (sp *shareProver) challenge(digest [blake2b.Size256]byte) (c []int64) {
+
+	prng, err := utils.NewKeyedPRNG(digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	N := sp.params.N()
+	c = make([]int64, N)
+
+	buf := make([]byte, 8)
+	for weight := 0; weight < sp.kappa; {
+		prng.Clock(buf)
+		v := binary.LittleEndian.Uint64(buf)
+		if idx := int(v>>1) & (N - 1); c[idx] == 0 {
+			c[idx] = 1 - 2*int64(v&1)
+			weight++
+		}
+	}
+
+	return
+}
+
+// coeffs returns the coefficients of p modulo Q_levelQ * P_levelP.
+func (sp *shareProver) coeffs(rel relation, p rlwe.PolyQP) (coeffs [][]uint64) {
+	coeffs = append(coeffs, p.Q.Coeffs[:rel.levelQ+1]...)
+	if rel.levelP > -1 {
+		coeffs = append(coeffs, p.P.Coeffs[:rel.levelP+1]...)
+	}
+	return
+}
+
+// rings returns the rings of the relation with their levels.
+func (sp *shareProver) rings(rel relation) (rings []*ring.Ring, levels []int) {
+	rings, levels = []*ring.Ring{sp.params.RingQ()}, []int{rel.levelQ}
+	if rel.levelP > -1 {
+		rings, levels = append(rings, sp.params.RingP()), append(levels, rel.levelP)
+	}
+	return
+}
+
+func (sp *shareProver) newPoly(rel relation) (p rlwe.PolyQP) {
+	p.Q = sp.params.RingQ().NewPolyLvl(rel.levelQ)
+	if rel.levelP > -1 {
+		p.P = sp.params.RingP().NewPolyLvl(rel.levelP)
+	}
+	return
+}
+
+// lift returns the small coefficients v in the NTT domain of the relation, and in the Montgomery domain if mForm is true.
+func (sp *shareProver) lift(rel relation, v []int64, mForm bool) (p rlwe.PolyQP) {
+
+	p = sp.newPoly(rel)
+	polys := []*ring.Poly{p.Q, p.P}
+	rings, levels := sp.rings(rel)
+
+	for r := range rings {
+		for i, qi := range rings[r].Modulus[:levels[r]+1] {
+			coeffs := polys[r].Coeffs[i]
+			for k, vk := range v {
+				if vk < 0 {
+					coeffs[k] = qi - uint64(-vk)%qi
+				} else {
+					coeffs[k] = uint64(vk) % qi
+				}
+			}
+		}
+
+		rings[r].NTTLvl(levels[r], polys[r], polys[r])
+
+		if mForm {
+			rings[r].MFormLvl(levels[r], polys[r], polys[r])
+		}
+	}
+
+	return
+}
+
+// center returns the centered coefficients of p, and false if they are not the same modulo each modulus.
+func (sp *shareProver) center(rel relation, p rlwe.PolyQP) (v []int64, ok bool) {
+
+	q0 := sp.params.RingQ().Modulus[0]
+
+	v = make([]int64, sp.params.N())
+	for k, c := range p.Q.Coeffs[0] {
+		if c > q0>>1 {
+			v[k] = -int64(q0 - c)
+		} else {
+			v[k] = int64(c)
+		}
+	}
+
+	polys := []*ring.Poly{p.Q, p.P}
+	rings, levels := sp.rings(rel)
+
+	for r := range rings {
+		for i, qi := range rings[r].Modulus[:levels[r]+1] {
+			for k, c := range polys[r].Coeffs[i] {
+				if (v[k] < 0 && c != qi-uint64(-v[k])%qi) || (v[k] >= 0 && c != uint64(v[k])%qi) {
+					return nil, false
+				}
+			}
+		}
+	}
+
+	return v, true
+}
+
+func (sp *shareProver) invNTT(rel relation, p rlwe.PolyQP) {
+	polys := []*ring.Poly{p.Q, p.P}
+	rings, levels := sp.rings(rel)
+	for r := range rings {
+		rings[r].InvNTTLvl(levels[r], polys[r], polys[r])
+	}
+}
+
+// mulCoeffsAndAdd adds a * b to pOut, where b is in the Montgomery domain.
+func (sp *shareProver) mulCoeffsAndAdd(rel relation, a, b, pOut rlwe.PolyQP) {
+	as, bs, outs := []*ring.Poly{a.Q, a.P}, []*ring.Poly{b.Q, b.P}, []*ring.Poly{pOut.Q, pOut.P}
+	rings, levels := sp.rings(rel)
+	for r := range rings {
+		rings[r].MulCoeffsMontgomeryAndAddLvl(levels[r], as[r], bs[r], outs[r])
+	}
+}
+
+// mulCoeffsAndSub subtracts a * b from pOut, where b is in the Montgomery domain.
+func (sp *shareProver) mulCoeffsAndSub(rel relation, a, b, pOut rlwe.PolyQP) {
+	as, bs, outs := []*ring.Poly{a.Q, a.P}, []*ring.Poly{b.Q, b.P}, []*ring.Poly{pOut.Q, pOut.P}
+	rings, levels := sp.rings(rel)
+	for r := range rings {
+		rings[r].MulCoeffsMontgomeryAndSubLvl(levels[r], as[r], bs[r], outs[r])
+	}
+}
+
+// mulChallenge sets out to the negacyclic product c * x.
+func mulChallenge(c, x, out []int64) {
+	N := len(c)
+	for k := range out {
+		out[k] = 0
+	}
+	for j, cj := range c {
+		if cj != 0 {
+			for k, xk := range x {
+				if idx := j + k; idx < N {
+					out[idx] += cj * xk
+				} else {
+					out[idx-N] -= cj * xk
+				}
+			}
+		}
+	}
+}
+
+// sampleUniformInt samples the coefficients of v uniformly in [-gamma, gamma].
+func sampleUniformInt(prng utils.PRNG, gamma int64, v []int64) {
+
+	mask := uint64(1)<<uint64(bits.Len64(uint64(2*gamma+1))) - 1
+
+	buf := make([]byte, 8*len(v))
+	prng.Clock(buf)
+
+	for k, ptr := 0, 0; k < len(v); {
+
+		if ptr == len(buf) {
+			prng.Clock(buf)
+			ptr = 0
+		}
+
+		if r := binary.LittleEndian.Uint64(buf[ptr:]) & mask; r < uint64(2*gamma+1) {
+			v[k] = int64(r) - gamma
+			k++
+		}
+
+		ptr += 8
+	}
+}
+
+func norm(v []int64) (max int64) {
+	for _, vk := range v {
+		if vk < 0 {
+			vk = -vk
+		}
+		if vk > max {
+			max = vk
+		}
+	}
+	return
+}
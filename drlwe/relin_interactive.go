@@ -0,0 +1,55 @@
+package drlwe
+
+import (
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// InteractiveRelinProtocol is the protocol with which the parties relinearize a specific ciphertext of degree 2
+// under the collective secret key s, e.g. the product of two ciphertexts, in a single round and without a
+// relinearization key: each party sends the share c2*s_i + e_i of the ciphertext (c0, c1, c2), and the aggregated
+// shares give the ciphertext (c0, c1 + c2*s + e) of degree 1 that encrypts the same message.
+// This avoids the two rounds of the RKGProtocol, and the size of the relinearization key, in the workloads with few
+// multiplications, at the cost of a round per relinearized ciphertext. The error e*s added to the ciphertext is of the
+// order of the error of a relinearization with a relinearization key.
+type InteractiveRelinProtocol struct {
+	degreeReducer
+}
+
+// NewInteractiveRelinProtocol creates a new InteractiveRelinProtocol instance.
+func NewInteractiveRelinProtocol(params rlwe.Parameters) *InteractiveRelinProtocol {
+	return &InteractiveRelinProtocol{newDegreeReducer(params)}
+}
+
+// ShallowCopy creates a shallow copy of InteractiveRelinProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// InteractiveRelinProtocol can be used concurrently.
+func (rp *InteractiveRelinProtocol) ShallowCopy() *InteractiveRelinProtocol {
+	return &InteractiveRelinProtocol{rp.degreeReducer.shallowCopy()}
+}
+
+// AllocateShare allocates the share of a party in the InteractiveRelinProtocol.
+func (rp *InteractiveRelinProtocol) AllocateShare(level int) *DegreeReductionShare {
+	return rp.AllocateDegreeReductionShare(level)
+}
+
+// GenShare computes the share c2*sk + e of a party in the relinearization of the ciphertext ct of degree 2.
+func (rp *InteractiveRelinProtocol) GenShare(sk *rlwe.SecretKey, ct *rlwe.Ciphertext, shareOut *DegreeReductionShare) {
+	if ct.Degree() != 2 {
+		panic("cannot GenShare: the degree of the ciphertext must be 2")
+	}
+	rp.GenDegreeReductionShare(sk, ct.Value[2], shareOut)
+}
+
+// AggregateShare aggregates two shares of the InteractiveRelinProtocol.
+func (rp *InteractiveRelinProtocol) AggregateShare(share1, share2, shareOut *DegreeReductionShare) {
+	rp.AggregateDegreeReductionShare(share1, share2, shareOut)
+}
+
+// Relinearize computes the relinearization of the ciphertext ctIn of degree 2 from the aggregated shares of all the
+// parties, and returns it in ctOut, which is resized to degree 1 if necessary. ctIn and ctOut can be the same.
+func (rp *InteractiveRelinProtocol) Relinearize(ctIn *rlwe.Ciphertext, combined *DegreeReductionShare, ctOut *rlwe.Ciphertext) {
+	if ctIn.Degree() != 2 {
+		panic("cannot Relinearize: the degree of the ciphertext must be 2")
+	}
+	rp.ReduceDegree(ctIn, combined, ctOut)
+}
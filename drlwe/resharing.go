@@ -0,0 +1,187 @@
+package drlwe
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// ResharingProtocol refreshes the sharing of the collective secret key among the parties without changing the key, so
+// that long-lived deployments can rotate the shares periodically and recover from the compromise of some of them: the
+// shares obtained by an adversary before a refresh cannot be combined with the shares obtained after it. It supports
+//   - the additive refresh of N-out-of-N shares: each party sends to each party a KeyRefreshShare, the KeyRefreshShares
+//     of a party summing to zero, and adds the KeyRefreshShares it receives to its secret key share
+//     (see GenKeyRefreshShares and RefreshSecretKey),
+//   - the refresh of T-out-of-N shares: each party sends to each party the evaluation at its public point of a
+//     ShamirPolynomial of constant coefficient zero, and aggregates the received evaluations to its ShamirSecretShare
+//     (see GenRefreshPolynomial),
+//   - the redistribution of T-out-of-N shares to a new set of parties or a new threshold: each of T active parties
+//     thresholdizes its additive share of the collective secret key among the new parties, which aggregate the received
+//     evaluations in their new ShamirSecretShare (see GenResharingPolynomial).
+//
+// The shares must be sent over confidential channels. Since the collective key does not change, the collective public,
+// relinearization and rotation keys remain valid, but the material derived from the shares of the parties, e.g. their
+// SecretKeyCommitments, must be re-derived from the new shares (see CheckRefresh). As the refreshed shares are not
+// ternary, the ShareProofs cannot be generated from them.
+type ResharingProtocol struct {
+	params   rlwe.Parameters
+	thr      *Thresholdizer
+	usampler rlwe.UniformSamplerQP
+	header   ShareHeader
+}
+
+// KeyRefreshShare is the share sent by a party to another party in the additive refresh of the collective secret key,
+// i.e. a uniformly random polynomial of R_QP. The KeyRefreshShares generated by a party for all the parties sum to zero.
+type KeyRefreshShare struct {
+	Header ShareHeader
+	Value  rlwe.PolyQP
+}
+
+// NewResharingProtocol creates a new ResharingProtocol.
+func NewResharingProtocol(params rlwe.Parameters) *ResharingProtocol {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return &ResharingProtocol{params: params, thr: NewThresholdizer(params), usampler: rlwe.NewUniformSamplerQP(params, prng, params.RingQP()), header: NewShareHeader(ProtocolKeyRefresh, params)}
+}
+
+// ShallowCopy creates a shallow copy of ResharingProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// ResharingProtocol can be used concurrently.
+func (rsp *ResharingProtocol) ShallowCopy() *ResharingProtocol {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return &ResharingProtocol{params: rsp.params, thr: NewThresholdizer(rsp.params), usampler: rlwe.NewUniformSamplerQP(rsp.params, prng, rsp.params.RingQP()), header: rsp.header}
+}
+
+// AllocateShare allocates a KeyRefreshShare.
+func (rsp *ResharingProtocol) AllocateShare() *KeyRefreshShare {
+	return &KeyRefreshShare{Header: rsp.header, Value: rsp.params.RingQP().NewPoly()}
+}
+
+// GenKeyRefreshShares generates the KeyRefreshShares of the party for all the parties, i.e. uniformly random
+// polynomials summing to zero, sharesOut[i] being sent to the i-th party (including the party itself).
+func (rsp *ResharingProtocol) GenKeyRefreshShares(sharesOut []*KeyRefreshShare) {
+
+	if len(sharesOut) == 0 {
+		return
+	}
+
+	ringQP, levelQ, levelP := rsp.params.RingQP(), rsp.params.QCount()-1, rsp.params.PCount()-1
+
+	last := sharesOut[len(sharesOut)-1]
+	ringQP.SubLvl(levelQ, levelP, last.Value, last.Value, last.Value)
+
+	for _, share := range sharesOut[:len(sharesOut)-1] {
+		rsp.usampler.Read(&share.Value)
+		ringQP.SubLvl(levelQ, levelP, last.Value, share.Value, last.Value)
+	}
+}
+
+// RefreshSecretKey adds the KeyRefreshShares received from all the parties to the secret key share sk of the party and
+// returns the result in skOut. The refreshed secret key shares of all the parties sum to the same collective secret key.
+func (rsp *ResharingProtocol) RefreshSecretKey(sk *rlwe.SecretKey, received []*KeyRefreshShare, skOut *rlwe.SecretKey) {
+	ringQP, levelQ, levelP := rsp.params.RingQP(), rsp.params.QCount()-1, rsp.params.PCount()-1
+	skOut.Value.Copy(sk.Value)
+	for _, share := range received {
+		ringQP.AddLvl(levelQ, levelP, skOut.Value, share.Value, skOut.Value)
+	}
+}
+
+// GenRefreshPolynomial generates a new ShamirPolynomial of degree threshold-1 whose constant coefficient is zero. Its
+// evaluations at the public points of the parties, generated with Thresholdizer.GenShamirSecretShare, are aggregated by
+// the parties to their ShamirSecretShare with Thresholdizer.AggregateShares, which refreshes the T-out-of-N sharing of
+// the collective secret key. It returns an error if threshold is smaller than 1.
+func (rsp *ResharingProtocol) GenRefreshPolynomial(threshold int) (*ShamirPolynomial, error) {
+	poly, err := rsp.thr.GenShamirPolynomial(threshold, rlwe.NewSecretKey(rsp.params))
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRefreshPolynomial: %w", err)
+	}
+	return poly, nil
+}
+
+// GenResharingPolynomial generates a new ShamirPolynomial of degree newThreshold-1 whose constant coefficient is the
+// additive share, obtained with cmb, of the party of public point own with respect to the active parties of public
+// points actives. Its evaluations at the public points of the new parties, generated with
+// Thresholdizer.GenShamirSecretShare, are aggregated by the new parties in a zero ShamirSecretShare with
+// Thresholdizer.AggregateShares, which reshares the collective secret key among the new parties with the new threshold.
+// It returns an error if cmb.GenAdditiveShare does or if newThreshold is smaller than 1.
+func (rsp *ResharingProtocol) GenResharingPolynomial(cmb *Combiner, actives []ShamirPublicPoint, own ShamirPublicPoint, share *ShamirSecretShare, newThreshold int) (*ShamirPolynomial, error) {
+
+	sk := rlwe.NewSecretKey(rsp.params)
+	if err := cmb.GenAdditiveShare(actives, own, share, sk); err != nil {
+		return nil, fmt.Errorf("cannot GenResharingPolynomial: %w", err)
+	}
+
+	poly, err := rsp.thr.GenShamirPolynomial(newThreshold, sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenResharingPolynomial: %w", err)
+	}
+
+	return poly, nil
+}
+
+// CheckRefresh checks that the CKG shares sharesNew, generated by the parties from their refreshed secret key shares,
+// commit to the same collective secret key as the CKG shares sharesOld, generated before the refresh for the same
+// common reference polynomial, i.e. that their aggregations differ by at most the sum of their errors. The parties must
+// be the same and their new CKG shares are their new SecretKeyCommitments. It returns an error otherwise, e.g. if a
+// party did not generate zero-sum KeyRefreshShares.
+func (rsp *ResharingProtocol) CheckRefresh(sharesOld, sharesNew map[ShamirPublicPoint]*CKGShare) error {
+
+	if len(sharesOld) != len(sharesNew) {
+		return errors.New("cannot CheckRefresh: the parties before and after the refresh differ")
+	}
+
+	ringQ, levelQ := rsp.params.RingQ(), rsp.params.QCount()-1
+
+	diff := ringQ.NewPoly()
+	for p, share := range sharesOld {
+		shareNew, ok := sharesNew[p]
+		if !ok {
+			return fmt.Errorf("cannot CheckRefresh: missing share of party %d after the refresh", p)
+		}
+		ringQ.AddLvl(levelQ, diff, share.Value.Q, diff)
+		ringQ.SubLvl(levelQ, diff, shareNew.Value.Q, diff)
+	}
+
+	ringQ.InvNTTLvl(levelQ, diff, diff)
+
+	bound := 2 * uint64(len(sharesOld)) * uint64(math.Ceil(6*rsp.params.Sigma()))
+	for i, qi := range ringQ.Modulus[:levelQ+1] {
+		for _, c := range diff.Coeffs[i] {
+			if c > bound && qi-c > bound {
+				return errors.New("cannot CheckRefresh: the refreshed shares do not commit to the same collective secret key")
+			}
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (share *KeyRefreshShare) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, ShareHeaderLen+share.Value.GetDataLen(true))
+	if _, err = share.Header.WriteTo(data); err != nil {
+		return nil, err
+	}
+	if _, err = share.Value.WriteTo(data[ShareHeaderLen:]); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+func (share *KeyRefreshShare) UnmarshalBinary(data []byte) (err error) {
+	var ptr int
+	if ptr, err = share.Header.Decode(data); err != nil {
+		return err
+	}
+	_, err = share.Value.DecodePolyNew(data[ptr:])
+	return err
+}
@@ -0,0 +1,143 @@
+package drlwe
+
+import (
+	"errors"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// In many deployments, the parties have one of two roles: the input parties, e.g. sensors or mobile clients, only
+// contribute to the collective public key, encrypt their inputs under it and contribute to the decryptions, whereas the
+// compute parties, e.g. servers, also generate the evaluation keys and run the key-switching protocols. The InputParty
+// and ComputeParty types bundle the state of each role, and allocate the protocols of a role, with their samplers and
+// buffers, only when they are first used, so that an input party never allocates the protocols it does not run.
+
+// InputParty is a party that only contributes to the collective public key, encrypts its inputs under it and
+// contributes to the collective decryptions, with the minimal state: its secret key share, the collective public key,
+// and the CKG and CKS protocols, allocated at their first use.
+type InputParty struct {
+	params        rlwe.Parameters
+	sk            *rlwe.SecretKey
+	sigmaSmudging float64
+	pk            *rlwe.PublicKey
+
+	ckg  *CKGProtocol
+	cks  *CKSProtocol
+	zero *rlwe.SecretKey
+}
+
+// NewInputParty creates a new InputParty with the secret key share sk. sigmaSmudging is the standard deviation of
+// the smudging noise of its decryption shares, e.g. computed with SmudgingParams.
+func NewInputParty(params rlwe.Parameters, sk *rlwe.SecretKey, sigmaSmudging float64) *InputParty {
+	return &InputParty{params: params, sk: sk, sigmaSmudging: sigmaSmudging}
+}
+
+// GenCKGShare generates the share of the party in the CKG protocol for the common reference polynomial crp.
+func (ip *InputParty) GenCKGShare(crp CKGCRP) *CKGShare {
+	if ip.ckg == nil {
+		ip.ckg = NewCKGProtocol(ip.params)
+	}
+	share := ip.ckg.AllocateShare()
+	ip.ckg.GenShare(ip.sk, crp, share)
+	return share
+}
+
+// SetPublicKey sets the collective public key, e.g. generated by the compute parties from the aggregated CKG shares.
+func (ip *InputParty) SetPublicKey(pk *rlwe.PublicKey) {
+	ip.pk = pk
+}
+
+// PublicKey returns the collective public key, or nil if it was not set.
+func (ip *InputParty) PublicKey() *rlwe.PublicKey {
+	return ip.pk
+}
+
+// NewEncryptor returns an rlwe.Encryptor under the collective public key. It returns an error if the collective public
+// key was not set.
+func (ip *InputParty) NewEncryptor() (rlwe.Encryptor, error) {
+	if ip.pk == nil {
+		return nil, errors.New("cannot NewEncryptor: the collective public key is not set")
+	}
+	return rlwe.NewEncryptor(ip.params, ip.pk), nil
+}
+
+// GenDecryptionShare generates the share of the party in the collective decryption of the ciphertext of degree 1 element
+// c1, i.e. its share in the CKS protocol to the zero secret key, at the level of c1.
+func (ip *InputParty) GenDecryptionShare(c1 *ring.Poly) *CKSShare {
+	if ip.cks == nil {
+		ip.cks = NewCKSProtocol(ip.params, ip.sigmaSmudging)
+		ip.zero = rlwe.NewSecretKey(ip.params)
+	}
+	share := ip.cks.AllocateShare(c1.Level())
+	ip.cks.GenShare(ip.sk, ip.zero, c1, share)
+	return share
+}
+
+// ComputeParty is a party that runs all the protocols of the package: the generation of the collective public key and
+// of the evaluation keys, and the key-switching protocols. Each protocol is allocated at its first use and then reused,
+// hence a ComputeParty must not be used concurrently; the protocols can be shallow-copied for concurrent use.
+type ComputeParty struct {
+	params        rlwe.Parameters
+	sk            *rlwe.SecretKey
+	sigmaSmudging float64
+	options       []ProtocolOption
+
+	ckg  *CKGProtocol
+	rkg  *RKGProtocol
+	rtg  *RTGProtocol
+	cks  *CKSProtocol
+	pcks *PCKSProtocol
+}
+
+// NewComputeParty creates a new ComputeParty with the secret key share sk. sigmaSmudging is the standard deviation of
+// the smudging noise of the CKS and PCKS protocols, e.g. computed with SmudgingParams, and the options are given to the
+// RKG and RTG protocols.
+func NewComputeParty(params rlwe.Parameters, sk *rlwe.SecretKey, sigmaSmudging float64, options ...ProtocolOption) *ComputeParty {
+	return &ComputeParty{params: params, sk: sk, sigmaSmudging: sigmaSmudging, options: options}
+}
+
+// SecretKey returns the secret key share of the party.
+func (cp *ComputeParty) SecretKey() *rlwe.SecretKey {
+	return cp.sk
+}
+
+// CKG returns the CKGProtocol of the party.
+func (cp *ComputeParty) CKG() *CKGProtocol {
+	if cp.ckg == nil {
+		cp.ckg = NewCKGProtocol(cp.params)
+	}
+	return cp.ckg
+}
+
+// RKG returns the RKGProtocol of the party.
+func (cp *ComputeParty) RKG() *RKGProtocol {
+	if cp.rkg == nil {
+		cp.rkg = NewRKGProtocol(cp.params, cp.options...)
+	}
+	return cp.rkg
+}
+
+// RTG returns the RTGProtocol of the party.
+func (cp *ComputeParty) RTG() *RTGProtocol {
+	if cp.rtg == nil {
+		cp.rtg = NewRTGProtocol(cp.params, cp.options...)
+	}
+	return cp.rtg
+}
+
+// CKS returns the CKSProtocol of the party.
+func (cp *ComputeParty) CKS() *CKSProtocol {
+	if cp.cks == nil {
+		cp.cks = NewCKSProtocol(cp.params, cp.sigmaSmudging)
+	}
+	return cp.cks
+}
+
+// PCKS returns the PCKSProtocol of the party.
+func (cp *ComputeParty) PCKS() *PCKSProtocol {
+	if cp.pcks == nil {
+		cp.pcks = NewPCKSProtocol(cp.params, cp.sigmaSmudging)
+	}
+	return cp.pcks
+}
@@ -0,0 +1,321 @@
+package drlwe
+
+import (
+	"errors"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// CKGInputs are the inputs of a party in the CKG protocol.
+type CKGInputs struct {
+	SecretKey *rlwe.SecretKey
+	CRP       CKGCRP
+}
+
+// CKGRounds is the RoundProtocol of the CKG protocol, of one round, whose output is the collective *rlwe.PublicKey.
+// Its inputs are CKGInputs.
+type CKGRounds struct {
+	*CKGProtocol
+}
+
+// Rounds returns the number of rounds of the protocol.
+func (ckg CKGRounds) Rounds() int {
+	return 1
+}
+
+// AllocateShare allocates a zero share of the round.
+func (ckg CKGRounds) AllocateShare(round int, inputs interface{}) Share {
+	return ckg.CKGProtocol.AllocateShare()
+}
+
+// GenShare generates the share of the party for the round and returns it in shareOut.
+func (ckg CKGRounds) GenShare(round int, inputs interface{}, aggregated []Share, shareOut Share) error {
+	in, ok := inputs.(CKGInputs)
+	if !ok {
+		return errors.New("cannot GenShare: inputs must be CKGInputs")
+	}
+	ckg.CKGProtocol.GenShare(in.SecretKey, in.CRP, shareOut.(*CKGShare))
+	return nil
+}
+
+// AggregateShares aggregates the shares share1 and share2 of the round and returns the result in shareOut.
+func (ckg CKGRounds) AggregateShares(round int, share1, share2, shareOut Share) {
+	ckg.CKGProtocol.AggregateShare(share1.(*CKGShare), share2.(*CKGShare), shareOut.(*CKGShare))
+}
+
+// Finalize returns the collective *rlwe.PublicKey.
+func (ckg CKGRounds) Finalize(inputs interface{}, aggregated []Share) (output interface{}, err error) {
+	in, ok := inputs.(CKGInputs)
+	if !ok {
+		return nil, errors.New("cannot Finalize: inputs must be CKGInputs")
+	}
+	pk := rlwe.NewPublicKey(ckg.params)
+	ckg.GenPublicKey(aggregated[0].(*CKGShare), in.CRP, pk)
+	return pk, nil
+}
+
+// RKGInputs are the inputs of a party in the RKG protocol.
+type RKGInputs struct {
+	SecretKey *rlwe.SecretKey
+	CRP       RKGCRP
+}
+
+// RKGRounds is the RoundProtocol of the RKG protocol, of two rounds, whose output is the collective
+// *rlwe.RelinearizationKey. Its inputs are RKGInputs. The ephemeral secret key generated in the first round is kept
+// by the RKGRounds for the second round, hence a run must be resumed with the same RKGRounds.
+type RKGRounds struct {
+	*RKGProtocol
+	ephSk *rlwe.SecretKey
+}
+
+// NewRKGRounds creates a new RKGRounds from the RKGProtocol rkg.
+func NewRKGRounds(rkg *RKGProtocol) *RKGRounds {
+	return &RKGRounds{RKGProtocol: rkg, ephSk: rlwe.NewSecretKey(rkg.params)}
+}
+
+// Rounds returns the number of rounds of the protocol.
+func (rkg *RKGRounds) Rounds() int {
+	return 2
+}
+
+// AllocateShare allocates a zero share of the round.
+func (rkg *RKGRounds) AllocateShare(round int, inputs interface{}) Share {
+	_, share, _ := rkg.RKGProtocol.AllocateShare()
+	return share
+}
+
+// GenShare generates the share of the party for the round and returns it in shareOut.
+func (rkg *RKGRounds) GenShare(round int, inputs interface{}, aggregated []Share, shareOut Share) error {
+	in, ok := inputs.(RKGInputs)
+	if !ok {
+		return errors.New("cannot GenShare: inputs must be RKGInputs")
+	}
+	if round == 0 {
+		rkg.GenShareRoundOne(in.SecretKey, in.CRP, rkg.ephSk, shareOut.(*RKGShare))
+	} else {
+		rkg.GenShareRoundTwo(rkg.ephSk, in.SecretKey, aggregated[0].(*RKGShare), shareOut.(*RKGShare))
+	}
+	return nil
+}
+
+// AggregateShares aggregates the shares share1 and share2 of the round and returns the result in shareOut.
+func (rkg *RKGRounds) AggregateShares(round int, share1, share2, shareOut Share) {
+	rkg.AggregateShare(share1.(*RKGShare), share2.(*RKGShare), shareOut.(*RKGShare))
+}
+
+// Finalize returns the collective *rlwe.RelinearizationKey.
+func (rkg *RKGRounds) Finalize(inputs interface{}, aggregated []Share) (output interface{}, err error) {
+	rlk := rlwe.NewRelinKey(rkg.params, 1)
+	rkg.GenRelinearizationKey(aggregated[0].(*RKGShare), aggregated[1].(*RKGShare), rlk)
+	return rlk, nil
+}
+
+// RTGInputs are the inputs of a party in the RTG protocol.
+type RTGInputs struct {
+	SecretKey     *rlwe.SecretKey
+	GaloisElement uint64
+	CRP           RTGCRP
+}
+
+// RTGRounds is the RoundProtocol of the RTG protocol, of one round, whose output is the collective *rlwe.SwitchingKey
+// of the Galois element. Its inputs are RTGInputs.
+type RTGRounds struct {
+	*RTGProtocol
+}
+
+// Rounds returns the number of rounds of the protocol.
+func (rtg RTGRounds) Rounds() int {
+	return 1
+}
+
+// AllocateShare allocates a zero share of the round.
+func (rtg RTGRounds) AllocateShare(round int, inputs interface{}) Share {
+	return rtg.RTGProtocol.AllocateShare()
+}
+
+// GenShare generates the share of the party for the round and returns it in shareOut.
+func (rtg RTGRounds) GenShare(round int, inputs interface{}, aggregated []Share, shareOut Share) error {
+	in, ok := inputs.(RTGInputs)
+	if !ok {
+		return errors.New("cannot GenShare: inputs must be RTGInputs")
+	}
+	rtg.RTGProtocol.GenShare(in.SecretKey, in.GaloisElement, in.CRP, shareOut.(*RTGShare))
+	return nil
+}
+
+// AggregateShares aggregates the shares share1 and share2 of the round and returns the result in shareOut.
+func (rtg RTGRounds) AggregateShares(round int, share1, share2, shareOut Share) {
+	rtg.AggregateShare(share1.(*RTGShare), share2.(*RTGShare), shareOut.(*RTGShare))
+}
+
+// Finalize returns the collective *rlwe.SwitchingKey.
+func (rtg RTGRounds) Finalize(inputs interface{}, aggregated []Share) (output interface{}, err error) {
+	in, ok := inputs.(RTGInputs)
+	if !ok {
+		return nil, errors.New("cannot Finalize: inputs must be RTGInputs")
+	}
+	swk := rlwe.NewSwitchingKey(rtg.params, rtg.params.QCount()-1, rtg.params.PCount()-1)
+	rtg.GenRotationKey(aggregated[0].(*RTGShare), in.CRP, swk)
+	return swk, nil
+}
+
+// CKSInputs are the inputs of a party in the CKS protocol.
+type CKSInputs struct {
+	SecretKeyIn  *rlwe.SecretKey
+	SecretKeyOut *rlwe.SecretKey
+	Ciphertext   *rlwe.Ciphertext
+}
+
+// CKSRounds is the RoundProtocol of the CKS protocol, of one round, whose output is the key-switched *rlwe.Ciphertext.
+// Its inputs are CKSInputs.
+type CKSRounds struct {
+	*CKSProtocol
+}
+
+// Rounds returns the number of rounds of the protocol.
+func (cks CKSRounds) Rounds() int {
+	return 1
+}
+
+// AllocateShare allocates a zero share of the round at the level of the ciphertext of the inputs.
+func (cks CKSRounds) AllocateShare(round int, inputs interface{}) Share {
+	level := cks.params.MaxLevel()
+	if in, ok := inputs.(CKSInputs); ok {
+		level = in.Ciphertext.Level()
+	}
+	return cks.CKSProtocol.AllocateShare(level)
+}
+
+// GenShare generates the share of the party for the round and returns it in shareOut.
+func (cks CKSRounds) GenShare(round int, inputs interface{}, aggregated []Share, shareOut Share) error {
+	in, ok := inputs.(CKSInputs)
+	if !ok {
+		return errors.New("cannot GenShare: inputs must be CKSInputs")
+	}
+	cks.CKSProtocol.GenShare(in.SecretKeyIn, in.SecretKeyOut, in.Ciphertext.Value[1], shareOut.(*CKSShare))
+	return nil
+}
+
+// AggregateShares aggregates the shares share1 and share2 of the round and returns the result in shareOut.
+func (cks CKSRounds) AggregateShares(round int, share1, share2, shareOut Share) {
+	cks.AggregateShare(share1.(*CKSShare), share2.(*CKSShare), shareOut.(*CKSShare))
+}
+
+// Finalize returns the key-switched *rlwe.Ciphertext.
+func (cks CKSRounds) Finalize(inputs interface{}, aggregated []Share) (output interface{}, err error) {
+	in, ok := inputs.(CKSInputs)
+	if !ok {
+		return nil, errors.New("cannot Finalize: inputs must be CKSInputs")
+	}
+	ctOut := in.Ciphertext.CopyNew()
+	cks.KeySwitch(in.Ciphertext, aggregated[0].(*CKSShare), ctOut)
+	return ctOut, nil
+}
+
+// PCKSInputs are the inputs of a party in the PCKS protocol.
+type PCKSInputs struct {
+	SecretKey  *rlwe.SecretKey
+	PublicKey  *rlwe.PublicKey
+	Ciphertext *rlwe.Ciphertext
+}
+
+// PCKSRounds is the RoundProtocol of the PCKS protocol, of one round, whose output is the key-switched
+// *rlwe.Ciphertext. Its inputs are PCKSInputs.
+type PCKSRounds struct {
+	*PCKSProtocol
+}
+
+// Rounds returns the number of rounds of the protocol.
+func (pcks PCKSRounds) Rounds() int {
+	return 1
+}
+
+// AllocateShare allocates a zero share of the round at the level of the ciphertext of the inputs.
+func (pcks PCKSRounds) AllocateShare(round int, inputs interface{}) Share {
+	level := pcks.params.MaxLevel()
+	if in, ok := inputs.(PCKSInputs); ok {
+		level = in.Ciphertext.Level()
+	}
+	return pcks.PCKSProtocol.AllocateShare(level)
+}
+
+// GenShare generates the share of the party for the round and returns it in shareOut.
+func (pcks PCKSRounds) GenShare(round int, inputs interface{}, aggregated []Share, shareOut Share) error {
+	in, ok := inputs.(PCKSInputs)
+	if !ok {
+		return errors.New("cannot GenShare: inputs must be PCKSInputs")
+	}
+	pcks.PCKSProtocol.GenShare(in.SecretKey, in.PublicKey, in.Ciphertext.Value[1], shareOut.(*PCKSShare))
+	return nil
+}
+
+// AggregateShares aggregates the shares share1 and share2 of the round and returns the result in shareOut.
+func (pcks PCKSRounds) AggregateShares(round int, share1, share2, shareOut Share) {
+	pcks.AggregateShare(share1.(*PCKSShare), share2.(*PCKSShare), shareOut.(*PCKSShare))
+}
+
+// Finalize returns the key-switched *rlwe.Ciphertext.
+func (pcks PCKSRounds) Finalize(inputs interface{}, aggregated []Share) (output interface{}, err error) {
+	in, ok := inputs.(PCKSInputs)
+	if !ok {
+		return nil, errors.New("cannot Finalize: inputs must be PCKSInputs")
+	}
+	ctOut := in.Ciphertext.CopyNew()
+	pcks.KeySwitch(in.Ciphertext, aggregated[0].(*PCKSShare), ctOut)
+	return ctOut, nil
+}
+
+// RelinInputs are the inputs of a party in the InteractiveRelinProtocol.
+type RelinInputs struct {
+	SecretKey  *rlwe.SecretKey
+	Ciphertext *rlwe.Ciphertext
+}
+
+// RelinRounds is the RoundProtocol of the InteractiveRelinProtocol, of one round, whose output is the relinearized
+// *rlwe.Ciphertext. Its inputs are RelinInputs.
+type RelinRounds struct {
+	*InteractiveRelinProtocol
+}
+
+// Rounds returns the number of rounds of the protocol.
+func (rp RelinRounds) Rounds() int {
+	return 1
+}
+
+// AllocateShare allocates a zero share of the round at the level of the ciphertext of the inputs.
+func (rp RelinRounds) AllocateShare(round int, inputs interface{}) Share {
+	level := rp.params.MaxLevel()
+	if in, ok := inputs.(RelinInputs); ok {
+		level = in.Ciphertext.Level()
+	}
+	return rp.InteractiveRelinProtocol.AllocateShare(level)
+}
+
+// GenShare generates the share of the party for the round and returns it in shareOut.
+func (rp RelinRounds) GenShare(round int, inputs interface{}, aggregated []Share, shareOut Share) error {
+	in, ok := inputs.(RelinInputs)
+	if !ok {
+		return errors.New("cannot GenShare: inputs must be RelinInputs")
+	}
+	if in.Ciphertext.Degree() != 2 {
+		return errors.New("cannot GenShare: the degree of the ciphertext must be 2")
+	}
+	rp.InteractiveRelinProtocol.GenShare(in.SecretKey, in.Ciphertext, shareOut.(*DegreeReductionShare))
+	return nil
+}
+
+// AggregateShares aggregates the shares share1 and share2 of the round and returns the result in shareOut.
+func (rp RelinRounds) AggregateShares(round int, share1, share2, shareOut Share) {
+	rp.AggregateShare(share1.(*DegreeReductionShare), share2.(*DegreeReductionShare), shareOut.(*DegreeReductionShare))
+}
+
+// Finalize returns the relinearized *rlwe.Ciphertext.
+func (rp RelinRounds) Finalize(inputs interface{}, aggregated []Share) (output interface{}, err error) {
+	in, ok := inputs.(RelinInputs)
+	if !ok {
+		return nil, errors.New("cannot Finalize: inputs must be RelinInputs")
+	}
+	ctOut := in.Ciphertext.CopyNew()
+	rp.Relinearize(in.Ciphertext, aggregated[0].(*DegreeReductionShare), ctOut)
+	return ctOut, nil
+}
@@ -0,0 +1,78 @@
+package drlwe
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// SmudgingParams returns the standard deviation sigmaSmudging of the smudging (or flooding) noise that each party must
+// add to its share in the collective key-switching and decryption protocols (CKS, PCKS and the protocols built on them,
+// e.g. E2S and the refresh protocols), so that the output of the protocol is statistically independent of the noise
+// of the input ciphertext up to a statistical distance of 2^-securityParameter, even if all the other parties collude.
+// The returned value is meant to be passed as the sigmaSmudging argument of the protocol constructors.
+//
+// noiseBound is a bound on the infinity norm of the noise of the input ciphertext. Since the statistical distance
+// between two Gaussian distributions of standard deviation sigma whose centers are at distance d is at most d/(2*sigma),
+// and the noise of the ciphertext has an l2 norm of at most sqrt(N)*noiseBound, the smudging noise of a single honest
+// party must have a standard deviation of at least sqrt(N)*noiseBound*2^(securityParameter-1).
+//
+// decryptionBound is the bound on the infinity norm of the noise of the output of the protocol below which it can be
+// decrypted, which depends on the scheme, e.g. Δ/2 = Q/(2t) for BFV, or Q/2 minus a bound on the scaled message for
+// CKKS, where Q is the modulus at the level of the ciphertext.
+//
+// It also returns outputNoiseBound, a bound on the infinity norm of the noise of the output of the protocol, which
+// accumulates the input noise and the smudging noise of the nbParties parties. It returns an error if the arguments
+// are invalid, if the smudging noise cannot be sampled for the moduli of the parameters, i.e. if 6*sigmaSmudging is not
+// smaller than the smallest modulus of Q, if the shares cannot be proven by CKSProtocol.GenProof, whose masks must fit
+// in an int64, or if outputNoiseBound is not smaller than decryptionBound, in which case the
+// output of the protocol cannot be decrypted. The parameters must then be changed, e.g. by reducing the noise of the
+// input ciphertext or by using larger moduli.
+func SmudgingParams(params rlwe.Parameters, securityParameter, nbParties int, noiseBound, decryptionBound float64) (sigmaSmudging, outputNoiseBound float64, err error) {
+
+	if securityParameter < 1 {
+		return 0, 0, errors.New("cannot SmudgingParams: securityParameter must be positive")
+	}
+
+	if nbParties < 1 {
+		return 0, 0, errors.New("cannot SmudgingParams: nbParties must be positive")
+	}
+
+	if noiseBound < 0 || math.IsNaN(noiseBound) || math.IsInf(noiseBound, 0) {
+		return 0, 0, errors.New("cannot SmudgingParams: noiseBound must be a non-negative real")
+	}
+
+	if !(decryptionBound > 0) || math.IsInf(decryptionBound, 0) {
+		return 0, 0, errors.New("cannot SmudgingParams: decryptionBound must be a positive real")
+	}
+
+	// The noise bound is at least 1, as the noise is an integer and the rounding errors are not accounted for.
+	noiseBound = math.Max(noiseBound, 1)
+
+	sigmaSmudging = math.Sqrt(float64(params.N())) * noiseBound * math.Exp2(float64(securityParameter-1))
+
+	qMin := params.Q()[0]
+	for _, qi := range params.Q() {
+		if qi < qMin {
+			qMin = qi
+		}
+	}
+
+	if 6*sigmaSmudging >= float64(qMin) {
+		return 0, 0, fmt.Errorf("cannot SmudgingParams: the smudging noise of standard deviation 2^%.2f cannot be sampled modulo the smallest modulus 2^%.2f", math.Log2(sigmaSmudging), math.Log2(float64(qMin)))
+	}
+
+	if maxBound := maxSmudgingBound(params); math.Ceil(6*sigmaSmudging) > maxBound {
+		return 0, 0, fmt.Errorf("cannot SmudgingParams: the smudging noise of standard deviation 2^%.2f is larger than the largest bound 2^%.2f of the share proofs", math.Log2(sigmaSmudging), math.Log2(maxBound))
+	}
+
+	outputNoiseBound = noiseBound + float64(nbParties)*math.Floor(6*sigmaSmudging)
+
+	if outputNoiseBound >= decryptionBound {
+		return 0, 0, fmt.Errorf("cannot SmudgingParams: the output noise bound 2^%.2f is not smaller than the decryption bound 2^%.2f", math.Log2(outputNoiseBound), math.Log2(decryptionBound))
+	}
+
+	return sigmaSmudging, outputNoiseBound, nil
+}
@@ -0,0 +1,183 @@
+package drlwe
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// ShamirPublicPoint is the public point of a party in a T-out-of-N threshold secret sharing, i.e. the point at which
+// the Shamir polynomials are evaluated to generate its shares. It also identifies the party in a ShareAggregator.
+// The public points must be non-zero, distinct and smaller than the moduli of the parameters.
+type ShamirPublicPoint uint64
+
+// ShamirPolynomial is a polynomial of degree T-1 whose coefficients are polynomials of R_QP, and whose constant
+// coefficient is a secret key. Its evaluations at T distinct public points determine the secret key.
+type ShamirPolynomial struct {
+	Coeffs []rlwe.PolyQP
+}
+
+// ShamirSecretShare is the share of a party in a T-out-of-N threshold secret sharing of a secret key, i.e. the sum of
+// the evaluations at its public point of the Shamir polynomials of all the parties.
+type ShamirSecretShare struct {
+	rlwe.PolyQP
+}
+
+// Thresholdizer generates the T-out-of-N threshold secret sharing of the secret key of a party: it samples a
+// ShamirPolynomial whose constant coefficient is the secret key, and evaluates it at the public points of the parties.
+// Each party sums the shares it receives from all the parties in its ShamirSecretShare of the collective secret key.
+type Thresholdizer struct {
+	params   rlwe.Parameters
+	usampler rlwe.UniformSamplerQP
+}
+
+// NewThresholdizer creates a new Thresholdizer.
+func NewThresholdizer(params rlwe.Parameters) *Thresholdizer {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return &Thresholdizer{params: params, usampler: rlwe.NewUniformSamplerQP(params, prng, params.RingQP())}
+}
+
+// GenShamirPolynomial generates a new ShamirPolynomial of degree threshold-1, whose constant coefficient is the secret
+// key sk and whose other coefficients are uniformly random. It returns an error if threshold is smaller than 1.
+func (thr *Thresholdizer) GenShamirPolynomial(threshold int, sk *rlwe.SecretKey) (*ShamirPolynomial, error) {
+
+	if threshold < 1 {
+		return nil, fmt.Errorf("cannot GenShamirPolynomial: threshold %d is smaller than 1", threshold)
+	}
+
+	ringQP := thr.params.RingQP()
+
+	poly := &ShamirPolynomial{Coeffs: make([]rlwe.PolyQP, threshold)}
+	poly.Coeffs[0] = sk.Value.CopyNew()
+	for i := 1; i < threshold; i++ {
+		poly.Coeffs[i] = ringQP.NewPoly()
+		thr.usampler.Read(&poly.Coeffs[i])
+	}
+
+	return poly, nil
+}
+
+// AllocateThresholdSecretShare allocates a ShamirSecretShare.
+func (thr *Thresholdizer) AllocateThresholdSecretShare() *ShamirSecretShare {
+	return &ShamirSecretShare{thr.params.RingQP().NewPoly()}
+}
+
+// GenShamirSecretShare evaluates the ShamirPolynomial poly at the public point of the recipient and returns the
+// result in shareOut. The method panics if the public point is zero.
+func (thr *Thresholdizer) GenShamirSecretShare(recipient ShamirPublicPoint, poly *ShamirPolynomial, shareOut *ShamirSecretShare) {
+
+	if recipient == 0 {
+		panic("cannot GenShamirSecretShare: the public point must be non-zero")
+	}
+
+	ringQ, ringP := thr.params.RingQ(), thr.params.RingP()
+
+	// Horner evaluation: share = (...(c_(T-1) * x + c_(T-2)) * x + ...) * x + c_0
+	last := len(poly.Coeffs) - 1
+	shareOut.Copy(poly.Coeffs[last])
+
+	for k := last - 1; k >= 0; k-- {
+		hornerStep(ringQ, uint64(recipient), poly.Coeffs[k].Q, shareOut.Q)
+		if ringP != nil {
+			hornerStep(ringP, uint64(recipient), poly.Coeffs[k].P, shareOut.P)
+		}
+	}
+}
+
+// hornerStep sets acc to acc * x + c.
+func hornerStep(r *ring.Ring, x uint64, c, acc *ring.Poly) {
+	for i, qi := range r.Modulus {
+		xMont := ring.MForm(x%qi, qi, r.BredParams[i])
+		ring.MulScalarMontgomeryVec(acc.Coeffs[i], acc.Coeffs[i], xMont, qi, r.MredParams[i])
+		ring.AddVec(acc.Coeffs[i], c.Coeffs[i], acc.Coeffs[i], qi)
+	}
+}
+
+// AggregateShares aggregates the shares share1 and share2 and returns the result in shareOut.
+func (thr *Thresholdizer) AggregateShares(share1, share2, shareOut *ShamirSecretShare) {
+	thr.params.RingQP().AddLvl(thr.params.QCount()-1, thr.params.PCount()-1, share1.PolyQP, share2.PolyQP, shareOut.PolyQP)
+}
+
+// Combiner converts the ShamirSecretShare of a party into an additive share of the collective secret key with respect
+// to a set of at least T active parties, i.e. such that the sum of the additive shares of the active parties is the
+// collective secret key. The additive shares are then used as secret keys in the multiparty protocols, whose shares
+// can only be aggregated among the same set of active parties.
+type Combiner struct {
+	params    rlwe.Parameters
+	threshold int
+}
+
+// NewCombiner creates a new Combiner for a T-out-of-N threshold secret sharing with T = threshold.
+func NewCombiner(params rlwe.Parameters, threshold int) *Combiner {
+	return &Combiner{params: params, threshold: threshold}
+}
+
+// GenAdditiveShare multiplies the ShamirSecretShare share of the party of public point own by its Lagrange coefficient
+// with respect to the active parties of public points actives, and returns the result in skOut. It returns an error if
+// there are less than T active parties, if their public points are not non-zero and distinct or if own is not active.
+func (cmb *Combiner) GenAdditiveShare(actives []ShamirPublicPoint, own ShamirPublicPoint, share *ShamirSecretShare, skOut *rlwe.SecretKey) (err error) {
+
+	if len(actives) < cmb.threshold {
+		return fmt.Errorf("cannot GenAdditiveShare: %d active parties but the threshold is %d", len(actives), cmb.threshold)
+	}
+
+	if err = checkPublicPoints(actives); err != nil {
+		return fmt.Errorf("cannot GenAdditiveShare: %w", err)
+	}
+
+	var isActive bool
+	for _, x := range actives {
+		isActive = isActive || x == own
+	}
+
+	if !isActive {
+		return fmt.Errorf("cannot GenAdditiveShare: the party %d is not active", own)
+	}
+
+	lagrangeMul(cmb.params.RingQ(), actives, own, share.Q, skOut.Value.Q)
+	if ringP := cmb.params.RingP(); ringP != nil {
+		lagrangeMul(ringP, actives, own, share.P, skOut.Value.P)
+	}
+
+	return
+}
+
+// lagrangeMul sets pOut to p times the Lagrange coefficient prod_(x != own) x/(x - own) of own among actives.
+func lagrangeMul(r *ring.Ring, actives []ShamirPublicPoint, own ShamirPublicPoint, p, pOut *ring.Poly) {
+	for i, qi := range r.Modulus {
+
+		bredParams := r.BredParams[i]
+
+		num, den := uint64(1), uint64(1)
+		for _, x := range actives {
+			if x != own {
+				num = ring.BRed(num, uint64(x)%qi, qi, bredParams)
+				den = ring.BRed(den, ring.CRed(uint64(x)%qi+qi-uint64(own)%qi, qi), qi, bredParams)
+			}
+		}
+
+		lambda := ring.BRed(num, ring.ModExp(den, qi-2, qi), qi, bredParams)
+
+		ring.MulScalarMontgomeryVec(p.Coeffs[i], pOut.Coeffs[i], ring.MForm(lambda, qi, bredParams), qi, r.MredParams[i])
+	}
+}
+
+// checkPublicPoints returns an error if the public points are not non-zero and distinct.
+func checkPublicPoints(points []ShamirPublicPoint) error {
+	seen := make(map[ShamirPublicPoint]bool, len(points))
+	for _, x := range points {
+		if x == 0 {
+			return fmt.Errorf("the public points must be non-zero")
+		}
+		if seen[x] {
+			return fmt.Errorf("the public point %d is duplicated", x)
+		}
+		seen[x] = true
+	}
+	return nil
+}
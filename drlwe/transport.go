@@ -0,0 +1,279 @@
+package drlwe
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Transport exchanges the marshaled shares of the rounds of the multiparty protocols among the parties. Broadcast
+// sends the share of the party for the round to all the other parties, and Receive returns the next share received for
+// the round from another party, blocking until a share is received or ctx is done. A share may be received more than
+// once, e.g. if it was broadcast again after a retry.
+//
+// MemoryNetwork implements it in memory, e.g. for the tests, and ConnTransport over stream connections, e.g. TLS
+// connections between the parties.
+type Transport interface {
+	Broadcast(ctx context.Context, round int, share []byte) error
+	Receive(ctx context.Context, round int) (party ShamirPublicPoint, share []byte, err error)
+}
+
+// transportMessage is a share received by a party.
+type transportMessage struct {
+	party ShamirPublicPoint
+	share []byte
+}
+
+// transportInboxes are the inboxes of the rounds of a party.
+type transportInboxes struct {
+	mu      sync.Mutex
+	size    int
+	inboxes map[int]chan transportMessage
+}
+
+// inbox returns the inbox of the round, whose buffer holds size shares.
+func (in *transportInboxes) inbox(round int) chan transportMessage {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.inboxes == nil {
+		in.inboxes = map[int]chan transportMessage{}
+	}
+	if in.inboxes[round] == nil {
+		in.inboxes[round] = make(chan transportMessage, in.size)
+	}
+	return in.inboxes[round]
+}
+
+// deliver delivers the share of the party for the round, blocking while the inbox is full or until ctx is done.
+func (in *transportInboxes) deliver(ctx context.Context, party ShamirPublicPoint, round int, share []byte) error {
+	select {
+	case in.inbox(round) <- transportMessage{party, share}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// MemoryNetwork is an in-memory network among parties, e.g. to test the multiparty protocols without a network. The
+// shares are delivered immediately.
+type MemoryNetwork struct {
+	parties []ShamirPublicPoint
+	inboxes map[ShamirPublicPoint]*transportInboxes
+}
+
+// NewMemoryNetwork creates a new MemoryNetwork among the parties of public points parties.
+func NewMemoryNetwork(parties []ShamirPublicPoint) *MemoryNetwork {
+	net := &MemoryNetwork{parties: append([]ShamirPublicPoint(nil), parties...), inboxes: make(map[ShamirPublicPoint]*transportInboxes, len(parties))}
+	for _, p := range parties {
+		net.inboxes[p] = &transportInboxes{size: 16 * len(parties)}
+	}
+	return net
+}
+
+// Transport returns the MemoryTransport of the party of public point self, which must be a party of the network.
+func (net *MemoryNetwork) Transport(self ShamirPublicPoint) *MemoryTransport {
+	return &MemoryTransport{net: net, self: self}
+}
+
+// MemoryTransport is the Transport of a party of a MemoryNetwork.
+type MemoryTransport struct {
+	net  *MemoryNetwork
+	self ShamirPublicPoint
+}
+
+// Broadcast delivers the share of the party for the round to the other parties.
+func (tr *MemoryTransport) Broadcast(ctx context.Context, round int, share []byte) error {
+	for _, p := range tr.net.parties {
+		if p == tr.self {
+			continue
+		}
+		if err := tr.Send(ctx, round, p, share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send delivers the share of the party for the round to the party to only, e.g. its Shamir share of the secret key
+// generated by a Thresholdizer. It returns an error if to is not a party of the network.
+func (tr *MemoryTransport) Send(ctx context.Context, round int, to ShamirPublicPoint, share []byte) error {
+	inboxes, ok := tr.net.inboxes[to]
+	if !ok {
+		return fmt.Errorf("cannot Send: unknown party %d", to)
+	}
+	return inboxes.deliver(ctx, tr.self, round, append([]byte(nil), share...))
+}
+
+// Receive returns the next share delivered to the party for the round.
+func (tr *MemoryTransport) Receive(ctx context.Context, round int) (ShamirPublicPoint, []byte, error) {
+	select {
+	case msg := <-tr.net.inboxes[tr.self].inbox(round):
+		return msg.party, msg.share, nil
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+}
+
+// maxConnShareLen is the size in bytes of the largest share accepted by a ConnTransport, so that a peer cannot exhaust
+// the memory of the party with a hostile length.
+const maxConnShareLen = 1 << 30
+
+// ConnTransport is a Transport over stream connections between the parties, e.g. TLS connections, which authenticate
+// the peers: the shares read from the connection to a peer are attributed to this peer. Each share is written as a
+// frame made of the round on 8 bytes, the length of the share on 4 bytes and the share, and the frames read from each
+// connection are delivered to the inboxes of their round by a goroutine, until the connection is closed.
+type ConnTransport struct {
+	conns   map[ShamirPublicPoint]io.ReadWriteCloser
+	writeMu map[ShamirPublicPoint]*sync.Mutex
+	inboxes transportInboxes
+
+	done   chan struct{}
+	failed chan error
+	close  sync.Once
+}
+
+// NewConnTransport creates a new ConnTransport for a party connected to each other party p by conns[p], and starts
+// reading the shares from the connections. The connections are closed by Close.
+func NewConnTransport(conns map[ShamirPublicPoint]io.ReadWriteCloser) *ConnTransport {
+
+	tr := &ConnTransport{
+		conns:   make(map[ShamirPublicPoint]io.ReadWriteCloser, len(conns)),
+		writeMu: make(map[ShamirPublicPoint]*sync.Mutex, len(conns)),
+		inboxes: transportInboxes{size: 16 * (len(conns) + 1)},
+		done:    make(chan struct{}),
+		failed:  make(chan error, len(conns)),
+	}
+
+	for p, conn := range conns {
+		tr.conns[p] = conn
+		tr.writeMu[p] = new(sync.Mutex)
+		go tr.read(p, conn)
+	}
+
+	return tr
+}
+
+// read delivers the shares read from the connection to the party p until the connection is closed. An error other
+// than the closing of the connection by the peer is returned by the pending and next calls to Receive.
+func (tr *ConnTransport) read(p ShamirPublicPoint, conn io.Reader) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-tr.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var header [12]byte
+	for {
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			if !errors.Is(err, io.EOF) {
+				tr.fail(p, err)
+			}
+			return
+		}
+
+		round := int(binary.LittleEndian.Uint64(header[:]))
+		size := binary.LittleEndian.Uint32(header[8:])
+
+		if size > maxConnShareLen {
+			tr.fail(p, fmt.Errorf("share of %d bytes is larger than %d bytes", size, maxConnShareLen))
+			return
+		}
+
+		share := make([]byte, size)
+		if _, err := io.ReadFull(conn, share); err != nil {
+			tr.fail(p, err)
+			return
+		}
+
+		if tr.inboxes.deliver(ctx, p, round, share) != nil {
+			return
+		}
+	}
+}
+
+func (tr *ConnTransport) fail(p ShamirPublicPoint, err error) {
+	select {
+	case <-tr.done:
+	default:
+		tr.failed <- fmt.Errorf("connection to party %d failed: %w", p, err)
+	}
+}
+
+// Broadcast writes the share of the party for the round on the connections to all the other parties.
+func (tr *ConnTransport) Broadcast(ctx context.Context, round int, share []byte) error {
+	for p := range tr.conns {
+		if err := tr.Send(ctx, round, p, share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send writes the share of the party for the round on the connection to the party to only, e.g. its Shamir share of
+// the secret key generated by a Thresholdizer. It returns an error if there is no connection to the party to.
+func (tr *ConnTransport) Send(ctx context.Context, round int, to ShamirPublicPoint, share []byte) error {
+
+	conn, ok := tr.conns[to]
+	if !ok {
+		return fmt.Errorf("cannot Send: no connection to party %d", to)
+	}
+
+	if len(share) > maxConnShareLen {
+		return fmt.Errorf("cannot Send: share of %d bytes is larger than %d bytes", len(share), maxConnShareLen)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	frame := make([]byte, 12+len(share))
+	binary.LittleEndian.PutUint64(frame, uint64(round))
+	binary.LittleEndian.PutUint32(frame[8:], uint32(len(share)))
+	copy(frame[12:], share)
+
+	tr.writeMu[to].Lock()
+	defer tr.writeMu[to].Unlock()
+
+	if _, err := conn.Write(frame); err != nil {
+		return fmt.Errorf("cannot Send: %w", err)
+	}
+
+	return nil
+}
+
+// Receive returns the next share read for the round from the connection to another party. It returns an error if a
+// connection failed.
+func (tr *ConnTransport) Receive(ctx context.Context, round int) (ShamirPublicPoint, []byte, error) {
+	select {
+	case msg := <-tr.inboxes.inbox(round):
+		return msg.party, msg.share, nil
+	case err := <-tr.failed:
+		tr.failed <- err
+		return 0, nil, fmt.Errorf("cannot Receive: %w", err)
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case <-tr.done:
+		return 0, nil, errors.New("cannot Receive: the transport is closed")
+	}
+}
+
+// Close closes the connections to the other parties.
+func (tr *ConnTransport) Close() (err error) {
+	tr.close.Do(func() {
+		close(tr.done)
+		for _, conn := range tr.conns {
+			if cerr := conn.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	})
+	return
+}
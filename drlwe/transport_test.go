@@ -0,0 +1,153 @@
+package drlwe
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/stretchr/testify/require"
+)
+
+// newPipeTransports returns the ConnTransports of the parties connected pairwise by in-memory connections.
+func newPipeTransports(parties []ShamirPublicPoint) map[ShamirPublicPoint]*ConnTransport {
+	conns := make(map[ShamirPublicPoint]map[ShamirPublicPoint]io.ReadWriteCloser, len(parties))
+	for _, p := range parties {
+		conns[p] = make(map[ShamirPublicPoint]io.ReadWriteCloser)
+	}
+	for i, p := range parties {
+		for _, q := range parties[i+1:] {
+			conns[p][q], conns[q][p] = net.Pipe()
+		}
+	}
+	transports := make(map[ShamirPublicPoint]*ConnTransport, len(parties))
+	for _, p := range parties {
+		transports[p] = NewConnTransport(conns[p])
+	}
+	return transports
+}
+
+// runCKG runs the CKG protocol among the parties over their transports and returns the public keys they generate.
+func runCKG(t *testing.T, testCtx testContext, parties []ShamirPublicPoint, transports map[ShamirPublicPoint]Transport) []*rlwe.PublicKey {
+
+	params := testCtx.params
+	crp := NewCKGProtocol(params).SampleCRP(testCtx.crs)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pks := make([]*rlwe.PublicKey, len(parties))
+	errs := make(chan error, len(parties))
+
+	for i, p := range parties {
+		go func(i int, tr Transport) {
+			ckg := NewCKGProtocol(params)
+			share := ckg.AllocateShare()
+			ckg.GenShare(testCtx.skShares[i], crp, share)
+
+			data, err := share.MarshalBinary()
+			if err == nil {
+				err = tr.Broadcast(ctx, 0, data)
+			}
+
+			received := make(map[ShamirPublicPoint]bool)
+			for err == nil && len(received) < len(parties)-1 {
+				var party ShamirPublicPoint
+				if party, data, err = tr.Receive(ctx, 0); err != nil {
+					break
+				}
+				peerShare := new(CKGShare)
+				if err = peerShare.UnmarshalBinary(data); err == nil && !received[party] {
+					received[party] = true
+					ckg.AggregateShare(share, peerShare, share)
+				}
+			}
+
+			if err == nil {
+				pks[i] = rlwe.NewPublicKey(params)
+				ckg.GenPublicKey(share, crp, pks[i])
+			}
+			errs <- err
+		}(i, transports[p])
+	}
+
+	for range parties {
+		require.NoError(t, <-errs)
+	}
+
+	return pks
+}
+
+func TestTransport(t *testing.T) {
+
+	params, err := rlwe.NewParametersFromLiteral(TestParams[0])
+	require.NoError(t, err)
+	testCtx := newTestContext(params)
+
+	parties := make([]ShamirPublicPoint, nbParties)
+	for i := range parties {
+		parties[i] = ShamirPublicPoint(i + 1)
+	}
+
+	t.Run("Memory/CKG", func(t *testing.T) {
+		network := NewMemoryNetwork(parties)
+		transports := make(map[ShamirPublicPoint]Transport, len(parties))
+		for _, p := range parties {
+			transports[p] = network.Transport(p)
+		}
+		pks := runCKG(t, testCtx, parties, transports)
+		for _, pk := range pks[1:] {
+			require.True(t, pk.Equals(pks[0]))
+		}
+	})
+
+	t.Run("Memory/Send", func(t *testing.T) {
+		network := NewMemoryNetwork(parties)
+		ctx := context.Background()
+
+		require.NoError(t, network.Transport(parties[0]).Send(ctx, 3, parties[1], []byte{1, 2, 3}))
+		require.Error(t, network.Transport(parties[0]).Send(ctx, 3, ShamirPublicPoint(nbParties+1), []byte{1}))
+
+		party, share, err := network.Transport(parties[1]).Receive(ctx, 3)
+		require.NoError(t, err)
+		require.Equal(t, parties[0], party)
+		require.Equal(t, []byte{1, 2, 3}, share)
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, _, err = network.Transport(parties[2]).Receive(ctx, 3)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Conn/CKG", func(t *testing.T) {
+		connTransports := newPipeTransports(parties)
+		transports := make(map[ShamirPublicPoint]Transport, len(parties))
+		for p, tr := range connTransports {
+			transports[p] = tr
+			defer tr.Close()
+		}
+		pks := runCKG(t, testCtx, parties, transports)
+		for _, pk := range pks[1:] {
+			require.True(t, pk.Equals(pks[0]))
+		}
+	})
+
+	t.Run("Conn/InvalidLength", func(t *testing.T) {
+		conn, peer := net.Pipe()
+		tr := NewConnTransport(map[ShamirPublicPoint]io.ReadWriteCloser{2: conn})
+		defer tr.Close()
+
+		var header [12]byte
+		binary.LittleEndian.PutUint32(header[8:], maxConnShareLen+1)
+		go peer.Write(header[:])
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		_, _, err := tr.Receive(ctx, 0)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
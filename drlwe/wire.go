@@ -0,0 +1,185 @@
+package drlwe
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ShareFormatVersion is the version of the wire format of the marshaled shares. It is incremented each time the
+// encoding of a share changes, so that the parties of a fleet running different versions detect it instead of
+// aggregating shares they decode differently.
+const ShareFormatVersion uint8 = 1
+
+// ShareHeaderLen is the size in bytes of a marshaled ShareHeader.
+const ShareHeaderLen = 2 + ParametersDigestLen
+
+// ErrIncompatibleShare is the error returned when a marshaled share was generated with another format version,
+// protocol or parameters than the share it is unmarshaled on.
+var ErrIncompatibleShare = errors.New("incompatible share")
+
+// ProtocolID identifies the protocol in which a share is generated.
+type ProtocolID uint8
+
+// The identifiers of the protocols of the drlwe, dbfv and dckks packages. The values are part of the wire format and
+// must not be changed.
+const (
+	ProtocolUnknown ProtocolID = iota
+	ProtocolCKG
+	ProtocolRKG
+	ProtocolRTG
+	ProtocolRTGBatch
+	ProtocolCKS
+	ProtocolPCKS
+	ProtocolBFVMaskedTransform
+	ProtocolCKKSMaskedTransform
+	ProtocolSchemeSwitching
+	ProtocolKeyRefresh
+	ProtocolDegreeReduction
+	ProtocolMultiPCKS
+	ProtocolMigration
+	ProtocolCKSBatch
+	ProtocolBFVRefreshBatch
+	ProtocolSKG
+)
+
+// String returns the name of the protocol.
+func (id ProtocolID) String() string {
+	switch id {
+	case ProtocolCKG:
+		return "CKG"
+	case ProtocolRKG:
+		return "RKG"
+	case ProtocolRTG:
+		return "RTG"
+	case ProtocolRTGBatch:
+		return "RTGBatch"
+	case ProtocolCKS:
+		return "CKS"
+	case ProtocolPCKS:
+		return "PCKS"
+	case ProtocolBFVMaskedTransform:
+		return "BFVMaskedTransform"
+	case ProtocolCKKSMaskedTransform:
+		return "CKKSMaskedTransform"
+	case ProtocolSchemeSwitching:
+		return "SchemeSwitching"
+	case ProtocolKeyRefresh:
+		return "KeyRefresh"
+	case ProtocolDegreeReduction:
+		return "DegreeReduction"
+	case ProtocolMultiPCKS:
+		return "MultiPCKS"
+	case ProtocolMigration:
+		return "Migration"
+	case ProtocolCKSBatch:
+		return "CKSBatch"
+	case ProtocolBFVRefreshBatch:
+		return "BFVRefreshBatch"
+	case ProtocolSKG:
+		return "SKG"
+	default:
+		return fmt.Sprintf("Protocol(%d)", uint8(id))
+	}
+}
+
+// ParametersDigestLen is the size in bytes of a ParametersDigest.
+const ParametersDigestLen = 8
+
+// ParametersDigest is a short digest of the parameters of a protocol, which identifies them in the marshaled shares.
+type ParametersDigest [ParametersDigestLen]byte
+
+// NewParametersDigest returns the digest of the marshaled parameters, e.g. rlwe.Parameters, bfv.Parameters or
+// ckks.Parameters. It panics if the parameters cannot be marshaled.
+func NewParametersDigest(params ...encoding.BinaryMarshaler) (digest ParametersDigest) {
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, p := range params {
+		data, err := p.MarshalBinary()
+		if err != nil {
+			panic(fmt.Errorf("cannot NewParametersDigest: %w", err))
+		}
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(data)))
+		h.Write(buf[:])
+		h.Write(data)
+	}
+
+	copy(digest[:], h.Sum(nil))
+	return
+}
+
+// ShareHeader is the header of the marshaled shares, which identifies the format version, the protocol and the
+// parameters with which the share was generated. The shares allocated by the protocols carry the header of the
+// protocol, and unmarshaling a share generated with another format version, protocol or parameters on them returns an
+// error wrapping ErrIncompatibleShare. A share with a zero header, e.g. allocated with new, adopts the header of the
+// share unmarshaled on it, provided that its format version is supported.
+type ShareHeader struct {
+	Version  uint8
+	Protocol ProtocolID
+	Params   ParametersDigest
+}
+
+// NewShareHeader returns the header of the shares of the protocol for the given parameters, at the current format
+// version.
+func NewShareHeader(protocol ProtocolID, params ...encoding.BinaryMarshaler) ShareHeader {
+	return ShareHeader{Version: ShareFormatVersion, Protocol: protocol, Params: NewParametersDigest(params...)}
+}
+
+// IsZero returns true if the header is the zero header.
+func (h ShareHeader) IsZero() bool {
+	return h == ShareHeader{}
+}
+
+// WriteTo writes the header on data and returns the number of bytes written.
+func (h ShareHeader) WriteTo(data []byte) (ptr int, err error) {
+
+	if len(data) < ShareHeaderLen {
+		return 0, errors.New("cannot WriteTo: buffer is too small")
+	}
+
+	data[0] = h.Version
+	data[1] = uint8(h.Protocol)
+	copy(data[2:], h.Params[:])
+
+	return ShareHeaderLen, nil
+}
+
+// Decode decodes a header from data and returns the number of bytes read. It returns an error wrapping
+// ErrIncompatibleShare if the format version is not supported, or if the target header is not zero and differs from
+// the decoded one. Otherwise, the target header is set to the decoded one.
+func (h *ShareHeader) Decode(data []byte) (ptr int, err error) {
+
+	if len(data) < ShareHeaderLen {
+		return 0, errors.New("cannot Decode: data is too short")
+	}
+
+	var dec ShareHeader
+	dec.Version = data[0]
+	dec.Protocol = ProtocolID(data[1])
+	copy(dec.Params[:], data[2:])
+
+	if dec.Version != ShareFormatVersion {
+		return 0, fmt.Errorf("cannot Decode: %w: format version %d is not supported (current is %d)", ErrIncompatibleShare, dec.Version, ShareFormatVersion)
+	}
+
+	if !h.IsZero() {
+		if dec.Protocol != h.Protocol {
+			return 0, fmt.Errorf("cannot Decode: %w: share of protocol %s instead of %s", ErrIncompatibleShare, dec.Protocol, h.Protocol)
+		}
+		if dec.Params != h.Params {
+			return 0, fmt.Errorf("cannot Decode: %w: share generated with parameters %x instead of %x", ErrIncompatibleShare, dec.Params, h.Params)
+		}
+	}
+
+	*h = dec
+
+	return ShareHeaderLen, nil
+}
@@ -0,0 +1,83 @@
+package dsession
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+)
+
+// Aggregator is a drlwe.StreamAggregator of the tagged shares of the parties of a session for a round of a protocol
+// domain. The tagged shares are checked before they are aggregated, so that the shares replayed from another session,
+// protocol or round and the misattributed shares are rejected.
+type Aggregator struct {
+	*drlwe.StreamAggregator
+	session  *Session
+	domain   string
+	round    int
+	newShare func() drlwe.Share
+	mu       sync.Mutex
+}
+
+// NewAggregator creates a new Aggregator of the shares of all the parties of the session for the round of the protocol
+// domain, which aggregates them in shareOut with the aggregation function aggregate. shareOut must be a zero share, and
+// newShare must allocate a share on which the tagged shares are unmarshaled.
+func (s *Session) NewAggregator(domain string, round int, shareOut drlwe.Share, aggregate drlwe.AggregateFunc, newShare func() drlwe.Share) (*Aggregator, error) {
+
+	agg, err := drlwe.NewStreamAggregator(s.Points(), shareOut, aggregate)
+	if err != nil {
+		return nil, fmt.Errorf("cannot NewAggregator: %w", err)
+	}
+
+	return &Aggregator{StreamAggregator: agg, session: s, domain: domain, round: round, newShare: newShare}, nil
+}
+
+// Put checks the tagged share received from the sender and aggregates it. It returns an error if the share does not pass
+// Session.Check, cannot be unmarshaled, or was already aggregated.
+func (agg *Aggregator) Put(sender drlwe.ShamirPublicPoint, tagged *TaggedShare) error {
+
+	agg.mu.Lock()
+	share := agg.newShare()
+	agg.mu.Unlock()
+
+	if err := agg.session.Open(sender, agg.domain, agg.round, tagged, share); err != nil {
+		return fmt.Errorf("cannot Put: %w", err)
+	}
+
+	return agg.StreamAggregator.Put(sender, share)
+}
+
+// Consume puts the tagged shares received from the channel until the shares of all the parties have been aggregated.
+// Shares that do not pass Session.Check are passed to reject, if not nil, and ignored. It returns an error if ctx is
+// done or shares is closed before, or if a checked share cannot be put.
+func (agg *Aggregator) Consume(ctx context.Context, shares <-chan ReceivedShare, reject func(sender drlwe.ShamirPublicPoint, err error)) error {
+	for {
+		select {
+		case <-agg.Done():
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("cannot Consume: missing shares of the parties %v: %w", agg.Missing(), ctx.Err())
+		case s, ok := <-shares:
+			if !ok {
+				return fmt.Errorf("cannot Consume: channel closed with missing shares of the parties %v", agg.Missing())
+			}
+			if err := agg.session.Check(s.Sender, agg.domain, agg.round, s.Share); err != nil {
+				if reject != nil {
+					reject(s.Sender, err)
+				}
+				continue
+			}
+			if err := agg.Put(s.Sender, s.Share); err != nil {
+				return fmt.Errorf("cannot Consume: %w", err)
+			}
+		}
+	}
+}
+
+// ReceivedShare is a tagged share received from a sender, e.g. identified by the authenticated channel it was received
+// from.
+type ReceivedShare struct {
+	Sender drlwe.ShamirPublicPoint
+	Share  *TaggedShare
+}
@@ -0,0 +1,244 @@
+package dsession
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/stretchr/testify/require"
+)
+
+var testParties = []Party{{"charlie", 3}, {"alice", 1}, {"bob", 2}}
+
+func TestSession(t *testing.T) {
+
+	t.Run("NewSession", func(t *testing.T) {
+
+		s, err := NewSession([]byte("nonce"), testParties)
+		require.NoError(t, err)
+		require.Equal(t, []drlwe.ShamirPublicPoint{1, 2, 3}, s.Points())
+		require.Equal(t, "alice", s.Parties()[0].Name)
+
+		// The identifier does not depend on the order of the parties, but depends on the nonce and the parties
+		other, err := NewSession([]byte("nonce"), []Party{testParties[1], testParties[2], testParties[0]})
+		require.NoError(t, err)
+		require.Equal(t, s.ID(), other.ID())
+
+		other, err = NewSession([]byte("other nonce"), testParties)
+		require.NoError(t, err)
+		require.NotEqual(t, s.ID(), other.ID())
+
+		other, err = NewSession([]byte("nonce"), []Party{{"alice", 1}, {"bob", 2}, {"charlie", 4}})
+		require.NoError(t, err)
+		require.NotEqual(t, s.ID(), other.ID())
+
+		p, err := s.PartyByName("bob")
+		require.NoError(t, err)
+		require.Equal(t, drlwe.ShamirPublicPoint(2), p.Point)
+		_, err = s.PartyByName("eve")
+		require.Error(t, err)
+
+		p, err = s.PartyByPoint(3)
+		require.NoError(t, err)
+		require.Equal(t, "charlie", p.Name)
+		_, err = s.PartyByPoint(4)
+		require.Error(t, err)
+
+		i, ok := s.Index(3)
+		require.True(t, ok)
+		require.Equal(t, 2, i)
+
+		for _, parties := range [][]Party{
+			nil,
+			{{"alice", 1}, {"alice", 2}},
+			{{"alice", 1}, {"bob", 1}},
+			{{"alice", 0}},
+			{{"", 1}},
+		} {
+			_, err = NewSession([]byte("nonce"), parties)
+			require.Error(t, err)
+		}
+
+		_, err = NewSession(nil, testParties)
+		require.Error(t, err)
+	})
+
+	t.Run("CRS", func(t *testing.T) {
+
+		s0, err := NewSession([]byte("nonce"), testParties)
+		require.NoError(t, err)
+		s1, err := NewSession([]byte("nonce"), testParties)
+		require.NoError(t, err)
+		s2, err := NewSession([]byte("other nonce"), testParties)
+		require.NoError(t, err)
+
+		read := func(crs drlwe.CRS) []byte {
+			b := make([]byte, 64)
+			crs.Clock(b)
+			return b
+		}
+
+		require.Equal(t, read(s0.CRS("CKG")), read(s1.CRS("CKG")))
+		require.NotEqual(t, read(s0.CRS("CKG")), read(s0.CRS("RKG")))
+		require.NotEqual(t, read(s0.CRS("CKG")), read(s2.CRS("CKG")))
+	})
+
+	t.Run("TagAndCheck", func(t *testing.T) {
+
+		params, err := rlwe.NewParametersFromLiteral(rlwe.TestPN12QP109)
+		require.NoError(t, err)
+
+		s, err := NewSession([]byte("nonce"), testParties)
+		require.NoError(t, err)
+		replayed, err := NewSession([]byte("previous nonce"), testParties)
+		require.NoError(t, err)
+
+		ckg := drlwe.NewCKGProtocol(params)
+		share := ckg.AllocateShare()
+		ckg.GenShare(rlwe.NewKeyGenerator(params).GenSecretKey(), ckg.SampleCRP(s.CRS("CKG")), share)
+
+		tagged, err := s.Tag(1, "CKG", 0, share)
+		require.NoError(t, err)
+
+		_, err = s.Tag(4, "CKG", 0, share)
+		require.True(t, errors.Is(err, ErrUnknownParty))
+
+		data, err := tagged.MarshalBinary()
+		require.NoError(t, err)
+		taggedNew := new(TaggedShare)
+		require.NoError(t, taggedNew.UnmarshalBinary(data))
+		require.Equal(t, tagged, taggedNew)
+		require.Error(t, taggedNew.UnmarshalBinary(data[:40]))
+
+		// The round is encoded on 4 bytes
+		invalid := *tagged
+		invalid.Round = -1
+		_, err = invalid.MarshalBinary()
+		require.Error(t, err)
+
+		shareOut := ckg.AllocateShare()
+		require.NoError(t, s.Open(1, "CKG", 0, taggedNew, shareOut))
+		require.True(t, share.Value.Equals(shareOut.Value))
+
+		require.True(t, errors.Is(replayed.Check(1, "CKG", 0, tagged), ErrSessionMismatch))
+		require.True(t, errors.Is(s.Check(1, "RKG", 0, tagged), ErrDomainMismatch))
+		require.True(t, errors.Is(s.Check(1, "CKG", 1, tagged), ErrDomainMismatch))
+		require.True(t, errors.Is(s.Check(2, "CKG", 0, tagged), ErrMisattributedShare))
+
+		tagged.Party = 4
+		require.True(t, errors.Is(s.Check(4, "CKG", 0, tagged), ErrUnknownParty))
+	})
+
+	t.Run("Aggregator", func(t *testing.T) {
+
+		params, err := rlwe.NewParametersFromLiteral(rlwe.TestPN12QP109)
+		require.NoError(t, err)
+
+		s, err := NewSession([]byte("nonce"), testParties)
+		require.NoError(t, err)
+		previous, err := NewSession([]byte("previous nonce"), testParties)
+		require.NoError(t, err)
+
+		ckg := drlwe.NewCKGProtocol(params)
+		crp := ckg.SampleCRP(s.CRS("CKG"))
+		kgen := rlwe.NewKeyGenerator(params)
+
+		want := ckg.AllocateShare()
+		shares := make(chan ReceivedShare, 3*len(testParties))
+
+		for _, p := range s.Points() {
+
+			share := ckg.AllocateShare()
+			ckg.GenShare(kgen.GenSecretKey(), crp, share)
+			ckg.AggregateShare(want, share, want)
+
+			// A share replayed from a previous session and a misattributed share are sent before the valid share
+			tagged, err := previous.Tag(p, "CKG", 0, share)
+			require.NoError(t, err)
+			shares <- ReceivedShare{Sender: p, Share: tagged}
+
+			tagged, err = s.Tag(p, "CKG", 0, share)
+			require.NoError(t, err)
+			shares <- ReceivedShare{Sender: p%3 + 1, Share: tagged}
+			shares <- ReceivedShare{Sender: p, Share: tagged}
+		}
+
+		shareOut := ckg.AllocateShare()
+		agg, err := s.NewAggregator("CKG", 0, shareOut, func(share1, share2, shareOut interface{}) {
+			ckg.AggregateShare(share1.(*drlwe.CKGShare), share2.(*drlwe.CKGShare), shareOut.(*drlwe.CKGShare))
+		}, func() drlwe.Share { return ckg.AllocateShare() })
+		require.NoError(t, err)
+
+		rejected := 0
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		require.NoError(t, agg.Consume(ctx, shares, func(sender drlwe.ShamirPublicPoint, err error) { rejected++ }))
+		require.Equal(t, 2*len(testParties), rejected)
+
+		out, err := agg.Wait(ctx)
+		require.NoError(t, err)
+		require.True(t, want.Value.Equals(out.(*drlwe.CKGShare).Value))
+
+		tagged, err := s.Tag(1, "CKG", 0, ckg.AllocateShare())
+		require.NoError(t, err)
+		require.Error(t, agg.Put(1, tagged))
+	})
+
+	t.Run("Transport", func(t *testing.T) {
+
+		s, err := NewSession([]byte("nonce"), testParties)
+		require.NoError(t, err)
+		previous, err := NewSession([]byte("previous nonce"), testParties)
+		require.NoError(t, err)
+
+		inner := &testTransport{}
+
+		prevTransport, err := previous.NewTransport(2, "CKG", inner)
+		require.NoError(t, err)
+		require.NoError(t, prevTransport.Broadcast(context.Background(), 0, []byte("replayed")))
+
+		bob, err := s.NewTransport(2, "CKG", inner)
+		require.NoError(t, err)
+		require.NoError(t, bob.Broadcast(context.Background(), 0, []byte("share")))
+
+		_, err = s.NewTransport(4, "CKG", inner)
+		require.Error(t, err)
+
+		alice, err := s.NewTransport(1, "CKG", inner)
+		require.NoError(t, err)
+
+		var rejected []error
+		alice.Rejected = func(sender drlwe.ShamirPublicPoint, err error) { rejected = append(rejected, err) }
+
+		party, share, err := alice.Receive(context.Background(), 0)
+		require.NoError(t, err)
+		require.Equal(t, drlwe.ShamirPublicPoint(2), party)
+		require.Equal(t, []byte("share"), share)
+		require.Len(t, rejected, 1)
+		require.True(t, errors.Is(rejected[0], ErrSessionMismatch))
+
+		_, _, err = alice.Receive(context.Background(), 0)
+		require.Error(t, err)
+	})
+}
+
+// testTransport is an in-memory drlwe.Transport delivering the broadcast shares in order, all sent by the party 2.
+type testTransport struct {
+	shares [][]byte
+}
+
+func (tt *testTransport) Broadcast(ctx context.Context, round int, share []byte) error {
+	tt.shares = append(tt.shares, share)
+	return nil
+}
+
+func (tt *testTransport) Receive(ctx context.Context, round int) (party drlwe.ShamirPublicPoint, share []byte, err error) {
+	if len(tt.shares) == 0 {
+		return 0, nil, errors.New("no share")
+	}
+	share, tt.shares = tt.shares[0], tt.shares[1:]
+	return 2, share, nil
+}
@@ -0,0 +1,303 @@
+// Package dsession implements the session and party identity management of the multiparty protocols of the drlwe,
+// dbfv and dckks packages. A Session binds a session identifier to the ordered list of its parties, derives the
+// common reference strings of the protocols run in the session with domain separation, and tags the shares of the
+// parties with the session, the party, the protocol domain and the round, so that the aggregators can detect the
+// shares replayed from another session, protocol or round, and the shares attributed to another party than their
+// sender.
+package dsession
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// ErrSessionMismatch is the error returned when a share is tagged with another session, e.g. because it was replayed
+	// from a previous session.
+	ErrSessionMismatch = errors.New("the share is tagged with another session")
+	// ErrDomainMismatch is the error returned when a share is tagged with another protocol domain or round.
+	ErrDomainMismatch = errors.New("the share is tagged with another domain or round")
+	// ErrUnknownParty is the error returned when a share is tagged with a party that is not in the session.
+	ErrUnknownParty = errors.New("the share is tagged with a party that is not in the session")
+	// ErrMisattributedShare is the error returned when a share is tagged with another party than its sender.
+	ErrMisattributedShare = errors.New("the share is tagged with another party than its sender")
+)
+
+// Party is the identity of a party in a session: its application identifier, e.g. a host name or a certificate
+// subject, and the public point identifying it in the protocols, e.g. in the threshold secret-sharing.
+type Party struct {
+	Name  string
+	Point drlwe.ShamirPublicPoint
+}
+
+// SessionID is the identifier of a session.
+type SessionID [blake2b.Size256]byte
+
+// String returns the hexadecimal representation of the first 8 bytes of the identifier.
+func (id SessionID) String() string {
+	return fmt.Sprintf("%x", id[:8])
+}
+
+// Session is a run of multiparty protocols among a fixed set of parties. Its identifier is derived from a nonce, which
+// must be unique for each session, e.g. random or a counter agreed upon by the parties, and from the ordered list of
+// its parties, so that all the parties compute the same identifier only if they agree on the nonce and the parties.
+type Session struct {
+	id      SessionID
+	parties []Party
+	byName  map[string]int
+	byPoint map[drlwe.ShamirPublicPoint]int
+}
+
+// NewSession creates a new Session for the nonce and the parties, which are ordered by increasing public point. It
+// returns an error if the nonce is empty, or if the names or the public points of the parties are not non-empty,
+// non-zero and distinct.
+func NewSession(nonce []byte, parties []Party) (*Session, error) {
+
+	if len(nonce) == 0 {
+		return nil, errors.New("cannot NewSession: the nonce is empty")
+	}
+
+	if len(parties) == 0 {
+		return nil, errors.New("cannot NewSession: the session has no party")
+	}
+
+	s := &Session{
+		parties: append([]Party(nil), parties...),
+		byName:  make(map[string]int, len(parties)),
+		byPoint: make(map[drlwe.ShamirPublicPoint]int, len(parties)),
+	}
+
+	sort.Slice(s.parties, func(i, j int) bool { return s.parties[i].Point < s.parties[j].Point })
+
+	for i, p := range s.parties {
+
+		if p.Name == "" {
+			return nil, errors.New("cannot NewSession: the party names must be non-empty")
+		}
+
+		if p.Point == 0 {
+			return nil, errors.New("cannot NewSession: the public points must be non-zero")
+		}
+
+		if _, ok := s.byName[p.Name]; ok {
+			return nil, fmt.Errorf("cannot NewSession: the party name %q is duplicated", p.Name)
+		}
+
+		if _, ok := s.byPoint[p.Point]; ok {
+			return nil, fmt.Errorf("cannot NewSession: the public point %d is duplicated", p.Point)
+		}
+
+		s.byName[p.Name] = i
+		s.byPoint[p.Point] = i
+	}
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	writeBytes(h, []byte("lattigo/dsession"))
+	writeBytes(h, nonce)
+	for _, p := range s.parties {
+		writeUint64(h, uint64(p.Point))
+		writeBytes(h, []byte(p.Name))
+	}
+
+	copy(s.id[:], h.Sum(nil))
+
+	return s, nil
+}
+
+// ID returns the identifier of the session.
+func (s *Session) ID() SessionID {
+	return s.id
+}
+
+// Parties returns the parties of the session, ordered by increasing public point.
+func (s *Session) Parties() []Party {
+	return append([]Party(nil), s.parties...)
+}
+
+// Points returns the public points of the parties of the session, in increasing order.
+func (s *Session) Points() []drlwe.ShamirPublicPoint {
+	points := make([]drlwe.ShamirPublicPoint, len(s.parties))
+	for i, p := range s.parties {
+		points[i] = p.Point
+	}
+	return points
+}
+
+// PartyByName returns the party of the session with the given name. It returns an error if there is none.
+func (s *Session) PartyByName(name string) (Party, error) {
+	i, ok := s.byName[name]
+	if !ok {
+		return Party{}, fmt.Errorf("cannot PartyByName: no party named %q in the session", name)
+	}
+	return s.parties[i], nil
+}
+
+// PartyByPoint returns the party of the session with the given public point. It returns an error if there is none.
+func (s *Session) PartyByPoint(point drlwe.ShamirPublicPoint) (Party, error) {
+	i, ok := s.byPoint[point]
+	if !ok {
+		return Party{}, fmt.Errorf("cannot PartyByPoint: no party of public point %d in the session", point)
+	}
+	return s.parties[i], nil
+}
+
+// Index returns the index of the party of the given public point in the ordered list of the parties of the session,
+// and false if there is no such party.
+func (s *Session) Index(point drlwe.ShamirPublicPoint) (int, bool) {
+	i, ok := s.byPoint[point]
+	return i, ok
+}
+
+// CRS returns the common reference string of the protocol domain in the session, e.g. "CKG", "RKG" or "RTG/5" for the
+// rotation key of Galois element 5. The CRSs of distinct sessions or domains are independent, so that the common
+// reference polynomials are never reused across protocols or sessions.
+func (s *Session) CRS(domain string) drlwe.CRS {
+
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	writeBytes(h, []byte("lattigo/dsession/CRS"))
+	writeBytes(h, s.id[:])
+	writeBytes(h, []byte(domain))
+
+	prng, err := utils.NewKeyedPRNG(h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+
+	return prng
+}
+
+// TaggedShare is a marshaled share tagged with the session, the party, the protocol domain and the round in which it
+// was generated.
+type TaggedShare struct {
+	Session SessionID
+	Party   drlwe.ShamirPublicPoint
+	Domain  string
+	Round   int
+	Data    []byte
+}
+
+// Tag returns the share of the party for the round of the protocol domain, tagged with the session. It returns an
+// error if the party is not in the session or if the share cannot be marshaled.
+func (s *Session) Tag(party drlwe.ShamirPublicPoint, domain string, round int, share drlwe.Share) (*TaggedShare, error) {
+
+	if _, ok := s.byPoint[party]; !ok {
+		return nil, fmt.Errorf("cannot Tag: %w", ErrUnknownParty)
+	}
+
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("cannot Tag: %w", err)
+	}
+
+	return &TaggedShare{Session: s.id, Party: party, Domain: domain, Round: round, Data: data}, nil
+}
+
+// Check checks that the tagged share received from the sender was generated in the session, for the round of the
+// protocol domain, by a party of the session which is the sender. It returns an error wrapping ErrSessionMismatch,
+// ErrDomainMismatch, ErrUnknownParty or ErrMisattributedShare otherwise.
+func (s *Session) Check(sender drlwe.ShamirPublicPoint, domain string, round int, tagged *TaggedShare) error {
+
+	switch {
+	case tagged.Session != s.id:
+		return fmt.Errorf("cannot Check: %w: %s instead of %s", ErrSessionMismatch, tagged.Session, s.id)
+	case tagged.Domain != domain || tagged.Round != round:
+		return fmt.Errorf("cannot Check: %w: %s/%d instead of %s/%d", ErrDomainMismatch, tagged.Domain, tagged.Round, domain, round)
+	}
+
+	if _, ok := s.byPoint[tagged.Party]; !ok {
+		return fmt.Errorf("cannot Check: %w: %d", ErrUnknownParty, tagged.Party)
+	}
+
+	if tagged.Party != sender {
+		return fmt.Errorf("cannot Check: %w: party %d sent by %d", ErrMisattributedShare, tagged.Party, sender)
+	}
+
+	return nil
+}
+
+// Open checks the tagged share received from the sender with Check and unmarshals it on shareOut.
+func (s *Session) Open(sender drlwe.ShamirPublicPoint, domain string, round int, tagged *TaggedShare, shareOut drlwe.Share) error {
+	if err := s.Check(sender, domain, round, tagged); err != nil {
+		return err
+	}
+	if err := shareOut.UnmarshalBinary(tagged.Data); err != nil {
+		return fmt.Errorf("cannot Open: invalid share of party %d: %w", sender, err)
+	}
+	return nil
+}
+
+// MarshalBinary encodes the tagged share on a slice of bytes.
+func (ts *TaggedShare) MarshalBinary() (data []byte, err error) {
+
+	if ts.Round < 0 || uint64(ts.Round) > math.MaxUint32 {
+		return nil, errors.New("cannot MarshalBinary: invalid round")
+	}
+
+	data = make([]byte, 0, len(ts.Session)+8+4+4+len(ts.Domain)+len(ts.Data))
+	data = append(data, ts.Session[:]...)
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(ts.Party))
+	data = append(data, buf[:]...)
+
+	binary.LittleEndian.PutUint32(buf[:4], uint32(ts.Round))
+	data = append(data, buf[:4]...)
+
+	binary.LittleEndian.PutUint32(buf[:4], uint32(len(ts.Domain)))
+	data = append(data, buf[:4]...)
+	data = append(data, ts.Domain...)
+
+	return append(data, ts.Data...), nil
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target tagged share.
+func (ts *TaggedShare) UnmarshalBinary(data []byte) (err error) {
+
+	header := len(ts.Session) + 8 + 4 + 4
+	if len(data) < header {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	copy(ts.Session[:], data)
+	data = data[len(ts.Session):]
+
+	ts.Party = drlwe.ShamirPublicPoint(binary.LittleEndian.Uint64(data))
+	ts.Round = int(binary.LittleEndian.Uint32(data[8:]))
+	domainLen := int(binary.LittleEndian.Uint32(data[12:]))
+	data = data[16:]
+
+	if domainLen > len(data) {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
+	ts.Domain = string(data[:domainLen])
+	ts.Data = append([]byte(nil), data[domainLen:]...)
+
+	return nil
+}
+
+func writeBytes(h interface{ Write(p []byte) (int, error) }, b []byte) {
+	writeUint64(h, uint64(len(b)))
+	h.Write(b)
+}
+
+func writeUint64(h interface{ Write(p []byte) (int, error) }, v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	h.Write(buf[:])
+}
@@ -0,0 +1,67 @@
+package dsession
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+)
+
+// Transport is a drlwe.Transport that tags the shares broadcast by a party with the session and the protocol domain,
+// and checks the tags of the received shares, so that a drlwe.Orchestrator running in the session never aggregates a
+// share replayed from another session, protocol or round, or a share attributed to another party than its sender.
+// The shares that do not pass the check are ignored and passed to Rejected, if not nil.
+type Transport struct {
+	session *Session
+	self    drlwe.ShamirPublicPoint
+	domain  string
+	inner   drlwe.Transport
+
+	Rejected func(sender drlwe.ShamirPublicPoint, err error)
+}
+
+// NewTransport creates a new Transport for the party self of the session, running the protocol domain over the
+// transport inner. It returns an error if self is not a party of the session.
+func (s *Session) NewTransport(self drlwe.ShamirPublicPoint, domain string, inner drlwe.Transport) (*Transport, error) {
+	if _, ok := s.byPoint[self]; !ok {
+		return nil, fmt.Errorf("cannot NewTransport: party %d is not in the session", self)
+	}
+	return &Transport{session: s, self: self, domain: domain, inner: inner}, nil
+}
+
+// Broadcast tags the share of the party for the round and broadcasts it over the inner transport.
+func (t *Transport) Broadcast(ctx context.Context, round int, share []byte) error {
+	data, err := (&TaggedShare{Session: t.session.id, Party: t.self, Domain: t.domain, Round: round, Data: share}).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return t.inner.Broadcast(ctx, round, data)
+}
+
+// Receive returns the next share received for the round over the inner transport whose tag passes Session.Check.
+func (t *Transport) Receive(ctx context.Context, round int) (party drlwe.ShamirPublicPoint, share []byte, err error) {
+	for {
+
+		var data []byte
+		if party, data, err = t.inner.Receive(ctx, round); err != nil {
+			return 0, nil, err
+		}
+
+		tagged := new(TaggedShare)
+		if err = tagged.UnmarshalBinary(data); err == nil {
+			err = t.session.Check(party, t.domain, round, tagged)
+		}
+
+		if err == nil {
+			return party, tagged.Data, nil
+		}
+
+		if t.Rejected != nil {
+			t.Rejected(party, err)
+		}
+
+		if err = ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"math"
+	"runtime"
 
 	"github.com/ldsec/lattigo/v2/ckks"
 	"github.com/ldsec/lattigo/v2/ckks/bootstrapping"
@@ -50,10 +51,8 @@ func main() {
 
 	fmt.Println()
 	fmt.Println("Generating bootstrapping keys...")
-	rotations := btpParams.RotationsForBootstrapping(params.LogN(), params.LogSlots())
-	rotkeys := kgen.GenRotationKeysForRotations(rotations, true, sk)
-	rlk := kgen.GenRelinearizationKey(sk, 2)
-	if btp, err = bootstrapping.NewBootstrapper(params, btpParams, rlwe.EvaluationKey{Rlk: rlk, Rtks: rotkeys}); err != nil {
+	btpKeys := bootstrapping.GenEvaluationKeys(params, btpParams, sk, runtime.NumCPU())
+	if btp, err = bootstrapping.NewBootstrapper(params, btpParams, btpKeys.EvaluationKey()); err != nil {
 		panic(err)
 	}
 	fmt.Println("Done")
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"math/big"
 	"os"
 	"strconv"
 	"sync"
@@ -196,7 +197,15 @@ func cksphase(params bfv.Parameters, P []*party, result *bfv.Ciphertext) *bfv.Ci
 
 	l.Println("> CKS Phase")
 
-	cks := dbfv.NewCKSProtocol(params, 3.19) // Collective public-key re-encryption
+	// The smudging noise statistically hides a ciphertext noise of up to 2^10, with 30 bits of statistical security
+	// and the output decrypts correctly if its noise is smaller than Δ/2 = Q/(2t)
+	decryptionBound, _ := new(big.Float).Quo(new(big.Float).SetInt(params.QBigInt()), big.NewFloat(float64(2*params.T()))).Float64()
+	sigmaSmudging, _, err := drlwe.SmudgingParams(params.Parameters, 30, len(P), 1<<10, decryptionBound)
+	if err != nil {
+		panic(err)
+	}
+
+	cks := dbfv.NewCKSProtocol(params, sigmaSmudging) // Collective public-key re-encryption
 
 	for _, pi := range P {
 		pi.cksShare = cks.AllocateShare()
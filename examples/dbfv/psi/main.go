@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"math/big"
 	"os"
 	"strconv"
 	"sync"
@@ -294,7 +295,15 @@ func pcksPhase(params bfv.Parameters, tpk *rlwe.PublicKey, encRes *bfv.Ciphertex
 	// Collective key switching from the collective secret key to
 	// the target public key
 
-	pcks := dbfv.NewPCKSProtocol(params, 3.19)
+	// The smudging noise statistically hides a ciphertext noise of up to 2^10, with 30 bits of statistical security
+	// and the output decrypts correctly if its noise is smaller than Δ/2 = Q/(2t)
+	decryptionBound, _ := new(big.Float).Quo(new(big.Float).SetInt(params.QBigInt()), big.NewFloat(float64(2*params.T()))).Float64()
+	sigmaSmudging, _, err := drlwe.SmudgingParams(params.Parameters, 30, len(P), 1<<10, decryptionBound)
+	if err != nil {
+		panic(err)
+	}
+
+	pcks := dbfv.NewPCKSProtocol(params, sigmaSmudging)
 
 	for _, pi := range P {
 		pi.pcksShare = pcks.AllocateShare()
@@ -0,0 +1,105 @@
+// Command netsim predicts the duration of the ceremonies of the multiparty protocols before their deployment: it runs
+// the CKG, RKG and RTG protocols among simulated parties connected by links of configurable latency and bandwidth, and
+// reports the wall time of each round and the size of the exchanged messages.
+//
+// Usage:
+//
+//	go run ./examples/drlwe/netsim -parties 8 -latency 50ms -bandwidth 12.5e6 -params 1 -rotations 4
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+func check(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+
+	nbParties := flag.Int("parties", 4, "number of parties")
+	latency := flag.Duration("latency", 20*time.Millisecond, "one-way latency of the links")
+	bandwidth := flag.Float64("bandwidth", 125e6, "upload bandwidth of each party in bytes per second (0 for unlimited)")
+	paramsIndex := flag.Int("params", 0, "index of the parameters in ckks.DefaultParams")
+	rotations := flag.Int("rotations", 2, "number of rotation keys")
+	flag.Parse()
+
+	if *paramsIndex < 0 || *paramsIndex >= len(ckks.DefaultParams) {
+		log.Fatalf("invalid parameters index %d", *paramsIndex)
+	}
+
+	ckksParams, err := ckks.NewParametersFromLiteral(ckks.DefaultParams[*paramsIndex])
+	check(err)
+	params := ckksParams.Parameters
+
+	parties := make([]drlwe.ShamirPublicPoint, *nbParties)
+	sks := make([]*rlwe.SecretKey, *nbParties)
+	kgen := rlwe.NewKeyGenerator(params)
+	for i := range parties {
+		parties[i] = drlwe.ShamirPublicPoint(i + 1)
+		sks[i] = kgen.GenSecretKey()
+	}
+
+	crs, err := utils.NewKeyedPRNG([]byte("netsim"))
+	check(err)
+
+	link := drlwe.LinkProfile{Latency: *latency, Bandwidth: *bandwidth}
+	net := drlwe.NewSimulatedNetwork(parties, link)
+
+	fmt.Printf("parameters: logN=%d, logQP=%d, #Qi=%d, #Pi=%d\n", params.LogN(), params.LogQP(), params.QCount(), params.PCount())
+	fmt.Printf("network: %d parties, latency %v, bandwidth %g B/s\n\n", *nbParties, link.Latency, link.Bandwidth)
+
+	var total time.Duration
+
+	run := func(name string, newProtocol func() drlwe.RoundProtocol, newInputs func(i int) interface{}) {
+
+		protocols := make([]drlwe.RoundProtocol, *nbParties)
+		inputs := make([]interface{}, *nbParties)
+		for i := range protocols {
+			protocols[i] = newProtocol()
+			inputs[i] = newInputs(i)
+		}
+
+		_, stats, err := drlwe.Simulate(context.Background(), net, protocols, inputs)
+		check(err)
+
+		for _, rs := range stats {
+			fmt.Printf("%-8s %v\n", name, rs)
+			total += rs.WallTime
+		}
+	}
+
+	ckg := drlwe.NewCKGProtocol(params)
+	ckgCRP := ckg.SampleCRP(crs)
+	run("CKG", func() drlwe.RoundProtocol { return drlwe.CKGRounds{CKGProtocol: ckg.ShallowCopy()} }, func(i int) interface{} {
+		return drlwe.CKGInputs{SecretKey: sks[i], CRP: ckgCRP}
+	})
+
+	rkg := drlwe.NewRKGProtocol(params)
+	rkgCRP := rkg.SampleCRP(crs)
+	run("RKG", func() drlwe.RoundProtocol { return drlwe.NewRKGRounds(rkg.ShallowCopy()) }, func(i int) interface{} {
+		return drlwe.RKGInputs{SecretKey: sks[i], CRP: rkgCRP}
+	})
+
+	rtg := drlwe.NewRTGProtocol(params)
+	for k := 1; k <= *rotations; k++ {
+		galEl := params.GaloisElementForColumnRotationBy(k)
+		rtgCRP := rtg.SampleCRP(crs)
+		run(fmt.Sprintf("RTG(%d)", k), func() drlwe.RoundProtocol { return drlwe.RTGRounds{RTGProtocol: rtg.ShallowCopy()} }, func(i int) interface{} {
+			return drlwe.RTGInputs{SecretKey: sks[i], GaloisElement: galEl, CRP: rtgCRP}
+		})
+	}
+
+	fmt.Printf("\ntotal: %v\n", total)
+}
@@ -0,0 +1,307 @@
+// Package kat implements the known-answer tests of the library. A Vector is a set of objects generated
+// deterministically from a scheme, its parameters and a seed: the keys, the plaintexts and the ciphertexts of fixed
+// values, and the ciphertexts and plaintexts resulting from their homomorphic evaluation. The vectors store the SHA-256
+// digests of the binary serialization of these objects, so that the golden vectors checked into the repository
+// (testdata/vectors.json) catch any change of the serialization or of the arithmetic of the library, across its
+// versions and the 64-bit platforms it supports, that the randomized tests cannot.
+//
+// The vectors only record objects computed with integer arithmetic: the CKKS values are encoded on the coefficients of
+// the plaintexts, and the decoding of the decrypted CKKS plaintexts is not recorded, as the floating-point operations
+// of the slot encoding may be contracted differently on each platform. An intended change of the serialization or of
+// the sampling must be followed by the regeneration of the golden vectors with
+//
+//	go test ./kat -run TestVectors -update
+package kat
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Vector is a known-answer test vector: the digests of the objects generated from a scheme, its parameters and a seed.
+type Vector struct {
+	// Name is the name of the vector.
+	Name string `json:"name"`
+	// Scheme is the scheme of the vector, "bfv" or "ckks".
+	Scheme string `json:"scheme"`
+	// Params are the parameters of the scheme marshaled in JSON.
+	Params json.RawMessage `json:"params"`
+	// Seed is the hexadecimal seed from which the objects are generated.
+	Seed string `json:"seed"`
+	// Digests are the hexadecimal SHA-256 digests of the binary serialization of the objects, indexed by their names.
+	Digests map[string]string `json:"digests"`
+}
+
+// Generate generates the vector of the given name, scheme ("bfv" or "ckks"), parameters (bfv.Parameters or
+// ckks.Parameters) and seed.
+func Generate(name, scheme string, params json.Marshaler, seed []byte) (v *Vector, err error) {
+
+	v = &Vector{Name: name, Scheme: scheme, Seed: hex.EncodeToString(seed)}
+	if v.Params, err = params.MarshalJSON(); err != nil {
+		return nil, fmt.Errorf("cannot Generate: %w", err)
+	}
+
+	if v.Digests, err = v.generate(); err != nil {
+		return nil, fmt.Errorf("cannot Generate: %w", err)
+	}
+
+	return v, nil
+}
+
+// Update regenerates the digests of the vector from its scheme, parameters and seed.
+func (v *Vector) Update() (err error) {
+	digests, err := v.generate()
+	if err != nil {
+		return fmt.Errorf("cannot Update: vector %s: %w", v.Name, err)
+	}
+	v.Digests = digests
+	return nil
+}
+
+// Verify regenerates the objects of the vector and returns an error naming the objects whose digests do not match
+// those of the vector.
+func (v *Vector) Verify() error {
+
+	digests, err := v.generate()
+	if err != nil {
+		return fmt.Errorf("cannot Verify: vector %s: %w", v.Name, err)
+	}
+
+	var mismatches []string
+	for name, digest := range digests {
+		if v.Digests[name] != digest {
+			mismatches = append(mismatches, name)
+		}
+	}
+	for name := range v.Digests {
+		if _, ok := digests[name]; !ok {
+			mismatches = append(mismatches, name)
+		}
+	}
+
+	if len(mismatches) != 0 {
+		sort.Strings(mismatches)
+		return fmt.Errorf("cannot Verify: vector %s: digests of %s do not match", v.Name, strings.Join(mismatches, ", "))
+	}
+
+	return nil
+}
+
+// ReadFile reads the vectors of the JSON file at path.
+func ReadFile(path string) (vectors []*Vector, err error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot ReadFile: %w", err)
+	}
+
+	if err = json.Unmarshal(data, &vectors); err != nil {
+		return nil, fmt.Errorf("cannot ReadFile: %w", err)
+	}
+
+	return vectors, nil
+}
+
+// WriteFile writes the vectors in the JSON file at path.
+func WriteFile(path string, vectors []*Vector) (err error) {
+
+	data, err := json.MarshalIndent(vectors, "", "\t")
+	if err != nil {
+		return fmt.Errorf("cannot WriteFile: %w", err)
+	}
+
+	if err = ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("cannot WriteFile: %w", err)
+	}
+
+	return nil
+}
+
+// generator generates the objects of a vector and records their digests.
+type generator struct {
+	seed    []byte
+	digests map[string]string
+	err     error
+}
+
+// prng returns the PRNG of the given label, keyed with the seed of the vector and the label, so that the objects
+// generated from one label do not depend on those generated from the others.
+func (g *generator) prng(label string) utils.PRNG {
+	prng, err := utils.NewKeyedPRNG(append(append(append([]byte{}, g.seed...), 0), label...))
+	if err != nil {
+		panic(err)
+	}
+	return prng
+}
+
+// record records the digest of the binary serialization of the object under the given name.
+func (g *generator) record(name string, object interface{ MarshalBinary() ([]byte, error) }) {
+
+	if g.err != nil {
+		return
+	}
+
+	data, err := object.MarshalBinary()
+	if err != nil {
+		g.err = fmt.Errorf("object %s: %w", name, err)
+		return
+	}
+
+	digest := sha256.Sum256(data)
+	g.digests[name] = hex.EncodeToString(digest[:])
+}
+
+// recordKeys records the secret key, the public key, the relinearization key and the rotation keys.
+func (g *generator) recordKeys(kgen rlwe.KeyGenerator) (sk *rlwe.SecretKey, pk *rlwe.PublicKey, evk rlwe.EvaluationKey) {
+
+	sk, pk = kgen.GenKeyPair()
+	evk.Rlk = kgen.GenRelinearizationKey(sk, 1)
+	evk.Rtks = kgen.GenRotationKeysForRotations([]int{1}, true, sk)
+
+	g.record("sk", sk)
+	g.record("pk", pk)
+	g.record("rlk", evk.Rlk)
+
+	// The serialization of the rotation key set follows the order of its map, hence each key is recorded separately
+	for galEl, swk := range evk.Rtks.Keys {
+		g.record(fmt.Sprintf("rtk/%d", galEl), swk)
+	}
+
+	return
+}
+
+// generate generates the objects of the vector and returns their digests.
+func (v *Vector) generate() (digests map[string]string, err error) {
+
+	seed, err := hex.DecodeString(v.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{seed: seed, digests: make(map[string]string)}
+
+	switch v.Scheme {
+	case "bfv":
+		var params bfv.Parameters
+		if err = json.Unmarshal(v.Params, &params); err != nil {
+			return nil, err
+		}
+		g.generateBFV(params)
+	case "ckks":
+		var params ckks.Parameters
+		if err = json.Unmarshal(v.Params, &params); err != nil {
+			return nil, err
+		}
+		g.generateCKKS(params)
+	default:
+		return nil, fmt.Errorf("unknown scheme %q", v.Scheme)
+	}
+
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	return g.digests, nil
+}
+
+// generateBFV generates the objects of a BFV vector.
+func (g *generator) generateBFV(params bfv.Parameters) {
+
+	sk, pk, evk := g.recordKeys(bfv.NewKeyGeneratorWithPRNG(params, g.prng("keys")))
+
+	encoder := bfv.NewEncoder(params)
+	eval := bfv.NewEvaluator(params, evk)
+	decryptor := bfv.NewDecryptor(params, sk)
+
+	// The values are uniform in Z_t
+	sampler := ring.NewUniformSampler(g.prng("values"), params.RingT())
+	pt := [2]*bfv.Plaintext{}
+	for i := range pt {
+		values := params.RingT().NewPoly()
+		sampler.Read(values)
+		pt[i] = bfv.NewPlaintext(params)
+		encoder.EncodeUint(values.Coeffs[0], pt[i])
+		g.record(fmt.Sprintf("pt/%d", i), pt[i].Value)
+	}
+
+	ct := [2]*bfv.Ciphertext{}
+	ct[0] = bfv.NewEncryptorWithPRNG(params, pk, g.prng("encrypt/pk")).EncryptNew(pt[0])
+	ct[1] = bfv.NewEncryptorWithPRNG(params, sk, g.prng("encrypt/sk")).EncryptNew(pt[1])
+	g.record("ct/0", ct[0])
+	g.record("ct/1", ct[1])
+
+	g.record("ct/add", eval.AddNew(ct[0], ct[1]))
+	g.record("ct/rot", eval.RotateColumnsNew(ct[0], 1))
+
+	ctMul := eval.RelinearizeNew(eval.MulNew(ct[0], ct[1]))
+	g.record("ct/mul", ctMul)
+
+	ptMul := decryptor.DecryptNew(ctMul)
+	g.record("pt/mul", ptMul.Value)
+	g.record("values/mul", uint64s(encoder.DecodeUintNew(ptMul)))
+}
+
+// generateCKKS generates the objects of a CKKS vector.
+func (g *generator) generateCKKS(params ckks.Parameters) {
+
+	sk, pk, evk := g.recordKeys(ckks.NewKeyGeneratorWithPRNG(params, g.prng("keys")))
+
+	encoder := ckks.NewEncoder(params)
+	eval := ckks.NewEvaluator(params, evk)
+	decryptor := ckks.NewDecryptor(params, sk)
+
+	// The values are small integers on the first coefficients, so that their encoding is exact and their product
+	// does not wrap around the modulus of the last level
+	prng := g.prng("values")
+	pt := [2]*ckks.Plaintext{}
+	for i := range pt {
+		values := make([]float64, 8)
+		random := make([]byte, len(values))
+		prng.Clock(random)
+		for j := range values {
+			values[j] = float64(int(random[j]%5) - 2)
+		}
+		pt[i] = encoder.EncodeCoeffsNew(values, params.MaxLevel(), params.DefaultScale())
+		g.record(fmt.Sprintf("pt/%d", i), pt[i].Value)
+	}
+
+	ct := [2]*ckks.Ciphertext{}
+	ct[0] = ckks.NewEncryptorWithPRNG(params, pk, g.prng("encrypt/pk")).EncryptNew(pt[0])
+	ct[1] = ckks.NewEncryptorWithPRNG(params, sk, g.prng("encrypt/sk")).EncryptNew(pt[1])
+	g.record("ct/0", ct[0])
+	g.record("ct/1", ct[1])
+
+	g.record("ct/add", eval.AddNew(ct[0], ct[1]))
+	g.record("ct/rot", eval.RotateNew(ct[0], 1))
+
+	ctMul := eval.MulRelinNew(ct[0], ct[1])
+	if err := eval.Rescale(ctMul, params.DefaultScale(), ctMul); err != nil && g.err == nil {
+		g.err = fmt.Errorf("object ct/mul: %w", err)
+	}
+	g.record("ct/mul", ctMul)
+	g.record("pt/mul", decryptor.DecryptNew(ctMul).Value)
+}
+
+// uint64s are the values of a slice serialized in big-endian.
+type uint64s []uint64
+
+// MarshalBinary encodes the values in big-endian.
+func (values uint64s) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 8*len(values))
+	for i, value := range values {
+		binary.BigEndian.PutUint64(data[8*i:], value)
+	}
+	return data, nil
+}
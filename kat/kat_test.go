@@ -0,0 +1,60 @@
+package kat
+
+import (
+	"encoding/hex"
+	"flag"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/stretchr/testify/require"
+)
+
+var flagUpdate = flag.Bool("update", false, "regenerate the digests of the golden vectors.")
+
+const goldenFile = "testdata/vectors.json"
+
+func TestVectors(t *testing.T) {
+
+	vectors, err := ReadFile(goldenFile)
+	require.NoError(t, err)
+	require.NotEmpty(t, vectors)
+
+	if *flagUpdate {
+		for _, v := range vectors {
+			require.NoError(t, v.Update())
+		}
+		require.NoError(t, WriteFile(goldenFile, vectors))
+	}
+
+	for _, v := range vectors {
+		v := v
+		t.Run(v.Name, func(t *testing.T) {
+			require.NoError(t, v.Verify())
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+
+	params, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	require.NoError(t, err)
+
+	v, err := Generate("test", "bfv", params, []byte{0x42})
+	require.NoError(t, err)
+	require.NoError(t, v.Verify())
+
+	// The vectors of different seeds have no digest in common
+	w, err := Generate("test", "bfv", params, []byte{0x43})
+	require.NoError(t, err)
+	for name := range v.Digests {
+		require.NotEqual(t, v.Digests[name], w.Digests[name], name)
+	}
+
+	v.Digests["ct/mul"] = hex.EncodeToString(make([]byte, 32))
+	require.Error(t, v.Verify())
+	delete(v.Digests, "ct/mul")
+	require.Error(t, v.Verify())
+
+	v.Scheme = "bgv"
+	require.Error(t, v.Verify())
+}
@@ -0,0 +1,201 @@
+// Package mkbfv implements a multi-key variant of the BFV scheme, which enables the evaluation of circuits on
+// ciphertexts encrypted under the individual keys of different parties, without a prior collective key generation.
+// The ciphertexts are encrypted with the bfv package, under the public key of a party, and are combined on the fly
+// into multi-key ciphertexts involving the union of the parties. See the drlwe package for the multi-key evaluation
+// keys and the decryption protocol.
+package mkbfv
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Ciphertext is a multi-key BFV ciphertext.
+type Ciphertext struct {
+	*drlwe.MKCiphertext
+}
+
+// NewCiphertext allocates a new multi-key ciphertext of the parties.
+func NewCiphertext(params bfv.Parameters, parties []drlwe.ShamirPublicPoint) *Ciphertext {
+	return &Ciphertext{drlwe.NewMKCiphertext(params.Parameters, parties, params.MaxLevel())}
+}
+
+// NewCiphertextFromBFV returns the multi-key ciphertext of the party of the BFV ciphertext ct, encrypted under its
+// individual key. The returned ciphertext shares the polynomials of ct.
+func NewCiphertextFromBFV(ct *bfv.Ciphertext, party drlwe.ShamirPublicPoint) *Ciphertext {
+	return &Ciphertext{drlwe.NewMKCiphertextFromCiphertext(ct.Ciphertext, party)}
+}
+
+// Evaluator is a struct for the homomorphic operations on the multi-key BFV ciphertexts.
+type Evaluator struct {
+	params        bfv.Parameters
+	mke           *drlwe.MKEvaluator
+	evk           map[drlwe.ShamirPublicPoint]*drlwe.MKEvaluationKey
+	basisExtender *ring.BasisExtender
+	pHalf         *big.Int
+}
+
+// NewEvaluator creates a new Evaluator for the MKEvaluationKeys evk of the parties, which are needed for the
+// multiplications. The map can be completed with the keys of new parties as long as the Evaluator is not used.
+func NewEvaluator(params bfv.Parameters, evk map[drlwe.ShamirPublicPoint]*drlwe.MKEvaluationKey) *Evaluator {
+	return &Evaluator{
+		params:        params,
+		mke:           drlwe.NewMKEvaluator(params.Parameters),
+		evk:           evk,
+		basisExtender: ring.NewBasisExtender(params.RingQ(), params.RingQMul()),
+		pHalf:         new(big.Int).Rsh(params.RingQMul().ModulusBigint, 1),
+	}
+}
+
+// ShallowCopy creates a shallow copy of Evaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Evaluator can be used concurrently.
+func (eval *Evaluator) ShallowCopy() *Evaluator {
+	return &Evaluator{
+		params:        eval.params,
+		mke:           eval.mke.ShallowCopy(),
+		evk:           eval.evk,
+		basisExtender: eval.basisExtender.ShallowCopy(),
+		pHalf:         eval.pHalf,
+	}
+}
+
+// AddNew adds ct0 to ct1 and returns the result, which involves the union of the parties of ct0 and ct1.
+func (eval *Evaluator) AddNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, drlwe.MKUnionParties(ct0.MKCiphertext, ct1.MKCiphertext))
+	eval.mke.Add(ct0.MKCiphertext, ct1.MKCiphertext, ctOut.MKCiphertext)
+	return
+}
+
+// MulRelinNew multiplies ct0 by ct1, relinearizes the product with the evaluation keys of the parties and returns the
+// result, which involves the union of the parties of ct0 and ct1. It panics if the evaluation key of a party is missing.
+func (eval *Evaluator) MulRelinNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+
+	ringQ := eval.params.RingQ()
+	ringQMul := eval.params.RingQMul()
+
+	parties := drlwe.MKUnionParties(ct0.MKCiphertext, ct1.MKCiphertext)
+
+	// Extends the components of the ciphertexts to the union of the parties, and their basis from Q to QQMul
+	c0Q, c0QMul := eval.modUpAndNTT(ct0, parties)
+	c1Q, c1QMul := eval.modUpAndNTT(ct1, parties)
+
+	for i := range c0Q {
+		ringQ.MForm(c0Q[i], c0Q[i])
+		ringQMul.MForm(c0QMul[i], c0QMul[i])
+	}
+
+	tmpQ, tmpQMul := ringQ.NewPoly(), ringQMul.NewPoly()
+
+	tensor := make([][]*ring.Poly, len(parties)+1)
+	for i := range tensor {
+		tensor[i] = make([]*ring.Poly, len(parties)+1)
+		for j := range tensor[i] {
+			ringQ.MulCoeffsMontgomery(c0Q[i], c1Q[j], tmpQ)
+			ringQMul.MulCoeffsMontgomery(c0QMul[i], c1QMul[j], tmpQMul)
+			tensor[i][j] = ringQ.NewPoly()
+			eval.quantize(tmpQ, tmpQMul, tensor[i][j])
+			ringQ.NTT(tensor[i][j], tensor[i][j])
+			tensor[i][j].IsNTT = true
+		}
+	}
+
+	ctOut = NewCiphertext(eval.params, parties)
+	eval.mke.Relinearize(tensor, eval.evk, ctOut.MKCiphertext)
+
+	for _, c := range ctOut.Value {
+		ringQ.InvNTT(c, c)
+		c.IsNTT = false
+	}
+
+	return
+}
+
+// modUpAndNTT returns the components of ct extended to the parties, mod Q and mod QMul and in the NTT domain.
+func (eval *Evaluator) modUpAndNTT(ct *Ciphertext, parties []drlwe.ShamirPublicPoint) (cQ, cQMul []*ring.Poly) {
+
+	ringQ := eval.params.RingQ()
+	ringQMul := eval.params.RingQMul()
+	levelQ, levelQMul := len(ringQ.Modulus)-1, len(ringQMul.Modulus)-1
+
+	cQ = make([]*ring.Poly, len(parties)+1)
+	cQMul = make([]*ring.Poly, len(parties)+1)
+
+	for i := range cQ {
+
+		cQ[i], cQMul[i] = ringQ.NewPoly(), ringQMul.NewPoly()
+
+		c := ct.Value[0]
+		if i > 0 {
+			if c = ct.Component(parties[i-1]); c == nil {
+				continue
+			}
+		}
+
+		eval.basisExtender.ModUpQtoP(levelQ, levelQMul, c, cQMul[i])
+		ringQ.NTT(c, cQ[i])
+		ringQMul.NTT(cQMul[i], cQMul[i])
+	}
+
+	return
+}
+
+// quantize computes round(t/Q * c) from c mod QQMul in the NTT domain and returns the result in cOut, mod Q and out of
+// the NTT domain.
+func (eval *Evaluator) quantize(cQ, cQMul, cOut *ring.Poly) {
+
+	ringQ := eval.params.RingQ()
+	ringQMul := eval.params.RingQMul()
+	levelQ, levelQMul := len(ringQ.Modulus)-1, len(ringQMul.Modulus)-1
+
+	ringQ.InvNTT(cQ, cQ)
+	ringQMul.InvNTT(cQMul, cQMul)
+
+	// Divides c by Q with the result in QMul, then centers it and extends it to the basis Q
+	eval.basisExtender.ModDownQPtoP(levelQ, levelQMul, cQ, cQMul, cQMul)
+	ringQMul.AddScalarBigint(cQMul, eval.pHalf, cQMul)
+	eval.basisExtender.ModUpPtoQ(levelQMul, levelQ, cQMul, cOut)
+	ringQ.SubScalarBigint(cOut, eval.pHalf, cOut)
+
+	ringQ.MulScalar(cOut, eval.params.T(), cOut)
+}
+
+// DecryptionProtocol is the protocol for the decryption of the multi-key BFV ciphertexts.
+type DecryptionProtocol struct {
+	drlwe.MKDecryptionProtocol
+	params bfv.Parameters
+}
+
+// NewDecryptionProtocol creates a new DecryptionProtocol, sigmaSmudging being the standard deviation of the smudging
+// noise added by each party to its share.
+func NewDecryptionProtocol(params bfv.Parameters, sigmaSmudging float64) *DecryptionProtocol {
+	return &DecryptionProtocol{*drlwe.NewMKDecryptionProtocol(params.Parameters, sigmaSmudging), params}
+}
+
+// ShallowCopy creates a shallow copy of DecryptionProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// DecryptionProtocol can be used concurrently.
+func (dec *DecryptionProtocol) ShallowCopy() *DecryptionProtocol {
+	return &DecryptionProtocol{*dec.MKDecryptionProtocol.ShallowCopy(), dec.params}
+}
+
+// AllocateShare allocates the share of a party in the DecryptionProtocol.
+func (dec *DecryptionProtocol) AllocateShare() *drlwe.CKSShare {
+	return dec.MKDecryptionProtocol.AllocateShare(dec.params.MaxLevel())
+}
+
+// GenShare computes the share of the party of secret key sk for the decryption of ct. It returns an error if ct does
+// not involve the party.
+func (dec *DecryptionProtocol) GenShare(sk *rlwe.SecretKey, party drlwe.ShamirPublicPoint, ct *Ciphertext, shareOut *drlwe.CKSShare) error {
+	return dec.MKDecryptionProtocol.GenShare(sk, party, ct.MKCiphertext, shareOut)
+}
+
+// Decrypt combines the shares of the parties of ct into the plaintext ptOut. It returns an error if the share of a
+// party of ct is missing.
+func (dec *DecryptionProtocol) Decrypt(ct *Ciphertext, shares map[drlwe.ShamirPublicPoint]*drlwe.CKSShare, ptOut *bfv.Plaintext) error {
+	return dec.MKDecryptionProtocol.Decrypt(ct.MKCiphertext, shares, ptOut.Value)
+}
@@ -0,0 +1,107 @@
+package mkbfv
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short.")
+var parties int = 3
+
+func testString(opname string, parties int, params bfv.Parameters) string {
+	return fmt.Sprintf("%s/LogN=%d/logQ=%d/parties=%d", opname, params.LogN(), params.LogQP(), parties)
+}
+
+func TestMKBFV(t *testing.T) {
+
+	defaultParams := bfv.DefaultParams[:2] // the default test runs for ring degree N=2^12, 2^13
+	if testing.Short() {
+		defaultParams = bfv.DefaultParams[:1] // the short test suite runs for ring degree N=2^12
+	}
+	if *flagParamString != "" {
+		var jsonParams bfv.ParametersLiteral
+		json.Unmarshal([]byte(*flagParamString), &jsonParams)
+		defaultParams = []bfv.ParametersLiteral{jsonParams} // the custom test suite reads the parameters from the -params flag
+	}
+
+	for _, p := range defaultParams {
+
+		params, err := bfv.NewParametersFromLiteral(p)
+		if err != nil {
+			panic(err)
+		}
+
+		t.Run(testString("MulRelinAndAdd", parties, params), func(t *testing.T) {
+
+			prng, err := utils.NewKeyedPRNG([]byte{'t', 'e', 's', 't'})
+			require.NoError(t, err)
+
+			kgen := bfv.NewKeyGenerator(params)
+			mkg := drlwe.NewMKKeyGenerator(params.Parameters)
+			crp := mkg.SampleCRP(prng)
+			encoder := bfv.NewEncoder(params)
+
+			points := make([]drlwe.ShamirPublicPoint, parties)
+			sk := make([]*rlwe.SecretKey, parties)
+			evk := map[drlwe.ShamirPublicPoint]*drlwe.MKEvaluationKey{}
+			values := make([][]uint64, parties)
+			cts := make([]*Ciphertext, parties)
+
+			for i := range points {
+
+				// Each party generates its individual keys and evaluation key, and encrypts its input
+				points[i] = drlwe.ShamirPublicPoint(i + 1)
+
+				var pk *rlwe.PublicKey
+				sk[i], pk = kgen.GenKeyPair()
+
+				evk[points[i]] = drlwe.NewMKEvaluationKey(params.Parameters)
+				mkg.GenEvaluationKey(sk[i], crp, evk[points[i]])
+
+				values[i] = make([]uint64, params.N())
+				for j := range values[i] {
+					values[i][j] = utils.RandUint64() % params.T()
+				}
+
+				pt := bfv.NewPlaintext(params)
+				encoder.EncodeUint(values[i], pt)
+				cts[i] = NewCiphertextFromBFV(bfv.NewEncryptor(params, pk).EncryptNew(pt), points[i])
+			}
+
+			eval := NewEvaluator(params, evk)
+
+			// ct = ct0*ct1 + ct1*ct2
+			ct := eval.AddNew(eval.MulRelinNew(cts[0], cts[1]), eval.ShallowCopy().MulRelinNew(cts[1], cts[2]))
+			require.Equal(t, points, ct.Parties)
+
+			dec := NewDecryptionProtocol(params, rlwe.DefaultSigma)
+			shares := map[drlwe.ShamirPublicPoint]*drlwe.CKSShare{}
+			for i, p := range points {
+				shares[p] = dec.AllocateShare()
+				require.NoError(t, dec.ShallowCopy().GenShare(sk[i], p, ct, shares[p]))
+			}
+
+			pt := bfv.NewPlaintext(params)
+			require.NoError(t, dec.Decrypt(ct, shares, pt))
+
+			want := make([]uint64, params.N())
+			for j := range want {
+				want[j] = (values[0][j]*values[1][j] + values[1][j]*values[2][j]) % params.T()
+			}
+
+			require.Equal(t, want, encoder.DecodeUintNew(pt))
+
+			// The evaluation key of each party involved in a multiplication is required
+			delete(evk, points[2])
+			require.Panics(t, func() { eval.MulRelinNew(cts[1], cts[2]) })
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Package mkckks implements a multi-key variant of the CKKS scheme, which enables the evaluation of circuits on
+// ciphertexts encrypted under the individual keys of different parties, without a prior collective key generation.
+// The ciphertexts are encrypted with the ckks package, under the public key of a party, and are combined on the fly
+// into multi-key ciphertexts involving the union of the parties. See the drlwe package for the multi-key evaluation
+// keys and the decryption protocol.
+package mkckks
+
+import (
+	"errors"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Ciphertext is a multi-key CKKS ciphertext.
+type Ciphertext struct {
+	*drlwe.MKCiphertext
+	Scale float64
+}
+
+// NewCiphertext allocates a new multi-key ciphertext of the parties at the given level and scale.
+func NewCiphertext(params ckks.Parameters, parties []drlwe.ShamirPublicPoint, level int, scale float64) *Ciphertext {
+	ct := &Ciphertext{drlwe.NewMKCiphertext(params.Parameters, parties, level), scale}
+	for _, c := range ct.Value {
+		c.IsNTT = true
+	}
+	return ct
+}
+
+// NewCiphertextFromCKKS returns the multi-key ciphertext of the party of the CKKS ciphertext ct, encrypted under its
+// individual key. The returned ciphertext shares the polynomials of ct.
+func NewCiphertextFromCKKS(ct *ckks.Ciphertext, party drlwe.ShamirPublicPoint) *Ciphertext {
+	return &Ciphertext{drlwe.NewMKCiphertextFromCiphertext(ct.Ciphertext, party), ct.Scale}
+}
+
+// Evaluator is a struct for the homomorphic operations on the multi-key CKKS ciphertexts.
+type Evaluator struct {
+	params ckks.Parameters
+	mke    *drlwe.MKEvaluator
+	evk    map[drlwe.ShamirPublicPoint]*drlwe.MKEvaluationKey
+	pool   *ring.Poly
+}
+
+// NewEvaluator creates a new Evaluator for the MKEvaluationKeys evk of the parties, which are needed for the
+// multiplications. The map can be completed with the keys of new parties as long as the Evaluator is not used.
+func NewEvaluator(params ckks.Parameters, evk map[drlwe.ShamirPublicPoint]*drlwe.MKEvaluationKey) *Evaluator {
+	return &Evaluator{
+		params: params,
+		mke:    drlwe.NewMKEvaluator(params.Parameters),
+		evk:    evk,
+		pool:   params.RingQ().NewPoly(),
+	}
+}
+
+// ShallowCopy creates a shallow copy of Evaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Evaluator can be used concurrently.
+func (eval *Evaluator) ShallowCopy() *Evaluator {
+	return &Evaluator{
+		params: eval.params,
+		mke:    eval.mke.ShallowCopy(),
+		evk:    eval.evk,
+		pool:   eval.params.RingQ().NewPoly(),
+	}
+}
+
+// AddNew adds ct0 to ct1 and returns the result, which involves the union of the parties of ct0 and ct1, at the minimum
+// level of ct0 and ct1. It panics if the ciphertexts do not have the same scale.
+func (eval *Evaluator) AddNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+
+	if ct0.Scale != ct1.Scale {
+		panic("cannot AddNew: the ciphertexts must have the same scale")
+	}
+
+	level := utils.MinInt(ct0.Level(), ct1.Level())
+	ctOut = NewCiphertext(eval.params, drlwe.MKUnionParties(ct0.MKCiphertext, ct1.MKCiphertext), level, ct0.Scale)
+	eval.mke.Add(ct0.MKCiphertext, ct1.MKCiphertext, ctOut.MKCiphertext)
+	return
+}
+
+// MulRelinNew multiplies ct0 by ct1, relinearizes the product with the evaluation keys of the parties and returns the
+// result, which involves the union of the parties of ct0 and ct1, at the minimum level of ct0 and ct1 and with the
+// product of their scales. The result is not rescaled. It panics if the evaluation key of a party is missing.
+func (eval *Evaluator) MulRelinNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+
+	ringQ := eval.params.RingQ()
+
+	parties := drlwe.MKUnionParties(ct0.MKCiphertext, ct1.MKCiphertext)
+	level := utils.MinInt(ct0.Level(), ct1.Level())
+
+	c0, c1 := eval.extend(ct0, parties), eval.extend(ct1, parties)
+
+	for i := range c0 {
+		if c0[i] != nil {
+			ringQ.MFormLvl(level, c0[i], c0[i])
+		}
+	}
+
+	tensor := make([][]*ring.Poly, len(parties)+1)
+	for i := range tensor {
+		tensor[i] = make([]*ring.Poly, len(parties)+1)
+		for j := range tensor[i] {
+			tensor[i][j] = ringQ.NewPolyLvl(level)
+			if c0[i] != nil && c1[j] != nil {
+				ringQ.MulCoeffsMontgomeryLvl(level, c0[i], c1[j], tensor[i][j])
+			}
+			tensor[i][j].IsNTT = true
+		}
+	}
+
+	ctOut = NewCiphertext(eval.params, parties, level, ct0.Scale*ct1.Scale)
+	eval.mke.Relinearize(tensor, eval.evk, ctOut.MKCiphertext)
+
+	return
+}
+
+// extend returns copies of the components of ct extended to the parties, nil being a zero component.
+func (eval *Evaluator) extend(ct *Ciphertext, parties []drlwe.ShamirPublicPoint) (c []*ring.Poly) {
+	c = make([]*ring.Poly, len(parties)+1)
+	c[0] = ct.Value[0].CopyNew()
+	for i, p := range parties {
+		if ci := ct.Component(p); ci != nil {
+			c[i+1] = ci.CopyNew()
+		}
+	}
+	return
+}
+
+// Rescale divides ctIn by the last moduli of the modulus chain as long as its scale remains larger than minScale/2,
+// and writes the result in ctOut, which must involve the same parties as ctIn. It returns an error if the scale of ctIn
+// is 0, if minScale is not positive or if ctIn is at level 0.
+func (eval *Evaluator) Rescale(ctIn *Ciphertext, minScale float64, ctOut *Ciphertext) (err error) {
+
+	ringQ := eval.params.RingQ()
+
+	if minScale <= 0 {
+		return errors.New("cannot Rescale: minScale is 0")
+	}
+
+	if ctIn.Scale == 0 {
+		return errors.New("cannot Rescale: ciphertext scale is 0")
+	}
+
+	if ctIn.Level() == 0 {
+		return errors.New("cannot Rescale: input Ciphertext already at level 0")
+	}
+
+	if ctOut.Degree() != ctIn.Degree() {
+		return errors.New("cannot Rescale : ctIn.Degree() != ctOut.Degree()")
+	}
+
+	ctOut.Scale = ctIn.Scale
+
+	var nbRescales int
+	for ctIn.Level()-nbRescales > 0 && ctOut.Scale/float64(ringQ.Modulus[ctIn.Level()-nbRescales]) >= minScale/2 {
+		ctOut.Scale /= float64(ringQ.Modulus[ctIn.Level()-nbRescales])
+		nbRescales++
+	}
+
+	level := ctIn.Level()
+	for i := range ctOut.Value {
+		if nbRescales > 0 {
+			ringQ.DivRoundByLastModulusManyNTTLvl(level, nbRescales, ctIn.Value[i], eval.pool, ctOut.Value[i])
+		} else if ctIn != ctOut {
+			ring.CopyValuesLvl(level, ctIn.Value[i], ctOut.Value[i])
+		}
+		ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:level+1-nbRescales]
+	}
+
+	return nil
+}
+
+// DecryptionProtocol is the protocol for the decryption of the multi-key CKKS ciphertexts.
+type DecryptionProtocol struct {
+	drlwe.MKDecryptionProtocol
+}
+
+// NewDecryptionProtocol creates a new DecryptionProtocol, sigmaSmudging being the standard deviation of the smudging
+// noise added by each party to its share.
+func NewDecryptionProtocol(params ckks.Parameters, sigmaSmudging float64) *DecryptionProtocol {
+	return &DecryptionProtocol{*drlwe.NewMKDecryptionProtocol(params.Parameters, sigmaSmudging)}
+}
+
+// ShallowCopy creates a shallow copy of DecryptionProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// DecryptionProtocol can be used concurrently.
+func (dec *DecryptionProtocol) ShallowCopy() *DecryptionProtocol {
+	return &DecryptionProtocol{*dec.MKDecryptionProtocol.ShallowCopy()}
+}
+
+// GenShare computes the share of the party of secret key sk for the decryption of ct. It returns an error if ct does
+// not involve the party.
+func (dec *DecryptionProtocol) GenShare(sk *rlwe.SecretKey, party drlwe.ShamirPublicPoint, ct *Ciphertext, shareOut *drlwe.CKSShare) error {
+	return dec.MKDecryptionProtocol.GenShare(sk, party, ct.MKCiphertext, shareOut)
+}
+
+// Decrypt combines the shares of the parties of ct into the plaintext ptOut, whose scale is set to the scale of ct. It
+// returns an error if the share of a party of ct is missing.
+func (dec *DecryptionProtocol) Decrypt(ct *Ciphertext, shares map[drlwe.ShamirPublicPoint]*drlwe.CKSShare, ptOut *ckks.Plaintext) error {
+	if err := dec.MKDecryptionProtocol.Decrypt(ct.MKCiphertext, shares, ptOut.Value); err != nil {
+		return err
+	}
+	ptOut.Scale = ct.Scale
+	return nil
+}
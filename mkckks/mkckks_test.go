@@ -0,0 +1,111 @@
+package mkckks
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/cmplx"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short.")
+var parties int = 3
+
+func testString(opname string, parties int, params ckks.Parameters) string {
+	return fmt.Sprintf("%s/LogN=%d/logQ=%d/parties=%d", opname, params.LogN(), params.LogQP(), parties)
+}
+
+func TestMKCKKS(t *testing.T) {
+
+	defaultParams := ckks.DefaultParams[:2] // the default test runs for ring degree N=2^12, 2^13
+	if testing.Short() {
+		defaultParams = ckks.DefaultParams[:1] // the short test suite runs for ring degree N=2^12
+	}
+	if *flagParamString != "" {
+		var jsonParams ckks.ParametersLiteral
+		json.Unmarshal([]byte(*flagParamString), &jsonParams)
+		defaultParams = []ckks.ParametersLiteral{jsonParams} // the custom test suite reads the parameters from the -params flag
+	}
+
+	for _, p := range defaultParams {
+
+		params, err := ckks.NewParametersFromLiteral(p)
+		if err != nil {
+			panic(err)
+		}
+
+		t.Run(testString("MulRelinAndAdd", parties, params), func(t *testing.T) {
+
+			prng, err := utils.NewKeyedPRNG([]byte{'t', 'e', 's', 't'})
+			require.NoError(t, err)
+
+			kgen := ckks.NewKeyGenerator(params)
+			mkg := drlwe.NewMKKeyGenerator(params.Parameters)
+			crp := mkg.SampleCRP(prng)
+			encoder := ckks.NewEncoder(params)
+
+			points := make([]drlwe.ShamirPublicPoint, parties)
+			sk := make([]*rlwe.SecretKey, parties)
+			evk := map[drlwe.ShamirPublicPoint]*drlwe.MKEvaluationKey{}
+			values := make([][]complex128, parties)
+			cts := make([]*Ciphertext, parties)
+
+			for i := range points {
+
+				// Each party generates its individual keys and evaluation key, and encrypts its input
+				points[i] = drlwe.ShamirPublicPoint(i + 1)
+
+				var pk *rlwe.PublicKey
+				sk[i], pk = kgen.GenKeyPair()
+
+				evk[points[i]] = drlwe.NewMKEvaluationKey(params.Parameters)
+				mkg.GenEvaluationKey(sk[i], crp, evk[points[i]])
+
+				values[i] = make([]complex128, params.Slots())
+				for j := range values[i] {
+					values[i][j] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+				}
+
+				pt := encoder.EncodeNew(values[i], params.MaxLevel(), params.DefaultScale(), params.LogSlots())
+				cts[i] = NewCiphertextFromCKKS(ckks.NewEncryptor(params, pk).EncryptNew(pt), points[i])
+			}
+
+			eval := NewEvaluator(params, evk)
+
+			// ct = ct0*ct1 + ct1*ct2
+			ct := eval.AddNew(eval.MulRelinNew(cts[0], cts[1]), eval.ShallowCopy().MulRelinNew(cts[1], cts[2]))
+			require.Equal(t, points, ct.Parties)
+			require.NoError(t, eval.Rescale(ct, params.DefaultScale(), ct))
+			require.Equal(t, params.MaxLevel()-1, ct.Level())
+
+			require.Panics(t, func() { eval.AddNew(ct, cts[0]) })
+
+			dec := NewDecryptionProtocol(params, rlwe.DefaultSigma)
+			shares := map[drlwe.ShamirPublicPoint]*drlwe.CKSShare{}
+			for i, p := range points {
+				shares[p] = dec.AllocateShare(ct.Level())
+				require.NoError(t, dec.ShallowCopy().GenShare(sk[i], p, ct, shares[p]))
+			}
+
+			pt := ckks.NewPlaintext(params, ct.Level(), 0)
+			require.NoError(t, dec.Decrypt(ct, shares, pt))
+			require.Equal(t, ct.Scale, pt.Scale)
+
+			have := encoder.Decode(pt, params.LogSlots())
+			for j := range have {
+				want := values[0][j]*values[1][j] + values[1][j]*values[2][j]
+				require.Less(t, cmplx.Abs(want-have[j]), 1e-3)
+			}
+
+			// The evaluation key of each party involved in a multiplication is required
+			delete(evk, points[2])
+			require.Panics(t, func() { eval.MulRelinNew(cts[1], cts[2]) })
+		})
+	}
+}
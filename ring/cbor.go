@@ -0,0 +1,53 @@
+package ring
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/utils/cbor"
+)
+
+// CBORValue returns the value encoding the polynomial in the CBOR serialization (see the utils/cbor package): a map
+// with the flags "ntt" and "mform" of the polynomial and its "coeffs", the typed arrays of its coefficients modulo each
+// modulus.
+func (pol *Poly) CBORValue() (interface{}, error) {
+	coeffs := make([]interface{}, len(pol.Coeffs))
+	for i := range coeffs {
+		coeffs[i] = pol.Coeffs[i]
+	}
+	return map[string]interface{}{"ntt": pol.IsNTT, "mform": pol.IsMForm, "coeffs": coeffs}, nil
+}
+
+// SetCBORValue sets the polynomial to the decoded CBOR value v generated by CBORValue.
+func (pol *Poly) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsObject(v)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	if pol.IsNTT, err = o.Bool("ntt"); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	if pol.IsMForm, err = o.Bool("mform"); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	rows, err := o.Array("coeffs")
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	coeffs := make([][]uint64, len(rows))
+	for i, row := range rows {
+		var ok bool
+		if coeffs[i], ok = row.([]uint64); !ok || len(coeffs[i]) != len(coeffs[0]) {
+			return errors.New("cannot SetCBORValue: invalid coefficients")
+		}
+	}
+
+	pol.Coeffs = coeffs
+
+	return nil
+}
@@ -172,6 +172,36 @@ func (r *Ring) StandardRing() (*Ring, error) {
 	return &sr, sr.genNTTParams(uint64(sr.N) << 1)
 }
 
+// SubRing returns the ring of the moduli of index [start, end) of the receiver ring, which can be used to operate on
+// the corresponding limbs of polynomials of the receiver ring, e.g. concurrently on disjoint ranges of limbs.
+// The returned Ring shares the precomputed values of the receiver ring, except for the rescaling parameters,
+// so that the operations dividing by the last modulus are not supported.
+func (r *Ring) SubRing(start, end int) *Ring {
+
+	if start < 0 || end > len(r.Modulus) || start >= end {
+		panic(fmt.Errorf("invalid SubRing range [%d, %d) for a ring with %d moduli", start, end, len(r.Modulus)))
+	}
+
+	sr := *r
+	sr.Modulus = r.Modulus[start:end]
+	sr.Mask = r.Mask[start:end]
+	sr.BredParams = r.BredParams[start:end]
+	sr.MredParams = r.MredParams[start:end]
+	sr.RescaleParams = nil
+	sr.PsiMont = r.PsiMont[start:end]
+	sr.PsiInvMont = r.PsiInvMont[start:end]
+	sr.NttPsi = r.NttPsi[start:end]
+	sr.NttPsiInv = r.NttPsiInv[start:end]
+	sr.NttNInv = r.NttNInv[start:end]
+
+	sr.ModulusBigint = NewUint(1)
+	for _, qi := range sr.Modulus {
+		sr.ModulusBigint.Mul(sr.ModulusBigint, NewUint(qi))
+	}
+
+	return &sr
+}
+
 // Type returns the Type of the ring which might be either `Standard` or `ConjugateInvariant`.
 func (r *Ring) Type() Type {
 	switch r.NumberTheoreticTransformer.(type) {
@@ -201,6 +231,12 @@ func (r *Ring) setParameters(N int, Modulus []uint64) error {
 		return errors.New("invalid modulus (moduli are not distinct)")
 	}
 
+	for _, qi := range Modulus {
+		if qi < 2 || bits.Len64(qi) > 62 {
+			return errors.New("invalid modulus (moduli must be between 2 and 2^62)")
+		}
+	}
+
 	r.AllowsNTT = false
 
 	r.N = N
@@ -357,6 +357,31 @@ func (r *Ring) MulScalarLvl(level int, p1 *Poly, scalar uint64, p2 *Poly) {
 	}
 }
 
+// MulScalarAndAdd multiplies each coefficient of p1 by a scalar and adds the result on p2.
+func (r *Ring) MulScalarAndAdd(p1 *Poly, scalar uint64, p2 *Poly) {
+	r.MulScalarAndAddLvl(r.minLevelBinary(p1, p2), p1, scalar, p2)
+}
+
+// MulScalarAndAddLvl multiplies each coefficient of p1 by a scalar for the moduli from q_0 up to q_level and adds the result on p2.
+func (r *Ring) MulScalarAndAddLvl(level int, p1 *Poly, scalar uint64, p2 *Poly) {
+	for i := 0; i < level+1; i++ {
+		MulScalarMontgomeryAndAddVec(p1.Coeffs[i][:r.N], p2.Coeffs[i][:r.N], MForm(BRedAdd(scalar, r.Modulus[i], r.BredParams[i]), r.Modulus[i], r.BredParams[i]), r.Modulus[i], r.MredParams[i])
+	}
+}
+
+// MulScalarAndAddNoMod multiplies each coefficient of p1 by a scalar and adds the result on p2 without modular reduction.
+func (r *Ring) MulScalarAndAddNoMod(p1 *Poly, scalar uint64, p2 *Poly) {
+	r.MulScalarAndAddNoModLvl(r.minLevelBinary(p1, p2), p1, scalar, p2)
+}
+
+// MulScalarAndAddNoModLvl multiplies each coefficient of p1 by a scalar for the moduli from q_0 up to q_level
+// and adds the result on p2 without modular reduction.
+func (r *Ring) MulScalarAndAddNoModLvl(level int, p1 *Poly, scalar uint64, p2 *Poly) {
+	for i := 0; i < level+1; i++ {
+		MulScalarMontgomeryAndAddNoModVec(p1.Coeffs[i][:r.N], p2.Coeffs[i][:r.N], MForm(BRedAdd(scalar, r.Modulus[i], r.BredParams[i]), r.Modulus[i], r.BredParams[i]), r.Modulus[i], r.MredParams[i])
+	}
+}
+
 // MulScalarBigint multiplies each coefficient of p1 by a big.Int scalar and writes the result on p2.
 func (r *Ring) MulScalarBigint(p1 *Poly, scalar *big.Int, p2 *Poly) {
 	r.MulScalarBigintLvl(r.minLevelBinary(p1, p2), p1, scalar, p2)
@@ -3,6 +3,7 @@ package ring
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"github.com/ldsec/lattigo/v2/utils"
 	"math/bits"
 )
@@ -309,20 +310,12 @@ func (pol *Poly) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decodes a slice of byte on the target polynomial.
 func (pol *Poly) UnmarshalBinary(data []byte) (err error) {
 
-	N := int(1 << data[0])
-	numberModulies := int(data[1])
-
-	if data[2] == 1 {
-		pol.IsNTT = true
-	}
-
-	if data[3] == 1 {
-		pol.IsMForm = true
+	N, numberModulies, err := decodePolyMetadata(data, 8)
+	if err != nil {
+		return err
 	}
 
-	pointer := 4
-
-	if ((len(data) - pointer) >> 3) != N*numberModulies {
+	if ((len(data) - 4) >> 3) != N*numberModulies {
 		return errors.New("invalid polynomial encoding")
 	}
 
@@ -337,20 +330,17 @@ func (pol *Poly) UnmarshalBinary(data []byte) (err error) {
 // decoded.
 func (pol *Poly) DecodePolyNew(data []byte) (pointer int, err error) {
 
-	N := int(1 << data[0])
-	numberModulies := int(data[1])
-
-	if data[2] == 1 {
-		pol.IsNTT = true
+	N, numberModulies, err := decodePolyMetadata(data, 8)
+	if err != nil {
+		return 0, err
 	}
 
-	if data[3] == 1 {
-		pol.IsMForm = true
-	}
+	pol.IsNTT = data[2] == 1
+	pol.IsMForm = data[3] == 1
 
 	pointer = 4
 
-	if pol.Coeffs == nil {
+	if len(pol.Coeffs) != numberModulies {
 		pol.Coeffs = make([][]uint64, numberModulies)
 	}
 
@@ -365,20 +355,17 @@ func (pol *Poly) DecodePolyNew(data []byte) (pointer int, err error) {
 // decoded.
 func (pol *Poly) DecodePolyNew32(data []byte) (pointer int, err error) {
 
-	N := int(1 << data[0])
-	numberModulies := int(data[1])
-
-	if data[2] == 1 {
-		pol.IsNTT = true
+	N, numberModulies, err := decodePolyMetadata(data, 4)
+	if err != nil {
+		return 0, err
 	}
 
-	if data[3] == 1 {
-		pol.IsMForm = true
-	}
+	pol.IsNTT = data[2] == 1
+	pol.IsMForm = data[3] == 1
 
 	pointer = 4
 
-	if pol.Coeffs == nil {
+	if len(pol.Coeffs) != numberModulies {
 		pol.Coeffs = make([][]uint64, numberModulies)
 	}
 
@@ -389,6 +376,33 @@ func (pol *Poly) DecodePolyNew32(data []byte) (pointer int, err error) {
 	return pointer, nil
 }
 
+// decodePolyMetadata decodes the degree and the number of moduli of a polynomial encoded with coefficients of size
+// bytes, and checks that data holds all its coefficients, so that an invalid encoding, e.g. received from an untrusted
+// party, cannot cause an out of range access or an allocation larger than data.
+func decodePolyMetadata(data []byte, size int) (N, numberModuli int, err error) {
+
+	if len(data) < 4 {
+		return 0, 0, errors.New("invalid polynomial encoding: data is too short")
+	}
+
+	// The degree is checked before it is computed, so that the shift cannot overflow
+	if data[0] > 30 {
+		return 0, 0, fmt.Errorf("invalid polynomial encoding: invalid degree 2^%d", data[0])
+	}
+
+	N, numberModuli = 1<<data[0], int(data[1])
+
+	if numberModuli == 0 {
+		return 0, 0, errors.New("invalid polynomial encoding: no moduli")
+	}
+
+	if uint64(len(data)-4) < uint64(N)*uint64(numberModuli)*uint64(size) {
+		return 0, 0, errors.New("invalid polynomial encoding: data is too short")
+	}
+
+	return N, numberModuli, nil
+}
+
 // DecodeCoeffsNew32 converts a byte array to a matrix of coefficients.
 func DecodeCoeffsNew32(pointer, N, numberModuli int, coeffs [][]uint64, data []byte) (int, error) {
 	tmp := N << 2
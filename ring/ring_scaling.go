@@ -14,16 +14,17 @@ type Scaler interface {
 
 // RNSScaler implements the Scaler interface by performing a scaling by t/Q in the RNS domain.
 // This implementation of the Scaler interface is preferred over the SimpleScaler implementation.
+// The scaling can be applied at any level, in which case Q is the product of the moduli up to that level.
 type RNSScaler struct {
 	ringQ, ringT *Ring
 	polypoolQ    *Poly
 	polypoolT    *Poly
 
-	qHalf     *big.Int // (q-1)/2
-	qHalfModT uint64   // (q-1)/2 mod t
-	qInv      uint64   //(q mod t)^-1 mod t
+	qHalf     []*big.Int // (q-1)/2 for each level
+	qHalfModT []uint64   // (q-1)/2 mod t for each level
+	qInv      []uint64   //(q mod t)^-1 mod t for each level
 
-	paramsQP modupParams
+	paramsQP []modupParams
 }
 
 // NewRNSScaler creates a new SimpleScaler from t, the modulus under which the reconstruction is returned, the Ring in which the polynomial to reconstruct is represented.
@@ -39,25 +40,44 @@ func NewRNSScaler(ringQ, ringT *Ring) (rnss *RNSScaler) {
 
 	t := ringT.Modulus[0]
 
-	rnss.qHalf = new(big.Int)
-	rnss.qInv = rnss.qHalf.Mod(ringQ.ModulusBigint, NewUint(t)).Uint64()
-	rnss.qInv = ModExp(rnss.qInv, t-2, t)
-	rnss.qInv = MForm(rnss.qInv, t, BRedParams(t))
+	levels := len(ringQ.Modulus)
 
-	rnss.qHalf.Set(ringQ.ModulusBigint)
-	rnss.qHalf.Rsh(rnss.qHalf, 1)
-	rnss.qHalfModT = rnss.qHalf.Mod(rnss.qHalf, NewUint(t)).Uint64()
+	rnss.qHalf = make([]*big.Int, levels)
+	rnss.qHalfModT = make([]uint64, levels)
+	rnss.qInv = make([]uint64, levels)
+	rnss.paramsQP = make([]modupParams, levels)
 
-	rnss.qHalf.Set(ringQ.ModulusBigint)
-	rnss.qHalf.Rsh(rnss.qHalf, 1)
+	Q := NewUint(1)
+	for level := 0; level < levels; level++ {
 
-	rnss.paramsQP = basisextenderparameters(ringQ.Modulus, []uint64{t})
+		Q.Mul(Q, NewUint(ringQ.Modulus[level]))
+
+		qHalf := new(big.Int)
+		rnss.qInv[level] = qHalf.Mod(Q, NewUint(t)).Uint64()
+		rnss.qInv[level] = ModExp(rnss.qInv[level], t-2, t)
+		rnss.qInv[level] = MForm(rnss.qInv[level], t, BRedParams(t))
+
+		qHalf.Set(Q)
+		qHalf.Rsh(qHalf, 1)
+		rnss.qHalfModT[level] = new(big.Int).Mod(qHalf, NewUint(t)).Uint64()
+
+		rnss.qHalf[level] = qHalf
+
+		rnss.paramsQP[level] = basisextenderparameters(ringQ.Modulus[:level+1], []uint64{t})
+	}
 
 	return
 }
 
 // DivByQOverTRounded returns p1 scaled by a factor t/Q and mod t on the receiver p2.
+// Q is the product of the moduli up to the level of p1.
 func (rnss *RNSScaler) DivByQOverTRounded(p1Q, p2T *Poly) {
+	rnss.DivByQOverTRoundedLvl(p1Q.Level(), p1Q, p2T)
+}
+
+// DivByQOverTRoundedLvl returns p1 scaled by a factor t/Q and mod t on the receiver p2,
+// where Q is the product of the moduli from q_0 up to q_level.
+func (rnss *RNSScaler) DivByQOverTRoundedLvl(level int, p1Q, p2T *Poly) {
 
 	ringQ := rnss.ringQ
 	ringT := rnss.ringT
@@ -66,19 +86,19 @@ func (rnss *RNSScaler) DivByQOverTRounded(p1Q, p2T *Poly) {
 	p2tmp := p2T.Coeffs[0]
 	p3tmp := rnss.polypoolT.Coeffs[0]
 	mredParams := rnss.ringT.MredParams[0]
-	qInv := T - rnss.qInv
-	qHalfModT := T - rnss.qHalfModT
+	qInv := T - rnss.qInv[level]
+	qHalfModT := T - rnss.qHalfModT[level]
 
 	// Multiply P_{Q} by t and extend the basis from P_{Q} to t*(P_{Q}||P_{t})
 	// Since the coefficients of P_{t} are multiplied by t, they are all zero,
 	// hence the basis extension can be omitted
-	ringQ.MulScalar(p1Q, T, rnss.polypoolQ)
+	ringQ.MulScalarLvl(level, p1Q, T, rnss.polypoolQ)
 
 	// Center t*P_{Q} around (Q-1)/2 to round instead of floor during the division
-	ringQ.AddScalarBigint(rnss.polypoolQ, rnss.qHalf, rnss.polypoolQ)
+	ringQ.AddScalarBigintLvl(level, rnss.polypoolQ, rnss.qHalf[level], rnss.polypoolQ)
 
 	// Extend the basis of (t*P_{Q} + (Q-1)/2) to (t*P_{t} + (Q-1)/2)
-	modUpExact(rnss.polypoolQ.Coeffs, rnss.polypoolT.Coeffs, ringQ, ringT, rnss.paramsQP)
+	modUpExact(rnss.polypoolQ.Coeffs[:level+1], rnss.polypoolT.Coeffs, ringQ, ringT, rnss.paramsQP[level])
 
 	// Compute [Q^{-1} * (t*P_{t} -   (t*P_{Q} - ((Q-1)/2 mod t)))] mod t which returns round(t/Q * P_{Q}) mod t
 	for j := 0; j < ringQ.N; j = j + 8 {
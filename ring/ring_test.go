@@ -80,9 +80,11 @@ func TestRing(t *testing.T) {
 		testModularReduction(testContext, t)
 		testMForm(testContext, t)
 		testMulScalarBigint(testContext, t)
+		testMulScalarAndAdd(testContext, t)
 		testExtendBasis(testContext, t)
 		testScaling(testContext, t)
 		testMultByMonomial(testContext, t)
+		testSubRing(testContext, t)
 	}
 }
 
@@ -601,6 +603,32 @@ func testMulScalarBigint(testContext *testParams, t *testing.T) {
 	})
 }
 
+func testMulScalarAndAdd(testContext *testParams, t *testing.T) {
+
+	t.Run(testString("MulScalarAndAdd/", testContext.ringQ), func(t *testing.T) {
+
+		ringQ := testContext.ringQ
+
+		pol0 := testContext.uniformSamplerQ.ReadNew()
+		pol1 := testContext.uniformSamplerQ.ReadNew()
+
+		scalar := RandUniform(testContext.prng, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF)
+
+		polWant := ringQ.NewPoly()
+		ringQ.MulScalar(pol0, scalar, polWant)
+		ringQ.Add(polWant, pol1, polWant)
+
+		polTest := pol1.CopyNew()
+		ringQ.MulScalarAndAdd(pol0, scalar, polTest)
+		require.True(t, ringQ.Equal(polWant, polTest))
+
+		polTest = pol1.CopyNew()
+		ringQ.MulScalarAndAddNoMod(pol0, scalar, polTest)
+		ringQ.Reduce(polTest, polTest)
+		require.True(t, ringQ.Equal(polWant, polTest))
+	})
+}
+
 func testExtendBasis(testContext *testParams, t *testing.T) {
 
 	t.Run(testString("ModUp/", testContext.ringQ), func(t *testing.T) {
@@ -684,6 +712,56 @@ func testExtendBasis(testContext *testParams, t *testing.T) {
 		}
 
 	})
+
+	t.Run(testString("ModDownQPtoP/", testContext.ringQ), func(t *testing.T) {
+
+		basisextender := NewBasisExtender(testContext.ringQ, testContext.ringP)
+
+		// The levels differ so that the parameters of the division by Q are indexed by levelQ
+		levelQ := len(testContext.ringQ.Modulus) - 1
+		levelP := len(testContext.ringP.Modulus) - 2
+
+		Q := NewUint(1)
+		P := NewUint(1)
+		QP := NewUint(1)
+		for i := range testContext.ringQ.Modulus[:levelQ+1] {
+			Q.Mul(Q, NewUint(testContext.ringQ.Modulus[i]))
+		}
+
+		for i := range testContext.ringP.Modulus[:levelP+1] {
+			P.Mul(P, NewUint(testContext.ringP.Modulus[i]))
+		}
+
+		QP.Mul(QP, Q)
+		QP.Mul(QP, P)
+
+		coeffs := make([]*big.Int, testContext.ringQ.N)
+		for i := 0; i < testContext.ringQ.N; i++ {
+			coeffs[i] = RandInt(QP)
+			coeffs[i].Quo(coeffs[i], NewUint(10))
+		}
+
+		coeffsWant := make([]*big.Int, testContext.ringQ.N)
+		for i := range coeffs {
+			coeffsWant[i] = new(big.Int).Set(coeffs[i])
+			coeffsWant[i].Quo(coeffsWant[i], Q)
+		}
+
+		PolQHave := testContext.ringQ.NewPolyLvl(levelQ)
+		PolPHave := testContext.ringP.NewPolyLvl(levelP)
+		PolPWant := testContext.ringP.NewPolyLvl(levelP)
+
+		testContext.ringQ.SetCoefficientsBigintLvl(levelQ, coeffs, PolQHave)
+		testContext.ringP.SetCoefficientsBigintLvl(levelP, coeffs, PolPHave)
+		testContext.ringP.SetCoefficientsBigintLvl(levelP, coeffsWant, PolPWant)
+
+		basisextender.ModDownQPtoP(levelQ, levelP, PolQHave, PolPHave, PolPHave)
+		testContext.ringP.ReduceLvl(levelP, PolPHave, PolPHave)
+
+		for i := 0; i < levelP+1; i++ {
+			require.Equal(t, PolPHave.Coeffs[i][:testContext.ringQ.N], PolPWant.Coeffs[i][:testContext.ringQ.N])
+		}
+	})
 }
 
 func testScaling(testContext *testParams, t *testing.T) {
@@ -745,6 +823,43 @@ func testScaling(testContext *testParams, t *testing.T) {
 			require.Equal(t, polyT.Coeffs[0][i], coeffsWant[i].Uint64())
 		}
 	})
+
+	t.Run(testString("Scaling/RNS/Lvl", testContext.ringQ), func(t *testing.T) {
+
+		ringT, _ := NewRing(testContext.ringQ.N, []uint64{T})
+
+		scaler := NewRNSScaler(testContext.ringQ, ringT)
+
+		level := len(testContext.ringQ.Modulus) - 2
+
+		Q := NewUint(1)
+		for _, qi := range testContext.ringQ.Modulus[:level+1] {
+			Q.Mul(Q, NewUint(qi))
+		}
+
+		coeffs := make([]*big.Int, testContext.ringQ.N)
+		for i := 0; i < testContext.ringQ.N; i++ {
+			coeffs[i] = RandInt(Q)
+		}
+
+		coeffsWant := make([]*big.Int, testContext.ringQ.N)
+		for i := range coeffs {
+			coeffsWant[i] = new(big.Int).Set(coeffs[i])
+			coeffsWant[i].Mul(coeffsWant[i], NewUint(T))
+			DivRound(coeffsWant[i], Q, coeffsWant[i])
+			coeffsWant[i].Mod(coeffsWant[i], NewUint(T))
+		}
+
+		polyQ := NewPoly(testContext.ringQ.N, level+1)
+		polyT := NewPoly(testContext.ringQ.N, 1)
+		testContext.ringQ.SetCoefficientsBigintLvl(level, coeffs, polyQ)
+
+		scaler.DivByQOverTRounded(polyQ, polyT)
+
+		for i := 0; i < testContext.ringQ.N; i++ {
+			require.Equal(t, polyT.Coeffs[0][i], coeffsWant[i].Uint64())
+		}
+	})
 }
 
 func testMultByMonomial(testContext *testParams, t *testing.T) {
@@ -764,3 +879,39 @@ func testMultByMonomial(testContext *testParams, t *testing.T) {
 		require.Equal(t, p3Want.Coeffs[0][:testContext.ringQ.N], p3Test.Coeffs[0][:testContext.ringQ.N])
 	})
 }
+
+func testSubRing(testContext *testParams, t *testing.T) {
+
+	t.Run(testString("SubRing/", testContext.ringQ), func(t *testing.T) {
+
+		ringQ := testContext.ringQ
+
+		if len(ringQ.Modulus) < 2 {
+			t.Skip("#Qi < 2")
+		}
+
+		start, end := 1, len(ringQ.Modulus)
+		subRing := ringQ.SubRing(start, end)
+
+		require.Equal(t, ringQ.Modulus[start:end], subRing.Modulus)
+
+		p0 := testContext.uniformSamplerQ.ReadNew()
+		p1 := testContext.uniformSamplerQ.ReadNew()
+
+		want := ringQ.NewPoly()
+		ringQ.NTT(p0, want)
+		ringQ.MForm(want, want)
+		ringQ.MulCoeffsMontgomery(want, p1, want)
+		ringQ.InvNTT(want, want)
+
+		have := &Poly{Coeffs: ringQ.NewPoly().Coeffs[start:end]}
+		p0Sub := &Poly{Coeffs: p0.Coeffs[start:end]}
+		p1Sub := &Poly{Coeffs: p1.Coeffs[start:end]}
+		subRing.NTT(p0Sub, have)
+		subRing.MForm(have, have)
+		subRing.MulCoeffsMontgomery(have, p1Sub, have)
+		subRing.InvNTT(have, have)
+
+		require.Equal(t, want.Coeffs[start:end], have.Coeffs)
+	})
+}
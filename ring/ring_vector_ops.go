@@ -530,6 +530,24 @@ func MulScalarMontgomeryAndAddVec(p1, p2 []uint64, scalarMont, qi, mredParams ui
 	}
 }
 
+// MulScalarMontgomeryAndAddNoModVec returns p2 = p2 + p1*scalarMont without modular reduction.
+func MulScalarMontgomeryAndAddNoModVec(p1, p2 []uint64, scalarMont, qi, mredParams uint64) {
+	for j := 0; j < len(p1); j = j + 8 {
+
+		x := (*[8]uint64)(unsafe.Pointer(&p1[j]))
+		z := (*[8]uint64)(unsafe.Pointer(&p2[j]))
+
+		z[0] += MRed(x[0], scalarMont, qi, mredParams)
+		z[1] += MRed(x[1], scalarMont, qi, mredParams)
+		z[2] += MRed(x[2], scalarMont, qi, mredParams)
+		z[3] += MRed(x[3], scalarMont, qi, mredParams)
+		z[4] += MRed(x[4], scalarMont, qi, mredParams)
+		z[5] += MRed(x[5], scalarMont, qi, mredParams)
+		z[6] += MRed(x[6], scalarMont, qi, mredParams)
+		z[7] += MRed(x[7], scalarMont, qi, mredParams)
+	}
+}
+
 // SubVecAndMulScalarMontgomeryTwoQiVec returns p3 = (p1 + twoqi - p2) * scalarMont mod qi.
 func SubVecAndMulScalarMontgomeryTwoQiVec(p1, p2, p3 []uint64, scalarMont, qi, mredParams uint64) {
 	twoqi := qi << 1
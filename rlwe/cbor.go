@@ -0,0 +1,494 @@
+package rlwe
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils/cbor"
+)
+
+// The CBOR serialization of the parameters, keys and ciphertexts is an alternative to their binary serialization for
+// the consumers that are not written in Go: the objects are encoded as self-describing CBOR maps, which carry their
+// type, e.g. "rlwe.Ciphertext", and the version of its schema (see the utils/cbor package). The polynomials are maps
+// with the keys "ntt", "mform" and "coeffs" (see ring.Poly.CBORValue), and the polynomials of QP are maps with the keys
+// "q" and, if P is not empty, "p".
+
+// CBORVersion is the version of the schemas of the CBOR serialization of the objects of the package.
+const CBORVersion = 1
+
+// CBORValue returns the value encoding the polynomial in the CBOR serialization: a map with the polynomials "q" and,
+// if not nil, "p".
+func (p *PolyQP) CBORValue() (interface{}, error) {
+	m := map[string]interface{}{"q": p.Q}
+	if p.P != nil {
+		m["p"] = p.P
+	}
+	return m, nil
+}
+
+// SetCBORValue sets the polynomial to the decoded CBOR value v generated by CBORValue.
+func (p *PolyQP) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsObject(v)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	var q, pp interface{}
+	if q, err = o.Value("q"); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	p.Q = new(ring.Poly)
+	if err = p.Q.SetCBORValue(q); err != nil {
+		return err
+	}
+
+	p.P = nil
+	if pp, err = o.Value("p"); err == nil {
+		p.P = new(ring.Poly)
+		if err = p.P.SetCBORValue(pp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CBORValue returns the value encoding the parameters in the CBOR serialization: an object of type "rlwe.Parameters"
+// with the keys "logN", "q", "p", "sigma" and "ringType".
+func (p Parameters) CBORValue() (interface{}, error) {
+	m := cbor.NewObject("rlwe.Parameters", CBORVersion)
+	m["logN"] = uint64(p.logN)
+	m["q"] = append([]uint64{}, p.qi...)
+	m["p"] = append([]uint64{}, p.pi...)
+	m["sigma"] = p.sigma
+	m["ringType"] = p.ringType.String()
+	return m, nil
+}
+
+// SetCBORValue sets the parameters to the decoded CBOR value v generated by CBORValue.
+func (p *Parameters) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "rlwe.Parameters", CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	var logN int
+	var q, pi []uint64
+	var sigma float64
+	var ringType string
+
+	if logN, err = o.Int("logN"); err == nil {
+		if q, err = o.Uint64s("q"); err == nil {
+			if pi, err = o.Uint64s("p"); err == nil {
+				if sigma, err = o.Float("sigma"); err == nil {
+					ringType, err = o.String("ringType")
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	var rt ring.Type
+	switch ringType {
+	case "Standard":
+		rt = ring.Standard
+	case "ConjugateInvariant":
+		rt = ring.ConjugateInvariant
+	default:
+		return fmt.Errorf("cannot SetCBORValue: invalid ring type: %s", ringType)
+	}
+
+	if *p, err = NewParameters(logN, q, pi, sigma, rt); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the parameters in the CBOR serialization.
+func (p Parameters) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(p)
+}
+
+// UnmarshalCBOR decodes the parameters encoded by MarshalCBOR.
+func (p *Parameters) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return p.SetCBORValue(v)
+}
+
+// polyQPArray returns the CBOR value of a slice of pairs of polynomials of QP.
+func polyQPArray(value [][2]PolyQP) []interface{} {
+	array := make([]interface{}, len(value))
+	for i := range value {
+		array[i] = []interface{}{&value[i][0], &value[i][1]}
+	}
+	return array
+}
+
+// setPolyQPArray decodes the CBOR value of a slice of pairs of polynomials of QP.
+func setPolyQPArray(array []interface{}) (value [][2]PolyQP, err error) {
+	value = make([][2]PolyQP, len(array))
+	for i := range array {
+		var pair []interface{}
+		if pair, err = cbor.AsArray(array[i]); err != nil {
+			return nil, err
+		}
+		if len(pair) != 2 {
+			return nil, errors.New("expected a pair of polynomials")
+		}
+		for j := range pair {
+			if err = value[i][j].SetCBORValue(pair[j]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return
+}
+
+// CBORValue returns the value encoding the secret key in the CBOR serialization: an object of type "rlwe.SecretKey"
+// with the polynomial "value".
+func (sk *SecretKey) CBORValue() (interface{}, error) {
+	m := cbor.NewObject("rlwe.SecretKey", CBORVersion)
+	m["value"] = &sk.Value
+	return m, nil
+}
+
+// SetCBORValue sets the secret key to the decoded CBOR value v generated by CBORValue.
+func (sk *SecretKey) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "rlwe.SecretKey", CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	if v, err = o.Value("value"); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	return sk.Value.SetCBORValue(v)
+}
+
+// MarshalCBOR encodes the secret key in the CBOR serialization.
+func (sk *SecretKey) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(sk)
+}
+
+// UnmarshalCBOR decodes the secret key encoded by MarshalCBOR.
+func (sk *SecretKey) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return sk.SetCBORValue(v)
+}
+
+// CBORValue returns the value encoding the public key in the CBOR serialization: an object of type "rlwe.PublicKey"
+// with the pair of polynomials "value".
+func (pk *PublicKey) CBORValue() (interface{}, error) {
+	m := cbor.NewObject("rlwe.PublicKey", CBORVersion)
+	m["value"] = []interface{}{&pk.Value[0], &pk.Value[1]}
+	return m, nil
+}
+
+// SetCBORValue sets the public key to the decoded CBOR value v generated by CBORValue.
+func (pk *PublicKey) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "rlwe.PublicKey", CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	value, err := o.Array("value")
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	values, err := setPolyQPArray([]interface{}{value})
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	pk.Value = values[0]
+
+	return nil
+}
+
+// MarshalCBOR encodes the public key in the CBOR serialization.
+func (pk *PublicKey) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(pk)
+}
+
+// UnmarshalCBOR decodes the public key encoded by MarshalCBOR.
+func (pk *PublicKey) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return pk.SetCBORValue(v)
+}
+
+// CBORValue returns the value encoding the switching key in the CBOR serialization: an object of type
+// "rlwe.SwitchingKey" with the array of the pairs of polynomials "value" of the elements of the decomposition basis.
+func (swk *SwitchingKey) CBORValue() (interface{}, error) {
+	m := cbor.NewObject("rlwe.SwitchingKey", CBORVersion)
+	m["value"] = polyQPArray(swk.Value)
+	return m, nil
+}
+
+// SetCBORValue sets the switching key to the decoded CBOR value v generated by CBORValue.
+func (swk *SwitchingKey) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "rlwe.SwitchingKey", CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	value, err := o.Array("value")
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	if swk.Value, err = setPolyQPArray(value); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the switching key in the CBOR serialization.
+func (swk *SwitchingKey) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(swk)
+}
+
+// UnmarshalCBOR decodes the switching key encoded by MarshalCBOR.
+func (swk *SwitchingKey) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return swk.SetCBORValue(v)
+}
+
+// CBORValue returns the value encoding the relinearization key in the CBOR serialization: an object of type
+// "rlwe.RelinearizationKey" with the array of switching keys "keys".
+func (rlk *RelinearizationKey) CBORValue() (interface{}, error) {
+	keys := make([]interface{}, len(rlk.Keys))
+	for i := range keys {
+		keys[i] = rlk.Keys[i]
+	}
+	m := cbor.NewObject("rlwe.RelinearizationKey", CBORVersion)
+	m["keys"] = keys
+	return m, nil
+}
+
+// SetCBORValue sets the relinearization key to the decoded CBOR value v generated by CBORValue.
+func (rlk *RelinearizationKey) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "rlwe.RelinearizationKey", CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	keys, err := o.Array("keys")
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	rlk.Keys = make([]*SwitchingKey, len(keys))
+	for i := range keys {
+		rlk.Keys[i] = new(SwitchingKey)
+		if err = rlk.Keys[i].SetCBORValue(keys[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the relinearization key in the CBOR serialization.
+func (rlk *RelinearizationKey) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(rlk)
+}
+
+// UnmarshalCBOR decodes the relinearization key encoded by MarshalCBOR.
+func (rlk *RelinearizationKey) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return rlk.SetCBORValue(v)
+}
+
+// CBORValue returns the value encoding the rotation keys in the CBOR serialization: an object of type
+// "rlwe.RotationKeySet" with the array "keys" of the maps with the Galois element "galEl" and the switching key "key",
+// sorted by Galois element.
+func (rtks *RotationKeySet) CBORValue() (interface{}, error) {
+
+	galEls := make([]uint64, 0, len(rtks.Keys))
+	for galEl := range rtks.Keys {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	keys := make([]interface{}, len(galEls))
+	for i, galEl := range galEls {
+		keys[i] = map[string]interface{}{"galEl": galEl, "key": rtks.Keys[galEl]}
+	}
+
+	m := cbor.NewObject("rlwe.RotationKeySet", CBORVersion)
+	m["keys"] = keys
+	return m, nil
+}
+
+// SetCBORValue sets the rotation keys to the decoded CBOR value v generated by CBORValue.
+func (rtks *RotationKeySet) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "rlwe.RotationKeySet", CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	keys, err := o.Array("keys")
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	rtks.Keys = make(map[uint64]*SwitchingKey, len(keys))
+	for i := range keys {
+
+		var entry cbor.Object
+		if entry, err = cbor.AsObject(keys[i]); err != nil {
+			return fmt.Errorf("cannot SetCBORValue: %w", err)
+		}
+
+		var galEl uint64
+		var key interface{}
+		if galEl, err = entry.Uint("galEl"); err == nil {
+			key, err = entry.Value("key")
+		}
+		if err != nil {
+			return fmt.Errorf("cannot SetCBORValue: %w", err)
+		}
+
+		swk := new(SwitchingKey)
+		if err = swk.SetCBORValue(key); err != nil {
+			return err
+		}
+		rtks.Keys[galEl] = swk
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the rotation keys in the CBOR serialization.
+func (rtks *RotationKeySet) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(rtks)
+}
+
+// UnmarshalCBOR decodes the rotation keys encoded by MarshalCBOR.
+func (rtks *RotationKeySet) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return rtks.SetCBORValue(v)
+}
+
+// CBORValue returns the value encoding the ciphertext in the CBOR serialization: an object of type "rlwe.Ciphertext"
+// with the array of polynomials "value".
+func (ciphertext *Ciphertext) CBORValue() (interface{}, error) {
+	value := make([]interface{}, len(ciphertext.Value))
+	for i := range value {
+		value[i] = ciphertext.Value[i]
+	}
+	m := cbor.NewObject("rlwe.Ciphertext", CBORVersion)
+	m["value"] = value
+	return m, nil
+}
+
+// SetCBORValue sets the ciphertext to the decoded CBOR value v generated by CBORValue.
+func (ciphertext *Ciphertext) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "rlwe.Ciphertext", CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	value, err := o.Array("value")
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	ciphertext.Value = make([]*ring.Poly, len(value))
+	for i := range value {
+		ciphertext.Value[i] = new(ring.Poly)
+		if err = ciphertext.Value[i].SetCBORValue(value[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalCBOR encodes the ciphertext in the CBOR serialization.
+func (ciphertext *Ciphertext) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(ciphertext)
+}
+
+// UnmarshalCBOR decodes the ciphertext encoded by MarshalCBOR.
+func (ciphertext *Ciphertext) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return ciphertext.SetCBORValue(v)
+}
+
+// CBORValue returns the value encoding the plaintext in the CBOR serialization: an object of type "rlwe.Plaintext"
+// with the polynomial "value".
+func (pt *Plaintext) CBORValue() (interface{}, error) {
+	m := cbor.NewObject("rlwe.Plaintext", CBORVersion)
+	m["value"] = pt.Value
+	return m, nil
+}
+
+// SetCBORValue sets the plaintext to the decoded CBOR value v generated by CBORValue.
+func (pt *Plaintext) SetCBORValue(v interface{}) (err error) {
+
+	o, err := cbor.AsTypedObject(v, "rlwe.Plaintext", CBORVersion)
+	if err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	if v, err = o.Value("value"); err != nil {
+		return fmt.Errorf("cannot SetCBORValue: %w", err)
+	}
+
+	pt.Value = new(ring.Poly)
+	return pt.Value.SetCBORValue(v)
+}
+
+// MarshalCBOR encodes the plaintext in the CBOR serialization.
+func (pt *Plaintext) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(pt)
+}
+
+// UnmarshalCBOR decodes the plaintext encoded by MarshalCBOR.
+func (pt *Plaintext) UnmarshalCBOR(data []byte) error {
+	v, err := cbor.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return pt.SetCBORValue(v)
+}
@@ -0,0 +1,126 @@
+package rlwe
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Compression is the compression of the encoding of a marshaled object, which is recorded in its FormatHeader so that
+// the decoders (UnmarshalBinary) decompress it transparently. The objects are marshaled without compression, and
+// Compress compresses a marshaled object, e.g. before it is stored or sent over a network.
+//
+// The coefficients of the keys and ciphertexts are uniform modulo primes smaller than 2^64, hence only their unused
+// most significant bits and the structured parts of the objects, e.g. the zero polynomials of a fresh ciphertext, are
+// compressed.
+type Compression uint8
+
+const (
+	// CompressionNone is the encoding without compression.
+	CompressionNone Compression = iota
+	// CompressionDeflate is the DEFLATE compression (RFC 1951) of the compress/flate package.
+	CompressionDeflate
+)
+
+// String returns the name of the compression.
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "None"
+	case CompressionDeflate:
+		return "Deflate"
+	default:
+		return fmt.Sprintf("Compression(%d)", uint8(c))
+	}
+}
+
+// MaxDecompressedLen is the largest size in bytes of the decompressed encoding of a compressed object accepted by the
+// decoders, which return an error for larger objects, so that a small compressed object received from an untrusted
+// party cannot exhaust the memory. The default is four times the size of a ciphertext of degree 1 at the maximum level
+// of the largest parameters, i.e. with a ring degree 2^MaxLogN and MaxModuliCount moduli. It can be lowered by the
+// services to the size of their largest objects, and must be raised to decompress larger objects, e.g. switching keys.
+var MaxDecompressedLen int64 = 4 * 2 * (1 << MaxLogN) * MaxModuliCount * 8
+
+// Compress compresses the encoding of a marshaled object, i.e. the output of a MarshalBinary method, with the given
+// compression, and returns the marshaled object with the header of the current version recording the compression.
+// The decoders of the object accept the compressed data. The encoding is left uncompressed, with CompressionNone, if
+// the compression does not shrink it.
+func Compress(data []byte, compression Compression) (compressed []byte, err error) {
+
+	var h FormatHeader
+	var body []byte
+	if h, body, err = DecodeFormat(data); err != nil {
+		return nil, fmt.Errorf("cannot Compress: %w", err)
+	}
+
+	if h.Version == 0 {
+		return nil, errors.New("cannot Compress: data has no format header")
+	}
+
+	h.Version = FormatVersion
+	h.Compression = compression
+
+	buf := bytes.NewBuffer(make([]byte, FormatHeaderLen, FormatHeaderLen+len(body)))
+	if _, err = h.WriteTo(buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	switch compression {
+	case CompressionNone:
+		buf.Write(body)
+	case CompressionDeflate:
+		var w *flate.Writer
+		if w, err = flate.NewWriter(buf, flate.DefaultCompression); err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(body); err != nil {
+			return nil, err
+		}
+		if err = w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("cannot Compress: %w: compression %d", ErrUnsupportedFormat, compression)
+	}
+
+	if compression != CompressionNone && buf.Len() >= FormatHeaderLen+len(body) {
+		return Compress(data, CompressionNone)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress returns the uncompressed encoding of a marshaled object, compressed or not.
+func Decompress(data []byte) ([]byte, error) {
+	return Compress(data, CompressionNone)
+}
+
+// DecodeFormat decodes the header of a marshaled object and returns it with the encoding of the object that follows
+// the header, decompressed. The data of the version 0, which has no header, returns a zero header and the data.
+func DecodeFormat(data []byte) (h FormatHeader, body []byte, err error) {
+
+	var ptr int
+	if h, ptr, err = DecodeFormatHeader(data); err != nil {
+		return
+	}
+
+	body = data[ptr:]
+
+	if h.Compression == CompressionDeflate {
+		r := flate.NewReader(bytes.NewReader(body))
+		if body, err = ioutil.ReadAll(io.LimitReader(r, MaxDecompressedLen+1)); err != nil {
+			return FormatHeader{}, nil, fmt.Errorf("cannot DecodeFormat: %w", err)
+		}
+		if int64(len(body)) > MaxDecompressedLen {
+			return FormatHeader{}, nil, fmt.Errorf("cannot DecodeFormat: decompressed encoding is larger than %d bytes", MaxDecompressedLen)
+		}
+		if err = r.Close(); err != nil {
+			return FormatHeader{}, nil, fmt.Errorf("cannot DecodeFormat: %w", err)
+		}
+	}
+
+	return
+}
@@ -33,11 +33,22 @@ type skEncryptor struct {
 // NewEncryptor creates a new Encryptor
 // Accepts either a secret-key or a public-key.
 func NewEncryptor(params Parameters, key interface{}) Encryptor {
-	enc := newEncryptor(params)
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return NewEncryptorWithPRNG(params, key, prng)
+}
+
+// NewEncryptorWithPRNG creates a new Encryptor which samples the randomness of the encryptions from the given PRNG.
+// Accepts either a secret-key or a public-key. With a utils.KeyedPRNG, the ciphertexts are a deterministic function
+// of its key, which must then be kept secret. The ShallowCopy of the Encryptor samples from a new random PRNG.
+func NewEncryptorWithPRNG(params Parameters, key interface{}, prng utils.PRNG) Encryptor {
+	enc := newEncryptor(params, prng)
 	return enc.setKey(key)
 }
 
-func newEncryptor(params Parameters) encryptor {
+func newEncryptor(params Parameters, prng utils.PRNG) encryptor {
 
 	var bc *ring.BasisExtender
 	if params.PCount() != 0 {
@@ -46,7 +57,7 @@ func newEncryptor(params Parameters) encryptor {
 
 	return encryptor{
 		encryptorBase:     newEncryptorBase(params),
-		encryptorSamplers: newEncryptorSamplers(params),
+		encryptorSamplers: newEncryptorSamplers(params, prng),
 		encryptorBuffers:  newEncryptorBuffers(params),
 		basisextender:     bc,
 	}
@@ -67,11 +78,7 @@ type encryptorSamplers struct {
 	uniformSampler  *ring.UniformSampler
 }
 
-func newEncryptorSamplers(params Parameters) *encryptorSamplers {
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
+func newEncryptorSamplers(params Parameters, prng utils.PRNG) *encryptorSamplers {
 	ringQ := params.RingQ()
 	return &encryptorSamplers{
 		gaussianSampler: ring.NewGaussianSampler(prng, ringQ, params.Sigma(), int(6*params.Sigma())),
@@ -162,9 +169,14 @@ func (enc *encryptor) ShallowCopy() *encryptor {
 		bc = enc.basisextender.ShallowCopy()
 	}
 
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
 	return &encryptor{
 		encryptorBase:     enc.encryptorBase,
-		encryptorSamplers: newEncryptorSamplers(enc.params),
+		encryptorSamplers: newEncryptorSamplers(enc.params, prng),
 		encryptorBuffers:  newEncryptorBuffers(enc.params),
 		basisextender:     bc,
 	}
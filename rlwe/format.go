@@ -0,0 +1,179 @@
+package rlwe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// The binary serialization (MarshalBinary) of the parameters, keys and ciphertexts of the rlwe, bfv and ckks packages
+// starts with a FormatHeader: the magic bytes FormatMagic, the version of the format, the Compression of the encoding
+// of the object that follows the header and the digest of the parameters of the object. The decoders (UnmarshalBinary)
+// support the current version and the previous ones, so that the stored objects can still be read after an upgrade of
+// the library, and return an error wrapping ErrUnsupportedFormat for the versions they do not know instead of
+// misreading the data.
+//
+// The version 1 has no Compression field. The version 0 is the format of the previous releases, which has no header,
+// hence the data that does not start with FormatMagic is decoded as before. The objects of the rlwe and bfv packages
+// of the version 0 start with a small count (the degree of a ciphertext, the log2 of the ring degree of the
+// parameters, ...) that cannot be the first byte of FormatMagic. The ciphertexts of the ckks package start with their
+// float64 scale instead, whose first four bytes can match FormatMagic: their decoder falls back to the version 0 if
+// the data cannot be decoded with a header.
+//
+// The parameters carry their own digest. The keys and the ciphertexts do not know their parameters and are marshaled
+// with a zero digest, which can be set with StampParameters and checked with CheckParameters by the applications that
+// store objects generated with several parameter sets.
+
+// FormatVersion is the current version of the binary serialization.
+const FormatVersion uint8 = 2
+
+// FormatMagic are the magic bytes that start the binary serialization of the objects.
+var FormatMagic = [4]byte{'L', 'T', 'G', 'O'}
+
+// ParametersDigestLen is the size in bytes of a ParametersDigest.
+const ParametersDigestLen = 8
+
+// FormatHeaderLen is the size in bytes of a marshaled FormatHeader of the current version.
+const FormatHeaderLen = len(FormatMagic) + 2 + ParametersDigestLen
+
+// formatHeaderLenV1 is the size in bytes of a marshaled FormatHeader of the version 1, without Compression.
+const formatHeaderLenV1 = len(FormatMagic) + 1 + ParametersDigestLen
+
+// ErrUnsupportedFormat is the error returned when decoding an object of an unknown format version or compression, or
+// whose digest of the parameters does not match the expected parameters.
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// ParametersDigest is a short digest of the parameters of an object, the zero digest standing for unknown parameters.
+type ParametersDigest [ParametersDigestLen]byte
+
+// IsZero returns true if the digest is the zero digest.
+func (d ParametersDigest) IsZero() bool {
+	return d == ParametersDigest{}
+}
+
+// Digest returns the digest of the parameters, which identifies them in the FormatHeader of the marshaled objects.
+func (p Parameters) Digest() (d ParametersDigest) {
+	h := blake2b.Sum256(p.marshalBody())
+	copy(d[:], h[:])
+	return
+}
+
+// FormatHeader is the header of the binary serialization of the objects.
+type FormatHeader struct {
+	Version     uint8
+	Compression Compression
+	Params      ParametersDigest
+}
+
+// WriteTo writes the header on data in the layout of the current version and returns the number of bytes written.
+func (h FormatHeader) WriteTo(data []byte) (ptr int, err error) {
+
+	if len(data) < FormatHeaderLen {
+		return 0, errors.New("cannot WriteTo: buffer is too small")
+	}
+
+	ptr = copy(data, FormatMagic[:])
+	data[ptr] = h.Version
+	data[ptr+1] = uint8(h.Compression)
+	ptr += 2
+	ptr += copy(data[ptr:], h.Params[:])
+
+	return ptr, nil
+}
+
+// DecodeFormatHeader decodes the header of a marshaled object and returns it with the number of bytes read, i.e. the
+// offset of the body of the object. The data of the version 0, which has no header, returns a zero header and offset.
+// It returns an error wrapping ErrUnsupportedFormat if the version or the compression of the header is not supported.
+func DecodeFormatHeader(data []byte) (h FormatHeader, ptr int, err error) {
+
+	if !bytes.HasPrefix(data, FormatMagic[:]) {
+		return FormatHeader{}, 0, nil
+	}
+
+	if len(data) < formatHeaderLenV1 {
+		return FormatHeader{}, 0, errors.New("cannot DecodeFormatHeader: data is too short")
+	}
+
+	ptr = len(FormatMagic)
+	h.Version = data[ptr]
+	ptr++
+
+	switch h.Version {
+	case 1:
+	case 2:
+		if len(data) < FormatHeaderLen {
+			return FormatHeader{}, 0, errors.New("cannot DecodeFormatHeader: data is too short")
+		}
+		h.Compression = Compression(data[ptr])
+		ptr++
+	default:
+		return FormatHeader{}, 0, fmt.Errorf("cannot DecodeFormatHeader: %w: version %d (current is %d)", ErrUnsupportedFormat, h.Version, FormatVersion)
+	}
+
+	ptr += copy(h.Params[:], data[ptr:])
+
+	if h.Compression > CompressionDeflate {
+		return FormatHeader{}, 0, fmt.Errorf("cannot DecodeFormatHeader: %w: compression %d", ErrUnsupportedFormat, h.Compression)
+	}
+
+	return h, ptr, nil
+}
+
+// newFormatHeader returns the header of the current version for the given digest.
+func newFormatHeader(params ParametersDigest) FormatHeader {
+	return FormatHeader{Version: FormatVersion, Params: params}
+}
+
+// StampParameters sets the digest of the parameters in the FormatHeader of a marshaled object, e.g. a ciphertext, so
+// that CheckParameters can later check that it is decoded with the parameters it was generated with.
+func StampParameters(data []byte, params Parameters) (err error) {
+
+	var h FormatHeader
+	var ptr int
+	if h, ptr, err = DecodeFormatHeader(data); err != nil {
+		return fmt.Errorf("cannot StampParameters: %w", err)
+	}
+
+	if ptr == 0 {
+		return errors.New("cannot StampParameters: data has no format header")
+	}
+
+	// The digest is the last field of the header of all the versions
+	h.Params = params.Digest()
+	copy(data[ptr-ParametersDigestLen:], h.Params[:])
+	return
+}
+
+// CheckParameters checks that a marshaled object was generated with the given parameters. It returns an error wrapping
+// ErrUnsupportedFormat if the digest of its FormatHeader is not zero and differs from the digest of the parameters.
+// The objects of the version 0 and the objects with a zero digest are accepted with any parameters.
+func CheckParameters(data []byte, params Parameters) (err error) {
+
+	var h FormatHeader
+	if h, _, err = DecodeFormatHeader(data); err != nil {
+		return fmt.Errorf("cannot CheckParameters: %w", err)
+	}
+
+	if d := params.Digest(); !h.Params.IsZero() && h.Params != d {
+		return fmt.Errorf("cannot CheckParameters: %w: object generated with parameters %x instead of %x", ErrUnsupportedFormat, h.Params, d)
+	}
+
+	return nil
+}
+
+// newFormatBuffer returns a buffer for the binary serialization of an object whose encoding without header has
+// dataLen bytes, which starts with the header of the current version and a zero digest, and the slice of the buffer
+// on which the object is encoded.
+func newFormatBuffer(dataLen int) (data, body []byte) {
+	data = make([]byte, FormatHeaderLen+dataLen)
+	newFormatHeader(ParametersDigest{}).WriteTo(data)
+	return data, data[FormatHeaderLen:]
+}
+
+// formatBody returns the encoding of a marshaled object without its header, if any, decompressed.
+func formatBody(data []byte) (body []byte, err error) {
+	_, body, err = DecodeFormat(data)
+	return
+}
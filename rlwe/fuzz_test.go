@@ -0,0 +1,111 @@
+//go:build go1.18
+// +build go1.18
+
+package rlwe
+
+import (
+	"encoding"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// The fuzz targets check that the decoders of the objects received from untrusted parties return an error instead of
+// panicking or allocating more than their input on invalid encodings, e.g. with
+//
+//	go test ./rlwe -run '^$' -fuzz FuzzCiphertextUnmarshalBinary
+
+// newFuzzParameters returns small parameters, so that the seeds of the fuzz targets are short.
+func newFuzzParameters(t testing.TB) Parameters {
+	logN := 5
+	q := ring.GenerateNTTPrimes(30, 2<<logN, 2)
+	p := ring.GenerateNTTPrimes(31, 2<<logN, 1)
+	params, err := NewParameters(logN, q, p, DefaultSigma, ring.Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return params
+}
+
+// fuzzUnmarshalBinary fuzzes the UnmarshalBinary method of the objects returned by newObject, seeded with the
+// serializations of seeds in the current format, compressed, and in the format of the version 0. A successfully
+// decoded object must be marshaled again without error.
+func fuzzUnmarshalBinary(f *testing.F, newObject func() encoding.BinaryUnmarshaler, seeds ...encoding.BinaryMarshaler) {
+
+	for _, seed := range seeds {
+		data, err := seed.MarshalBinary()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+		f.Add(data[FormatHeaderLen:])
+		if compressed, err := Compress(data, CompressionDeflate); err == nil {
+			f.Add(compressed)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		object := newObject()
+		if object.UnmarshalBinary(data) != nil {
+			return
+		}
+		if _, err := object.(encoding.BinaryMarshaler).MarshalBinary(); err != nil {
+			t.Fatalf("cannot marshal a decoded object: %s", err)
+		}
+	})
+}
+
+func FuzzParametersUnmarshalBinary(f *testing.F) {
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(Parameters) }, newFuzzParameters(f))
+}
+
+func FuzzParametersUnmarshalJSON(f *testing.F) {
+	data, err := newFuzzParameters(f).MarshalJSON()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		new(Parameters).UnmarshalJSON(data)
+	})
+}
+
+func FuzzCiphertextUnmarshalBinary(f *testing.F) {
+	params := newFuzzParameters(f)
+	sk := NewKeyGenerator(params).GenSecretKey()
+	ct := NewCiphertextNTT(params, 1, params.MaxLevel())
+	NewEncryptor(params, sk).Encrypt(NewPlaintext(params, params.MaxLevel()), ct)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(Ciphertext) }, ct)
+}
+
+func FuzzSecretKeyUnmarshalBinary(f *testing.F) {
+	params := newFuzzParameters(f)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(SecretKey) }, NewKeyGenerator(params).GenSecretKey())
+}
+
+func FuzzPublicKeyUnmarshalBinary(f *testing.F) {
+	params := newFuzzParameters(f)
+	_, pk := NewKeyGenerator(params).GenKeyPair()
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(PublicKey) }, pk)
+}
+
+func FuzzSwitchingKeyUnmarshalBinary(f *testing.F) {
+	params := newFuzzParameters(f)
+	kgen := NewKeyGenerator(params)
+	swk := kgen.GenSwitchingKey(kgen.GenSecretKey(), kgen.GenSecretKey())
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(SwitchingKey) }, swk)
+}
+
+func FuzzRelinearizationKeyUnmarshalBinary(f *testing.F) {
+	params := newFuzzParameters(f)
+	kgen := NewKeyGenerator(params)
+	rlk := kgen.GenRelinearizationKey(kgen.GenSecretKey(), 2)
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(RelinearizationKey) }, rlk)
+}
+
+func FuzzRotationKeySetUnmarshalBinary(f *testing.F) {
+	params := newFuzzParameters(f)
+	kgen := NewKeyGenerator(params)
+	rtks := kgen.GenRotationKeysForRotations([]int{1}, false, kgen.GenSecretKey())
+	fuzzUnmarshalBinary(f, func() encoding.BinaryUnmarshaler { return new(RotationKeySet) }, rtks)
+}
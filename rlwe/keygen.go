@@ -26,6 +26,7 @@ type KeyGenerator interface {
 	GenSwitchingKeyForRowRotation(sk *SecretKey) (swk *SwitchingKey)
 	GenRotationKeysForInnerSum(sk *SecretKey) (rks *RotationKeySet)
 	GenSwitchingKeysForRingSwap(skCKKS, skCI *SecretKey) (swkStdToConjugateInvariant, swkConjugateInvariantToStd *SwitchingKey)
+	GenBlindRotationKey(sk *SecretKey) (brk *BlindRotationKey)
 }
 
 // KeyGenerator is a structure that stores the elements required to create new keys,
@@ -37,6 +38,7 @@ type keyGenerator struct {
 	gaussianSamplerQ *ring.GaussianSampler
 	uniformSamplerQ  *ring.UniformSampler
 	uniformSamplerP  *ring.UniformSampler
+	prng             utils.PRNG
 }
 
 // NewKeyGenerator creates a new KeyGenerator, from which the secret and public keys, as well as the evaluation,
@@ -48,6 +50,13 @@ func NewKeyGenerator(params Parameters) KeyGenerator {
 		panic(err)
 	}
 
+	return NewKeyGeneratorWithPRNG(params, prng)
+}
+
+// NewKeyGeneratorWithPRNG creates a new KeyGenerator which samples the keys from the given PRNG. With a
+// utils.KeyedPRNG, the keys are a deterministic function of its key, which must then be kept secret.
+func NewKeyGeneratorWithPRNG(params Parameters, prng utils.PRNG) KeyGenerator {
+
 	var poolQP PolyQP
 	var uniformSamplerP *ring.UniformSampler
 	if params.PCount() > 0 {
@@ -62,6 +71,7 @@ func NewKeyGenerator(params Parameters) KeyGenerator {
 		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
 		uniformSamplerQ:  ring.NewUniformSampler(prng, params.RingQ()),
 		uniformSamplerP:  uniformSamplerP,
+		prng:             prng,
 	}
 }
 
@@ -77,21 +87,13 @@ func (keygen *keyGenerator) GenSecretKeyGaussian() (sk *SecretKey) {
 
 // GenSecretKeyWithDistrib generates a new SecretKey with the distribution [(p-1)/2, p, (p-1)/2].
 func (keygen *keyGenerator) GenSecretKeyWithDistrib(p float64) (sk *SecretKey) {
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
-	ternarySamplerMontgomery := ring.NewTernarySampler(prng, keygen.params.RingQ(), p, false)
+	ternarySamplerMontgomery := ring.NewTernarySampler(keygen.prng, keygen.params.RingQ(), p, false)
 	return keygen.genSecretKeyFromSampler(ternarySamplerMontgomery)
 }
 
 // GenSecretKeySparse generates a new SecretKey with exactly hw non-zero coefficients.
 func (keygen *keyGenerator) GenSecretKeySparse(hw int) (sk *SecretKey) {
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
-	ternarySamplerMontgomery := ring.NewTernarySamplerSparse(prng, keygen.params.RingQ(), hw, false)
+	ternarySamplerMontgomery := ring.NewTernarySamplerSparse(keygen.prng, keygen.params.RingQ(), hw, false)
 	return keygen.genSecretKeyFromSampler(ternarySamplerMontgomery)
 }
 
@@ -0,0 +1,372 @@
+package rlwe
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// RGSWCiphertext is an RGSW encryption of a polynomial m under a secret key s, stored as the gadget encryptions of m
+// and of m * s, i.e. the switching keys from m and from m * s to s.
+type RGSWCiphertext struct {
+	Value [2]*SwitchingKey
+}
+
+// BlindRotationKey is the key of the blind rotations of a LUTEvaluator. It stores, for each coefficient s_j of a ternary
+// secret key s, the RGSW encryptions under s of the positive part max(s_j, 0) and of the negative part max(-s_j, 0).
+type BlindRotationKey struct {
+	Value [][2]*RGSWCiphertext
+}
+
+// GenBlindRotationKey generates a new BlindRotationKey from the secret key sk, whose coefficients must be ternary.
+// The LWE ciphertexts encrypted under the coefficients of sk, e.g. returned by ExtractLWE, can then be bootstrapped
+// by a LUTEvaluator, which returns LWE ciphertexts encrypted under the same key (which assumes the circular security
+// of the RGSW encryptions). The key has 4N switching keys. The method panics if sk is not ternary.
+func (keygen *keyGenerator) GenBlindRotationKey(sk *SecretKey) (brk *BlindRotationKey) {
+
+	ringQ := keygen.params.RingQ()
+	ringQP := keygen.params.RingQP()
+	levelQ, levelP := keygen.params.QCount()-1, keygen.params.PCount()-1
+
+	s := ringQ.NewPolyLvl(0)
+	ringQ.InvMFormLvl(0, sk.Value.Q, s)
+	ringQ.InvNTTLvl(0, s, s)
+
+	// zero and one are the constant polynomials 0 and 1 in the NTT and Montgomery domain
+	zero := &SecretKey{Value: ringQP.NewPoly()}
+	one := &SecretKey{Value: ringQP.NewPoly()}
+	for i := range one.Value.Q.Coeffs {
+		one.Value.Q.Coeffs[i][0] = 1
+	}
+	for i := range one.Value.P.Coeffs {
+		one.Value.P.Coeffs[i][0] = 1
+	}
+	ringQP.NTTLvl(levelQ, levelP, one.Value, one.Value)
+	ringQP.MFormLvl(levelQ, levelP, one.Value, one.Value)
+
+	// RGSW(1) encrypts (1, s) and RGSW(0) encrypts (0, 0)
+	rgsw := func(bit bool) *RGSWCiphertext {
+		if bit {
+			return &RGSWCiphertext{Value: [2]*SwitchingKey{keygen.GenSwitchingKey(one, sk), keygen.GenSwitchingKey(sk, sk)}}
+		}
+		return &RGSWCiphertext{Value: [2]*SwitchingKey{keygen.GenSwitchingKey(zero, sk), keygen.GenSwitchingKey(zero, sk)}}
+	}
+
+	q0 := ringQ.Modulus[0]
+
+	brk = &BlindRotationKey{Value: make([][2]*RGSWCiphertext, keygen.params.N())}
+	for j, sj := range s.Coeffs[0] {
+		if sj > 1 && sj != q0-1 {
+			panic("cannot GenBlindRotationKey: the secret key is not ternary")
+		}
+		brk.Value[j] = [2]*RGSWCiphertext{rgsw(sj == 1), rgsw(sj == q0-1)}
+	}
+
+	return
+}
+
+// LUT is a look-up table of the function F on the messages of LWE ciphertexts. The LWE ciphertexts bootstrapped by
+// LUTEvaluator.EvaluateLUT must have the phase x * ScaleIn + e with |x| < Bound, and the returned LWE ciphertexts have
+// the phase F(x) * ScaleOut + e'. F is arbitrary, e.g. a step function or a quantization, but it is evaluated on x up
+// to the resolution of the blind rotation, that is about 2 * Bound / N, plus the noise of the modulus switching.
+// For instance, the LWE ciphertexts extracted from the coefficients of a CKKS ciphertext have the scale of the
+// ciphertext, and the ones extracted from a BFV ciphertext have the scale Q/t, with Bound at most t/4.
+type LUT struct {
+	F        func(x float64) float64
+	Bound    float64
+	ScaleIn  float64
+	ScaleOut float64
+}
+
+// LUTEvaluator evaluates look-up tables on LWE ciphertexts with a programmable bootstrapping in the style of FHEW/TFHE:
+// the LWE ciphertext (b, a) of dimension N is switched to the modulus 2N, and a test polynomial storing the table is
+// blindly rotated by X^-(b + <a, s>) with the RGSW encryptions of the BlindRotationKey, so that the constant
+// coefficient of the result is the table at the phase. This costs N external products, i.e. 2N key-switchings.
+type LUTEvaluator struct {
+	params Parameters
+	*KeySwitcher
+	brk *BlindRotationKey
+
+	acc        *Ciphertext
+	monomial   *ring.Poly
+	poolDecomp []PolyQP
+	poolQP     [4]PolyQP
+	poolQ      [2]*ring.Poly
+}
+
+// NewLUTEvaluator creates a new LUTEvaluator from the blind rotation key brk.
+func NewLUTEvaluator(params Parameters, brk *BlindRotationKey) *LUTEvaluator {
+
+	ringQ := params.RingQ()
+	ringQP := params.RingQP()
+
+	eval := &LUTEvaluator{
+		params:      params,
+		KeySwitcher: NewKeySwitcher(params),
+		brk:         brk,
+		monomial:    ringQ.NewPoly(),
+		poolDecomp:  make([]PolyQP, params.Beta()),
+		poolQ:       [2]*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()},
+	}
+
+	for i := range eval.poolDecomp {
+		eval.poolDecomp[i] = ringQP.NewPoly()
+	}
+
+	for i := range eval.poolQP {
+		eval.poolQP[i] = ringQP.NewPoly()
+	}
+
+	return eval
+}
+
+// EvaluateLUT bootstraps the LWE ciphertexts lwe, of phases x * lut.ScaleIn + e, to new LWE ciphertexts at the level
+// levelOut, of phases lut.F(x) * lut.ScaleOut + e', encrypted under the secret key of the BlindRotationKey. The nil
+// LWE ciphertexts are returned as nil. The method returns an error if an LWE ciphertext is not of dimension N or if
+// lut.Bound * lut.ScaleIn is larger than Q_level/4 at its level.
+func (eval *LUTEvaluator) EvaluateLUT(lwe []*LWECiphertext, lut LUT, levelOut int) (res []*LWECiphertext, err error) {
+
+	ringQ := eval.params.RingQ()
+	N := eval.params.N()
+
+	eval.acc = NewCiphertextNTT(eval.params, 1, levelOut)
+
+	// The test polynomials depend on the level of the LWE ciphertexts
+	testPoly := make(map[int]*ring.Poly)
+	modSwitch := make(map[int]*modSwitcher)
+
+	res = make([]*LWECiphertext, len(lwe))
+
+	for k, ct := range lwe {
+
+		if ct == nil {
+			continue
+		}
+
+		if len(ct.A[0]) != N {
+			return nil, fmt.Errorf("cannot EvaluateLUT: LWE ciphertext of dimension %d instead of %d", len(ct.A[0]), N)
+		}
+
+		level := ct.Level()
+
+		if _, ok := modSwitch[level]; !ok {
+
+			if modSwitch[level], err = newModSwitcher(eval.params, level, lut); err != nil {
+				return nil, fmt.Errorf("cannot EvaluateLUT: %w", err)
+			}
+
+			testPoly[level] = ringQ.NewPolyLvl(levelOut)
+			eval.genTestPoly(lut, modSwitch[level].resolution, testPoly[level])
+		}
+
+		b, a := modSwitch[level].switchLWE(ct)
+
+		eval.blindRotate(testPoly[level], b, a)
+
+		res[k] = ExtractLWE(eval.params, eval.acc, []int{0})[0]
+	}
+
+	return
+}
+
+// genTestPoly sets testPoly to the test polynomial sum t_i X^i of the look-up table, with the resolution res, i.e.
+// such that the constant coefficient of testPoly * X^-phi is F(phi * res) * ScaleOut for phi in [-N/2, N/2).
+// Since X^N = -1, t_i = F(i * res) * ScaleOut for i < N/2 and t_i = -F((i-N) * res) * ScaleOut for i >= N/2.
+func (eval *LUTEvaluator) genTestPoly(lut LUT, res float64, testPoly *ring.Poly) {
+
+	N := eval.params.N()
+
+	coeffs := make([]*big.Int, N)
+	for i := range coeffs {
+
+		x := float64(i) * res
+		if i >= N>>1 {
+			x = float64(i-N) * res
+		}
+
+		coeffs[i], _ = new(big.Float).SetFloat64(math.Round(lut.F(x) * lut.ScaleOut)).Int(nil)
+
+		if i >= N>>1 {
+			coeffs[i].Neg(coeffs[i])
+		}
+	}
+
+	eval.params.RingQ().SetCoefficientsBigintLvl(testPoly.Level(), coeffs, testPoly)
+}
+
+// blindRotate sets the accumulator to testPoly * X^-(b + <a, s>), with b and a switched to the modulus 2N.
+func (eval *LUTEvaluator) blindRotate(testPoly *ring.Poly, b int, a []int) {
+
+	ringQ := eval.params.RingQ()
+	ringQP := eval.params.RingQP()
+	level := eval.acc.Level()
+	levelP := eval.params.PCount() - 1
+	alpha := levelP + 1
+	N := eval.params.N()
+
+	// acc = (testPoly * X^-b, 0)
+	c0, c1 := eval.acc.Value[0], eval.acc.Value[1]
+	for i, qi := range ringQ.Modulus[:level+1] {
+		t, c := testPoly.Coeffs[i], c0.Coeffs[i]
+		for j := 0; j < N; j++ {
+			if idx := j + b; idx < N {
+				c[j] = t[idx]
+			} else if idx < 2*N {
+				c[j] = ring.CRed(qi-t[idx-N], qi)
+			} else {
+				c[j] = t[idx-2*N]
+			}
+		}
+	}
+	ringQ.NTTLvl(level, c0, c0)
+	c1.Zero()
+
+	ext := [2]PolyQP{eval.poolQP[0], eval.poolQP[1]}
+	tmp := [2]PolyQP{eval.poolQP[2], eval.poolQP[3]}
+
+	// acc = acc + (X^-a_j - 1) * acc x RGSW(max(s_j, 0)) + (X^a_j - 1) * acc x RGSW(max(-s_j, 0)), i.e. acc * X^(-a_j * s_j)
+	for j, aj := range a {
+
+		if aj == 0 {
+			continue
+		}
+
+		eval.DecomposeNTT(level, levelP, alpha, c0, eval.poolDecomp)
+		eval.DecomposeNTT(level, levelP, alpha, c1, eval.PoolDecompQP)
+
+		for sign, rgsw := range eval.brk.Value[j] {
+
+			// acc x RGSW(m) = decomp(c0) * RGSW(m)[0] + decomp(c1) * RGSW(m)[1], of phase (c0 + c1 * s) * m
+			eval.KeyswitchHoistedNoModDown(level, eval.poolDecomp, rgsw.Value[0], ext[0].Q, ext[1].Q, ext[0].P, ext[1].P)
+			eval.KeyswitchHoistedNoModDown(level, eval.PoolDecompQP, rgsw.Value[1], tmp[0].Q, tmp[1].Q, tmp[0].P, tmp[1].P)
+
+			ringQP.AddLvl(level, levelP, ext[0], tmp[0], ext[0])
+			ringQP.AddLvl(level, levelP, ext[1], tmp[1], ext[1])
+
+			eval.BasisExtender.ModDownQPtoQNTT(level, levelP, ext[0].Q, ext[0].P, eval.poolQ[0])
+			eval.BasisExtender.ModDownQPtoQNTT(level, levelP, ext[1].Q, ext[1].P, eval.poolQ[1])
+
+			if sign == 0 {
+				eval.genMonomialMinusOne(level, 2*N-aj)
+			} else {
+				eval.genMonomialMinusOne(level, aj)
+			}
+
+			ringQ.MulCoeffsMontgomeryAndAddLvl(level, eval.poolQ[0], eval.monomial, c0)
+			ringQ.MulCoeffsMontgomeryAndAddLvl(level, eval.poolQ[1], eval.monomial, c1)
+		}
+	}
+}
+
+// genMonomialMinusOne sets eval.monomial to X^k - 1 in the NTT and Montgomery domain, for 0 < k < 2N.
+func (eval *LUTEvaluator) genMonomialMinusOne(level, k int) {
+
+	ringQ := eval.params.RingQ()
+	N := eval.params.N()
+
+	eval.monomial.Zero()
+
+	for i, qi := range ringQ.Modulus[:level+1] {
+		c := eval.monomial.Coeffs[i]
+		if k < N {
+			c[k] = 1
+			c[0] = qi - 1
+		} else if k == N {
+			c[0] = qi - 2
+		} else {
+			c[k-N] = qi - 1
+			c[0] = qi - 1
+		}
+	}
+
+	ringQ.NTTLvl(level, eval.monomial, eval.monomial)
+	ringQ.MFormLvl(level, eval.monomial, eval.monomial)
+}
+
+// modSwitcher switches LWE ciphertexts from the modulus Q_level to the modulus 2N, after a multiplication by the integer
+// K = floor(Q_level/(4 * Bound * ScaleIn)), which maps the messages x in (-Bound, Bound) to the phases in (-N/2, N/2).
+type modSwitcher struct {
+	N          int
+	Q          *big.Int
+	QHalf      *big.Int
+	crt        []*big.Int
+	resolution float64
+}
+
+func newModSwitcher(params Parameters, level int, lut LUT) (ms *modSwitcher, err error) {
+
+	ringQ := params.RingQ()
+
+	Q := big.NewInt(1)
+	for _, qi := range ringQ.Modulus[:level+1] {
+		Q.Mul(Q, ring.NewUint(qi))
+	}
+
+	K := new(big.Float).SetInt(Q)
+	K.Quo(K, new(big.Float).SetFloat64(4*lut.Bound*lut.ScaleIn))
+	KInt, _ := K.Int(nil)
+
+	if KInt.Sign() <= 0 {
+		return nil, fmt.Errorf("lut.Bound * lut.ScaleIn is larger than Q_level/4 at level %d", level)
+	}
+
+	ms = &modSwitcher{N: params.N(), Q: Q, QHalf: new(big.Int).Rsh(Q, 1), crt: make([]*big.Int, level+1)}
+
+	// crt[i] = K * (Q/q_i) * ((Q/q_i)^-1 mod q_i) mod Q
+	for i, qi := range ringQ.Modulus[:level+1] {
+		bigQi := ring.NewUint(qi)
+		QOverQi := new(big.Int).Quo(Q, bigQi)
+		ms.crt[i] = new(big.Int).ModInverse(new(big.Int).Mod(QOverQi, bigQi), bigQi)
+		ms.crt[i].Mul(ms.crt[i], QOverQi)
+		ms.crt[i].Mul(ms.crt[i], KInt)
+		ms.crt[i].Mod(ms.crt[i], Q)
+	}
+
+	// A phase phi in Z_2N is the message phi * Q/(2N * K * ScaleIn)
+	res := new(big.Float).SetInt(Q)
+	res.Quo(res, new(big.Float).SetInt(KInt))
+	res.Quo(res, new(big.Float).SetFloat64(2*float64(params.N())*lut.ScaleIn))
+	ms.resolution, _ = res.Float64()
+
+	return
+}
+
+// switchLWE returns round(2N * K * (b, a) / Q_level) mod 2N.
+func (ms *modSwitcher) switchLWE(ct *LWECiphertext) (b int, a []int) {
+
+	residues := make([]uint64, len(ms.crt))
+
+	for i := range residues {
+		residues[i] = ct.B[i]
+	}
+	b = ms.switchValue(residues)
+
+	a = make([]int, ms.N)
+	for j := range a {
+		for i := range residues {
+			residues[i] = ct.A[i][j]
+		}
+		a[j] = ms.switchValue(residues)
+	}
+
+	return
+}
+
+// switchValue returns round(2N * v / Q_level) mod 2N, with v = K * x mod Q_level and x the value of the residues.
+func (ms *modSwitcher) switchValue(residues []uint64) int {
+
+	v := new(big.Int)
+	tmp := new(big.Int)
+	for i, r := range residues {
+		v.Add(v, tmp.Mul(ms.crt[i], tmp.SetUint64(r)))
+	}
+	v.Mod(v, ms.Q)
+
+	v.Mul(v, big.NewInt(int64(2*ms.N)))
+	v.Add(v, ms.QHalf)
+	v.Quo(v, ms.Q)
+
+	return int(v.Int64()) % (2 * ms.N)
+}
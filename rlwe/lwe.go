@@ -0,0 +1,328 @@
+package rlwe
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// LWECiphertext is an LWE ciphertext (b, a) of the message m = b + <a, s> mod Q_level, where s is the vector of the
+// coefficients of an RLWE secret key. B[i] and A[i] store b and a modulo the i-th modulus of Q.
+type LWECiphertext struct {
+	B []uint64
+	A [][]uint64
+}
+
+// NewLWECiphertext allocates a new LWECiphertext of dimension params.N() at the given level, encrypting zero.
+func NewLWECiphertext(params Parameters, level int) (ct *LWECiphertext) {
+	ct = &LWECiphertext{B: make([]uint64, level+1), A: make([][]uint64, level+1)}
+	for i := range ct.A {
+		ct.A[i] = make([]uint64, params.N())
+	}
+	return
+}
+
+// Level returns the level of the LWECiphertext.
+func (ct *LWECiphertext) Level() int {
+	return len(ct.B) - 1
+}
+
+// ExtractLWE returns the LWE ciphertexts of the coefficients idx of the plaintext of the RLWE ciphertext ct of degree 1,
+// in the NTT domain or not. The LWE ciphertexts are at the level of ct and are encrypted under the coefficients of
+// the secret key of ct. The method panics if an index is not in [0, params.N()).
+func ExtractLWE(params Parameters, ct *Ciphertext, idx []int) (lwe []*LWECiphertext) {
+
+	ringQ := params.RingQ()
+	level := ct.Level()
+	N := params.N()
+
+	c0, c1 := ct.Value[0], ct.Value[1]
+	if c0.IsNTT {
+		c0, c1 = ringQ.NewPolyLvl(level), ringQ.NewPolyLvl(level)
+		ringQ.InvNTTLvl(level, ct.Value[0], c0)
+		ringQ.InvNTTLvl(level, ct.Value[1], c1)
+	}
+
+	lwe = make([]*LWECiphertext, len(idx))
+
+	for k, j := range idx {
+
+		if j < 0 || j >= N {
+			panic(fmt.Sprintf("cannot ExtractLWE: index %d is not in [0, %d)", j, N))
+		}
+
+		lwe[k] = NewLWECiphertext(params, level)
+
+		for i, qi := range ringQ.Modulus[:level+1] {
+
+			lwe[k].B[i] = c0.Coeffs[i][j]
+
+			// The j-th coefficient of c1 * s is sum_{l <= j} c1[j-l] * s[l] - sum_{l > j} c1[N+j-l] * s[l]
+			a := lwe[k].A[i]
+			for l := 0; l <= j; l++ {
+				a[l] = c1.Coeffs[i][j-l]
+			}
+			for l := j + 1; l < N; l++ {
+				a[l] = qi - c1.Coeffs[i][N+j-l]
+				if a[l] == qi {
+					a[l] = 0
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// DecryptLWE decrypts the LWECiphertext ct with the secret key sk and returns the centered message b + <a, s> mod Q_level.
+func DecryptLWE(params Parameters, ct *LWECiphertext, sk *SecretKey) (m *big.Int) {
+
+	ringQ := params.RingQ()
+	level := ct.Level()
+
+	s := ringQ.NewPolyLvl(level)
+	ringQ.InvMFormLvl(level, sk.Value.Q, s)
+	ringQ.InvNTTLvl(level, s, s)
+
+	Q := big.NewInt(1)
+	for _, qi := range ringQ.Modulus[:level+1] {
+		Q.Mul(Q, new(big.Int).SetUint64(qi))
+	}
+
+	m = new(big.Int)
+	tmp := new(big.Int)
+
+	for i, qi := range ringQ.Modulus[:level+1] {
+
+		bredParams := ringQ.BredParams[i]
+
+		mi := ct.B[i]
+		for j, aj := range ct.A[i] {
+			mi = ring.CRed(mi+ring.BRed(aj, s.Coeffs[i][j], qi, bredParams), qi)
+		}
+
+		// CRT reconstruction: m = sum m_i * (Q/q_i) * ((Q/q_i)^-1 mod q_i) mod Q
+		bigQi := new(big.Int).SetUint64(qi)
+		QOverQi := new(big.Int).Quo(Q, bigQi)
+		tmp.ModInverse(tmp.Mod(QOverQi, bigQi), bigQi)
+		tmp.Mul(tmp, QOverQi)
+		tmp.Mul(tmp, new(big.Int).SetUint64(mi))
+		m.Add(m, tmp)
+	}
+
+	m.Mod(m, Q)
+
+	// Centers the message in [-Q/2, Q/2)
+	if m.Cmp(new(big.Int).Rsh(Q, 1)) >= 0 {
+		m.Sub(m, Q)
+	}
+
+	return
+}
+
+// GaloisElementsForLWEPacking returns the Galois elements 2^k + 1, for 1 <= k <= log(N), of the automorphisms
+// used by LWEPacker.Pack.
+func GaloisElementsForLWEPacking(params Parameters) (galEls []uint64) {
+	galEls = make([]uint64, params.LogN())
+	for k := range galEls {
+		galEls[k] = (uint64(1) << (k + 1)) + 1
+	}
+	return
+}
+
+// LWEPacker packs LWE ciphertexts in the coefficients of an RLWE ciphertext with the algorithm of Chen et al.,
+// "Efficient Homomorphic Conversion Between (Ring) LWE Ciphertexts" (https://eprint.iacr.org/2020/015).
+type LWEPacker struct {
+	params Parameters
+	*KeySwitcher
+	rtks            *RotationKeySet
+	permuteNTTIndex map[uint64][]uint64
+	monomials       []*ring.Poly
+	pool            [2]*ring.Poly
+}
+
+// NewLWEPacker creates a new LWEPacker from the rotation keys rtks, which must contain the keys for the Galois
+// elements returned by GaloisElementsForLWEPacking.
+func NewLWEPacker(params Parameters, rtks *RotationKeySet) *LWEPacker {
+
+	ringQ := params.RingQ()
+
+	p := &LWEPacker{
+		params:          params,
+		KeySwitcher:     NewKeySwitcher(params),
+		rtks:            rtks,
+		permuteNTTIndex: make(map[uint64][]uint64),
+		pool:            [2]*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()},
+	}
+
+	for _, galEl := range GaloisElementsForLWEPacking(params) {
+		p.permuteNTTIndex[galEl] = ringQ.PermuteNTTIndex(galEl)
+	}
+
+	// monomials[l] = X^(N/2^(l+1)) in the NTT and Montgomery domain
+	p.monomials = make([]*ring.Poly, params.LogN())
+	for l := range p.monomials {
+		p.monomials[l] = ringQ.NewPoly()
+		for i := range ringQ.Modulus {
+			p.monomials[l].Coeffs[i][params.N()>>(l+1)] = 1
+		}
+		ringQ.NTT(p.monomials[l], p.monomials[l])
+		ringQ.MForm(p.monomials[l], p.monomials[l])
+	}
+
+	return p
+}
+
+// Pack returns a new RLWE ciphertext in the NTT domain whose plaintext has the message of lwe[j] as coefficient
+// j * N/n, where n is the smallest power of two greater than or equal to len(lwe), and zero as its other coefficients.
+// The nil LWE ciphertexts are considered as encryptions of zero and are not packed. The result is at the minimum
+// level of the LWE ciphertexts and is encrypted under the secret key of the rotation keys. Packing m non-nil
+// ciphertexts requires at most m * (1 + log(n/m)) + log(N/n) automorphisms, whose key-switching noise is multiplied
+// by up to N. The method panics if there is no non-nil LWE ciphertext or if a rotation key is missing.
+func (p *LWEPacker) Pack(lwe []*LWECiphertext) (ct *Ciphertext) {
+
+	ringQ := p.params.RingQ()
+	N := p.params.N()
+	logN := p.params.LogN()
+
+	level := -1
+	for _, c := range lwe {
+		if c != nil {
+			if level == -1 {
+				level = c.Level()
+			}
+			level = utils.MinInt(level, c.Level())
+		}
+	}
+
+	if level == -1 {
+		panic("cannot Pack: no LWE ciphertext")
+	}
+
+	logn := 0
+	for 1<<logn < len(lwe) {
+		logn++
+	}
+
+	// Maps each LWE ciphertext (b, a) to an RLWE ciphertext (b * N^-1, a' * N^-1) with a'(X) = a_0 - sum_{j>0} a_j X^(N-j),
+	// whose plaintext has b + <a, s> as constant coefficient. The factor N^-1 cancels the factor N of the packing.
+	cts := make([]*Ciphertext, 1<<logn)
+	for k := range lwe {
+
+		if lwe[k] == nil {
+			continue
+		}
+
+		cts[k] = NewCiphertextNTT(p.params, 1, level)
+
+		for i, qi := range ringQ.Modulus[:level+1] {
+
+			NInv := ring.ModExp(uint64(N), qi-2, qi)
+			bredParams := ringQ.BredParams[i]
+
+			// The constant polynomial b is b in all the NTT slots
+			b := ring.BRed(lwe[k].B[i], NInv, qi, bredParams)
+			for j := range cts[k].Value[0].Coeffs[i] {
+				cts[k].Value[0].Coeffs[i][j] = b
+			}
+
+			a, c1 := lwe[k].A[i], cts[k].Value[1].Coeffs[i]
+			c1[0] = ring.BRed(a[0], NInv, qi, bredParams)
+			for j := 1; j < N; j++ {
+				c1[N-j] = ring.BRed(ring.CRed(qi-a[j], qi), NInv, qi, bredParams)
+			}
+		}
+
+		ringQ.NTTLvl(level, cts[k].Value[1], cts[k].Value[1])
+	}
+
+	ct = p.pack(cts, logn, level)
+
+	// Field trace Tr_{K/K_n}: removes the coefficients that are not multiples of N/n and multiplies the others by N/n
+	tmp := NewCiphertextNTT(p.params, 1, level)
+	for k := logN; k > logn; k-- {
+		p.automorphism(ct, (uint64(1)<<k)+1, tmp, level)
+		ringQ.AddLvl(level, ct.Value[0], tmp.Value[0], ct.Value[0])
+		ringQ.AddLvl(level, ct.Value[1], tmp.Value[1], ct.Value[1])
+	}
+
+	return
+}
+
+// pack recursively merges the 2^logn RLWE ciphertexts cts into one RLWE ciphertext whose coefficient j * N/2^logn is
+// 2^logn times the constant coefficient of cts[j]. The nil ciphertexts are encryptions of zero, and pack returns nil
+// if all the ciphertexts are nil.
+func (p *LWEPacker) pack(cts []*Ciphertext, logn, level int) *Ciphertext {
+
+	if logn == 0 {
+		return cts[0]
+	}
+
+	ringQ := p.params.RingQ()
+
+	even := make([]*Ciphertext, len(cts)>>1)
+	odd := make([]*Ciphertext, len(cts)>>1)
+	for k := range even {
+		even[k], odd[k] = cts[2*k], cts[2*k+1]
+	}
+
+	ctEven := p.pack(even, logn-1, level)
+	ctOdd := p.pack(odd, logn-1, level)
+
+	if ctEven == nil && ctOdd == nil {
+		return nil
+	}
+
+	// ct = (ctEven + X^(N/2^logn) * ctOdd) + phi_{2^logn+1}(ctEven - X^(N/2^logn) * ctOdd)
+	tmp := NewCiphertextNTT(p.params, 1, level)
+
+	if ctOdd != nil {
+		for i := range ctOdd.Value {
+			ringQ.MulCoeffsMontgomeryLvl(level, ctOdd.Value[i], p.monomials[logn-1], ctOdd.Value[i])
+		}
+	}
+
+	switch {
+	case ctOdd == nil:
+		tmp.Copy(ctEven)
+	case ctEven == nil:
+		ctEven = NewCiphertextNTT(p.params, 1, level)
+		for i := range ctOdd.Value {
+			ringQ.NegLvl(level, ctOdd.Value[i], tmp.Value[i])
+			ctEven.Value[i].Copy(ctOdd.Value[i])
+		}
+	default:
+		for i := range ctOdd.Value {
+			ringQ.SubLvl(level, ctEven.Value[i], ctOdd.Value[i], tmp.Value[i])
+			ringQ.AddLvl(level, ctEven.Value[i], ctOdd.Value[i], ctEven.Value[i])
+		}
+	}
+
+	p.automorphism(tmp, (uint64(1)<<logn)+1, tmp, level)
+
+	for i := range ctEven.Value {
+		ringQ.AddLvl(level, ctEven.Value[i], tmp.Value[i], ctEven.Value[i])
+	}
+
+	return ctEven
+}
+
+// automorphism applies the automorphism X -> X^galEl on ctIn and returns the result in ctOut.
+func (p *LWEPacker) automorphism(ctIn *Ciphertext, galEl uint64, ctOut *Ciphertext, level int) {
+
+	rtk, generated := p.rtks.GetRotationKey(galEl)
+	if !generated {
+		panic(fmt.Sprintf("cannot Pack: rotation key for the Galois element %d not available", galEl))
+	}
+
+	ringQ := p.params.RingQ()
+	index := p.permuteNTTIndex[galEl]
+
+	p.SwitchKeysInPlace(level, ctIn.Value[1], rtk, p.pool[0], p.pool[1])
+	ringQ.AddLvl(level, p.pool[0], ctIn.Value[0], p.pool[0])
+
+	ringQ.PermuteNTTWithIndexLvl(level, p.pool[0], index, ctOut.Value[0])
+	ringQ.PermuteNTTWithIndexLvl(level, p.pool[1], index, ctOut.Value[1])
+}
@@ -22,12 +22,12 @@ func (ciphertext *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 }
 
 // MarshalBinary encodes a Ciphertext on a byte slice. The total size
-// in byte is 4 + 8* N * numberModuliQ * (degree + 1).
+// in byte is FormatHeaderLen + 1 + (4 + 8 * N * numberModuliQ) * (degree + 1).
 func (ciphertext *Ciphertext) MarshalBinary() (data []byte, err error) {
 
-	data = make([]byte, ciphertext.GetDataLen(true))
+	data, body := newFormatBuffer(ciphertext.GetDataLen(true))
 
-	data[0] = uint8(ciphertext.Degree() + 1)
+	body[0] = uint8(ciphertext.Degree() + 1)
 
 	var pointer, inc int
 
@@ -35,7 +35,7 @@ func (ciphertext *Ciphertext) MarshalBinary() (data []byte, err error) {
 
 	for _, el := range ciphertext.Value {
 
-		if inc, err = el.WriteTo(data[pointer:]); err != nil {
+		if inc, err = el.WriteTo(body[pointer:]); err != nil {
 			return nil, err
 		}
 
@@ -47,6 +47,10 @@ func (ciphertext *Ciphertext) MarshalBinary() (data []byte, err error) {
 
 // UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext.
 func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) (err error) {
+	if data, err = formatBody(data); err != nil {
+		return err
+	}
+
 	if len(data) < 10 { // cf. ciphertext.GetDataLen()
 		return errors.New("too small bytearray")
 	}
@@ -81,8 +85,8 @@ func (sk *SecretKey) GetDataLen(WithMetadata bool) (dataLen int) {
 
 // MarshalBinary encodes a secret key in a byte slice.
 func (sk *SecretKey) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, sk.GetDataLen(true))
-	if _, err = sk.Value.WriteTo(data); err != nil {
+	data, body := newFormatBuffer(sk.GetDataLen(true))
+	if _, err = sk.Value.WriteTo(body); err != nil {
 		return nil, err
 	}
 	return
@@ -90,6 +94,9 @@ func (sk *SecretKey) MarshalBinary() (data []byte, err error) {
 
 // UnmarshalBinary decodes a previously marshaled SecretKey in the target SecretKey.
 func (sk *SecretKey) UnmarshalBinary(data []byte) (err error) {
+	if data, err = formatBody(data); err != nil {
+		return err
+	}
 	_, err = sk.Value.DecodePolyNew(data)
 	return
 }
@@ -101,14 +108,14 @@ func (pk *PublicKey) GetDataLen(WithMetadata bool) (dataLen int) {
 
 // MarshalBinary encodes a PublicKey in a byte slice.
 func (pk *PublicKey) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, pk.GetDataLen(true))
+	data, body := newFormatBuffer(pk.GetDataLen(true))
 	var inc, pt int
-	if inc, err = pk.Value[0].WriteTo(data[pt:]); err != nil {
+	if inc, err = pk.Value[0].WriteTo(body[pt:]); err != nil {
 		return nil, err
 	}
 	pt += inc
 
-	if _, err = pk.Value[1].WriteTo(data[pt:]); err != nil {
+	if _, err = pk.Value[1].WriteTo(body[pt:]); err != nil {
 		return nil, err
 	}
 
@@ -118,6 +125,10 @@ func (pk *PublicKey) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decodes a previously marshaled PublicKey in the target PublicKey.
 func (pk *PublicKey) UnmarshalBinary(data []byte) (err error) {
 
+	if data, err = formatBody(data); err != nil {
+		return err
+	}
+
 	var pt, inc int
 	if inc, err = pk.Value[0].DecodePolyNew(data[pt:]); err != nil {
 		return
@@ -150,17 +161,15 @@ func (rlk *RelinearizationKey) MarshalBinary() (data []byte, err error) {
 
 	var pointer int
 
-	dataLen := rlk.GetDataLen(true)
-
-	data = make([]byte, dataLen)
+	data, body := newFormatBuffer(rlk.GetDataLen(true))
 
-	data[0] = uint8(len(rlk.Keys))
+	body[0] = uint8(len(rlk.Keys))
 
 	pointer++
 
 	for _, evakey := range rlk.Keys {
 
-		if pointer, err = (*SwitchingKey)(evakey).encode(pointer, data); err != nil {
+		if pointer, err = (*SwitchingKey)(evakey).encode(pointer, body); err != nil {
 			return nil, err
 		}
 	}
@@ -171,6 +180,14 @@ func (rlk *RelinearizationKey) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decodes a previously marshaled EvaluationKey in the target EvaluationKey.
 func (rlk *RelinearizationKey) UnmarshalBinary(data []byte) (err error) {
 
+	if data, err = formatBody(data); err != nil {
+		return err
+	}
+
+	if len(data) < 1 {
+		return errors.New("cannot UnmarshalBinary: data is too short")
+	}
+
 	deg := int(data[0])
 
 	rlk.Keys = make([]*SwitchingKey, deg)
@@ -206,9 +223,9 @@ func (swk *SwitchingKey) GetDataLen(WithMetadata bool) (dataLen int) {
 // MarshalBinary encodes an SwitchingKey in a byte slice.
 func (swk *SwitchingKey) MarshalBinary() (data []byte, err error) {
 
-	data = make([]byte, swk.GetDataLen(true))
+	data, body := newFormatBuffer(swk.GetDataLen(true))
 
-	if _, err = swk.encode(0, data); err != nil {
+	if _, err = swk.encode(0, body); err != nil {
 		return nil, err
 	}
 
@@ -218,6 +235,10 @@ func (swk *SwitchingKey) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decode a previously marshaled SwitchingKey in the target SwitchingKey.
 func (swk *SwitchingKey) UnmarshalBinary(data []byte) (err error) {
 
+	if data, err = formatBody(data); err != nil {
+		return err
+	}
+
 	if _, err = swk.decode(data); err != nil {
 		return err
 	}
@@ -254,6 +275,10 @@ func (swk *SwitchingKey) encode(pointer int, data []byte) (int, error) {
 
 func (swk *SwitchingKey) decode(data []byte) (pointer int, err error) {
 
+	if len(data) < 1 {
+		return 0, errors.New("cannot decode: data is too short")
+	}
+
 	decomposition := int(data[0])
 
 	pointer = 1
@@ -264,13 +289,11 @@ func (swk *SwitchingKey) decode(data []byte) (pointer int, err error) {
 
 	for j := 0; j < decomposition; j++ {
 
-		swk.Value[j][0].Q = new(ring.Poly)
 		if inc, err = swk.Value[j][0].DecodePolyNew(data[pointer:]); err != nil {
 			return
 		}
 		pointer += inc
 
-		swk.Value[j][1].P = new(ring.Poly)
 		if inc, err = swk.Value[j][1].DecodePolyNew(data[pointer:]); err != nil {
 			return
 		}
@@ -294,16 +317,16 @@ func (rtks *RotationKeySet) GetDataLen(WithMetaData bool) (dataLen int) {
 // MarshalBinary encodes a RotationKeys struct in a byte slice.
 func (rtks *RotationKeySet) MarshalBinary() (data []byte, err error) {
 
-	data = make([]byte, rtks.GetDataLen(true))
+	data, body := newFormatBuffer(rtks.GetDataLen(true))
 
 	pointer := int(0)
 
 	for galEL, key := range rtks.Keys {
 
-		binary.BigEndian.PutUint32(data[pointer:pointer+4], uint32(galEL))
+		binary.BigEndian.PutUint32(body[pointer:pointer+4], uint32(galEL))
 		pointer += 4
 
-		if pointer, err = key.encode(pointer, data); err != nil {
+		if pointer, err = key.encode(pointer, body); err != nil {
 			return nil, err
 		}
 	}
@@ -314,10 +337,18 @@ func (rtks *RotationKeySet) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decodes a previously marshaled RotationKeys in the target RotationKeys.
 func (rtks *RotationKeySet) UnmarshalBinary(data []byte) (err error) {
 
+	if data, err = formatBody(data); err != nil {
+		return err
+	}
+
 	rtks.Keys = make(map[uint64]*SwitchingKey)
 
 	for len(data) > 0 {
 
+		if len(data) < 4 {
+			return errors.New("cannot UnmarshalBinary: data is too short")
+		}
+
 		galEl := uint64(binary.BigEndian.Uint32(data))
 		data = data[4:]
 
@@ -0,0 +1,18 @@
+package rlwe
+
+import "time"
+
+// Metrics is the interface of the metrics emitted by the instrumented evaluators and protocols of the library, e.g.
+// those created with bfv.NewMetricsEvaluator, ckks.NewMetricsEvaluator or the drlwe.WithMetrics option, so that the
+// services can export them, e.g. to Prometheus or OpenMetrics, without wrapping every call. The component is the
+// instrumented object, e.g. "bfv.Evaluator" or "drlwe.CKGProtocol". The methods are called from the goroutines
+// performing the operations, hence must be safe for concurrent use, and should not block.
+type Metrics interface {
+	// ObserveOperation records an operation, i.e. a call to the method operation of the component, and its duration.
+	ObserveOperation(component, operation string, duration time.Duration)
+	// ObserveKeySize records the size in bytes of the binary serialization of a key or a share of the given kind
+	// generated by the component.
+	ObserveKeySize(component, kind string, size int)
+	// ObserveNoiseBudget records the noise budget in bits of the output ciphertext of an operation of the component.
+	ObserveNoiseBudget(component, operation string, bits float64)
+}
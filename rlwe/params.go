@@ -361,12 +361,24 @@ func (p Parameters) CopyNew() Parameters {
 	return p
 }
 
-// MarshalBinary returns a []byte representation of the parameter set.
+// MarshalBinary returns a []byte representation of the parameter set, which starts with a FormatHeader carrying the
+// digest of the parameters.
 func (p Parameters) MarshalBinary() ([]byte, error) {
 	if p.LogN() == 0 { // if N is 0, then p is the zero value
 		return []byte{}, nil
 	}
 
+	data := make([]byte, FormatHeaderLen, p.MarshalBinarySize())
+	if _, err := newFormatHeader(p.Digest()).WriteTo(data); err != nil {
+		return nil, err
+	}
+
+	return append(data, p.marshalBody()...), nil
+}
+
+// marshalBody returns the encoding of the parameter set without header, which is the format of the version 0.
+func (p Parameters) marshalBody() []byte {
+
 	// 1 byte : logN
 	// 1 byte : #Q
 	// 1 byte : #P
@@ -374,7 +386,7 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 	// 1 byte : ringType
 	// 8 * (#Q) : Q
 	// 8 * (#P) : P
-	b := utils.NewBuffer(make([]byte, 0, p.MarshalBinarySize()))
+	b := utils.NewBuffer(make([]byte, 0, p.MarshalBinarySize()-FormatHeaderLen))
 	b.WriteUint8(uint8(p.logN))
 	b.WriteUint8(uint8(len(p.qi)))
 	b.WriteUint8(uint8(len(p.pi)))
@@ -382,12 +394,19 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 	b.WriteUint8(uint8(p.ringType))
 	b.WriteUint64Slice(p.qi)
 	b.WriteUint64Slice(p.pi)
-	return b.Bytes(), nil
+	return b.Bytes()
 }
 
-// UnmarshalBinary decodes a []byte into a parameter set struct.
+// UnmarshalBinary decodes a []byte into a parameter set struct. It accepts the current and the previous versions of
+// the format, and returns an error if the digest of the header does not match the decoded parameters.
 func (p *Parameters) UnmarshalBinary(data []byte) error {
-	if len(data) < 11 {
+
+	h, data, err := DecodeFormat(data)
+	if err != nil {
+		return err
+	}
+
+	if len(data) < 12 {
 		return fmt.Errorf("invalid rlwe.Parameter serialization")
 	}
 	b := utils.NewBuffer(data)
@@ -401,19 +420,29 @@ func (p *Parameters) UnmarshalBinary(data []byte) error {
 		return err
 	}
 
+	if len(data) < 12+(lenQ+lenP)<<3 {
+		return fmt.Errorf("invalid rlwe.Parameter serialization")
+	}
+
 	qi := make([]uint64, lenQ)
 	pi := make([]uint64, lenP)
 	b.ReadUint64Slice(qi)
 	b.ReadUint64Slice(pi)
 
-	var err error
-	*p, err = NewParameters(logN, qi, pi, sigma, ringType)
-	return err
+	if *p, err = NewParameters(logN, qi, pi, sigma, ringType); err != nil {
+		return err
+	}
+
+	if !h.Params.IsZero() && h.Params != p.Digest() {
+		return fmt.Errorf("invalid rlwe.Parameter serialization: %w: digest does not match the parameters", ErrUnsupportedFormat)
+	}
+
+	return nil
 }
 
 // MarshalBinarySize returns the length of the []byte encoding of the reciever.
 func (p Parameters) MarshalBinarySize() int {
-	return 12 + (len(p.qi)+len(p.pi))<<3
+	return FormatHeaderLen + 12 + (len(p.qi)+len(p.pi))<<3
 }
 
 // MarshalJSON returns a JSON representation of this parameter set. See `Marshal` from the `encoding/json` package.
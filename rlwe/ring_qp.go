@@ -1,6 +1,8 @@
 package rlwe
 
 import (
+	"errors"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/utils"
 )
@@ -308,6 +310,10 @@ func (p *PolyQP) WriteTo(data []byte) (pt int, err error) {
 // DecodePolyNew decodes the input bytes on the target polyQP.
 func (p *PolyQP) DecodePolyNew(data []byte) (pt int, err error) {
 
+	if len(data) < 2 {
+		return 0, errors.New("cannot DecodePolyNew: data is too short")
+	}
+
 	var inc int
 	pt = 2
 
@@ -1,6 +1,9 @@
 package rlwe
 
 import (
+	"bytes"
+	"compress/flate"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -8,6 +11,7 @@ import (
 	"math/big"
 	"math/bits"
 	"runtime"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -59,7 +63,9 @@ func TestRLWE(t *testing.T) {
 			testDecryptor,
 			testKeySwitcher,
 			testKeySwitchDimension,
+			testLWE,
 			testMarshaller,
+			testTracing,
 		} {
 			testSet(kgen, t)
 			runtime.GC()
@@ -555,6 +561,88 @@ func testKeySwitchDimension(kgen KeyGenerator, t *testing.T) {
 	})
 }
 
+func testLWE(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+	sk := kgen.GenSecretKey()
+	ringQ := params.RingQ()
+	encryptor := NewEncryptor(params, sk)
+	decryptor := NewDecryptor(params, sk)
+
+	level := params.MaxLevel()
+
+	// Plaintext of coefficients m_j = (j+1) * 2^20
+	plaintext := NewPlaintext(params, level)
+	want := make([]*big.Int, params.N())
+	for j := range want {
+		want[j] = new(big.Int).Lsh(big.NewInt(int64(j+1)), 20)
+		for i := range ringQ.Modulus[:level+1] {
+			plaintext.Value.Coeffs[i][j] = uint64(j+1) << 20
+		}
+	}
+	ringQ.NTTLvl(level, plaintext.Value, plaintext.Value)
+	plaintext.Value.IsNTT = true
+
+	ciphertext := NewCiphertextNTT(params, 1, level)
+	encryptor.Encrypt(plaintext, ciphertext)
+
+	idx := []int{0, 1, 7, params.N() - 1}
+
+	lwe := ExtractLWE(params, ciphertext, idx)
+
+	t.Run(testString(params, "LWE/Extract/"), func(t *testing.T) {
+		for k, j := range idx {
+			require.Equal(t, level, lwe[k].Level())
+			diff := new(big.Int).Sub(DecryptLWE(params, lwe[k], sk), want[j])
+			require.LessOrEqual(t, diff.BitLen(), 5+params.LogN())
+		}
+	})
+
+	t.Run(testString(params, "LWE/Pack/"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		packer := NewLWEPacker(params, kgen.GenRotationKeys(GaloisElementsForLWEPacking(params), sk))
+
+		// The ciphertexts are packed at the coefficients multiple of N/8, with zero ciphertexts at the other ones
+		index := []int{-1, 1, 2, 3, 0, -1, -1, -1}
+		packed := make([]*LWECiphertext, len(index))
+		for k, i := range index {
+			if i != -1 {
+				packed[k] = lwe[i]
+			}
+		}
+
+		ct := packer.Pack(packed)
+		require.Equal(t, level, ct.Level())
+
+		pt := NewPlaintext(params, level)
+		pt.Value.IsNTT = true
+		decryptor.Decrypt(ct, pt)
+		ringQ.InvNTTLvl(level, pt.Value, pt.Value)
+
+		coeffs := make([]*big.Int, params.N())
+		for j := range coeffs {
+			coeffs[j] = new(big.Int)
+		}
+		ringQ.PolyToBigintCenteredLvl(level, pt.Value, 1, coeffs)
+
+		for j := range coeffs {
+
+			expected := new(big.Int)
+			if j%(params.N()/8) == 0 && index[j/(params.N()/8)] != -1 {
+				expected = want[idx[index[j/(params.N()/8)]]]
+			}
+
+			// The key-switching noise of the automorphisms is multiplied by up to N
+			diff := new(big.Int).Sub(coeffs[j], expected)
+			require.LessOrEqual(t, diff.BitLen(), 12+params.LogN())
+		}
+	})
+}
+
 func testMarshaller(kgen KeyGenerator, t *testing.T) {
 
 	params := kgen.(*keyGenerator).params
@@ -695,4 +783,399 @@ func testMarshaller(kgen KeyGenerator, t *testing.T) {
 
 		rotationKey.Equals(resRotationKey)
 	})
+
+	t.Run(testString(params, "Marshaller/Format"), func(t *testing.T) {
+
+		prng, _ := utils.NewPRNG()
+		ciphertext := NewCiphertextRandom(prng, params, 1, params.MaxLevel())
+
+		data, err := ciphertext.MarshalBinary()
+		require.NoError(t, err)
+		h, ptr, err := DecodeFormatHeader(data)
+		require.NoError(t, err)
+		require.Equal(t, FormatHeaderLen, ptr)
+		require.Equal(t, FormatVersion, h.Version)
+		require.True(t, h.Params.IsZero())
+
+		// The objects of the version 0, without header, are still decoded
+		for _, obj := range []interface {
+			MarshalBinary() ([]byte, error)
+			UnmarshalBinary([]byte) error
+		}{ciphertext, sk, pk} {
+			data, err := obj.MarshalBinary()
+			require.NoError(t, err)
+			require.NoError(t, obj.UnmarshalBinary(data[FormatHeaderLen:]))
+			dataTest, err := obj.MarshalBinary()
+			require.NoError(t, err)
+			require.Equal(t, data, dataTest)
+		}
+
+		dataParams, err := params.MarshalBinary()
+		require.NoError(t, err)
+		var paramsTest Parameters
+		require.NoError(t, paramsTest.UnmarshalBinary(dataParams[FormatHeaderLen:]))
+		require.True(t, params.Equals(paramsTest))
+
+		// The digest of the parameters is checked
+		require.NoError(t, CheckParameters(data, params))
+		require.NoError(t, StampParameters(data, params))
+		require.NoError(t, CheckParameters(data, params))
+		paramsOther, err := NewParameters(params.LogN(), params.Q(), params.P(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		require.ErrorIs(t, CheckParameters(data, paramsOther), ErrUnsupportedFormat)
+		digestOther := paramsOther.Digest()
+		copy(dataParams[len(FormatMagic)+1:], digestOther[:])
+		require.ErrorIs(t, paramsTest.UnmarshalBinary(dataParams), ErrUnsupportedFormat)
+
+		// The objects of the version 1, without compression, are still decoded
+		dataV1 := append(append(FormatMagic[:], 1), data[FormatHeaderLen-ParametersDigestLen:]...)
+		ciphertextTest := new(Ciphertext)
+		require.NoError(t, ciphertextTest.UnmarshalBinary(dataV1))
+		require.NoError(t, CheckParameters(dataV1, params))
+		require.ErrorIs(t, CheckParameters(dataV1, paramsOther), ErrUnsupportedFormat)
+		for i := range ciphertext.Value {
+			require.True(t, params.RingQ().Equal(ciphertext.Value[i], ciphertextTest.Value[i]))
+		}
+
+		// A future version is rejected
+		data[len(FormatMagic)] = FormatVersion + 1
+		require.ErrorIs(t, new(Ciphertext).UnmarshalBinary(data), ErrUnsupportedFormat)
+	})
+
+	t.Run(testString(params, "Marshaller/Compression"), func(t *testing.T) {
+
+		prng, _ := utils.NewPRNG()
+
+		// The zero ciphertext is compressed, whereas the uniform coefficients might not be
+		for i, ciphertext := range []*Ciphertext{NewCiphertext(params, 1, params.MaxLevel()), NewCiphertextRandom(prng, params, 1, params.MaxLevel())} {
+
+			data, err := ciphertext.MarshalBinary()
+			require.NoError(t, err)
+
+			compressed, err := Compress(data, CompressionDeflate)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(compressed), len(data))
+
+			if i == 0 {
+				require.Less(t, len(compressed), len(data)/100)
+				h, _, err := DecodeFormatHeader(compressed)
+				require.NoError(t, err)
+				require.Equal(t, CompressionDeflate, h.Compression)
+			}
+
+			// The decoders decompress transparently
+			ciphertextTest := new(Ciphertext)
+			require.NoError(t, ciphertextTest.UnmarshalBinary(compressed))
+			for i := range ciphertext.Value {
+				require.True(t, params.RingQ().Equal(ciphertext.Value[i], ciphertextTest.Value[i]))
+			}
+
+			decompressed, err := Decompress(compressed)
+			require.NoError(t, err)
+			require.Equal(t, data, decompressed)
+
+			// The decompressed encoding is bounded by MaxDecompressedLen
+			if i == 0 {
+				maxDecompressedLen := MaxDecompressedLen
+				MaxDecompressedLen = int64(len(data) - FormatHeaderLen - 1)
+				require.Error(t, new(Ciphertext).UnmarshalBinary(compressed))
+				MaxDecompressedLen = maxDecompressedLen
+			}
+		}
+
+		// A highly compressible encoding, e.g. 64MB of zeros compressed to 64KB, is not decompressed beyond
+		// MaxDecompressedLen
+		data, err := NewCiphertext(params, 1, params.MaxLevel()).MarshalBinary()
+		require.NoError(t, err)
+		h, _, err := DecodeFormatHeader(data)
+		require.NoError(t, err)
+		h.Compression = CompressionDeflate
+		bomb := bytes.NewBuffer(make([]byte, FormatHeaderLen))
+		_, err = h.WriteTo(bomb.Bytes())
+		require.NoError(t, err)
+		w, err := flate.NewWriter(bomb, flate.BestCompression)
+		require.NoError(t, err)
+		zeros := make([]byte, 1<<16)
+		for i := 0; i < 1<<10; i++ {
+			_, err = w.Write(zeros)
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		require.Less(t, bomb.Len(), 1<<17)
+
+		maxDecompressedLen := MaxDecompressedLen
+		MaxDecompressedLen = 1 << 20
+		_, _, err = DecodeFormat(bomb.Bytes())
+		require.Error(t, err)
+		require.Error(t, new(Ciphertext).UnmarshalBinary(bomb.Bytes()))
+		MaxDecompressedLen = maxDecompressedLen
+
+		data, err = params.MarshalBinary()
+		require.NoError(t, err)
+		compressed, err := Compress(data, CompressionDeflate)
+		require.NoError(t, err)
+		var paramsTest Parameters
+		require.NoError(t, paramsTest.UnmarshalBinary(compressed))
+		require.True(t, params.Equals(paramsTest))
+
+		_, err = Compress(data, CompressionDeflate+1)
+		require.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+
+	t.Run(testString(params, "Marshaller/CBOR"), func(t *testing.T) {
+
+		data, err := params.MarshalCBOR()
+		require.NoError(t, err)
+		var paramsTest Parameters
+		require.NoError(t, paramsTest.UnmarshalCBOR(data))
+		require.True(t, params.Equals(paramsTest))
+
+		// The type of the object is checked
+		require.Error(t, new(SecretKey).UnmarshalCBOR(data))
+
+		data, err = sk.MarshalCBOR()
+		require.NoError(t, err)
+		skTest := new(SecretKey)
+		require.NoError(t, skTest.UnmarshalCBOR(data))
+		require.True(t, sk.Value.Equals(skTest.Value))
+
+		data, err = pk.MarshalCBOR()
+		require.NoError(t, err)
+		pkTest := new(PublicKey)
+		require.NoError(t, pkTest.UnmarshalCBOR(data))
+		require.True(t, pk.Equals(pkTest))
+
+		prng, _ := utils.NewPRNG()
+		ciphertext := NewCiphertextRandom(prng, params, 2, params.MaxLevel())
+		data, err = ciphertext.MarshalCBOR()
+		require.NoError(t, err)
+		ciphertextTest := new(Ciphertext)
+		require.NoError(t, ciphertextTest.UnmarshalCBOR(data))
+		require.Equal(t, ciphertext.Degree(), ciphertextTest.Degree())
+		for i := range ciphertext.Value {
+			require.True(t, params.RingQ().Equal(ciphertext.Value[i], ciphertextTest.Value[i]))
+			require.Equal(t, ciphertext.Value[i].IsNTT, ciphertextTest.Value[i].IsNTT)
+		}
+
+		if params.PCount() == 0 {
+			return
+		}
+
+		rlk := kgen.GenRelinearizationKey(sk, 2)
+		data, err = rlk.MarshalCBOR()
+		require.NoError(t, err)
+		rlkTest := new(RelinearizationKey)
+		require.NoError(t, rlkTest.UnmarshalCBOR(data))
+		require.True(t, rlk.Equals(rlkTest))
+
+		galEls := []uint64{params.GaloisElementForColumnRotationBy(1), params.GaloisElementForColumnRotationBy(-1)}
+		rtks := kgen.GenRotationKeys(galEls, sk)
+		data, err = rtks.MarshalCBOR()
+		require.NoError(t, err)
+		rtksTest := new(RotationKeySet)
+		require.NoError(t, rtksTest.UnmarshalCBOR(data))
+		require.True(t, rtks.Equals(rtksTest))
+
+		// The encoding is deterministic
+		dataTest, err := rtksTest.MarshalCBOR()
+		require.NoError(t, err)
+		require.Equal(t, data, dataTest)
+	})
+}
+
+// testSpan is a span recorded by a testTracer.
+type testSpan struct {
+	name       string
+	parent     *testSpan
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (span *testSpan) SetAttribute(key string, value interface{}) {
+	span.attributes[key] = value
+}
+
+func (span *testSpan) End(err error) {
+	span.ended = true
+}
+
+type testSpanKey struct{}
+
+// testTracer is a Tracer recording the spans it starts.
+type testTracer struct {
+	sync.Mutex
+	spans []*testSpan
+}
+
+func (tracer *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	tracer.Lock()
+	defer tracer.Unlock()
+	parent, _ := ctx.Value(testSpanKey{}).(*testSpan)
+	span := &testSpan{name: name, parent: parent, attributes: map[string]interface{}{}}
+	tracer.spans = append(tracer.spans, span)
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func testTracing(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	t.Run(testString(params, "Tracing/NoTracer"), func(t *testing.T) {
+		ctx := context.Background()
+		require.Nil(t, TracerFromContext(ctx))
+		spanCtx, span := StartSpan(ctx, "op")
+		require.Equal(t, ctx, spanCtx)
+		span.SetAttribute("key", 0)
+		span.End(nil)
+	})
+
+	t.Run(testString(params, "Tracing/KeyGenerator"), func(t *testing.T) {
+
+		tracer := new(testTracer)
+		ctx, root := StartSpan(ContextWithTracer(context.Background(), tracer), "root")
+		require.Equal(t, tracer, TracerFromContext(ctx))
+
+		tkgen := NewTracingKeyGenerator(ctx, kgen)
+		sk := tkgen.GenSecretKey()
+		tkgen.GenRelinearizationKey(sk, 1)
+		rtks := tkgen.GenRotationKeysForRotations([]int{1, 2}, false, sk)
+		root.End(nil)
+
+		require.Len(t, rtks.Keys, 2)
+		require.Len(t, tracer.spans, 3)
+		for i, name := range []string{"GenRelinearizationKey", "GenRotationKeysForRotations"} {
+			span := tracer.spans[i+1]
+			require.Equal(t, "rlwe.KeyGenerator/"+name, span.name)
+			require.Equal(t, tracer.spans[0], span.parent)
+			require.True(t, span.ended)
+		}
+		require.Equal(t, 1, tracer.spans[1].attributes["maxDegree"])
+		require.Equal(t, 2, tracer.spans[2].attributes["keys"])
+	})
+}
+
+func TestSecurityLevel(t *testing.T) {
+
+	t.Run("SecurityLevel/MaxLogQP", func(t *testing.T) {
+		for _, pl := range TestParams[:4] {
+			params, err := NewParametersFromLiteral(pl)
+			require.NoError(t, err)
+			maxLogQP, err := MaxLogQP(params.LogN(), Classic128)
+			require.NoError(t, err)
+			require.LessOrEqual(t, params.LogQP(), maxLogQP)
+		}
+
+		_, err := MaxLogQP(17, Classic128)
+		require.Error(t, err)
+		_, err = MaxLogQP(12, SecurityLevel(-1))
+		require.Error(t, err)
+	})
+
+	t.Run("SecurityLevel/MinLogNForLogQP", func(t *testing.T) {
+		logN, err := MinLogNForLogQP(109, Classic128)
+		require.NoError(t, err)
+		require.Equal(t, 12, logN)
+
+		logN, err = MinLogNForLogQP(110, Classic128)
+		require.NoError(t, err)
+		require.Equal(t, 13, logN)
+
+		logN, err = MinLogNForLogQP(100, PostQuantum128)
+		require.NoError(t, err)
+		require.Equal(t, 12, logN)
+
+		_, err = MinLogNForLogQP(1762, Classic128)
+		require.Error(t, err)
+	})
+
+	t.Run("SecurityLevel/ModuliLogSizes", func(t *testing.T) {
+		require.Equal(t, []int{}, ModuliLogSizes(0, 60))
+		require.Equal(t, []int{45}, ModuliLogSizes(45, 60))
+		require.Equal(t, []int{61, 60}, ModuliLogSizes(121, 61))
+		require.Equal(t, []int{41, 40, 40}, ModuliLogSizes(121, 60))
+	})
+
+	t.Run("SecurityLevel/Standard", func(t *testing.T) {
+
+		sets := StandardParameterSets()
+		require.Len(t, sets, 18)
+		require.Equal(t, "HES-ternary-128-PN10QP27", sets[0].Name)
+
+		for _, s := range sets {
+			params, err := ParamsFromStandard(s.Name)
+			require.NoError(t, err, s.Name)
+			require.Equal(t, s.LogN, params.LogN())
+			require.LessOrEqual(t, params.LogQP(), s.LogQP, s.Name)
+			require.GreaterOrEqual(t, params.LogQP(), s.LogQP-1, s.Name)
+
+			maxLogQP, err := MaxLogQP(s.LogN, s.Security)
+			require.NoError(t, err)
+			require.Equal(t, maxLogQP, s.LogQP)
+		}
+
+		params, err := ParamsFromStandard("HES-ternary-128-PN12QP109")
+		require.NoError(t, err)
+		require.Equal(t, 1, params.QCount())
+		require.Equal(t, 1, params.PCount())
+
+		_, err = ParamsFromStandard("HES-ternary-128-PN12QP110")
+		require.Error(t, err)
+	})
+}
+
+func TestLUT(t *testing.T) {
+
+	// The blind rotation key has 4N switching keys and a blind rotation costs 2N key-switchings, hence the small ring
+	params, err := NewParametersFromLiteral(ParametersLiteral{LogN: 8, LogQ: []int{55, 40}, LogP: []int{61}})
+	require.NoError(t, err)
+
+	kgen := NewKeyGenerator(params)
+	sk := kgen.GenSecretKey()
+	brk := kgen.GenBlindRotationKey(sk)
+	ringQ := params.RingQ()
+	level := params.MaxLevel()
+
+	// Messages x_j close to integers, which are rounded by the look-up table
+	scale := float64(1 << 30)
+	values := make([]float64, params.N())
+	plaintext := NewPlaintext(params, level)
+	for j := range values {
+		values[j] = float64(j%5-2) + utils.RandFloat64(-0.1, 0.1)
+		m := int64(math.Round(values[j] * scale))
+		for i, qi := range ringQ.Modulus[:level+1] {
+			plaintext.Value.Coeffs[i][j] = uint64((m%int64(qi) + int64(qi)) % int64(qi))
+		}
+	}
+	ringQ.NTTLvl(level, plaintext.Value, plaintext.Value)
+	plaintext.Value.IsNTT = true
+
+	ciphertext := NewCiphertextNTT(params, 1, level)
+	NewEncryptor(params, sk).Encrypt(plaintext, ciphertext)
+
+	idx := []int{0, 1, 2, 3, 4, params.N() - 1}
+	lwe := ExtractLWE(params, ciphertext, idx)
+
+	eval := NewLUTEvaluator(params, brk)
+
+	t.Run(testString(params, "LUT/Evaluate/"), func(t *testing.T) {
+
+		lut := LUT{F: math.Round, Bound: 2.5, ScaleIn: scale, ScaleOut: scale}
+
+		// A nil LWE ciphertext is skipped
+		res, err := eval.EvaluateLUT(append(lwe, nil), lut, level)
+		require.NoError(t, err)
+		require.Len(t, res, len(idx)+1)
+		require.Nil(t, res[len(idx)])
+
+		for k, j := range idx {
+			require.Equal(t, level, res[k].Level())
+			m, _ := new(big.Float).SetInt(DecryptLWE(params, res[k], sk)).Float64()
+			require.InDelta(t, math.Round(values[j]), m/scale, 1e-4)
+		}
+	})
+
+	t.Run(testString(params, "LUT/Errors/"), func(t *testing.T) {
+		_, err := eval.EvaluateLUT(lwe, LUT{F: math.Round, Bound: 1 << 70, ScaleIn: scale, ScaleOut: scale}, level)
+		require.Error(t, err)
+	})
 }
@@ -0,0 +1,101 @@
+package rlwe
+
+import (
+	"fmt"
+)
+
+// SecurityLevel is a target security level for a parameter set. It bounds the bit-size of the modulus QP
+// for a given ring degree.
+type SecurityLevel int
+
+const (
+	// Classic128 is 128-bit security against classical attacks.
+	Classic128 = SecurityLevel(iota)
+	// Classic192 is 192-bit security against classical attacks.
+	Classic192
+	// Classic256 is 256-bit security against classical attacks.
+	Classic256
+	// PostQuantum128 is 128-bit security against quantum attacks.
+	PostQuantum128
+)
+
+// maxLogQP maps each security level to the largest bit-size of QP for each log2 of the ring degree.
+// The classic bounds are the ones of the Homomorphic Encryption Standard for ternary secrets,
+// and the post-quantum bounds are the ones of the post-quantum default parameters of Lattigo.
+var maxLogQP = map[SecurityLevel]map[int]int{
+	Classic128:     {10: 27, 11: 54, 12: 109, 13: 218, 14: 438, 15: 881, 16: 1761},
+	Classic192:     {10: 19, 11: 37, 12: 75, 13: 152, 14: 305, 15: 611},
+	Classic256:     {10: 14, 11: 29, 12: 58, 13: 118, 14: 237, 15: 476},
+	PostQuantum128: {12: 101, 13: 202, 14: 411, 15: 827, 16: 1654},
+}
+
+func (s SecurityLevel) String() string {
+	switch s {
+	case Classic128:
+		return "Classic128"
+	case Classic192:
+		return "Classic192"
+	case Classic256:
+		return "Classic256"
+	case PostQuantum128:
+		return "PostQuantum128"
+	default:
+		return fmt.Sprintf("SecurityLevel(%d)", int(s))
+	}
+}
+
+// MaxLogQP returns the largest bit-size of the modulus QP that ensures the security level for the ring degree 2^logN.
+// It returns an error if the security level or the ring degree are not supported.
+func MaxLogQP(logN int, security SecurityLevel) (int, error) {
+
+	bounds, ok := maxLogQP[security]
+	if !ok {
+		return 0, fmt.Errorf("unsupported security level: %s", security)
+	}
+
+	logQP, ok := bounds[logN]
+	if !ok {
+		return 0, fmt.Errorf("unsupported ring degree 2^%d for security level %s", logN, security)
+	}
+
+	return logQP, nil
+}
+
+// MinLogNForLogQP returns the log2 of the smallest ring degree for which a modulus QP of logQP bits ensures the security level.
+// It returns an error if the security level is not supported or if no supported ring degree is large enough.
+func MinLogNForLogQP(logQP int, security SecurityLevel) (int, error) {
+
+	bounds, ok := maxLogQP[security]
+	if !ok {
+		return 0, fmt.Errorf("unsupported security level: %s", security)
+	}
+
+	for logN := MinLogN; logN < MaxLogN+1; logN++ {
+		if max, ok := bounds[logN]; ok && logQP <= max {
+			return logN, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no supported ring degree ensures %s with logQP=%d", security, logQP)
+}
+
+// ModuliLogSizes splits logQ bits into the smallest number of moduli of at most maxLogQi bits
+// and returns their bit-sizes, which differ by at most one.
+func ModuliLogSizes(logQ, maxLogQi int) (logQi []int) {
+
+	if logQ <= 0 {
+		return []int{}
+	}
+
+	n := (logQ + maxLogQi - 1) / maxLogQi
+
+	logQi = make([]int, n)
+	for i := range logQi {
+		logQi[i] = logQ / n
+		if i < logQ%n {
+			logQi[i]++
+		}
+	}
+
+	return
+}
@@ -0,0 +1,88 @@
+package rlwe
+
+import (
+	"fmt"
+	"sort"
+)
+
+// StandardParameterSet is a parameter set of the security tables of the Homomorphic Encryption Standard
+// (HomomorphicEncryption.org, 2018): the ring degree 2^LogN, the largest bit-size LogQP of the modulus QP and the
+// distribution of the secret for the target security level, with an error of standard deviation DefaultSigma. The
+// key generators of the library sample ternary secrets, hence only the sets of the table for ternary secrets are
+// provided.
+type StandardParameterSet struct {
+	Name     string
+	LogN     int
+	LogQP    int
+	Secret   string
+	Security SecurityLevel
+}
+
+// standardSecurityLevels maps the security levels of the Homomorphic Encryption Standard to their number of bits.
+var standardSecurityLevels = map[SecurityLevel]int{Classic128: 128, Classic192: 192, Classic256: 256}
+
+// standardMaxLogN is the log2 of the largest ring degree of the tables of the Homomorphic Encryption Standard.
+const standardMaxLogN = 15
+
+// StandardParameterSets returns the parameter sets of the Homomorphic Encryption Standard, sorted by security level and
+// ring degree. Their names are of the form HES-ternary-128-PN12QP109, for the ternary secrets, 128-bit classical
+// security, a ring degree of 2^12 and a modulus QP of at most 109 bits.
+func StandardParameterSets() (sets []StandardParameterSet) {
+
+	for security, bits := range standardSecurityLevels {
+		for logN, logQP := range maxLogQP[security] {
+			if logN <= standardMaxLogN {
+				sets = append(sets, StandardParameterSet{
+					Name:     fmt.Sprintf("HES-ternary-%d-PN%dQP%d", bits, logN, logQP),
+					LogN:     logN,
+					LogQP:    logQP,
+					Secret:   "ternary",
+					Security: security,
+				})
+			}
+		}
+	}
+
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Security != sets[j].Security {
+			return sets[i].Security < sets[j].Security
+		}
+		return sets[i].LogN < sets[j].LogN
+	})
+
+	return
+}
+
+// ParametersLiteral returns the ParametersLiteral of the parameter set: the modulus QP is split into moduli of at most
+// 60 bits whose product is smaller than 2^LogQP, the largest of which is the modulus P if there are several moduli.
+func (s StandardParameterSet) ParametersLiteral() ParametersLiteral {
+
+	// The moduli of logQi bits are the NTT primes closest to 2^logQi, which can be slightly larger than 2^logQi, hence
+	// their product can exceed 2^(sum of the logQi), but not 2^(sum of the logQi + 1): one bit of LogQP is kept for it.
+	logQi := ModuliLogSizes(s.LogQP-1, MaxModuliSize)
+
+	pl := ParametersLiteral{LogN: s.LogN, Sigma: DefaultSigma, LogQ: logQi, LogP: []int{}}
+	if len(logQi) > 1 {
+		pl.LogP, pl.LogQ = logQi[:1], logQi[1:]
+	}
+
+	return pl
+}
+
+// ParamsFromStandard returns the parameters of the parameter set of the Homomorphic Encryption Standard of the given
+// name (see StandardParameterSets), so that a deployment can refer to a standard set instead of a custom literal. It
+// returns an error if no standard set has this name.
+func ParamsFromStandard(name string) (Parameters, error) {
+
+	for _, s := range StandardParameterSets() {
+		if s.Name == name {
+			params, err := NewParametersFromLiteral(s.ParametersLiteral())
+			if err != nil {
+				return Parameters{}, fmt.Errorf("cannot ParamsFromStandard: %w", err)
+			}
+			return params, nil
+		}
+	}
+
+	return Parameters{}, fmt.Errorf("cannot ParamsFromStandard: unknown standard parameter set %q", name)
+}
@@ -0,0 +1,138 @@
+package rlwe
+
+import (
+	"context"
+)
+
+// Tracer is the interface of the tracing hooks of the library, through which the context-aware methods of the
+// library, e.g. those of the key generators created with NewTracingKeyGenerator, of the bootstrapping or of the
+// drlwe.Orchestrator, report spans around their expensive operations. It is carried by the context given to these
+// methods (see ContextWithTracer), so that the spans are children of the span of the caller and the pipelines of
+// several services can be traced end-to-end, e.g. by an adapter of an OpenTelemetry trace.Tracer:
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, rlwe.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	// Start starts a span named name, as a child of the span of ctx if any, and returns a context carrying it.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer. Its methods are called from the goroutine performing the operation.
+type Span interface {
+	// SetAttribute sets the attribute key of the span to value, e.g. the level of a ciphertext.
+	SetAttribute(key string, value interface{})
+	// End ends the span, recording err as its status if not nil.
+	End(err error)
+}
+
+type tracerKey struct{}
+
+// ContextWithTracer returns a copy of ctx carrying tracer, from which the context-aware methods of the library start
+// their spans.
+func ContextWithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, tracer)
+}
+
+// TracerFromContext returns the Tracer carried by ctx, or nil if it carries none.
+func TracerFromContext(ctx context.Context) Tracer {
+	tracer, _ := ctx.Value(tracerKey{}).(Tracer)
+	return tracer
+}
+
+// StartSpan starts a span named name with the Tracer carried by ctx and returns a context carrying it. If ctx carries
+// no Tracer, it returns ctx and a Span doing nothing, so that the untraced operations do not allocate.
+func StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	if tracer := TracerFromContext(ctx); tracer != nil {
+		return tracer.Start(ctx, name)
+	}
+	return ctx, noopSpan{}
+}
+
+// noopSpan is the Span of the operations of an untraced context.
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+
+func (noopSpan) End(err error) {}
+
+// tracingKeyGenerator is a KeyGenerator starting a span around the generation of each evaluation key.
+type tracingKeyGenerator struct {
+	KeyGenerator
+	ctx context.Context
+}
+
+// NewTracingKeyGenerator creates a KeyGenerator that generates the keys with kgen and starts a span named
+// "rlwe.KeyGenerator/<method>" with the Tracer carried by ctx around the generation of each relinearization, switching,
+// rotation and blind rotation key, i.e. of each key whose generation is expensive. The generation of the secret and
+// public keys is not traced.
+func NewTracingKeyGenerator(ctx context.Context, kgen KeyGenerator) KeyGenerator {
+	return &tracingKeyGenerator{KeyGenerator: kgen, ctx: ctx}
+}
+
+// span starts the span of the method.
+func (kgen *tracingKeyGenerator) span(method string) Span {
+	_, span := StartSpan(kgen.ctx, "rlwe.KeyGenerator/"+method)
+	return span
+}
+
+func (kgen *tracingKeyGenerator) GenRelinearizationKey(sk *SecretKey, maxDegree int) (evk *RelinearizationKey) {
+	span := kgen.span("GenRelinearizationKey")
+	span.SetAttribute("maxDegree", maxDegree)
+	defer span.End(nil)
+	return kgen.KeyGenerator.GenRelinearizationKey(sk, maxDegree)
+}
+
+func (kgen *tracingKeyGenerator) GenSwitchingKey(skInput, skOutput *SecretKey) (newevakey *SwitchingKey) {
+	defer kgen.span("GenSwitchingKey").End(nil)
+	return kgen.KeyGenerator.GenSwitchingKey(skInput, skOutput)
+}
+
+func (kgen *tracingKeyGenerator) GenSwitchingKeyForGalois(galEl uint64, sk *SecretKey) (swk *SwitchingKey) {
+	span := kgen.span("GenSwitchingKeyForGalois")
+	span.SetAttribute("galEl", galEl)
+	defer span.End(nil)
+	return kgen.KeyGenerator.GenSwitchingKeyForGalois(galEl, sk)
+}
+
+func (kgen *tracingKeyGenerator) GenRotationKeys(galEls []uint64, sk *SecretKey) (rks *RotationKeySet) {
+	span := kgen.span("GenRotationKeys")
+	span.SetAttribute("keys", len(galEls))
+	defer span.End(nil)
+	return kgen.KeyGenerator.GenRotationKeys(galEls, sk)
+}
+
+func (kgen *tracingKeyGenerator) GenSwitchingKeyForRotationBy(k int, sk *SecretKey) (swk *SwitchingKey) {
+	span := kgen.span("GenSwitchingKeyForRotationBy")
+	span.SetAttribute("k", k)
+	defer span.End(nil)
+	return kgen.KeyGenerator.GenSwitchingKeyForRotationBy(k, sk)
+}
+
+func (kgen *tracingKeyGenerator) GenRotationKeysForRotations(ks []int, inclueSwapRows bool, sk *SecretKey) (rks *RotationKeySet) {
+	span := kgen.span("GenRotationKeysForRotations")
+	span.SetAttribute("keys", len(ks))
+	defer span.End(nil)
+	return kgen.KeyGenerator.GenRotationKeysForRotations(ks, inclueSwapRows, sk)
+}
+
+func (kgen *tracingKeyGenerator) GenSwitchingKeyForRowRotation(sk *SecretKey) (swk *SwitchingKey) {
+	defer kgen.span("GenSwitchingKeyForRowRotation").End(nil)
+	return kgen.KeyGenerator.GenSwitchingKeyForRowRotation(sk)
+}
+
+func (kgen *tracingKeyGenerator) GenRotationKeysForInnerSum(sk *SecretKey) (rks *RotationKeySet) {
+	defer kgen.span("GenRotationKeysForInnerSum").End(nil)
+	return kgen.KeyGenerator.GenRotationKeysForInnerSum(sk)
+}
+
+func (kgen *tracingKeyGenerator) GenSwitchingKeysForRingSwap(skCKKS, skCI *SecretKey) (swkStdToConjugateInvariant, swkConjugateInvariantToStd *SwitchingKey) {
+	defer kgen.span("GenSwitchingKeysForRingSwap").End(nil)
+	return kgen.KeyGenerator.GenSwitchingKeysForRingSwap(skCKKS, skCI)
+}
+
+func (kgen *tracingKeyGenerator) GenBlindRotationKey(sk *SecretKey) (brk *BlindRotationKey) {
+	defer kgen.span("GenBlindRotationKey").End(nil)
+	return kgen.KeyGenerator.GenBlindRotationKey(sk)
+}
@@ -0,0 +1,212 @@
+package secureagg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/dckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Client is a client of a secure aggregation, which holds the threshold secret key share of the current round.
+type Client struct {
+	params    Parameters
+	self      drlwe.ShamirPublicPoint
+	clients   []drlwe.ShamirPublicPoint
+	seed      []byte
+	serverKey *rlwe.PublicKey
+
+	kgen    rlwe.KeyGenerator
+	ckg     *dckks.CKGProtocol
+	thr     *drlwe.Thresholdizer
+	cmb     *drlwe.Combiner
+	pcks    *dckks.PCKSProtocol
+	encoder ckks.Encoder
+
+	round    int
+	selfEval *drlwe.ShamirSecretShare
+	share    *drlwe.ShamirSecretShare
+	key      *RoundKey
+	released []byte
+}
+
+// NewClient creates a new Client of public point self among the clients of public points clients. The seed of the
+// common reference strings of the rounds is shared by the clients and the server, and serverKey is the public key of
+// the server, under which the aggregates are released. It returns an error if the parameters are invalid or if self is
+// not among the clients.
+func NewClient(params Parameters, self drlwe.ShamirPublicPoint, clients []drlwe.ShamirPublicPoint, seed []byte, serverKey *rlwe.PublicKey) (*Client, error) {
+
+	if err := checkParameters(params, clients); err != nil {
+		return nil, fmt.Errorf("cannot NewClient: %w", err)
+	}
+
+	if !contains(clients, self) {
+		return nil, fmt.Errorf("cannot NewClient: the client %d is not among the clients", self)
+	}
+
+	return &Client{
+		params:    params,
+		self:      self,
+		clients:   append([]drlwe.ShamirPublicPoint(nil), clients...),
+		seed:      append([]byte(nil), seed...),
+		serverKey: serverKey,
+		kgen:      ckks.NewKeyGenerator(params.Parameters),
+		ckg:       dckks.NewCKGProtocol(params.Parameters),
+		thr:       drlwe.NewThresholdizer(params.Parameters.Parameters),
+		cmb:       drlwe.NewCombiner(params.Parameters.Parameters, params.Threshold),
+		pcks:      dckks.NewPCKSProtocol(params.Parameters, params.SigmaSmudging),
+		encoder:   ckks.NewEncoder(params.Parameters),
+		round:     -1,
+	}, nil
+}
+
+// StartRound starts the key setup of the round, which must be larger than the previous rounds, and returns the
+// KeySetupMessage of the client. The secret key of the client for the round is erased once shared, and the threshold
+// secret key share of the previous round is erased.
+func (c *Client) StartRound(round int) (*KeySetupMessage, error) {
+
+	if round <= c.round {
+		return nil, fmt.Errorf("cannot StartRound: round %d is not after round %d", round, c.round)
+	}
+
+	c.round, c.share, c.key, c.released = round, nil, nil, nil
+
+	sk := c.kgen.GenSecretKey()
+
+	msg := &KeySetupMessage{
+		Round:        round,
+		Client:       c.self,
+		CKGShare:     c.ckg.AllocateShare(),
+		ShamirShares: make(map[drlwe.ShamirPublicPoint]*drlwe.ShamirSecretShare, len(c.clients)-1),
+	}
+
+	c.ckg.GenShare(sk, c.ckg.SampleCRP(roundCRS(c.seed, round)), msg.CKGShare)
+
+	poly, err := c.thr.GenShamirPolynomial(c.params.Threshold, sk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot StartRound: %w", err)
+	}
+
+	for _, p := range c.clients {
+		share := c.thr.AllocateThresholdSecretShare()
+		c.thr.GenShamirSecretShare(p, poly, share)
+		if p == c.self {
+			c.selfEval = share
+		} else {
+			msg.ShamirShares[p] = share
+		}
+	}
+
+	return msg, nil
+}
+
+// FinalizeRound finalizes the key setup of the current round from its RoundKey and the ShamirSecretShares received
+// from the other participants of the round, i.e. the ShamirShares[self] of their KeySetupMessages. It returns a
+// DropoutError if the share of a participant is missing, and an error if the client is not a participant of the round.
+func (c *Client) FinalizeRound(key *RoundKey, received map[drlwe.ShamirPublicPoint]*drlwe.ShamirSecretShare) error {
+
+	if c.selfEval == nil || key.Round != c.round {
+		return fmt.Errorf("cannot FinalizeRound: round %d is not started", key.Round)
+	}
+
+	if !contains(key.Participants, c.self) {
+		return fmt.Errorf("cannot FinalizeRound: the client %d is not a participant of round %d", c.self, key.Round)
+	}
+
+	missing := []drlwe.ShamirPublicPoint{}
+	for _, p := range key.Participants {
+		if _, ok := received[p]; !ok && p != c.self {
+			missing = append(missing, p)
+		}
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("cannot FinalizeRound: %w", &DropoutError{Missing: missing})
+	}
+
+	share := c.thr.AllocateThresholdSecretShare()
+	c.thr.AggregateShares(share, c.selfEval, share)
+	for _, p := range key.Participants {
+		if p != c.self {
+			c.thr.AggregateShares(share, received[p], share)
+		}
+	}
+
+	c.share, c.key, c.selfEval = share, key, nil
+
+	return nil
+}
+
+// EncryptUpdate encrypts the update of the client, of at most params.Slots() values, under the public key of the
+// current round. It returns an error if the round is not finalized or if the update is too large.
+func (c *Client) EncryptUpdate(values []float64) (*Update, error) {
+
+	if c.key == nil {
+		return nil, errors.New("cannot EncryptUpdate: the round is not finalized")
+	}
+
+	if len(values) > c.params.Slots() {
+		return nil, fmt.Errorf("cannot EncryptUpdate: %d values but only %d slots", len(values), c.params.Slots())
+	}
+
+	padded := make([]float64, c.params.Slots())
+	copy(padded, values)
+
+	pt := c.encoder.EncodeNew(padded, c.params.MaxLevel(), c.params.DefaultScale(), c.params.LogSlots())
+	ct := ckks.NewEncryptor(c.params.Parameters, c.key.PublicKey).EncryptNew(pt)
+
+	return &Update{Round: c.round, Client: c.self, Ciphertext: ct}, nil
+}
+
+// GenReleaseShare generates the ReleaseShare of the client for the request of the server. It returns an error if the
+// request is not for the aggregate of the current round, if the aggregate averages less than MinUpdates updates, if
+// the client is not active, or if another aggregate was already released in the round.
+func (c *Client) GenReleaseShare(req *ReleaseRequest) (*ReleaseShare, error) {
+
+	agg := req.Aggregate
+
+	if c.key == nil || agg.Round != c.round {
+		return nil, fmt.Errorf("cannot GenReleaseShare: round %d is not the current round", agg.Round)
+	}
+
+	if len(agg.Contributors) < c.params.MinUpdates {
+		return nil, fmt.Errorf("cannot GenReleaseShare: the aggregate averages %d updates but at least %d are required", len(agg.Contributors), c.params.MinUpdates)
+	}
+
+	if !contains(req.Actives, c.self) {
+		return nil, fmt.Errorf("cannot GenReleaseShare: the client %d is not active", c.self)
+	}
+
+	for _, p := range req.Actives {
+		if !contains(c.key.Participants, p) {
+			return nil, fmt.Errorf("cannot GenReleaseShare: the client %d is not a participant of round %d", p, c.round)
+		}
+	}
+
+	data, err := agg.Ciphertext.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenReleaseShare: %w", err)
+	}
+
+	// The release can be retried with other active clients, but only for the same aggregate.
+	digest := blake2b.Sum256(data)
+	if c.released != nil && !bytes.Equal(c.released, digest[:]) {
+		return nil, fmt.Errorf("cannot GenReleaseShare: another aggregate was released in round %d", c.round)
+	}
+
+	sk := rlwe.NewSecretKey(c.params.Parameters.Parameters)
+	if err = c.cmb.GenAdditiveShare(req.Actives, c.self, c.share, sk); err != nil {
+		return nil, fmt.Errorf("cannot GenReleaseShare: %w", err)
+	}
+
+	share := c.pcks.AllocateShare(agg.Ciphertext.Level())
+	c.pcks.GenShare(sk, c.serverKey, agg.Ciphertext.Value[1], share)
+
+	c.released = digest[:]
+
+	return &ReleaseShare{Round: c.round, Client: c.self, Share: share}, nil
+}
@@ -0,0 +1,148 @@
+// Package secureagg implements the secure aggregation of model updates for federated learning on top of the dckks
+// package. In each round, the clients generate a fresh collective public key, whose secret key is shared among them
+// with a T-out-of-N threshold secret sharing, and encrypt their model updates under it. The server homomorphically
+// averages the encrypted updates it receives, and any T clients collectively re-encrypt the encrypted average under the
+// public key of the server, which decrypts it: neither the server nor fewer than T clients learn anything on the
+// individual updates beyond the average. A round proceeds as follows:
+//
+//  1. Key setup: each client generates a KeySetupMessage with StartRound, from which the server generates the RoundKey
+//     with Setup, and the clients finalize their threshold secret key shares of the round with FinalizeRound.
+//  2. Updates: each client encrypts its update with EncryptUpdate, and the server averages the received updates with
+//     Aggregate.
+//  3. Release: the server requests a ReleaseShare from T clients with NewReleaseRequest, and decrypts the average
+//     with Release.
+//
+// The clients that drop out before the end of the key setup are excluded from the round, and the clients that drop out
+// before sending their update are excluded from the average. The release only requires T clients, and if one of them
+// drops out, the server can request the ReleaseShares from another set of T clients (see DropoutError).
+//
+// The keys are ratcheted at each round: the secret key of a client for a round is erased once it is shared, and the
+// threshold secret key share of the previous round is erased when the next round starts, so that the compromise of a
+// client does not expose the updates of the previous rounds. The clients release a single aggregate per round, which
+// must average at least MinUpdates updates.
+//
+// The server is assumed to be honest-but-curious. The messages are exchanged through the server, and the
+// ShamirSecretShares of the KeySetupMessages must be encrypted end-to-end from client to client, e.g. over TLS
+// channels or with the public keys of the clients.
+package secureagg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Parameters are the parameters of a secure aggregation.
+type Parameters struct {
+	ckks.Parameters
+	// Threshold is the number T of clients required to release an aggregate.
+	Threshold int
+	// MinUpdates is the minimum number of updates that an aggregate must average to be released.
+	MinUpdates int
+	// SigmaSmudging is the standard deviation of the smudging noise of the ReleaseShares, e.g. computed with
+	// drlwe.SmudgingParams.
+	SigmaSmudging float64
+}
+
+// KeySetupMessage is the message of a client in the key setup of a round: its CKG share of the round public key and
+// the evaluations at the public points of the other clients of the ShamirPolynomial of its secret key of the round.
+// ShamirShares[j] must be delivered confidentially to the client of public point j.
+type KeySetupMessage struct {
+	Round        int
+	Client       drlwe.ShamirPublicPoint
+	CKGShare     *drlwe.CKGShare
+	ShamirShares map[drlwe.ShamirPublicPoint]*drlwe.ShamirSecretShare
+}
+
+// RoundKey is the public key of a round, generated from the KeySetupMessages of its participants.
+type RoundKey struct {
+	Round        int
+	Participants []drlwe.ShamirPublicPoint
+	PublicKey    *rlwe.PublicKey
+}
+
+// Update is the model update of a client, encrypted under the public key of the round.
+type Update struct {
+	Round      int
+	Client     drlwe.ShamirPublicPoint
+	Ciphertext *ckks.Ciphertext
+}
+
+// Aggregate is the average of the updates of the contributors of a round, encrypted under the public key of the round.
+type Aggregate struct {
+	Round        int
+	Contributors []drlwe.ShamirPublicPoint
+	Ciphertext   *ckks.Ciphertext
+}
+
+// ReleaseRequest is the request of the server to the active clients to release an Aggregate.
+type ReleaseRequest struct {
+	Aggregate *Aggregate
+	Actives   []drlwe.ShamirPublicPoint
+}
+
+// ReleaseShare is the PCKS share of an active client for the re-encryption of an Aggregate under the public key of the
+// server.
+type ReleaseShare struct {
+	Round  int
+	Client drlwe.ShamirPublicPoint
+	Share  *drlwe.PCKSShare
+}
+
+// DropoutError is the error returned when the messages of some clients are missing, e.g. because they dropped out.
+// The step can be retried without them, e.g. with another set of active clients for a release.
+type DropoutError struct {
+	Missing []drlwe.ShamirPublicPoint
+}
+
+func (e *DropoutError) Error() string {
+	return fmt.Sprintf("missing messages of the clients %v", e.Missing)
+}
+
+// checkParameters checks the parameters and the public points of the clients.
+func checkParameters(params Parameters, clients []drlwe.ShamirPublicPoint) error {
+
+	if params.Threshold < 1 || params.Threshold > len(clients) {
+		return fmt.Errorf("invalid threshold %d for %d clients", params.Threshold, len(clients))
+	}
+
+	if params.MinUpdates < 1 {
+		return fmt.Errorf("invalid minimum number of updates %d", params.MinUpdates)
+	}
+
+	seen := make(map[drlwe.ShamirPublicPoint]bool, len(clients))
+	for _, p := range clients {
+		if p == 0 || seen[p] {
+			return errors.New("the public points of the clients must be non-zero and distinct")
+		}
+		seen[p] = true
+	}
+
+	return nil
+}
+
+// roundCRS returns the common reference string of the round, derived from the seed shared by the clients and the server.
+func roundCRS(seed []byte, round int) drlwe.CRS {
+	key := make([]byte, len(seed)+8)
+	copy(key, seed)
+	binary.LittleEndian.PutUint64(key[len(seed):], uint64(round))
+	prng, err := utils.NewKeyedPRNG(key)
+	if err != nil {
+		panic(err)
+	}
+	return prng
+}
+
+func contains(points []drlwe.ShamirPublicPoint, p drlwe.ShamirPublicPoint) bool {
+	for _, q := range points {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package secureagg
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureAggregation(t *testing.T) {
+
+	ckksParams, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+	require.NoError(t, err)
+
+	params := Parameters{Parameters: ckksParams, Threshold: 3, MinUpdates: 2, SigmaSmudging: 1 << 10}
+	points := []drlwe.ShamirPublicPoint{1, 2, 3, 4, 5}
+	seed := []byte("secureagg")
+
+	server, err := NewServer(params, points, seed)
+	require.NoError(t, err)
+
+	clients := map[drlwe.ShamirPublicPoint]*Client{}
+	for _, p := range points {
+		clients[p], err = NewClient(params, p, points, seed, server.PublicKey())
+		require.NoError(t, err)
+	}
+
+	// setup runs the key setup of the round among the participants
+	setup := func(round int, participants []drlwe.ShamirPublicPoint) *RoundKey {
+
+		msgs := []*KeySetupMessage{}
+		for _, p := range participants {
+			msg, err := clients[p].StartRound(round)
+			require.NoError(t, err)
+			msgs = append(msgs, msg)
+		}
+
+		key, err := server.Setup(round, msgs)
+		require.NoError(t, err)
+		require.Equal(t, participants, key.Participants)
+
+		for _, p := range participants {
+			received := map[drlwe.ShamirPublicPoint]*drlwe.ShamirSecretShare{}
+			for _, msg := range msgs {
+				if msg.Client != p {
+					received[msg.Client] = msg.ShamirShares[p]
+				}
+			}
+			require.NoError(t, clients[p].FinalizeRound(key, received))
+		}
+
+		return key
+	}
+
+	// release releases the aggregate with the active clients, of which the dropped ones do not respond
+	release := func(agg *Aggregate, actives []drlwe.ShamirPublicPoint, dropped map[drlwe.ShamirPublicPoint]bool) ([]float64, error) {
+		req, err := server.NewReleaseRequest(agg, actives)
+		require.NoError(t, err)
+		shares := []*ReleaseShare{}
+		for _, p := range actives {
+			if !dropped[p] {
+				share, err := clients[p].GenReleaseShare(req)
+				require.NoError(t, err)
+				shares = append(shares, share)
+			}
+		}
+		return server.Release(req, shares)
+	}
+
+	// The error of the released values is bounded by 8 standard deviations of its two main terms, at the scale of the
+	// parameters:
+	//  - the smudging noise of the Threshold release shares, whose real part has a standard deviation of
+	//    sqrt(Threshold*N/2)*SigmaSmudging on each slot;
+	//  - the rounding of the division by P of the Threshold release shares, of at most 1/2 on each coefficient, which
+	//    is multiplied by the ternary secret key of the server, whose real part has a standard deviation of sqrt(N/3)
+	//    on each slot, and which is up to Threshold/2*N on the slots of the roots closest to 1, e.g. the first one.
+	N := float64(params.N())
+	tolerance := 8 * (math.Sqrt(float64(params.Threshold)*N/2)*params.SigmaSmudging + float64(params.Threshold)/2*N*math.Sqrt(N/3)) / params.DefaultScale()
+
+	updates := map[drlwe.ShamirPublicPoint][]float64{}
+	for _, p := range points {
+		updates[p] = make([]float64, 100)
+		for i := range updates[p] {
+			updates[p][i] = 2*rand.Float64() - 1
+		}
+	}
+
+	t.Run("Round/Dropouts", func(t *testing.T) {
+
+		// The client 5 drops out during the key setup
+		key := setup(0, []drlwe.ShamirPublicPoint{1, 2, 3, 4})
+
+		_, err := clients[5].EncryptUpdate(updates[5])
+		require.Error(t, err)
+
+		// The client 4 drops out before sending its update
+		encrypted := []*Update{}
+		for _, p := range key.Participants[:3] {
+			u, err := clients[p].EncryptUpdate(updates[p])
+			require.NoError(t, err)
+			encrypted = append(encrypted, u)
+		}
+
+		_, err = server.Aggregate(0, append(encrypted, encrypted[0]))
+		require.Error(t, err)
+
+		agg, err := server.Aggregate(0, encrypted)
+		require.NoError(t, err)
+		require.Equal(t, []drlwe.ShamirPublicPoint{1, 2, 3}, agg.Contributors)
+
+		// The client 3 drops out during the release, which is retried with the client 4
+		_, err = release(agg, []drlwe.ShamirPublicPoint{1, 2, 3}, map[drlwe.ShamirPublicPoint]bool{3: true})
+		var dropout *DropoutError
+		require.True(t, errors.As(err, &dropout))
+		require.Equal(t, []drlwe.ShamirPublicPoint{3}, dropout.Missing)
+
+		average, err := release(agg, []drlwe.ShamirPublicPoint{1, 2, 4}, nil)
+		require.NoError(t, err)
+
+		for i := range updates[1] {
+			want := (updates[1][i] + updates[2][i] + updates[3][i]) / 3
+			require.InDelta(t, want, average[i], tolerance)
+		}
+		for i := len(updates[1]); i < len(average); i++ {
+			require.InDelta(t, 0, average[i], tolerance)
+		}
+
+		// Only the released aggregate can be released again in the round
+		other, err := server.Aggregate(0, encrypted[:2])
+		require.NoError(t, err)
+		req, err := server.NewReleaseRequest(other, []drlwe.ShamirPublicPoint{1, 2, 4})
+		require.NoError(t, err)
+		_, err = clients[1].GenReleaseShare(req)
+		require.Error(t, err)
+
+		// The aggregates of less than MinUpdates updates are not released
+		single, err := server.Aggregate(0, encrypted[2:])
+		require.NoError(t, err)
+		req, err = server.NewReleaseRequest(single, []drlwe.ShamirPublicPoint{2, 3, 4})
+		require.NoError(t, err)
+		_, err = clients[3].GenReleaseShare(req)
+		require.Error(t, err)
+
+		// The release requires Threshold participants
+		_, err = server.NewReleaseRequest(agg, []drlwe.ShamirPublicPoint{1, 2})
+		require.Error(t, err)
+		_, err = server.NewReleaseRequest(agg, []drlwe.ShamirPublicPoint{1, 2, 5})
+		require.Error(t, err)
+	})
+
+	t.Run("Round/Ratchet", func(t *testing.T) {
+
+		_, err := server.Aggregate(0, nil)
+		require.Error(t, err)
+
+		key := setup(1, points)
+		require.False(t, key.PublicKey.Equals(server.keys[0].PublicKey))
+
+		_, err = clients[1].StartRound(1)
+		require.Error(t, err)
+
+		encrypted := []*Update{}
+		for _, p := range points {
+			u, err := clients[p].EncryptUpdate(updates[p])
+			require.NoError(t, err)
+			encrypted = append(encrypted, u)
+		}
+
+		agg, err := server.Aggregate(1, encrypted)
+		require.NoError(t, err)
+
+		average, err := release(agg, []drlwe.ShamirPublicPoint{2, 4, 5}, nil)
+		require.NoError(t, err)
+
+		for i := range updates[1] {
+			var want float64
+			for _, p := range points {
+				want += updates[p][i]
+			}
+			require.InDelta(t, want/float64(len(points)), average[i], tolerance)
+		}
+
+		// The clients no longer release the aggregates of the previous rounds
+		agg.Round = 0
+		_, err = clients[2].GenReleaseShare(&ReleaseRequest{Aggregate: agg, Actives: []drlwe.ShamirPublicPoint{2, 4, 5}})
+		require.Error(t, err)
+
+		// Not enough participants
+		_, err = clients[1].StartRound(2)
+		require.NoError(t, err)
+		_, err = server.Setup(2, nil)
+		var dropout *DropoutError
+		require.True(t, errors.As(err, &dropout))
+		require.Len(t, dropout.Missing, len(points))
+	})
+
+	t.Run("Parameters", func(t *testing.T) {
+		_, err := NewServer(Parameters{Parameters: ckksParams, Threshold: 6, MinUpdates: 1}, points, seed)
+		require.Error(t, err)
+		_, err = NewServer(Parameters{Parameters: ckksParams, Threshold: 3, MinUpdates: 0}, points, seed)
+		require.Error(t, err)
+		_, err = NewClient(params, 6, points, seed, server.PublicKey())
+		require.Error(t, err)
+		_, err = NewClient(params, 1, []drlwe.ShamirPublicPoint{1, 1, 2}, seed, server.PublicKey())
+		require.Error(t, err)
+	})
+
+}
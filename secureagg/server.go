@@ -0,0 +1,211 @@
+package secureagg
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/dckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Server is the server of a secure aggregation, which relays the messages of the clients, averages their updates and
+// decrypts the released aggregates with its secret key.
+type Server struct {
+	params  Parameters
+	clients []drlwe.ShamirPublicPoint
+	seed    []byte
+
+	sk *rlwe.SecretKey
+	pk *rlwe.PublicKey
+
+	ckg     *dckks.CKGProtocol
+	pcks    *dckks.PCKSProtocol
+	eval    ckks.Evaluator
+	encoder ckks.Encoder
+
+	keys map[int]*RoundKey
+}
+
+// NewServer creates a new Server for the clients of public points clients, with a new key pair. The seed of the common
+// reference strings of the rounds is shared by the clients and the server. It returns an error if the parameters are
+// invalid.
+func NewServer(params Parameters, clients []drlwe.ShamirPublicPoint, seed []byte) (*Server, error) {
+
+	if err := checkParameters(params, clients); err != nil {
+		return nil, fmt.Errorf("cannot NewServer: %w", err)
+	}
+
+	sk, pk := ckks.NewKeyGenerator(params.Parameters).GenKeyPair()
+
+	return &Server{
+		params:  params,
+		clients: append([]drlwe.ShamirPublicPoint(nil), clients...),
+		seed:    append([]byte(nil), seed...),
+		sk:      sk,
+		pk:      pk,
+		ckg:     dckks.NewCKGProtocol(params.Parameters),
+		pcks:    dckks.NewPCKSProtocol(params.Parameters, params.SigmaSmudging),
+		eval:    ckks.NewEvaluator(params.Parameters, rlwe.EvaluationKey{}),
+		encoder: ckks.NewEncoder(params.Parameters),
+		keys:    map[int]*RoundKey{},
+	}, nil
+}
+
+// PublicKey returns the public key of the server, under which the aggregates are released.
+func (s *Server) PublicKey() *rlwe.PublicKey {
+	return s.pk
+}
+
+// Setup generates the RoundKey of the round from the KeySetupMessages of its participants, i.e. of the clients whose
+// message was received. It returns a DropoutError if less than Threshold clients participate, and an error if a message
+// is not from a client, is duplicated or is not for the round.
+func (s *Server) Setup(round int, msgs []*KeySetupMessage) (*RoundKey, error) {
+
+	key := &RoundKey{Round: round, Participants: []drlwe.ShamirPublicPoint{}, PublicKey: rlwe.NewPublicKey(s.params.Parameters.Parameters)}
+
+	crp := s.ckg.SampleCRP(roundCRS(s.seed, round))
+	combined := s.ckg.AllocateShare()
+
+	for _, msg := range msgs {
+
+		if msg.Round != round {
+			return nil, fmt.Errorf("cannot Setup: message of the client %d for round %d", msg.Client, msg.Round)
+		}
+
+		if !contains(s.clients, msg.Client) || contains(key.Participants, msg.Client) {
+			return nil, fmt.Errorf("cannot Setup: unknown or duplicated message of the client %d", msg.Client)
+		}
+
+		s.ckg.AggregateShare(combined, msg.CKGShare, combined)
+		key.Participants = append(key.Participants, msg.Client)
+	}
+
+	if len(key.Participants) < s.params.Threshold {
+		return nil, fmt.Errorf("cannot Setup: %w", &DropoutError{Missing: s.missing(key.Participants)})
+	}
+
+	s.ckg.GenPublicKey(combined, crp, key.PublicKey)
+	s.keys[round] = key
+
+	return key, nil
+}
+
+// Aggregate averages the updates received in the round. It returns an error if the round is not set up, if there is
+// no update, or if an update is not from a participant of the round, is duplicated or is not for the round.
+func (s *Server) Aggregate(round int, updates []*Update) (*Aggregate, error) {
+
+	key, ok := s.keys[round]
+	if !ok {
+		return nil, fmt.Errorf("cannot Aggregate: round %d is not set up", round)
+	}
+
+	if len(updates) == 0 {
+		return nil, errors.New("cannot Aggregate: no update")
+	}
+
+	agg := &Aggregate{Round: round, Contributors: []drlwe.ShamirPublicPoint{}}
+
+	for _, u := range updates {
+
+		if u.Round != round {
+			return nil, fmt.Errorf("cannot Aggregate: update of the client %d for round %d", u.Client, u.Round)
+		}
+
+		if !contains(key.Participants, u.Client) || contains(agg.Contributors, u.Client) {
+			return nil, fmt.Errorf("cannot Aggregate: unknown or duplicated update of the client %d", u.Client)
+		}
+
+		if agg.Ciphertext == nil {
+			agg.Ciphertext = u.Ciphertext.CopyNew()
+		} else {
+			s.eval.Add(agg.Ciphertext, u.Ciphertext, agg.Ciphertext)
+		}
+
+		agg.Contributors = append(agg.Contributors, u.Client)
+	}
+
+	s.eval.MultByConst(agg.Ciphertext, 1/float64(len(updates)), agg.Ciphertext)
+	if err := s.eval.Rescale(agg.Ciphertext, s.params.DefaultScale(), agg.Ciphertext); err != nil {
+		return nil, fmt.Errorf("cannot Aggregate: %w", err)
+	}
+
+	return agg, nil
+}
+
+// NewReleaseRequest returns the request of the release of the aggregate by the active clients, which must be at least
+// Threshold participants of the round.
+func (s *Server) NewReleaseRequest(agg *Aggregate, actives []drlwe.ShamirPublicPoint) (*ReleaseRequest, error) {
+
+	key, ok := s.keys[agg.Round]
+	if !ok {
+		return nil, fmt.Errorf("cannot NewReleaseRequest: round %d is not set up", agg.Round)
+	}
+
+	if len(actives) < s.params.Threshold {
+		return nil, fmt.Errorf("cannot NewReleaseRequest: %d active clients but the threshold is %d", len(actives), s.params.Threshold)
+	}
+
+	for _, p := range actives {
+		if !contains(key.Participants, p) {
+			return nil, fmt.Errorf("cannot NewReleaseRequest: the client %d is not a participant of round %d", p, agg.Round)
+		}
+	}
+
+	return &ReleaseRequest{Aggregate: agg, Actives: append([]drlwe.ShamirPublicPoint(nil), actives...)}, nil
+}
+
+// Release decrypts the aggregate of the request from the ReleaseShares of the active clients and returns the average
+// of the updates, of params.Slots() values. It returns a DropoutError if the share of an active client is missing, in
+// which case the release can be requested again from other active clients.
+func (s *Server) Release(req *ReleaseRequest, shares []*ReleaseShare) ([]float64, error) {
+
+	agg := req.Aggregate
+
+	received := map[drlwe.ShamirPublicPoint]*ReleaseShare{}
+	for _, share := range shares {
+		if share.Round == agg.Round && contains(req.Actives, share.Client) {
+			received[share.Client] = share
+		}
+	}
+
+	missing := []drlwe.ShamirPublicPoint{}
+	for _, p := range req.Actives {
+		if _, ok := received[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+
+	if len(missing) != 0 {
+		return nil, fmt.Errorf("cannot Release: %w", &DropoutError{Missing: missing})
+	}
+
+	combined := s.pcks.AllocateShare(agg.Ciphertext.Level())
+	for _, share := range received {
+		s.pcks.AggregateShare(combined, share.Share, combined)
+	}
+
+	ct := ckks.NewCiphertext(s.params.Parameters, 1, agg.Ciphertext.Level(), agg.Ciphertext.Scale)
+	s.pcks.KeySwitch(agg.Ciphertext, combined, ct)
+
+	pt := ckks.NewDecryptor(s.params.Parameters, s.sk).DecryptNew(ct)
+
+	values := s.encoder.Decode(pt, s.params.LogSlots())
+	average := make([]float64, len(values))
+	for i, v := range values {
+		average[i] = real(v)
+	}
+
+	return average, nil
+}
+
+// missing returns the clients that are not among the participants.
+func (s *Server) missing(participants []drlwe.ShamirPublicPoint) (missing []drlwe.ShamirPublicPoint) {
+	for _, p := range s.clients {
+		if !contains(participants, p) {
+			missing = append(missing, p)
+		}
+	}
+	return
+}
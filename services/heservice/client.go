@@ -0,0 +1,205 @@
+package heservice
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Client is the reference client of the service, which calls the RPCs of a Server over HTTP. The parties generate
+// their keys, ciphertexts and shares with the library, and exchange them with the server through the Client.
+type Client struct {
+	// URL is the base URL of the server, e.g. "https://heservice.example.com".
+	URL string
+	// HTTPClient is the HTTP client of the calls, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// NewClient creates a new Client of the server at the given base URL.
+func NewClient(url string) *Client {
+	return &Client{URL: strings.TrimSuffix(url, "/")}
+}
+
+// CreateSession creates a session of the scheme "bfv" or "ckks" with the given parameters (bfv.Parameters or
+// ckks.Parameters) and number of parties, and returns its identifier.
+func (c *Client) CreateSession(ctx context.Context, scheme string, params json.Marshaler, parties int) (session string, err error) {
+
+	req := CreateSessionRequest{Scheme: scheme, Parties: parties}
+	if req.Params, err = params.MarshalJSON(); err != nil {
+		return "", fmt.Errorf("cannot CreateSession: %w", err)
+	}
+
+	var res CreateSessionResponse
+	if err = c.callJSON(ctx, "CreateSession", req, &res); err != nil {
+		return "", err
+	}
+	return res.Session, nil
+}
+
+// UploadRelinearizationKey uploads the relinearization key of the session.
+func (c *Client) UploadRelinearizationKey(ctx context.Context, session string, rlk *rlwe.RelinearizationKey) error {
+	return c.upload(ctx, "UploadRelinearizationKey", objectHeader(session, ""), rlk)
+}
+
+// UploadRotationKeys uploads the rotation keys of the session, which are added to its previous rotation keys. The keys
+// are marshaled and streamed one switching key at a time.
+func (c *Client) UploadRotationKeys(ctx context.Context, session string, rtks *rlwe.RotationKeySet) error {
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(WriteRotationKeys(pw, rtks))
+	}()
+	defer pr.Close()
+
+	_, err := c.call(ctx, "UploadRotationKeys", objectHeader(session, ""), pr)
+	return err
+}
+
+// UploadCiphertext uploads the ciphertext (a *bfv.Ciphertext or a *ckks.Ciphertext) under the given name of the
+// session.
+func (c *Client) UploadCiphertext(ctx context.Context, session, name string, ct encoding.BinaryMarshaler) error {
+	return c.upload(ctx, "UploadCiphertext", objectHeader(session, name), ct)
+}
+
+// Evaluate evaluates the named circuit on the input ciphertexts of the session and stores the result under the output
+// name.
+func (c *Client) Evaluate(ctx context.Context, session, circuit string, inputs []string, output string) error {
+	return c.callJSON(ctx, "Evaluate", EvaluateRequest{Session: session, Circuit: circuit, Inputs: inputs, Output: output}, nil)
+}
+
+// DownloadCiphertext downloads the named ciphertext of the session into ct (a *bfv.Ciphertext or a *ckks.Ciphertext).
+func (c *Client) DownloadCiphertext(ctx context.Context, session, name string, ct encoding.BinaryUnmarshaler) error {
+	return c.download(ctx, "DownloadCiphertext", session, name, ct)
+}
+
+// SubmitDecryptionShare submits the CKS share of the party, from its secret key to the zero key, for the collective
+// decryption of the named ciphertext of the session. The parties are identified by 1 to the number of parties of the
+// session, and each party submits a single share.
+func (c *Client) SubmitDecryptionShare(ctx context.Context, session, name string, party int, share *drlwe.CKSShare) error {
+	header := objectHeader(session, name)
+	header.Set(PartyHeader, strconv.Itoa(party))
+	return c.upload(ctx, "SubmitDecryptionShare", header, share)
+}
+
+// CollectiveDecrypt waits until all the parties of the session submitted their shares for the named ciphertext, and
+// downloads into ct the ciphertext switched to the zero key, which is decrypted with the zero secret key.
+func (c *Client) CollectiveDecrypt(ctx context.Context, session, name string, ct encoding.BinaryUnmarshaler) error {
+	return c.download(ctx, "CollectiveDecrypt", session, name, ct)
+}
+
+// upload calls the RPC streaming the marshaled object, with the given headers.
+func (c *Client) upload(ctx context.Context, method string, header http.Header, object encoding.BinaryMarshaler) error {
+	data, err := object.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("cannot %s: %w", method, err)
+	}
+	_, err = c.call(ctx, method, header, bytes.NewReader(data))
+	return err
+}
+
+// download calls the RPC returning the marshaled object.
+func (c *Client) download(ctx context.Context, method, session, name string, object encoding.BinaryUnmarshaler) error {
+
+	req, err := json.Marshal(ObjectRequest{Session: session, Name: name})
+	if err != nil {
+		return fmt.Errorf("cannot %s: %w", method, err)
+	}
+
+	data, err := c.call(ctx, method, jsonHeader(), bytes.NewReader(req))
+	if err != nil {
+		return err
+	}
+
+	if err = object.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("cannot %s: %w", method, err)
+	}
+	return nil
+}
+
+// callJSON calls the RPC with the request and response encoded in JSON.
+func (c *Client) callJSON(ctx context.Context, method string, req, res interface{}) error {
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("cannot %s: %w", method, err)
+	}
+
+	if data, err = c.call(ctx, method, jsonHeader(), bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	if res != nil {
+		if err = json.Unmarshal(data, res); err != nil {
+			return fmt.Errorf("cannot %s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// objectHeader returns the headers of a request streaming the object of the given name of the session, which are not
+// set if empty.
+func objectHeader(session, name string) http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	if session != "" {
+		header.Set(SessionHeader, session)
+	}
+	if name != "" {
+		header.Set(NameHeader, name)
+	}
+	return header
+}
+
+// jsonHeader returns the headers of a request encoded in JSON.
+func jsonHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	return header
+}
+
+// call calls the RPC with the given headers and returns the body of the response. The errors of the server are
+// returned as errors, which wrap ErrNotFound for the unknown sessions, ciphertexts and circuits.
+func (c *Client) call(ctx context.Context, method string, header http.Header, body io.Reader) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodPost, c.URL+ServicePath+method, body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot %s: %w", method, err)
+	}
+	req = req.WithContext(ctx)
+	req.Header = header
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot %s: %w", method, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return data, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("cannot %s: %w (%s)", method, ErrNotFound, strings.TrimSpace(string(data)))
+	default:
+		return nil, fmt.Errorf("cannot %s: %s", method, strings.TrimSpace(string(data)))
+	}
+}
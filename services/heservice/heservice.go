@@ -0,0 +1,211 @@
+// Package heservice is the reference homomorphic evaluation service of the library. The parties of a session upload
+// their ciphertexts, encrypted under the collective public key, and the evaluation keys, the server evaluates named
+// circuits on the ciphertexts, and the parties decrypt the results collectively by submitting their shares of the CKS
+// protocol of the drlwe package, which the server aggregates.
+//
+// The service is specified by the gRPC definition heservice.proto. The module does not depend on gRPC, hence the Server
+// and the Client of this package implement it over HTTP with the standard library: each RPC is a POST request on the
+// path of its gRPC method, e.g. /heservice.HEService/Evaluate, its small messages are encoded in JSON, and the keys,
+// ciphertexts and shares are streamed in the bodies of the requests and responses, with the session and the name of
+// the object in the Lattigo-Session and Lattigo-Name headers, and the party submitting a decryption share in the
+// Lattigo-Party header. The rotation keys are streamed one switching key at a
+// time, so that neither the client nor the server holds the whole marshaled set in memory. The methods of the Server
+// are those of the service, so that a gRPC transport generated from heservice.proto can call them as well.
+//
+// The parties of a session are identified by 1 to its number of parties, and the server aggregates a single decryption
+// share of each party. The parties are not authenticated by the service, hence the server must be deployed behind an
+// authenticating proxy, e.g. with mutual TLS, which checks that the Lattigo-Party header of a request is the identifier
+// of the authenticated party. The objects are exchanged in the binary serialization of the library.
+package heservice
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// ServicePath is the path prefix of the RPCs of the service, followed by the name of the method.
+const ServicePath = "/heservice.HEService/"
+
+// The headers of the requests and responses that stream an object.
+const (
+	SessionHeader = "Lattigo-Session"
+	NameHeader    = "Lattigo-Name"
+	PartyHeader   = "Lattigo-Party"
+)
+
+// ErrNotFound is the error returned for an unknown session, ciphertext or circuit.
+var ErrNotFound = errors.New("not found")
+
+// CreateSessionRequest is the request of the CreateSession RPC.
+type CreateSessionRequest struct {
+	Scheme  string          `json:"scheme"`
+	Params  json.RawMessage `json:"params"`
+	Parties int             `json:"parties"`
+}
+
+// CreateSessionResponse is the response of the CreateSession RPC.
+type CreateSessionResponse struct {
+	Session string `json:"session"`
+}
+
+// EvaluateRequest is the request of the Evaluate RPC.
+type EvaluateRequest struct {
+	Session string   `json:"session"`
+	Circuit string   `json:"circuit"`
+	Inputs  []string `json:"inputs"`
+	Output  string   `json:"output"`
+}
+
+// ObjectRequest is the request of the DownloadCiphertext and CollectiveDecrypt RPCs.
+type ObjectRequest struct {
+	Session string `json:"session"`
+	Name    string `json:"name"`
+}
+
+// Circuit is a named circuit evaluated by the server. Its functions are called with the evaluator of the session,
+// which has the evaluation keys uploaded in the session, and the input ciphertexts, which they can modify.
+type Circuit struct {
+	// Inputs is the number of inputs of the circuit, or 0 for any positive number of inputs.
+	Inputs int
+	// BFV evaluates the circuit for the BFV scheme, nil if the circuit does not support it.
+	BFV func(params bfv.Parameters, eval bfv.Evaluator, inputs []*bfv.Ciphertext) (*bfv.Ciphertext, error)
+	// CKKS evaluates the circuit for the CKKS scheme, nil if the circuit does not support it.
+	CKKS func(params ckks.Parameters, eval ckks.Evaluator, inputs []*ckks.Ciphertext) (*ckks.Ciphertext, error)
+}
+
+// DefaultCircuits are the circuits registered by NewServer: "sum" adds its inputs, and "mul" multiplies its two inputs
+// and relinearizes the product, which it rescales for the CKKS scheme.
+var DefaultCircuits = map[string]Circuit{
+	"sum": {
+		BFV: func(params bfv.Parameters, eval bfv.Evaluator, inputs []*bfv.Ciphertext) (*bfv.Ciphertext, error) {
+			for _, ct := range inputs[1:] {
+				eval.Add(inputs[0], ct, inputs[0])
+			}
+			return inputs[0], nil
+		},
+		CKKS: func(params ckks.Parameters, eval ckks.Evaluator, inputs []*ckks.Ciphertext) (*ckks.Ciphertext, error) {
+			for _, ct := range inputs[1:] {
+				eval.Add(inputs[0], ct, inputs[0])
+			}
+			return inputs[0], nil
+		},
+	},
+	"mul": {
+		Inputs: 2,
+		BFV: func(params bfv.Parameters, eval bfv.Evaluator, inputs []*bfv.Ciphertext) (*bfv.Ciphertext, error) {
+			return eval.RelinearizeNew(eval.MulNew(inputs[0], inputs[1])), nil
+		},
+		CKKS: func(params ckks.Parameters, eval ckks.Evaluator, inputs []*ckks.Ciphertext) (*ckks.Ciphertext, error) {
+			ct := eval.MulRelinNew(inputs[0], inputs[1])
+			return ct, eval.Rescale(ct, params.DefaultScale(), ct)
+		},
+	},
+}
+
+// parameters are the parameters of the scheme "bfv" or "ckks" of a session.
+type parameters struct {
+	scheme string
+	rlwe   rlwe.Parameters
+	bfv    bfv.Parameters
+	ckks   ckks.Parameters
+}
+
+// newParameters returns the parameters of the scheme "bfv" or "ckks" marshaled in JSON.
+func newParameters(scheme string, paramsJSON []byte) (params *parameters, err error) {
+
+	params = &parameters{scheme: scheme}
+
+	switch scheme {
+	case "bfv":
+		if err = json.Unmarshal(paramsJSON, &params.bfv); err != nil {
+			return nil, err
+		}
+		params.rlwe = params.bfv.Parameters
+	case "ckks":
+		if err = json.Unmarshal(paramsJSON, &params.ckks); err != nil {
+			return nil, err
+		}
+		params.rlwe = params.ckks.Parameters
+	default:
+		return nil, fmt.Errorf("unknown scheme %q", scheme)
+	}
+
+	return params, nil
+}
+
+// WriteRotationKeys writes the rotation keys on w in the framing of the UploadRotationKeys RPC: each switching key is
+// written as its Galois element and the length of its binary serialization (both big-endian uint64) followed by its
+// binary serialization, in the increasing order of the Galois elements.
+func WriteRotationKeys(w io.Writer, rtks *rlwe.RotationKeySet) (err error) {
+
+	galEls := make([]uint64, 0, len(rtks.Keys))
+	for galEl := range rtks.Keys {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	for _, galEl := range galEls {
+
+		var data []byte
+		if data, err = rtks.Keys[galEl].MarshalBinary(); err != nil {
+			return fmt.Errorf("cannot WriteRotationKeys: %w", err)
+		}
+
+		var header [16]byte
+		binary.BigEndian.PutUint64(header[:8], galEl)
+		binary.BigEndian.PutUint64(header[8:], uint64(len(data)))
+		if _, err = w.Write(header[:]); err != nil {
+			return fmt.Errorf("cannot WriteRotationKeys: %w", err)
+		}
+		if _, err = w.Write(data); err != nil {
+			return fmt.Errorf("cannot WriteRotationKeys: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ReadRotationKeys reads the rotation keys written by WriteRotationKeys from r into rtks, whose switching keys of the
+// same Galois elements are replaced. It returns an error if a switching key is larger than maxSize bytes.
+func ReadRotationKeys(r io.Reader, maxSize int64, rtks *rlwe.RotationKeySet) (err error) {
+
+	if rtks.Keys == nil {
+		rtks.Keys = make(map[uint64]*rlwe.SwitchingKey)
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		var header [16]byte
+		if _, err = io.ReadFull(br, header[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("cannot ReadRotationKeys: %w", err)
+		}
+
+		galEl := binary.BigEndian.Uint64(header[:8])
+		size := binary.BigEndian.Uint64(header[8:])
+		if size > uint64(maxSize) {
+			return fmt.Errorf("cannot ReadRotationKeys: switching key of %d bytes is larger than %d bytes", size, maxSize)
+		}
+
+		data := make([]byte, size)
+		if _, err = io.ReadFull(br, data); err != nil {
+			return fmt.Errorf("cannot ReadRotationKeys: %w", err)
+		}
+
+		swk := new(rlwe.SwitchingKey)
+		if err = swk.UnmarshalBinary(data); err != nil {
+			return fmt.Errorf("cannot ReadRotationKeys: %w", err)
+		}
+		rtks.Keys[galEl] = swk
+	}
+}
@@ -0,0 +1,219 @@
+package heservice
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/services/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+
+	registry := metrics.NewRegistry()
+	server := NewServer()
+	server.Metrics = registry
+	server.RegisterCircuit("rotate", Circuit{
+		Inputs: 1,
+		BFV: func(params bfv.Parameters, eval bfv.Evaluator, inputs []*bfv.Ciphertext) (*bfv.Ciphertext, error) {
+			return eval.RotateColumnsNew(inputs[0], 1), nil
+		},
+		CKKS: func(params ckks.Parameters, eval ckks.Evaluator, inputs []*ckks.Ciphertext) (*ckks.Ciphertext, error) {
+			return eval.RotateNew(inputs[0], 1), nil
+		},
+	})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := NewClient(ts.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	paramsBFV, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	require.NoError(t, err)
+	paramsCKKS, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		scheme string
+		params json.Marshaler
+		rlwe   rlwe.Parameters
+		delta  float64
+	}{
+		{"bfv", paramsBFV, paramsBFV.Parameters, 0},
+		{"ckks", paramsCKKS, paramsCKKS.Parameters, 1e-2},
+	} {
+		t.Run(tc.scheme, func(t *testing.T) {
+
+			params := tc.rlwe
+			parties := 3
+
+			session, err := client.CreateSession(ctx, tc.scheme, tc.params, parties)
+			require.NoError(t, err)
+
+			// The collective keys of the ideal secret key, i.e. the sum of the secret keys of the parties
+			kgen := rlwe.NewKeyGenerator(params)
+			sks := make([]*rlwe.SecretKey, parties)
+			skIdeal := rlwe.NewSecretKey(params)
+			for i := range sks {
+				sks[i] = kgen.GenSecretKey()
+				params.RingQP().AddLvl(params.QCount()-1, params.PCount()-1, skIdeal.Value, sks[i].Value, skIdeal.Value)
+			}
+			pk := kgen.GenPublicKey(skIdeal)
+			require.NoError(t, client.UploadRelinearizationKey(ctx, session, kgen.GenRelinearizationKey(skIdeal, 1)))
+			require.NoError(t, client.UploadRotationKeys(ctx, session, kgen.GenRotationKeysForRotations([]int{1}, false, skIdeal)))
+
+			values0 := []float64{1, 2, 3, 4}
+			values1 := []float64{5, 6, 7, 8}
+			want := []float64{2 * 6, 3 * 7, 4 * 8}
+
+			var encrypt func(values []float64) interface{}
+			var newCiphertext func() interface{}
+			var decrypt func(ct interface{}) []float64
+			switch tc.scheme {
+			case "bfv":
+				encoder := bfv.NewEncoder(paramsBFV)
+				encrypt = func(values []float64) interface{} {
+					coeffs := make([]int64, len(values))
+					for i := range values {
+						coeffs[i] = int64(values[i])
+					}
+					pt := bfv.NewPlaintext(paramsBFV)
+					encoder.EncodeInt(coeffs, pt)
+					return bfv.NewEncryptor(paramsBFV, pk).EncryptNew(pt)
+				}
+				newCiphertext = func() interface{} { return new(bfv.Ciphertext) }
+				decrypt = func(ct interface{}) []float64 {
+					coeffs := encoder.DecodeIntNew(bfv.NewDecryptor(paramsBFV, rlwe.NewSecretKey(params)).DecryptNew(ct.(*bfv.Ciphertext)))
+					values := make([]float64, len(coeffs))
+					for i := range coeffs {
+						values[i] = float64(coeffs[i])
+					}
+					return values
+				}
+			default:
+				encoder := ckks.NewEncoder(paramsCKKS)
+				encrypt = func(values []float64) interface{} {
+					pt := encoder.EncodeNew(values, paramsCKKS.MaxLevel(), paramsCKKS.DefaultScale(), paramsCKKS.LogSlots())
+					return ckks.NewEncryptor(paramsCKKS, pk).EncryptNew(pt)
+				}
+				newCiphertext = func() interface{} { return new(ckks.Ciphertext) }
+				decrypt = func(ct interface{}) []float64 {
+					slots := encoder.Decode(ckks.NewDecryptor(paramsCKKS, rlwe.NewSecretKey(params)).DecryptNew(ct.(*ckks.Ciphertext)), paramsCKKS.LogSlots())
+					values := make([]float64, len(slots))
+					for i := range slots {
+						values[i] = real(slots[i])
+					}
+					return values
+				}
+			}
+
+			require.NoError(t, client.UploadCiphertext(ctx, session, "x", encrypt(values0).(encoding.BinaryMarshaler)))
+			require.NoError(t, client.UploadCiphertext(ctx, session, "y", encrypt(values1).(encoding.BinaryMarshaler)))
+			require.NoError(t, client.Evaluate(ctx, session, "mul", []string{"x", "y"}, "xy"))
+			require.NoError(t, client.Evaluate(ctx, session, "rotate", []string{"xy"}, "result"))
+
+			// The collective decryption is awaited before the parties submit their shares
+			var wg sync.WaitGroup
+			result := newCiphertext()
+			var errDecrypt error
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errDecrypt = client.CollectiveDecrypt(ctx, session, "result", result.(encoding.BinaryUnmarshaler))
+			}()
+
+			cks := drlwe.NewCKSProtocol(params, 3.2)
+			for i := range sks {
+				ct := newCiphertext()
+				require.NoError(t, client.DownloadCiphertext(ctx, session, "result", ct.(encoding.BinaryUnmarshaler)))
+				ctRLWE := rlweCiphertext(ct)
+				share := cks.AllocateShare(ctRLWE.Level())
+				cks.GenShare(sks[i], rlwe.NewSecretKey(params), ctRLWE.Value[1], share)
+				require.NoError(t, client.SubmitDecryptionShare(ctx, session, "result", i+1, share))
+
+				// A party cannot submit its share twice, e.g. to complete the decryption alone, and the unknown
+				// parties cannot submit shares
+				if i == 0 {
+					require.Error(t, client.SubmitDecryptionShare(ctx, session, "result", i+1, share))
+					require.Error(t, client.SubmitDecryptionShare(ctx, session, "result", 0, share))
+					require.Error(t, client.SubmitDecryptionShare(ctx, session, "result", parties+1, share))
+				}
+			}
+
+			wg.Wait()
+			require.NoError(t, errDecrypt)
+			have := decrypt(result)
+			for i := range want {
+				require.InDelta(t, want[i], have[i], tc.delta)
+			}
+
+			// The decryption is complete
+			ct := newCiphertext()
+			require.NoError(t, client.DownloadCiphertext(ctx, session, "result", ct.(encoding.BinaryUnmarshaler)))
+			share := cks.AllocateShare(rlweCiphertext(ct).Level())
+			require.Error(t, client.SubmitDecryptionShare(ctx, session, "result", 1, share))
+		})
+	}
+
+	t.Run("Metrics", func(t *testing.T) {
+		var out strings.Builder
+		_, err := registry.WriteTo(&out)
+		require.NoError(t, err)
+		require.Contains(t, out.String(), `lattigo_operations_total{component="bfv.Evaluator",operation="RotateColumnsNew"} 1`)
+		require.Contains(t, out.String(), `lattigo_noise_budget_bits_count{component="ckks.Evaluator",operation="RotateNew"} 1`)
+		require.Contains(t, out.String(), `lattigo_operations_total{component="drlwe.CKSProtocol",operation="AggregateShare"}`)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+
+		err := client.Evaluate(ctx, "unknown", "sum", []string{"x"}, "y")
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		session, err := client.CreateSession(ctx, "bfv", paramsBFV, 1)
+		require.NoError(t, err)
+
+		err = client.Evaluate(ctx, session, "unknown", []string{"x"}, "y")
+		require.True(t, errors.Is(err, ErrNotFound))
+		err = client.Evaluate(ctx, session, "sum", []string{"x"}, "y")
+		require.True(t, errors.Is(err, ErrNotFound))
+		err = client.Evaluate(ctx, session, "mul", []string{"x"}, "y")
+		require.Error(t, err)
+
+		_, err = client.CreateSession(ctx, "bgv", paramsBFV, 1)
+		require.Error(t, err)
+
+		// The evaluator of the session has no relinearization key
+		kgen := bfv.NewKeyGenerator(paramsBFV)
+		_, pk := kgen.GenKeyPair()
+		ct := bfv.NewEncryptor(paramsBFV, pk).EncryptNew(bfv.NewPlaintext(paramsBFV))
+		require.NoError(t, client.UploadCiphertext(ctx, session, "x", ct))
+		err = client.Evaluate(ctx, session, "mul", []string{"x", "x"}, "y")
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrNotFound))
+
+		ctxCancel, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		require.Error(t, client.CollectiveDecrypt(ctxCancel, session, "x", new(bfv.Ciphertext)))
+	})
+}
+
+func rlweCiphertext(ct interface{}) *rlwe.Ciphertext {
+	switch ct := ct.(type) {
+	case *bfv.Ciphertext:
+		return ct.Ciphertext
+	default:
+		return ct.(*ckks.Ciphertext).Ciphertext
+	}
+}
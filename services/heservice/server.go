@@ -0,0 +1,548 @@
+package heservice
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// DefaultMaxObjectSize is the default maximum size in bytes of an object uploaded to a Server.
+const DefaultMaxObjectSize = 1 << 30
+
+// Server is the reference server of the service. It serves the RPCs over HTTP as an http.Handler, e.g. with
+// http.ListenAndServe, and keeps the sessions in memory. It is safe for concurrent use, the evaluations and the
+// aggregations of the shares of a session being serialized.
+type Server struct {
+	// MaxObjectSize is the maximum size in bytes of an uploaded object, i.e. of a ciphertext, a share, the
+	// relinearization key or a switching key of the rotation keys.
+	MaxObjectSize int64
+	// Metrics records the operations of the evaluators of the sessions and of the aggregations of the decryption
+	// shares, if not nil, e.g. a *metrics.Registry of the services/metrics package.
+	Metrics rlwe.Metrics
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	circuits map[string]Circuit
+}
+
+// session is a session of the Server.
+type session struct {
+	mu      sync.Mutex
+	params  *parameters
+	parties int
+
+	evk      rlwe.EvaluationKey
+	evalBFV  bfv.Evaluator
+	evalCKKS ckks.Evaluator
+
+	ciphertexts map[string][]byte
+	decryptions map[string]*decryption
+}
+
+// decryption is the collective decryption of a ciphertext of a session, which is complete when the shares of all the
+// parties are aggregated.
+type decryption struct {
+	shares map[int]bool // the parties whose shares are aggregated
+	share  *drlwe.CKSShare
+	done   chan struct{}
+	result []byte
+}
+
+// NewServer creates a new Server with the DefaultCircuits.
+func NewServer() *Server {
+	s := &Server{
+		MaxObjectSize: DefaultMaxObjectSize,
+		sessions:      make(map[string]*session),
+		circuits:      make(map[string]Circuit),
+	}
+	for name, c := range DefaultCircuits {
+		s.circuits[name] = c
+	}
+	return s
+}
+
+// RegisterCircuit registers the circuit under the given name, replacing the circuit of the same name if any.
+func (s *Server) RegisterCircuit(name string, c Circuit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.circuits[name] = c
+}
+
+// CreateSession creates a new session.
+func (s *Server) CreateSession(req CreateSessionRequest) (res CreateSessionResponse, err error) {
+
+	if req.Parties < 1 {
+		return res, errors.New("cannot CreateSession: parties must be positive")
+	}
+
+	params, err := newParameters(req.Scheme, req.Params)
+	if err != nil {
+		return res, fmt.Errorf("cannot CreateSession: %w", err)
+	}
+
+	var id [16]byte
+	if _, err = rand.Read(id[:]); err != nil {
+		return res, fmt.Errorf("cannot CreateSession: %w", err)
+	}
+	res.Session = hex.EncodeToString(id[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[res.Session] = &session{
+		params:      params,
+		parties:     req.Parties,
+		ciphertexts: make(map[string][]byte),
+		decryptions: make(map[string]*decryption),
+	}
+
+	return res, nil
+}
+
+// UploadRelinearizationKey reads the marshaled relinearization key of the session from r.
+func (s *Server) UploadRelinearizationKey(sessionID string, r io.Reader) (err error) {
+
+	sess, err := s.session(sessionID)
+	if err != nil {
+		return fmt.Errorf("cannot UploadRelinearizationKey: %w", err)
+	}
+
+	data, err := s.readObject(r)
+	if err != nil {
+		return fmt.Errorf("cannot UploadRelinearizationKey: %w", err)
+	}
+
+	rlk := new(rlwe.RelinearizationKey)
+	if err = rlk.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("cannot UploadRelinearizationKey: %w", err)
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.evk.Rlk = rlk
+	sess.evalBFV, sess.evalCKKS = nil, nil
+	return nil
+}
+
+// UploadRotationKeys reads rotation keys of the session from r, in the framing of WriteRotationKeys, and adds them to
+// the rotation keys of the session.
+func (s *Server) UploadRotationKeys(sessionID string, r io.Reader) (err error) {
+
+	sess, err := s.session(sessionID)
+	if err != nil {
+		return fmt.Errorf("cannot UploadRotationKeys: %w", err)
+	}
+
+	rtks := new(rlwe.RotationKeySet)
+	if err = ReadRotationKeys(r, s.MaxObjectSize, rtks); err != nil {
+		return fmt.Errorf("cannot UploadRotationKeys: %w", err)
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.evk.Rtks == nil {
+		sess.evk.Rtks = rtks
+	} else {
+		for galEl, swk := range rtks.Keys {
+			sess.evk.Rtks.Keys[galEl] = swk
+		}
+	}
+	sess.evalBFV, sess.evalCKKS = nil, nil
+	return nil
+}
+
+// UploadCiphertext reads the marshaled ciphertext of the given name of the session from r, replacing the ciphertext of
+// the same name if any.
+func (s *Server) UploadCiphertext(sessionID, name string, r io.Reader) (err error) {
+
+	sess, err := s.session(sessionID)
+	if err != nil {
+		return fmt.Errorf("cannot UploadCiphertext: %w", err)
+	}
+
+	if name == "" {
+		return errors.New("cannot UploadCiphertext: empty name")
+	}
+
+	data, err := s.readObject(r)
+	if err != nil {
+		return fmt.Errorf("cannot UploadCiphertext: %w", err)
+	}
+
+	if _, _, err = sess.params.decodeCiphertext(data); err != nil {
+		return fmt.Errorf("cannot UploadCiphertext: %w", err)
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if err = sess.setCiphertext(name, data); err != nil {
+		return fmt.Errorf("cannot UploadCiphertext: %w", err)
+	}
+	return nil
+}
+
+// Evaluate evaluates the circuit of the request on the input ciphertexts and stores the result as the output
+// ciphertext.
+func (s *Server) Evaluate(req EvaluateRequest) (err error) {
+
+	sess, err := s.session(req.Session)
+	if err != nil {
+		return fmt.Errorf("cannot Evaluate: %w", err)
+	}
+
+	s.mu.Lock()
+	c, ok := s.circuits[req.Circuit]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("cannot Evaluate: circuit %q: %w", req.Circuit, ErrNotFound)
+	}
+
+	if len(req.Inputs) == 0 || (c.Inputs != 0 && len(req.Inputs) != c.Inputs) {
+		return fmt.Errorf("cannot Evaluate: circuit %q called with %d inputs", req.Circuit, len(req.Inputs))
+	}
+
+	if req.Output == "" {
+		return errors.New("cannot Evaluate: empty output name")
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	cts := make([]interface{}, len(req.Inputs))
+	for i, name := range req.Inputs {
+		data, ok := sess.ciphertexts[name]
+		if !ok {
+			return fmt.Errorf("cannot Evaluate: ciphertext %q: %w", name, ErrNotFound)
+		}
+		if cts[i], _, err = sess.params.decodeCiphertext(data); err != nil {
+			return fmt.Errorf("cannot Evaluate: %w", err)
+		}
+	}
+
+	var data []byte
+	if err = catch(func() (err error) {
+		switch sess.params.scheme {
+		case "bfv":
+			if c.BFV == nil {
+				return errors.New("circuit does not support the BFV scheme")
+			}
+			if sess.evalBFV == nil {
+				sess.evalBFV = bfv.NewEvaluator(sess.params.bfv, sess.evk)
+				if s.Metrics != nil {
+					sess.evalBFV = bfv.NewMetricsEvaluator(sess.evalBFV, s.Metrics, nil)
+				}
+			}
+			inputs := make([]*bfv.Ciphertext, len(cts))
+			for i := range cts {
+				inputs[i] = cts[i].(*bfv.Ciphertext)
+			}
+			var ct *bfv.Ciphertext
+			if ct, err = c.BFV(sess.params.bfv, sess.evalBFV, inputs); err != nil {
+				return err
+			}
+			data, err = ct.MarshalBinary()
+		default:
+			if c.CKKS == nil {
+				return errors.New("circuit does not support the CKKS scheme")
+			}
+			if sess.evalCKKS == nil {
+				sess.evalCKKS = ckks.NewEvaluator(sess.params.ckks, sess.evk)
+				if s.Metrics != nil {
+					params := sess.params.ckks
+					sess.evalCKKS = ckks.NewMetricsEvaluator(sess.evalCKKS, s.Metrics, func(ct *ckks.Ciphertext) float64 {
+						return ckks.ModulusBudget(params, ct)
+					})
+				}
+			}
+			inputs := make([]*ckks.Ciphertext, len(cts))
+			for i := range cts {
+				inputs[i] = cts[i].(*ckks.Ciphertext)
+			}
+			var ct *ckks.Ciphertext
+			if ct, err = c.CKKS(sess.params.ckks, sess.evalCKKS, inputs); err != nil {
+				return err
+			}
+			data, err = ct.MarshalBinary()
+		}
+		return
+	}); err != nil {
+		return fmt.Errorf("cannot Evaluate: circuit %q: %w", req.Circuit, err)
+	}
+
+	if err = sess.setCiphertext(req.Output, data); err != nil {
+		return fmt.Errorf("cannot Evaluate: %w", err)
+	}
+	return nil
+}
+
+// DownloadCiphertext returns the marshaled ciphertext of the request.
+func (s *Server) DownloadCiphertext(req ObjectRequest) ([]byte, error) {
+
+	sess, err := s.session(req.Session)
+	if err != nil {
+		return nil, fmt.Errorf("cannot DownloadCiphertext: %w", err)
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	data, ok := sess.ciphertexts[req.Name]
+	if !ok {
+		return nil, fmt.Errorf("cannot DownloadCiphertext: ciphertext %q: %w", req.Name, ErrNotFound)
+	}
+	return data, nil
+}
+
+// SubmitDecryptionShare reads the marshaled CKS share of the party from r, for the collective decryption of the
+// ciphertext of the given name of the session, i.e. its key switch to the zero key, and aggregates it. The parties of
+// a session are identified by 1 to its number of parties, and the share of each party is aggregated once: it returns
+// an error for an unknown party or a party whose share is already aggregated. The decryption is complete when the
+// shares of all the parties of the session are aggregated.
+func (s *Server) SubmitDecryptionShare(sessionID, name string, party int, r io.Reader) (err error) {
+
+	sess, err := s.session(sessionID)
+	if err != nil {
+		return fmt.Errorf("cannot SubmitDecryptionShare: %w", err)
+	}
+
+	if party < 1 || party > sess.parties {
+		return fmt.Errorf("cannot SubmitDecryptionShare: unknown party %d", party)
+	}
+
+	data, err := s.readObject(r)
+	if err != nil {
+		return fmt.Errorf("cannot SubmitDecryptionShare: %w", err)
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	ctData, ok := sess.ciphertexts[name]
+	if !ok {
+		return fmt.Errorf("cannot SubmitDecryptionShare: ciphertext %q: %w", name, ErrNotFound)
+	}
+
+	ct, ctRLWE, err := sess.params.decodeCiphertext(ctData)
+	if err != nil {
+		return fmt.Errorf("cannot SubmitDecryptionShare: %w", err)
+	}
+
+	share := new(drlwe.CKSShare)
+	if err = share.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("cannot SubmitDecryptionShare: %w", err)
+	}
+
+	if share.Value.Level() != ctRLWE.Level() || share.Value.Degree() != sess.params.rlwe.N() {
+		return fmt.Errorf("cannot SubmitDecryptionShare: share does not match the ciphertext %q", name)
+	}
+
+	dec := sess.decryption(name)
+	if len(dec.shares) == sess.parties {
+		return fmt.Errorf("cannot SubmitDecryptionShare: the decryption of %q is complete", name)
+	}
+
+	if dec.shares[party] {
+		return fmt.Errorf("cannot SubmitDecryptionShare: the share of party %d for %q is already aggregated", party, name)
+	}
+
+	cks := drlwe.NewCKSProtocol(sess.params.rlwe, sess.params.rlwe.Sigma(), drlwe.WithMetrics(s.Metrics))
+	if dec.share == nil {
+		dec.share = share
+	} else {
+		cks.AggregateShare(dec.share, share, dec.share)
+	}
+	dec.shares[party] = true
+
+	if len(dec.shares) == sess.parties {
+		cks.KeySwitch(ctRLWE, dec.share, ctRLWE)
+		if dec.result, err = ct.(encoding.BinaryMarshaler).MarshalBinary(); err != nil {
+			return fmt.Errorf("cannot SubmitDecryptionShare: %w", err)
+		}
+		close(dec.done)
+	}
+
+	return nil
+}
+
+// CollectiveDecrypt waits until the collective decryption of the ciphertext of the request is complete or ctx is done,
+// and returns the marshaled ciphertext switched to the zero key, which is decrypted with the zero secret key.
+func (s *Server) CollectiveDecrypt(ctx context.Context, req ObjectRequest) ([]byte, error) {
+
+	sess, err := s.session(req.Session)
+	if err != nil {
+		return nil, fmt.Errorf("cannot CollectiveDecrypt: %w", err)
+	}
+
+	sess.mu.Lock()
+	if _, ok := sess.ciphertexts[req.Name]; !ok {
+		sess.mu.Unlock()
+		return nil, fmt.Errorf("cannot CollectiveDecrypt: ciphertext %q: %w", req.Name, ErrNotFound)
+	}
+	dec := sess.decryption(req.Name)
+	sess.mu.Unlock()
+
+	select {
+	case <-dec.done:
+		return dec.result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("cannot CollectiveDecrypt: %w", ctx.Err())
+	}
+}
+
+// ServeHTTP serves the RPCs of the service.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, ServicePath) {
+		http.Error(w, "unknown RPC", http.StatusNotFound)
+		return
+	}
+
+	sessionID, name := r.Header.Get(SessionHeader), r.Header.Get(NameHeader)
+
+	var res interface{} = struct{}{}
+	var data []byte
+	var err error
+
+	switch method := strings.TrimPrefix(r.URL.Path, ServicePath); method {
+	case "CreateSession":
+		var req CreateSessionRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err == nil {
+			res, err = s.CreateSession(req)
+		}
+	case "UploadRelinearizationKey":
+		err = s.UploadRelinearizationKey(sessionID, r.Body)
+	case "UploadRotationKeys":
+		err = s.UploadRotationKeys(sessionID, r.Body)
+	case "UploadCiphertext":
+		err = s.UploadCiphertext(sessionID, name, r.Body)
+	case "Evaluate":
+		var req EvaluateRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err == nil {
+			err = s.Evaluate(req)
+		}
+	case "DownloadCiphertext", "CollectiveDecrypt":
+		var req ObjectRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err == nil {
+			if method == "DownloadCiphertext" {
+				data, err = s.DownloadCiphertext(req)
+			} else {
+				data, err = s.CollectiveDecrypt(r.Context(), req)
+			}
+		}
+	case "SubmitDecryptionShare":
+		var party int
+		if party, err = strconv.Atoi(r.Header.Get(PartyHeader)); err != nil {
+			err = fmt.Errorf("cannot SubmitDecryptionShare: invalid party: %w", err)
+		} else {
+			err = s.SubmitDecryptionShare(sessionID, name, party, r.Body)
+		}
+	default:
+		http.Error(w, fmt.Sprintf("unknown RPC %q", method), http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case data != nil:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(data)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+// session returns the session of the given identifier.
+func (s *Server) session(id string) (*session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session %q: %w", id, ErrNotFound)
+	}
+	return sess, nil
+}
+
+// readObject reads an uploaded object from r.
+func (s *Server) readObject(r io.Reader) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, s.MaxObjectSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.MaxObjectSize {
+		return nil, fmt.Errorf("object larger than %d bytes", s.MaxObjectSize)
+	}
+	return data, nil
+}
+
+// setCiphertext stores the ciphertext under the given name and resets its collective decryption. It returns an error if
+// the decryption of the previous ciphertext of the same name is in progress.
+func (sess *session) setCiphertext(name string, data []byte) error {
+	if dec, ok := sess.decryptions[name]; ok {
+		if len(dec.shares) > 0 && len(dec.shares) < sess.parties {
+			return fmt.Errorf("the decryption of %q is in progress", name)
+		}
+		// The calls of CollectiveDecrypt waiting for a decryption without shares wait for the new ciphertext
+		if len(dec.shares) == sess.parties {
+			delete(sess.decryptions, name)
+		}
+	}
+	sess.ciphertexts[name] = data
+	return nil
+}
+
+// decryption returns the collective decryption of the ciphertext of the given name, which is created if needed.
+func (sess *session) decryption(name string) *decryption {
+	dec, ok := sess.decryptions[name]
+	if !ok {
+		dec = &decryption{shares: make(map[int]bool), done: make(chan struct{})}
+		sess.decryptions[name] = dec
+	}
+	return dec
+}
+
+// decodeCiphertext returns the marshaled ciphertext of the scheme of the parameters, as a *bfv.Ciphertext or a
+// *ckks.Ciphertext, with its rlwe.Ciphertext.
+func (params *parameters) decodeCiphertext(data []byte) (ct interface{}, ctRLWE *rlwe.Ciphertext, err error) {
+	switch params.scheme {
+	case "bfv":
+		ctBFV := new(bfv.Ciphertext)
+		if err = ctBFV.UnmarshalBinary(data); err != nil {
+			return nil, nil, err
+		}
+		return ctBFV, ctBFV.Ciphertext, nil
+	default:
+		ctCKKS := new(ckks.Ciphertext)
+		if err = ctCKKS.UnmarshalBinary(data); err != nil {
+			return nil, nil, err
+		}
+		return ctCKKS, ctCKKS.Ciphertext, nil
+	}
+}
+
+// catch calls f and returns its error, or the panic of f as an error, e.g. the panic of an evaluator without the
+// evaluation keys of a circuit.
+func catch(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,215 @@
+// Package metrics implements a registry of the metrics emitted by the instrumented evaluators and protocols of the
+// library (see rlwe.Metrics), which serves them over HTTP in the Prometheus text exposition format, also read by the
+// OpenMetrics scrapers, e.g. on the /metrics path of a service. It does not depend on the Prometheus client library:
+// the applications already using it can implement rlwe.Metrics with their own collectors instead.
+//
+// The registry exports the following metrics:
+//
+//	lattigo_operations_total{component, operation}              counter of the operations
+//	lattigo_operation_duration_seconds{component, operation}    histogram of the durations of the operations
+//	lattigo_key_size_bytes{component, kind}                     histogram of the sizes of the keys and shares
+//	lattigo_noise_budget_bits{component, operation}             histogram of the noise budgets of the outputs
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDurationBuckets are the upper bounds in seconds of the buckets of the durations, from 10µs to about 40s.
+var DefaultDurationBuckets = exponentialBuckets(1e-5, 4, 12)
+
+// DefaultSizeBuckets are the upper bounds in bytes of the buckets of the sizes of the keys and shares, from 1KiB to
+// 1GiB.
+var DefaultSizeBuckets = exponentialBuckets(1<<10, 4, 11)
+
+// DefaultBudgetBuckets are the upper bounds in bits of the buckets of the noise budgets, from 0 to 200 bits.
+var DefaultBudgetBuckets = linearBuckets(0, 10, 21)
+
+// Registry is an rlwe.Metrics which aggregates the metrics in memory and serves them over HTTP. It is safe for
+// concurrent use.
+type Registry struct {
+	durationBuckets []float64
+	sizeBuckets     []float64
+	budgetBuckets   []float64
+
+	mu         sync.Mutex
+	operations map[[2]string]*histogram
+	sizes      map[[2]string]*histogram
+	budgets    map[[2]string]*histogram
+}
+
+// NewRegistry creates a new Registry with the default buckets.
+func NewRegistry() *Registry {
+	return NewRegistryWithBuckets(DefaultDurationBuckets, DefaultSizeBuckets, DefaultBudgetBuckets)
+}
+
+// NewRegistryWithBuckets creates a new Registry with the given upper bounds of the buckets of the durations in
+// seconds, of the sizes in bytes and of the noise budgets in bits, which must be sorted in increasing order.
+func NewRegistryWithBuckets(durations, sizes, budgets []float64) *Registry {
+	return &Registry{
+		durationBuckets: durations,
+		sizeBuckets:     sizes,
+		budgetBuckets:   budgets,
+		operations:      make(map[[2]string]*histogram),
+		sizes:           make(map[[2]string]*histogram),
+		budgets:         make(map[[2]string]*histogram),
+	}
+}
+
+// ObserveOperation records an operation of the component and its duration.
+func (r *Registry) ObserveOperation(component, operation string, duration time.Duration) {
+	r.observe(r.operations, r.durationBuckets, [2]string{component, operation}, duration.Seconds())
+}
+
+// ObserveKeySize records the size in bytes of a key or a share of the given kind generated by the component.
+func (r *Registry) ObserveKeySize(component, kind string, size int) {
+	r.observe(r.sizes, r.sizeBuckets, [2]string{component, kind}, float64(size))
+}
+
+// ObserveNoiseBudget records the noise budget in bits of the output ciphertext of an operation of the component.
+func (r *Registry) ObserveNoiseBudget(component, operation string, bits float64) {
+	r.observe(r.budgets, r.budgetBuckets, [2]string{component, operation}, bits)
+}
+
+func (r *Registry) observe(series map[[2]string]*histogram, buckets []float64, labels [2]string, value float64) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := series[labels]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		series[labels] = h
+	}
+
+	for i, bound := range buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// WriteTo writes the metrics on w in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (n int64, err error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	writeCounter(cw, "lattigo_operations_total", "Number of operations of the instrumented components.",
+		[2]string{"component", "operation"}, r.operations)
+	writeHistogram(cw, "lattigo_operation_duration_seconds", "Durations of the operations of the instrumented components.",
+		[2]string{"component", "operation"}, r.durationBuckets, r.operations)
+	writeHistogram(cw, "lattigo_key_size_bytes", "Sizes of the binary serializations of the keys and shares generated by the instrumented components.",
+		[2]string{"component", "kind"}, r.sizeBuckets, r.sizes)
+	writeHistogram(cw, "lattigo_noise_budget_bits", "Noise budgets of the output ciphertexts of the operations of the instrumented components.",
+		[2]string{"component", "operation"}, r.budgetBuckets, r.budgets)
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// histogram is a histogram of the observations of a series, whose counts are cumulative.
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// countingWriter is a writer that counts the bytes written and records the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) printf(format string, args ...interface{}) {
+	if cw.err != nil {
+		return
+	}
+	var n int
+	n, cw.err = fmt.Fprintf(cw.w, format, args...)
+	cw.n += int64(n)
+}
+
+// sortedLabels returns the labels of the series in increasing order.
+func sortedLabels(series map[[2]string]*histogram) (labels [][2]string) {
+	labels = make([][2]string, 0, len(series))
+	for l := range series {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i][0] != labels[j][0] {
+			return labels[i][0] < labels[j][0]
+		}
+		return labels[i][1] < labels[j][1]
+	})
+	return
+}
+
+func writeCounter(cw *countingWriter, name, help string, names [2]string, series map[[2]string]*histogram) {
+	cw.printf("# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, l := range sortedLabels(series) {
+		cw.printf("%s{%s=\"%s\",%s=\"%s\"} %d\n", name, names[0], escape(l[0]), names[1], escape(l[1]), series[l].count)
+	}
+}
+
+func writeHistogram(cw *countingWriter, name, help string, names [2]string, buckets []float64, series map[[2]string]*histogram) {
+	cw.printf("# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, l := range sortedLabels(series) {
+		h := series[l]
+		labels := fmt.Sprintf("%s=\"%s\",%s=\"%s\"", names[0], escape(l[0]), names[1], escape(l[1]))
+		for i, bound := range buckets {
+			cw.printf("%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(bound), h.counts[i])
+		}
+		cw.printf("%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		cw.printf("%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+		cw.printf("%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+// escape escapes the backslashes, double quotes and line feeds of a label value.
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func exponentialBuckets(start, factor float64, count int) (buckets []float64) {
+	buckets = make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return
+}
+
+func linearBuckets(start, width float64, count int) (buckets []float64) {
+	buckets = make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start + float64(i)*width
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+
+	var _ rlwe.Metrics = new(Registry)
+
+	registry := NewRegistryWithBuckets([]float64{0.001, 1}, []float64{1024}, []float64{10, 20})
+	registry.ObserveOperation("bfv.Evaluator", "MulNew", 10*time.Millisecond)
+	registry.ObserveOperation("bfv.Evaluator", "MulNew", 2*time.Second)
+	registry.ObserveOperation("bfv.Evaluator", "Add", time.Microsecond)
+	registry.ObserveKeySize("drlwe.CKGProtocol", "CKGShare", 2048)
+	registry.ObserveNoiseBudget("bfv.Evaluator", "MulNew", 15)
+	registry.ObserveOperation("a\"b", "c\\d\ne", time.Second)
+
+	t.Run("WriteTo", func(t *testing.T) {
+
+		var out strings.Builder
+		n, err := registry.WriteTo(&out)
+		require.NoError(t, err)
+		require.Equal(t, int64(out.Len()), n)
+
+		for _, line := range []string{
+			"# TYPE lattigo_operations_total counter",
+			`lattigo_operations_total{component="bfv.Evaluator",operation="MulNew"} 2`,
+			`lattigo_operations_total{component="bfv.Evaluator",operation="Add"} 1`,
+			`lattigo_operations_total{component="a\"b",operation="c\\d\ne"} 1`,
+			"# TYPE lattigo_operation_duration_seconds histogram",
+			`lattigo_operation_duration_seconds_bucket{component="bfv.Evaluator",operation="MulNew",le="0.001"} 0`,
+			`lattigo_operation_duration_seconds_bucket{component="bfv.Evaluator",operation="MulNew",le="1"} 1`,
+			`lattigo_operation_duration_seconds_bucket{component="bfv.Evaluator",operation="MulNew",le="+Inf"} 2`,
+			`lattigo_operation_duration_seconds_sum{component="bfv.Evaluator",operation="MulNew"} 2.01`,
+			`lattigo_operation_duration_seconds_count{component="bfv.Evaluator",operation="MulNew"} 2`,
+			`lattigo_key_size_bytes_bucket{component="drlwe.CKGProtocol",kind="CKGShare",le="1024"} 0`,
+			`lattigo_key_size_bytes_count{component="drlwe.CKGProtocol",kind="CKGShare"} 1`,
+			`lattigo_noise_budget_bits_bucket{component="bfv.Evaluator",operation="MulNew",le="10"} 0`,
+			`lattigo_noise_budget_bits_bucket{component="bfv.Evaluator",operation="MulNew",le="20"} 1`,
+			`lattigo_noise_budget_bits_sum{component="bfv.Evaluator",operation="MulNew"} 15`,
+		} {
+			require.Contains(t, strings.Split(out.String(), "\n"), line)
+		}
+
+		// The series are sorted by their labels
+		require.Less(t, strings.Index(out.String(), `operation="Add"} 1`), strings.Index(out.String(), `operation="MulNew"} 2`))
+	})
+
+	t.Run("ServeHTTP", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		registry.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		require.Equal(t, 200, rec.Code)
+		require.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+		require.Contains(t, rec.Body.String(), "lattigo_operations_total")
+	})
+}
@@ -0,0 +1,375 @@
+// Package cbor implements a compact encoding and decoding of the CBOR data items (RFC 8949) used by the CBOR
+// serialization of the public objects of Lattigo, e.g. the parameters, keys, ciphertexts and protocol shares.
+//
+// The objects are encoded as CBOR maps with text keys, which carry their "type" and the "version" of their schema, so
+// that they are self-describing and can be decoded by generic CBOR decoders in other languages. The decoders ignore the
+// unknown keys, so that new fields can be added to a schema without breaking the existing consumers. The vectors of
+// coefficients are encoded as typed arrays of little-endian uint64 (tag 71 of RFC 8746).
+//
+// The encoding is deterministic: the keys of the maps are sorted as in the core deterministic encoding of RFC 8949 and
+// the integers and lengths are encoded in their shortest form. Only the definite-length items are decoded.
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TagUint64LE is the tag of the typed arrays of little-endian uint64 of RFC 8746.
+const TagUint64LE = 71
+
+// maxDepth bounds the nesting depth of the decoded data items.
+const maxDepth = 64
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// Tag is a tagged data item.
+type Tag struct {
+	Number  uint64
+	Content interface{}
+}
+
+// Valuer is the interface implemented by the types that are encoded as a CBOR data item: CBORValue returns the value
+// that Marshal encodes in place of the receiver.
+type Valuer interface {
+	CBORValue() (interface{}, error)
+}
+
+// Marshal returns the CBOR encoding of v, which must be nil, a bool, an integer, a float64, a string, a []byte, a
+// []uint64 (encoded as a typed array), a []interface{}, a map[string]interface{}, a Tag or a Valuer, or a composition
+// of them.
+func Marshal(v interface{}) (data []byte, err error) {
+	var buf bytes.Buffer
+	if err = encode(&buf, v, 0); err != nil {
+		return nil, fmt.Errorf("cannot Marshal: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHead(buf *bytes.Buffer, major byte, v uint64) {
+	switch {
+	case v < 24:
+		buf.WriteByte(major<<5 | byte(v))
+	case v <= math.MaxUint8:
+		buf.Write([]byte{major<<5 | 24, byte(v)})
+	case v <= math.MaxUint16:
+		var b [3]byte
+		b[0] = major<<5 | 25
+		binary.BigEndian.PutUint16(b[1:], uint16(v))
+		buf.Write(b[:])
+	case v <= math.MaxUint32:
+		var b [5]byte
+		b[0] = major<<5 | 26
+		binary.BigEndian.PutUint32(b[1:], uint32(v))
+		buf.Write(b[:])
+	default:
+		var b [9]byte
+		b[0] = major<<5 | 27
+		binary.BigEndian.PutUint64(b[1:], v)
+		buf.Write(b[:])
+	}
+}
+
+func encode(buf *bytes.Buffer, v interface{}, depth int) (err error) {
+
+	if depth > maxDepth {
+		return errors.New("maximum nesting depth exceeded")
+	}
+
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(majorSimple<<5 | 22)
+	case bool:
+		if v {
+			buf.WriteByte(majorSimple<<5 | 21)
+		} else {
+			buf.WriteByte(majorSimple<<5 | 20)
+		}
+	case int:
+		return encode(buf, int64(v), depth)
+	case int64:
+		if v < 0 {
+			writeHead(buf, majorNegInt, uint64(-(v + 1)))
+		} else {
+			writeHead(buf, majorUint, uint64(v))
+		}
+	case uint64:
+		writeHead(buf, majorUint, v)
+	case float64:
+		var b [9]byte
+		b[0] = majorSimple<<5 | 27
+		binary.BigEndian.PutUint64(b[1:], math.Float64bits(v))
+		buf.Write(b[:])
+	case string:
+		writeHead(buf, majorText, uint64(len(v)))
+		buf.WriteString(v)
+	case []byte:
+		writeHead(buf, majorBytes, uint64(len(v)))
+		buf.Write(v)
+	case []uint64:
+		writeHead(buf, majorTag, TagUint64LE)
+		writeHead(buf, majorBytes, uint64(8*len(v)))
+		var b [8]byte
+		for _, c := range v {
+			binary.LittleEndian.PutUint64(b[:], c)
+			buf.Write(b[:])
+		}
+	case []interface{}:
+		writeHead(buf, majorArray, uint64(len(v)))
+		for _, e := range v {
+			if err = encode(buf, e, depth+1); err != nil {
+				return
+			}
+		}
+	case map[string]interface{}:
+		// The keys are sorted by the bytewise order of their encoding, i.e. by length and then lexicographically
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+		writeHead(buf, majorMap, uint64(len(v)))
+		for _, k := range keys {
+			writeHead(buf, majorText, uint64(len(k)))
+			buf.WriteString(k)
+			if err = encode(buf, v[k], depth+1); err != nil {
+				return
+			}
+		}
+	case Tag:
+		writeHead(buf, majorTag, v.Number)
+		return encode(buf, v.Content, depth+1)
+	case Valuer:
+		var value interface{}
+		if value, err = v.CBORValue(); err != nil {
+			return
+		}
+		return encode(buf, value, depth+1)
+	default:
+		return fmt.Errorf("unsupported type %T", v)
+	}
+
+	return nil
+}
+
+// Unmarshal decodes the CBOR data item of data, which must not have trailing bytes. The unsigned integers are decoded
+// as uint64, the negative integers as int64, the floating-point numbers as float64, the byte and text strings as []byte
+// and string, the arrays as []interface{}, the maps with text keys as map[string]interface{}, the typed arrays of
+// little-endian uint64 as []uint64 and the other tagged items as Tag.
+func Unmarshal(data []byte) (v interface{}, err error) {
+
+	d := decoder{data: data}
+	if v, err = d.decode(0); err != nil {
+		return nil, fmt.Errorf("cannot Unmarshal: %w", err)
+	}
+
+	if d.ptr != len(data) {
+		return nil, errors.New("cannot Unmarshal: trailing bytes")
+	}
+
+	return v, nil
+}
+
+type decoder struct {
+	data []byte
+	ptr  int
+}
+
+var errShortData = errors.New("data is too short")
+
+func (d *decoder) head() (major byte, info byte, v uint64, err error) {
+
+	if d.ptr >= len(d.data) {
+		return 0, 0, 0, errShortData
+	}
+
+	b := d.data[d.ptr]
+	d.ptr++
+	major, info = b>>5, b&0x1f
+
+	var n int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		n = 1
+	case info == 25:
+		n = 2
+	case info == 26:
+		n = 4
+	case info == 27:
+		n = 8
+	default:
+		return 0, 0, 0, errors.New("indefinite-length and reserved items are not supported")
+	}
+
+	if len(d.data)-d.ptr < n {
+		return 0, 0, 0, errShortData
+	}
+
+	for _, c := range d.data[d.ptr : d.ptr+n] {
+		v = v<<8 | uint64(c)
+	}
+	d.ptr += n
+
+	return major, info, v, nil
+}
+
+func (d *decoder) bytes(n uint64) ([]byte, error) {
+	if uint64(len(d.data)-d.ptr) < n {
+		return nil, errShortData
+	}
+	b := d.data[d.ptr : d.ptr+int(n)]
+	d.ptr += int(n)
+	return b, nil
+}
+
+func (d *decoder) decode(depth int) (v interface{}, err error) {
+
+	if depth > maxDepth {
+		return nil, errors.New("maximum nesting depth exceeded")
+	}
+
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUint:
+		return arg, nil
+
+	case majorNegInt:
+		if arg > math.MaxInt64 {
+			return nil, errors.New("negative integer overflows int64")
+		}
+		return -int64(arg) - 1, nil
+
+	case majorBytes:
+		b, err := d.bytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+
+	case majorText:
+		b, err := d.bytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+
+	case majorArray:
+		// Each element is at least one byte long
+		if arg > uint64(len(d.data)-d.ptr) {
+			return nil, errShortData
+		}
+		array := make([]interface{}, arg)
+		for i := range array {
+			if array[i], err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+
+	case majorMap:
+		if arg > uint64(len(d.data)-d.ptr)/2 {
+			return nil, errShortData
+		}
+		m := make(map[string]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key interface{}
+			if key, err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, errors.New("map keys must be text strings")
+			}
+			if _, ok := m[k]; ok {
+				return nil, fmt.Errorf("duplicated map key %q", k)
+			}
+			if m[k], err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+
+	case majorTag:
+		var content interface{}
+		if content, err = d.decode(depth + 1); err != nil {
+			return nil, err
+		}
+		if arg == TagUint64LE {
+			b, ok := content.([]byte)
+			if !ok || len(b)%8 != 0 {
+				return nil, errors.New("invalid typed array")
+			}
+			coeffs := make([]uint64, len(b)/8)
+			for i := range coeffs {
+				coeffs[i] = binary.LittleEndian.Uint64(b[8*i:])
+			}
+			return coeffs, nil
+		}
+		return Tag{Number: arg, Content: content}, nil
+
+	default:
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		case 25:
+			return halfToFloat64(uint16(arg)), nil
+		case 26:
+			return float64(math.Float32frombits(uint32(arg))), nil
+		case 27:
+			return math.Float64frombits(arg), nil
+		default:
+			return nil, fmt.Errorf("unsupported simple value %d", arg)
+		}
+	}
+}
+
+// halfToFloat64 converts an IEEE 754 half-precision number to a float64.
+func halfToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
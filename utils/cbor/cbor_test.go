@@ -0,0 +1,95 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBOR(t *testing.T) {
+
+	t.Run("Encoding", func(t *testing.T) {
+		// Test vectors of Appendix A of RFC 8949
+		for _, tc := range []struct {
+			v    interface{}
+			want string
+		}{
+			{uint64(0), "00"},
+			{uint64(23), "17"},
+			{uint64(24), "1818"},
+			{uint64(1000000), "1a000f4240"},
+			{uint64(math.MaxUint64), "1bffffffffffffffff"},
+			{-1, "20"},
+			{int64(-1000), "3903e7"},
+			{1.1, "fb3ff199999999999a"},
+			{false, "f4"},
+			{nil, "f6"},
+			{"IETF", "6449455446"},
+			{[]byte{1, 2, 3, 4}, "4401020304"},
+			{[]interface{}{uint64(1), []interface{}{uint64(2), uint64(3)}}, "8201820203"},
+			{map[string]interface{}{"b": uint64(1), "a": uint64(2), "aa": uint64(3)}, "a361610261620162616103"},
+			{[]uint64{1}, "d847480100000000000000"},
+		} {
+			data, err := Marshal(tc.v)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, hex.EncodeToString(data))
+
+			v, err := Unmarshal(data)
+			require.NoError(t, err)
+			if i, ok := tc.v.(int); ok {
+				require.Equal(t, int64(i), v)
+			} else {
+				require.Equal(t, tc.v, v)
+			}
+		}
+	})
+
+	t.Run("Decoding", func(t *testing.T) {
+		for _, tc := range []struct {
+			data string
+			want interface{}
+		}{
+			{"f93e00", 1.5},
+			{"fa47c35000", 100000.0},
+			{"c11a514b67b0", Tag{Number: 1, Content: uint64(1363896240)}},
+		} {
+			data, err := hex.DecodeString(tc.data)
+			require.NoError(t, err)
+			v, err := Unmarshal(data)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, v)
+		}
+
+		// Truncated items, indefinite lengths, trailing bytes, duplicated keys and non-text keys are rejected
+		for _, s := range []string{"", "19", "4401", "5f4101ff", "0000", "a2616101616102", "a10101"} {
+			data, _ := hex.DecodeString(s)
+			_, err := Unmarshal(data)
+			require.Error(t, err, s)
+		}
+	})
+
+	t.Run("Object", func(t *testing.T) {
+		m := NewObject("test.Object", 2)
+		m["n"] = uint64(3)
+		data, err := Marshal(m)
+		require.NoError(t, err)
+
+		o, err := DecodeObject(data, "test.Object", 2)
+		require.NoError(t, err)
+		n, err := o.Int("n")
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		_, err = o.String("n")
+		require.Error(t, err)
+		_, err = o.Value("missing")
+		require.Error(t, err)
+
+		// Another type or a newer version of the schema
+		_, err = DecodeObject(data, "test.Other", 2)
+		require.Error(t, err)
+		_, err = DecodeObject(data, "test.Object", 1)
+		require.Error(t, err)
+	})
+}
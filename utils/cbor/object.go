@@ -0,0 +1,219 @@
+package cbor
+
+import (
+	"fmt"
+	"math"
+)
+
+// Object is the decoded CBOR map of an object, whose accessors return an error if a key is missing or if its value is
+// not of the expected type.
+type Object map[string]interface{}
+
+// NewObject returns the map of an object of the given type and schema version, to which the fields of the object are
+// added before it is encoded.
+func NewObject(typ string, version uint64) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "version": version}
+}
+
+// DecodeObject decodes the CBOR data item of data as an object of the given type, whose schema version must be at most
+// version.
+func DecodeObject(data []byte, typ string, version uint64) (Object, error) {
+	v, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return AsTypedObject(v, typ, version)
+}
+
+// AsTypedObject returns the decoded value v as an object of the given type, whose schema version must be at most
+// version.
+func AsTypedObject(v interface{}, typ string, version uint64) (Object, error) {
+
+	o, err := AsObject(v)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := o.String("type")
+	if err != nil {
+		return nil, err
+	}
+
+	if t != typ {
+		return nil, fmt.Errorf("object of type %q instead of %q", t, typ)
+	}
+
+	ver, err := o.Uint("version")
+	if err != nil {
+		return nil, err
+	}
+
+	if ver == 0 || ver > version {
+		return nil, fmt.Errorf("version %d of the type %q is not supported (current is %d)", ver, typ, version)
+	}
+
+	return o, nil
+}
+
+// AsObject returns the decoded value v as an object, e.g. a nested object without type.
+func AsObject(v interface{}) (Object, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a map, got %T", v)
+	}
+	return Object(m), nil
+}
+
+// Has returns true if the object has the key.
+func (o Object) Has(key string) bool {
+	_, ok := o[key]
+	return ok
+}
+
+// Value returns the value of the key.
+func (o Object) Value(key string) (interface{}, error) {
+	v, ok := o[key]
+	if !ok {
+		return nil, fmt.Errorf("missing key %q", key)
+	}
+	return v, nil
+}
+
+// Uint returns the value of the key as an unsigned integer.
+func (o Object) Uint(key string) (uint64, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return 0, err
+	}
+	u, ok := v.(uint64)
+	if !ok {
+		return 0, fmt.Errorf("key %q: expected an unsigned integer, got %T", key, v)
+	}
+	return u, nil
+}
+
+// Int returns the value of the key as an int.
+func (o Object) Int(key string) (int, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return 0, err
+	}
+	switch v := v.(type) {
+	case uint64:
+		if v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case int64:
+		if v >= math.MinInt32 {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("key %q: expected a small integer, got %v", key, v)
+}
+
+// Bool returns the value of the key as a bool.
+func (o Object) Bool(key string) (bool, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("key %q: expected a bool, got %T", key, v)
+	}
+	return b, nil
+}
+
+// Float returns the value of the key as a float64. The integers are converted to float64.
+func (o Object) Float(key string) (float64, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return 0, err
+	}
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case uint64:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("key %q: expected a number, got %T", key, v)
+}
+
+// String returns the value of the key as a string.
+func (o Object) String(key string) (string, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("key %q: expected a text string, got %T", key, v)
+	}
+	return s, nil
+}
+
+// Bytes returns the value of the key as a byte string.
+func (o Object) Bytes(key string) ([]byte, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("key %q: expected a byte string, got %T", key, v)
+	}
+	return b, nil
+}
+
+// Uint64s returns the value of the key as a typed array of uint64.
+func (o Object) Uint64s(key string) ([]uint64, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return nil, err
+	}
+	u, ok := v.([]uint64)
+	if !ok {
+		return nil, fmt.Errorf("key %q: expected a typed array of uint64, got %T", key, v)
+	}
+	return u, nil
+}
+
+// Array returns the value of the key as an array.
+func (o Object) Array(key string) ([]interface{}, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return nil, err
+	}
+	return AsArray(v)
+}
+
+// Object returns the value of the key as a nested object.
+func (o Object) Object(key string) (Object, error) {
+	v, err := o.Value(key)
+	if err != nil {
+		return nil, err
+	}
+	return AsObject(v)
+}
+
+// AsArray returns the decoded value v as an array.
+func AsArray(v interface{}) ([]interface{}, error) {
+	a, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+	return a, nil
+}
+
+// Retype returns a shallow copy of the object with the given type and schema version, so that the fields of an embedded
+// type can be decoded by the decoder of this type.
+func (o Object) Retype(typ string, version uint64) map[string]interface{} {
+	m := make(map[string]interface{}, len(o))
+	for k, v := range o {
+		m[k] = v
+	}
+	m["type"], m["version"] = typ, version
+	return m
+}
@@ -0,0 +1,328 @@
+// Command wasm is a thin WebAssembly wrapper of the client side of the schemes and of the multiparty protocols, so that
+// browsers can act as input parties: it encodes, encrypts and decrypts values and generates the shares of the parties
+// in the CKG, RKG, RTG, CKS and PCKS protocols of the drlwe package, while the servers aggregate the shares and
+// evaluate the circuits. The tree has no assembly and builds as is for GOOS=js GOARCH=wasm, see `make test_wasm`.
+//
+// Usage:
+//
+//	GOOS=js GOARCH=wasm go build -o lattigo.wasm ./wasm
+//	cp "$(go env GOROOT)/misc/wasm/wasm_exec.js" .   # lib/wasm since Go 1.24
+//
+// The module registers the global function lattigoNewClient(scheme, paramsJSON) in the JavaScript runtime, see main.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Client is the client of an input party: it holds the secret key share of the party, encodes, encrypts and decrypts
+// the values of the party, and generates its shares of the multiparty protocols. The aggregation of the shares and
+// the evaluation are left to the servers. The objects are exchanged as the byte slices of their MarshalBinary methods.
+//
+// To reduce the memory footprint of the client, the encoder, encryptor and decryptor are only allocated at their
+// first use, no evaluator is allocated, and the shares are generated sequentially by the calling goroutine.
+type Client struct {
+	scheme string
+	params rlwe.Parameters
+
+	paramsBFV  bfv.Parameters
+	paramsCKKS ckks.Parameters
+
+	sk    *rlwe.SecretKey
+	ephSk *rlwe.SecretKey
+
+	encoderBFV  bfv.Encoder
+	encoderCKKS ckks.Encoder
+}
+
+// NewClient creates a new Client for the scheme "bfv" or "ckks" and the parameters of this scheme marshaled in JSON.
+func NewClient(scheme string, paramsJSON []byte) (c *Client, err error) {
+
+	c = &Client{scheme: scheme}
+
+	switch scheme {
+	case "bfv":
+		if err = json.Unmarshal(paramsJSON, &c.paramsBFV); err != nil {
+			return nil, fmt.Errorf("cannot NewClient: %w", err)
+		}
+		c.params = c.paramsBFV.Parameters
+	case "ckks":
+		if err = json.Unmarshal(paramsJSON, &c.paramsCKKS); err != nil {
+			return nil, fmt.Errorf("cannot NewClient: %w", err)
+		}
+		c.params = c.paramsCKKS.Parameters
+	default:
+		return nil, fmt.Errorf("cannot NewClient: unknown scheme %q", scheme)
+	}
+
+	return c, nil
+}
+
+// GenSecretKey generates a fresh secret key share for the client and returns it marshaled.
+func (c *Client) GenSecretKey() ([]byte, error) {
+	c.sk = rlwe.NewKeyGenerator(c.params).GenSecretKey()
+	return c.sk.MarshalBinary()
+}
+
+// SetSecretKey sets the secret key share of the client to the marshaled secret key data.
+func (c *Client) SetSecretKey(data []byte) error {
+	sk := new(rlwe.SecretKey)
+	if err := sk.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("cannot SetSecretKey: %w", err)
+	}
+	c.sk = sk
+	return nil
+}
+
+// PublicKey returns the marshaled public key of the secret key share of the client, e.g. the target key of a PCKS
+// protocol whose output is decrypted by the client.
+func (c *Client) PublicKey() ([]byte, error) {
+	if c.sk == nil {
+		return nil, errors.New("cannot PublicKey: no secret key")
+	}
+	return rlwe.NewKeyGenerator(c.params).GenPublicKey(c.sk).MarshalBinary()
+}
+
+// Encrypt encodes and encrypts the values with the marshaled public key pk, e.g. the collective public key, and
+// returns the marshaled ciphertext. The BFV values are rounded to integers.
+func (c *Client) Encrypt(values []float64, pk []byte) ([]byte, error) {
+
+	key := new(rlwe.PublicKey)
+	if err := key.UnmarshalBinary(pk); err != nil {
+		return nil, fmt.Errorf("cannot Encrypt: %w", err)
+	}
+
+	switch c.scheme {
+	case "bfv":
+		if len(values) > c.paramsBFV.N() {
+			return nil, fmt.Errorf("cannot Encrypt: more than %d values", c.paramsBFV.N())
+		}
+		coeffs := make([]int64, len(values))
+		for i, v := range values {
+			coeffs[i] = int64(math.Round(v))
+		}
+		pt := bfv.NewPlaintext(c.paramsBFV)
+		c.bfvEncoder().EncodeInt(coeffs, pt)
+		return bfv.NewEncryptor(c.paramsBFV, key).EncryptNew(pt).MarshalBinary()
+	default:
+		if len(values) > c.paramsCKKS.Slots() {
+			return nil, fmt.Errorf("cannot Encrypt: more than %d values", c.paramsCKKS.Slots())
+		}
+		pt := c.ckksEncoder().EncodeNew(values, c.paramsCKKS.MaxLevel(), c.paramsCKKS.DefaultScale(), c.paramsCKKS.LogSlots())
+		return ckks.NewEncryptor(c.paramsCKKS, key).EncryptNew(pt).MarshalBinary()
+	}
+}
+
+// Decrypt decrypts the marshaled ciphertext ct with the secret key share of the client, e.g. the output of a PCKS
+// protocol to the public key of the client, and returns the decoded values. The imaginary parts of the CKKS values
+// are discarded.
+func (c *Client) Decrypt(ct []byte) (values []float64, err error) {
+
+	if c.sk == nil {
+		return nil, errors.New("cannot Decrypt: no secret key")
+	}
+
+	switch c.scheme {
+	case "bfv":
+		ciphertext := new(bfv.Ciphertext)
+		if err = ciphertext.UnmarshalBinary(ct); err != nil {
+			return nil, fmt.Errorf("cannot Decrypt: %w", err)
+		}
+		coeffs := c.bfvEncoder().DecodeIntNew(bfv.NewDecryptor(c.paramsBFV, c.sk).DecryptNew(ciphertext))
+		values = make([]float64, len(coeffs))
+		for i, v := range coeffs {
+			values[i] = float64(v)
+		}
+	default:
+		ciphertext := new(ckks.Ciphertext)
+		if err = ciphertext.UnmarshalBinary(ct); err != nil {
+			return nil, fmt.Errorf("cannot Decrypt: %w", err)
+		}
+		slots := c.ckksEncoder().Decode(ckks.NewDecryptor(c.paramsCKKS, c.sk).DecryptNew(ciphertext), c.paramsCKKS.LogSlots())
+		values = make([]float64, len(slots))
+		for i, v := range slots {
+			values[i] = real(v)
+		}
+	}
+
+	return values, nil
+}
+
+// GenCKGShare returns the marshaled share of the client in the CKG protocol, whose common reference polynomial is
+// sampled from the seed of the session.
+func (c *Client) GenCKGShare(seed []byte) ([]byte, error) {
+
+	crs, err := c.checkKeyAndSeed(seed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenCKGShare: %w", err)
+	}
+
+	ckg := drlwe.NewCKGProtocol(c.params)
+	share := ckg.AllocateShare()
+	ckg.GenShare(c.sk, ckg.SampleCRP(crs), share)
+	return share.MarshalBinary()
+}
+
+// GenRKGShareRoundOne returns the marshaled share of the client in the first round of the RKG protocol, whose common
+// reference polynomials are sampled from the seed of the session. The ephemeral secret key of the protocol is kept by
+// the client for the second round.
+func (c *Client) GenRKGShareRoundOne(seed []byte) ([]byte, error) {
+
+	crs, err := c.checkKeyAndSeed(seed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRKGShareRoundOne: %w", err)
+	}
+
+	rkg := drlwe.NewRKGProtocol(c.params)
+	ephSk, share, _ := rkg.AllocateShare()
+	rkg.GenShareRoundOne(c.sk, rkg.SampleCRP(crs), ephSk, share)
+	c.ephSk = ephSk
+	return share.MarshalBinary()
+}
+
+// GenRKGShareRoundTwo returns the marshaled share of the client in the second round of the RKG protocol, given the
+// marshaled aggregation of the shares of the first round.
+func (c *Client) GenRKGShareRoundTwo(round1 []byte) ([]byte, error) {
+
+	if c.sk == nil || c.ephSk == nil {
+		return nil, errors.New("cannot GenRKGShareRoundTwo: the first round was not run")
+	}
+
+	rkg := drlwe.NewRKGProtocol(c.params)
+	_, share1, share2 := rkg.AllocateShare()
+	if err := share1.UnmarshalBinary(round1); err != nil {
+		return nil, fmt.Errorf("cannot GenRKGShareRoundTwo: %w", err)
+	}
+
+	rkg.GenShareRoundTwo(c.ephSk, c.sk, share1, share2)
+	c.ephSk = nil
+	return share2.MarshalBinary()
+}
+
+// GenRTGShare returns the marshaled share of the client in the RTG protocol of the Galois element galEl, whose common
+// reference polynomials are sampled from the seed of the session.
+func (c *Client) GenRTGShare(galEl uint64, seed []byte) ([]byte, error) {
+
+	crs, err := c.checkKeyAndSeed(seed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRTGShare: %w", err)
+	}
+
+	rtg := drlwe.NewRTGProtocol(c.params)
+	share := rtg.AllocateShare()
+	rtg.GenShare(c.sk, galEl, rtg.SampleCRP(crs), share)
+	return share.MarshalBinary()
+}
+
+// GenCKSShare returns the marshaled share of the client in the CKS protocol from the collective secret key to the
+// zero key, i.e. in the collective decryption of the marshaled ciphertext ct, with a smudging noise of standard
+// deviation sigmaSmudging.
+func (c *Client) GenCKSShare(ct []byte, sigmaSmudging float64) ([]byte, error) {
+
+	c1, err := c.ciphertextC1(ct)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenCKSShare: %w", err)
+	}
+
+	if c.sk == nil {
+		return nil, errors.New("cannot GenCKSShare: no secret key")
+	}
+
+	cks := drlwe.NewCKSProtocol(c.params, sigmaSmudging)
+	share := cks.AllocateShare(c1.Level())
+	cks.GenShare(c.sk, rlwe.NewSecretKey(c.params), c1, share)
+	return share.MarshalBinary()
+}
+
+// GenPCKSShare returns the marshaled share of the client in the PCKS protocol from the collective secret key to the
+// marshaled public key pk of the marshaled ciphertext ct, with a smudging noise of standard deviation sigmaSmudging.
+func (c *Client) GenPCKSShare(pk, ct []byte, sigmaSmudging float64) ([]byte, error) {
+
+	c1, err := c.ciphertextC1(ct)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenPCKSShare: %w", err)
+	}
+
+	if c.sk == nil {
+		return nil, errors.New("cannot GenPCKSShare: no secret key")
+	}
+
+	key := new(rlwe.PublicKey)
+	if err = key.UnmarshalBinary(pk); err != nil {
+		return nil, fmt.Errorf("cannot GenPCKSShare: %w", err)
+	}
+
+	pcks := drlwe.NewPCKSProtocol(c.params, sigmaSmudging)
+	share := pcks.AllocateShare(c1.Level())
+	pcks.GenShare(c.sk, key, c1, share)
+	return share.MarshalBinary()
+}
+
+// checkKeyAndSeed checks that the client has a secret key and returns the CRS of the seed.
+func (c *Client) checkKeyAndSeed(seed []byte) (drlwe.CRS, error) {
+
+	if c.sk == nil {
+		return nil, errors.New("no secret key")
+	}
+
+	if len(seed) == 0 {
+		return nil, errors.New("empty seed")
+	}
+
+	prng, err := utils.NewKeyedPRNG(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return prng, nil
+}
+
+// ciphertextC1 returns the degree one polynomial of the marshaled ciphertext of the scheme of the client.
+func (c *Client) ciphertextC1(ct []byte) (*ring.Poly, error) {
+
+	var ciphertext *rlwe.Ciphertext
+	switch c.scheme {
+	case "bfv":
+		ctBFV := new(bfv.Ciphertext)
+		if err := ctBFV.UnmarshalBinary(ct); err != nil {
+			return nil, err
+		}
+		ciphertext = ctBFV.Ciphertext
+	default:
+		ctCKKS := new(ckks.Ciphertext)
+		if err := ctCKKS.UnmarshalBinary(ct); err != nil {
+			return nil, err
+		}
+		ciphertext = ctCKKS.Ciphertext
+	}
+
+	if ciphertext.Degree() != 1 {
+		return nil, fmt.Errorf("ciphertext of degree %d instead of 1", ciphertext.Degree())
+	}
+
+	return ciphertext.Value[1], nil
+}
+
+func (c *Client) bfvEncoder() bfv.Encoder {
+	if c.encoderBFV == nil {
+		c.encoderBFV = bfv.NewEncoder(c.paramsBFV)
+	}
+	return c.encoderBFV
+}
+
+func (c *Client) ckksEncoder() ckks.Encoder {
+	if c.encoderCKKS == nil {
+		c.encoderCKKS = ckks.NewEncoder(c.paramsCKKS)
+	}
+	return c.encoderCKKS
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient runs the client-side halves of the CKG and PCKS protocols with two clients, the aggregation being done as
+// by a server, and checks that the values encrypted under the collective public key are decrypted by the first client.
+func TestClient(t *testing.T) {
+
+	paramsBFV, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	require.NoError(t, err)
+	paramsCKKS, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		scheme string
+		params interface{}
+		delta  float64
+	}{
+		{"bfv", paramsBFV, 0},
+		{"ckks", paramsCKKS, 1e-3},
+	} {
+		t.Run(tc.scheme, func(t *testing.T) {
+
+			paramsJSON, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+
+			clients := make([]*Client, 2)
+			for i := range clients {
+				clients[i], err = NewClient(tc.scheme, paramsJSON)
+				require.NoError(t, err)
+				_, err = clients[i].GenSecretKey()
+				require.NoError(t, err)
+			}
+
+			params := clients[0].params
+			seed := []byte("wasm client test")
+
+			// Collective public key
+			ckg := drlwe.NewCKGProtocol(params)
+			prng, err := utils.NewKeyedPRNG(seed)
+			require.NoError(t, err)
+			crp := ckg.SampleCRP(prng)
+			shareCKG := ckg.AllocateShare()
+			for _, c := range clients {
+				data, err := c.GenCKGShare(seed)
+				require.NoError(t, err)
+				share := ckg.AllocateShare()
+				require.NoError(t, share.UnmarshalBinary(data))
+				ckg.AggregateShare(share, shareCKG, shareCKG)
+			}
+			cpk := rlwe.NewPublicKey(params)
+			ckg.GenPublicKey(shareCKG, crp, cpk)
+			cpkData, err := cpk.MarshalBinary()
+			require.NoError(t, err)
+
+			values := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+			ctData, err := clients[1].Encrypt(values, cpkData)
+			require.NoError(t, err)
+
+			// Key switch to the public key of the first client
+			pkData, err := clients[0].PublicKey()
+			require.NoError(t, err)
+			ct, err := clients[0].ciphertextC1(ctData)
+			require.NoError(t, err)
+			pcks := drlwe.NewPCKSProtocol(params, 3.2)
+			sharePCKS := pcks.AllocateShare(ct.Level())
+			for _, c := range clients {
+				data, err := c.GenPCKSShare(pkData, ctData, 3.2)
+				require.NoError(t, err)
+				share := pcks.AllocateShare(ct.Level())
+				require.NoError(t, share.UnmarshalBinary(data))
+				pcks.AggregateShare(share, sharePCKS, sharePCKS)
+			}
+
+			var ctOut []byte
+			switch tc.scheme {
+			case "bfv":
+				ciphertext := new(bfv.Ciphertext)
+				require.NoError(t, ciphertext.UnmarshalBinary(ctData))
+				pcks.KeySwitch(ciphertext.Ciphertext, sharePCKS, ciphertext.Ciphertext)
+				ctOut, err = ciphertext.MarshalBinary()
+			default:
+				ciphertext := new(ckks.Ciphertext)
+				require.NoError(t, ciphertext.UnmarshalBinary(ctData))
+				pcks.KeySwitch(ciphertext.Ciphertext, sharePCKS, ciphertext.Ciphertext)
+				ctOut, err = ciphertext.MarshalBinary()
+			}
+			require.NoError(t, err)
+
+			have, err := clients[0].Decrypt(ctOut)
+			require.NoError(t, err)
+			for i := range values {
+				require.InDelta(t, values[i], have[i], tc.delta)
+			}
+
+			_, err = clients[1].Decrypt(ctOut)
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := NewClient("bgv", []byte("{}"))
+		require.Error(t, err)
+
+		paramsJSON, err := json.Marshal(paramsBFV)
+		require.NoError(t, err)
+		c, err := NewClient("bfv", paramsJSON)
+		require.NoError(t, err)
+		_, err = c.GenCKGShare([]byte("seed"))
+		require.Error(t, err)
+		_, err = c.Decrypt(nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,130 @@
+//go:build js && wasm
+// +build js,wasm
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// The functions of the Client are exposed to JavaScript as the methods of the objects returned by the global function
+// lattigoNewClient(scheme, paramsJSON). The marshaled objects are Uint8Array, the values are arrays of numbers, and
+// the functions return an Error instead of throwing it if they fail, e.g.:
+//
+//	const client = lattigoNewClient("ckks", paramsJSON);
+//	client.genSecretKey();
+//	const share = client.genCKGShare(seed);
+//	const ct = client.encrypt([1.5, 2.5], collectivePk);
+//	if (ct instanceof Error) { ... }
+func main() {
+	js.Global().Set("lattigoNewClient", js.FuncOf(newClient))
+	// The functions are called by the JavaScript runtime while main is blocked
+	select {}
+}
+
+func newClient(this js.Value, args []js.Value) interface{} {
+
+	if len(args) != 2 {
+		return jsError("lattigoNewClient(scheme, paramsJSON): wrong number of arguments")
+	}
+
+	c, err := NewClient(args[0].String(), []byte(args[1].String()))
+	if err != nil {
+		return jsError(err.Error())
+	}
+
+	methods := map[string]func(args []js.Value) (interface{}, error){
+		"genSecretKey": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.GenSecretKey())
+		},
+		"setSecretKey": func(args []js.Value) (interface{}, error) {
+			return nil, c.SetSecretKey(bytesArg(args, 0))
+		},
+		"publicKey": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.PublicKey())
+		},
+		"encrypt": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.Encrypt(floatsArg(args, 0), bytesArg(args, 1)))
+		},
+		"decrypt": func(args []js.Value) (interface{}, error) {
+			values, err := c.Decrypt(bytesArg(args, 0))
+			if err != nil {
+				return nil, err
+			}
+			array := make([]interface{}, len(values))
+			for i := range values {
+				array[i] = values[i]
+			}
+			return array, nil
+		},
+		"genCKGShare": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.GenCKGShare(bytesArg(args, 0)))
+		},
+		"genRKGShareRoundOne": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.GenRKGShareRoundOne(bytesArg(args, 0)))
+		},
+		"genRKGShareRoundTwo": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.GenRKGShareRoundTwo(bytesArg(args, 0)))
+		},
+		"genRTGShare": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.GenRTGShare(uint64(args[0].Int()), bytesArg(args, 1)))
+		},
+		"genCKSShare": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.GenCKSShare(bytesArg(args, 0), args[1].Float()))
+		},
+		"genPCKSShare": func(args []js.Value) (interface{}, error) {
+			return bytesResult(c.GenPCKSShare(bytesArg(args, 0), bytesArg(args, 1), args[2].Float()))
+		},
+	}
+
+	obj := js.Global().Get("Object").New()
+	for name, method := range methods {
+		method := method
+		obj.Set(name, js.FuncOf(func(this js.Value, args []js.Value) (res interface{}) {
+			// The invalid arguments, e.g. a missing argument, panic in the accessors of js.Value
+			defer func() {
+				if r := recover(); r != nil {
+					res = jsError("invalid arguments")
+				}
+			}()
+			v, err := method(args)
+			if err != nil {
+				return jsError(err.Error())
+			}
+			return v
+		}))
+	}
+
+	return obj
+}
+
+// jsError returns a JavaScript Error with the given message.
+func jsError(msg string) js.Value {
+	return js.Global().Get("Error").New(msg)
+}
+
+// bytesArg returns the Uint8Array argument i as a byte slice.
+func bytesArg(args []js.Value, i int) []byte {
+	b := make([]byte, args[i].Get("length").Int())
+	js.CopyBytesToGo(b, args[i])
+	return b
+}
+
+// floatsArg returns the array of numbers argument i as a slice of float64.
+func floatsArg(args []js.Value, i int) []float64 {
+	values := make([]float64, args[i].Get("length").Int())
+	for j := range values {
+		values[j] = args[i].Index(j).Float()
+	}
+	return values
+}
+
+// bytesResult returns the byte slice b as a Uint8Array.
+func bytesResult(b []byte, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	array := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(array, b)
+	return array, nil
+}